@@ -0,0 +1,253 @@
+// Package admin serves a console of troubleshooting commands on a unix
+// socket. The socket is only reachable from the host of the server and does
+// not go through the HTTP API, so operators can inspect and steer a server
+// whose HTTP API is saturated.
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"go.uber.org/zap"
+)
+
+// Request runs a command of the console.
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// Response is the output of a command, or the error it failed with.
+type Response struct {
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Command is a command of the console.
+type Command struct {
+	Name string
+	// Usage shows the arguments of the command, such as "cancel <id>".
+	Usage string
+	Help  string
+	Run   func(ctx context.Context, w io.Writer, args []string) error
+}
+
+// Server serves the console on a unix socket.
+type Server struct {
+	log      *zap.Logger
+	path     string
+	commands map[string]Command
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	ln    net.Listener
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// NewServer returns a server of the commands listening on the socket at
+// path once opened. The help command is built in.
+func NewServer(log *zap.Logger, path string, commands ...Command) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		log:      log,
+		path:     path,
+		commands: make(map[string]Command),
+		ctx:      ctx,
+		cancel:   cancel,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	for _, c := range commands {
+		s.commands[c.Name] = c
+	}
+	s.commands["help"] = Command{
+		Name: "help",
+		Help: "list the commands",
+		Run:  s.help,
+	}
+	return s
+}
+
+// Open listens on the socket. A socket left behind by a server that did
+// not shut down cleanly is replaced, one in use is not.
+func (s *Server) Open() error {
+	if _, err := os.Stat(s.path); err == nil {
+		if conn, err := net.Dial("unix", s.path); err == nil {
+			conn.Close()
+			return fmt.Errorf("admin socket %s is in use by another process", s.path)
+		}
+		if err := os.Remove(s.path); err != nil {
+			return err
+		}
+	}
+
+	ln, err := net.Listen("unix", s.path)
+	if err != nil {
+		return err
+	}
+	// Only the user running the server may use the console.
+	if err := os.Chmod(s.path, 0600); err != nil {
+		ln.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	s.ln = ln
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.serve(ln)
+	}()
+	s.log.Info("Listening on admin socket", zap.String("path", s.path))
+	return nil
+}
+
+// Close stops listening, disconnects the consoles attached and removes the
+// socket.
+func (s *Server) Close() error {
+	s.cancel()
+
+	s.mu.Lock()
+	var err error
+	if s.ln != nil {
+		err = s.ln.Close()
+		s.ln = nil
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.log.Error("Failed to accept admin connection", zap.Error(err))
+			}
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// handle runs the requests of a console until it disconnects.
+func (s *Server) handle(conn net.Conn) {
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		var req Request
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		if err := enc.Encode(s.run(req)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) run(req Request) Response {
+	c, ok := s.commands[req.Command]
+	if !ok {
+		return Response{Error: fmt.Sprintf("unknown command %q, see help", req.Command)}
+	}
+
+	s.log.Info("Running admin command", zap.String("command", req.Command), zap.Strings("args", req.Args))
+	var out bytes.Buffer
+	if err := c.Run(s.ctx, &out, req.Args); err != nil {
+		return Response{Output: out.String(), Error: err.Error()}
+	}
+	return Response{Output: out.String()}
+}
+
+func (s *Server) help(_ context.Context, w io.Writer, _ []string) error {
+	names := make([]string, 0, len(s.commands))
+	for name := range s.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, name := range names {
+		c := s.commands[name]
+		usage := c.Usage
+		if usage == "" {
+			usage = c.Name
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", usage, c.Help)
+	}
+	return tw.Flush()
+}
+
+// Client runs commands on the console of a server.
+type Client struct {
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+}
+
+// Dial attaches to the console on the socket at path.
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, enc: json.NewEncoder(conn), dec: json.NewDecoder(conn)}, nil
+}
+
+// Run runs a command line such as "cancel 12" and returns its output.
+func (c *Client) Run(line string) (string, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	if err := c.enc.Encode(Request{Command: fields[0], Args: fields[1:]}); err != nil {
+		return "", err
+	}
+	var resp Response
+	if err := c.dec.Decode(&resp); err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return resp.Output, errors.New(resp.Error)
+	}
+	return resp.Output, nil
+}
+
+// Close detaches from the console.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
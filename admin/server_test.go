@@ -0,0 +1,73 @@
+package admin_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/admin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "influxd.sock")
+	s := admin.NewServer(zaptest.NewLogger(t), path,
+		admin.Command{
+			Name:  "echo",
+			Usage: "echo <words>",
+			Help:  "print the words",
+			Run: func(_ context.Context, w io.Writer, args []string) error {
+				_, err := fmt.Fprintln(w, args)
+				return err
+			},
+		},
+		admin.Command{
+			Name: "fail",
+			Help: "fail",
+			Run: func(context.Context, io.Writer, []string) error {
+				return errors.New("failed")
+			},
+		},
+	)
+	require.NoError(t, s.Open())
+	defer s.Close()
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	c, err := admin.Dial(path)
+	require.NoError(t, err)
+	defer c.Close()
+
+	out, err := c.Run("echo a b")
+	require.NoError(t, err)
+	require.Equal(t, "[a b]\n", out)
+
+	_, err = c.Run("fail")
+	require.EqualError(t, err, "failed")
+
+	_, err = c.Run("nope")
+	require.EqualError(t, err, `unknown command "nope", see help`)
+
+	out, err = c.Run("help")
+	require.NoError(t, err)
+	require.Regexp(t, `echo <words>\s+print the words`, out)
+
+	// A second server may not take over the socket in use.
+	require.Error(t, admin.NewServer(zaptest.NewLogger(t), path).Open())
+}
+
+func TestServer_StaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "influxd.sock")
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+
+	s := admin.NewServer(zaptest.NewLogger(t), path)
+	require.NoError(t, s.Open())
+	require.NoError(t, s.Close())
+}
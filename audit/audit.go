@@ -0,0 +1,220 @@
+// Package audit records the mutating calls made to the API, with the actor
+// making them, the resource and organization they change and their outcome.
+//
+// Events are chained to be tamper-evident: every event carries the hash of
+// the event recorded before it, and its own hash covers that link. Changing,
+// removing or reordering a recorded event breaks the chain, which Verify
+// detects. With a key the hashes are HMACs, so the chain cannot be rebuilt
+// by someone who can change the log but cannot read the key.
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Outcome is the outcome of an audited call.
+type Outcome string
+
+const (
+	// OutcomeSuccess is the outcome of a call answered with a 2xx or 3xx
+	// status.
+	OutcomeSuccess Outcome = "success"
+	// OutcomeFailure is the outcome of a call that was rejected or failed.
+	OutcomeFailure Outcome = "failure"
+)
+
+// Actor is who made an audited call.
+type Actor struct {
+	// Kind is the kind of the credentials used, authorization or session.
+	Kind string `json:"kind,omitempty"`
+	// ID is the ID of the authorization or session.
+	ID     string `json:"id,omitempty"`
+	UserID string `json:"userID,omitempty"`
+	// OrgID is the organization of the authorization, empty for sessions.
+	OrgID string `json:"orgID,omitempty"`
+	// Operator is the actor impersonating UserID, set for impersonations only.
+	Operator *Actor `json:"operator,omitempty"`
+}
+
+// Event is the record of an audited call.
+type Event struct {
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"requestID"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Resource   string    `json:"resource"`
+	ResourceID string    `json:"resourceID,omitempty"`
+	OrgID      string    `json:"orgID,omitempty"`
+	Actor      Actor     `json:"actor"`
+	Status     int       `json:"status"`
+	Outcome    Outcome   `json:"outcome"`
+	ErrorCode  string    `json:"errorCode,omitempty"`
+	RemoteAddr string    `json:"remoteAddr,omitempty"`
+
+	// PrevHash is the hash of the event recorded before this one, empty
+	// for the first event.
+	PrevHash string `json:"prevHash"`
+	// Hash is the hash of the event, PrevHash included.
+	Hash string `json:"hash"`
+}
+
+// hash returns the hash of the event, which covers every field but Hash.
+// It is the HMAC of the event with key, or its SHA-256 hash without a key.
+func (e Event) hash(key []byte) string {
+	e.Hash = ""
+	// an Event only holds values that encode
+	b, _ := json.Marshal(e)
+
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Sink stores audit events.
+type Sink interface {
+	WriteEvent(ctx context.Context, e Event) error
+}
+
+// batchSink is a Sink that stores several events at once, such as a file
+// synced to disk once per batch.
+type batchSink interface {
+	Sink
+	WriteEvents(ctx context.Context, events []Event) error
+}
+
+// chainedSink is a Sink that stored events before the server started, such
+// as a file, so the chain continues from its last event.
+type chainedSink interface {
+	Sink
+	LastHash() string
+}
+
+// Logger records audit events to its sinks, in the order they happen.
+// Events logged while the sinks are busy are queued and written in the next
+// batch, so requests do not wait on each other for every sink write.
+type Logger struct {
+	log   *zap.Logger
+	key   []byte
+	sinks []Sink
+	now   func() time.Time
+
+	mu       sync.Mutex
+	prevHash string
+	queue    []queuedEvent
+	writing  bool
+}
+
+type queuedEvent struct {
+	e    Event
+	done chan error
+}
+
+// NewLogger constructs a Logger recording events to sinks. The events are
+// hashed with key, see Verify. The chain of events continues from the last
+// event of the first sink that stored events before.
+func NewLogger(log *zap.Logger, key []byte, sinks ...Sink) *Logger {
+	l := &Logger{
+		log:   log,
+		key:   key,
+		sinks: sinks,
+		now:   time.Now,
+	}
+	for _, s := range sinks {
+		if cs, ok := s.(chainedSink); ok && cs.LastHash() != "" {
+			l.prevHash = cs.LastHash()
+			break
+		}
+	}
+	return l
+}
+
+// Log chains an event to the events recorded before and writes it to every
+// sink. A sink failing to store the event does not stop the others from
+// storing it, the first error is returned.
+func (l *Logger) Log(ctx context.Context, e Event) error {
+	if e.Time.IsZero() {
+		e.Time = l.now()
+	}
+	e.Time = e.Time.UTC()
+	done := make(chan error, 1)
+
+	l.mu.Lock()
+	e.PrevHash = l.prevHash
+	e.Hash = e.hash(l.key)
+	l.prevHash = e.Hash
+	l.queue = append(l.queue, queuedEvent{e: e, done: done})
+	first := !l.writing
+	l.writing = true
+	l.mu.Unlock()
+
+	// The caller finding the sinks idle writes the queued events; the
+	// others wait for their event to be written.
+	if first {
+		l.drain(ctx)
+	}
+	return <-done
+}
+
+// drain writes the queued events to the sinks. The events queued while they
+// are written are handed to a goroutine, so the caller does not wait for
+// events logged after its own.
+func (l *Logger) drain(ctx context.Context) {
+	l.mu.Lock()
+	batch := l.queue
+	l.queue = nil
+	l.mu.Unlock()
+
+	l.write(ctx, batch)
+
+	l.mu.Lock()
+	more := len(l.queue) > 0
+	l.writing = more
+	l.mu.Unlock()
+	if more {
+		go l.drain(contextWithoutCancel{ctx})
+	}
+}
+
+func (l *Logger) write(ctx context.Context, batch []queuedEvent) {
+	events := make([]Event, len(batch))
+	for i, q := range batch {
+		events[i] = q.e
+	}
+
+	var firstErr error
+	for _, s := range l.sinks {
+		var err error
+		if bs, ok := s.(batchSink); ok {
+			err = bs.WriteEvents(ctx, events)
+		} else {
+			for _, e := range events {
+				if werr := s.WriteEvent(ctx, e); werr != nil && err == nil {
+					err = werr
+				}
+			}
+		}
+		if err != nil {
+			l.log.Error("Failed to record audit events", zap.Int("events", len(events)), zap.Error(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	for _, q := range batch {
+		q.done <- firstErr
+	}
+}
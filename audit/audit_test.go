@@ -0,0 +1,267 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type recordingSink struct {
+	events []Event
+}
+
+func (s *recordingSink) WriteEvent(_ context.Context, e Event) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+func TestFileSink_Verify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	key := []byte("secret")
+
+	f, err := OpenFile(path)
+	require.NoError(t, err)
+	l := NewLogger(zaptest.NewLogger(t), key, f)
+	require.NoError(t, l.Log(context.Background(), Event{RequestID: "1", Method: http.MethodPost, Resource: "buckets"}))
+	require.NoError(t, l.Log(context.Background(), Event{RequestID: "2", Method: http.MethodDelete, Resource: "buckets"}))
+	lastHash := f.LastHash()
+	require.NoError(t, f.Close())
+
+	// The chain continues from the last event of the file when reopened.
+	f, err = OpenFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, lastHash, f.LastHash())
+	l = NewLogger(zaptest.NewLogger(t), key, f)
+	require.NoError(t, l.Log(context.Background(), Event{RequestID: "3", Method: http.MethodPatch, Resource: "orgs"}))
+	require.NoError(t, f.Close())
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, Verify(bytes.NewReader(b), key))
+
+	lines := strings.SplitAfter(strings.TrimSuffix(string(b), "\n"), "\n")
+	require.Len(t, lines, 3)
+
+	t.Run("changed event", func(t *testing.T) {
+		changed := strings.Replace(string(b), `"method":"DELETE"`, `"method":"PATCH"`, 1)
+		err := Verify(strings.NewReader(changed), key)
+		assert.EqualError(t, err, "line 2: event does not match its hash, it was changed")
+	})
+
+	t.Run("removed event", func(t *testing.T) {
+		removed := lines[0] + lines[2]
+		err := Verify(strings.NewReader(removed), key)
+		assert.EqualError(t, err, "line 2: event does not follow the previous event, events were removed or reordered")
+	})
+
+	t.Run("rotated log", func(t *testing.T) {
+		assert.NoError(t, Verify(strings.NewReader(lines[1]+lines[2]), key))
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		err := Verify(bytes.NewReader(b), []byte("other"))
+		assert.EqualError(t, err, "line 1: event does not match its hash, it was changed")
+	})
+}
+
+func TestOpenFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	require.NoError(t, os.WriteFile(path, []byte("not json\n"), 0600))
+
+	_, err := OpenFile(path)
+	assert.Error(t, err)
+}
+
+func TestLogger_Middleware(t *testing.T) {
+	sink := &recordingSink{}
+	l := NewLogger(zaptest.NewLogger(t), nil, sink)
+	l.now = func() time.Time { return time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	auth := &influxdb.Authorization{
+		ID:     platform.ID(1),
+		OrgID:  platform.ID(2),
+		UserID: platform.ID(3),
+	}
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if !strings.Contains(string(b), `"orgID"`) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		r = r.WithContext(icontext.SetAuthorizer(r.Context(), auth))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(httptest.NewRequest(http.MethodPost, "/api/v2/buckets", strings.NewReader(`{"orgID": "000000000000000a", "name": "b"}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotEmpty(t, w.Header().Get(RequestIDHeader))
+
+	r := httptest.NewRequest(http.MethodDelete, "/api/v2/buckets/000000000000000b/labels/000000000000000c", nil)
+	r.Header.Set(RequestIDHeader, "req-2")
+	w = serve(r)
+	assert.Equal(t, "req-2", w.Header().Get(RequestIDHeader))
+
+	serve(httptest.NewRequest(http.MethodGet, "/api/v2/buckets", nil))
+	serve(httptest.NewRequest(http.MethodPost, "/api/v2/write?orgID=000000000000000a", strings.NewReader(`m f=1`)))
+
+	require.Len(t, sink.events, 2)
+	actor := Actor{Kind: influxdb.AuthorizationKind, ID: "0000000000000001", UserID: "0000000000000003", OrgID: "0000000000000002"}
+
+	created := sink.events[0]
+	assert.Equal(t, http.MethodPost, created.Method)
+	assert.Equal(t, "buckets", created.Resource)
+	assert.Empty(t, created.ResourceID)
+	assert.Equal(t, "000000000000000a", created.OrgID)
+	assert.Equal(t, actor, created.Actor)
+	assert.Equal(t, http.StatusCreated, created.Status)
+	assert.Equal(t, OutcomeSuccess, created.Outcome)
+	assert.Empty(t, created.PrevHash)
+	assert.Equal(t, created.hash(nil), created.Hash)
+
+	deleted := sink.events[1]
+	assert.Equal(t, "req-2", deleted.RequestID)
+	assert.Equal(t, "buckets", deleted.Resource)
+	assert.Equal(t, "000000000000000b", deleted.ResourceID)
+	assert.Empty(t, deleted.OrgID)
+	assert.Equal(t, http.StatusBadRequest, deleted.Status)
+	assert.Equal(t, OutcomeFailure, deleted.Outcome)
+	assert.Equal(t, created.Hash, deleted.PrevHash)
+}
+
+func TestLogger_MiddlewareBeforeAuthentication(t *testing.T) {
+	sink := &recordingSink{}
+	l := NewLogger(zaptest.NewLogger(t), nil, sink)
+
+	auth := &influxdb.Authorization{ID: platform.ID(1), OrgID: platform.ID(2), UserID: platform.ID(3)}
+	authenticate := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(icontext.SetAuthorizer(r.Context(), auth)))
+		})
+	}
+	h := l.Middleware(authenticate(l.ActorMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))))
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v2/buckets", nil)
+	r.Header.Set("Authorization", "Token abc")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v2/buckets", nil))
+
+	require.Len(t, sink.events, 2)
+	assert.Equal(t, "0000000000000001", sink.events[0].Actor.ID)
+	assert.Equal(t, OutcomeSuccess, sink.events[0].Outcome)
+
+	rejected := sink.events[1]
+	assert.Equal(t, http.MethodGet, rejected.Method)
+	assert.Equal(t, http.StatusUnauthorized, rejected.Status)
+	assert.Equal(t, OutcomeFailure, rejected.Outcome)
+	assert.Empty(t, rejected.Actor)
+}
+
+func TestLogger_MiddlewareImpersonation(t *testing.T) {
+	sink := &recordingSink{}
+	l := NewLogger(zaptest.NewLogger(t), nil, sink)
+
+	operator := &influxdb.Authorization{ID: platform.ID(1), OrgID: platform.ID(2), UserID: platform.ID(3)}
+	imp := influxdb.NewImpersonation(operator, platform.ID(4), platform.ID(5), nil)
+	h := l.Middleware(l.ActorMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v2/buckets", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r.WithContext(icontext.SetAuthorizer(r.Context(), imp)))
+
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, Actor{
+		Kind:   influxdb.ImpersonationAuthorizationKind,
+		ID:     "0000000000000001",
+		UserID: "0000000000000004",
+		OrgID:  "0000000000000005",
+		Operator: &Actor{
+			Kind:   influxdb.AuthorizationKind,
+			ID:     "0000000000000001",
+			UserID: "0000000000000003",
+			OrgID:  "0000000000000002",
+		},
+	}, sink.events[0].Actor)
+}
+
+func TestLogger_LogConcurrently(t *testing.T) {
+	sink := &recordingSink{}
+	l := NewLogger(zaptest.NewLogger(t), nil, sink)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, l.Log(context.Background(), Event{Method: http.MethodPost}))
+		}()
+	}
+	wg.Wait()
+
+	// The events are written in the order they are chained.
+	require.Eventually(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return !l.writing
+	}, time.Second, time.Millisecond)
+	require.Len(t, sink.events, 50)
+	for i := 1; i < len(sink.events); i++ {
+		assert.Equal(t, sink.events[i-1].Hash, sink.events[i].PrevHash)
+	}
+}
+
+func TestLogger_MiddlewareNil(t *testing.T) {
+	var l *Logger
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := l.Middleware(next)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v2/buckets", nil))
+	assert.Empty(t, w.Header().Get(RequestIDHeader))
+}
+
+func TestResourceOf(t *testing.T) {
+	for _, tt := range []struct {
+		path     string
+		resource string
+		id       string
+	}{
+		{path: "/api/v2/buckets", resource: "buckets"},
+		{path: "/api/v2/buckets/000000000000000a", resource: "buckets", id: "000000000000000a"},
+		{path: "/api/v2/orgs/000000000000000a/members", resource: "orgs", id: "000000000000000a"},
+		{path: "/api/v2/templates/apply", resource: "templates"},
+		{path: "/api/v2/signin", resource: "signin"},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			resource, id := resourceOf(tt.path)
+			assert.Equal(t, tt.resource, resource)
+			assert.Equal(t, tt.id, id)
+		})
+	}
+}
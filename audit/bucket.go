@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+)
+
+const measurement = "audit"
+
+// BucketSink writes audit events to a bucket chosen by the operator, as
+// points of the audit measurement tagged with the organization they change.
+// The bucket should belong to an organization only operators are members
+// of, with a retention period as long as the audit events must be kept, as
+// the members of its organization can change and delete its data.
+type BucketSink struct {
+	pw       storage.PointsWriter
+	buckets  influxdb.BucketService
+	bucketID platform.ID
+}
+
+// NewBucketSink constructs a BucketSink writing events with pw to the bucket
+// with the ID, found with buckets.
+func NewBucketSink(pw storage.PointsWriter, buckets influxdb.BucketService, bucketID platform.ID) *BucketSink {
+	return &BucketSink{pw: pw, buckets: buckets, bucketID: bucketID}
+}
+
+// WriteEvent writes the event to the bucket.
+func (s *BucketSink) WriteEvent(ctx context.Context, e Event) error {
+	return s.WriteEvents(ctx, []Event{e})
+}
+
+// WriteEvents writes the events to the bucket.
+func (s *BucketSink) WriteEvents(ctx context.Context, events []Event) error {
+	bucket, err := s.buckets.FindBucketByID(ctx, s.bucketID)
+	if err != nil {
+		return err
+	}
+
+	points := make(models.Points, 0, len(events))
+	for _, e := range events {
+		pt, err := eventPoint(e)
+		if err != nil {
+			return err
+		}
+		points = append(points, pt)
+	}
+	return s.pw.WritePoints(ctx, bucket.OrgID, bucket.ID, points)
+}
+
+func eventPoint(e Event) (models.Point, error) {
+	org := e.OrgID
+	if org == "" {
+		org = e.Actor.OrgID
+	}
+	tags := map[string]string{
+		"method":   e.Method,
+		"resource": e.Resource,
+		"outcome":  string(e.Outcome),
+	}
+	if org != "" {
+		tags["orgID"] = org
+	}
+	fields := map[string]interface{}{
+		"requestID": e.RequestID,
+		"path":      e.Path,
+		"status":    int64(e.Status),
+		"actorKind": e.Actor.Kind,
+		"actorID":   e.Actor.ID,
+		"userID":    e.Actor.UserID,
+		"prevHash":  e.PrevHash,
+		"hash":      e.Hash,
+	}
+	if op := e.Actor.Operator; op != nil {
+		fields["operatorKind"] = op.Kind
+		fields["operatorID"] = op.ID
+		fields["operatorUserID"] = op.UserID
+	}
+	if e.ResourceID != "" {
+		fields["resourceID"] = e.ResourceID
+	}
+	if e.ErrorCode != "" {
+		fields["errorCode"] = e.ErrorCode
+	}
+	if e.RemoteAddr != "" {
+		fields["remoteAddr"] = e.RemoteAddr
+	}
+	return models.NewPoint(measurement, models.NewTags(tags), fields, e.Time)
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// FileSink appends audit events to a file, one JSON event per line.
+type FileSink struct {
+	mu       sync.Mutex
+	f        *os.File
+	lastHash string
+}
+
+// OpenFile opens the file at path to append events to, creating it when it
+// does not exist. The chain continues from the last event of the file.
+func OpenFile(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	var last Event
+	err = readEvents(f, func(_ int, e Event) error {
+		last = e
+		return nil
+	})
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("reading audit log %s: %w", path, err)
+	}
+	return &FileSink{f: f, lastHash: last.Hash}, nil
+}
+
+// LastHash returns the hash of the last event of the file.
+func (s *FileSink) LastHash() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastHash
+}
+
+// WriteEvent appends the event to the file and syncs it to disk.
+func (s *FileSink) WriteEvent(ctx context.Context, e Event) error {
+	return s.WriteEvents(ctx, []Event{e})
+}
+
+// WriteEvents appends the events to the file and syncs it to disk once.
+func (s *FileSink) WriteEvents(_ context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var b []byte
+	for _, e := range events {
+		eb, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b = append(append(b, eb...), '\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(b); err != nil {
+		return err
+	}
+	if err := s.f.Sync(); err != nil {
+		return err
+	}
+	s.lastHash = events[len(events)-1].Hash
+	return nil
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// Verify reads the events of an audit log written by a FileSink and checks
+// their chain is intact, with the key the events were hashed with. The first
+// event may link to events of a log that was rotated away. It returns an
+// error locating the first event that was changed, removed or reordered.
+func Verify(r io.Reader, key []byte) error {
+	var prevHash string
+	return readEvents(r, func(line int, e Event) error {
+		if line > 1 && e.PrevHash != prevHash {
+			return fmt.Errorf("line %d: event does not follow the previous event, events were removed or reordered", line)
+		}
+		if e.hash(key) != e.Hash {
+			return fmt.Errorf("line %d: event does not match its hash, it was changed", line)
+		}
+		prevHash = e.Hash
+		return nil
+	})
+}
+
+func readEvents(r io.Reader, fn func(line int, e Event) error) error {
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, err := br.ReadBytes('\n')
+		if err == io.EOF && len(b) == 0 {
+			return nil
+		} else if err != nil && err != io.EOF {
+			return err
+		}
+
+		var e Event
+		if err := json.Unmarshal(b, &e); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := fn(line, e); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader is the header the ID of an audited request is read from
+// and returned in. Requests without one are given a new ID.
+const RequestIDHeader = "X-Request-Id"
+
+// orgPeekBytes is how much of a JSON request body is read to find the
+// organization the request changes.
+const orgPeekBytes = 64 << 10
+
+var idGen = snowflake.NewDefaultIDGenerator()
+
+// dataPaths are the mutating routes that write or query data rather than
+// change the resources of the server, which are not audited.
+var dataPaths = []string{"/api/v2/write", "/api/v2/query", "/write", "/query"}
+
+type eventContextKey struct{}
+
+// Middleware records an audit event for every POST, PUT, PATCH and DELETE
+// request once it is served, and for every request rejected as
+// unauthenticated. It runs before the request is authenticated, so that
+// the rejected requests are recorded, and ActorMiddleware records the actor
+// of the requests that are authenticated. A nil Logger records nothing.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = idGen.ID().String()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		audited := isAudited(r)
+		e := &Event{
+			Time:       l.now(),
+			RequestID:  requestID,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			RemoteAddr: remoteAddr(r),
+		}
+		e.Resource, e.ResourceID = resourceOf(r.URL.Path)
+		if audited {
+			e.OrgID = orgOf(r)
+		}
+		if actor, ok := actorOf(r.Context()); ok {
+			e.Actor = actor
+		}
+		r = r.WithContext(context.WithValue(r.Context(), eventContextKey{}, e))
+
+		sw := kithttp.NewStatusResponseWriter(w)
+		defer func() {
+			e.Status = sw.Code()
+			if !audited && e.Status != http.StatusUnauthorized {
+				return
+			}
+			e.Outcome = OutcomeSuccess
+			if e.Status >= http.StatusBadRequest {
+				e.Outcome = OutcomeFailure
+				e.ErrorCode = sw.Header().Get(kithttp.PlatformErrorCodeHeader)
+			}
+			// The event is recorded even when the client went away, as the
+			// change it made stays.
+			if err := l.Log(contextWithoutCancel{r.Context()}, *e); err != nil {
+				l.log.Warn("Audit event not recorded to every sink", zap.String("request_id", requestID), zap.Error(err))
+			}
+		}()
+		next.ServeHTTP(sw, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// ActorMiddleware records the actor of an authenticated request in its
+// audit event. It must run after the request is authenticated. A nil Logger
+// records nothing.
+func (l *Logger) ActorMiddleware(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if e, ok := r.Context().Value(eventContextKey{}).(*Event); ok {
+			if actor, ok := actorOf(r.Context()); ok {
+				e.Actor = actor
+			}
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// actorOf returns the actor of the authorizer of the context.
+func actorOf(ctx context.Context) (Actor, bool) {
+	a, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return Actor{}, false
+	}
+	return newActor(a), true
+}
+
+// newActor returns the actor of an authorizer. The actor of an impersonation
+// is the impersonated user, and the operator who acted is recorded with it.
+func newActor(a influxdb.Authorizer) Actor {
+	actor := Actor{
+		Kind:   a.Kind(),
+		ID:     a.Identifier().String(),
+		UserID: a.GetUserID().String(),
+	}
+	switch a := a.(type) {
+	case *influxdb.Authorization:
+		actor.OrgID = a.OrgID.String()
+	case *influxdb.Impersonation:
+		if a.OrgID.Valid() {
+			actor.OrgID = a.OrgID.String()
+		}
+		if a.Operator != nil {
+			operator := newActor(a.Operator)
+			actor.Operator = &operator
+		}
+	}
+	return actor
+}
+
+func isAudited(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return false
+	}
+	for _, p := range dataPaths {
+		if r.URL.Path == p || strings.HasPrefix(r.URL.Path, p+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+// resourceOf returns the resource a request path addresses, such as
+// buckets for /api/v2/buckets/{id}/labels, and the ID of the resource when
+// the path has one.
+func resourceOf(path string) (string, string) {
+	path = strings.TrimPrefix(path, "/api/v2")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	resource := segments[0]
+	if len(segments) > 1 {
+		if id, err := platform.IDFromString(segments[1]); err == nil {
+			return resource, id.String()
+		}
+	}
+	return resource, ""
+}
+
+// orgOf returns the organization a request changes, given by the orgID
+// query parameter or the orgID field of a JSON body. Organization requests
+// change the organization they address.
+func orgOf(r *http.Request) string {
+	if resource, id := resourceOf(r.URL.Path); resource == "orgs" && id != "" {
+		return id
+	}
+	if orgID := r.URL.Query().Get("orgID"); orgID != "" {
+		return orgID
+	}
+	if r.Body == nil || r.Header.Get("Content-Encoding") != "" || !isJSON(r.Header.Get("Content-Type")) {
+		return ""
+	}
+
+	// The body is peeked and put back together for the handler.
+	peeked, err := ioutil.ReadAll(io.LimitReader(r.Body, orgPeekBytes))
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(peeked), r.Body), Closer: r.Body}
+	if err != nil {
+		return ""
+	}
+	var body struct {
+		OrgID string `json:"orgID"`
+	}
+	if err := json.Unmarshal(peeked, &body); err != nil {
+		return ""
+	}
+	return body.OrgID
+}
+
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mt == "application/json"
+}
+
+func remoteAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// contextWithoutCancel keeps the values of a context, such as the tracing
+// span, but is never canceled.
+type contextWithoutCancel struct {
+	context.Context
+}
+
+func (contextWithoutCancel) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (contextWithoutCancel) Done() <-chan struct{}       { return nil }
+func (contextWithoutCancel) Err() error                  { return nil }
@@ -19,13 +19,14 @@ var ErrUnableToCreateToken = &errors.Error{
 
 // Authorization is an authorization. 🎉
 type Authorization struct {
-	ID          platform.ID  `json:"id"`
-	Token       string       `json:"token"`
-	Status      Status       `json:"status"`
-	Description string       `json:"description"`
-	OrgID       platform.ID  `json:"orgID"`
-	UserID      platform.ID  `json:"userID,omitempty"`
-	Permissions []Permission `json:"permissions"`
+	ID          platform.ID         `json:"id"`
+	Token       string              `json:"token"`
+	Status      Status              `json:"status"`
+	Description string              `json:"description"`
+	OrgID       platform.ID         `json:"orgID"`
+	UserID      platform.ID         `json:"userID,omitempty"`
+	Permissions []Permission        `json:"permissions"`
+	Annotations ResourceAnnotations `json:"annotations,omitempty"`
 	CRUDLog
 }
 
@@ -33,6 +34,8 @@ type Authorization struct {
 type AuthorizationUpdate struct {
 	Status      *Status `json:"status,omitempty"`
 	Description *string `json:"description,omitempty"`
+	// Annotations replaces all annotations of the authorization when set.
+	Annotations *ResourceAnnotations `json:"annotations,omitempty"`
 }
 
 // Valid ensures that the authorization is valid.
@@ -46,7 +49,7 @@ func (a *Authorization) Valid() error {
 		}
 	}
 
-	return nil
+	return a.Annotations.Valid()
 }
 
 // PermissionSet returns the set of permissions associated with the Authorization.
@@ -124,4 +127,6 @@ type AuthorizationFilter struct {
 
 	OrgID *platform.ID
 	Org   *string
+
+	Annotations ResourceAnnotations
 }
@@ -48,6 +48,9 @@ func (s *AuthorizationClientService) FindAuthorizations(ctx context.Context, fil
 	if filter.Org != nil {
 		params = append(params, [2]string{"org", *filter.Org})
 	}
+	for k, v := range filter.Annotations {
+		params = append(params, [2]string{"annotations[" + k + "]", v})
+	}
 
 	var as authsResponse
 	err := s.Client.
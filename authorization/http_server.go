@@ -24,6 +24,7 @@ type TenantService interface {
 	FindUserByID(ctx context.Context, id platform.ID) (*influxdb.User, error)
 	FindUser(ctx context.Context, filter influxdb.UserFilter) (*influxdb.User, error)
 	FindBucketByID(ctx context.Context, id platform.ID) (*influxdb.Bucket, error)
+	FindBucketByName(ctx context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error)
 }
 
 type AuthHandler struct {
@@ -53,6 +54,8 @@ func NewHTTPAuthHandler(log *zap.Logger, authService influxdb.AuthorizationServi
 	r.Route("/", func(r chi.Router) {
 		r.Post("/", h.handlePostAuthorization)
 		r.Get("/", h.handleGetAuthorizations)
+		r.Get("/export", h.handleExportSecurityConfig)
+		r.Post("/import", h.handleImportSecurityConfig)
 
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", h.handleGetAuthorization)
@@ -127,26 +130,28 @@ func getAuthorizedUser(r *http.Request, ts TenantService) (*influxdb.User, error
 }
 
 type postAuthorizationRequest struct {
-	Status      influxdb.Status       `json:"status"`
-	OrgID       platform.ID           `json:"orgID"`
-	UserID      *platform.ID          `json:"userID,omitempty"`
-	Description string                `json:"description"`
-	Permissions []influxdb.Permission `json:"permissions"`
+	Status      influxdb.Status              `json:"status"`
+	OrgID       platform.ID                  `json:"orgID"`
+	UserID      *platform.ID                 `json:"userID,omitempty"`
+	Description string                       `json:"description"`
+	Permissions []influxdb.Permission        `json:"permissions"`
+	Annotations influxdb.ResourceAnnotations `json:"annotations,omitempty"`
 }
 
 type authResponse struct {
-	ID          platform.ID          `json:"id"`
-	Token       string               `json:"token"`
-	Status      influxdb.Status      `json:"status"`
-	Description string               `json:"description"`
-	OrgID       platform.ID          `json:"orgID"`
-	Org         string               `json:"org"`
-	UserID      platform.ID          `json:"userID"`
-	User        string               `json:"user"`
-	Permissions []permissionResponse `json:"permissions"`
-	Links       map[string]string    `json:"links"`
-	CreatedAt   time.Time            `json:"createdAt"`
-	UpdatedAt   time.Time            `json:"updatedAt"`
+	ID          platform.ID                  `json:"id"`
+	Token       string                       `json:"token"`
+	Status      influxdb.Status              `json:"status"`
+	Description string                       `json:"description"`
+	OrgID       platform.ID                  `json:"orgID"`
+	Org         string                       `json:"org"`
+	UserID      platform.ID                  `json:"userID"`
+	User        string                       `json:"user"`
+	Permissions []permissionResponse         `json:"permissions"`
+	Annotations influxdb.ResourceAnnotations `json:"annotations,omitempty"`
+	Links       map[string]string            `json:"links"`
+	CreatedAt   time.Time                    `json:"createdAt"`
+	UpdatedAt   time.Time                    `json:"updatedAt"`
 }
 
 // In the future, we would like only the service layer to look up the user and org to see if they are valid
@@ -173,6 +178,7 @@ func (h *AuthHandler) newAuthResponse(ctx context.Context, a *influxdb.Authoriza
 		User:        user.Name,
 		Org:         org.Name,
 		Permissions: ps,
+		Annotations: a.Annotations,
 		Links: map[string]string{
 			"self": fmt.Sprintf("/api/v2/authorizations/%s", a.ID),
 			"user": fmt.Sprintf("/api/v2/users/%s", a.UserID),
@@ -189,6 +195,7 @@ func (p *postAuthorizationRequest) toInfluxdb(userID platform.ID) *influxdb.Auth
 		Status:      p.Status,
 		Description: p.Description,
 		Permissions: p.Permissions,
+		Annotations: p.Annotations,
 		UserID:      userID,
 	}
 }
@@ -201,6 +208,7 @@ func (a *authResponse) toInfluxdb() *influxdb.Authorization {
 		Description: a.Description,
 		OrgID:       a.OrgID,
 		UserID:      a.UserID,
+		Annotations: a.Annotations,
 		CRUDLog: influxdb.CRUDLog{
 			CreatedAt: a.CreatedAt,
 			UpdatedAt: a.UpdatedAt,
@@ -233,6 +241,7 @@ func newPostAuthorizationRequest(a *influxdb.Authorization) (*postAuthorizationR
 		Description: a.Description,
 		Permissions: a.Permissions,
 		Status:      a.Status,
+		Annotations: a.Annotations,
 	}
 
 	if a.UserID.Valid() {
@@ -283,7 +292,7 @@ func (p *postAuthorizationRequest) Validate() error {
 		return err
 	}
 
-	return nil
+	return p.Annotations.Valid()
 }
 
 type permissionResponse struct {
@@ -485,6 +494,8 @@ func decodeGetAuthorizationsRequest(ctx context.Context, r *http.Request) (*getA
 		req.filter.ID = id
 	}
 
+	req.filter.Annotations = influxdb.DecodeResourceAnnotationsFilter(qp)
+
 	return req, nil
 }
 
@@ -576,6 +587,12 @@ func decodeUpdateAuthorizationRequest(ctx context.Context, r *http.Request) (*up
 		return nil, err
 	}
 
+	if upd.Annotations != nil {
+		if err := upd.Annotations.Valid(); err != nil {
+			return nil, err
+		}
+	}
+
 	return &updateAuthorizationRequest{
 		ID:                  *id,
 		AuthorizationUpdate: upd,
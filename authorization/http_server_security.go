@@ -0,0 +1,409 @@
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"go.uber.org/zap"
+)
+
+// SecurityConfig is a reviewable document describing the authorizations of an
+// organization. Token values are never part of the document; resources are
+// referenced by name where possible so the document can be imported into the
+// same organization on another instance.
+type SecurityConfig struct {
+	Org            string                  `json:"org"`
+	Authorizations []SecurityAuthorization `json:"authorizations"`
+}
+
+// SecurityAuthorization describes the scopes of an authorization. An
+// authorization is identified by its user and description.
+type SecurityAuthorization struct {
+	User        string                       `json:"user"`
+	Description string                       `json:"description"`
+	Status      influxdb.Status              `json:"status"`
+	Annotations influxdb.ResourceAnnotations `json:"annotations,omitempty"`
+	Permissions []SecurityPermission         `json:"permissions"`
+}
+
+// SecurityPermission is a permission whose resource is independent of the instance.
+type SecurityPermission struct {
+	Action   influxdb.Action  `json:"action"`
+	Resource SecurityResource `json:"resource"`
+}
+
+// SecurityResource references the resource of a permission. OrgScoped
+// restricts the permission to the organization the document is imported into.
+// Buckets and users are referenced by Name; other resources by their ID.
+type SecurityResource struct {
+	Type      influxdb.ResourceType `json:"type"`
+	OrgScoped bool                  `json:"orgScoped,omitempty"`
+	Name      string                `json:"name,omitempty"`
+	ID        *platform.ID          `json:"id,omitempty"`
+}
+
+// SecurityChangeAction is the action taken for an authorization on import.
+type SecurityChangeAction string
+
+// SecurityChangeAction actions.
+const (
+	SecurityChangeCreate    SecurityChangeAction = "create"
+	SecurityChangeUpdate    SecurityChangeAction = "update"
+	SecurityChangeUnchanged SecurityChangeAction = "unchanged"
+	// SecurityChangeConflict signals the permissions of an existing
+	// authorization differ from the document. Permissions of a token cannot
+	// be changed without replacing the token, which is left to the operator.
+	SecurityChangeConflict SecurityChangeAction = "conflict"
+)
+
+// SecurityChange reports the outcome of importing a single authorization.
+type SecurityChange struct {
+	Action      SecurityChangeAction `json:"action"`
+	User        string               `json:"user"`
+	Description string               `json:"description"`
+	ID          *platform.ID         `json:"id,omitempty"`
+}
+
+// SecurityImportResult is the response of a security config import.
+type SecurityImportResult struct {
+	DryRun  bool             `json:"dryRun"`
+	Changes []SecurityChange `json:"changes"`
+}
+
+// handleExportSecurityConfig is the HTTP handler for the GET /api/v2/authorizations/export route.
+func (h *AuthHandler) handleExportSecurityConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	org, err := h.decodeSecurityConfigOrg(ctx, r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	cfg, err := h.exportSecurityConfig(ctx, org)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, cfg)
+}
+
+// handleImportSecurityConfig is the HTTP handler for the POST /api/v2/authorizations/import route.
+func (h *AuthHandler) handleImportSecurityConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	org, err := h.decodeSecurityConfigOrg(ctx, r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var cfg SecurityConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		})
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	res, err := h.importSecurityConfig(ctx, org, cfg, dryRun)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Security config imported", zap.String("orgID", org.ID.String()), zap.Bool("dryRun", dryRun))
+
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+func (h *AuthHandler) decodeSecurityConfigOrg(ctx context.Context, r *http.Request) (*influxdb.Organization, error) {
+	qp := r.URL.Query()
+	if orgID := qp.Get("orgID"); orgID != "" {
+		id, err := platform.IDFromString(orgID)
+		if err != nil {
+			return nil, err
+		}
+		return h.tenantService.FindOrganizationByID(ctx, *id)
+	}
+	if org := qp.Get("org"); org != "" {
+		return h.tenantService.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &org})
+	}
+	return nil, &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "org or orgID must be provided",
+	}
+}
+
+func (h *AuthHandler) exportSecurityConfig(ctx context.Context, org *influxdb.Organization) (*SecurityConfig, error) {
+	as, _, err := h.authSvc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{OrgID: &org.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &SecurityConfig{
+		Org:            org.Name,
+		Authorizations: []SecurityAuthorization{},
+	}
+	for _, a := range as {
+		user, err := h.tenantService.FindUserByID(ctx, a.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		sa := SecurityAuthorization{
+			User:        user.Name,
+			Description: a.Description,
+			Status:      a.Status,
+			Annotations: a.Annotations,
+			Permissions: make([]SecurityPermission, 0, len(a.Permissions)),
+		}
+		for _, p := range a.Permissions {
+			sp, err := h.exportPermission(ctx, org.ID, p)
+			if err != nil {
+				return nil, err
+			}
+			sa.Permissions = append(sa.Permissions, sp)
+		}
+		cfg.Authorizations = append(cfg.Authorizations, sa)
+	}
+
+	sort.Slice(cfg.Authorizations, func(i, j int) bool {
+		ai, aj := cfg.Authorizations[i], cfg.Authorizations[j]
+		if ai.User != aj.User {
+			return ai.User < aj.User
+		}
+		return ai.Description < aj.Description
+	})
+	return cfg, nil
+}
+
+func (h *AuthHandler) exportPermission(ctx context.Context, orgID platform.ID, p influxdb.Permission) (SecurityPermission, error) {
+	sp := SecurityPermission{
+		Action: p.Action,
+		Resource: SecurityResource{
+			Type:      p.Resource.Type,
+			OrgScoped: p.Resource.OrgID != nil,
+		},
+	}
+	if p.Resource.ID == nil {
+		return sp, nil
+	}
+
+	switch p.Resource.Type {
+	case influxdb.OrgsResourceType:
+		// a permission on the organization itself follows the org it is imported into
+		if *p.Resource.ID == orgID {
+			sp.Resource.OrgScoped = true
+			return sp, nil
+		}
+	case influxdb.BucketsResourceType, influxdb.UsersResourceType:
+		name, err := h.getNameForResource(ctx, p.Resource.Type, *p.Resource.ID)
+		if err == nil {
+			sp.Resource.Name = name
+			return sp, nil
+		}
+		if errors.ErrorCode(err) != errors.ENotFound {
+			return SecurityPermission{}, err
+		}
+	}
+
+	id := *p.Resource.ID
+	sp.Resource.ID = &id
+	return sp, nil
+}
+
+func (h *AuthHandler) importSecurityConfig(ctx context.Context, org *influxdb.Organization, cfg SecurityConfig, dryRun bool) (*SecurityImportResult, error) {
+	existing, _, err := h.authSvc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{OrgID: &org.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &SecurityImportResult{
+		DryRun:  dryRun,
+		Changes: []SecurityChange{},
+	}
+	seen := make(map[string]bool, len(cfg.Authorizations))
+	for i, sa := range cfg.Authorizations {
+		key := sa.User + "\x00" + sa.Description
+		if seen[key] {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("authorizations[%d]: duplicate authorization for user %q with description %q", i, sa.User, sa.Description),
+			}
+		}
+		seen[key] = true
+
+		desired, err := h.importAuthorization(ctx, org.ID, sa)
+		if err != nil {
+			return nil, &errors.Error{
+				Code: errors.ErrorCode(err),
+				Msg:  fmt.Sprintf("authorizations[%d]", i),
+				Err:  err,
+			}
+		}
+
+		change := SecurityChange{
+			User:        sa.User,
+			Description: sa.Description,
+		}
+
+		current := findSecurityAuthorization(existing, desired.UserID, desired.Description)
+		switch {
+		case current == nil:
+			change.Action = SecurityChangeCreate
+			if !dryRun {
+				if err := h.authSvc.CreateAuthorization(ctx, desired); err != nil {
+					return nil, err
+				}
+				change.ID = &desired.ID
+			}
+		case permissionsKey(current.Permissions) != permissionsKey(desired.Permissions):
+			change.Action = SecurityChangeConflict
+			change.ID = &current.ID
+		case current.Status != desired.Status || !annotationsEqual(current.Annotations, desired.Annotations):
+			change.Action = SecurityChangeUpdate
+			change.ID = &current.ID
+			if !dryRun {
+				annotations := desired.Annotations
+				if annotations == nil {
+					annotations = influxdb.ResourceAnnotations{}
+				}
+				if _, err := h.authSvc.UpdateAuthorization(ctx, current.ID, &influxdb.AuthorizationUpdate{
+					Status:      &desired.Status,
+					Annotations: &annotations,
+				}); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			change.Action = SecurityChangeUnchanged
+			change.ID = &current.ID
+		}
+		res.Changes = append(res.Changes, change)
+	}
+
+	return res, nil
+}
+
+// importAuthorization resolves the user and resources of the authorization
+// within the organization being imported into.
+func (h *AuthHandler) importAuthorization(ctx context.Context, orgID platform.ID, sa SecurityAuthorization) (*influxdb.Authorization, error) {
+	user, err := h.tenantService.FindUser(ctx, influxdb.UserFilter{Name: &sa.User})
+	if err != nil {
+		return nil, err
+	}
+
+	status := sa.Status
+	if status == "" {
+		status = influxdb.Active
+	}
+	if err := status.Valid(); err != nil {
+		return nil, err
+	}
+
+	a := &influxdb.Authorization{
+		OrgID:       orgID,
+		UserID:      user.ID,
+		Description: sa.Description,
+		Status:      status,
+		Annotations: sa.Annotations,
+		Permissions: make([]influxdb.Permission, 0, len(sa.Permissions)),
+	}
+	for _, sp := range sa.Permissions {
+		p, err := h.importPermission(ctx, orgID, sp)
+		if err != nil {
+			return nil, err
+		}
+		a.Permissions = append(a.Permissions, p)
+	}
+
+	if len(a.Permissions) == 0 {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "authorization must include permissions",
+		}
+	}
+	if err := a.Valid(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (h *AuthHandler) importPermission(ctx context.Context, orgID platform.ID, sp SecurityPermission) (influxdb.Permission, error) {
+	p := influxdb.Permission{
+		Action:   sp.Action,
+		Resource: influxdb.Resource{Type: sp.Resource.Type},
+	}
+
+	switch {
+	case sp.Resource.Type == influxdb.OrgsResourceType && sp.Resource.OrgScoped:
+		id := orgID
+		p.Resource.ID = &id
+	case sp.Resource.OrgScoped:
+		id := orgID
+		p.Resource.OrgID = &id
+	}
+
+	switch {
+	case sp.Resource.Name != "" && sp.Resource.Type == influxdb.BucketsResourceType:
+		b, err := h.tenantService.FindBucketByName(ctx, orgID, sp.Resource.Name)
+		if err != nil {
+			return influxdb.Permission{}, err
+		}
+		p.Resource.ID = &b.ID
+	case sp.Resource.Name != "" && sp.Resource.Type == influxdb.UsersResourceType:
+		u, err := h.tenantService.FindUser(ctx, influxdb.UserFilter{Name: &sp.Resource.Name})
+		if err != nil {
+			return influxdb.Permission{}, err
+		}
+		p.Resource.ID = &u.ID
+	case sp.Resource.Name != "":
+		return influxdb.Permission{}, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("resources of type %q cannot be referenced by name", sp.Resource.Type),
+		}
+	case sp.Resource.ID != nil:
+		id := *sp.Resource.ID
+		p.Resource.ID = &id
+	}
+
+	if err := p.Valid(); err != nil {
+		return influxdb.Permission{}, err
+	}
+	return p, nil
+}
+
+func findSecurityAuthorization(as []*influxdb.Authorization, userID platform.ID, description string) *influxdb.Authorization {
+	for _, a := range as {
+		if a.UserID == userID && a.Description == description {
+			return a
+		}
+	}
+	return nil
+}
+
+// permissionsKey returns a representation of the permissions that is
+// independent of their order.
+func permissionsKey(ps []influxdb.Permission) string {
+	keys := make([]string, 0, len(ps))
+	for _, p := range ps {
+		keys = append(keys, p.String())
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func annotationsEqual(a, b influxdb.ResourceAnnotations) bool {
+	return len(a) == len(b) && a.Matches(b)
+}
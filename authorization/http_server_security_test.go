@@ -0,0 +1,233 @@
+package authorization
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestService_SecurityConfig(t *testing.T) {
+	const (
+		stagingOrgID platform.ID = 0x10
+		prodOrgID    platform.ID = 0x20
+		userID       platform.ID = 0x30
+	)
+
+	bucketIDs := map[platform.ID]map[string]platform.ID{
+		stagingOrgID: {"telemetry": 0x11},
+		prodOrgID:    {"telemetry": 0x21},
+	}
+
+	newTenantService := func() *tenantService {
+		return &tenantService{
+			FindOrganizationByIDF: func(ctx context.Context, id platform.ID) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: id, Name: "org-" + id.String()}, nil
+			},
+			FindUserByIDFn: func(ctx context.Context, id platform.ID) (*influxdb.User, error) {
+				return &influxdb.User{ID: id, Name: "ops"}, nil
+			},
+			FindUserFn: func(ctx context.Context, f influxdb.UserFilter) (*influxdb.User, error) {
+				if *f.Name != "ops" {
+					return nil, &errors.Error{Code: errors.ENotFound, Msg: "user not found"}
+				}
+				return &influxdb.User{ID: userID, Name: "ops"}, nil
+			},
+			FindBucketByIDFn: func(ctx context.Context, id platform.ID) (*influxdb.Bucket, error) {
+				for _, buckets := range bucketIDs {
+					for name, bucketID := range buckets {
+						if bucketID == id {
+							return &influxdb.Bucket{ID: id, Name: name}, nil
+						}
+					}
+				}
+				return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+			},
+			FindBucketByNameFn: func(ctx context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error) {
+				id, ok := bucketIDs[orgID][name]
+				if !ok {
+					return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+				}
+				return &influxdb.Bucket{ID: id, OrgID: orgID, Name: name}, nil
+			},
+		}
+	}
+
+	permissions := func(orgID platform.ID) []influxdb.Permission {
+		bucketID := bucketIDs[orgID]["telemetry"]
+		return []influxdb.Permission{
+			{
+				Action:   influxdb.ReadAction,
+				Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &orgID, ID: &bucketID},
+			},
+			{
+				Action:   influxdb.ReadAction,
+				Resource: influxdb.Resource{Type: influxdb.OrgsResourceType, ID: &orgID},
+			},
+			{
+				Action:   influxdb.WriteAction,
+				Resource: influxdb.Resource{Type: influxdb.DashboardsResourceType, OrgID: &orgID},
+			},
+		}
+	}
+
+	staging := []*influxdb.Authorization{
+		{
+			ID:          1,
+			Token:       "secret-writer",
+			OrgID:       stagingOrgID,
+			UserID:      userID,
+			Description: "writer",
+			Status:      influxdb.Inactive,
+			Permissions: permissions(stagingOrgID),
+		},
+		{
+			ID:          2,
+			Token:       "secret-reader",
+			OrgID:       stagingOrgID,
+			UserID:      userID,
+			Description: "reader",
+			Status:      influxdb.Active,
+			Annotations: influxdb.ResourceAnnotations{"team": "payments"},
+			Permissions: permissions(stagingOrgID),
+		},
+		{
+			ID:          3,
+			Token:       "secret-dashboards",
+			OrgID:       stagingOrgID,
+			UserID:      userID,
+			Description: "dashboards",
+			Status:      influxdb.Active,
+			Permissions: permissions(stagingOrgID),
+		},
+	}
+
+	prod := []*influxdb.Authorization{
+		{
+			ID:          0x101,
+			OrgID:       prodOrgID,
+			UserID:      userID,
+			Description: "writer",
+			Status:      influxdb.Active,
+			Permissions: permissions(prodOrgID),
+		},
+		{
+			ID:          0x102,
+			OrgID:       prodOrgID,
+			UserID:      userID,
+			Description: "dashboards",
+			Status:      influxdb.Active,
+			Permissions: permissions(prodOrgID)[:1],
+		},
+	}
+
+	authSvc := func(auths []*influxdb.Authorization) *mock.AuthorizationService {
+		svc := mock.NewAuthorizationService()
+		svc.FindAuthorizationsFn = func(ctx context.Context, f influxdb.AuthorizationFilter, opts ...influxdb.FindOptions) ([]*influxdb.Authorization, int, error) {
+			var out []*influxdb.Authorization
+			for _, a := range auths {
+				if a.OrgID == *f.OrgID {
+					out = append(out, a)
+				}
+			}
+			return out, len(out), nil
+		}
+		return svc
+	}
+
+	// export from staging
+	h := NewHTTPAuthHandler(zaptest.NewLogger(t), authSvc(staging), newTenantService())
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://any.url/export?orgID="+stagingOrgID.String(), nil)
+	h.handleExportSecurityConfig(w, r)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.NotContains(t, w.Body.String(), "secret")
+
+	var cfg SecurityConfig
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cfg))
+	require.Len(t, cfg.Authorizations, 3)
+	assert.Equal(t, "dashboards", cfg.Authorizations[0].Description)
+	assert.Equal(t, []SecurityPermission{
+		{
+			Action:   influxdb.ReadAction,
+			Resource: SecurityResource{Type: influxdb.BucketsResourceType, OrgScoped: true, Name: "telemetry"},
+		},
+		{
+			Action:   influxdb.ReadAction,
+			Resource: SecurityResource{Type: influxdb.OrgsResourceType, OrgScoped: true},
+		},
+		{
+			Action:   influxdb.WriteAction,
+			Resource: SecurityResource{Type: influxdb.DashboardsResourceType, OrgScoped: true},
+		},
+	}, cfg.Authorizations[0].Permissions)
+
+	// import into prod
+	var created []*influxdb.Authorization
+	var updated []platform.ID
+	prodSvc := authSvc(prod)
+	prodSvc.CreateAuthorizationFn = func(ctx context.Context, a *influxdb.Authorization) error {
+		a.ID = 0x103
+		created = append(created, a)
+		return nil
+	}
+	prodSvc.UpdateAuthorizationFn = func(ctx context.Context, id platform.ID, upd *influxdb.AuthorizationUpdate) (*influxdb.Authorization, error) {
+		updated = append(updated, id)
+		assert.Equal(t, influxdb.Inactive, *upd.Status)
+		return &influxdb.Authorization{ID: id}, nil
+	}
+
+	body, err := json.Marshal(cfg)
+	require.NoError(t, err)
+
+	for _, dryRun := range []bool{true, false} {
+		created, updated = nil, nil
+
+		h = NewHTTPAuthHandler(zaptest.NewLogger(t), prodSvc, newTenantService())
+		w = httptest.NewRecorder()
+		target := "http://any.url/import?orgID=" + prodOrgID.String()
+		if dryRun {
+			target += "&dryRun=true"
+		}
+		r = httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+		h.handleImportSecurityConfig(w, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var res SecurityImportResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, dryRun, res.DryRun)
+
+		actions := map[string]SecurityChangeAction{}
+		for _, c := range res.Changes {
+			actions[c.Description] = c.Action
+		}
+		assert.Equal(t, map[string]SecurityChangeAction{
+			"dashboards": SecurityChangeConflict,
+			"reader":     SecurityChangeCreate,
+			"writer":     SecurityChangeUpdate,
+		}, actions)
+
+		if dryRun {
+			assert.Empty(t, created)
+			assert.Empty(t, updated)
+			continue
+		}
+
+		require.Len(t, created, 1)
+		assert.Equal(t, prodOrgID, created[0].OrgID)
+		assert.Equal(t, influxdb.ResourceAnnotations{"team": "payments"}, created[0].Annotations)
+		assert.Equal(t, permissions(prodOrgID), created[0].Permissions)
+		assert.Equal(t, []platform.ID{0x101}, updated)
+	}
+}
@@ -14,6 +14,7 @@ type tenantService struct {
 	FindOrganizationByIDF func(ctx context.Context, id platform.ID) (*influxdb.Organization, error)
 	FindOrganizationF     func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error)
 	FindBucketByIDFn      func(context.Context, platform.ID) (*influxdb.Bucket, error)
+	FindBucketByNameFn    func(context.Context, platform.ID, string) (*influxdb.Bucket, error)
 }
 
 // FindUserByID returns a single User by ID.
@@ -39,3 +40,7 @@ func (s *tenantService) FindOrganization(ctx context.Context, filter influxdb.Or
 func (s *tenantService) FindBucketByID(ctx context.Context, id platform.ID) (*influxdb.Bucket, error) {
 	return s.FindBucketByIDFn(ctx, id)
 }
+
+func (s *tenantService) FindBucketByName(ctx context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error) {
+	return s.FindBucketByNameFn(ctx, orgID, name)
+}
@@ -131,6 +131,9 @@ func (s *Service) FindAuthorizations(ctx context.Context, filter influxdb.Author
 			}
 		}
 
+		if !auth.Annotations.Matches(filter.Annotations) {
+			return []*influxdb.Authorization{}, 0, nil
+		}
 		return []*influxdb.Authorization{auth}, 1, nil
 	}
 
@@ -150,6 +153,9 @@ func (s *Service) FindAuthorizations(ctx context.Context, filter influxdb.Author
 			}
 		}
 
+		if !auth.Annotations.Matches(filter.Annotations) {
+			return []*influxdb.Authorization{}, 0, nil
+		}
 		return []*influxdb.Authorization{auth}, 1, nil
 	}
 
@@ -197,6 +203,12 @@ func (s *Service) UpdateAuthorization(ctx context.Context, id platform.ID, upd *
 	if upd.Description != nil {
 		auth.Description = *upd.Description
 	}
+	if upd.Annotations != nil {
+		if err := upd.Annotations.Valid(); err != nil {
+			return nil, err
+		}
+		auth.Annotations = upd.Annotations.Clone()
+	}
 
 	auth.SetUpdatedAt(time.Now())
 
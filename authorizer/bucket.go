@@ -2,9 +2,11 @@ package authorizer
 
 import (
 	"context"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 )
 
@@ -13,14 +15,30 @@ var _ influxdb.BucketService = (*BucketService)(nil)
 // BucketService wraps a influxdb.BucketService and authorizes actions
 // against it appropriately.
 type BucketService struct {
-	s influxdb.BucketService
+	s      influxdb.BucketService
+	shares influxdb.BucketShareService
+}
+
+// BucketServiceOption configures the authorizing bucket service.
+type BucketServiceOption func(*BucketService)
+
+// WithBucketShares makes the buckets shared with an organization readable
+// through FindSharedBucket.
+func WithBucketShares(shares influxdb.BucketShareService) BucketServiceOption {
+	return func(s *BucketService) {
+		s.shares = shares
+	}
 }
 
 // NewBucketService constructs an instance of an authorizing bucket service.
-func NewBucketService(s influxdb.BucketService) *BucketService {
-	return &BucketService{
+func NewBucketService(s influxdb.BucketService, opts ...BucketServiceOption) *BucketService {
+	svc := &BucketService{
 		s: s,
 	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
 }
 
 // FindBucketByID checks to see if the authorizer on context has read access to the id provided.
@@ -68,6 +86,47 @@ func (s *BucketService) FindBucket(ctx context.Context, filter influxdb.BucketFi
 	return b, nil
 }
 
+// FindSharedBucket returns the bucket with the name shared with the
+// organization. The authorizer on context needs read access to the buckets of
+// the organization shared with, and the share must not have expired.
+func (s *BucketService) FindSharedBucket(ctx context.Context, orgID platform.ID, n string) (*influxdb.Bucket, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	notFound := &errors.Error{
+		Code: errors.ENotFound,
+		Msg:  "shared bucket not found",
+	}
+	if s.shares == nil {
+		return nil, notFound
+	}
+	if _, _, err := AuthorizeOrgReadResource(ctx, influxdb.BucketsResourceType, orgID); err != nil {
+		return nil, err
+	}
+
+	shares, err := s.shares.FindBucketShares(ctx, influxdb.BucketShareFilter{TargetOrgID: &orgID})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, sh := range shares {
+		if !sh.Active(now) {
+			continue
+		}
+		b, err := s.s.FindBucketByID(ctx, sh.BucketID)
+		if errors.ErrorCode(err) == errors.ENotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b.Name == n {
+			return b, nil
+		}
+	}
+	return nil, notFound
+}
+
 // FindBuckets retrieves all buckets that match the provided filter and then filters the list down to only the resources that are authorized.
 func (s *BucketService) FindBuckets(ctx context.Context, filter influxdb.BucketFilter, opt ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
 	span, ctx := tracing.StartSpanFromContext(ctx)
@@ -5,6 +5,7 @@ import (
 	"context"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/influxdb/v2"
@@ -628,3 +629,71 @@ func TestBucketService_CreateBucket(t *testing.T) {
 		})
 	}
 }
+
+type bucketShares []*influxdb.BucketShare
+
+func (s bucketShares) CreateBucketShare(ctx context.Context, sh *influxdb.BucketShare) error {
+	panic("not implemented")
+}
+
+func (s bucketShares) FindBucketShareByID(ctx context.Context, id platform.ID) (*influxdb.BucketShare, error) {
+	panic("not implemented")
+}
+
+func (s bucketShares) FindBucketShares(ctx context.Context, filter influxdb.BucketShareFilter) ([]*influxdb.BucketShare, error) {
+	var shares []*influxdb.BucketShare
+	for _, sh := range s {
+		if filter.TargetOrgID == nil || sh.TargetOrgID == *filter.TargetOrgID {
+			shares = append(shares, sh)
+		}
+	}
+	return shares, nil
+}
+
+func (s bucketShares) DeleteBucketShare(ctx context.Context, id platform.ID) error {
+	panic("not implemented")
+}
+
+func TestBucketService_FindSharedBucket(t *testing.T) {
+	const ownerOrg, targetOrg platform.ID = 10, 11
+	buckets := &mock.BucketService{
+		FindBucketByIDFn: func(ctx context.Context, id platform.ID) (*influxdb.Bucket, error) {
+			return &influxdb.Bucket{ID: id, OrgID: ownerOrg, Name: "bucket" + id.String()}, nil
+		},
+	}
+	expired := time.Now().Add(-time.Minute)
+	s := authorizer.NewBucketService(buckets, authorizer.WithBucketShares(bucketShares{
+		{BucketID: 1, OrgID: ownerOrg, TargetOrgID: targetOrg},
+		{BucketID: 2, OrgID: ownerOrg, TargetOrgID: targetOrg, ExpiresAt: &expired},
+	}))
+
+	readBuckets := func(orgID platform.ID) context.Context {
+		return influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, []influxdb.Permission{{
+			Action:   influxdb.ReadAction,
+			Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: influxdbtesting.IDPtr(orgID)},
+		}}))
+	}
+
+	b, err := s.FindSharedBucket(readBuckets(targetOrg), targetOrg, "bucket"+platform.ID(1).String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ID != 1 {
+		t.Fatalf("expected bucket 1, got %s", b.ID)
+	}
+
+	// expired shares grant no access
+	if _, err := s.FindSharedBucket(readBuckets(targetOrg), targetOrg, "bucket"+platform.ID(2).String()); errors.ErrorCode(err) != errors.ENotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	// access to the buckets of the organization shared with is required
+	if _, err := s.FindSharedBucket(readBuckets(ownerOrg), targetOrg, "bucket"+platform.ID(1).String()); errors.ErrorCode(err) != errors.EUnauthorized {
+		t.Fatalf("expected unauthorized, got %v", err)
+	}
+
+	// without shares no bucket is found
+	if _, err := authorizer.NewBucketService(buckets).FindSharedBucket(readBuckets(targetOrg), targetOrg, "bucket"+platform.ID(1).String()); errors.ErrorCode(err) != errors.ENotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
@@ -68,9 +68,23 @@ func (s *CheckService) CreateCheck(ctx context.Context, chk influxdb.CheckCreate
 	if _, _, err := AuthorizeCreate(ctx, influxdb.ChecksResourceType, chk.GetOrgID()); err != nil {
 		return err
 	}
+	if err := authorizeCheckDatasource(ctx, chk.Check, chk.GetOrgID()); err != nil {
+		return err
+	}
 	return s.s.CreateCheck(ctx, chk, userID)
 }
 
+// authorizeCheckDatasource checks to see if the authorizer on context has read
+// access to the datasource queried by the check, if any.
+func authorizeCheckDatasource(ctx context.Context, chk influxdb.Check, orgID platform.ID) error {
+	dc, ok := chk.(interface{ GetDatasourceID() platform.ID })
+	if !ok {
+		return nil
+	}
+	_, _, err := AuthorizeRead(ctx, influxdb.DatasourcesResourceType, dc.GetDatasourceID(), orgID)
+	return err
+}
+
 // UpdateCheck checks to see if the authorizer on context has write access to the check provided.
 func (s *CheckService) UpdateCheck(ctx context.Context, id platform.ID, upd influxdb.CheckCreate) (influxdb.Check, error) {
 	chk, err := s.FindCheckByID(ctx, id)
@@ -80,6 +94,9 @@ func (s *CheckService) UpdateCheck(ctx context.Context, id platform.ID, upd infl
 	if _, _, err := AuthorizeWrite(ctx, influxdb.ChecksResourceType, chk.GetID(), chk.GetOrgID()); err != nil {
 		return nil, err
 	}
+	if err := authorizeCheckDatasource(ctx, upd.Check, chk.GetOrgID()); err != nil {
+		return nil, err
+	}
 	return s.s.UpdateCheck(ctx, id, upd)
 }
 
@@ -715,3 +715,38 @@ func TestCheckService_CreateCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckService_CreateSQLCheck(t *testing.T) {
+	orgID, datasourceID := platform.ID(10), platform.ID(30)
+	writeChecks := influxdb.Permission{
+		Action:   influxdb.WriteAction,
+		Resource: influxdb.Resource{Type: influxdb.ChecksResourceType, OrgID: &orgID},
+	}
+	readDatasource := influxdb.Permission{
+		Action:   influxdb.ReadAction,
+		Resource: influxdb.Resource{Type: influxdb.DatasourcesResourceType, OrgID: &orgID, ID: &datasourceID},
+	}
+
+	s := authorizer.NewCheckService(&mock.CheckService{
+		CreateCheckFn: func(ctx context.Context, chk influxdb.CheckCreate, userID platform.ID) error {
+			return nil
+		},
+	}, mock.NewUserResourceMappingService(), mock.NewOrganizationService())
+
+	cc := influxdb.CheckCreate{
+		Check: &check.SQL{
+			Base:         check.Base{OrgID: orgID},
+			DatasourceID: datasourceID,
+		},
+		Status: influxdb.Active,
+	}
+
+	ctx := influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, []influxdb.Permission{writeChecks}))
+	influxdbtesting.ErrorsEqual(t, s.CreateCheck(ctx, cc, 3), &errors.Error{
+		Msg:  "read:orgs/000000000000000a/datasources/000000000000001e is unauthorized",
+		Code: errors.EUnauthorized,
+	})
+
+	ctx = influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, []influxdb.Permission{writeChecks, readDatasource}))
+	influxdbtesting.ErrorsEqual(t, s.CreateCheck(ctx, cc, 3), nil)
+}
@@ -88,7 +88,7 @@ func (s *DashboardService) AddDashboardCell(ctx context.Context, id platform.ID,
 	return s.s.AddDashboardCell(ctx, id, c, opts)
 }
 
-func (s *DashboardService) RemoveDashboardCell(ctx context.Context, dashboardID platform.ID, cellID platform.ID) error {
+func (s *DashboardService) RemoveDashboardCell(ctx context.Context, dashboardID platform.ID, cellID platform.ID, opts influxdb.DashboardCellsOptions) error {
 	b, err := s.s.FindDashboardByID(ctx, dashboardID)
 	if err != nil {
 		return err
@@ -96,7 +96,7 @@ func (s *DashboardService) RemoveDashboardCell(ctx context.Context, dashboardID
 	if _, _, err := AuthorizeWrite(ctx, influxdb.DashboardsResourceType, dashboardID, b.OrganizationID); err != nil {
 		return err
 	}
-	return s.s.RemoveDashboardCell(ctx, dashboardID, cellID)
+	return s.s.RemoveDashboardCell(ctx, dashboardID, cellID, opts)
 }
 
 func (s *DashboardService) UpdateDashboardCell(ctx context.Context, dashboardID platform.ID, cellID platform.ID, upd influxdb.CellUpdate) (*influxdb.Cell, error) {
@@ -132,7 +132,7 @@ func (s *DashboardService) UpdateDashboardCellView(ctx context.Context, dashboar
 	return s.s.UpdateDashboardCellView(ctx, dashboardID, cellID, upd)
 }
 
-func (s *DashboardService) ReplaceDashboardCells(ctx context.Context, id platform.ID, c []*influxdb.Cell) error {
+func (s *DashboardService) ReplaceDashboardCells(ctx context.Context, id platform.ID, c []*influxdb.Cell, opts influxdb.DashboardCellsOptions) error {
 	b, err := s.s.FindDashboardByID(ctx, id)
 	if err != nil {
 		return err
@@ -140,5 +140,5 @@ func (s *DashboardService) ReplaceDashboardCells(ctx context.Context, id platfor
 	if _, _, err := AuthorizeWrite(ctx, influxdb.DashboardsResourceType, id, b.OrganizationID); err != nil {
 		return err
 	}
-	return s.s.ReplaceDashboardCells(ctx, id, c)
+	return s.s.ReplaceDashboardCells(ctx, id, c, opts)
 }
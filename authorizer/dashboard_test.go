@@ -575,10 +575,10 @@ func TestDashboardService_WriteDashboardCell(t *testing.T) {
 					AddDashboardCellF: func(ctx context.Context, id platform.ID, c *influxdb.Cell, opts influxdb.AddDashboardCellOptions) error {
 						return nil
 					},
-					RemoveDashboardCellF: func(ctx context.Context, id platform.ID, cid platform.ID) error {
+					RemoveDashboardCellF: func(ctx context.Context, id platform.ID, cid platform.ID, opts influxdb.DashboardCellsOptions) error {
 						return nil
 					},
-					ReplaceDashboardCellsF: func(ctx context.Context, id platform.ID, cs []*influxdb.Cell) error {
+					ReplaceDashboardCellsF: func(ctx context.Context, id platform.ID, cs []*influxdb.Cell, opts influxdb.DashboardCellsOptions) error {
 						return nil
 					},
 					UpdateDashboardCellF: func(ctx context.Context, id platform.ID, cid platform.ID, upd influxdb.CellUpdate) (*influxdb.Cell, error) {
@@ -616,13 +616,13 @@ func TestDashboardService_WriteDashboardCell(t *testing.T) {
 					AddDashboardCellF: func(ctx context.Context, id platform.ID, c *influxdb.Cell, opts influxdb.AddDashboardCellOptions) error {
 						return nil
 					},
-					ReplaceDashboardCellsF: func(ctx context.Context, id platform.ID, cs []*influxdb.Cell) error {
+					ReplaceDashboardCellsF: func(ctx context.Context, id platform.ID, cs []*influxdb.Cell, opts influxdb.DashboardCellsOptions) error {
 						return nil
 					},
 					UpdateDashboardCellF: func(ctx context.Context, id platform.ID, cid platform.ID, upd influxdb.CellUpdate) (*influxdb.Cell, error) {
 						return &influxdb.Cell{}, nil
 					},
-					RemoveDashboardCellF: func(ctx context.Context, id platform.ID, cid platform.ID) error {
+					RemoveDashboardCellF: func(ctx context.Context, id platform.ID, cid platform.ID, opts influxdb.DashboardCellsOptions) error {
 						return nil
 					},
 					UpdateDashboardCellViewF: func(ctx context.Context, id platform.ID, cid platform.ID, upd influxdb.ViewUpdate) (*influxdb.View, error) {
@@ -659,7 +659,7 @@ func TestDashboardService_WriteDashboardCell(t *testing.T) {
 			err := s.AddDashboardCell(ctx, 1, &influxdb.Cell{}, influxdb.AddDashboardCellOptions{})
 			influxdbtesting.ErrorsEqual(t, err, tt.wants.err)
 
-			err = s.RemoveDashboardCell(ctx, 1, 2)
+			err = s.RemoveDashboardCell(ctx, 1, 2, influxdb.DashboardCellsOptions{})
 			influxdbtesting.ErrorsEqual(t, err, tt.wants.err)
 
 			_, err = s.UpdateDashboardCellView(ctx, 1, 2, influxdb.ViewUpdate{})
@@ -668,7 +668,7 @@ func TestDashboardService_WriteDashboardCell(t *testing.T) {
 			_, err = s.UpdateDashboardCell(ctx, 1, 2, influxdb.CellUpdate{})
 			influxdbtesting.ErrorsEqual(t, err, tt.wants.err)
 
-			err = s.ReplaceDashboardCells(ctx, 1, []*influxdb.Cell{})
+			err = s.ReplaceDashboardCells(ctx, 1, []*influxdb.Cell{}, influxdb.DashboardCellsOptions{})
 			influxdbtesting.ErrorsEqual(t, err, tt.wants.err)
 		})
 	}
@@ -124,6 +124,30 @@ func (ts *taskServiceValidator) UpdateTask(ctx context.Context, id platform.ID,
 	return ts.TaskService.UpdateTask(ctx, id, upd)
 }
 
+// UpdateTasksStatus checks to see if the authorizer on context has write access to the tasks of the filter's organization.
+func (ts *taskServiceValidator) UpdateTasksStatus(ctx context.Context, filter taskmodel.TaskFilter, status taskmodel.TaskStatus, dryRun bool) ([]*taskmodel.Task, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	ss, ok := ts.TaskService.(taskmodel.TaskStatusService)
+	if !ok {
+		return nil, taskmodel.ErrTaskStatusUpdateNotSupported
+	}
+	if filter.OrganizationID == nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "an organization is required to update the status of tasks",
+		}
+	}
+
+	a, p, err := AuthorizeOrgWriteResource(ctx, influxdb.TasksResourceType, *filter.OrganizationID)
+	loggerFields := []zap.Field{zap.String("method", "UpdateTasksStatus"), zap.Stringer("org_id", filter.OrganizationID)}
+	if err := ts.processPermissionError(a, p, err, loggerFields...); err != nil {
+		return nil, err
+	}
+	return ss.UpdateTasksStatus(ctx, filter, status, dryRun)
+}
+
 func (ts *taskServiceValidator) DeleteTask(ctx context.Context, id platform.ID) error {
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
@@ -143,6 +143,10 @@ const (
 	RemotesResourceType = ResourceType("remotes") // 20
 	// ReplicationsResourceType gives permission to one or more replications.
 	ReplicationsResourceType = ResourceType("replications") // 21
+	// DatasourcesResourceType gives permission to one or more external datasources.
+	DatasourcesResourceType = ResourceType("datasources") // 22
+	// ParsersResourceType gives permission to one or more query time parsers.
+	ParsersResourceType = ResourceType("parsers") // 23
 )
 
 // AllResourceTypes is the list of all known resource types.
@@ -169,6 +173,8 @@ var AllResourceTypes = []ResourceType{
 	AnnotationsResourceType,          // 19
 	RemotesResourceType,              // 20
 	ReplicationsResourceType,         // 21
+	DatasourcesResourceType,          // 22
+	ParsersResourceType,              // 23
 	// NOTE: when modifying this list, please update the swagger for components.schemas.Permission resource enum.
 }
 
@@ -202,6 +208,8 @@ func (t ResourceType) Valid() (err error) {
 	case AnnotationsResourceType: // 19
 	case RemotesResourceType: // 20
 	case ReplicationsResourceType: // 21
+	case DatasourcesResourceType: // 22
+	case ParsersResourceType: // 23
 	default:
 		err = ErrInvalidResourceType
 	}
@@ -32,14 +32,22 @@ const InfiniteRetention = 0
 
 // Bucket is a bucket. 🎉
 type Bucket struct {
-	ID                  platform.ID   `json:"id,omitempty"`
-	OrgID               platform.ID   `json:"orgID,omitempty"`
-	Type                BucketType    `json:"type"`
-	Name                string        `json:"name"`
-	Description         string        `json:"description"`
-	RetentionPolicyName string        `json:"rp,omitempty"` // This to support v1 sources
-	RetentionPeriod     time.Duration `json:"retentionPeriod"`
-	ShardGroupDuration  time.Duration `json:"shardGroupDuration"`
+	ID                  platform.ID         `json:"id,omitempty"`
+	OrgID               platform.ID         `json:"orgID,omitempty"`
+	Type                BucketType          `json:"type"`
+	Name                string              `json:"name"`
+	Description         string              `json:"description"`
+	RetentionPolicyName string              `json:"rp,omitempty"` // This to support v1 sources
+	RetentionPeriod     time.Duration       `json:"retentionPeriod"`
+	ShardGroupDuration  time.Duration       `json:"shardGroupDuration"`
+	Annotations         ResourceAnnotations `json:"annotations,omitempty"`
+	// SeriesTTL expires the series that received no points for longer than
+	// it, independently of the retention period. Zero keeps series until
+	// their data expires.
+	SeriesTTL time.Duration `json:"seriesTTL,omitempty"`
+	// Limits bound the growth of the schema of the bucket. Nil does not
+	// bound it.
+	Limits *BucketLimits `json:"limits,omitempty"`
 	CRUDLog
 }
 
@@ -49,6 +57,34 @@ func (b *Bucket) Clone() *Bucket {
 	return &other
 }
 
+// BucketLimits bound the growth of the schema of a bucket, so a runaway
+// writer cannot degrade the whole instance. The points of a write that would
+// exceed a limit are rejected, the others are written. Zero does not bound.
+type BucketLimits struct {
+	// MaxTagKeys bounds the tag keys of the bucket, over all measurements.
+	MaxTagKeys int `json:"maxTagKeys,omitempty"`
+	// MaxFieldsPerMeasurement bounds the field keys of every measurement.
+	MaxFieldsPerMeasurement int `json:"maxFieldsPerMeasurement,omitempty"`
+	// MaxNewSeriesPerHour bounds the series created in the last hour.
+	MaxNewSeriesPerHour int `json:"maxNewSeriesPerHour,omitempty"`
+}
+
+// Valid returns an error if a limit is negative.
+func (l *BucketLimits) Valid() error {
+	if l.MaxTagKeys < 0 || l.MaxFieldsPerMeasurement < 0 || l.MaxNewSeriesPerHour < 0 {
+		return &errors.Error{
+			Code: errors.EUnprocessableEntity,
+			Msg:  "bucket limits cannot be negative",
+		}
+	}
+	return nil
+}
+
+// IsZero reports whether no limit is set.
+func (l *BucketLimits) IsZero() bool {
+	return l == nil || *l == BucketLimits{}
+}
+
 // BucketType differentiates system buckets from user buckets.
 type BucketType int
 
@@ -110,6 +146,123 @@ type BucketUpdate struct {
 	Description        *string
 	RetentionPeriod    *time.Duration
 	ShardGroupDuration *time.Duration
+	SeriesTTL          *time.Duration
+	// Limits replaces all limits of the bucket when set.
+	Limits *BucketLimits
+	// Annotations replaces all annotations of the bucket when set.
+	Annotations *ResourceAnnotations
+}
+
+// RetentionEnforcer enforces the retention period of a bucket immediately,
+// rather than on the next pass of the retention service.
+type RetentionEnforcer interface {
+	// EnforceBucketRetention removes the data of the bucket that is older than
+	// the retention period. The bucket's current retention period is used when
+	// retentionPeriod is nil. A dry run reports the data without removing it.
+	EnforceBucketRetention(ctx context.Context, bucketID platform.ID, retentionPeriod *time.Duration, dryRun bool) (*RetentionEnforcement, error)
+}
+
+// RetentionEnforcement describes the data removed from a bucket when its
+// retention period was enforced, or the data a dry run would remove.
+type RetentionEnforcement struct {
+	DryRun          bool
+	RetentionPeriod time.Duration
+	// Cutoff is the time before which data is removed.
+	Cutoff time.Time
+	Ranges []RetentionRange
+}
+
+// RetentionRange is a range of data removed from a shard group. When the
+// whole shard group expired, it is dropped rather than deleted from.
+type RetentionRange struct {
+	ShardGroupID uint64
+	Start        time.Time
+	End          time.Time
+	Dropped      bool
+}
+
+// BucketActivity is approximately when data was last written to and queried
+// from a bucket. A zero time means no activity was recorded.
+type BucketActivity struct {
+	LastWriteAt time.Time
+	LastQueryAt time.Time
+}
+
+// BucketActivityRecorder records writes to and queries of buckets.
+type BucketActivityRecorder interface {
+	RecordBucketWrite(bucketID platform.ID)
+	RecordBucketQuery(bucketID platform.ID)
+}
+
+// BucketActivityFinder finds the recorded activity of buckets.
+type BucketActivityFinder interface {
+	// FindBucketActivity returns the activity of each of the buckets. Buckets
+	// without recorded activity are omitted.
+	FindBucketActivity(ctx context.Context, bucketIDs ...platform.ID) (map[platform.ID]BucketActivity, error)
+}
+
+// BucketCardinality is the number of series of a bucket, broken down by
+// measurement.
+type BucketCardinality struct {
+	BucketID platform.ID `json:"bucketID"`
+	// Exact is false when the counts are estimated.
+	Exact        bool                     `json:"exact"`
+	Series       int64                    `json:"series"`
+	Measurements []MeasurementCardinality `json:"measurements"`
+}
+
+// MeasurementCardinality is the number of series of a measurement and the
+// number of values of its tag keys with the most values.
+type MeasurementCardinality struct {
+	Name    string              `json:"name"`
+	Series  int64               `json:"series"`
+	TagKeys []TagKeyCardinality `json:"tagKeys"`
+}
+
+// TagKeyCardinality is the number of values of a tag key.
+type TagKeyCardinality struct {
+	Key    string `json:"key"`
+	Values int64  `json:"values"`
+}
+
+// BucketCardinalityFinder finds the series cardinality of buckets.
+type BucketCardinalityFinder interface {
+	// FindBucketCardinality counts the series of the bucket, exactly or
+	// estimated, and the values of the topTagKeys tag keys of each
+	// measurement with the most values.
+	FindBucketCardinality(ctx context.Context, bucketID platform.ID, exact bool, topTagKeys int) (*BucketCardinality, error)
+}
+
+// ShardGroupStatus is the time range of a shard group of a bucket and the
+// state of its shards on disk.
+type ShardGroupStatus struct {
+	ID     uint64        `json:"id"`
+	Start  time.Time     `json:"start"`
+	End    time.Time     `json:"end"`
+	Shards []ShardStatus `json:"shards"`
+}
+
+// ShardStatus is the state of a shard on disk. A shard whose files are not
+// open on this server only reports its ID.
+type ShardStatus struct {
+	ID       uint64 `json:"id"`
+	DiskSize int64  `json:"diskSize"`
+	TSMFiles int    `json:"tsmFiles"`
+	// CompactionLevel is the highest compaction level of the TSM files of
+	// the shard, from 1 to 4.
+	CompactionLevel int  `json:"compactionLevel"`
+	FullyCompacted  bool `json:"fullyCompacted"`
+	// TombstoneFiles is the number of TSM files with deleted data that a
+	// compaction has yet to remove.
+	TombstoneFiles int   `json:"tombstoneFiles"`
+	TombstoneSize  int64 `json:"tombstoneSize"`
+}
+
+// BucketShardFinder finds the shards storing the data of buckets.
+type BucketShardFinder interface {
+	// FindBucketShards returns the shard groups of the bucket, ordered by
+	// time.
+	FindBucketShards(ctx context.Context, bucketID platform.ID) ([]ShardGroupStatus, error)
 }
 
 // BucketFilter represents a set of filter that restrict the returned results.
@@ -118,6 +271,7 @@ type BucketFilter struct {
 	Name           *string
 	OrganizationID *platform.ID
 	Org            *string
+	Annotations    ResourceAnnotations
 }
 
 // QueryParams Converts BucketFilter fields to url query params.
@@ -139,6 +293,8 @@ func (f BucketFilter) QueryParams() map[string][]string {
 		qp["org"] = []string{*f.Org}
 	}
 
+	f.Annotations.AddQueryParams(qp)
+
 	return qp
 }
 
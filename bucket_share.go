@@ -0,0 +1,58 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// BucketShare grants an organization read access to a bucket of another
+// organization. Whoever can read the buckets of the organization shared
+// with can read the shared bucket, but not write to it.
+type BucketShare struct {
+	ID       platform.ID `json:"id"`
+	BucketID platform.ID `json:"bucketID"`
+	// OrgID is the organization owning the bucket.
+	OrgID platform.ID `json:"orgID"`
+	// TargetOrgID is the organization the bucket is shared with.
+	TargetOrgID platform.ID `json:"targetOrgID"`
+	Description string      `json:"description,omitempty"`
+	// ExpiresAt ends the share. Shares without it last until deleted.
+	ExpiresAt *time.Time  `json:"expiresAt,omitempty"`
+	SharedBy  platform.ID `json:"sharedBy,omitempty"`
+	CRUDLog
+}
+
+// Active reports whether the share grants access at t.
+func (s *BucketShare) Active(t time.Time) bool {
+	return s.ExpiresAt == nil || t.Before(*s.ExpiresAt)
+}
+
+// BucketShareFilter represents a set of filters that restrict the returned
+// bucket shares.
+type BucketShareFilter struct {
+	// OrgID matches the shares of the buckets of the organization and the
+	// shares with the organization.
+	OrgID       *platform.ID
+	TargetOrgID *platform.ID
+	BucketID    *platform.ID
+}
+
+// BucketShareService represents a service for sharing buckets with other
+// organizations.
+type BucketShareService interface {
+	// CreateBucketShare shares a bucket with the target organization of the
+	// share.
+	CreateBucketShare(ctx context.Context, s *BucketShare) error
+
+	// FindBucketShareByID returns a single bucket share by ID.
+	FindBucketShareByID(ctx context.Context, id platform.ID) (*BucketShare, error)
+
+	// FindBucketShares returns the bucket shares matching the filter,
+	// including expired shares.
+	FindBucketShares(ctx context.Context, filter BucketShareFilter) ([]*BucketShare, error)
+
+	// DeleteBucketShare revokes a bucket share.
+	DeleteBucketShare(ctx context.Context, id platform.ID) error
+}
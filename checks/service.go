@@ -25,8 +25,10 @@ type Service struct {
 
 	log *zap.Logger
 
-	orgs  influxdb.OrganizationService
-	tasks taskmodel.TaskService
+	orgs        influxdb.OrganizationService
+	tasks       taskmodel.TaskService
+	buckets     influxdb.BucketService
+	datasources DatasourceFinder
 
 	timeGenerator influxdb.TimeGenerator
 	idGenerator   platform.IDGenerator
@@ -48,6 +50,84 @@ func NewService(logger *zap.Logger, store kv.Store, orgs influxdb.OrganizationSe
 	}
 }
 
+// WithBucketService validates that the status buckets of checks are
+// buckets of their organization.
+func (s *Service) WithBucketService(buckets influxdb.BucketService) {
+	s.buckets = buckets
+}
+
+// validateStatusBucket returns an error when the status bucket of the check
+// is not a bucket of the organization.
+func (s *Service) validateStatusBucket(ctx context.Context, orgID platform.ID, c influxdb.Check) error {
+	sb, ok := c.(interface{ GetStatusBucket() string })
+	if !ok || sb.GetStatusBucket() == "" || s.buckets == nil {
+		return nil
+	}
+
+	if _, err := s.buckets.FindBucketByName(ctx, orgID, sb.GetStatusBucket()); err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("Check StatusBucket %s is not a bucket of the organization", sb.GetStatusBucket()),
+			Err:  err,
+		}
+	}
+	return nil
+}
+
+// resolveTimezone gives a check without a time zone the default time zone
+// of its organization.
+func (s *Service) resolveTimezone(ctx context.Context, orgID platform.ID, c influxdb.Check) error {
+	tz, ok := c.(interface {
+		GetTimezone() string
+		SetTimezone(string)
+	})
+	if !ok || tz.GetTimezone() != "" {
+		return nil
+	}
+
+	o, err := s.orgs.FindOrganizationByID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	tz.SetTimezone(o.DefaultTimezone)
+	return nil
+}
+
+// DatasourceFinder finds the external datasources queried by SQL checks.
+type DatasourceFinder interface {
+	GetDatasource(ctx context.Context, id platform.ID) (*influxdb.Datasource, error)
+}
+
+// WithDatasourceService validates that the datasources of SQL checks are
+// datasources of their organization, and sets the driver of the checks from
+// them.
+func (s *Service) WithDatasourceService(datasources DatasourceFinder) {
+	s.datasources = datasources
+}
+
+// resolveDatasource sets the driver of a SQL check from its datasource. It
+// returns an error when the datasource is not one of the organization.
+func (s *Service) resolveDatasource(ctx context.Context, orgID platform.ID, c influxdb.Check) error {
+	sc, ok := c.(*check.SQL)
+	if !ok || s.datasources == nil {
+		return nil
+	}
+
+	ds, err := s.datasources.GetDatasource(ctx, sc.DatasourceID)
+	if err == nil && ds.OrgID != orgID {
+		err = &errors.Error{Code: errors.ENotFound, Msg: "datasource not found"}
+	}
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("Check DatasourceID %s is not a datasource of the organization", sc.DatasourceID),
+			Err:  err,
+		}
+	}
+	sc.Driver = ds.Driver
+	return nil
+}
+
 func newCheckStore() *kv.IndexStore {
 	const resource = "check"
 
@@ -301,6 +381,18 @@ func (s *Service) CreateCheck(ctx context.Context, c influxdb.CheckCreate, userI
 		return err
 	}
 
+	if err := s.validateStatusBucket(ctx, c.GetOrgID(), c.Check); err != nil {
+		return err
+	}
+
+	if err := s.resolveDatasource(ctx, c.GetOrgID(), c.Check); err != nil {
+		return err
+	}
+
+	if err := s.resolveTimezone(ctx, c.GetOrgID(), c.Check); err != nil {
+		return err
+	}
+
 	// create task initially in inactive state
 	t, err := s.createCheckTask(ctx, c)
 	if err != nil {
@@ -415,6 +507,20 @@ func (s *Service) UpdateCheck(ctx context.Context, id platform.ID, chk influxdb.
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	current, err := s.FindCheckByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateStatusBucket(ctx, current.GetOrgID(), chk.Check); err != nil {
+		return nil, err
+	}
+	if err := s.resolveDatasource(ctx, current.GetOrgID(), chk.Check); err != nil {
+		return nil, err
+	}
+	if err := s.resolveTimezone(ctx, current.GetOrgID(), chk.Check); err != nil {
+		return nil, err
+	}
+
 	var check influxdb.Check
 	if err := s.kv.Update(ctx, func(tx kv.Tx) error {
 		c, err := s.updateCheck(ctx, tx, id, chk)
@@ -7,12 +7,18 @@ import (
 	"github.com/influxdata/influxdb/v2"
 	_ "github.com/influxdata/influxdb/v2/fluxinit/static"
 	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kv"
 	"github.com/influxdata/influxdb/v2/kv/migration/all"
 	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/notification"
+	"github.com/influxdata/influxdb/v2/notification/check"
 	"github.com/influxdata/influxdb/v2/query/fluxlang"
 	"github.com/influxdata/influxdb/v2/task/taskmodel"
 	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -87,3 +93,168 @@ func initCheckService(f CheckFields, t *testing.T) (influxdb.CheckService, taskm
 		closeKVStore()
 	}
 }
+
+func TestService_CreateCheck_StatusBucket(t *testing.T) {
+	store, closeKVStore := NewKVTestStore(t)
+	defer closeKVStore()
+	logger := zaptest.NewLogger(t)
+
+	tenantSvc := tenant.NewService(tenant.NewStore(store))
+	svc := kv.NewService(logger, store, tenantSvc, kv.ServiceConfig{
+		FluxLanguageService: fluxlang.DefaultService,
+	})
+
+	ctx := context.Background()
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, tenantSvc.CreateOrganization(ctx, org))
+
+	checkService := NewService(logger, store, tenantSvc, svc)
+	checkService.WithBucketService(&mock.BucketService{
+		FindBucketByNameFn: func(ctx context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error) {
+			if orgID == org.ID && name == "statuses" {
+				return &influxdb.Bucket{OrgID: orgID, Name: name}, nil
+			}
+			return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+		},
+	})
+
+	newCheck := func(name, statusBucket string) influxdb.CheckCreate {
+		return influxdb.CheckCreate{
+			Check: &check.Deadman{
+				Base: check.Base{
+					Name:                  name,
+					OrgID:                 org.ID,
+					Every:                 mustDuration("1m"),
+					StatusMessageTemplate: "msg",
+					StatusBucket:          statusBucket,
+					Query:                 influxdb.DashboardQuery{Text: script},
+				},
+				TimeSince: mustDuration("1m"),
+				StaleTime: mustDuration("10m"),
+				Level:     notification.Info,
+			},
+			Status: influxdb.Active,
+		}
+	}
+
+	err := checkService.CreateCheck(ctx, newCheck("unknown", "nope"), 1)
+	require.Error(t, err)
+	assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	require.NoError(t, checkService.CreateCheck(ctx, newCheck("redirected", "statuses"), 1))
+}
+
+func TestService_CreateCheck_DefaultTimezone(t *testing.T) {
+	store, closeKVStore := NewKVTestStore(t)
+	defer closeKVStore()
+	logger := zaptest.NewLogger(t)
+
+	tenantSvc := tenant.NewService(tenant.NewStore(store))
+	svc := kv.NewService(logger, store, tenantSvc, kv.ServiceConfig{
+		FluxLanguageService: fluxlang.DefaultService,
+	})
+
+	ctx := context.Background()
+	err := tenantSvc.CreateOrganization(ctx, &influxdb.Organization{Name: "bad", DefaultTimezone: "Mars/Olympus"})
+	assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	org := &influxdb.Organization{Name: "org", DefaultTimezone: "Europe/Berlin"}
+	require.NoError(t, tenantSvc.CreateOrganization(ctx, org))
+
+	checkService := NewService(logger, store, tenantSvc, svc)
+	newCheck := func(name, tz string) influxdb.CheckCreate {
+		return influxdb.CheckCreate{
+			Check: &check.Deadman{
+				Base: check.Base{
+					Name:                  name,
+					OrgID:                 org.ID,
+					Every:                 mustDuration("1m"),
+					StatusMessageTemplate: "msg",
+					Timezone:              tz,
+					Query:                 influxdb.DashboardQuery{Text: script},
+				},
+				TimeSince: mustDuration("1m"),
+				StaleTime: mustDuration("10m"),
+				Level:     notification.Info,
+			},
+			Status: influxdb.Active,
+		}
+	}
+
+	defaulted := newCheck("defaulted", "")
+	require.NoError(t, checkService.CreateCheck(ctx, defaulted, 1))
+	own := newCheck("own", "America/New_York")
+	require.NoError(t, checkService.CreateCheck(ctx, own, 1))
+
+	for c, tz := range map[influxdb.Check]string{
+		defaulted.Check: "Europe/Berlin",
+		own.Check:       "America/New_York",
+	} {
+		found, err := checkService.FindCheckByID(ctx, c.GetID())
+		require.NoError(t, err)
+		assert.Equal(t, tz, found.(*check.Deadman).Timezone)
+	}
+}
+
+type datasourceFinderFunc func(ctx context.Context, id platform.ID) (*influxdb.Datasource, error)
+
+func (fn datasourceFinderFunc) GetDatasource(ctx context.Context, id platform.ID) (*influxdb.Datasource, error) {
+	return fn(ctx, id)
+}
+
+func TestService_CreateCheck_SQLDatasource(t *testing.T) {
+	store, closeKVStore := NewKVTestStore(t)
+	defer closeKVStore()
+	logger := zaptest.NewLogger(t)
+
+	tenantSvc := tenant.NewService(tenant.NewStore(store))
+	svc := kv.NewService(logger, store, tenantSvc, kv.ServiceConfig{
+		FluxLanguageService: fluxlang.DefaultService,
+	})
+
+	ctx := context.Background()
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, tenantSvc.CreateOrganization(ctx, org))
+
+	checkService := NewService(logger, store, tenantSvc, svc)
+	checkService.WithDatasourceService(datasourceFinderFunc(func(ctx context.Context, id platform.ID) (*influxdb.Datasource, error) {
+		switch id {
+		case 30:
+			return &influxdb.Datasource{ID: id, OrgID: org.ID, Name: "orders", Driver: "mysql"}, nil
+		case 40:
+			return &influxdb.Datasource{ID: id, OrgID: org.ID + 1, Name: "elsewhere", Driver: "postgres"}, nil
+		}
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: "datasource not found"}
+	}))
+
+	newCheck := func(name string, datasourceID platform.ID) influxdb.CheckCreate {
+		return influxdb.CheckCreate{
+			Check: &check.SQL{
+				Base: check.Base{
+					Name:                  name,
+					OrgID:                 org.ID,
+					Every:                 mustDuration("1m"),
+					StatusMessageTemplate: "msg",
+				},
+				DatasourceID: datasourceID,
+				SQLQuery:     "SELECT count(*) AS open FROM orders",
+				Field:        "open",
+			},
+			Status: influxdb.Active,
+		}
+	}
+
+	for name, id := range map[string]platform.ID{"unknown": 50, "other org": 40} {
+		err := checkService.CreateCheck(ctx, newCheck(name, id), 1)
+		require.Error(t, err, name)
+		assert.Equal(t, errors.EInvalid, errors.ErrorCode(err), name)
+	}
+
+	c := newCheck("orders", 30)
+	require.NoError(t, checkService.CreateCheck(ctx, c, 1))
+	assert.Equal(t, "mysql", c.Check.(*check.SQL).Driver, "the driver is set from the datasource")
+
+	task, err := svc.FindTaskByID(ctx, c.GetTaskID())
+	require.NoError(t, err)
+	assert.Contains(t, task.Flux, `sql.from(driverName: "mysql", dataSourceName: secrets.get(key: "000000000000001e-dsn")`)
+}
@@ -0,0 +1,67 @@
+package attach
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/admin"
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/spf13/cobra"
+)
+
+// NewCommand creates the command attaching to the admin console of a
+// running influxd.
+func NewCommand() *cobra.Command {
+	var socketPath string
+	cmd := &cobra.Command{
+		Use:   "attach [command [args...]]",
+		Short: "Attach to the admin console of a running influxd",
+		Long: `
+This command attaches to the admin socket of an influxd running on this host
+and runs a command of its console, or reads commands from stdin when none is
+given. The console does not go through the HTTP API, so it can be used to
+inspect a server that no longer answers HTTP requests. Run "help" for the
+commands of the console.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := admin.Dial(socketPath)
+			if err != nil {
+				return fmt.Errorf("failed to attach to influxd on %s: %w", socketPath, err)
+			}
+			defer c.Close()
+
+			if len(args) > 0 {
+				out, err := c.Run(strings.Join(args, " "))
+				cmd.Print(out)
+				return err
+			}
+
+			in := bufio.NewScanner(cmd.InOrStdin())
+			for {
+				cmd.Print("> ")
+				if !in.Scan() {
+					cmd.Println()
+					return in.Err()
+				}
+				line := strings.TrimSpace(in.Text())
+				if line == "exit" || line == "quit" {
+					return nil
+				}
+				out, err := c.Run(line)
+				cmd.Print(out)
+				if err != nil {
+					cmd.PrintErrln("Error:", err)
+				}
+			}
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(err)
+	}
+	cmd.Flags().StringVar(&socketPath, "socket", filepath.Join(dir, "influxd.sock"), "Path to the admin socket of influxd")
+
+	return cmd
+}
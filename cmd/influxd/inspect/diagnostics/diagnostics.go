@@ -0,0 +1,123 @@
+package diagnostics
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/diagnostics"
+	influxfs "github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+)
+
+type args struct {
+	dataDir    string
+	outputPath string
+}
+
+func NewDiagnosticsCommand() *cobra.Command {
+	var arguments args
+	cmd := &cobra.Command{
+		Use:   "diagnostics",
+		Short: "Export diagnostics as line protocol",
+		Long: `
+This command exports the build information and the on-disk statistics of every
+shard as line protocol, which can be written into a support bucket.
+It only reads the data directory, so it can be run while influxd is stopped.
+The diagnostics of a running server, including its redacted configuration,
+series cardinality and error counters, are served at /api/v2/diagnostics.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return arguments.run(cmd)
+		},
+	}
+
+	dir, err := influxfs.InfluxDir()
+	if err != nil {
+		panic(err)
+	}
+	dir = filepath.Join(dir, "engine/data")
+	cmd.Flags().StringVar(&arguments.dataDir, "data-path", dir, "use provided data directory")
+	cmd.Flags().StringVar(&arguments.outputPath, "output-path", "", "file the line protocol is written to; defaults to stdout")
+
+	return cmd
+}
+
+func (a *args) run(cmd *cobra.Command) error {
+	now := time.Now()
+
+	points := models.Points{diagnostics.BuildPoint(now)}
+	shards, err := shardPoints(a.dataDir, now)
+	if err != nil {
+		return err
+	}
+	points = append(points, shards...)
+
+	var w io.Writer = cmd.OutOrStdout()
+	if a.outputPath != "" {
+		f, err := os.Create(a.outputPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return diagnostics.WriteLineProtocol(w, points)
+}
+
+// shardPoints returns the file statistics of the shards in the data
+// directory, which is laid out as <bucket>/<retention policy>/<shard id>.
+func shardPoints(dataDir string, now time.Time) (models.Points, error) {
+	shardDirs, err := filepath.Glob(filepath.Join(dataDir, "*", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var points models.Points
+	for _, dir := range shardDirs {
+		id := filepath.Base(dir)
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+			continue
+		}
+
+		var size, tsmFiles int64
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += fi.Size()
+			if strings.HasSuffix(path, "."+tsm1.TSMFileExtension) {
+				tsmFiles++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		rpDir := filepath.Dir(dir)
+		points = append(points, models.MustNewPoint(diagnostics.MeasurementShard,
+			models.NewTags(map[string]string{
+				"bucket":           filepath.Base(filepath.Dir(rpDir)),
+				"retention_policy": filepath.Base(rpDir),
+				"shard_id":         id,
+			}),
+			models.Fields{
+				"disk_bytes": size,
+				"tsm_files":  tsmFiles,
+			},
+			now,
+		))
+	}
+	return points, nil
+}
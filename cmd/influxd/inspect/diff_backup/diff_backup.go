@@ -0,0 +1,425 @@
+package diff_backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+)
+
+// errDiffer fails the command when the data sets differ, so that scripts
+// verifying backups can check its exit code.
+var errDiffer = errors.New("found differences")
+
+type args struct {
+	enginePath string
+}
+
+func NewDiffBackupCommand() *cobra.Command {
+	var arguments args
+	cmd := &cobra.Command{
+		Use:   "diff-backup <backup-path> [<other-backup-path>]",
+		Short: "Compare a backup with another backup or with live data",
+		Long: `
+This command compares the buckets, shards and series counts of a backup with
+those of another backup, or with the data of the storage engine at
+--engine-path when a single backup is given. The latest manifest of each
+backup directory is compared.
+
+Shards are matched by bucket and shard ID. Restores give shards new IDs, so
+compare backups of the same instance, or a backup with the instance it was
+taken from. Series of live data are counted from its TSM files: series only
+in the WAL are not counted until they are compacted.
+
+Only the differences are printed. The command fails when there are any.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return arguments.run(cmd, args)
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(err)
+	}
+	dir = filepath.Join(dir, "engine")
+	cmd.Flags().StringVar(&arguments.enginePath, "engine-path", dir, "Path to the storage engine compared with when a single backup is given")
+
+	return cmd
+}
+
+func (a *args) run(cmd *cobra.Command, paths []string) error {
+	from, err := loadBackup(paths[0])
+	if err != nil {
+		return err
+	}
+
+	var to *dataset
+	if len(paths) == 2 {
+		to, err = loadBackup(paths[1])
+	} else {
+		to, err = loadEngine(a.enginePath)
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := diff(cmd.OutOrStdout(), from, to)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return errDiffer
+	}
+	return nil
+}
+
+// dataset is the buckets and shards of a backup or of a storage engine.
+type dataset struct {
+	name    string
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	id   string
+	name string
+	// shards maps the IDs of the shards of the bucket to their series
+	// counts.
+	shards map[uint64]int
+}
+
+func (d *dataset) bucket(id, name string) *bucket {
+	b, ok := d.buckets[id]
+	if !ok {
+		b = &bucket{id: id, shards: make(map[uint64]int)}
+		d.buckets[id] = b
+	}
+	if b.name == "" {
+		b.name = name
+	}
+	return b
+}
+
+// manifest is the manifest the influx CLI writes to a backup directory. Its
+// Files are those of the manifests of backups taken before the buckets of
+// a backup were recorded with their shards.
+type manifest struct {
+	Buckets []struct {
+		BucketID          string `json:"bucketID"`
+		BucketName        string `json:"bucketName"`
+		RetentionPolicies []struct {
+			ShardGroups []struct {
+				Shards []struct {
+					ID       uint64 `json:"id"`
+					FileName string `json:"fileName"`
+				} `json:"shards"`
+			} `json:"shardGroups"`
+		} `json:"retentionPolicies"`
+	} `json:"buckets"`
+	Files []struct {
+		BucketID   string `json:"bucketID"`
+		BucketName string `json:"bucketName"`
+		ShardID    uint64 `json:"shardID"`
+		FileName   string `json:"fileName"`
+	} `json:"files"`
+}
+
+// loadBackup reads the latest manifest of the backup directory and counts
+// the series of the shards it lists.
+func loadBackup(dir string) (*dataset, error) {
+	manifests, err := filepath.Glob(filepath.Join(dir, "*.manifest"))
+	if err != nil {
+		return nil, err
+	}
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("no backup manifest found in %s", dir)
+	}
+	// Manifests are named after the time of their backup.
+	sort.Strings(manifests)
+	path := manifests[len(manifests)-1]
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode backup manifest %s: %w", path, err)
+	}
+
+	d := &dataset{name: path, buckets: make(map[string]*bucket)}
+	addShard := func(bucketID, bucketName string, shardID uint64, fileName string) error {
+		b := d.bucket(bucketID, bucketName)
+		if fileName == "" {
+			b.shards[shardID] = 0
+			return nil
+		}
+		n, err := countBackupSeries(filepath.Join(dir, fileName))
+		if err != nil {
+			return fmt.Errorf("failed to read shard %d of backup: %w", shardID, err)
+		}
+		b.shards[shardID] = n
+		return nil
+	}
+
+	for _, mb := range m.Buckets {
+		d.bucket(mb.BucketID, mb.BucketName)
+		for _, rp := range mb.RetentionPolicies {
+			for _, sg := range rp.ShardGroups {
+				for _, s := range sg.Shards {
+					if err := addShard(mb.BucketID, mb.BucketName, s.ID, s.FileName); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+	for _, f := range m.Files {
+		if err := addShard(f.BucketID, f.BucketName, f.ShardID, f.FileName); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// loadEngine counts the series of the shards of a storage engine, laid out
+// as data/<bucket ID>/<retention policy>/<shard ID>.
+func loadEngine(enginePath string) (*dataset, error) {
+	dataDir := filepath.Join(enginePath, "data")
+	d := &dataset{name: enginePath, buckets: make(map[string]*bucket)}
+
+	bucketDirs, err := os.ReadDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, bd := range bucketDirs {
+		if !bd.IsDir() || strings.HasPrefix(bd.Name(), "_") {
+			continue
+		}
+		b := d.bucket(bd.Name(), "")
+
+		rpDirs, err := os.ReadDir(filepath.Join(dataDir, bd.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, rd := range rpDirs {
+			if !rd.IsDir() {
+				continue
+			}
+			shardDirs, err := os.ReadDir(filepath.Join(dataDir, bd.Name(), rd.Name()))
+			if err != nil {
+				return nil, err
+			}
+			for _, sd := range shardDirs {
+				id, err := strconv.ParseUint(sd.Name(), 10, 64)
+				if !sd.IsDir() || err != nil {
+					continue
+				}
+				n, err := countSeries(filepath.Join(dataDir, bd.Name(), rd.Name(), sd.Name()))
+				if err != nil {
+					return nil, fmt.Errorf("failed to read shard %d: %w", id, err)
+				}
+				b.shards[id] = n
+			}
+		}
+	}
+	return d, nil
+}
+
+// countBackupSeries extracts the backup of a shard, a tar archive which may
+// be compressed with gzip, and counts the series of its TSM files.
+func countBackupSeries(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, err := br.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	dir, err := os.MkdirTemp("", "diff-backup")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Tombstones are extracted next to their TSM files, which applies
+		// them when the files are read.
+		out, err := os.Create(filepath.Join(dir, filepath.Base(hdr.Name)))
+		if err != nil {
+			return 0, err
+		}
+		_, err = io.Copy(out, tr)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return countSeries(dir)
+}
+
+// countSeries counts the distinct series of the TSM files of a shard
+// directory.
+func countSeries(dir string) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*."+tsm1.TSMFileExtension))
+	if err != nil {
+		return 0, err
+	}
+
+	series := make(map[string]struct{})
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		r, err := tsm1.NewTSMReader(f)
+		if err != nil {
+			f.Close()
+			return 0, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		for i := 0; i < r.KeyCount(); i++ {
+			key, _ := r.KeyAt(i)
+			seriesKey, _ := tsm1.SeriesAndFieldFromCompositeKey(key)
+			series[string(seriesKey)] = struct{}{}
+		}
+		if err := r.Close(); err != nil {
+			return 0, err
+		}
+	}
+	return len(series), nil
+}
+
+// diff prints the differences between the data sets and returns their
+// number.
+func diff(w io.Writer, from, to *dataset) (int, error) {
+	fmt.Fprintf(w, "Comparing %s (A) with %s (B)\n\n", from.name, to.name)
+
+	ids := make(map[string]struct{})
+	for id := range from.buckets {
+		ids[id] = struct{}{}
+	}
+	for id := range to.buckets {
+		ids[id] = struct{}{}
+	}
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+
+	tw := tabwriter.NewWriter(w, 8, 2, 2, ' ', 0)
+	fmt.Fprintln(tw, "BUCKET\tSHARD\tA SERIES\tB SERIES\tDIFFERENCE")
+	var n int
+	row := func(b, shard, a, bs, difference string) {
+		n++
+		fmt.Fprintln(tw, strings.Join([]string{b, shard, a, bs, difference}, "\t"))
+	}
+	for _, id := range sorted {
+		fb, tb := from.buckets[id], to.buckets[id]
+		switch {
+		case tb == nil:
+			row(fb.String(), "-", strconv.Itoa(fb.series()), "-", "bucket only in A")
+			continue
+		case fb == nil:
+			row(tb.String(), "-", "-", strconv.Itoa(tb.series()), "bucket only in B")
+			continue
+		}
+
+		name := fb.String()
+		if fb.name == "" {
+			name = tb.String()
+		}
+		for _, shard := range shardIDs(fb, tb) {
+			fromSeries, inFrom := fb.shards[shard]
+			toSeries, inTo := tb.shards[shard]
+			id := strconv.FormatUint(shard, 10)
+			switch {
+			case !inTo:
+				row(name, id, strconv.Itoa(fromSeries), "-", "shard only in A")
+			case !inFrom:
+				row(name, id, "-", strconv.Itoa(toSeries), "shard only in B")
+			case fromSeries != toSeries:
+				row(name, id, strconv.Itoa(fromSeries), strconv.Itoa(toSeries), fmt.Sprintf("%+d series", toSeries-fromSeries))
+			}
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return 0, err
+	}
+
+	if n == 0 {
+		fmt.Fprintln(w, "\nNo differences")
+	} else {
+		fmt.Fprintf(w, "\n%d differences\n", n)
+	}
+	return n, nil
+}
+
+func (b *bucket) String() string {
+	if b.name == "" {
+		return b.id
+	}
+	return fmt.Sprintf("%s (%s)", b.name, b.id)
+}
+
+// series sums the series counts of the shards of the bucket. Series
+// written to several shards are counted once per shard.
+func (b *bucket) series() int {
+	var n int
+	for _, s := range b.shards {
+		n += s
+	}
+	return n
+}
+
+func shardIDs(buckets ...*bucket) []uint64 {
+	seen := make(map[uint64]struct{})
+	var ids []uint64
+	for _, b := range buckets {
+		for id := range b.shards {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,137 @@
+package diff_backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTSM writes a TSM file with a field of each of the series.
+func writeTSM(t *testing.T, path string, series ...string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := tsm1.NewTSMWriter(f)
+	require.NoError(t, err)
+	for _, s := range series {
+		key := tsm1.SeriesFieldKeyBytes(s, "value")
+		require.NoError(t, w.Write(key, []tsm1.Value{tsm1.NewValue(0, 1.0)}))
+	}
+	require.NoError(t, w.WriteIndex())
+	require.NoError(t, w.Close())
+}
+
+// writeShardBackup writes the backup of a shard as a gzipped tar archive
+// of a TSM file with the series.
+func writeShardBackup(t *testing.T, path string, series ...string) {
+	t.Helper()
+
+	tsm := filepath.Join(t.TempDir(), "000000001-000000001.tsm")
+	writeTSM(t, tsm, series...)
+	buf, err := os.ReadFile(tsm)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	gz := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "db/rp/1/000000001-000000001.tsm",
+		Mode:     0600,
+		Size:     int64(len(buf)),
+		Typeflag: tar.TypeReg,
+	}))
+	_, err = tw.Write(buf)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0600))
+}
+
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := NewDiffBackupCommand()
+	cmd.SetArgs(args)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestDiffBackup(t *testing.T) {
+	const bucketID = "0000000000000002"
+
+	from := t.TempDir()
+	writeShardBackup(t, filepath.Join(from, "20210101T000000Z.s1.tar.gz"), "cpu,host=a", "cpu,host=b")
+	writeShardBackup(t, filepath.Join(from, "20210101T000000Z.s2.tar.gz"), "mem,host=a")
+	require.NoError(t, os.WriteFile(filepath.Join(from, "20210101T000000Z.manifest"), []byte(fmt.Sprintf(`{
+		"kv": {"fileName": "20210101T000000Z.bolt.gz"},
+		"buckets": [{
+			"bucketID": %q,
+			"bucketName": "metrics",
+			"retentionPolicies": [{"shardGroups": [
+				{"shards": [{"id": 1, "fileName": "20210101T000000Z.s1.tar.gz"}]},
+				{"shards": [{"id": 2, "fileName": "20210101T000000Z.s2.tar.gz"}]}
+			]}]
+		}]
+	}`, bucketID)), 0600))
+
+	t.Run("same backup", func(t *testing.T) {
+		out, err := runCommand(t, from, from)
+		require.NoError(t, err)
+		require.Contains(t, out, "No differences")
+	})
+
+	t.Run("legacy backup", func(t *testing.T) {
+		to := t.TempDir()
+		writeShardBackup(t, filepath.Join(to, "20200101T000000Z.s1.tar.gz"), "cpu,host=a")
+		require.NoError(t, os.WriteFile(filepath.Join(to, "20200101T000000Z.manifest"), []byte(fmt.Sprintf(`{
+			"kv": {"fileName": "20200101T000000Z.bolt"},
+			"files": [{"bucketID": %q, "bucketName": "metrics", "shardID": 1, "fileName": "20200101T000000Z.s1.tar.gz"}]
+		}`, bucketID)), 0600))
+
+		out, err := runCommand(t, from, to)
+		require.Equal(t, errDiffer, err)
+		require.Regexp(t, `metrics \(0000000000000002\)\s+1\s+2\s+1\s+-1 series`, out)
+		require.Regexp(t, `metrics \(0000000000000002\)\s+2\s+1\s+-\s+shard only in A`, out)
+		require.Contains(t, out, "2 differences")
+	})
+
+	t.Run("live data", func(t *testing.T) {
+		engine := t.TempDir()
+		for shard, series := range map[string][]string{
+			"1": {"cpu,host=a", "cpu,host=b"},
+			"2": {"mem,host=a"},
+		} {
+			dir := filepath.Join(engine, "data", bucketID, "autogen", shard)
+			require.NoError(t, os.MkdirAll(dir, 0700))
+			writeTSM(t, filepath.Join(dir, "000000001-000000001.tsm"), series...)
+		}
+
+		out, err := runCommand(t, from, "--engine-path", engine)
+		require.NoError(t, err)
+		require.Contains(t, out, "No differences")
+
+		other := filepath.Join(engine, "data", "0000000000000003", "autogen", "3")
+		require.NoError(t, os.MkdirAll(other, 0700))
+		writeTSM(t, filepath.Join(other, "000000001-000000001.tsm"), "disk,host=a")
+
+		out, err = runCommand(t, from, "--engine-path", engine)
+		require.Equal(t, errDiffer, err)
+		require.Regexp(t, `0000000000000003\s+-\s+-\s+1\s+bucket only in B`, out)
+	})
+
+	t.Run("no manifest", func(t *testing.T) {
+		_, err := runCommand(t, t.TempDir(), from)
+		require.Error(t, err)
+	})
+}
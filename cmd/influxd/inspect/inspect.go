@@ -3,6 +3,8 @@ package inspect
 import (
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/build_tsi"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/delete_tsm"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/diagnostics"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/diff_backup"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/dump_tsi"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/dump_tsm"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/dump_wal"
@@ -46,6 +48,8 @@ func NewCommand(v *viper.Viper) (*cobra.Command, error) {
 	base.AddCommand(verify_wal.NewVerifyWALCommand())
 	base.AddCommand(report_tsm.NewReportTSMCommand())
 	base.AddCommand(build_tsi.NewBuildTSICommand())
+	base.AddCommand(diagnostics.NewDiagnosticsCommand())
+	base.AddCommand(diff_backup.NewDiffBackupCommand())
 
 	return base, nil
 }
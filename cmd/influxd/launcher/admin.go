@@ -0,0 +1,156 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/admin"
+	"github.com/influxdata/influxdb/v2/kit/memstat"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// adminCommands are the commands of the console served on the admin socket.
+func (m *Launcher) adminCommands() []admin.Command {
+	return []admin.Command{
+		{
+			Name: "queries",
+			Help: "list the running queries",
+			Run:  m.adminQueries,
+		},
+		{
+			Name:  "cancel",
+			Usage: "cancel <query id>",
+			Help:  "cancel a running query",
+			Run:   m.adminCancel,
+		},
+		{
+			Name: "compactions",
+			Help: "show the TSM compactions queued and running by level",
+			Run:  m.adminCompactions,
+		},
+		{
+			Name: "caches",
+			Help: "show the memory held by the caches and queries",
+			Run:  m.adminCaches,
+		},
+		{
+			Name:  "log-level",
+			Usage: "log-level [debug|info|warn|error]",
+			Help:  "show or set the log level",
+			Run:   m.adminLogLevel,
+		},
+	}
+}
+
+func (m *Launcher) adminQueries(_ context.Context, w io.Writer, _ []string) error {
+	queries := m.queryController.ActiveQueries()
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tORG\tSTATE\tDURATION\tSOURCE")
+	now := time.Now()
+	for _, q := range queries {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", q.ID, q.OrganizationID, q.State, now.Sub(q.StartedAt).Round(time.Millisecond), q.Source)
+	}
+	return tw.Flush()
+}
+
+func (m *Launcher) adminCancel(_ context.Context, w io.Writer, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: cancel <query id>")
+	}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid query id %q", args[0])
+	}
+	if !m.queryController.CancelQuery(id) {
+		return fmt.Errorf("no running query with id %d", id)
+	}
+	_, err = fmt.Fprintf(w, "canceled query %d\n", id)
+	return err
+}
+
+// adminCompactions reads the compaction gauges of the engine, which are
+// summed over its shards.
+func (m *Launcher) adminCompactions(_ context.Context, w io.Writer, _ []string) error {
+	families, err := m.reg.Gather()
+	if err != nil {
+		return err
+	}
+
+	byLevel := make(map[string]*[2]float64)
+	for _, f := range families {
+		var i int
+		switch f.GetName() {
+		case "storage_compactions_queued":
+			i = 0
+		case "storage_compactions_active":
+			i = 1
+		default:
+			continue
+		}
+		for _, metric := range f.GetMetric() {
+			var level string
+			for _, l := range metric.GetLabel() {
+				if l.GetName() == "level" {
+					level = l.GetValue()
+				}
+			}
+			counts, ok := byLevel[level]
+			if !ok {
+				counts = new([2]float64)
+				byLevel[level] = counts
+			}
+			counts[i] += metric.GetGauge().GetValue()
+		}
+	}
+
+	levels := make([]string, 0, len(byLevel))
+	for level := range byLevel {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "LEVEL\tQUEUED\tACTIVE")
+	for _, level := range levels {
+		counts := byLevel[level]
+		fmt.Fprintf(tw, "%s\t%.0f\t%.0f\n", level, counts[0], counts[1])
+	}
+	return tw.Flush()
+}
+
+func (m *Launcher) adminCaches(_ context.Context, w io.Writer, _ []string) error {
+	report := memstat.Collect(m.engine, m.queryController)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tNAME\tBYTES")
+	for _, u := range report.Components {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", u.Component, u.Name, u.Bytes)
+	}
+	fmt.Fprintf(tw, "heap\t\t%d\n", report.Heap.Alloc)
+	fmt.Fprintf(tw, "unattributed\t\t%d\n", report.Unattributed)
+	return tw.Flush()
+}
+
+func (m *Launcher) adminLogLevel(_ context.Context, w io.Writer, args []string) error {
+	if m.logLevel == nil {
+		return fmt.Errorf("the log level of this server cannot be changed")
+	}
+	if len(args) == 0 {
+		_, err := fmt.Fprintln(w, m.logLevel.Level())
+		return err
+	}
+
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(args[0])); err != nil {
+		return err
+	}
+	m.log.Info("Changing log level", zap.Stringer("from", m.logLevel.Level()), zap.Stringer("to", level))
+	m.logLevel.SetLevel(level)
+	_, err := fmt.Fprintf(w, "log level set to %s\n", level)
+	return err
+}
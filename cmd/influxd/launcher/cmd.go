@@ -8,19 +8,25 @@ import (
 	"strings"
 	"time"
 
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/bolt"
 	"github.com/influxdata/influxdb/v2/fluxinit"
+	"github.com/influxdata/influxdb/v2/http/points"
 	"github.com/influxdata/influxdb/v2/internal/fs"
 	"github.com/influxdata/influxdb/v2/kit/cli"
 	"github.com/influxdata/influxdb/v2/kit/signals"
 	influxlogger "github.com/influxdata/influxdb/v2/logger"
 	"github.com/influxdata/influxdb/v2/pprof"
+	"github.com/influxdata/influxdb/v2/querycache"
+	"github.com/influxdata/influxdb/v2/queryhistory"
+	"github.com/influxdata/influxdb/v2/schemacache"
 	"github.com/influxdata/influxdb/v2/sqlite"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/influxdata/influxdb/v2/v1/coordinator"
 	"github.com/influxdata/influxdb/v2/vault"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -109,16 +115,18 @@ func cmdRunE(ctx context.Context, o *InfluxdOpts) func() error {
 
 		l := NewLauncher()
 
-		// Create top level logger
+		// Create top level logger. Its level can be changed on the admin socket.
+		level := zap.NewAtomicLevelAt(o.LogLevel)
 		logconf := &influxlogger.Config{
 			Format: "auto",
-			Level:  o.LogLevel,
+			Level:  level,
 		}
 		logger, err := logconf.New(os.Stdout)
 		if err != nil {
 			return err
 		}
 		l.log = logger
+		l.logLevel = &level
 
 		// Start the launcher and wait for it to exit on SIGINT or SIGTERM.
 		if err := l.run(signals.WithStandardSignals(ctx), o); err != nil {
@@ -149,21 +157,53 @@ type InfluxdOpts struct {
 	SqLitePath string
 	EnginePath string
 
+	WriteBufferPath    string
+	WriteBufferMaxSize int64
+
+	StorageBreakerStallTimeout time.Duration
+	StorageBreakerMaxFailures  int
+	StorageBreakerCooldown     time.Duration
+
 	StoreType   string
 	SecretStore string
 	VaultConfig vault.Config
 
-	HttpBindAddress       string
-	HttpReadHeaderTimeout time.Duration
-	HttpReadTimeout       time.Duration
-	HttpWriteTimeout      time.Duration
-	HttpIdleTimeout       time.Duration
-	HttpTLSCert           string
-	HttpTLSKey            string
-	HttpTLSMinVersion     string
-	HttpTLSStrictCiphers  bool
-	SessionLength         int // in minutes
-	SessionRenewDisabled  bool
+	HttpBindAddress          string
+	HttpReadHeaderTimeout    time.Duration
+	HttpReadTimeout          time.Duration
+	HttpWriteTimeout         time.Duration
+	HttpIdleTimeout          time.Duration
+	HttpSlowWriteThreshold   time.Duration
+	HttpSlowWriteSampleLines int
+	HttpWriteStatsMaxTokens  int
+	HttpWriteStatsResolution time.Duration
+	HttpRateLimitRPS         int
+	HttpRateLimitBurst       int
+	HttpRateLimitKey         string
+	HttpRouteWriteTimeout    time.Duration
+	HttpRouteQueryTimeout    time.Duration
+	HttpRouteAPITimeout      time.Duration
+	HttpWriteMaxBodyBytes    int64
+	HttpQueryMaxBodyBytes    int64
+	HttpTemplateMaxBodyBytes int64
+	HttpAPIMaxBodyBytes      int64
+	HttpValidateRequests     bool
+	OTLPMetricsNaming        string
+	AuditLogPath             string
+	AuditLogKeyPath          string
+	AuditLogBucketID         string
+	WebhooksConfig           string
+	SnapshotsPath            string
+	AdminSocketPath          string
+	AdminSocketDisabled      bool
+	StorageReadsBindAddress  string
+	StorageReadsInsecure     bool
+	HttpTLSCert              string
+	HttpTLSKey               string
+	HttpTLSMinVersion        string
+	HttpTLSStrictCiphers     bool
+	SessionLength            int // in minutes
+	SessionRenewDisabled     bool
 
 	ProfilingDisabled bool
 	MetricsDisabled   bool
@@ -175,20 +215,87 @@ type InfluxdOpts struct {
 	NoTasks      bool
 	FeatureFlags map[string]string
 
+	// Task run limits.
+	TaskRunMaxMemoryBytes  int64
+	TaskRunMaxWallTime     time.Duration
+	TaskRunMaxBytesWritten int64
+
 	// Query options.
 	ConcurrencyQuota                int32
 	InitialMemoryBytesQuotaPerQuery int64
 	MemoryBytesQuotaPerQuery        int64
 	MaxMemoryBytes                  int64
 	QueueSize                       int32
+	OrgConcurrencyQuota             int32
+	OrgQueueSize                    int32
+	OrgQueueTimeout                 time.Duration
+	QueryCalendarConfig             string
+	QueryUtilsDisabled              bool
 	CoordinatorConfig               coordinator.Config
 
 	// Storage options.
 	StorageConfig storage.Config
 
+	// Read replica options.
+	ReplicaMode            bool
+	ReplicaRefreshInterval time.Duration
+	ReplicaBoltSourcePath  string
+
+	// Query result cache options.
+	QueryCacheEnabled        bool
+	QueryCacheTTL            time.Duration
+	QueryCacheMaxBytes       int64
+	QueryCacheMaxResultBytes int64
+
+	// Query history options.
+	QueryHistoryPath      string
+	QueryHistoryRetention time.Duration
+	QueryHistoryBucketID  string
+
+	// Schema cache options.
+	SchemaCacheEnabled         bool
+	SchemaCacheMaxMeasurements int
+	SchemaCacheMaxTagKeys      int
+	SchemaCacheMaxFields       int
+	SchemaCacheMaxTagValues    int
+
+	// Maintenance options.
+	MaintenanceMaxConcurrent int
+	MaintenanceIOBudget      int
+
+	// System bucket options.
+	TasksBucketRetention               time.Duration
+	TasksBucketShardGroupDuration      time.Duration
+	MonitoringBucketRetention          time.Duration
+	MonitoringBucketShardGroupDuration time.Duration
+
 	Viper *viper.Viper
 
 	HardeningEnabled bool
+
+	FluxHTTPAllowlist          []string
+	FluxHTTPAllowlistOverrides []string
+
+	TemplateTrustAnchors     []string
+	TemplateWebhookURL       string
+	TemplateWebhookSecret    string
+	TemplateJsonnetOperators bool
+	TemplateRegistryURL      string
+
+	TemplateFetchProxy        string
+	TemplateFetchCACerts      string
+	TemplateFetchTLSCert      string
+	TemplateFetchTLSKey       string
+	TemplateFetchHeaders      []string
+	TemplateFetchMaxRedirects int
+
+	SMTPAddr           string
+	SMTPUsername       string
+	SMTPPassword       string
+	SMTPFrom           string
+	InviteSignupURL    string
+	InviteTemplatesDir string
+	InviteExpiration   time.Duration
 }
 
 // NewOpts constructs options with default values.
@@ -211,13 +318,30 @@ func NewOpts(viper *viper.Viper) *InfluxdOpts {
 		SqLitePath: filepath.Join(dir, sqlite.DefaultFilename),
 		EnginePath: filepath.Join(dir, "engine"),
 
-		HttpBindAddress:       ":8086",
-		HttpReadHeaderTimeout: 10 * time.Second,
-		HttpIdleTimeout:       3 * time.Minute,
-		HttpTLSMinVersion:     "1.2",
-		HttpTLSStrictCiphers:  false,
-		SessionLength:         60, // 60 minutes
-		SessionRenewDisabled:  false,
+		WebhooksConfig: filepath.Join(dir, "webhooks.json"),
+		SnapshotsPath:  filepath.Join(dir, "snapshots"),
+
+		AdminSocketPath: filepath.Join(dir, "influxd.sock"),
+
+		StorageReadsBindAddress: "127.0.0.1:8082",
+
+		WriteBufferMaxSize: 1 << 30,
+
+		StorageBreakerCooldown: 10 * time.Second,
+
+		HttpBindAddress:          ":8086",
+		HttpReadHeaderTimeout:    10 * time.Second,
+		HttpIdleTimeout:          3 * time.Minute,
+		HttpSlowWriteSampleLines: points.DefaultSlowWriteSampleLines,
+		HttpWriteStatsMaxTokens:  points.DefaultTokenWriteMaxTokens,
+		HttpWriteStatsResolution: points.DefaultTokenWriteResolution,
+		HttpRateLimitKey:         "token",
+		OTLPMetricsNaming:        string(points.OTLPNamingMeasurement),
+		HttpTemplateMaxBodyBytes: 64 * 1024 * 1024,
+		HttpTLSMinVersion:        "1.2",
+		HttpTLSStrictCiphers:     false,
+		SessionLength:            60, // 60 minutes
+		SessionRenewDisabled:     false,
 
 		ProfilingDisabled: false,
 		MetricsDisabled:   false,
@@ -231,16 +355,41 @@ func NewOpts(viper *viper.Viper) *InfluxdOpts {
 
 		NoTasks: false,
 
+		ReplicaRefreshInterval: 10 * time.Second,
+
+		QueryCacheTTL:            querycache.DefaultTTL,
+		QueryCacheMaxBytes:       querycache.DefaultMaxBytes,
+		QueryCacheMaxResultBytes: querycache.DefaultMaxResultBytes,
+
+		QueryHistoryRetention: queryhistory.DefaultRetention,
+
+		SchemaCacheMaxMeasurements: schemacache.DefaultMaxMeasurements,
+		SchemaCacheMaxTagKeys:      schemacache.DefaultMaxTagKeys,
+		SchemaCacheMaxFields:       schemacache.DefaultMaxFields,
+		SchemaCacheMaxTagValues:    schemacache.DefaultMaxTagValues,
+
+		MaintenanceMaxConcurrent: 2,
+
+		TasksBucketRetention:      influxdb.TasksSystemBucketRetention,
+		MonitoringBucketRetention: influxdb.MonitoringSystemBucketRetention,
+
 		ConcurrencyQuota:                1024,
 		InitialMemoryBytesQuotaPerQuery: 0,
 		MemoryBytesQuotaPerQuery:        0,
 		MaxMemoryBytes:                  0,
 		QueueSize:                       1024,
+		OrgQueueSize:                    64,
+		OrgQueueTimeout:                 10 * time.Second,
 
 		Testing:                 false,
 		TestingAlwaysAllowSetup: false,
 
 		HardeningEnabled: false,
+
+		TemplateFetchMaxRedirects: 10,
+
+		InviteSignupURL:  "/signup",
+		InviteExpiration: influxdb.DefaultInviteExpiration,
 	}
 }
 
@@ -305,6 +454,33 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.EnginePath,
 			Desc:    "path to persistent engine files",
 		},
+		{
+			DestP: &o.StorageBreakerStallTimeout,
+			Flag:  "storage-circuit-breaker-stall-timeout",
+			Desc:  "duration after which a write, delete or bucket change still in progress in the storage engine opens its circuit breaker, failing further calls right away with a storage unavailable error instead of waiting on a stalled engine (hung fsync, full disk). Set to 0 to not open it on stalls. The circuit breaker is disabled when neither this nor storage-circuit-breaker-max-failures is set",
+		},
+		{
+			DestP: &o.StorageBreakerMaxFailures,
+			Flag:  "storage-circuit-breaker-max-failures",
+			Desc:  "number of consecutive storage engine failures that open its circuit breaker. Set to 0 to not open it on failures. The circuit breaker is disabled when neither this nor storage-circuit-breaker-stall-timeout is set",
+		},
+		{
+			DestP:   &o.StorageBreakerCooldown,
+			Flag:    "storage-circuit-breaker-cooldown",
+			Default: o.StorageBreakerCooldown,
+			Desc:    "how long the storage circuit breaker stays open before a call is let through to probe whether the engine recovered",
+		},
+		{
+			DestP: &o.WriteBufferPath,
+			Flag:  "write-buffer-path",
+			Desc:  "directory of the queue writes are kept in while the storage engine is briefly unavailable (cache full behind a compaction, snapshot in progress, disk full), to be replayed once it accepts writes again instead of failing them. Empty fails these writes",
+		},
+		{
+			DestP:   &o.WriteBufferMaxSize,
+			Flag:    "write-buffer-max-size",
+			Default: o.WriteBufferMaxSize,
+			Desc:    "maximum size in bytes of the queue of the write buffer, writes are failed once it is full. At least 20MiB",
+		},
 		{
 			DestP:   &o.SecretStore,
 			Flag:    "secret-store",
@@ -375,9 +551,10 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Desc:  "name to use as the SNI host when connecting via TLS.",
 		},
 		{
-			DestP: &o.VaultConfig.Token,
-			Flag:  "vault-token",
-			Desc:  "vault authentication token",
+			DestP:  &o.VaultConfig.Token,
+			Flag:   "vault-token",
+			Desc:   "vault authentication token",
+			Secret: true,
 		},
 
 		// HTTP options
@@ -411,6 +588,143 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.HttpIdleTimeout,
 			Desc:    "max duration the server should keep established connections alive while waiting for new requests. Set to 0 for no timeout",
 		},
+		{
+			DestP:   &o.HttpSlowWriteThreshold,
+			Flag:    "http-slow-write-threshold",
+			Default: o.HttpSlowWriteThreshold,
+			Desc:    "log write requests that take longer than this duration. Can be changed at runtime via /debug/slow-writes. Set to 0 to disable",
+		},
+		{
+			DestP:   &o.HttpSlowWriteSampleLines,
+			Flag:    "http-slow-write-sample-lines",
+			Default: o.HttpSlowWriteSampleLines,
+			Desc:    "number of lines, with tag and field values redacted, included when logging a slow write",
+		},
+		{
+			DestP:   &o.HttpWriteStatsMaxTokens,
+			Flag:    "http-write-stats-max-tokens",
+			Default: o.HttpWriteStatsMaxTokens,
+			Desc:    "number of authorizations write statistics are tracked and labeled in metrics for individually; writes of further authorizations are counted under the \"other\" label",
+		},
+		{
+			DestP:   &o.HttpWriteStatsResolution,
+			Flag:    "http-write-stats-resolution",
+			Default: o.HttpWriteStatsResolution,
+			Desc:    "width of the time buckets per-authorization write statistics are reported in",
+		},
+		{
+			DestP: &o.HttpRateLimitRPS,
+			Flag:  "http-rate-limit-rps",
+			Desc:  "requests per second allowed to the write and query endpoints for every authorization, session or organization; 0 disables rate limiting",
+		},
+		{
+			DestP: &o.HttpRateLimitBurst,
+			Flag:  "http-rate-limit-burst",
+			Desc:  "requests allowed in a burst above http-rate-limit-rps; defaults to http-rate-limit-rps",
+		},
+		{
+			DestP:   &o.HttpRateLimitKey,
+			Flag:    "http-rate-limit-key",
+			Default: o.HttpRateLimitKey,
+			Desc:    "what requests are rate limited by, one of token (the authorization or session) or org",
+		},
+		{
+			DestP: &o.HttpRouteWriteTimeout,
+			Flag:  "http-route-write-timeout",
+			Desc:  "max duration a write request may take before it starts responding; slower writes are answered with 503. Set to 0 for no timeout",
+		},
+		{
+			DestP: &o.HttpRouteQueryTimeout,
+			Flag:  "http-route-query-timeout",
+			Desc:  "max duration a query request may take before it starts streaming its result; slower queries are answered with 503. Set to 0 for no timeout",
+		},
+		{
+			DestP: &o.HttpRouteAPITimeout,
+			Flag:  "http-route-api-timeout",
+			Desc:  "max duration a request to the rest of the API, other than backups and restores, may take before it starts responding; slower requests are answered with 503. Set to 0 for no timeout",
+		},
+		{
+			DestP: &o.HttpWriteMaxBodyBytes,
+			Flag:  "http-write-max-body-bytes",
+			Desc:  "maximum size in bytes of a write request body as sent, before decompression; larger requests are rejected with 413. 0 is unlimited",
+		},
+		{
+			DestP: &o.HttpQueryMaxBodyBytes,
+			Flag:  "http-query-max-body-bytes",
+			Desc:  "maximum size in bytes of a query request body; larger requests are rejected with 413. 0 is unlimited",
+		},
+		{
+			DestP:   &o.HttpTemplateMaxBodyBytes,
+			Flag:    "http-template-max-body-bytes",
+			Default: o.HttpTemplateMaxBodyBytes,
+			Desc:    "maximum size in bytes of a template request body, such as a template apply; larger requests are rejected with 413. 0 is unlimited",
+		},
+		{
+			DestP: &o.HttpAPIMaxBodyBytes,
+			Flag:  "http-api-max-body-bytes",
+			Desc:  "maximum size in bytes of a request body to the API routes other than write, query, templates and restore; larger requests are rejected with 413. 0 is unlimited",
+		},
+		{
+			DestP: &o.HttpValidateRequests,
+			Flag:  "http-validate-requests",
+			Desc:  "validates JSON request bodies against the API spec embedded in the binary and rejects malformed payloads with 400, listing every invalid field",
+		},
+		{
+			DestP:   &o.OTLPMetricsNaming,
+			Flag:    "otlp-metrics-naming",
+			Default: o.OTLPMetricsNaming,
+			Desc:    "how OpenTelemetry metrics written to /api/v2/write/otlp are named, one of measurement (a measurement per metric) or field (a field per metric in the otel measurement)",
+		},
+		{
+			DestP: &o.AuditLogPath,
+			Flag:  "audit-log-path",
+			Desc:  "file the audit events of calls changing resources through the API are appended to, one JSON event per line. Empty does not write them to a file",
+		},
+		{
+			DestP: &o.AuditLogKeyPath,
+			Flag:  "audit-log-key-path",
+			Desc:  "file holding the secret key audit events are hashed with (HMAC-SHA256), so the chain of events cannot be rebuilt without the key. Keep it outside the data directory and the directory of the audit log. Empty chains events with plain SHA-256 hashes",
+		},
+		{
+			DestP: &o.AuditLogBucketID,
+			Flag:  "audit-log-bucket-id",
+			Desc:  "ID of the bucket the audit events of calls changing resources through the API are written to. Use a bucket of an organization only operators belong to, with a retention period as long as audit events must be kept. Empty does not write them to a bucket",
+		},
+		{
+			DestP:   &o.WebhooksConfig,
+			Flag:    "webhooks-config",
+			Default: o.WebhooksConfig,
+			Desc:    "JSON or YAML file of the HTTP endpoints lifecycle events (bucket created or deleted, token revoked, task run failed) are delivered to, with the types of events and the secret signing the deliveries of each endpoint. Operators create and delete endpoints with the /api/v2/webhooks API, which saves them to the file. Empty delivers no events",
+		},
+		{
+			DestP:   &o.SnapshotsPath,
+			Flag:    "snapshots-path",
+			Default: o.SnapshotsPath,
+			Desc:    "directory the snapshots taken with the /api/v2/snapshots API are kept in. The files of the shards are hard linked into it, so keep it on the file system of the engine path, or they are copied",
+		},
+		{
+			DestP:   &o.AdminSocketPath,
+			Flag:    "admin-socket-path",
+			Default: o.AdminSocketPath,
+			Desc:    "unix socket serving the admin console influxd attach connects to (list and cancel running queries, show compactions and caches, change the log level). Only the user running influxd may connect to it",
+		},
+		{
+			DestP:   &o.AdminSocketDisabled,
+			Flag:    "admin-socket-disabled",
+			Default: o.AdminSocketDisabled,
+			Desc:    "disable the admin socket",
+		},
+		{
+			DestP:   &o.StorageReadsBindAddress,
+			Flag:    "storage-reads-bind-address",
+			Default: o.StorageReadsBindAddress,
+			Desc:    "bind address of the storage reads gRPC API external query engines read series with, served when the storageReadsService feature flag is enabled. It uses the TLS certificate of the HTTP server; without TLS it only binds to a loopback address, unless storage-reads-insecure is set",
+		},
+		{
+			DestP: &o.StorageReadsInsecure,
+			Flag:  "storage-reads-insecure",
+			Desc:  "serve the storage reads gRPC API on a non-loopback storage-reads-bind-address without TLS. Tokens are then sent in plaintext",
+		},
 		{
 			DestP: &o.HttpTLSCert,
 			Flag:  "tls-cert",
@@ -440,6 +754,24 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.NoTasks,
 			Desc:    "disables the task scheduler",
 		},
+		{
+			DestP:   &o.TaskRunMaxMemoryBytes,
+			Flag:    "task-run-max-memory-bytes",
+			Default: o.TaskRunMaxMemoryBytes,
+			Desc:    "maximum number of bytes a task run's query is allowed to use. It can only lower query-memory-bytes. Set to 0 to use query-memory-bytes",
+		},
+		{
+			DestP:   &o.TaskRunMaxWallTime,
+			Flag:    "task-run-max-wall-time",
+			Default: o.TaskRunMaxWallTime,
+			Desc:    "maximum wall clock time a task run is allowed to execute for. CPU time is not measured, as a run shares its goroutines with other queries. Set to 0 for no limit",
+		},
+		{
+			DestP:   &o.TaskRunMaxBytesWritten,
+			Flag:    "task-run-max-bytes-written",
+			Default: o.TaskRunMaxBytesWritten,
+			Desc:    "maximum number of bytes of line protocol a task run is allowed to write. Set to 0 for no limit",
+		},
 		{
 			DestP:   &o.ConcurrencyQuota,
 			Flag:    "query-concurrency",
@@ -470,10 +802,38 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.QueueSize,
 			Desc:    "the number of queries that are allowed to be awaiting execution before new queries are rejected. Must be > 0 if query-concurrency is not unlimited",
 		},
+		{
+			DestP:   &o.OrgConcurrencyQuota,
+			Flag:    "query-org-concurrency",
+			Default: o.OrgConcurrencyQuota,
+			Desc:    "the number of queries of an organization that are allowed to be queued or executing at once. Set to 0 to only limit queries with query-concurrency",
+		},
+		{
+			DestP:   &o.OrgQueueSize,
+			Flag:    "query-org-queue-size",
+			Default: o.OrgQueueSize,
+			Desc:    "the number of queries of an organization that are allowed to wait for query-org-concurrency before new queries of the organization are rejected with 429",
+		},
+		{
+			DestP:   &o.OrgQueueTimeout,
+			Flag:    "query-org-queue-timeout",
+			Default: o.OrgQueueTimeout,
+			Desc:    "how long a query waits for query-org-concurrency before it is rejected with 503. Set to 0 to wait until the query is canceled",
+		},
+		{
+			DestP: &o.QueryCalendarConfig,
+			Flag:  "query-calendar-config",
+			Desc:  "path to a JSON or YAML file of business calendars (working days, hours and holidays per org) exposed to Flux as the calendar record, i.e. calendar.isBusinessHour()",
+		},
+		{
+			DestP: &o.QueryUtilsDisabled,
+			Flag:  "query-utils-disabled",
+			Desc:  "disables the utils record of common dashboard query patterns available to Flux queries, i.e. utils.topNByTag()",
+		},
 		{
 			DestP: &o.FeatureFlags,
 			Flag:  "feature-flags",
-			Desc:  "feature flag overrides",
+			Desc:  "feature flag overrides; a flag key prefixed with an org ID and a colon, such as 0123456789abcdef:mergeFiltersRule=true, overrides the flag for that org only, until changed through /api/v2/flags/orgs",
 		},
 
 		// storage configuration
@@ -483,6 +843,133 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.StorageConfig.WriteTimeout,
 			Desc:    "The max amount of time the engine will spend completing a write request before cancelling with a timeout.",
 		},
+		{
+			DestP: &o.ReplicaMode,
+			Flag:  "replica-mode",
+			Desc:  "runs as a read replica serving queries from a copy of the data of a write node, such as a filesystem snapshot or object storage sync. The storage engine is opened read-only, writes are rejected and tasks are not run. Metadata written to the replica is replaced on refresh",
+		},
+		{
+			DestP:   &o.ReplicaRefreshInterval,
+			Flag:    "replica-refresh-interval",
+			Default: o.ReplicaRefreshInterval,
+			Desc:    "how often a read replica reopens its shards and restores its metadata to serve the latest copy of the data",
+		},
+		{
+			DestP: &o.ReplicaBoltSourcePath,
+			Flag:  "replica-bolt-source-path",
+			Desc:  "path to the copy of the bolt file of the write node a read replica restores its metadata from when it changes; the metadata is not refreshed when unset",
+		},
+		{
+			DestP: &o.QueryCacheEnabled,
+			Flag:  "query-cache-enabled",
+			Desc:  "cache the results of Flux queries made through /api/v2/query for query-cache-ttl, so identical dashboard queries are computed once. Results are dropped when their buckets are written to or deleted from; queries with side effects or buckets only known when they run are not cached",
+		},
+		{
+			DestP:   &o.QueryCacheTTL,
+			Flag:    "query-cache-ttl",
+			Default: o.QueryCacheTTL,
+			Desc:    "how long a query result is served from the cache. Queries at the current time reuse results up to this old",
+		},
+		{
+			DestP:   &o.QueryCacheMaxBytes,
+			Flag:    "query-cache-max-bytes",
+			Default: o.QueryCacheMaxBytes,
+			Desc:    "size of all of the query results cached; the result used least recently is evicted beyond it",
+		},
+		{
+			DestP:   &o.QueryCacheMaxResultBytes,
+			Flag:    "query-cache-max-result-bytes",
+			Default: o.QueryCacheMaxResultBytes,
+			Desc:    "size of the largest query result cached",
+		},
+		{
+			DestP: &o.QueryHistoryPath,
+			Flag:  "query-history-path",
+			Desc:  "directory the history of the queries made through /api/v2/query is written to, a file per day with one JSON entry per query holding its organization, user, query hash, duration, rows returned, bytes scanned and error. Empty does not write them to files",
+		},
+		{
+			DestP:   &o.QueryHistoryRetention,
+			Flag:    "query-history-retention",
+			Default: o.QueryHistoryRetention,
+			Desc:    "how long the daily files of query-history-path are kept. 0 keeps them forever",
+		},
+		{
+			DestP: &o.QueryHistoryBucketID,
+			Flag:  "query-history-bucket-id",
+			Desc:  "ID of the bucket the history of the queries made through /api/v2/query is written to, as points of the queries measurement. Use a bucket of an organization only operators belong to, with a retention period as long as the history must be kept. Empty does not write it to a bucket",
+		},
+		{
+			DestP: &o.SchemaCacheEnabled,
+			Flag:  "schema-cache-enabled",
+			Desc:  "cache the measurements, tag keys, tag values and field keys written to each bucket and serve them at /api/v2/schema/cache for autocompletion",
+		},
+		{
+			DestP:   &o.SchemaCacheMaxMeasurements,
+			Flag:    "schema-cache-max-measurements",
+			Default: o.SchemaCacheMaxMeasurements,
+			Desc:    "number of measurements cached per bucket; the measurement written least recently is evicted beyond it",
+		},
+		{
+			DestP:   &o.SchemaCacheMaxTagKeys,
+			Flag:    "schema-cache-max-tag-keys",
+			Default: o.SchemaCacheMaxTagKeys,
+			Desc:    "number of tag keys cached per measurement; the tag key written least recently is evicted beyond it",
+		},
+		{
+			DestP:   &o.SchemaCacheMaxFields,
+			Flag:    "schema-cache-max-fields",
+			Default: o.SchemaCacheMaxFields,
+			Desc:    "number of field keys cached per measurement; the field key written least recently is evicted beyond it",
+		},
+		{
+			DestP:   &o.SchemaCacheMaxTagValues,
+			Flag:    "schema-cache-max-tag-values",
+			Default: o.SchemaCacheMaxTagValues,
+			Desc:    "number of values cached per tag key; the value written least recently is evicted beyond it",
+		},
+		{
+			DestP:   &o.MaintenanceMaxConcurrent,
+			Flag:    "maintenance-max-concurrent",
+			Default: o.MaintenanceMaxConcurrent,
+			Desc:    "number of maintenance operations, such as backups, shard moves and full compactions, running at once; further backups and moves wait for one to end, and full compactions are retried later. 0 runs them all at once",
+		},
+		{
+			DestP: &o.MaintenanceIOBudget,
+			Flag:  "maintenance-io-budget",
+			Desc:  "bytes per second written by all running maintenance operations together. 0 does not limit their throughput",
+		},
+		{
+			DestP:   &o.TasksBucketRetention,
+			Flag:    "tasks-bucket-retention",
+			Default: o.TasksBucketRetention,
+			Desc:    "retention period of the _tasks bucket created with a new organization; use the buckets API to change it for existing organizations",
+		},
+		{
+			DestP: &o.TasksBucketShardGroupDuration,
+			Flag:  "tasks-bucket-shard-group-duration",
+			Desc:  "shard group duration of the _tasks bucket created with a new organization; derived from its retention period when unset",
+		},
+		{
+			DestP:   &o.MonitoringBucketRetention,
+			Flag:    "monitoring-bucket-retention",
+			Default: o.MonitoringBucketRetention,
+			Desc:    "retention period of the _monitoring bucket created with a new organization; use the buckets API to change it for existing organizations",
+		},
+		{
+			DestP: &o.MonitoringBucketShardGroupDuration,
+			Flag:  "monitoring-bucket-shard-group-duration",
+			Desc:  "shard group duration of the _monitoring bucket created with a new organization; derived from its retention period when unset",
+		},
+		{
+			DestP: &o.StorageConfig.Data.ExtraDirs,
+			Flag:  "storage-extra-data-dirs",
+			Desc:  "additional data directories, such as added volumes, shards can be moved to with the shard move API; new shards are created in the engine path",
+		},
+		{
+			DestP: &o.StorageConfig.Data.SystemBucketDir,
+			Flag:  "storage-system-bucket-dir",
+			Desc:  "data directory new shards of the _monitoring and _tasks buckets are created in instead of the engine path; their series files stay in the engine path",
+		},
 		{
 			DestP: &o.StorageConfig.Data.WALFsyncDelay,
 			Flag:  "storage-wal-fsync-delay",
@@ -514,6 +1001,11 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Flag:  "storage-cache-max-memory-size",
 			Desc:  "The maximum size a shard's cache can reach before it starts rejecting writes.",
 		},
+		{
+			DestP: &o.StorageConfig.Data.BlockCacheMaxMemorySize,
+			Flag:  "storage-block-cache-max-memory-size",
+			Desc:  "The maximum size of the decoded TSM blocks kept in memory for repeated reads. 0 disables the block cache.",
+		},
 		{
 			DestP: &o.StorageConfig.Data.CacheSnapshotMemorySize,
 			Flag:  "storage-cache-snapshot-memory-size",
@@ -534,6 +1026,21 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Flag:  "storage-compact-throughput-burst",
 			Desc:  "The rate limit in bytes per second that we will allow TSM compactions to write to disk.",
 		},
+		{
+			DestP: &o.StorageConfig.Data.CompactFullAllowWindows,
+			Flag:  "storage-compact-full-allow-windows",
+			Desc:  "The daily windows, formatted as HH:MM-HH:MM, full compactions may start in, such as 02:00-06:00. Full compactions may start at any time when no window is set.",
+		},
+		{
+			DestP: &o.StorageConfig.Data.CompactFullBlackoutWindows,
+			Flag:  "storage-compact-full-blackout-windows",
+			Desc:  "The daily windows, formatted as HH:MM-HH:MM, full compactions may not start in, such as 09:00-17:00.",
+		},
+		{
+			DestP: &o.StorageConfig.Data.CompactFullWindowsTimezone,
+			Flag:  "storage-compact-full-windows-timezone",
+			Desc:  "The time zone of the full compaction windows, such as UTC. Defaults to the local time zone of the server.",
+		},
 		// limits
 		{
 			DestP: &o.StorageConfig.Data.MaxConcurrentCompactions,
@@ -565,6 +1072,21 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Flag:  "storage-retention-check-interval",
 			Desc:  "The interval of time when retention policy enforcement checks run.",
 		},
+		{
+			DestP: &o.StorageConfig.RetentionService.ProgressiveDelete,
+			Flag:  "storage-retention-progressive-delete",
+			Desc:  "Continuously delete expired data in small time slices instead of only dropping whole shard groups.",
+		},
+		{
+			DestP: &o.StorageConfig.RetentionService.ProgressiveDeleteInterval,
+			Flag:  "storage-retention-progressive-delete-interval",
+			Desc:  "The interval of time between progressive retention deletes.",
+		},
+		{
+			DestP: &o.StorageConfig.RetentionService.ProgressiveDeleteSlice,
+			Flag:  "storage-retention-progressive-delete-slice",
+			Desc:  "The maximum time range of expired data removed by a single progressive retention delete.",
+		},
 		{
 			DestP: &o.StorageConfig.PrecreatorConfig.CheckInterval,
 			Flag:  "storage-shard-precreator-check-interval",
@@ -649,6 +1171,115 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.HardeningEnabled,
 			Desc:    "enable hardening options (disallow private IPs within flux and templates HTTP requests)",
 		},
+		{
+			DestP: &o.FluxHTTPAllowlist,
+			Flag:  "flux-http-allowlist",
+			Desc:  "domains (optionally *.example.com), IPs or CIDRs that HTTP requests made from Flux queries and tasks are restricted to; when unset, any destination is allowed",
+		},
+		{
+			DestP: &o.FluxHTTPAllowlistOverrides,
+			Flag:  "flux-http-allowlist-overrides",
+			Desc:  "per-organization flux HTTP allowlist entries formatted as <org-id>:<entry>; an org with overrides uses them in place of --flux-http-allowlist",
+		},
+		{
+			DestP: &o.TemplateTrustAnchors,
+			Flag:  "template-trust-anchors",
+			Desc:  "base64 encoded ed25519 public keys; when set, templates applied from remote URLs must carry a signature from one of these keys",
+		},
+		{
+			DestP:   &o.TemplateJsonnetOperators,
+			Flag:    "template-jsonnet-operators",
+			Default: o.TemplateJsonnetOperators,
+			Desc:    "allow operator tokens to apply templates written in jsonnet; other orgs require the templateJsonnet feature flag",
+		},
+		{
+			DestP: &o.TemplateRegistryURL,
+			Flag:  "template-registry-url",
+			Desc:  "URL of a template registry index; when set, template remotes may be given as references such as influxdata/linux_system@v1.2.0",
+		},
+		{
+			DestP: &o.TemplateFetchProxy,
+			Flag:  "template-fetch-proxy",
+			Desc:  "URL of the HTTP proxy remote templates are fetched through",
+		},
+		{
+			DestP: &o.TemplateFetchCACerts,
+			Flag:  "template-fetch-ca-certs",
+			Desc:  "path to a PEM file of the root certificate authorities servers hosting remote templates are verified with, in place of the system pool",
+		},
+		{
+			DestP: &o.TemplateFetchTLSCert,
+			Flag:  "template-fetch-tls-cert",
+			Desc:  "path to the client certificate presented to servers hosting remote templates that require mutual TLS",
+		},
+		{
+			DestP: &o.TemplateFetchTLSKey,
+			Flag:  "template-fetch-tls-key",
+			Desc:  "path to the private key of --template-fetch-tls-cert",
+		},
+		{
+			DestP:  &o.TemplateFetchHeaders,
+			Flag:   "template-fetch-headers",
+			Desc:   "headers sent when fetching remote templates from a host, formatted as <host>=<header>:<value>, e.g. artifacts.example.com=Authorization:Bearer abc",
+			Secret: true,
+		},
+		{
+			DestP:   &o.TemplateFetchMaxRedirects,
+			Flag:    "template-fetch-max-redirects",
+			Default: o.TemplateFetchMaxRedirects,
+			Desc:    "maximum number of redirects followed when fetching remote templates",
+		},
+		{
+			DestP: &o.TemplateWebhookURL,
+			Flag:  "template-webhook-url",
+			Desc:  "URL to which an event is posted for every template apply and dry run, successful or failed",
+		},
+		{
+			DestP:  &o.TemplateWebhookSecret,
+			Flag:   "template-webhook-secret",
+			Desc:   "secret used to sign template webhook events; the HMAC-SHA256 of the body is sent in the X-Influxdb-Signature header",
+			Secret: true,
+		},
+
+		// user invitations
+		{
+			DestP: &o.SMTPAddr,
+			Flag:  "smtp-addr",
+			Desc:  "host:port of the SMTP server invitation emails are sent with; without it invites are created but not emailed",
+		},
+		{
+			DestP: &o.SMTPUsername,
+			Flag:  "smtp-username",
+			Desc:  "username to authenticate to the SMTP server with",
+		},
+		{
+			DestP:  &o.SMTPPassword,
+			Flag:   "smtp-password",
+			Desc:   "password to authenticate to the SMTP server with",
+			Secret: true,
+		},
+		{
+			DestP: &o.SMTPFrom,
+			Flag:  "smtp-from",
+			Desc:  "sender address of invitation emails",
+		},
+		{
+			DestP:   &o.InviteSignupURL,
+			Flag:    "invite-signup-url",
+			Default: o.InviteSignupURL,
+			Desc:    "URL of the signup page invitation links point to; the invite token is added as the token query parameter",
+		},
+		{
+			DestP: &o.InviteTemplatesDir,
+			Flag:  "invite-templates-dir",
+			Desc:  "directory of invitation email templates named invite.<locale>.tmpl, each defining a subject and a body template",
+		},
+		{
+			DestP:   &o.InviteExpiration,
+			Flag:    "invite-expiration",
+			Default: o.InviteExpiration,
+			Desc:    "how long invites can be accepted",
+		},
 	}
 }
 
@@ -67,3 +67,19 @@ bind-address = "127.0.0.1:8088"
 		})
 	}
 }
+
+func TestIsLoopbackAddress(t *testing.T) {
+	t.Parallel()
+
+	for addr, want := range map[string]bool{
+		"127.0.0.1:8082": true,
+		"[::1]:8082":     true,
+		"localhost:8082": true,
+		":8082":          false,
+		"0.0.0.0:8082":   false,
+		"10.0.0.1:8082":  false,
+		"invalid":        false,
+	} {
+		require.Equal(t, want, isLoopbackAddress(addr), addr)
+	}
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/kit/check"
+	"github.com/influxdata/influxdb/v2/kit/memstat"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/prom"
 	"github.com/influxdata/influxdb/v2/models"
@@ -26,7 +28,12 @@ type Engine interface {
 	influxdb.DeleteService
 	storage.PointsWriter
 	storage.EngineSchema
+	influxdb.RetentionEnforcer
+	influxdb.BucketCardinalityFinder
+	influxdb.BucketShardFinder
 	prom.PrometheusCollector
+	memstat.Reporter
+	check.Checker
 	influxdb.BackupService
 	influxdb.RestoreService
 
@@ -132,11 +139,36 @@ func (t *TemporaryEngine) UpdateBucketRetentionPolicy(ctx context.Context, bucke
 	return t.engine.UpdateBucketRetentionPolicy(ctx, bucketID, upd)
 }
 
+// EnforceBucketRetention removes the expired data of a bucket immediately.
+func (t *TemporaryEngine) EnforceBucketRetention(ctx context.Context, bucketID platform.ID, retentionPeriod *time.Duration, dryRun bool) (*influxdb.RetentionEnforcement, error) {
+	return t.engine.EnforceBucketRetention(ctx, bucketID, retentionPeriod, dryRun)
+}
+
+// FindBucketCardinality counts the series of a bucket.
+func (t *TemporaryEngine) FindBucketCardinality(ctx context.Context, bucketID platform.ID, exact bool, topTagKeys int) (*influxdb.BucketCardinality, error) {
+	return t.engine.FindBucketCardinality(ctx, bucketID, exact, topTagKeys)
+}
+
+// FindBucketShards returns the shard groups of a bucket.
+func (t *TemporaryEngine) FindBucketShards(ctx context.Context, bucketID platform.ID) ([]influxdb.ShardGroupStatus, error) {
+	return t.engine.FindBucketShards(ctx, bucketID)
+}
+
 // DeleteBucket deletes a bucket from the time-series data.
 func (t *TemporaryEngine) DeleteBucket(ctx context.Context, orgID, bucketID platform.ID) error {
 	return t.engine.DeleteBucket(ctx, orgID, bucketID)
 }
 
+// MemoryUsage attributes the memory held by the engine.
+func (t *TemporaryEngine) MemoryUsage() []memstat.Usage {
+	return t.engine.MemoryUsage()
+}
+
+// Check reports whether the engine is open.
+func (t *TemporaryEngine) Check(ctx context.Context) check.Response {
+	return t.engine.Check(ctx)
+}
+
 // WithLogger sets the logger on the engine. It must be called before Open.
 func (t *TemporaryEngine) WithLogger(log *zap.Logger) {
 	t.log = log.With(zap.String("service", "temporary_engine"))
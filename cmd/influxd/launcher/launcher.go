@@ -17,8 +17,10 @@ import (
 	"github.com/influxdata/flux/dependencies/testing"
 	"github.com/influxdata/flux/dependencies/url"
 	platform "github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/admin"
 	"github.com/influxdata/influxdb/v2/annotations"
 	annotationTransport "github.com/influxdata/influxdb/v2/annotations/transport"
+	"github.com/influxdata/influxdb/v2/audit"
 	"github.com/influxdata/influxdb/v2/authorization"
 	"github.com/influxdata/influxdb/v2/authorizer"
 	"github.com/influxdata/influxdb/v2/backup"
@@ -26,16 +28,23 @@ import (
 	"github.com/influxdata/influxdb/v2/checks"
 	"github.com/influxdata/influxdb/v2/dashboards"
 	dashboardTransport "github.com/influxdata/influxdb/v2/dashboards/transport"
+	"github.com/influxdata/influxdb/v2/datagen"
+	"github.com/influxdata/influxdb/v2/datasources"
+	datasourcesTransport "github.com/influxdata/influxdb/v2/datasources/transport"
 	"github.com/influxdata/influxdb/v2/dbrp"
+	"github.com/influxdata/influxdb/v2/diagnostics"
 	"github.com/influxdata/influxdb/v2/gather"
 	"github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/http/points"
 	iqlcontrol "github.com/influxdata/influxdb/v2/influxql/control"
 	iqlquery "github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxdb/v2/inmem"
 	"github.com/influxdata/influxdb/v2/internal/resource"
+	"github.com/influxdata/influxdb/v2/kit/check"
 	"github.com/influxdata/influxdb/v2/kit/feature"
 	overrideflagger "github.com/influxdata/influxdb/v2/kit/feature/override"
 	"github.com/influxdata/influxdb/v2/kit/metric"
+	"github.com/influxdata/influxdb/v2/kit/openapi"
 	platform2 "github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/prom"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
@@ -44,28 +53,41 @@ import (
 	"github.com/influxdata/influxdb/v2/kv/migration"
 	"github.com/influxdata/influxdb/v2/kv/migration/all"
 	"github.com/influxdata/influxdb/v2/label"
+	"github.com/influxdata/influxdb/v2/maintenance"
 	"github.com/influxdata/influxdb/v2/notebooks"
 	notebookTransport "github.com/influxdata/influxdb/v2/notebooks/transport"
 	endpointservice "github.com/influxdata/influxdb/v2/notification/endpoint/service"
 	ruleservice "github.com/influxdata/influxdb/v2/notification/rule/service"
+	"github.com/influxdata/influxdb/v2/parsers"
+	parsersTransport "github.com/influxdata/influxdb/v2/parsers/transport"
 	"github.com/influxdata/influxdb/v2/pkger"
+	"github.com/influxdata/influxdb/v2/pkger/registry"
 	infprom "github.com/influxdata/influxdb/v2/prometheus"
 	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/query/calendar"
 	"github.com/influxdata/influxdb/v2/query/control"
 	"github.com/influxdata/influxdb/v2/query/fluxlang"
 	"github.com/influxdata/influxdb/v2/query/stdlib/influxdata/influxdb"
+	"github.com/influxdata/influxdb/v2/query/utils"
+	"github.com/influxdata/influxdb/v2/querycache"
+	"github.com/influxdata/influxdb/v2/queryhistory"
 	"github.com/influxdata/influxdb/v2/remotes"
 	remotesTransport "github.com/influxdata/influxdb/v2/remotes/transport"
 	"github.com/influxdata/influxdb/v2/replications"
 	replicationTransport "github.com/influxdata/influxdb/v2/replications/transport"
+	"github.com/influxdata/influxdb/v2/schemacache"
 	"github.com/influxdata/influxdb/v2/secret"
 	"github.com/influxdata/influxdb/v2/session"
+	"github.com/influxdata/influxdb/v2/shardmove"
+	"github.com/influxdata/influxdb/v2/snapshot"
 	"github.com/influxdata/influxdb/v2/snowflake"
 	"github.com/influxdata/influxdb/v2/source"
 	"github.com/influxdata/influxdb/v2/sqlite"
 	sqliteMigrations "github.com/influxdata/influxdb/v2/sqlite/migrations"
+	"github.com/influxdata/influxdb/v2/static"
 	"github.com/influxdata/influxdb/v2/storage"
 	storageflux "github.com/influxdata/influxdb/v2/storage/flux"
+	"github.com/influxdata/influxdb/v2/storage/reads"
 	"github.com/influxdata/influxdb/v2/storage/readservice"
 	taskbackend "github.com/influxdata/influxdb/v2/task/backend"
 	"github.com/influxdata/influxdb/v2/task/backend/coordinator"
@@ -87,11 +109,15 @@ import (
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
 	storage2 "github.com/influxdata/influxdb/v2/v1/services/storage"
 	"github.com/influxdata/influxdb/v2/vault"
+	"github.com/influxdata/influxdb/v2/webhook"
+	"github.com/influxdata/influxdb/v2/writebuffer"
 	pzap "github.com/influxdata/influxdb/v2/zap"
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 	jaegerconfig "github.com/uber/jaeger-client-go/config"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const (
@@ -140,7 +166,9 @@ type Launcher struct {
 	executor  *executor.Executor
 
 	log *zap.Logger
-	reg *prom.Registry
+	// logLevel changes the level of log when set.
+	logLevel *zap.AtomicLevel
+	reg      *prom.Registry
 
 	apibackend *http.APIBackend
 }
@@ -150,6 +178,30 @@ type stoppingScheduler interface {
 	Stop()
 }
 
+// healthDetail checks the subsystems reported by the /health/detail endpoint.
+func (m *Launcher) healthDetail() *check.Detail {
+	d := check.NewDetail(check.DefaultSubsystemTimeout)
+	d.AddSubsystem("kv-store", check.CheckerFunc(func(ctx context.Context) check.Response {
+		if err := m.kvStore.View(ctx, func(kv.Tx) error { return nil }); err != nil {
+			return check.Error(err)
+		}
+		return check.Pass()
+	}))
+	d.AddSubsystem("storage-engine", m.engine)
+	d.AddSubsystem("query-controller", m.queryController)
+	d.AddSubsystem("task-scheduler", check.CheckerFunc(func(ctx context.Context) check.Response {
+		sch, ok := m.scheduler.(*scheduler.TreeScheduler)
+		if !ok {
+			return check.Info("task scheduler disabled")
+		}
+		if sch.Stopped() {
+			return check.Response{Status: check.StatusFail, Message: "task scheduler stopped"}
+		}
+		return check.Info("%d tasks scheduled", sch.Len())
+	}))
+	return d
+}
+
 // NewLauncher returns a new instance of Launcher with a no-op logger.
 func NewLauncher() *Launcher {
 	return &Launcher{
@@ -232,18 +284,19 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	// These flags can be used to modify the remaining setup logic in this method.
 	// They will also be injected into the contexts of incoming HTTP requests at runtime,
 	// for use in modifying behavior there.
+	// The overrides of organizations can be changed through the API, so an
+	// override flagger is used even without any configured.
 	if m.flagger == nil {
-		m.flagger = feature.DefaultFlagger()
+		f, err := overrideflagger.Make(opts.FeatureFlags, feature.ByKey)
+		if err != nil {
+			m.log.Error("Failed to configure feature flag overrides",
+				zap.Error(err), zap.Any("overrides", opts.FeatureFlags))
+			return err
+		}
 		if len(opts.FeatureFlags) > 0 {
-			f, err := overrideflagger.Make(opts.FeatureFlags, feature.ByKey)
-			if err != nil {
-				m.log.Error("Failed to configure feature flag overrides",
-					zap.Error(err), zap.Any("overrides", opts.FeatureFlags))
-				return err
-			}
 			m.log.Info("Running with feature flag overrides", zap.Any("overrides", opts.FeatureFlags))
-			m.flagger = f
 		}
+		m.flagger = f
 	}
 
 	m.reg = prom.NewRegistry(m.log.With(zap.String("service", "prom_registry")))
@@ -256,7 +309,16 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	}
 	m.reg.MustRegister(infprom.NewInfluxCollector(procID, info))
 
-	tenantStore := tenant.NewStore(m.kvStore)
+	tenantStore := tenant.NewStore(m.kvStore,
+		tenant.WithTasksBucketConfig(tenant.SystemBucketConfig{
+			RetentionPeriod:    opts.TasksBucketRetention,
+			ShardGroupDuration: opts.TasksBucketShardGroupDuration,
+		}),
+		tenant.WithMonitoringBucketConfig(tenant.SystemBucketConfig{
+			RetentionPeriod:    opts.MonitoringBucketRetention,
+			ShardGroupDuration: opts.MonitoringBucketShardGroupDuration,
+		}),
+	)
 	ts := tenant.NewSystem(tenantStore, m.log.With(zap.String("store", "new")), m.reg, metric.WithSuffix("new"))
 
 	serviceConfig := kv.ServiceConfig{
@@ -277,6 +339,24 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		scraperTargetSvc platform.ScraperTargetStoreService = m.kvService
 	)
 
+	var webhooks *webhook.Dispatcher
+	if opts.WebhooksConfig != "" {
+		webhooksConfig, err := webhook.Load(opts.WebhooksConfig)
+		if err != nil {
+			m.log.Error("Failed to load webhooks", zap.Error(err))
+			return err
+		}
+		webhooks = webhook.NewDispatcher(m.log.With(zap.String("service", "webhooks")), webhooksConfig.Endpoints,
+			webhook.WithConfigPath(opts.WebhooksConfig))
+		m.reg.MustRegister(webhooks.PrometheusCollectors()...)
+		m.closers = append(m.closers, labeledCloser{
+			label: "webhooks",
+			closer: func(context.Context) error {
+				return webhooks.Close()
+			},
+		})
+	}
+
 	var authSvc platform.AuthorizationService
 	{
 		authStore, err := authorization.NewStore(m.kvStore)
@@ -285,6 +365,9 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 			return err
 		}
 		authSvc = authorization.NewService(authStore, ts)
+		if webhooks != nil {
+			authSvc = webhook.NewAuthorizationService(authSvc, webhooks)
+		}
 	}
 
 	secretStore, err := secret.NewStore(m.kvStore)
@@ -319,11 +402,26 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		return err
 	}
 
+	maintenanceCoordinator := maintenance.NewCoordinator(
+		maintenance.WithMaxConcurrent(opts.MaintenanceMaxConcurrent),
+		maintenance.WithIOBudget(opts.MaintenanceIOBudget),
+	)
+
+	compactionSchedule, err := opts.StorageConfig.Data.CompactionSchedule()
+	if err != nil {
+		m.log.Error("Invalid full compaction windows", zap.Error(err))
+		return err
+	}
+
+	var replica *replicaRefresher
 	if opts.Testing {
 		// the testing engine will write/read into a temporary directory
 		engine := NewTemporaryEngine(
 			opts.StorageConfig,
 			storage.WithMetaClient(metaClient),
+			storage.WithMaintenanceCoordinator(maintenanceCoordinator),
+			storage.WithCompactionSchedule(compactionSchedule),
+			storage.WithBucketFinder(ts.BucketService),
 		)
 		m.flushers = append(m.flushers, engine)
 		m.engine = engine
@@ -333,18 +431,41 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 			os.Exit(1)
 		}
 
-		m.engine = storage.NewEngine(
+		engine := storage.NewEngine(
 			opts.EnginePath,
 			opts.StorageConfig,
 			storage.WithMetricsDisabled(opts.MetricsDisabled),
 			storage.WithMetaClient(metaClient),
+			storage.WithReadOnly(opts.ReplicaMode),
+			storage.WithMaintenanceCoordinator(maintenanceCoordinator),
+			storage.WithCompactionSchedule(compactionSchedule),
+			storage.WithBucketFinder(ts.BucketService),
 		)
+		m.engine = engine
+
+		if opts.ReplicaMode {
+			m.log.Info("Running as a read replica", zap.String("bolt-source-path", opts.ReplicaBoltSourcePath), zap.Duration("refresh-interval", opts.ReplicaRefreshInterval))
+			replica = &replicaRefresher{
+				engine:         engine,
+				metaClient:     metaClient,
+				boltSourcePath: opts.ReplicaBoltSourcePath,
+				interval:       opts.ReplicaRefreshInterval,
+				log:            m.log.With(zap.String("service", "replica")),
+			}
+		}
 	}
 	m.engine.WithLogger(m.log)
 	if err := m.engine.Open(ctx); err != nil {
 		m.log.Error("Failed to open engine", zap.Error(err))
 		return err
 	}
+	if replica != nil && replica.interval > 0 {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			replica.run(ctx)
+		}()
+	}
 	m.closers = append(m.closers, labeledCloser{
 		label: "engine",
 		closer: func(context.Context) error {
@@ -361,10 +482,69 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		restoreService platform.RestoreService = m.engine
 	)
 
+	var engineSchema storage.EngineSchema = m.engine
+	if opts.StorageBreakerStallTimeout > 0 || opts.StorageBreakerMaxFailures > 0 {
+		breaker := storage.NewCircuitBreaker(m.log.With(zap.String("service", "storage-circuit-breaker")), storage.CircuitBreakerConfig{
+			StallTimeout: opts.StorageBreakerStallTimeout,
+			MaxFailures:  opts.StorageBreakerMaxFailures,
+			Cooldown:     opts.StorageBreakerCooldown,
+		})
+		m.reg.MustRegister(breaker.PrometheusCollectors()...)
+		breakingEngine := storage.NewCircuitBreakingEngine(m.engine, breaker)
+		pointsWriter = breakingEngine
+		deleteService = breakingEngine
+		engineSchema = breakingEngine
+	}
+
+	var queryCache *querycache.Cache
+	if opts.QueryCacheEnabled {
+		queryCache = querycache.NewCache(
+			querycache.WithTTL(opts.QueryCacheTTL),
+			querycache.WithMaxBytes(opts.QueryCacheMaxBytes),
+			querycache.WithMaxResultBytes(opts.QueryCacheMaxResultBytes),
+		)
+		m.reg.MustRegister(queryCache.PrometheusCollectors()...)
+		// Results are dropped when points reach the engine, below the write
+		// buffer, so writes it holds drop them when they are replayed.
+		pointsWriter = &querycache.InvalidatingPointsWriter{
+			Underlying: pointsWriter,
+			Cache:      queryCache,
+		}
+		deleteService = &querycache.InvalidatingDeleteService{
+			Underlying: deleteService,
+			Cache:      queryCache,
+		}
+	}
+
+	var writeBuffer *writebuffer.PointsWriter
+	if opts.WriteBufferPath != "" {
+		writeBuffer, err = writebuffer.Open(m.log.With(zap.String("service", "write-buffer")), pointsWriter, opts.WriteBufferPath, opts.WriteBufferMaxSize)
+		if err != nil {
+			m.log.Error("Failed to open write buffer", zap.String("path", opts.WriteBufferPath), zap.Error(err))
+			return err
+		}
+		m.reg.MustRegister(writeBuffer.PrometheusCollectors()...)
+		m.closers = append(m.closers, labeledCloser{
+			label: "write-buffer",
+			closer: func(context.Context) error {
+				return writeBuffer.Close()
+			},
+		})
+		pointsWriter = writeBuffer
+	}
+
 	remotesSvc := remotes.NewService(m.sqlStore)
 	remotesServer := remotesTransport.NewInstrumentedRemotesHandler(
 		m.log.With(zap.String("handler", "remotes")), m.reg, remotesSvc)
 
+	datasourcesSvc := datasources.NewService(m.sqlStore, secretSvc)
+	datasourcesServer := datasourcesTransport.NewInstrumentedDatasourcesHandler(
+		m.log.With(zap.String("handler", "datasources")), m.reg, datasourcesSvc)
+
+	parsersSvc := parsers.NewService(m.sqlStore)
+	parsersServer := parsersTransport.NewInstrumentedParsersHandler(
+		m.log.With(zap.String("handler", "parsers")), m.reg, parsersSvc)
+
 	replicationSvc, replicationsMetrics := replications.NewService(m.sqlStore, ts, pointsWriter, m.log.With(zap.String("service", "replications")), opts.EnginePath)
 	replicationServer := replicationTransport.NewInstrumentedReplicationHandler(
 		m.log.With(zap.String("handler", "replications")), m.reg, replicationSvc)
@@ -387,6 +567,42 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 
 	pointsWriter = replicationSvc
 
+	// Record approximately when buckets were last written to and queried.
+	bucketActivity := tenant.NewBucketActivityTracker(m.log.With(zap.String("service", "bucket_activity")), tenantStore)
+	bucketActivityCtx, stopBucketActivity := context.WithCancel(ctx)
+	bucketActivityDone := make(chan struct{})
+	go func() {
+		defer close(bucketActivityDone)
+		bucketActivity.Run(bucketActivityCtx, tenant.DefaultBucketActivityFlushInterval)
+	}()
+	m.closers = append(m.closers, labeledCloser{
+		label: "bucket activity",
+		closer: func(context.Context) error {
+			stopBucketActivity()
+			<-bucketActivityDone
+			return nil
+		},
+	})
+
+	pointsWriter = &storage.BucketActivityPointsWriter{
+		Underlying: pointsWriter,
+		Recorder:   bucketActivity,
+	}
+
+	var schemaCache *schemacache.Cache
+	if opts.SchemaCacheEnabled {
+		schemaCache = schemacache.NewCache(
+			schemacache.WithMaxMeasurements(opts.SchemaCacheMaxMeasurements),
+			schemacache.WithMaxTagKeys(opts.SchemaCacheMaxTagKeys),
+			schemacache.WithMaxFields(opts.SchemaCacheMaxFields),
+			schemacache.WithMaxTagValues(opts.SchemaCacheMaxTagValues),
+		)
+		pointsWriter = &schemacache.ObservingPointsWriter{
+			Underlying: pointsWriter,
+			Cache:      schemaCache,
+		}
+	}
+
 	// When --hardening-enabled, use an HTTP IP validator that restricts
 	// flux and pkger HTTP requests to private addressess.
 	var urlValidator url.Validator
@@ -396,32 +612,85 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		urlValidator = url.PassValidator{}
 	}
 
+	// The flux http allowlist only applies to Flux, templates keep using urlValidator.
+	fluxURLValidator := urlValidator
+	if len(opts.FluxHTTPAllowlist) > 0 {
+		allowlist, err := influxdb.NewHTTPAllowlist(urlValidator, opts.FluxHTTPAllowlist...)
+		if err != nil {
+			m.log.Error("Failed to parse flux http allowlist", zap.Error(err))
+			return err
+		}
+		fluxURLValidator = allowlist
+	}
+	orgURLValidators, err := influxdb.ParseOrgHTTPAllowlists(urlValidator, opts.FluxHTTPAllowlistOverrides...)
+	if err != nil {
+		m.log.Error("Failed to parse flux http allowlist overrides", zap.Error(err))
+		return err
+	}
+
+	// Buckets shared with an organization are readable by name in its queries.
+	bucketShareSvc := tenant.NewBucketShareSvc(tenantStore, ts)
+
 	deps, err := influxdb.NewDependencies(
-		storageflux.NewReader(storage2.NewStore(m.engine.TSDBStore(), m.engine.MetaClient())),
+		storageflux.NewRetentionWarningReader(
+			storageflux.NewBucketActivityReader(
+				storageflux.NewReader(storage2.NewStore(m.engine.TSDBStore(), m.engine.MetaClient())),
+				bucketActivity,
+			),
+			ts.BucketService,
+		),
 		pointsWriter,
-		authorizer.NewBucketService(ts.BucketService),
+		authorizer.NewBucketService(ts.BucketService, authorizer.WithBucketShares(bucketShareSvc)),
 		authorizer.NewOrgService(ts.OrganizationService),
 		authorizer.NewSecretService(secretSvc),
 		nil,
-		influxdb.WithURLValidator(urlValidator),
+		influxdb.WithURLValidator(fluxURLValidator),
 	)
 	if err != nil {
 		m.log.Error("Failed to get query controller dependencies", zap.Error(err))
 		return err
 	}
+	deps = deps.WithOrgURLValidators(orgURLValidators)
 
 	dependencyList := []flux.Dependency{deps}
 	if opts.Testing {
 		dependencyList = append(dependencyList, testing.FrameworkConfig{})
 	}
 
+	var externProviders control.ExternProviders
+	if !opts.QueryUtilsDisabled {
+		utilsLib, err := utils.NewProvider()
+		if err != nil {
+			m.log.Error("Failed to load Flux utils library", zap.Error(err))
+			return err
+		}
+		externProviders = append(externProviders, utilsLib)
+	}
+	if opts.QueryCalendarConfig != "" {
+		calendars, err := calendar.Load(opts.QueryCalendarConfig)
+		if err != nil {
+			m.log.Error("Failed to load business calendars", zap.Error(err))
+			return err
+		}
+		externProviders = append(externProviders, calendars)
+	}
+	externProviders = append(externProviders, parsers.NewExternProvider(parsersSvc))
+	var externProvider control.ExternProvider
+	if len(externProviders) > 0 {
+		externProvider = externProviders
+	}
+
 	m.queryController, err = control.New(control.Config{
 		ConcurrencyQuota:                opts.ConcurrencyQuota,
 		InitialMemoryBytesQuotaPerQuery: opts.InitialMemoryBytesQuotaPerQuery,
 		MemoryBytesQuotaPerQuery:        opts.MemoryBytesQuotaPerQuery,
 		MaxMemoryBytes:                  opts.MaxMemoryBytes,
 		QueueSize:                       opts.QueueSize,
+		OrgConcurrencyQuota:             opts.OrgConcurrencyQuota,
+		OrgQueueSize:                    opts.OrgQueueSize,
+		OrgQueueTimeout:                 opts.OrgQueueTimeout,
 		ExecutorDependencies:            dependencyList,
+		ExternProvider:                  externProvider,
 		FluxLogEnabled:                  opts.FluxLogEnabled,
 	}, m.log.With(zap.String("service", "storage-reads")))
 	if err != nil {
@@ -438,6 +707,44 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	m.reg.MustRegister(m.queryController.PrometheusCollectors()...)
 
 	var storageQueryService = readservice.NewProxyQueryService(m.queryController)
+	if queryCache != nil {
+		storageQueryService = querycache.NewProxyQueryService(storageQueryService, queryCache, ts.BucketService)
+	}
+	var queryHistorySinks []queryhistory.Sink
+	if opts.QueryHistoryPath != "" {
+		queryHistoryFiles, err := queryhistory.OpenFileSink(opts.QueryHistoryPath, opts.QueryHistoryRetention)
+		if err != nil {
+			m.log.Error("Failed to open query history", zap.String("path", opts.QueryHistoryPath), zap.Error(err))
+			return err
+		}
+		m.closers = append(m.closers, labeledCloser{
+			label: "query-history-files",
+			closer: func(context.Context) error {
+				return queryHistoryFiles.Close()
+			},
+		})
+		queryHistorySinks = append(queryHistorySinks, queryHistoryFiles)
+	}
+	if opts.QueryHistoryBucketID != "" {
+		bucketID, err := platform2.IDFromString(opts.QueryHistoryBucketID)
+		if err != nil {
+			m.log.Error("Invalid query history bucket ID", zap.String("bucket_id", opts.QueryHistoryBucketID), zap.Error(err))
+			return err
+		}
+		queryHistorySinks = append(queryHistorySinks, queryhistory.NewBucketSink(pointsWriter, ts.BucketService, *bucketID))
+	}
+	if len(queryHistorySinks) > 0 {
+		queryHistory := queryhistory.NewLogger(m.log.With(zap.String("service", "query-history")), queryHistorySinks...)
+		m.reg.MustRegister(queryHistory.PrometheusCollectors()...)
+		// Closed before the sinks, so the queued entries are written.
+		m.closers = append(m.closers, labeledCloser{
+			label: "query-history",
+			closer: func(context.Context) error {
+				return queryHistory.Close()
+			},
+		})
+		storageQueryService = query.NewLoggingProxyQueryService(m.log.With(zap.String("service", "query-history")), queryHistory, storageQueryService)
+	}
 	var taskSvc taskmodel.TaskService
 	{
 		// create the task stack
@@ -450,20 +757,32 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 			query.QueryServiceBridge{AsyncQueryService: m.queryController},
 		)
 
+		var taskControlSvc taskbackend.TaskControlService = combinedTaskService
+		if webhooks != nil {
+			taskControlSvc = webhook.NewTaskControlService(combinedTaskService, combinedTaskService, webhooks)
+		}
+
 		executor, executorMetrics := executor.NewExecutor(
 			m.log.With(zap.String("service", "task-executor")),
 			query.QueryServiceBridge{AsyncQueryService: m.queryController},
 			ts.UserService,
 			combinedTaskService,
-			combinedTaskService,
+			taskControlSvc,
 			executor.WithFlagger(m.flagger),
+			executor.WithRunLimits(query.Limits{
+				MaxMemoryBytes:  opts.TaskRunMaxMemoryBytes,
+				MaxWallTime:     opts.TaskRunMaxWallTime,
+				MaxBytesWritten: opts.TaskRunMaxBytesWritten,
+			}),
 		)
 		m.executor = executor
 		m.reg.MustRegister(executorMetrics.PrometheusCollectors()...)
 		schLogger := m.log.With(zap.String("service", "task-scheduler"))
 
 		var sch stoppingScheduler = &scheduler.NoopScheduler{}
-		if !opts.NoTasks {
+		// a replica does not write, so it leaves running tasks to the
+		// write node
+		if !opts.NoTasks && !opts.ReplicaMode {
 			var (
 				sm  *scheduler.SchedulerMetrics
 				err error
@@ -547,8 +866,10 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	var checkSvc platform.CheckService
 	{
 		coordinator := coordinator.NewCoordinator(m.log, m.scheduler, m.executor)
-		checkSvc = checks.NewService(m.log.With(zap.String("svc", "checks")), m.kvStore, ts.OrganizationService, m.kvService)
-		checkSvc = middleware.NewCheckService(checkSvc, m.kvService, coordinator)
+		kvCheckSvc := checks.NewService(m.log.With(zap.String("svc", "checks")), m.kvStore, ts.OrganizationService, m.kvService)
+		kvCheckSvc.WithBucketService(ts.BucketService)
+		kvCheckSvc.WithDatasourceService(datasourcesSvc)
+		checkSvc = middleware.NewCheckService(kvCheckSvc, m.kvService, coordinator)
 	}
 
 	var notificationEndpointSvc platform.NotificationEndpointService
@@ -610,8 +931,14 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		labelSvc = label.NewService(labelsStore)
 	}
 
-	ts.BucketService = storage.NewBucketService(m.log, ts.BucketService, m.engine)
+	ts.BucketService = storage.NewBucketService(m.log, ts.BucketService, engineSchema)
 	ts.BucketService = dbrp.NewBucketService(m.log, ts.BucketService, dbrpSvc)
+	if schemaCache != nil {
+		ts.BucketService = schemacache.NewBucketService(ts.BucketService, schemaCache)
+	}
+	if webhooks != nil {
+		ts.BucketService = webhook.NewBucketService(ts.BucketService, webhooks)
+	}
 
 	bucketManifestWriter := backup.NewBucketManifestWriter(ts, metaClient)
 
@@ -626,6 +953,33 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	onboardSvc = tenant.NewOnboardingMetrics(m.reg, onboardSvc, metric.WithSuffix("new")) // with metrics
 	onboardSvc = tenant.NewOnboardingLogger(onboardingLogger, onboardSvc)                 // with logging
 
+	inviteLogger := m.log.With(zap.String("service", "invite"))
+	inviteOpts := []tenant.InviteServiceOptionFn{
+		tenant.WithInviteLogger(inviteLogger),
+		tenant.WithInviteSignupURL(opts.InviteSignupURL),
+		tenant.WithInviteExpiration(opts.InviteExpiration),
+	}
+	if opts.SMTPAddr != "" {
+		inviteTemplates := tenant.NewInviteTemplates()
+		if opts.InviteTemplatesDir != "" {
+			if inviteTemplates, err = tenant.LoadInviteTemplates(opts.InviteTemplatesDir); err != nil {
+				m.log.Error("Failed to load invite templates", zap.Error(err))
+				return err
+			}
+		}
+		inviteOpts = append(inviteOpts, tenant.WithInviteMailer(tenant.NewSMTPInviteMailer(tenant.SMTPConfig{
+			Addr:     opts.SMTPAddr,
+			Username: opts.SMTPUsername,
+			Password: opts.SMTPPassword,
+			From:     opts.SMTPFrom,
+		}, inviteTemplates)))
+	}
+
+	var inviteSvc platform.InviteService = tenant.NewInviteSvc(tenantStore, ts, inviteOpts...) // basic service
+	inviteSvc = tenant.NewAuthedInviteService(inviteSvc)                                       // with auth
+	inviteSvc = tenant.NewInviteMetrics(m.reg, inviteSvc, metric.WithSuffix("new"))            // with metrics
+	inviteSvc = tenant.NewInviteLogger(inviteLogger, inviteSvc)                                // with logging
+
 	var (
 		passwordV1 platform.PasswordsService
 		authSvcV1  *authv1.Service
@@ -670,6 +1024,73 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		NotificationRuleFinder:     notificationRuleSvc,
 	}
 
+	var rateLimiter *kithttp.RateLimiter
+	if opts.HttpRateLimitRPS > 0 {
+		var keyFn kithttp.RateLimitKeyFn
+		switch opts.HttpRateLimitKey {
+		case "token":
+			keyFn = http.RateLimitByAuthorizer
+		case "org":
+			keyFn = http.RateLimitByOrg(ts.OrganizationService)
+		default:
+			return fmt.Errorf("unsupported rate limit key: %s", opts.HttpRateLimitKey)
+		}
+		rateLimiter = kithttp.NewRateLimiter(float64(opts.HttpRateLimitRPS), opts.HttpRateLimitBurst, keyFn)
+	}
+
+	otlpNaming, err := points.ParseOTLPNaming(opts.OTLPMetricsNaming)
+	if err != nil {
+		return err
+	}
+
+	var requestValidator *openapi.Validator
+	if opts.HttpValidateRequests {
+		spec, err := static.Swagger()
+		if err != nil {
+			m.log.Warn("Request validation disabled, the API spec is not embedded in the binary", zap.Error(err))
+		} else if requestValidator, err = openapi.NewValidator(spec, openapi.WithMaxBodyBytes(opts.HttpAPIMaxBodyBytes)); err != nil {
+			m.log.Error("Failed to load the API spec to validate requests", zap.Error(err))
+			return err
+		}
+	}
+
+	var auditSinks []audit.Sink
+	if opts.AuditLogPath != "" {
+		auditFile, err := audit.OpenFile(opts.AuditLogPath)
+		if err != nil {
+			m.log.Error("Failed to open audit log", zap.String("path", opts.AuditLogPath), zap.Error(err))
+			return err
+		}
+		m.closers = append(m.closers, labeledCloser{
+			label: "audit-log",
+			closer: func(context.Context) error {
+				return auditFile.Close()
+			},
+		})
+		auditSinks = append(auditSinks, auditFile)
+	}
+	if opts.AuditLogBucketID != "" {
+		bucketID, err := platform2.IDFromString(opts.AuditLogBucketID)
+		if err != nil {
+			m.log.Error("Invalid audit log bucket ID", zap.String("bucket_id", opts.AuditLogBucketID), zap.Error(err))
+			return err
+		}
+		auditSinks = append(auditSinks, audit.NewBucketSink(pointsWriter, ts.BucketService, *bucketID))
+	}
+	var auditLog *audit.Logger
+	if len(auditSinks) > 0 {
+		var auditKey []byte
+		if opts.AuditLogKeyPath != "" {
+			if auditKey, err = os.ReadFile(opts.AuditLogKeyPath); err != nil {
+				m.log.Error("Failed to read audit log key", zap.String("path", opts.AuditLogKeyPath), zap.Error(err))
+				return err
+			}
+		} else {
+			m.log.Warn("Audit events are hashed without a key, see --audit-log-key-path")
+		}
+		auditLog = audit.NewLogger(m.log.With(zap.String("service", "audit")), auditKey, auditSinks...)
+	}
+
 	errorHandler := kithttp.NewErrorHandler(m.log.With(zap.String("handler", "error_logger")))
 	m.apibackend = &http.APIBackend{
 		AssetsPath:           opts.AssetsPath,
@@ -677,6 +1098,7 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		HTTPErrorHandler:     errorHandler,
 		Logger:               m.log,
 		FluxLogEnabled:       opts.FluxLogEnabled,
+		FluxUtilsDisabled:    opts.QueryUtilsDisabled,
 		SessionRenewDisabled: opts.SessionRenewDisabled,
 		NewQueryService:      source.NewQueryService,
 		PointsWriter: &storage.LoggingPointsWriter{
@@ -718,6 +1140,7 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		PasswordsService:                ts.PasswordsService,
 		InfluxqldService:                iqlquery.NewProxyExecutor(m.log, qe),
 		FluxService:                     storageQueryService,
+		ActiveQueryService:              m.queryController,
 		FluxLanguageService:             fluxlang.DefaultService,
 		TaskService:                     taskSvc,
 		TelegrafService:                 telegrafSvc,
@@ -730,15 +1153,51 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		DocumentService:                 m.kvService,
 		OrgLookupService:                resourceResolver,
 		WriteEventRecorder:              infprom.NewEventRecorder("write"),
+		SlowWriteLog:                    points.NewSlowWriteLog(m.log, opts.HttpSlowWriteThreshold, opts.HttpSlowWriteSampleLines),
+		WriteRejectionLog:               points.NewRejectionLog(points.DefaultRejectionResolution, points.DefaultRejectionWindow, points.DefaultRejectionSamples),
+		TokenWriteLog:                   points.NewTokenWriteLog(opts.HttpWriteStatsResolution, points.DefaultTokenWriteWindow, opts.HttpWriteStatsMaxTokens),
+		OTLPNaming:                      otlpNaming,
+		RateLimiter:                     rateLimiter,
+		BodyLimiter:                     kithttp.NewBodyLimiter(),
+		Timeouter:                       kithttp.NewTimeouter(),
+		WriteTimeout:                    opts.HttpRouteWriteTimeout,
+		QueryTimeout:                    opts.HttpRouteQueryTimeout,
+		APITimeout:                      opts.HttpRouteAPITimeout,
+		MaxWriteBodyBytes:               opts.HttpWriteMaxBodyBytes,
+		MaxQueryBodyBytes:               opts.HttpQueryMaxBodyBytes,
+		MaxTemplateBodyBytes:            opts.HttpTemplateMaxBodyBytes,
+		MaxAPIBodyBytes:                 opts.HttpAPIMaxBodyBytes,
+		AuditLog:                        auditLog,
+		RequestValidator:                requestValidator,
 		QueryEventRecorder:              infprom.NewEventRecorder("query"),
 		Flagger:                         m.flagger,
 		FlagsHandler:                    feature.NewFlagsHandler(errorHandler, feature.ByKey),
 	}
+	if f, ok := m.flagger.(overrideflagger.Flagger); ok {
+		m.apibackend.FlagOverridesHandler = overrideflagger.NewHTTPHandler(m.log.With(zap.String("handler", "flag_overrides")), f)
+	}
 
 	m.reg.MustRegister(m.apibackend.PrometheusCollectors()...)
 
 	authAgent := new(authorizer.AuthAgent)
 
+	templateClientOpts, err := templateHTTPClientOpts(opts)
+	if err != nil {
+		m.log.Error("Failed to configure template HTTP client", zap.Error(err))
+		return err
+	}
+
+	var registryClient *registry.Client
+	if opts.TemplateRegistryURL != "" {
+		registryClient = registry.NewClient(opts.TemplateRegistryURL, pkger.NewDefaultHTTPClient(urlValidator, templateClientOpts...))
+	}
+
+	trustAnchors, err := pkger.ParseTrustAnchors(opts.TemplateTrustAnchors...)
+	if err != nil {
+		m.log.Error("Failed to parse template trust anchors", zap.Error(err))
+		return err
+	}
+
 	var pkgSVC pkger.SVC
 	{
 		b := m.apibackend
@@ -746,9 +1205,12 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		authedUrmSVC := authorizer.NewURMService(b.OrgLookupService, b.UserResourceMappingService)
 		pkgerLogger := m.log.With(zap.String("service", "pkger"))
 		pkgSVC = pkger.NewService(
-			pkger.WithHTTPClient(pkger.NewDefaultHTTPClient(urlValidator)),
+			pkger.WithHTTPClient(pkger.NewDefaultHTTPClient(urlValidator, templateClientOpts...)),
 			pkger.WithLogger(pkgerLogger),
+			pkger.WithRegistryClient(registryClient),
+			pkger.WithTemplateTrustAnchors(trustAnchors...),
 			pkger.WithStore(pkger.NewStoreKV(m.kvStore)),
+			pkger.WithAuthorizationSVC(authorizer.NewAuthorizationService(authSvc)),
 			pkger.WithBucketSVC(authorizer.NewBucketService(b.BucketService)),
 			pkger.WithCheckSVC(authorizer.NewCheckService(b.CheckService, authedUrmSVC, authedOrgSVC)),
 			pkger.WithDashboardSVC(authorizer.NewDashboardService(b.DashboardService)),
@@ -756,6 +1218,7 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 			pkger.WithNotificationEndpointSVC(authorizer.NewNotificationEndpointService(b.NotificationEndpointService, authedUrmSVC, authedOrgSVC)),
 			pkger.WithNotificationRuleSVC(authorizer.NewNotificationRuleStore(b.NotificationRuleStore, authedUrmSVC, authedOrgSVC)),
 			pkger.WithOrganizationService(authorizer.NewOrgService(b.OrganizationService)),
+			pkger.WithQuerySVC(query.QueryServiceBridge{AsyncQueryService: m.queryController}),
 			pkger.WithSecretSVC(authorizer.NewSecretService(b.SecretService)),
 			pkger.WithTaskSVC(authorizer.NewTaskService(pkgerLogger, b.TaskService)),
 			pkger.WithTelegrafSVC(authorizer.NewTelegrafConfigService(b.TelegrafService, b.UserResourceMappingService)),
@@ -764,6 +1227,9 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		pkgSVC = pkger.MWTracing()(pkgSVC)
 		pkgSVC = pkger.MWMetrics(m.reg)(pkgSVC)
 		pkgSVC = pkger.MWLogging(pkgerLogger)(pkgSVC)
+		if opts.TemplateWebhookURL != "" {
+			pkgSVC = pkger.MWWebhook(pkgerLogger, opts.TemplateWebhookURL, opts.TemplateWebhookSecret, nil)(pkgSVC)
+		}
 		pkgSVC = pkger.MWAuth(authAgent)(pkgSVC)
 	}
 
@@ -776,11 +1242,26 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	var templatesHTTPServer *pkger.HTTPServerTemplates
 	{
 		tLogger := m.log.With(zap.String("handler", "templates"))
-		templatesHTTPServer = pkger.NewHTTPServerTemplates(tLogger, pkgSVC, pkger.NewDefaultHTTPClient(urlValidator))
+		var templatesOpts []pkger.HTTPServerTemplatesOptFn
+		if len(trustAnchors) > 0 {
+			templatesOpts = append(templatesOpts, pkger.WithTrustAnchors(trustAnchors...))
+		}
+		if opts.TemplateJsonnetOperators {
+			templatesOpts = append(templatesOpts, pkger.WithJsonnetPermissions(platform.OperPermissions()...))
+		}
+		if registryClient != nil {
+			templatesOpts = append(templatesOpts, pkger.WithTemplateRegistry(registryClient))
+		}
+		templatesHTTPServer = pkger.NewHTTPServerTemplates(tLogger, pkgSVC, pkger.NewDefaultHTTPClient(urlValidator, templateClientOpts...), templatesOpts...)
 	}
 
 	userHTTPServer := ts.NewUserHTTPHandler(m.log)
 	onboardHTTPServer := tenant.NewHTTPOnboardHandler(m.log, onboardSvc)
+	inviteHTTPServer := tenant.NewHTTPInviteHandler(m.log.With(zap.String("handler", "invite")), inviteSvc)
+	bucketShareHTTPServer := tenant.NewHTTPBucketShareHandler(
+		m.log.With(zap.String("handler", "bucket_share")),
+		tenant.NewAuthedBucketShareService(ts.BucketService, bucketShareSvc),
+	)
 
 	// feature flagging for new labels service
 	var labelHandler *label.LabelHandler
@@ -825,7 +1306,12 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 
 	orgHTTPServer := ts.NewOrgHTTPHandler(m.log, secret.NewAuthedService(secretSvc))
 
-	bucketHTTPServer := ts.NewBucketHTTPHandler(m.log, labelSvc)
+	bucketHTTPServer := ts.NewBucketHTTPHandler(m.log, labelSvc,
+		tenant.WithRetentionEnforcer(tenant.NewAuthedRetentionEnforcer(ts.BucketService, m.engine)),
+		tenant.WithBucketActivity(bucketActivity),
+		tenant.WithBucketCardinality(tenant.NewAuthedBucketCardinalityFinder(ts.BucketService, m.engine)),
+		tenant.WithBucketShards(tenant.NewAuthedBucketShardFinder(ts.BucketService, m.engine)),
+	)
 
 	var dashboardServer *dashboardTransport.DashboardHandler
 	{
@@ -876,16 +1362,74 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		),
 	)
 
-	configHandler, err := http.NewConfigHandler(m.log.With(zap.String("handler", "config")), opts.BindCliOpts())
+	configHandler, err := http.NewConfigHandler(
+		m.log.With(zap.String("handler", "config")),
+		opts.BindCliOpts(),
+		http.WithCompactionLimiter(m.engine.TSDBStore()),
+	)
 	if err != nil {
 		return err
 	}
 
-	platformHandler := http.NewPlatformHandler(
-		m.apibackend,
+	diagnosticsHandler := diagnostics.NewHTTPHandler(
+		m.log.With(zap.String("handler", "diagnostics")),
+		diagnostics.NewCollector(
+			diagnostics.WithConfig(opts.BindCliOpts()),
+			diagnostics.WithStore(m.engine.TSDBStore()),
+			diagnostics.WithGatherer(m.reg),
+		),
+	)
+
+	shardMoveSvc := shardmove.NewService(
+		m.log.With(zap.String("service", "shard-move")),
+		m.engine.TSDBStore(),
+		shardmove.WithCoordinator(maintenanceCoordinator),
+	)
+	m.closers = append(m.closers, labeledCloser{
+		label: "shard-move",
+		closer: func(context.Context) error {
+			return shardMoveSvc.Close()
+		},
+	})
+	shardMoveHandler := shardmove.NewHTTPHandler(m.log.With(zap.String("handler", "shard_move")), shardMoveSvc)
+	datagenSvc := datagen.NewService(
+		m.log.With(zap.String("service", "datagen")),
+		pointsWriter,
+		ts.BucketService,
+		deleteService,
+	)
+	m.closers = append(m.closers, labeledCloser{
+		label: "datagen",
+		closer: func(context.Context) error {
+			return datagenSvc.Close()
+		},
+	})
+	datagenHandler := datagen.NewHTTPHandler(m.log.With(zap.String("handler", "datagen")), datagenSvc)
+	snapshotHandler := snapshot.NewHTTPHandler(
+		m.log.With(zap.String("handler", "snapshot")),
+		snapshot.NewService(
+			m.log.With(zap.String("service", "snapshot")),
+			opts.SnapshotsPath,
+			backupService,
+			m.sqlStore,
+			bucketManifestWriter,
+			m.engine.TSDBStore(),
+			snapshot.WithCoordinator(maintenanceCoordinator),
+		),
+	)
+	maintenanceHandler := maintenance.NewHTTPHandler(
+		m.log.With(zap.String("handler", "maintenance")),
+		maintenanceCoordinator,
+		maintenance.WithCompactionSchedule(compactionSchedule),
+		maintenance.WithShardStore(m.engine.TSDBStore()),
+	)
+
+	resourceHandlers := []http.APIHandlerOptFn{
 		http.WithResourceHandler(stacksHTTPServer),
 		http.WithResourceHandler(templatesHTTPServer),
 		http.WithResourceHandler(onboardHTTPServer),
+		http.WithResourceHandler(inviteHTTPServer),
+		http.WithResourceHandler(bucketShareHTTPServer),
 		http.WithResourceHandler(authHTTPServer),
 		http.WithResourceHandler(labelHandler),
 		http.WithResourceHandler(sessionHTTPServer.SignInResourceHandler()),
@@ -899,9 +1443,28 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		http.WithResourceHandler(notebookServer),
 		http.WithResourceHandler(annotationServer),
 		http.WithResourceHandler(remotesServer),
+		http.WithResourceHandler(datasourcesServer),
+		http.WithResourceHandler(parsersServer),
 		http.WithResourceHandler(replicationServer),
 		http.WithResourceHandler(configHandler),
-	)
+		http.WithResourceHandler(diagnosticsHandler),
+		http.WithResourceHandler(shardMoveHandler),
+		http.WithResourceHandler(snapshotHandler),
+		http.WithResourceHandler(maintenanceHandler),
+		http.WithResourceHandler(datagenHandler),
+	}
+	if writeBuffer != nil {
+		resourceHandlers = append(resourceHandlers, http.WithResourceHandler(writebuffer.NewHTTPHandler(m.log.With(zap.String("handler", "write_buffer")), writeBuffer)))
+	}
+	if webhooks != nil {
+		resourceHandlers = append(resourceHandlers, http.WithResourceHandler(webhook.NewHTTPHandler(m.log.With(zap.String("handler", "webhooks")), webhooks)))
+	}
+	if schemaCache != nil {
+		schemaCacheHTTPServer := schemacache.NewHTTPHandler(m.log.With(zap.String("handler", "schema_cache")), schemaCache, authorizer.NewBucketService(ts.BucketService))
+		resourceHandlers = append(resourceHandlers, http.WithResourceHandler(schemaCacheHTTPServer))
+	}
+
+	platformHandler := http.NewPlatformHandler(m.apibackend, resourceHandlers...)
 
 	httpLogger := m.log.With(zap.String("service", "http"))
 	var httpHandler nethttp.Handler = http.NewRootHandler(
@@ -910,6 +1473,12 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		http.WithAPIHandler(platformHandler),
 		http.WithPprofEnabled(!opts.ProfilingDisabled),
 		http.WithMetrics(m.reg, !opts.MetricsDisabled),
+		http.WithMemoryReporters(m.engine, m.queryController),
+		http.WithSlowWriteLogControl(m.apibackend.SlowWriteLog),
+		http.WithOperatorOnly(func(next nethttp.Handler) nethttp.Handler {
+			return http.NewOperatorHandler(m.apibackend, next)
+		}),
+		http.WithHealthDetail(m.healthDetail()),
 	)
 
 	if opts.LogLevel == zap.DebugLevel {
@@ -927,9 +1496,109 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		return err
 	}
 
+	if !opts.AdminSocketDisabled {
+		m.runAdminSocket(opts)
+	}
+
+	if feature.StorageReadsService().Enabled(ctx, m.flagger) {
+		store := storage2.NewStore(m.engine.TSDBStore(), m.engine.MetaClient())
+		if err := m.runStorageReads(opts, store, authSvc, ts.UserService, ts.BucketService); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// runAdminSocket serves the admin console. The server runs without it if
+// the socket cannot be opened.
+func (m *Launcher) runAdminSocket(opts *InfluxdOpts) {
+	srv := admin.NewServer(m.log.With(zap.String("service", "admin")), opts.AdminSocketPath, m.adminCommands()...)
+	if err := srv.Open(); err != nil {
+		m.log.Warn("Failed to open admin socket", zap.String("path", opts.AdminSocketPath), zap.Error(err))
+		return
+	}
+	m.closers = append(m.closers, labeledCloser{
+		label: "admin socket",
+		closer: func(context.Context) error {
+			return srv.Close()
+		},
+	})
+}
+
+// runStorageReads serves the storage reads gRPC API to external query
+// engines, with the TLS certificate of the HTTP server when it has one.
+// Without TLS the tokens of the calls are sent in plaintext, so it only
+// binds to a loopback address unless the operator opts in.
+func (m *Launcher) runStorageReads(opts *InfluxdOpts, store reads.Store, auths platform.AuthorizationService, users platform.UserService, buckets platform.BucketService) error {
+	log := m.log.With(zap.String("service", "storage-reads"))
+
+	if !m.tlsEnabled && !opts.StorageReadsInsecure && !isLoopbackAddress(opts.StorageReadsBindAddress) {
+		err := fmt.Errorf("storage reads bind address %s is not a loopback address and TLS is disabled; enable TLS or set --storage-reads-insecure", opts.StorageReadsBindAddress)
+		log.Error("Refusing to serve storage reads without TLS", zap.Error(err))
+		return err
+	}
+
+	var serverOpts []grpc.ServerOption
+	if m.tlsEnabled {
+		creds, err := credentials.NewServerTLSFromFile(opts.HttpTLSCert, opts.HttpTLSKey)
+		if err != nil {
+			log.Error("Failed to load x509 key pair", zap.String("cert-path", opts.HttpTLSCert), zap.String("key-path", opts.HttpTLSKey))
+			return err
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	}
+	grpcServer := grpc.NewServer(serverOpts...)
+	readservice.NewServer(log, store, auths, users, buckets).Register(grpcServer)
+
+	ln, err := net.Listen("tcp", opts.StorageReadsBindAddress)
+	if err != nil {
+		log.Error("Failed to set up TCP listener", zap.String("addr", opts.StorageReadsBindAddress), zap.Error(err))
+		return err
+	}
+	m.closers = append(m.closers, labeledCloser{
+		label: "storage reads server",
+		closer: func(ctx context.Context) error {
+			stopped := make(chan struct{})
+			go func() {
+				grpcServer.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				grpcServer.Stop()
+			}
+			return nil
+		},
+	})
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		log.Info("Listening", zap.String("transport", "grpc"), zap.String("addr", opts.StorageReadsBindAddress), zap.Bool("tls", m.tlsEnabled))
+		if err := grpcServer.Serve(ln); err != nil {
+			log.Error("Failed to serve storage reads", zap.Error(err))
+		}
+		log.Info("Stopping")
+	}()
+	return nil
+}
+
+// isLoopbackAddress reports whether the host of addr is a loopback address.
+// An empty host binds to every interface.
+func isLoopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // initTracing sets up the global tracer for the influxd process.
 // Any errors encountered during setup are logged, but don't crash the process.
 func (m *Launcher) initTracing(opts *InfluxdOpts) {
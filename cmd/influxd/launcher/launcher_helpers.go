@@ -135,6 +135,9 @@ func (tl *TestLauncher) Run(tb zaptest.TestingT, ctx context.Context, setters ..
 	opts.BoltPath = filepath.Join(tl.Path, bolt.DefaultFilename)
 	opts.SqLitePath = filepath.Join(tl.Path, sqlite.DefaultFilename)
 	opts.EnginePath = filepath.Join(tl.Path, "engine")
+	opts.WebhooksConfig = filepath.Join(tl.Path, "webhooks.json")
+	opts.SnapshotsPath = filepath.Join(tl.Path, "snapshots")
+	opts.AdminSocketPath = filepath.Join(tl.Path, "influxd.sock")
 	opts.HttpBindAddress = "127.0.0.1:0"
 	opts.LogLevel = zap.DebugLevel
 	opts.ReportingDisabled = true
@@ -735,6 +735,55 @@ stream2 |> filter(fn: (r) => contains(value: r._value, set: col)) |> group() |>
 	}
 }
 
+func TestLauncher_Query_Utils(t *testing.T) {
+	l := launcher.RunAndSetupNewLauncherOrFail(ctx, t)
+	defer l.ShutdownOrFail(t, ctx)
+
+	l.WritePointsOrFail(t, `
+cpu,host=a v=1 1609459200000000000
+cpu,host=a v=2 1609459230000000000
+cpu,host=b v=10 1609459200000000000
+`)
+
+	t.Run("topNByTag", func(t *testing.T) {
+		got := l.QueryFlux(t, l.Org, l.Auth.Token, fmt.Sprintf(`
+from(bucket: "%s")
+	|> range(start: 2021-01-01T00:00:00Z, stop: 2021-01-01T01:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu")
+	|> utils.topNByTag(tag: "host", n: 1)
+	|> map(fn: (r) => ({r with top: r.host + "=" + string(v: r._value)}))
+	|> keep(columns: ["top"])
+`, l.Bucket.Name))
+		assert.Equal(t, ",result,table,top\n,_result,0,b=10\n", got)
+	})
+
+	t.Run("rateLimitSafeAggregate widens windows", func(t *testing.T) {
+		got := l.QueryFlux(t, l.Org, l.Auth.Token, fmt.Sprintf(`
+from(bucket: "%s")
+	|> range(start: 2021-01-01T00:00:00Z, stop: 2021-01-01T01:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r.host == "a")
+	|> utils.rateLimitSafeAggregate(every: 1s, start: 2021-01-01T00:00:00Z, stop: 2021-01-01T01:00:00Z, maxPoints: 4)
+	|> keep(columns: ["_time", "_value"])
+`, l.Bucket.Name))
+		assert.Equal(t, ",result,table,_time,_value\n,_result,0,2021-01-01T00:15:00Z,1.5\n", got)
+	})
+
+	t.Run("rateLimitSafeAggregate accepts relative start", func(t *testing.T) {
+		got := l.QueryFlux(t, l.Org, l.Auth.Token, fmt.Sprintf(`
+import "experimental"
+
+option now = () => 2021-01-01T01:00:00Z
+
+from(bucket: "%s")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement == "cpu" and r.host == "a")
+	|> utils.rateLimitSafeAggregate(every: 1s, start: -1h, maxPoints: 4)
+	|> keep(columns: ["_time", "_value"])
+`, l.Bucket.Name))
+		assert.Equal(t, ",result,table,_time,_value\n,_result,0,2021-01-01T00:15:00Z,1.5\n", got)
+	})
+}
+
 func TestLauncher_Query_ExperimentalTo(t *testing.T) {
 	l := launcher.RunAndSetupNewLauncherOrFail(ctx, t)
 	defer l.ShutdownOrFail(t, ctx)
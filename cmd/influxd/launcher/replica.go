@@ -0,0 +1,87 @@
+package launcher
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// replicaRefresher keeps a read replica serving near-real-time copies of
+// the data of a write node. The TSM data at the engine path is replaced by
+// filesystem snapshots or object storage sync, the metadata is restored
+// from a copy of the bolt file of the write node.
+type replicaRefresher struct {
+	engine interface {
+		Refresh(ctx context.Context) error
+	}
+	metaClient interface {
+		Restore(ctx context.Context, r io.Reader) error
+		Reload() error
+	}
+
+	// boltSourcePath is the copy of the bolt file of the write node. The
+	// metadata is not refreshed when it is empty.
+	boltSourcePath string
+	interval       time.Duration
+	log            *zap.Logger
+
+	boltModTime time.Time
+}
+
+func (r *replicaRefresher) run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			if err := r.refresh(ctx); err != nil {
+				r.log.Error("Failed to refresh replica", zap.Error(err))
+				continue
+			}
+			r.log.Debug("Refreshed replica", zap.Duration("duration", time.Since(start)))
+		}
+	}
+}
+
+// refresh restores the metadata when its copy changed, so the shards of
+// new shard groups are known, and reopens the shards.
+func (r *replicaRefresher) refresh(ctx context.Context) error {
+	if r.boltSourcePath != "" {
+		if err := r.refreshMeta(ctx); err != nil {
+			return err
+		}
+	}
+	return r.engine.Refresh(ctx)
+}
+
+func (r *replicaRefresher) refreshMeta(ctx context.Context) error {
+	fi, err := os.Stat(r.boltSourcePath)
+	if err != nil {
+		return err
+	}
+	if fi.ModTime().Equal(r.boltModTime) {
+		return nil
+	}
+
+	f, err := os.Open(r.boltSourcePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := r.metaClient.Restore(ctx, f); err != nil {
+		return err
+	}
+	if err := r.metaClient.Reload(); err != nil {
+		return err
+	}
+	r.boltModTime = fi.ModTime()
+	return nil
+}
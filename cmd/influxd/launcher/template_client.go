@@ -0,0 +1,73 @@
+package launcher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/pkger"
+)
+
+// templateHTTPClientOpts returns the options of the HTTP client remote
+// templates are fetched with.
+func templateHTTPClientOpts(opts *InfluxdOpts) ([]pkger.HTTPClientOptFn, error) {
+	clientOpts := []pkger.HTTPClientOptFn{
+		pkger.WithHTTPClientRedirectPolicy(pkger.MaxRedirects(opts.TemplateFetchMaxRedirects)),
+	}
+
+	if opts.TemplateFetchProxy != "" {
+		proxy, err := url.Parse(opts.TemplateFetchProxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template fetch proxy: %w", err)
+		}
+		clientOpts = append(clientOpts, pkger.WithHTTPClientProxy(proxy))
+	}
+
+	if opts.TemplateFetchCACerts != "" {
+		pem, err := ioutil.ReadFile(opts.TemplateFetchCACerts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template fetch CA certs: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.TemplateFetchCACerts)
+		}
+		clientOpts = append(clientOpts, pkger.WithHTTPClientRootCAs(pool))
+	}
+
+	if opts.TemplateFetchTLSCert != "" || opts.TemplateFetchTLSKey != "" {
+		if opts.TemplateFetchTLSCert == "" || opts.TemplateFetchTLSKey == "" {
+			return nil, errors.New("template fetch TLS cert and key must be provided together")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.TemplateFetchTLSCert, opts.TemplateFetchTLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load template fetch TLS cert: %w", err)
+		}
+		clientOpts = append(clientOpts, pkger.WithHTTPClientCertificates(cert))
+	}
+
+	for _, h := range opts.TemplateFetchHeaders {
+		host, header, ok := cutString(h, "=")
+		if !ok || host == "" {
+			return nil, fmt.Errorf("invalid template fetch header %q; must be formatted as <host>=<header>:<value>", h)
+		}
+		key, value, ok := cutString(header, ":")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid template fetch header %q; must be formatted as <host>=<header>:<value>", h)
+		}
+		clientOpts = append(clientOpts, pkger.WithHTTPClientHostHeader(host, strings.TrimSpace(key), strings.TrimSpace(value)))
+	}
+
+	return clientOpts, nil
+}
+
+func cutString(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/attach"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/downgrade"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/launcher"
@@ -51,6 +52,7 @@ func main() {
 	rootCmd.AddCommand(inspectCmd)
 	rootCmd.AddCommand(versionCmd())
 	rootCmd.AddCommand(recovery.NewCommand())
+	rootCmd.AddCommand(attach.NewCommand())
 	downgradeCmd, err := downgrade.NewCommand(ctx, v)
 	if err != nil {
 		handleErr(err.Error())
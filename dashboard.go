@@ -56,7 +56,7 @@ type DashboardService interface {
 	AddDashboardCell(ctx context.Context, id platform.ID, c *Cell, opts AddDashboardCellOptions) error
 
 	// RemoveDashboardCell removes a dashboard.
-	RemoveDashboardCell(ctx context.Context, dashboardID, cellID platform.ID) error
+	RemoveDashboardCell(ctx context.Context, dashboardID, cellID platform.ID, opts DashboardCellsOptions) error
 
 	// UpdateDashboardCell replaces the dashboard cell with the provided ID.
 	UpdateDashboardCell(ctx context.Context, dashboardID, cellID platform.ID, upd CellUpdate) (*Cell, error)
@@ -71,18 +71,21 @@ type DashboardService interface {
 	DeleteDashboard(ctx context.Context, id platform.ID) error
 
 	// ReplaceDashboardCells replaces all cells in a dashboard
-	ReplaceDashboardCells(ctx context.Context, id platform.ID, c []*Cell) error
+	ReplaceDashboardCells(ctx context.Context, id platform.ID, c []*Cell, opts DashboardCellsOptions) error
 }
 
 // Dashboard represents all visual and query data for a dashboard.
 type Dashboard struct {
-	ID             platform.ID   `json:"id,omitempty"`
-	OrganizationID platform.ID   `json:"orgID,omitempty"`
-	Name           string        `json:"name"`
-	Description    string        `json:"description"`
-	Cells          []*Cell       `json:"cells"`
-	Meta           DashboardMeta `json:"meta"`
-	OwnerID        *platform.ID  `json:"owner,omitempty"`
+	ID             platform.ID         `json:"id,omitempty"`
+	OrganizationID platform.ID         `json:"orgID,omitempty"`
+	Name           string              `json:"name"`
+	Description    string              `json:"description"`
+	Cells          []*Cell             `json:"cells"`
+	Meta           DashboardMeta       `json:"meta"`
+	OwnerID        *platform.ID        `json:"owner,omitempty"`
+	Annotations    ResourceAnnotations `json:"annotations,omitempty"`
+	// TimeRange, when set, is the time range the dashboard is shown with.
+	TimeRange *TimeRangeOverride `json:"timeRange,omitempty"`
 }
 
 // DashboardMeta contains meta information about dashboards
@@ -194,6 +197,17 @@ type CellProperty struct {
 	Y int32 `json:"y"`
 	W int32 `json:"w"`
 	H int32 `json:"h"`
+	// TimeRange, when set, is the time range the cell is shown with in
+	// place of the one of the dashboard.
+	TimeRange *TimeRangeOverride `json:"timeRange,omitempty"`
+}
+
+// Valid returns an error if the time range of the cell is invalid.
+func (p CellProperty) Valid() error {
+	if p.TimeRange != nil {
+		return p.TimeRange.Valid()
+	}
+	return nil
 }
 
 // DashboardFilter is a filter for dashboards.
@@ -202,6 +216,7 @@ type DashboardFilter struct {
 	OrganizationID *platform.ID
 	Organization   *string
 	OwnerID        *platform.ID
+	Annotations    ResourceAnnotations
 }
 
 // QueryParams turns a dashboard filter into query params
@@ -227,6 +242,8 @@ func (f DashboardFilter) QueryParams() map[string][]string {
 		qp.Add("owner", f.OwnerID.String())
 	}
 
+	f.Annotations.AddQueryParams(qp)
+
 	return qp
 }
 
@@ -235,6 +252,15 @@ type DashboardUpdate struct {
 	Name        *string  `json:"name"`
 	Description *string  `json:"description"`
 	Cells       *[]*Cell `json:"cells"`
+	// Annotations replaces all annotations of the dashboard when set.
+	Annotations *ResourceAnnotations `json:"annotations,omitempty"`
+	// TimeRange replaces the time range of the dashboard when set. An empty
+	// time range removes it.
+	TimeRange *TimeRangeOverride `json:"timeRange,omitempty"`
+	// IfUpdatedAt, when set, is the version of the dashboard the update was
+	// made against. The update fails with EPreconditionFailed when the
+	// dashboard was updated since.
+	IfUpdatedAt *time.Time `json:"-"`
 }
 
 // Apply applies an update to a dashboard.
@@ -251,24 +277,63 @@ func (u DashboardUpdate) Apply(d *Dashboard) error {
 		d.Cells = *u.Cells
 	}
 
+	if u.Annotations != nil {
+		d.Annotations = u.Annotations.Clone()
+	}
+
+	if u.TimeRange != nil {
+		d.TimeRange = u.TimeRange.override()
+	}
+
 	return nil
 }
 
 // Valid returns an error if the dashboard update is invalid.
 func (u DashboardUpdate) Valid() *errors.Error {
-	if u.Name == nil && u.Description == nil {
+	if u.Name == nil && u.Description == nil && u.Annotations == nil && u.TimeRange == nil {
 		return &errors.Error{
 			Code: errors.EInvalid,
 			Msg:  "must update at least one attribute",
 		}
 	}
 
+	if u.Annotations != nil {
+		if err := u.Annotations.Valid(); err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Err:  err,
+			}
+		}
+	}
+
+	if u.TimeRange != nil && !u.TimeRange.IsZero() {
+		if err := u.TimeRange.Valid(); err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Err:  err,
+			}
+		}
+	}
+
 	return nil
 }
 
 // AddDashboardCellOptions are options for adding a dashboard.
 type AddDashboardCellOptions struct {
 	View *View
+	// IfUpdatedAt, when set, is the version of the dashboard the cell was
+	// added against. Adding fails with EPreconditionFailed when the
+	// dashboard was updated since.
+	IfUpdatedAt *time.Time
+}
+
+// DashboardCellsOptions are options for removing or replacing the cells of
+// a dashboard.
+type DashboardCellsOptions struct {
+	// IfUpdatedAt, when set, is the version of the dashboard the cells were
+	// changed against. The change fails with EPreconditionFailed when the
+	// dashboard was updated since.
+	IfUpdatedAt *time.Time
 }
 
 // CellUpdate is the patch structure for a cell.
@@ -277,6 +342,13 @@ type CellUpdate struct {
 	Y *int32 `json:"y"`
 	W *int32 `json:"w"`
 	H *int32 `json:"h"`
+	// TimeRange replaces the time range of the cell when set. An empty time
+	// range removes it.
+	TimeRange *TimeRangeOverride `json:"timeRange,omitempty"`
+	// IfUpdatedAt, when set, is the version of the dashboard the update was
+	// made against. The update fails with EPreconditionFailed when the
+	// dashboard was updated since.
+	IfUpdatedAt *time.Time `json:"-"`
 }
 
 // Apply applies an update to a Cell.
@@ -297,18 +369,31 @@ func (u CellUpdate) Apply(c *Cell) error {
 		c.H = *u.H
 	}
 
+	if u.TimeRange != nil {
+		c.TimeRange = u.TimeRange.override()
+	}
+
 	return nil
 }
 
 // Valid returns an error if the cell update is invalid.
 func (u CellUpdate) Valid() *errors.Error {
-	if u.H == nil && u.W == nil && u.Y == nil && u.X == nil {
+	if u.H == nil && u.W == nil && u.Y == nil && u.X == nil && u.TimeRange == nil {
 		return &errors.Error{
 			Code: errors.EInvalid,
 			Msg:  "must update at least one attribute",
 		}
 	}
 
+	if u.TimeRange != nil && !u.TimeRange.IsZero() {
+		if err := u.TimeRange.Valid(); err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Err:  err,
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -248,3 +248,30 @@ func jsonEqual(s1, s2 string) (eq bool, err error) {
 	}
 	return cmp.Equal(o1, o2), nil
 }
+
+func TestTimeRangeOverride_Valid(t *testing.T) {
+	for _, tr := range []platform.TimeRangeOverride{
+		{Lower: "-30d"},
+		{Lower: "-1mo", Upper: "-1w", Refresh: "10s"},
+		{Lower: "-1h30m", Upper: "now()"},
+		{Lower: "2021-01-01T00:00:00Z", Upper: "2021-01-02T00:00:00Z"},
+	} {
+		if err := tr.Valid(); err != nil {
+			t.Errorf("%+v: unexpected error: %v", tr, err)
+		}
+	}
+
+	for _, tr := range []platform.TimeRangeOverride{
+		{},
+		{Lower: "30d"},
+		{Lower: "-30x"},
+		{Lower: "-1h", Upper: "-2h"},
+		{Lower: "2021-01-02T00:00:00Z", Upper: "2021-01-01T00:00:00Z"},
+		{Lower: "-1h", Refresh: "-1m"},
+		{Lower: "-1h", Refresh: "1mo"},
+	} {
+		if err := tr.Valid(); err == nil {
+			t.Errorf("%+v: expected an error", tr)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package influxdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// TimeRangeOverride is the time range and refresh interval a dashboard or a
+// cell is shown with, in place of the ones picked in the UI. It lets a cell
+// show a 30 day trend on a dashboard showing the last hour.
+type TimeRangeOverride struct {
+	// Lower is the start of the range, either a negative duration relative
+	// to now such as -30d, or an RFC3339 time.
+	Lower string `json:"lower"`
+	// Upper is the end of the range in the same format as Lower. It
+	// defaults to now.
+	Upper string `json:"upper,omitempty"`
+	// Refresh is how often the data is refreshed, such as 1m. It defaults
+	// to the refresh interval picked in the UI.
+	Refresh string `json:"refresh,omitempty"`
+}
+
+// IsZero reports whether the override sets nothing. Updates use it to
+// remove an override.
+func (t TimeRangeOverride) IsZero() bool {
+	return t == TimeRangeOverride{}
+}
+
+// override returns a copy of the override an update sets, nil for an empty
+// one.
+func (t *TimeRangeOverride) override() *TimeRangeOverride {
+	if t.IsZero() {
+		return nil
+	}
+	o := *t
+	return &o
+}
+
+// Valid returns an error if the bounds or the refresh interval are
+// malformed, or if the range is empty.
+func (t TimeRangeOverride) Valid() error {
+	now := time.Now()
+	lower, err := timeRangeBound(t.Lower, now)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid lower bound %q of time range", t.Lower),
+			Err:  err,
+		}
+	}
+
+	upper := now
+	if t.Upper != "" {
+		if upper, err = timeRangeBound(t.Upper, now); err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid upper bound %q of time range", t.Upper),
+				Err:  err,
+			}
+		}
+	}
+
+	if !lower.Before(upper) {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "lower bound of time range must be before its upper bound",
+		}
+	}
+
+	if t.Refresh != "" {
+		o, err := parseTimeRangeOffset(t.Refresh)
+		if err == nil && (o.months != 0 || o.dur <= 0) {
+			err = fmt.Errorf("must be a positive duration of at most weeks")
+		}
+		if err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid refresh interval %q of time range", t.Refresh),
+				Err:  err,
+			}
+		}
+	}
+
+	return nil
+}
+
+// timeRangeBound resolves a bound of a time range relative to now.
+func timeRangeBound(s string, now time.Time) (time.Time, error) {
+	if s == "now()" {
+		return now, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+
+	o, err := parseTimeRangeOffset(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if o.months > 0 || o.dur > 0 {
+		return time.Time{}, fmt.Errorf("relative bounds must not be in the future")
+	}
+	return now.AddDate(0, int(o.months), 0).Add(o.dur), nil
+}
+
+// timeRangeOffset is a duration in the flux syntax, split into the months
+// and the part of fixed length.
+type timeRangeOffset struct {
+	months int64
+	dur    time.Duration
+}
+
+var timeRangeUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+// parseTimeRangeOffset parses durations such as -30d or 1h30m. The month
+// and year units are kept apart since their length varies.
+func parseTimeRangeOffset(s string) (timeRangeOffset, error) {
+	var o timeRangeOffset
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	if s == "" {
+		return o, fmt.Errorf("empty duration")
+	}
+
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+	for s != "" {
+		i := 0
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+		if i == 0 {
+			return o, fmt.Errorf("missing magnitude before %q", s)
+		}
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return o, err
+		}
+		s = s[i:]
+
+		j := 0
+		for j < len(s) && !isDigit(s[j]) {
+			j++
+		}
+		unit := s[:j]
+		s = s[j:]
+
+		switch unit {
+		case "y":
+			o.months += 12 * n
+		case "mo":
+			o.months += n
+		default:
+			d, ok := timeRangeUnits[unit]
+			if !ok {
+				return o, fmt.Errorf("unknown duration unit %q", unit)
+			}
+			o.dur += time.Duration(n) * d
+		}
+	}
+
+	if neg {
+		o.months, o.dur = -o.months, -o.dur
+	}
+	return o, nil
+}
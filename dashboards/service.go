@@ -128,7 +128,7 @@ func (s *Service) FindDashboard(ctx context.Context, filter influxdb.DashboardFi
 	var d *influxdb.Dashboard
 	err := s.kv.View(ctx, func(tx kv.Tx) error {
 		filterFn := filterDashboardsFn(filter)
-		return s.forEachDashboard(ctx, tx, opts[0].Descending, func(dash *influxdb.Dashboard) bool {
+		return s.forEachDashboard(ctx, tx, opts[0].Descending, nil, func(dash *influxdb.Dashboard) bool {
 			if filterFn(dash) {
 				d = dash
 				return false
@@ -159,13 +159,14 @@ func filterDashboardsFn(filter influxdb.DashboardFilter) func(d *influxdb.Dashbo
 		}
 		return func(d *influxdb.Dashboard) bool {
 			_, ok := m[d.ID.String()]
-			return ok
+			return ok && d.Annotations.Matches(filter.Annotations)
 		}
 	}
 
 	return func(d *influxdb.Dashboard) bool {
 		return ((filter.OrganizationID == nil) || (*filter.OrganizationID == d.OrganizationID)) &&
-			((filter.OwnerID == nil) || (d.OwnerID != nil && *filter.OwnerID == *d.OwnerID))
+			((filter.OwnerID == nil) || (d.OwnerID != nil && *filter.OwnerID == *d.OwnerID)) &&
+			d.Annotations.Matches(filter.Annotations)
 	}
 }
 
@@ -179,7 +180,7 @@ func (s *Service) FindDashboards(ctx context.Context, filter influxdb.DashboardF
 				Err: err,
 			}
 		}
-		if d == nil {
+		if d == nil || !d.Annotations.Matches(filter.Annotations) {
 			return ds, 0, nil
 		}
 		return []*influxdb.Dashboard{d}, 1, nil
@@ -204,7 +205,9 @@ func (s *Service) FindDashboards(ctx context.Context, filter influxdb.DashboardF
 	return ds, len(ds), nil
 }
 
-func (s *Service) findOrganizationDashboards(ctx context.Context, tx kv.Tx, orgID platform.ID, filter influxdb.DashboardFilter) ([]*influxdb.Dashboard, error) {
+// findOrganizationDashboards returns the dashboards of the org ordered by
+// ID, starting after the dashboard with the after ID when it is set.
+func (s *Service) findOrganizationDashboards(ctx context.Context, tx kv.Tx, orgID platform.ID, filter influxdb.DashboardFilter, after *platform.ID) ([]*influxdb.Dashboard, error) {
 	idx, err := tx.Bucket(orgDashboardIndex)
 	if err != nil {
 		return nil, err
@@ -215,8 +218,17 @@ func (s *Service) findOrganizationDashboards(ctx context.Context, tx kv.Tx, orgI
 		return nil, err
 	}
 
+	seek := prefix
+	if after != nil {
+		next, err := (*after + 1).Encode()
+		if err != nil {
+			return nil, err
+		}
+		seek = append(append([]byte{}, prefix...), next...)
+	}
+
 	// TODO(desa): support find options.
-	cur, err := idx.ForwardCursor(prefix, kv.WithCursorPrefix(prefix))
+	cur, err := idx.ForwardCursor(seek, kv.WithCursorPrefix(prefix))
 	if err != nil {
 		return nil, err
 	}
@@ -259,10 +271,15 @@ func decodeOrgDashboardIndexKey(indexKey []byte) (orgID platform.ID, dashID plat
 }
 
 func (s *Service) findDashboards(ctx context.Context, tx kv.Tx, filter influxdb.DashboardFilter, opts ...influxdb.FindOptions) ([]*influxdb.Dashboard, error) {
+	var after *platform.ID
+	if len(opts) > 0 {
+		after = opts[0].After
+	}
+
 	enforceOrgPagination := feature.EnforceOrganizationDashboardLimits().Enabled(ctx)
 	if !enforceOrgPagination {
 		if filter.OrganizationID != nil {
-			return s.findOrganizationDashboards(ctx, tx, *filter.OrganizationID, filter)
+			return s.findOrganizationDashboards(ctx, tx, *filter.OrganizationID, filter, after)
 		}
 	}
 
@@ -276,7 +293,7 @@ func (s *Service) findDashboards(ctx context.Context, tx kv.Tx, filter influxdb.
 
 	if enforceOrgPagination {
 		if filter.OrganizationID != nil {
-			orgDashboards, err := s.findOrganizationDashboards(ctx, tx, *filter.OrganizationID, filter)
+			orgDashboards, err := s.findOrganizationDashboards(ctx, tx, *filter.OrganizationID, filter, after)
 			if err != nil {
 				return nil, &errors.Error{
 					Err: err,
@@ -300,7 +317,7 @@ func (s *Service) findDashboards(ctx context.Context, tx kv.Tx, filter influxdb.
 
 	ds := []*influxdb.Dashboard{}
 	filterFn := filterDashboardsFn(filter)
-	err := s.forEachDashboard(ctx, tx, descending, func(d *influxdb.Dashboard) bool {
+	err := s.forEachDashboard(ctx, tx, descending, after, func(d *influxdb.Dashboard) bool {
 		if filterFn(d) {
 			if count >= offset {
 				ds = append(ds, d)
@@ -322,6 +339,14 @@ func (s *Service) findDashboards(ctx context.Context, tx kv.Tx, filter influxdb.
 
 // CreateDashboard creates a influxdb dashboard and sets d.ID.
 func (s *Service) CreateDashboard(ctx context.Context, d *influxdb.Dashboard) error {
+	if err := d.Annotations.Valid(); err != nil {
+		return err
+	}
+
+	if err := validTimeRanges(d.TimeRange, d.Cells); err != nil {
+		return err
+	}
+
 	err := s.kv.Update(ctx, func(tx kv.Tx) error {
 		d.ID = s.IDGenerator.ID()
 
@@ -369,13 +394,21 @@ func (s *Service) createCellView(ctx context.Context, tx kv.Tx, dashID, cellID p
 }
 
 // ReplaceDashboardCells updates the positions of each cell in a dashboard concurrently.
-func (s *Service) ReplaceDashboardCells(ctx context.Context, id platform.ID, cs []*influxdb.Cell) error {
+func (s *Service) ReplaceDashboardCells(ctx context.Context, id platform.ID, cs []*influxdb.Cell, opts influxdb.DashboardCellsOptions) error {
+	if err := validTimeRanges(nil, cs); err != nil {
+		return err
+	}
+
 	err := s.kv.Update(ctx, func(tx kv.Tx) error {
 		d, err := s.findDashboardByID(ctx, tx, id)
 		if err != nil {
 			return err
 		}
 
+		if err := checkDashboardVersion(d, opts.IfUpdatedAt); err != nil {
+			return err
+		}
+
 		ids := map[string]*influxdb.Cell{}
 		for _, cell := range d.Cells {
 			ids[cell.ID.String()] = cell
@@ -417,6 +450,9 @@ func (s *Service) addDashboardCell(ctx context.Context, tx kv.Tx, id platform.ID
 	if err != nil {
 		return err
 	}
+	if err := checkDashboardVersion(d, opts.IfUpdatedAt); err != nil {
+		return err
+	}
 	cell.ID = s.IDGenerator.ID()
 	if err := s.createCellView(ctx, tx, id, cell.ID, opts.View); err != nil {
 		return err
@@ -433,6 +469,10 @@ func (s *Service) addDashboardCell(ctx context.Context, tx kv.Tx, id platform.ID
 
 // AddDashboardCell adds a cell to a dashboard and sets the cells ID.
 func (s *Service) AddDashboardCell(ctx context.Context, id platform.ID, cell *influxdb.Cell, opts influxdb.AddDashboardCellOptions) error {
+	if err := cell.Valid(); err != nil {
+		return err
+	}
+
 	err := s.kv.Update(ctx, func(tx kv.Tx) error {
 		return s.addDashboardCell(ctx, tx, id, cell, opts)
 	})
@@ -445,7 +485,7 @@ func (s *Service) AddDashboardCell(ctx context.Context, id platform.ID, cell *in
 }
 
 // RemoveDashboardCell removes a cell from a dashboard.
-func (s *Service) RemoveDashboardCell(ctx context.Context, dashboardID, cellID platform.ID) error {
+func (s *Service) RemoveDashboardCell(ctx context.Context, dashboardID, cellID platform.ID, opts influxdb.DashboardCellsOptions) error {
 	return s.kv.Update(ctx, func(tx kv.Tx) error {
 		d, err := s.findDashboardByID(ctx, tx, dashboardID)
 		if err != nil {
@@ -454,6 +494,10 @@ func (s *Service) RemoveDashboardCell(ctx context.Context, dashboardID, cellID p
 			}
 		}
 
+		if err := checkDashboardVersion(d, opts.IfUpdatedAt); err != nil {
+			return err
+		}
+
 		idx := -1
 		for i, cell := range d.Cells {
 			if cell.ID == cellID {
@@ -651,6 +695,10 @@ func (s *Service) UpdateDashboardCell(ctx context.Context, dashboardID, cellID p
 			return err
 		}
 
+		if err := checkDashboardVersion(d, upd.IfUpdatedAt); err != nil {
+			return err
+		}
+
 		idx := -1
 		for i, cell := range d.Cells {
 			if cell.ID == cellID {
@@ -788,7 +836,9 @@ func (s *Service) putDashboardWithMeta(ctx context.Context, tx kv.Tx, d *influxd
 }
 
 // forEachDashboard will iterate through all dashboards while fn returns true.
-func (s *Service) forEachDashboard(ctx context.Context, tx kv.Tx, descending bool, fn func(*influxdb.Dashboard) bool) error {
+// forEachDashboard calls fn with the dashboards in the order of their IDs,
+// starting after the dashboard with the after ID when it is set.
+func (s *Service) forEachDashboard(ctx context.Context, tx kv.Tx, descending bool, after *platform.ID, fn func(*influxdb.Dashboard) bool) error {
 	b, err := tx.Bucket(dashboardBucket)
 	if err != nil {
 		return err
@@ -799,7 +849,21 @@ func (s *Service) forEachDashboard(ctx context.Context, tx kv.Tx, descending boo
 		direction = kv.CursorDescending
 	}
 
-	cur, err := b.ForwardCursor(nil, kv.WithCursorDirection(direction))
+	var seek []byte
+	if after != nil {
+		next := *after + 1
+		if descending {
+			if !(*after - 1).Valid() {
+				return nil
+			}
+			next = *after - 1
+		}
+		if seek, err = next.Encode(); err != nil {
+			return err
+		}
+	}
+
+	cur, err := b.ForwardCursor(seek, kv.WithCursorDirection(direction))
 	if err != nil {
 		return err
 	}
@@ -849,7 +913,14 @@ func (s *Service) updateDashboard(ctx context.Context, tx kv.Tx, id platform.ID,
 		return nil, err
 	}
 
+	if err := checkDashboardVersion(d, upd.IfUpdatedAt); err != nil {
+		return nil, err
+	}
+
 	if upd.Cells != nil {
+		if err := validTimeRanges(nil, *upd.Cells); err != nil {
+			return nil, err
+		}
 		for _, c := range *upd.Cells {
 			if !c.ID.Valid() {
 				c.ID = s.IDGenerator.ID()
@@ -888,6 +959,34 @@ func (s *Service) updateDashboard(ctx context.Context, tx kv.Tx, id platform.ID,
 	return d, nil
 }
 
+// checkDashboardVersion fails with EPreconditionFailed when the dashboard
+// was updated since ifUpdatedAt. A nil ifUpdatedAt matches any version.
+func checkDashboardVersion(d *influxdb.Dashboard, ifUpdatedAt *time.Time) error {
+	if ifUpdatedAt != nil && !d.Meta.UpdatedAt.Equal(*ifUpdatedAt) {
+		return &errors.Error{
+			Code: errors.EPreconditionFailed,
+			Msg:  "dashboard was updated since the version the update was made against",
+		}
+	}
+	return nil
+}
+
+// validTimeRanges returns an error if the time range of a dashboard or of
+// one of its cells is invalid.
+func validTimeRanges(tr *influxdb.TimeRangeOverride, cells []*influxdb.Cell) error {
+	if tr != nil {
+		if err := tr.Valid(); err != nil {
+			return err
+		}
+	}
+	for _, c := range cells {
+		if err := c.Valid(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // DeleteDashboard deletes a dashboard and prunes it from the index.
 func (s *Service) DeleteDashboard(ctx context.Context, id platform.ID) error {
 	return s.kv.Update(ctx, func(tx kv.Tx) error {
@@ -6,9 +6,12 @@ import (
 
 	"github.com/influxdata/influxdb/v2"
 	dashboardtesting "github.com/influxdata/influxdb/v2/dashboards/testing"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kv"
 	"github.com/influxdata/influxdb/v2/mock"
 	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -52,3 +55,56 @@ func initDashboardService(s kv.SchemaStore, f dashboardtesting.DashboardFields,
 		}
 	}
 }
+
+func TestService_TimeRanges(t *testing.T) {
+	svc, _, done := initBoltDashboardService(dashboardtesting.DashboardFields{
+		IDGenerator: mock.NewIncrementingIDGenerator(1),
+	}, t)
+	defer done()
+	ctx := context.Background()
+
+	trend := &influxdb.TimeRangeOverride{Lower: "-30d", Refresh: "1h"}
+	d := &influxdb.Dashboard{
+		OrganizationID: 10,
+		Name:           "trends",
+		TimeRange:      &influxdb.TimeRangeOverride{Lower: "-1h"},
+		Cells: []*influxdb.Cell{
+			{CellProperty: influxdb.CellProperty{W: 4, H: 4, TimeRange: trend}},
+			{CellProperty: influxdb.CellProperty{X: 4, W: 4, H: 4}},
+		},
+	}
+	require.NoError(t, svc.CreateDashboard(ctx, d))
+
+	got, err := svc.FindDashboardByID(ctx, d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "-1h", got.TimeRange.Lower)
+	assert.Equal(t, trend, got.Cells[0].TimeRange)
+	assert.Nil(t, got.Cells[1].TimeRange)
+
+	// an empty time range removes the override
+	cell, err := svc.UpdateDashboardCell(ctx, d.ID, got.Cells[0].ID, influxdb.CellUpdate{
+		TimeRange: &influxdb.TimeRangeOverride{},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, cell.TimeRange)
+
+	cell, err = svc.UpdateDashboardCell(ctx, d.ID, got.Cells[1].ID, influxdb.CellUpdate{
+		TimeRange: &influxdb.TimeRangeOverride{Lower: "2021-01-01T00:00:00Z", Upper: "2021-02-01T00:00:00Z"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "2021-02-01T00:00:00Z", cell.TimeRange.Upper)
+
+	_, err = svc.UpdateDashboardCell(ctx, d.ID, got.Cells[1].ID, influxdb.CellUpdate{
+		TimeRange: &influxdb.TimeRangeOverride{Lower: "-1h", Upper: "-2h"},
+	})
+	assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	err = svc.CreateDashboard(ctx, &influxdb.Dashboard{
+		OrganizationID: 10,
+		Name:           "invalid",
+		Cells: []*influxdb.Cell{
+			{CellProperty: influxdb.CellProperty{TimeRange: &influxdb.TimeRangeOverride{Lower: "yesterday"}}},
+		},
+	})
+	assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+}
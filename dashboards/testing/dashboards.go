@@ -29,6 +29,10 @@ func int32Ptr(i int32) *int32 {
 	return &i
 }
 
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
 var dashboardCmpOptions = cmp.Options{
 	cmpopts.EquateEmpty(),
 	cmp.Comparer(func(x, y []byte) bool {
@@ -1384,6 +1388,7 @@ func RemoveDashboardCell(
 	type args struct {
 		dashboardID platform2.ID
 		cellID      platform2.ID
+		opts        platform.DashboardCellsOptions
 	}
 	type wants struct {
 		err        error
@@ -1450,6 +1455,60 @@ func RemoveDashboardCell(
 				},
 			},
 		},
+		{
+			name: "remove cell of a dashboard updated since",
+			fields: DashboardFields{
+				TimeGenerator: mock.TimeGenerator{FakeValue: time.Date(2009, time.November, 10, 24, 0, 0, 0, time.UTC)},
+				IDGenerator: &mock.IDGenerator{
+					IDFn: func() platform2.ID {
+						return MustIDBase16(dashTwoID)
+					},
+				},
+				Dashboards: []*platform.Dashboard{
+					{
+						ID:             MustIDBase16(dashOneID),
+						OrganizationID: 1,
+						Meta: platform.DashboardMeta{
+							UpdatedAt: time.Date(2009, time.November, 9, 0, 0, 0, 0, time.UTC),
+						},
+						Name: "dashboard1",
+						Cells: []*platform.Cell{
+							{
+								ID: MustIDBase16(dashTwoID),
+							},
+						},
+					},
+				},
+			},
+			args: args{
+				dashboardID: MustIDBase16(dashOneID),
+				cellID:      MustIDBase16(dashTwoID),
+				opts: platform.DashboardCellsOptions{
+					IfUpdatedAt: timePtr(time.Date(2009, time.November, 8, 0, 0, 0, 0, time.UTC)),
+				},
+			},
+			wants: wants{
+				err: &errors.Error{
+					Code: errors.EPreconditionFailed,
+					Msg:  "dashboard was updated since the version the update was made against",
+				},
+				dashboards: []*platform.Dashboard{
+					{
+						ID:             MustIDBase16(dashOneID),
+						OrganizationID: 1,
+						Meta: platform.DashboardMeta{
+							UpdatedAt: time.Date(2009, time.November, 9, 0, 0, 0, 0, time.UTC),
+						},
+						Name: "dashboard1",
+						Cells: []*platform.Cell{
+							{
+								ID: MustIDBase16(dashTwoID),
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1457,7 +1516,7 @@ func RemoveDashboardCell(
 			s, opPrefix, done := init(tt.fields, t)
 			defer done()
 			ctx := context.Background()
-			err := s.RemoveDashboardCell(ctx, tt.args.dashboardID, tt.args.cellID)
+			err := s.RemoveDashboardCell(ctx, tt.args.dashboardID, tt.args.cellID, tt.args.opts)
 			diffPlatformErrors(tt.name, err, tt.wants.err, opPrefix, t)
 
 			defer s.DeleteDashboard(ctx, tt.args.dashboardID)
@@ -1855,7 +1914,7 @@ func ReplaceDashboardCells(
 			s, opPrefix, done := init(tt.fields, t)
 			defer done()
 			ctx := context.Background()
-			err := s.ReplaceDashboardCells(ctx, tt.args.dashboardID, tt.args.cells)
+			err := s.ReplaceDashboardCells(ctx, tt.args.dashboardID, tt.args.cells, platform.DashboardCellsOptions{})
 			diffPlatformErrors(tt.name, err, tt.wants.err, opPrefix, t)
 
 			defer s.DeleteDashboard(ctx, tt.args.dashboardID)
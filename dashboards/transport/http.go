@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
@@ -63,7 +64,7 @@ func NewDashboardHandler(
 
 		r.Route("/", func(r chi.Router) {
 			r.Post("/", h.handlePostDashboard)
-			r.Get("/", h.handleGetDashboards)
+			r.With(kithttp.ConditionalGet).Get("/", h.handleGetDashboards)
 
 			r.Route("/{id}", func(r chi.Router) {
 				r.Get("/", h.handleGetDashboard)
@@ -79,7 +80,7 @@ func NewDashboardHandler(
 						r.Patch("/", h.handlePatchDashboardCell)
 
 						r.Route("/view", func(r chi.Router) {
-							r.Get("/", h.handleGetDashboardCellView)
+							r.With(kithttp.ConditionalGet).Get("/", h.handleGetDashboardCellView)
 							r.Patch("/", h.handlePatchDashboardCellView)
 						})
 					})
@@ -114,14 +115,16 @@ type dashboardLinks struct {
 }
 
 type dashboardResponse struct {
-	ID             platform.ID             `json:"id,omitempty"`
-	OrganizationID platform.ID             `json:"orgID,omitempty"`
-	Name           string                  `json:"name"`
-	Description    string                  `json:"description"`
-	Meta           influxdb.DashboardMeta  `json:"meta"`
-	Cells          []dashboardCellResponse `json:"cells"`
-	Labels         []influxdb.Label        `json:"labels"`
-	Links          dashboardLinks          `json:"links"`
+	ID             platform.ID                  `json:"id,omitempty"`
+	OrganizationID platform.ID                  `json:"orgID,omitempty"`
+	Name           string                       `json:"name"`
+	Description    string                       `json:"description"`
+	Meta           influxdb.DashboardMeta       `json:"meta"`
+	Cells          []dashboardCellResponse      `json:"cells"`
+	Annotations    influxdb.ResourceAnnotations `json:"annotations,omitempty"`
+	TimeRange      *influxdb.TimeRangeOverride  `json:"timeRange,omitempty"`
+	Labels         []influxdb.Label             `json:"labels"`
+	Links          dashboardLinks               `json:"links"`
 }
 
 func (d dashboardResponse) toinfluxdb() *influxdb.Dashboard {
@@ -139,6 +142,8 @@ func (d dashboardResponse) toinfluxdb() *influxdb.Dashboard {
 		Description:    d.Description,
 		Meta:           d.Meta,
 		Cells:          cells,
+		Annotations:    d.Annotations,
+		TimeRange:      d.TimeRange,
 	}
 }
 
@@ -157,6 +162,8 @@ func newDashboardResponse(d *influxdb.Dashboard, labels []*influxdb.Label) dashb
 		Name:           d.Name,
 		Description:    d.Description,
 		Meta:           d.Meta,
+		Annotations:    d.Annotations,
+		TimeRange:      d.TimeRange,
 		Labels:         []influxdb.Label{},
 		Cells:          []dashboardCellResponse{},
 	}
@@ -298,6 +305,11 @@ func (h *DashboardHandler) handleGetDashboards(w http.ResponseWriter, r *http.Re
 		dashboardFilter.OrganizationID = &o.ID
 	}
 
+	if kithttp.AcceptsNDJSON(r) {
+		h.streamDashboards(w, r, dashboardFilter, req)
+		return
+	}
+
 	dashboards, _, err := h.dashboardService.FindDashboards(ctx, dashboardFilter, req.opts)
 	if err != nil {
 		h.api.Err(w, r, err)
@@ -309,6 +321,53 @@ func (h *DashboardHandler) handleGetDashboards(w http.ResponseWriter, r *http.Re
 	h.api.Respond(w, r, http.StatusOK, newGetDashboardsResponse(ctx, dashboards, req.filter, req.opts, h.labelService))
 }
 
+// streamDashboards streams all dashboards of the request after its cursor
+// as NDJSON. The dashboards are read from the store a page at a time, the
+// paging links of the list are in the Link header.
+func (h *DashboardHandler) streamDashboards(w http.ResponseWriter, r *http.Request, filter influxdb.DashboardFilter, req *getDashboardsRequest) {
+	ctx := r.Context()
+	opts, err := influxdb.CursorFindOptions(req.opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	links := influxdb.NewCursorPagingLinks(prefixDashboards, opts, req.filter, 0, 0)
+	opts.Limit = influxdb.MaxPageSize
+
+	done := false
+	next := func() ([]interface{}, error) {
+		if done {
+			return nil, nil
+		}
+		dashboards, _, err := h.dashboardService.FindDashboards(ctx, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		// a filter by a single ID finds the same dashboard on every page
+		if len(dashboards) == 0 || (opts.After != nil && dashboards[len(dashboards)-1].ID <= *opts.After) {
+			return nil, nil
+		}
+		done = len(dashboards) < opts.Limit
+		last := dashboards[len(dashboards)-1].ID
+		opts.After = &last
+
+		page := make([]interface{}, 0, len(dashboards))
+		for _, dashboard := range dashboards {
+			labels, _ := h.labelService.FindResourceLabels(ctx, influxdb.LabelMappingFilter{ResourceID: dashboard.ID, ResourceType: influxdb.DashboardsResourceType})
+			page = append(page, newDashboardResponse(dashboard, labels))
+		}
+		return page, nil
+	}
+
+	page, err := next()
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	kithttp.AddLinkHeader(w, "self", links.Self)
+	h.api.RespondNDJSON(w, r, http.StatusOK, page, next)
+}
+
 type getDashboardsRequest struct {
 	filter influxdb.DashboardFilter
 	opts   influxdb.FindOptions
@@ -348,6 +407,8 @@ func decodeGetDashboardsRequest(ctx context.Context, r *http.Request) (*getDashb
 		req.filter.Organization = &org
 	}
 
+	req.filter.Annotations = influxdb.DecodeResourceAnnotationsFilter(qp)
+
 	return req, nil
 }
 
@@ -369,6 +430,13 @@ func newGetDashboardsResponse(ctx context.Context, dashboards []*influxdb.Dashbo
 		Links:      influxdb.NewPagingLinks(prefixDashboards, opts, filter, len(dashboards)),
 		Dashboards: make([]dashboardResponse, 0, len(dashboards)),
 	}
+	if opts.Cursor {
+		var last platform.ID
+		if len(dashboards) > 0 {
+			last = dashboards[len(dashboards)-1].ID
+		}
+		res.Links = influxdb.NewCursorPagingLinks(prefixDashboards, opts, filter, len(dashboards), last)
+	}
 
 	for _, dashboard := range dashboards {
 		if dashboard != nil {
@@ -437,6 +505,7 @@ func (h *DashboardHandler) handleGetDashboard(w http.ResponseWriter, r *http.Req
 
 	h.log.Debug("Get Dashboard", zap.String("dashboard", fmt.Sprint(dashboard)))
 
+	kithttp.SetVersionETag(w, dashboard.Meta.UpdatedAt)
 	h.api.Respond(w, r, http.StatusOK, newDashboardResponse(dashboard, labels))
 }
 
@@ -527,6 +596,7 @@ func (h *DashboardHandler) handlePatchDashboard(w http.ResponseWriter, r *http.R
 
 	h.log.Debug("Dashboard updated", zap.String("dashboard", fmt.Sprint(dashboard)))
 
+	kithttp.SetVersionETag(w, dashboard.Meta.UpdatedAt)
 	h.api.Respond(w, r, http.StatusOK, newDashboardResponse(dashboard, labels))
 }
 
@@ -544,6 +614,12 @@ func decodePatchDashboardRequest(ctx context.Context, r *http.Request) (*patchDa
 			Err:  err,
 		}
 	}
+
+	ifUpdatedAt, err := kithttp.IfMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	upd.IfUpdatedAt = ifUpdatedAt
 	req.Upd = upd
 
 	id := chi.URLParam(r, "id")
@@ -587,6 +663,7 @@ func (r *patchDashboardRequest) Valid() error {
 
 type postDashboardCellRequest struct {
 	dashboardID platform.ID
+	ifUpdatedAt *time.Time
 	*influxdb.CellProperty
 	UsingView *platform.ID `json:"usingView"`
 	Name      *string      `json:"name"`
@@ -614,6 +691,12 @@ func decodePostDashboardCellRequest(ctx context.Context, r *http.Request) (*post
 		return nil, err
 	}
 
+	ifUpdatedAt, err := kithttp.IfMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	req.ifUpdatedAt = ifUpdatedAt
+
 	return req, nil
 }
 
@@ -627,7 +710,7 @@ func (h *DashboardHandler) handlePostDashboardCell(w http.ResponseWriter, r *htt
 	}
 	cell := new(influxdb.Cell)
 
-	opts := new(influxdb.AddDashboardCellOptions)
+	opts := &influxdb.AddDashboardCellOptions{IfUpdatedAt: req.ifUpdatedAt}
 	if req.UsingView != nil || req.Name != nil {
 		opts.View = new(influxdb.View)
 		if req.UsingView != nil {
@@ -666,6 +749,7 @@ func (h *DashboardHandler) handlePostDashboardCell(w http.ResponseWriter, r *htt
 type putDashboardCellRequest struct {
 	dashboardID platform.ID
 	cells       []*influxdb.Cell
+	opts        influxdb.DashboardCellsOptions
 }
 
 func decodePutDashboardCellRequest(ctx context.Context, r *http.Request) (*putDashboardCellRequest, error) {
@@ -687,6 +771,12 @@ func decodePutDashboardCellRequest(ctx context.Context, r *http.Request) (*putDa
 		return nil, err
 	}
 
+	ifUpdatedAt, err := kithttp.IfMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	req.opts.IfUpdatedAt = ifUpdatedAt
+
 	return req, nil
 }
 
@@ -699,7 +789,7 @@ func (h *DashboardHandler) handlePutDashboardCells(w http.ResponseWriter, r *htt
 		return
 	}
 
-	if err := h.dashboardService.ReplaceDashboardCells(ctx, req.dashboardID, req.cells); err != nil {
+	if err := h.dashboardService.ReplaceDashboardCells(ctx, req.dashboardID, req.cells, req.opts); err != nil {
 		h.api.Err(w, r, err)
 		return
 	}
@@ -712,6 +802,7 @@ func (h *DashboardHandler) handlePutDashboardCells(w http.ResponseWriter, r *htt
 type deleteDashboardCellRequest struct {
 	dashboardID platform.ID
 	cellID      platform.ID
+	opts        influxdb.DashboardCellsOptions
 }
 
 func decodeDeleteDashboardCellRequest(ctx context.Context, r *http.Request) (*deleteDashboardCellRequest, error) {
@@ -739,6 +830,12 @@ func decodeDeleteDashboardCellRequest(ctx context.Context, r *http.Request) (*de
 		return nil, err
 	}
 
+	ifUpdatedAt, err := kithttp.IfMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	req.opts.IfUpdatedAt = ifUpdatedAt
+
 	return req, nil
 }
 
@@ -846,7 +943,7 @@ func (h *DashboardHandler) handleDeleteDashboardCell(w http.ResponseWriter, r *h
 		h.api.Err(w, r, err)
 		return
 	}
-	if err := h.dashboardService.RemoveDashboardCell(ctx, req.dashboardID, req.cellID); err != nil {
+	if err := h.dashboardService.RemoveDashboardCell(ctx, req.dashboardID, req.cellID, req.opts); err != nil {
 		h.api.Err(w, r, err)
 		return
 	}
@@ -897,6 +994,12 @@ func decodePatchDashboardCellRequest(ctx context.Context, r *http.Request) (*pat
 		return nil, pe
 	}
 
+	ifUpdatedAt, err := kithttp.IfMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	req.upd.IfUpdatedAt = ifUpdatedAt
+
 	return req, nil
 }
 
@@ -959,6 +1062,9 @@ func (s *DashboardService) FindDashboards(ctx context.Context, filter influxdb.D
 	if filter.Organization != nil {
 		queryPairs = append(queryPairs, [2]string{"org", *filter.Organization})
 	}
+	for k, v := range filter.Annotations {
+		queryPairs = append(queryPairs, [2]string{"annotations[" + k + "]", v})
+	}
 
 	var dr getDashboardsResponse
 	err := s.Client.
@@ -986,8 +1092,7 @@ func (s *DashboardService) CreateDashboard(ctx context.Context, d *influxdb.Dash
 // Returns the new dashboard state after update.
 func (s *DashboardService) UpdateDashboard(ctx context.Context, id platform.ID, upd influxdb.DashboardUpdate) (*influxdb.Dashboard, error) {
 	var d influxdb.Dashboard
-	err := s.Client.
-		PatchJSON(upd, prefixDashboards, id.String()).
+	err := ifMatch(s.Client.PatchJSON(upd, prefixDashboards, id.String()), upd.IfUpdatedAt).
 		DecodeJSON(&d).
 		Do(ctx)
 	if err != nil {
@@ -1011,16 +1116,14 @@ func (s *DashboardService) DeleteDashboard(ctx context.Context, id platform.ID)
 
 // AddDashboardCell adds a cell to a dashboard.
 func (s *DashboardService) AddDashboardCell(ctx context.Context, id platform.ID, c *influxdb.Cell, opts influxdb.AddDashboardCellOptions) error {
-	return s.Client.
-		PostJSON(c, cellPath(id)).
+	return ifMatch(s.Client.PostJSON(c, cellPath(id)), opts.IfUpdatedAt).
 		DecodeJSON(c).
 		Do(ctx)
 }
 
 // RemoveDashboardCell removes a dashboard.
-func (s *DashboardService) RemoveDashboardCell(ctx context.Context, dashboardID, cellID platform.ID) error {
-	return s.Client.
-		Delete(dashboardCellIDPath(dashboardID, cellID)).
+func (s *DashboardService) RemoveDashboardCell(ctx context.Context, dashboardID, cellID platform.ID, opts influxdb.DashboardCellsOptions) error {
+	return ifMatch(s.Client.Delete(dashboardCellIDPath(dashboardID, cellID)), opts.IfUpdatedAt).
 		Do(ctx)
 }
 
@@ -1033,8 +1136,7 @@ func (s *DashboardService) UpdateDashboardCell(ctx context.Context, dashboardID,
 	}
 
 	var c influxdb.Cell
-	err := s.Client.
-		PatchJSON(upd, dashboardCellIDPath(dashboardID, cellID)).
+	err := ifMatch(s.Client.PatchJSON(upd, dashboardCellIDPath(dashboardID, cellID)), upd.IfUpdatedAt).
 		DecodeJSON(&c).
 		Do(ctx)
 	if err != nil {
@@ -1072,15 +1174,23 @@ func (s *DashboardService) UpdateDashboardCellView(ctx context.Context, dashboar
 }
 
 // ReplaceDashboardCells replaces all cells in a dashboard
-func (s *DashboardService) ReplaceDashboardCells(ctx context.Context, id platform.ID, cs []*influxdb.Cell) error {
-	return s.Client.
-		PutJSON(cs, cellPath(id)).
+func (s *DashboardService) ReplaceDashboardCells(ctx context.Context, id platform.ID, cs []*influxdb.Cell, opts influxdb.DashboardCellsOptions) error {
+	return ifMatch(s.Client.PutJSON(cs, cellPath(id)), opts.IfUpdatedAt).
 		// TODO: previous implementation did not do anything with the response except validate it is valid json.
 		//  seems likely we should have to overwrite (:sadpanda:) the incoming cs...
 		DecodeJSON(&dashboardCellsResponse{}).
 		Do(ctx)
 }
 
+// ifMatch makes the request conditional on the version of the dashboard
+// when ifUpdatedAt is set.
+func ifMatch(req *httpc.Req, ifUpdatedAt *time.Time) *httpc.Req {
+	if ifUpdatedAt == nil {
+		return req
+	}
+	return req.Header("If-Match", kithttp.VersionETag(*ifUpdatedAt))
+}
+
 func dashboardIDPath(id platform.ID) string {
 	return path.Join(prefixDashboards, id.String())
 }
@@ -1579,7 +1579,7 @@ func TestService_handleDeleteDashboardCell(t *testing.T) {
 			name: "remove a dashboard cell",
 			fields: fields{
 				&mock.DashboardService{
-					RemoveDashboardCellF: func(ctx context.Context, id platform.ID, cellID platform.ID) error {
+					RemoveDashboardCellF: func(ctx context.Context, id platform.ID, cellID platform.ID, opts influxdb.DashboardCellsOptions) error {
 						return nil
 					},
 				},
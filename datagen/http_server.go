@@ -0,0 +1,152 @@
+package datagen
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixDatagen = "/api/v2/datagen"
+
+// Handler serves the data generator API to operators. Generators can load
+// a server heavily, so only operators may run them.
+type Handler struct {
+	chi.Router
+
+	log *zap.Logger
+	api *kithttp.API
+	svc *Service
+}
+
+// NewHTTPHandler constructs a handler starting, reporting and tearing down
+// data generators.
+func NewHTTPHandler(log *zap.Logger, svc *Service) *Handler {
+	h := &Handler{
+		log: log,
+		api: kithttp.NewAPI(kithttp.WithLog(log)),
+		svc: svc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+		h.mwAuthorize,
+	)
+
+	r.Post("/", h.handlePostGenerator)
+	r.Get("/", h.handleGetGenerators)
+	r.Route("/{id}", func(r chi.Router) {
+		r.Get("/", h.handleGetGenerator)
+		r.Delete("/", h.handleDeleteGenerator)
+		r.Post("/stop", h.handleStopGenerator)
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix is the route the handler is mounted at.
+func (h *Handler) Prefix() string {
+	return prefixDatagen
+}
+
+// handlePostGenerator is the HTTP handler for the POST /api/v2/datagen route.
+func (h *Handler) handlePostGenerator(w http.ResponseWriter, r *http.Request) {
+	var spec Spec
+	if err := h.api.DecodeJSON(r.Body, &spec); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	g, err := h.svc.Start(r.Context(), spec)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Info("Started data generator", zap.Stringer("id", g.ID), zap.Stringer("bucket_id", g.Spec.BucketID))
+	h.api.Respond(w, r, http.StatusCreated, g)
+}
+
+type generatorsResponse struct {
+	Generators []Generator `json:"generators"`
+}
+
+// handleGetGenerators is the HTTP handler for the GET /api/v2/datagen route.
+func (h *Handler) handleGetGenerators(w http.ResponseWriter, r *http.Request) {
+	h.api.Respond(w, r, http.StatusOK, generatorsResponse{
+		Generators: h.svc.List(),
+	})
+}
+
+// handleGetGenerator is the HTTP handler for the GET /api/v2/datagen/:id route.
+func (h *Handler) handleGetGenerator(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	g, err := h.svc.Find(*id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, g)
+}
+
+// handleStopGenerator is the HTTP handler for the POST /api/v2/datagen/:id/stop route.
+func (h *Handler) handleStopGenerator(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	g, err := h.svc.Stop(*id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Info("Stopped data generator", zap.Stringer("id", id))
+	h.api.Respond(w, r, http.StatusOK, g)
+}
+
+// handleDeleteGenerator is the HTTP handler for the DELETE /api/v2/datagen/:id route.
+// It stops the generator and deletes the data it wrote.
+func (h *Handler) handleDeleteGenerator(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), *id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Info("Tore down data generator", zap.Stringer("id", id))
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
+
+func (h *Handler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("access to %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,457 @@
+// Package datagen runs data generators, which write synthetic data of a
+// configurable schema into a bucket at a steady rate, so that hardware can
+// be benchmarked and dashboards tried out without an external load tool.
+// The data of a generator is tagged with its ID, which lets it be deleted
+// again when the generator is torn down.
+package datagen
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/predicate"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"go.uber.org/zap"
+)
+
+const (
+	// TagKey is the key of the tag holding the ID of the generator that
+	// wrote a point.
+	TagKey = "generator"
+
+	// MaxRate is the maximum rate, in points per second, of a generator.
+	MaxRate = 1000000
+	// MaxSeriesPerMeasurement is the maximum number of series a generator
+	// writes to each measurement.
+	MaxSeriesPerMeasurement = 10000000
+
+	maxMeasurements = 1000
+	maxFields       = 100
+	maxBatchSize    = 5000
+
+	defaultMeasurementPrefix = "m"
+	defaultInterval          = 100 * time.Millisecond
+)
+
+// PointsWriter writes the points of the generators.
+type PointsWriter interface {
+	WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error
+}
+
+// BucketFinder finds the buckets generators write to.
+type BucketFinder interface {
+	FindBucketByID(ctx context.Context, id platform.ID) (*influxdb.Bucket, error)
+}
+
+// Tag is a tag key the points of a generator have, and the number of
+// values it takes, named after the key, such as host0, host1 and so on.
+type Tag struct {
+	Key         string `json:"key"`
+	Cardinality int    `json:"cardinality"`
+}
+
+// Spec describes the data a generator writes. Each measurement has a
+// series for every combination of the values of the tags, so it has as
+// many series as the product of their cardinalities. Points are written
+// to the series in turn, each with every field set to a random value.
+type Spec struct {
+	BucketID platform.ID `json:"bucketID"`
+	// MeasurementPrefix names the measurements, followed by their index,
+	// such as m0, m1 and so on.
+	MeasurementPrefix string `json:"measurementPrefix,omitempty"`
+	Measurements      int    `json:"measurements"`
+	Tags              []Tag  `json:"tags"`
+	Fields            int    `json:"fields"`
+	// Rate is the number of points written per second.
+	Rate     int               `json:"rate"`
+	Duration influxdb.Duration `json:"duration"`
+}
+
+// validate sets the defaults of the spec and checks its limits.
+func (s *Spec) validate() error {
+	if !s.BucketID.Valid() {
+		return invalidf("bucketID is required")
+	}
+	if s.MeasurementPrefix == "" {
+		s.MeasurementPrefix = defaultMeasurementPrefix
+	}
+	if s.Measurements == 0 {
+		s.Measurements = 1
+	}
+	if s.Fields == 0 {
+		s.Fields = 1
+	}
+	if s.Measurements < 0 || s.Measurements > maxMeasurements {
+		return invalidf("measurements must be between 1 and %d", maxMeasurements)
+	}
+	if s.Fields < 0 || s.Fields > maxFields {
+		return invalidf("fields must be between 1 and %d", maxFields)
+	}
+	if s.Rate <= 0 || s.Rate > MaxRate {
+		return invalidf("rate must be between 1 and %d points per second", MaxRate)
+	}
+	if s.Duration.Duration < time.Second {
+		return invalidf("duration must be at least 1s")
+	}
+
+	keys := map[string]bool{TagKey: true}
+	series := 1
+	for _, t := range s.Tags {
+		if t.Key == "" || keys[t.Key] {
+			return invalidf("tag keys must be set, unique and not %q", TagKey)
+		}
+		keys[t.Key] = true
+		if t.Cardinality <= 0 {
+			return invalidf("cardinality of tag %q must be positive", t.Key)
+		}
+		if series > MaxSeriesPerMeasurement/t.Cardinality {
+			return invalidf("tags must not have more than %d series per measurement", MaxSeriesPerMeasurement)
+		}
+		series *= t.Cardinality
+	}
+	return nil
+}
+
+func invalidf(format string, args ...interface{}) error {
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  fmt.Sprintf(format, args...),
+	}
+}
+
+// Status is the status of a generator.
+type Status string
+
+const (
+	// StatusRunning is the status of a generator writing data.
+	StatusRunning Status = "running"
+	// StatusDone is the status of a generator that wrote all its data.
+	StatusDone Status = "done"
+	// StatusStopped is the status of a generator stopped before it wrote
+	// all its data.
+	StatusStopped Status = "stopped"
+	// StatusFailed is the status of a generator whose data could not be
+	// written.
+	StatusFailed Status = "failed"
+)
+
+// Generator is the state of a generator.
+type Generator struct {
+	ID    platform.ID `json:"id"`
+	OrgID platform.ID `json:"orgID"`
+	Spec  Spec        `json:"spec"`
+
+	Status        Status     `json:"status"`
+	PointsWritten int64      `json:"pointsWritten"`
+	PointsTotal   int64      `json:"pointsTotal"`
+	StartedAt     time.Time  `json:"startedAt"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
+
+type generator struct {
+	Generator
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Service runs data generators and keeps their state until they are
+// deleted or the server restarts.
+type Service struct {
+	log      *zap.Logger
+	writer   PointsWriter
+	buckets  BucketFinder
+	deleter  influxdb.DeleteService
+	idGen    platform.IDGenerator
+	now      func() time.Time
+	interval time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu         sync.Mutex
+	generators map[platform.ID]*generator
+}
+
+// NewService constructs a service writing the data of generators with
+// writer and deleting it with deleter.
+func NewService(log *zap.Logger, writer PointsWriter, buckets BucketFinder, deleter influxdb.DeleteService) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		log:        log,
+		writer:     writer,
+		buckets:    buckets,
+		deleter:    deleter,
+		idGen:      snowflake.NewIDGenerator(),
+		now:        time.Now,
+		interval:   defaultInterval,
+		ctx:        ctx,
+		cancel:     cancel,
+		generators: make(map[platform.ID]*generator),
+	}
+}
+
+// Start starts a generator writing the data of spec. It runs in the
+// background, its progress is returned by Find.
+func (s *Service) Start(ctx context.Context, spec Spec) (*Generator, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	b, err := s.buckets.FindBucketByID(ctx, spec.BucketID)
+	if err != nil {
+		return nil, err
+	}
+	if b.Type == influxdb.BucketTypeSystem {
+		return nil, invalidf("cannot generate data in system bucket %q", b.Name)
+	}
+
+	g := &generator{
+		Generator: Generator{
+			ID:          s.idGen.ID(),
+			OrgID:       b.OrgID,
+			Spec:        spec,
+			Status:      StatusRunning,
+			PointsTotal: int64(float64(spec.Rate) * spec.Duration.Seconds()),
+			StartedAt:   s.now().UTC(),
+		},
+		done: make(chan struct{}),
+	}
+	var gctx context.Context
+	gctx, g.cancel = context.WithCancel(s.ctx)
+
+	s.mu.Lock()
+	s.generators[g.ID] = g
+	gen := g.Generator
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(g.done)
+		err := s.run(gctx, g)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		finishedAt := s.now().UTC()
+		g.FinishedAt = &finishedAt
+		switch {
+		case err == nil:
+			g.Status = StatusDone
+		case gctx.Err() != nil:
+			g.Status = StatusStopped
+		default:
+			s.log.Error("Data generator failed", zap.Stringer("id", g.ID), zap.Error(err))
+			g.Status = StatusFailed
+			g.Error = err.Error()
+		}
+	}()
+	return &gen, nil
+}
+
+// run writes the points of a generator, catching up with its rate every
+// interval.
+func (s *Service) run(ctx context.Context, g *generator) error {
+	points := newPointGenerator(g.ID, g.Spec, g.StartedAt)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	var written int64
+	for written < g.PointsTotal {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		due := int64(s.now().Sub(g.StartedAt).Seconds() * float64(g.Spec.Rate))
+		if due > g.PointsTotal {
+			due = g.PointsTotal
+		}
+		for written < due {
+			n := due - written
+			if n > maxBatchSize {
+				n = maxBatchSize
+			}
+			batch, err := points.next(written, int(n))
+			if err != nil {
+				return err
+			}
+			if err := s.writer.WritePoints(ctx, g.OrgID, g.Spec.BucketID, batch); err != nil {
+				return err
+			}
+			written += n
+
+			s.mu.Lock()
+			g.PointsWritten = written
+			s.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// Find returns the state of a generator.
+func (s *Service) Find(id platform.ID) (*Generator, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	gen := g.Generator
+	return &gen, nil
+}
+
+func (s *Service) find(id platform.ID) (*generator, error) {
+	g, ok := s.generators[id]
+	if !ok {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("data generator %s not found", id),
+		}
+	}
+	return g, nil
+}
+
+// List returns the state of every generator, ordered by the time they
+// started.
+func (s *Service) List() []Generator {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gens := make([]Generator, 0, len(s.generators))
+	for _, g := range s.generators {
+		gens = append(gens, g.Generator)
+	}
+	sort.Slice(gens, func(i, j int) bool {
+		if !gens[i].StartedAt.Equal(gens[j].StartedAt) {
+			return gens[i].StartedAt.Before(gens[j].StartedAt)
+		}
+		return gens[i].ID < gens[j].ID
+	})
+	return gens
+}
+
+// Stop stops a generator and waits for it to stop writing. The data it
+// wrote is kept until it is deleted.
+func (s *Service) Stop(id platform.ID) (*Generator, error) {
+	s.mu.Lock()
+	g, err := s.find(id)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	g.cancel()
+	<-g.done
+	return s.Find(id)
+}
+
+// Delete tears a generator down: it stops it and deletes the data it
+// wrote, which has the tag generator=<id>.
+func (s *Service) Delete(ctx context.Context, id platform.ID) error {
+	g, err := s.Stop(id)
+	if err != nil {
+		return err
+	}
+
+	pred, err := teardownPredicate(id)
+	if err != nil {
+		return err
+	}
+	min := g.StartedAt.UnixNano()
+	max := g.StartedAt.Add(g.Spec.Duration.Duration).UnixNano()
+	if err := s.deleter.DeleteBucketRangePredicate(ctx, g.OrgID, g.Spec.BucketID, min, max, pred); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.generators, id)
+	s.mu.Unlock()
+	return nil
+}
+
+func teardownPredicate(id platform.ID) (influxdb.Predicate, error) {
+	node, err := predicate.Parse(fmt.Sprintf("%s=%q", TagKey, id.String()))
+	if err != nil {
+		return nil, err
+	}
+	return predicate.New(node)
+}
+
+// Close stops the generators and waits for them to stop writing.
+func (s *Service) Close() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// pointGenerator generates the points of a generator. Point i is written
+// to series i modulo the number of series, at i/rate seconds after the
+// generator started, so that the points of a series never overwrite each
+// other.
+type pointGenerator struct {
+	spec      Spec
+	start     time.Time
+	id        string
+	perSeries int
+	rnd       *rand.Rand
+	fields    []string
+}
+
+func newPointGenerator(id platform.ID, spec Spec, start time.Time) *pointGenerator {
+	g := &pointGenerator{
+		spec:      spec,
+		start:     start,
+		id:        id.String(),
+		perSeries: 1,
+		rnd:       rand.New(rand.NewSource(int64(id))),
+	}
+	for _, t := range spec.Tags {
+		g.perSeries *= t.Cardinality
+	}
+	for i := 0; i < spec.Fields; i++ {
+		g.fields = append(g.fields, "f"+strconv.Itoa(i))
+	}
+	return g
+}
+
+// next returns the n points following the first i points.
+func (g *pointGenerator) next(i int64, n int) ([]models.Point, error) {
+	series := int64(g.spec.Measurements) * int64(g.perSeries)
+	points := make([]models.Point, 0, n)
+	for ; n > 0; i, n = i+1, n-1 {
+		s := i % series
+		name := g.spec.MeasurementPrefix + strconv.FormatInt(s/int64(g.perSeries), 10)
+
+		tags := map[string]string{TagKey: g.id}
+		v := s % int64(g.perSeries)
+		for _, t := range g.spec.Tags {
+			tags[t.Key] = t.Key + strconv.FormatInt(v%int64(t.Cardinality), 10)
+			v /= int64(t.Cardinality)
+		}
+
+		fields := make(models.Fields, len(g.fields))
+		for _, f := range g.fields {
+			fields[f] = g.rnd.Float64() * 100
+		}
+
+		ts := g.start.Add(time.Duration(i) * time.Second / time.Duration(g.spec.Rate))
+		p, err := models.NewPoint(name, models.NewTags(tags), fields, ts)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
@@ -0,0 +1,158 @@
+package datagen
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type bucketFinder map[platform.ID]*influxdb.Bucket
+
+func (f bucketFinder) FindBucketByID(_ context.Context, id platform.ID) (*influxdb.Bucket, error) {
+	b, ok := f[id]
+	if !ok {
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+	}
+	return b, nil
+}
+
+type store struct {
+	mu     sync.Mutex
+	points []models.Point
+}
+
+func (s *store) WritePoints(_ context.Context, _, _ platform.ID, points []models.Point) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.points = append(s.points, points...)
+	return nil
+}
+
+func (s *store) DeleteBucketRangePredicate(_ context.Context, _, _ platform.ID, _, _ int64, pred influxdb.Predicate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var kept []models.Point
+	for _, p := range s.points {
+		if !pred.Matches(p.Key()) {
+			kept = append(kept, p)
+		}
+	}
+	s.points = kept
+	return nil
+}
+
+func TestService(t *testing.T) {
+	orgID, bucketID := platform.ID(1), platform.ID(2)
+	st := &store{}
+	svc := NewService(zaptest.NewLogger(t), st, bucketFinder{
+		bucketID: {ID: bucketID, OrgID: orgID, Name: "b"},
+	}, st)
+	svc.interval = time.Millisecond
+	defer svc.Close()
+
+	g, err := svc.Start(context.Background(), Spec{
+		BucketID:     bucketID,
+		Measurements: 2,
+		Tags:         []Tag{{Key: "host", Cardinality: 3}, {Key: "region", Cardinality: 2}},
+		Fields:       2,
+		Rate:         30,
+		Duration:     influxdb.Duration{Duration: time.Second},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, orgID, g.OrgID)
+	assert.Equal(t, int64(30), g.PointsTotal)
+
+	require.Eventually(t, func() bool {
+		g, err := svc.Find(g.ID)
+		require.NoError(t, err)
+		return g.Status == StatusDone
+	}, 5*time.Second, 10*time.Millisecond)
+
+	g, err = svc.Find(g.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(30), g.PointsWritten)
+
+	// 2 measurements of 6 series each, each written to before any repeats
+	series := make(map[string]bool)
+	for _, p := range st.points[:12] {
+		series[string(p.Key())] = true
+		assert.Equal(t, g.ID.String(), p.Tags().GetString(TagKey))
+		fields, err := p.Fields()
+		require.NoError(t, err)
+		assert.Len(t, fields, 2)
+	}
+	assert.Len(t, series, 12)
+	assert.Equal(t, "m0,generator="+g.ID.String()+",host=host0,region=region0", string(st.points[0].Key()))
+	assert.Equal(t, "m1,generator="+g.ID.String()+",host=host2,region=region1", string(st.points[11].Key()))
+
+	// teardown deletes the data of the generator only
+	other := models.MustNewPoint("cpu", models.NewTags(map[string]string{"host": "a"}), models.Fields{"v": 1.0}, time.Now())
+	require.NoError(t, st.WritePoints(context.Background(), orgID, bucketID, []models.Point{other}))
+	require.NoError(t, svc.Delete(context.Background(), g.ID))
+	assert.Equal(t, []models.Point{other}, st.points)
+	_, err = svc.Find(g.ID)
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	assert.Empty(t, svc.List())
+}
+
+func TestService_Stop(t *testing.T) {
+	bucketID := platform.ID(2)
+	st := &store{}
+	svc := NewService(zaptest.NewLogger(t), st, bucketFinder{bucketID: {ID: bucketID, OrgID: 1}}, st)
+	defer svc.Close()
+
+	g, err := svc.Start(context.Background(), Spec{
+		BucketID: bucketID,
+		Rate:     10,
+		Duration: influxdb.Duration{Duration: time.Hour},
+	})
+	require.NoError(t, err)
+
+	g, err = svc.Stop(g.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusStopped, g.Status)
+	assert.NotNil(t, g.FinishedAt)
+	assert.Len(t, svc.List(), 1)
+}
+
+func TestService_StartInvalid(t *testing.T) {
+	bucketID := platform.ID(2)
+	svc := NewService(zaptest.NewLogger(t), &store{}, bucketFinder{
+		bucketID:       {ID: bucketID, OrgID: 1},
+		platform.ID(3): {ID: 3, OrgID: 1, Type: influxdb.BucketTypeSystem},
+	}, &store{})
+	defer svc.Close()
+
+	valid := Spec{BucketID: bucketID, Rate: 10, Duration: influxdb.Duration{Duration: time.Minute}}
+	for name, modify := range map[string]func(*Spec){
+		"no bucket":       func(s *Spec) { s.BucketID = 0 },
+		"system bucket":   func(s *Spec) { s.BucketID = 3 },
+		"no rate":         func(s *Spec) { s.Rate = 0 },
+		"rate too high":   func(s *Spec) { s.Rate = MaxRate + 1 },
+		"short duration":  func(s *Spec) { s.Duration.Duration = time.Millisecond },
+		"reserved tag":    func(s *Spec) { s.Tags = []Tag{{Key: TagKey, Cardinality: 1}} },
+		"duplicate tag":   func(s *Spec) { s.Tags = []Tag{{Key: "a", Cardinality: 1}, {Key: "a", Cardinality: 1}} },
+		"no tag values":   func(s *Spec) { s.Tags = []Tag{{Key: "a"}} },
+		"too many series": func(s *Spec) { s.Tags = []Tag{{Key: "a", Cardinality: 10000}, {Key: "b", Cardinality: 10000}} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			spec := valid
+			modify(&spec)
+			_, err := svc.Start(context.Background(), spec)
+			assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+		})
+	}
+
+	_, err := svc.Start(context.Background(), Spec{BucketID: 4, Rate: 10, Duration: influxdb.Duration{Duration: time.Minute}})
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	assert.Empty(t, svc.List())
+}
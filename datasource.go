@@ -0,0 +1,85 @@
+package influxdb
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// datasourceDrivers are the sql.from drivers an external datasource may be
+// queried with. The drivers reading local files of the server, such as
+// sqlite3, are left out.
+var datasourceDrivers = map[string]bool{
+	"postgres":  true,
+	"mysql":     true,
+	"snowflake": true,
+	"sqlserver": true,
+	"mssql":     true,
+	"awsathena": true,
+	"bigquery":  true,
+	"vertica":   true,
+	"vertigo":   true,
+	"hdb":       true,
+}
+
+// ValidDatasourceDriver returns an error if the driver is not one external
+// datasources may be queried with.
+func ValidDatasourceDriver(driver string) error {
+	if !datasourceDrivers[driver] {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("datasource driver %q is not supported", driver),
+		}
+	}
+	return nil
+}
+
+// DatasourceSecretKey is the key of the organization secret holding the data
+// source name of the datasource, which queries of the datasource read with
+// secrets.get.
+func DatasourceSecretKey(id platform.ID) string {
+	return id.String() + "-dsn"
+}
+
+// Datasource contains all info about an external datasource that should be
+// returned to users. Note that the data source name, which holds the
+// credentials of the datasource, is *not* included here.
+type Datasource struct {
+	ID          platform.ID `json:"id" db:"id"`
+	OrgID       platform.ID `json:"orgID" db:"org_id"`
+	Name        string      `json:"name" db:"name"`
+	Description *string     `json:"description,omitempty" db:"description"`
+	Driver      string      `json:"driver" db:"driver"`
+}
+
+// DatasourceListFilter is a selection filter for listing external datasources.
+type DatasourceListFilter struct {
+	OrgID  platform.ID
+	Name   *string
+	Driver *string
+}
+
+// Datasources is a collection of metadata about external datasources.
+type Datasources struct {
+	Datasources []Datasource `json:"datasources"`
+}
+
+// CreateDatasourceRequest contains all info needed to register an external
+// datasource.
+type CreateDatasourceRequest struct {
+	OrgID          platform.ID `json:"orgID"`
+	Name           string      `json:"name"`
+	Description    *string     `json:"description,omitempty"`
+	Driver         string      `json:"driver"`
+	DataSourceName string      `json:"dataSourceName"`
+}
+
+// UpdateDatasourceRequest contains a partial update to existing info about an
+// external datasource. The driver cannot be changed, as the queries of the
+// datasource are written for it.
+type UpdateDatasourceRequest struct {
+	Name           *string `json:"name,omitempty"`
+	Description    *string `json:"description,omitempty"`
+	DataSourceName *string `json:"dataSourceName,omitempty"`
+}
@@ -0,0 +1,202 @@
+package datasources
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/influxdata/influxdb/v2/sqlite"
+)
+
+var (
+	errDatasourceNotFound = &ierrors.Error{
+		Code: ierrors.ENotFound,
+		Msg:  "datasource not found",
+	}
+
+	errEmptyDataSourceName = &ierrors.Error{
+		Code: ierrors.EInvalid,
+		Msg:  "datasource dataSourceName can't be empty",
+	}
+)
+
+// NewService constructs the service of external datasources. The metadata of
+// the datasources is kept in the sqlite store, their data source names are
+// kept as secrets of their organization, so queries of the datasources read
+// them with secrets.get.
+func NewService(store *sqlite.SqlStore, secrets influxdb.SecretService) *service {
+	return &service{
+		store:       store,
+		secrets:     secrets,
+		idGenerator: snowflake.NewIDGenerator(),
+	}
+}
+
+type service struct {
+	store       *sqlite.SqlStore
+	secrets     influxdb.SecretService
+	idGenerator platform.IDGenerator
+}
+
+func (s service) ListDatasources(ctx context.Context, filter influxdb.DatasourceListFilter) (*influxdb.Datasources, error) {
+	q := sq.Select("id", "org_id", "name", "description", "driver").
+		From("datasources").
+		Where(sq.Eq{"org_id": filter.OrgID})
+
+	if filter.Name != nil {
+		q = q.Where(sq.Eq{"name": *filter.Name})
+	}
+	if filter.Driver != nil {
+		q = q.Where(sq.Eq{"driver": *filter.Driver})
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var ds influxdb.Datasources
+	if err := s.store.DB.SelectContext(ctx, &ds.Datasources, query, args...); err != nil {
+		return nil, err
+	}
+
+	return &ds, nil
+}
+
+func (s service) CreateDatasource(ctx context.Context, request influxdb.CreateDatasourceRequest) (*influxdb.Datasource, error) {
+	if err := influxdb.ValidDatasourceDriver(request.Driver); err != nil {
+		return nil, err
+	}
+	if request.DataSourceName == "" {
+		return nil, errEmptyDataSourceName
+	}
+
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	q := sq.Insert("datasources").
+		SetMap(sq.Eq{
+			"id":          s.idGenerator.ID(),
+			"org_id":      request.OrgID,
+			"name":        request.Name,
+			"description": request.Description,
+			"driver":      request.Driver,
+			"created_at":  "datetime('now')",
+			"updated_at":  "datetime('now')",
+		}).
+		Suffix("RETURNING id, org_id, name, description, driver")
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var d influxdb.Datasource
+	if err := s.store.DB.GetContext(ctx, &d, query, args...); err != nil {
+		return nil, err
+	}
+
+	if err := s.secrets.PutSecret(ctx, d.OrgID, influxdb.DatasourceSecretKey(d.ID), request.DataSourceName); err != nil {
+		// a datasource without its data source name cannot be queried
+		if _, derr := s.deleteDatasource(ctx, d.ID); derr != nil {
+			return nil, derr
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s service) GetDatasource(ctx context.Context, id platform.ID) (*influxdb.Datasource, error) {
+	q := sq.Select("id", "org_id", "name", "description", "driver").
+		From("datasources").
+		Where(sq.Eq{"id": id})
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var d influxdb.Datasource
+	if err := s.store.DB.GetContext(ctx, &d, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errDatasourceNotFound
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s service) UpdateDatasource(ctx context.Context, id platform.ID, request influxdb.UpdateDatasourceRequest) (*influxdb.Datasource, error) {
+	if request.DataSourceName != nil && *request.DataSourceName == "" {
+		return nil, errEmptyDataSourceName
+	}
+
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	updates := sq.Eq{"updated_at": sq.Expr("datetime('now')")}
+	if request.Name != nil {
+		updates["name"] = *request.Name
+	}
+	if request.Description != nil {
+		updates["description"] = *request.Description
+	}
+
+	q := sq.Update("datasources").SetMap(updates).Where(sq.Eq{"id": id}).
+		Suffix("RETURNING id, org_id, name, description, driver")
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var d influxdb.Datasource
+	if err := s.store.DB.GetContext(ctx, &d, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errDatasourceNotFound
+		}
+		return nil, err
+	}
+
+	if request.DataSourceName != nil {
+		if err := s.secrets.PutSecret(ctx, d.OrgID, influxdb.DatasourceSecretKey(d.ID), *request.DataSourceName); err != nil {
+			return nil, err
+		}
+	}
+	return &d, nil
+}
+
+func (s service) DeleteDatasource(ctx context.Context, id platform.ID) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	orgID, err := s.deleteDatasource(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.secrets.DeleteSecret(ctx, orgID, influxdb.DatasourceSecretKey(id))
+}
+
+// deleteDatasource deletes the datasource from the store and returns the ID
+// of its organization. The store must be locked.
+func (s service) deleteDatasource(ctx context.Context, id platform.ID) (platform.ID, error) {
+	q := sq.Delete("datasources").Where(sq.Eq{"id": id}).Suffix("RETURNING org_id")
+	query, args, err := q.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var orgID platform.ID
+	if err := s.store.DB.GetContext(ctx, &orgID, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errDatasourceNotFound
+		}
+		return 0, err
+	}
+	return orgID, nil
+}
@@ -0,0 +1,201 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	prefixDatasources = "/api/v2/datasources"
+)
+
+var (
+	errBadOrg = &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "invalid or missing org ID",
+	}
+
+	errBadId = &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "datasource ID is invalid",
+	}
+)
+
+type DatasourceService interface {
+	// ListDatasources returns all info about registered external datasources matching a filter.
+	ListDatasources(context.Context, influxdb.DatasourceListFilter) (*influxdb.Datasources, error)
+
+	// CreateDatasource registers a new external datasource.
+	CreateDatasource(context.Context, influxdb.CreateDatasourceRequest) (*influxdb.Datasource, error)
+
+	// GetDatasource returns metadata about the external datasource with the given ID.
+	GetDatasource(context.Context, platform.ID) (*influxdb.Datasource, error)
+
+	// UpdateDatasource updates the settings for the external datasource with the given ID.
+	UpdateDatasource(context.Context, platform.ID, influxdb.UpdateDatasourceRequest) (*influxdb.Datasource, error)
+
+	// DeleteDatasource deletes all info for the external datasource with the given ID.
+	DeleteDatasource(context.Context, platform.ID) error
+}
+
+type DatasourceHandler struct {
+	chi.Router
+
+	log *zap.Logger
+	api *kithttp.API
+
+	datasourcesService DatasourceService
+}
+
+func NewInstrumentedDatasourcesHandler(log *zap.Logger, reg prometheus.Registerer, svc DatasourceService) *DatasourceHandler {
+	// Collect metrics.
+	svc = newMetricCollectingService(reg, svc)
+	// Wrap logging.
+	svc = newLoggingService(log, svc)
+	// Wrap authz.
+	svc = newAuthCheckingService(svc)
+
+	return newDatasourceHandler(log, svc)
+}
+
+func newDatasourceHandler(log *zap.Logger, svc DatasourceService) *DatasourceHandler {
+	h := &DatasourceHandler{
+		log:                log,
+		api:                kithttp.NewAPI(kithttp.WithLog(log)),
+		datasourcesService: svc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetDatasources)
+		r.Post("/", h.handlePostDatasource)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.handleGetDatasource)
+			r.Patch("/", h.handlePatchDatasource)
+			r.Delete("/", h.handleDeleteDatasource)
+		})
+	})
+
+	h.Router = r
+	return h
+}
+
+func (h *DatasourceHandler) Prefix() string {
+	return prefixDatasources
+}
+
+func (h *DatasourceHandler) handleGetDatasources(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	// orgID is required for listing datasources.
+	orgID := q.Get("orgID")
+	o, err := platform.IDFromString(orgID)
+	if err != nil {
+		h.api.Err(w, r, errBadOrg)
+		return
+	}
+
+	// name and driver are optional additional filters.
+	name := q.Get("name")
+	driver := q.Get("driver")
+
+	filters := influxdb.DatasourceListFilter{OrgID: *o}
+	if name != "" {
+		filters.Name = &name
+	}
+	if driver != "" {
+		filters.Driver = &driver
+	}
+
+	ds, err := h.datasourcesService.ListDatasources(r.Context(), filters)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, ds)
+}
+
+func (h *DatasourceHandler) handlePostDatasource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req influxdb.CreateDatasourceRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	d, err := h.datasourcesService.CreateDatasource(ctx, req)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusCreated, d)
+}
+
+func (h *DatasourceHandler) handleGetDatasource(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, errBadId)
+		return
+	}
+
+	d, err := h.datasourcesService.GetDatasource(r.Context(), *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, d)
+}
+
+func (h *DatasourceHandler) handlePatchDatasource(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, errBadId)
+		return
+	}
+
+	ctx := r.Context()
+
+	var req influxdb.UpdateDatasourceRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	d, err := h.datasourcesService.UpdateDatasource(ctx, *id, req)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, d)
+}
+
+func (h *DatasourceHandler) handleDeleteDatasource(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, errBadId)
+		return
+	}
+
+	if err := h.datasourcesService.DeleteDatasource(r.Context(), *id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
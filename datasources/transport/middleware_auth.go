@@ -0,0 +1,81 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+func newAuthCheckingService(underlying DatasourceService) *authCheckingService {
+	return &authCheckingService{underlying}
+}
+
+type authCheckingService struct {
+	underlying DatasourceService
+}
+
+var _ DatasourceService = (*authCheckingService)(nil)
+
+func (a authCheckingService) ListDatasources(ctx context.Context, filter influxdb.DatasourceListFilter) (*influxdb.Datasources, error) {
+	ds, err := a.underlying.ListDatasources(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	dds := ds.Datasources[:0]
+	for _, d := range ds.Datasources {
+		_, _, err := authorizer.AuthorizeRead(ctx, influxdb.DatasourcesResourceType, d.ID, d.OrgID)
+		if err != nil && errors.ErrorCode(err) != errors.EUnauthorized {
+			return nil, err
+		}
+		if errors.ErrorCode(err) == errors.EUnauthorized {
+			continue
+		}
+		dds = append(dds, d)
+	}
+	return &influxdb.Datasources{Datasources: dds}, nil
+}
+
+func (a authCheckingService) CreateDatasource(ctx context.Context, request influxdb.CreateDatasourceRequest) (*influxdb.Datasource, error) {
+	if _, _, err := authorizer.AuthorizeCreate(ctx, influxdb.DatasourcesResourceType, request.OrgID); err != nil {
+		return nil, err
+	}
+
+	return a.underlying.CreateDatasource(ctx, request)
+}
+
+func (a authCheckingService) GetDatasource(ctx context.Context, id platform.ID) (*influxdb.Datasource, error) {
+	d, err := a.underlying.GetDatasource(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeRead(ctx, influxdb.DatasourcesResourceType, id, d.OrgID); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (a authCheckingService) UpdateDatasource(ctx context.Context, id platform.ID, request influxdb.UpdateDatasourceRequest) (*influxdb.Datasource, error) {
+	d, err := a.underlying.GetDatasource(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.DatasourcesResourceType, id, d.OrgID); err != nil {
+		return nil, err
+	}
+	return a.underlying.UpdateDatasource(ctx, id, request)
+}
+
+func (a authCheckingService) DeleteDatasource(ctx context.Context, id platform.ID) error {
+	d, err := a.underlying.GetDatasource(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.DatasourcesResourceType, id, d.OrgID); err != nil {
+		return err
+	}
+	return a.underlying.DeleteDatasource(ctx, id)
+}
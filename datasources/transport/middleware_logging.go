@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"go.uber.org/zap"
+)
+
+func newLoggingService(logger *zap.Logger, underlying DatasourceService) *loggingService {
+	return &loggingService{
+		logger:     logger,
+		underlying: underlying,
+	}
+}
+
+type loggingService struct {
+	logger     *zap.Logger
+	underlying DatasourceService
+}
+
+var _ DatasourceService = (*loggingService)(nil)
+
+func (l loggingService) ListDatasources(ctx context.Context, filter influxdb.DatasourceListFilter) (ds *influxdb.Datasources, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to find datasources", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("datasources find", dur)
+	}(time.Now())
+	return l.underlying.ListDatasources(ctx, filter)
+}
+
+func (l loggingService) CreateDatasource(ctx context.Context, request influxdb.CreateDatasourceRequest) (d *influxdb.Datasource, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to create datasource", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("datasource create", dur)
+	}(time.Now())
+	return l.underlying.CreateDatasource(ctx, request)
+}
+
+func (l loggingService) GetDatasource(ctx context.Context, id platform.ID) (d *influxdb.Datasource, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to find datasource by ID", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("datasource find by ID", dur)
+	}(time.Now())
+	return l.underlying.GetDatasource(ctx, id)
+}
+
+func (l loggingService) UpdateDatasource(ctx context.Context, id platform.ID, request influxdb.UpdateDatasourceRequest) (d *influxdb.Datasource, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to update datasource", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("datasource update", dur)
+	}(time.Now())
+	return l.underlying.UpdateDatasource(ctx, id, request)
+}
+
+func (l loggingService) DeleteDatasource(ctx context.Context, id platform.ID) (err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to delete datasource", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("datasource delete", dur)
+	}(time.Now())
+	return l.underlying.DeleteDatasource(ctx, id)
+}
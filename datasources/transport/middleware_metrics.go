@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/metric"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newMetricCollectingService(reg prometheus.Registerer, underlying DatasourceService, opts ...metric.ClientOptFn) *metricsService {
+	o := metric.ApplyMetricOpts(opts...)
+	return &metricsService{
+		rec:        metric.New(reg, o.ApplySuffix("datasource")),
+		underlying: underlying,
+	}
+}
+
+type metricsService struct {
+	// RED metrics
+	rec        *metric.REDClient
+	underlying DatasourceService
+}
+
+var _ DatasourceService = (*metricsService)(nil)
+
+func (m metricsService) ListDatasources(ctx context.Context, filter influxdb.DatasourceListFilter) (*influxdb.Datasources, error) {
+	rec := m.rec.Record("find_datasources")
+	ds, err := m.underlying.ListDatasources(ctx, filter)
+	return ds, rec(err)
+}
+
+func (m metricsService) CreateDatasource(ctx context.Context, request influxdb.CreateDatasourceRequest) (*influxdb.Datasource, error) {
+	rec := m.rec.Record("create_datasource")
+	d, err := m.underlying.CreateDatasource(ctx, request)
+	return d, rec(err)
+}
+
+func (m metricsService) GetDatasource(ctx context.Context, id platform.ID) (*influxdb.Datasource, error) {
+	rec := m.rec.Record("find_datasource_by_id")
+	d, err := m.underlying.GetDatasource(ctx, id)
+	return d, rec(err)
+}
+
+func (m metricsService) UpdateDatasource(ctx context.Context, id platform.ID, request influxdb.UpdateDatasourceRequest) (*influxdb.Datasource, error) {
+	rec := m.rec.Record("update_datasource")
+	d, err := m.underlying.UpdateDatasource(ctx, id, request)
+	return d, rec(err)
+}
+
+func (m metricsService) DeleteDatasource(ctx context.Context, id platform.ID) error {
+	rec := m.rec.Record("delete_datasource")
+	return rec(m.underlying.DeleteDatasource(ctx, id))
+}
@@ -0,0 +1,278 @@
+// Package diagnostics collects the runtime diagnostics of influxd as line
+// protocol. Written into a support bucket, the data collected for a
+// troubleshooting session can be queried like any other.
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Measurements of the diagnostics.
+const (
+	MeasurementBuild       = "influxd_build"
+	MeasurementConfig      = "influxd_config"
+	MeasurementCardinality = "influxd_cardinality"
+	MeasurementShard       = "influxd_shard"
+	MeasurementErrors      = "influxd_errors"
+)
+
+// Redacted replaces the value of a configuration option holding a secret.
+const Redacted = "[REDACTED]"
+
+// Store is the storage the cardinality and shard statistics are read from.
+type Store interface {
+	Databases() []string
+	ShardIDs() []uint64
+	Shards(ids []uint64) []*tsdb.Shard
+	SeriesCardinality(ctx context.Context, database string) (int64, error)
+}
+
+// Collector collects the diagnostics of the sources it is configured with.
+type Collector struct {
+	config   []cli.Opt
+	store    Store
+	gatherer prometheus.Gatherer
+
+	now func() time.Time
+}
+
+// CollectorOptFn is a functional option for configuring a Collector.
+type CollectorOptFn func(*Collector)
+
+// WithConfig includes the configuration options. The values of secret
+// options are redacted, and the userinfo of urls is removed.
+func WithConfig(opts []cli.Opt) CollectorOptFn {
+	return func(c *Collector) {
+		c.config = opts
+	}
+}
+
+// WithStore includes the series cardinality of every bucket and the
+// statistics of every shard.
+func WithStore(s Store) CollectorOptFn {
+	return func(c *Collector) {
+		c.store = s
+	}
+}
+
+// WithGatherer includes the error and failure counters of the metrics.
+func WithGatherer(g prometheus.Gatherer) CollectorOptFn {
+	return func(c *Collector) {
+		c.gatherer = g
+	}
+}
+
+// NewCollector constructs a Collector. Without options only the build
+// information is collected.
+func NewCollector(opts ...CollectorOptFn) *Collector {
+	c := &Collector{
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Collect returns the diagnostics as points, all with the same timestamp.
+func (c *Collector) Collect(ctx context.Context) (models.Points, error) {
+	now := c.now()
+
+	points := models.Points{BuildPoint(now)}
+
+	config, err := c.configPoints(now)
+	if err != nil {
+		return nil, err
+	}
+	points = append(points, config...)
+
+	storage, err := c.storePoints(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	points = append(points, storage...)
+
+	errs, err := c.errorPoints(now)
+	if err != nil {
+		return nil, err
+	}
+	return append(points, errs...), nil
+}
+
+// BuildPoint returns the build and runtime information of the process.
+func BuildPoint(now time.Time) models.Point {
+	info := influxdb.GetBuildInfo()
+	return models.MustNewPoint(MeasurementBuild, nil, models.Fields{
+		"version":    info.Version,
+		"commit":     info.Commit,
+		"date":       info.Date,
+		"go_version": runtime.Version(),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+		"cpus":       int64(runtime.NumCPU()),
+		"goroutines": int64(runtime.NumGoroutine()),
+	}, now)
+}
+
+func (c *Collector) configPoints(now time.Time) (models.Points, error) {
+	points := make(models.Points, 0, len(c.config))
+	for _, o := range c.config {
+		value := Redacted
+		if !isSecret(o) {
+			b, err := json.Marshal(withoutUserinfo(o.DestP))
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode option %q: %w", o.Flag, err)
+			}
+			value = string(b)
+		}
+
+		p, err := models.NewPoint(MeasurementConfig, models.NewTags(map[string]string{"flag": o.Flag}), models.Fields{"value": value}, now)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// isSecret reports whether the option holds a credential. Options not
+// marked as secret are still redacted when their flag names a credential.
+func isSecret(o cli.Opt) bool {
+	if o.Secret {
+		return true
+	}
+	for _, suffix := range []string{"-token", "-password", "-secret", "-headers"} {
+		if strings.HasSuffix(o.Flag, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutUserinfo returns the value of a string option, or of each element
+// of a string slice option, with the userinfo of urls removed since it may
+// hold credentials. Other values are returned unchanged.
+func withoutUserinfo(destP interface{}) interface{} {
+	switch v := destP.(type) {
+	case *string:
+		return stripUserinfo(*v)
+	case *[]string:
+		out := make([]string, len(*v))
+		for i, s := range *v {
+			out[i] = stripUserinfo(s)
+		}
+		return out
+	default:
+		return destP
+	}
+}
+
+func stripUserinfo(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	u.User = nil
+	return u.String()
+}
+
+func (c *Collector) storePoints(ctx context.Context, now time.Time) (models.Points, error) {
+	if c.store == nil {
+		return nil, nil
+	}
+
+	var points models.Points
+	for _, db := range c.store.Databases() {
+		n, err := c.store.SeriesCardinality(ctx, db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute cardinality of bucket %s: %w", db, err)
+		}
+		points = append(points, models.MustNewPoint(MeasurementCardinality,
+			models.NewTags(map[string]string{"bucket": db}),
+			models.Fields{"series": n},
+			now,
+		))
+	}
+
+	for _, sh := range c.store.Shards(c.store.ShardIDs()) {
+		size, err := sh.DiskSize()
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute size of shard %d: %w", sh.ID(), err)
+		}
+		points = append(points, models.MustNewPoint(MeasurementShard,
+			models.NewTags(map[string]string{
+				"bucket":           sh.Database(),
+				"retention_policy": sh.RetentionPolicy(),
+				"shard_id":         strconv.FormatUint(sh.ID(), 10),
+				"index":            sh.IndexType(),
+			}),
+			models.Fields{
+				"series":     sh.SeriesN(),
+				"disk_bytes": size,
+			},
+			now,
+		))
+	}
+	return points, nil
+}
+
+// errorPoints returns the counters whose name mentions errors or failures,
+// one point per label set.
+func (c *Collector) errorPoints(now time.Time) (models.Points, error) {
+	if c.gatherer == nil {
+		return nil, nil
+	}
+
+	families, err := c.gatherer.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	var points models.Points
+	for _, mf := range families {
+		if mf.GetType() != dto.MetricType_COUNTER || !isErrorCounter(mf.GetName()) {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			tags := map[string]string{"metric": mf.GetName()}
+			for _, l := range m.GetLabel() {
+				tags[l.GetName()] = l.GetValue()
+			}
+			p, err := models.NewPoint(MeasurementErrors, models.NewTags(tags), models.Fields{"count": m.GetCounter().GetValue()}, now)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, p)
+		}
+	}
+	return points, nil
+}
+
+func isErrorCounter(name string) bool {
+	return strings.Contains(name, "error") || strings.Contains(name, "fail")
+}
+
+// WriteLineProtocol writes the points as line protocol, one per line.
+func WriteLineProtocol(w io.Writer, points models.Points) error {
+	for _, p := range points {
+		if _, err := io.WriteString(w, p.String()+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
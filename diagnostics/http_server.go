@@ -0,0 +1,83 @@
+package diagnostics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixDiagnostics = "/api/v2/diagnostics"
+
+// Handler serves the diagnostics as line protocol to operators.
+type Handler struct {
+	chi.Router
+
+	log       *zap.Logger
+	api       *kithttp.API
+	collector *Collector
+}
+
+// NewHTTPHandler constructs a handler serving the diagnostics of the collector.
+func NewHTTPHandler(log *zap.Logger, collector *Collector) *Handler {
+	h := &Handler{
+		log:       log,
+		api:       kithttp.NewAPI(kithttp.WithLog(log)),
+		collector: collector,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+		h.mwAuthorize,
+	)
+
+	r.Get("/", h.handleGetDiagnostics)
+	h.Router = r
+	return h
+}
+
+// Prefix is the route the handler is mounted at.
+func (h *Handler) Prefix() string {
+	return prefixDiagnostics
+}
+
+func (h *Handler) handleGetDiagnostics(w http.ResponseWriter, r *http.Request) {
+	points, err := h.collector.Collect(r.Context())
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := WriteLineProtocol(&buf, points); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	h.api.Write(w, http.StatusOK, buf.Bytes())
+}
+
+func (h *Handler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("access to %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -3,15 +3,19 @@ package http
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/audit"
 	"github.com/influxdata/influxdb/v2/authorizer"
 	"github.com/influxdata/influxdb/v2/dbrp"
 	"github.com/influxdata/influxdb/v2/http/metric"
+	"github.com/influxdata/influxdb/v2/http/points"
 	"github.com/influxdata/influxdb/v2/influxql"
 	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/influxdata/influxdb/v2/kit/openapi"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/prom"
@@ -37,6 +41,9 @@ type APIBackend struct {
 	UIDisabled     bool   // if true requests for the UI will return 404
 	Logger         *zap.Logger
 	FluxLogEnabled bool
+	// FluxUtilsDisabled is set when the utils library of common query
+	// patterns is not available to Flux queries.
+	FluxUtilsDisabled bool
 	errors.HTTPErrorHandler
 	SessionRenewDisabled bool
 	// MaxBatchSizeBytes is the maximum number of bytes which can be written
@@ -55,6 +62,49 @@ type APIBackend struct {
 	// write request. A value of zero specifies there is no limit.
 	WriteParserMaxValues int
 
+	// SlowWriteLog records writes that exceed its latency threshold.
+	SlowWriteLog *points.SlowWriteLog
+
+	// WriteRejectionLog tracks writes rejected in full or in part.
+	WriteRejectionLog *points.RejectionLog
+
+	// TokenWriteLog tracks writes per authorization.
+	TokenWriteLog *points.TokenWriteLog
+
+	// OTLPNaming is the convention OpenTelemetry metrics are named by,
+	// points.OTLPNamingMeasurement when empty.
+	OTLPNaming points.OTLPNaming
+
+	// RateLimiter limits the requests to the write and query endpoints,
+	// nil does not limit.
+	RateLimiter *kithttp.RateLimiter
+
+	// BodyLimiter limits the request bodies of writes, queries, template
+	// applies and the remaining API to the respective maximum, which is not
+	// limited when 0. Restores are never limited.
+	BodyLimiter          *kithttp.BodyLimiter
+	MaxWriteBodyBytes    int64
+	MaxQueryBodyBytes    int64
+	MaxTemplateBodyBytes int64
+	MaxAPIBodyBytes      int64
+
+	// Timeouter answers the requests to writes, queries and the remaining
+	// API with 503 when they do not start responding within the respective
+	// timeout, which is unlimited when 0. Backups and restores are never
+	// bounded.
+	Timeouter    *kithttp.Timeouter
+	WriteTimeout time.Duration
+	QueryTimeout time.Duration
+	APITimeout   time.Duration
+
+	// AuditLog records the calls changing the resources of the server,
+	// nil does not record them.
+	AuditLog *audit.Logger
+
+	// RequestValidator rejects JSON request bodies that do not match the
+	// schemas of the API spec, nil does not validate them.
+	RequestValidator *openapi.Validator
+
 	NewQueryService func(*influxdb.Source) (query.ProxyQueryService, error)
 
 	WriteEventRecorder metric.EventRecorder
@@ -90,6 +140,7 @@ type APIBackend struct {
 	PasswordsService                influxdb.PasswordsService
 	InfluxqldService                influxql.ProxyQueryService
 	FluxService                     query.ProxyQueryService
+	ActiveQueryService              query.ActiveQueryService
 	FluxLanguageService             fluxlang.FluxLanguageService
 	TaskService                     taskmodel.TaskService
 	CheckService                    influxdb.CheckService
@@ -103,6 +154,9 @@ type APIBackend struct {
 	NotificationEndpointService     influxdb.NotificationEndpointService
 	Flagger                         feature.Flagger
 	FlagsHandler                    http.Handler
+	// FlagOverridesHandler serves the overrides of the flags of
+	// organizations under /api/v2/flags/orgs. It is optional.
+	FlagOverridesHandler http.Handler
 }
 
 // PrometheusCollectors exposes the prometheus collectors associated with an APIBackend.
@@ -117,6 +171,30 @@ func (b *APIBackend) PrometheusCollectors() []prometheus.Collector {
 		cs = append(cs, pc.PrometheusCollectors()...)
 	}
 
+	if b.SlowWriteLog != nil {
+		cs = append(cs, b.SlowWriteLog.PrometheusCollectors()...)
+	}
+
+	if b.WriteRejectionLog != nil {
+		cs = append(cs, b.WriteRejectionLog.PrometheusCollectors()...)
+	}
+
+	if b.TokenWriteLog != nil {
+		cs = append(cs, b.TokenWriteLog.PrometheusCollectors()...)
+	}
+
+	if b.RateLimiter != nil {
+		cs = append(cs, b.RateLimiter.PrometheusCollectors()...)
+	}
+
+	if b.BodyLimiter != nil {
+		cs = append(cs, b.BodyLimiter.PrometheusCollectors()...)
+	}
+
+	if b.Timeouter != nil {
+		cs = append(cs, b.Timeouter.PrometheusCollectors()...)
+	}
+
 	return cs
 }
 
@@ -131,11 +209,31 @@ func WithResourceHandler(resHandler kithttp.ResourceHandler) APIHandlerOptFn {
 	}
 }
 
+// prefixTemplates is the prefix of the template routes, which are
+// mounted from the pkger package.
+const prefixTemplates = "/api/v2/templates"
+
 // NewAPIHandler constructs all api handlers beneath it and returns an APIHandler
 func NewAPIHandler(b *APIBackend, opts ...APIHandlerOptFn) *APIHandler {
 	h := &APIHandler{
 		Router: NewBaseChiRouter(kithttp.NewAPI(kithttp.WithLog(b.Logger))),
 	}
+	h.Use(b.BodyLimiter.RouteMiddleware(
+		kithttp.BodyLimitRoute{Name: "api", MaxBytes: b.MaxAPIBodyBytes},
+		kithttp.BodyLimitRoute{Name: "write", Prefix: prefixWrite, MaxBytes: b.MaxWriteBodyBytes},
+		kithttp.BodyLimitRoute{Name: "query", Prefix: prefixQuery, MaxBytes: b.MaxQueryBodyBytes},
+		kithttp.BodyLimitRoute{Name: "templates", Prefix: prefixTemplates, MaxBytes: b.MaxTemplateBodyBytes},
+		kithttp.BodyLimitRoute{Name: "restore", Prefix: prefixRestore},
+	))
+	h.Use(b.Timeouter.RouteMiddleware(
+		kithttp.TimeoutRoute{Name: "api", Timeout: b.APITimeout},
+		kithttp.TimeoutRoute{Name: "write", Prefix: prefixWrite, Timeout: b.WriteTimeout},
+		kithttp.TimeoutRoute{Name: "query", Prefix: prefixQuery, Timeout: b.QueryTimeout},
+		kithttp.TimeoutRoute{Name: "backup", Prefix: prefixBackup},
+		kithttp.TimeoutRoute{Name: "restore", Prefix: prefixRestore},
+	))
+	h.Use(b.AuditLog.ActorMiddleware)
+	h.Use(b.RequestValidator.Middleware(b.HTTPErrorHandler))
 
 	b.UserResourceMappingService = authorizer.NewURMService(b.OrgLookupService, b.UserResourceMappingService)
 
@@ -154,7 +252,7 @@ func NewAPIHandler(b *APIBackend, opts ...APIHandlerOptFn) *APIHandler {
 	h.Mount(prefixDocuments, NewDocumentHandler(documentBackend))
 
 	fluxBackend := NewFluxBackend(b.Logger.With(zap.String("handler", "query")), b)
-	h.Mount(prefixQuery, NewFluxHandler(b.Logger, fluxBackend))
+	h.Mount(prefixQuery, b.RateLimiter.Middleware("query")(NewFluxHandler(b.Logger, fluxBackend)))
 
 	notificationEndpointBackend := NewNotificationEndpointBackend(b.Logger.With(zap.String("handler", "notificationEndpoint")), b)
 	notificationEndpointBackend.NotificationEndpointService = authorizer.NewNotificationEndpointService(b.NotificationEndpointService,
@@ -190,7 +288,14 @@ func NewAPIHandler(b *APIBackend, opts ...APIHandlerOptFn) *APIHandler {
 	h.Mount(prefixTelegrafPlugins, NewTelegrafHandler(b.Logger, telegrafBackend))
 	h.Mount(prefixTelegraf, NewTelegrafHandler(b.Logger, telegrafBackend))
 
-	h.Mount("/api/v2/flags", b.FlagsHandler)
+	if b.FlagOverridesHandler != nil {
+		flags := chi.NewRouter()
+		flags.Mount("/orgs", b.FlagOverridesHandler)
+		flags.Handle("/", b.FlagsHandler)
+		h.Mount("/api/v2/flags", flags)
+	} else {
+		h.Mount("/api/v2/flags", b.FlagsHandler)
+	}
 
 	h.Mount(prefixResources, NewResourceListHandler())
 
@@ -211,14 +316,18 @@ func NewAPIHandler(b *APIBackend, opts ...APIHandlerOptFn) *APIHandler {
 	h.Mount(dbrp.PrefixDBRP, dbrp.NewHTTPHandler(b.Logger, b.DBRPService, b.OrganizationService))
 
 	writeBackend := NewWriteBackend(b.Logger.With(zap.String("handler", "write")), b)
-	h.Mount(prefixWrite, NewWriteHandler(b.Logger, writeBackend,
+	h.Mount(prefixWrite, b.RateLimiter.Middleware("write")(NewWriteHandler(b.Logger, writeBackend,
 		WithMaxBatchSizeBytes(b.MaxBatchSizeBytes),
+		WithSlowWriteLog(b.SlowWriteLog),
+		WithRejectionLog(b.WriteRejectionLog),
+		WithTokenWriteLog(b.TokenWriteLog),
+		WithOTLPNaming(b.OTLPNaming),
 		// WithParserOptions(
 		//	models.WithParserMaxBytes(b.WriteParserMaxBytes),
 		//	models.WithParserMaxLines(b.WriteParserMaxLines),
 		//	models.WithParserMaxValues(b.WriteParserMaxValues),
 		// ),
-	))
+	)))
 
 	for _, o := range opts {
 		o(h)
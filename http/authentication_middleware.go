@@ -9,8 +9,10 @@ import (
 
 	"github.com/influxdata/httprouter"
 	platform "github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
 	platcontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/jsonweb"
+	platform2 "github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/session"
 	"github.com/opentracing/opentracing-go"
@@ -118,6 +120,14 @@ func (h *AuthenticationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	if r.Header.Get(ImpersonateUserHeader) != "" {
+		auth, err = h.impersonate(platcontext.SetAuthorizer(ctx, auth), r, auth)
+		if err != nil {
+			h.HandleHTTPError(ctx, err, w)
+			return
+		}
+	}
+
 	ctx = platcontext.SetAuthorizer(ctx, auth)
 
 	if span := opentracing.SpanFromContext(ctx); span != nil {
@@ -127,6 +137,68 @@ func (h *AuthenticationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 	h.Handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
+// Headers an operator sets to execute a request with the identity of another
+// user and, optionally, restricted to one of the user's organizations.
+const (
+	ImpersonateUserHeader = "Influx-Impersonate-User"
+	ImpersonateOrgHeader  = "Influx-Impersonate-Org"
+)
+
+// impersonate returns the authorizer of the user impersonated by the operator
+// authorized in ctx. Every impersonated request is recorded in the audit log
+// with both the real and the effective identity.
+func (h *AuthenticationHandler) impersonate(ctx context.Context, r *http.Request, operator platform.Authorizer) (platform.Authorizer, error) {
+	if err := authorizer.IsAllowedAll(ctx, platform.OperPermissions()); err != nil {
+		return nil, &errors2.Error{
+			Code: errors2.EForbidden,
+			Msg:  "impersonation requires an operator token",
+		}
+	}
+
+	var userID, orgID platform2.ID
+	if err := userID.DecodeFromString(r.Header.Get(ImpersonateUserHeader)); err != nil {
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  fmt.Sprintf("invalid %s header", ImpersonateUserHeader),
+			Err:  err,
+		}
+	}
+	if v := r.Header.Get(ImpersonateOrgHeader); v != "" {
+		if err := orgID.DecodeFromString(v); err != nil {
+			return nil, &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  fmt.Sprintf("invalid %s header", ImpersonateOrgHeader),
+				Err:  err,
+			}
+		}
+	}
+
+	u, err := h.UserService.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Status == "inactive" {
+		return nil, &errors2.Error{Code: errors2.EForbidden, Msg: "User is inactive"}
+	}
+
+	ps, err := h.UserService.FindPermissionForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	h.log.Named("audit").Info("Impersonated request",
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.String("real_user_id", operator.GetUserID().String()),
+		zap.String("real_authorizer_id", operator.Identifier().String()),
+		zap.String("real_authorizer_kind", operator.Kind()),
+		zap.String("effective_user_id", userID.String()),
+		zap.String("effective_org_id", orgID.String()),
+	)
+
+	return platform.NewImpersonation(operator, userID, orgID, ps), nil
+}
+
 func (h *AuthenticationHandler) isUserActive(ctx context.Context, auth platform.Authorizer) error {
 	u, err := h.UserService.FindUserByID(ctx, auth.GetUserID())
 	if err != nil {
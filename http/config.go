@@ -14,6 +14,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/spf13/pflag"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -41,16 +42,39 @@ type ConfigHandler struct {
 	api *kithttp.API
 
 	config parsedOpt
+
+	compactionLimiter CompactionLimiter
+}
+
+// CompactionLimiter reports and changes the limits of compactions at
+// runtime.
+type CompactionLimiter interface {
+	CompactionLimits() (tsdb.CompactionLimits, error)
+	SetCompactionLimits(l tsdb.CompactionLimits) (tsdb.CompactionLimits, error)
+}
+
+// ConfigHandlerOption is an option for the config handler.
+type ConfigHandlerOption func(*ConfigHandler)
+
+// WithCompactionLimiter lets operators tune the limits of compactions
+// without a restart.
+func WithCompactionLimiter(l CompactionLimiter) ConfigHandlerOption {
+	return func(h *ConfigHandler) {
+		h.compactionLimiter = l
+	}
 }
 
 // NewConfigHandler creates a handler that will return a JSON object with key/value pairs for the configuration values
 // used during the launcher startup. The opts slice provides a list of options names along with a pointer to their
 // value.
-func NewConfigHandler(log *zap.Logger, opts []cli.Opt) (*ConfigHandler, error) {
+func NewConfigHandler(log *zap.Logger, opts []cli.Opt, handlerOpts ...ConfigHandlerOption) (*ConfigHandler, error) {
 	h := &ConfigHandler{
 		log: log,
 		api: kithttp.NewAPI(kithttp.WithLog(log)),
 	}
+	for _, o := range handlerOpts {
+		o(h)
+	}
 
 	if err := h.parseOptions(opts); err != nil {
 		return nil, err
@@ -65,6 +89,11 @@ func NewConfigHandler(log *zap.Logger, opts []cli.Opt) (*ConfigHandler, error) {
 	)
 
 	r.Get("/", h.handleGetConfig)
+	r.Route("/compaction", func(r chi.Router) {
+		r.Use(h.mwCompactionLimiter)
+		r.Get("/", h.handleGetCompactionLimits)
+		r.Patch("/", h.handlePatchCompactionLimits)
+	})
 	h.Router = r
 	return h, nil
 }
@@ -101,6 +130,77 @@ func (h *ConfigHandler) handleGetConfig(w http.ResponseWriter, r *http.Request)
 	h.api.Respond(w, r, http.StatusOK, map[string]parsedOpt{"config": h.config})
 }
 
+// handleGetCompactionLimits is the HTTP handler for the GET /api/v2/config/compaction route.
+// It reports the limits compactions currently run with, which differ from the
+// configuration values once changed.
+func (h *ConfigHandler) handleGetCompactionLimits(w http.ResponseWriter, r *http.Request) {
+	l, err := h.compactionLimiter.CompactionLimits()
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, l)
+}
+
+type patchCompactionLimitsRequest struct {
+	MaxConcurrent   *int `json:"maxConcurrentCompactions"`
+	Throughput      *int `json:"compactThroughput"`
+	ThroughputBurst *int `json:"compactThroughputBurst"`
+}
+
+// handlePatchCompactionLimits is the HTTP handler for the PATCH /api/v2/config/compaction route.
+// The limits not set in the request are kept.
+func (h *ConfigHandler) handlePatchCompactionLimits(w http.ResponseWriter, r *http.Request) {
+	var req patchCompactionLimitsRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	l, err := h.compactionLimiter.CompactionLimits()
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if req.MaxConcurrent != nil {
+		l.MaxConcurrent = *req.MaxConcurrent
+	}
+	if req.Throughput != nil {
+		l.Throughput = *req.Throughput
+	}
+	if req.ThroughputBurst != nil {
+		l.ThroughputBurst = *req.ThroughputBurst
+	}
+	if err := l.Valid(); err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		})
+		return
+	}
+
+	l, err = h.compactionLimiter.SetCompactionLimits(l)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, l)
+}
+
+func (h *ConfigHandler) mwCompactionLimiter(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if h.compactionLimiter == nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.ENotImplemented,
+				Msg:  "compaction limits cannot be changed on this server",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
 func (h *ConfigHandler) mwAuthorize(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
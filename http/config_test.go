@@ -14,6 +14,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 )
@@ -125,3 +126,52 @@ func TestConfigHandler_Authorization(t *testing.T) {
 		})
 	}
 }
+
+type compactionLimiter struct {
+	limits tsdb.CompactionLimits
+}
+
+func (l *compactionLimiter) CompactionLimits() (tsdb.CompactionLimits, error) {
+	return l.limits, nil
+}
+
+func (l *compactionLimiter) SetCompactionLimits(limits tsdb.CompactionLimits) (tsdb.CompactionLimits, error) {
+	l.limits = limits
+	return limits, nil
+}
+
+func TestConfigHandler_CompactionLimits(t *testing.T) {
+	lim := &compactionLimiter{limits: tsdb.CompactionLimits{MaxConcurrent: 4, Throughput: 1024, ThroughputBurst: 2048}}
+	h, err := NewConfigHandler(zaptest.NewLogger(t), nil, WithCompactionLimiter(lim))
+	require.NoError(t, err)
+	ctx := influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, influxdb.OperPermissions()))
+
+	do := func(method, body string) (int, tsdb.CompactionLimits) {
+		r := httptest.NewRequest(method, "/compaction", bytes.NewBufferString(body)).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r)
+		var l tsdb.CompactionLimits
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&l))
+		}
+		return rr.Code, l
+	}
+
+	code, l := do(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, lim.limits, l)
+
+	// limits missing from the request are kept
+	code, l = do(http.MethodPatch, `{"maxConcurrentCompactions": 1}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, tsdb.CompactionLimits{MaxConcurrent: 1, Throughput: 1024, ThroughputBurst: 2048}, l)
+	require.Equal(t, l, lim.limits)
+
+	code, _ = do(http.MethodPatch, `{"compactThroughput": -1}`)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	h, err = NewConfigHandler(zaptest.NewLogger(t), nil)
+	require.NoError(t, err)
+	code, _ = do(http.MethodGet, "")
+	require.Equal(t, http.StatusNotImplemented, code)
+}
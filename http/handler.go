@@ -7,6 +7,8 @@ import (
 
 	"github.com/go-chi/chi"
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/points"
+	"github.com/influxdata/influxdb/v2/kit/memstat"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/prom"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
@@ -22,8 +24,12 @@ const (
 	ReadyPath = "/ready"
 	// HealthPath exposes the health of the service over /health.
 	HealthPath = "/health"
+	// HealthDetailPath exposes the health of each subsystem to operators over /health/detail.
+	HealthDetailPath = HealthPath + "/detail"
 	// DebugPath exposes /debug/pprof for go debugging.
 	DebugPath = "/debug"
+	// SlowWriteLogPath exposes the slow write log settings over /debug/slow-writes.
+	SlowWriteLogPath = DebugPath + "/slow-writes"
 )
 
 // Handler provides basic handling of metrics, health and debug endpoints.
@@ -41,11 +47,16 @@ type Handler struct {
 
 type (
 	handlerOpts struct {
-		log           *zap.Logger
-		apiHandler    http.Handler
-		healthHandler http.Handler
-		readyHandler  http.Handler
-		pprofEnabled  bool
+		log                 *zap.Logger
+		apiHandler          http.Handler
+		healthHandler       http.Handler
+		healthDetailHandler http.Handler
+		readyHandler        http.Handler
+		pprofEnabled        bool
+
+		memoryReporters []memstat.Reporter
+		slowWriteLog    *points.SlowWriteLog
+		operatorOnly    func(http.Handler) http.Handler
 
 		// NOTE: Track the registry even if metricsExposed = false
 		// so we can report HTTP metrics via telemetry.
@@ -84,6 +95,40 @@ func WithPprofEnabled(enabled bool) HandlerOptFn {
 	}
 }
 
+// WithMemoryReporters registers the components whose memory usage is
+// reported by the /debug/memory endpoint.
+func WithMemoryReporters(reporters ...memstat.Reporter) HandlerOptFn {
+	return func(opts *handlerOpts) {
+		opts.memoryReporters = append(opts.memoryReporters, reporters...)
+	}
+}
+
+// WithSlowWriteLogControl exposes the settings of the slow write
+// log at /debug/slow-writes so they can be changed at runtime.
+// The route is only mounted when profiling is enabled and the
+// requests of operators can be told apart, see WithOperatorOnly.
+func WithSlowWriteLogControl(l *points.SlowWriteLog) HandlerOptFn {
+	return func(opts *handlerOpts) {
+		opts.slowWriteLog = l
+	}
+}
+
+// WithOperatorOnly sets the middleware restricting the endpoints
+// which change the server to operators, see NewOperatorHandler.
+func WithOperatorOnly(mw func(http.Handler) http.Handler) HandlerOptFn {
+	return func(opts *handlerOpts) {
+		opts.operatorOnly = mw
+	}
+}
+
+// WithHealthDetail serves the health of each subsystem at /health/detail to
+// operators, see WithOperatorOnly.
+func WithHealthDetail(h http.Handler) HandlerOptFn {
+	return func(opts *handlerOpts) {
+		opts.healthDetailHandler = h
+	}
+}
+
 func WithMetrics(reg *prom.Registry, exposed bool) HandlerOptFn {
 	return func(opts *handlerOpts) {
 		opts.metricsRegistry = reg
@@ -140,8 +185,15 @@ func NewRootHandler(name string, opts ...HandlerOptFn) *Handler {
 		)
 		r.Mount(MetricsPath, opt.metricsHTTPHandler())
 		r.Mount(ReadyPath, opt.readyHandler)
+		// the detail reports internal errors, so it is only served to operators
+		if opt.healthDetailHandler != nil && opt.operatorOnly != nil {
+			r.Handle(HealthDetailPath, opt.operatorOnly(opt.healthDetailHandler))
+		}
 		r.Mount(HealthPath, opt.healthHandler)
-		r.Mount(DebugPath, pprof.NewHTTPHandler(opt.pprofEnabled))
+		if opt.slowWriteLog != nil && opt.operatorOnly != nil && opt.pprofEnabled {
+			r.Mount(SlowWriteLogPath, opt.operatorOnly(opt.slowWriteLog))
+		}
+		r.Mount(DebugPath, pprof.NewHTTPHandler(opt.pprofEnabled, opt.memoryReporters...))
 	})
 
 	// gather metrics and traces for everything else
@@ -90,3 +90,37 @@ func TestHandler_ServeHTTP(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_HealthDetailOperatorOnly(t *testing.T) {
+	detail := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	operatorOnly := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Token operator" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	h := NewRootHandler("test",
+		WithLog(zaptest.NewLogger(t)),
+		WithAPIHandler(http.NotFoundHandler()),
+		WithHealthDetail(detail),
+		WithOperatorOnly(operatorOnly),
+	)
+
+	get := func(auth string) int {
+		req := httptest.NewRequest(http.MethodGet, HealthDetailPath, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusUnauthorized, get(""))
+	require.Equal(t, http.StatusOK, get("Token operator"))
+}
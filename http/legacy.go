@@ -18,6 +18,7 @@ func newLegacyBackend(b *APIBackend) *legacy.Backend {
 		DBRPMappingService:    b.DBRPService,
 		InfluxqldQueryService: b.InfluxqldService,
 		WriteEventRecorder:    b.WriteEventRecorder,
+		SlowWriteLog:          b.SlowWriteLog,
 	}
 }
 
@@ -28,7 +29,10 @@ func newLegacyHandler(b *legacy.Backend, config legacy.HandlerConfig) *legacy.Ha
 	}
 
 	pointsWriterBackend := legacy.NewPointsWriterBackend(b)
-	h.PointsWriterHandler = legacy.NewWriterHandler(pointsWriterBackend, legacy.WithMaxBatchSizeBytes(b.MaxBatchSizeBytes))
+	h.PointsWriterHandler = legacy.NewWriterHandler(pointsWriterBackend,
+		legacy.WithMaxBatchSizeBytes(b.MaxBatchSizeBytes),
+		legacy.WithSlowWriteLog(b.SlowWriteLog),
+	)
 
 	influxqlBackend := legacy.NewInfluxQLBackend(b)
 	h.InfluxQLHandler = legacy.NewInfluxQLHandler(influxqlBackend, config)
@@ -5,6 +5,7 @@ import (
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/http/metric"
+	"github.com/influxdata/influxdb/v2/http/points"
 	"github.com/influxdata/influxdb/v2/influxql"
 	"github.com/influxdata/influxdb/v2/kit/cli"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
@@ -25,6 +26,7 @@ type Backend struct {
 	errors.HTTPErrorHandler
 	Logger            *zap.Logger
 	MaxBatchSizeBytes int64
+	SlowWriteLog      *points.SlowWriteLog
 
 	WriteEventRecorder    metric.EventRecorder
 	AuthorizationService  influxdb.AuthorizationService
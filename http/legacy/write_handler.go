@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
@@ -59,6 +60,7 @@ type WriteHandler struct {
 	router            *httprouter.Router
 	logger            *zap.Logger
 	maxBatchSizeBytes int64
+	slowWriteLog      *points.SlowWriteLog
 }
 
 // NewWriterHandler returns a new instance of PointsWriterHandler.
@@ -94,6 +96,14 @@ func WithMaxBatchSizeBytes(n int64) WriteHandlerOption {
 	}
 }
 
+// WithSlowWriteLog configures the log recording writes
+// that exceed its latency threshold.
+func WithSlowWriteLog(l *points.SlowWriteLog) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.slowWriteLog = l
+	}
+}
+
 // ServeHTTP implements http.Handler
 func (h *WriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.router.ServeHTTP(w, r)
@@ -104,6 +114,7 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 	span, r := tracing.ExtractFromHTTPRequest(r, "WriteHandler")
 	defer span.Finish()
 
+	start := time.Now()
 	ctx := r.Context()
 	auth, err := getAuthorization(ctx)
 	if err != nil {
@@ -150,13 +161,35 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	parsed, err := points.NewParser(req.Precision).Parse(ctx, auth.OrgID, bucket.ID, req.Body)
+	var (
+		parsed   *points.ParsedPoints
+		writeErr error
+	)
+	defer func() {
+		sample := points.SlowWrite{
+			OrgID:       bucket.OrgID,
+			BucketID:    bucket.ID,
+			Bucket:      bucket.Name,
+			Compression: r.Header.Get("Content-Encoding"),
+			Duration:    time.Since(start),
+			Err:         writeErr,
+		}
+		if parsed != nil {
+			sample.Bytes = parsed.RawSize
+			sample.Points = parsed.Points
+		}
+		h.slowWriteLog.Observe(sample)
+	}()
+
+	parsed, err = points.NewParser(req.Precision).Parse(ctx, auth.OrgID, bucket.ID, req.Body)
 	if err != nil {
+		writeErr = err
 		h.HandleHTTPError(ctx, err, sw)
 		return
 	}
 
 	if err := h.PointsWriter.WritePoints(ctx, auth.OrgID, bucket.ID, parsed.Points); err != nil {
+		writeErr = err
 		if partialErr, ok := err.(tsdb.PartialWriteError); ok {
 			h.HandleHTTPError(ctx, &errors.Error{
 				Code: errors.EUnprocessableEntity,
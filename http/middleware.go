@@ -140,6 +140,8 @@ var blacklistEndpoints = map[string]isValidMethodFn{
 	"/api/v2/users/:id/password":     ignoreMethod(),
 	"/api/v2/packages/apply":         ignoreMethod(),
 	prefixWrite:                      ignoreMethod("POST"),
+	prefixWritePrometheus:            ignoreMethod("POST"),
+	prefixWriteOTLP:                  ignoreMethod("POST"),
 	"/write":                         ignoreMethod("POST"),
 	organizationsIDSecretsPath:       ignoreMethod("PATCH"),
 	organizationsIDSecretsDeletePath: ignoreMethod("POST"),
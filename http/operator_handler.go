@@ -0,0 +1,29 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+)
+
+// NewOperatorHandler authenticates requests the way the API does and passes
+// only those of operators, holding all the permissions of an operator token,
+// to next. It guards the endpoints served outside the API, such as those
+// under /debug, which change the server.
+func NewOperatorHandler(b *APIBackend, next http.Handler) http.Handler {
+	h := NewAuthenticationHandler(b.Logger, b.HTTPErrorHandler)
+	h.AuthorizationService = b.AuthorizationService
+	h.SessionService = b.SessionService
+	h.SessionRenewDisabled = b.SessionRenewDisabled
+	h.UserService = b.UserService
+	h.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if err := authorizer.IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+			b.HTTPErrorHandler.HandleHTTPError(ctx, err, w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+	return h
+}
@@ -0,0 +1,60 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	platformhttp "github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestOperatorHandler(t *testing.T) {
+	auths := map[string]*influxdb.Authorization{
+		"operator": {ID: 1, UserID: 5, Status: influxdb.Active, Permissions: influxdb.OperPermissions()},
+		"member":   {ID: 2, OrgID: 3, UserID: 4, Status: influxdb.Active, Permissions: influxdb.MePermissions(4)},
+	}
+	b := &platformhttp.APIBackend{
+		Logger:           zaptest.NewLogger(t),
+		HTTPErrorHandler: kithttp.NewErrorHandler(zaptest.NewLogger(t)),
+		AuthorizationService: &mock.AuthorizationService{
+			FindAuthorizationByTokenFn: func(ctx context.Context, token string) (*influxdb.Authorization, error) {
+				if a, ok := auths[token]; ok {
+					return a, nil
+				}
+				return nil, &errors.Error{Code: errors.ENotFound}
+			},
+		},
+		SessionService: mock.NewSessionService(),
+		UserService: &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id platform.ID) (*influxdb.User, error) {
+				return &influxdb.User{ID: id, Status: influxdb.Active}, nil
+			},
+		},
+	}
+	h := platformhttp.NewOperatorHandler(b, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for token, code := range map[string]int{
+		"":         http.StatusUnauthorized,
+		"unknown":  http.StatusUnauthorized,
+		"member":   http.StatusUnauthorized,
+		"operator": http.StatusNoContent,
+	} {
+		req := httptest.NewRequest(http.MethodPut, "/debug/slow-writes", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Token "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, code, rec.Code, token)
+	}
+}
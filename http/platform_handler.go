@@ -32,6 +32,7 @@ func NewPlatformHandler(b *APIBackend, opts ...APIHandlerOptFn) *PlatformHandler
 	h.RegisterNoAuthRoute("POST", "/api/v2/signout")
 	h.RegisterNoAuthRoute("POST", "/api/v2/setup")
 	h.RegisterNoAuthRoute("GET", "/api/v2/setup")
+	h.RegisterNoAuthRoute("POST", "/api/v2/invites/accept")
 	h.RegisterNoAuthRoute("GET", "/api/v2/swagger.json")
 
 	assetHandler := static.NewAssetHandler(b.AssetsPath)
@@ -40,7 +41,9 @@ func NewPlatformHandler(b *APIBackend, opts ...APIHandlerOptFn) *PlatformHandler
 		assetHandler = http.NotFoundHandler()
 	}
 
-	wrappedHandler := kithttp.SetCORS(h)
+	// Requests are audited before they are authenticated, so that rejected
+	// requests are audited too.
+	wrappedHandler := kithttp.SetCORS(b.AuditLog.Middleware(h))
 	wrappedHandler = kithttp.SkipOptions(wrappedHandler)
 
 	legacyBackend := newLegacyBackend(b)
@@ -50,7 +53,7 @@ func NewPlatformHandler(b *APIBackend, opts ...APIHandlerOptFn) *PlatformHandler
 		AssetHandler:  assetHandler,
 		DocsHandler:   Redoc("/api/v2/swagger.json"),
 		APIHandler:    wrappedHandler,
-		LegacyHandler: legacy.NewInflux1xAuthenticationHandler(lh, b.AuthorizerV1, b.HTTPErrorHandler),
+		LegacyHandler: b.AuditLog.Middleware(legacy.NewInflux1xAuthenticationHandler(b.RateLimiter.Middleware("legacy")(lh), b.AuthorizerV1, b.HTTPErrorHandler)),
 	}
 }
 
@@ -1,14 +1,38 @@
 package points
 
 import (
+	"bytes"
 	"compress/gzip"
+	"fmt"
 	"io"
 
+	"github.com/golang/snappy"
 	io2 "github.com/influxdata/influxdb/v2/kit/io"
+	"github.com/klauspost/compress/zstd"
 )
 
-// BatchReadCloser (potentially) wraps an io.ReadCloser in Gzip
-// decompression and limits the reading to a specific number of bytes.
+// zstdMaxWindowBytes bounds the memory a zstd compressed body may require
+// to be decompressed.
+const zstdMaxWindowBytes = 64 << 20
+
+// DecompressError is the error of a request body that can not be
+// decompressed with its content encoding.
+type DecompressError struct {
+	Encoding string
+	Err      error
+}
+
+func (e *DecompressError) Error() string {
+	return fmt.Sprintf("invalid %s compressed data: %v", e.Encoding, e.Err)
+}
+
+func (e *DecompressError) Unwrap() error {
+	return e.Err
+}
+
+// BatchReadCloser (potentially) wraps an io.ReadCloser in gzip, zstd or
+// snappy decompression and limits the reading to a specific number of bytes.
+// A snappy body is a single block, like the one of a Prometheus remote write.
 func BatchReadCloser(rc io.ReadCloser, encoding string, maxBatchSizeBytes int64) (io.ReadCloser, error) {
 	switch encoding {
 	case "gzip", "x-gzip":
@@ -17,9 +41,81 @@ func BatchReadCloser(rc io.ReadCloser, encoding string, maxBatchSizeBytes int64)
 		if err != nil {
 			return nil, err
 		}
+	case "zstd":
+		dec, err := zstd.NewReader(rc, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(zstdMaxWindowBytes))
+		if err != nil {
+			return nil, &DecompressError{Encoding: encoding, Err: err}
+		}
+		rc = &decompressReadCloser{ReadCloser: dec.IOReadCloser(), encoding: encoding}
+	case "snappy":
+		// the size of a block is only known once it is read in full, so it
+		// is decompressed on the first read
+		return &snappyReadCloser{body: rc, max: maxBatchSizeBytes}, nil
 	}
 	if maxBatchSizeBytes > 0 {
 		rc = io2.NewLimitedReadCloser(rc, maxBatchSizeBytes)
 	}
 	return rc, nil
 }
+
+// decompressReadCloser reports the errors of decompressing as DecompressError.
+type decompressReadCloser struct {
+	io.ReadCloser
+	encoding string
+}
+
+func (r *decompressReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = &DecompressError{Encoding: r.encoding, Err: err}
+	}
+	return n, err
+}
+
+// snappyReadCloser decompresses a snappy block of at most max bytes once
+// decompressed, 0 is unlimited.
+type snappyReadCloser struct {
+	body io.ReadCloser
+	max  int64
+	r    io.Reader
+	err  error
+}
+
+func (r *snappyReadCloser) Read(p []byte) (int, error) {
+	if r.r == nil && r.err == nil {
+		r.r, r.err = r.decode()
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.r.Read(p)
+}
+
+func (r *snappyReadCloser) decode() (io.Reader, error) {
+	body := io.Reader(r.body)
+	if r.max > 0 {
+		// a block is never much larger than the data it compresses
+		body = io.LimitReader(body, int64(snappy.MaxEncodedLen(int(r.max)))+1)
+	}
+	compressed, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return nil, &DecompressError{Encoding: "snappy", Err: err}
+	}
+	if r.max > 0 && int64(n) > r.max {
+		return nil, ErrMaxBatchSizeExceeded
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, &DecompressError{Encoding: "snappy", Err: err}
+	}
+	return bytes.NewReader(data), nil
+}
+
+func (r *snappyReadCloser) Close() error {
+	return r.body.Close()
+}
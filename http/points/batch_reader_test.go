@@ -0,0 +1,93 @@
+package points
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchReadCloser(t *testing.T) {
+	const lines = "m,t=a f=1 1\nm,t=b f=2 2\n"
+
+	zstdBody := func(t *testing.T, data string) []byte {
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		require.NoError(t, err)
+		return enc.EncodeAll([]byte(data), nil)
+	}
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     func(t *testing.T) []byte
+		max      int64
+		want     string
+		wantCode string
+	}{
+		{
+			name:     "zstd",
+			encoding: "zstd",
+			body:     func(t *testing.T) []byte { return zstdBody(t, lines) },
+			want:     lines,
+		},
+		{
+			name:     "snappy",
+			encoding: "snappy",
+			body:     func(t *testing.T) []byte { return snappy.Encode(nil, []byte(lines)) },
+			want:     lines,
+		},
+		{
+			name:     "zstd over the maximum",
+			encoding: "zstd",
+			body:     func(t *testing.T) []byte { return zstdBody(t, lines) },
+			max:      int64(len(lines)) - 1,
+			wantCode: errors.ETooLarge,
+		},
+		{
+			name:     "snappy over the maximum",
+			encoding: "snappy",
+			body:     func(t *testing.T) []byte { return snappy.Encode(nil, []byte(lines)) },
+			max:      int64(len(lines)) - 1,
+			wantCode: errors.ETooLarge,
+		},
+		{
+			name:     "invalid zstd",
+			encoding: "zstd",
+			body:     func(t *testing.T) []byte { return []byte(lines) },
+			wantCode: errors.EInvalid,
+		},
+		{
+			name:     "invalid snappy",
+			encoding: "snappy",
+			body:     func(t *testing.T) []byte { return []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff} },
+			wantCode: errors.EInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, err := BatchReadCloser(io.NopCloser(bytes.NewReader(tt.body(t))), tt.encoding, tt.max)
+			require.NoError(t, err)
+
+			parsed, err := NewParser("ns").Parse(context.Background(), 1, 2, rc)
+			if tt.wantCode != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantCode, errors.ErrorCode(err))
+				return
+			}
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			for _, p := range parsed.Points {
+				buf.WriteString(p.String())
+				buf.WriteByte('\n')
+			}
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
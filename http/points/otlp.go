@@ -0,0 +1,482 @@
+package points
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// OTLPNaming is the convention the OpenTelemetry metrics of an OTLP export
+// request are named by in line protocol.
+//
+// The value of a gauge or a sum is written as a float, whether it was
+// recorded as an integer or a double, so the points of a metric share the
+// type of their field. A histogram is written as its count, its sum, its min
+// and max when recorded, and the cumulative count of every bucket, tagged
+// with the upper bound of the bucket as le. A summary is written as its
+// count, its sum and the value of every quantile, tagged with the quantile as
+// quantile. The attributes of the resource and of the data point are the
+// tags of every point; an le attribute of a histogram or a quantile attribute
+// of a summary is tagged as exported_le or exported_quantile instead, like
+// Prometheus keeps conflicting labels. Data points without a time are written
+// at the time of the request.
+type OTLPNaming string
+
+const (
+	// OTLPNamingMeasurement writes every metric to the measurement of its
+	// name. A gauge or a sum is written to the value field, the values of
+	// a histogram or a summary to the count, sum, min, max, bucket and
+	// quantile fields.
+	OTLPNamingMeasurement OTLPNaming = "measurement"
+
+	// OTLPNamingField writes every metric to the OTLPMeasurement
+	// measurement. A gauge or a sum is written to the field of its name,
+	// the values of a histogram or a summary to the fields of its name
+	// suffixed with _count, _sum, _min, _max, _bucket and _quantile, like
+	// Prometheus names them.
+	OTLPNamingField OTLPNaming = "field"
+)
+
+// OTLPMeasurement is the measurement metrics are written to by OTLPNamingField.
+const OTLPMeasurement = "otel"
+
+// ParseOTLPNaming returns the naming convention of the given name.
+func ParseOTLPNaming(s string) (OTLPNaming, error) {
+	switch n := OTLPNaming(s); n {
+	case OTLPNamingMeasurement, OTLPNamingField:
+		return n, nil
+	default:
+		return "", fmt.Errorf("unsupported OTLP metrics naming %q, expected %q or %q", s, OTLPNamingMeasurement, OTLPNamingField)
+	}
+}
+
+// otlpNoRecordedValue is the data point flag of a point without a value.
+const otlpNoRecordedValue = 1
+
+// ParseOTLPMetrics parses the points of a protobuf encoded OTLP metrics
+// export request, named by the given convention. Data points flagged with no
+// recorded value and exponential histograms, which have no line protocol
+// representation, are dropped.
+func ParseOTLPMetrics(ctx context.Context, rc io.ReadCloser, naming OTLPNaming) (*ParsedPoints, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "write otlp points")
+	defer span.Finish()
+
+	data, err := readMessage(ctx, rc)
+	if err != nil {
+		return nil, err
+	}
+
+	span, _ = tracing.StartSpanFromContextWithOperationName(ctx, "decoding and converting")
+	w := &otlpPointsWriter{naming: naming, now: time.Now().UTC()}
+	err = decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		// ExportMetricsServiceRequest.resource_metrics
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		return w.resourceMetrics(b)
+	})
+	span.LogKV("values_total", len(w.points))
+	span.Finish()
+	if err != nil {
+		tracing.LogError(span, fmt.Errorf("error parsing points: %v", err))
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Op:   opPointsWriter,
+			Msg:  "invalid otlp metrics export request",
+			Err:  err,
+		}
+	}
+
+	return &ParsedPoints{
+		Points:  w.points,
+		RawSize: len(data),
+	}, nil
+}
+
+// otlpPointsWriter converts the metrics of an export request to points.
+type otlpPointsWriter struct {
+	naming OTLPNaming
+	// now is the time of the data points without one.
+	now    time.Time
+	points models.Points
+}
+
+func (w *otlpPointsWriter) resourceMetrics(data []byte) error {
+	// The resource may follow its metrics on the wire, so its attributes
+	// are decoded before any metric.
+	tags := make(map[string]string)
+	var scopes [][]byte
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1: // resource
+			return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) error {
+				if num == 1 && typ == protowire.BytesType {
+					return decodeAttribute(b, tags)
+				}
+				return nil
+			})
+		case 2, 1000: // scope_metrics, instrumentation_library_metrics before OTLP 0.15
+			scopes = append(scopes, b)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, scope := range scopes {
+		err := decodeMessage(scope, func(num protowire.Number, typ protowire.Type, b []byte) error {
+			if num != 2 || typ != protowire.BytesType {
+				return nil
+			}
+			return w.metric(b, tags)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *otlpPointsWriter) metric(data []byte, resource map[string]string) error {
+	var (
+		name   string
+		kind   protowire.Number
+		points []byte
+	)
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			name = string(b)
+		case 5, 7, 9, 11: // gauge, sum, histogram, summary
+			kind, points = num, b
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if points == nil {
+		return nil
+	}
+	if name == "" {
+		return fmt.Errorf("metric is missing its name")
+	}
+
+	return decodeMessage(points, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		// data_points
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		switch kind {
+		case 9:
+			return w.histogramDataPoint(name, b, resource)
+		case 11:
+			return w.summaryDataPoint(name, b, resource)
+		default:
+			return w.numberDataPoint(name, b, resource)
+		}
+	})
+}
+
+func (w *otlpPointsWriter) numberDataPoint(name string, data []byte, resource map[string]string) error {
+	var (
+		tags  = copyTags(resource)
+		ts    uint64
+		value *float64
+		flags uint64
+	)
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		switch {
+		case num == 7 && typ == protowire.BytesType:
+			return decodeAttribute(b, tags)
+		case num == 3 && typ == protowire.Fixed64Type:
+			ts = consumeFixed64(b)
+		case num == 4 && typ == protowire.Fixed64Type:
+			value = consumeDouble(b)
+		case num == 6 && typ == protowire.Fixed64Type:
+			v := float64(int64(consumeFixed64(b)))
+			value = &v
+		case num == 8 && typ == protowire.VarintType:
+			flags = consumeVarint(b)
+		}
+		return nil
+	})
+	if err != nil || value == nil || flags&otlpNoRecordedValue != 0 {
+		return err
+	}
+	return w.add(name, "", tags, *value, ts)
+}
+
+func (w *otlpPointsWriter) histogramDataPoint(name string, data []byte, resource map[string]string) error {
+	var (
+		tags          = copyTags(resource)
+		ts            uint64
+		count         uint64
+		sum, min, max *float64
+		counts        []uint64
+		bounds        []float64
+		flags         uint64
+	)
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		switch {
+		case num == 9 && typ == protowire.BytesType:
+			return decodeAttribute(b, tags)
+		case num == 3 && typ == protowire.Fixed64Type:
+			ts = consumeFixed64(b)
+		case num == 4 && typ == protowire.Fixed64Type:
+			count = consumeFixed64(b)
+		case num == 5 && typ == protowire.Fixed64Type:
+			sum = consumeDouble(b)
+		case num == 11 && typ == protowire.Fixed64Type:
+			min = consumeDouble(b)
+		case num == 12 && typ == protowire.Fixed64Type:
+			max = consumeDouble(b)
+		case num == 6:
+			return decodeRepeatedFixed64(typ, b, func(v uint64) {
+				counts = append(counts, v)
+			})
+		case num == 7:
+			return decodeRepeatedFixed64(typ, b, func(v uint64) {
+				bounds = append(bounds, math.Float64frombits(v))
+			})
+		case num == 10 && typ == protowire.VarintType:
+			flags = consumeVarint(b)
+		}
+		return nil
+	})
+	if err != nil || flags&otlpNoRecordedValue != 0 {
+		return err
+	}
+	if len(counts) > 0 && len(counts) != len(bounds)+1 {
+		return fmt.Errorf("histogram %s has %d bucket counts for %d bounds", name, len(counts), len(bounds))
+	}
+	exportTag(tags, "le")
+
+	if err := w.add(name, "count", tags, float64(count), ts); err != nil {
+		return err
+	}
+	for _, v := range []struct {
+		suffix string
+		value  *float64
+	}{{"sum", sum}, {"min", min}, {"max", max}} {
+		if v.value == nil {
+			continue
+		}
+		if err := w.add(name, v.suffix, tags, *v.value, ts); err != nil {
+			return err
+		}
+	}
+
+	// OTLP counts the values of every bucket, le buckets count the values
+	// of all the buckets up to their bound.
+	var cumulative uint64
+	for i, n := range counts {
+		cumulative += n
+		le := "+Inf"
+		if i < len(bounds) {
+			le = strconv.FormatFloat(bounds[i], 'f', -1, 64)
+		}
+		bucketTags := copyTags(tags)
+		bucketTags["le"] = le
+		if err := w.add(name, "bucket", bucketTags, float64(cumulative), ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *otlpPointsWriter) summaryDataPoint(name string, data []byte, resource map[string]string) error {
+	var (
+		tags      = copyTags(resource)
+		ts        uint64
+		count     uint64
+		sum       float64
+		quantiles [][]byte
+		flags     uint64
+	)
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		switch {
+		case num == 7 && typ == protowire.BytesType:
+			return decodeAttribute(b, tags)
+		case num == 3 && typ == protowire.Fixed64Type:
+			ts = consumeFixed64(b)
+		case num == 4 && typ == protowire.Fixed64Type:
+			count = consumeFixed64(b)
+		case num == 5 && typ == protowire.Fixed64Type:
+			sum = math.Float64frombits(consumeFixed64(b))
+		case num == 6 && typ == protowire.BytesType:
+			quantiles = append(quantiles, b)
+		case num == 8 && typ == protowire.VarintType:
+			flags = consumeVarint(b)
+		}
+		return nil
+	})
+	if err != nil || flags&otlpNoRecordedValue != 0 {
+		return err
+	}
+	exportTag(tags, "quantile")
+
+	if err := w.add(name, "count", tags, float64(count), ts); err != nil {
+		return err
+	}
+	if err := w.add(name, "sum", tags, sum, ts); err != nil {
+		return err
+	}
+	for _, q := range quantiles {
+		var quantile, value float64
+		err := decodeMessage(q, func(num protowire.Number, typ protowire.Type, b []byte) error {
+			if typ != protowire.Fixed64Type {
+				return nil
+			}
+			switch num {
+			case 1:
+				quantile = math.Float64frombits(consumeFixed64(b))
+			case 2:
+				value = math.Float64frombits(consumeFixed64(b))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		quantileTags := copyTags(tags)
+		quantileTags["quantile"] = strconv.FormatFloat(quantile, 'f', -1, 64)
+		if err := w.add(name, "quantile", quantileTags, value, ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add writes a value of a metric, named by the naming convention. An empty
+// suffix is the value of a gauge or a sum.
+func (w *otlpPointsWriter) add(metric, suffix string, tags map[string]string, value float64, ts uint64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+
+	measurement, field := metric, suffix
+	switch w.naming {
+	case OTLPNamingField:
+		measurement, field = OTLPMeasurement, metric
+		if suffix != "" {
+			field += "_" + suffix
+		}
+	default:
+		if field == "" {
+			field = "value"
+		}
+	}
+
+	t := w.now
+	if ts != 0 {
+		t = time.Unix(0, int64(ts))
+	}
+	pt, err := models.NewPoint(measurement, models.NewTags(tags), models.Fields{field: value}, t)
+	if err != nil {
+		return err
+	}
+	w.points = append(w.points, pt)
+	return nil
+}
+
+// exportTag moves the attribute named like a tag the values of a data point
+// are written with to the tag of its name prefixed with exported_.
+func exportTag(tags map[string]string, key string) {
+	if v, ok := tags[key]; ok {
+		delete(tags, key)
+		tags["exported_"+key] = v
+	}
+}
+
+// decodeAttribute adds an attribute to the tags. Attributes with an empty,
+// array, key-value list or bytes value have no tag value and are skipped.
+func decodeAttribute(data []byte, tags map[string]string) error {
+	var key, value string
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			key = string(b)
+		case num == 2 && typ == protowire.BytesType:
+			return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) error {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					value = string(b)
+				case num == 2 && typ == protowire.VarintType:
+					value = strconv.FormatBool(consumeVarint(b) != 0)
+				case num == 3 && typ == protowire.VarintType:
+					value = strconv.FormatInt(int64(consumeVarint(b)), 10)
+				case num == 4 && typ == protowire.Fixed64Type:
+					value = strconv.FormatFloat(math.Float64frombits(consumeFixed64(b)), 'f', -1, 64)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if key != "" && value != "" {
+		tags[key] = value
+	}
+	return nil
+}
+
+// decodeRepeatedFixed64 calls fn with every value of a repeated fixed64 or
+// double field, which is packed by current encoders but may not be.
+func decodeRepeatedFixed64(typ protowire.Type, b []byte, fn func(uint64)) error {
+	switch typ {
+	case protowire.Fixed64Type:
+		fn(consumeFixed64(b))
+	case protowire.BytesType:
+		for len(b) > 0 {
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fn(v)
+			b = b[n:]
+		}
+	}
+	return nil
+}
+
+func consumeFixed64(b []byte) uint64 {
+	v, _ := protowire.ConsumeFixed64(b)
+	return v
+}
+
+func consumeDouble(b []byte) *float64 {
+	v := math.Float64frombits(consumeFixed64(b))
+	return &v
+}
+
+func consumeVarint(b []byte) uint64 {
+	v, _ := protowire.ConsumeVarint(b)
+	return v
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	c := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		c[k] = v
+	}
+	return c
+}
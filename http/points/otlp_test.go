@@ -0,0 +1,266 @@
+package points
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func otlpStringAttribute(key, value string) []byte {
+	var b []byte
+	b = appendMessage(b, 1, []byte(key))
+	return appendMessage(b, 2, appendMessage(nil, 1, []byte(value)))
+}
+
+func otlpIntAttribute(key string, value int64) []byte {
+	var v []byte
+	v = protowire.AppendTag(v, 3, protowire.VarintType)
+	v = protowire.AppendVarint(v, uint64(value))
+
+	var b []byte
+	b = appendMessage(b, 1, []byte(key))
+	return appendMessage(b, 2, v)
+}
+
+func appendFixed64(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, v)
+}
+
+func appendDouble(b []byte, num protowire.Number, v float64) []byte {
+	return appendFixed64(b, num, math.Float64bits(v))
+}
+
+func otlpMetric(name string, kind protowire.Number, points ...[]byte) []byte {
+	var data []byte
+	for _, p := range points {
+		data = appendMessage(data, 1, p)
+	}
+	var b []byte
+	// The data of the metric precedes its name to check that the order of
+	// the fields does not matter.
+	b = appendMessage(b, kind, data)
+	return appendMessage(b, 1, []byte(name))
+}
+
+func otlpRequest(resourceAttributes [][]byte, metrics ...[]byte) []byte {
+	var resource []byte
+	for _, a := range resourceAttributes {
+		resource = appendMessage(resource, 1, a)
+	}
+	var scope []byte
+	scope = appendMessage(scope, 1, appendMessage(nil, 1, []byte("io.opentelemetry.test")))
+	for _, m := range metrics {
+		scope = appendMessage(scope, 2, m)
+	}
+
+	var rm []byte
+	rm = appendMessage(rm, 2, scope)
+	rm = appendMessage(rm, 1, resource)
+	return appendMessage(nil, 1, rm)
+}
+
+func testOTLPRequest() []byte {
+	var gauge []byte
+	gauge = appendMessage(gauge, 7, otlpStringAttribute("cpu", "cpu0"))
+	gauge = appendFixed64(gauge, 3, 1000)
+	gauge = appendDouble(gauge, 4, 0.5)
+
+	var noValue []byte
+	noValue = appendFixed64(noValue, 3, 2000)
+	noValue = protowire.AppendTag(noValue, 8, protowire.VarintType)
+	noValue = protowire.AppendVarint(noValue, otlpNoRecordedValue)
+
+	var sum []byte
+	sum = appendFixed64(sum, 3, 1000)
+	sum = protowire.AppendTag(sum, 6, protowire.Fixed64Type)
+	sum = protowire.AppendFixed64(sum, 42)
+
+	var counts, bounds []byte
+	for _, c := range []uint64{1, 2, 3} {
+		counts = protowire.AppendFixed64(counts, c)
+	}
+	for _, b := range []float64{0.1, 1} {
+		bounds = protowire.AppendFixed64(bounds, math.Float64bits(b))
+	}
+	var histogram []byte
+	histogram = appendFixed64(histogram, 3, 1000)
+	histogram = appendFixed64(histogram, 4, 6)
+	histogram = appendDouble(histogram, 5, 4.2)
+	histogram = appendMessage(histogram, 6, counts)
+	histogram = appendMessage(histogram, 7, bounds)
+
+	var quantile []byte
+	quantile = appendDouble(quantile, 1, 0.99)
+	quantile = appendDouble(quantile, 2, 0.3)
+	var summary []byte
+	summary = appendFixed64(summary, 3, 1000)
+	summary = appendFixed64(summary, 4, 2)
+	summary = appendDouble(summary, 5, 0.4)
+	summary = appendMessage(summary, 6, quantile)
+
+	return otlpRequest(
+		[][]byte{otlpStringAttribute("service.name", "api"), otlpIntAttribute("pid", 42)},
+		otlpMetric("cpu.utilization", 5, gauge, noValue),
+		otlpMetric("requests", 7, sum),
+		otlpMetric("latency", 9, histogram),
+		otlpMetric("gc", 11, summary),
+	)
+}
+
+func TestParseOTLPMetrics(t *testing.T) {
+	for _, tt := range []struct {
+		naming OTLPNaming
+		lines  []string
+	}{
+		{
+			naming: OTLPNamingMeasurement,
+			lines: []string{
+				"cpu.utilization,cpu=cpu0,pid=42,service.name=api value=0.5 1000",
+				"requests,pid=42,service.name=api value=42 1000",
+				"latency,pid=42,service.name=api count=6 1000",
+				"latency,pid=42,service.name=api sum=4.2 1000",
+				"latency,le=0.1,pid=42,service.name=api bucket=1 1000",
+				"latency,le=1,pid=42,service.name=api bucket=3 1000",
+				"latency,le=+Inf,pid=42,service.name=api bucket=6 1000",
+				"gc,pid=42,service.name=api count=2 1000",
+				"gc,pid=42,service.name=api sum=0.4 1000",
+				"gc,pid=42,quantile=0.99,service.name=api quantile=0.3 1000",
+			},
+		},
+		{
+			naming: OTLPNamingField,
+			lines: []string{
+				"otel,cpu=cpu0,pid=42,service.name=api cpu.utilization=0.5 1000",
+				"otel,pid=42,service.name=api requests=42 1000",
+				"otel,pid=42,service.name=api latency_count=6 1000",
+				"otel,pid=42,service.name=api latency_sum=4.2 1000",
+				"otel,le=0.1,pid=42,service.name=api latency_bucket=1 1000",
+				"otel,le=1,pid=42,service.name=api latency_bucket=3 1000",
+				"otel,le=+Inf,pid=42,service.name=api latency_bucket=6 1000",
+				"otel,pid=42,service.name=api gc_count=2 1000",
+				"otel,pid=42,service.name=api gc_sum=0.4 1000",
+				"otel,pid=42,quantile=0.99,service.name=api gc_quantile=0.3 1000",
+			},
+		},
+	} {
+		t.Run(string(tt.naming), func(t *testing.T) {
+			req := testOTLPRequest()
+			parsed, err := ParseOTLPMetrics(context.Background(), ioutil.NopCloser(bytes.NewReader(req)), tt.naming)
+			require.NoError(t, err)
+			assert.Equal(t, len(req), parsed.RawSize)
+
+			var lines []string
+			for _, p := range parsed.Points {
+				lines = append(lines, p.String())
+			}
+			assert.Equal(t, tt.lines, lines)
+		})
+	}
+}
+
+func TestParseOTLPMetrics_Conflicts(t *testing.T) {
+	var gauge []byte
+	gauge = appendDouble(gauge, 4, 0.5)
+
+	var sum []byte
+	sum = appendFixed64(sum, 3, 1000)
+	sum = protowire.AppendTag(sum, 6, protowire.Fixed64Type)
+	sum = protowire.AppendFixed64(sum, 42)
+
+	var histogram []byte
+	histogram = appendMessage(histogram, 9, otlpStringAttribute("le", "attr"))
+	histogram = appendFixed64(histogram, 3, 1000)
+	histogram = appendFixed64(histogram, 4, 1)
+	histogram = appendMessage(histogram, 6, protowire.AppendFixed64(nil, 1))
+
+	var summary []byte
+	summary = appendMessage(summary, 7, otlpStringAttribute("quantile", "attr"))
+	summary = appendFixed64(summary, 3, 1000)
+	summary = appendFixed64(summary, 4, 1)
+	summary = appendDouble(summary, 5, 0.4)
+	summary = appendMessage(summary, 6, appendDouble(appendDouble(nil, 1, 0.5), 2, 0.4))
+
+	req := otlpRequest(nil,
+		otlpMetric("load", 5, gauge),
+		otlpMetric("load", 7, sum),
+		otlpMetric("latency", 9, histogram),
+		otlpMetric("gc", 11, summary),
+	)
+
+	before := time.Now()
+	parsed, err := ParseOTLPMetrics(context.Background(), ioutil.NopCloser(bytes.NewReader(req)), OTLPNamingMeasurement)
+	require.NoError(t, err)
+	require.Len(t, parsed.Points, 7)
+
+	// a data point without a time is written at the time of the request
+	p := parsed.Points[0]
+	fields, err := p.Fields()
+	require.NoError(t, err)
+	assert.Equal(t, models.Fields{"value": 0.5}, fields)
+	assert.False(t, p.Time().Before(before))
+	assert.False(t, p.Time().After(time.Now()))
+
+	var lines []string
+	for _, p := range parsed.Points[1:] {
+		lines = append(lines, p.String())
+	}
+	assert.Equal(t, []string{
+		// an integer sum shares the float field of a double gauge
+		"load value=42 1000",
+		"latency,exported_le=attr count=1 1000",
+		"latency,exported_le=attr,le=+Inf bucket=1 1000",
+		"gc,exported_quantile=attr count=1 1000",
+		"gc,exported_quantile=attr sum=0.4 1000",
+		"gc,exported_quantile=attr,quantile=0.5 quantile=0.4 1000",
+	}, lines)
+}
+
+func TestParseOTLPMetrics_Invalid(t *testing.T) {
+	var histogram []byte
+	histogram = appendFixed64(histogram, 3, 1000)
+	histogram = appendMessage(histogram, 6, protowire.AppendFixed64(nil, 1))
+	histogram = appendMessage(histogram, 7, protowire.AppendFixed64(nil, math.Float64bits(1)))
+
+	for _, tt := range []struct {
+		name string
+		body []byte
+	}{
+		{
+			name: "not protobuf",
+			body: []byte{0xff, 0xff},
+		},
+		{
+			name: "metric without name",
+			body: otlpRequest(nil, appendMessage(nil, 5, appendMessage(nil, 1, appendDouble(nil, 4, 1)))),
+		},
+		{
+			name: "histogram with too few buckets",
+			body: otlpRequest(nil, otlpMetric("latency", 9, histogram)),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseOTLPMetrics(context.Background(), ioutil.NopCloser(bytes.NewReader(tt.body)), OTLPNamingMeasurement)
+			require.Error(t, err)
+			assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+		})
+	}
+}
+
+func TestParseOTLPNaming(t *testing.T) {
+	n, err := ParseOTLPNaming("field")
+	require.NoError(t, err)
+	assert.Equal(t, OTLPNamingField, n)
+
+	_, err = ParseOTLPNaming("prometheus")
+	require.Error(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	io2 "github.com/influxdata/influxdb/v2/kit/io"
@@ -33,12 +34,62 @@ const (
 type ParsedPoints struct {
 	Points  models.Points
 	RawSize int
+
+	// Lines are the lines of the batch the points were parsed from,
+	// starting at 1. They are only known for line protocol.
+	Lines []int
+
+	// Rejected are the lines that could not be parsed, when the parser
+	// accepts partial batches.
+	Rejected []RejectedLine
+}
+
+// RejectedLine is a line of a batch that was not written.
+type RejectedLine struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+
+	// Text is the text of the line, kept for the rejection log.
+	Text string `json:"-"`
+}
+
+// rejectedLinesError is the error of a batch whose lines could not be
+// parsed, carrying the text of these lines.
+type rejectedLinesError struct {
+	lines []string
+	err   error
+}
+
+func (e *rejectedLinesError) Error() string {
+	return e.err.Error()
+}
+
+// RejectedLines returns the text of the lines that failed the batch with
+// err, or nil when they are not known.
+func RejectedLines(err error) []string {
+	for err != nil {
+		var linesErr *rejectedLinesError
+		if errors.As(err, &linesErr) {
+			return linesErr.lines
+		}
+		var pErr *errors2.Error
+		if !errors.As(err, &pErr) {
+			return nil
+		}
+		err = pErr.Err
+	}
+	return nil
 }
 
 // Parser parses batches of Points.
 type Parser struct {
 	Precision string
 	//ParserOptions []models.ParserOption
+
+	// Partial accepts the lines that parse in a batch with lines that do
+	// not, which are reported as rejected. Otherwise a batch with a line
+	// that does not parse fails in full.
+	Partial bool
 }
 
 // Parse parses the points from an io.ReadCloser for a specific Bucket.
@@ -51,11 +102,19 @@ func (pw *Parser) Parse(ctx context.Context, orgID, bucketID platform.ID, rc io.
 func (pw *Parser) parsePoints(ctx context.Context, orgID, bucketID platform.ID, rc io.ReadCloser) (*ParsedPoints, error) {
 	data, err := readAll(ctx, rc)
 	if err != nil {
+		// the request body exceeded the maximum of the endpoint
+		var tooLarge *errors2.Error
+		if errors.As(err, &tooLarge) && tooLarge.Code == errors2.ETooLarge {
+			return nil, tooLarge
+		}
+
 		code := errors2.EInternal
 		if errors.Is(err, ErrMaxBatchSizeExceeded) {
 			code = errors2.ETooLarge
 		} else if errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) {
 			code = errors2.EInvalid
+		} else if errors.As(err, new(*DecompressError)) {
+			code = errors2.EInvalid
 		}
 		return nil, &errors2.Error{
 			Code: code,
@@ -67,10 +126,17 @@ func (pw *Parser) parsePoints(ctx context.Context, orgID, bucketID platform.ID,
 
 	span, _ := tracing.StartSpanFromContextWithOperationName(ctx, "encoding and parsing")
 
-	points, err := models.ParsePointsWithPrecision(data, time.Now().UTC(), pw.Precision)
+	points, lines, failed := models.ParsePointsWithLines(data, time.Now().UTC(), pw.Precision)
 	span.LogKV("values_total", len(points))
 	span.Finish()
-	if err != nil {
+	if len(failed) > 0 && (!pw.Partial || len(points) == 0) {
+		msgs := make([]string, len(failed))
+		lines := make([]string, len(failed))
+		for i, f := range failed {
+			msgs[i] = f.Error()
+			lines[i] = f.Text
+		}
+		err := errors.New(strings.Join(msgs, "\n"))
 		tracing.LogError(span, fmt.Errorf("error parsing points: %v", err))
 
 		code := errors2.EInvalid
@@ -85,13 +151,20 @@ func (pw *Parser) parsePoints(ctx context.Context, orgID, bucketID platform.ID,
 			Code: code,
 			Op:   opPointsWriter,
 			Msg:  "",
-			Err:  err,
+			Err:  &rejectedLinesError{lines: lines, err: err},
 		}
 	}
 
+	var rejected []RejectedLine
+	for _, f := range failed {
+		rejected = append(rejected, RejectedLine{Line: f.Line, Reason: f.Error(), Text: f.Text})
+	}
+
 	return &ParsedPoints{
-		Points:  points,
-		RawSize: len(data),
+		Points:   points,
+		RawSize:  len(data),
+		Lines:    lines,
+		Rejected: rejected,
 	}, nil
 }
 
@@ -0,0 +1,234 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/golang/snappy"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// PrometheusValueField is the field the value of a Prometheus sample is
+// written to. The metric name is the measurement and the other labels are
+// the tags of the point.
+const PrometheusValueField = "value"
+
+const prometheusNameLabel = "__name__"
+
+// promTimeSeries is a series of a Prometheus remote write request.
+type promTimeSeries struct {
+	Labels  []promLabel
+	Samples []promSample
+}
+
+type promLabel struct {
+	Name, Value string
+}
+
+type promSample struct {
+	Value     float64
+	Timestamp int64 // milliseconds since the epoch
+}
+
+// ParsePrometheusRemoteWrite parses the points of a snappy compressed
+// Prometheus remote write request. Samples that are not a number or are
+// infinite, such as the stale markers of Prometheus, have no line protocol
+// value and are dropped. maxBatchSizeBytes limits the size of the request
+// once decompressed, 0 is unlimited.
+func ParsePrometheusRemoteWrite(ctx context.Context, rc io.ReadCloser, maxBatchSizeBytes int64) (*ParsedPoints, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "write prometheus points")
+	defer span.Finish()
+
+	compressed, err := readMessage(ctx, rc)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := snappy.DecodedLen(compressed)
+	if err == nil && maxBatchSizeBytes > 0 && int64(n) > maxBatchSizeBytes {
+		err = ErrMaxBatchSizeExceeded
+	}
+	var data []byte
+	if err == nil {
+		data, err = snappy.Decode(nil, compressed)
+	}
+	if err != nil {
+		code := errors2.EInvalid
+		if errors.Is(err, ErrMaxBatchSizeExceeded) {
+			code = errors2.ETooLarge
+		}
+		return nil, &errors2.Error{
+			Code: code,
+			Op:   opPointsWriter,
+			Msg:  "unable to decompress snappy encoded data",
+			Err:  err,
+		}
+	}
+
+	span, _ = tracing.StartSpanFromContextWithOperationName(ctx, "decoding and converting")
+	points, err := prometheusPoints(data)
+	span.LogKV("values_total", len(points))
+	span.Finish()
+	if err != nil {
+		tracing.LogError(span, fmt.Errorf("error parsing points: %v", err))
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Op:   opPointsWriter,
+			Msg:  "invalid prometheus remote write request",
+			Err:  err,
+		}
+	}
+
+	return &ParsedPoints{
+		Points:  points,
+		RawSize: len(data),
+	}, nil
+}
+
+func prometheusPoints(data []byte) (models.Points, error) {
+	var points models.Points
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		// WriteRequest.timeseries, the metadata of the request is ignored.
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		ts, err := decodeTimeSeries(b)
+		if err != nil {
+			return err
+		}
+
+		var name string
+		tags := make(map[string]string, len(ts.Labels))
+		for _, l := range ts.Labels {
+			switch {
+			case l.Name == prometheusNameLabel:
+				name = l.Value
+			case l.Value != "":
+				// Prometheus treats an empty label as a missing one.
+				tags[l.Name] = l.Value
+			}
+		}
+		if name == "" {
+			return fmt.Errorf("series is missing the %s label", prometheusNameLabel)
+		}
+
+		for _, s := range ts.Samples {
+			if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+				continue
+			}
+			pt, err := models.NewPoint(name, models.NewTags(tags), models.Fields{PrometheusValueField: s.Value}, time.Unix(0, s.Timestamp*int64(time.Millisecond)))
+			if err != nil {
+				return err
+			}
+			points = append(points, pt)
+		}
+		return nil
+	})
+	return points, err
+}
+
+func decodeTimeSeries(data []byte) (promTimeSeries, error) {
+	var ts promTimeSeries
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			var l promLabel
+			err := decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) error {
+				if typ != protowire.BytesType {
+					return nil
+				}
+				switch num {
+				case 1:
+					l.Name = string(b)
+				case 2:
+					l.Value = string(b)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			ts.Labels = append(ts.Labels, l)
+		case num == 2 && typ == protowire.BytesType:
+			var s promSample
+			err := decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) error {
+				switch {
+				case num == 1 && typ == protowire.Fixed64Type:
+					v, _ := protowire.ConsumeFixed64(b)
+					s.Value = math.Float64frombits(v)
+				case num == 2 && typ == protowire.VarintType:
+					v, _ := protowire.ConsumeVarint(b)
+					s.Timestamp = int64(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			ts.Samples = append(ts.Samples, s)
+		}
+		return nil
+	})
+	return ts, err
+}
+
+// readMessage reads the protobuf encoded body of a request.
+func readMessage(ctx context.Context, rc io.ReadCloser) ([]byte, error) {
+	data, err := readAll(ctx, rc)
+	if err != nil {
+		var tooLarge *errors2.Error
+		if errors.As(err, &tooLarge) && tooLarge.Code == errors2.ETooLarge {
+			return nil, tooLarge
+		}
+
+		code := errors2.EInternal
+		if errors.Is(err, ErrMaxBatchSizeExceeded) {
+			code = errors2.ETooLarge
+		} else if errors.As(err, new(*DecompressError)) {
+			code = errors2.EInvalid
+		}
+		return nil, &errors2.Error{
+			Code: code,
+			Op:   opPointsWriter,
+			Msg:  msgUnableToReadData,
+			Err:  err,
+		}
+	}
+	return data, nil
+}
+
+// decodeMessage calls fn with every field of a protobuf message. The value
+// of length delimited fields is given without its length, the value of
+// other fields is given as encoded.
+func decodeMessage(data []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		n = protowire.ConsumeFieldValue(num, typ, data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		value := data[:n]
+		if typ == protowire.BytesType {
+			value, _ = protowire.ConsumeBytes(value)
+		}
+		data = data[n:]
+
+		if err := fn(num, typ, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package points
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func promLabelMessage(name, value string) []byte {
+	var b []byte
+	b = appendMessage(b, 1, []byte(name))
+	return appendMessage(b, 2, []byte(value))
+}
+
+func promSampleMessage(v float64, ts int64) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(v))
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(ts))
+}
+
+func promWriteRequest(series ...promTimeSeries) []byte {
+	var req []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.Labels {
+			ts = appendMessage(ts, 1, promLabelMessage(l.Name, l.Value))
+		}
+		for _, smpl := range s.Samples {
+			ts = appendMessage(ts, 2, promSampleMessage(smpl.Value, smpl.Timestamp))
+		}
+		req = appendMessage(req, 1, ts)
+	}
+	// metadata, which is ignored
+	return appendMessage(req, 3, []byte{})
+}
+
+func TestParsePrometheusRemoteWrite(t *testing.T) {
+	req := promWriteRequest(
+		promTimeSeries{
+			Labels: []promLabel{
+				{Name: "__name__", Value: "http_requests_total"},
+				{Name: "job", Value: "api"},
+				{Name: "instance", Value: ""},
+			},
+			Samples: []promSample{
+				{Value: 10, Timestamp: 1000},
+				{Value: math.NaN(), Timestamp: 2000},
+				{Value: 12.5, Timestamp: 3000},
+			},
+		},
+		promTimeSeries{
+			Labels:  []promLabel{{Name: "__name__", Value: "up"}},
+			Samples: []promSample{{Value: 1, Timestamp: 1000}},
+		},
+	)
+	body := ioutil.NopCloser(bytes.NewReader(snappy.Encode(nil, req)))
+
+	parsed, err := ParsePrometheusRemoteWrite(context.Background(), body, 0)
+	require.NoError(t, err)
+	assert.Equal(t, len(req), parsed.RawSize)
+
+	var lines []string
+	for _, p := range parsed.Points {
+		lines = append(lines, p.String())
+	}
+	assert.Equal(t, []string{
+		"http_requests_total,job=api value=10 1000000000",
+		"http_requests_total,job=api value=12.5 3000000000",
+		"up value=1 1000000000",
+	}, lines)
+}
+
+func TestParsePrometheusRemoteWrite_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		body     []byte
+		maxBytes int64
+		code     string
+	}{
+		{
+			name: "not snappy",
+			body: []byte("cpu value=1"),
+			code: errors.EInvalid,
+		},
+		{
+			name: "not protobuf",
+			body: snappy.Encode(nil, []byte{0xff, 0xff}),
+			code: errors.EInvalid,
+		},
+		{
+			name: "series without name",
+			body: snappy.Encode(nil, promWriteRequest(promTimeSeries{
+				Labels:  []promLabel{{Name: "job", Value: "api"}},
+				Samples: []promSample{{Value: 1, Timestamp: 1000}},
+			})),
+			code: errors.EInvalid,
+		},
+		{
+			name:     "too large once decompressed",
+			body:     snappy.Encode(nil, make([]byte, 1024)),
+			maxBytes: 512,
+			code:     errors.ETooLarge,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePrometheusRemoteWrite(context.Background(), ioutil.NopCloser(bytes.NewReader(tt.body)), tt.maxBytes)
+			require.Error(t, err)
+			assert.Equal(t, tt.code, errors.ErrorCode(err))
+		})
+	}
+}
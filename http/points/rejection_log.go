@@ -0,0 +1,310 @@
+package points
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultRejectionResolution is the width of the time buckets rejected writes are counted in.
+	DefaultRejectionResolution = time.Minute
+	// DefaultRejectionWindow is how long rejected writes are reported for.
+	DefaultRejectionWindow = time.Hour
+	// DefaultRejectionSamples is the number of recent rejections kept per bucket and category.
+	DefaultRejectionSamples = 5
+	// RejectionSampleLines is the number of offending lines kept with a sample.
+	RejectionSampleLines = 3
+
+	// maxRejectionSnippet bounds the size of a sampled error message and of
+	// each of its lines.
+	maxRejectionSnippet = 512
+)
+
+// RejectionCategory classifies why a write was rejected.
+type RejectionCategory string
+
+// RejectionCategory categories.
+const (
+	RejectionCategoryParse     RejectionCategory = "parse"
+	RejectionCategorySchema    RejectionCategory = "schema"
+	RejectionCategoryLimit     RejectionCategory = "limit"
+	RejectionCategoryTimestamp RejectionCategory = "timestamp"
+	RejectionCategoryOther     RejectionCategory = "other"
+)
+
+// CategorizeRejection returns the category of an error returned while
+// parsing or writing points.
+func CategorizeRejection(err error) RejectionCategory {
+	var partialErr tsdb.PartialWriteError
+	if errors.As(err, &partialErr) {
+		reason := partialErr.Reason
+		switch {
+		case strings.Contains(reason, "beyond retention policy"):
+			return RejectionCategoryTimestamp
+		case strings.Contains(reason, "limit exceeded"),
+			strings.Contains(reason, "is too long"):
+			return RejectionCategoryLimit
+		case strings.Contains(reason, tsdb.ErrFieldTypeConflict.Error()),
+			strings.Contains(reason, "invalid field name"),
+			strings.Contains(reason, "invalid tag key"),
+			strings.Contains(reason, "invalid unicode"):
+			return RejectionCategorySchema
+		}
+		return RejectionCategoryOther
+	}
+
+	switch errors2.ErrorCode(err) {
+	case errors2.ETooLarge:
+		return RejectionCategoryLimit
+	case errors2.EInvalid:
+		if strings.Contains(err.Error(), "time outside range") {
+			return RejectionCategoryTimestamp
+		}
+		return RejectionCategoryParse
+	}
+	return RejectionCategoryOther
+}
+
+// Rejection describes a write request that failed in full or in part.
+type Rejection struct {
+	OrgID      platform.ID
+	BucketID   platform.ID
+	Bucket     string
+	UserAgent  string
+	RemoteAddr string
+	Err        error
+	// Lines are the text of the offending lines, when known.
+	Lines []string
+}
+
+// RejectionSample is a recently rejected write, kept to identify the
+// client sending it.
+type RejectionSample struct {
+	Time       time.Time `json:"time"`
+	UserAgent  string    `json:"userAgent,omitempty"`
+	RemoteAddr string    `json:"remoteAddr,omitempty"`
+	Points     int       `json:"droppedPoints,omitempty"`
+	Message    string    `json:"message"`
+	Lines      []string  `json:"lines,omitempty"`
+}
+
+// RejectionCount is the number of rejected writes in a time bucket.
+type RejectionCount struct {
+	Time     time.Time `json:"time"`
+	Requests int       `json:"requests"`
+	Points   int       `json:"droppedPoints"`
+}
+
+// RejectionStats are the rejected writes of a bucket for a single category.
+type RejectionStats struct {
+	OrgID    platform.ID       `json:"orgID"`
+	BucketID platform.ID       `json:"bucketID"`
+	Bucket   string            `json:"bucket"`
+	Category RejectionCategory `json:"category"`
+	Requests int               `json:"requests"`
+	Points   int               `json:"droppedPoints"`
+	Counts   []RejectionCount  `json:"counts"`
+	Samples  []RejectionSample `json:"samples"`
+}
+
+type rejectionKey struct {
+	bucketID platform.ID
+	category RejectionCategory
+}
+
+type rejectionSeries struct {
+	orgID   platform.ID
+	bucket  string
+	counts  []RejectionCount
+	samples []RejectionSample
+}
+
+// RejectionLog counts rejected writes per bucket and category in time
+// buckets, and keeps a sample of the most recent rejections.
+//
+// A nil *RejectionLog is valid and records nothing.
+type RejectionLog struct {
+	resolution time.Duration
+	window     int // number of time buckets
+	samples    int
+	now        func() time.Time
+
+	mu     sync.Mutex
+	series map[rejectionKey]*rejectionSeries
+
+	rejected *prometheus.CounterVec
+}
+
+// NewRejectionLog constructs a RejectionLog reporting rejections over the
+// window in time buckets of the given resolution.
+func NewRejectionLog(resolution, window time.Duration, samples int) *RejectionLog {
+	if resolution <= 0 {
+		resolution = DefaultRejectionResolution
+	}
+	if window < resolution {
+		window = resolution
+	}
+	if samples < 0 {
+		samples = 0
+	}
+
+	return &RejectionLog{
+		resolution: resolution,
+		window:     int(window / resolution),
+		samples:    samples,
+		now:        time.Now,
+		series:     make(map[rejectionKey]*rejectionSeries),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "http",
+			Subsystem: "write",
+			Name:      "rejected_total",
+			Help:      "Number of write requests rejected in full or in part, by category",
+		}, []string{"category"}),
+	}
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (l *RejectionLog) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{l.rejected}
+}
+
+// Observe records the rejection. Writes without an error are ignored.
+func (l *RejectionLog) Observe(r Rejection) {
+	if l == nil || r.Err == nil {
+		return
+	}
+
+	category := CategorizeRejection(r.Err)
+	l.rejected.WithLabelValues(string(category)).Inc()
+
+	var dropped int
+	var partialErr tsdb.PartialWriteError
+	if errors.As(r.Err, &partialErr) {
+		dropped = partialErr.Dropped
+	}
+
+	now := l.now().UTC()
+	start := now.Truncate(l.resolution)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := rejectionKey{bucketID: r.BucketID, category: category}
+	s, ok := l.series[key]
+	if !ok {
+		s = &rejectionSeries{counts: make([]RejectionCount, l.window)}
+		l.series[key] = s
+	}
+	s.orgID, s.bucket = r.OrgID, r.Bucket
+
+	c := &s.counts[int(start.UnixNano()/int64(l.resolution))%l.window]
+	if !c.Time.Equal(start) {
+		*c = RejectionCount{Time: start}
+	}
+	c.Requests++
+	c.Points += dropped
+
+	if l.samples > 0 {
+		if len(s.samples) == l.samples {
+			s.samples = s.samples[1:]
+		}
+		s.samples = append(s.samples, RejectionSample{
+			Time:       now,
+			UserAgent:  r.UserAgent,
+			RemoteAddr: r.RemoteAddr,
+			Points:     dropped,
+			Message:    snippet(r.Err.Error()),
+			Lines:      sampleLines(r.Lines),
+		})
+	}
+}
+
+// Stats returns the rejections of the org's buckets within the window,
+// ordered by bucket and category. Buckets for which include returns false
+// are left out.
+func (l *RejectionLog) Stats(orgID platform.ID, include func(bucketID platform.ID) bool) []RejectionStats {
+	if l == nil {
+		return nil
+	}
+
+	cutoff := l.now().UTC().Truncate(l.resolution).Add(-time.Duration(l.window-1) * l.resolution)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var out []RejectionStats
+	for key, s := range l.series {
+		if s.orgID != orgID || (include != nil && !include(key.bucketID)) {
+			continue
+		}
+		stats := RejectionStats{
+			OrgID:    orgID,
+			BucketID: key.bucketID,
+			Bucket:   s.bucket,
+			Category: key.category,
+			Counts:   []RejectionCount{},
+			Samples:  []RejectionSample{},
+		}
+		for _, c := range s.counts {
+			if c.Requests == 0 || c.Time.Before(cutoff) {
+				continue
+			}
+			stats.Counts = append(stats.Counts, c)
+			stats.Requests += c.Requests
+			stats.Points += c.Points
+		}
+		if stats.Requests == 0 {
+			continue
+		}
+		sort.Slice(stats.Counts, func(i, j int) bool {
+			return stats.Counts[i].Time.Before(stats.Counts[j].Time)
+		})
+		for _, sample := range s.samples {
+			if !sample.Time.Before(cutoff) {
+				stats.Samples = append(stats.Samples, sample)
+			}
+		}
+		out = append(out, stats)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].BucketID != out[j].BucketID {
+			return out[i].BucketID < out[j].BucketID
+		}
+		return out[i].Category < out[j].Category
+	})
+	return out
+}
+
+// sampleLines returns the first lines of a rejection, truncated like
+// messages.
+func sampleLines(lines []string) []string {
+	if len(lines) > RejectionSampleLines {
+		lines = lines[:RejectionSampleLines]
+	}
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		out = append(out, snippet(line))
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// snippet truncates an error message so that a sample of a large rejected
+// line does not grow the log unbounded.
+func snippet(msg string) string {
+	if len(msg) <= maxRejectionSnippet {
+		return msg
+	}
+	return msg[:maxRejectionSnippet] + "..."
+}
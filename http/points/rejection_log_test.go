@@ -0,0 +1,132 @@
+package points
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategorizeRejection(t *testing.T) {
+	tests := []struct {
+		err  error
+		want RejectionCategory
+	}{
+		{
+			err:  &errors2.Error{Code: errors2.EInvalid, Err: fmt.Errorf("unable to parse 'cpu value=': missing field value")},
+			want: RejectionCategoryParse,
+		},
+		{
+			err:  &errors2.Error{Code: errors2.EInvalid, Err: fmt.Errorf("unable to parse 'cpu value=1 99999999999999999999': time outside range")},
+			want: RejectionCategoryTimestamp,
+		},
+		{
+			err:  &errors2.Error{Code: errors2.ETooLarge, Err: ErrMaxBatchSizeExceeded},
+			want: RejectionCategoryLimit,
+		},
+		{
+			err:  tsdb.PartialWriteError{Reason: "points beyond retention policy", Dropped: 2},
+			want: RejectionCategoryTimestamp,
+		},
+		{
+			err:  tsdb.PartialWriteError{Reason: fmt.Sprintf("%s: input field \"v\" on measurement \"cpu\" is type integer, already exists as type float", tsdb.ErrFieldTypeConflict), Dropped: 1},
+			want: RejectionCategorySchema,
+		},
+		{
+			err:  tsdb.PartialWriteError{Reason: "input field \"v\" on measurement \"cpu\" is too long, 70000 > 65536", Dropped: 1},
+			want: RejectionCategoryLimit,
+		},
+		{
+			err:  fmt.Errorf("disk full"),
+			want: RejectionCategoryOther,
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CategorizeRejection(tt.err), tt.err.Error())
+	}
+}
+
+func TestRejectionLog(t *testing.T) {
+	const (
+		orgID      platform.ID = 1
+		otherOrgID platform.ID = 2
+		bucketID   platform.ID = 10
+		hiddenID   platform.ID = 11
+	)
+
+	now := time.Date(2021, 1, 1, 12, 0, 30, 0, time.UTC)
+	l := NewRejectionLog(time.Minute, 5*time.Minute, 2)
+	l.now = func() time.Time { return now }
+
+	parseErr := &errors2.Error{Code: errors2.EInvalid, Err: fmt.Errorf("unable to parse '%s': bad", strings.Repeat("x", 1000))}
+	schemaErr := tsdb.PartialWriteError{Reason: tsdb.ErrFieldTypeConflict.Error(), Dropped: 3}
+
+	l.Observe(Rejection{OrgID: orgID, BucketID: bucketID, Bucket: "b", UserAgent: "telegraf/1.0", Err: parseErr})
+	l.Observe(Rejection{OrgID: orgID, BucketID: bucketID, Bucket: "b"}) // successful write
+	l.Observe(Rejection{OrgID: orgID, BucketID: hiddenID, Bucket: "hidden", Err: parseErr})
+	l.Observe(Rejection{OrgID: otherOrgID, BucketID: 20, Bucket: "other", Err: parseErr})
+
+	now = now.Add(time.Minute)
+	l.Observe(Rejection{OrgID: orgID, BucketID: bucketID, Bucket: "b", UserAgent: "agent-a", Err: parseErr})
+	l.Observe(Rejection{OrgID: orgID, BucketID: bucketID, Bucket: "b", UserAgent: "agent-b", Err: parseErr,
+		Lines: []string{"cpu value=", strings.Repeat("x", 1000), "mem", "disk"}})
+	l.Observe(Rejection{OrgID: orgID, BucketID: bucketID, Bucket: "b", Err: schemaErr})
+
+	stats := l.Stats(orgID, func(id platform.ID) bool { return id != hiddenID })
+	require.Len(t, stats, 2)
+
+	parse := stats[0]
+	assert.Equal(t, RejectionCategoryParse, parse.Category)
+	assert.Equal(t, "b", parse.Bucket)
+	assert.Equal(t, 3, parse.Requests)
+	assert.Equal(t, []RejectionCount{
+		{Time: time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC), Requests: 1},
+		{Time: time.Date(2021, 1, 1, 12, 1, 0, 0, time.UTC), Requests: 2},
+	}, parse.Counts)
+	require.Len(t, parse.Samples, 2, "only the most recent samples are kept")
+	assert.Equal(t, "agent-a", parse.Samples[0].UserAgent)
+	assert.Equal(t, "agent-b", parse.Samples[1].UserAgent)
+	assert.True(t, strings.HasSuffix(parse.Samples[0].Message, "..."))
+	assert.LessOrEqual(t, len(parse.Samples[0].Message), maxRejectionSnippet+3)
+	assert.Equal(t, []string{"cpu value=", strings.Repeat("x", maxRejectionSnippet) + "...", "mem"}, parse.Samples[1].Lines,
+		"the first lines are kept, truncated")
+
+	schema := stats[1]
+	assert.Equal(t, RejectionCategorySchema, schema.Category)
+	assert.Equal(t, 1, schema.Requests)
+	assert.Equal(t, 3, schema.Points)
+
+	// counts age out of the window
+	now = now.Add(5 * time.Minute)
+	assert.Empty(t, l.Stats(orgID, nil))
+
+	var nilLog *RejectionLog
+	nilLog.Observe(Rejection{Err: parseErr})
+	assert.Nil(t, nilLog.Stats(orgID, nil))
+}
+
+func TestRejectedLines(t *testing.T) {
+	const batch = "cpu value=1\ninvalid\nmem value=2"
+
+	_, err := NewParser("ns").Parse(context.Background(), 1, 10, io.NopCloser(strings.NewReader(batch)))
+	require.Error(t, err)
+	assert.Equal(t, []string{"invalid"}, RejectedLines(err))
+	assert.Equal(t, "unable to parse 'invalid': missing fields", err.Error())
+
+	parser := NewParser("ns")
+	parser.Partial = true
+	parsed, err := parser.Parse(context.Background(), 1, 10, io.NopCloser(strings.NewReader(batch)))
+	require.NoError(t, err)
+	require.Len(t, parsed.Rejected, 1)
+	assert.Equal(t, "invalid", parsed.Rejected[0].Text)
+
+	assert.Nil(t, RejectedLines(fmt.Errorf("disk full")))
+}
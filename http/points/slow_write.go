@@ -0,0 +1,247 @@
+package points
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// DefaultSlowWriteSampleLines is the number of lines included
+// in the log entry of a slow write when not configured.
+const DefaultSlowWriteSampleLines = 3
+
+// redactedValue replaces tag and field values in logged line samples.
+const redactedValue = "?"
+
+// quotedText matches the quoted parts of write errors, which hold the
+// lines, series keys and tag values that failed to be written.
+var quotedText = regexp.MustCompile(`'[^']*'|"[^"]*"`)
+
+// SlowWrite describes a completed write request.
+type SlowWrite struct {
+	OrgID       platform.ID
+	BucketID    platform.ID
+	Bucket      string
+	Compression string
+	Bytes       int
+	Points      models.Points
+	Duration    time.Duration
+	Err         error
+}
+
+// SlowWriteLog logs and counts write requests whose latency exceeds a
+// threshold. The threshold and the number of sampled lines may be changed
+// while the server is running; a threshold of zero disables the log.
+//
+// A nil *SlowWriteLog is valid and never logs.
+type SlowWriteLog struct {
+	log *zap.Logger
+
+	threshold   int64 // time.Duration
+	sampleLines int64
+
+	slowWrites   *prometheus.CounterVec
+	slowWriteDur prometheus.Histogram
+}
+
+// NewSlowWriteLog constructs a SlowWriteLog.
+func NewSlowWriteLog(log *zap.Logger, threshold time.Duration, sampleLines int) *SlowWriteLog {
+	const (
+		namespace = "http"
+		subsystem = "write"
+	)
+
+	l := &SlowWriteLog{
+		log: log.With(zap.String("service", "slow_write_log")),
+		slowWrites: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "slow_total",
+			Help:      "Number of write requests that exceeded the slow write threshold",
+		}, []string{"compression", "status"}),
+		slowWriteDur: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "slow_duration_seconds",
+			Help:      "Duration of write requests that exceeded the slow write threshold",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+		}),
+	}
+	l.SetThreshold(threshold)
+	l.SetSampleLines(sampleLines)
+	return l
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (l *SlowWriteLog) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{l.slowWrites, l.slowWriteDur}
+}
+
+// Threshold returns the latency above which writes are logged.
+func (l *SlowWriteLog) Threshold() time.Duration {
+	if l == nil {
+		return 0
+	}
+	return time.Duration(atomic.LoadInt64(&l.threshold))
+}
+
+// SetThreshold sets the latency above which writes are logged.
+// A zero or negative threshold disables the log.
+func (l *SlowWriteLog) SetThreshold(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	atomic.StoreInt64(&l.threshold, int64(d))
+}
+
+// SampleLines returns the number of lines included with each logged write.
+func (l *SlowWriteLog) SampleLines() int {
+	if l == nil {
+		return 0
+	}
+	return int(atomic.LoadInt64(&l.sampleLines))
+}
+
+// SetSampleLines sets the number of lines included with each logged write.
+func (l *SlowWriteLog) SetSampleLines(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt64(&l.sampleLines, int64(n))
+}
+
+// Observe records w if its duration exceeds the configured threshold.
+func (l *SlowWriteLog) Observe(w SlowWrite) {
+	threshold := l.Threshold()
+	if threshold <= 0 || w.Duration < threshold {
+		return
+	}
+
+	compression := w.Compression
+	if compression == "" {
+		compression = "none"
+	}
+	status := "ok"
+	if w.Err != nil {
+		status = "error"
+	}
+	l.slowWrites.WithLabelValues(compression, status).Inc()
+	l.slowWriteDur.Observe(w.Duration.Seconds())
+
+	fields := []zap.Field{
+		zap.Duration("duration", w.Duration),
+		zap.Duration("threshold", threshold),
+		zap.Stringer("org_id", w.OrgID),
+		zap.Stringer("bucket_id", w.BucketID),
+		zap.String("bucket", w.Bucket),
+		zap.String("compression", compression),
+		zap.Int("bytes", w.Bytes),
+		zap.Int("points", len(w.Points)),
+		zap.Strings("sample", redactedSample(w.Points, l.SampleLines())),
+	}
+	if w.Err != nil {
+		fields = append(fields, zap.String("error", redactedError(w.Err)))
+	}
+	l.log.Warn("Slow write", fields...)
+}
+
+// redactedError returns the message of err with its quoted parts replaced,
+// so that the lines and series keys named by write errors are not logged.
+func redactedError(err error) string {
+	return quotedText.ReplaceAllString(err.Error(), redactedValue)
+}
+
+// redactedSample renders the first n points as line protocol with all
+// tag and field values replaced, so that the shape of a batch can be
+// logged without exposing the data written.
+func redactedSample(points models.Points, n int) []string {
+	if n > len(points) {
+		n = len(points)
+	}
+	sample := make([]string, 0, n)
+	for _, p := range points[:n] {
+		var b strings.Builder
+		b.Write(models.EscapeMeasurement(p.Name()))
+		for _, t := range p.Tags() {
+			b.WriteByte(',')
+			b.Write(t.Key)
+			b.WriteString("=" + redactedValue)
+		}
+		b.WriteByte(' ')
+		iter := p.FieldIterator()
+		for i := 0; iter.Next(); i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.Write(iter.FieldKey())
+			b.WriteString("=" + redactedValue)
+		}
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatInt(p.UnixNano(), 10))
+		sample = append(sample, b.String())
+	}
+	return sample
+}
+
+// slowWriteLogSettings is the representation of the SlowWriteLog
+// settings read and updated over HTTP.
+type slowWriteLogSettings struct {
+	Threshold   string `json:"threshold"`
+	SampleLines *int   `json:"sampleLines,omitempty"`
+}
+
+// ServeHTTP reports the current settings on GET and updates them on PUT,
+// allowing the log to be toggled without restarting the server.
+func (l *SlowWriteLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req slowWriteLogSettings
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			kithttp.WriteErrorResponse(ctx, w, errors2.EInvalid, err.Error())
+			return
+		}
+		if req.Threshold != "" {
+			d, err := time.ParseDuration(req.Threshold)
+			if err != nil || d < 0 {
+				kithttp.WriteErrorResponse(ctx, w, errors2.EInvalid, "threshold must be a non-negative duration")
+				return
+			}
+			l.SetThreshold(d)
+		}
+		if req.SampleLines != nil {
+			if *req.SampleLines < 0 {
+				kithttp.WriteErrorResponse(ctx, w, errors2.EInvalid, "sampleLines must not be negative")
+				return
+			}
+			l.SetSampleLines(*req.SampleLines)
+		}
+		l.log.Info("Slow write log settings updated",
+			zap.Duration("threshold", l.Threshold()),
+			zap.Int("sample_lines", l.SampleLines()))
+	default:
+		kithttp.WriteErrorResponse(ctx, w, errors2.EMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	n := l.SampleLines()
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(slowWriteLogSettings{
+		Threshold:   l.Threshold().String(),
+		SampleLines: &n,
+	})
+}
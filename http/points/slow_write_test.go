@@ -0,0 +1,74 @@
+package points
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlowWriteLog_Observe(t *testing.T) {
+	pts, err := models.ParsePointsString("cpu,host=secret-host idle=0.5,usage=99.5 1000\nmem,host=other used=1i 2000\ndisk free=2i 3000")
+	require.NoError(t, err)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := NewSlowWriteLog(zap.New(core), 100*time.Millisecond, 2)
+
+	l.Observe(SlowWrite{Bucket: "b", Points: pts, Duration: 50 * time.Millisecond})
+	require.Equal(t, 0, logs.Len(), "write below threshold should not be logged")
+
+	l.Observe(SlowWrite{Bucket: "b", Compression: "gzip", Bytes: 42, Points: pts, Duration: 150 * time.Millisecond})
+	require.Equal(t, 1, logs.Len())
+
+	entry := logs.All()[0]
+	fields := entry.ContextMap()
+	assert.Equal(t, "Slow write", entry.Message)
+	assert.Equal(t, "gzip", fields["compression"])
+	assert.Equal(t, int64(3), fields["points"])
+	assert.Equal(t, []interface{}{
+		"cpu,host=? idle=?,usage=? 1000",
+		"mem,host=? used=? 2000",
+	}, fields["sample"])
+
+	l.Observe(SlowWrite{
+		Points:   pts,
+		Duration: time.Second,
+		Err:      errors.New(`unable to parse 'cpu,host=secret-host idle=': missing field value; max-values-per-tag limit exceeded: tag="host" value="secret-host"`),
+	})
+	require.Equal(t, 2, logs.Len())
+	assert.Equal(t, "unable to parse ?: missing field value; max-values-per-tag limit exceeded: tag=? value=?", logs.All()[1].ContextMap()["error"])
+
+	l.SetThreshold(0)
+	l.Observe(SlowWrite{Points: pts, Duration: time.Hour})
+	assert.Equal(t, 2, logs.Len(), "disabled log should not record writes")
+
+	var nilLog *SlowWriteLog
+	nilLog.Observe(SlowWrite{Points: pts, Duration: time.Hour})
+}
+
+func TestSlowWriteLog_ServeHTTP(t *testing.T) {
+	l := NewSlowWriteLog(zap.NewNop(), 0, DefaultSlowWriteSampleLines)
+
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"threshold":"250ms","sampleLines":5}`))
+	rec := httptest.NewRecorder()
+	l.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"threshold":"250ms","sampleLines":5}`, rec.Body.String())
+	assert.Equal(t, 250*time.Millisecond, l.Threshold())
+	assert.Equal(t, 5, l.SampleLines())
+
+	req = httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"threshold":"-1s"}`))
+	rec = httptest.NewRecorder()
+	l.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, 250*time.Millisecond, l.Threshold())
+}
@@ -0,0 +1,314 @@
+package points
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultTokenWriteResolution is the width of the time buckets writes are counted in.
+	DefaultTokenWriteResolution = 10 * time.Second
+	// DefaultTokenWriteWindow is how long write statistics are reported for.
+	DefaultTokenWriteWindow = 5 * time.Minute
+	// DefaultTokenWriteMaxTokens is the number of authorizations tracked individually.
+	DefaultTokenWriteMaxTokens = 1000
+
+	// otherTokensLabel is the metric label of the authorizations that are
+	// not tracked individually.
+	otherTokensLabel = "other"
+
+	// metricTokenIdle is how long an authorization keeps its metric labels
+	// without writing. Its metrics are removed afterwards, so the labels of
+	// authorizations that stopped writing do not hold up the limit.
+	metricTokenIdle = time.Hour
+	// metricTokenSweep is how often the idle metric labels are removed.
+	metricTokenSweep = time.Minute
+)
+
+// TokenWrite describes a write request made with an authorization.
+type TokenWrite struct {
+	OrgID           platform.ID
+	AuthorizationID platform.ID
+	// Kind is the kind of the authorizer, e.g. authorization or session.
+	Kind   string
+	Points int
+	Bytes  int
+	Err    error
+}
+
+// TokenWriteCount are the writes made with an authorization in a time bucket.
+type TokenWriteCount struct {
+	Time     time.Time `json:"time"`
+	Requests int       `json:"requests"`
+	Errors   int       `json:"errors"`
+	Points   int       `json:"points"`
+	Bytes    int       `json:"bytes"`
+}
+
+// TokenWriteStats are the writes made with an authorization within the
+// window. PointsPerSecond is averaged over the window, AvgBatchSize is the
+// average number of points per request and ErrorRate the fraction of
+// requests that failed.
+type TokenWriteStats struct {
+	OrgID           platform.ID       `json:"orgID"`
+	AuthorizationID platform.ID       `json:"authorizationID"`
+	Kind            string            `json:"kind"`
+	Requests        int               `json:"requests"`
+	Errors          int               `json:"errors"`
+	Points          int               `json:"points"`
+	Bytes           int               `json:"bytes"`
+	PointsPerSecond float64           `json:"pointsPerSecond"`
+	AvgBatchSize    float64           `json:"avgBatchSize"`
+	ErrorRate       float64           `json:"errorRate"`
+	Counts          []TokenWriteCount `json:"counts"`
+}
+
+type tokenWriteSeries struct {
+	orgID  platform.ID
+	kind   string
+	counts []TokenWriteCount
+	last   time.Time
+}
+
+// TokenWriteLog counts the writes made with each authorization in time
+// buckets, so that clients sending pathological batches, e.g. a single point
+// per request, can be identified.
+//
+// At most maxTokens authorizations are tracked. When the limit is reached,
+// the least recently used authorization is replaced if it has no writes
+// within the window, otherwise the write of the new authorization is not
+// tracked. The same limit bounds the cardinality of the metrics: writes of
+// authorizations beyond it are counted under the "other" label, and the
+// metrics of authorizations idle for metricTokenIdle are removed.
+//
+// A nil *TokenWriteLog is valid and records nothing.
+type TokenWriteLog struct {
+	resolution time.Duration
+	window     int // number of time buckets
+	maxTokens  int
+	now        func() time.Time
+
+	mu           sync.Mutex
+	series       map[platform.ID]*tokenWriteSeries
+	metricTokens map[string]time.Time // last write by label
+	lastSweep    time.Time
+
+	requests  *prometheus.CounterVec
+	points    *prometheus.CounterVec
+	bytes     *prometheus.CounterVec
+	batchSize prometheus.Histogram
+}
+
+// NewTokenWriteLog constructs a TokenWriteLog reporting writes over the
+// window in time buckets of the given resolution.
+func NewTokenWriteLog(resolution, window time.Duration, maxTokens int) *TokenWriteLog {
+	const (
+		namespace = "http"
+		subsystem = "write"
+	)
+
+	if resolution <= 0 {
+		resolution = DefaultTokenWriteResolution
+	}
+	if window < resolution {
+		window = resolution
+	}
+	if maxTokens < 0 {
+		maxTokens = 0
+	}
+
+	return &TokenWriteLog{
+		resolution:   resolution,
+		window:       int(window / resolution),
+		maxTokens:    maxTokens,
+		now:          time.Now,
+		series:       make(map[platform.ID]*tokenWriteSeries),
+		metricTokens: make(map[string]time.Time),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "token_requests_total",
+			Help:      "Number of write requests by authorization and status",
+		}, []string{"authorization_id", "status"}),
+		points: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "token_points_total",
+			Help:      "Number of points written by authorization",
+		}, []string{"authorization_id"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "token_bytes_total",
+			Help:      "Number of line protocol bytes written by authorization",
+		}, []string{"authorization_id"}),
+		batchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "batch_points",
+			Help:      "Number of points per write request",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 9),
+		}),
+	}
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (l *TokenWriteLog) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{l.requests, l.points, l.bytes, l.batchSize}
+}
+
+// Observe records the write.
+func (l *TokenWriteLog) Observe(w TokenWrite) {
+	if l == nil {
+		return
+	}
+
+	now := l.now().UTC()
+	start := now.Truncate(l.resolution)
+	status := "success"
+	if w.Err != nil {
+		status = "error"
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.expireMetricTokens(now)
+	label := l.metricLabel(w.AuthorizationID, now)
+	l.requests.WithLabelValues(label, status).Inc()
+	l.points.WithLabelValues(label).Add(float64(w.Points))
+	l.bytes.WithLabelValues(label).Add(float64(w.Bytes))
+	if w.Err == nil {
+		l.batchSize.Observe(float64(w.Points))
+	}
+
+	s, ok := l.series[w.AuthorizationID]
+	if !ok {
+		if s = l.newSeries(now); s == nil {
+			return
+		}
+		l.series[w.AuthorizationID] = s
+	}
+	s.orgID, s.kind, s.last = w.OrgID, w.Kind, now
+
+	c := &s.counts[int(start.UnixNano()/int64(l.resolution))%l.window]
+	if !c.Time.Equal(start) {
+		*c = TokenWriteCount{Time: start}
+	}
+	c.Requests++
+	c.Points += w.Points
+	c.Bytes += w.Bytes
+	if w.Err != nil {
+		c.Errors++
+	}
+}
+
+// metricLabel returns the label of the authorization, or the label of the
+// authorizations not tracked individually once the limit is reached.
+func (l *TokenWriteLog) metricLabel(id platform.ID, now time.Time) string {
+	label := id.String()
+	if _, ok := l.metricTokens[label]; !ok && len(l.metricTokens) >= l.maxTokens {
+		return otherTokensLabel
+	}
+	l.metricTokens[label] = now
+	return label
+}
+
+// expireMetricTokens removes the metrics of the authorizations that did not
+// write for metricTokenIdle. It runs at most once every metricTokenSweep.
+func (l *TokenWriteLog) expireMetricTokens(now time.Time) {
+	if now.Sub(l.lastSweep) < metricTokenSweep {
+		return
+	}
+	l.lastSweep = now
+
+	for label, last := range l.metricTokens {
+		if now.Sub(last) < metricTokenIdle {
+			continue
+		}
+		delete(l.metricTokens, label)
+		l.requests.DeleteLabelValues(label, "success")
+		l.requests.DeleteLabelValues(label, "error")
+		l.points.DeleteLabelValues(label)
+		l.bytes.DeleteLabelValues(label)
+	}
+}
+
+// newSeries returns a new series, evicting the least recently written
+// series when the limit is reached. It returns nil if no series has been
+// idle for the whole window.
+func (l *TokenWriteLog) newSeries(now time.Time) *tokenWriteSeries {
+	if len(l.series) >= l.maxTokens {
+		var (
+			oldestID platform.ID
+			oldest   *tokenWriteSeries
+		)
+		for id, s := range l.series {
+			if oldest == nil || s.last.Before(oldest.last) {
+				oldestID, oldest = id, s
+			}
+		}
+		if oldest == nil || now.Sub(oldest.last) < time.Duration(l.window)*l.resolution {
+			return nil
+		}
+		delete(l.series, oldestID)
+	}
+	return &tokenWriteSeries{counts: make([]TokenWriteCount, l.window)}
+}
+
+// Stats returns the writes made with the authorizations of the org within
+// the window, ordered by authorization. Authorizations for which include
+// returns false are left out.
+func (l *TokenWriteLog) Stats(orgID platform.ID, include func(authorizationID platform.ID) bool) []TokenWriteStats {
+	if l == nil {
+		return nil
+	}
+
+	cutoff := l.now().UTC().Truncate(l.resolution).Add(-time.Duration(l.window-1) * l.resolution)
+	windowSeconds := (time.Duration(l.window) * l.resolution).Seconds()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var out []TokenWriteStats
+	for id, s := range l.series {
+		if s.orgID != orgID || (include != nil && !include(id)) {
+			continue
+		}
+		stats := TokenWriteStats{
+			OrgID:           orgID,
+			AuthorizationID: id,
+			Kind:            s.kind,
+			Counts:          []TokenWriteCount{},
+		}
+		for _, c := range s.counts {
+			if c.Requests == 0 || c.Time.Before(cutoff) {
+				continue
+			}
+			stats.Counts = append(stats.Counts, c)
+			stats.Requests += c.Requests
+			stats.Errors += c.Errors
+			stats.Points += c.Points
+			stats.Bytes += c.Bytes
+		}
+		if stats.Requests == 0 {
+			continue
+		}
+		sort.Slice(stats.Counts, func(i, j int) bool {
+			return stats.Counts[i].Time.Before(stats.Counts[j].Time)
+		})
+		stats.PointsPerSecond = float64(stats.Points) / windowSeconds
+		stats.AvgBatchSize = float64(stats.Points) / float64(stats.Requests)
+		stats.ErrorRate = float64(stats.Errors) / float64(stats.Requests)
+		out = append(out, stats)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].AuthorizationID < out[j].AuthorizationID
+	})
+	return out
+}
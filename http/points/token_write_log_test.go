@@ -0,0 +1,83 @@
+package points
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenWriteLog(t *testing.T) {
+	const (
+		orgID      platform.ID = 1
+		otherOrgID platform.ID = 2
+		tokenID    platform.ID = 10
+		hiddenID   platform.ID = 11
+	)
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := NewTokenWriteLog(10*time.Second, time.Minute, 3)
+	l.now = func() time.Time { return now }
+
+	// single point batches
+	for i := 0; i < 4; i++ {
+		l.Observe(TokenWrite{OrgID: orgID, AuthorizationID: tokenID, Kind: "authorization", Points: 1, Bytes: 20})
+	}
+	l.Observe(TokenWrite{OrgID: orgID, AuthorizationID: tokenID, Kind: "authorization", Points: 2, Bytes: 40, Err: errors.New("partial write")})
+	l.Observe(TokenWrite{OrgID: orgID, AuthorizationID: hiddenID, Kind: "authorization", Points: 5000, Bytes: 100000})
+	l.Observe(TokenWrite{OrgID: otherOrgID, AuthorizationID: 20, Kind: "authorization", Points: 1, Bytes: 20})
+
+	now = now.Add(10 * time.Second)
+	l.Observe(TokenWrite{OrgID: orgID, AuthorizationID: tokenID, Kind: "authorization", Points: 6, Bytes: 120})
+
+	stats := l.Stats(orgID, func(id platform.ID) bool { return id != hiddenID })
+	require.Len(t, stats, 1)
+	s := stats[0]
+	assert.Equal(t, tokenID, s.AuthorizationID)
+	assert.Equal(t, "authorization", s.Kind)
+	assert.Equal(t, 6, s.Requests)
+	assert.Equal(t, 1, s.Errors)
+	assert.Equal(t, 12, s.Points)
+	assert.Equal(t, 240, s.Bytes)
+	assert.Equal(t, 2.0, s.AvgBatchSize)
+	assert.InDelta(t, 1.0/6, s.ErrorRate, 1e-9)
+	assert.Equal(t, 0.2, s.PointsPerSecond)
+	require.Len(t, s.Counts, 2)
+	assert.Equal(t, 5, s.Counts[0].Requests)
+	assert.Equal(t, 1, s.Counts[1].Requests)
+
+	t.Run("tokens beyond the limit are labeled other", func(t *testing.T) {
+		l.Observe(TokenWrite{OrgID: orgID, AuthorizationID: 30, Points: 1})
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(l.points.WithLabelValues(otherTokensLabel)))
+		assert.Empty(t, l.Stats(orgID, func(id platform.ID) bool { return id == 30 }), "no tracked token is idle")
+	})
+
+	t.Run("idle tokens are replaced", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		l.Observe(TokenWrite{OrgID: orgID, AuthorizationID: 30, Points: 1})
+
+		stats := l.Stats(orgID, nil)
+		require.Len(t, stats, 1)
+		assert.Equal(t, platform.ID(30), stats[0].AuthorizationID)
+	})
+
+	t.Run("metrics of idle tokens are removed", func(t *testing.T) {
+		now = now.Add(metricTokenIdle)
+		l.Observe(TokenWrite{OrgID: orgID, AuthorizationID: 30, Points: 1})
+
+		// the labels of the idle tokens made room for token 30
+		assert.Equal(t, 1.0, testutil.ToFloat64(l.points.WithLabelValues(platform.ID(30).String())))
+		assert.Equal(t, 2, testutil.CollectAndCount(l.points), "only token 30 and other are left")
+	})
+}
+
+func TestTokenWriteLog_Nil(t *testing.T) {
+	var l *TokenWriteLog
+	l.Observe(TokenWrite{OrgID: 1, AuthorizationID: 1, Points: 1})
+	assert.Nil(t, l.Stats(1, nil))
+}
@@ -9,6 +9,8 @@ import (
 	"io/ioutil"
 	"mime"
 	"net/http"
+	"regexp"
+	"sort"
 	"time"
 	"unicode/utf8"
 
@@ -33,6 +35,12 @@ type QueryRequest struct {
 	Dialect QueryDialect    `json:"dialect"`
 	Now     time.Time       `json:"now"`
 
+	// Params are bound to the params option of the query as values of
+	// their JSON type, so that they are never parsed as Flux. For example
+	// {"params": {"bucket": "telegraf"}} is read by the query as
+	// params.bucket.
+	Params map[string]interface{} `json:"params,omitempty"`
+
 	Org *influxdb.Organization `json:"-"`
 
 	// PreferNoContent specifies if the Response to this request should
@@ -117,6 +125,10 @@ func (r QueryRequest) Validate() error {
 		return fmt.Errorf(`unknown dialect date time format: %s`, r.Dialect.DateTimeFormat)
 	}
 
+	if _, err := paramsExpression(r.Params); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -183,6 +195,11 @@ func (r QueryRequest) proxyRequest(now func() time.Time) (*query.ProxyRequest, e
 		n = now()
 	}
 
+	extern, err := r.externWithParams()
+	if err != nil {
+		return nil, err
+	}
+
 	// Query is preferred over AST
 	var compiler flux.Compiler
 	if r.Query != "" {
@@ -192,13 +209,13 @@ func (r QueryRequest) proxyRequest(now func() time.Time) (*query.ProxyRequest, e
 		default:
 			compiler = lang.FluxCompiler{
 				Now:    n,
-				Extern: r.Extern,
+				Extern: extern,
 				Query:  r.Query,
 			}
 		}
 	} else if len(r.AST) > 0 {
 		c := lang.ASTCompiler{
-			Extern: r.Extern,
+			Extern: extern,
 			AST:    r.AST,
 			Now:    n,
 		}
@@ -243,6 +260,118 @@ func (r QueryRequest) proxyRequest(now func() time.Time) (*query.ProxyRequest, e
 	}, nil
 }
 
+// externWithParams returns the extern of the request with the params
+// option appended, so that it is set after the options of the extern.
+func (r QueryRequest) externWithParams() (json.RawMessage, error) {
+	if len(r.Params) == 0 {
+		return r.Extern, nil
+	}
+	params, err := paramsExpression(r.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &ast.File{}
+	if len(r.Extern) > 0 {
+		node, err := ast.UnmarshalNode(r.Extern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode extern: %w", err)
+		}
+		switch n := node.(type) {
+		case *ast.File:
+			file = n
+		case *ast.Package:
+			for _, f := range n.Files {
+				file.Imports = append(file.Imports, f.Imports...)
+				file.Body = append(file.Body, f.Body...)
+			}
+		default:
+			return nil, fmt.Errorf("extern must be a file or a package, got %s", node.Type())
+		}
+	}
+	file.Body = append(file.Body, &ast.OptionStatement{
+		Assignment: &ast.VariableAssignment{
+			ID:   &ast.Identifier{Name: paramsOption},
+			Init: params,
+		},
+	})
+	return json.Marshal(file)
+}
+
+// paramsOption is the option the params of a request are bound to.
+const paramsOption = "params"
+
+var paramKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// paramsExpression returns the params as a Flux record expression. Numbers
+// without a fraction or exponent are integers, and nested objects and
+// arrays are records and arrays. Keys must be identifiers, so that they are
+// read as members of the record.
+func paramsExpression(params map[string]interface{}) (ast.Expression, error) {
+	return paramExpression(params)
+}
+
+func paramExpression(v interface{}) (ast.Expression, error) {
+	switch v := v.(type) {
+	case string:
+		return &ast.StringLiteral{Value: v}, nil
+	case bool:
+		return &ast.BooleanLiteral{Value: v}, nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return &ast.IntegerLiteral{Value: i}, nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid param number %s: %w", v, err)
+		}
+		return &ast.FloatLiteral{Value: f}, nil
+	case int:
+		return &ast.IntegerLiteral{Value: int64(v)}, nil
+	case int64:
+		return &ast.IntegerLiteral{Value: v}, nil
+	case float64:
+		return &ast.FloatLiteral{Value: v}, nil
+	case []interface{}:
+		arr := &ast.ArrayExpression{Elements: make([]ast.Expression, 0, len(v))}
+		for _, e := range v {
+			expr, err := paramExpression(e)
+			if err != nil {
+				return nil, err
+			}
+			arr.Elements = append(arr.Elements, expr)
+		}
+		return arr, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			if !paramKey.MatchString(k) {
+				return nil, fmt.Errorf("invalid param key %q: keys may only contain letters, digits and underscores", k)
+			}
+			keys = append(keys, k)
+		}
+		// Sorted, so that the same params give the same extern.
+		sort.Strings(keys)
+
+		obj := &ast.ObjectExpression{Properties: make([]*ast.Property, 0, len(keys))}
+		for _, k := range keys {
+			expr, err := paramExpression(v[k])
+			if err != nil {
+				return nil, err
+			}
+			obj.Properties = append(obj.Properties, &ast.Property{
+				Key:   &ast.Identifier{Name: k},
+				Value: expr,
+			})
+		}
+		return obj, nil
+	case nil:
+		return nil, errors.New("params can't be null, Flux has no null literal")
+	default:
+		return nil, fmt.Errorf("unsupported param type %T", v)
+	}
+}
+
 // QueryRequestFromProxyRequest converts a query.ProxyRequest into a QueryRequest.
 // The ProxyRequest must contain supported compilers and dialects otherwise an error occurs.
 func QueryRequestFromProxyRequest(req *query.ProxyRequest) (*QueryRequest, error) {
@@ -302,7 +431,10 @@ func decodeQueryRequest(ctx context.Context, r *http.Request, svc influxdb.Organ
 	case "application/json":
 		fallthrough
 	default:
-		if err := json.NewDecoder(body).Decode(&req); err != nil {
+		// Numbers are decoded as such, so that integer params stay integers.
+		dec := json.NewDecoder(body)
+		dec.UseNumber()
+		if err := dec.Decode(&req); err != nil {
 			return nil, body.bytesRead,
 				fmt.Errorf("failed parsing request body as JSON; if sending a raw Flux script, set 'Content-Type: %s' in your request headers: %w", fluxContentType, err)
 		}
@@ -354,6 +486,8 @@ func decodeProxyQueryRequest(ctx context.Context, r *http.Request, auth influxdb
 		token = a.EphemeralAuth(req.Org.ID)
 	case *jsonweb.Token:
 		token = a.EphemeralAuth(req.Org.ID)
+	case *influxdb.Impersonation:
+		token = a.EphemeralAuth(req.Org.ID)
 	default:
 		return pr, n, influxdb.ErrAuthorizerNotSupported
 	}
@@ -9,9 +9,9 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
-	"github.com/NYTimes/gziphandler"
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/csv"
@@ -19,6 +19,7 @@ import (
 	"github.com/influxdata/flux/lang"
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
 	pcontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/http/metric"
 	"github.com/influxdata/influxdb/v2/kit/check"
@@ -30,6 +31,7 @@ import (
 	"github.com/influxdata/influxdb/v2/logger"
 	"github.com/influxdata/influxdb/v2/query"
 	"github.com/influxdata/influxdb/v2/query/fluxlang"
+	"github.com/influxdata/influxdb/v2/query/utils"
 	"github.com/pkg/errors"
 	prom "github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
@@ -38,6 +40,14 @@ import (
 const (
 	prefixQuery   = "/api/v2/query"
 	traceIDHeader = "Trace-Id"
+
+	// RetentionWarningHeader is set, once per bucket, when a query reads a
+	// time range starting before the data retained by the bucket.
+	RetentionWarningHeader = "Influx-Retention-Warning"
+
+	// QueryIDHeader is the ID of a query, which cancels it with
+	// DELETE /api/v2/query/{id} while it runs.
+	QueryIDHeader = "Influx-Query-Id"
 )
 
 // FluxBackend is all services and associated parameters required to construct
@@ -46,11 +56,13 @@ type FluxBackend struct {
 	errors2.HTTPErrorHandler
 	log                *zap.Logger
 	FluxLogEnabled     bool
+	FluxUtilsDisabled  bool
 	QueryEventRecorder metric.EventRecorder
 
 	AlgoWProxy          FeatureProxyHandler
 	OrganizationService influxdb.OrganizationService
 	ProxyQueryService   query.ProxyQueryService
+	ActiveQueryService  query.ActiveQueryService
 	FluxLanguageService fluxlang.FluxLanguageService
 	Flagger             feature.Flagger
 }
@@ -61,9 +73,11 @@ func NewFluxBackend(log *zap.Logger, b *APIBackend) *FluxBackend {
 		HTTPErrorHandler:    b.HTTPErrorHandler,
 		log:                 log,
 		FluxLogEnabled:      b.FluxLogEnabled,
+		FluxUtilsDisabled:   b.FluxUtilsDisabled,
 		QueryEventRecorder:  b.QueryEventRecorder,
 		AlgoWProxy:          b.AlgoWProxy,
 		ProxyQueryService:   b.FluxService,
+		ActiveQueryService:  b.ActiveQueryService,
 		OrganizationService: b.OrganizationService,
 		FluxLanguageService: b.FluxLanguageService,
 		Flagger:             b.Flagger,
@@ -79,12 +93,14 @@ type HTTPDialect interface {
 type FluxHandler struct {
 	*httprouter.Router
 	errors2.HTTPErrorHandler
-	log            *zap.Logger
-	FluxLogEnabled bool
+	log               *zap.Logger
+	FluxLogEnabled    bool
+	FluxUtilsDisabled bool
 
 	Now                 func() time.Time
 	OrganizationService influxdb.OrganizationService
 	ProxyQueryService   query.ProxyQueryService
+	ActiveQueryService  query.ActiveQueryService
 	FluxLanguageService fluxlang.FluxLanguageService
 
 	EventRecorder metric.EventRecorder
@@ -100,26 +116,33 @@ func (*FluxHandler) Prefix() string {
 // NewFluxHandler returns a new handler at /api/v2/query for flux queries.
 func NewFluxHandler(log *zap.Logger, b *FluxBackend) *FluxHandler {
 	h := &FluxHandler{
-		Router:           NewRouter(b.HTTPErrorHandler),
-		Now:              time.Now,
-		HTTPErrorHandler: b.HTTPErrorHandler,
-		log:              log,
-		FluxLogEnabled:   b.FluxLogEnabled,
+		Router:            NewRouter(b.HTTPErrorHandler),
+		Now:               time.Now,
+		HTTPErrorHandler:  b.HTTPErrorHandler,
+		log:               log,
+		FluxLogEnabled:    b.FluxLogEnabled,
+		FluxUtilsDisabled: b.FluxUtilsDisabled,
 
 		ProxyQueryService:   b.ProxyQueryService,
+		ActiveQueryService:  b.ActiveQueryService,
 		OrganizationService: b.OrganizationService,
 		EventRecorder:       b.QueryEventRecorder,
 		FluxLanguageService: b.FluxLanguageService,
 		Flagger:             b.Flagger,
 	}
 
-	// query reponses can optionally be gzip encoded
-	qh := gziphandler.GzipHandler(http.HandlerFunc(h.handleQuery))
+	// query responses are compressed when the client accepts zstd, brotli or gzip
+	qh := kithttp.Compress(http.HandlerFunc(h.handleQuery))
 	h.Handler("POST", prefixQuery, withFeatureProxy(b.AlgoWProxy, qh))
 	h.Handler("POST", "/api/v2/query/ast", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.postFluxAST)))
 	h.Handler("POST", "/api/v2/query/analyze", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.postQueryAnalyze)))
 	h.Handler("GET", "/api/v2/query/suggestions", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.getFluxSuggestions)))
 	h.Handler("GET", "/api/v2/query/suggestions/:name", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.getFluxSuggestion)))
+	h.Handler("GET", "/api/v2/query/utils", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.getFluxUtils)))
+	if h.ActiveQueryService != nil {
+		h.HandlerFunc("GET", "/api/v2/query/active", h.getActiveQueries)
+		h.HandlerFunc("DELETE", "/api/v2/query/:id", h.deleteQuery)
+	}
 	return h
 }
 
@@ -163,6 +186,11 @@ func (h *FluxHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 	}
 
 	req, n, err := decodeProxyQueryRequest(ctx, r, a, h.OrganizationService)
+	if tooLarge, ok := kithttp.BodyTooLargeError(err); ok {
+		requestBytes = n
+		h.HandleHTTPError(ctx, tooLarge, w)
+		return
+	}
 	if err != nil && err != influxdb.ErrAuthorizerNotSupported {
 		err := &errors2.Error{
 			Code: errors2.EInvalid,
@@ -195,7 +223,12 @@ func (h *FluxHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 	}
 	hd.SetHeaders(w)
 
-	cw := iocounter.Writer{Writer: w}
+	ctx, retentionWarnings := query.ContextWithRetentionWarnings(ctx)
+	ctx, queryID := query.ContextWithQueryIDRecorder(ctx)
+	cw := iocounter.Writer{Writer: &retentionWarningWriter{
+		ResponseWriter: &queryIDWriter{ResponseWriter: w, id: queryID},
+		warnings:       retentionWarnings,
+	}}
 	stats, err := h.ProxyQueryService.Query(ctx, &cw, req)
 	if err != nil {
 		if cw.Count() == 0 {
@@ -217,6 +250,32 @@ func (h *FluxHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// retentionWarningWriter sets the retention warnings collected so far as
+// headers before the response is first written. Reads of the sources of a
+// query start before their results are written, so the warnings are
+// complete unless results of one source are written before another source
+// is read.
+type retentionWarningWriter struct {
+	http.ResponseWriter
+	warnings *query.RetentionWarnings
+	wrote    bool
+}
+
+func (w *retentionWarningWriter) Write(b []byte) (int, error) {
+	if !w.wrote {
+		w.wrote = true
+		for _, warning := range w.warnings.Warnings() {
+			w.Header().Add(RetentionWarningHeader, fmt.Sprintf(
+				"bucket %s: requested range starts at %s, but data is only retained since %s",
+				warning.BucketID,
+				warning.RequestedStart.UTC().Format(time.RFC3339),
+				warning.AvailableStart.UTC().Format(time.RFC3339),
+			))
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
 func (h *FluxHandler) logFluxQuery(n int64, stats flux.Statistics, compiler flux.Compiler, err error) {
 	var q string
 	c, ok := compiler.(lang.FluxCompiler)
@@ -242,6 +301,94 @@ type langRequest struct {
 	Query string `json:"query"`
 }
 
+// queryIDWriter sets the ID of the query as a header before the response is
+// first written, so that clients can cancel the query while it streams.
+type queryIDWriter struct {
+	http.ResponseWriter
+	id    *query.QueryIDRecorder
+	wrote bool
+}
+
+func (w *queryIDWriter) Write(b []byte) (int, error) {
+	if !w.wrote {
+		w.wrote = true
+		if id, ok := w.id.ID(); ok {
+			w.Header().Set(QueryIDHeader, strconv.FormatUint(id, 10))
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+type activeQueriesResponse struct {
+	Queries []query.ActiveQuery `json:"queries"`
+}
+
+// getActiveQueries lists the queries in flight of the organizations the
+// request may read.
+func (h *FluxHandler) getActiveQueries(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "FluxHandler")
+	defer span.Finish()
+
+	ctx := r.Context()
+	res := activeQueriesResponse{Queries: []query.ActiveQuery{}}
+	for _, q := range h.ActiveQueryService.ActiveQueries() {
+		if _, _, err := authorizer.AuthorizeReadOrg(ctx, q.OrganizationID); err != nil {
+			continue
+		}
+		res.Queries = append(res.Queries, q)
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, res); err != nil {
+		logEncodingError(h.log, r, err)
+		return
+	}
+}
+
+// deleteQuery cancels a query in flight. Canceling a query requires write
+// access to its organization.
+func (h *FluxHandler) deleteQuery(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "FluxHandler")
+	defer span.Finish()
+
+	ctx := r.Context()
+	param := httprouter.ParamsFromContext(ctx).ByName("id")
+	id, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		h.HandleHTTPError(ctx, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  fmt.Sprintf("invalid query ID %q", param),
+			Err:  err,
+		}, w)
+		return
+	}
+
+	notFound := &errors2.Error{
+		Code: errors2.ENotFound,
+		Msg:  fmt.Sprintf("query %d is not running", id),
+	}
+	var found *query.ActiveQuery
+	for _, q := range h.ActiveQueryService.ActiveQueries() {
+		if q.ID == id {
+			found = &q
+			break
+		}
+	}
+	if found == nil {
+		h.HandleHTTPError(ctx, notFound, w)
+		return
+	}
+	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, found.OrganizationID); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if !h.ActiveQueryService.CancelQuery(id) {
+		h.HandleHTTPError(ctx, notFound, w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 type postFluxASTResponse struct {
 	AST *ast.Package `json:"ast"`
 }
@@ -386,6 +533,38 @@ func (h *FluxHandler) getFluxSuggestion(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// utilsResponse describes the utils library of common query patterns.
+type utilsResponse struct {
+	Name      string           `json:"name"`
+	Version   string           `json:"version"`
+	Functions []utils.Function `json:"funcs"`
+}
+
+// getFluxUtils returns the functions of the utils library for the Flux Builder
+func (h *FluxHandler) getFluxUtils(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "FluxHandler")
+	defer span.Finish()
+
+	ctx := r.Context()
+	if h.FluxUtilsDisabled {
+		h.HandleHTTPError(ctx, &errors2.Error{
+			Code: errors2.ENotFound,
+			Msg:  "the utils library is disabled",
+		}, w)
+		return
+	}
+
+	res := utilsResponse{
+		Name:      utils.Name,
+		Version:   utils.Version,
+		Functions: utils.Functions(),
+	}
+	if err := encodeResponse(ctx, w, http.StatusOK, res); err != nil {
+		logEncodingError(h.log, r, err)
+		return
+	}
+}
+
 // PrometheusCollectors satisifies the prom.PrometheusCollector interface.
 func (h *FluxHandler) PrometheusCollectors() []prom.Collector {
 	// TODO: gather and return relevant metrics.
@@ -458,6 +458,66 @@ func TestFluxHandler_PostQuery_Errors(t *testing.T) {
 	})
 }
 
+func TestFluxHandler_PostQuery_Impersonation(t *testing.T) {
+	store := itesting.NewTestInmemStore(t)
+	orgSVC := tenant.NewService(tenant.NewStore(store))
+	org := influxdb.Organization{Name: t.Name()}
+	if err := orgSVC.CreateOrganization(context.Background(), &org); err != nil {
+		t.Fatal(err)
+	}
+
+	var got *influxdb.Authorization
+	b := &FluxBackend{
+		HTTPErrorHandler:    kithttp.NewErrorHandler(zaptest.NewLogger(t)),
+		log:                 zaptest.NewLogger(t),
+		QueryEventRecorder:  noopEventRecorder{},
+		OrganizationService: orgSVC,
+		ProxyQueryService: &mock.ProxyQueryService{
+			QueryF: func(ctx context.Context, w io.Writer, req *query.ProxyRequest) (flux.Statistics, error) {
+				got = req.Request.Authorization
+				a, err := icontext.GetAuthorizer(ctx)
+				if err != nil {
+					return flux.Statistics{}, err
+				}
+				if _, err := a.PermissionSet(); err != nil {
+					return flux.Statistics{}, err
+				}
+				return flux.Statistics{}, nil
+			},
+		},
+		FluxLanguageService: fluxlang.DefaultService,
+		Flagger:             feature.DefaultFlagger(),
+	}
+	h := NewFluxHandler(zaptest.NewLogger(t), b)
+
+	operator := &influxdb.Authorization{ID: 1, Status: influxdb.Active}
+	perms := []influxdb.Permission{{Action: influxdb.ReadAction, Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &org.ID}}}
+	impersonation := influxdb.NewImpersonation(operator, 2, org.ID, perms)
+
+	req, err := http.NewRequest("POST", "/api/v2/query?orgID="+org.ID.String(), strings.NewReader("buckets()"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(icontext.SetAuthorizer(req.Context(), impersonation))
+	req.Header.Set("Content-Type", "application/vnd.flux")
+
+	w := httptest.NewRecorder()
+	h.handleQuery(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected ok status, got %d: %s", w.Code, w.Body.String())
+	}
+	if got == nil {
+		t.Fatal("expected the query to be authorized")
+	}
+	if got.ID != operator.ID || got.UserID != 2 || got.OrgID != org.ID {
+		t.Errorf("unexpected authorization: id %s user %s org %s", got.ID, got.UserID, got.OrgID)
+	}
+	if !reflect.DeepEqual(got.Permissions, perms) {
+		t.Errorf("unexpected permissions: %v", got.Permissions)
+	}
+}
+
 func TestFluxService_Query_gzip(t *testing.T) {
 	// orgService is just to mock out orgs by returning
 	// the same org every time.
@@ -698,3 +758,117 @@ func benchmarkQuery(b *testing.B, disableCompression bool) {
 
 	}
 }
+
+type activeQueries struct {
+	queries  []query.ActiveQuery
+	canceled []uint64
+}
+
+func (s *activeQueries) ActiveQueries() []query.ActiveQuery {
+	return s.queries
+}
+
+func (s *activeQueries) CancelQuery(id uint64) bool {
+	for _, q := range s.queries {
+		if q.ID == id {
+			s.canceled = append(s.canceled, id)
+			return true
+		}
+	}
+	return false
+}
+
+func TestFluxHandler_ActiveQueries(t *testing.T) {
+	store := itesting.NewTestInmemStore(t)
+	orgSVC := tenant.NewService(tenant.NewStore(store))
+	org := influxdb.Organization{Name: t.Name()}
+	if err := orgSVC.CreateOrganization(context.Background(), &org); err != nil {
+		t.Fatal(err)
+	}
+	orgID, otherOrgID := org.ID, org.ID+1
+
+	active := &activeQueries{queries: []query.ActiveQuery{
+		{ID: 7, OrganizationID: orgID, State: "executing"},
+		{ID: 8, OrganizationID: otherOrgID, State: "queueing"},
+	}}
+	b := &FluxBackend{
+		HTTPErrorHandler:    kithttp.NewErrorHandler(zaptest.NewLogger(t)),
+		log:                 zaptest.NewLogger(t),
+		QueryEventRecorder:  noopEventRecorder{},
+		OrganizationService: orgSVC,
+		ProxyQueryService: &mock.ProxyQueryService{
+			QueryF: func(ctx context.Context, w io.Writer, req *query.ProxyRequest) (flux.Statistics, error) {
+				query.RecordQueryID(ctx, 7)
+				_, err := w.Write([]byte("_result"))
+				return flux.Statistics{}, err
+			},
+		},
+		ActiveQueryService:  active,
+		FluxLanguageService: fluxlang.DefaultService,
+		Flagger:             feature.DefaultFlagger(),
+	}
+	h := NewFluxHandler(zaptest.NewLogger(t), b)
+
+	authz := func(action influxdb.Action) *influxdb.Authorization {
+		return &influxdb.Authorization{
+			ID:     1,
+			OrgID:  orgID,
+			Status: influxdb.Active,
+			Permissions: []influxdb.Permission{
+				{Action: influxdb.ReadAction, Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &orgID}},
+				{Action: action, Resource: influxdb.Resource{Type: influxdb.OrgsResourceType, ID: &orgID}},
+			},
+		}
+	}
+	do := func(method, path string, a *influxdb.Authorization, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(icontext.SetAuthorizer(req.Context(), a))
+		req.Header.Set("Content-Type", "application/vnd.flux")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("POST", "/api/v2/query?orgID="+orgID.String(), authz(influxdb.ReadAction), "buckets()")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected ok status, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(QueryIDHeader); got != "7" {
+		t.Errorf("unexpected query ID header %q", got)
+	}
+
+	w = do("GET", "/api/v2/query/active", authz(influxdb.ReadAction), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected ok status, got %d: %s", w.Code, w.Body.String())
+	}
+	var res activeQueriesResponse
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Queries) != 1 || res.Queries[0].ID != 7 {
+		t.Errorf("expected only the query of the organization, got %+v", res.Queries)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		path   string
+		action influxdb.Action
+		code   int
+	}{
+		{name: "read only", path: "/api/v2/query/7", action: influxdb.ReadAction, code: http.StatusUnauthorized},
+		{name: "other organization", path: "/api/v2/query/8", action: influxdb.WriteAction, code: http.StatusUnauthorized},
+		{name: "not running", path: "/api/v2/query/9", action: influxdb.WriteAction, code: http.StatusNotFound},
+		{name: "invalid ID", path: "/api/v2/query/x", action: influxdb.WriteAction, code: http.StatusBadRequest},
+		{name: "canceled", path: "/api/v2/query/7", action: influxdb.WriteAction, code: http.StatusNoContent},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do("DELETE", tt.path, authz(tt.action), "")
+			if w.Code != tt.code {
+				t.Errorf("expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+		})
+	}
+	if !reflect.DeepEqual(active.canceled, []uint64{7}) {
+		t.Errorf("unexpected canceled queries %v", active.canceled)
+	}
+}
@@ -198,6 +198,7 @@ func TestQueryRequest_proxyRequest(t *testing.T) {
 		Type    string
 		Dialect QueryDialect
 		Now     time.Time
+		Params  map[string]interface{}
 		org     *platform.Organization
 	}
 	tests := []struct {
@@ -343,6 +344,102 @@ func TestQueryRequest_proxyRequest(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "valid query with params",
+			fields: fields{
+				Extern: mustMarshal(&ast.File{
+					Body: []ast.Statement{
+						&ast.OptionStatement{
+							Assignment: &ast.VariableAssignment{
+								ID:   &ast.Identifier{Name: "x"},
+								Init: &ast.IntegerLiteral{Value: 0},
+							},
+						},
+					},
+				}),
+				Query: `from(bucket: params.bucket)`,
+				Type:  "flux",
+				Dialect: QueryDialect{
+					Delimiter:      ",",
+					DateTimeFormat: "RFC3339",
+				},
+				Params: map[string]interface{}{
+					"bucket": `telegraf") |> drop(columns: ["_value"]`,
+					"limit":  json.Number("10"),
+					"ratio":  json.Number("0.5"),
+					"tags":   []interface{}{"a", "b"},
+				},
+				org: &platform.Organization{},
+			},
+			now: func() time.Time { return time.Unix(1, 1) },
+			want: &query.ProxyRequest{
+				Request: query.Request{
+					Compiler: lang.FluxCompiler{
+						Extern: mustMarshal(&ast.File{
+							Body: []ast.Statement{
+								&ast.OptionStatement{
+									Assignment: &ast.VariableAssignment{
+										ID:   &ast.Identifier{Name: "x"},
+										Init: &ast.IntegerLiteral{Value: 0},
+									},
+								},
+								&ast.OptionStatement{
+									Assignment: &ast.VariableAssignment{
+										ID: &ast.Identifier{Name: "params"},
+										Init: &ast.ObjectExpression{
+											Properties: []*ast.Property{
+												{Key: &ast.Identifier{Name: "bucket"}, Value: &ast.StringLiteral{Value: `telegraf") |> drop(columns: ["_value"]`}},
+												{Key: &ast.Identifier{Name: "limit"}, Value: &ast.IntegerLiteral{Value: 10}},
+												{Key: &ast.Identifier{Name: "ratio"}, Value: &ast.FloatLiteral{Value: 0.5}},
+												{Key: &ast.Identifier{Name: "tags"}, Value: &ast.ArrayExpression{
+													Elements: []ast.Expression{&ast.StringLiteral{Value: "a"}, &ast.StringLiteral{Value: "b"}},
+												}},
+											},
+										},
+									},
+								},
+							},
+						}),
+						Query: `from(bucket: params.bucket)`,
+						Now:   time.Unix(1, 1),
+					},
+				},
+				Dialect: &csv.Dialect{
+					ResultEncoderConfig: csv.ResultEncoderConfig{
+						NoHeader:  false,
+						Delimiter: ',',
+					},
+				},
+			},
+		},
+		{
+			name: "invalid param key",
+			fields: fields{
+				Query: `from(bucket: params.bucket)`,
+				Type:  "flux",
+				Dialect: QueryDialect{
+					Delimiter:      ",",
+					DateTimeFormat: "RFC3339",
+				},
+				Params: map[string]interface{}{"bucket name": "telegraf"},
+				org:    &platform.Organization{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "null param",
+			fields: fields{
+				Query: `from(bucket: params.bucket)`,
+				Type:  "flux",
+				Dialect: QueryDialect{
+					Delimiter:      ",",
+					DateTimeFormat: "RFC3339",
+				},
+				Params: map[string]interface{}{"bucket": nil},
+				org:    &platform.Organization{},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -353,6 +450,7 @@ func TestQueryRequest_proxyRequest(t *testing.T) {
 				Type:    tt.fields.Type,
 				Dialect: tt.fields.Dialect,
 				Now:     tt.fields.Now,
+				Params:  tt.fields.Params,
 				Org:     tt.fields.org,
 			}
 			got, err := r.proxyRequest(tt.now)
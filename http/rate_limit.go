@@ -0,0 +1,71 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+)
+
+// RateLimitByAuthorizer keys requests by the authorizer the request was
+// authenticated with, so that tokens, sessions and v1 credentials given in
+// any form share the budget of their authorization.
+func RateLimitByAuthorizer(r *http.Request) string {
+	auth, err := icontext.GetAuthorizer(r.Context())
+	if err != nil {
+		return ""
+	}
+	return auth.Identifier().String()
+}
+
+// RateLimitByOrg keys requests by the organization they target. The
+// organization is taken from the context, from the authorization, or looked
+// up from the orgID or org query parameters. Requests whose organization
+// cannot be found, or is not readable by the authorizer, are keyed by their
+// authorizer instead, so that naming organizations escapes neither the limit
+// nor spends the budget of an organization of others.
+func RateLimitByOrg(orgs influxdb.OrganizationService) kithttp.RateLimitKeyFn {
+	return func(r *http.Request) string {
+		ctx := r.Context()
+		if id := kithttp.OrgIDFromContext(ctx); id != nil {
+			return id.String()
+		}
+		auth, err := icontext.GetAuthorizer(ctx)
+		if err != nil {
+			return ""
+		}
+		if a, ok := auth.(*influxdb.Authorization); ok && a.OrgID.Valid() {
+			return a.OrgID.String()
+		}
+
+		var filter influxdb.OrganizationFilter
+		q := r.URL.Query()
+		if id := q.Get("orgID"); id != "" {
+			orgID, err := platform.IDFromString(id)
+			if err == nil {
+				filter.ID = orgID
+			}
+		} else if name := q.Get("org"); name != "" {
+			filter.Name = &name
+		}
+		if filter.ID != nil || filter.Name != nil {
+			if org, err := orgs.FindOrganization(ctx, filter); err == nil && canReadOrg(auth, org.ID) {
+				return org.ID.String()
+			}
+		}
+		return "auth:" + auth.Identifier().String()
+	}
+}
+
+func canReadOrg(auth influxdb.Authorizer, orgID platform.ID) bool {
+	ps, err := auth.PermissionSet()
+	if err != nil {
+		return false
+	}
+	return ps.Allowed(influxdb.Permission{
+		Action:   influxdb.ReadAction,
+		Resource: influxdb.Resource{Type: influxdb.OrgsResourceType, ID: &orgID},
+	})
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitByOrg(t *testing.T) {
+	orgID := platform.ID(1)
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		if filter.Name != nil && *filter.Name == "my-org" {
+			return &influxdb.Organization{ID: orgID, Name: "my-org"}, nil
+		}
+		return nil, &errors.Error{Code: errors.ENotFound}
+	}
+	keyFn := RateLimitByOrg(orgs)
+
+	request := func(target string, auth influxdb.Authorizer) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		if auth != nil {
+			req = req.WithContext(icontext.SetAuthorizer(req.Context(), auth))
+		}
+		return req
+	}
+	member := &influxdb.Session{
+		ID:          10,
+		ExpiresAt:   time.Now().Add(time.Hour),
+		Permissions: []influxdb.Permission{*mustReadOrgPermission(orgID)},
+	}
+	stranger := &influxdb.Session{ID: 11}
+
+	assert.Empty(t, keyFn(request("/api/v2/write?org=my-org", nil)), "unauthenticated")
+	assert.Equal(t, "0000000000000002", keyFn(request("/api/v2/write?org=my-org", &influxdb.Authorization{ID: 20, OrgID: 2})), "authorization org wins")
+	assert.Equal(t, orgID.String(), keyFn(request("/api/v2/write?org=my-org", member)))
+	assert.Equal(t, "auth:"+stranger.ID.String(), keyFn(request("/api/v2/write?org=my-org", stranger)), "org of others")
+	assert.Equal(t, "auth:"+member.ID.String(), keyFn(request("/api/v2/write?org=missing", member)), "missing org")
+}
+
+func mustReadOrgPermission(orgID platform.ID) *influxdb.Permission {
+	p, err := influxdb.NewPermissionAtID(orgID, influxdb.ReadAction, influxdb.OrgsResourceType, orgID)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
@@ -45,6 +45,8 @@ func TestResourceListHandler(t *testing.T) {
 		string(influxdb.AnnotationsResourceType),
 		string(influxdb.RemotesResourceType),
 		string(influxdb.ReplicationsResourceType),
+		string(influxdb.DatasourcesResourceType),
+		string(influxdb.ParsersResourceType),
 	}
 
 	resp := w.Result()
@@ -17,6 +17,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/kv"
 	"github.com/influxdata/influxdb/v2/pkg/httpc"
 	"github.com/influxdata/influxdb/v2/task/options"
@@ -103,10 +104,11 @@ func NewTaskHandler(log *zap.Logger, b *TaskBackend) *TaskHandler {
 		BucketService:              b.BucketService,
 	}
 
-	h.HandlerFunc("GET", prefixTasks, h.handleGetTasks)
+	h.Handler("GET", prefixTasks, kithttp.ConditionalGet(http.HandlerFunc(h.handleGetTasks)))
 	h.Handler("POST", prefixTasks, withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.handlePostTask)))
 
-	h.HandlerFunc("GET", tasksIDPath, h.handleGetTask)
+	h.Handler("GET", tasksIDPath, kithttp.ConditionalGet(http.HandlerFunc(h.handleGetTask)))
+	h.HandlerFunc("PATCH", prefixTasks, h.handlePatchTasks)
 	h.Handler("PATCH", tasksIDPath, withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.handleUpdateTask)))
 	h.HandlerFunc("DELETE", tasksIDPath, h.handleDeleteTask)
 
@@ -137,7 +139,7 @@ func NewTaskHandler(log *zap.Logger, b *TaskBackend) *TaskHandler {
 	h.HandlerFunc("GET", tasksIDOwnersPath, newGetMembersHandler(ownerBackend))
 	h.HandlerFunc("DELETE", tasksIDOwnersIDPath, newDeleteMemberHandler(ownerBackend))
 
-	h.HandlerFunc("GET", tasksIDRunsPath, h.handleGetRuns)
+	h.Handler("GET", tasksIDRunsPath, kithttp.ConditionalGet(http.HandlerFunc(h.handleGetRuns)))
 	h.HandlerFunc("POST", tasksIDRunsPath, h.handleForceRun)
 	h.HandlerFunc("GET", tasksIDRunsIDPath, h.handleGetRun)
 	h.HandlerFunc("POST", tasksIDRunsIDRetryPath, h.handleRetryRun)
@@ -159,23 +161,24 @@ func NewTaskHandler(log *zap.Logger, b *TaskBackend) *TaskHandler {
 // Task is a package-specific Task format that preserves the expected format for the API,
 // where time values are represented as strings
 type Task struct {
-	ID              platform.ID            `json:"id"`
-	OrganizationID  platform.ID            `json:"orgID"`
-	Organization    string                 `json:"org"`
-	OwnerID         platform.ID            `json:"ownerID"`
-	Name            string                 `json:"name"`
-	Description     string                 `json:"description,omitempty"`
-	Status          string                 `json:"status"`
-	Flux            string                 `json:"flux"`
-	Every           string                 `json:"every,omitempty"`
-	Cron            string                 `json:"cron,omitempty"`
-	Offset          string                 `json:"offset,omitempty"`
-	LatestCompleted string                 `json:"latestCompleted,omitempty"`
-	LastRunStatus   string                 `json:"lastRunStatus,omitempty"`
-	LastRunError    string                 `json:"lastRunError,omitempty"`
-	CreatedAt       string                 `json:"createdAt,omitempty"`
-	UpdatedAt       string                 `json:"updatedAt,omitempty"`
-	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	ID              platform.ID                  `json:"id"`
+	OrganizationID  platform.ID                  `json:"orgID"`
+	Organization    string                       `json:"org"`
+	OwnerID         platform.ID                  `json:"ownerID"`
+	Name            string                       `json:"name"`
+	Description     string                       `json:"description,omitempty"`
+	Status          string                       `json:"status"`
+	Flux            string                       `json:"flux"`
+	Every           string                       `json:"every,omitempty"`
+	Cron            string                       `json:"cron,omitempty"`
+	Offset          string                       `json:"offset,omitempty"`
+	LatestCompleted string                       `json:"latestCompleted,omitempty"`
+	LastRunStatus   string                       `json:"lastRunStatus,omitempty"`
+	LastRunError    string                       `json:"lastRunError,omitempty"`
+	CreatedAt       string                       `json:"createdAt,omitempty"`
+	UpdatedAt       string                       `json:"updatedAt,omitempty"`
+	Metadata        map[string]interface{}       `json:"metadata,omitempty"`
+	Annotations     influxdb.ResourceAnnotations `json:"annotations,omitempty"`
 }
 
 type taskResponse struct {
@@ -221,6 +224,7 @@ func NewFrontEndTask(t taskmodel.Task) Task {
 		CreatedAt:       createdAt,
 		UpdatedAt:       updatedAt,
 		Metadata:        t.Metadata,
+		Annotations:     t.Annotations,
 	}
 }
 
@@ -269,6 +273,7 @@ func convertTask(t Task) *taskmodel.Task {
 		CreatedAt:       createdAt,
 		UpdatedAt:       updatedAt,
 		Metadata:        t.Metadata,
+		Annotations:     t.Annotations,
 	}
 }
 
@@ -489,6 +494,11 @@ func (h *TaskHandler) handleGetTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if kithttp.AcceptsNDJSON(r) {
+		h.streamTasks(w, r, req.filter)
+		return
+	}
+
 	tasks, _, err := h.TaskService.FindTasks(ctx, req.filter)
 	if err != nil {
 		h.HandleHTTPError(ctx, err, w)
@@ -501,6 +511,50 @@ func (h *TaskHandler) handleGetTasks(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// streamTasks streams all tasks of the filter after its after ID as NDJSON.
+// The tasks are read from the store a page at a time, the paging links of
+// the list are in the Link header.
+func (h *TaskHandler) streamTasks(w http.ResponseWriter, r *http.Request, filter taskmodel.TaskFilter) {
+	ctx := r.Context()
+	links := newTasksPagingLinks(prefixTasks, nil, filter)
+	filter.Limit = taskmodel.TaskMaxPageSize
+
+	done := false
+	next := func() ([]interface{}, error) {
+		if done {
+			return nil, nil
+		}
+		tasks, _, err := h.TaskService.FindTasks(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		// stop when a page does not move past the previous one
+		if len(tasks) == 0 || (filter.After != nil && tasks[len(tasks)-1].ID <= *filter.After) {
+			return nil, nil
+		}
+		done = len(tasks) < filter.Limit
+		last := tasks[len(tasks)-1].ID
+		filter.After = &last
+
+		page := make([]interface{}, 0, len(tasks))
+		for _, t := range tasks {
+			labels, _ := h.LabelService.FindResourceLabels(ctx, influxdb.LabelMappingFilter{ResourceID: t.ID, ResourceType: influxdb.TasksResourceType})
+			page = append(page, newTaskResponse(*t, labels))
+		}
+		return page, nil
+	}
+
+	page, err := next()
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	kithttp.AddLinkHeader(w, "self", links.Self)
+	if err := kithttp.EncodeNDJSON(w, http.StatusOK, page, next); err != nil {
+		logEncodingError(h.log, r, err)
+	}
+}
+
 type getTasksRequest struct {
 	filter taskmodel.TaskFilter
 }
@@ -517,6 +571,22 @@ func decodeGetTasksRequest(ctx context.Context, r *http.Request, orgs influxdb.O
 		req.filter.After = id
 	}
 
+	// tasks are always listed by ascending ID, so the opaque cursor of the
+	// other list endpoints is the same as after
+	if cursor := qp.Get("cursor"); cursor != "" {
+		if req.filter.After != nil {
+			return nil, &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "cursor cannot be combined with offset or after",
+			}
+		}
+		id, err := influxdb.DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		req.filter.After = &id
+	}
+
 	if orgName := qp.Get("org"); orgName != "" {
 		o, err := orgs.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &orgName})
 		if err != nil {
@@ -589,6 +659,32 @@ func decodeGetTasksRequest(ctx context.Context, r *http.Request, orgs influxdb.O
 		req.filter.Name = &name
 	}
 
+	for _, label := range qp["label"] {
+		id, err := platform.IDFromString(label)
+		if err != nil {
+			return nil, err
+		}
+		req.filter.Labels = append(req.filter.Labels, *id)
+	}
+
+	if lastRunStatus := qp.Get("lastRunStatus"); lastRunStatus != "" {
+		req.filter.LastRunStatus = &lastRunStatus
+	}
+
+	if scheduleType := qp.Get("scheduleType"); scheduleType != "" {
+		req.filter.ScheduleType = &scheduleType
+	}
+
+	if namePrefix := qp.Get("namePrefix"); namePrefix != "" {
+		req.filter.NamePrefix = &namePrefix
+	}
+
+	req.filter.Annotations = influxdb.DecodeResourceAnnotationsFilter(qp)
+
+	if err := req.filter.Validate(); err != nil {
+		return nil, err
+	}
+
 	return req, nil
 }
 
@@ -783,6 +879,88 @@ func (h *TaskHandler) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type patchTasksRequest struct {
+	Status taskmodel.TaskStatus `json:"status"`
+}
+
+type patchTasksResponse struct {
+	DryRun bool                 `json:"dryRun"`
+	Status taskmodel.TaskStatus `json:"status"`
+	Tasks  []taskResponse       `json:"tasks"`
+}
+
+// handlePatchTasks sets the status of every task matching the filter of the
+// query parameters, as accepted by GET /api/v2/tasks, in one transaction.
+// With dryRun=true it lists the tasks whose status would change.
+func (h *TaskHandler) handlePatchTasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ss, ok := h.TaskService.(taskmodel.TaskStatusService)
+	if !ok {
+		h.HandleHTTPError(ctx, taskmodel.ErrTaskStatusUpdateNotSupported, w)
+		return
+	}
+
+	req, err := decodeGetTasksRequest(ctx, r, h.OrganizationService)
+	if err != nil {
+		err = &errors2.Error{
+			Err:  err,
+			Code: errors2.EInvalid,
+			Msg:  "failed to decode request",
+		}
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	if req.filter.After != nil {
+		h.HandleHTTPError(ctx, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "the status of all matching tasks is updated, after and cursor are not supported",
+		}, w)
+		return
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dryRun"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			h.HandleHTTPError(ctx, &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  fmt.Sprintf("invalid dryRun parameter %q", v),
+			}, w)
+			return
+		}
+	}
+
+	var body patchTasksRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.HandleHTTPError(ctx, &errors2.Error{
+			Err:  err,
+			Code: errors2.EInvalid,
+			Msg:  "failed to decode request body",
+		}, w)
+		return
+	}
+
+	tasks, err := ss.UpdateTasksStatus(ctx, req.filter, body.Status, dryRun)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	h.log.Debug("Tasks status updated", zap.String("status", string(body.Status)), zap.Bool("dryRun", dryRun), zap.Int("count", len(tasks)))
+
+	res := patchTasksResponse{
+		DryRun: dryRun,
+		Status: body.Status,
+		Tasks:  make([]taskResponse, 0, len(tasks)),
+	}
+	for _, t := range tasks {
+		labels, _ := h.LabelService.FindResourceLabels(ctx, influxdb.LabelMappingFilter{ResourceID: t.ID, ResourceType: influxdb.TasksResourceType})
+		res.Tasks = append(res.Tasks, newTaskResponse(*t, labels))
+	}
+	if err := encodeResponse(ctx, w, http.StatusOK, res); err != nil {
+		logEncodingError(h.log, r, err)
+		return
+	}
+}
+
 type updateTaskRequest struct {
 	Update taskmodel.TaskUpdate
 	TaskID platform.ID
@@ -1445,8 +1623,13 @@ func (h *TaskHandler) populateTaskCreateOrg(ctx context.Context, tc *taskmodel.T
 //
 // This method returns a *influxdb.Error, suitable for directly passing to h.HandleHTTPError.
 func (h *TaskHandler) getAuthorizationForTask(ctx context.Context, auth influxdb.Authorizer, taskID platform.ID) (*influxdb.Authorization, *errors2.Error) {
-	sess, ok := auth.(*influxdb.Session)
-	if !ok {
+	var ephemeralAuth func(orgID platform.ID) *influxdb.Authorization
+	switch a := auth.(type) {
+	case *influxdb.Session:
+		ephemeralAuth = a.EphemeralAuth
+	case *influxdb.Impersonation:
+		ephemeralAuth = a.EphemeralAuth
+	default:
 		return nil, &errors2.Error{
 			Code: errors2.EUnauthorized,
 			Msg:  "unable to authorize session",
@@ -1463,7 +1646,7 @@ func (h *TaskHandler) getAuthorizationForTask(ctx context.Context, auth influxdb
 		}
 	}
 
-	return sess.EphemeralAuth(t.OrganizationID), nil
+	return ephemeralAuth(t.OrganizationID), nil
 }
 
 // TaskService connects to Influx via HTTP using tokens to manage tasks.
@@ -1518,6 +1701,22 @@ func (t TaskService) FindTasks(ctx context.Context, filter taskmodel.TaskFilter)
 		params = append(params, [2]string{"type", *filter.Type})
 	}
 
+	for _, id := range filter.Labels {
+		params = append(params, [2]string{"label", id.String()})
+	}
+
+	if filter.LastRunStatus != nil {
+		params = append(params, [2]string{"lastRunStatus", *filter.LastRunStatus})
+	}
+
+	if filter.ScheduleType != nil {
+		params = append(params, [2]string{"scheduleType", *filter.ScheduleType})
+	}
+
+	for k, v := range filter.Annotations {
+		params = append(params, [2]string{"annotations[" + k + "]", v})
+	}
+
 	var tr tasksResponse
 	err := t.Client.
 		Get(prefixTasks).
@@ -23,6 +23,7 @@ import (
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/label"
 	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/task/options"
 	"github.com/influxdata/influxdb/v2/task/taskmodel"
 	"github.com/influxdata/influxdb/v2/tenant"
 	influxdbtesting "github.com/influxdata/influxdb/v2/testing"
@@ -385,6 +386,26 @@ func TestTaskHandler_handleGetTasks(t *testing.T) {
 				body: `{
 "code": "invalid",
 "message": "failed to decode request: org non-existent-org not found or unauthorized: org not found or unauthorized"
+}`,
+			},
+		},
+		{
+			name:      "get tasks by invalid last run status",
+			getParams: "lastRunStatus=started",
+			fields: fields{
+				taskService: &mock.TaskService{
+					FindTasksFn: func(ctx context.Context, f taskmodel.TaskFilter) ([]*taskmodel.Task, int, error) {
+						return nil, 0, nil
+					},
+				},
+				labelService: &mock.LabelService{},
+			},
+			wants: wants{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json; charset=utf-8",
+				body: `{
+"code": "invalid",
+"message": "failed to decode request: \"started\" is not a valid last run status"
 }`,
 			},
 		},
@@ -518,6 +539,35 @@ func TestTaskHandler_handlePostTasks(t *testing.T) {
     "code": "invalid",
     "message": "something really went wrong: something went wrong"
 }
+`,
+			},
+		},
+		{
+			name: "create task - invalid options",
+			args: args{
+				taskCreate: taskmodel.TaskCreate{
+					OrganizationID: 1,
+					Flux:           "abc",
+				},
+			},
+			fields: fields{
+				taskService: &mock.TaskService{
+					CreateTaskFn: func(ctx context.Context, tc taskmodel.TaskCreate) (*taskmodel.Task, error) {
+						return nil, taskmodel.ErrTaskOptionParse(&options.ValidationError{Fields: []options.FieldError{
+							{Field: "name", Msg: "required"},
+							{Field: "offset", Msg: "must not be longer than every (10s)"},
+						}})
+					},
+				},
+			},
+			wants: wants{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json; charset=utf-8",
+				body: `
+{
+    "code": "invalid",
+    "message": "invalid options: name: required, offset: must not be longer than every (10s)"
+}
 `,
 			},
 		},
@@ -13,6 +13,7 @@ import (
 	pctx "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/pkg/httpc"
 	"github.com/influxdata/influxdb/v2/telegraf/plugins"
 	"go.uber.org/zap"
@@ -86,8 +87,8 @@ func NewTelegrafHandler(log *zap.Logger, b *TelegrafBackend) *TelegrafHandler {
 		OrganizationService:        b.OrganizationService,
 	}
 	h.HandlerFunc("POST", prefixTelegraf, h.handlePostTelegraf)
-	h.HandlerFunc("GET", prefixTelegraf, h.handleGetTelegrafs)
-	h.HandlerFunc("GET", telegrafsIDPath, h.handleGetTelegraf)
+	h.Handler("GET", prefixTelegraf, kithttp.ConditionalGet(http.HandlerFunc(h.handleGetTelegrafs)))
+	h.Handler("GET", telegrafsIDPath, kithttp.ConditionalGet(http.HandlerFunc(h.handleGetTelegraf)))
 	h.HandlerFunc("DELETE", telegrafsIDPath, h.handleDeleteTelegraf)
 	h.HandlerFunc("PUT", telegrafsIDPath, h.handlePutTelegraf)
 
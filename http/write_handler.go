@@ -5,7 +5,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
@@ -58,6 +61,10 @@ type WriteHandler struct {
 	router            *httprouter.Router
 	log               *zap.Logger
 	maxBatchSizeBytes int64
+	slowWriteLog      *points.SlowWriteLog
+	rejectionLog      *points.RejectionLog
+	tokenWriteLog     *points.TokenWriteLog
+	otlpNaming        points.OTLPNaming
 	// parserOptions     []models.ParserOption
 }
 
@@ -72,6 +79,40 @@ func WithMaxBatchSizeBytes(n int64) WriteHandlerOption {
 	}
 }
 
+// WithSlowWriteLog configures the log recording writes
+// that exceed its latency threshold.
+func WithSlowWriteLog(l *points.SlowWriteLog) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.slowWriteLog = l
+	}
+}
+
+// WithRejectionLog configures the log tracking rejected writes,
+// which is reported at /api/v2/write/rejections.
+func WithRejectionLog(l *points.RejectionLog) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.rejectionLog = l
+	}
+}
+
+// WithTokenWriteLog configures the log tracking writes per authorization,
+// which is reported at /api/v2/write/stats.
+func WithTokenWriteLog(l *points.TokenWriteLog) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.tokenWriteLog = l
+	}
+}
+
+// WithOTLPNaming configures the convention OpenTelemetry metrics written
+// to /api/v2/write/otlp are named by, points.OTLPNamingMeasurement by default.
+func WithOTLPNaming(n points.OTLPNaming) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		if n != "" {
+			w.otlpNaming = n
+		}
+	}
+}
+
 //func WithParserOptions(opts ...models.ParserOption) WriteHandlerOption {
 //	return func(w *WriteHandler) {
 //		w.parserOptions = opts
@@ -84,9 +125,13 @@ func (*WriteHandler) Prefix() string {
 }
 
 const (
-	prefixWrite          = "/api/v2/write"
-	msgInvalidGzipHeader = "gzipped HTTP body contains an invalid header"
-	msgInvalidPrecision  = "invalid precision; valid precision units are ns, us, ms, and s"
+	prefixWrite           = "/api/v2/write"
+	prefixWriteRejections = "/api/v2/write/rejections"
+	prefixWriteStats      = "/api/v2/write/stats"
+	prefixWritePrometheus = "/api/v2/write/prometheus"
+	prefixWriteOTLP       = "/api/v2/write/otlp"
+	msgInvalidGzipHeader  = "gzipped HTTP body contains an invalid header"
+	msgInvalidPrecision   = "invalid precision; valid precision units are ns, us, ms, and s"
 
 	opWriteHandler = "http/writeHandler"
 )
@@ -100,8 +145,9 @@ func NewWriteHandler(log *zap.Logger, b *WriteBackend, opts ...WriteHandlerOptio
 		OrganizationService: b.OrganizationService,
 		EventRecorder:       b.WriteEventRecorder,
 
-		router: NewRouter(b.HTTPErrorHandler),
-		log:    log,
+		router:     NewRouter(b.HTTPErrorHandler),
+		log:        log,
+		otlpNaming: points.OTLPNamingMeasurement,
 	}
 
 	for _, opt := range opts {
@@ -109,6 +155,10 @@ func NewWriteHandler(log *zap.Logger, b *WriteBackend, opts ...WriteHandlerOptio
 	}
 
 	h.router.HandlerFunc(http.MethodPost, prefixWrite, h.handleWrite)
+	h.router.HandlerFunc(http.MethodPost, prefixWritePrometheus, h.handleWritePrometheus)
+	h.router.HandlerFunc(http.MethodPost, prefixWriteOTLP, h.handleWriteOTLP)
+	h.router.HandlerFunc(http.MethodGet, prefixWriteRejections, h.handleGetRejections)
+	h.router.HandlerFunc(http.MethodGet, prefixWriteStats, h.handleGetWriteStats)
 	return h
 }
 
@@ -135,10 +185,50 @@ func (h *WriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.router.ServeHTTP(w, r)
 }
 
+// parsePointsFn parses the points of the body of a write request.
+type parsePointsFn func(ctx context.Context, req *writeRequest, orgID, bucketID platform.ID) (*points.ParsedPoints, error)
+
 func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
+	h.write(w, r, func(ctx context.Context, req *writeRequest, orgID, bucketID platform.ID) (*points.ParsedPoints, error) {
+		// TODO: Backport?
+		//opts := append([]models.ParserOption{}, h.parserOptions...)
+		//opts = append(opts, models.WithParserPrecision(req.Precision))
+		parser := points.NewParser(req.Precision)
+		parser.Partial = true
+		return parser.Parse(ctx, orgID, bucketID, req.Body)
+	})
+}
+
+// handleWritePrometheus writes the samples of a Prometheus remote write
+// request, so Prometheus can write to a bucket without a translating proxy.
+func (h *WriteHandler) handleWritePrometheus(w http.ResponseWriter, r *http.Request) {
+	h.write(w, r, func(ctx context.Context, req *writeRequest, _, _ platform.ID) (*points.ParsedPoints, error) {
+		return points.ParsePrometheusRemoteWrite(ctx, req.Body, h.maxBatchSizeBytes)
+	})
+}
+
+// handleWriteOTLP writes the metrics of an OTLP/HTTP export request, so
+// OpenTelemetry collectors can export to a bucket with the attributes of
+// their resources as tags. Only the protobuf encoding of OTLP is supported.
+func (h *WriteHandler) handleWriteOTLP(w http.ResponseWriter, r *http.Request) {
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/x-protobuf" {
+		h.HandleHTTPError(r.Context(), &errors.Error{
+			Code: errors.EInvalid,
+			Op:   opWriteHandler,
+			Msg:  fmt.Sprintf("unsupported content type %q, OTLP metrics must be encoded as application/x-protobuf", ct),
+		}, w)
+		return
+	}
+	h.write(w, r, func(ctx context.Context, req *writeRequest, _, _ platform.ID) (*points.ParsedPoints, error) {
+		return points.ParseOTLPMetrics(ctx, req.Body, h.otlpNaming)
+	})
+}
+
+func (h *WriteHandler) write(w http.ResponseWriter, r *http.Request, parse parsePointsFn) {
 	span, r := tracing.ExtractFromHTTPRequest(r, "WriteHandler")
 	defer span.Finish()
 
+	start := time.Now()
 	ctx := r.Context()
 	auth, err := pcontext.GetAuthorizer(ctx)
 	if err != nil {
@@ -146,22 +236,46 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The writes of the authorizer are counted from here on, including the
+	// ones rejected before the points are parsed.
+	var (
+		orgID        platform.ID
+		requestBytes int
+		parsed       *points.ParsedPoints
+		writeErr     error
+	)
+	defer func() {
+		tokenWrite := points.TokenWrite{
+			OrgID:           orgID,
+			AuthorizationID: auth.Identifier(),
+			Kind:            auth.Kind(),
+			Bytes:           requestBytes,
+			Err:             writeErr,
+		}
+		if parsed != nil {
+			tokenWrite.Points = len(parsed.Points)
+		}
+		h.tokenWriteLog.Observe(tokenWrite)
+	}()
+
 	req, err := decodeWriteRequest(ctx, r, h.maxBatchSizeBytes)
 	if err != nil {
+		writeErr = err
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
 
 	org, err := queryOrganization(ctx, r, h.OrganizationService)
 	if err != nil {
+		writeErr = err
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
+	orgID = org.ID
 	span.LogKV("org_id", org.ID)
 
 	sw := kithttp.NewStatusResponseWriter(w)
 	recorder := NewWriteUsageRecorder(sw, h.EventRecorder)
-	var requestBytes int
 	defer func() {
 		// Close around the requestBytes variable to placate the linter.
 		recorder.Record(ctx, requestBytes, org.ID, r.URL.Path)
@@ -169,27 +283,77 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 
 	bucket, err := h.findBucket(ctx, org.ID, req.Bucket)
 	if err != nil {
+		writeErr = err
 		h.HandleHTTPError(ctx, err, sw)
 		return
 	}
 	span.LogKV("bucket_id", bucket.ID)
 
 	if err := checkBucketWritePermissions(auth, org.ID, bucket.ID); err != nil {
+		writeErr = err
 		h.HandleHTTPError(ctx, err, sw)
 		return
 	}
 
-	// TODO: Backport?
-	//opts := append([]models.ParserOption{}, h.parserOptions...)
-	//opts = append(opts, models.WithParserPrecision(req.Precision))
-	parsed, err := points.NewParser(req.Precision).Parse(ctx, org.ID, bucket.ID, req.Body)
+	// rejected are the text of the lines that failed the write.
+	var rejected []string
+	defer func() {
+		sample := points.SlowWrite{
+			OrgID:       org.ID,
+			BucketID:    bucket.ID,
+			Bucket:      bucket.Name,
+			Compression: r.Header.Get("Content-Encoding"),
+			Bytes:       requestBytes,
+			Duration:    time.Since(start),
+			Err:         writeErr,
+		}
+		if parsed != nil {
+			sample.Points = parsed.Points
+		}
+		h.slowWriteLog.Observe(sample)
+		h.rejectionLog.Observe(points.Rejection{
+			OrgID:      org.ID,
+			BucketID:   bucket.ID,
+			Bucket:     bucket.Name,
+			UserAgent:  r.UserAgent(),
+			RemoteAddr: r.RemoteAddr,
+			Err:        writeErr,
+			Lines:      rejected,
+		})
+	}()
+
+	parsed, err = parse(ctx, req, org.ID, bucket.ID)
 	if err != nil {
+		writeErr = err
+		rejected = points.RejectedLines(err)
 		h.HandleHTTPError(ctx, err, sw)
 		return
 	}
 	requestBytes = parsed.RawSize
 
-	if err := h.PointsWriter.WritePoints(ctx, org.ID, bucket.ID, parsed.Points); err != nil {
+	writeCtx, droppedPoints := tsdb.ContextWithDroppedPoints(ctx)
+	err = h.PointsWriter.WritePoints(writeCtx, org.ID, bucket.ID, parsed.Points)
+	if _, partial := err.(tsdb.PartialWriteError); err == nil || partial {
+		if res := newPartialWriteResponse(parsed, droppedPoints.Points()); res != nil {
+			writeErr = err
+			rejected = offendingLines(res.Rejected, droppedPoints.Points())
+			if writeErr == nil {
+				writeErr = &errors.Error{
+					Code: errors.EInvalid,
+					Op:   opWriteHandler,
+					Msg:  res.Rejected[0].Reason,
+				}
+			}
+			sw.Header().Set(kithttp.PlatformErrorCodeHeader, res.Code)
+			if err := encodeResponse(ctx, sw, http.StatusUnprocessableEntity, res); err != nil {
+				h.HandleHTTPError(ctx, err, sw)
+			}
+			return
+		}
+	}
+	if err != nil {
+		writeErr = err
+		rejected = offendingLines(nil, droppedPoints.Points())
 		if partialErr, ok := err.(tsdb.PartialWriteError); ok {
 			h.HandleHTTPError(ctx, &errors.Error{
 				Code: errors.EUnprocessableEntity,
@@ -212,6 +376,184 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 	sw.WriteHeader(http.StatusNoContent)
 }
 
+// partialWriteResponse is the response body of a write that rejected some
+// of its lines, which either did not parse or were dropped by storage. The
+// other lines were written, so a client should only retry the rejected ones
+// once fixed.
+type partialWriteResponse struct {
+	Code     string                `json:"code"`
+	Message  string                `json:"message"`
+	Accepted int                   `json:"accepted"`
+	Rejected []points.RejectedLine `json:"rejected"`
+}
+
+// newPartialWriteResponse returns the response of a write that rejected
+// lines, or nil when no lines were rejected or the lines of the points
+// dropped by storage are not known.
+func newPartialWriteResponse(parsed *points.ParsedPoints, dropped []tsdb.DroppedPoint) *partialWriteResponse {
+	if len(dropped) > 0 && len(parsed.Lines) != len(parsed.Points) {
+		return nil
+	}
+
+	rejected := append([]points.RejectedLine(nil), parsed.Rejected...)
+	if len(dropped) > 0 {
+		lines := make(map[models.Point]int, len(parsed.Points))
+		for i, p := range parsed.Points {
+			lines[p] = parsed.Lines[i]
+		}
+		for _, d := range dropped {
+			line, ok := lines[d.Point]
+			if !ok {
+				return nil
+			}
+			rejected = append(rejected, points.RejectedLine{Line: line, Reason: d.Reason})
+		}
+	}
+	if len(rejected) == 0 {
+		return nil
+	}
+	sort.Slice(rejected, func(i, j int) bool { return rejected[i].Line < rejected[j].Line })
+
+	accepted := len(parsed.Points) - len(dropped)
+	return &partialWriteResponse{
+		Code:     errors.EUnprocessableEntity,
+		Message:  fmt.Sprintf("partial write: %d of %d lines rejected", len(rejected), len(rejected)+accepted),
+		Accepted: accepted,
+		Rejected: rejected,
+	}
+}
+
+// offendingLines returns the text of the first lines rejected by a write,
+// either when parsed or as points dropped by storage.
+func offendingLines(rejected []points.RejectedLine, dropped []tsdb.DroppedPoint) []string {
+	var lines []string
+	for _, r := range rejected {
+		if len(lines) == points.RejectionSampleLines {
+			return lines
+		}
+		if r.Text != "" {
+			lines = append(lines, r.Text)
+		}
+	}
+	for _, d := range dropped {
+		if len(lines) == points.RejectionSampleLines {
+			break
+		}
+		lines = append(lines, d.Point.String())
+	}
+	return lines
+}
+
+// writeRejectionsResponse is the response body for the write rejections endpoint.
+type writeRejectionsResponse struct {
+	Rejections []points.RejectionStats `json:"rejections"`
+}
+
+// handleGetRejections reports the writes rejected for the buckets of an org
+// that the caller may read, optionally limited to a single bucket.
+func (h *WriteHandler) handleGetRejections(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	auth, err := pcontext.GetAuthorizer(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	org, err := queryOrganization(ctx, r, h.OrganizationService)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	var bucketID *platform.ID
+	if id := r.URL.Query().Get("bucketID"); id != "" {
+		if bucketID, err = platform.IDFromString(id); err != nil {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "invalid bucket id",
+				Err:  err,
+			}, w)
+			return
+		}
+	}
+
+	pset, err := auth.PermissionSet()
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	stats := h.rejectionLog.Stats(org.ID, func(id platform.ID) bool {
+		if bucketID != nil && *bucketID != id {
+			return false
+		}
+		p, err := influxdb.NewPermissionAtID(id, influxdb.ReadAction, influxdb.BucketsResourceType, org.ID)
+		return err == nil && pset.Allowed(*p)
+	})
+	if stats == nil {
+		stats = []points.RejectionStats{}
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, writeRejectionsResponse{Rejections: stats}); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+	}
+}
+
+// writeStatsResponse is the response body for the write stats endpoint.
+type writeStatsResponse struct {
+	Authorizations []points.TokenWriteStats `json:"authorizations"`
+}
+
+// handleGetWriteStats reports the writes made with the authorizations of an
+// org that the caller may read, optionally limited to a single authorization.
+func (h *WriteHandler) handleGetWriteStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	auth, err := pcontext.GetAuthorizer(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	org, err := queryOrganization(ctx, r, h.OrganizationService)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	var authID *platform.ID
+	if id := r.URL.Query().Get("authorizationID"); id != "" {
+		if authID, err = platform.IDFromString(id); err != nil {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "invalid authorization id",
+				Err:  err,
+			}, w)
+			return
+		}
+	}
+
+	pset, err := auth.PermissionSet()
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	stats := h.tokenWriteLog.Stats(org.ID, func(id platform.ID) bool {
+		if authID != nil && *authID != id {
+			return false
+		}
+		p, err := influxdb.NewPermissionAtID(id, influxdb.ReadAction, influxdb.AuthorizationsResourceType, org.ID)
+		return err == nil && pset.Allowed(*p)
+	})
+	if stats == nil {
+		stats = []points.TokenWriteStats{}
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, writeStatsResponse{Authorizations: stats}); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+	}
+}
+
 // checkBucketWritePermissions checks an Authorizer for write permissions to a
 // specific Bucket.
 func checkBucketWritePermissions(auth influxdb.Authorizer, orgID, bucketID platform.ID) error {
@@ -271,6 +613,11 @@ func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes
 	}
 
 	encoding := r.Header.Get("Content-Encoding")
+	if r.URL.Path == prefixWritePrometheus && encoding == "snappy" {
+		// a remote write compresses its message itself, it is decompressed
+		// by the parser
+		encoding = ""
+	}
 	body, err := points.BatchReadCloser(r.Body, encoding, maxBatchSizeBytes)
 	if err != nil {
 		return nil, err
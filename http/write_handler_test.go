@@ -14,10 +14,12 @@ import (
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/http/metric"
 	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/http/points"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
 	influxtesting "github.com/influxdata/influxdb/v2/testing"
 	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/stretchr/testify/require"
@@ -421,6 +423,129 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 	}
 }
 
+func TestWriteHandler_handleWrite_PartialWrite(t *testing.T) {
+	// The points writer drops the points of the old measurement, like
+	// storage drops the points beyond the retention of a bucket.
+	writer := &mock.PointsWriter{
+		WritePointsFn: func(ctx context.Context, _, _ platform.ID, points []models.Point) error {
+			var dropped int
+			for _, p := range points {
+				if string(p.Name()) == "old" {
+					tsdb.DroppedPointsFromContext(ctx).Add(p, "point beyond retention policy")
+					dropped++
+				}
+			}
+			if dropped > 0 {
+				return tsdb.PartialWriteError{Reason: "points beyond retention policy", Dropped: dropped}
+			}
+			return nil
+		},
+	}
+
+	tests := []struct {
+		name string
+		body string
+		code int
+		want string
+	}{
+		{
+			name: "lines that do not parse are rejected",
+			body: "m1,t1=v1 f1=1\ninvalid\nm1,t1=v1 f1=2",
+			code: 422,
+			want: `{"code":"unprocessable entity","message":"partial write: 1 of 3 lines rejected","accepted":2,"rejected":[{"line":2,"reason":"unable to parse 'invalid': missing fields"}]}` + "\n",
+		},
+		{
+			name: "points dropped by storage are rejected",
+			body: "old f1=1\nm1,t1=v1 f1=1\n\nold f1=2",
+			code: 422,
+			want: `{"code":"unprocessable entity","message":"partial write: 2 of 3 lines rejected","accepted":1,"rejected":[{"line":1,"reason":"point beyond retention policy"},{"line":4,"reason":"point beyond retention policy"}]}` + "\n",
+		},
+		{
+			name: "rejected lines are ordered",
+			body: "old f1=1\ninvalid\nm1,t1=v1 f1=1",
+			code: 422,
+			want: `{"code":"unprocessable entity","message":"partial write: 2 of 3 lines rejected","accepted":1,"rejected":[{"line":1,"reason":"point beyond retention policy"},{"line":2,"reason":"unable to parse 'invalid': missing fields"}]}` + "\n",
+		},
+		{
+			name: "batch without a valid line fails",
+			body: "invalid\ninvalid too",
+			code: 400,
+			want: `{"code":"invalid","message":"unable to parse 'invalid': missing fields\nunable to parse 'invalid too': invalid field format"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg("043e0780ee2b1000"), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket("043e0780ee2b1000", "04504b356e23b000"), nil
+			}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    kithttp.NewErrorHandler(zaptest.NewLogger(t)),
+				Logger:              zaptest.NewLogger(t),
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        writer,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"))
+
+			r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/write?org=043e0780ee2b1000&bucket=04504b356e23b000", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if got, want := w.Code, tt.code; got != want {
+				t.Errorf("unexpected status code: got %d want %d", got, want)
+			}
+			if got, want := w.Body.String(), tt.want; got != want {
+				t.Errorf("unexpected body: got %s want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestWriteHandler_handleWrite_TokenWriteLog(t *testing.T) {
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg("043e0780ee2b1000"), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket("043e0780ee2b1000", "04504b356e23b000"), nil
+	}
+
+	b := &APIBackend{
+		HTTPErrorHandler:    kithttp.NewErrorHandler(zaptest.NewLogger(t)),
+		Logger:              zaptest.NewLogger(t),
+		OrganizationService: orgs,
+		BucketService:       buckets,
+		PointsWriter:        &mock.PointsWriter{},
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	l := points.NewTokenWriteLog(points.DefaultTokenWriteResolution, points.DefaultTokenWriteWindow, points.DefaultTokenWriteMaxTokens)
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), WithTokenWriteLog(l))
+
+	// the token may only write to another bucket
+	auth := bucketWritePermission("043e0780ee2b1000", "04504b356e23b001")
+	auth.ID = influxtesting.MustIDBase16("0000000000000010")
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, auth)
+
+	r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/write?org=043e0780ee2b1000&bucket=04504b356e23b000", strings.NewReader("m1,t1=v1 f1=1"))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	stats := l.Stats(influxtesting.MustIDBase16("043e0780ee2b1000"), nil)
+	require.Len(t, stats, 1, "writes rejected before the points are parsed are counted")
+	require.Equal(t, auth.ID, stats[0].AuthorizationID)
+	require.Equal(t, 1, stats[0].Requests)
+	require.Equal(t, 1, stats[0].Errors)
+}
+
 func bucketWritePermission(org, bucket string) *influxdb.Authorization {
 	oid := influxtesting.MustIDBase16(org)
 	bid := influxtesting.MustIDBase16(bucket)
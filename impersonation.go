@@ -0,0 +1,82 @@
+package influxdb
+
+import (
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// ImpersonationAuthorizationKind is returned by (*Impersonation).Kind().
+const ImpersonationAuthorizationKind = "impersonation"
+
+var _ Authorizer = (*Impersonation)(nil)
+
+// Impersonation is an authorizer an operator uses to execute a request with
+// the identity of another user, e.g. when debugging an issue for support.
+// It is authorized with the permissions of the impersonated user, optionally
+// restricted to a single organization.
+type Impersonation struct {
+	// Operator is the authorizer of the operator impersonating the user.
+	Operator Authorizer
+	// UserID is the effective user.
+	UserID platform.ID
+	// OrgID is the effective organization. When valid, only permissions
+	// scoped to the organization are retained.
+	OrgID       platform.ID
+	Permissions []Permission
+}
+
+// NewImpersonation returns the authorizer of an operator impersonating the
+// user with the permissions of the user.
+func NewImpersonation(operator Authorizer, userID, orgID platform.ID, ps PermissionSet) *Impersonation {
+	perms := make([]Permission, 0, len(ps))
+	for _, p := range ps {
+		if orgID.Valid() && !permissionInOrg(p, orgID) {
+			continue
+		}
+		perms = append(perms, p)
+	}
+	return &Impersonation{
+		Operator:    operator,
+		UserID:      userID,
+		OrgID:       orgID,
+		Permissions: perms,
+	}
+}
+
+func permissionInOrg(p Permission, orgID platform.ID) bool {
+	if p.Resource.OrgID != nil {
+		return *p.Resource.OrgID == orgID
+	}
+	return p.Resource.Type == OrgsResourceType && p.Resource.ID != nil && *p.Resource.ID == orgID
+}
+
+// PermissionSet returns the permissions of the impersonated user.
+func (i *Impersonation) PermissionSet() (PermissionSet, error) {
+	return i.Permissions, nil
+}
+
+// Identifier returns the identifier of the operator's authorizer and is used
+// for auditing.
+func (i *Impersonation) Identifier() platform.ID {
+	return i.Operator.Identifier()
+}
+
+// GetUserID returns the id of the impersonated user.
+func (i *Impersonation) GetUserID() platform.ID {
+	return i.UserID
+}
+
+// EphemeralAuth generates an Authorization with the permissions of the
+// impersonated user. Its ID is the identifier of the operator, so requests
+// made with it remain attributed to the operator.
+func (i *Impersonation) EphemeralAuth(orgID platform.ID) *Authorization {
+	return &Authorization{
+		ID:          i.Identifier(),
+		OrgID:       orgID,
+		Status:      Active,
+		UserID:      i.UserID,
+		Permissions: i.Permissions,
+	}
+}
+
+// Kind returns impersonation and is used for auditing.
+func (i *Impersonation) Kind() string { return ImpersonationAuthorizationKind }
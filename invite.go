@@ -0,0 +1,64 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// DefaultInviteExpiration is how long an invite can be accepted when no
+// expiration is configured.
+const DefaultInviteExpiration = 7 * 24 * time.Hour
+
+// Invite is an invitation sent by email for a person to sign up as a user
+// and join an organization with a role.
+type Invite struct {
+	ID    platform.ID `json:"id"`
+	OrgID platform.ID `json:"orgID"`
+	Email string      `json:"email"`
+	// Role is the role the user is granted in the organization.
+	Role UserType `json:"role"`
+	// Locale selects the language of the invitation email, e.g. "de".
+	Locale    string      `json:"locale,omitempty"`
+	InvitedBy platform.ID `json:"invitedBy,omitempty"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+	CRUDLog
+}
+
+// InviteFilter represents a set of filters that restrict the returned invites.
+type InviteFilter struct {
+	OrgID platform.ID
+}
+
+// AcceptInviteRequest is the request to sign up with an invite.
+type AcceptInviteRequest struct {
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// InviteService represents a service for inviting users to organizations.
+type InviteService interface {
+	// CreateInvite creates an invite and sends the invitation email. The
+	// returned link signs up with the invite.
+	CreateInvite(ctx context.Context, i *Invite) (link string, err error)
+
+	// FindInviteByID returns a single invite by ID.
+	FindInviteByID(ctx context.Context, id platform.ID) (*Invite, error)
+
+	// FindInvites returns the pending invites matching the filter.
+	FindInvites(ctx context.Context, filter InviteFilter) ([]*Invite, error)
+
+	// DeleteInvite revokes an invite.
+	DeleteInvite(ctx context.Context, id platform.ID) error
+
+	// AcceptInvite creates the invited user with the invite's role in the
+	// organization and consumes the invite.
+	AcceptInvite(ctx context.Context, req AcceptInviteRequest) (*User, error)
+}
+
+// InviteMailer sends invitation emails.
+type InviteMailer interface {
+	SendInvite(ctx context.Context, i *Invite, org *Organization, link string) error
+}
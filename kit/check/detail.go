@@ -0,0 +1,236 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultSubsystemTimeout is the time a subsystem has to answer its check
+// before it is reported as failing.
+const DefaultSubsystemTimeout = 5 * time.Second
+
+// DefaultDetailCacheTTL is the time the result of the checks is reused for.
+const DefaultDetailCacheTTL = time.Second
+
+// Detail checks the subsystems of a server for the detailed health
+// endpoint. Every check is timed, and the last failure of each subsystem is
+// remembered so it is reported after the subsystem recovered.
+//
+// Concurrent requests share a single run of the checks, whose result is
+// reused for the cache TTL. A subsystem whose check hangs is not checked
+// again until the hung check returns.
+type Detail struct {
+	timeout    time.Duration
+	cacheTTL   time.Duration
+	now        func() time.Time
+	subsystems []subsystem
+	group      singleflight.Group
+
+	mu         sync.Mutex
+	lastErrors map[string]LastError
+	running    map[string]bool
+	cached     *DetailResponse
+	cachedAt   time.Time
+}
+
+type subsystem struct {
+	name    string
+	checker Checker
+}
+
+// LastError is the last failure of a subsystem.
+type LastError struct {
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// SubsystemResponse is the result of the check of a subsystem.
+type SubsystemResponse struct {
+	Name      string     `json:"name"`
+	Status    Status     `json:"status"`
+	Message   string     `json:"message,omitempty"`
+	LatencyMs float64    `json:"latencyMs"`
+	LastError *LastError `json:"lastError,omitempty"`
+}
+
+// DetailResponse is the result of the checks of all subsystems. It fails
+// when any subsystem fails.
+type DetailResponse struct {
+	Name       string              `json:"name"`
+	Status     Status              `json:"status"`
+	Subsystems []SubsystemResponse `json:"subsystems"`
+}
+
+// NewDetail returns a Detail without subsystems. A subsystem whose check
+// takes longer than timeout fails.
+func NewDetail(timeout time.Duration) *Detail {
+	if timeout <= 0 {
+		timeout = DefaultSubsystemTimeout
+	}
+	return &Detail{
+		timeout:    timeout,
+		cacheTTL:   DefaultDetailCacheTTL,
+		now:        time.Now,
+		lastErrors: make(map[string]LastError),
+		running:    make(map[string]bool),
+	}
+}
+
+// SetCacheTTL sets the time the result of the checks is reused for, zero
+// runs the checks for every request.
+func (d *Detail) SetCacheTTL(ttl time.Duration) {
+	d.cacheTTL = ttl
+}
+
+// AddSubsystem adds the check of a subsystem.
+func (d *Detail) AddSubsystem(name string, checker Checker) {
+	d.subsystems = append(d.subsystems, subsystem{name: name, checker: checker})
+}
+
+// Check returns the result of the checks of all subsystems. The result of
+// a recent run is reused, and callers arriving while the checks run wait for
+// that run. The checks are not bound to ctx, so a caller giving up does not
+// fail the run shared with the others.
+func (d *Detail) Check(ctx context.Context) DetailResponse {
+	d.mu.Lock()
+	if d.cached != nil && d.now().Sub(d.cachedAt) < d.cacheTTL {
+		resp := *d.cached
+		d.mu.Unlock()
+		return resp
+	}
+	d.mu.Unlock()
+
+	ch := d.group.DoChan("check", func() (interface{}, error) {
+		resp := d.check(context.Background())
+
+		d.mu.Lock()
+		d.cached, d.cachedAt = &resp, d.now()
+		d.mu.Unlock()
+		return resp, nil
+	})
+
+	select {
+	case res := <-ch:
+		return res.Val.(DetailResponse)
+	case <-ctx.Done():
+		return DetailResponse{
+			Name:       "Health",
+			Status:     StatusFail,
+			Subsystems: []SubsystemResponse{},
+		}
+	}
+}
+
+// check evaluates the checks of all subsystems concurrently.
+func (d *Detail) check(ctx context.Context) DetailResponse {
+	response := DetailResponse{
+		Name:       "Health",
+		Status:     StatusPass,
+		Subsystems: make([]SubsystemResponse, len(d.subsystems)),
+	}
+
+	var wg sync.WaitGroup
+	for i := range d.subsystems {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response.Subsystems[i] = d.checkSubsystem(ctx, d.subsystems[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for _, s := range response.Subsystems {
+		if s.Status != StatusPass {
+			response.Status = s.Status
+		}
+	}
+	sort.Slice(response.Subsystems, func(i, j int) bool {
+		return response.Subsystems[i].Name < response.Subsystems[j].Name
+	})
+	return response
+}
+
+func (d *Detail) checkSubsystem(ctx context.Context, s subsystem) SubsystemResponse {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	start := d.now()
+	var resp Response
+
+	d.mu.Lock()
+	hung := d.running[s.name]
+	if !hung {
+		d.running[s.name] = true
+	}
+	d.mu.Unlock()
+
+	if hung {
+		resp = Response{
+			Status:  StatusFail,
+			Message: "previous check has not completed",
+		}
+	} else {
+		done := make(chan Response, 1)
+		go func() {
+			r := s.checker.Check(ctx)
+			d.mu.Lock()
+			delete(d.running, s.name)
+			d.mu.Unlock()
+			done <- r
+		}()
+
+		select {
+		case resp = <-done:
+		case <-ctx.Done():
+			resp = Response{
+				Status:  StatusFail,
+				Message: fmt.Sprintf("check did not complete within %s", d.timeout),
+			}
+		}
+	}
+	end := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if resp.Status != StatusPass {
+		d.lastErrors[s.name] = LastError{Message: resp.Message, Time: end.UTC()}
+	}
+
+	sr := SubsystemResponse{
+		Name:      s.name,
+		Status:    resp.Status,
+		Message:   resp.Message,
+		LatencyMs: float64(end.Sub(start)) / float64(time.Millisecond),
+	}
+	if lastErr, ok := d.lastErrors[s.name]; ok {
+		sr.LastError = &lastErr
+	}
+	return sr
+}
+
+// ServeHTTP serves the result of the checks as JSON. It responds 200 OK
+// when all subsystems pass and 503 Service Unavailable otherwise.
+func (d *Detail) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp := d.Check(r.Context())
+
+	status := http.StatusOK
+	if resp.Status == StatusFail {
+		status = http.StatusServiceUnavailable
+	}
+
+	msg, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		msg = []byte(`{"message": "error marshaling response", "status": "fail"}`)
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintln(w, string(msg))
+}
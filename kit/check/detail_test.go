@@ -0,0 +1,144 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDetail(t *testing.T) {
+	var unhealthy int32
+	d := NewDetail(time.Second)
+	d.SetCacheTTL(0)
+	d.AddSubsystem("storage", mockPass("storage"))
+	d.AddSubsystem("kv", mockPass("kv"))
+	d.AddSubsystem("query", CheckerFunc(func(ctx context.Context) Response {
+		if atomic.LoadInt32(&unhealthy) == 0 {
+			return Pass()
+		}
+		return Response{Status: StatusFail, Message: "shutdown"}
+	}))
+
+	ts := httptest.NewServer(d)
+	defer ts.Close()
+
+	get := func() (int, DetailResponse) {
+		t.Helper()
+		resp, err := http.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var dr DetailResponse
+		if err := json.NewDecoder(resp.Body).Decode(&dr); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, dr
+	}
+
+	code, resp := get()
+	if code != http.StatusOK || resp.Status != StatusPass {
+		t.Fatalf("expected healthy response, got %d %s", code, resp.Status)
+	}
+	names := []string{"kv", "query", "storage"}
+	if len(resp.Subsystems) != len(names) {
+		t.Fatalf("expected %d subsystems, got %d", len(names), len(resp.Subsystems))
+	}
+	for i, s := range resp.Subsystems {
+		if s.Name != names[i] {
+			t.Errorf("expected subsystem %q at %d, got %q", names[i], i, s.Name)
+		}
+		if s.LastError != nil {
+			t.Errorf("expected no last error for %q", s.Name)
+		}
+	}
+
+	atomic.StoreInt32(&unhealthy, 1)
+	code, resp = get()
+	if code != http.StatusServiceUnavailable || resp.Status != StatusFail {
+		t.Fatalf("expected unhealthy response, got %d %s", code, resp.Status)
+	}
+	query := resp.Subsystems[1]
+	if query.Status != StatusFail || query.LastError == nil || query.LastError.Message != "shutdown" {
+		t.Fatalf("expected failed query subsystem, got %+v", query)
+	}
+
+	// the last error is reported after the subsystem recovered
+	atomic.StoreInt32(&unhealthy, 0)
+	code, resp = get()
+	if code != http.StatusOK {
+		t.Fatalf("expected healthy response, got %d", code)
+	}
+	query = resp.Subsystems[1]
+	if query.Status != StatusPass || query.LastError == nil || query.LastError.Message != "shutdown" {
+		t.Fatalf("expected recovered query subsystem with last error, got %+v", query)
+	}
+}
+
+func TestDetailTimeout(t *testing.T) {
+	d := NewDetail(10 * time.Millisecond)
+	block := make(chan struct{})
+	defer close(block)
+	d.AddSubsystem("slow", CheckerFunc(func(ctx context.Context) Response {
+		<-block
+		return Pass()
+	}))
+
+	resp := d.Check(context.Background())
+	if resp.Status != StatusFail {
+		t.Fatalf("expected slow subsystem to fail, got %s", resp.Status)
+	}
+	if s := resp.Subsystems[0]; s.LastError == nil || s.LatencyMs < 10 {
+		t.Fatalf("expected timed out subsystem, got %+v", s)
+	}
+}
+
+func TestDetailHungCheck(t *testing.T) {
+	d := NewDetail(10 * time.Millisecond)
+	d.SetCacheTTL(0)
+	var calls int32
+	block := make(chan struct{})
+	defer close(block)
+	d.AddSubsystem("slow", CheckerFunc(func(ctx context.Context) Response {
+		atomic.AddInt32(&calls, 1)
+		<-block
+		return Pass()
+	}))
+
+	for i := 0; i < 3; i++ {
+		if resp := d.Check(context.Background()); resp.Status != StatusFail {
+			t.Fatalf("expected slow subsystem to fail, got %s", resp.Status)
+		}
+	}
+	// the hung check is not started again
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("expected a single check of the hung subsystem, got %d", n)
+	}
+}
+
+func TestDetailCache(t *testing.T) {
+	d := NewDetail(time.Second)
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	var calls int32
+	d.AddSubsystem("kv", CheckerFunc(func(ctx context.Context) Response {
+		atomic.AddInt32(&calls, 1)
+		return Pass()
+	}))
+
+	d.Check(context.Background())
+	d.Check(context.Background())
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("expected the cached result to be reused, got %d checks", n)
+	}
+
+	now = now.Add(DefaultDetailCacheTTL)
+	d.Check(context.Background())
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("expected the expired result to be checked again, got %d checks", n)
+	}
+}
@@ -24,6 +24,7 @@ type Opt struct {
 	Hidden     bool
 	Persistent bool
 	Required   bool
+	Secret     bool // the value holds a credential and is never exposed, e.g. in diagnostics
 	Short      rune // using rune b/c it guarantees correctness. a short must always be a string of length 1
 
 	Default interface{}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"strings"
 
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/opentracing/opentracing-go"
 )
 
 type contextKey string
 
-const featureContextKey contextKey = "influx/feature/v1"
+const (
+	featureContextKey contextKey = "influx/feature/v1"
+	orgIDContextKey   contextKey = "influx/feature/org/v1"
+)
 
 // Flagger returns flag values.
 type Flagger interface {
@@ -51,6 +55,20 @@ func FlagsFromContext(ctx context.Context) map[string]interface{} {
 	return v
 }
 
+// WithOrgID returns a context the flags of the organization with the given
+// ID are computed for when its authorizer is not bound to an organization,
+// as sessions are not.
+func WithOrgID(ctx context.Context, orgID platform.ID) context.Context {
+	return context.WithValue(ctx, orgIDContextKey, orgID)
+}
+
+// OrgIDFromContext returns the organization ID attached to the context by
+// WithOrgID.
+func OrgIDFromContext(ctx context.Context) (platform.ID, bool) {
+	id, ok := ctx.Value(orgIDContextKey).(platform.ID)
+	return id, ok
+}
+
 type ByKeyFn func(string) (Flag, bool)
 
 // ExposedFlagsFromContext returns the filtered map of exposed  flags attached
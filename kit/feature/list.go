@@ -30,6 +30,20 @@ func GroupWindowAggregateTranspose() BoolFlag {
 	return groupWindowAggregateTranspose
 }
 
+var mergeFiltersRule = MakeBoolFlag(
+	"Merge Filters Rule",
+	"mergeFiltersRule",
+	"Query Team",
+	false,
+	Temporary,
+	false,
+)
+
+// MergeFiltersRule - Enables the MergeFiltersRule planner rule, which merges consecutive filters into one
+func MergeFiltersRule() BoolFlag {
+	return mergeFiltersRule
+}
+
 var newLabels = MakeBoolFlag(
 	"New Label Package",
 	"newLabels",
@@ -170,9 +184,38 @@ func NewDashboardAutorefresh() BoolFlag {
 	return newAutoRefresh
 }
 
+var templateJsonnet = MakeBoolFlag(
+	"Template Jsonnet",
+	"templateJsonnet",
+	"Compute Team",
+	false,
+	Permanent,
+	false,
+)
+
+// TemplateJsonnet - Allows templates written in jsonnet to be applied for the org
+func TemplateJsonnet() BoolFlag {
+	return templateJsonnet
+}
+
+var storageReadsService = MakeBoolFlag(
+	"Storage Reads Service",
+	"storageReadsService",
+	"Storage Team",
+	false,
+	Permanent,
+	false,
+)
+
+// StorageReadsService - Serves the storage reads gRPC API to external query engines
+func StorageReadsService() BoolFlag {
+	return storageReadsService
+}
+
 var all = []Flag{
 	appMetrics,
 	groupWindowAggregateTranspose,
+	mergeFiltersRule,
 	newLabels,
 	memoryOptimizedFill,
 	memoryOptimizedSchemaMutation,
@@ -183,11 +226,14 @@ var all = []Flag{
 	cursorAtEOF,
 	refreshSingleCell,
 	newAutoRefresh,
+	templateJsonnet,
+	storageReadsService,
 }
 
 var byKey = map[string]Flag{
 	"appMetrics":                    appMetrics,
 	"groupWindowAggregateTranspose": groupWindowAggregateTranspose,
+	"mergeFiltersRule":              mergeFiltersRule,
 	"newLabels":                     newLabels,
 	"memoryOptimizedFill":           memoryOptimizedFill,
 	"memoryOptimizedSchemaMutation": memoryOptimizedSchemaMutation,
@@ -198,4 +244,6 @@ var byKey = map[string]Flag{
 	"cursorAtEOF":                   cursorAtEOF,
 	"refreshSingleCell":             refreshSingleCell,
 	"newAutoRefresh":                newAutoRefresh,
+	"templateJsonnet":               templateJsonnet,
+	"storageReadsService":           storageReadsService,
 }
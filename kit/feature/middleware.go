@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	"go.uber.org/zap"
 )
 
@@ -30,9 +31,15 @@ func NewHandler(log *zap.Logger, flagger Flagger, flags []Flag, next http.Handle
 }
 
 // ServeHTTP annotates the request context with a map of computed feature flags before
-// continuing to serve the request.
+// continuing to serve the request. The flags are computed for the organization of the
+// orgID query parameter when the authorizer is not bound to one.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	ctx, err := Annotate(r.Context(), h.flagger, h.flags...)
+	ctx := r.Context()
+	if orgID, err := platform.IDFromString(r.URL.Query().Get("orgID")); err == nil {
+		ctx = WithOrgID(ctx, *orgID)
+	}
+
+	ctx, err := Annotate(ctx, h.flagger, h.flags...)
 	if err != nil {
 		h.log.Warn("Unable to annotate context with feature flags", zap.Error(err))
 	} else {
@@ -35,6 +35,24 @@ func Test_Handler(t *testing.T) {
 	}
 }
 
+func Test_HandlerOrgID(t *testing.T) {
+	handler := &checkHandler{t: t, f: func(t *testing.T, r *http.Request) {
+		orgID, ok := feature.OrgIDFromContext(r.Context())
+		if !ok || orgID != 1 {
+			t.Errorf("expected the org of the request on the context, got %v", orgID)
+		}
+	}}
+
+	subject := feature.NewHandler(zaptest.NewLogger(t), feature.DefaultFlagger(), feature.Flags(), handler)
+
+	r := httptest.NewRequest(http.MethodGet, "http://nowhere.test?orgID=0000000000000001", nil)
+	subject.ServeHTTP(&httptest.ResponseRecorder{}, r)
+
+	if !handler.called {
+		t.Error("expected handler to be called")
+	}
+}
+
 type checkHandler struct {
 	t      *testing.T
 	f      func(t *testing.T, r *http.Request)
@@ -0,0 +1,202 @@
+package override
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+// PrefixOrgOverrides is the route the overrides of the flags of
+// organizations are served at, beneath the computed flags.
+const PrefixOrgOverrides = "/api/v2/flags/orgs"
+
+// Handler serves the overrides of the flags of organizations to operators,
+// so a flag can be rolled out organization by organization without
+// restarting the server. The overrides set through it are kept in memory.
+type Handler struct {
+	chi.Router
+
+	log     *zap.Logger
+	api     *kithttp.API
+	flagger Flagger
+}
+
+// NewHTTPHandler constructs a handler listing, replacing and removing the
+// overrides of the flags of organizations.
+func NewHTTPHandler(log *zap.Logger, flagger Flagger) *Handler {
+	h := &Handler{
+		log:     log,
+		api:     kithttp.NewAPI(kithttp.WithLog(log)),
+		flagger: flagger,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+		h.mwAuthorize,
+	)
+
+	r.Get("/", h.handleGetAllOrgOverrides)
+	r.Route("/{orgID}", func(r chi.Router) {
+		r.Get("/", h.handleGetOrgOverrides)
+		r.Put("/", h.handlePutOrgOverrides)
+		r.Delete("/", h.handleDeleteOrgOverrides)
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix is the route the handler is mounted at.
+func (h *Handler) Prefix() string {
+	return PrefixOrgOverrides
+}
+
+type orgOverridesResponse struct {
+	OrgID platform.ID            `json:"orgID"`
+	Flags map[string]interface{} `json:"flags"`
+}
+
+type allOrgOverridesResponse struct {
+	Orgs []orgOverridesResponse `json:"orgs"`
+}
+
+func (h *Handler) orgOverridesResponse(orgID platform.ID, overrides map[string]string) (orgOverridesResponse, error) {
+	resp := orgOverridesResponse{
+		OrgID: orgID,
+		Flags: make(map[string]interface{}, len(overrides)),
+	}
+	for k, v := range overrides {
+		flag, found := h.flagger.byKey(k)
+		if !found {
+			continue
+		}
+		iface, err := h.flagger.coerce(v, flag)
+		if err != nil {
+			return orgOverridesResponse{}, err
+		}
+		resp.Flags[k] = iface
+	}
+	return resp, nil
+}
+
+// handleGetAllOrgOverrides is the HTTP handler for the GET /api/v2/flags/orgs route.
+func (h *Handler) handleGetAllOrgOverrides(w http.ResponseWriter, r *http.Request) {
+	all := h.flagger.AllOrgOverrides()
+	resp := allOrgOverridesResponse{Orgs: make([]orgOverridesResponse, 0, len(all))}
+	for orgID, overrides := range all {
+		o, err := h.orgOverridesResponse(orgID, overrides)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		resp.Orgs = append(resp.Orgs, o)
+	}
+	h.api.Respond(w, r, http.StatusOK, resp)
+}
+
+// handleGetOrgOverrides is the HTTP handler for the GET /api/v2/flags/orgs/:orgID route.
+func (h *Handler) handleGetOrgOverrides(w http.ResponseWriter, r *http.Request) {
+	orgID, err := platform.IDFromString(chi.URLParam(r, "orgID"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	resp, err := h.orgOverridesResponse(*orgID, h.flagger.OrgOverrides(*orgID))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, resp)
+}
+
+// handlePutOrgOverrides is the HTTP handler for the PUT /api/v2/flags/orgs/:orgID route.
+// The body maps the keys of the flags to their values, replacing the
+// overrides of the organization.
+func (h *Handler) handlePutOrgOverrides(w http.ResponseWriter, r *http.Request) {
+	orgID, err := platform.IDFromString(chi.URLParam(r, "orgID"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var body map[string]interface{}
+	if err := h.api.DecodeJSON(r.Body, &body); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	overrides := make(map[string]string, len(body))
+	for k, v := range body {
+		switch v := v.(type) {
+		case bool:
+			overrides[k] = strconv.FormatBool(v)
+		case float64:
+			overrides[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		case string:
+			overrides[k] = v
+		default:
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("override of flag %s must be a boolean, a number or a string", k),
+			})
+			return
+		}
+	}
+	if err := h.flagger.SetOrgOverrides(*orgID, overrides); err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		})
+		return
+	}
+	h.log.Info("Set feature flag overrides", zap.Stringer("orgID", orgID), zap.Any("overrides", overrides))
+
+	resp, err := h.orgOverridesResponse(*orgID, h.flagger.OrgOverrides(*orgID))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, resp)
+}
+
+// handleDeleteOrgOverrides is the HTTP handler for the DELETE /api/v2/flags/orgs/:orgID route.
+func (h *Handler) handleDeleteOrgOverrides(w http.ResponseWriter, r *http.Request) {
+	orgID, err := platform.IDFromString(chi.URLParam(r, "orgID"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.flagger.SetOrgOverrides(*orgID, nil); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Info("Removed feature flag overrides", zap.Stringer("orgID", orgID))
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
+
+func (h *Handler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("access to %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,72 @@
+package override
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/feature"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHandler(t *testing.T) {
+	byKey := newByKey(map[string]feature.Flag{
+		"flag0": newFlag("flag0", false),
+	})
+	flagger, err := Make(nil, byKey)
+	if err != nil {
+		t.Fatalf("unexpected error making Flagger: %v", err)
+	}
+	h := NewHTTPHandler(zaptest.NewLogger(t), flagger)
+
+	do := func(auth influxdb.Authorizer, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if auth != nil {
+			r = r.WithContext(icontext.SetAuthorizer(context.Background(), auth))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	operator := &influxdb.Authorization{Status: influxdb.Active, Permissions: influxdb.OperPermissions()}
+
+	if w := do(&influxdb.Authorization{OrgID: 1, Status: influxdb.Active}, http.MethodPut, "/0000000000000001", `{"flag0": true}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected non operators to be rejected, got %d", w.Code)
+	}
+
+	if w := do(operator, http.MethodPut, "/0000000000000001", `{"flag0": true}`); w.Code != http.StatusOK {
+		t.Fatalf("unexpected status setting overrides: %d %s", w.Code, w.Body.String())
+	}
+	computed, _ := flagger.Flags(icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{OrgID: 1}), newFlag("flag0", false))
+	if computed["flag0"] != true {
+		t.Errorf("expected the override to apply to the org, got %v", computed)
+	}
+
+	w := do(operator, http.MethodGet, "/0000000000000001", "")
+	var resp orgOverridesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("unexpected error decoding response: %v", err)
+	}
+	if resp.OrgID != 1 || resp.Flags["flag0"] != true {
+		t.Errorf("unexpected overrides: %+v", resp)
+	}
+
+	for _, body := range []string{`{"dne": true}`, `{"flag0": "notabool"}`, `{"flag0": [true]}`} {
+		if w := do(operator, http.MethodPut, "/0000000000000001", body); w.Code != http.StatusBadRequest {
+			t.Errorf("expected invalid overrides %s to be rejected, got %d", body, w.Code)
+		}
+	}
+
+	if w := do(operator, http.MethodDelete, "/0000000000000001", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status removing overrides: %d", w.Code)
+	}
+	if all := flagger.AllOrgOverrides(); len(all) != 0 {
+		t.Errorf("expected no org overrides, got %v", all)
+	}
+}
@@ -5,26 +5,61 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 )
 
 // Flagger can override default flag values.
 type Flagger struct {
-	overrides map[string]string
-	byKey     feature.ByKeyFn
+	overrides    map[string]string
+	orgOverrides *orgOverrides
+	byKey        feature.ByKeyFn
+}
+
+// orgOverrides are the overrides of the flags of every organization, which
+// may change while the flags are computed.
+type orgOverrides struct {
+	mu sync.RWMutex
+	m  map[platform.ID]map[string]string
 }
 
 // Make a Flagger that returns defaults with any overrides parsed from the string.
+//
+// An override keyed by an organization ID and a flag key separated by a
+// colon, such as 0123456789abcdef:flagKey, only applies to the requests
+// of that organization and takes precedence over the override of the flag
+// for all organizations. The overrides of an organization can be changed
+// later with SetOrgOverrides.
 func Make(overrides map[string]string, byKey feature.ByKeyFn) (Flagger, error) {
 	if byKey == nil {
 		byKey = feature.ByKey
 	}
 
-	// Check all provided override keys correspond to an existing Flag.
+	global := make(map[string]string, len(overrides))
+	orgOverrides := make(map[platform.ID]map[string]string)
 	var missing []string
-	for k := range overrides {
-		if _, found := byKey(k); !found {
+	for k, v := range overrides {
+		key := k
+		if i := strings.Index(k, ":"); i >= 0 {
+			orgID, err := platform.IDFromString(k[:i])
+			if err != nil {
+				return Flagger{}, fmt.Errorf("configured override %s for invalid organization ID: %v", k, err)
+			}
+			key = k[i+1:]
+			if orgOverrides[*orgID] == nil {
+				orgOverrides[*orgID] = make(map[string]string)
+			}
+			orgOverrides[*orgID][key] = v
+		} else {
+			global[key] = v
+		}
+
+		// Check all provided override keys correspond to an existing Flag.
+		if _, found := byKey(key); !found {
 			missing = append(missing, k)
 		}
 	}
@@ -33,20 +68,86 @@ func Make(overrides map[string]string, byKey feature.ByKeyFn) (Flagger, error) {
 	}
 
 	return Flagger{
-		overrides: overrides,
-		byKey:     byKey,
+		overrides:    global,
+		orgOverrides: &orgOverrides{m: orgOverrides},
+		byKey:        byKey,
 	}, nil
 }
 
-// Flags returns a map of default values with overrides applied. It never returns an error.
-func (f Flagger) Flags(_ context.Context, flags ...feature.Flag) (map[string]interface{}, error) {
+// OrgOverrides returns the overrides of the flags of an organization.
+func (f Flagger) OrgOverrides(orgID platform.ID) map[string]string {
+	f.orgOverrides.mu.RLock()
+	defer f.orgOverrides.mu.RUnlock()
+
+	m := make(map[string]string, len(f.orgOverrides.m[orgID]))
+	for k, v := range f.orgOverrides.m[orgID] {
+		m[k] = v
+	}
+	return m
+}
+
+// AllOrgOverrides returns the overrides of the flags of every organization
+// with any.
+func (f Flagger) AllOrgOverrides() map[platform.ID]map[string]string {
+	f.orgOverrides.mu.RLock()
+	defer f.orgOverrides.mu.RUnlock()
+
+	all := make(map[platform.ID]map[string]string, len(f.orgOverrides.m))
+	for orgID, overrides := range f.orgOverrides.m {
+		m := make(map[string]string, len(overrides))
+		for k, v := range overrides {
+			m[k] = v
+		}
+		all[orgID] = m
+	}
+	return all
+}
+
+// SetOrgOverrides replaces the overrides of the flags of an organization,
+// taking effect for the flags computed from then on. Empty overrides remove
+// those of the organization. An error is returned when a key is not that of
+// an existing flag or a value is not of the type of its flag.
+func (f Flagger) SetOrgOverrides(orgID platform.ID, overrides map[string]string) error {
+	m := make(map[string]string, len(overrides))
+	for k, v := range overrides {
+		flag, found := f.byKey(k)
+		if !found {
+			return fmt.Errorf("override for non-existent flag %s", k)
+		}
+		if _, err := f.coerce(v, flag); err != nil {
+			return err
+		}
+		m[k] = v
+	}
+
+	f.orgOverrides.mu.Lock()
+	defer f.orgOverrides.mu.Unlock()
+	if len(m) == 0 {
+		delete(f.orgOverrides.m, orgID)
+	} else {
+		f.orgOverrides.m[orgID] = m
+	}
+	return nil
+}
+
+// Flags returns a map of default values with overrides applied, including
+// those of the organization of the authorization on the context.
+func (f Flagger) Flags(ctx context.Context, flags ...feature.Flag) (map[string]interface{}, error) {
 	if len(flags) == 0 {
 		flags = feature.Flags()
 	}
 
+	f.orgOverrides.mu.RLock()
+	orgOverrides := f.orgOverrides.m[orgID(ctx)]
+	f.orgOverrides.mu.RUnlock()
+
 	m := make(map[string]interface{}, len(flags))
 	for _, flag := range flags {
-		if s, overridden := f.overrides[flag.Key()]; overridden {
+		s, overridden := orgOverrides[flag.Key()]
+		if !overridden {
+			s, overridden = f.overrides[flag.Key()]
+		}
+		if overridden {
 			iface, err := f.coerce(s, flag)
 			if err != nil {
 				return nil, err
@@ -60,6 +161,19 @@ func (f Flagger) Flags(_ context.Context, flags ...feature.Flag) (map[string]int
 	return m, nil
 }
 
+// orgID returns the organization of the authorization on the context. A
+// session is not bound to an organization, its requests use the one
+// attached to the context with feature.WithOrgID.
+func orgID(ctx context.Context) platform.ID {
+	if a, err := icontext.GetAuthorizer(ctx); err == nil {
+		if auth, ok := a.(*influxdb.Authorization); ok {
+			return auth.OrgID
+		}
+	}
+	id, _ := feature.OrgIDFromContext(ctx)
+	return id
+}
+
 func (f Flagger) coerce(s string, flag feature.Flag) (iface interface{}, err error) {
 	if base, ok := flag.(feature.Base); ok {
 		flag, _ = f.byKey(base.Key())
@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/kit/feature"
 )
 
@@ -167,6 +169,140 @@ func TestFlagger(t *testing.T) {
 	}
 }
 
+func TestFlagger_OrgOverrides(t *testing.T) {
+	byKey := newByKey(map[string]feature.Flag{
+		"flag0": newFlag("flag0", false),
+		"flag1": newFlag("flag1", "original1"),
+	})
+	subject, err := Make(map[string]string{
+		"flag1":                  "new1",
+		"0000000000000001:flag0": "true",
+		"0000000000000001:flag1": "org1",
+		"0000000000000002:flag1": "org2",
+	}, byKey)
+	if err != nil {
+		t.Fatalf("unexpected error making Flagger: %v", err)
+	}
+
+	for _, test := range []struct {
+		name     string
+		ctx      context.Context
+		expected map[string]interface{}
+	}{
+		{
+			name:     "no authorization",
+			ctx:      context.Background(),
+			expected: map[string]interface{}{"flag0": false, "flag1": "new1"},
+		},
+		{
+			name:     "org with overrides",
+			ctx:      icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{OrgID: 1}),
+			expected: map[string]interface{}{"flag0": true, "flag1": "org1"},
+		},
+		{
+			name:     "org overriding a single flag",
+			ctx:      icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{OrgID: 2}),
+			expected: map[string]interface{}{"flag0": false, "flag1": "org2"},
+		},
+		{
+			name:     "org without overrides",
+			ctx:      icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{OrgID: 3}),
+			expected: map[string]interface{}{"flag0": false, "flag1": "new1"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			computed, err := subject.Flags(test.ctx, newFlag("flag0", false), newFlag("flag1", "original1"))
+			if err != nil {
+				t.Fatalf("unexpected error calling Flags: %v", err)
+			}
+			for k, xv := range test.expected {
+				if v := computed[k]; v != xv {
+					t.Errorf("incorrect value for key %s: expected %v [%T], got %v [%T]", k, xv, xv, v, v)
+				}
+			}
+		})
+	}
+}
+
+func TestFlagger_InvalidOrgOverrides(t *testing.T) {
+	byKey := newByKey(map[string]feature.Flag{
+		"flag0": newFlag("flag0", false),
+	})
+	for _, overrides := range []map[string]string{
+		{"notanid:flag0": "true"},
+		{"0000000000000001:dne": "true"},
+	} {
+		if _, err := Make(overrides, byKey); err == nil {
+			t.Errorf("expected error making Flagger with overrides %v", overrides)
+		}
+	}
+}
+
+func TestFlagger_SetOrgOverrides(t *testing.T) {
+	byKey := newByKey(map[string]feature.Flag{
+		"flag0": newFlag("flag0", false),
+		"flag1": newFlag("flag1", "original1"),
+	})
+	subject, err := Make(map[string]string{
+		"0000000000000001:flag1": "org1",
+	}, byKey)
+	if err != nil {
+		t.Fatalf("unexpected error making Flagger: %v", err)
+	}
+
+	flags := func(ctx context.Context) map[string]interface{} {
+		t.Helper()
+		computed, err := subject.Flags(ctx, newFlag("flag0", false), newFlag("flag1", "original1"))
+		if err != nil {
+			t.Fatalf("unexpected error calling Flags: %v", err)
+		}
+		return computed
+	}
+	org1 := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{OrgID: 1})
+
+	if err := subject.SetOrgOverrides(1, map[string]string{"flag0": "true"}); err != nil {
+		t.Fatalf("unexpected error setting overrides: %v", err)
+	}
+	if computed := flags(org1); computed["flag0"] != true || computed["flag1"] != "original1" {
+		t.Errorf("expected the overrides of the org to be replaced, got %v", computed)
+	}
+
+	// a session computes the flags of the org on the context
+	session := icontext.SetAuthorizer(context.Background(), &influxdb.Session{UserID: 1})
+	if computed := flags(session); computed["flag0"] != false {
+		t.Errorf("expected no overrides for a session without org, got %v", computed)
+	}
+	if computed := flags(feature.WithOrgID(session, 1)); computed["flag0"] != true {
+		t.Errorf("expected the overrides of the org of the session, got %v", computed)
+	}
+	// the org of an authorization takes precedence
+	if computed := flags(feature.WithOrgID(org1, 2)); computed["flag0"] != true {
+		t.Errorf("expected the overrides of the org of the authorization, got %v", computed)
+	}
+
+	for _, overrides := range []map[string]string{
+		{"dne": "true"},
+		{"flag0": "notabool"},
+	} {
+		if err := subject.SetOrgOverrides(1, overrides); err == nil {
+			t.Errorf("expected error setting overrides %v", overrides)
+		}
+	}
+	if computed := flags(org1); computed["flag0"] != true {
+		t.Errorf("expected invalid overrides to be ignored, got %v", computed)
+	}
+
+	if err := subject.SetOrgOverrides(1, nil); err != nil {
+		t.Fatalf("unexpected error removing overrides: %v", err)
+	}
+	if computed := flags(org1); computed["flag0"] != false {
+		t.Errorf("expected the overrides of the org to be removed, got %v", computed)
+	}
+	if all := subject.AllOrgOverrides(); len(all) != 0 {
+		t.Errorf("expected no org overrides, got %v", all)
+	}
+}
+
 func newFlag(key string, defaultValue interface{}) feature.Flag {
 	return feature.MakeFlag(key, key, "", defaultValue, feature.Temporary, false)
 }
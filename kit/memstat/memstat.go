@@ -0,0 +1,105 @@
+// Package memstat attributes process memory to the components that hold it,
+// so that memory investigations do not have to start from a bare heap profile.
+package memstat
+
+import (
+	"runtime"
+	"sort"
+)
+
+// Component names reported by the built-in reporters.
+const (
+	ComponentTSMCache        = "tsm_cache"
+	ComponentTSIIndex        = "tsi_index"
+	ComponentQueryController = "query_controller"
+	ComponentTaskExecutor    = "task_executor"
+)
+
+// Usage is the memory held by a component, optionally broken down by name
+// (i.e. the bucket a cache belongs to or the org a query runs for).
+type Usage struct {
+	Component string `json:"component"`
+	Name      string `json:"name,omitempty"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// Reporter is implemented by components that account for the memory they hold.
+type Reporter interface {
+	MemoryUsage() []Usage
+}
+
+// ReporterFunc adapts a function to the Reporter interface.
+type ReporterFunc func() []Usage
+
+// MemoryUsage calls fn.
+func (fn ReporterFunc) MemoryUsage() []Usage {
+	return fn()
+}
+
+// Heap is a summary of the Go runtime memory statistics.
+type Heap struct {
+	Alloc     uint64 `json:"alloc"`
+	Sys       uint64 `json:"sys"`
+	HeapInuse uint64 `json:"heapInuse"`
+	HeapIdle  uint64 `json:"heapIdle"`
+	Objects   uint64 `json:"objects"`
+	NumGC     uint32 `json:"numGC"`
+}
+
+// ComponentTotal is the sum of the memory attributed to a single component.
+type ComponentTotal struct {
+	Component string `json:"component"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// Report is a point in time attribution of process memory.
+type Report struct {
+	Heap         Heap             `json:"heap"`
+	Totals       []ComponentTotal `json:"totals"`
+	Components   []Usage          `json:"components"`
+	Attributed   int64            `json:"attributed"`
+	Unattributed int64            `json:"unattributed"`
+}
+
+// Collect gathers the usage of all reporters along with the runtime heap statistics.
+func Collect(reporters ...Reporter) Report {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	report := Report{
+		Heap: Heap{
+			Alloc:     ms.Alloc,
+			Sys:       ms.Sys,
+			HeapInuse: ms.HeapInuse,
+			HeapIdle:  ms.HeapIdle,
+			Objects:   ms.HeapObjects,
+			NumGC:     ms.NumGC,
+		},
+		Totals:     []ComponentTotal{},
+		Components: []Usage{},
+	}
+
+	totals := make(map[string]int64)
+	for _, r := range reporters {
+		for _, u := range r.MemoryUsage() {
+			report.Components = append(report.Components, u)
+			report.Attributed += u.Bytes
+			totals[u.Component] += u.Bytes
+		}
+	}
+
+	for component, bytes := range totals {
+		report.Totals = append(report.Totals, ComponentTotal{Component: component, Bytes: bytes})
+	}
+	sort.Slice(report.Totals, func(i, j int) bool {
+		return report.Totals[i].Bytes > report.Totals[j].Bytes
+	})
+	sort.SliceStable(report.Components, func(i, j int) bool {
+		return report.Components[i].Bytes > report.Components[j].Bytes
+	})
+
+	if unattributed := int64(ms.Alloc) - report.Attributed; unattributed > 0 {
+		report.Unattributed = unattributed
+	}
+	return report
+}
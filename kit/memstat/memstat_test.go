@@ -0,0 +1,47 @@
+package memstat_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/kit/memstat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollect(t *testing.T) {
+	storage := memstat.ReporterFunc(func() []memstat.Usage {
+		return []memstat.Usage{
+			{Component: memstat.ComponentTSMCache, Name: "bucket-1", Bytes: 10},
+			{Component: memstat.ComponentTSMCache, Name: "bucket-2", Bytes: 30},
+			{Component: memstat.ComponentTSIIndex, Name: "bucket-1", Bytes: 5},
+		}
+	})
+	queries := memstat.ReporterFunc(func() []memstat.Usage {
+		return []memstat.Usage{
+			{Component: memstat.ComponentQueryController, Name: "org-1", Bytes: 20},
+		}
+	})
+
+	report := memstat.Collect(storage, queries)
+
+	assert.Equal(t, int64(65), report.Attributed)
+	assert.Equal(t, []memstat.ComponentTotal{
+		{Component: memstat.ComponentTSMCache, Bytes: 40},
+		{Component: memstat.ComponentQueryController, Bytes: 20},
+		{Component: memstat.ComponentTSIIndex, Bytes: 5},
+	}, report.Totals)
+
+	require.Len(t, report.Components, 4)
+	assert.Equal(t, "bucket-2", report.Components[0].Name)
+	assert.NotZero(t, report.Heap.Alloc)
+	assert.Equal(t, int64(report.Heap.Alloc)-report.Attributed, report.Unattributed)
+}
+
+func TestCollect_NoReporters(t *testing.T) {
+	report := memstat.Collect()
+
+	assert.Empty(t, report.Totals)
+	assert.NotNil(t, report.Components)
+	assert.Zero(t, report.Attributed)
+	assert.Equal(t, int64(report.Heap.Alloc), report.Unattributed)
+}
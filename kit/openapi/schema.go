@@ -0,0 +1,360 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError is a field of a request body that does not match its schema.
+type FieldError struct {
+	// Field is the path of the field in the body, such as
+	// retentionRules[0].everySeconds. It is empty for the body itself.
+	Field   string
+	Message string
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// FieldErrors are all the fields of a request body that do not match their
+// schemas.
+type FieldErrors []FieldError
+
+// Error implements the error interface.
+func (e FieldErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+var (
+	patternsMu sync.Mutex
+	patterns   = make(map[string]*regexp.Regexp)
+)
+
+// compilePattern returns the compiled pattern of a schema, or nil when the
+// pattern is not a valid Go regular expression.
+func compilePattern(pattern string) *regexp.Regexp {
+	patternsMu.Lock()
+	defer patternsMu.Unlock()
+
+	re, ok := patterns[pattern]
+	if !ok {
+		re, _ = regexp.Compile(pattern)
+		patterns[pattern] = re
+	}
+	return re
+}
+
+// validate validates value against schema, appending the fields that do
+// not match to errs. Keywords that are not supported are ignored, so a
+// value is only rejected for what the validator understands.
+func (v *Validator) validate(schema, value interface{}, field string, errs *FieldErrors) {
+	s, ok := v.resolve(schema).(map[string]interface{})
+	if !ok {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value == nil {
+		if nullable, _ := s["nullable"].(bool); !nullable {
+			if typ, ok := s["type"].(string); ok {
+				fail("must be %s, got null", withArticle(typ))
+			}
+		}
+		return
+	}
+
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			v.validate(sub, value, field, errs)
+		}
+	}
+	if one, ok := s["oneOf"].([]interface{}); ok {
+		v.validateOneOf(s, one, value, field, errs)
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok && !v.matchesAny(anyOf, value) {
+		fail("does not match any of the allowed schemas")
+	}
+
+	if typ, ok := s["type"].(string); ok && !hasType(value, typ) {
+		fail("must be %s, got %s", withArticle(typ), typeOf(value))
+		return
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok && !inEnum(enum, value) {
+		fail("must be one of %s", formatEnum(enum))
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(s, val, field, errs)
+	case []interface{}:
+		if n, ok := number(s["minItems"]); ok && float64(len(val)) < n {
+			fail("must have at least %s items", formatNumber(n))
+		}
+		if n, ok := number(s["maxItems"]); ok && float64(len(val)) > n {
+			fail("must have at most %s items", formatNumber(n))
+		}
+		if items, ok := s["items"]; ok {
+			for i, item := range val {
+				v.validate(items, item, fmt.Sprintf("%s[%d]", field, i), errs)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(val))
+		if n, ok := number(s["minLength"]); ok && length < n {
+			fail("must be at least %s characters long", formatNumber(n))
+		}
+		if n, ok := number(s["maxLength"]); ok && length > n {
+			fail("must be at most %s characters long", formatNumber(n))
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			if re := compilePattern(pattern); re != nil && !re.MatchString(val) {
+				fail("must match the pattern %q", pattern)
+			}
+		}
+	case json.Number:
+		f, _ := val.Float64()
+		if n, ok := number(s["minimum"]); ok {
+			if exclusive, _ := s["exclusiveMinimum"].(bool); exclusive && f <= n {
+				fail("must be greater than %s", formatNumber(n))
+			} else if f < n {
+				fail("must be at least %s", formatNumber(n))
+			}
+		}
+		if n, ok := number(s["maximum"]); ok {
+			if exclusive, _ := s["exclusiveMaximum"].(bool); exclusive && f >= n {
+				fail("must be less than %s", formatNumber(n))
+			} else if f > n {
+				fail("must be at most %s", formatNumber(n))
+			}
+		}
+	}
+}
+
+func (v *Validator) validateObject(s, obj map[string]interface{}, field string, errs *FieldErrors) {
+	props, _ := s["properties"].(map[string]interface{})
+
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, name := range required {
+			name, ok := name.(string)
+			if !ok {
+				continue
+			}
+			if _, ok := obj[name]; ok || v.readOnly(props[name]) {
+				continue
+			}
+			*errs = append(*errs, FieldError{Field: joinField(field, name), Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if prop, ok := props[name]; ok {
+			// Read-only properties are ignored in requests, such as
+			// the links of a resource sent back when it is updated.
+			if !v.readOnly(prop) {
+				v.validate(prop, obj[name], joinField(field, name), errs)
+			}
+			continue
+		}
+		switch additional := s["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*errs = append(*errs, FieldError{Field: joinField(field, name), Message: "is not a known property"})
+			}
+		case map[string]interface{}:
+			v.validate(additional, obj[name], joinField(field, name), errs)
+		}
+	}
+}
+
+// validateOneOf validates a value that must match one of several schemas.
+// The schema of the discriminator property is validated when the schema has
+// one. Without a discriminator the value must match any of the schemas, as
+// the schemas of the spec do not forbid additional properties and objects
+// commonly match several of them.
+func (v *Validator) validateOneOf(s map[string]interface{}, one []interface{}, value interface{}, field string, errs *FieldErrors) {
+	disc, _ := s["discriminator"].(map[string]interface{})
+	propName, _ := disc["propertyName"].(string)
+	obj, isObj := value.(map[string]interface{})
+	if propName == "" || !isObj {
+		if !v.matchesAny(one, value) {
+			*errs = append(*errs, FieldError{Field: field, Message: "does not match any of the allowed schemas"})
+		}
+		return
+	}
+
+	kind, ok := obj[propName].(string)
+	if !ok {
+		*errs = append(*errs, FieldError{Field: joinField(field, propName), Message: "is required"})
+		return
+	}
+
+	if mapping, ok := disc["mapping"].(map[string]interface{}); ok {
+		if ref, ok := mapping[kind].(string); ok {
+			v.validate(map[string]interface{}{"$ref": ref}, value, field, errs)
+			return
+		}
+	}
+	// Without a mapping the discriminator values are the names of the
+	// referenced schemas.
+	for _, sub := range one {
+		m, _ := sub.(map[string]interface{})
+		if ref, ok := m["$ref"].(string); ok && ref[strings.LastIndex(ref, "/")+1:] == kind {
+			v.validate(sub, value, field, errs)
+			return
+		}
+	}
+
+	kinds := make([]string, 0, len(one))
+	if mapping, ok := disc["mapping"].(map[string]interface{}); ok {
+		for k := range mapping {
+			kinds = append(kinds, k)
+		}
+	}
+	sort.Strings(kinds)
+	if len(kinds) > 0 {
+		*errs = append(*errs, FieldError{Field: joinField(field, propName), Message: fmt.Sprintf("must be one of [%s]", strings.Join(kinds, ", "))})
+		return
+	}
+	if !v.matchesAny(one, value) {
+		*errs = append(*errs, FieldError{Field: field, Message: "does not match any of the allowed schemas"})
+	}
+}
+
+func (v *Validator) matchesAny(schemas []interface{}, value interface{}) bool {
+	for _, sub := range schemas {
+		var errs FieldErrors
+		v.validate(sub, value, "", &errs)
+		if len(errs) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Validator) readOnly(schema interface{}) bool {
+	s, _ := v.resolve(schema).(map[string]interface{})
+	readOnly, _ := s["readOnly"].(bool)
+	return readOnly
+}
+
+func joinField(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+func hasType(value interface{}, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		if _, err := strconv.ParseInt(n.String(), 10, 64); err == nil {
+			return true
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	}
+	return true
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	}
+	return "null"
+}
+
+func withArticle(typ string) string {
+	switch typ {
+	case "object", "array", "integer":
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		switch e := e.(type) {
+		case float64:
+			if n, ok := value.(json.Number); ok {
+				if f, err := n.Float64(); err == nil && f == e {
+					return true
+				}
+			}
+		case string, bool:
+			if e == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	vals := make([]string, 0, len(enum))
+	for _, e := range enum {
+		vals = append(vals, fmt.Sprint(e))
+	}
+	return "[" + strings.Join(vals, ", ") + "]"
+}
+
+func number(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,325 @@
+// Package openapi validates the bodies of API requests against the OpenAPI
+// spec of the API, so malformed payloads are rejected with the same precise,
+// field-level errors whatever handler serves them.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+const mediaTypeJSON = "application/json"
+
+// DefaultMaxBodyBytes is the default size of the largest body the
+// middleware of a Validator buffers to validate.
+const DefaultMaxBodyBytes = 10 * 1024 * 1024
+
+// Validator validates the JSON bodies of requests against the schemas of
+// the operations of an OpenAPI 3 spec. Requests to operations the spec does
+// not describe, or that do not take a JSON body, are not validated.
+type Validator struct {
+	doc          interface{}
+	routes       []route
+	maxBodyBytes int64
+}
+
+// ValidatorOptFn is a functional option for configuring a Validator.
+type ValidatorOptFn func(*Validator)
+
+// WithMaxBodyBytes sets the size of the largest body the middleware
+// buffers to validate, larger bodies are passed to the handler unvalidated.
+// A size that is not positive keeps DefaultMaxBodyBytes.
+func WithMaxBodyBytes(n int64) ValidatorOptFn {
+	return func(v *Validator) {
+		if n > 0 {
+			v.maxBodyBytes = n
+		}
+	}
+}
+
+type route struct {
+	segments []string
+	// literals is the number of segments that are not parameters, routes
+	// with more literals take precedence as in the API router.
+	literals   int
+	operations map[string]map[string]interface{}
+}
+
+// NewValidator constructs a Validator from an OpenAPI 3 spec in JSON.
+func NewValidator(spec []byte, opts ...ValidatorOptFn) (*Validator, error) {
+	var doc interface{}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("decoding OpenAPI spec: %w", err)
+	}
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("decoding OpenAPI spec: not an object")
+	}
+	paths, ok := root["paths"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("decoding OpenAPI spec: no paths")
+	}
+
+	v := &Validator{doc: doc, maxBodyBytes: DefaultMaxBodyBytes}
+	for _, opt := range opts {
+		opt(v)
+	}
+	base := serverPath(root)
+	for p, item := range paths {
+		item, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		prefix := base
+		if _, ok := item["servers"]; ok {
+			prefix = serverPath(item)
+		}
+
+		r := route{operations: make(map[string]map[string]interface{})}
+		for _, seg := range splitPath(prefix + p) {
+			if !isParam(seg) {
+				r.literals++
+			}
+			r.segments = append(r.segments, seg)
+		}
+		for method, op := range item {
+			if op, ok := op.(map[string]interface{}); ok {
+				r.operations[strings.ToUpper(method)] = op
+			}
+		}
+		v.routes = append(v.routes, r)
+	}
+	sort.SliceStable(v.routes, func(i, j int) bool {
+		return v.routes[i].literals > v.routes[j].literals
+	})
+	return v, nil
+}
+
+// serverPath returns the path of the first server of a spec or path item,
+// which prefixes its paths.
+func serverPath(obj map[string]interface{}) string {
+	servers, _ := obj["servers"].([]interface{})
+	if len(servers) == 0 {
+		return ""
+	}
+	server, _ := servers[0].(map[string]interface{})
+	u, _ := server["url"].(string)
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+len("://"):]
+		if j := strings.Index(u, "/"); j >= 0 {
+			u = u[j:]
+		} else {
+			u = ""
+		}
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+func splitPath(p string) []string {
+	return strings.Split(strings.Trim(p, "/"), "/")
+}
+
+func isParam(seg string) bool {
+	return strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")
+}
+
+// operation returns the operation of the spec serving method and path.
+func (v *Validator) operation(method, path string) map[string]interface{} {
+	segments := splitPath(path)
+	for _, r := range v.routes {
+		if len(r.segments) != len(segments) {
+			continue
+		}
+		op, ok := r.operations[method]
+		if !ok {
+			continue
+		}
+		matched := true
+		for i, seg := range r.segments {
+			if !isParam(seg) && seg != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return op
+		}
+	}
+	return nil
+}
+
+// requestBody returns the request body object of the operation serving
+// method and path, and the schema of its JSON content. It returns nil when
+// the operation does not take a JSON body or contentType is not JSON.
+func (v *Validator) requestBody(method, path, contentType string) (map[string]interface{}, interface{}) {
+	if contentType != "" {
+		mt, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mt != mediaTypeJSON {
+			return nil, nil
+		}
+	}
+
+	op := v.operation(method, path)
+	if op == nil {
+		return nil, nil
+	}
+	reqBody, _ := v.resolve(op["requestBody"]).(map[string]interface{})
+	if reqBody == nil {
+		return nil, nil
+	}
+	content, _ := reqBody["content"].(map[string]interface{})
+	media, _ := content[mediaTypeJSON].(map[string]interface{})
+	if media == nil {
+		return nil, nil
+	}
+	return reqBody, media["schema"]
+}
+
+// Validate validates the body of a request with method to path. The body
+// is only validated when the content type is JSON or not set. It returns
+// an EInvalid error listing every field that does not match the schema.
+func (v *Validator) Validate(method, path, contentType string, body []byte) error {
+	reqBody, schema := v.requestBody(method, path, contentType)
+	if reqBody == nil {
+		return nil
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if required, _ := reqBody["required"].(bool); required {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "request body is required",
+			}
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "request body is not valid JSON",
+			Err:  err,
+		}
+	}
+
+	var errs FieldErrors
+	v.validate(schema, value, "", &errs)
+	if len(errs) > 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid request body",
+			Err:  errs,
+		}
+	}
+	return nil
+}
+
+// Middleware returns a middleware rejecting requests whose bodies do not
+// match the spec. The error of a rejected request is handled by
+// errorHandler. A nil Validator does not validate requests.
+//
+// Bodies are buffered up to the maximum body size of the Validator, larger
+// bodies are passed to the handler unvalidated, so memory stays bounded
+// whether or not the API limits the size of request bodies.
+func (v *Validator) Middleware(errorHandler errors.HTTPErrorHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if v == nil {
+			return next
+		}
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			contentType := r.Header.Get("Content-Type")
+			if reqBody, _ := v.requestBody(r.Method, r.URL.Path, contentType); reqBody == nil || !identityEncoded(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var body []byte
+			if r.Body != nil {
+				b, err := ioutil.ReadAll(io.LimitReader(r.Body, v.maxBodyBytes+1))
+				if err != nil {
+					_ = r.Body.Close()
+					errorHandler.HandleHTTPError(r.Context(), err, w)
+					return
+				}
+				if int64(len(b)) > v.maxBodyBytes {
+					r.Body = readCloser{
+						Reader: io.MultiReader(bytes.NewReader(b), r.Body),
+						Closer: r.Body,
+					}
+					next.ServeHTTP(w, r)
+					return
+				}
+				_ = r.Body.Close()
+				body = b
+			}
+			if err := v.Validate(r.Method, r.URL.Path, contentType, body); err != nil {
+				errorHandler.HandleHTTPError(r.Context(), err, w)
+				return
+			}
+
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// readCloser is the body of a request whose start was already read.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// identityEncoded reports whether the body of r is not compressed, the
+// bodies of compressed requests are passed to the handler unvalidated.
+func identityEncoded(r *http.Request) bool {
+	enc := r.Header.Get("Content-Encoding")
+	return enc == "" || strings.EqualFold(enc, "identity")
+}
+
+// resolve follows the $ref of an object of the spec to the object it
+// refers to.
+func (v *Validator) resolve(obj interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return obj
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return obj
+		}
+		obj = v.lookup(ref)
+	}
+	return nil
+}
+
+// lookup returns the object of the spec at a local reference such as
+// #/components/schemas/Bucket.
+func (v *Validator) lookup(ref string) interface{} {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	obj := v.doc
+	for _, tok := range strings.Split(ref[len("#/"):], "/") {
+		tok = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		obj = m[tok]
+	}
+	return obj
+}
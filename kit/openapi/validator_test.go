@@ -0,0 +1,348 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+const testSpec = `{
+  "openapi": "3.0.0",
+  "servers": [{"url": "/api/v2"}],
+  "paths": {
+    "/buckets": {
+      "post": {
+        "requestBody": {"$ref": "#/components/requestBodies/PostBucket"}
+      }
+    },
+    "/buckets/{bucketID}": {
+      "patch": {
+        "requestBody": {
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/PatchBucket"}}}
+        }
+      }
+    },
+    "/buckets/{bucketID}/labels": {
+      "post": {
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"type": "object", "required": ["labelID"], "properties": {"labelID": {"type": "string"}}}}}
+        }
+      }
+    },
+    "/checks": {
+      "post": {
+        "requestBody": {
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Check"}}}
+        }
+      }
+    },
+    "/write": {
+      "post": {
+        "requestBody": {"content": {"text/plain": {"schema": {"type": "string"}}}}
+      }
+    },
+    "/health": {
+      "servers": [{"url": ""}],
+      "post": {
+        "requestBody": {"content": {"application/json": {"schema": {"type": "object", "additionalProperties": false}}}}
+      }
+    }
+  },
+  "components": {
+    "requestBodies": {
+      "PostBucket": {
+        "required": true,
+        "content": {"application/json": {"schema": {"$ref": "#/components/schemas/PostBucketRequest"}}}
+      }
+    },
+    "schemas": {
+      "PostBucketRequest": {
+        "type": "object",
+        "required": ["orgID", "name", "id"],
+        "properties": {
+          "id": {"type": "string", "readOnly": true},
+          "orgID": {"type": "string"},
+          "name": {"type": "string", "minLength": 1},
+          "retentionRules": {"type": "array", "items": {"$ref": "#/components/schemas/RetentionRule"}},
+          "schemaType": {"type": "string", "enum": ["implicit", "explicit"]}
+        }
+      },
+      "PatchBucket": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "description": {"type": "string", "nullable": true}
+        }
+      },
+      "RetentionRule": {
+        "type": "object",
+        "required": ["everySeconds"],
+        "properties": {
+          "everySeconds": {"type": "integer", "minimum": 0},
+          "shardGroupDurationSeconds": {"type": "integer"}
+        }
+      },
+      "Check": {
+        "oneOf": [{"$ref": "#/components/schemas/DeadmanCheck"}, {"$ref": "#/components/schemas/ThresholdCheck"}],
+        "discriminator": {
+          "propertyName": "type",
+          "mapping": {
+            "deadman": "#/components/schemas/DeadmanCheck",
+            "threshold": "#/components/schemas/ThresholdCheck"
+          }
+        }
+      },
+      "DeadmanCheck": {
+        "type": "object",
+        "properties": {"type": {"type": "string"}, "timeSince": {"type": "string"}}
+      },
+      "ThresholdCheck": {
+        "type": "object",
+        "properties": {"type": {"type": "string"}, "thresholds": {"type": "array", "items": {"type": "object"}}}
+      }
+    }
+  }
+}`
+
+func newTestValidator(t *testing.T) *Validator {
+	t.Helper()
+	v, err := NewValidator([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestValidator_Validate(t *testing.T) {
+	v := newTestValidator(t)
+
+	for _, tt := range []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantErr     string
+	}{
+		{
+			name:   "valid body",
+			method: http.MethodPost,
+			path:   "/api/v2/buckets",
+			body:   `{"orgID": "0000000000000001", "name": "b", "retentionRules": [{"everySeconds": 3600}]}`,
+		},
+		{
+			name:    "missing and mistyped fields",
+			method:  http.MethodPost,
+			path:    "/api/v2/buckets",
+			body:    `{"name": 1, "retentionRules": [{"everySeconds": 3600}, {"everySeconds": -1.5}]}`,
+			wantErr: "invalid request body: orgID: is required; name: must be a string, got number; retentionRules[1].everySeconds: must be an integer, got number",
+		},
+		{
+			name:    "constraints",
+			method:  http.MethodPost,
+			path:    "/api/v2/buckets",
+			body:    `{"orgID": "1", "name": "", "retentionRules": [{"everySeconds": -1}], "schemaType": "strict"}`,
+			wantErr: "invalid request body: name: must be at least 1 characters long; retentionRules[0].everySeconds: must be at least 0; schemaType: must be one of [implicit, explicit]",
+		},
+		{
+			name:    "body of the wrong type",
+			method:  http.MethodPost,
+			path:    "/api/v2/buckets",
+			body:    `[]`,
+			wantErr: "invalid request body: must be an object, got array",
+		},
+		{
+			name:    "malformed JSON",
+			method:  http.MethodPost,
+			path:    "/api/v2/buckets",
+			body:    `{"orgID": `,
+			wantErr: "request body is not valid JSON: unexpected EOF",
+		},
+		{
+			name:    "required body",
+			method:  http.MethodPost,
+			path:    "/api/v2/buckets/0000000000000001/labels",
+			wantErr: "request body is required",
+		},
+		{
+			name:   "optional body",
+			method: http.MethodPatch,
+			path:   "/api/v2/buckets/0000000000000001",
+		},
+		{
+			name:   "nullable field",
+			method: http.MethodPatch,
+			path:   "/api/v2/buckets/0000000000000001",
+			body:   `{"description": null}`,
+		},
+		{
+			name:    "null field",
+			method:  http.MethodPatch,
+			path:    "/api/v2/buckets/0000000000000001",
+			body:    `{"name": null}`,
+			wantErr: "invalid request body: name: must be a string, got null",
+		},
+		{
+			name:    "discriminated schema",
+			method:  http.MethodPost,
+			path:    "/api/v2/checks",
+			body:    `{"type": "threshold", "thresholds": {}}`,
+			wantErr: "invalid request body: thresholds: must be an array, got object",
+		},
+		{
+			name:    "unknown discriminator",
+			method:  http.MethodPost,
+			path:    "/api/v2/checks",
+			body:    `{"type": "custom"}`,
+			wantErr: "invalid request body: type: must be one of [deadman, threshold]",
+		},
+		{
+			name:    "additional properties",
+			method:  http.MethodPost,
+			path:    "/health",
+			body:    `{"status": "pass"}`,
+			wantErr: "invalid request body: status: is not a known property",
+		},
+		{
+			name:        "other content type",
+			method:      http.MethodPost,
+			path:        "/api/v2/buckets",
+			contentType: "text/plain",
+			body:        `not json`,
+		},
+		{
+			name:   "no JSON body",
+			method: http.MethodPost,
+			path:   "/api/v2/write",
+			body:   `m f=1`,
+		},
+		{
+			name:   "unknown route",
+			method: http.MethodPost,
+			path:   "/api/v2/unknown",
+			body:   `{`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.method, tt.path, tt.contentType, []byte(tt.body))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error %q", tt.wantErr)
+			}
+			if got := err.Error(); got != tt.wantErr {
+				t.Errorf("unexpected error:\n got: %s\nwant: %s", got, tt.wantErr)
+			}
+			if code := errors.ErrorCode(err); code != errors.EInvalid {
+				t.Errorf("unexpected error code %q", code)
+			}
+		})
+	}
+}
+
+type testErrorHandler struct{}
+
+func (testErrorHandler) HandleHTTPError(_ context.Context, err error, w http.ResponseWriter) {
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"code":    errors.ErrorCode(err),
+		"message": err.Error(),
+	})
+}
+
+func TestValidator_Middleware(t *testing.T) {
+	var gotBody string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusCreated)
+	})
+	h := newTestValidator(t).Middleware(testErrorHandler{})(next)
+
+	body := `{"orgID": "1", "name": "b"}`
+	r := httptest.NewRequest(http.MethodPost, "/api/v2/buckets", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body)
+	}
+	if gotBody != body {
+		t.Errorf("handler read body %q, want %q", gotBody, body)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/api/v2/buckets", strings.NewReader(`{"name": "b"}`))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+	var resp map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp["code"] != errors.EInvalid || resp["message"] != "invalid request body: orgID: is required" {
+		t.Errorf("unexpected response %v", resp)
+	}
+}
+
+func TestValidator_MiddlewareNil(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	var v *Validator
+	h := v.Middleware(testErrorHandler{})(next)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v2/buckets", strings.NewReader(`{`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+}
+
+func TestValidator_MiddlewareMaxBodyBytes(t *testing.T) {
+	v, err := NewValidator([]byte(testSpec), WithMaxBodyBytes(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotBody string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusCreated)
+	})
+	h := v.Middleware(testErrorHandler{})(next)
+
+	// the body is larger than the maximum, so it reaches the handler whole
+	// and unvalidated
+	body := `{"name": "a bucket without an org"}`
+	r := httptest.NewRequest(http.MethodPost, "/api/v2/buckets", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body)
+	}
+	if gotBody != body {
+		t.Errorf("handler read body %q, want %q", gotBody, body)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/api/v2/buckets", strings.NewReader(`{"name": "b"}`))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+}
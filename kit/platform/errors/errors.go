@@ -26,6 +26,7 @@ const (
 	EUnauthorized        = "unauthorized"
 	EMethodNotAllowed    = "method not allowed"
 	ETooLarge            = "request too large"
+	EPreconditionFailed  = "precondition failed"
 )
 
 // Error is the error struct of platform.
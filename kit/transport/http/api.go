@@ -127,6 +127,9 @@ type (
 
 func (a *API) decode(encoding string, dec decoder, v interface{}) error {
 	if err := dec.Decode(v); err != nil {
+		if tooLarge, ok := BodyTooLargeError(err); ok {
+			return tooLarge
+		}
 		if a != nil && a.unmarshalErrFn != nil {
 			return a.unmarshalErrFn(encoding, err)
 		}
@@ -227,6 +230,12 @@ func (a *API) Err(w http.ResponseWriter, r *http.Request, err error) {
 
 	a.logErr("api error encountered", zap.Error(err))
 
+	// handlers report a body exceeding its maximum as whatever error
+	// decoding it caused
+	if tooLarge := bodyTooLarge(r.Context()); tooLarge != nil {
+		err = tooLarge
+	}
+
 	v, status, err := a.errFn(r.Context(), err)
 	if err != nil {
 		a.logErr("failed to write err to response writer", zap.Error(err))
@@ -0,0 +1,193 @@
+package http
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// BodyLimiter rejects requests with bodies larger than the maximum of their
+// handler with 413 Request Entity Too Large, stating the maximum and the
+// size of the body.
+type BodyLimiter struct {
+	api *API
+
+	rejected *prometheus.CounterVec
+	maxBytes *prometheus.GaugeVec
+}
+
+// NewBodyLimiter constructs a BodyLimiter.
+func NewBodyLimiter() *BodyLimiter {
+	return &BodyLimiter{
+		api: NewAPI(),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "http",
+			Subsystem: "api",
+			Name:      "request_body_too_large_total",
+			Help:      "Number of requests rejected for exceeding the maximum body size",
+		}, []string{"handler"}),
+		maxBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "http",
+			Subsystem: "api",
+			Name:      "request_body_max_bytes",
+			Help:      "Maximum request body size in bytes, 0 is unlimited",
+		}, []string{"handler"}),
+	}
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (l *BodyLimiter) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{l.rejected, l.maxBytes}
+}
+
+// Middleware returns the middleware limiting the request bodies of the
+// named handler to maxBytes. Bodies are not limited by a nil BodyLimiter
+// or when maxBytes is not positive.
+//
+// Requests declaring a larger Content-Length are rejected before the body
+// is read. Otherwise reading the body fails with an ETooLarge error once
+// the maximum is exceeded. Errors the handler then responds with through
+// API.Err or ErrorHandler are replaced by it, however the handler wrapped
+// the decoding error.
+func (l *BodyLimiter) Middleware(name string, maxBytes int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		if maxBytes < 0 {
+			maxBytes = 0
+		}
+		l.maxBytes.WithLabelValues(name).Set(float64(maxBytes))
+		if maxBytes == 0 {
+			return next
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				l.rejected.WithLabelValues(name).Inc()
+				l.api.Err(w, r, &errors.Error{
+					Code: errors.ETooLarge,
+					Msg:  fmt.Sprintf("request body of %d bytes exceeds the maximum of %d bytes", r.ContentLength, maxBytes),
+				})
+				return
+			}
+
+			if r.Body != nil && r.Body != http.NoBody {
+				body := &limitedBody{
+					ReadCloser: r.Body,
+					max:        maxBytes,
+					onExceeded: l.rejected.WithLabelValues(name).Inc,
+				}
+				r = r.WithContext(context.WithValue(r.Context(), limitedBodyKey{}, body))
+				r.Body = body
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// BodyLimitRoute is the maximum body size of the requests to a family of
+// routes. Bodies are not limited when MaxBytes is 0.
+type BodyLimitRoute struct {
+	// Name labels the metrics of the routes.
+	Name string
+	// Prefix matches the paths of the routes. The empty prefix matches all
+	// paths.
+	Prefix   string
+	MaxBytes int64
+}
+
+func (r BodyLimitRoute) match(path string) bool {
+	return r.Prefix == "" || path == r.Prefix || strings.HasPrefix(path, strings.TrimSuffix(r.Prefix, "/")+"/")
+}
+
+// RouteMiddleware returns the middleware limiting the request bodies of
+// every route family to its maximum. Requests are limited by the route with
+// the longest matching prefix, or by def when none match.
+func (l *BodyLimiter) RouteMiddleware(def BodyLimitRoute, routes ...BodyLimitRoute) Middleware {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+
+		defHandler := l.Middleware(def.Name, def.MaxBytes)(next)
+		handlers := make([]http.Handler, len(routes))
+		for i, route := range routes {
+			handlers[i] = l.Middleware(route.Name, route.MaxBytes)(next)
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			h, longest := defHandler, -1
+			for i, route := range routes {
+				if route.match(r.URL.Path) && len(route.Prefix) > longest {
+					h, longest = handlers[i], len(route.Prefix)
+				}
+			}
+			h.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// BodyTooLargeError returns the error of a request body exceeding the
+// maximum of a BodyLimiter when err wraps one. Handlers respond with it
+// instead of a decoding error.
+func BodyTooLargeError(err error) (*errors.Error, bool) {
+	var e *errors.Error
+	if stderrors.As(err, &e) && e.Code == errors.ETooLarge {
+		return e, true
+	}
+	return nil, false
+}
+
+type limitedBodyKey struct{}
+
+// bodyTooLarge returns the error of the request body of ctx when it
+// exceeded the maximum of a BodyLimiter, nil otherwise.
+func bodyTooLarge(ctx context.Context) *errors.Error {
+	b, _ := ctx.Value(limitedBodyKey{}).(*limitedBody)
+	if b == nil {
+		return nil
+	}
+	return b.err
+}
+
+// limitedBody fails reads past max bytes with an ETooLarge error.
+type limitedBody struct {
+	io.ReadCloser
+	max        int64
+	n          int64
+	err        *errors.Error
+	onExceeded func()
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	// read one byte past the maximum to tell a body of exactly max bytes
+	// from a larger one
+	if remaining := b.max - b.n + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if b.n <= b.max {
+		return n, err
+	}
+
+	b.err = &errors.Error{
+		Code: errors.ETooLarge,
+		Msg:  fmt.Sprintf("request body of more than %d bytes exceeds the maximum of %d bytes", b.max, b.max),
+	}
+	b.onExceeded()
+	return n - int(b.n-b.max), b.err
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestBodyLimiter(t *testing.T) {
+	l := NewBodyLimiter()
+
+	var readErr error
+	h := l.Middleware("write", 5)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+		if readErr != nil {
+			l.api.Err(w, r, readErr)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	do := func(body string, chunked bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/write", strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, 5.0, testutil.ToFloat64(l.maxBytes.WithLabelValues("write")))
+
+	rec := do("12345", false)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = do("12345", true)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	t.Run("content length exceeds the maximum", func(t *testing.T) {
+		readErr = nil
+		rec := do("1234567", false)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Contains(t, rec.Body.String(), "request body of 7 bytes exceeds the maximum of 5 bytes")
+		assert.NoError(t, readErr, "body must not be read")
+	})
+
+	t.Run("streamed body exceeds the maximum", func(t *testing.T) {
+		rec := do("1234567", true)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Contains(t, rec.Body.String(), "exceeds the maximum of 5 bytes")
+		require.Error(t, readErr)
+		assert.Equal(t, errors.ETooLarge, errors.ErrorCode(readErr))
+	})
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(l.rejected.WithLabelValues("write")))
+}
+
+func TestBodyLimiter_Unlimited(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	var l *BodyLimiter
+	assert.NotNil(t, l.Middleware("write", 5)(next))
+
+	l = NewBodyLimiter()
+	l.Middleware("query", 0)(next)
+	assert.Equal(t, 0.0, testutil.ToFloat64(l.maxBytes.WithLabelValues("query")))
+}
+
+func TestBodyLimiter_RouteMiddleware(t *testing.T) {
+	l := NewBodyLimiter()
+	h := l.RouteMiddleware(
+		BodyLimitRoute{Name: "api", MaxBytes: 2},
+		BodyLimitRoute{Name: "templates", Prefix: "/api/v2/templates", MaxBytes: 4},
+		BodyLimitRoute{Name: "template_apply", Prefix: "/api/v2/templates/apply", MaxBytes: 6},
+		BodyLimitRoute{Name: "restore", Prefix: "/api/v2/restore"},
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v interface{}
+		if err := l.api.DecodeJSON(r.Body, &v); err != nil {
+			l.api.Err(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		path string
+		body string
+		code int
+	}{
+		{path: "/api/v2/buckets", body: `""`, code: http.StatusNoContent},
+		{path: "/api/v2/buckets", body: `"ab"`, code: http.StatusRequestEntityTooLarge},
+		{path: "/api/v2/templates", body: `"ab"`, code: http.StatusNoContent},
+		{path: "/api/v2/templates/export", body: `"abcd"`, code: http.StatusRequestEntityTooLarge},
+		{path: "/api/v2/templates/apply", body: `"abcd"`, code: http.StatusNoContent},
+		{path: "/api/v2/templatesx", body: `"ab"`, code: http.StatusRequestEntityTooLarge},
+		{path: "/api/v2/restore/kv", body: `"abcdefghijklmnop"`, code: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			// stream the body, decoding must fail with the body limit error
+			req.ContentLength = -1
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestBodyLimiter_WrappedDecodeError(t *testing.T) {
+	l := NewBodyLimiter()
+	decode := func(r *http.Request) error {
+		var v interface{}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			// handlers commonly drop the cause of decoding errors
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("failed to decode request body: %v", err),
+			}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name string
+		h    http.HandlerFunc
+	}{
+		{
+			name: "API",
+			h: func(w http.ResponseWriter, r *http.Request) {
+				if err := decode(r); err != nil {
+					l.api.Err(w, r, err)
+				}
+			},
+		},
+		{
+			name: "ErrorHandler",
+			h: func(w http.ResponseWriter, r *http.Request) {
+				if err := decode(r); err != nil {
+					NewErrorHandler(zap.NewNop()).HandleHTTPError(r.Context(), err, w)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := l.Middleware("api", 4)(tt.h)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/buckets", strings.NewReader(`"abcdef"`))
+			req.ContentLength = -1
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+			assert.Equal(t, errors.ETooLarge, rec.Header().Get(PlatformErrorCodeHeader))
+			assert.Contains(t, rec.Body.String(), "exceeds the maximum of 4 bytes")
+
+			// malformed bodies within the maximum are still invalid
+			req = httptest.NewRequest(http.MethodPost, "/api/v2/buckets", strings.NewReader(`"a`))
+			req.ContentLength = -1
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
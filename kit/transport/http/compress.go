@@ -0,0 +1,209 @@
+package http
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content encodings negotiated by the Compress middleware.
+const (
+	EncodingZstd   = "zstd"
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+// supportedEncodings are the content encodings responses are compressed
+// with, ordered by preference when the client accepts several equally.
+var supportedEncodings = []string{EncodingZstd, EncodingBrotli, EncodingGzip}
+
+// compressor is a compressing writer that can be reused for another
+// response.
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var compressorPools = map[string]*sync.Pool{
+	EncodingZstd: {
+		New: func() interface{} {
+			// the options are valid, so creating the encoder does not fail
+			enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			return enc
+		},
+	},
+	EncodingBrotli: {
+		New: func() interface{} {
+			return brotli.NewWriter(nil)
+		},
+	},
+	EncodingGzip: {
+		New: func() interface{} {
+			return gzip.NewWriter(nil)
+		},
+	},
+}
+
+// NegotiateEncoding returns the content encoding to compress a response
+// with, given the Accept-Encoding header of the request. It returns an
+// empty string when the response is sent uncompressed.
+func NegotiateEncoding(acceptEncoding string) string {
+	var (
+		best  string
+		bestQ float64
+	)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, q := parseAcceptEncoding(part)
+		if q <= 0 {
+			continue
+		}
+		if coding == "*" {
+			// Clients accepting anything get the most widely supported encoding.
+			coding = EncodingGzip
+		}
+		if !isSupportedEncoding(coding) {
+			continue
+		}
+		if q > bestQ || (q == bestQ && encodingRank(coding) < encodingRank(best)) {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+func parseAcceptEncoding(part string) (string, float64) {
+	params := strings.Split(part, ";")
+	coding := strings.ToLower(strings.TrimSpace(params[0]))
+	q := 1.0
+	for _, param := range params[1:] {
+		param = strings.TrimSpace(param)
+		if !strings.HasPrefix(param, "q=") {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+		if err != nil {
+			return coding, 0
+		}
+		q = v
+	}
+	return coding, q
+}
+
+func isSupportedEncoding(coding string) bool {
+	return encodingRank(coding) < len(supportedEncodings)
+}
+
+func encodingRank(coding string) int {
+	for i, enc := range supportedEncodings {
+		if enc == coding {
+			return i
+		}
+	}
+	return len(supportedEncodings)
+}
+
+// Compress is a middleware that compresses responses with the content
+// encoding the client prefers of zstd, brotli and gzip. Responses that
+// already set a Content-Encoding or are compressed archives are sent as they
+// are. Flushing the response flushes the compressed data, so streamed
+// responses such as query results keep streaming.
+func Compress(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := NegotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressResponseWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding    string
+	compressor  compressor
+	wroteHeader bool
+}
+
+func (w *compressResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	h := w.Header()
+	if h.Get("Content-Encoding") == "" && bodyAllowed(status) && !isCompressedContentType(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		w.compressor = compressorPools[w.encoding].Get().(compressor)
+		w.compressor.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.compressor == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.compressor.Write(b)
+}
+
+// Flush writes the data compressed so far to the client. Flushing sends the
+// headers, so the encoding is decided first.
+func (w *compressResponseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.compressor != nil {
+		// the error resurfaces on the next write
+		_ = w.compressor.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressResponseWriter) close() {
+	if w.compressor == nil {
+		return
+	}
+	// errors closing are the ones of writing to the client, which the
+	// handler already saw
+	_ = w.compressor.Close()
+	w.compressor.Reset(nil)
+	compressorPools[w.encoding].Put(w.compressor)
+	w.compressor = nil
+}
+
+// isCompressedContentType reports whether the content type is a compressed
+// format, which is not worth compressing again.
+func isCompressedContentType(contentType string) bool {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	switch strings.ToLower(strings.TrimSpace(contentType)) {
+	case "application/gzip", "application/x-gzip", "application/zip", "application/zstd", "application/x-brotli":
+		return true
+	}
+	return false
+}
+
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
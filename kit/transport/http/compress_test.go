@@ -0,0 +1,139 @@
+package http
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	for _, tt := range []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: ""},
+		{accept: "identity", want: ""},
+		{accept: "gzip", want: EncodingGzip},
+		{accept: "gzip, deflate", want: EncodingGzip},
+		{accept: "gzip, deflate, br", want: EncodingBrotli},
+		{accept: "br, zstd", want: EncodingZstd},
+		{accept: "gzip, zstd", want: EncodingZstd},
+		{accept: "zstd;q=0.5, gzip", want: EncodingGzip},
+		{accept: "ZSTD", want: EncodingZstd},
+		{accept: "*", want: EncodingGzip},
+		{accept: "gzip;q=0", want: ""},
+		{accept: "gzip;q=bad", want: ""},
+	} {
+		t.Run(tt.accept, func(t *testing.T) {
+			assert.Equal(t, tt.want, NegotiateEncoding(tt.accept))
+		})
+	}
+}
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat("_result,table,_value\n,0,1\n", 100)
+	h := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		io.WriteString(w, body[:len(body)/2])
+		w.(http.Flusher).Flush()
+		io.WriteString(w, body[len(body)/2:])
+	}))
+
+	for _, tt := range []struct {
+		accept string
+		decode func(io.Reader) (io.Reader, error)
+	}{
+		{
+			accept: "",
+			decode: func(r io.Reader) (io.Reader, error) { return r, nil },
+		},
+		{
+			accept: EncodingGzip,
+			decode: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		},
+		{
+			accept: EncodingZstd,
+			decode: func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+		},
+		{
+			accept: EncodingBrotli,
+			decode: func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		},
+	} {
+		t.Run(tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v2/query", nil)
+			r.Header.Set("Accept-Encoding", tt.accept)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.accept, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+			dec, err := tt.decode(w.Body)
+			require.NoError(t, err)
+			got, err := ioutil.ReadAll(dec)
+			require.NoError(t, err)
+			assert.Equal(t, body, string(got))
+		})
+	}
+}
+
+func TestCompress_NoContent(t *testing.T) {
+	h := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	r := httptest.NewRequest(http.MethodDelete, "/api/v2/buckets/1", nil)
+	r.Header.Set("Accept-Encoding", EncodingZstd)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Zero(t, w.Body.Len())
+}
+
+func TestCompress_FlushBeforeWrite(t *testing.T) {
+	h := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "streamed")
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v2/query", nil)
+	r.Header.Set("Accept-Encoding", EncodingGzip)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	// the encoding is sent with the headers written by the flush
+	assert.Equal(t, EncodingGzip, w.Result().Header.Get("Content-Encoding"))
+	dec, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	got, err := ioutil.ReadAll(dec)
+	require.NoError(t, err)
+	assert.Equal(t, "streamed", string(got))
+}
+
+func TestCompress_CompressedContentType(t *testing.T) {
+	h := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		io.WriteString(w, "archive")
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v2/templates/bundles/export", nil)
+	r.Header.Set("Accept-Encoding", EncodingZstd)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "archive", w.Body.String())
+}
@@ -32,6 +32,9 @@ func (h ErrorHandler) HandleHTTPError(ctx context.Context, err error, w http.Res
 	if err == nil {
 		return
 	}
+	if tooLarge := bodyTooLarge(ctx); tooLarge != nil {
+		err = tooLarge
+	}
 
 	code := errors2.ErrorCode(err)
 	var msg string
@@ -106,6 +109,7 @@ var influxDBErrorToStatusCode = map[string]int{
 	errors2.EUnauthorized:        http.StatusUnauthorized,
 	errors2.EMethodNotAllowed:    http.StatusMethodNotAllowed,
 	errors2.ETooLarge:            http.StatusRequestEntityTooLarge,
+	errors2.EPreconditionFailed:  http.StatusPreconditionFailed,
 }
 
 var httpStatusCodeToInfluxDBError = map[int]string{}
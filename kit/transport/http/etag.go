@@ -0,0 +1,125 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// VersionETag returns the entity tag of a version of a resource, which is
+// identified by the time the resource was last updated.
+func VersionETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixNano(), 36) + `"`
+}
+
+// SetVersionETag sets the ETag header of the response to the version of
+// the resource.
+func SetVersionETag(w http.ResponseWriter, updatedAt time.Time) {
+	w.Header().Set("ETag", VersionETag(updatedAt))
+}
+
+// IfMatchVersion returns the version of the resource an update requires
+// with the If-Match header. It returns nil when the request has no If-Match
+// header or it matches any version. An If-Match header that is not a
+// version entity tag never matches and fails with EPreconditionFailed.
+func IfMatchVersion(r *http.Request) (*time.Time, error) {
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	if tag == "" || tag == "*" {
+		return nil, nil
+	}
+
+	errNoMatch := &errors.Error{
+		Code: errors.EPreconditionFailed,
+		Msg:  "If-Match must be the ETag of a single version of the resource",
+	}
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return nil, errNoMatch
+	}
+	nanos, err := strconv.ParseInt(tag[1:len(tag)-1], 36, 64)
+	if err != nil {
+		return nil, errNoMatch
+	}
+
+	t := time.Unix(0, nanos).UTC()
+	return &t, nil
+}
+
+// ConditionalGet tags successful responses with a weak entity tag of their
+// body and answers requests whose If-None-Match header matches the tag with
+// 304 Not Modified, so that clients polling a resource do not download it
+// again while it is unchanged. Responses are buffered to compute their tag.
+// A tag set by the handler, such as the version of a resource, is kept and
+// matched instead.
+//
+// It is meant for reads, including reads sent with POST such as template
+// exports. Streamed NDJSON responses are passed through untagged.
+func ConditionalGet(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if AcceptsNDJSON(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(bw, r)
+
+		if bw.status != http.StatusOK {
+			w.WriteHeader(bw.status)
+			w.Write(bw.body.Bytes())
+			return
+		}
+
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			sum := sha256.Sum256(bw.body.Bytes())
+			etag = `W/"` + hex.EncodeToString(sum[:16]) + `"`
+			w.Header().Set("ETag", etag)
+		}
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(bw.body.Bytes())
+	}
+	return http.HandlerFunc(fn)
+}
+
+// etagMatches reports whether the entity tags of an If-None-Match header
+// match etag, comparing them weakly.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag != "" && strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.body.Write(b)
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	updatedAt := time.Date(2021, 3, 4, 5, 6, 7, 89, time.UTC)
+
+	w := httptest.NewRecorder()
+	SetVersionETag(w, updatedAt)
+	etag := w.Header().Get("ETag")
+	require.Equal(t, VersionETag(updatedAt), etag)
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    *time.Time
+		wantErr bool
+	}{
+		{name: "no header"},
+		{name: "any version", ifMatch: "*"},
+		{name: "version", ifMatch: etag, want: &updatedAt},
+		{name: "unquoted", ifMatch: etag[1 : len(etag)-1], wantErr: true},
+		{name: "weak", ifMatch: "W/" + etag, wantErr: true},
+		{name: "not a version", ifMatch: `"abc-def"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/", nil)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			got, err := IfMatchVersion(r)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, errors.EPreconditionFailed, errors.ErrorCode(err))
+				return
+			}
+			require.NoError(t, err)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			assert.True(t, tt.want.Equal(*got))
+		})
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	var body, version string
+	h := ConditionalGet(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if version != "" {
+			w.Header().Set("ETag", version)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	body = `{"a":1}`
+	w := get("")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	w = get(etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusNotModified, get(`"other", `+etag).Code)
+	assert.Equal(t, http.StatusNotModified, get("*").Code)
+
+	// a changed body changes the tag
+	body = `{"a":2}`
+	w = get(etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	// the tag of the handler is kept
+	version = VersionETag(time.Unix(1, 0))
+	w = get("")
+	assert.Equal(t, version, w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusNotModified, get(version).Code)
+}
+
+func TestConditionalGet_Error(t *testing.T) {
+	h := ConditionalGet(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", "*")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "not found", w.Body.String())
+	assert.Empty(t, w.Header().Get("ETag"))
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// NDJSONContentType is the media type of newline delimited JSON, which
+// list endpoints stream one resource per line.
+const NDJSONContentType = "application/x-ndjson"
+
+// ndjsonFlushLines is the number of lines written between flushes, so
+// clients start receiving a long list before all of it is encoded.
+const ndjsonFlushLines = 100
+
+// AcceptsNDJSON reports whether the Accept header of the request asks for
+// newline delimited JSON.
+func AcceptsNDJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+				mediaType = mediaType[:i]
+			}
+			if strings.EqualFold(strings.TrimSpace(mediaType), NDJSONContentType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EncodeNDJSON streams a list to the response, one JSON document per line.
+// page is the first page of the list and next returns the following ones,
+// an empty page ends the list. Only a page of the list is held in memory at
+// a time. The status is written before the first line, an error reading a
+// page or encoding a value ends the response early.
+func EncodeNDJSON(w http.ResponseWriter, status int, page []interface{}, next func() ([]interface{}, error)) error {
+	w.Header().Set("Content-Type", NDJSONContentType)
+	w.WriteHeader(status)
+	return encodeNDJSON(w, w, page, next)
+}
+
+func encodeNDJSON(w io.Writer, rw http.ResponseWriter, page []interface{}, next func() ([]interface{}, error)) error {
+	flusher, _ := rw.(http.Flusher)
+
+	enc := json.NewEncoder(w)
+	for lines := 0; len(page) > 0; {
+		for _, v := range page {
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+			lines++
+			if flusher != nil && lines%ndjsonFlushLines == 0 {
+				if err := flushNDJSON(w, flusher); err != nil {
+					return err
+				}
+			}
+		}
+
+		var err error
+		if page, err = next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flushNDJSON(w io.Writer, flusher http.Flusher) error {
+	if gw, ok := w.(*gzip.Writer); ok {
+		if err := gw.Flush(); err != nil {
+			return err
+		}
+	}
+	flusher.Flush()
+	return nil
+}
+
+// RespondNDJSON streams a list to the response as newline delimited JSON.
+// See EncodeNDJSON, errors once the stream started are logged.
+func (a *API) RespondNDJSON(w http.ResponseWriter, r *http.Request, status int, page []interface{}, next func() ([]interface{}, error)) {
+	var writer io.WriteCloser = noopCloser{Writer: w}
+	if a != nil && a.encodeGZIP {
+		w.Header().Set("Content-Encoding", "gzip")
+		writer = gzip.NewWriter(w)
+	}
+
+	w.Header().Set("Content-Type", NDJSONContentType)
+	w.WriteHeader(status)
+
+	if err := encodeNDJSON(writer, w, page, next); err != nil {
+		a.logErr("failed to stream response", zap.Error(err), zap.String("path", r.URL.Path))
+	}
+	if err := writer.Close(); err != nil {
+		a.logErr("failed to close response writer", zap.Error(err))
+	}
+}
+
+// AddLinkHeader adds the link of the response with the relation rel to its
+// Link header. A list streamed as NDJSON has no body to hold its links.
+func AddLinkHeader(w http.ResponseWriter, rel, link string) {
+	w.Header().Add("Link", fmt.Sprintf("<%s>; rel=%q", link, rel))
+}
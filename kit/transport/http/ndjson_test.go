@@ -0,0 +1,96 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptsNDJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{accept: "", want: false},
+		{accept: "application/json", want: false},
+		{accept: "application/x-ndjson", want: true},
+		{accept: "application/json, application/x-ndjson;q=0.9", want: true},
+		{accept: "Application/X-NDJSON", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v2/buckets", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			assert.Equal(t, tt.want, AcceptsNDJSON(r))
+		})
+	}
+}
+
+func TestAPI_RespondNDJSON(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v2/buckets", nil)
+	// pages of 100 items, the last one short
+	var pages int
+	next := func() ([]interface{}, error) {
+		var page []interface{}
+		for i := pages * 100; i < 250 && len(page) < 100; i++ {
+			page = append(page, item{ID: i})
+		}
+		pages++
+		return page, nil
+	}
+	first, err := next()
+	require.NoError(t, err)
+	NewAPI().RespondNDJSON(w, r, http.StatusOK, first, next)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, NDJSONContentType, w.Header().Get("Content-Type"))
+
+	var got []item
+	sc := bufio.NewScanner(w.Body)
+	for sc.Scan() {
+		var it item
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &it))
+		got = append(got, it)
+	}
+	require.NoError(t, sc.Err())
+	require.Len(t, got, 250)
+	for i, it := range got {
+		assert.Equal(t, i, it.ID)
+	}
+}
+
+func TestAPI_RespondNDJSON_PageError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v2/buckets", nil)
+	NewAPI().RespondNDJSON(w, r, http.StatusOK, []interface{}{1, 2}, func() ([]interface{}, error) {
+		return nil, errors.New("store closed")
+	})
+
+	// the lines before the failed page are still sent
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1\n2\n", w.Body.String())
+}
+
+func TestAddLinkHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	AddLinkHeader(w, "self", "/api/v2/buckets?cursor=")
+	AddLinkHeader(w, "next", "/api/v2/buckets?cursor=abc")
+
+	assert.Equal(t, []string{
+		`</api/v2/buckets?cursor=>; rel="self"`,
+		`</api/v2/buckets?cursor=abc>; rel="next"`,
+	}, w.Header().Values("Link"))
+}
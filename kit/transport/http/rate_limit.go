@@ -0,0 +1,158 @@
+package http
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitKeyFn returns the key a request is rate limited by. Requests
+// with an empty key are not limited.
+// The key functions run after authentication and should key requests on what
+// it resolved rather than on the credentials or parameters as sent.
+type RateLimitKeyFn func(r *http.Request) string
+
+// RateLimiterOptFn is a functional option for the RateLimiter.
+type RateLimiterOptFn func(*RateLimiter)
+
+// WithRateLimiterIdleTimeout sets how long the limiter of a key is kept
+// after its last request. Defaults to 5 minutes.
+func WithRateLimiterIdleTimeout(d time.Duration) RateLimiterOptFn {
+	return func(l *RateLimiter) {
+		l.idleTimeout = d
+	}
+}
+
+// WithRateLimiterAPI sets the API used to write the rejections.
+func WithRateLimiterAPI(api *API) RateLimiterOptFn {
+	return func(l *RateLimiter) {
+		l.api = api
+	}
+}
+
+type keyLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter limits the requests per second of every key, requests beyond
+// the limit are rejected with 429 Too Many Requests and a Retry-After header.
+type RateLimiter struct {
+	limit rate.Limit
+	burst int
+	keyFn RateLimitKeyFn
+	api   *API
+
+	idleTimeout time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]*keyLimiter
+	lastSweep time.Time
+
+	rejected *prometheus.CounterVec
+}
+
+// NewRateLimiter constructs a RateLimiter allowing rps requests per second
+// with bursts of burst requests for every key returned by keyFn.
+func NewRateLimiter(rps float64, burst int, keyFn RateLimitKeyFn, opts ...RateLimiterOptFn) *RateLimiter {
+	if burst < 1 {
+		burst = int(math.Ceil(rps))
+	}
+	l := &RateLimiter{
+		limit:       rate.Limit(rps),
+		burst:       burst,
+		keyFn:       keyFn,
+		api:         NewAPI(),
+		idleTimeout: 5 * time.Minute,
+		now:         time.Now,
+		keys:        make(map[string]*keyLimiter),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "http",
+			Subsystem: "api",
+			Name:      "rate_limited_requests_total",
+			Help:      "Number of requests rejected by the rate limiter",
+		}, []string{"handler"}),
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (l *RateLimiter) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{l.rejected}
+}
+
+// Middleware returns the middleware limiting the requests of the named
+// handler. A nil RateLimiter does not limit.
+func (l *RateLimiter) Middleware(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := l.keyFn(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if wait := l.reserve(key); wait > 0 {
+				l.rejected.WithLabelValues(name).Inc()
+				secs := int(math.Ceil(wait.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(secs))
+				l.api.Err(w, r, &errors.Error{
+					Code: errors.ETooManyRequests,
+					Msg:  fmt.Sprintf("rate limit exceeded, retry after %d seconds", secs),
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// reserve takes a request from the budget of the key. It returns how long
+// to wait before the request would be allowed when it is over the limit.
+func (l *RateLimiter) reserve(key string) time.Duration {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= l.idleTimeout {
+		for k, kl := range l.keys {
+			if now.Sub(kl.lastSeen) >= l.idleTimeout {
+				delete(l.keys, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	kl, ok := l.keys[key]
+	if !ok {
+		kl = &keyLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.keys[key] = kl
+	}
+	kl.lastSeen = now
+
+	res := kl.limiter.ReserveN(now, 1)
+	if !res.OK() {
+		return l.idleTimeout
+	}
+	if wait := res.DelayFrom(now); wait > 0 {
+		res.CancelAt(now)
+		return wait
+	}
+	return 0
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	byToken := func(r *http.Request) string {
+		return strings.TrimPrefix(r.Header.Get("Authorization"), "Token ")
+	}
+	l := NewRateLimiter(1, 2, byToken, WithRateLimiterIdleTimeout(time.Minute))
+	l.now = func() time.Time { return now }
+
+	h := l.Middleware("write")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	do := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/write", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Token "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// burst
+	assert.Equal(t, http.StatusNoContent, do("a").Code)
+	assert.Equal(t, http.StatusNoContent, do("a").Code)
+
+	rec := do("a")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(l.rejected.WithLabelValues("write")))
+
+	// other tokens and requests without a key are not limited
+	assert.Equal(t, http.StatusNoContent, do("b").Code)
+	assert.Equal(t, http.StatusNoContent, do("").Code)
+
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusNoContent, do("a").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("a").Code)
+
+	t.Run("idle keys are evicted", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		do("c")
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		assert.Len(t, l.keys, 1)
+		assert.Contains(t, l.keys, "c")
+	})
+}
+
+func TestRateLimiter_Nil(t *testing.T) {
+	var l *RateLimiter
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := l.Middleware("write")(next)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v2/write", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
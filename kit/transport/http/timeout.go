@@ -0,0 +1,244 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Timeouter responds 503 Service Unavailable to the requests their handler
+// has not started responding to within the timeout of their route, so a
+// handler stuck on a stalled dependency does not hang its clients.
+type Timeouter struct {
+	api *API
+
+	timedOut *prometheus.CounterVec
+	timeout  *prometheus.GaugeVec
+}
+
+// NewTimeouter constructs a Timeouter.
+func NewTimeouter() *Timeouter {
+	return &Timeouter{
+		api: NewAPI(),
+		timedOut: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "http",
+			Subsystem: "api",
+			Name:      "request_timeouts_total",
+			Help:      "Number of requests answered with 503 for exceeding the timeout of their handler",
+		}, []string{"handler"}),
+		timeout: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "http",
+			Subsystem: "api",
+			Name:      "request_timeout_seconds",
+			Help:      "Timeout of requests in seconds, 0 is unlimited",
+		}, []string{"handler"}),
+	}
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (t *Timeouter) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{t.timedOut, t.timeout}
+}
+
+// Middleware returns the middleware bounding the requests of the named
+// handler to timeout. Requests are not bounded by a nil Timeouter or when
+// timeout is not positive.
+//
+// The context of the request is canceled at the timeout. A request whose
+// handler has not started its response by then is answered with an
+// EUnavailable error, and the writes of its handler are discarded. A
+// response already started, such as a streamed query result, is left to
+// its handler.
+func (t *Timeouter) Middleware(name string, timeout time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		if t == nil {
+			return next
+		}
+		if timeout < 0 {
+			timeout = 0
+		}
+		t.timeout.WithLabelValues(name).Set(timeout.Seconds())
+		if timeout == 0 {
+			return next
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			parent := r.Context()
+			ctx, cancel := context.WithTimeout(parent, timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{w: w, h: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.finish()
+			case <-ctx.Done():
+				if tw.timeout() {
+					if parent.Err() != nil {
+						// the client went away, there is no one to respond to
+						return
+					}
+					t.timedOut.WithLabelValues(name).Inc()
+					t.api.Err(w, r, &errors.Error{
+						Code: errors.EUnavailable,
+						Msg:  fmt.Sprintf("request did not complete within the timeout of %s", timeout),
+					})
+					return
+				}
+				// the handler is responding and still writes to w, wait for
+				// it to complete
+				select {
+				case p := <-panicked:
+					panic(p)
+				case <-done:
+					tw.finish()
+				}
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// TimeoutRoute is the timeout of the requests to a family of routes.
+// Requests are not bounded when Timeout is 0.
+type TimeoutRoute struct {
+	// Name labels the metrics of the routes.
+	Name string
+	// Prefix matches the paths of the routes. The empty prefix matches all
+	// paths.
+	Prefix  string
+	Timeout time.Duration
+}
+
+func (r TimeoutRoute) match(path string) bool {
+	return BodyLimitRoute{Prefix: r.Prefix}.match(path)
+}
+
+// RouteMiddleware returns the middleware bounding the requests of every
+// route family to its timeout. Requests are bounded by the route with the
+// longest matching prefix, or by def when none match.
+func (t *Timeouter) RouteMiddleware(def TimeoutRoute, routes ...TimeoutRoute) Middleware {
+	return func(next http.Handler) http.Handler {
+		if t == nil {
+			return next
+		}
+
+		defHandler := t.Middleware(def.Name, def.Timeout)(next)
+		handlers := make([]http.Handler, len(routes))
+		for i, route := range routes {
+			handlers[i] = t.Middleware(route.Name, route.Timeout)(next)
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			h, longest := defHandler, -1
+			for i, route := range routes {
+				if route.match(r.URL.Path) && len(route.Prefix) > longest {
+					h, longest = handlers[i], len(route.Prefix)
+				}
+			}
+			h.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// timeoutWriter passes the response of a handler through to the response
+// writer once the handler starts it. Once the request timed out before the
+// response started, the writes of the handler are discarded.
+type timeoutWriter struct {
+	w http.ResponseWriter
+	h http.Header
+
+	mu       sync.Mutex
+	started  bool
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.started {
+		return
+	}
+	tw.start(code)
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.started {
+		tw.start(http.StatusOK)
+	}
+	return tw.w.Write(p)
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	if !tw.started {
+		tw.start(http.StatusOK)
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// start writes the header through, under the lock.
+func (tw *timeoutWriter) start(code int) {
+	tw.started = true
+	dst := tw.w.Header()
+	for k, v := range tw.h {
+		dst[k] = v
+	}
+	tw.w.WriteHeader(code)
+}
+
+// finish starts the response of a handler that completed without writing.
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.started {
+		tw.start(http.StatusOK)
+	}
+}
+
+// timeout reports whether the request timed out before its response
+// started, in which case the writes of the handler are discarded from then on.
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.started {
+		return false
+	}
+	tw.timedOut = true
+	return true
+}
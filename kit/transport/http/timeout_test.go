@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeouter(t *testing.T) {
+	to := NewTimeouter()
+
+	var writeErr error
+	stalled := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		_, writeErr = w.Write([]byte("late"))
+	})
+	streaming := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		_, _ = w.Write([]byte("a,"))
+		<-r.Context().Done()
+		_, _ = w.Write([]byte("b"))
+	})
+
+	t.Run("stalled handler is answered with 503", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		to.Middleware("api", 10*time.Millisecond)(stalled).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/buckets", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		var body struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, errors.EUnavailable, body.Code)
+		assert.Contains(t, body.Message, "10ms")
+		assert.Equal(t, 1.0, testutil.ToFloat64(to.timedOut.WithLabelValues("api")))
+	})
+
+	t.Run("started response is left to its handler", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		to.Middleware("query", 10*time.Millisecond)(streaming).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v2/query", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "a,b", rec.Body.String())
+		assert.Equal(t, 0.0, testutil.ToFloat64(to.timedOut.WithLabelValues("query")))
+	})
+
+	t.Run("routes without a timeout are not bounded", func(t *testing.T) {
+		done := make(chan struct{})
+		h := to.RouteMiddleware(
+			TimeoutRoute{Name: "api", Timeout: 10 * time.Millisecond},
+			TimeoutRoute{Name: "backup", Prefix: "/api/v2/backup"},
+		)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+				t.Error("backup request was canceled")
+			case <-time.After(50 * time.Millisecond):
+			}
+			w.WriteHeader(http.StatusNoContent)
+			close(done)
+		}))
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/backup/kv", nil))
+		<-done
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("started response outlives a client that went away", func(t *testing.T) {
+		wrote := make(chan struct{})
+		h := to.Middleware("query", time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("a,"))
+			close(wrote)
+			<-r.Context().Done()
+			for i := 0; i < 100; i++ {
+				_, _ = w.Write([]byte("b"))
+			}
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-wrote
+			cancel()
+		}()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v2/query", nil).WithContext(ctx))
+
+		// the handler completed before ServeHTTP returned, reading the
+		// recorder does not race with its writes
+		assert.Equal(t, "a,"+strings.Repeat("b", 100), rec.Body.String())
+	})
+
+	t.Run("writes of a timed out handler are discarded", func(t *testing.T) {
+		done := make(chan struct{})
+		h := to.Middleware("write", 10*time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			stalled(w, r)
+			close(done)
+		}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v2/write", nil))
+		<-done
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, http.ErrHandlerTimeout, writeErr)
+		assert.NotContains(t, rec.Body.String(), "late")
+	})
+}
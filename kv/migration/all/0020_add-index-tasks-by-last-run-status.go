@@ -0,0 +1,6 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv"
+
+// Migration0020_AddIndexTasksByLastRunStatus adds the index tasks by organization ID and last run status
+var Migration0020_AddIndexTasksByLastRunStatus = kv.NewIndexMigration(kv.TaskLastRunStatusIndexMapping, kv.WithIndexMigrationCleanup)
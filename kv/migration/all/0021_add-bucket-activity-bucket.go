@@ -0,0 +1,9 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0021_AddBucketActivityBucket creates the bucket recording when data was last written to and queried from each bucket.
+var Migration0021_AddBucketActivityBucket = migration.CreateBuckets(
+	"create bucket activity bucket",
+	[]byte("bucketactivityv1"),
+)
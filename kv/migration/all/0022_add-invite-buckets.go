@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0022_AddInviteBuckets creates the buckets storing user invites and their token index.
+var Migration0022_AddInviteBuckets = migration.CreateBuckets(
+	"create user invite buckets",
+	[]byte("invitesv1"),
+	[]byte("invitetokenindexv1"),
+)
@@ -0,0 +1,9 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0023_AddPkgerStackJournalBucket creates the bucket journaling the applies, dry runs and uninstalls of stacks.
+var Migration0023_AddPkgerStackJournalBucket = migration.CreateBuckets(
+	"create pkger stack journal bucket",
+	[]byte("v1_pkger_stack_journal"),
+)
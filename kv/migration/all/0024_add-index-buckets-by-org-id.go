@@ -0,0 +1,9 @@
+package all
+
+import (
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/tenant/index"
+)
+
+// Migration0024_AddIndexBucketsByOrgID adds the index of buckets by organization ID, ordered by bucket ID
+var Migration0024_AddIndexBucketsByOrgID = kv.NewIndexMigration(index.BucketByOrgIndexMapping, kv.WithIndexMigrationCleanup)
@@ -0,0 +1,56 @@
+package all
+
+import (
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+var Migration0025_AddDatasourcesToTokens = &Migration{
+	name: "add datasources resource type to operator and all-access tokens",
+	up: migrateTokensMigration(
+		func(t influxdb.Authorization) bool {
+			return permListsMatch(preDatasourcesOpPerms(), t.Permissions) ||
+				permListsMatch(preDatasourcesAllAccessPerms(t.OrgID, t.UserID), t.Permissions)
+		},
+		func(t *influxdb.Authorization) {
+			if permListsMatch(preDatasourcesOpPerms(), t.Permissions) {
+				t.Permissions = append(t.Permissions, datasourcesPerms(0)...)
+			} else {
+				t.Permissions = append(t.Permissions, datasourcesPerms(t.OrgID)...)
+			}
+		},
+	),
+	down: migrateTokensMigration(
+		func(t influxdb.Authorization) bool {
+			return permListsMatch(append(preDatasourcesOpPerms(), datasourcesPerms(0)...), t.Permissions) ||
+				permListsMatch(append(preDatasourcesAllAccessPerms(t.OrgID, t.UserID), datasourcesPerms(t.OrgID)...), t.Permissions)
+		},
+		func(t *influxdb.Authorization) {
+			newPerms := t.Permissions[:0]
+			for _, p := range t.Permissions {
+				if p.Resource.Type != influxdb.DatasourcesResourceType {
+					newPerms = append(newPerms, p)
+				}
+			}
+			t.Permissions = newPerms
+		},
+	),
+}
+
+func preDatasourcesOpPerms() []influxdb.Permission {
+	return append(preReplicationOpPerms(), remotesAndReplicationsPerms(0)...)
+}
+
+func preDatasourcesAllAccessPerms(orgID platform.ID, userID platform.ID) []influxdb.Permission {
+	return append(preReplicationAllAccessPerms(orgID, userID), remotesAndReplicationsPerms(orgID)...)
+}
+
+func datasourcesPerms(orgID platform.ID) []influxdb.Permission {
+	perms := permListFromResources([]influxdb.Resource{{Type: influxdb.DatasourcesResourceType}})
+	if orgID.Valid() {
+		for i := range perms {
+			perms[i].Resource.OrgID = &orgID
+		}
+	}
+	return perms
+}
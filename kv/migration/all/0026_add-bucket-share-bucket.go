@@ -0,0 +1,9 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0026_AddBucketShareBucket creates the bucket storing the shares of buckets with other organizations.
+var Migration0026_AddBucketShareBucket = migration.CreateBuckets(
+	"create bucket share bucket",
+	[]byte("bucketsharesv1"),
+)
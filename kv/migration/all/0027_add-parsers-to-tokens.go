@@ -0,0 +1,56 @@
+package all
+
+import (
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+var Migration0027_AddParsersToTokens = &Migration{
+	name: "add parsers resource type to operator and all-access tokens",
+	up: migrateTokensMigration(
+		func(t influxdb.Authorization) bool {
+			return permListsMatch(preParsersOpPerms(), t.Permissions) ||
+				permListsMatch(preParsersAllAccessPerms(t.OrgID, t.UserID), t.Permissions)
+		},
+		func(t *influxdb.Authorization) {
+			if permListsMatch(preParsersOpPerms(), t.Permissions) {
+				t.Permissions = append(t.Permissions, parsersPerms(0)...)
+			} else {
+				t.Permissions = append(t.Permissions, parsersPerms(t.OrgID)...)
+			}
+		},
+	),
+	down: migrateTokensMigration(
+		func(t influxdb.Authorization) bool {
+			return permListsMatch(append(preParsersOpPerms(), parsersPerms(0)...), t.Permissions) ||
+				permListsMatch(append(preParsersAllAccessPerms(t.OrgID, t.UserID), parsersPerms(t.OrgID)...), t.Permissions)
+		},
+		func(t *influxdb.Authorization) {
+			newPerms := t.Permissions[:0]
+			for _, p := range t.Permissions {
+				if p.Resource.Type != influxdb.ParsersResourceType {
+					newPerms = append(newPerms, p)
+				}
+			}
+			t.Permissions = newPerms
+		},
+	),
+}
+
+func preParsersOpPerms() []influxdb.Permission {
+	return append(preDatasourcesOpPerms(), datasourcesPerms(0)...)
+}
+
+func preParsersAllAccessPerms(orgID platform.ID, userID platform.ID) []influxdb.Permission {
+	return append(preDatasourcesAllAccessPerms(orgID, userID), datasourcesPerms(orgID)...)
+}
+
+func parsersPerms(orgID platform.ID) []influxdb.Permission {
+	perms := permListFromResources([]influxdb.Resource{{Type: influxdb.ParsersResourceType}})
+	if orgID.Valid() {
+		for i := range perms {
+			perms[i].Resource.OrgID = &orgID
+		}
+	}
+	return perms
+}
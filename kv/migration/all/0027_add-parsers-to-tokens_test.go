@@ -0,0 +1,133 @@
+package all
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigration_ParsersTokens(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	// Run up to migration 26.
+	ts := newService(t, ctx, 26)
+
+	// Auth bucket contains the authorizations AKA tokens
+	authBucket := []byte("authorizationsv1")
+
+	// The store returned by newService will include an operator token with the
+	// current system's entire list of resources already, so remove that before
+	// proceeding with the tests.
+	err := ts.Store.Update(context.Background(), func(tx kv.Tx) error {
+		bkt, err := tx.Bucket(authBucket)
+		require.NoError(t, err)
+
+		cursor, err := bkt.ForwardCursor(nil)
+		require.NoError(t, err)
+
+		return kv.WalkCursor(ctx, cursor, func(k, _ []byte) (bool, error) {
+			require.NoError(t, bkt.Delete(k))
+			return true, nil
+		})
+	})
+	require.NoError(t, err)
+
+	// Verify that running the migration in the absence of tokens will not
+	// crash influxdb.
+	require.NoError(t, Migration0027_AddParsersToTokens.Up(context.Background(), ts.Store))
+
+	OrgID := ts.Org.ID
+	UserID := ts.User.ID
+	idGen := snowflake.NewIDGenerator()
+	auths := []influxdb.Authorization{
+		{
+			ID:          idGen.ID(), // a non-operator token
+			OrgID:       OrgID,
+			UserID:      UserID,
+			Permissions: permsShouldNotChange(),
+		},
+		{
+			ID:          idGen.ID(), // a non-all-access token
+			OrgID:       OrgID,
+			UserID:      UserID,
+			Permissions: orgPermsShouldNotChange(OrgID),
+		},
+		{
+			ID:          idGen.ID(), // an operator token
+			OrgID:       OrgID,
+			UserID:      UserID,
+			Permissions: preParsersOpPerms(),
+		},
+		{
+			ID:          idGen.ID(), // an all-access token
+			OrgID:       OrgID,
+			UserID:      UserID,
+			Permissions: preParsersAllAccessPerms(OrgID, UserID),
+		},
+	}
+
+	for _, a := range auths {
+		js, err := json.Marshal(a)
+		require.NoError(t, err)
+		idBytes, err := a.ID.Encode()
+		require.NoError(t, err)
+
+		err = ts.Store.Update(context.Background(), func(tx kv.Tx) error {
+			bkt, err := tx.Bucket(authBucket)
+			require.NoError(t, err)
+			return bkt.Put(idBytes, js)
+		})
+		require.NoError(t, err)
+	}
+
+	readToken := func(id platform.ID) influxdb.Authorization {
+		encoded, err := id.Encode()
+		require.NoError(t, err)
+
+		var token influxdb.Authorization
+		err = ts.Store.View(context.Background(), func(tx kv.Tx) error {
+			bkt, err := tx.Bucket(authBucket)
+			require.NoError(t, err)
+
+			b, err := bkt.Get(encoded)
+			require.NoError(t, err)
+			return json.Unmarshal(b, &token)
+		})
+		require.NoError(t, err)
+		return token
+	}
+
+	checkPerms := func(expectedOpPerms, expectedAllAccessPerms []influxdb.Permission) {
+		// the other tokens should never change
+		require.Equal(t, auths[0], readToken(auths[0].ID))
+		require.Equal(t, auths[1], readToken(auths[1].ID))
+
+		require.ElementsMatch(t, expectedOpPerms, readToken(auths[2].ID).Permissions)
+		require.ElementsMatch(t, expectedAllAccessPerms, readToken(auths[3].ID).Permissions)
+	}
+
+	// Test applying the migration for the 1st time.
+	require.NoError(t, Migration0027_AddParsersToTokens.Up(context.Background(), ts.Store))
+	checkPerms(
+		append(preParsersOpPerms(), parsersPerms(0)...),
+		append(preParsersAllAccessPerms(OrgID, UserID), parsersPerms(OrgID)...),
+	)
+
+	// Downgrade the migration.
+	require.NoError(t, Migration0027_AddParsersToTokens.Down(context.Background(), ts.Store))
+	checkPerms(preParsersOpPerms(), preParsersAllAccessPerms(OrgID, UserID))
+
+	// Test re-applying the migration after a downgrade.
+	require.NoError(t, Migration0027_AddParsersToTokens.Up(context.Background(), ts.Store))
+	checkPerms(
+		append(preParsersOpPerms(), parsersPerms(0)...),
+		append(preParsersAllAccessPerms(OrgID, UserID), parsersPerms(OrgID)...),
+	)
+}
@@ -45,5 +45,21 @@ var Migrations = [...]migration.Spec{
 	Migration0018_RepairMissingShardGroupDurations,
 	// add remotes and replications resource types to operator and all-access tokens
 	Migration0019_AddRemotesReplicationsToTokens,
+	// add index tasks by org and last run status
+	Migration0020_AddIndexTasksByLastRunStatus,
+	// add bucket activity bucket
+	Migration0021_AddBucketActivityBucket,
+	// add user invite buckets
+	Migration0022_AddInviteBuckets,
+	// add pkger stack journal bucket
+	Migration0023_AddPkgerStackJournalBucket,
+	// add index buckets by org ID
+	Migration0024_AddIndexBucketsByOrgID,
+	// add datasources resource type to operator and all-access tokens
+	Migration0025_AddDatasourcesToTokens,
+	// add bucket share bucket
+	Migration0026_AddBucketShareBucket,
+	// add parsers resource type to operator and all-access tokens
+	Migration0027_AddParsersToTokens,
 	// {{ do_not_edit . }}
 }
@@ -1,6 +1,7 @@
 package kv
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -11,6 +12,7 @@ import (
 	"github.com/influxdata/influxdb/v2"
 	icontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/resource"
 	"github.com/influxdata/influxdb/v2/task/options"
 	"github.com/influxdata/influxdb/v2/task/taskmodel"
@@ -25,6 +27,8 @@ import (
 //   <taskID>/latestCompleted: run data for the latest completed run of a task
 // taskIndexBucket
 //   <orgID>/<taskID>: index for tasks by org
+// taskLastRunStatusIndexBucket
+//   <orgID><lastRunStatus>/<taskID>: index for tasks by org and last run status
 
 // We may want to add a <taskName>/<taskID> index to allow us to look up tasks by task name.
 
@@ -32,8 +36,27 @@ var (
 	taskBucket      = []byte("tasksv1")
 	taskRunBucket   = []byte("taskRunsv1")
 	taskIndexBucket = []byte("taskIndexsv1")
+
+	labelMappingBucket = []byte("labelmappingsv1")
+)
+
+// TaskLastRunStatusIndexMapping is the mapping definition for fetching
+// tasks by organization ID and last run status.
+var TaskLastRunStatusIndexMapping = NewIndexMapping(
+	taskBucket,
+	[]byte("tasklastrunstatusindexv1"),
+	func(v []byte) ([]byte, error) {
+		var task basicKvTask
+		if err := json.Unmarshal(v, &task); err != nil {
+			return nil, err
+		}
+
+		return taskLastRunStatusForeignKey(task.OrganizationID, task.LastRunStatus)
+	},
 )
 
+var taskLastRunStatusIndex = NewIndex(TaskLastRunStatusIndexMapping, WithIndexReadPathEnabled)
+
 var _ taskmodel.TaskService = (*Service)(nil)
 
 type matchableTask interface {
@@ -43,26 +66,30 @@ type matchableTask interface {
 	GetType() string
 	GetName() string
 	GetStatus() string
+	GetLastRunStatus() string
+	GetScheduleType() string
+	GetAnnotations() influxdb.ResourceAnnotations
 	ToInfluxDB() *taskmodel.Task
 }
 
 type basicKvTask struct {
-	ID              platform.ID       `json:"id"`
-	Type            string            `json:"type,omitempty"`
-	OrganizationID  platform.ID       `json:"orgID"`
-	OwnerID         platform.ID       `json:"ownerID"`
-	Name            string            `json:"name"`
-	Description     string            `json:"description,omitempty"`
-	Status          string            `json:"status"`
-	Every           string            `json:"every,omitempty"`
-	Cron            string            `json:"cron,omitempty"`
-	LastRunStatus   string            `json:"lastRunStatus,omitempty"`
-	LastRunError    string            `json:"lastRunError,omitempty"`
-	Offset          influxdb.Duration `json:"offset,omitempty"`
-	LatestCompleted time.Time         `json:"latestCompleted,omitempty"`
-	LatestScheduled time.Time         `json:"latestScheduled,omitempty"`
-	LatestSuccess   time.Time         `json:"latestSuccess,omitempty"`
-	LatestFailure   time.Time         `json:"latestFailure,omitempty"`
+	ID              platform.ID                  `json:"id"`
+	Type            string                       `json:"type,omitempty"`
+	OrganizationID  platform.ID                  `json:"orgID"`
+	OwnerID         platform.ID                  `json:"ownerID"`
+	Name            string                       `json:"name"`
+	Description     string                       `json:"description,omitempty"`
+	Status          string                       `json:"status"`
+	Every           string                       `json:"every,omitempty"`
+	Cron            string                       `json:"cron,omitempty"`
+	LastRunStatus   string                       `json:"lastRunStatus,omitempty"`
+	LastRunError    string                       `json:"lastRunError,omitempty"`
+	Offset          influxdb.Duration            `json:"offset,omitempty"`
+	LatestCompleted time.Time                    `json:"latestCompleted,omitempty"`
+	LatestScheduled time.Time                    `json:"latestScheduled,omitempty"`
+	LatestSuccess   time.Time                    `json:"latestSuccess,omitempty"`
+	LatestFailure   time.Time                    `json:"latestFailure,omitempty"`
+	Annotations     influxdb.ResourceAnnotations `json:"annotations,omitempty"`
 }
 
 func (kv basicKvTask) GetID() platform.ID {
@@ -89,6 +116,21 @@ func (kv basicKvTask) GetStatus() string {
 	return kv.Status
 }
 
+func (kv basicKvTask) GetLastRunStatus() string {
+	return kv.LastRunStatus
+}
+
+func (kv basicKvTask) GetScheduleType() string {
+	if kv.Cron != "" {
+		return taskmodel.TaskScheduleCron
+	}
+	return taskmodel.TaskScheduleEvery
+}
+
+func (kv basicKvTask) GetAnnotations() influxdb.ResourceAnnotations {
+	return kv.Annotations
+}
+
 func (kv basicKvTask) ToInfluxDB() *taskmodel.Task {
 	return &taskmodel.Task{
 		ID:              kv.ID,
@@ -107,6 +149,7 @@ func (kv basicKvTask) ToInfluxDB() *taskmodel.Task {
 		LatestScheduled: kv.LatestScheduled,
 		LatestSuccess:   kv.LatestSuccess,
 		LatestFailure:   kv.LatestFailure,
+		Annotations:     kv.Annotations,
 	}
 }
 
@@ -221,6 +264,9 @@ func (s *Service) findTasks(ctx context.Context, tx Tx, filter taskmodel.TaskFil
 	if filter.Limit == 0 {
 		filter.Limit = taskmodel.TaskDefaultPageSize
 	}
+	if err := filter.Validate(); err != nil {
+		return nil, 0, err
+	}
 
 	// if no user or organization is passed, assume contexts auth is the user we are looking for.
 	// it is possible for a  internal system to call this with no auth so we shouldnt fail if no auth is found.
@@ -234,6 +280,11 @@ func (s *Service) findTasks(ctx context.Context, tx Tx, filter taskmodel.TaskFil
 		}
 	}
 
+	// the last run status index is narrower than either the user or org scans.
+	if filter.OrganizationID != nil && filter.LastRunStatus != nil {
+		return s.findTasksByLastRunStatus(ctx, tx, *filter.OrganizationID, *filter.LastRunStatus, filter)
+	}
+
 	// filter by user id.
 	if filter.User != nil {
 		return s.findTasksByUser(ctx, tx, filter)
@@ -272,7 +323,10 @@ func (s *Service) findTasksByUser(ctx context.Context, tx Tx, filter taskmodel.T
 		return nil, 0, taskmodel.ErrUnexpectedTaskBucketErr(err)
 	}
 
-	matchFn := newTaskMatchFn(filter)
+	matchFn, err := newTaskLabelMatchFn(tx, filter, newTaskMatchFn(filter))
+	if err != nil {
+		return nil, 0, err
+	}
 
 	for k, v := c.Next(); k != nil; k, v = c.Next() {
 		var task matchableTask
@@ -344,7 +398,10 @@ func (s *Service) findTasksByOrg(ctx context.Context, tx Tx, orgID platform.ID,
 	// free cursor resources
 	defer c.Close()
 
-	matchFn := newTaskMatchFn(filter)
+	matchFn, err := newTaskLabelMatchFn(tx, filter, newTaskMatchFn(filter))
+	if err != nil {
+		return nil, 0, err
+	}
 
 	for k, v := c.Next(); k != nil; k, v = c.Next() {
 		id, err := platform.IDFromString(string(v))
@@ -385,7 +442,8 @@ type taskMatchFn func(matchableTask) bool
 // a task matches the filter. Will return nil if
 // the filter should match all tasks.
 func newTaskMatchFn(f taskmodel.TaskFilter) taskMatchFn {
-	if f.Type == nil && f.Name == nil && f.Status == nil && f.User == nil {
+	if f.Type == nil && f.Name == nil && f.Status == nil && f.User == nil && len(f.Annotations) == 0 &&
+		f.LastRunStatus == nil && f.ScheduleType == nil && f.NamePrefix == nil {
 		return nil
 	}
 
@@ -408,17 +466,125 @@ func newTaskMatchFn(f taskmodel.TaskFilter) taskMatchFn {
 		if f.Name != nil && t.GetName() != *f.Name {
 			return false
 		}
+		if f.NamePrefix != nil && !strings.HasPrefix(t.GetName(), *f.NamePrefix) {
+			return false
+		}
 		if f.Status != nil && t.GetStatus() != *f.Status {
 			return false
 		}
 		if f.User != nil && t.GetOwnerID() != *f.User {
 			return false
 		}
+		if f.LastRunStatus != nil && t.GetLastRunStatus() != *f.LastRunStatus {
+			return false
+		}
+		if f.ScheduleType != nil && t.GetScheduleType() != *f.ScheduleType {
+			return false
+		}
+		if !t.GetAnnotations().Matches(f.Annotations) {
+			return false
+		}
 
 		return true
 	}
 }
 
+// newTaskLabelMatchFn extends matchFn to only match tasks that have every
+// label in the filter. Each label is a point lookup in the label mappings.
+func newTaskLabelMatchFn(tx Tx, f taskmodel.TaskFilter, matchFn taskMatchFn) (taskMatchFn, error) {
+	if len(f.Labels) == 0 {
+		return matchFn, nil
+	}
+
+	mappings, err := tx.Bucket(labelMappingBucket)
+	if err != nil {
+		return nil, taskmodel.ErrUnexpectedTaskBucketErr(err)
+	}
+
+	labelIDs := make([][]byte, 0, len(f.Labels))
+	for _, id := range f.Labels {
+		labelID, err := id.Encode()
+		if err != nil {
+			return nil, &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "invalid label ID",
+				Err:  err,
+			}
+		}
+		labelIDs = append(labelIDs, labelID)
+	}
+
+	return func(t matchableTask) bool {
+		if matchFn != nil && !matchFn(t) {
+			return false
+		}
+
+		taskID, err := t.GetID().Encode()
+		if err != nil {
+			return false
+		}
+		for _, labelID := range labelIDs {
+			if _, err := mappings.Get(append(append([]byte{}, taskID...), labelID...)); err != nil {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// findTasksByLastRunStatus is a subset of the find tasks function. It walks the
+// last run status index of the organization, so only matching tasks are read.
+func (s *Service) findTasksByLastRunStatus(ctx context.Context, tx Tx, orgID platform.ID, lastRunStatus string, filter taskmodel.TaskFilter) ([]*taskmodel.Task, int, error) {
+	if !orgID.Valid() {
+		return nil, 0, fmt.Errorf("finding tasks by organization ID: %w", platform.ErrInvalidID)
+	}
+
+	fk, err := taskLastRunStatusForeignKey(orgID, lastRunStatus)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var after []byte
+	if filter.After != nil {
+		if after, err = taskKey(*filter.After); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	matchFn, err := newTaskLabelMatchFn(tx, filter, newTaskMatchFn(filter))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var ts []*taskmodel.Task
+	err = taskLastRunStatusIndex.Walk(ctx, tx, fk, func(k, v []byte) (bool, error) {
+		// index entries are ordered by task ID
+		if after != nil && bytes.Compare(k, after) <= 0 {
+			return true, nil
+		}
+
+		var task matchableTask
+		if filter.Type != nil && *filter.Type == taskmodel.TaskBasicType {
+			task = &basicKvTask{}
+		} else {
+			task = &kvTask{}
+		}
+		if err := json.Unmarshal(v, task); err != nil {
+			return false, taskmodel.ErrInternalTaskServiceError(err)
+		}
+
+		if matchFn == nil || matchFn(task) {
+			ts = append(ts, task.ToInfluxDB())
+		}
+		return len(ts) < filter.Limit, nil
+	})
+	if err != nil {
+		return nil, 0, taskmodel.ErrUnexpectedTaskBucketErr(err)
+	}
+
+	return ts, len(ts), nil
+}
+
 // findAllTasks is a subset of the find tasks function. Used for cleanliness.
 // This function should only be executed internally because it doesn't force organization or user filtering.
 // Enforcing filters should be done in a validation layer.
@@ -451,7 +617,10 @@ func (s *Service) findAllTasks(ctx context.Context, tx Tx, filter taskmodel.Task
 	// free cursor resources
 	defer c.Close()
 
-	matchFn := newTaskMatchFn(filter)
+	matchFn, err := newTaskLabelMatchFn(tx, filter, newTaskMatchFn(filter))
+	if err != nil {
+		return nil, 0, err
+	}
 
 	for k, v := c.Next(); k != nil; k, v = c.Next() {
 		var task matchableTask
@@ -523,6 +692,9 @@ func (s *Service) createTask(ctx context.Context, tx Tx, org *influxdb.Organizat
 	if err != nil {
 		return nil, taskmodel.ErrTaskOptionParse(err)
 	}
+	if err := opts.ValidateSchedule(); err != nil {
+		return nil, taskmodel.ErrTaskOptionParse(err)
+	}
 
 	if tc.Status == "" {
 		tc.Status = string(taskmodel.TaskActive)
@@ -545,6 +717,7 @@ func (s *Service) createTask(ctx context.Context, tx Tx, org *influxdb.Organizat
 		CreatedAt:       createdAt,
 		LatestCompleted: createdAt,
 		LatestScheduled: createdAt,
+		Annotations:     tc.Annotations,
 	}
 
 	if opts.Offset != nil {
@@ -593,6 +766,11 @@ func (s *Service) createTask(ctx context.Context, tx Tx, org *influxdb.Organizat
 		return nil, taskmodel.ErrUnexpectedTaskBucketErr(err)
 	}
 
+	// write the last run status index
+	if err := putTaskLastRunStatusIndex(tx, task.OrganizationID, task.LastRunStatus, taskKey); err != nil {
+		return nil, err
+	}
+
 	uid, _ := icontext.GetUserID(ctx)
 	if err := s.audit.Log(resource.Change{
 		Type:           resource.Create,
@@ -627,6 +805,65 @@ func (s *Service) UpdateTask(ctx context.Context, id platform.ID, upd taskmodel.
 	return t, nil
 }
 
+// UpdateTasksStatus sets the status of every task of the filter's
+// organization matching the filter in a single transaction.
+func (s *Service) UpdateTasksStatus(ctx context.Context, filter taskmodel.TaskFilter, status taskmodel.TaskStatus, dryRun bool) ([]*taskmodel.Task, error) {
+	if status != taskmodel.TaskActive && status != taskmodel.TaskInactive {
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  fmt.Sprintf("invalid task status: %q", status),
+		}
+	}
+	if filter.OrganizationID == nil || !filter.OrganizationID.Valid() {
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "an organization is required to update the status of tasks",
+		}
+	}
+
+	st := string(status)
+	var changed []*taskmodel.Task
+	fn := func(tx Tx) error {
+		filter.Limit = taskmodel.TaskMaxPageSize
+		for {
+			ts, _, err := s.findTasks(ctx, tx, filter)
+			if err != nil {
+				return err
+			}
+			for _, t := range ts {
+				// tasks found by user are of any organization
+				if t.OrganizationID != *filter.OrganizationID || t.Status == st {
+					continue
+				}
+				if dryRun {
+					changed = append(changed, t)
+					continue
+				}
+				updated, err := s.updateTask(ctx, tx, t.ID, taskmodel.TaskUpdate{Status: &st})
+				if err != nil {
+					return err
+				}
+				changed = append(changed, updated)
+			}
+			if len(ts) < filter.Limit {
+				return nil
+			}
+			filter.After = &ts[len(ts)-1].ID
+		}
+	}
+
+	var err error
+	if dryRun {
+		err = s.kv.View(ctx, fn)
+	} else {
+		err = s.kv.Update(ctx, fn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
 func (s *Service) updateTask(ctx context.Context, tx Tx, id platform.ID, upd taskmodel.TaskUpdate) (*taskmodel.Task, error) {
 	// retrieve the task
 	t, err := s.findTaskByID(ctx, tx, id, false)
@@ -634,6 +871,7 @@ func (s *Service) updateTask(ctx context.Context, tx Tx, id platform.ID, upd tas
 		return nil, err
 	}
 	task := t.ToInfluxDB()
+	prevLastRunStatus := task.LastRunStatus
 
 	updatedAt := s.clock.Now().UTC()
 
@@ -648,6 +886,9 @@ func (s *Service) updateTask(ctx context.Context, tx Tx, id platform.ID, upd tas
 		if err != nil {
 			return nil, taskmodel.ErrTaskOptionParse(err)
 		}
+		if err := opts.ValidateSchedule(); err != nil {
+			return nil, taskmodel.ErrTaskOptionParse(err)
+		}
 		task.Name = opts.Name
 		task.Every = opts.Every.String()
 		task.Cron = opts.Cron
@@ -685,6 +926,11 @@ func (s *Service) updateTask(ctx context.Context, tx Tx, id platform.ID, upd tas
 		task.UpdatedAt = updatedAt
 	}
 
+	if upd.Annotations != nil {
+		task.Annotations = upd.Annotations.Clone()
+		task.UpdatedAt = updatedAt
+	}
+
 	if upd.LatestCompleted != nil {
 		// make sure we only update latest completed one way
 		tlc := task.LatestCompleted
@@ -751,6 +997,15 @@ func (s *Service) updateTask(ctx context.Context, tx Tx, id platform.ID, upd tas
 		return nil, taskmodel.ErrUnexpectedTaskBucketErr(err)
 	}
 
+	if task.LastRunStatus != prevLastRunStatus {
+		if err := deleteTaskLastRunStatusIndex(tx, task.OrganizationID, prevLastRunStatus, key); err != nil {
+			return nil, err
+		}
+		if err := putTaskLastRunStatusIndex(tx, task.OrganizationID, task.LastRunStatus, key); err != nil {
+			return nil, err
+		}
+	}
+
 	uid, _ := icontext.GetUserID(ctx)
 	if err := s.audit.Log(resource.Change{
 		Type:           resource.Update,
@@ -815,6 +1070,16 @@ func (s *Service) deleteTask(ctx context.Context, tx Tx, id platform.ID) error {
 		return taskmodel.ErrUnexpectedTaskBucketErr(err)
 	}
 
+	// remove the last run status index
+	taskID, err := taskKey(task.GetID())
+	if err != nil {
+		return err
+	}
+
+	if err := deleteTaskLastRunStatusIndex(tx, task.GetOrgID(), task.GetLastRunStatus(), taskID); err != nil {
+		return err
+	}
+
 	// remove latest completed
 	lastCompletedKey, err := taskLatestCompletedKey(task.GetID())
 	if err != nil {
@@ -1623,6 +1888,41 @@ func taskOrgKey(orgID, taskID platform.ID) ([]byte, error) {
 	return []byte(string(encodedOrgID) + "/" + string(encodedID)), nil
 }
 
+// taskLastRunStatusForeignKey returns the foreign key of a task in the last
+// run status index. Tasks that have not run are indexed by org ID alone.
+func taskLastRunStatusForeignKey(orgID platform.ID, lastRunStatus string) ([]byte, error) {
+	encodedOrgID, err := orgID.Encode()
+	if err != nil {
+		return nil, taskmodel.ErrInvalidTaskID
+	}
+
+	return []byte(string(encodedOrgID) + lastRunStatus), nil
+}
+
+func putTaskLastRunStatusIndex(tx Tx, orgID platform.ID, lastRunStatus string, taskKey []byte) error {
+	fk, err := taskLastRunStatusForeignKey(orgID, lastRunStatus)
+	if err != nil {
+		return err
+	}
+
+	if err := taskLastRunStatusIndex.Insert(tx, fk, taskKey); err != nil {
+		return taskmodel.ErrUnexpectedTaskBucketErr(err)
+	}
+	return nil
+}
+
+func deleteTaskLastRunStatusIndex(tx Tx, orgID platform.ID, lastRunStatus string, taskKey []byte) error {
+	fk, err := taskLastRunStatusForeignKey(orgID, lastRunStatus)
+	if err != nil {
+		return err
+	}
+
+	if err := taskLastRunStatusIndex.Delete(tx, fk, taskKey); err != nil {
+		return taskmodel.ErrUnexpectedTaskBucketErr(err)
+	}
+	return nil
+}
+
 func taskRunKey(taskID, runID platform.ID) ([]byte, error) {
 	encodedID, err := taskID.Encode()
 	if err != nil {
@@ -14,7 +14,9 @@ import (
 	icontext "github.com/influxdata/influxdb/v2/context"
 	_ "github.com/influxdata/influxdb/v2/fluxinit/static"
 	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/label"
 	"github.com/influxdata/influxdb/v2/query/fluxlang"
 	"github.com/influxdata/influxdb/v2/task/options"
 	"github.com/influxdata/influxdb/v2/task/servicetest"
@@ -256,6 +258,41 @@ func TestService_UpdateTask_InactiveToActive(t *testing.T) {
 	}
 }
 
+func TestService_ValidateScheduleOnSave(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	ts := newService(t, ctx, nil)
+	ctx = icontext.SetAuthorizer(ctx, &ts.Auth)
+
+	_, err := ts.Service.CreateTask(ctx, taskmodel.TaskCreate{
+		Flux:           `option task = {name: "a task", every: 10s, offset: 20s} from(bucket:"test") |> range(start:-1h)`,
+		OrganizationID: ts.Org.ID,
+		OwnerID:        ts.User.ID,
+	})
+	if code := errors.ErrorCode(err); code != errors.EInvalid {
+		t.Fatalf("expected invalid error creating task with offset longer than every, got %v", err)
+	}
+	// The field of the invalid option is in the message returned by the API.
+	if msg := err.(*errors.Error).Msg; msg != "invalid options: offset: must not be longer than every (10s)" {
+		t.Fatalf("unexpected error message %q", msg)
+	}
+
+	task, err := ts.Service.CreateTask(ctx, taskmodel.TaskCreate{
+		Flux:           `option task = {name: "a task", every: 10s, offset: 10s} from(bucket:"test") |> range(start:-1h)`,
+		OrganizationID: ts.Org.ID,
+		OwnerID:        ts.User.ID,
+	})
+	if err != nil {
+		t.Fatal("CreateTask", err)
+	}
+
+	_, err = ts.Service.UpdateTask(ctx, task.ID, taskmodel.TaskUpdate{Options: options.Options{Offset: options.MustParseDuration("20s")}})
+	if code := errors.ErrorCode(err); code != errors.EInvalid {
+		t.Fatalf("expected invalid error updating task with offset longer than every, got %v", err)
+	}
+}
+
 func TestTaskRunCancellation(t *testing.T) {
 	store, closeSvc := itesting.NewTestBoltStore(t)
 	defer closeSvc()
@@ -409,6 +446,139 @@ func TestService_UpdateTask_RecordLatestSuccessAndFailure(t *testing.T) {
 	}
 }
 
+func TestService_FindTasks_Filters(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	ts := newService(t, ctx, nil)
+	ctx = icontext.SetAuthorizer(ctx, &ts.Auth)
+
+	labelStore, err := label.NewStore(ts.Store)
+	require.NoError(t, err)
+	labelSvc := label.NewService(labelStore)
+
+	create := func(name, schedule string) *taskmodel.Task {
+		t.Helper()
+
+		task, err := ts.Service.CreateTask(ctx, taskmodel.TaskCreate{
+			Flux:           `option task = {name: "` + name + `", ` + schedule + `} from(bucket:"test") |> range(start:-1h)`,
+			OrganizationID: ts.Org.ID,
+			OwnerID:        ts.User.ID,
+		})
+		require.NoError(t, err)
+		return task
+	}
+	setLastRunStatus := func(task *taskmodel.Task, status string) {
+		t.Helper()
+
+		_, err := ts.Service.UpdateTask(ctx, task.ID, taskmodel.TaskUpdate{LastRunStatus: &status})
+		require.NoError(t, err)
+	}
+
+	failing := create("failing", `every: 1h`)
+	setLastRunStatus(failing, "success")
+	setLastRunStatus(failing, "failed")
+	succeeding := create("succeeding", `cron: "0 * * * *"`)
+	setLastRunStatus(succeeding, "success")
+	create("never run", `every: 1h`)
+
+	l := &influxdb.Label{OrgID: ts.Org.ID, Name: "critical"}
+	require.NoError(t, labelSvc.CreateLabel(ctx, l))
+	require.NoError(t, labelSvc.CreateLabelMapping(ctx, &influxdb.LabelMapping{
+		LabelID:      l.ID,
+		ResourceID:   succeeding.ID,
+		ResourceType: influxdb.TasksResourceType,
+	}))
+
+	names := func(filter taskmodel.TaskFilter) []string {
+		t.Helper()
+
+		filter.OrganizationID = &ts.Org.ID
+		tasks, _, err := ts.Service.FindTasks(ctx, filter)
+		require.NoError(t, err)
+
+		var out []string
+		for _, task := range tasks {
+			out = append(out, task.Name)
+		}
+		return out
+	}
+	strPtr := func(s string) *string { return &s }
+
+	assert.Equal(t, []string{"failing"}, names(taskmodel.TaskFilter{LastRunStatus: strPtr("failed")}))
+	assert.Equal(t, []string{"succeeding"}, names(taskmodel.TaskFilter{LastRunStatus: strPtr("success")}))
+	assert.Empty(t, names(taskmodel.TaskFilter{LastRunStatus: strPtr("canceled")}))
+	assert.Equal(t, []string{"succeeding"}, names(taskmodel.TaskFilter{ScheduleType: strPtr(taskmodel.TaskScheduleCron)}))
+	assert.Equal(t, []string{"failing", "never run"}, names(taskmodel.TaskFilter{ScheduleType: strPtr(taskmodel.TaskScheduleEvery)}))
+	assert.Equal(t, []string{"succeeding"}, names(taskmodel.TaskFilter{Labels: []platform.ID{l.ID}}))
+	assert.Empty(t, names(taskmodel.TaskFilter{Labels: []platform.ID{l.ID}, LastRunStatus: strPtr("failed")}))
+
+	require.NoError(t, ts.Service.DeleteTask(ctx, failing.ID))
+	assert.Empty(t, names(taskmodel.TaskFilter{LastRunStatus: strPtr("failed")}))
+
+	_, _, err = ts.Service.FindTasks(ctx, taskmodel.TaskFilter{LastRunStatus: strPtr("started")})
+	require.Error(t, err)
+}
+
+func TestService_UpdateTasksStatus(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	ts := newService(t, ctx, nil)
+	ctx = icontext.SetAuthorizer(ctx, &ts.Auth)
+
+	for _, name := range []string{"downsample-cpu", "downsample-mem", "alert-cpu"} {
+		_, err := ts.Service.CreateTask(ctx, taskmodel.TaskCreate{
+			Flux:           `option task = {name: "` + name + `", every: 1h} from(bucket:"test") |> range(start:-1h)`,
+			OrganizationID: ts.Org.ID,
+			OwnerID:        ts.User.ID,
+		})
+		require.NoError(t, err)
+	}
+
+	prefix := "downsample-"
+	filter := taskmodel.TaskFilter{OrganizationID: &ts.Org.ID, NamePrefix: &prefix}
+	statuses := func() map[string]string {
+		t.Helper()
+
+		tasks, _, err := ts.Service.FindTasks(ctx, taskmodel.TaskFilter{OrganizationID: &ts.Org.ID})
+		require.NoError(t, err)
+		out := make(map[string]string)
+		for _, task := range tasks {
+			out[task.Name] = task.Status
+		}
+		return out
+	}
+	names := func(tasks []*taskmodel.Task) []string {
+		var out []string
+		for _, task := range tasks {
+			out = append(out, task.Name)
+		}
+		return out
+	}
+
+	// a dry run lists the tasks without changing them
+	tasks, err := ts.Service.UpdateTasksStatus(ctx, filter, taskmodel.TaskInactive, true)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"downsample-cpu", "downsample-mem"}, names(tasks))
+	assert.Equal(t, map[string]string{"downsample-cpu": "active", "downsample-mem": "active", "alert-cpu": "active"}, statuses())
+
+	tasks, err = ts.Service.UpdateTasksStatus(ctx, filter, taskmodel.TaskInactive, false)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"downsample-cpu", "downsample-mem"}, names(tasks))
+	assert.Equal(t, map[string]string{"downsample-cpu": "inactive", "downsample-mem": "inactive", "alert-cpu": "active"}, statuses())
+
+	// tasks already in the status are not changed again
+	tasks, err = ts.Service.UpdateTasksStatus(ctx, filter, taskmodel.TaskInactive, false)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+
+	_, err = ts.Service.UpdateTasksStatus(ctx, taskmodel.TaskFilter{NamePrefix: &prefix}, taskmodel.TaskInactive, false)
+	assert.Error(t, err, "an organization is required")
+	_, err = ts.Service.UpdateTasksStatus(ctx, filter, "paused", false)
+	assert.Error(t, err)
+}
+
 type taskOptions struct {
 	name        string
 	every       string
@@ -0,0 +1,434 @@
+// Package maintenance coordinates the maintenance operations of a server,
+// such as backups, shard moves and full compactions, so they do not
+// saturate its IO. It bounds the number of operations running at once and
+// their combined throughput, and lets operators pause and abort individual
+// operations.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/pkg/limiter"
+	"github.com/influxdata/influxdb/v2/snowflake"
+)
+
+// Kind is the kind of a maintenance operation.
+type Kind string
+
+const (
+	// KindBackup is the backup of a shard or of the metadata.
+	KindBackup Kind = "backup"
+	// KindShardMove is the move of a shard between data directories.
+	KindShardMove Kind = "shard-move"
+	// KindCompaction is the full compaction of a shard.
+	KindCompaction Kind = "compaction"
+)
+
+// State is the state of a maintenance operation.
+type State string
+
+const (
+	// StateWaiting is the state of an operation waiting for other
+	// operations to complete before it starts.
+	StateWaiting State = "waiting"
+	// StateRunning is the state of a running operation.
+	StateRunning State = "running"
+	// StatePaused is the state of an operation paused by an operator.
+	StatePaused State = "paused"
+)
+
+// Operation is the state of a maintenance operation.
+type Operation struct {
+	ID          platform.ID `json:"id"`
+	Kind        Kind        `json:"kind"`
+	Description string      `json:"description"`
+	State       State       `json:"state"`
+	Bytes       int64       `json:"bytes"`
+	QueuedAt    time.Time   `json:"queuedAt"`
+	StartedAt   *time.Time  `json:"startedAt,omitempty"`
+}
+
+// Coordinator admits maintenance operations. Operations that exceed the
+// number allowed to run at once wait for a running one to end, and the
+// bytes of all running operations share the IO budget.
+type Coordinator struct {
+	slots limiter.Fixed
+	rate  limiter.Rate
+	idGen platform.IDGenerator
+	now   func() time.Time
+
+	mu  sync.Mutex
+	ops map[platform.ID]*Op
+}
+
+// CoordinatorOptFn is a functional option for configuring a Coordinator.
+type CoordinatorOptFn func(*Coordinator)
+
+// WithMaxConcurrent bounds the number of operations running at once. Zero
+// does not bound them.
+func WithMaxConcurrent(n int) CoordinatorOptFn {
+	return func(c *Coordinator) {
+		c.slots = nil
+		if n > 0 {
+			c.slots = limiter.NewFixed(n)
+		}
+	}
+}
+
+// WithIOBudget bounds the bytes per second written by all running
+// operations. Zero does not bound them.
+func WithIOBudget(bytesPerSec int) CoordinatorOptFn {
+	return func(c *Coordinator) {
+		c.rate = nil
+		if bytesPerSec > 0 {
+			c.rate = limiter.NewRate(bytesPerSec, bytesPerSec)
+		}
+	}
+}
+
+// WithIDGenerator sets the generator of operation IDs.
+func WithIDGenerator(idGen platform.IDGenerator) CoordinatorOptFn {
+	return func(c *Coordinator) {
+		c.idGen = idGen
+	}
+}
+
+// NewCoordinator constructs a Coordinator. Without options operations are
+// neither bounded in number nor in throughput.
+func NewCoordinator(opts ...CoordinatorOptFn) *Coordinator {
+	c := &Coordinator{
+		idGen: snowflake.NewDefaultIDGenerator(),
+		now:   time.Now,
+		ops:   make(map[platform.ID]*Op),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Begin admits an operation, waiting until it may run. The operation must
+// be ended by calling End. Its context is canceled when it is aborted.
+func (c *Coordinator) Begin(ctx context.Context, kind Kind, description string) (*Op, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	op := &Op{
+		c:      c,
+		ctx:    ctx,
+		cancel: cancel,
+		state: Operation{
+			ID:          c.idGen.ID(),
+			Kind:        kind,
+			Description: description,
+			State:       StateWaiting,
+			QueuedAt:    c.now().UTC(),
+		},
+	}
+
+	c.mu.Lock()
+	c.ops[op.state.ID] = op
+	c.mu.Unlock()
+
+	if c.slots != nil {
+		if err := c.slots.Take(ctx); err != nil {
+			op.End()
+			return nil, op.err(err)
+		}
+		op.hasSlot = true
+	}
+
+	op.mu.Lock()
+	startedAt := c.now().UTC()
+	op.state.StartedAt = &startedAt
+	if op.state.State == StateWaiting {
+		op.state.State = StateRunning
+	}
+	op.mu.Unlock()
+	return op, nil
+}
+
+// TryBegin admits an operation if it may run at once, without waiting. It
+// returns false when the operations allowed to run at once are running, so
+// work that is retried later, such as compactions, does not queue up. The
+// operation must be ended by calling End.
+func (c *Coordinator) TryBegin(ctx context.Context, kind Kind, description string) (*Op, bool) {
+	if c.slots != nil && !c.slots.TryTake() {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	now := c.now().UTC()
+	op := &Op{
+		c:       c,
+		ctx:     ctx,
+		cancel:  cancel,
+		hasSlot: c.slots != nil,
+		state: Operation{
+			ID:          c.idGen.ID(),
+			Kind:        kind,
+			Description: description,
+			State:       StateRunning,
+			QueuedAt:    now,
+			StartedAt:   &now,
+		},
+	}
+
+	c.mu.Lock()
+	c.ops[op.state.ID] = op
+	c.mu.Unlock()
+	return op, true
+}
+
+// Track registers an operation that runs while holding locks other work
+// waits on, such as the backup of the metadata. It is listed and can be
+// aborted like the operations admitted by Begin, but it starts at once and
+// its bytes are neither paused nor throttled, so it does not hold the locks
+// for longer than its IO takes. The operation must be ended by calling End.
+func (c *Coordinator) Track(ctx context.Context, kind Kind, description string) *Op {
+	ctx, cancel := context.WithCancel(ctx)
+	now := c.now().UTC()
+	op := &Op{
+		c:       c,
+		ctx:     ctx,
+		cancel:  cancel,
+		tracked: true,
+		state: Operation{
+			ID:          c.idGen.ID(),
+			Kind:        kind,
+			Description: description,
+			State:       StateRunning,
+			QueuedAt:    now,
+			StartedAt:   &now,
+		},
+	}
+
+	c.mu.Lock()
+	c.ops[op.state.ID] = op
+	c.mu.Unlock()
+	return op
+}
+
+// List returns the operations running and waiting, in the order they were
+// queued.
+func (c *Coordinator) List() []Operation {
+	c.mu.Lock()
+	ops := make([]Operation, 0, len(c.ops))
+	for _, op := range c.ops {
+		ops = append(ops, op.Operation())
+	}
+	c.mu.Unlock()
+
+	sort.Slice(ops, func(i, j int) bool {
+		if !ops[i].QueuedAt.Equal(ops[j].QueuedAt) {
+			return ops[i].QueuedAt.Before(ops[j].QueuedAt)
+		}
+		return ops[i].ID < ops[j].ID
+	})
+	return ops
+}
+
+// Pause pauses an operation at the next bytes it writes. Operations
+// registered with Track cannot be paused.
+func (c *Coordinator) Pause(id platform.ID) (*Operation, error) {
+	op, err := c.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if op.tracked {
+		return nil, &errors.Error{
+			Code: errors.EConflict,
+			Msg:  fmt.Sprintf("%s cannot be paused", op.state.Description),
+		}
+	}
+	op.pause()
+	o := op.Operation()
+	return &o, nil
+}
+
+// Resume resumes a paused operation.
+func (c *Coordinator) Resume(id platform.ID) (*Operation, error) {
+	op, err := c.find(id)
+	if err != nil {
+		return nil, err
+	}
+	op.resume()
+	o := op.Operation()
+	return &o, nil
+}
+
+// Abort cancels an operation. The operation fails and ends.
+func (c *Coordinator) Abort(id platform.ID) error {
+	op, err := c.find(id)
+	if err != nil {
+		return err
+	}
+	op.abort()
+	return nil
+}
+
+func (c *Coordinator) find(id platform.ID) (*Op, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	op, ok := c.ops[id]
+	if !ok {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("maintenance operation %s not found", id),
+		}
+	}
+	return op, nil
+}
+
+// Op is an operation admitted by a Coordinator.
+type Op struct {
+	c       *Coordinator
+	ctx     context.Context
+	cancel  context.CancelFunc
+	hasSlot bool
+	tracked bool
+
+	mu      sync.Mutex
+	state   Operation
+	resumed chan struct{} // set while paused, closed on resume
+	aborted bool
+	ended   bool
+}
+
+// Context returns the context of the operation, which is canceled when the
+// operation is aborted.
+func (o *Op) Context() context.Context {
+	return o.ctx
+}
+
+// Operation returns the state of the operation.
+func (o *Op) Operation() Operation {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.state
+}
+
+// Throttle accounts for n bytes written by the operation. It blocks while
+// the operation is paused and until the IO budget allows the bytes, unless
+// the operation was registered with Track. It must not be called while
+// holding locks that other work waits on.
+func (o *Op) Throttle(n int) error {
+	for !o.tracked {
+		o.mu.Lock()
+		resumed := o.resumed
+		o.mu.Unlock()
+		if resumed == nil {
+			break
+		}
+		select {
+		case <-resumed:
+		case <-o.ctx.Done():
+			return o.err(o.ctx.Err())
+		}
+	}
+
+	if rate := o.c.rate; rate != nil && !o.tracked {
+		for left := n; left > 0; {
+			chunk := left
+			if chunk > rate.Burst() {
+				chunk = rate.Burst()
+			}
+			if err := rate.WaitN(o.ctx, chunk); err != nil {
+				return o.err(err)
+			}
+			left -= chunk
+		}
+	} else if err := o.ctx.Err(); err != nil {
+		return o.err(err)
+	}
+
+	o.mu.Lock()
+	o.state.Bytes += int64(n)
+	o.mu.Unlock()
+	return nil
+}
+
+// Writer returns a writer throttling the bytes the operation writes to w.
+func (o *Op) Writer(w io.Writer) io.Writer {
+	return &opWriter{op: o, w: w}
+}
+
+// End releases the operation. It is safe to call more than once.
+func (o *Op) End() {
+	o.mu.Lock()
+	if o.ended {
+		o.mu.Unlock()
+		return
+	}
+	o.ended = true
+	o.mu.Unlock()
+
+	o.cancel()
+	if o.hasSlot {
+		o.c.slots.Release()
+	}
+
+	o.c.mu.Lock()
+	delete(o.c.ops, o.state.ID)
+	o.c.mu.Unlock()
+}
+
+func (o *Op) pause() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.resumed == nil {
+		o.resumed = make(chan struct{})
+	}
+	o.state.State = StatePaused
+}
+
+func (o *Op) resume() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.resumed != nil {
+		close(o.resumed)
+		o.resumed = nil
+	}
+	o.state.State = StateRunning
+	if o.state.StartedAt == nil {
+		o.state.State = StateWaiting
+	}
+}
+
+func (o *Op) abort() {
+	o.mu.Lock()
+	o.aborted = true
+	o.mu.Unlock()
+	o.cancel()
+}
+
+// err returns the error of an operation that was stopped by err.
+func (o *Op) err(err error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.aborted {
+		return err
+	}
+	return &errors.Error{
+		Code: errors.EConflict,
+		Msg:  fmt.Sprintf("%s was aborted", o.state.Description),
+	}
+}
+
+type opWriter struct {
+	op *Op
+	w  io.Writer
+}
+
+func (w *opWriter) Write(p []byte) (int, error) {
+	if err := w.op.Throttle(len(p)); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
@@ -0,0 +1,167 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoordinator_MaxConcurrent(t *testing.T) {
+	c := NewCoordinator(WithMaxConcurrent(1))
+	ctx := context.Background()
+
+	first, err := c.Begin(ctx, KindBackup, "backup of shard 1")
+	require.NoError(t, err)
+
+	begun := make(chan *Op)
+	go func() {
+		op, err := c.Begin(ctx, KindShardMove, "move of shard 2")
+		assert.NoError(t, err)
+		begun <- op
+	}()
+
+	require.Eventually(t, func() bool { return len(c.List()) == 2 }, time.Second, time.Millisecond)
+	ops := c.List()
+	assert.Equal(t, StateRunning, ops[0].State)
+	assert.Equal(t, StateWaiting, ops[1].State)
+	assert.Equal(t, "move of shard 2", ops[1].Description)
+
+	select {
+	case <-begun:
+		t.Fatal("second operation began while the first was running")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	first.End()
+	first.End()
+	second := <-begun
+	defer second.End()
+
+	ops = c.List()
+	require.Len(t, ops, 1)
+	assert.Equal(t, KindShardMove, ops[0].Kind)
+	assert.Equal(t, StateRunning, ops[0].State)
+	assert.NotNil(t, ops[0].StartedAt)
+}
+
+func TestCoordinator_TryBegin(t *testing.T) {
+	c := NewCoordinator(WithMaxConcurrent(1))
+	ctx := context.Background()
+
+	first, ok := c.TryBegin(ctx, KindCompaction, "full compaction of shard 1")
+	require.True(t, ok)
+
+	_, ok = c.TryBegin(ctx, KindCompaction, "full compaction of shard 2")
+	assert.False(t, ok, "operation began while the operations allowed to run were running")
+	ops := c.List()
+	require.Len(t, ops, 1)
+	assert.Equal(t, StateRunning, ops[0].State)
+	assert.Equal(t, KindCompaction, ops[0].Kind)
+
+	require.NoError(t, first.Throttle(10))
+	assert.Equal(t, int64(10), c.List()[0].Bytes)
+
+	require.NoError(t, c.Abort(first.Operation().ID))
+	assert.Error(t, first.Throttle(10))
+	first.End()
+
+	second, ok := c.TryBegin(ctx, KindCompaction, "full compaction of shard 2")
+	require.True(t, ok)
+	second.End()
+	assert.Empty(t, c.List())
+}
+
+func TestCoordinator_PauseResume(t *testing.T) {
+	c := NewCoordinator()
+	op, err := c.Begin(context.Background(), KindBackup, "backup of metadata")
+	require.NoError(t, err)
+	defer op.End()
+
+	paused, err := c.Pause(op.Operation().ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatePaused, paused.State)
+
+	throttled := make(chan error)
+	go func() { throttled <- op.Throttle(10) }()
+
+	select {
+	case <-throttled:
+		t.Fatal("paused operation was not blocked")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	resumed, err := c.Resume(op.Operation().ID)
+	require.NoError(t, err)
+	assert.Equal(t, StateRunning, resumed.State)
+	require.NoError(t, <-throttled)
+	assert.Equal(t, int64(10), op.Operation().Bytes)
+}
+
+func TestCoordinator_Abort(t *testing.T) {
+	c := NewCoordinator()
+	op, err := c.Begin(context.Background(), KindBackup, "backup of shard 1")
+	require.NoError(t, err)
+	defer op.End()
+
+	require.NoError(t, c.Abort(op.Operation().ID))
+	assert.Error(t, op.Context().Err())
+
+	err = op.Throttle(1)
+	assert.Equal(t, errors.EConflict, errors.ErrorCode(err))
+	assert.Contains(t, err.Error(), "backup of shard 1 was aborted")
+
+	op.End()
+	assert.Empty(t, c.List())
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(c.Abort(op.Operation().ID)))
+}
+
+func TestCoordinator_AbortWaiting(t *testing.T) {
+	c := NewCoordinator(WithMaxConcurrent(1))
+	ctx := context.Background()
+
+	first, err := c.Begin(ctx, KindBackup, "backup of shard 1")
+	require.NoError(t, err)
+	defer first.End()
+
+	begun := make(chan error)
+	go func() {
+		_, err := c.Begin(ctx, KindBackup, "backup of shard 2")
+		begun <- err
+	}()
+
+	require.Eventually(t, func() bool { return len(c.List()) == 2 }, time.Second, time.Millisecond)
+	require.NoError(t, c.Abort(c.List()[1].ID))
+
+	err = <-begun
+	assert.Equal(t, errors.EConflict, errors.ErrorCode(err))
+	assert.Len(t, c.List(), 1)
+}
+
+func TestCoordinator_Track(t *testing.T) {
+	c := NewCoordinator(WithMaxConcurrent(1), WithIOBudget(1))
+	ctx := context.Background()
+
+	running, err := c.Begin(ctx, KindBackup, "backup of shard 1")
+	require.NoError(t, err)
+	defer running.End()
+
+	// Tracked operations start at once and are not throttled.
+	op := c.Track(ctx, KindBackup, "backup of metadata")
+	defer op.End()
+	require.NoError(t, op.Throttle(1<<20))
+
+	ops := c.List()
+	require.Len(t, ops, 2)
+	assert.Equal(t, StateRunning, ops[1].State)
+	assert.Equal(t, int64(1<<20), ops[1].Bytes)
+
+	_, err = c.Pause(op.Operation().ID)
+	assert.Equal(t, errors.EConflict, errors.ErrorCode(err))
+
+	require.NoError(t, c.Abort(op.Operation().ID))
+	assert.Equal(t, errors.EConflict, errors.ErrorCode(op.Throttle(1)))
+}
@@ -0,0 +1,252 @@
+package maintenance
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"go.uber.org/zap"
+)
+
+const prefixMaintenance = "/api/v2/maintenance"
+
+// Handler serves the maintenance operations to operators.
+type Handler struct {
+	chi.Router
+
+	log         *zap.Logger
+	api         *kithttp.API
+	coordinator *Coordinator
+	schedule    *tsdb.CompactionSchedule
+	shards      ShardStore
+}
+
+// HandlerOptFn configures the Handler.
+type HandlerOptFn func(*Handler)
+
+// WithCompactionSchedule reports the windows full compactions may start in
+// on the GET /api/v2/maintenance/compaction-schedule route.
+func WithCompactionSchedule(s *tsdb.CompactionSchedule) HandlerOptFn {
+	return func(h *Handler) {
+		h.schedule = s
+	}
+}
+
+// WithShardStore reports the deleted data of shards on the GET
+// /api/v2/maintenance/tombstones route, and compacts it away on the POST
+// /api/v2/maintenance/tombstones/compact route.
+func WithShardStore(s ShardStore) HandlerOptFn {
+	return func(h *Handler) {
+		h.shards = s
+	}
+}
+
+// NewHTTPHandler constructs a handler listing, pausing and aborting the
+// operations of the coordinator.
+func NewHTTPHandler(log *zap.Logger, coordinator *Coordinator, opts ...HandlerOptFn) *Handler {
+	h := &Handler{
+		log:         log,
+		api:         kithttp.NewAPI(kithttp.WithLog(log)),
+		coordinator: coordinator,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+		h.mwAuthorize,
+	)
+
+	r.Get("/", h.handleGetOperations)
+	r.Get("/compaction-schedule", h.handleGetCompactionSchedule)
+	r.Route("/tombstones", func(r chi.Router) {
+		r.Use(h.mwShardStore)
+		r.Get("/", h.handleGetTombstones)
+		r.Post("/compact", h.handleCompactTombstones)
+	})
+	r.Route("/{id}", func(r chi.Router) {
+		r.Delete("/", h.handleAbortOperation)
+		r.Post("/pause", h.handlePauseOperation)
+		r.Post("/resume", h.handleResumeOperation)
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix is the route the handler is mounted at.
+func (h *Handler) Prefix() string {
+	return prefixMaintenance
+}
+
+type operationsResponse struct {
+	Operations []Operation `json:"operations"`
+}
+
+// handleGetOperations is the HTTP handler for the GET /api/v2/maintenance route.
+func (h *Handler) handleGetOperations(w http.ResponseWriter, r *http.Request) {
+	h.api.Respond(w, r, http.StatusOK, operationsResponse{
+		Operations: h.coordinator.List(),
+	})
+}
+
+type compactionScheduleResponse struct {
+	AllowWindows    []tsdb.TimeWindow `json:"allowWindows"`
+	BlackoutWindows []tsdb.TimeWindow `json:"blackoutWindows"`
+	Timezone        string            `json:"timezone"`
+	// FullCompactionsAllowed reports whether full compactions may start now.
+	FullCompactionsAllowed bool `json:"fullCompactionsAllowed"`
+	// NextChange is when full compactions are next allowed, or no longer
+	// allowed, to start. It is omitted when that never happens.
+	NextChange *time.Time `json:"nextChange,omitempty"`
+}
+
+// handleGetCompactionSchedule is the HTTP handler for the GET /api/v2/maintenance/compaction-schedule route.
+func (h *Handler) handleGetCompactionSchedule(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	res := compactionScheduleResponse{
+		AllowWindows:           []tsdb.TimeWindow{},
+		BlackoutWindows:        []tsdb.TimeWindow{},
+		Timezone:               h.schedule.Timezone(),
+		FullCompactionsAllowed: h.schedule.Allowed(now),
+	}
+	if h.schedule != nil {
+		res.AllowWindows = append(res.AllowWindows, h.schedule.Allow...)
+		res.BlackoutWindows = append(res.BlackoutWindows, h.schedule.Blackout...)
+	}
+	if next, ok := h.schedule.NextChange(now); ok {
+		res.NextChange = &next
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+type tombstonesResponse struct {
+	Shards []ShardTombstones `json:"shards"`
+	// WastedSize is the total estimated bytes taken by deleted data.
+	WastedSize int64 `json:"wastedSize"`
+}
+
+// handleGetTombstones is the HTTP handler for the GET /api/v2/maintenance/tombstones route.
+func (h *Handler) handleGetTombstones(w http.ResponseWriter, r *http.Request) {
+	res := tombstonesResponse{Shards: TombstoneReport(h.shards)}
+	for _, s := range res.Shards {
+		res.WastedSize += s.WastedSize
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+type compactTombstonesRequest struct {
+	ShardIDs []uint64 `json:"shardIDs"`
+}
+
+// handleCompactTombstones is the HTTP handler for the POST /api/v2/maintenance/tombstones/compact route.
+func (h *Handler) handleCompactTombstones(w http.ResponseWriter, r *http.Request) {
+	var req compactTombstonesRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if len(req.ShardIDs) == 0 {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "shardIDs is required",
+		})
+		return
+	}
+
+	if err := CompactTombstones(h.shards, req.ShardIDs); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Info("Scheduled tombstone compactions", zap.Uint64s("shard_ids", req.ShardIDs))
+	h.api.Respond(w, r, http.StatusAccepted, req)
+}
+
+// handlePauseOperation is the HTTP handler for the POST /api/v2/maintenance/:id/pause route.
+func (h *Handler) handlePauseOperation(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	op, err := h.coordinator.Pause(*id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Info("Paused maintenance operation", zap.Stringer("id", id), zap.String("description", op.Description))
+	h.api.Respond(w, r, http.StatusOK, op)
+}
+
+// handleResumeOperation is the HTTP handler for the POST /api/v2/maintenance/:id/resume route.
+func (h *Handler) handleResumeOperation(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	op, err := h.coordinator.Resume(*id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Info("Resumed maintenance operation", zap.Stringer("id", id), zap.String("description", op.Description))
+	h.api.Respond(w, r, http.StatusOK, op)
+}
+
+// handleAbortOperation is the HTTP handler for the DELETE /api/v2/maintenance/:id route.
+func (h *Handler) handleAbortOperation(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.coordinator.Abort(*id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Info("Aborted maintenance operation", zap.Stringer("id", id))
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
+
+func (h *Handler) mwShardStore(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if h.shards == nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.ENotImplemented,
+				Msg:  "tombstone reports are not supported",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+func (h *Handler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("access to %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
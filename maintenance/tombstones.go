@@ -0,0 +1,89 @@
+package maintenance
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+// ShardStore is the storage the deleted data of shards is reported and
+// compacted in.
+type ShardStore interface {
+	ShardIDs() []uint64
+	Shards(ids []uint64) []*tsdb.Shard
+}
+
+// ShardTombstones is the deleted data of a shard still on disk.
+type ShardTombstones struct {
+	ShardID  uint64 `json:"shardID"`
+	BucketID string `json:"bucketID"`
+	// TombstoneFiles is the number of TSM files with tombstones, and
+	// TombstoneSize the size of the tombstones.
+	TombstoneFiles int   `json:"tombstoneFiles"`
+	TombstoneSize  int64 `json:"tombstoneSize"`
+	// TSMSize is the size of the TSM files with tombstones.
+	TSMSize int64 `json:"tsmSize"`
+	// WastedSize estimates the bytes a compaction of the files would reclaim.
+	WastedSize int64 `json:"wastedSize"`
+}
+
+// TombstoneReport lists the shards with tombstones, the most wasted space
+// first. It reads the index of every TSM file with tombstones.
+func TombstoneReport(store ShardStore) []ShardTombstones {
+	report := []ShardTombstones{}
+	for _, sh := range store.Shards(store.ShardIDs()) {
+		stats, err := sh.TombstoneStats()
+		if err != nil || stats.TombstoneFiles == 0 {
+			continue
+		}
+		report = append(report, ShardTombstones{
+			ShardID:        sh.ID(),
+			BucketID:       sh.Database(),
+			TombstoneFiles: stats.TombstoneFiles,
+			TombstoneSize:  stats.TombstoneSize,
+			TSMSize:        stats.TSMSize,
+			WastedSize:     stats.WastedSize,
+		})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].WastedSize != report[j].WastedSize {
+			return report[i].WastedSize > report[j].WastedSize
+		}
+		return report[i].ShardID < report[j].ShardID
+	})
+	return report
+}
+
+// CompactTombstones schedules full compactions of the shards, which rewrite
+// their TSM files without the deleted data. Like any full compaction, they
+// start within the compaction windows and as the coordinator admits them.
+func CompactTombstones(store ShardStore, ids []uint64) error {
+	shards := store.Shards(ids)
+	if len(shards) != len(ids) {
+		found := make(map[uint64]bool, len(shards))
+		for _, sh := range shards {
+			found[sh.ID()] = true
+		}
+		for _, id := range ids {
+			if !found[id] {
+				return &errors.Error{
+					Code: errors.ENotFound,
+					Msg:  fmt.Sprintf("shard %d not found", id),
+				}
+			}
+		}
+	}
+
+	for _, sh := range shards {
+		if err := sh.ScheduleFullCompaction(); err != nil {
+			return &errors.Error{
+				Code: errors.EInternal,
+				Msg:  fmt.Sprintf("failed to schedule compaction of shard %d", sh.ID()),
+				Err:  err,
+			}
+		}
+	}
+	return nil
+}
@@ -23,7 +23,7 @@ type DashboardService struct {
 
 	AddDashboardCellF            func(ctx context.Context, id platform2.ID, c *platform.Cell, opts platform.AddDashboardCellOptions) error
 	AddDashboardCellCalls        SafeCount
-	RemoveDashboardCellF         func(ctx context.Context, dashboardID platform2.ID, cellID platform2.ID) error
+	RemoveDashboardCellF         func(ctx context.Context, dashboardID platform2.ID, cellID platform2.ID, opts platform.DashboardCellsOptions) error
 	RemoveDashboardCellCalls     SafeCount
 	GetDashboardCellViewF        func(ctx context.Context, dashboardID platform2.ID, cellID platform2.ID) (*platform.View, error)
 	GetDashboardCellViewCalls    SafeCount
@@ -33,7 +33,7 @@ type DashboardService struct {
 	UpdateDashboardCellCalls     SafeCount
 	CopyDashboardCellF           func(ctx context.Context, dashboardID platform2.ID, cellID platform2.ID) (*platform.Cell, error)
 	CopyDashboardCellCalls       SafeCount
-	ReplaceDashboardCellsF       func(ctx context.Context, id platform2.ID, cs []*platform.Cell) error
+	ReplaceDashboardCellsF       func(ctx context.Context, id platform2.ID, cs []*platform.Cell, opts platform.DashboardCellsOptions) error
 	ReplaceDashboardCellsCalls   SafeCount
 }
 
@@ -53,7 +53,9 @@ func NewDashboardService() *DashboardService {
 		AddDashboardCellF: func(ctx context.Context, id platform2.ID, c *platform.Cell, opts platform.AddDashboardCellOptions) error {
 			return nil
 		},
-		RemoveDashboardCellF: func(ctx context.Context, dashboardID platform2.ID, cellID platform2.ID) error { return nil },
+		RemoveDashboardCellF: func(ctx context.Context, dashboardID platform2.ID, cellID platform2.ID, opts platform.DashboardCellsOptions) error {
+			return nil
+		},
 		GetDashboardCellViewF: func(ctx context.Context, dashboardID platform2.ID, cellID platform2.ID) (*platform.View, error) {
 			return nil, nil
 		},
@@ -66,7 +68,9 @@ func NewDashboardService() *DashboardService {
 		CopyDashboardCellF: func(ctx context.Context, dashboardID platform2.ID, cellID platform2.ID) (*platform.Cell, error) {
 			return nil, nil
 		},
-		ReplaceDashboardCellsF: func(ctx context.Context, id platform2.ID, cs []*platform.Cell) error { return nil },
+		ReplaceDashboardCellsF: func(ctx context.Context, id platform2.ID, cs []*platform.Cell, opts platform.DashboardCellsOptions) error {
+			return nil
+		},
 	}
 }
 
@@ -110,14 +114,14 @@ func (s *DashboardService) AddDashboardCell(ctx context.Context, id platform2.ID
 	return s.AddDashboardCellF(ctx, id, c, opts)
 }
 
-func (s *DashboardService) ReplaceDashboardCells(ctx context.Context, id platform2.ID, cs []*platform.Cell) error {
+func (s *DashboardService) ReplaceDashboardCells(ctx context.Context, id platform2.ID, cs []*platform.Cell, opts platform.DashboardCellsOptions) error {
 	defer s.ReplaceDashboardCellsCalls.IncrFn()()
-	return s.ReplaceDashboardCellsF(ctx, id, cs)
+	return s.ReplaceDashboardCellsF(ctx, id, cs, opts)
 }
 
-func (s *DashboardService) RemoveDashboardCell(ctx context.Context, dashboardID platform2.ID, cellID platform2.ID) error {
+func (s *DashboardService) RemoveDashboardCell(ctx context.Context, dashboardID platform2.ID, cellID platform2.ID, opts platform.DashboardCellsOptions) error {
 	defer s.RemoveDashboardCellCalls.IncrFn()()
-	return s.RemoveDashboardCellF(ctx, dashboardID, cellID)
+	return s.RemoveDashboardCellF(ctx, dashboardID, cellID, opts)
 }
 
 func (s *DashboardService) UpdateDashboardCell(ctx context.Context, dashboardID platform2.ID, cellID platform2.ID, upd platform.CellUpdate) (*platform.Cell, error) {
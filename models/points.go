@@ -402,6 +402,76 @@ func ParsePointsWithPrecision(buf []byte, defaultTime time.Time, precision strin
 
 }
 
+// LineError is a line of a batch of points that could not be parsed.
+type LineError struct {
+	// Line is the number of the line in the batch, starting at 1.
+	Line int
+	// Text is the text of the line.
+	Text string
+	Err  error
+}
+
+func (e LineError) Error() string {
+	return e.Err.Error()
+}
+
+// ParsePointsWithLines is similar to ParsePointsWithPrecision, but returns
+// the line every point was parsed from, starting at 1, and an error for
+// every line that could not be parsed.
+func ParsePointsWithLines(buf []byte, defaultTime time.Time, precision string) ([]Point, []int, []LineError) {
+	points := make([]Point, 0, bytes.Count(buf, []byte{'\n'})+1)
+	var (
+		lines   = make([]int, 0, cap(points))
+		failed  []LineError
+		pos     int
+		block   []byte
+		line    = 1
+		counted int // position of buf newlines are counted up to
+	)
+	for pos < len(buf) {
+		blockPos := pos
+		pos, block = scanLine(buf, pos)
+		pos++
+
+		if len(block) == 0 {
+			continue
+		}
+
+		start := skipWhitespace(block, 0)
+
+		// If line is all whitespace, just skip it
+		if start >= len(block) {
+			continue
+		}
+
+		// lines which start with '#' are comments
+		if block[start] == '#' {
+			continue
+		}
+
+		// strip the newline if one is present
+		if block[len(block)-1] == '\n' {
+			block = block[:len(block)-1]
+		}
+
+		line += bytes.Count(buf[counted:blockPos+start], []byte{'\n'})
+		counted = blockPos + start
+
+		pt, err := parsePoint(block[start:], defaultTime, precision)
+		if err != nil {
+			failed = append(failed, LineError{
+				Line: line,
+				Text: string(block[start:]),
+				Err:  fmt.Errorf("unable to parse '%s': %v", string(block[start:]), err),
+			})
+		} else {
+			points = append(points, pt)
+			lines = append(lines, line)
+		}
+	}
+	return points, lines, failed
+}
+
 func parsePoint(buf []byte, defaultTime time.Time, precision string) (Point, error) {
 	// scan the first block which is measurement[,tag1=value1,tag2=value2...]
 	pos, key, err := scanKey(buf, 0)
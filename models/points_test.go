@@ -1950,6 +1950,42 @@ cpu,host=serverA,region=us-east value=1.0 946730096789012345`,
 	}
 }
 
+func TestParsePointsWithLines(t *testing.T) {
+	batch := `# comment
+cpu value=1 1
+
+cpu value=
+  cpu str="multi
+line" 2
+cpu value=3 3
+mem`
+	pts, lines, failed := models.ParsePointsWithLines([]byte(batch), time.Now().UTC(), "")
+
+	var got []string
+	for _, pt := range pts {
+		got = append(got, pt.String())
+	}
+	if exp := []string{"cpu value=1 1", "cpu str=\"multi\nline\" 2", "cpu value=3 3"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("points mismatch:\n got %q\n exp %q", got, exp)
+	}
+	if exp := []int{2, 5, 7}; !reflect.DeepEqual(lines, exp) {
+		t.Errorf("lines mismatch: got %v, exp %v", lines, exp)
+	}
+
+	if len(failed) != 2 {
+		t.Fatalf("failed lines mismatch: got %v, exp 2", failed)
+	}
+	if failed[0].Line != 4 || failed[0].Error() != "unable to parse 'cpu value=': missing field value" {
+		t.Errorf("failed line mismatch: got %d %v", failed[0].Line, failed[0])
+	}
+	if failed[1].Line != 8 || failed[1].Error() != "unable to parse 'mem': missing fields" {
+		t.Errorf("failed line mismatch: got %d %v", failed[1].Line, failed[1])
+	}
+	if failed[0].Text != "cpu value=" || failed[1].Text != "mem" {
+		t.Errorf("failed line text mismatch: got %q, %q", failed[0].Text, failed[1].Text)
+	}
+}
+
 func TestNewPointEscaped(t *testing.T) {
 	// commas
 	pt := models.MustNewPoint("cpu,main", models.NewTags(map[string]string{"tag,bar": "value"}), models.Fields{"name,bar": 1.0}, time.Unix(0, 0))
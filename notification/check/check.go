@@ -3,6 +3,7 @@ package check
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/influxdb/v2"
@@ -35,6 +36,17 @@ type Base struct {
 	Offset *notification.Duration `json:"offset,omitempty"`
 
 	Tags []influxdb.Tag `json:"tags"`
+	// StatusBucket is the bucket of the organization the statuses of the
+	// check are written to instead of _monitoring, which keeps
+	// high-frequency checks from bloating it. Notification rules read the
+	// statuses in _monitoring, so they do not notify on the statuses of a
+	// check with a StatusBucket.
+	StatusBucket string `json:"statusBucket,omitempty"`
+	// Timezone aligns the windows of threshold checks to the local time
+	// of a time zone such as Europe/Berlin, so that daily windows start at
+	// local midnight across daylight saving changes. Checks created
+	// without one get the default time zone of their organization.
+	Timezone string `json:"timezone,omitempty"`
 	influxdb.CRUDLog
 }
 
@@ -87,6 +99,15 @@ func (b Base) Valid(lang fluxlang.FluxLanguageService) error {
 			return err
 		}
 	}
+	if strings.HasPrefix(b.StatusBucket, "_") || strings.Contains(b.StatusBucket, "\"") {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("Check StatusBucket %s is not a valid bucket name", b.StatusBucket),
+		}
+	}
+	if err := influxdb.ValidTimezone(b.Timezone); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -112,6 +133,42 @@ func (b Base) generateTaskOption() ast.Statement {
 	return flux.DefineTaskOption(flux.Object(props...))
 }
 
+// generateStatusBucketOption redirects the statuses monitor.check writes
+// when the check has a StatusBucket. The generated script must import
+// experimental.
+func (b Base) generateStatusBucketOption() []ast.Statement {
+	if b.StatusBucket == "" {
+		return nil
+	}
+
+	// monitor.bucket is not an option, monitor.write is.
+	params := []*ast.Property{{Key: flux.Identifier("tables"), Value: &ast.PipeLiteral{}}}
+	to := flux.Call(flux.Member("experimental", "to"), flux.Object(flux.Property("bucket", flux.String(b.StatusBucket))))
+	write := flux.Function(params, flux.Pipe(flux.Identifier("tables"), to))
+	return []ast.Statement{flux.DefineOption("monitor", "write", write)}
+}
+
+// addLocationOption aligns the windows of the script to the time zone of
+// the check, unless the script sets the location itself.
+func (b Base) addLocationOption(f *ast.File) {
+	if b.Timezone == "" {
+		return
+	}
+	for _, stmt := range f.Body {
+		if opt, ok := stmt.(*ast.OptionStatement); ok {
+			if v, ok := opt.Assignment.(*ast.VariableAssignment); ok && v.ID.Name == "location" {
+				return
+			}
+		}
+	}
+
+	if !hasImport(f, "timezone") {
+		f.Imports = append(f.Imports, flux.ImportDeclaration("timezone"))
+	}
+	// The option must precede the windows of the query that read it.
+	f.Body = append([]ast.Statement{flux.DefineLocationOption(b.Timezone)}, f.Body...)
+}
+
 func (b Base) generateFluxASTCheckDefinition(checkType string) ast.Statement {
 	props := append([]*ast.Property{}, flux.Property("_check_id", flux.String(b.ID.String())))
 	props = append(props, flux.Property("_check_name", flux.String(b.Name)))
@@ -144,6 +201,20 @@ func (b Base) GetOwnerID() platform.ID {
 }
 
 // GetTaskID retrieves the task ID for a check.
+func (b Base) GetStatusBucket() string {
+	return b.StatusBucket
+}
+
+// GetTimezone returns the time zone the windows of the check are aligned to.
+func (b Base) GetTimezone() string {
+	return b.Timezone
+}
+
+// SetTimezone sets the time zone the windows of the check are aligned to.
+func (b *Base) SetTimezone(tz string) {
+	b.Timezone = tz
+}
+
 func (b Base) GetTaskID() platform.ID {
 	return b.TaskID
 }
@@ -199,9 +270,11 @@ func (b *Base) SetDescription(description string) {
 }
 
 var typeToCheck = map[string](func() influxdb.Check){
-	"deadman":   func() influxdb.Check { return &Deadman{} },
-	"threshold": func() influxdb.Check { return &Threshold{} },
-	"custom":    func() influxdb.Check { return &Custom{} },
+	"deadman":          func() influxdb.Check { return &Deadman{} },
+	"threshold":        func() influxdb.Check { return &Threshold{} },
+	"custom":           func() influxdb.Check { return &Custom{} },
+	"custom_threshold": func() influxdb.Check { return &CustomThreshold{} },
+	"sql":              func() influxdb.Check { return &SQL{} },
 }
 
 // UnmarshalJSON will convert
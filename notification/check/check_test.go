@@ -131,6 +131,42 @@ func TestValidCheck(t *testing.T) {
 				Msg:  "tag must contain a key and a value",
 			},
 		},
+		{
+			name: "system status bucket",
+			src: &check.Deadman{
+				Base: check.Base{
+					ID:                    influxTesting.MustIDBase16(id1),
+					Name:                  "name1",
+					OwnerID:               influxTesting.MustIDBase16(id2),
+					OrgID:                 influxTesting.MustIDBase16(id3),
+					StatusMessageTemplate: "temp1",
+					Every:                 mustDuration("1m"),
+					StatusBucket:          "_tasks",
+				},
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "Check StatusBucket _tasks is not a valid bucket name",
+			},
+		},
+		{
+			name: "unknown time zone",
+			src: &check.Deadman{
+				Base: check.Base{
+					ID:                    influxTesting.MustIDBase16(id1),
+					Name:                  "name1",
+					OwnerID:               influxTesting.MustIDBase16(id2),
+					OrgID:                 influxTesting.MustIDBase16(id3),
+					StatusMessageTemplate: "temp1",
+					Every:                 mustDuration("1m"),
+					Timezone:              "Mars/Olympus",
+				},
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  `unknown time zone "Mars/Olympus"`,
+			},
+		},
 		{
 			name: "bad threshold",
 			src: &check.Threshold{
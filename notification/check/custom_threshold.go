@@ -0,0 +1,220 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/ast/astutil"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification/flux"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/query/fluxlang"
+)
+
+var _ influxdb.Check = (*CustomThreshold)(nil)
+
+// managedStatements are the variables of the task of a custom threshold
+// check that are generated from its metadata. The query defining them is
+// ignored.
+var managedStatements = map[string]bool{
+	"check":     true,
+	"messageFn": true,
+	"ok":        true,
+	"info":      true,
+	"warn":      true,
+	"crit":      true,
+}
+
+// CustomThreshold is the custom threshold check. Its query is custom flux
+// producing the data to check, while its thresholds, schedule, tags and
+// status message are managed like the ones of a threshold check and
+// stitched to the query when the task is generated.
+//
+// The query either defines data or ends with the pipeline producing it.
+// Its task option, check object, message and level functions are replaced
+// by the generated ones.
+type CustomThreshold struct {
+	Base
+	// Field is the column of the data the thresholds are compared to. The
+	// data is checked as the query returns it, so a field must be pivoted
+	// to a column by the query.
+	Field      string            `json:"field"`
+	Thresholds []ThresholdConfig `json:"thresholds"`
+}
+
+// Type returns the type of the check.
+func (c CustomThreshold) Type() string {
+	return "custom_threshold"
+}
+
+// Valid returns error if something is invalid.
+func (c CustomThreshold) Valid(lang fluxlang.FluxLanguageService) error {
+	if err := c.Base.Valid(lang); err != nil {
+		return err
+	}
+	if c.Field == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "Check Field can't be empty",
+		}
+	}
+	for _, cc := range c.Thresholds {
+		if err := cc.Valid(); err != nil {
+			return err
+		}
+	}
+	if _, err := c.GenerateFluxAST(lang); err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "Check query is invalid",
+			Err:  err,
+		}
+	}
+	return nil
+}
+
+type customThresholdDecode struct {
+	Base
+	Field      string                  `json:"field"`
+	Thresholds []thresholdConfigDecode `json:"thresholds"`
+}
+
+// UnmarshalJSON implement json.Unmarshaler interface.
+func (c *CustomThreshold) UnmarshalJSON(b []byte) error {
+	raw := new(customThresholdDecode)
+	if err := json.Unmarshal(b, raw); err != nil {
+		return err
+	}
+	thresholds, err := decodeThresholdConfigs(raw.Thresholds)
+	if err != nil {
+		return err
+	}
+	c.Base = raw.Base
+	c.Field = raw.Field
+	c.Thresholds = thresholds
+	return nil
+}
+
+type customThresholdAlias CustomThreshold
+
+// MarshalJSON implement json.Marshaler interface.
+func (c CustomThreshold) MarshalJSON() ([]byte, error) {
+	return json.Marshal(
+		struct {
+			customThresholdAlias
+			Type string `json:"type"`
+		}{
+			customThresholdAlias: customThresholdAlias(c),
+			Type:                 c.Type(),
+		})
+}
+
+// GenerateFlux returns the flux script of the task of the check, the query
+// followed by the statements generated from the metadata of the check.
+func (c CustomThreshold) GenerateFlux(lang fluxlang.FluxLanguageService) (string, error) {
+	f, err := c.GenerateFluxAST(lang)
+	if err != nil {
+		return "", err
+	}
+
+	return astutil.Format(f)
+}
+
+// GenerateFluxAST returns the flux AST of the task of the check. If there
+// are any errors in the query the function will return an error for each
+// error found when the script is parsed.
+func (c CustomThreshold) GenerateFluxAST(lang fluxlang.FluxLanguageService) (*ast.File, error) {
+	p, err := query.Parse(lang, c.Query.Text)
+	if p == nil {
+		return nil, err
+	}
+	if errs := ast.GetErrors(p); len(errs) != 0 {
+		return nil, multiError(errs)
+	}
+	if len(p.Files) != 1 {
+		return nil, fmt.Errorf("expect a single file to be returned from query parsing got %d", len(p.Files))
+	}
+
+	f := p.Files[0]
+	body, err := customThresholdData(f.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.Body = append(body, c.generateFluxASTBody()...)
+	if !hasImport(f, "influxdata/influxdb/monitor") {
+		f.Imports = append(f.Imports, flux.ImportDeclaration("influxdata/influxdb/monitor"))
+	}
+	c.addLocationOption(f)
+	return f, nil
+}
+
+// customThresholdData returns the statements of a query without the ones
+// the check generates, with the pipeline the query ends with assigned to
+// data when the query does not define it.
+func customThresholdData(stmts []ast.Statement) ([]ast.Statement, error) {
+	var (
+		body    []ast.Statement
+		last    ast.Expression
+		hasData bool
+	)
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *ast.OptionStatement:
+			if va, ok := s.Assignment.(*ast.VariableAssignment); ok && va.ID.Name == "task" {
+				continue
+			}
+		case *ast.VariableAssignment:
+			if managedStatements[s.ID.Name] {
+				continue
+			}
+			if s.ID.Name == "data" {
+				hasData = true
+			}
+		case *ast.ExpressionStatement:
+			// The generated task ends with the check of data, results of
+			// the query are not written.
+			last = s.Expression
+			continue
+		}
+		body = append(body, stmt)
+	}
+	if hasData {
+		return body, nil
+	}
+
+	pipe, ok := last.(*ast.PipeExpression)
+	if !ok {
+		return nil, fmt.Errorf("query must define data or end with the pipeline producing it")
+	}
+	if id, ok := pipe.Call.Callee.(*ast.Identifier); ok && id.Name == "yield" {
+		last = pipe.Argument
+	}
+	return append(body, flux.DefineVariable("data", last)), nil
+}
+
+func hasImport(f *ast.File, path string) bool {
+	for _, imp := range f.Imports {
+		if imp.Path.Value == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (c CustomThreshold) generateFluxASTBody() []ast.Statement {
+	t := Threshold{Base: c.Base, Thresholds: c.Thresholds}
+
+	var statements []ast.Statement
+	statements = append(statements, c.generateTaskOption())
+	statements = append(statements, c.generateStatusBucketOption()...)
+	statements = append(statements, c.generateFluxASTCheckDefinition(c.Type()))
+	statements = append(statements, t.generateFluxASTThresholdFunctions(c.Field)...)
+	statements = append(statements, c.generateFluxASTMessageFunction())
+	statements = append(statements, flux.ExpressionStatement(flux.Pipe(
+		flux.Identifier("data"),
+		t.generateFluxASTChecksCall(),
+	)))
+	return statements
+}
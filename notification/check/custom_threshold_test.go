@@ -0,0 +1,129 @@
+package check_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/notification"
+	"github.com/influxdata/influxdb/v2/notification/check"
+	"github.com/influxdata/influxdb/v2/query/fluxlang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomThreshold_GenerateFlux(t *testing.T) {
+	newCheck := func(query string) check.CustomThreshold {
+		return check.CustomThreshold{
+			Base: check.Base{
+				ID:                    10,
+				Name:                  "moo",
+				OrgID:                 20,
+				Every:                 mustDuration("1m"),
+				StatusMessageTemplate: "whoa!",
+				Tags: []influxdb.Tag{
+					{Key: "aaa", Value: "vaaa"},
+				},
+				Query: influxdb.DashboardQuery{Text: query},
+			},
+			Field: "ratio",
+			Thresholds: []check.ThresholdConfig{
+				check.Greater{
+					ThresholdConfigBase: check.ThresholdConfigBase{Level: notification.Critical},
+					Value:               0.5,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "query ending with the data pipeline",
+			query: `import "math"
+from(bucket: "foo") |> range(start: -5m) |> map(fn: (r) => ({r with ratio: math.abs(x: r._value)})) |> yield()`,
+			contains: []string{
+				`import "math"`,
+				`import "influxdata/influxdb/monitor"`,
+				`data =`,
+				`option task = {name: "moo", every: 1m}`,
+				`check = {_check_id: "000000000000000a", _check_name: "moo", _type: "custom_threshold", tags: {aaa: "vaaa"}}`,
+				`crit = (r) => r["ratio"] > 0.5`,
+				`messageFn = (r) => "whoa!"`,
+			},
+			excludes: []string{`yield`, `fieldsAsCols`},
+		},
+		{
+			name: "managed statements of the query are replaced",
+			query: `option task = {name: "other", every: 1h}
+data = from(bucket: "foo") |> range(start: -5m)
+check = {_check_id: "x", _check_name: "other", _type: "custom", tags: {}}
+crit = (r) => r.ratio > 100.0
+data |> monitor.check(data: check, messageFn: (r) => "", crit: crit)`,
+			contains: []string{
+				`option task = {name: "moo", every: 1m}`,
+				`_type: "custom_threshold"`,
+				`crit = (r) => r["ratio"] > 0.5`,
+			},
+			excludes: []string{`"other"`, `100.0`, `_check_id: "x"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCheck(tt.query)
+			require.NoError(t, c.Valid(fluxlang.DefaultService))
+
+			s, err := c.GenerateFlux(fluxlang.DefaultService)
+			require.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, s, want)
+			}
+			for _, exclude := range tt.excludes {
+				assert.NotContains(t, s, exclude)
+			}
+		})
+	}
+
+	t.Run("query without data is invalid", func(t *testing.T) {
+		c := newCheck(`x = 1`)
+		assert.Error(t, c.Valid(fluxlang.DefaultService))
+	})
+
+	t.Run("field is required", func(t *testing.T) {
+		c := newCheck(`from(bucket: "foo") |> range(start: -5m)`)
+		c.Field = ""
+		assert.Error(t, c.Valid(fluxlang.DefaultService))
+	})
+}
+
+func TestCustomThreshold_JSON(t *testing.T) {
+	c := &check.CustomThreshold{
+		Base: check.Base{
+			ID:    10,
+			Name:  "moo",
+			OrgID: 20,
+			Every: mustDuration("1m"),
+			Query: influxdb.DashboardQuery{Text: `from(bucket: "foo") |> range(start: -5m)`},
+		},
+		Field: "ratio",
+		Thresholds: []check.ThresholdConfig{
+			&check.Range{
+				ThresholdConfigBase: check.ThresholdConfigBase{Level: notification.Warn},
+				Min:                 1,
+				Max:                 2,
+			},
+		},
+	}
+	b, err := json.Marshal(c)
+	require.NoError(t, err)
+
+	got, err := check.UnmarshalJSON(b)
+	require.NoError(t, err)
+	assert.Equal(t, "custom_threshold", got.Type())
+	assert.Equal(t, c, got)
+}
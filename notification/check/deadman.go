@@ -76,6 +76,7 @@ func (c Deadman) GenerateFluxAST(lang fluxlang.FluxLanguageService) (*ast.File,
 func (c Deadman) generateFluxASTBody() []ast.Statement {
 	var statements []ast.Statement
 	statements = append(statements, c.generateTaskOption())
+	statements = append(statements, c.generateStatusBucketOption()...)
 	statements = append(statements, c.generateFluxASTCheckDefinition("deadman"))
 	statements = append(statements, c.generateLevelFn())
 	statements = append(statements, c.generateFluxASTMessageFunction())
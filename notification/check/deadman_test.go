@@ -126,6 +126,45 @@ check = {_check_id: "000000000000000a", _check_name: "moo", _type: "deadman", ta
 info = (r) => r["dead"]
 messageFn = (r) => "whoa! {r[\"dead\"]}"
 
+data
+    |> v1["fieldsAsCols"]()
+    |> monitor["deadman"](t: experimental["subDuration"](from: now(), d: 60s))
+    |> monitor["check"](data: check, messageFn: messageFn, info: info)`,
+			},
+		},
+		{
+			name: "with status bucket",
+			args: args{
+				deadman: check.Deadman{
+					Base: check.Base{
+						ID:                    10,
+						Name:                  "moo",
+						Every:                 mustDuration("1h"),
+						StatusMessageTemplate: "whoa! {r[\"dead\"]}",
+						StatusBucket:          "hf_statuses",
+						Query: influxdb.DashboardQuery{
+							Text: `from(bucket: "foo") |> range(start: -1d, stop: now()) |> yield()`,
+						},
+					},
+					TimeSince: mustDuration("60s"),
+					StaleTime: mustDuration("10m"),
+					Level:     notification.Info,
+				},
+			},
+			wants: wants{
+				script: `import "influxdata/influxdb/monitor"
+import "experimental"
+import "influxdata/influxdb/v1"
+
+data = from(bucket: "foo") |> range(start: -10m)
+
+option task = {name: "moo", every: 1h}
+option monitor.write = (tables=<-) => tables |> experimental["to"](bucket: "hf_statuses")
+
+check = {_check_id: "000000000000000a", _check_name: "moo", _type: "deadman", tags: {}}
+info = (r) => r["dead"]
+messageFn = (r) => "whoa! {r[\"dead\"]}"
+
 data
     |> v1["fieldsAsCols"]()
     |> monitor["deadman"](t: experimental["subDuration"](from: now(), d: 60s))
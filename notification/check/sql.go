@@ -0,0 +1,175 @@
+package check
+
+import (
+	"encoding/json"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/ast/astutil"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification/flux"
+	"github.com/influxdata/influxdb/v2/query/fluxlang"
+)
+
+var _ influxdb.Check = (*SQL)(nil)
+
+// sqlMeasurement is the source measurement of the statuses of SQL checks.
+const sqlMeasurement = "sql"
+
+// SQL is the check of a query-only datasource held outside of influxdb,
+// such as a SQL database of business metrics. The check refers to a
+// datasource registered with the datasources API, whose data source name is
+// read by the task of the check, so the credentials are kept out of the
+// check and its task. The rows returned by the query are checked against the
+// thresholds like the ones of a threshold check, and their statuses are
+// written to _monitoring, or the StatusBucket, for the notification rules to
+// alert on.
+type SQL struct {
+	Base
+	// DatasourceID is the ID of the datasource the query is run against.
+	DatasourceID platform.ID `json:"datasourceID,omitempty"`
+	// Driver is the sql.from driver of the datasource. It is set from the
+	// datasource when the check is created or updated.
+	Driver string `json:"driver,omitempty"`
+	// SQLQuery is the query run against the datasource.
+	SQLQuery string `json:"sqlQuery"`
+	// Field is the column of the rows the thresholds are compared to.
+	Field      string            `json:"field"`
+	Thresholds []ThresholdConfig `json:"thresholds"`
+}
+
+// Type returns the type of the check.
+func (c SQL) Type() string {
+	return "sql"
+}
+
+// Valid returns error if something is invalid.
+func (c SQL) Valid(lang fluxlang.FluxLanguageService) error {
+	if err := c.Base.Valid(lang); err != nil {
+		return err
+	}
+	if !c.DatasourceID.Valid() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "Check DatasourceID is invalid",
+		}
+	}
+	if c.Driver != "" {
+		if err := influxdb.ValidDatasourceDriver(c.Driver); err != nil {
+			return err
+		}
+	}
+	if c.SQLQuery == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "Check SQLQuery can't be empty",
+		}
+	}
+	if c.Field == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "Check Field can't be empty",
+		}
+	}
+	for _, cc := range c.Thresholds {
+		if err := cc.Valid(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type sqlDecode struct {
+	Base
+	DatasourceID platform.ID             `json:"datasourceID,omitempty"`
+	Driver       string                  `json:"driver"`
+	SQLQuery     string                  `json:"sqlQuery"`
+	Field        string                  `json:"field"`
+	Thresholds   []thresholdConfigDecode `json:"thresholds"`
+}
+
+// UnmarshalJSON implement json.Unmarshaler interface.
+func (c *SQL) UnmarshalJSON(b []byte) error {
+	raw := new(sqlDecode)
+	if err := json.Unmarshal(b, raw); err != nil {
+		return err
+	}
+	thresholds, err := decodeThresholdConfigs(raw.Thresholds)
+	if err != nil {
+		return err
+	}
+	c.Base = raw.Base
+	c.DatasourceID = raw.DatasourceID
+	c.Driver = raw.Driver
+	c.SQLQuery = raw.SQLQuery
+	c.Field = raw.Field
+	c.Thresholds = thresholds
+	return nil
+}
+
+type sqlAlias SQL
+
+// MarshalJSON implement json.Marshaler interface.
+func (c SQL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(
+		struct {
+			sqlAlias
+			Type string `json:"type"`
+		}{
+			sqlAlias: sqlAlias(c),
+			Type:     c.Type(),
+		})
+}
+
+// GetDatasourceID returns the ID of the datasource the check queries.
+func (c SQL) GetDatasourceID() platform.ID {
+	return c.DatasourceID
+}
+
+// GenerateFlux returns the flux script of the task of the check.
+func (c SQL) GenerateFlux(lang fluxlang.FluxLanguageService) (string, error) {
+	return astutil.Format(c.GenerateFluxAST())
+}
+
+// GenerateFluxAST returns the flux AST of the task of the check. The rows
+// of the query are stamped with the time the task runs at, as the
+// datasource has no notion of the time of the data.
+func (c SQL) GenerateFluxAST() *ast.File {
+	t := Threshold{Base: c.Base, Thresholds: c.Thresholds}
+
+	from := flux.Call(flux.Member("sql", "from"), flux.Object(
+		flux.Property("driverName", flux.String(c.Driver)),
+		flux.Property("dataSourceName", flux.Call(flux.Member("secrets", "get"), flux.Object(
+			flux.Property("key", flux.String(influxdb.DatasourceSecretKey(c.DatasourceID))),
+		))),
+		flux.Property("query", flux.String(c.SQLQuery)),
+	))
+	row := flux.ObjectWith("r",
+		flux.Property("_measurement", flux.String(sqlMeasurement)),
+		flux.Property("_time", flux.Call(flux.Identifier("now"), flux.Object())),
+		&ast.Property{
+			Key: &ast.StringLiteral{Value: c.Field},
+			Value: flux.Call(flux.Identifier("float"), flux.Object(
+				flux.Property("v", &ast.IndexExpression{Array: flux.Identifier("r"), Index: flux.String(c.Field)}),
+			)),
+		},
+	)
+	data := flux.Pipe(from, flux.Call(flux.Identifier("map"), flux.Object(
+		flux.Property("fn", flux.Function(flux.FunctionParams("r"), row)),
+	)))
+
+	var body []ast.Statement
+	body = append(body, c.generateTaskOption())
+	body = append(body, c.generateStatusBucketOption()...)
+	body = append(body, flux.DefineVariable("data", data))
+	body = append(body, c.generateFluxASTCheckDefinition(c.Type()))
+	body = append(body, t.generateFluxASTThresholdFunctions(c.Field)...)
+	body = append(body, c.generateFluxASTMessageFunction())
+	body = append(body, flux.ExpressionStatement(flux.Pipe(
+		flux.Identifier("data"),
+		t.generateFluxASTChecksCall(),
+	)))
+
+	return flux.File(c.Name, flux.Imports("influxdata/influxdb/monitor", "influxdata/influxdb/secrets", "sql"), body)
+}
@@ -0,0 +1,88 @@
+package check_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/notification"
+	"github.com/influxdata/influxdb/v2/notification/check"
+	"github.com/influxdata/influxdb/v2/query/fluxlang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLCheck() *check.SQL {
+	return &check.SQL{
+		Base: check.Base{
+			ID:                    10,
+			Name:                  "orders",
+			OrgID:                 20,
+			Every:                 mustDuration("5m"),
+			StatusMessageTemplate: "open orders",
+			Tags: []influxdb.Tag{
+				{Key: "team", Value: "sales"},
+			},
+		},
+		DatasourceID: 30,
+		Driver:       "postgres",
+		SQLQuery:     "SELECT region, count(*) AS open FROM orders GROUP BY region",
+		Field:        "open",
+		Thresholds: []check.ThresholdConfig{
+			&check.Greater{
+				ThresholdConfigBase: check.ThresholdConfigBase{Level: notification.Critical},
+				Value:               100,
+			},
+		},
+	}
+}
+
+func TestSQL_GenerateFlux(t *testing.T) {
+	c := newSQLCheck()
+	require.NoError(t, c.Valid(fluxlang.DefaultService))
+
+	s, err := c.GenerateFlux(fluxlang.DefaultService)
+	require.NoError(t, err)
+	for _, want := range []string{
+		`import "influxdata/influxdb/monitor"`,
+		`import "influxdata/influxdb/secrets"`,
+		`import "sql"`,
+		`option task = {name: "orders", every: 5m}`,
+		`sql.from(driverName: "postgres", dataSourceName: secrets.get(key: "000000000000001e-dsn"), query: "SELECT region, count(*) AS open FROM orders GROUP BY region")`,
+		`"open": float(v: r["open"])`,
+		`check = {_check_id: "000000000000000a", _check_name: "orders", _type: "sql", tags: {team: "sales"}}`,
+		`crit = (r) => r["open"] > 100.0`,
+		`monitor.check(data: check, messageFn: messageFn, crit: crit)`,
+	} {
+		assert.Contains(t, s, want)
+	}
+
+	_, err = fluxlang.DefaultService.Parse(s)
+	assert.NoError(t, err)
+}
+
+func TestSQL_Valid(t *testing.T) {
+	for name, mutate := range map[string]func(c *check.SQL){
+		"unsupported driver": func(c *check.SQL) { c.Driver = "sqlite3" },
+		"missing datasource": func(c *check.SQL) { c.DatasourceID = 0 },
+		"missing query":      func(c *check.SQL) { c.SQLQuery = "" },
+		"missing field":      func(c *check.SQL) { c.Field = "" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := newSQLCheck()
+			mutate(c)
+			assert.Error(t, c.Valid(fluxlang.DefaultService))
+		})
+	}
+}
+
+func TestSQL_JSON(t *testing.T) {
+	c := newSQLCheck()
+	b, err := json.Marshal(c)
+	require.NoError(t, err)
+
+	got, err := check.UnmarshalJSON(b)
+	require.NoError(t, err)
+	assert.Equal(t, "sql", got.Type())
+	assert.Equal(t, c, got)
+}
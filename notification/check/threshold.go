@@ -62,20 +62,30 @@ func (t *Threshold) UnmarshalJSON(b []byte) error {
 		return err
 	}
 	t.Base = tdRaws.Base
-	for _, tdRaw := range tdRaws.Thresholds {
+	thresholds, err := decodeThresholdConfigs(tdRaws.Thresholds)
+	if err != nil {
+		return err
+	}
+	t.Thresholds = thresholds
+	return nil
+}
+
+func decodeThresholdConfigs(tdRaws []thresholdConfigDecode) ([]ThresholdConfig, error) {
+	var thresholds []ThresholdConfig
+	for _, tdRaw := range tdRaws {
 		switch tdRaw.Type {
 		case "lesser":
 			td := &Lesser{
 				ThresholdConfigBase: tdRaw.ThresholdConfigBase,
 				Value:               tdRaw.Value,
 			}
-			t.Thresholds = append(t.Thresholds, td)
+			thresholds = append(thresholds, td)
 		case "greater":
 			td := &Greater{
 				ThresholdConfigBase: tdRaw.ThresholdConfigBase,
 				Value:               tdRaw.Value,
 			}
-			t.Thresholds = append(t.Thresholds, td)
+			thresholds = append(thresholds, td)
 		case "range":
 			td := &Range{
 				ThresholdConfigBase: tdRaw.ThresholdConfigBase,
@@ -83,15 +93,14 @@ func (t *Threshold) UnmarshalJSON(b []byte) error {
 				Max:                 tdRaw.Max,
 				Within:              tdRaw.Within,
 			}
-			t.Thresholds = append(t.Thresholds, td)
+			thresholds = append(thresholds, td)
 		default:
-			return &errors.Error{
+			return nil, &errors.Error{
 				Msg: fmt.Sprintf("invalid threshold type %s", tdRaw.Type),
 			}
 		}
 	}
-
-	return nil
+	return thresholds, nil
 }
 
 func multiError(errs []error) error {
@@ -147,7 +156,11 @@ func (t Threshold) GenerateFluxAST(lang fluxlang.FluxLanguageService) (*ast.File
 	assignPipelineToData(f)
 
 	f.Imports = append(f.Imports, flux.Imports("influxdata/influxdb/monitor", "influxdata/influxdb/v1")...)
+	if t.StatusBucket != "" {
+		f.Imports = append(f.Imports, flux.ImportDeclaration("experimental"))
+	}
 	f.Body = append(f.Body, t.generateFluxASTBody(fields[0])...)
+	t.addLocationOption(f)
 
 	return f, nil
 }
@@ -275,6 +288,7 @@ func assignPipelineToData(f *ast.File) error {
 func (t Threshold) generateFluxASTBody(field string) []ast.Statement {
 	var statements []ast.Statement
 	statements = append(statements, t.generateTaskOption())
+	statements = append(statements, t.generateStatusBucketOption()...)
 	statements = append(statements, t.generateFluxASTCheckDefinition("threshold"))
 	statements = append(statements, t.generateFluxASTThresholdFunctions(field)...)
 	statements = append(statements, t.generateFluxASTMessageFunction())
@@ -347,6 +347,54 @@ data
     )`,
 			},
 		},
+		{
+			name: "aligned to time zone",
+			args: args{
+				threshold: check.Threshold{
+					Base: check.Base{
+						ID:                    10,
+						Name:                  "moo",
+						Every:                 mustDuration("1d"),
+						Timezone:              "Europe/Berlin",
+						StatusMessageTemplate: "whoa! {r[\"usage_user\"]}",
+						Query: influxdb.DashboardQuery{
+							Text: `from(bucket: "foo") |> range(start: -1d) |> filter(fn: (r) => r._field == "usage_user") |> aggregateWindow(every: 1m, fn: mean)`,
+						},
+					},
+					Thresholds: []check.ThresholdConfig{
+						check.Greater{
+							ThresholdConfigBase: check.ThresholdConfigBase{
+								Level: notification.Critical,
+							},
+							Value: u,
+						},
+					},
+				},
+			},
+			wants: wants{
+				script: `import "influxdata/influxdb/monitor"
+import "influxdata/influxdb/v1"
+import "timezone"
+
+option location = timezone["location"](name: "Europe/Berlin")
+
+data =
+    from(bucket: "foo")
+        |> range(start: -1d)
+        |> filter(fn: (r) => r._field == "usage_user")
+        |> aggregateWindow(every: 1d, fn: mean, createEmpty: false)
+
+option task = {name: "moo", every: 1d}
+
+check = {_check_id: "000000000000000a", _check_name: "moo", _type: "threshold", tags: {}}
+crit = (r) => r["usage_user"] > 40.0
+messageFn = (r) => "whoa! {r[\"usage_user\"]}"
+
+data
+    |> v1["fieldsAsCols"]()
+    |> monitor["check"](data: check, messageFn: messageFn, crit: crit)`,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -216,6 +216,29 @@ func DefineTaskOption(o *ast.ObjectExpression) *ast.OptionStatement {
 	}
 }
 
+// DefineLocationOption returns an *ast.OptionStatement aligning windows to a time zone.
+// (e.g. option location = timezone.location(name: "Europe/Berlin"))
+// The script must import timezone.
+func DefineLocationOption(tz string) *ast.OptionStatement {
+	loc := Call(Member("timezone", "location"), Object(Property("name", String(tz))))
+	return &ast.OptionStatement{
+		Assignment: DefineVariable("location", loc),
+	}
+}
+
+// DefineOption returns an *ast.OptionStatement assigning e to an option of a package. (e.g. option pkg.name = <expression>)
+func DefineOption(pkg, name string, e ast.Expression) *ast.OptionStatement {
+	return &ast.OptionStatement{
+		Assignment: &ast.MemberAssignment{
+			Member: &ast.MemberExpression{
+				Object:   &ast.Identifier{Name: pkg},
+				Property: &ast.Identifier{Name: name},
+			},
+			Init: e,
+		},
+	}
+}
+
 // Property returns an *ast.Property of key to e. (e.g. key: <expression>)
 func Property(key string, e ast.Expression) *ast.Property {
 	return &ast.Property{
@@ -3,6 +3,7 @@ package influxdb
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
@@ -13,6 +14,10 @@ type Organization struct {
 	ID          platform.ID `json:"id,omitempty"`
 	Name        string      `json:"name"`
 	Description string      `json:"description"`
+	// DefaultTimezone is the time zone the windows of the checks of the
+	// organization are aligned to when they set none, such as
+	// Europe/Berlin. Windows are aligned to UTC when it is empty.
+	DefaultTimezone string `json:"defaultTimezone,omitempty"`
 	CRUDLog
 }
 
@@ -62,8 +67,25 @@ type OrganizationService interface {
 // OrganizationUpdate represents updates to a organization.
 // Only fields which are set are updated.
 type OrganizationUpdate struct {
-	Name        *string
-	Description *string `json:"description,omitempty"`
+	Name            *string
+	Description     *string `json:"description,omitempty"`
+	DefaultTimezone *string `json:"defaultTimezone,omitempty"`
+}
+
+// ValidTimezone returns an error if the time zone is neither empty nor the
+// name of a time zone of the IANA database, such as Europe/Berlin.
+func ValidTimezone(tz string) error {
+	if tz == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("unknown time zone %q", tz),
+			Err:  err,
+		}
+	}
+	return nil
 }
 
 // ErrInvalidOrgFilter is the error indicate org filter is empty
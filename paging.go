@@ -1,6 +1,7 @@
 package influxdb
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -35,6 +36,39 @@ type FindOptions struct {
 	After      *platform.ID
 	SortBy     string
 	Descending bool
+	// Cursor pages by the opaque cursor of the cursor query parameter,
+	// which is decoded into After, instead of by the offset. Pages of
+	// cursor pagination are ordered by ascending ID, so resources created
+	// or deleted while paging neither shift nor repeat the others.
+	Cursor bool
+}
+
+// EncodeCursor returns the opaque cursor of the page that starts after the
+// resource with the id.
+func EncodeCursor(id platform.ID) string {
+	b, _ := id.Encode()
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor returns the id of the resource the page of the cursor starts
+// after.
+func DecodeCursor(cursor string) (platform.ID, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "cursor is invalid",
+		}
+	}
+
+	var id platform.ID
+	if err := id.Decode(b); err != nil {
+		return 0, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "cursor is invalid",
+		}
+	}
+	return id, nil
 }
 
 // GetLimit returns the resolved limit between then limit boundaries.
@@ -117,6 +151,63 @@ func DecodeFindOptions(r *http.Request) (*FindOptions, error) {
 		opts.Descending = desc
 	}
 
+	if qp.Has("cursor") {
+		if err := decodeCursorOptions(qp, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	return opts, nil
+}
+
+// decodeCursorOptions sets up cursor pagination, which cannot be combined
+// with the other ways of paging or ordering.
+func decodeCursorOptions(qp url.Values, opts *FindOptions) error {
+	if opts.Offset > 0 || opts.After != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "cursor cannot be combined with offset or after",
+		}
+	}
+	if err := checkCursorOrder(*opts); err != nil {
+		return err
+	}
+
+	if cursor := qp.Get("cursor"); cursor != "" {
+		id, err := DecodeCursor(cursor)
+		if err != nil {
+			return err
+		}
+		opts.After = &id
+	}
+	opts.Cursor = true
+	return nil
+}
+
+func checkCursorOrder(opts FindOptions) error {
+	if opts.Descending || (opts.SortBy != "" && opts.SortBy != "ID") {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "cursor pagination is ordered by ascending ID",
+		}
+	}
+	return nil
+}
+
+// CursorFindOptions returns opts switched to cursor pagination, for a list
+// that is read from the store page after page until its end. The list
+// starts after opts.After, an offset or another order cannot be kept.
+func CursorFindOptions(opts FindOptions) (FindOptions, error) {
+	if opts.Offset > 0 {
+		return opts, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "offset cannot be combined with cursor pagination",
+		}
+	}
+	if err := checkCursorOrder(opts); err != nil {
+		return opts, err
+	}
+	opts.Cursor = true
 	return opts, nil
 }
 
@@ -136,11 +227,19 @@ func FindOptionParams(opts ...FindOptions) [][2]string {
 func (f FindOptions) QueryParams() map[string][]string {
 	qp := map[string][]string{
 		"descending": {strconv.FormatBool(f.Descending)},
-		"offset":     {strconv.Itoa(f.Offset)},
 	}
 
-	if f.After != nil {
-		qp["after"] = []string{f.After.String()}
+	if f.Cursor {
+		var cursor string
+		if f.After != nil {
+			cursor = EncodeCursor(*f.After)
+		}
+		qp["cursor"] = []string{cursor}
+	} else {
+		qp["offset"] = []string{strconv.Itoa(f.Offset)}
+		if f.After != nil {
+			qp["after"] = []string{f.After.String()}
+		}
 	}
 
 	if f.Limit > 0 {
@@ -207,3 +306,45 @@ func NewPagingLinks(basePath string, opts FindOptions, f PagingFilter, num int)
 
 	return links
 }
+
+// NewCursorPagingLinks returns the PagingLinks of a page of cursor
+// pagination. num is the number of returned results and last the ID of the
+// last of them, which the cursor of the next page starts after. f may be nil.
+func NewCursorPagingLinks(basePath string, opts FindOptions, f PagingFilter, num int, last platform.ID) *PagingLinks {
+	u := url.URL{
+		Path: basePath,
+	}
+
+	values := url.Values{}
+	if f != nil {
+		for k, vs := range f.QueryParams() {
+			for _, v := range vs {
+				if v != "" {
+					values.Add(k, v)
+				}
+			}
+		}
+	}
+	for k, vs := range opts.QueryParams() {
+		for _, v := range vs {
+			if v != "" {
+				values.Add(k, v)
+			}
+		}
+	}
+	// the first page has an empty cursor, which still selects cursor pagination
+	values.Set("cursor", values.Get("cursor"))
+
+	u.RawQuery = values.Encode()
+	links := &PagingLinks{
+		Self: u.String(),
+	}
+
+	if num > 0 && num >= opts.Limit {
+		values.Set("cursor", EncodeCursor(last))
+		u.RawQuery = values.Encode()
+		links.Next = u.String()
+	}
+
+	return links
+}
@@ -0,0 +1,97 @@
+package influxdb_test
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	id := platform.ID(0x0a0b0c0d)
+
+	cursor := influxdb.EncodeCursor(id)
+	got, err := influxdb.DecodeCursor(cursor)
+	require.NoError(t, err)
+	assert.Equal(t, id, got)
+
+	for _, bad := range []string{"!!", "YWJj"} {
+		_, err := influxdb.DecodeCursor(bad)
+		require.Error(t, err)
+		assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+	}
+}
+
+func TestDecodeFindOptions_Cursor(t *testing.T) {
+	id := platform.ID(0x0a0b0c0d)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    influxdb.FindOptions
+		wantErr bool
+	}{
+		{
+			name:  "first page",
+			query: "cursor=&limit=2",
+			want:  influxdb.FindOptions{Limit: 2, Cursor: true},
+		},
+		{
+			name:  "next page",
+			query: "cursor=" + influxdb.EncodeCursor(id),
+			want:  influxdb.FindOptions{Limit: influxdb.DefaultPageSize, After: &id, Cursor: true},
+		},
+		{
+			name:    "with offset",
+			query:   "cursor=&offset=2",
+			wantErr: true,
+		},
+		{
+			name:    "descending",
+			query:   "cursor=&descending=true",
+			wantErr: true,
+		},
+		{
+			name:    "sorted by name",
+			query:   "cursor=&sortBy=name",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v2/buckets?"+tt.query, nil)
+			got, err := influxdb.DecodeFindOptions(r)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
+
+func TestNewCursorPagingLinks(t *testing.T) {
+	opts := influxdb.FindOptions{Limit: 2, Cursor: true}
+
+	links := influxdb.NewCursorPagingLinks("/api/v2/buckets", opts, nil, 2, platform.ID(0x0a))
+	self, err := url.Parse(links.Self)
+	require.NoError(t, err)
+	assert.True(t, self.Query().Has("cursor"))
+	assert.Empty(t, self.Query().Get("offset"))
+
+	next, err := url.Parse(links.Next)
+	require.NoError(t, err)
+	assert.Equal(t, influxdb.EncodeCursor(platform.ID(0x0a)), next.Query().Get("cursor"))
+	assert.Equal(t, "2", next.Query().Get("limit"))
+	assert.Empty(t, links.Prev)
+
+	last := influxdb.NewCursorPagingLinks("/api/v2/buckets", opts, nil, 1, platform.ID(0x0b))
+	assert.Empty(t, last.Next)
+}
@@ -0,0 +1,177 @@
+package influxdb
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// Parser types.
+const (
+	// ParserTypeRegex extracts the named groups of a regular expression.
+	ParserTypeRegex = "regex"
+	// ParserTypeJSON extracts the values at paths of a JSON document.
+	ParserTypeJSON = "json"
+)
+
+// parserPathSegment is a key of a JSON path, which Flux reads as a record
+// member.
+var parserPathSegment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Parser is a named rule extracting fields from a string column, which Flux
+// queries of its organization apply with parsers.apply(name: "nginx_log").
+type Parser struct {
+	ID          platform.ID `json:"id" db:"id"`
+	OrgID       platform.ID `json:"orgID" db:"org_id"`
+	Name        string      `json:"name" db:"name"`
+	Description *string     `json:"description,omitempty" db:"description"`
+	Type        string      `json:"type" db:"type"`
+	// Pattern is the regular expression of a regex parser. Its named
+	// groups are the fields extracted.
+	Pattern string `json:"pattern,omitempty" db:"pattern"`
+	// Paths maps the fields extracted by a json parser to their path in
+	// the document, such as "request.status".
+	Paths ParserPaths `json:"paths,omitempty" db:"paths"`
+}
+
+// Fields returns the names of the fields the parser extracts, in the order
+// of the groups of a regex parser or sorted for a json parser.
+func (p Parser) Fields() []string {
+	var fields []string
+	switch p.Type {
+	case ParserTypeRegex:
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil
+		}
+		for _, name := range re.SubexpNames() {
+			if name != "" {
+				fields = append(fields, name)
+			}
+		}
+	case ParserTypeJSON:
+		for field := range p.Paths {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+	}
+	return fields
+}
+
+// Valid returns an error if the parser cannot extract any field.
+func (p Parser) Valid() error {
+	if p.Name == "" {
+		return &errors.Error{Code: errors.EInvalid, Msg: "parser name can't be empty"}
+	}
+
+	switch p.Type {
+	case ParserTypeRegex:
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return &errors.Error{Code: errors.EInvalid, Msg: "invalid parser pattern", Err: err}
+		}
+		for _, name := range re.SubexpNames() {
+			if name != "" {
+				return nil
+			}
+		}
+		return &errors.Error{Code: errors.EInvalid, Msg: "parser pattern must have a named group, such as (?P<status>\\d+)"}
+	case ParserTypeJSON:
+		if len(p.Paths) == 0 {
+			return &errors.Error{Code: errors.EInvalid, Msg: "parser paths can't be empty"}
+		}
+		for field, path := range p.Paths {
+			if field == "" {
+				return &errors.Error{Code: errors.EInvalid, Msg: "parser field names can't be empty"}
+			}
+			for _, key := range strings.Split(path, ".") {
+				if !parserPathSegment.MatchString(key) {
+					return &errors.Error{
+						Code: errors.EInvalid,
+						Msg:  fmt.Sprintf("invalid path %q of field %q: keys are separated by dots and may only contain letters, digits and underscores", path, field),
+					}
+				}
+			}
+		}
+		return nil
+	default:
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("parser type must be %q or %q", ParserTypeRegex, ParserTypeJSON),
+		}
+	}
+}
+
+// ParserPaths maps the fields of a json parser to their paths.
+type ParserPaths map[string]string
+
+// Value implements the database/sql Valuer interface for adding ParserPaths to the database.
+func (p ParserPaths) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	paths, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(paths), nil
+}
+
+// Scan implements the database/sql Scanner interface for retrieving ParserPaths from the database.
+func (p *ParserPaths) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return fmt.Errorf("cannot scan %T into parser paths", value)
+	}
+
+	var paths ParserPaths
+	if err := json.Unmarshal(raw, &paths); err != nil {
+		return err
+	}
+	*p = paths
+	return nil
+}
+
+// ParserListFilter is a selection filter for listing parsers.
+type ParserListFilter struct {
+	OrgID platform.ID
+	Name  *string
+}
+
+// Parsers is a collection of parsers.
+type Parsers struct {
+	Parsers []Parser `json:"parsers"`
+}
+
+// CreateParserRequest contains all info needed to store a parser.
+type CreateParserRequest struct {
+	OrgID       platform.ID `json:"orgID"`
+	Name        string      `json:"name"`
+	Description *string     `json:"description,omitempty"`
+	Type        string      `json:"type"`
+	Pattern     string      `json:"pattern,omitempty"`
+	Paths       ParserPaths `json:"paths,omitempty"`
+}
+
+// UpdateParserRequest contains a partial update to a parser. The type of a
+// parser cannot be changed.
+type UpdateParserRequest struct {
+	Name        *string     `json:"name,omitempty"`
+	Description *string     `json:"description,omitempty"`
+	Pattern     *string     `json:"pattern,omitempty"`
+	Paths       ParserPaths `json:"paths,omitempty"`
+}
@@ -0,0 +1,162 @@
+package parsers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// Name is the identifier the parsers record is bound to in Flux.
+const Name = "parsers"
+
+// ParserLister lists the parsers of an organization.
+type ParserLister interface {
+	ListParsers(context.Context, influxdb.ParserListFilter) (*influxdb.Parsers, error)
+}
+
+// ExternProvider binds the parsers of an organization to Name in its
+// queries.
+type ExternProvider struct {
+	parsers ParserLister
+}
+
+// NewExternProvider constructs an ExternProvider of the parsers of svc.
+func NewExternProvider(svc ParserLister) *ExternProvider {
+	return &ExternProvider{parsers: svc}
+}
+
+// Extern returns the Flux statements that bind the parsers of the
+// organization to Name, or nil when it has none. The record provides:
+//
+//	parsers.apply(tables=<-, name, column="_value")
+//
+// which extracts the fields of the named parser from the string column into
+// columns of the same names, one row per time.
+func (e *ExternProvider) Extern(ctx context.Context, orgID platform.ID) (*ast.File, error) {
+	ps, err := e.parsers.ListParsers(ctx, influxdb.ParserListFilter{OrgID: orgID})
+	if err != nil {
+		return nil, err
+	}
+	if len(ps.Parsers) == 0 {
+		return nil, nil
+	}
+
+	pkg := parser.ParseSource(source(ps.Parsers))
+	if ast.Check(pkg) > 0 {
+		return nil, ast.GetError(pkg)
+	}
+	return pkg.Files[0], nil
+}
+
+// source renders the parsers as Flux. Each row of the input is copied once
+// per field index, with the name and value of the field of the applied
+// parser at that index, and the copies are pivoted into columns. The fields
+// are selected by the name of the parser within each copy, since the
+// branches of a conditional must have the same type.
+func source(ps []influxdb.Parser) string {
+	var (
+		names     []string
+		maxFields int
+		hasJSON   bool
+	)
+	fields := make([][]string, len(ps))
+	for i, p := range ps {
+		names = append(names, fluxString(p.Name))
+		fields[i] = p.Fields()
+		if len(fields[i]) > maxFields {
+			maxFields = len(fields[i])
+		}
+		if p.Type == influxdb.ParserTypeJSON {
+			hasJSON = true
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("import \"regexp\"\n")
+	if hasJSON {
+		// Aliased so that it does not shadow the json package of the query.
+		b.WriteString("import parsersjson \"experimental/json\"\n")
+	}
+	fmt.Fprintf(&b, "\n%s = {\n", Name)
+	b.WriteString("    apply: (tables=<-, name, column=\"_value\") => {\n")
+	for i, p := range ps {
+		if p.Type == influxdb.ParserTypeRegex {
+			// Replacing the whole string with a group extracts its value.
+			fmt.Fprintf(&b, "        re%d = regexp.compile(v: %s)\n", i, fluxString("(?s)^.*?(?:"+p.Pattern+").*$"))
+		}
+	}
+	for f := 0; f < maxFields; f++ {
+		fmt.Fprintf(&b, "        field%d = ", f)
+		for i := range ps {
+			if f < len(fields[i]) {
+				fmt.Fprintf(&b, "if name == %s then %s else ", names[i], fluxString(fields[i][f]))
+			}
+		}
+		b.WriteString("\"\"\n")
+
+		fmt.Fprintf(&b, "        value%d = (s) => ", f)
+		for i, p := range ps {
+			if f >= len(fields[i]) {
+				continue
+			}
+			field := fields[i][f]
+			switch p.Type {
+			case influxdb.ParserTypeRegex:
+				fmt.Fprintf(&b, "if name == %s then (if regexp.matchRegexpString(r: re%d, v: s) then regexp.replaceAllString(r: re%d, v: s, t: %s) else \"\") else ",
+					names[i], i, i, fluxString("${"+field+"}"))
+			case influxdb.ParserTypeJSON:
+				fmt.Fprintf(&b, "if name == %s then string(v: parsersjson.parse(data: bytes(v: s))%s) else ",
+					names[i], jsonPath(p.Paths[field]))
+			}
+		}
+		b.WriteString("\"\"\n")
+	}
+	fmt.Fprintf(&b, "        input = if contains(value: name, set: [%s]) then tables |> duplicate(column: column, as: \"_parser_source\") else die(msg: \"parser \" + name + \" not found\")\n",
+		strings.Join(names, ", "))
+
+	streams := make([]string, maxFields)
+	for f := 0; f < maxFields; f++ {
+		streams[f] = fmt.Sprintf("unpacked%d", f)
+		fmt.Fprintf(&b, "        %s = input |> map(fn: (r) => ({r with _parser_field: field%d, _parser_value: value%d(s: r._parser_source)}))\n", streams[f], f, f)
+	}
+	if len(streams) == 1 {
+		fmt.Fprintf(&b, "        return %s\n", streams[0])
+	} else {
+		fmt.Fprintf(&b, "        return union(tables: [%s])\n", strings.Join(streams, ", "))
+	}
+	b.WriteString("            |> filter(fn: (r) => r._parser_field != \"\" and r._parser_value != \"\")\n")
+	b.WriteString("            |> drop(columns: [\"_parser_source\"])\n")
+	b.WriteString("            |> pivot(rowKey: [\"_time\"], columnKey: [\"_parser_field\"], valueColumn: \"_parser_value\")\n")
+	b.WriteString("    },\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// jsonPath renders a dotted path as Flux member expressions, such as
+// ["request"]["status"].
+func jsonPath(path string) string {
+	var b strings.Builder
+	for _, key := range strings.Split(path, ".") {
+		fmt.Fprintf(&b, "[%s]", fluxString(key))
+	}
+	return b.String()
+}
+
+// fluxString quotes s as a Flux string literal, which unlike a Go string
+// literal interpolates ${.
+func fluxString(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+		"${", `\${`,
+	)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,72 @@
+package parsers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/influxdb/v2"
+	_ "github.com/influxdata/influxdb/v2/fluxinit/static"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type parserList []influxdb.Parser
+
+func (l parserList) ListParsers(context.Context, influxdb.ParserListFilter) (*influxdb.Parsers, error) {
+	return &influxdb.Parsers{Parsers: l}, nil
+}
+
+func TestExternProvider_Extern(t *testing.T) {
+	provider := NewExternProvider(parserList{
+		{
+			Name:    "nginx_log",
+			Type:    influxdb.ParserTypeRegex,
+			Pattern: `(?P<method>[A-Z]+) (?P<path>\S+) (?P<status>\d{3})`,
+		},
+		{
+			Name:  "event",
+			Type:  influxdb.ParserTypeJSON,
+			Paths: influxdb.ParserPaths{"status": "response.status"},
+		},
+	})
+
+	file, err := provider.Extern(context.Background(), 1)
+	require.NoError(t, err)
+	require.NotNil(t, file)
+
+	src := "import \"array\"\n" + ast.Format(file) + `
+nginx = array.from(rows: [{_time: 2021-01-01T00:00:00Z, _value: "10.0.0.1 GET /index.html 200 \"curl/7.64\""}])
+    |> parsers.apply(name: "nginx_log")
+    |> findRecord(fn: (key) => true, idx: 0)
+method = nginx.method
+path = nginx.path
+status = nginx.status
+event = array.from(rows: [{_time: 2021-01-01T00:00:00Z, message: "{\"response\": {\"status\": \"404\"}}"}])
+    |> parsers.apply(name: "event", column: "message")
+    |> findRecord(fn: (key) => true, idx: 0)
+eventStatus = event.status
+`
+	_, scope, err := runtime.Eval(context.Background(), src)
+	require.NoError(t, err)
+
+	for name, expected := range map[string]string{
+		"method":      "GET",
+		"path":        "/index.html",
+		"status":      "200",
+		"eventStatus": "404",
+	} {
+		v, ok := scope.Lookup(name)
+		require.True(t, ok, name)
+		assert.Equal(t, expected, v.Str(), name)
+	}
+
+	file, err = NewExternProvider(parserList{}).Extern(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Nil(t, file, "org without parsers should not get an extern")
+}
+
+func TestFluxString(t *testing.T) {
+	assert.Equal(t, `"a\\d+\"\${b}\n"`, fluxString("a\\d+\"${b}\n"))
+}
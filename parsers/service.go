@@ -0,0 +1,200 @@
+package parsers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/influxdata/influxdb/v2/sqlite"
+)
+
+var errParserNotFound = &ierrors.Error{
+	Code: ierrors.ENotFound,
+	Msg:  "parser not found",
+}
+
+var parserColumns = []string{"id", "org_id", "name", "description", "type", "pattern", "paths"}
+
+// NewService constructs the service of the parsers that Flux queries apply
+// to their string columns with parsers.apply. The parsers are kept in the
+// sqlite store.
+func NewService(store *sqlite.SqlStore) *service {
+	return &service{
+		store:       store,
+		idGenerator: snowflake.NewIDGenerator(),
+	}
+}
+
+type service struct {
+	store       *sqlite.SqlStore
+	idGenerator platform.IDGenerator
+}
+
+func (s service) ListParsers(ctx context.Context, filter influxdb.ParserListFilter) (*influxdb.Parsers, error) {
+	q := sq.Select(parserColumns...).
+		From("parsers").
+		Where(sq.Eq{"org_id": filter.OrgID}).
+		OrderBy("name")
+
+	if filter.Name != nil {
+		q = q.Where(sq.Eq{"name": *filter.Name})
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var ps influxdb.Parsers
+	if err := s.store.DB.SelectContext(ctx, &ps.Parsers, query, args...); err != nil {
+		return nil, err
+	}
+
+	return &ps, nil
+}
+
+func (s service) CreateParser(ctx context.Context, request influxdb.CreateParserRequest) (*influxdb.Parser, error) {
+	p := influxdb.Parser{
+		OrgID:       request.OrgID,
+		Name:        request.Name,
+		Description: request.Description,
+		Type:        request.Type,
+		Pattern:     request.Pattern,
+		Paths:       request.Paths,
+	}
+	if p.Type == influxdb.ParserTypeRegex {
+		p.Paths = nil
+	} else {
+		p.Pattern = ""
+	}
+	if err := p.Valid(); err != nil {
+		return nil, err
+	}
+
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	q := sq.Insert("parsers").
+		SetMap(sq.Eq{
+			"id":          s.idGenerator.ID(),
+			"org_id":      p.OrgID,
+			"name":        p.Name,
+			"description": p.Description,
+			"type":        p.Type,
+			"pattern":     p.Pattern,
+			"paths":       p.Paths,
+			"created_at":  "datetime('now')",
+			"updated_at":  "datetime('now')",
+		}).
+		Suffix("RETURNING id, org_id, name, description, type, pattern, paths")
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var created influxdb.Parser
+	if err := s.store.DB.GetContext(ctx, &created, query, args...); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (s service) GetParser(ctx context.Context, id platform.ID) (*influxdb.Parser, error) {
+	return s.getParser(ctx, id)
+}
+
+func (s service) UpdateParser(ctx context.Context, id platform.ID, request influxdb.UpdateParserRequest) (*influxdb.Parser, error) {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	// The parser is validated as a whole, as a new pattern must still
+	// extract fields.
+	p, err := s.getParser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := sq.Eq{"updated_at": sq.Expr("datetime('now')")}
+	if request.Name != nil {
+		p.Name = *request.Name
+		updates["name"] = *request.Name
+	}
+	if request.Description != nil {
+		p.Description = request.Description
+		updates["description"] = *request.Description
+	}
+	if request.Pattern != nil && p.Type == influxdb.ParserTypeRegex {
+		p.Pattern = *request.Pattern
+		updates["pattern"] = *request.Pattern
+	}
+	if request.Paths != nil && p.Type == influxdb.ParserTypeJSON {
+		p.Paths = request.Paths
+		updates["paths"] = request.Paths
+	}
+	if err := p.Valid(); err != nil {
+		return nil, err
+	}
+
+	q := sq.Update("parsers").SetMap(updates).Where(sq.Eq{"id": id}).
+		Suffix("RETURNING id, org_id, name, description, type, pattern, paths")
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated influxdb.Parser
+	if err := s.store.DB.GetContext(ctx, &updated, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errParserNotFound
+		}
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (s service) DeleteParser(ctx context.Context, id platform.ID) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	q := sq.Delete("parsers").Where(sq.Eq{"id": id}).Suffix("RETURNING id")
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+
+	var d platform.ID
+	if err := s.store.DB.GetContext(ctx, &d, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errParserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s service) getParser(ctx context.Context, id platform.ID) (*influxdb.Parser, error) {
+	q := sq.Select(parserColumns...).
+		From("parsers").
+		Where(sq.Eq{"id": id})
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var p influxdb.Parser
+	if err := s.store.DB.GetContext(ctx, &p, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errParserNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
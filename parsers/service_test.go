@@ -0,0 +1,177 @@
+package parsers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"github.com/influxdata/influxdb/v2/sqlite/migrations"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	ctx        = context.Background()
+	initID     = platform.ID(1)
+	desc       = "testing testing"
+	testParser = influxdb.Parser{
+		ID:          initID,
+		OrgID:       platform.ID(10),
+		Name:        "nginx_log",
+		Description: &desc,
+		Type:        influxdb.ParserTypeRegex,
+		Pattern:     `(?P<method>[A-Z]+) (?P<path>\S+) (?P<status>\d{3})`,
+	}
+	createReq = influxdb.CreateParserRequest{
+		OrgID:       testParser.OrgID,
+		Name:        testParser.Name,
+		Description: testParser.Description,
+		Type:        testParser.Type,
+		Pattern:     testParser.Pattern,
+	}
+)
+
+func TestCreateAndGetParser(t *testing.T) {
+	t.Parallel()
+
+	svc, clean := newTestService(t)
+	defer clean(t)
+
+	// Getting an invalid ID should return an error.
+	got, err := svc.GetParser(ctx, initID)
+	require.Equal(t, errParserNotFound, err)
+	require.Nil(t, got)
+
+	// Create a parser, check the results.
+	created, err := svc.CreateParser(ctx, createReq)
+	require.NoError(t, err)
+	require.Equal(t, testParser, *created)
+
+	// Read the created parser and assert it matches the creation response.
+	got, err = svc.GetParser(ctx, initID)
+	require.NoError(t, err)
+	require.Equal(t, testParser, *got)
+}
+
+func TestCreateParserInvalid(t *testing.T) {
+	t.Parallel()
+
+	svc, clean := newTestService(t)
+	defer clean(t)
+
+	for name, req := range map[string]influxdb.CreateParserRequest{
+		"no named group": {OrgID: testParser.OrgID, Name: "a", Type: influxdb.ParserTypeRegex, Pattern: `\d+`},
+		"bad pattern":    {OrgID: testParser.OrgID, Name: "a", Type: influxdb.ParserTypeRegex, Pattern: `(?P<a>`},
+		"no paths":       {OrgID: testParser.OrgID, Name: "a", Type: influxdb.ParserTypeJSON},
+		"bad path":       {OrgID: testParser.OrgID, Name: "a", Type: influxdb.ParserTypeJSON, Paths: influxdb.ParserPaths{"a": "b[0]"}},
+		"bad type":       {OrgID: testParser.OrgID, Name: "a", Type: "grok"},
+		"no name":        {OrgID: testParser.OrgID, Type: influxdb.ParserTypeRegex, Pattern: testParser.Pattern},
+	} {
+		_, err := svc.CreateParser(ctx, req)
+		require.Equal(t, ierrors.EInvalid, ierrors.ErrorCode(err), name)
+	}
+}
+
+func TestUpdateAndGetParser(t *testing.T) {
+	t.Parallel()
+
+	svc, clean := newTestService(t)
+	defer clean(t)
+
+	newName, newPattern := "nginx", `(?P<status>\d{3})`
+	updateReq := influxdb.UpdateParserRequest{Name: &newName, Pattern: &newPattern}
+
+	// Updating a nonexistent ID fails.
+	updated, err := svc.UpdateParser(ctx, initID, updateReq)
+	require.Equal(t, errParserNotFound, err)
+	require.Nil(t, updated)
+
+	_, err = svc.CreateParser(ctx, createReq)
+	require.NoError(t, err)
+
+	// The updated parser must still extract a field.
+	noGroup := `\d{3}`
+	_, err = svc.UpdateParser(ctx, initID, influxdb.UpdateParserRequest{Pattern: &noGroup})
+	require.Equal(t, ierrors.EInvalid, ierrors.ErrorCode(err))
+
+	expected := testParser
+	expected.Name = newName
+	expected.Pattern = newPattern
+	updated, err = svc.UpdateParser(ctx, initID, updateReq)
+	require.NoError(t, err)
+	require.Equal(t, expected, *updated)
+
+	got, err := svc.GetParser(ctx, initID)
+	require.NoError(t, err)
+	require.Equal(t, expected, *got)
+}
+
+func TestDeleteParser(t *testing.T) {
+	t.Parallel()
+
+	svc, clean := newTestService(t)
+	defer clean(t)
+
+	// Deleting a nonexistent ID fails.
+	require.Equal(t, errParserNotFound, svc.DeleteParser(ctx, initID))
+
+	_, err := svc.CreateParser(ctx, createReq)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.DeleteParser(ctx, initID))
+
+	got, err := svc.GetParser(ctx, initID)
+	require.Equal(t, errParserNotFound, err)
+	require.Nil(t, got)
+}
+
+func TestListParsers(t *testing.T) {
+	t.Parallel()
+
+	svc, clean := newTestService(t)
+	defer clean(t)
+
+	otherReq := influxdb.CreateParserRequest{
+		OrgID: testParser.OrgID,
+		Name:  "event",
+		Type:  influxdb.ParserTypeJSON,
+		Paths: influxdb.ParserPaths{"status": "response.status"},
+	}
+
+	_, err := svc.CreateParser(ctx, createReq)
+	require.NoError(t, err)
+	_, err = svc.CreateParser(ctx, otherReq)
+	require.NoError(t, err)
+
+	listed, err := svc.ListParsers(ctx, influxdb.ParserListFilter{OrgID: testParser.OrgID})
+	require.NoError(t, err)
+	require.Len(t, listed.Parsers, 2)
+
+	name := "event"
+	listed, err = svc.ListParsers(ctx, influxdb.ParserListFilter{OrgID: testParser.OrgID, Name: &name})
+	require.NoError(t, err)
+	require.Len(t, listed.Parsers, 1)
+	require.Equal(t, otherReq.Paths, listed.Parsers[0].Paths)
+
+	listed, err = svc.ListParsers(ctx, influxdb.ParserListFilter{OrgID: platform.ID(1000)})
+	require.NoError(t, err)
+	require.Equal(t, influxdb.Parsers{}, *listed)
+}
+
+func newTestService(t *testing.T) (*service, func(t *testing.T)) {
+	store, clean := sqlite.NewTestStore(t)
+	logger := zaptest.NewLogger(t)
+	sqliteMigrator := sqlite.NewMigrator(store, logger)
+	require.NoError(t, sqliteMigrator.Up(ctx, migrations.AllUp))
+
+	svc := service{
+		store:       store,
+		idGenerator: mock.NewIncrementingIDGenerator(initID),
+	}
+
+	return &svc, clean
+}
@@ -0,0 +1,197 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	prefixParsers = "/api/v2/parsers"
+)
+
+var (
+	errBadOrg = &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "invalid or missing org ID",
+	}
+
+	errBadId = &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "parser ID is invalid",
+	}
+)
+
+type ParserService interface {
+	// ListParsers returns all info about parsers matching a filter.
+	ListParsers(context.Context, influxdb.ParserListFilter) (*influxdb.Parsers, error)
+
+	// CreateParser stores a new parser.
+	CreateParser(context.Context, influxdb.CreateParserRequest) (*influxdb.Parser, error)
+
+	// GetParser returns metadata about the parser with the given ID.
+	GetParser(context.Context, platform.ID) (*influxdb.Parser, error)
+
+	// UpdateParser updates the settings for the parser with the given ID.
+	UpdateParser(context.Context, platform.ID, influxdb.UpdateParserRequest) (*influxdb.Parser, error)
+
+	// DeleteParser deletes all info for the parser with the given ID.
+	DeleteParser(context.Context, platform.ID) error
+}
+
+type ParserHandler struct {
+	chi.Router
+
+	log *zap.Logger
+	api *kithttp.API
+
+	parsersService ParserService
+}
+
+func NewInstrumentedParsersHandler(log *zap.Logger, reg prometheus.Registerer, svc ParserService) *ParserHandler {
+	// Collect metrics.
+	svc = newMetricCollectingService(reg, svc)
+	// Wrap logging.
+	svc = newLoggingService(log, svc)
+	// Wrap authz.
+	svc = newAuthCheckingService(svc)
+
+	return newParserHandler(log, svc)
+}
+
+func newParserHandler(log *zap.Logger, svc ParserService) *ParserHandler {
+	h := &ParserHandler{
+		log:            log,
+		api:            kithttp.NewAPI(kithttp.WithLog(log)),
+		parsersService: svc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetParsers)
+		r.Post("/", h.handlePostParser)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.handleGetParser)
+			r.Patch("/", h.handlePatchParser)
+			r.Delete("/", h.handleDeleteParser)
+		})
+	})
+
+	h.Router = r
+	return h
+}
+
+func (h *ParserHandler) Prefix() string {
+	return prefixParsers
+}
+
+func (h *ParserHandler) handleGetParsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	// orgID is required for listing parsers.
+	orgID := q.Get("orgID")
+	o, err := platform.IDFromString(orgID)
+	if err != nil {
+		h.api.Err(w, r, errBadOrg)
+		return
+	}
+
+	// name is an optional additional filter.
+	name := q.Get("name")
+
+	filters := influxdb.ParserListFilter{OrgID: *o}
+	if name != "" {
+		filters.Name = &name
+	}
+
+	ps, err := h.parsersService.ListParsers(r.Context(), filters)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, ps)
+}
+
+func (h *ParserHandler) handlePostParser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req influxdb.CreateParserRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	p, err := h.parsersService.CreateParser(ctx, req)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusCreated, p)
+}
+
+func (h *ParserHandler) handleGetParser(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, errBadId)
+		return
+	}
+
+	p, err := h.parsersService.GetParser(r.Context(), *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, p)
+}
+
+func (h *ParserHandler) handlePatchParser(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, errBadId)
+		return
+	}
+
+	ctx := r.Context()
+
+	var req influxdb.UpdateParserRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	p, err := h.parsersService.UpdateParser(ctx, *id, req)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, p)
+}
+
+func (h *ParserHandler) handleDeleteParser(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, errBadId)
+		return
+	}
+
+	if err := h.parsersService.DeleteParser(r.Context(), *id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
@@ -0,0 +1,224 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/influxdata/influxdb/v2"
+	influxdbcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/parsers/mock"
+	"github.com/stretchr/testify/assert"
+	tmock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+//go:generate go run github.com/golang/mock/mockgen -package mock -destination ../mock/service.go github.com/influxdata/influxdb/v2/parsers/transport ParserService
+
+var (
+	orgStr     = "1234123412341234"
+	orgID, _   = platform.IDFromString(orgStr)
+	idStr      = "4321432143214321"
+	id, _      = platform.IDFromString(idStr)
+	testParser = influxdb.Parser{
+		ID:      *id,
+		OrgID:   *orgID,
+		Name:    "nginx_log",
+		Type:    influxdb.ParserTypeRegex,
+		Pattern: `(?P<method>[A-Z]+) (?P<path>\S+) (?P<status>\d{3})`,
+	}
+)
+
+func TestParserHandler(t *testing.T) {
+	t.Run("get parsers happy path", func(t *testing.T) {
+		ts, svc := newTestServer(t)
+		defer ts.Close()
+
+		req := newTestRequest(t, "GET", ts.URL, nil)
+
+		q := req.URL.Query()
+		q.Add("orgID", orgStr)
+		q.Add("name", testParser.Name)
+		req.URL.RawQuery = q.Encode()
+
+		expected := influxdb.Parsers{Parsers: []influxdb.Parser{testParser}}
+
+		svc.EXPECT().
+			ListParsers(gomock.Any(), tmock.MatchedBy(func(in influxdb.ParserListFilter) bool {
+				return assert.Equal(t, *orgID, in.OrgID) &&
+					assert.Equal(t, testParser.Name, *in.Name)
+			})).Return(&expected, nil)
+
+		res := doTestRequest(t, req, http.StatusOK, true)
+
+		var got influxdb.Parsers
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+		require.Equal(t, expected, got)
+	})
+
+	t.Run("create parser happy path", func(t *testing.T) {
+		ts, svc := newTestServer(t)
+		defer ts.Close()
+
+		body := influxdb.CreateParserRequest{
+			OrgID:   testParser.OrgID,
+			Name:    testParser.Name,
+			Type:    testParser.Type,
+			Pattern: testParser.Pattern,
+		}
+
+		req := newTestRequest(t, "POST", ts.URL, &body)
+
+		svc.EXPECT().CreateParser(gomock.Any(), body).Return(&testParser, nil)
+
+		res := doTestRequest(t, req, http.StatusCreated, true)
+
+		var got influxdb.Parser
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+		require.Equal(t, testParser, got)
+	})
+
+	t.Run("get parser happy path", func(t *testing.T) {
+		ts, svc := newTestServer(t)
+		defer ts.Close()
+
+		req := newTestRequest(t, "GET", ts.URL+"/"+id.String(), nil)
+
+		svc.EXPECT().GetParser(gomock.Any(), *id).Return(&testParser, nil)
+
+		res := doTestRequest(t, req, http.StatusOK, true)
+
+		var got influxdb.Parser
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+		require.Equal(t, testParser, got)
+	})
+
+	t.Run("delete parser happy path", func(t *testing.T) {
+		ts, svc := newTestServer(t)
+		defer ts.Close()
+
+		req := newTestRequest(t, "DELETE", ts.URL+"/"+id.String(), nil)
+
+		svc.EXPECT().DeleteParser(gomock.Any(), *id).Return(nil)
+
+		doTestRequest(t, req, http.StatusNoContent, false)
+	})
+
+	t.Run("update parser happy path", func(t *testing.T) {
+		ts, svc := newTestServer(t)
+		defer ts.Close()
+
+		newPattern := `(?P<method>[A-Z]+) (?P<path>\S+)`
+		body := influxdb.UpdateParserRequest{Pattern: &newPattern}
+
+		req := newTestRequest(t, "PATCH", ts.URL+"/"+id.String(), &body)
+
+		svc.EXPECT().UpdateParser(gomock.Any(), *id, body).Return(&testParser, nil)
+
+		res := doTestRequest(t, req, http.StatusOK, true)
+
+		var got influxdb.Parser
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+		require.Equal(t, testParser, got)
+	})
+
+	t.Run("invalid parser IDs return 400", func(t *testing.T) {
+		ts, _ := newTestServer(t)
+		defer ts.Close()
+
+		req1 := newTestRequest(t, "GET", ts.URL+"/foo", nil)
+		req2 := newTestRequest(t, "PATCH", ts.URL+"/foo", &influxdb.UpdateParserRequest{})
+		req3 := newTestRequest(t, "DELETE", ts.URL+"/foo", nil)
+
+		for _, req := range []*http.Request{req1, req2, req3} {
+			t.Run(req.Method, func(t *testing.T) {
+				doTestRequest(t, req, http.StatusBadRequest, true)
+			})
+		}
+	})
+
+	t.Run("invalid org ID to GET /parsers returns 400", func(t *testing.T) {
+		ts, _ := newTestServer(t)
+		defer ts.Close()
+
+		req := newTestRequest(t, "GET", ts.URL, nil)
+		q := req.URL.Query()
+		q.Add("orgID", "foo")
+		req.URL.RawQuery = q.Encode()
+
+		doTestRequest(t, req, http.StatusBadRequest, true)
+	})
+}
+
+func TestParserAuthorization(t *testing.T) {
+	otherID := platform.ID(2)
+	readOne := influxdbcontext.SetAuthorizer(context.Background(), &influxdb.Authorization{
+		Status: influxdb.Active,
+		Permissions: []influxdb.Permission{{
+			Action:   influxdb.ReadAction,
+			Resource: influxdb.Resource{Type: influxdb.ParsersResourceType, OrgID: orgID, ID: id},
+		}},
+	})
+
+	t.Run("list only returns readable parsers", func(t *testing.T) {
+		svc := mock.NewMockParserService(gomock.NewController(t))
+		other := testParser
+		other.ID = otherID
+		svc.EXPECT().ListParsers(gomock.Any(), gomock.Any()).
+			Return(&influxdb.Parsers{Parsers: []influxdb.Parser{testParser, other}}, nil)
+
+		got, err := newAuthCheckingService(svc).ListParsers(readOne, influxdb.ParserListFilter{OrgID: *orgID})
+		require.NoError(t, err)
+		require.Equal(t, []influxdb.Parser{testParser}, got.Parsers)
+	})
+
+	t.Run("writes require write permission", func(t *testing.T) {
+		svc := mock.NewMockParserService(gomock.NewController(t))
+		svc.EXPECT().GetParser(gomock.Any(), *id).Return(&testParser, nil).Times(2)
+
+		auth := newAuthCheckingService(svc)
+		name := "renamed"
+		_, err := auth.UpdateParser(readOne, *id, influxdb.UpdateParserRequest{Name: &name})
+		require.Equal(t, errors.EUnauthorized, errors.ErrorCode(err))
+		require.Equal(t, errors.EUnauthorized, errors.ErrorCode(auth.DeleteParser(readOne, *id)))
+
+		_, err = auth.CreateParser(readOne, influxdb.CreateParserRequest{OrgID: *orgID})
+		require.Equal(t, errors.EUnauthorized, errors.ErrorCode(err))
+	})
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *mock.MockParserService) {
+	ctrlr := gomock.NewController(t)
+	svc := mock.NewMockParserService(ctrlr)
+	server := newParserHandler(zaptest.NewLogger(t), svc)
+	return httptest.NewServer(server), svc
+}
+
+func newTestRequest(t *testing.T, method, path string, body interface{}) *http.Request {
+	dat, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(method, path, bytes.NewBuffer(dat))
+	require.NoError(t, err)
+
+	req.Header.Add("Content-Type", "application/json")
+
+	return req
+}
+
+func doTestRequest(t *testing.T, req *http.Request, wantCode int, needJSON bool) *http.Response {
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, wantCode, res.StatusCode)
+	if needJSON {
+		require.Equal(t, "application/json; charset=utf-8", res.Header.Get("Content-Type"))
+	}
+	return res
+}
@@ -0,0 +1,81 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+func newAuthCheckingService(underlying ParserService) *authCheckingService {
+	return &authCheckingService{underlying}
+}
+
+type authCheckingService struct {
+	underlying ParserService
+}
+
+var _ ParserService = (*authCheckingService)(nil)
+
+func (a authCheckingService) ListParsers(ctx context.Context, filter influxdb.ParserListFilter) (*influxdb.Parsers, error) {
+	ps, err := a.underlying.ListParsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	pps := ps.Parsers[:0]
+	for _, p := range ps.Parsers {
+		_, _, err := authorizer.AuthorizeRead(ctx, influxdb.ParsersResourceType, p.ID, p.OrgID)
+		if err != nil && errors.ErrorCode(err) != errors.EUnauthorized {
+			return nil, err
+		}
+		if errors.ErrorCode(err) == errors.EUnauthorized {
+			continue
+		}
+		pps = append(pps, p)
+	}
+	return &influxdb.Parsers{Parsers: pps}, nil
+}
+
+func (a authCheckingService) CreateParser(ctx context.Context, request influxdb.CreateParserRequest) (*influxdb.Parser, error) {
+	if _, _, err := authorizer.AuthorizeCreate(ctx, influxdb.ParsersResourceType, request.OrgID); err != nil {
+		return nil, err
+	}
+
+	return a.underlying.CreateParser(ctx, request)
+}
+
+func (a authCheckingService) GetParser(ctx context.Context, id platform.ID) (*influxdb.Parser, error) {
+	p, err := a.underlying.GetParser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeRead(ctx, influxdb.ParsersResourceType, id, p.OrgID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (a authCheckingService) UpdateParser(ctx context.Context, id platform.ID, request influxdb.UpdateParserRequest) (*influxdb.Parser, error) {
+	p, err := a.underlying.GetParser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.ParsersResourceType, id, p.OrgID); err != nil {
+		return nil, err
+	}
+	return a.underlying.UpdateParser(ctx, id, request)
+}
+
+func (a authCheckingService) DeleteParser(ctx context.Context, id platform.ID) error {
+	p, err := a.underlying.GetParser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.ParsersResourceType, id, p.OrgID); err != nil {
+		return err
+	}
+	return a.underlying.DeleteParser(ctx, id)
+}
@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"go.uber.org/zap"
+)
+
+func newLoggingService(logger *zap.Logger, underlying ParserService) *loggingService {
+	return &loggingService{
+		logger:     logger,
+		underlying: underlying,
+	}
+}
+
+type loggingService struct {
+	logger     *zap.Logger
+	underlying ParserService
+}
+
+var _ ParserService = (*loggingService)(nil)
+
+func (l loggingService) ListParsers(ctx context.Context, filter influxdb.ParserListFilter) (ps *influxdb.Parsers, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to find parsers", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("parsers find", dur)
+	}(time.Now())
+	return l.underlying.ListParsers(ctx, filter)
+}
+
+func (l loggingService) CreateParser(ctx context.Context, request influxdb.CreateParserRequest) (p *influxdb.Parser, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to create parser", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("parser create", dur)
+	}(time.Now())
+	return l.underlying.CreateParser(ctx, request)
+}
+
+func (l loggingService) GetParser(ctx context.Context, id platform.ID) (p *influxdb.Parser, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to find parser by ID", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("parser find by ID", dur)
+	}(time.Now())
+	return l.underlying.GetParser(ctx, id)
+}
+
+func (l loggingService) UpdateParser(ctx context.Context, id platform.ID, request influxdb.UpdateParserRequest) (p *influxdb.Parser, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to update parser", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("parser update", dur)
+	}(time.Now())
+	return l.underlying.UpdateParser(ctx, id, request)
+}
+
+func (l loggingService) DeleteParser(ctx context.Context, id platform.ID) (err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to delete parser", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("parser delete", dur)
+	}(time.Now())
+	return l.underlying.DeleteParser(ctx, id)
+}
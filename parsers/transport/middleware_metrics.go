@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/metric"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newMetricCollectingService(reg prometheus.Registerer, underlying ParserService, opts ...metric.ClientOptFn) *metricsService {
+	o := metric.ApplyMetricOpts(opts...)
+	return &metricsService{
+		rec:        metric.New(reg, o.ApplySuffix("parser")),
+		underlying: underlying,
+	}
+}
+
+type metricsService struct {
+	// RED metrics
+	rec        *metric.REDClient
+	underlying ParserService
+}
+
+var _ ParserService = (*metricsService)(nil)
+
+func (m metricsService) ListParsers(ctx context.Context, filter influxdb.ParserListFilter) (*influxdb.Parsers, error) {
+	rec := m.rec.Record("find_parsers")
+	ps, err := m.underlying.ListParsers(ctx, filter)
+	return ps, rec(err)
+}
+
+func (m metricsService) CreateParser(ctx context.Context, request influxdb.CreateParserRequest) (*influxdb.Parser, error) {
+	rec := m.rec.Record("create_parser")
+	p, err := m.underlying.CreateParser(ctx, request)
+	return p, rec(err)
+}
+
+func (m metricsService) GetParser(ctx context.Context, id platform.ID) (*influxdb.Parser, error) {
+	rec := m.rec.Record("find_parser_by_id")
+	p, err := m.underlying.GetParser(ctx, id)
+	return p, rec(err)
+}
+
+func (m metricsService) UpdateParser(ctx context.Context, id platform.ID, request influxdb.UpdateParserRequest) (*influxdb.Parser, error) {
+	rec := m.rec.Record("update_parser")
+	p, err := m.underlying.UpdateParser(ctx, id, request)
+	return p, rec(err)
+}
+
+func (m metricsService) DeleteParser(ctx context.Context, id platform.ID) error {
+	rec := m.rec.Record("delete_parser")
+	return rec(m.underlying.DeleteParser(ctx, id))
+}
@@ -10,12 +10,36 @@ import (
 
 // Decoder type can decode a jsonnet stream into the given output.
 type Decoder struct {
-	r io.Reader
+	r       io.Reader
+	extVars map[string]string
+	tlaVars map[string]string
+}
+
+// DecoderOptFn configures the jsonnet VM used by the Decoder.
+type DecoderOptFn func(*Decoder)
+
+// WithExtVars makes the vars available to the snippet via std.extVar.
+func WithExtVars(vars map[string]string) DecoderOptFn {
+	return func(d *Decoder) {
+		d.extVars = vars
+	}
+}
+
+// WithTLAVars passes the vars as top-level arguments to a snippet that
+// evaluates to a function.
+func WithTLAVars(vars map[string]string) DecoderOptFn {
+	return func(d *Decoder) {
+		d.tlaVars = vars
+	}
 }
 
 // NewDecoder creates a new decoder.
-func NewDecoder(r io.Reader) *Decoder {
-	return &Decoder{r: r}
+func NewDecoder(r io.Reader, opts ...DecoderOptFn) *Decoder {
+	d := &Decoder{r: r}
+	for _, o := range opts {
+		o(d)
+	}
+	return d
 }
 
 // Decode decodes the stream into the provide value.
@@ -26,9 +50,16 @@ func (d *Decoder) Decode(v interface{}) error {
 	}
 
 	vm := jsonnet.MakeVM()
+	for k, val := range d.extVars {
+		vm.ExtVar(k, val)
+	}
+	for k, val := range d.tlaVars {
+		vm.TLAVar(k, val)
+	}
 	jsonStr, err := vm.EvaluateAnonymousSnippet("memory", string(b))
 	if err != nil {
 		return err
 	}
+
 	return json.Unmarshal([]byte(jsonStr), &v)
 }
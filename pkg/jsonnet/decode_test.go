@@ -45,3 +45,27 @@ func TestDecoder(t *testing.T) {
 	}
 	assert.Equal(t, expected, out)
 }
+
+func TestDecoder_Vars(t *testing.T) {
+	type bucket struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	const entry = `function(name) {
+  name: name,
+  description: "owned by " + std.extVar("team"),
+}`
+
+	var out bucket
+	dec := jsonnet.NewDecoder(strings.NewReader(entry),
+		jsonnet.WithExtVars(map[string]string{"team": "payments"}),
+		jsonnet.WithTLAVars(map[string]string{"name": "invoices"}),
+	)
+	require.NoError(t, dec.Decode(&out))
+
+	assert.Equal(t, bucket{Name: "invoices", Description: "owned by payments"}, out)
+
+	err := jsonnet.NewDecoder(strings.NewReader(entry)).Decode(&out)
+	assert.Error(t, err, "missing vars should fail evaluation")
+}
@@ -0,0 +1,119 @@
+package limiter
+
+import (
+	"math"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// Concurrency limits the number of concurrent callers that take a token
+// without blocking.
+type Concurrency interface {
+	// TryTake attempts to take a token and returns true if successful.
+	TryTake() bool
+	// Release releases a token back to the limiter.
+	Release()
+	// Available returns the number of available tokens that may be taken.
+	Available() int
+	// Capacity returns the number of tokens can be taken.
+	Capacity() int
+}
+
+// Resizable is a concurrency limiter whose capacity can be changed while
+// tokens are taken. When the capacity is lowered below the number of taken
+// tokens, no token can be taken until enough are released.
+type Resizable struct {
+	mu       sync.Mutex
+	capacity int
+	taken    int
+}
+
+// NewResizable returns a limiter allowing limit tokens to be taken.
+func NewResizable(limit int) *Resizable {
+	return &Resizable{capacity: limit}
+}
+
+// TryTake attempts to take a token and return true if successful, otherwise returns false.
+func (r *Resizable) TryTake() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.taken >= r.capacity {
+		return false
+	}
+	r.taken++
+	return true
+}
+
+// Release releases a token back to the limiter.
+func (r *Resizable) Release() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.taken--
+}
+
+// Available returns the number of available tokens that may be taken.
+func (r *Resizable) Available() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.taken >= r.capacity {
+		return 0
+	}
+	return r.capacity - r.taken
+}
+
+// Capacity returns the number of tokens can be taken.
+func (r *Resizable) Capacity() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.capacity
+}
+
+// SetCapacity changes the number of tokens can be taken. Tokens taken
+// beyond the new capacity stay taken until released.
+func (r *Resizable) SetCapacity(limit int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.capacity = limit
+}
+
+// AdjustableRate is a rate limiter whose rate can be changed while in use.
+type AdjustableRate struct {
+	*rate.Limiter
+
+	mu          sync.Mutex
+	bytesPerSec int
+	burstLimit  int
+}
+
+// NewAdjustableRate returns a rate limiter limiting the rate to bytesPerSec
+// with a maximum burst of burstLimit. A zero bytesPerSec disables the limit.
+func NewAdjustableRate(bytesPerSec, burstLimit int) *AdjustableRate {
+	r := &AdjustableRate{Limiter: rate.NewLimiter(rate.Inf, math.MaxInt32)}
+	r.SetRate(bytesPerSec, burstLimit)
+	return r
+}
+
+// SetRate changes the rate and the maximum burst of the limiter. A zero
+// bytesPerSec disables the limit.
+func (r *AdjustableRate) SetRate(bytesPerSec, burstLimit int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bytesPerSec, r.burstLimit = bytesPerSec, burstLimit
+	if bytesPerSec <= 0 {
+		// Writers write up to the burst at once, so an unlimited rate
+		// must not leave them a small burst.
+		r.Limiter.SetLimit(rate.Inf)
+		r.Limiter.SetBurst(math.MaxInt32)
+		return
+	}
+	r.Limiter.SetBurst(burstLimit)
+	r.Limiter.SetLimit(rate.Limit(bytesPerSec))
+}
+
+// Rate returns the rate and the maximum burst of the limiter.
+func (r *AdjustableRate) Rate() (bytesPerSec, burstLimit int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.bytesPerSec, r.burstLimit
+}
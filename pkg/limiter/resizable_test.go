@@ -0,0 +1,42 @@
+package limiter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/pkg/limiter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResizable(t *testing.T) {
+	r := limiter.NewResizable(2)
+	require.True(t, r.TryTake())
+	require.True(t, r.TryTake())
+	require.False(t, r.TryTake())
+	require.Equal(t, 0, r.Available())
+
+	// lowering the capacity keeps the tokens taken
+	r.SetCapacity(1)
+	r.Release()
+	require.False(t, r.TryTake())
+	r.Release()
+	require.Equal(t, 1, r.Available())
+	require.True(t, r.TryTake())
+
+	r.SetCapacity(3)
+	require.Equal(t, 3, r.Capacity())
+	require.Equal(t, 2, r.Available())
+}
+
+func TestAdjustableRate(t *testing.T) {
+	r := limiter.NewAdjustableRate(0, 10)
+	// unlimited rates wait for no burst
+	require.NoError(t, r.WaitN(context.Background(), 1<<20))
+
+	r.SetRate(100, 10)
+	bytesPerSec, burst := r.Rate()
+	require.Equal(t, 100, bytesPerSec)
+	require.Equal(t, 10, burst)
+	require.Equal(t, 10, r.Burst())
+	require.Error(t, r.WaitN(context.Background(), 11))
+}
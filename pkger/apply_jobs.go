@@ -0,0 +1,201 @@
+package pkger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/snowflake"
+)
+
+// DefaultApplyJobRetention is how long the status of a finished asynchronous
+// apply is kept.
+const DefaultApplyJobRetention = time.Hour
+
+// ApplyJobStatus is the status of an asynchronous apply.
+type ApplyJobStatus string
+
+// apply job statuses
+const (
+	ApplyJobPending   ApplyJobStatus = "pending"
+	ApplyJobRunning   ApplyJobStatus = "running"
+	ApplyJobSucceeded ApplyJobStatus = "succeeded"
+	ApplyJobFailed    ApplyJobStatus = "failed"
+)
+
+type (
+	// RespApplyJob is the response body for an asynchronous apply. Result is
+	// provided once the apply finishes, Error once it fails.
+	RespApplyJob struct {
+		ID          string            `json:"id"`
+		OrgID       string            `json:"orgID"`
+		Status      ApplyJobStatus    `json:"status"`
+		DryRun      bool              `json:"dryRun"`
+		Resources   int               `json:"resources"`
+		CreatedAt   time.Time         `json:"createdAt"`
+		StartedAt   *time.Time        `json:"startedAt,omitempty"`
+		CompletedAt *time.Time        `json:"completedAt,omitempty"`
+		Result      *RespApply        `json:"result,omitempty"`
+		Error       *RespApplyJobErr  `json:"error,omitempty"`
+		Links       RespApplyJobLinks `json:"links"`
+	}
+
+	// RespApplyJobErr describes why an asynchronous apply failed.
+	RespApplyJobErr struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	// RespApplyJobLinks provides the links of an asynchronous apply.
+	RespApplyJobLinks struct {
+		Self string `json:"self"`
+	}
+)
+
+type applyJob struct {
+	id        platform.ID
+	orgID     platform.ID
+	userID    platform.ID
+	dryRun    bool
+	resources int
+
+	status      ApplyJobStatus
+	createdAt   time.Time
+	startedAt   time.Time
+	completedAt time.Time
+	result      *RespApply
+	err         *RespApplyJobErr
+}
+
+func (j *applyJob) resp() RespApplyJob {
+	out := RespApplyJob{
+		ID:        j.id.String(),
+		OrgID:     j.orgID.String(),
+		Status:    j.status,
+		DryRun:    j.dryRun,
+		Resources: j.resources,
+		CreatedAt: j.createdAt,
+		Result:    j.result,
+		Error:     j.err,
+		Links: RespApplyJobLinks{
+			Self: fmt.Sprintf("%s/jobs/%s", RoutePrefixTemplates, j.id),
+		},
+	}
+	if !j.startedAt.IsZero() {
+		startedAt := j.startedAt
+		out.StartedAt = &startedAt
+	}
+	if !j.completedAt.IsZero() {
+		completedAt := j.completedAt
+		out.CompletedAt = &completedAt
+	}
+	return out
+}
+
+// applyJobs runs applies in the background and tracks their status. Jobs
+// are only visible to the user that started them, and finished jobs are
+// forgotten after the retention period.
+type applyJobs struct {
+	retention time.Duration
+	idGen     platform.IDGenerator
+	now       func() time.Time
+
+	mu   sync.Mutex
+	jobs map[platform.ID]*applyJob
+}
+
+func newApplyJobs(retention time.Duration) *applyJobs {
+	return &applyJobs{
+		retention: retention,
+		idGen:     snowflake.NewDefaultIDGenerator(),
+		now:       time.Now,
+		jobs:      make(map[platform.ID]*applyJob),
+	}
+}
+
+// start runs fn in the background. The job's context keeps the values of
+// ctx, i.e. the authorizer, but is not canceled when ctx is.
+func (a *applyJobs) start(ctx context.Context, orgID, userID platform.ID, dryRun bool, resources int, fn func(context.Context) (*RespApply, error)) RespApplyJob {
+	job := &applyJob{
+		id:        a.idGen.ID(),
+		orgID:     orgID,
+		userID:    userID,
+		dryRun:    dryRun,
+		resources: resources,
+		status:    ApplyJobPending,
+		createdAt: a.now(),
+	}
+
+	a.mu.Lock()
+	a.removeExpired()
+	a.jobs[job.id] = job
+	resp := job.resp()
+	a.mu.Unlock()
+
+	go a.run(detachedContext{ctx}, job, fn)
+
+	return resp
+}
+
+func (a *applyJobs) run(ctx context.Context, job *applyJob, fn func(context.Context) (*RespApply, error)) {
+	a.mu.Lock()
+	job.status = ApplyJobRunning
+	job.startedAt = a.now()
+	a.mu.Unlock()
+
+	result, err := fn(ctx)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	job.completedAt = a.now()
+	job.result = result
+	if err != nil {
+		job.status = ApplyJobFailed
+		job.err = &RespApplyJobErr{
+			Code:    errors.ErrorCode(err),
+			Message: errors.ErrorMessage(err),
+		}
+		return
+	}
+	job.status = ApplyJobSucceeded
+}
+
+// find returns the job with the given id if it was started by the user.
+func (a *applyJobs) find(id, userID platform.ID) (RespApplyJob, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.removeExpired()
+	job, ok := a.jobs[id]
+	if !ok || job.userID != userID {
+		return RespApplyJob{}, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("apply job %q not found", id),
+		}
+	}
+	return job.resp(), nil
+}
+
+// removeExpired must be called with the lock held.
+func (a *applyJobs) removeExpired() {
+	now := a.now()
+	for id, job := range a.jobs {
+		if !job.completedAt.IsZero() && !now.Before(job.completedAt.Add(a.retention)) {
+			delete(a.jobs, id)
+		}
+	}
+}
+
+// detachedContext provides the values of the wrapped context without its
+// deadline or cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
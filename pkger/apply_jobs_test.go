@@ -0,0 +1,93 @@
+package pkger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_applyJobs(t *testing.T) {
+	waitFor := func(t *testing.T, jobs *applyJobs, id, userID platform.ID, status ApplyJobStatus) RespApplyJob {
+		t.Helper()
+
+		var job RespApplyJob
+		require.Eventually(t, func() bool {
+			var err error
+			job, err = jobs.find(id, userID)
+			return err == nil && job.Status == status
+		}, time.Second, time.Millisecond)
+		return job
+	}
+
+	t.Run("reports the result of a finished apply", func(t *testing.T) {
+		jobs := newApplyJobs(time.Hour)
+
+		release := make(chan struct{})
+		ctx, cancel := context.WithCancel(context.Background())
+		job := jobs.start(ctx, 1, 2, false, 3, func(ctx context.Context) (*RespApply, error) {
+			<-release
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return &RespApply{StackID: "000000000000000a"}, nil
+		})
+		cancel()
+		assert.Equal(t, 3, job.Resources)
+		assert.Nil(t, job.Result)
+
+		id, err := platform.IDFromString(job.ID)
+		require.NoError(t, err)
+		waitFor(t, jobs, *id, 2, ApplyJobRunning)
+
+		close(release)
+		job = waitFor(t, jobs, *id, 2, ApplyJobSucceeded)
+		require.NotNil(t, job.Result, "job outlives the request's context")
+		assert.Equal(t, "000000000000000a", job.Result.StackID)
+		assert.NotNil(t, job.CompletedAt)
+
+		_, err = jobs.find(*id, 3)
+		assert.Equal(t, errors2.ENotFound, errors2.ErrorCode(err), "jobs are only visible to the user that started them")
+	})
+
+	t.Run("reports a failed apply", func(t *testing.T) {
+		jobs := newApplyJobs(time.Hour)
+
+		job := jobs.start(context.Background(), 1, 2, true, 0, func(ctx context.Context) (*RespApply, error) {
+			return nil, &errors2.Error{Code: errors2.EConflict, Msg: "bucket exists"}
+		})
+
+		id, err := platform.IDFromString(job.ID)
+		require.NoError(t, err)
+		job = waitFor(t, jobs, *id, 2, ApplyJobFailed)
+		require.NotNil(t, job.Error)
+		assert.Equal(t, errors2.EConflict, job.Error.Code)
+		assert.Equal(t, "bucket exists", job.Error.Message)
+		assert.True(t, job.DryRun)
+	})
+
+	t.Run("forgets finished jobs after the retention period", func(t *testing.T) {
+		jobs := newApplyJobs(time.Hour)
+		now := time.Now()
+		jobs.now = func() time.Time { return now }
+
+		job := jobs.start(context.Background(), 1, 2, false, 0, func(ctx context.Context) (*RespApply, error) {
+			return nil, errors.New("failed")
+		})
+		id, err := platform.IDFromString(job.ID)
+		require.NoError(t, err)
+		waitFor(t, jobs, *id, 2, ApplyJobFailed)
+
+		jobs.mu.Lock()
+		now = now.Add(time.Hour)
+		jobs.mu.Unlock()
+
+		_, err = jobs.find(*id, 2)
+		assert.Equal(t, errors2.ENotFound, errors2.ErrorCode(err))
+	})
+}
@@ -0,0 +1,534 @@
+package pkger
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// BundleManifestFile is the path of the manifest within a bundle archive.
+const BundleManifestFile = "manifest.yml"
+
+const (
+	// maxBundleFileSize is the largest decompressed file of a bundle archive.
+	maxBundleFileSize = 16 << 20
+	// maxBundleFilesSize is the largest decompressed size of all the files
+	// of a bundle archive.
+	maxBundleFilesSize = 64 << 20
+)
+
+// BundleFormat is the archive format of a bundle.
+type BundleFormat string
+
+// bundle formats
+const (
+	BundleFormatTarGz BundleFormat = "tar.gz"
+	BundleFormatZip   BundleFormat = "zip"
+)
+
+type (
+	// BundleManifest describes the templates a bundle is made of.
+	BundleManifest struct {
+		Name    string         `json:"name" yaml:"name"`
+		Version string         `json:"version,omitempty" yaml:"version,omitempty"`
+		Members []BundleMember `json:"members" yaml:"members"`
+	}
+
+	// BundleMember is a template within a bundle. Members are applied after
+	// the members they depend on.
+	BundleMember struct {
+		Name      string   `json:"name" yaml:"name"`
+		Version   string   `json:"version,omitempty" yaml:"version,omitempty"`
+		Path      string   `json:"path" yaml:"path"`
+		DependsOn []string `json:"dependsOn,omitempty" yaml:"dependsOn,omitempty"`
+	}
+
+	// Bundle is a set of templates distributed and applied as a unit.
+	Bundle struct {
+		Manifest  BundleManifest
+		Templates map[string]*Template
+	}
+)
+
+// StackName is the name of the stack the member is applied to.
+func (m BundleManifest) StackName(member string) string {
+	return m.Name + "/" + member
+}
+
+// source is the source the template of the member is applied from, which
+// the stack of the member records.
+func (m BundleManifest) source(mem BundleMember) string {
+	return m.Name + "/" + mem.path()
+}
+
+// ApplyOrder returns the members ordered so that every member follows the
+// members it depends on. Members without dependencies between them keep
+// their manifest order.
+func (m BundleManifest) ApplyOrder() ([]BundleMember, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(m.Members))
+	byName := make(map[string]BundleMember, len(m.Members))
+	for _, mem := range m.Members {
+		byName[mem.Name] = mem
+	}
+
+	ordered := make([]BundleMember, 0, len(m.Members))
+	var visit func(mem BundleMember, chain []string) error
+	visit = func(mem BundleMember, chain []string) error {
+		switch state[mem.Name] {
+		case visited:
+			return nil
+		case visiting:
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("bundle members have a dependency cycle: %s", strings.Join(append(chain, mem.Name), " -> ")),
+			}
+		}
+		state[mem.Name] = visiting
+		for _, dep := range mem.DependsOn {
+			if err := visit(byName[dep], append(chain, mem.Name)); err != nil {
+				return err
+			}
+		}
+		state[mem.Name] = visited
+		ordered = append(ordered, mem)
+		return nil
+	}
+
+	for _, mem := range m.Members {
+		if err := visit(mem, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+func (m BundleManifest) validate() error {
+	if m.Name == "" {
+		return &errors.Error{Code: errors.EInvalid, Msg: "bundle manifest must provide a name"}
+	}
+	if len(m.Members) == 0 {
+		return &errors.Error{Code: errors.EInvalid, Msg: "bundle manifest must provide at least one member"}
+	}
+
+	names := make(map[string]bool, len(m.Members))
+	for _, mem := range m.Members {
+		if mem.Name == "" {
+			return &errors.Error{Code: errors.EInvalid, Msg: "bundle members must provide a name"}
+		}
+		if names[mem.Name] {
+			return &errors.Error{Code: errors.EInvalid, Msg: fmt.Sprintf("bundle member %q is declared more than once", mem.Name)}
+		}
+		names[mem.Name] = true
+	}
+	for _, mem := range m.Members {
+		for _, dep := range mem.DependsOn {
+			if !names[dep] {
+				return &errors.Error{
+					Code: errors.EInvalid,
+					Msg:  fmt.Sprintf("bundle member %q depends on unknown member %q", mem.Name, dep),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (mem BundleMember) path() string {
+	if mem.Path != "" {
+		return path.Clean(mem.Path)
+	}
+	return mem.Name + ".yml"
+}
+
+// ParseBundle parses a bundle from a zip, tar or gzipped tar archive. The
+// archive must contain a manifest.yml at its root along with every member
+// template the manifest declares.
+func ParseBundle(b []byte, opts ...ValidateOptFn) (*Bundle, error) {
+	files, err := readBundleArchive(b)
+	if errors.ErrorCode(err) == errors.ETooLarge {
+		return nil, err
+	}
+	if err != nil {
+		return nil, influxErr(errors.EInvalid, "failed to read bundle archive", err)
+	}
+
+	rawManifest, ok := files[BundleManifestFile]
+	if !ok {
+		return nil, influxErr(errors.EInvalid, "bundle archive is missing "+BundleManifestFile)
+	}
+	var manifest BundleManifest
+	if err := yaml.Unmarshal(rawManifest, &manifest); err != nil {
+		return nil, influxErr(errors.EInvalid, "invalid bundle manifest", err)
+	}
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+
+	bundle := &Bundle{
+		Manifest:  manifest,
+		Templates: make(map[string]*Template, len(manifest.Members)),
+	}
+	for _, mem := range manifest.Members {
+		p := mem.path()
+		raw, ok := files[p]
+		if !ok {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("bundle member %q template %q not found in archive", mem.Name, p),
+			}
+		}
+		template, err := Parse(convertEncoding("", p), FromReader(bytes.NewReader(raw), manifest.source(mem)), opts...)
+		if err != nil {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("failed to parse bundle member %q", mem.Name),
+				Err:  err,
+			}
+		}
+		bundle.Templates[mem.Name] = template
+	}
+	return bundle, nil
+}
+
+func readBundleArchive(b []byte) (map[string][]byte, error) {
+	switch {
+	case bytes.HasPrefix(b, []byte("PK\x03\x04")):
+		return readZip(b)
+	case bytes.HasPrefix(b, []byte{0x1f, 0x8b}):
+		gr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		return readTar(gr)
+	default:
+		return readTar(bytes.NewReader(b))
+	}
+}
+
+func readZip(b []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	var total int64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := readBundleFile(rc, f.Name, &total)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(f.Name)] = content
+	}
+	return files, nil
+}
+
+func readTar(r io.Reader) (map[string][]byte, error) {
+	tr := tar.NewReader(r)
+	files := make(map[string][]byte)
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := readBundleFile(tr, hdr.Name, &total)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(hdr.Name)] = content
+	}
+}
+
+// readBundleFile reads a file of a bundle archive, adding its size to total.
+// It fails when the file or the files read so far are larger than allowed,
+// without decompressing more than the limit.
+func readBundleFile(r io.Reader, name string, total *int64) ([]byte, error) {
+	content, err := ioutil.ReadAll(io.LimitReader(r, maxBundleFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxBundleFileSize {
+		return nil, &errors.Error{
+			Code: errors.ETooLarge,
+			Msg:  fmt.Sprintf("bundle file %q exceeds the maximum size of %d bytes", name, maxBundleFileSize),
+		}
+	}
+	*total += int64(len(content))
+	if *total > maxBundleFilesSize {
+		return nil, &errors.Error{
+			Code: errors.ETooLarge,
+			Msg:  fmt.Sprintf("bundle files exceed the maximum total size of %d bytes", maxBundleFilesSize),
+		}
+	}
+	return content, nil
+}
+
+// Encode writes the bundle as an archive of the given format. Member
+// templates are written as yaml to the paths declared in the manifest.
+func (b *Bundle) Encode(format BundleFormat) ([]byte, error) {
+	files := make(map[string][]byte, len(b.Manifest.Members)+1)
+	manifest, err := yaml.Marshal(b.Manifest)
+	if err != nil {
+		return nil, influxErr(errors.EInternal, err)
+	}
+	files[BundleManifestFile] = manifest
+
+	for _, mem := range b.Manifest.Members {
+		template, ok := b.Templates[mem.Name]
+		if !ok {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("bundle member %q has no template", mem.Name),
+			}
+		}
+		raw, err := template.Encode(EncodingYAML)
+		if err != nil {
+			return nil, err
+		}
+		files[mem.path()] = raw
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	switch format {
+	case BundleFormatZip:
+		return writeZip(names, files)
+	case BundleFormatTarGz, "":
+		return writeTarGz(names, files)
+	default:
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("unsupported bundle format %q", format),
+		}
+	}
+}
+
+func writeZip(names []string, files map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeTarGz(names []string, files map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, name := range names {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(files[name])),
+			Typeflag: tar.TypeReg,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type (
+	// BundleImpact is the impact of applying each member of a bundle, in
+	// the order the members were applied.
+	BundleImpact struct {
+		Name    string
+		Version string
+		Members []BundleMemberImpact
+	}
+
+	// BundleMemberImpact is the impact of applying a single bundle member.
+	BundleMemberImpact struct {
+		Member string
+		ImpactSummary
+	}
+)
+
+// ApplyBundle applies each member of the bundle to its own stack, in
+// dependency order. The stack for a member is named after the bundle and
+// member and is created on first apply. The provided options, e.g. env
+// references and secrets, are passed to every member. Applying stops at the
+// first member that fails; members applied before it are left in place.
+func ApplyBundle(ctx context.Context, svc SVC, orgID, userID platform.ID, bundle *Bundle, dryRun bool, opts ...ApplyOptFn) (BundleImpact, error) {
+	impact := BundleImpact{
+		Name:    bundle.Manifest.Name,
+		Version: bundle.Manifest.Version,
+	}
+
+	members, err := bundle.Manifest.ApplyOrder()
+	if err != nil {
+		return impact, err
+	}
+
+	for _, mem := range members {
+		template, ok := bundle.Templates[mem.Name]
+		if !ok {
+			return impact, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("bundle member %q has no template", mem.Name),
+			}
+		}
+
+		stackID, err := bundleMemberStack(ctx, svc, orgID, userID, bundle.Manifest, mem, dryRun)
+		if err != nil {
+			return impact, err
+		}
+
+		memberOpts := append([]ApplyOptFn{ApplyWithTemplate(template)}, opts...)
+		if stackID != 0 {
+			memberOpts = append(memberOpts, ApplyWithStackID(stackID))
+		}
+
+		var memberImpact ImpactSummary
+		if dryRun {
+			memberImpact, err = svc.DryRun(ctx, orgID, userID, memberOpts...)
+		} else {
+			memberImpact, err = svc.Apply(ctx, orgID, userID, memberOpts...)
+		}
+		impact.Members = append(impact.Members, BundleMemberImpact{
+			Member:        mem.Name,
+			ImpactSummary: memberImpact,
+		})
+		if err != nil {
+			return impact, &errors.Error{
+				Msg: fmt.Sprintf("failed to apply bundle member %q", mem.Name),
+				Err: err,
+			}
+		}
+	}
+	return impact, nil
+}
+
+// bundleMemberStack returns the id of the stack the member is applied to,
+// creating it if it does not exist. The stack is found by its name and the
+// source of the member it records, so stacks that happen to have the same
+// name are not reused. Dry runs never create stacks and return a zero id
+// for a member that has not been applied before.
+func bundleMemberStack(ctx context.Context, svc SVC, orgID, userID platform.ID, manifest BundleManifest, mem BundleMember, dryRun bool) (platform.ID, error) {
+	name := manifest.StackName(mem.Name)
+	source := manifest.source(mem)
+	stacks, err := svc.ListStacks(ctx, orgID, ListFilter{Names: []string{name}})
+	if err != nil {
+		return 0, err
+	}
+	for _, st := range stacks {
+		for _, src := range st.LatestEvent().Sources {
+			if src == source {
+				return st.ID, nil
+			}
+		}
+	}
+	if dryRun {
+		return 0, nil
+	}
+
+	desc := fmt.Sprintf("member %s of bundle %s", mem.Name, manifest.Name)
+	if manifest.Version != "" {
+		desc += "@" + manifest.Version
+	}
+	stack, err := svc.InitStack(ctx, userID, StackCreate{
+		OrgID:       orgID,
+		Name:        name,
+		Description: desc,
+		Sources:     []string{source},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return stack.ID, nil
+}
+
+// ExportBundle exports the resources of each member's stack into a bundle
+// described by the manifest. stackIDs maps member names to the stack the
+// member is exported from.
+func ExportBundle(ctx context.Context, svc SVC, manifest BundleManifest, stackIDs map[string]platform.ID) (*Bundle, error) {
+	if _, err := manifest.ApplyOrder(); err != nil {
+		return nil, err
+	}
+
+	bundle := &Bundle{
+		Manifest:  manifest,
+		Templates: make(map[string]*Template, len(manifest.Members)),
+	}
+	bundle.Manifest.Members = append([]BundleMember(nil), manifest.Members...)
+	for i, mem := range manifest.Members {
+		stackID, ok := stackIDs[mem.Name]
+		if !ok {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("no stack provided for bundle member %q", mem.Name),
+			}
+		}
+		template, err := svc.Export(ctx, ExportWithStackID(stackID))
+		if err != nil {
+			return nil, &errors.Error{
+				Msg: fmt.Sprintf("failed to export bundle member %q", mem.Name),
+				Err: err,
+			}
+		}
+		bundle.Templates[mem.Name] = template
+		bundle.Manifest.Members[i].Path = mem.path()
+	}
+	return bundle, nil
+}
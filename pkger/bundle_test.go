@@ -0,0 +1,262 @@
+package pkger_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/pkger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundleManifest_ApplyOrder(t *testing.T) {
+	t.Run("orders members after their dependencies", func(t *testing.T) {
+		manifest := pkger.BundleManifest{
+			Name: "monitoring",
+			Members: []pkger.BundleMember{
+				{Name: "alerts", DependsOn: []string{"infra", "dashboards"}},
+				{Name: "dashboards", DependsOn: []string{"infra"}},
+				{Name: "infra"},
+			},
+		}
+
+		members, err := manifest.ApplyOrder()
+		require.NoError(t, err)
+
+		var names []string
+		for _, m := range members {
+			names = append(names, m.Name)
+		}
+		assert.Equal(t, []string{"infra", "dashboards", "alerts"}, names)
+	})
+
+	tests := []struct {
+		name     string
+		manifest pkger.BundleManifest
+	}{
+		{
+			name:     "missing name",
+			manifest: pkger.BundleManifest{Members: []pkger.BundleMember{{Name: "infra"}}},
+		},
+		{
+			name:     "no members",
+			manifest: pkger.BundleManifest{Name: "monitoring"},
+		},
+		{
+			name: "duplicate member",
+			manifest: pkger.BundleManifest{
+				Name:    "monitoring",
+				Members: []pkger.BundleMember{{Name: "infra"}, {Name: "infra"}},
+			},
+		},
+		{
+			name: "unknown dependency",
+			manifest: pkger.BundleManifest{
+				Name:    "monitoring",
+				Members: []pkger.BundleMember{{Name: "alerts", DependsOn: []string{"infra"}}},
+			},
+		},
+		{
+			name: "dependency cycle",
+			manifest: pkger.BundleManifest{
+				Name: "monitoring",
+				Members: []pkger.BundleMember{
+					{Name: "alerts", DependsOn: []string{"dashboards"}},
+					{Name: "dashboards", DependsOn: []string{"alerts"}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.manifest.ApplyOrder()
+			require.Error(t, err)
+			assert.Equal(t, errors2.EInvalid, errors2.ErrorCode(err))
+		})
+	}
+}
+
+func TestBundle_EncodeParse(t *testing.T) {
+	bundle := newTestBundle(t)
+
+	for _, format := range []pkger.BundleFormat{pkger.BundleFormatTarGz, pkger.BundleFormatZip} {
+		t.Run(string(format), func(t *testing.T) {
+			b, err := bundle.Encode(format)
+			require.NoError(t, err)
+
+			parsed, err := pkger.ParseBundle(b)
+			require.NoError(t, err)
+
+			assert.Equal(t, bundle.Manifest.Name, parsed.Manifest.Name)
+			assert.Equal(t, bundle.Manifest.Version, parsed.Manifest.Version)
+			require.Len(t, parsed.Manifest.Members, 2)
+			assert.Equal(t, "infra.yml", parsed.Manifest.Members[1].Path)
+
+			buckets := parsed.Templates["infra"].Summary().Buckets
+			require.Len(t, buckets, 1)
+			assert.Equal(t, "rucket-1", buckets[0].Name)
+		})
+	}
+
+	t.Run("oversized files", func(t *testing.T) {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		size := int64(17 << 20)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "infra.yml", Mode: 0644, Size: size, Typeflag: tar.TypeReg}))
+		_, err := io.CopyN(tw, zeros{}, size)
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+
+		_, err = pkger.ParseBundle(buf.Bytes())
+		require.Error(t, err)
+		assert.Equal(t, errors2.ETooLarge, errors2.ErrorCode(err))
+	})
+
+	t.Run("missing member template", func(t *testing.T) {
+		b, err := (&pkger.Bundle{
+			Manifest:  pkger.BundleManifest{Name: "monitoring", Members: []pkger.BundleMember{{Name: "infra"}}},
+			Templates: map[string]*pkger.Template{},
+		}).Encode(pkger.BundleFormatTarGz)
+		require.Error(t, err)
+		assert.Nil(t, b)
+	})
+}
+
+func TestApplyBundle(t *testing.T) {
+	orgID, userID := platform.ID(1), platform.ID(2)
+
+	stackOf := func(id platform.ID, sources ...string) pkger.Stack {
+		return pkger.Stack{ID: id, OrgID: orgID, Events: []pkger.StackEvent{{Sources: sources}}}
+	}
+
+	newSVC := func(existing map[string]pkger.Stack, applied *[]platform.ID, created *[]string) *fakeSVC {
+		nextID := platform.ID(100)
+		record := func(opts []pkger.ApplyOptFn) pkger.ImpactSummary {
+			var opt pkger.ApplyOpt
+			for _, o := range opts {
+				o(&opt)
+			}
+			*applied = append(*applied, opt.StackID)
+			return pkger.ImpactSummary{StackID: opt.StackID}
+		}
+		return &fakeSVC{
+			listStacksFn: func(ctx context.Context, _ platform.ID, f pkger.ListFilter) ([]pkger.Stack, error) {
+				if st, ok := existing[f.Names[0]]; ok {
+					return []pkger.Stack{st}, nil
+				}
+				return nil, nil
+			},
+			initStackFn: func(ctx context.Context, _ platform.ID, stack pkger.StackCreate) (pkger.Stack, error) {
+				*created = append(*created, stack.Name)
+				nextID++
+				existing[stack.Name] = stackOf(nextID, stack.Sources...)
+				return existing[stack.Name], nil
+			},
+			applyFn: func(ctx context.Context, _, _ platform.ID, opts ...pkger.ApplyOptFn) (pkger.ImpactSummary, error) {
+				return record(opts), nil
+			},
+			dryRunFn: func(ctx context.Context, _, _ platform.ID, opts ...pkger.ApplyOptFn) (pkger.ImpactSummary, error) {
+				return record(opts), nil
+			},
+		}
+	}
+
+	t.Run("applies each member to its own stack in dependency order", func(t *testing.T) {
+		var (
+			applied []platform.ID
+			created []string
+		)
+		svc := newSVC(map[string]pkger.Stack{"monitoring/infra": stackOf(10, "monitoring/infra.yml")}, &applied, &created)
+
+		impact, err := pkger.ApplyBundle(context.Background(), svc, orgID, userID, newTestBundle(t), false)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"monitoring/dashboards"}, created)
+		assert.Equal(t, []platform.ID{10, 101}, applied)
+		require.Len(t, impact.Members, 2)
+		assert.Equal(t, "infra", impact.Members[0].Member)
+		assert.Equal(t, "dashboards", impact.Members[1].Member)
+		assert.Equal(t, "1.0.0", impact.Version)
+	})
+
+	t.Run("stacks of the same name not created by the bundle are not reused", func(t *testing.T) {
+		var (
+			applied []platform.ID
+			created []string
+		)
+		svc := newSVC(map[string]pkger.Stack{"monitoring/infra": stackOf(10, "https://example.com/infra.yml")}, &applied, &created)
+
+		_, err := pkger.ApplyBundle(context.Background(), svc, orgID, userID, newTestBundle(t), false)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"monitoring/infra", "monitoring/dashboards"}, created)
+		assert.Equal(t, []platform.ID{101, 102}, applied)
+	})
+
+	t.Run("dry run does not create stacks", func(t *testing.T) {
+		var (
+			applied []platform.ID
+			created []string
+		)
+		svc := newSVC(map[string]pkger.Stack{}, &applied, &created)
+
+		impact, err := pkger.ApplyBundle(context.Background(), svc, orgID, userID, newTestBundle(t), true)
+		require.NoError(t, err)
+
+		assert.Empty(t, created)
+		assert.Equal(t, []platform.ID{0, 0}, applied)
+		assert.Len(t, impact.Members, 2)
+	})
+}
+
+type zeros struct{}
+
+func (zeros) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
+
+func newTestBundle(t *testing.T) *pkger.Bundle {
+	t.Helper()
+
+	infra, err := pkger.Parse(pkger.EncodingYAML, pkger.FromString(`
+apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: rucket-1
+`))
+	require.NoError(t, err)
+
+	dashboards, err := pkger.Parse(pkger.EncodingYAML, pkger.FromString(`
+apiVersion: influxdata.com/v2alpha1
+kind: Dashboard
+metadata:
+  name: dash-1
+`))
+	require.NoError(t, err)
+
+	return &pkger.Bundle{
+		Manifest: pkger.BundleManifest{
+			Name:    "monitoring",
+			Version: "1.0.0",
+			Members: []pkger.BundleMember{
+				{Name: "dashboards", Path: "dashboards.yml", DependsOn: []string{"infra"}},
+				{Name: "infra", Path: "infra.yml"},
+			},
+		},
+		Templates: map[string]*pkger.Template{
+			"infra":      infra,
+			"dashboards": dashboards,
+		},
+	}
+}
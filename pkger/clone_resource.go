@@ -16,7 +16,9 @@ import (
 	"github.com/influxdata/influxdb/v2/notification/endpoint"
 	"github.com/influxdata/influxdb/v2/notification/rule"
 	"github.com/influxdata/influxdb/v2/pkger/internal/wordplay"
+	"github.com/influxdata/influxdb/v2/query/fluxlang"
 	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/influxdata/influxdb/v2/task/options"
 	"github.com/influxdata/influxdb/v2/task/taskmodel"
 )
 
@@ -722,6 +724,8 @@ func convertCellView(cell influxdb.Cell) chart {
 		Width:  int(cell.W),
 		XPos:   int(cell.X),
 		YPos:   int(cell.Y),
+
+		TimeRange: cell.TimeRange,
 	}
 
 	setCommon := func(k chartKind, iColors []influxdb.ViewColor, dec influxdb.DecimalPlaces, iQueries []influxdb.DashboardQuery) {
@@ -1068,6 +1072,10 @@ func convertChartToResource(ch chart) Resource {
 		r[fieldChartTableOptions] = tRes
 	}
 
+	if ch.TimeRange != nil {
+		r[fieldChartTimeRange] = timeRangeToResource(*ch.TimeRange)
+	}
+
 	if len(ch.FieldOptions) > 0 {
 		fieldOpts := make([]Resource, 0, len(ch.FieldOptions))
 		for _, fo := range ch.FieldOptions {
@@ -1236,9 +1244,22 @@ func DashboardToObject(name string, dash influxdb.Dashboard) Object {
 		fieldDescription: dash.Description,
 	})
 	o.Spec[fieldDashCharts] = charts
+	if dash.TimeRange != nil {
+		o.Spec[fieldDashTimeRange] = timeRangeToResource(*dash.TimeRange)
+	}
 	return o
 }
 
+func timeRangeToResource(tr influxdb.TimeRangeOverride) Resource {
+	r := make(Resource)
+	assignNonZeroStrings(r, map[string]string{
+		fieldTimeRangeLower:   tr.Lower,
+		fieldTimeRangeUpper:   tr.Upper,
+		fieldTimeRangeRefresh: tr.Refresh,
+	})
+	return r
+}
+
 // LabelToObject converts an influxdb.Label to an Object.
 func LabelToObject(name string, l influxdb.Label) Object {
 	if name == "" {
@@ -1271,7 +1292,7 @@ func NotificationEndpointToObject(name string, e influxdb.NotificationEndpoint)
 		o.Spec[fieldNotificationEndpointHTTPMethod] = actual.Method
 		o.Spec[fieldNotificationEndpointURL] = actual.URL
 		o.Spec[fieldType] = actual.AuthMethod
-		assignNonZeroSecrets(o.Spec, map[string]influxdb.SecretField{
+		assignNonZeroSecrets(o.Spec, name, map[string]influxdb.SecretField{
 			fieldNotificationEndpointPassword: actual.Password,
 			fieldNotificationEndpointToken:    actual.Token,
 			fieldNotificationEndpointUsername: actual.Username,
@@ -1279,13 +1300,13 @@ func NotificationEndpointToObject(name string, e influxdb.NotificationEndpoint)
 	case *endpoint.PagerDuty:
 		o.Kind = KindNotificationEndpointPagerDuty
 		o.Spec[fieldNotificationEndpointURL] = actual.ClientURL
-		assignNonZeroSecrets(o.Spec, map[string]influxdb.SecretField{
+		assignNonZeroSecrets(o.Spec, name, map[string]influxdb.SecretField{
 			fieldNotificationEndpointRoutingKey: actual.RoutingKey,
 		})
 	case *endpoint.Slack:
 		o.Kind = KindNotificationEndpointSlack
 		o.Spec[fieldNotificationEndpointURL] = actual.URL
-		assignNonZeroSecrets(o.Spec, map[string]influxdb.SecretField{
+		assignNonZeroSecrets(o.Spec, name, map[string]influxdb.SecretField{
 			fieldNotificationEndpointToken: actual.Token,
 		})
 	}
@@ -1364,17 +1385,86 @@ func TaskToObject(name string, t taskmodel.Task) Object {
 
 	query := strings.TrimSpace(taskFluxRegex.ReplaceAllString(t.Flux, ""))
 
+	// the schedule is exported as written in the task's options, a cron
+	// stays a cron and every and offset keep their units
+	cron, every, offset := t.Cron, t.Every, durToStr(t.Offset)
+	if s, ok := taskFluxSchedule(t.Flux); ok && (s.cron != "" || s.every != "") {
+		cron, every, offset = s.cron, s.every, s.offset
+	}
+
 	o := newObject(KindTask, name)
 	assignNonZeroStrings(o.Spec, map[string]string{
-		fieldTaskCron:    t.Cron,
+		fieldTaskCron:    cron,
 		fieldDescription: t.Description,
-		fieldEvery:       t.Every,
-		fieldOffset:      durToStr(t.Offset),
+		fieldEvery:       every,
+		fieldOffset:      offset,
 		fieldQuery:       strings.TrimSpace(query),
+		fieldStatus:      t.Status,
 	})
+
+	// only options that differ from the defaults are exported
+	concurrency, retry := taskFluxOptions(t.Flux)
+	if concurrency != taskDefaultConcurrency {
+		o.Spec[fieldTaskConcurrency] = int(concurrency)
+	}
+	if retry != taskDefaultRetry {
+		o.Spec[fieldTaskRetry] = int(retry)
+	}
+
+	// secrets read by the query are listed as placeholders so the secrets
+	// to provision are known before the template is applied
+	var secrets []Resource
+	for _, key := range querySecretKeys(t.Flux) {
+		secrets = append(secrets, secretRefResource(key))
+	}
+	if len(secrets) > 0 {
+		o.Spec[fieldTaskSecrets] = secrets
+	}
 	return o
 }
 
+// taskSchedule is the schedule of a task as written in its options.
+type taskSchedule struct {
+	cron   string
+	every  string
+	offset string
+}
+
+// taskFluxSchedule returns the schedule set in the task's flux, and false
+// when its options cannot be read.
+func taskFluxSchedule(flux string) (taskSchedule, bool) {
+	opts, err := options.FromScriptAST(fluxlang.DefaultService, flux)
+	if err != nil {
+		return taskSchedule{}, false
+	}
+	s := taskSchedule{cron: opts.Cron}
+	if !opts.Every.IsZero() {
+		s.every = opts.Every.String()
+	}
+	if opts.Offset != nil && !opts.Offset.IsZero() {
+		s.offset = opts.Offset.String()
+	}
+	return s, true
+}
+
+// taskFluxOptions returns the concurrency and retry options set in the
+// task's flux, or their defaults when unset.
+func taskFluxOptions(flux string) (concurrency, retry int64) {
+	concurrency, retry = taskDefaultConcurrency, taskDefaultRetry
+
+	opts, err := options.FromScriptAST(fluxlang.DefaultService, flux)
+	if err != nil {
+		return concurrency, retry
+	}
+	if opts.Concurrency != nil {
+		concurrency = *opts.Concurrency
+	}
+	if opts.Retry != nil {
+		retry = *opts.Retry
+	}
+	return concurrency, retry
+}
+
 // TelegrafToObject converts an influxdb.TelegrafConfig into a pkger.Object.
 func TelegrafToObject(name string, t influxdb.TelegrafConfig) Object {
 	if name == "" {
@@ -1491,16 +1581,27 @@ func assignNonZeroStrings(r Resource, m map[string]string) {
 	}
 }
 
-func assignNonZeroSecrets(r Resource, m map[string]influxdb.SecretField) {
+// assignNonZeroSecrets assigns secret references for the secret fields. A
+// secret with a value but without a key gets a placeholder key from the
+// prefix and the field.
+func assignNonZeroSecrets(r Resource, prefix string, m map[string]influxdb.SecretField) {
 	for field, secret := range m {
-		if secret.Key == "" {
-			continue
+		key := secret.Key
+		if key == "" && secret.Value != nil {
+			key = prefix + "-" + field
 		}
-		r[field] = Resource{
-			fieldReferencesSecret: Resource{
-				fieldKey: secret.Key,
-			},
+		if key == "" {
+			continue
 		}
+		r[field] = secretRefResource(key)
+	}
+}
+
+func secretRefResource(key string) Resource {
+	return Resource{
+		fieldReferencesSecret: Resource{
+			fieldKey: key,
+		},
 	}
 }
 
@@ -0,0 +1,189 @@
+package pkger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// DiffFormat is the format a Diff is rendered in for humans.
+type DiffFormat string
+
+// Formats a Diff can be rendered in.
+const (
+	DiffFormatMarkdown DiffFormat = "markdown"
+	DiffFormatText     DiffFormat = "text"
+)
+
+// ContentType returns the HTTP content type of the rendered format.
+func (f DiffFormat) ContentType() string {
+	if f == DiffFormatMarkdown {
+		return "text/markdown; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+type diffRenderEntry struct {
+	id      DiffIdentifier
+	changes []DiffField
+	// compared is set when the changes were computed against the existing
+	// resource.
+	compared bool
+}
+
+type diffRenderGroup struct {
+	title   string
+	entries []diffRenderEntry
+}
+
+// newDiffRenderEntry builds the entry of a resource from its old value, a
+// pointer that is nil when there is no existing resource, and its new value.
+func newDiffRenderEntry(id DiffIdentifier, old, new interface{}, changes []DiffField) diffRenderEntry {
+	v := reflect.ValueOf(old)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			old = nil
+		} else {
+			old = v.Elem().Interface()
+		}
+	}
+
+	compared := old != nil
+	if changes == nil && id.StateStatus == StateStatusExists && old != nil {
+		changes = diffFields(old, new)
+	}
+	return diffRenderEntry{id: id, changes: changes, compared: compared}
+}
+
+// change returns the change applying the diff makes to the resource. An
+// existing resource without an old value to compare against is reported as
+// updated, as the apply updates it.
+func (e diffRenderEntry) change() ResourceChange {
+	switch {
+	case IsNew(e.id.StateStatus):
+		return ResourceChangeCreated
+	case IsRemoval(e.id.StateStatus):
+		return ResourceChangeRemoved
+	case len(e.changes) > 0 || !e.compared:
+		return ResourceChangeUpdated
+	}
+	return ResourceChangeUnchanged
+}
+
+func (d Diff) renderGroups() []diffRenderGroup {
+	var buckets, checks, dashboards, labels, endpoints, rules, tasks, telegrafs, variables []diffRenderEntry
+	for _, r := range d.Buckets {
+		buckets = append(buckets, newDiffRenderEntry(r.DiffIdentifier, r.Old, r.New, nil))
+	}
+	for _, r := range d.Checks {
+		checks = append(checks, newDiffRenderEntry(r.DiffIdentifier, r.Old, r.New, nil))
+	}
+	for _, r := range d.Dashboards {
+		dashboards = append(dashboards, newDiffRenderEntry(r.DiffIdentifier, r.Old, r.New, r.Changes))
+	}
+	for _, r := range d.Labels {
+		labels = append(labels, newDiffRenderEntry(r.DiffIdentifier, r.Old, r.New, nil))
+	}
+	for _, r := range d.NotificationEndpoints {
+		endpoints = append(endpoints, newDiffRenderEntry(r.DiffIdentifier, r.Old, r.New, nil))
+	}
+	for _, r := range d.NotificationRules {
+		rules = append(rules, newDiffRenderEntry(r.DiffIdentifier, r.Old, r.New, nil))
+	}
+	for _, r := range d.Tasks {
+		tasks = append(tasks, newDiffRenderEntry(r.DiffIdentifier, r.Old, r.New, r.Changes))
+	}
+	for _, r := range d.Telegrafs {
+		telegrafs = append(telegrafs, newDiffRenderEntry(r.DiffIdentifier, r.Old, r.New, nil))
+	}
+	for _, r := range d.Variables {
+		variables = append(variables, newDiffRenderEntry(r.DiffIdentifier, r.Old, r.New, nil))
+	}
+
+	var mappings []diffRenderEntry
+	for _, m := range d.LabelMappings {
+		mappings = append(mappings, diffRenderEntry{
+			id: DiffIdentifier{
+				StateStatus: m.StateStatus,
+				MetaName:    fmt.Sprintf("%s %s -> label %s", m.ResType, m.ResMetaName, m.LabelMetaName),
+			},
+		})
+	}
+
+	return []diffRenderGroup{
+		{title: "Buckets", entries: buckets},
+		{title: "Checks", entries: checks},
+		{title: "Dashboards", entries: dashboards},
+		{title: "Labels", entries: labels},
+		{title: "Label Mappings", entries: mappings},
+		{title: "Notification Endpoints", entries: endpoints},
+		{title: "Notification Rules", entries: rules},
+		{title: "Tasks", entries: tasks},
+		{title: "Telegraf Configs", entries: telegrafs},
+		{title: "Variables", entries: variables},
+	}
+}
+
+// Render writes a human readable changelog of the diff, in the style of a
+// unified diff. New resources are prefixed with a +, removed resources with
+// a - and resources with changed fields are followed by the old and new value
+// of each field. Resources without any changes are only counted.
+func (d Diff) Render(w io.Writer, format DiffFormat) error {
+	var buf bytes.Buffer
+	for _, g := range d.renderGroups() {
+		if len(g.entries) == 0 {
+			continue
+		}
+
+		var lines bytes.Buffer
+		var unchanged int
+		for _, e := range g.entries {
+			switch {
+			case e.id.StateStatus == StateStatusNew:
+				fmt.Fprintf(&lines, "+ %s\n", e.id.MetaName)
+			case e.id.StateStatus == StateStatusRemove:
+				fmt.Fprintf(&lines, "- %s\n", e.id.MetaName)
+			case len(e.changes) > 0:
+				fmt.Fprintf(&lines, "  %s\n", e.id.MetaName)
+				for _, c := range e.changes {
+					if c.Old != nil {
+						fmt.Fprintf(&lines, "-   %s: %s\n", c.Path, renderDiffValue(c.Old))
+					}
+					if c.New != nil {
+						fmt.Fprintf(&lines, "+   %s: %s\n", c.Path, renderDiffValue(c.New))
+					}
+				}
+			default:
+				unchanged++
+			}
+		}
+		if unchanged > 0 {
+			fmt.Fprintf(&lines, "  (%d unchanged)\n", unchanged)
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		if format == DiffFormatMarkdown {
+			fmt.Fprintf(&buf, "## %s\n\n```diff\n%s```\n", g.title, lines.String())
+		} else {
+			fmt.Fprintf(&buf, "%s:\n%s", g.title, lines.String())
+		}
+	}
+
+	if buf.Len() == 0 {
+		buf.WriteString("No changes.\n")
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func renderDiffValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
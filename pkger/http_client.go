@@ -0,0 +1,299 @@
+package pkger
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	fluxurl "github.com/influxdata/flux/dependencies/url"
+)
+
+type (
+	// HTTPClientOptFn configures the HTTP client remote templates are fetched with.
+	HTTPClientOptFn func(opt *httpClientOpt)
+
+	httpClientOpt struct {
+		proxy         *url.URL
+		rootCAs       *x509.CertPool
+		certificates  []tls.Certificate
+		hostHeaders   map[string]http.Header
+		checkRedirect func(req *http.Request, via []*http.Request) error
+	}
+)
+
+// WithHTTPClientProxy sends all requests through the proxy. The proxy itself
+// is trusted; the addresses the requested hosts resolve to are validated
+// instead, and the proxy is asked to connect to those addresses rather than
+// resolving the hosts again.
+func WithHTTPClientProxy(proxy *url.URL) HTTPClientOptFn {
+	return func(opt *httpClientOpt) {
+		opt.proxy = proxy
+	}
+}
+
+// WithHTTPClientRootCAs sets the root certificate authorities the servers
+// are verified with, in place of the system pool.
+func WithHTTPClientRootCAs(pool *x509.CertPool) HTTPClientOptFn {
+	return func(opt *httpClientOpt) {
+		opt.rootCAs = pool
+	}
+}
+
+// WithHTTPClientCertificates sets the client certificates presented to
+// servers requiring mutual TLS.
+func WithHTTPClientCertificates(certs ...tls.Certificate) HTTPClientOptFn {
+	return func(opt *httpClientOpt) {
+		opt.certificates = append(opt.certificates, certs...)
+	}
+}
+
+// WithHTTPClientHostHeader sets a header, e.g. Authorization, on every request
+// to the host. The header is not sent to other hosts, including those
+// redirected to.
+func WithHTTPClientHostHeader(host, key, value string) HTTPClientOptFn {
+	return func(opt *httpClientOpt) {
+		if opt.hostHeaders == nil {
+			opt.hostHeaders = make(map[string]http.Header)
+		}
+		if opt.hostHeaders[host] == nil {
+			opt.hostHeaders[host] = make(http.Header)
+		}
+		opt.hostHeaders[host].Add(key, value)
+	}
+}
+
+// WithHTTPClientRedirectPolicy sets the policy redirects are followed with,
+// see http.Client.CheckRedirect.
+func WithHTTPClientRedirectPolicy(fn func(req *http.Request, via []*http.Request) error) HTTPClientOptFn {
+	return func(opt *httpClientOpt) {
+		opt.checkRedirect = fn
+	}
+}
+
+// MaxRedirects is a redirect policy following at most n redirects. With n
+// of 0, redirects are not followed.
+func MaxRedirects(n int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > n {
+			return fmt.Errorf("stopped after %d redirects", n)
+		}
+		return nil
+	}
+}
+
+// NewDefaultHTTPClient creates a client with the specified flux IP validator.
+// This is copied from flux/dependencies/http/http.go
+func NewDefaultHTTPClient(urlValidator fluxurl.Validator, opts ...HTTPClientOptFn) *http.Client {
+	var opt httpClientOpt
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	// Control is called after DNS lookup, but before the network
+	// connection is initiated.
+	control := func(network, address string, c syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+
+		ip := net.ParseIP(host)
+		return urlValidator.ValidateIP(ip)
+	}
+
+	dialer := &net.Dialer{
+		Timeout: time.Minute,
+		// DualStack is deprecated
+	}
+
+	transport := &http.Transport{
+		DialContext: dialer.DialContext,
+	}
+	if opt.proxy != nil {
+		// plain HTTP requests are forwarded by the proxy to the address
+		// pinned by the remoteTransport, HTTPS requests are tunneled by
+		// the proxyDialer to the address it validated
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if req.URL.Scheme == "http" {
+				return opt.proxy, nil
+			}
+			return nil, nil
+		}
+		transport.DialContext = (&proxyDialer{
+			proxy:     opt.proxy,
+			dialer:    dialer,
+			validator: urlValidator,
+		}).DialContext
+	} else {
+		dialer.Control = control
+	}
+	if opt.rootCAs != nil || len(opt.certificates) > 0 {
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:      opt.rootCAs,
+			Certificates: opt.certificates,
+		}
+	}
+
+	var rt http.RoundTripper = transport
+	if opt.proxy != nil || len(opt.hostHeaders) > 0 {
+		remote := &remoteTransport{
+			RoundTripper: transport,
+			hostHeaders:  opt.hostHeaders,
+		}
+		if opt.proxy != nil {
+			remote.validator = urlValidator
+		}
+		rt = remote
+	}
+
+	return &http.Client{
+		Transport:     rt,
+		CheckRedirect: opt.checkRedirect,
+	}
+}
+
+// remoteTransport sets the headers of the host requested and, when the
+// requests are forwarded by a proxy, pins plain HTTP requests to a validated
+// address of the host.
+type remoteTransport struct {
+	http.RoundTripper
+	hostHeaders map[string]http.Header
+	validator   fluxurl.Validator
+}
+
+func (t *remoteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers, hasHeaders := t.hostHeaders[req.URL.Hostname()]
+	pin := t.validator != nil && req.URL.Scheme == "http"
+	if !hasHeaders && !pin {
+		return t.RoundTripper.RoundTrip(req)
+	}
+
+	// a RoundTripper must not modify the request
+	req = req.Clone(req.Context())
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	if pin {
+		port := req.URL.Port()
+		if port == "" {
+			port = "80"
+		}
+		ip, err := resolveValidIP(req.Context(), t.validator, req.URL.Hostname())
+		if err != nil {
+			return nil, err
+		}
+		if req.Host == "" {
+			req.Host = req.URL.Host
+		}
+		req.URL.Host = net.JoinHostPort(ip.String(), port)
+	}
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// proxyDialer tunnels connections through the proxy with CONNECT requests
+// to validated addresses. Connections to the proxy itself are dialed
+// directly.
+type proxyDialer struct {
+	proxy     *url.URL
+	dialer    *net.Dialer
+	validator fluxurl.Validator
+}
+
+func (d *proxyDialer) proxyAddr() string {
+	port := d.proxy.Port()
+	if port == "" {
+		port = "80"
+		if d.proxy.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(d.proxy.Hostname(), port)
+}
+
+func (d *proxyDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	proxyAddr := d.proxyAddr()
+	if addr == proxyAddr {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ip, err := resolveValidIP(ctx, d.validator, host)
+	if err != nil {
+		return nil, err
+	}
+	target := net.JoinHostPort(ip.String(), port)
+
+	conn, err := d.dialer.DialContext(ctx, network, proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if d.proxy.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: d.proxy.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	connect := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: target},
+		Host:   target,
+		Header: make(http.Header),
+	}
+	if u := d.proxy.User; u != nil {
+		password, _ := u.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.Username() + ":" + password))
+		connect.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := connect.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connect)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused to connect to %s: %s", host, resp.Status)
+	}
+	return conn, nil
+}
+
+// resolveValidIP resolves the host and returns one of its addresses, provided
+// the validator accepts all of them.
+func resolveValidIP(ctx context.Context, validator fluxurl.Validator, host string) (net.IP, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	for _, addr := range addrs {
+		if err := validator.ValidateIP(addr.IP); err != nil {
+			return nil, err
+		}
+	}
+	return addrs[0].IP, nil
+}
@@ -0,0 +1,102 @@
+package pkger_test
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	fluxurl "github.com/influxdata/flux/dependencies/url"
+	"github.com/influxdata/influxdb/v2/pkger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDefaultHTTPClient(t *testing.T) {
+	t.Run("verifies servers with the root CAs", func(t *testing.T) {
+		svr := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer svr.Close()
+
+		_, err := pkger.NewDefaultHTTPClient(fluxurl.PassValidator{}).Get(svr.URL)
+		require.Error(t, err, "server certificate is not trusted by default")
+
+		pool := x509.NewCertPool()
+		pool.AddCert(svr.Certificate())
+		client := pkger.NewDefaultHTTPClient(fluxurl.PassValidator{}, pkger.WithHTTPClientRootCAs(pool))
+
+		resp, err := client.Get(svr.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("sends host headers only to the host", func(t *testing.T) {
+		var gotAuth []string
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		}))
+		defer other.Close()
+
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+			http.Redirect(w, r, other.URL, http.StatusFound)
+		}))
+		defer svr.Close()
+
+		// both servers listen on 127.0.0.1, so address the first by name
+		svrURL, err := url.Parse(svr.URL)
+		require.NoError(t, err)
+		svrURL.Host = "localhost:" + svrURL.Port()
+
+		client := pkger.NewDefaultHTTPClient(fluxurl.PassValidator{},
+			pkger.WithHTTPClientHostHeader("localhost", "Authorization", "Bearer abc"),
+		)
+
+		resp, err := client.Get(svrURL.String())
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, []string{"Bearer abc", ""}, gotAuth)
+	})
+
+	t.Run("applies the redirect policy", func(t *testing.T) {
+		var svr *httptest.Server
+		svr = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, svr.URL, http.StatusFound)
+		}))
+		defer svr.Close()
+
+		client := pkger.NewDefaultHTTPClient(fluxurl.PassValidator{},
+			pkger.WithHTTPClientRedirectPolicy(pkger.MaxRedirects(2)),
+		)
+
+		_, err := client.Get(svr.URL)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "stopped after 2 redirects")
+	})
+
+	t.Run("validates the addresses requested through the proxy", func(t *testing.T) {
+		var requested []string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = append(requested, r.Method+" "+r.URL.Host+" "+r.Host)
+		}))
+		defer proxy.Close()
+		proxyURL, err := url.Parse(proxy.URL)
+		require.NoError(t, err)
+
+		for _, target := range []string{"http://localhost:8080/template.yml", "https://localhost/template.yml"} {
+			client := pkger.NewDefaultHTTPClient(fluxurl.PrivateIPValidator{}, pkger.WithHTTPClientProxy(proxyURL))
+			_, err := client.Get(target)
+			require.Error(t, err, target)
+		}
+		assert.Empty(t, requested, "private addresses are refused before the proxy is asked")
+
+		client := pkger.NewDefaultHTTPClient(fluxurl.PassValidator{}, pkger.WithHTTPClientProxy(proxyURL))
+		resp, err := client.Get("http://localhost:8080/template.yml")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Len(t, requested, 1)
+		assert.Regexp(t, `^GET (127\.0\.0\.1|\[::1\]):8080 localhost:8080$`, requested[0], "proxy is asked for the resolved address")
+	})
+}
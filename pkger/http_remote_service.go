@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"sort"
 
 	ihttp "github.com/influxdata/influxdb/v2/http"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/pkg/httpc"
 )
 
@@ -115,9 +117,13 @@ func (s *HTTPRemoteService) UpdateStack(ctx context.Context, upd StackUpdate) (S
 		})
 	}
 
+	req := s.Client.PatchJSON(reqBody, RoutePrefixStacks, upd.ID.String())
+	if upd.IfUpdatedAt != nil {
+		req = req.Header("If-Match", kithttp.VersionETag(*upd.IfUpdatedAt))
+	}
+
 	var respBody RespStack
-	err := s.Client.
-		PatchJSON(reqBody, RoutePrefixStacks, upd.ID.String()).
+	err := req.
 		DecodeJSON(&respBody).
 		Do(ctx)
 	if err != nil {
@@ -127,6 +133,78 @@ func (s *HTTPRemoteService) UpdateStack(ctx context.Context, upd StackUpdate) (S
 	return convertRespStackToStack(respBody)
 }
 
+func (s *HTTPRemoteService) CheckStackUpdates(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackUpdateCheck, error) {
+	var respBody RespStackUpdateCheck
+	err := s.Client.
+		Get(RoutePrefixStacks, identifiers.StackID.String(), "/updates").
+		QueryParams([2]string{"orgID", identifiers.OrgID.String()}).
+		DecodeJSON(&respBody).
+		Do(ctx)
+	if err != nil {
+		return StackUpdateCheck{}, err
+	}
+
+	check := StackUpdateCheck{
+		StackID:         identifiers.StackID,
+		UpdateAvailable: respBody.UpdateAvailable,
+		Diff:            respBody.Diff,
+	}
+	for _, src := range respBody.Sources {
+		check.Sources = append(check.Sources, StackSourceUpdate(src))
+	}
+	return check, nil
+}
+
+func (s *HTTPRemoteService) CheckStackDrift(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackDrift, error) {
+	var respBody RespStackDrift
+	err := s.Client.
+		Get(RoutePrefixStacks, identifiers.StackID.String(), "/drift").
+		QueryParams([2]string{"orgID", identifiers.OrgID.String()}).
+		DecodeJSON(&respBody).
+		Do(ctx)
+	if err != nil {
+		return StackDrift{}, err
+	}
+
+	return StackDrift{
+		StackID: identifiers.StackID,
+		Checked: respBody.Checked,
+		Drifted: respBody.Drifted,
+		Diff:    respBody.Diff,
+	}, nil
+}
+
+func (s *HTTPRemoteService) ReadStackJournal(ctx context.Context, stackID platform.ID) ([]StackJournalEntry, error) {
+	var respBody RespStackJournal
+	err := s.Client.
+		Get(RoutePrefixStacks, stackID.String(), "/events").
+		DecodeJSON(&respBody).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]StackJournalEntry, 0, len(respBody.Events))
+	for _, e := range respBody.Events {
+		entry := StackJournalEntry{
+			StackID: stackID,
+			Action:  e.Action,
+			Sources: e.Sources,
+			Impact:  e.Impact,
+			Error:   e.Error,
+			Time:    e.Time,
+		}
+		// entries recorded without a user have no user ID
+		if e.UserID != "" {
+			if err := entry.UserID.DecodeFromString(e.UserID); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
 // Export will produce a template from the parameters provided.
 func (s *HTTPRemoteService) Export(ctx context.Context, opts ...ExportOptFn) (*Template, error) {
 	opt, err := exportOptFromOptFns(opts)
@@ -207,6 +285,10 @@ func (s *HTTPRemoteService) apply(ctx context.Context, orgID platform.ID, dryRun
 		EnvRefs:     opt.EnvRefs,
 		Secrets:     opt.MissingSecrets,
 		RawTemplate: rawTemplate,
+
+		IdempotencyKey:   opt.IdempotencyKey,
+		MergeStrategy:    opt.MergeStrategy,
+		ConflictPolicies: opt.ConflictPolicies,
 	}
 	if opt.StackID != 0 {
 		stackID := opt.StackID.String()
@@ -223,6 +305,36 @@ func (s *HTTPRemoteService) apply(ctx context.Context, orgID platform.ID, dryRun
 			Properties: b,
 		})
 	}
+	for act := range opt.ResourcesToDetach {
+		b, err := json.Marshal(act)
+		if err != nil {
+			return ImpactSummary{}, influxErr(errors.EInvalid, err)
+		}
+		reqBody.RawActions = append(reqBody.RawActions, ReqRawAction{
+			Action:     string(ActionTypeDetachResource),
+			Properties: b,
+		})
+	}
+	for act := range opt.ResourcesToRemove {
+		b, err := json.Marshal(act)
+		if err != nil {
+			return ImpactSummary{}, influxErr(errors.EInvalid, err)
+		}
+		reqBody.RawActions = append(reqBody.RawActions, ReqRawAction{
+			Action:     string(ActionTypeRemoveResource),
+			Properties: b,
+		})
+	}
+	if len(opt.KindsToApply) > 0 {
+		for kind := range kinds {
+			if !opt.KindsToApply[normalizeActionKind(kind)] {
+				reqBody.SkipKinds = append(reqBody.SkipKinds, kind)
+			}
+		}
+		sort.Slice(reqBody.SkipKinds, func(i, j int) bool {
+			return reqBody.SkipKinds[i] < reqBody.SkipKinds[j]
+		})
+	}
 	for kind := range opt.KindsToSkip {
 		b, err := json.Marshal(ActionSkipKind{Kind: kind})
 		if err != nil {
@@ -253,9 +365,16 @@ func (s *HTTPRemoteService) apply(ctx context.Context, orgID platform.ID, dryRun
 	}
 
 	impact := ImpactSummary{
-		Sources: resp.Sources,
-		Diff:    resp.Diff,
-		Summary: resp.Summary,
+		Sources:        resp.Sources,
+		Diff:           resp.Diff,
+		Summary:        resp.Summary,
+		Hooks:          resp.Hooks,
+		Authorizations: resp.Authorizations,
+		Resources:      resp.Resources,
+		NoOp:           resp.NoOp,
+	}
+	if resp.Timing != nil {
+		impact.Timing = *resp.Timing
 	}
 
 	if stackID, err := platform.IDFromString(resp.StackID); err == nil {
@@ -312,13 +431,14 @@ func convertRespStackEvent(ev RespStackEvent) (StackEvent, error) {
 	}
 
 	return StackEvent{
-		EventType:    eventType,
-		Name:         ev.Name,
-		Description:  ev.Description,
-		Resources:    res,
-		Sources:      ev.Sources,
-		TemplateURLs: ev.URLs,
-		UpdatedAt:    ev.UpdatedAt,
+		EventType:      eventType,
+		Name:           ev.Name,
+		Description:    ev.Description,
+		Resources:      res,
+		Sources:        ev.Sources,
+		SourceVersions: ev.SourceVersions,
+		TemplateURLs:   ev.URLs,
+		UpdatedAt:      ev.UpdatedAt,
 	}, nil
 }
 
@@ -43,6 +43,9 @@ func NewHTTPServerStacks(log *zap.Logger, svc SVC) *HTTPServerStacks {
 			r.Delete("/", svr.deleteStack)
 			r.Patch("/", svr.updateStack)
 			r.Post("/uninstall", svr.uninstallStack)
+			r.Get("/updates", svr.checkStackUpdates)
+			r.Get("/drift", svr.checkStackDrift)
+			r.Get("/events", svr.readStackJournal)
 		})
 	}
 
@@ -68,13 +71,14 @@ type (
 	}
 
 	RespStackEvent struct {
-		EventType   string              `json:"eventType"`
-		Name        string              `json:"name"`
-		Description string              `json:"description"`
-		Resources   []RespStackResource `json:"resources"`
-		Sources     []string            `json:"sources"`
-		URLs        []string            `json:"urls"`
-		UpdatedAt   time.Time           `json:"updatedAt"`
+		EventType      string               `json:"eventType"`
+		Name           string               `json:"name"`
+		Description    string               `json:"description"`
+		Resources      []RespStackResource  `json:"resources"`
+		Sources        []string             `json:"sources"`
+		SourceVersions []StackSourceVersion `json:"sourceVersions,omitempty"`
+		URLs           []string             `json:"urls"`
+		UpdatedAt      time.Time            `json:"updatedAt"`
 	}
 
 	// RespStackResource is the response for a stack resource. This type exists
@@ -291,6 +295,165 @@ func (s *HTTPServerStacks) uninstallStack(w http.ResponseWriter, r *http.Request
 	s.api.Respond(w, r, http.StatusOK, convertStackToRespStack(stack))
 }
 
+// RespStackSourceUpdate is the response for a single source of a stack
+// update check.
+type RespStackSourceUpdate struct {
+	Current         StackSourceVersion  `json:"current"`
+	Latest          *StackSourceVersion `json:"latest,omitempty"`
+	Checked         bool                `json:"checked"`
+	UpdateAvailable bool                `json:"updateAvailable"`
+}
+
+// RespStackUpdateCheck is the response body for the stack updates endpoint.
+type RespStackUpdateCheck struct {
+	StackID         string                  `json:"stackID"`
+	UpdateAvailable bool                    `json:"updateAvailable"`
+	Sources         []RespStackSourceUpdate `json:"sources"`
+	Diff            *Diff                   `json:"diff,omitempty"`
+}
+
+func (s *HTTPServerStacks) checkStackUpdates(w http.ResponseWriter, r *http.Request) {
+	orgID, err := getRequiredOrgIDFromQuery(r.URL.Query())
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	stackID, err := stackIDFromReq(r)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	auth, err := pctx.GetAuthorizer(r.Context())
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	check, err := s.svc.CheckStackUpdates(r.Context(), struct{ OrgID, UserID, StackID platform.ID }{
+		OrgID:   orgID,
+		UserID:  auth.GetUserID(),
+		StackID: stackID,
+	})
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	resp := RespStackUpdateCheck{
+		StackID:         check.StackID.String(),
+		UpdateAvailable: check.UpdateAvailable,
+		Sources:         make([]RespStackSourceUpdate, 0, len(check.Sources)),
+		Diff:            check.Diff,
+	}
+	for _, src := range check.Sources {
+		resp.Sources = append(resp.Sources, RespStackSourceUpdate(src))
+	}
+	s.api.Respond(w, r, http.StatusOK, resp)
+}
+
+// RespStackDrift is the response body for the stack drift endpoint.
+type RespStackDrift struct {
+	StackID string `json:"stackID"`
+	Checked bool   `json:"checked"`
+	Drifted bool   `json:"drifted"`
+	Diff    *Diff  `json:"diff,omitempty"`
+}
+
+func (s *HTTPServerStacks) checkStackDrift(w http.ResponseWriter, r *http.Request) {
+	orgID, err := getRequiredOrgIDFromQuery(r.URL.Query())
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	stackID, err := stackIDFromReq(r)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	auth, err := pctx.GetAuthorizer(r.Context())
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	drift, err := s.svc.CheckStackDrift(r.Context(), struct{ OrgID, UserID, StackID platform.ID }{
+		OrgID:   orgID,
+		UserID:  auth.GetUserID(),
+		StackID: stackID,
+	})
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	s.api.Respond(w, r, http.StatusOK, RespStackDrift{
+		StackID: drift.StackID.String(),
+		Checked: drift.Checked,
+		Drifted: drift.Drifted,
+		Diff:    drift.Diff,
+	})
+}
+
+// RespStackJournalEntry is an apply, dry run or uninstall performed against a stack.
+type RespStackJournalEntry struct {
+	Action  StackJournalAction `json:"action"`
+	Status  ApplyEventStatus   `json:"status"`
+	Error   string             `json:"error,omitempty"`
+	UserID  string             `json:"userID"`
+	Sources []string           `json:"sources"`
+	Impact  StackJournalImpact `json:"impact"`
+	Time    time.Time          `json:"time"`
+}
+
+// RespStackJournal is the response body for the stack events endpoint.
+type RespStackJournal struct {
+	StackID string                  `json:"stackID"`
+	Events  []RespStackJournalEntry `json:"events"`
+}
+
+func (s *HTTPServerStacks) readStackJournal(w http.ResponseWriter, r *http.Request) {
+	stackID, err := stackIDFromReq(r)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	entries, err := s.svc.ReadStackJournal(r.Context(), stackID)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	resp := RespStackJournal{
+		StackID: stackID.String(),
+		Events:  make([]RespStackJournalEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		status := ApplyEventStatusSuccess
+		if e.Error != "" {
+			status = ApplyEventStatusFailed
+		}
+		sources := e.Sources
+		if sources == nil {
+			sources = []string{}
+		}
+		resp.Events = append(resp.Events, RespStackJournalEntry{
+			Action:  e.Action,
+			Status:  status,
+			Error:   e.Error,
+			UserID:  e.UserID.String(),
+			Sources: sources,
+			Impact:  e.Impact,
+			Time:    e.Time,
+		})
+	}
+	s.api.Respond(w, r, http.StatusOK, resp)
+}
+
 func (s *HTTPServerStacks) readStack(w http.ResponseWriter, r *http.Request) {
 	stackID, err := stackIDFromReq(r)
 	if err != nil {
@@ -304,6 +467,7 @@ func (s *HTTPServerStacks) readStack(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	kithttp.SetVersionETag(w, stack.LatestEvent().UpdatedAt)
 	s.api.Respond(w, r, http.StatusOK, convertStackToRespStack(stack))
 }
 
@@ -340,11 +504,18 @@ func (s *HTTPServerStacks) updateStack(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ifUpdatedAt, err := kithttp.IfMatchVersion(r)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
 	update := StackUpdate{
 		ID:           stackID,
 		Name:         req.Name,
 		Description:  req.Description,
 		TemplateURLs: append(req.TemplateURLs, req.URLs...),
+		IfUpdatedAt:  ifUpdatedAt,
 	}
 	for _, res := range req.AdditionalResources {
 		id, err := platform.IDFromString(res.ID)
@@ -366,6 +537,7 @@ func (s *HTTPServerStacks) updateStack(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	kithttp.SetVersionETag(w, stack.LatestEvent().UpdatedAt)
 	s.api.Respond(w, r, http.StatusOK, convertStackToRespStack(stack))
 }
 
@@ -402,19 +574,22 @@ func convertStackEvent(ev StackEvent) RespStackEvent {
 	}
 
 	return RespStackEvent{
-		EventType:   ev.EventType.String(),
-		Name:        ev.Name,
-		Description: ev.Description,
-		Resources:   resources,
-		Sources:     append([]string{}, ev.Sources...),
-		URLs:        append([]string{}, ev.TemplateURLs...),
-		UpdatedAt:   ev.UpdatedAt,
+		EventType:      ev.EventType.String(),
+		Name:           ev.Name,
+		Description:    ev.Description,
+		Resources:      resources,
+		Sources:        append([]string{}, ev.Sources...),
+		SourceVersions: ev.SourceVersions,
+		URLs:           append([]string{}, ev.TemplateURLs...),
+		UpdatedAt:      ev.UpdatedAt,
 	}
 }
 
 func stackResLinks(r StackResource) RespStackResourceLinks {
 	var linkResource string
 	switch r.Kind {
+	case KindAuthorization:
+		linkResource = "authorizations"
 	case KindBucket:
 		linkResource = "buckets"
 	case KindCheck, KindCheckDeadman, KindCheckThreshold:
@@ -14,6 +14,7 @@ import (
 
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
 	"github.com/influxdata/influxdb/v2/pkg/testttp"
 	"github.com/influxdata/influxdb/v2/pkger"
 	"github.com/stretchr/testify/assert"
@@ -1542,6 +1543,151 @@ func TestPkgerHTTPServerStacks(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("read stack events", func(t *testing.T) {
+		now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+		svc := &fakeSVC{
+			readStackJournalFn: func(ctx context.Context, stackID platform.ID) ([]pkger.StackJournalEntry, error) {
+				if stackID != 1 {
+					return nil, &errors2.Error{Code: errors2.ENotFound}
+				}
+				return []pkger.StackJournalEntry{
+					{
+						StackID: stackID,
+						Action:  pkger.StackJournalActionApply,
+						UserID:  9,
+						Sources: []string{"http://example.com/t.yml"},
+						Impact:  pkger.StackJournalImpact{Created: 1, Unchanged: 2},
+						Time:    now,
+					},
+					{
+						// recorded without a user
+						StackID: stackID,
+						Action:  pkger.StackJournalActionUninstall,
+						Error:   "failed to delete bucket",
+						Time:    now.Add(time.Minute),
+					},
+				}, nil
+			},
+		}
+		pkgHandler := pkger.NewHTTPServerStacks(zap.NewNop(), svc)
+		svr := newMountedHandler(pkgHandler, 1)
+
+		testttp.
+			Get(t, "/api/v2/stacks/"+platform.ID(1).String()+"/events").
+			Do(svr).
+			ExpectStatus(http.StatusOK).
+			ExpectBody(func(buf *bytes.Buffer) {
+				var resp pkger.RespStackJournal
+				decodeBody(t, buf, &resp)
+
+				assert.Equal(t, pkger.RespStackJournal{
+					StackID: platform.ID(1).String(),
+					Events: []pkger.RespStackJournalEntry{
+						{
+							Action:  pkger.StackJournalActionApply,
+							Status:  pkger.ApplyEventStatusSuccess,
+							UserID:  platform.ID(9).String(),
+							Sources: []string{"http://example.com/t.yml"},
+							Impact:  pkger.StackJournalImpact{Created: 1, Unchanged: 2},
+							Time:    now,
+						},
+						{
+							Action:  pkger.StackJournalActionUninstall,
+							Status:  pkger.ApplyEventStatusFailed,
+							Error:   "failed to delete bucket",
+							Sources: []string{},
+							Time:    now.Add(time.Minute),
+						},
+					},
+				}, resp)
+			})
+
+		testttp.
+			Get(t, "/api/v2/stacks/"+platform.ID(2).String()+"/events").
+			Do(svr).
+			ExpectStatus(http.StatusNotFound)
+
+		ts := httptest.NewServer(svr)
+		defer ts.Close()
+		client, err := httpc.New(httpc.WithAddr(ts.URL))
+		require.NoError(t, err)
+
+		entries, err := (&pkger.HTTPRemoteService{Client: client}).ReadStackJournal(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, platform.ID(9), entries[0].UserID)
+		assert.Zero(t, entries[1].UserID)
+	})
+
+	t.Run("check stack drift", func(t *testing.T) {
+		svc := &fakeSVC{
+			checkStackDriftFn: func(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (pkger.StackDrift, error) {
+				if identifiers.StackID != 1 {
+					return pkger.StackDrift{}, &errors2.Error{Code: errors2.ENotFound}
+				}
+				assert.Equal(t, platform.ID(3), identifiers.OrgID)
+				return pkger.StackDrift{
+					StackID: identifiers.StackID,
+					Checked: true,
+					Drifted: true,
+					Diff: &pkger.Diff{
+						Buckets: []pkger.DiffBucket{{
+							DiffIdentifier: pkger.DiffIdentifier{
+								Kind:        pkger.KindBucket,
+								MetaName:    "rucket-1",
+								StateStatus: pkger.StateStatusNew,
+							},
+						}},
+					},
+				}, nil
+			},
+		}
+		pkgHandler := pkger.NewHTTPServerStacks(zap.NewNop(), svc)
+		svr := newMountedHandler(pkgHandler, 1)
+
+		testttp.
+			Get(t, "/api/v2/stacks/"+platform.ID(1).String()+"/drift?orgID="+platform.ID(3).String()).
+			Do(svr).
+			ExpectStatus(http.StatusOK).
+			ExpectBody(func(buf *bytes.Buffer) {
+				var resp pkger.RespStackDrift
+				decodeBody(t, buf, &resp)
+
+				assert.Equal(t, platform.ID(1).String(), resp.StackID)
+				assert.True(t, resp.Checked)
+				assert.True(t, resp.Drifted)
+				require.NotNil(t, resp.Diff)
+				require.Len(t, resp.Diff.Buckets, 1)
+				assert.Equal(t, "rucket-1", resp.Diff.Buckets[0].MetaName)
+			})
+
+		testttp.
+			Get(t, "/api/v2/stacks/"+platform.ID(1).String()+"/drift").
+			Do(svr).
+			ExpectStatus(http.StatusBadRequest)
+
+		testttp.
+			Get(t, "/api/v2/stacks/"+platform.ID(2).String()+"/drift?orgID="+platform.ID(3).String()).
+			Do(svr).
+			ExpectStatus(http.StatusNotFound)
+
+		ts := httptest.NewServer(svr)
+		defer ts.Close()
+		client, err := httpc.New(httpc.WithAddr(ts.URL))
+		require.NoError(t, err)
+
+		drift, err := (&pkger.HTTPRemoteService{Client: client}).CheckStackDrift(context.Background(), struct{ OrgID, UserID, StackID platform.ID }{
+			OrgID:   3,
+			StackID: 1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, platform.ID(1), drift.StackID)
+		assert.True(t, drift.Checked)
+		assert.True(t, drift.Drifted)
+		require.NotNil(t, drift.Diff)
+		assert.False(t, drift.Diff.IsNoOp())
+	})
 }
 
 type fakeSVC struct {
@@ -1551,6 +1697,10 @@ type fakeSVC struct {
 	updateStackFn func(ctx context.Context, upd pkger.StackUpdate) (pkger.Stack, error)
 	dryRunFn      func(ctx context.Context, orgID, userID platform.ID, opts ...pkger.ApplyOptFn) (pkger.ImpactSummary, error)
 	applyFn       func(ctx context.Context, orgID, userID platform.ID, opts ...pkger.ApplyOptFn) (pkger.ImpactSummary, error)
+
+	checkStackUpdatesFn func(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (pkger.StackUpdateCheck, error)
+	checkStackDriftFn   func(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (pkger.StackDrift, error)
+	readStackJournalFn  func(ctx context.Context, stackID platform.ID) ([]pkger.StackJournalEntry, error)
 }
 
 var _ pkger.SVC = (*fakeSVC)(nil)
@@ -1591,6 +1741,27 @@ func (f *fakeSVC) UpdateStack(ctx context.Context, upd pkger.StackUpdate) (pkger
 	panic("not implemented")
 }
 
+func (f *fakeSVC) CheckStackUpdates(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (pkger.StackUpdateCheck, error) {
+	if f.checkStackUpdatesFn == nil {
+		panic("not implemented")
+	}
+	return f.checkStackUpdatesFn(ctx, identifiers)
+}
+
+func (f *fakeSVC) CheckStackDrift(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (pkger.StackDrift, error) {
+	if f.checkStackDriftFn == nil {
+		panic("not implemented")
+	}
+	return f.checkStackDriftFn(ctx, identifiers)
+}
+
+func (f *fakeSVC) ReadStackJournal(ctx context.Context, stackID platform.ID) ([]pkger.StackJournalEntry, error) {
+	if f.readStackJournalFn == nil {
+		panic("not implemented")
+	}
+	return f.readStackJournalFn(ctx, stackID)
+}
+
 func (f *fakeSVC) Export(ctx context.Context, setters ...pkger.ExportOptFn) (*pkger.Template, error) {
 	panic("not implemented")
 }
@@ -2,22 +2,30 @@ package pkger
 
 import (
 	"bytes"
+	"context"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
 	pctx "github.com/influxdata/influxdb/v2/context"
 	ierrors "github.com/influxdata/influxdb/v2/kit/errors"
+	"github.com/influxdata/influxdb/v2/kit/feature"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/pkg/jsonnet"
+	"github.com/influxdata/influxdb/v2/pkger/registry"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 )
@@ -31,15 +39,61 @@ type HTTPServerTemplates struct {
 	logger *zap.Logger
 	svc    SVC
 	client *http.Client
+
+	trustAnchors       []ed25519.PublicKey
+	jsonnetPermissions []influxdb.Permission
+	registry           *registry.Client
+	jobs               *applyJobs
+}
+
+// HTTPServerTemplatesOptFn is a functional option for configuring the templates http server.
+type HTTPServerTemplatesOptFn func(*HTTPServerTemplates)
+
+// WithTrustAnchors requires templates fetched from remote URLs to carry a detached
+// signature that verifies against one of the provided public keys. Unsigned or
+// tampered remote templates are rejected.
+func WithTrustAnchors(anchors ...ed25519.PublicKey) HTTPServerTemplatesOptFn {
+	return func(s *HTTPServerTemplates) {
+		s.trustAnchors = append(s.trustAnchors, anchors...)
+	}
+}
+
+// WithJsonnetPermissions allows requests authorized with all of the provided
+// permissions to apply templates written in jsonnet. Without it, jsonnet is
+// only accepted for orgs with the templateJsonnet feature flag enabled.
+func WithJsonnetPermissions(perms ...influxdb.Permission) HTTPServerTemplatesOptFn {
+	return func(s *HTTPServerTemplates) {
+		s.jsonnetPermissions = append(s.jsonnetPermissions, perms...)
+	}
+}
+
+// WithTemplateRegistry resolves remotes given as registry references, e.g.
+// influxdata/linux_system@v1.2.0, through the registry client.
+func WithTemplateRegistry(c *registry.Client) HTTPServerTemplatesOptFn {
+	return func(s *HTTPServerTemplates) {
+		s.registry = c
+	}
+}
+
+// WithApplyJobRetention sets how long the status of a finished asynchronous
+// apply may be retrieved for. Defaults to DefaultApplyJobRetention.
+func WithApplyJobRetention(d time.Duration) HTTPServerTemplatesOptFn {
+	return func(s *HTTPServerTemplates) {
+		s.jobs.retention = d
+	}
 }
 
 // NewHTTPServerTemplates constructs a new http server.
-func NewHTTPServerTemplates(log *zap.Logger, svc SVC, client *http.Client) *HTTPServerTemplates {
+func NewHTTPServerTemplates(log *zap.Logger, svc SVC, client *http.Client, opts ...HTTPServerTemplatesOptFn) *HTTPServerTemplates {
 	svr := &HTTPServerTemplates{
 		api:    kithttp.NewAPI(kithttp.WithLog(log)),
 		logger: log,
 		svc:    svc,
 		client: client,
+		jobs:   newApplyJobs(DefaultApplyJobRetention),
+	}
+	for _, o := range opts {
+		o(svr)
 	}
 
 	exportAllowContentTypes := middleware.AllowContentType("text/yml", "application/x-yaml", "application/json")
@@ -47,8 +101,13 @@ func NewHTTPServerTemplates(log *zap.Logger, svc SVC, client *http.Client) *HTTP
 
 	r := chi.NewRouter()
 	{
-		r.With(exportAllowContentTypes).Post("/export", svr.export)
+		r.With(exportAllowContentTypes, kithttp.Compress, kithttp.ConditionalGet).Post("/export", svr.export)
 		r.With(setJSONContentType).Post("/apply", svr.apply)
+		r.With(setJSONContentType).Post("/validate", svr.validate)
+		r.With(setJSONContentType).Get("/jobs/{id}", svr.getApplyJob)
+		r.With(setJSONContentType).Post("/bundles/apply", svr.applyBundle)
+		// bundles are compressed archives already
+		r.Post("/bundles/export", svr.exportBundle)
 	}
 
 	svr.Router = r
@@ -165,10 +224,19 @@ func (s *HTTPServerTemplates) export(w http.ResponseWriter, r *http.Request) {
 
 // ReqTemplateRemote provides a package via a remote (i.e. a gist). If content type is not
 // provided then the service will do its best to discern the content type of the
-// contents.
+// contents. When the server is configured with trust anchors, the remote must be
+// signed; the detached signature is read from Signature, or fetched from SignatureURL,
+// or fetched from the URL with the SignatureExt appended.
 type ReqTemplateRemote struct {
-	URL         string `json:"url" yaml:"url"`
-	ContentType string `json:"contentType" yaml:"contentType"`
+	URL          string `json:"url" yaml:"url"`
+	ContentType  string `json:"contentType" yaml:"contentType"`
+	Signature    string `json:"signature,omitempty" yaml:"signature,omitempty"`
+	SignatureURL string `json:"signatureURL,omitempty" yaml:"signatureURL,omitempty"`
+
+	// ExtVars and TLAVars are passed to the jsonnet VM as external variables
+	// and top-level arguments when jsonnet is enabled.
+	ExtVars map[string]string `json:"extVars,omitempty" yaml:"extVars,omitempty"`
+	TLAVars map[string]string `json:"tlaVars,omitempty" yaml:"tlaVars,omitempty"`
 }
 
 // Encoding returns the encoding type that corresponds to the given content type.
@@ -176,10 +244,43 @@ func (p ReqTemplateRemote) Encoding() Encoding {
 	return convertEncoding(p.ContentType, p.URL)
 }
 
+// readerFn returns the reader and encoding for the remote template. Registry
+// references are resolved to the URL of the referenced version when a
+// registry is configured.
+func (p ReqTemplateRemote) readerFn(ctx context.Context, client *http.Client, sources remoteSources) (ReaderFn, Encoding, error) {
+	if sources.registry == nil || !registry.IsReference(p.URL) {
+		readerFn := FromHTTPRequest(p.URL, client)
+		if len(sources.trustAnchors) > 0 {
+			readerFn = FromVerifiedHTTPRequest(p.URL, p.Signature, p.SignatureURL, client, sources.trustAnchors)
+		}
+		return readerFn, p.Encoding(), nil
+	}
+
+	ref, err := registry.ParseReference(p.URL)
+	if err != nil {
+		return nil, EncodingUnknown, err
+	}
+	v, err := sources.registry.Resolve(ctx, ref)
+	if err != nil {
+		return nil, EncodingUnknown, err
+	}
+
+	readerFn := FromRegistry(ctx, sources.registry, ref)
+	if len(sources.trustAnchors) > 0 {
+		readerFn = verifiedReaderFn(readerFn, v.URL, p.Signature, p.SignatureURL, client, sources.trustAnchors)
+	}
+	return readerFn, convertEncoding(p.ContentType, v.URL), nil
+}
+
 type ReqRawTemplate struct {
 	ContentType string          `json:"contentType" yaml:"contentType"`
 	Sources     []string        `json:"sources" yaml:"sources"`
 	Template    json.RawMessage `json:"contents" yaml:"contents"`
+
+	// ExtVars and TLAVars are passed to the jsonnet VM as external variables
+	// and top-level arguments when jsonnet is enabled.
+	ExtVars map[string]string `json:"extVars,omitempty" yaml:"extVars,omitempty"`
+	TLAVars map[string]string `json:"tlaVars,omitempty" yaml:"tlaVars,omitempty"`
 }
 
 func (p ReqRawTemplate) Encoding() Encoding {
@@ -210,17 +311,96 @@ type ReqApply struct {
 	EnvRefs map[string]interface{} `json:"envRefs"`
 	Secrets map[string]string      `json:"secrets"`
 
+	// SkipKinds lists the resource kinds in the templates that are not applied.
+	SkipKinds  []Kind         `json:"skipKinds" yaml:"skipKinds"`
 	RawActions []ReqRawAction `json:"actions"`
+
+	// IdempotencyKey identifies the apply so a retried request returns the
+	// result of the original apply. It may also be provided with the
+	// Idempotency-Key header.
+	IdempotencyKey string `json:"idempotencyKey,omitempty" yaml:"idempotencyKey,omitempty"`
+
+	// MergeStrategy resolves resources defined by more than one of the
+	// templates. Defaults to failing the apply.
+	MergeStrategy MergeStrategy `json:"mergeStrategy,omitempty" yaml:"mergeStrategy,omitempty"`
+
+	// ConflictPolicies resolve template resources, by kind, that are not part
+	// of the stack and whose name collides with an existing resource.
+	ConflictPolicies map[Kind]ConflictPolicy `json:"conflictPolicies,omitempty" yaml:"conflictPolicies,omitempty"`
+}
+
+func (r ReqApply) mergeStrategy() (MergeStrategy, error) {
+	if r.MergeStrategy == "" {
+		return MergeStrategyError, nil
+	}
+	if err := r.MergeStrategy.OK(); err != nil {
+		return "", influxErr(errors.EInvalid, err)
+	}
+	return r.MergeStrategy, nil
 }
 
-// Templates returns all templates associated with the request.
-func (r ReqApply) Templates(encoding Encoding, client *http.Client) (*Template, error) {
+func (r ReqApply) conflictPolicies() ([]ApplyOptFn, error) {
+	var opts []ApplyOptFn
+	for k, p := range r.ConflictPolicies {
+		if err := k.OK(); err != nil {
+			return nil, influxErr(errors.EInvalid, fmt.Sprintf("invalid conflict policy kind %q: %s", k, err))
+		}
+		if err := p.OK(); err != nil {
+			return nil, influxErr(errors.EInvalid, err)
+		}
+		opts = append(opts, ApplyWithConflictPolicy(k, p))
+	}
+	return opts, nil
+}
+
+// headerIdempotencyKey is the header a client identifies an apply with.
+const headerIdempotencyKey = "Idempotency-Key"
+
+// maxIdempotencyKeyLen bounds the length of an idempotency key.
+const maxIdempotencyKeyLen = 255
+
+func (r ReqApply) idempotencyKey(req *http.Request) (string, error) {
+	key := req.Header.Get(headerIdempotencyKey)
+	if key != "" && r.IdempotencyKey != "" && key != r.IdempotencyKey {
+		return "", influxErr(errors.EInvalid, "idempotency key in the header does not match the key in the request body")
+	}
+	if key == "" {
+		key = r.IdempotencyKey
+	}
+	if len(key) > maxIdempotencyKeyLen {
+		return "", influxErr(errors.EInvalid, fmt.Sprintf("idempotency key must be at most %d characters", maxIdempotencyKeyLen))
+	}
+	return key, nil
+}
+
+// Templates returns all templates associated with the request. The opts are
+// applied when parsing each template, i.e. EnableJsonnet.
+func (r ReqApply) Templates(encoding Encoding, client *http.Client, opts ...ValidateOptFn) (*Template, error) {
+	return r.templates(context.Background(), encoding, client, remoteSources{}, opts...)
+}
+
+// remoteSources configures how the remote templates of a request are retrieved.
+type remoteSources struct {
+	trustAnchors []ed25519.PublicKey
+	registry     *registry.Client
+}
+
+func (r ReqApply) templates(ctx context.Context, encoding Encoding, client *http.Client, sources remoteSources, opts ...ValidateOptFn) (*Template, error) {
+	parseOpts := func(extVars, tlaVars map[string]string) []ValidateOptFn {
+		return append(append([]ValidateOptFn{}, opts...), ValidSkipParseError(), WithJsonnetVars(extVars, tlaVars))
+	}
+
 	var rawTemplates []*Template
 	for _, rem := range r.Remotes {
 		if rem.URL == "" {
 			continue
 		}
-		template, err := Parse(rem.Encoding(), FromHTTPRequest(rem.URL, client), ValidSkipParseError())
+		readerFn, enc, err := rem.readerFn(ctx, client, sources)
+		if err != nil {
+			msg := fmt.Sprintf("template from url[%s] had an issue: %s", rem.URL, err.Error())
+			return nil, influxErr(errors.EUnprocessableEntity, msg)
+		}
+		template, err := Parse(enc, readerFn, parseOpts(rem.ExtVars, rem.TLAVars)...)
 		if err != nil {
 			msg := fmt.Sprintf("template from url[%s] had an issue: %s", rem.URL, err.Error())
 			return nil, influxErr(errors.EUnprocessableEntity, msg)
@@ -236,7 +416,7 @@ func (r ReqApply) Templates(encoding Encoding, client *http.Client) (*Template,
 		if sourceEncoding := rawTmpl.Encoding(); sourceEncoding != EncodingSource {
 			enc = sourceEncoding
 		}
-		template, err := Parse(enc, FromReader(bytes.NewReader(rawTmpl.Template), rawTmpl.Sources...), ValidSkipParseError())
+		template, err := Parse(enc, FromReader(bytes.NewReader(rawTmpl.Template), rawTmpl.Sources...), parseOpts(rawTmpl.ExtVars, rawTmpl.TLAVars)...)
 		if err != nil {
 			sources := formatSources(rawTmpl.Sources)
 			msg := fmt.Sprintf("template[%d] from source(s) %q had an issue: %s", i, sources, err.Error())
@@ -245,24 +425,34 @@ func (r ReqApply) Templates(encoding Encoding, client *http.Client) (*Template,
 		rawTemplates = append(rawTemplates, template)
 	}
 
-	return Combine(rawTemplates, ValidWithoutResources(), ValidSkipParseError())
+	mergeStrategy, err := r.mergeStrategy()
+	if err != nil {
+		return nil, err
+	}
+	return Combine(rawTemplates, ValidWithoutResources(), ValidSkipParseError(), WithMergeStrategy(mergeStrategy))
 }
 
 type actionType string
 
 // various ActionTypes the transport API speaks
 const (
-	ActionTypeSkipKind     actionType = "skipKind"
-	ActionTypeSkipResource actionType = "skipResource"
+	ActionTypeSkipKind       actionType = "skipKind"
+	ActionTypeSkipResource   actionType = "skipResource"
+	ActionTypeDetachResource actionType = "detachResource"
+	ActionTypeRemoveResource actionType = "removeResource"
 )
 
 func (r ReqApply) validActions() (struct {
-	SkipKinds     []ActionSkipKind
-	SkipResources []ActionSkipResource
+	SkipKinds       []ActionSkipKind
+	SkipResources   []ActionSkipResource
+	DetachResources []ActionDetachResource
+	RemoveResources []ActionRemoveResource
 }, error) {
 	type actions struct {
-		SkipKinds     []ActionSkipKind
-		SkipResources []ActionSkipResource
+		SkipKinds       []ActionSkipKind
+		SkipResources   []ActionSkipResource
+		DetachResources []ActionDetachResource
+		RemoveResources []ActionRemoveResource
 	}
 
 	unmarshalErrFn := func(err error, idx int, actionType string) error {
@@ -276,6 +466,14 @@ func (r ReqApply) validActions() (struct {
 	}
 
 	var out actions
+	for i, k := range r.SkipKinds {
+		if err := k.OK(); err != nil {
+			msg := fmt.Sprintf("invalid kind for skipKinds[%d]", i)
+			return actions{}, influxErr(errors.EInvalid, ierrors.Wrap(err, msg))
+		}
+		out.SkipKinds = append(out.SkipKinds, ActionSkipKind{Kind: k})
+	}
+
 	for i, rawAct := range r.RawActions {
 		switch a := rawAct.Action; actionType(a) {
 		case ActionTypeSkipResource:
@@ -296,10 +494,33 @@ func (r ReqApply) validActions() (struct {
 				return actions{}, influxErr(errors.EInvalid, kindErrFn(err, i, a))
 			}
 			out.SkipKinds = append(out.SkipKinds, ask)
+		case ActionTypeDetachResource:
+			var adr ActionDetachResource
+			if err := json.Unmarshal(rawAct.Properties, &adr); err != nil {
+				return actions{}, influxErr(errors.EInvalid, unmarshalErrFn(err, i, a))
+			}
+			if err := adr.Kind.OK(); err != nil {
+				return actions{}, influxErr(errors.EInvalid, kindErrFn(err, i, a))
+			}
+			out.DetachResources = append(out.DetachResources, adr)
+		case ActionTypeRemoveResource:
+			var arr ActionRemoveResource
+			if err := json.Unmarshal(rawAct.Properties, &arr); err != nil {
+				return actions{}, influxErr(errors.EInvalid, unmarshalErrFn(err, i, a))
+			}
+			if err := arr.Kind.OK(); err != nil {
+				return actions{}, influxErr(errors.EInvalid, kindErrFn(err, i, a))
+			}
+			out.RemoveResources = append(out.RemoveResources, arr)
 		default:
 			msg := fmt.Sprintf(
 				"invalid action type %q provided for actions[%d] ; Must be one of [%s]",
-				a, i, ActionTypeSkipResource,
+				a, i, strings.Join([]string{
+					string(ActionTypeSkipResource),
+					string(ActionTypeSkipKind),
+					string(ActionTypeDetachResource),
+					string(ActionTypeRemoveResource),
+				}, ", "),
 			)
 			return actions{}, influxErr(errors.EInvalid, msg)
 		}
@@ -315,7 +536,15 @@ type RespApply struct {
 	Diff    Diff     `json:"diff" yaml:"diff"`
 	Summary Summary  `json:"summary" yaml:"summary"`
 
-	Errors []ValidationErr `json:"errors,omitempty" yaml:"errors,omitempty"`
+	Hooks          []HookResult           `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+	Authorizations []SummaryAuthorization `json:"authorizations,omitempty" yaml:"authorizations,omitempty"`
+	Timing         *ApplyTiming           `json:"timing,omitempty" yaml:"timing,omitempty"`
+	Errors         []ValidationErr        `json:"errors,omitempty" yaml:"errors,omitempty"`
+
+	// Resources is the change the apply makes to each resource, and NoOp
+	// is set when every resource is unchanged.
+	Resources []ResourceImpact `json:"resources" yaml:"resources"`
+	NoOp      bool             `json:"noop" yaml:"noop"`
 }
 
 // RespApplyErr is the response body for a dry-run parse error in the apply template endpoint.
@@ -343,8 +572,10 @@ func (s *HTTPServerTemplates) apply(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Reject use of server-side jsonnet with /api/v2/templates/apply
-	if encoding == EncodingJsonnet {
+	// Reject use of server-side jsonnet with /api/v2/templates/apply unless
+	// the request is trusted to evaluate it.
+	allowJsonnet := s.jsonnetAllowed(r.Context())
+	if encoding == EncodingJsonnet && !allowJsonnet {
 		s.api.Err(w, r, &errors.Error{
 			Code: errors.EUnprocessableEntity,
 			Msg:  fmt.Sprintf("template from source(s) had an issue: %s", ErrInvalidEncoding.Error()),
@@ -368,7 +599,7 @@ func (s *HTTPServerTemplates) apply(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
-		if len(decoded) > 0 && strings.HasSuffix(strings.ToLower(decoded), "jsonnet") {
+		if !allowJsonnet && len(decoded) > 0 && strings.HasSuffix(strings.ToLower(decoded), "jsonnet") {
 			s.api.Err(w, r, &errors.Error{
 				Code: errors.EUnprocessableEntity,
 				Msg:  fmt.Sprintf("template from url[%q] had an issue: %s", rem, ErrInvalidEncoding.Error()),
@@ -388,7 +619,28 @@ func (s *HTTPServerTemplates) apply(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	parsedTemplate, err := reqBody.Templates(encoding, s.client)
+	var parseOpts []ValidateOptFn
+	if allowJsonnet {
+		parseOpts = append(parseOpts, EnableJsonnet())
+	}
+
+	mergeStrategy, err := reqBody.mergeStrategy()
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	conflictOpts, err := reqBody.conflictPolicies()
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	sources := remoteSources{
+		trustAnchors: s.trustAnchors,
+		registry:     s.registry,
+	}
+	parsedTemplate, err := reqBody.templates(r.Context(), encoding, s.client, sources, parseOpts...)
 	if err != nil {
 		s.api.Err(w, r, &errors.Error{
 			Code: errors.EUnprocessableEntity,
@@ -407,6 +659,7 @@ func (s *HTTPServerTemplates) apply(w http.ResponseWriter, r *http.Request) {
 		ApplyWithEnvRefs(reqBody.EnvRefs),
 		ApplyWithTemplate(parsedTemplate),
 		ApplyWithStackID(stackID),
+		ApplyWithMergeStrategy(mergeStrategy),
 	}
 	for _, a := range actions.SkipResources {
 		applyOpts = append(applyOpts, ApplyWithResourceSkip(a))
@@ -414,6 +667,13 @@ func (s *HTTPServerTemplates) apply(w http.ResponseWriter, r *http.Request) {
 	for _, a := range actions.SkipKinds {
 		applyOpts = append(applyOpts, ApplyWithKindSkip(a))
 	}
+	for _, a := range actions.DetachResources {
+		applyOpts = append(applyOpts, ApplyWithResourceDetach(a))
+	}
+	for _, a := range actions.RemoveResources {
+		applyOpts = append(applyOpts, ApplyWithResourceRemove(a))
+	}
+	applyOpts = append(applyOpts, conflictOpts...)
 
 	auth, err := pctx.GetAuthorizer(r.Context())
 	if err != nil {
@@ -422,6 +682,11 @@ func (s *HTTPServerTemplates) apply(w http.ResponseWriter, r *http.Request) {
 	}
 	userID := auth.GetUserID()
 
+	if r.URL.Query().Get("async") == "true" {
+		s.applyAsync(w, r, reqBody, *orgID, userID, len(parsedTemplate.Objects), applyOpts)
+		return
+	}
+
 	if reqBody.DryRun {
 		impact, err := s.svc.DryRun(r.Context(), *orgID, userID, applyOpts...)
 		if IsParseErr(err) {
@@ -437,12 +702,30 @@ func (s *HTTPServerTemplates) apply(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if format, ok := diffFormatFromRequest(r); ok {
+			w.Header().Set("Content-Type", format.ContentType())
+			w.WriteHeader(http.StatusOK)
+			if err := impact.Diff.Render(w, format); err != nil {
+				s.logger.Error("failed to render diff", zap.Error(err))
+			}
+			return
+		}
+
 		s.api.Respond(w, r, http.StatusOK, impactToRespApply(impact, nil))
 		return
 	}
 
 	applyOpts = append(applyOpts, ApplyWithSecrets(reqBody.Secrets))
 
+	idempotencyKey, err := reqBody.idempotencyKey(r)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+	if idempotencyKey != "" {
+		applyOpts = append(applyOpts, ApplyWithIdempotencyKey(idempotencyKey))
+	}
+
 	impact, err := s.svc.Apply(r.Context(), *orgID, userID, applyOpts...)
 	if err != nil && !IsParseErr(err) {
 		s.api.Err(w, r, err)
@@ -452,6 +735,309 @@ func (s *HTTPServerTemplates) apply(w http.ResponseWriter, r *http.Request) {
 	s.api.Respond(w, r, http.StatusCreated, impactToRespApply(impact, err))
 }
 
+// diffFormatFromRequest returns the format a dry run diff is rendered in for
+// humans. It is requested by the format query parameter, or by accepting
+// text/markdown.
+func diffFormatFromRequest(r *http.Request) (DiffFormat, bool) {
+	switch format := DiffFormat(r.URL.Query().Get("format")); format {
+	case DiffFormatMarkdown, DiffFormatText:
+		return format, true
+	}
+	if strings.Contains(r.Header.Get("Accept"), "text/markdown") {
+		return DiffFormatMarkdown, true
+	}
+	return "", false
+}
+
+// ReqValidate is the request body for the validate template endpoint.
+type ReqValidate struct {
+	Remotes      []ReqTemplateRemote `json:"remotes" yaml:"remotes"`
+	RawTemplates []ReqRawTemplate    `json:"templates" yaml:"templates"`
+	RawTemplate  ReqRawTemplate      `json:"template" yaml:"template"`
+
+	EnvRefs       map[string]interface{} `json:"envRefs" yaml:"envRefs"`
+	MergeStrategy MergeStrategy          `json:"mergeStrategy,omitempty" yaml:"mergeStrategy,omitempty"`
+
+	// Rules overrides the configuration of the lint rules by rule ID.
+	Rules map[LintRuleID]LintRuleConfig `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
+
+// RespValidate is the response body for the validate template endpoint.
+type RespValidate struct {
+	// Valid is false when any of the findings is an error.
+	Valid    bool         `json:"valid" yaml:"valid"`
+	Findings LintFindings `json:"findings" yaml:"findings"`
+}
+
+// validate lints the templates provided without applying them.
+func (s *HTTPServerTemplates) validate(w http.ResponseWriter, r *http.Request) {
+	var reqBody ReqValidate
+	encoding, err := decodeWithEncoding(r, &reqBody)
+	if err != nil {
+		s.api.Err(w, r, newDecodeErr(encoding.String(), err))
+		return
+	}
+
+	allowJsonnet := s.jsonnetAllowed(r.Context())
+	if encoding == EncodingJsonnet && !allowJsonnet {
+		s.api.Err(w, r, &errors.Error{
+			Code: errors.EUnprocessableEntity,
+			Msg:  fmt.Sprintf("template from source(s) had an issue: %s", ErrInvalidEncoding.Error()),
+		})
+		return
+	}
+
+	var parseOpts []ValidateOptFn
+	if allowJsonnet {
+		parseOpts = append(parseOpts, EnableJsonnet())
+	}
+
+	req := ReqApply{
+		Remotes:       reqBody.Remotes,
+		RawTemplates:  reqBody.RawTemplates,
+		RawTemplate:   reqBody.RawTemplate,
+		MergeStrategy: reqBody.MergeStrategy,
+	}
+	sources := remoteSources{
+		trustAnchors: s.trustAnchors,
+		registry:     s.registry,
+	}
+	template, err := req.templates(r.Context(), encoding, s.client, sources, parseOpts...)
+	if err != nil {
+		s.api.Err(w, r, &errors.Error{
+			Code: errors.EUnprocessableEntity,
+			Err:  err,
+		})
+		return
+	}
+
+	lintOpts := []LintOptFn{LintWithEnvRefs(reqBody.EnvRefs)}
+	for id, cfg := range reqBody.Rules {
+		lintOpts = append(lintOpts, LintWithRule(id, cfg))
+	}
+
+	findings, err := Lint(template, lintOpts...)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+	if findings == nil {
+		findings = LintFindings{}
+	}
+
+	s.api.Respond(w, r, http.StatusOK, RespValidate{
+		Valid:    !findings.HasErrors(),
+		Findings: findings,
+	})
+}
+
+// maxBundleSize is the largest bundle archive accepted by the bundle apply endpoint.
+const maxBundleSize = 32 << 20
+
+// RespApplyBundleMember is the response for a single member of an applied bundle.
+type RespApplyBundleMember struct {
+	Member string `json:"member"`
+	RespApply
+}
+
+// RespApplyBundle is the response body for the bundle apply endpoint.
+type RespApplyBundle struct {
+	Name    string                  `json:"name"`
+	Version string                  `json:"version,omitempty"`
+	Members []RespApplyBundleMember `json:"members"`
+}
+
+func impactToRespApplyBundle(impact BundleImpact) RespApplyBundle {
+	out := RespApplyBundle{
+		Name:    impact.Name,
+		Version: impact.Version,
+		Members: []RespApplyBundleMember{},
+	}
+	for _, m := range impact.Members {
+		out.Members = append(out.Members, RespApplyBundleMember{
+			Member:    m.Member,
+			RespApply: impactToRespApply(m.ImpactSummary, nil),
+		})
+	}
+	return out
+}
+
+// applyBundle applies a bundle archive provided as the request body. The
+// org is provided by the orgID query parameter and dryRun=true reports the
+// impact of each member without applying it.
+func (s *HTTPServerTemplates) applyBundle(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	orgID, err := platform.IDFromString(q.Get("orgID"))
+	if err != nil {
+		s.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid organization ID provided: %q", q.Get("orgID")),
+		})
+		return
+	}
+	dryRun := q.Get("dryRun") == "true"
+
+	b, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBundleSize+1))
+	if err != nil {
+		s.api.Err(w, r, influxErr(errors.EInvalid, "failed to read bundle", err))
+		return
+	}
+	if len(b) > maxBundleSize {
+		s.api.Err(w, r, &errors.Error{
+			Code: errors.ETooLarge,
+			Msg:  fmt.Sprintf("bundle exceeds the maximum size of %d bytes", maxBundleSize),
+		})
+		return
+	}
+
+	var parseOpts []ValidateOptFn
+	if s.jsonnetAllowed(r.Context()) {
+		parseOpts = append(parseOpts, EnableJsonnet())
+	}
+	bundle, err := ParseBundle(b, parseOpts...)
+	if errors.ErrorCode(err) == errors.ETooLarge {
+		s.api.Err(w, r, err)
+		return
+	}
+	if err != nil {
+		s.api.Err(w, r, &errors.Error{
+			Code: errors.EUnprocessableEntity,
+			Err:  err,
+		})
+		return
+	}
+
+	auth, err := pctx.GetAuthorizer(r.Context())
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	impact, err := ApplyBundle(r.Context(), s.svc, *orgID, auth.GetUserID(), bundle, dryRun)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	code := http.StatusCreated
+	if dryRun {
+		code = http.StatusOK
+	}
+	s.api.Respond(w, r, code, impactToRespApplyBundle(impact))
+}
+
+// ReqExportBundle is a request body for the bundle export endpoint.
+type ReqExportBundle struct {
+	Manifest BundleManifest    `json:"manifest"`
+	StackIDs map[string]string `json:"stackIDs"`
+	Format   BundleFormat      `json:"format"`
+}
+
+// exportBundle exports the stack of each manifest member into a bundle
+// archive.
+func (s *HTTPServerTemplates) exportBundle(w http.ResponseWriter, r *http.Request) {
+	var reqBody ReqExportBundle
+	if err := s.api.DecodeJSON(r.Body, &reqBody); err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+	defer r.Body.Close()
+
+	stackIDs := make(map[string]platform.ID, len(reqBody.StackIDs))
+	for member, rawID := range reqBody.StackIDs {
+		stackID, err := platform.IDFromString(rawID)
+		if err != nil {
+			s.api.Err(w, r, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid stack ID provided for bundle member %q: %q", member, rawID),
+			})
+			return
+		}
+		stackIDs[member] = *stackID
+	}
+
+	bundle, err := ExportBundle(r.Context(), s.svc, reqBody.Manifest, stackIDs)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	b, err := bundle.Encode(reqBody.Format)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	contentType := "application/gzip"
+	if reqBody.Format == BundleFormatZip {
+		contentType = "application/zip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		s.logger.Error("failed to write bundle", zap.Error(err))
+	}
+}
+
+// applyAsync starts the apply in the background and responds with the job
+// tracking it. The job's status, and its result once finished, is retrieved
+// from the jobs endpoint.
+func (s *HTTPServerTemplates) applyAsync(w http.ResponseWriter, r *http.Request, reqBody ReqApply, orgID, userID platform.ID, resources int, applyOpts []ApplyOptFn) {
+	if !reqBody.DryRun {
+		applyOpts = append(applyOpts, ApplyWithSecrets(reqBody.Secrets))
+
+		idempotencyKey, err := reqBody.idempotencyKey(r)
+		if err != nil {
+			s.api.Err(w, r, err)
+			return
+		}
+		if idempotencyKey != "" {
+			applyOpts = append(applyOpts, ApplyWithIdempotencyKey(idempotencyKey))
+		}
+	}
+
+	job := s.jobs.start(r.Context(), orgID, userID, reqBody.DryRun, resources, func(ctx context.Context) (*RespApply, error) {
+		applyFn := s.svc.Apply
+		if reqBody.DryRun {
+			applyFn = s.svc.DryRun
+		}
+		impact, err := applyFn(ctx, orgID, userID, applyOpts...)
+		if err != nil && !IsParseErr(err) {
+			return nil, err
+		}
+		resp := impactToRespApply(impact, err)
+		return &resp, err
+	})
+
+	s.api.Respond(w, r, http.StatusAccepted, job)
+}
+
+func (s *HTTPServerTemplates) getApplyJob(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		s.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid apply job ID provided: %q", chi.URLParam(r, "id")),
+		})
+		return
+	}
+
+	auth, err := pctx.GetAuthorizer(r.Context())
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	job, err := s.jobs.find(*id, auth.GetUserID())
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+	s.api.Respond(w, r, http.StatusOK, job)
+}
+
 func (s *HTTPServerTemplates) encResp(w http.ResponseWriter, r *http.Request, enc encoder, code int, res interface{}) {
 	w.WriteHeader(code)
 	if err := enc.Encode(res); err != nil {
@@ -469,6 +1055,16 @@ func impactToRespApply(impact ImpactSummary, err error) RespApply {
 		StackID: impact.StackID.String(),
 		Diff:    impact.Diff,
 		Summary: impact.Summary,
+		Hooks:   impact.Hooks,
+
+		Authorizations: impact.Authorizations,
+
+		Resources: append([]ResourceImpact{}, impact.Resources...), // guarantee non nil slice
+		NoOp:      impact.NoOp,
+	}
+	if impact.Timing.Duration > 0 {
+		timing := impact.Timing
+		out.Timing = &timing
 	}
 	if err != nil {
 		out.Errors = convertParseErr(err)
@@ -544,6 +1140,18 @@ func impactToRespApply(impact ImpactSummary, err error) RespApply {
 	return out
 }
 
+// jsonnetAllowed reports whether the request may apply templates written in
+// jsonnet, either through the org's feature flag or the configured permissions.
+func (s *HTTPServerTemplates) jsonnetAllowed(ctx context.Context) bool {
+	if feature.TemplateJsonnet().Enabled(ctx) {
+		return true
+	}
+	if len(s.jsonnetPermissions) == 0 {
+		return false
+	}
+	return authorizer.IsAllowedAll(ctx, s.jsonnetPermissions) == nil
+}
+
 func formatSources(sources []string) string {
 	return strings.Join(sources, "; ")
 }
@@ -609,6 +1217,9 @@ func convertParseErr(err error) []ValidationErr {
 }
 
 func newDecodeErr(encoding string, err error) *errors.Error {
+	if tooLarge, ok := kithttp.BodyTooLargeError(err); ok {
+		return tooLarge
+	}
 	return &errors.Error{
 		Msg:  fmt.Sprintf("unable to unmarshal %s", encoding),
 		Code: errors.EInvalid,
@@ -3,6 +3,8 @@ package pkger_test
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,6 +20,7 @@ import (
 	fluxurl "github.com/influxdata/flux/dependencies/url"
 	"github.com/influxdata/influxdb/v2"
 	pcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/feature"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	influxerror "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
@@ -243,6 +246,183 @@ func TestPkgerHTTPServerTemplate(t *testing.T) {
 			}
 		})
 
+		t.Run("renders diff for humans", func(t *testing.T) {
+			svc := &fakeSVC{
+				dryRunFn: func(ctx context.Context, orgID, userID platform.ID, opts ...pkger.ApplyOptFn) (pkger.ImpactSummary, error) {
+					return pkger.ImpactSummary{
+						Diff: pkger.Diff{
+							Buckets: []pkger.DiffBucket{
+								{
+									DiffIdentifier: pkger.DiffIdentifier{StateStatus: pkger.StateStatusNew, MetaName: "rucket-1"},
+									New:            pkger.DiffBucketValues{Name: "rucket-1"},
+								},
+								{
+									DiffIdentifier: pkger.DiffIdentifier{ID: 1, StateStatus: pkger.StateStatusExists, MetaName: "rucket-2"},
+									New:            pkger.DiffBucketValues{Name: "rucket-2", Description: "new desc"},
+									Old:            &pkger.DiffBucketValues{Name: "rucket-2", Description: "old desc"},
+								},
+								{
+									DiffIdentifier: pkger.DiffIdentifier{ID: 2, StateStatus: pkger.StateStatusExists, MetaName: "rucket-3"},
+									New:            pkger.DiffBucketValues{Name: "rucket-3"},
+									Old:            &pkger.DiffBucketValues{Name: "rucket-3"},
+								},
+							},
+							Labels: []pkger.DiffLabel{
+								{
+									DiffIdentifier: pkger.DiffIdentifier{ID: 3, StateStatus: pkger.StateStatusRemove, MetaName: "label-1"},
+								},
+							},
+						},
+					}, nil
+				},
+			}
+
+			tests := []struct {
+				name        string
+				path        string
+				accept      string
+				contentType string
+				expected    string
+			}{
+				{
+					name:        "markdown",
+					path:        "/api/v2/templates/apply",
+					accept:      "text/markdown",
+					contentType: "text/markdown; charset=utf-8",
+					expected: "## Buckets\n\n```diff\n" +
+						"+ rucket-1\n" +
+						"  rucket-2\n" +
+						"-   description: \"old desc\"\n" +
+						"+   description: \"new desc\"\n" +
+						"  (1 unchanged)\n" +
+						"```\n" +
+						"\n## Labels\n\n```diff\n" +
+						"- label-1\n" +
+						"```\n",
+				},
+				{
+					name:        "text",
+					path:        "/api/v2/templates/apply?format=text",
+					accept:      "application/json",
+					contentType: "text/plain; charset=utf-8",
+					expected: "Buckets:\n" +
+						"+ rucket-1\n" +
+						"  rucket-2\n" +
+						"-   description: \"old desc\"\n" +
+						"+   description: \"new desc\"\n" +
+						"  (1 unchanged)\n" +
+						"\nLabels:\n" +
+						"- label-1\n",
+				},
+			}
+
+			for _, tt := range tests {
+				fn := func(t *testing.T) {
+					pkgHandler := pkger.NewHTTPServerTemplates(zap.NewNop(), svc, defaultClient)
+					svr := newMountedHandler(pkgHandler, 1)
+
+					testttp.
+						PostJSON(t, tt.path, pkger.ReqApply{
+							DryRun:      true,
+							OrgID:       platform.ID(9000).String(),
+							RawTemplate: bucketPkgKinds(t, pkger.EncodingJSON),
+						}).
+						Headers("Content-Type", "application/json").
+						Headers("Accept", tt.accept).
+						Do(svr).
+						ExpectStatus(http.StatusOK).
+						ExpectHeader("Content-Type", tt.contentType).
+						ExpectBody(func(buf *bytes.Buffer) {
+							assert.Equal(t, tt.expected, buf.String())
+						})
+				}
+				t.Run(tt.name, fn)
+			}
+		})
+
+		t.Run("jsonnet allowed per request", func(t *testing.T) {
+			operPerms := influxdb.OperPermissions()
+
+			tests := []struct {
+				name       string
+				opts       []pkger.HTTPServerTemplatesOptFn
+				perms      []influxdb.Permission
+				flagger    feature.Flagger
+				expectCode int
+			}{
+				{
+					name:       "operator token with jsonnet permissions",
+					opts:       []pkger.HTTPServerTemplatesOptFn{pkger.WithJsonnetPermissions(operPerms...)},
+					perms:      operPerms,
+					expectCode: http.StatusOK,
+				},
+				{
+					name: "regular token with jsonnet permissions",
+					opts: []pkger.HTTPServerTemplatesOptFn{pkger.WithJsonnetPermissions(operPerms...)},
+					perms: []influxdb.Permission{{
+						Action:   influxdb.WriteAction,
+						Resource: influxdb.Resource{Type: influxdb.BucketsResourceType},
+					}},
+					expectCode: http.StatusUnprocessableEntity,
+				},
+				{
+					name:       "operator token without jsonnet permissions",
+					perms:      operPerms,
+					expectCode: http.StatusUnprocessableEntity,
+				},
+				{
+					name:       "org with feature flag enabled",
+					flagger:    mock.NewFlagger(map[feature.Flag]interface{}{feature.TemplateJsonnet(): true}),
+					expectCode: http.StatusOK,
+				},
+			}
+
+			for _, tt := range tests {
+				fn := func(t *testing.T) {
+					svc := &fakeSVC{
+						dryRunFn: func(ctx context.Context, orgID, userID platform.ID, opts ...pkger.ApplyOptFn) (pkger.ImpactSummary, error) {
+							var opt pkger.ApplyOpt
+							for _, o := range opts {
+								o(&opt)
+							}
+							pkg, err := pkger.Combine(opt.Templates)
+							if err != nil {
+								return pkger.ImpactSummary{}, err
+							}
+							return pkger.ImpactSummary{Summary: pkg.Summary()}, nil
+						},
+					}
+
+					pkgHandler := pkger.NewHTTPServerTemplates(zap.NewNop(), svc, defaultClient, tt.opts...)
+					svr := chi.NewRouter()
+					svr.Mount(pkgHandler.Prefix(), pkgHandler)
+
+					ctx := pcontext.SetAuthorizer(context.Background(), &influxdb.Authorization{
+						Status:      influxdb.Active,
+						UserID:      1,
+						Permissions: tt.perms,
+					})
+					if tt.flagger != nil {
+						var err error
+						ctx, err = feature.Annotate(ctx, tt.flagger)
+						require.NoError(t, err)
+					}
+
+					testttp.
+						PostJSON(t, "/api/v2/templates/apply", pkger.ReqApply{
+							DryRun:      true,
+							OrgID:       platform.ID(9000).String(),
+							RawTemplate: bucketPkgKinds(t, pkger.EncodingJsonnet),
+						}).
+						Headers("Content-Type", "application/x-jsonnet").
+						WithCtx(ctx).
+						Do(svr).
+						ExpectStatus(tt.expectCode)
+				}
+				t.Run(tt.name, fn)
+			}
+		})
+
 		t.Run("json", func(t *testing.T) {
 			tests := []struct {
 				name        string
@@ -576,6 +756,17 @@ func TestPkgerHTTPServerTemplate(t *testing.T) {
 					},
 					expectedStatusCode: http.StatusBadRequest,
 				},
+				{
+					name:        "invalid skip kind",
+					contentType: "application/json",
+					reqBody: pkger.ReqApply{
+						DryRun:      true,
+						OrgID:       platform.ID(9000).String(),
+						RawTemplate: bucketPkgKinds(t, pkger.EncodingJSON),
+						SkipKinds:   []pkger.Kind{"bad kind"},
+					},
+					expectedStatusCode: http.StatusBadRequest,
+				},
 			}
 
 			for _, tt := range tests {
@@ -765,6 +956,180 @@ func TestPkgerHTTPServerTemplate(t *testing.T) {
 		})
 	})
 
+	t.Run("validate pkg", func(t *testing.T) {
+		bucketTemplate := func(name string) pkger.ReqRawTemplate {
+			return pkger.ReqRawTemplate{
+				ContentType: pkger.EncodingJSON.String(),
+				Sources:     []string{"test.json"},
+				Template: []byte(fmt.Sprintf(`[{
+  "apiVersion": %q,
+  "kind": "Bucket",
+  "metadata": {"name": %q}
+}]`, pkger.APIVersion, name)),
+			}
+		}
+
+		tests := []struct {
+			name          string
+			reqBody       pkger.ReqValidate
+			expectedValid bool
+			expected      []pkger.LintFinding
+		}{
+			{
+				name:          "no findings",
+				reqBody:       pkger.ReqValidate{RawTemplate: bucketPkgKinds(t, pkger.EncodingJSON)},
+				expectedValid: true,
+				expected:      []pkger.LintFinding{},
+			},
+			{
+				name:          "default rules",
+				reqBody:       pkger.ReqValidate{RawTemplate: bucketTemplate("1-rucket")},
+				expectedValid: true,
+				expected: []pkger.LintFinding{
+					{
+						Rule:     pkger.LintRuleNamingConvention,
+						Severity: pkger.LintSeverityWarning,
+						Field:    "metadata.name",
+					},
+					{
+						Rule:     pkger.LintRuleMissingDescription,
+						Severity: pkger.LintSeverityInfo,
+						Field:    "spec.description",
+					},
+				},
+			},
+			{
+				name: "rules overridden",
+				reqBody: pkger.ReqValidate{
+					RawTemplate: bucketTemplate("1-rucket"),
+					Rules: map[pkger.LintRuleID]pkger.LintRuleConfig{
+						pkger.LintRuleNamingConvention:   {Severity: pkger.LintSeverityError},
+						pkger.LintRuleMissingDescription: {Severity: pkger.LintSeverityOff},
+					},
+				},
+				expectedValid: false,
+				expected: []pkger.LintFinding{
+					{
+						Rule:     pkger.LintRuleNamingConvention,
+						Severity: pkger.LintSeverityError,
+						Field:    "metadata.name",
+					},
+				},
+			},
+			{
+				name: "unused env ref",
+				reqBody: pkger.ReqValidate{
+					RawTemplate: bucketPkgKinds(t, pkger.EncodingJSON),
+					EnvRefs:     map[string]interface{}{"bkt-name": "rucket"},
+				},
+				expectedValid: true,
+				expected: []pkger.LintFinding{
+					{
+						Rule:     pkger.LintRuleUnusedEnvRef,
+						Severity: pkger.LintSeverityWarning,
+						Field:    "bkt-name",
+					},
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			fn := func(t *testing.T) {
+				pkgHandler := pkger.NewHTTPServerTemplates(zap.NewNop(), nil, defaultClient)
+				svr := newMountedHandler(pkgHandler, 1)
+
+				testttp.
+					PostJSON(t, "/api/v2/templates/validate", tt.reqBody).
+					Headers("Content-Type", "application/json").
+					Do(svr).
+					ExpectStatus(http.StatusOK).
+					ExpectBody(func(buf *bytes.Buffer) {
+						var resp pkger.RespValidate
+						decodeBody(t, buf, &resp)
+
+						assert.Equal(t, tt.expectedValid, resp.Valid)
+						require.NotNil(t, resp.Findings)
+						require.Len(t, resp.Findings, len(tt.expected))
+						for i, expected := range tt.expected {
+							actual := resp.Findings[i]
+							assert.Equal(t, expected.Rule, actual.Rule)
+							assert.Equal(t, expected.Severity, actual.Severity)
+							assert.Equal(t, expected.Field, actual.Field)
+							assert.NotEmpty(t, actual.Message)
+						}
+					})
+			}
+
+			t.Run(tt.name, fn)
+		}
+
+		t.Run("invalid template is reported as a validation error", func(t *testing.T) {
+			pkgHandler := pkger.NewHTTPServerTemplates(zap.NewNop(), nil, defaultClient)
+			svr := newMountedHandler(pkgHandler, 1)
+
+			testttp.
+				PostJSON(t, "/api/v2/templates/validate", pkger.ReqValidate{
+					RawTemplate: bucketTemplate("Rucket_1"),
+				}).
+				Headers("Content-Type", "application/json").
+				Do(svr).
+				ExpectStatus(http.StatusOK).
+				ExpectBody(func(buf *bytes.Buffer) {
+					var resp pkger.RespValidate
+					decodeBody(t, buf, &resp)
+
+					assert.False(t, resp.Valid)
+					var rules []pkger.LintRuleID
+					for _, f := range resp.Findings {
+						rules = append(rules, f.Rule)
+					}
+					assert.Contains(t, rules, pkger.LintRuleValidation)
+				})
+		})
+
+		t.Run("invalid rule config", func(t *testing.T) {
+			tests := []struct {
+				name  string
+				rules map[pkger.LintRuleID]pkger.LintRuleConfig
+			}{
+				{
+					name:  "unknown rule",
+					rules: map[pkger.LintRuleID]pkger.LintRuleConfig{"no-such-rule": {}},
+				},
+				{
+					name: "invalid severity",
+					rules: map[pkger.LintRuleID]pkger.LintRuleConfig{
+						pkger.LintRuleMissingDescription: {Severity: "fatal"},
+					},
+				},
+				{
+					name: "invalid pattern",
+					rules: map[pkger.LintRuleID]pkger.LintRuleConfig{
+						pkger.LintRuleNamingConvention: {Pattern: "(["},
+					},
+				},
+			}
+
+			for _, tt := range tests {
+				fn := func(t *testing.T) {
+					pkgHandler := pkger.NewHTTPServerTemplates(zap.NewNop(), nil, defaultClient)
+					svr := newMountedHandler(pkgHandler, 1)
+
+					testttp.
+						PostJSON(t, "/api/v2/templates/validate", pkger.ReqValidate{
+							RawTemplate: bucketPkgKinds(t, pkger.EncodingJSON),
+							Rules:       tt.rules,
+						}).
+						Headers("Content-Type", "application/json").
+						Do(svr).
+						ExpectStatus(http.StatusBadRequest)
+				}
+
+				t.Run(tt.name, fn)
+			}
+		})
+	})
+
 	t.Run("Templates()", func(t *testing.T) {
 		tests := []struct {
 			name     string
@@ -808,6 +1173,33 @@ func TestPkgerHTTPServerTemplate(t *testing.T) {
 		}
 	})
 
+	t.Run("Templates() jsonnet vars", func(t *testing.T) {
+		const tmpl = `function(name) [{
+  apiVersion: "influxdata.com/v2alpha1",
+  kind: "Bucket",
+  metadata: { name: name },
+  spec: { description: "owned by " + std.extVar("team") },
+}]`
+
+		reqBody := pkger.ReqApply{
+			OrgID: platform.ID(9000).String(),
+			RawTemplate: pkger.ReqRawTemplate{
+				ContentType: "jsonnet",
+				Template:    []byte(tmpl),
+				ExtVars:     map[string]string{"team": "payments"},
+				TLAVars:     map[string]string{"name": "invoices"},
+			},
+		}
+
+		template, err := reqBody.Templates(pkger.EncodingJsonnet, defaultClient, pkger.EnableJsonnet())
+		require.NoError(t, err)
+
+		buckets := template.Summary().Buckets
+		require.Len(t, buckets, 1)
+		assert.Equal(t, "invoices", buckets[0].Name)
+		assert.Equal(t, "owned by payments", buckets[0].Description)
+	})
+
 	t.Run("Templates() remotes with IP validation", func(t *testing.T) {
 		tests := []struct {
 			name    string
@@ -871,6 +1263,70 @@ func TestPkgerHTTPServerTemplate(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("Templates() remotes with signature verification", func(t *testing.T) {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		contents, err := ioutil.ReadFile("testdata/remote_bucket.json")
+		require.NoError(t, err)
+
+		tests := []struct {
+			name      string
+			signature string
+			expCode   int
+		}{
+			{
+				name:      "valid signature",
+				signature: pkger.SignTemplate(priv, contents),
+				expCode:   http.StatusOK,
+			},
+			{
+				name:      "signed by untrusted key",
+				signature: pkger.SignTemplate(otherPriv, contents),
+				expCode:   http.StatusUnprocessableEntity,
+			},
+			{
+				name:      "tampered contents",
+				signature: pkger.SignTemplate(priv, append(contents, ' ')),
+				expCode:   http.StatusUnprocessableEntity,
+			},
+			{
+				name:    "missing signature",
+				expCode: http.StatusUnprocessableEntity,
+			},
+		}
+
+		svc := &fakeSVC{
+			dryRunFn: func(ctx context.Context, orgID, userID platform.ID, opts ...pkger.ApplyOptFn) (pkger.ImpactSummary, error) {
+				return pkger.ImpactSummary{}, nil
+			},
+		}
+		for _, tt := range tests {
+			fn := func(t *testing.T) {
+				pkgHandler := pkger.NewHTTPServerTemplates(zap.NewNop(), svc, defaultClient, pkger.WithTrustAnchors(pub))
+				svr := newMountedHandler(pkgHandler, 1)
+
+				reqBody := pkger.ReqApply{
+					DryRun: true,
+					OrgID:  platform.ID(9000).String(),
+					Remotes: []pkger.ReqTemplateRemote{{
+						URL:       newPkgURL(t, filesvr.URL, "testdata/remote_bucket.json"),
+						Signature: tt.signature,
+					}},
+				}
+
+				testttp.
+					PostJSON(t, "/api/v2/templates/apply", reqBody).
+					Headers("Content-Type", "application/json").
+					Do(svr).
+					ExpectStatus(tt.expCode)
+			}
+			t.Run(tt.name, fn)
+		}
+	})
 }
 
 func assertNonZeroApplyResp(t *testing.T, resp pkger.RespApply) {
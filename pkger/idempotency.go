@@ -0,0 +1,195 @@
+package pkger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// DefaultIdempotencyWindow is how long the result of an apply is returned
+// for a repeated idempotency key.
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+// maxIdempotencyEntries bounds the number of applies remembered. The apply
+// closest to leaving the window is forgotten to make room for a new one.
+const maxIdempotencyEntries = 10000
+
+type idempotencyKey struct {
+	orgID  platform.ID
+	userID platform.ID
+	key    string
+}
+
+type idempotencyEntry struct {
+	fingerprint string
+	done        chan struct{}
+	impact      ImpactSummary
+	err         error
+	expiresAt   time.Time
+}
+
+// idempotencyCache remembers the impact of successful applies by org, user
+// and idempotency key. A repeated key within the window returns the
+// remembered impact rather than applying the template again; a repeated key
+// while the first apply is in flight waits for its result. A repeated key
+// with a different fingerprint, as the key is reused for another apply, is
+// rejected. Failed applies are not remembered so they may be retried.
+type idempotencyCache struct {
+	window     time.Duration
+	maxEntries int
+	timeGen    influxdb.TimeGenerator
+
+	mu      sync.Mutex
+	entries map[idempotencyKey]*idempotencyEntry
+}
+
+func newIdempotencyCache(window time.Duration, timeGen influxdb.TimeGenerator) *idempotencyCache {
+	return &idempotencyCache{
+		window:     window,
+		maxEntries: maxIdempotencyEntries,
+		timeGen:    timeGen,
+		entries:    make(map[idempotencyKey]*idempotencyEntry),
+	}
+}
+
+func (c *idempotencyCache) do(ctx context.Context, orgID, userID platform.ID, key, fingerprint string, fn func() (ImpactSummary, error)) (ImpactSummary, error) {
+	k := idempotencyKey{orgID: orgID, userID: userID, key: key}
+
+	c.mu.Lock()
+	c.removeExpired()
+	if e, ok := c.entries[k]; ok {
+		c.mu.Unlock()
+		if e.fingerprint != fingerprint {
+			return ImpactSummary{}, &errors.Error{
+				Code: errors.EUnprocessableEntity,
+				Msg:  "idempotency key was already used for a different apply",
+			}
+		}
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return ImpactSummary{}, ctx.Err()
+		}
+		if e.err != nil {
+			// the apply this request raced with failed, apply again
+			return c.do(ctx, orgID, userID, key, fingerprint, fn)
+		}
+		return e.impact, nil
+	}
+	if len(c.entries) >= c.maxEntries {
+		c.removeOldest()
+	}
+	e := &idempotencyEntry{fingerprint: fingerprint, done: make(chan struct{})}
+	c.entries[k] = e
+	c.mu.Unlock()
+
+	e.impact, e.err = fn()
+
+	c.mu.Lock()
+	if e.err != nil {
+		delete(c.entries, k)
+	} else {
+		e.expiresAt = c.timeGen.Now().Add(c.window)
+	}
+	c.mu.Unlock()
+	close(e.done)
+
+	return e.impact, e.err
+}
+
+// removeOldest removes the finished apply closest to leaving the window. It
+// must be called with the lock held.
+func (c *idempotencyCache) removeOldest() {
+	var (
+		oldest    idempotencyKey
+		expiresAt time.Time
+	)
+	for k, e := range c.entries {
+		if e.expiresAt.IsZero() {
+			// in flight
+			continue
+		}
+		if expiresAt.IsZero() || e.expiresAt.Before(expiresAt) {
+			oldest, expiresAt = k, e.expiresAt
+		}
+	}
+	if !expiresAt.IsZero() {
+		delete(c.entries, oldest)
+	}
+}
+
+// removeExpired must be called with the lock held.
+func (c *idempotencyCache) removeExpired() {
+	now := c.timeGen.Now()
+	for k, e := range c.entries {
+		if !e.expiresAt.IsZero() && !now.Before(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// fingerprint hashes what the apply does, to tell a retried apply from
+// another apply reusing its idempotency key.
+func (o ApplyOpt) fingerprint() (string, error) {
+	h := sha256.New()
+	for _, t := range o.Templates {
+		b, err := t.Encode(EncodingJSON)
+		if err != nil {
+			return "", err
+		}
+		h.Write(b)
+	}
+
+	sorted := func(keys []string) []string {
+		sort.Strings(keys)
+		return keys
+	}
+	var skipped, detached, removed []string
+	for r := range o.ResourcesToSkip {
+		skipped = append(skipped, string(r.Kind)+"/"+r.MetaName)
+	}
+	for r := range o.ResourcesToDetach {
+		detached = append(detached, string(r.Kind)+"/"+r.MetaName)
+	}
+	for r := range o.ResourcesToRemove {
+		removed = append(removed, string(r.Kind)+"/"+r.MetaName)
+	}
+
+	// maps of string keys encode in the order of their keys
+	b, err := json.Marshal(struct {
+		EnvRefs          map[string]interface{}
+		Secrets          map[string]string
+		StackID          platform.ID
+		Skip             []string
+		Detach           []string
+		Remove           []string
+		KindsToSkip      map[Kind]bool
+		KindsToApply     map[Kind]bool
+		MergeStrategy    MergeStrategy
+		ConflictPolicies map[Kind]ConflictPolicy
+	}{
+		EnvRefs:          o.EnvRefs,
+		Secrets:          o.MissingSecrets,
+		StackID:          o.StackID,
+		Skip:             sorted(skipped),
+		Detach:           sorted(detached),
+		Remove:           sorted(removed),
+		KindsToSkip:      o.KindsToSkip,
+		KindsToApply:     o.KindsToApply,
+		MergeStrategy:    o.MergeStrategy,
+		ConflictPolicies: o.ConflictPolicies,
+	})
+	if err != nil {
+		return "", err
+	}
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,120 @@
+package pkger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_idempotencyCache(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newIdempotencyCache(time.Hour, fakeTimeGen(func() time.Time { return now }))
+	ctx := context.Background()
+
+	var calls int
+	apply := func(stackID platform.ID, err error) func() (ImpactSummary, error) {
+		return func() (ImpactSummary, error) {
+			calls++
+			if err != nil {
+				return ImpactSummary{}, err
+			}
+			return ImpactSummary{StackID: stackID}, nil
+		}
+	}
+
+	impact, err := cache.do(ctx, 1, 1, "key", "fp", apply(10, nil))
+	require.NoError(t, err)
+	assert.Equal(t, platform.ID(10), impact.StackID)
+
+	impact, err = cache.do(ctx, 1, 1, "key", "fp", apply(20, nil))
+	require.NoError(t, err)
+	assert.Equal(t, platform.ID(10), impact.StackID, "repeated key returns the original impact")
+	assert.Equal(t, 1, calls)
+
+	impact, err = cache.do(ctx, 2, 1, "key", "fp", apply(30, nil))
+	require.NoError(t, err)
+	assert.Equal(t, platform.ID(30), impact.StackID, "keys are scoped to the org")
+
+	impact, err = cache.do(ctx, 1, 2, "key", "fp", apply(35, nil))
+	require.NoError(t, err)
+	assert.Equal(t, platform.ID(35), impact.StackID, "keys are scoped to the user")
+
+	_, err = cache.do(ctx, 1, 1, "key", "other", apply(36, nil))
+	assert.Equal(t, errors2.EUnprocessableEntity, errors2.ErrorCode(err), "key reused for another apply")
+
+	_, err = cache.do(ctx, 1, 1, "failing", "fp", apply(0, errors.New("apply failed")))
+	require.Error(t, err)
+	impact, err = cache.do(ctx, 1, 1, "failing", "fp", apply(40, nil))
+	require.NoError(t, err)
+	assert.Equal(t, platform.ID(40), impact.StackID, "failed applies are not remembered")
+
+	now = now.Add(time.Hour)
+	impact, err = cache.do(ctx, 1, 1, "key", "fp", apply(50, nil))
+	require.NoError(t, err)
+	assert.Equal(t, platform.ID(50), impact.StackID, "key is forgotten after the window")
+}
+
+func Test_idempotencyCache_bounded(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newIdempotencyCache(time.Hour, fakeTimeGen(func() time.Time { return now }))
+	cache.maxEntries = 2
+	ctx := context.Background()
+
+	apply := func(stackID platform.ID) func() (ImpactSummary, error) {
+		return func() (ImpactSummary, error) {
+			return ImpactSummary{StackID: stackID}, nil
+		}
+	}
+	for i, key := range []string{"a", "b", "c"} {
+		_, err := cache.do(ctx, 1, 1, key, "fp", apply(platform.ID(i+1)))
+		require.NoError(t, err)
+		now = now.Add(time.Minute)
+	}
+	assert.Len(t, cache.entries, 2)
+
+	impact, err := cache.do(ctx, 1, 1, "a", "fp", apply(10))
+	require.NoError(t, err)
+	assert.Equal(t, platform.ID(10), impact.StackID, "the oldest apply is forgotten")
+}
+
+func Test_idempotencyCache_concurrent(t *testing.T) {
+	cache := newIdempotencyCache(time.Hour, fakeTimeGen(time.Now))
+
+	release := make(chan struct{})
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	apply := func() (ImpactSummary, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		<-release
+		return ImpactSummary{StackID: 1}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]ImpactSummary, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = cache.do(context.Background(), 1, 1, "key", "fp", apply)
+		}(i)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, 1, calls, "in flight applies are not repeated")
+	for _, impact := range results {
+		assert.Equal(t, platform.ID(1), impact.StackID)
+	}
+}
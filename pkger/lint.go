@@ -0,0 +1,257 @@
+package pkger
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// LintSeverity is the severity of a lint finding.
+type LintSeverity string
+
+// Lint severities. A rule with LintSeverityOff is not run.
+const (
+	LintSeverityError   LintSeverity = "error"
+	LintSeverityWarning LintSeverity = "warning"
+	LintSeverityInfo    LintSeverity = "info"
+	LintSeverityOff     LintSeverity = "off"
+)
+
+func (s LintSeverity) ok() error {
+	switch s {
+	case LintSeverityError, LintSeverityWarning, LintSeverityInfo, LintSeverityOff:
+		return nil
+	default:
+		return fmt.Errorf("invalid severity %q; must be one of [%s, %s, %s, %s]",
+			s, LintSeverityError, LintSeverityWarning, LintSeverityInfo, LintSeverityOff)
+	}
+}
+
+// LintRuleID identifies a lint rule.
+type LintRuleID string
+
+// Lint rules run against a template.
+const (
+	// LintRuleValidation reports the errors of Template.Validate.
+	LintRuleValidation LintRuleID = "validation"
+	// LintRuleNamingConvention reports metadata names not matching the
+	// configured pattern. Defaults to lower kebab case.
+	LintRuleNamingConvention LintRuleID = "naming-convention"
+	// LintRuleMissingDescription reports resources without a description.
+	LintRuleMissingDescription LintRuleID = "missing-description"
+	// LintRuleDeprecatedKind reports objects of kinds that are no longer
+	// applied, in favor of more specific kinds.
+	LintRuleDeprecatedKind LintRuleID = "deprecated-kind"
+	// LintRuleUnusedEnvRef reports provided env refs the template does not
+	// reference.
+	LintRuleUnusedEnvRef LintRuleID = "unused-env-ref"
+)
+
+// DefaultLintNamingPattern is the default pattern of the naming-convention rule.
+const DefaultLintNamingPattern = `^[a-z][a-z0-9]*(-[a-z0-9]+)*$`
+
+var defaultLintRules = map[LintRuleID]LintRuleConfig{
+	LintRuleValidation:         {Severity: LintSeverityError},
+	LintRuleNamingConvention:   {Severity: LintSeverityWarning, Pattern: DefaultLintNamingPattern},
+	LintRuleMissingDescription: {Severity: LintSeverityInfo},
+	LintRuleDeprecatedKind:     {Severity: LintSeverityWarning},
+	LintRuleUnusedEnvRef:       {Severity: LintSeverityWarning},
+}
+
+// deprecatedKinds maps kinds that are no longer applied to their replacements.
+var deprecatedKinds = map[Kind][]Kind{
+	KindCheck: {KindCheckDeadman, KindCheckThreshold},
+	KindNotificationEndpoint: {
+		KindNotificationEndpointHTTP,
+		KindNotificationEndpointPagerDuty,
+		KindNotificationEndpointSlack,
+	},
+}
+
+// LintRuleConfig configures a lint rule. Zero values fall back to the
+// defaults of the rule.
+type LintRuleConfig struct {
+	Severity LintSeverity `json:"severity,omitempty" yaml:"severity,omitempty"`
+	// Pattern is the regular expression names must match. Only used by the
+	// naming-convention rule.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+}
+
+// LintFinding is a single issue found in a template by a lint rule.
+type LintFinding struct {
+	Rule     LintRuleID   `json:"rule" yaml:"rule"`
+	Severity LintSeverity `json:"severity" yaml:"severity"`
+	Kind     Kind         `json:"kind,omitempty" yaml:"kind,omitempty"`
+	MetaName string       `json:"templateMetaName,omitempty" yaml:"templateMetaName,omitempty"`
+	Index    *int         `json:"idx,omitempty" yaml:"idx,omitempty"`
+	Field    string       `json:"field,omitempty" yaml:"field,omitempty"`
+	Message  string       `json:"message" yaml:"message"`
+}
+
+// LintFindings are the findings of linting a template.
+type LintFindings []LintFinding
+
+// HasErrors indicates any of the findings is an error.
+func (f LintFindings) HasErrors() bool {
+	for _, finding := range f {
+		if finding.Severity == LintSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+type (
+	// LintOptFn configures the linting of a template.
+	LintOptFn func(opt *LintOpt)
+
+	// LintOpt are the options for linting a template.
+	LintOpt struct {
+		Rules   map[LintRuleID]LintRuleConfig
+		EnvRefs map[string]interface{}
+	}
+)
+
+// LintWithRule overrides the configuration of a rule.
+func LintWithRule(id LintRuleID, cfg LintRuleConfig) LintOptFn {
+	return func(opt *LintOpt) {
+		if opt.Rules == nil {
+			opt.Rules = make(map[LintRuleID]LintRuleConfig)
+		}
+		opt.Rules[id] = cfg
+	}
+}
+
+// LintWithEnvRefs provides the env refs the template is applied with.
+func LintWithEnvRefs(envRefs map[string]interface{}) LintOptFn {
+	return func(opt *LintOpt) {
+		opt.EnvRefs = envRefs
+	}
+}
+
+type lintRule struct {
+	severity LintSeverity
+	pattern  *regexp.Regexp
+}
+
+// Lint runs the lint rules against the template, extending the checks of
+// Template.Validate with conventions teams may want to enforce before a
+// template is applied. An error is returned for an invalid rule configuration.
+func Lint(t *Template, opts ...LintOptFn) (LintFindings, error) {
+	var opt LintOpt
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	rules := make(map[LintRuleID]lintRule, len(defaultLintRules))
+	for id, def := range defaultLintRules {
+		cfg := opt.Rules[id]
+		if cfg.Severity == "" {
+			cfg.Severity = def.Severity
+		}
+		if cfg.Pattern == "" {
+			cfg.Pattern = def.Pattern
+		}
+		if err := cfg.Severity.ok(); err != nil {
+			return nil, influxErr(errors.EInvalid, fmt.Sprintf("invalid rule %q", id), err)
+		}
+
+		rule := lintRule{severity: cfg.Severity}
+		if cfg.Pattern != "" {
+			re, err := regexp.Compile(cfg.Pattern)
+			if err != nil {
+				return nil, influxErr(errors.EInvalid, fmt.Sprintf("invalid pattern for rule %q", id), err)
+			}
+			rule.pattern = re
+		}
+		rules[id] = rule
+	}
+	for id := range opt.Rules {
+		if _, ok := defaultLintRules[id]; !ok {
+			return nil, influxErr(errors.EInvalid, fmt.Sprintf("unknown rule %q", id))
+		}
+	}
+
+	var findings LintFindings
+	add := func(id LintRuleID, f LintFinding) {
+		if rules[id].severity == LintSeverityOff {
+			return
+		}
+		f.Rule, f.Severity = id, rules[id].severity
+		findings = append(findings, f)
+	}
+
+	var validateErr error
+	if len(opt.EnvRefs) > 0 {
+		validateErr = t.applyEnvRefs(opt.EnvRefs)
+	} else {
+		validateErr = t.Validate()
+	}
+	if validateErr != nil && !IsParseErr(validateErr) {
+		add(LintRuleValidation, LintFinding{Message: validateErr.Error()})
+	}
+	for _, vErr := range convertParseErr(validateErr) {
+		f := LintFinding{
+			Kind:    Kind(vErr.Kind),
+			Field:   strings.Join(vErr.Fields, "."),
+			Message: vErr.Reason,
+		}
+		if len(vErr.Indexes) > 0 {
+			f.Index = vErr.Indexes[0]
+		}
+		add(LintRuleValidation, f)
+	}
+
+	for i, o := range t.Objects {
+		idx := i
+		ident := LintFinding{Kind: o.Kind, MetaName: o.Name(), Index: &idx}
+
+		if replacements, ok := deprecatedKinds[o.Kind]; ok {
+			f := ident
+			f.Field = fieldKind
+			f.Message = fmt.Sprintf("kind %s is not applied; use one of %s", o.Kind, joinKinds(replacements))
+			add(LintRuleDeprecatedKind, f)
+		}
+
+		if pattern := rules[LintRuleNamingConvention].pattern; pattern != nil && !pattern.MatchString(o.Name()) {
+			f := ident
+			f.Field = fieldMetadata + "." + fieldName
+			f.Message = fmt.Sprintf("name %q does not match the naming convention %q", o.Name(), pattern.String())
+			add(LintRuleNamingConvention, f)
+		}
+
+		if o.Kind != KindApplyHook && o.Spec.stringShort(fieldDescription) == "" {
+			f := ident
+			f.Field = fieldSpec + "." + fieldDescription
+			f.Message = "resource has no description"
+			add(LintRuleMissingDescription, f)
+		}
+	}
+
+	unused := make([]string, 0, len(opt.EnvRefs))
+	for k := range opt.EnvRefs {
+		if _, ok := t.mEnv[k]; !ok {
+			unused = append(unused, k)
+		}
+	}
+	sort.Strings(unused)
+	for _, k := range unused {
+		add(LintRuleUnusedEnvRef, LintFinding{
+			Field:   k,
+			Message: fmt.Sprintf("env ref %q is provided but not referenced by the template", k),
+		})
+	}
+
+	return findings, nil
+}
+
+func joinKinds(kinds []Kind) string {
+	out := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		out = append(out, k.String())
+	}
+	return "[" + strings.Join(out, ", ") + "]"
+}
@@ -0,0 +1,144 @@
+package pkger_test
+
+import (
+	"testing"
+
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/pkger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const lintTemplate = `
+apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: rucket-1
+spec:
+  description: all good
+---
+apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: rucket--2
+---
+apiVersion: influxdata.com/v2alpha1
+kind: Check
+metadata:
+  name: check-1
+spec:
+  description: generic check
+`
+
+func TestLint(t *testing.T) {
+	newTemplate := func(t *testing.T) *pkger.Template {
+		t.Helper()
+
+		template, err := pkger.Parse(pkger.EncodingYAML, pkger.FromString(lintTemplate), pkger.ValidSkipParseError())
+		require.NoError(t, err)
+		return template
+	}
+
+	idx := func(i int) *int {
+		return &i
+	}
+
+	t.Run("reports findings of the default rules", func(t *testing.T) {
+		findings, err := pkger.Lint(newTemplate(t), pkger.LintWithEnvRefs(map[string]interface{}{
+			"unused-key": "foo",
+		}))
+		require.NoError(t, err)
+
+		expected := pkger.LintFindings{
+			{
+				Rule:     pkger.LintRuleNamingConvention,
+				Severity: pkger.LintSeverityWarning,
+				Kind:     pkger.KindBucket,
+				MetaName: "rucket--2",
+				Index:    idx(1),
+				Field:    "metadata.name",
+				Message:  `name "rucket--2" does not match the naming convention "` + pkger.DefaultLintNamingPattern + `"`,
+			},
+			{
+				Rule:     pkger.LintRuleMissingDescription,
+				Severity: pkger.LintSeverityInfo,
+				Kind:     pkger.KindBucket,
+				MetaName: "rucket--2",
+				Index:    idx(1),
+				Field:    "spec.description",
+				Message:  "resource has no description",
+			},
+			{
+				Rule:     pkger.LintRuleDeprecatedKind,
+				Severity: pkger.LintSeverityWarning,
+				Kind:     pkger.KindCheck,
+				MetaName: "check-1",
+				Index:    idx(2),
+				Field:    "kind",
+				Message:  "kind Check is not applied; use one of [CheckDeadman, CheckThreshold]",
+			},
+			{
+				Rule:     pkger.LintRuleUnusedEnvRef,
+				Severity: pkger.LintSeverityWarning,
+				Field:    "unused-key",
+				Message:  `env ref "unused-key" is provided but not referenced by the template`,
+			},
+		}
+		assert.Equal(t, expected, findings)
+		assert.False(t, findings.HasErrors())
+	})
+
+	t.Run("applies the configured rules", func(t *testing.T) {
+		findings, err := pkger.Lint(newTemplate(t),
+			pkger.LintWithRule(pkger.LintRuleNamingConvention, pkger.LintRuleConfig{
+				Severity: pkger.LintSeverityError,
+				Pattern:  "^check-",
+			}),
+			pkger.LintWithRule(pkger.LintRuleMissingDescription, pkger.LintRuleConfig{
+				Severity: pkger.LintSeverityOff,
+			}),
+			pkger.LintWithRule(pkger.LintRuleDeprecatedKind, pkger.LintRuleConfig{
+				Severity: pkger.LintSeverityOff,
+			}),
+		)
+		require.NoError(t, err)
+
+		var names []string
+		for _, f := range findings {
+			assert.Equal(t, pkger.LintRuleNamingConvention, f.Rule)
+			assert.Equal(t, pkger.LintSeverityError, f.Severity)
+			names = append(names, f.MetaName)
+		}
+		assert.Equal(t, []string{"rucket-1", "rucket--2"}, names)
+		assert.True(t, findings.HasErrors())
+	})
+
+	t.Run("rejects invalid rule configuration", func(t *testing.T) {
+		tests := []struct {
+			name string
+			opt  pkger.LintOptFn
+		}{
+			{
+				name: "unknown rule",
+				opt:  pkger.LintWithRule("no-such-rule", pkger.LintRuleConfig{}),
+			},
+			{
+				name: "invalid severity",
+				opt:  pkger.LintWithRule(pkger.LintRuleUnusedEnvRef, pkger.LintRuleConfig{Severity: "fatal"}),
+			},
+			{
+				name: "invalid pattern",
+				opt:  pkger.LintWithRule(pkger.LintRuleNamingConvention, pkger.LintRuleConfig{Pattern: "(["}),
+			},
+		}
+
+		for _, tt := range tests {
+			fn := func(t *testing.T) {
+				_, err := pkger.Lint(newTemplate(t), tt.opt)
+				require.Error(t, err)
+				assert.Equal(t, errors2.EInvalid, errors2.ErrorCode(err))
+			}
+			t.Run(tt.name, fn)
+		}
+	})
+}
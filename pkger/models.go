@@ -16,6 +16,8 @@ import (
 // Package kind types.
 const (
 	KindUnknown                       Kind = ""
+	KindApplyHook                     Kind = "ApplyHook"
+	KindAuthorization                 Kind = "Authorization"
 	KindBucket                        Kind = "Bucket"
 	KindCheck                         Kind = "Check"
 	KindCheckDeadman                  Kind = "CheckDeadman"
@@ -43,6 +45,8 @@ func Kinds() []Kind {
 }
 
 var kinds = map[Kind]bool{
+	KindApplyHook:                     true,
+	KindAuthorization:                 true,
 	KindBucket:                        true,
 	KindCheck:                         true,
 	KindCheckDeadman:                  true,
@@ -87,6 +91,8 @@ func (k Kind) OK() error {
 // ResourceType converts a kind to a known resource type (if applicable).
 func (k Kind) ResourceType() influxdb.ResourceType {
 	switch k {
+	case KindAuthorization:
+		return influxdb.AuthorizationsResourceType
 	case KindBucket:
 		return influxdb.BucketsResourceType
 	case KindCheck, KindCheckDeadman, KindCheckThreshold:
@@ -152,6 +158,36 @@ func (d DiffIdentifier) IsNew() bool {
 	return d.ID == 0
 }
 
+// DiffField is a single field that differs between an existing resource and
+// the template. The Path locates the field within the JSON representation of
+// the resource, i.e. charts[0].properties.queries[0].text. A nil Old or New
+// value indicates the field is added or removed.
+type DiffField struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// ResourceChange is the change applying a template makes to a resource.
+type ResourceChange string
+
+// Changes applying a template makes to a resource.
+const (
+	ResourceChangeCreated   ResourceChange = "created"
+	ResourceChangeUpdated   ResourceChange = "updated"
+	ResourceChangeRemoved   ResourceChange = "removed"
+	ResourceChangeUnchanged ResourceChange = "unchanged"
+)
+
+// ResourceImpact is the change applying a template makes to one of its
+// resources.
+type ResourceImpact struct {
+	Kind     Kind           `json:"kind" yaml:"kind"`
+	MetaName string         `json:"templateMetaName" yaml:"templateMetaName"`
+	ID       SafeID         `json:"id" yaml:"id"`
+	Change   ResourceChange `json:"change" yaml:"change"`
+}
+
 // Diff is the result of a service DryRun call. The diff outlines
 // what is new and or updated from the current state of the platform.
 type Diff struct {
@@ -167,6 +203,77 @@ type Diff struct {
 	Variables             []DiffVariable             `json:"variables"`
 }
 
+// resources returns the identifiers of all resources within the diff.
+func (d Diff) resources() []DiffIdentifier {
+	out := make([]DiffIdentifier, 0)
+	for _, r := range d.Buckets {
+		out = append(out, r.DiffIdentifier)
+	}
+	for _, r := range d.Checks {
+		out = append(out, r.DiffIdentifier)
+	}
+	for _, r := range d.Dashboards {
+		out = append(out, r.DiffIdentifier)
+	}
+	for _, r := range d.Labels {
+		out = append(out, r.DiffIdentifier)
+	}
+	for _, r := range d.NotificationEndpoints {
+		out = append(out, r.DiffIdentifier)
+	}
+	for _, r := range d.NotificationRules {
+		out = append(out, r.DiffIdentifier)
+	}
+	for _, r := range d.Tasks {
+		out = append(out, r.DiffIdentifier)
+	}
+	for _, r := range d.Telegrafs {
+		out = append(out, r.DiffIdentifier)
+	}
+	for _, r := range d.Variables {
+		out = append(out, r.DiffIdentifier)
+	}
+	return out
+}
+
+// Impacts returns the change applying the diff makes to each resource. An
+// existing resource is unchanged when none of its fields differ from the
+// template, as in the rendered diff.
+func (d Diff) Impacts() []ResourceImpact {
+	out := make([]ResourceImpact, 0)
+	for _, g := range d.renderGroups() {
+		for _, e := range g.entries {
+			if e.id.Kind == "" {
+				// label mappings are not resources of their own
+				continue
+			}
+			out = append(out, ResourceImpact{
+				Kind:     e.id.Kind,
+				MetaName: e.id.MetaName,
+				ID:       e.id.ID,
+				Change:   e.change(),
+			})
+		}
+	}
+	return out
+}
+
+// IsNoOp reports whether applying the diff changes nothing, because every
+// resource and label mapping of the template is unchanged.
+func (d Diff) IsNoOp() bool {
+	for _, m := range d.LabelMappings {
+		if !IsExisting(m.StateStatus) {
+			return false
+		}
+	}
+	for _, r := range d.Impacts() {
+		if r.Change != ResourceChangeUnchanged {
+			return false
+		}
+	}
+	return true
+}
+
 // HasConflicts provides a binary t/f if there are any changes within package
 // after dry run is complete.
 func (d Diff) HasConflicts() bool {
@@ -251,13 +358,18 @@ type (
 
 		New DiffDashboardValues  `json:"new"`
 		Old *DiffDashboardValues `json:"old"`
+
+		// Changes lists the individual fields, including those of the
+		// charts, that differ between the existing dashboard and the template.
+		Changes []DiffField `json:"changes,omitempty"`
 	}
 
 	// DiffDashboardValues are values for a dashboard.
 	DiffDashboardValues struct {
-		Name   string      `json:"name"`
-		Desc   string      `json:"description"`
-		Charts []DiffChart `json:"charts"`
+		Name      string                      `json:"name"`
+		Desc      string                      `json:"description"`
+		Charts    []DiffChart                 `json:"charts"`
+		TimeRange *influxdb.TimeRangeOverride `json:"timeRange,omitempty"`
 	}
 )
 
@@ -392,6 +504,10 @@ type (
 
 		New DiffTaskValues  `json:"new"`
 		Old *DiffTaskValues `json:"old"`
+
+		// Changes lists the individual fields that differ between
+		// the existing task and the template.
+		Changes []DiffField `json:"changes,omitempty"`
 	}
 
 	// DiffTaskValues are the values for an individual task.
@@ -401,6 +517,8 @@ type (
 		Description string          `json:"description"`
 		Every       string          `json:"every"`
 		Offset      string          `json:"offset"`
+		Concurrency int64           `json:"concurrency,omitempty"`
+		Retry       int64           `json:"retry,omitempty"`
 		Query       string          `json:"query"`
 		Status      influxdb.Status `json:"status"`
 	}
@@ -524,6 +642,8 @@ type SummaryDashboard struct {
 	Description string         `json:"description"`
 	Charts      []SummaryChart `json:"charts"`
 
+	TimeRange *influxdb.TimeRangeOverride `json:"timeRange,omitempty"`
+
 	LabelAssociations []SummaryLabel `json:"labelAssociations"`
 }
 
@@ -535,6 +655,8 @@ type SummaryChart struct {
 	YPosition int `json:"yPos"`
 	Height    int `json:"height"`
 	Width     int `json:"width"`
+
+	TimeRange *influxdb.TimeRangeOverride `json:"timeRange,omitempty"`
 }
 
 // MarshalJSON marshals a summary chart.
@@ -682,6 +804,9 @@ type SummaryTask struct {
 	Description string          `json:"description"`
 	Every       string          `json:"every"`
 	Offset      string          `json:"offset"`
+	Concurrency int64           `json:"concurrency,omitempty"`
+	Retry       int64           `json:"retry,omitempty"`
+	Secrets     []string        `json:"secrets,omitempty"`
 	Query       string          `json:"query"`
 	Status      influxdb.Status `json:"status"`
 
@@ -708,3 +833,44 @@ type SummaryVariable struct {
 
 	LabelAssociations []SummaryLabel `json:"labelAssociations"`
 }
+
+// SummaryAuthorization provides a summary of a template authorization. The
+// token is only set once the authorization is created by an apply.
+type SummaryAuthorization struct {
+	SummaryIdentifier
+	ID          SafeID                `json:"id,omitempty"`
+	Description string                `json:"description"`
+	Permissions []influxdb.Permission `json:"permissions"`
+	Token       string                `json:"token,omitempty"`
+}
+
+// HookPhase identifies when a template hook runs relative to the application
+// of the template's resources.
+type HookPhase string
+
+// Hook phases.
+const (
+	HookPhasePreApply  HookPhase = "preApply"
+	HookPhasePostApply HookPhase = "postApply"
+)
+
+// HookStatus indicates the outcome of a template hook.
+type HookStatus string
+
+// Hook statuses. Hooks are never run during a dry run and are reported
+// as pending instead.
+const (
+	HookStatusPending HookStatus = "pending"
+	HookStatusSuccess HookStatus = "success"
+	HookStatusFailed  HookStatus = "failed"
+)
+
+// HookResult records the execution of an individual template hook.
+type HookResult struct {
+	MetaName string     `json:"templateMetaName"`
+	Phase    HookPhase  `json:"phase"`
+	Type     string     `json:"type"`
+	Status   HookStatus `json:"status"`
+	Output   string     `json:"output,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
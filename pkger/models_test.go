@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -468,6 +469,135 @@ func TestTemplate(t *testing.T) {
 				t.Run(tt.name, fn)
 			}
 		})
+
+		t.Run("field changes", func(t *testing.T) {
+			t.Run("dashboard", func(t *testing.T) {
+				newChart := func(query string, height int) DiffChart {
+					return DiffChart{
+						Properties: influxdb.SingleStatViewProperties{
+							Type:    influxdb.ViewPropertyTypeSingleStat,
+							Queries: []influxdb.DashboardQuery{{Text: query}},
+						},
+						Height: height,
+						Width:  4,
+					}
+				}
+
+				oldValues := DiffDashboardValues{
+					Name:   "dash",
+					Desc:   "old desc",
+					Charts: []DiffChart{newChart("from(bucket: \"a\")", 2)},
+				}
+				newValues := DiffDashboardValues{
+					Name: "dash",
+					Desc: "new desc",
+					Charts: []DiffChart{
+						newChart("from(bucket: \"b\")", 3),
+						newChart("from(bucket: \"c\")", 3),
+					},
+				}
+
+				changes := diffFields(oldValues, newValues)
+				require.Len(t, changes, 4)
+				assert.Equal(t, DiffField{Path: "charts[0].height", Old: float64(2), New: float64(3)}, changes[0])
+				assert.Equal(t, DiffField{
+					Path: "charts[0].properties.queries[0].text",
+					Old:  "from(bucket: \"a\")",
+					New:  "from(bucket: \"b\")",
+				}, changes[1])
+				assert.Equal(t, "charts[1]", changes[2].Path)
+				assert.Nil(t, changes[2].Old)
+				assert.NotNil(t, changes[2].New)
+				assert.Equal(t, DiffField{Path: "description", Old: "old desc", New: "new desc"}, changes[3])
+			})
+
+			t.Run("task", func(t *testing.T) {
+				oldValues := DiffTaskValues{
+					Name:   "task",
+					Every:  "5m",
+					Query:  "from(bucket: \"a\")",
+					Status: influxdb.Active,
+				}
+				newValues := oldValues
+				newValues.Every = "10m"
+				newValues.Status = influxdb.Inactive
+
+				assert.Equal(t, []DiffField{
+					{Path: "every", Old: "5m", New: "10m"},
+					{Path: "status", Old: "active", New: "inactive"},
+				}, diffFields(oldValues, newValues))
+
+				assert.Empty(t, diffFields(oldValues, oldValues))
+			})
+
+			t.Run("task without offset", func(t *testing.T) {
+				st := &stateTask{
+					stateStatus: StateStatusExists,
+					parserTask: &task{
+						identity: identity{name: &references{val: "task"}},
+						every:    5 * time.Minute,
+					},
+					existing: &taskmodel.Task{
+						Name:   "task",
+						Every:  "5m0s",
+						Status: string(influxdb.Active),
+					},
+				}
+
+				diff := st.diffTask()
+				require.NotNil(t, diff.Old)
+				assert.Equal(t, "0s", diff.Old.Offset)
+				assert.Empty(t, diff.Changes)
+			})
+		})
+
+		t.Run("Impacts", func(t *testing.T) {
+			labelValues := DiffLabelValues{Name: "label", Color: "#FFF"}
+			changedValues := labelValues
+			changedValues.Color = "#000"
+
+			diff := Diff{
+				Labels: []DiffLabel{
+					{
+						DiffIdentifier: DiffIdentifier{ID: 1, Kind: KindLabel, MetaName: "unchanged", StateStatus: StateStatusExists},
+						Old:            &labelValues,
+						New:            labelValues,
+					},
+					{
+						DiffIdentifier: DiffIdentifier{ID: 2, Kind: KindLabel, MetaName: "updated", StateStatus: StateStatusExists},
+						Old:            &labelValues,
+						New:            changedValues,
+					},
+					{
+						DiffIdentifier: DiffIdentifier{Kind: KindLabel, MetaName: "created", StateStatus: StateStatusNew},
+						New:            labelValues,
+					},
+					{
+						DiffIdentifier: DiffIdentifier{ID: 3, Kind: KindLabel, MetaName: "removed", StateStatus: StateStatusRemove},
+						Old:            &labelValues,
+					},
+				},
+			}
+
+			assert.Equal(t, []ResourceImpact{
+				{Kind: KindLabel, MetaName: "unchanged", ID: 1, Change: ResourceChangeUnchanged},
+				{Kind: KindLabel, MetaName: "updated", ID: 2, Change: ResourceChangeUpdated},
+				{Kind: KindLabel, MetaName: "created", Change: ResourceChangeCreated},
+				{Kind: KindLabel, MetaName: "removed", ID: 3, Change: ResourceChangeRemoved},
+			}, diff.Impacts())
+			assert.False(t, diff.IsNoOp())
+
+			diff.Labels = diff.Labels[:1]
+			assert.True(t, diff.IsNoOp())
+
+			diff.LabelMappings = []DiffLabelMapping{{StateStatus: StateStatusExists}}
+			assert.True(t, diff.IsNoOp())
+
+			diff.LabelMappings = append(diff.LabelMappings, DiffLabelMapping{StateStatus: StateStatusNew})
+			assert.False(t, diff.IsNoOp())
+
+			assert.True(t, Diff{}.IsNoOp())
+		})
 	})
 
 	t.Run("Contains", func(t *testing.T) {
@@ -2,12 +2,14 @@ package pkger
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net"
 	"net/http"
 	"net/url"
 	"path"
@@ -15,15 +17,15 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/ast/edit"
-	fluxurl "github.com/influxdata/flux/dependencies/url"
 	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/pkg/jsonnet"
+	"github.com/influxdata/influxdb/v2/pkger/registry"
 	"github.com/influxdata/influxdb/v2/task/options"
 	"gopkg.in/yaml.v3"
 )
@@ -79,6 +81,14 @@ func Parse(encoding Encoding, readerFn ReaderFn, opts ...ValidateOptFn) (*Templa
 		return nil, err
 	}
 
+	// the contents are hashed so that a stack can record exactly which
+	// version of its sources was applied.
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	r = bytes.NewReader(b)
+
 	var pkgFn func(io.Reader, ...ValidateOptFn) (*Template, error)
 	switch encoding {
 	case EncodingJSON:
@@ -98,10 +108,25 @@ func Parse(encoding Encoding, readerFn ReaderFn, opts ...ValidateOptFn) (*Templa
 		return nil, err
 	}
 	pkg.sources = []string{source}
+	pkg.sourceVersions = []StackSourceVersion{newStackSourceVersion(source, b)}
 
 	return pkg, nil
 }
 
+func newStackSourceVersion(source string, contents []byte) StackSourceVersion {
+	sum := sha256.Sum256(contents)
+	v := StackSourceVersion{
+		Source: source,
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+	if registry.IsReference(source) {
+		if ref, err := registry.ParseReference(source); err == nil {
+			v.Version = ref.Version
+		}
+	}
+	return v
+}
+
 // FromFile reads a file from disk and provides a reader from it.
 func FromFile(filePath string) ReaderFn {
 	return func() (io.Reader, string, error) {
@@ -148,34 +173,6 @@ func FromString(s string) ReaderFn {
 	}
 }
 
-// NewDefaultHTTPClient creates a client with the specified flux IP validator.
-// This is copied from flux/dependencies/http/http.go
-func NewDefaultHTTPClient(urlValidator fluxurl.Validator) *http.Client {
-	// Control is called after DNS lookup, but before the network
-	// connection is initiated.
-	control := func(network, address string, c syscall.RawConn) error {
-		host, _, err := net.SplitHostPort(address)
-		if err != nil {
-			return err
-		}
-
-		ip := net.ParseIP(host)
-		return urlValidator.ValidateIP(ip)
-	}
-
-	dialer := &net.Dialer{
-		Timeout: time.Minute,
-		Control: control,
-		// DualStack is deprecated
-	}
-
-	return &http.Client{
-		Transport: &http.Transport{
-			DialContext: dialer.DialContext,
-		},
-	}
-}
-
 // FromHTTPRequest parses a pkg from the request body of a HTTP request. This is
 // very useful when using packages that are hosted..
 func FromHTTPRequest(addr string, client *http.Client) ReaderFn {
@@ -202,6 +199,19 @@ func FromHTTPRequest(addr string, client *http.Client) ReaderFn {
 	}
 }
 
+// FromRegistry retrieves the template the registry resolves the reference to.
+// The contents are verified against the checksum recorded in the registry index.
+func FromRegistry(ctx context.Context, reg *registry.Client, ref registry.Reference) ReaderFn {
+	return func() (io.Reader, string, error) {
+		b, v, err := reg.Fetch(ctx, ref)
+		if err != nil {
+			return nil, ref.String(), err
+		}
+		ref.Version = v.Version
+		return bytes.NewReader(b), ref.String(), nil
+	}
+}
+
 const (
 	githubRawContentHost = "raw.githubusercontent.com"
 	githubHost           = "github.com"
@@ -243,7 +253,11 @@ func parseJsonnet(r io.Reader, opts ...ValidateOptFn) (*Template, error) {
 	// For security, we'll default to disabling parsing jsonnet but allow callers to override the behavior via
 	// EnableJsonnet(). Enabling jsonnet might be useful for client code where parsing jsonnet could be acceptable.
 	if opt.enableJsonnet {
-		return parse(jsonnet.NewDecoder(r), opts...)
+		dec := jsonnet.NewDecoder(r,
+			jsonnet.WithExtVars(opt.jsonnetExtVars),
+			jsonnet.WithTLAVars(opt.jsonnetTLAVars),
+		)
+		return parse(dec, opts...)
 	}
 	return nil, fmt.Errorf("%s: jsonnet", ErrInvalidEncoding)
 }
@@ -380,9 +394,13 @@ func (k Object) SetMetadataName(name string) {
 // lib (looking at you yaml/v2). This allows us to parse it and leave the matching
 // to another power, the graphing of the package is handled within itself.
 type Template struct {
-	Objects []Object `json:"-" yaml:"-"`
-	sources []string
+	Objects        []Object `json:"-" yaml:"-"`
+	sources        []string
+	sourceVersions []StackSourceVersion
+	objectSources  []string
 
+	mAuthorizations        map[string]*authorization
+	mHooks                 map[string]*hook
 	mLabels                map[string]*label
 	mBuckets               map[string]*bucket
 	mChecks                map[string]*check
@@ -524,12 +542,21 @@ func (p *Template) applySecrets(secrets map[string]string) {
 // by its kind and metadata.Name (MetaName) field.
 func (p *Template) Contains(k Kind, pkgName string) bool {
 	switch k {
+	case KindApplyHook:
+		_, ok := p.mHooks[pkgName]
+		return ok
+	case KindAuthorization:
+		_, ok := p.mAuthorizations[pkgName]
+		return ok
 	case KindBucket:
 		_, ok := p.mBuckets[pkgName]
 		return ok
 	case KindCheck, KindCheckDeadman, KindCheckThreshold:
 		_, ok := p.mChecks[pkgName]
 		return ok
+	case KindDashboard:
+		_, ok := p.mDashboards[pkgName]
+		return ok
 	case KindLabel:
 		_, ok := p.mLabels[pkgName]
 		return ok
@@ -555,16 +582,130 @@ func (p *Template) Contains(k Kind, pkgName string) bool {
 	return false
 }
 
+// MergeStrategy decides how Combine resolves templates that define a
+// resource of the same kind and metadata name.
+type MergeStrategy string
+
+// merge strategies
+const (
+	// MergeStrategyError fails the combine with a CombineConflictError.
+	MergeStrategyError MergeStrategy = "error"
+	// MergeStrategyFirstWins keeps the resource from the first template.
+	MergeStrategyFirstWins MergeStrategy = "firstWins"
+	// MergeStrategyLastWins keeps the resource from the last template.
+	MergeStrategyLastWins MergeStrategy = "lastWins"
+)
+
+// OK validates the merge strategy.
+func (m MergeStrategy) OK() error {
+	switch m {
+	case MergeStrategyError, MergeStrategyFirstWins, MergeStrategyLastWins:
+		return nil
+	default:
+		return fmt.Errorf("invalid merge strategy %q", m)
+	}
+}
+
+// ResourceConflict is a resource defined by more than one template.
+// Sources lists the source of each definition in the order combined.
+type ResourceConflict struct {
+	Kind     Kind
+	MetaName string
+	Sources  []string
+}
+
+// CombineConflictError is returned by Combine when templates define the
+// same resource and the merge strategy is MergeStrategyError.
+type CombineConflictError struct {
+	Conflicts []ResourceConflict
+}
+
+func (e *CombineConflictError) Error() string {
+	msgs := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		msgs = append(msgs, fmt.Sprintf("%s %q is defined in %s", c.Kind, c.MetaName, strings.Join(c.Sources, " and ")))
+	}
+	return "templates define duplicate resources: " + strings.Join(msgs, "; ")
+}
+
 // Combine combines pkgs together. Is useful when you want to take multiple disparate pkgs
 // and compile them into one to take advantage of the parser and service guarantees.
+// Resources of the same kind and metadata name defined by more than one pkg are
+// resolved by the merge strategy, see WithMergeStrategy.
 func Combine(pkgs []*Template, validationOpts ...ValidateOptFn) (*Template, error) {
+	opt := &validateOpt{}
+	for _, o := range validationOpts {
+		o(opt)
+	}
+
+	type objectKey struct {
+		group    string
+		metaName string
+	}
+	type seenObject struct {
+		pkg      int
+		idx      int
+		conflict int
+	}
+	seen := make(map[objectKey]seenObject)
+
 	newPkg := new(Template)
-	for _, p := range pkgs {
+	var conflicts []ResourceConflict
+	for pkgIdx, p := range pkgs {
 		if len(p.Objects) == 0 {
 			continue
 		}
 		newPkg.sources = append(newPkg.sources, p.sources...)
-		newPkg.Objects = append(newPkg.Objects, p.Objects...)
+		newPkg.sourceVersions = append(newPkg.sourceVersions, p.sourceVersions...)
+
+		for i, o := range p.Objects {
+			// a combined template remembers the source of each of its objects
+			source := formatSources(p.sources)
+			if len(p.objectSources) == len(p.Objects) {
+				source = p.objectSources[i]
+			}
+
+			metaName := o.Name()
+			group := string(o.Kind.ResourceType())
+			if group == "" {
+				group = string(o.Kind)
+			}
+			k := objectKey{group: group, metaName: metaName}
+
+			// duplicates within a single pkg are left to its validation
+			prev, ok := seen[k]
+			if !ok || metaName == "" || prev.pkg == pkgIdx {
+				seen[k] = seenObject{pkg: pkgIdx, idx: len(newPkg.Objects), conflict: -1}
+				newPkg.Objects = append(newPkg.Objects, o)
+				newPkg.objectSources = append(newPkg.objectSources, source)
+				continue
+			}
+
+			switch opt.mergeStrategy {
+			case MergeStrategyFirstWins:
+			case MergeStrategyLastWins:
+				newPkg.Objects[prev.idx] = o
+				newPkg.objectSources[prev.idx] = source
+				seen[k] = seenObject{pkg: pkgIdx, idx: prev.idx, conflict: -1}
+			default:
+				if prev.conflict < 0 {
+					prev.conflict = len(conflicts)
+					seen[k] = prev
+					conflicts = append(conflicts, ResourceConflict{
+						Kind:     newPkg.Objects[prev.idx].Kind,
+						MetaName: metaName,
+						Sources:  []string{newPkg.objectSources[prev.idx]},
+					})
+				}
+				conflicts[prev.conflict].Sources = append(conflicts[prev.conflict].Sources, source)
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, &errors2.Error{
+			Code: errors2.EConflict,
+			Err:  &CombineConflictError{Conflicts: conflicts},
+		}
 	}
 
 	return newPkg, newPkg.Validate(validationOpts...)
@@ -575,6 +716,11 @@ type (
 		minResources  bool
 		skipValidate  bool
 		enableJsonnet bool
+
+		jsonnetExtVars map[string]string
+		jsonnetTLAVars map[string]string
+
+		mergeStrategy MergeStrategy
 	}
 
 	// ValidateOptFn provides a means to disable desired validation checks.
@@ -588,6 +734,23 @@ func EnableJsonnet() ValidateOptFn {
 	}
 }
 
+// WithJsonnetVars passes external variables and top-level arguments to the
+// jsonnet VM. It has no effect unless jsonnet is enabled.
+func WithJsonnetVars(extVars, tlaVars map[string]string) ValidateOptFn {
+	return func(opt *validateOpt) {
+		opt.jsonnetExtVars = extVars
+		opt.jsonnetTLAVars = tlaVars
+	}
+}
+
+// WithMergeStrategy sets how Combine resolves resources defined by more than
+// one template. Defaults to MergeStrategyError.
+func WithMergeStrategy(m MergeStrategy) ValidateOptFn {
+	return func(opt *validateOpt) {
+		opt.mergeStrategy = m
+	}
+}
+
 // ValidWithoutResources ignores the validation check for minimum number
 // of resources. This is useful for the service Create to ignore this and
 // allow the creation of a pkg without resources.
@@ -660,6 +823,28 @@ func (p *Template) checks() []*check {
 	return checks
 }
 
+func (p *Template) authorizations() []*authorization {
+	auths := make([]*authorization, 0, len(p.mAuthorizations))
+	for _, a := range p.mAuthorizations {
+		auths = append(auths, a)
+	}
+
+	sort.Slice(auths, func(i, j int) bool { return auths[i].MetaName() < auths[j].MetaName() })
+
+	return auths
+}
+
+func (p *Template) hooks() []*hook {
+	hooks := make([]*hook, 0, len(p.mHooks))
+	for _, h := range p.mHooks {
+		hooks = append(hooks, h)
+	}
+
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].MetaName() < hooks[j].MetaName() })
+
+	return hooks
+}
+
 func (p *Template) labels() []*label {
 	labels := make(sortedLabels, 0, len(p.mLabels))
 	for _, l := range p.mLabels {
@@ -824,6 +1009,9 @@ func (p *Template) graphResources() error {
 		p.graphNotificationRules,
 		p.graphTasks,
 		p.graphTelegrafs,
+		p.graphHooks,
+		// authorizations are last, they reference the other resources
+		p.graphAuthorizations,
 	}
 
 	var pErr parseErr
@@ -898,6 +1086,83 @@ func (p *Template) graphBuckets() *parseErr {
 	})
 }
 
+func (p *Template) graphHooks() *parseErr {
+	p.mHooks = make(map[string]*hook)
+	tracker := p.trackNames(false)
+	return p.eachResource(KindApplyHook, func(o Object) []validationErr {
+		ident, errs := tracker(o)
+		if len(errs) > 0 {
+			return errs
+		}
+
+		h := &hook{
+			identity: ident,
+			phase:    HookPhase(strings.TrimSpace(o.Spec.stringShort(fieldHookPhase))),
+			hookType: normStr(o.Spec.stringShort(fieldType)),
+			query:    strings.TrimSpace(o.Spec.stringShort(fieldQuery)),
+			url:      o.Spec.stringShort(fieldHookURL),
+			method:   strings.TrimSpace(strings.ToUpper(o.Spec.stringShort(fieldHookMethod))),
+			headers:  o.Spec.mapStrStr(fieldHookHeaders),
+			body:     o.Spec.stringShort(fieldHookBody),
+		}
+		if h.hookType == hookTypeHTTP && h.method == "" {
+			h.method = http.MethodPost
+		}
+
+		p.mHooks[h.MetaName()] = h
+		p.setRefs(h.name, h.displayName)
+
+		return h.valid()
+	})
+}
+
+func (p *Template) graphAuthorizations() *parseErr {
+	p.mAuthorizations = make(map[string]*authorization)
+	tracker := p.trackNames(false)
+	return p.eachResource(KindAuthorization, func(o Object) []validationErr {
+		ident, errs := tracker(o)
+		if len(errs) > 0 {
+			return errs
+		}
+
+		a := &authorization{
+			identity:    ident,
+			description: o.Spec.stringShort(fieldDescription),
+		}
+		for _, r := range o.Spec.slcResource(fieldAuthPermissions) {
+			k, _ := r.kind()
+			a.permissions = append(a.permissions, authPermission{
+				action:   influxdb.Action(normStr(r.stringShort(fieldAuthAction))),
+				kind:     k,
+				metaName: r.Name(),
+			})
+		}
+
+		p.mAuthorizations[a.MetaName()] = a
+		p.setRefs(a.name, a.displayName)
+
+		if vErrs := a.valid(); len(vErrs) > 0 {
+			return vErrs
+		}
+
+		var vErrs []validationErr
+		for i, perm := range a.permissions {
+			if p.Contains(perm.kind, perm.metaName) {
+				continue
+			}
+			vErrs = append(vErrs, validationErr{
+				Field: fieldAuthPermissions,
+				Index: intPtr(i),
+				Msg:   fmt.Sprintf("template has no %s with metadata name %q", perm.kind, perm.metaName),
+			})
+		}
+		if len(vErrs) > 0 {
+			return []validationErr{objectValidationErr(fieldSpec, vErrs...)}
+		}
+		return nil
+	})
+}
+
 func (p *Template) graphLabels() *parseErr {
 	p.mLabels = make(map[string]*label)
 	tracker := p.trackNames(true)
@@ -952,6 +1217,7 @@ func (p *Template) graphChecks() *parseErr {
 				status:        normStr(o.Spec.stringShort(fieldStatus)),
 				statusMessage: o.Spec.stringShort(fieldCheckStatusMessageTemplate),
 				timeSince:     o.Spec.durationShort(fieldCheckTimeSince),
+				specRefs:      make(fieldRefs),
 			}
 			for _, tagRes := range o.Spec.slcResource(fieldCheckTags) {
 				ch.tags = append(ch.tags, struct{ k, v string }{
@@ -959,14 +1225,20 @@ func (p *Template) graphChecks() *parseErr {
 					v: tagRes.stringShort(fieldValue),
 				})
 			}
-			for _, th := range o.Spec.slcResource(fieldCheckThresholds) {
+			for i, th := range o.Spec.slcResource(fieldCheckThresholds) {
+				thresholdFloat := func(field string) float64 {
+					ref := p.getRefWithKnownEnvs(th, field)
+					ch.specRefs.add(fmt.Sprintf("spec.%s[%d].%s", fieldCheckThresholds, i, field), ref)
+					f, _ := ifaceToFloat64(ref.valOrDefault())
+					return f
+				}
 				ch.thresholds = append(ch.thresholds, threshold{
 					threshType: thresholdType(normStr(th.stringShort(fieldType))),
 					allVals:    th.boolShort(fieldCheckAllValues),
 					level:      strings.TrimSpace(strings.ToUpper(th.stringShort(fieldLevel))),
-					max:        th.float64Short(fieldMax),
-					min:        th.float64Short(fieldMin),
-					val:        th.float64Short(fieldValue),
+					max:        thresholdFloat(fieldMax),
+					min:        thresholdFloat(fieldMin),
+					val:        thresholdFloat(fieldValue),
 				})
 			}
 
@@ -978,7 +1250,7 @@ func (p *Template) graphChecks() *parseErr {
 			sort.Sort(ch.labels)
 
 			p.mChecks[ch.MetaName()] = ch
-			p.setRefs(ch.name, ch.displayName)
+			p.setRefs(append(ch.specRefs.refs(), ch.name, ch.displayName)...)
 			return append(failures, ch.valid()...)
 		})
 		if err != nil {
@@ -1003,6 +1275,7 @@ func (p *Template) graphDashboards() *parseErr {
 		dash := &dashboard{
 			identity:    ident,
 			Description: o.Spec.stringShort(fieldDescription),
+			TimeRange:   parseTimeRange(o.Spec, fieldDashTimeRange),
 		}
 
 		failures := p.parseNestedLabels(o.Spec, func(l *label) error {
@@ -1124,6 +1397,7 @@ func (p *Template) graphNotificationRules() *parseErr {
 			msgTemplate:  o.Spec.stringShort(fieldNotificationRuleMessageTemplate),
 			offset:       o.Spec.durationShort(fieldOffset),
 			status:       normStr(o.Spec.stringShort(fieldStatus)),
+			specRefs:     make(fieldRefs),
 		}
 
 		for _, sRule := range o.Spec.slcResource(fieldNotificationRuleStatusRules) {
@@ -1133,10 +1407,16 @@ func (p *Template) graphNotificationRules() *parseErr {
 			})
 		}
 
-		for _, tRule := range o.Spec.slcResource(fieldNotificationRuleTagRules) {
+		for i, tRule := range o.Spec.slcResource(fieldNotificationRuleTagRules) {
+			tagRuleStr := func(field string) string {
+				ref := p.getRefWithKnownEnvs(tRule, field)
+				rule.specRefs.add(fmt.Sprintf("spec.%s[%d].%s", fieldNotificationRuleTagRules, i, field), ref)
+				s, _ := ifaceToStr(ref.valOrDefault())
+				return s
+			}
 			rule.tagRules = append(rule.tagRules, struct{ k, v, op string }{
-				k:  tRule.stringShort(fieldKey),
-				v:  tRule.stringShort(fieldValue),
+				k:  tagRuleStr(fieldKey),
+				v:  tagRuleStr(fieldValue),
 				op: normStr(tRule.stringShort(fieldOperator)),
 			})
 		}
@@ -1151,7 +1431,7 @@ func (p *Template) graphNotificationRules() *parseErr {
 		sort.Sort(rule.labels)
 
 		p.mNotificationRules[rule.MetaName()] = rule
-		p.setRefs(rule.name, rule.displayName, rule.endpointName)
+		p.setRefs(append(rule.specRefs.refs(), rule.name, rule.displayName, rule.endpointName)...)
 		return append(failures, rule.valid()...)
 	})
 }
@@ -1171,7 +1451,12 @@ func (p *Template) graphTasks() *parseErr {
 			description: o.Spec.stringShort(fieldDescription),
 			every:       o.Spec.durationShort(fieldEvery),
 			offset:      o.Spec.durationShort(fieldOffset),
+			everyLit:    o.Spec.durationLit(fieldEvery),
+			offsetLit:   o.Spec.durationLit(fieldOffset),
+			concurrency: int64(o.Spec.intShort(fieldTaskConcurrency)),
+			retry:       int64(o.Spec.intShort(fieldTaskRetry)),
 			status:      normStr(o.Spec.stringShort(fieldStatus)),
+			specRefs:    make(fieldRefs),
 		}
 
 		prefix := fmt.Sprintf("tasks[%s].spec", t.MetaName())
@@ -1183,7 +1468,28 @@ func (p *Template) graphTasks() *parseErr {
 			failures []validationErr
 		)
 
-		t.query, err = p.parseQuery(prefix, o.Spec.stringShort(fieldQuery), params, task)
+		// a zero concurrency or retry reads as not provided, reject it when
+		// it is given explicitly
+		for _, opt := range []struct {
+			field string
+			max   int64
+		}{
+			{field: fieldTaskConcurrency, max: taskMaxConcurrency},
+			{field: fieldTaskRetry, max: taskMaxRetry},
+		} {
+			if v, ok := o.Spec.int(opt.field); ok && v == 0 {
+				failures = append(failures, objectValidationErr(fieldSpec, validationErr{
+					Field: opt.field,
+					Msg:   fmt.Sprintf("must be between 1 and %d", opt.max),
+				}))
+			}
+		}
+
+		queryRef := p.getRefWithKnownEnvs(o.Spec, fieldQuery)
+		t.specRefs.add("spec."+fieldQuery, queryRef)
+		source, _ := ifaceToStr(queryRef.valOrDefault())
+
+		t.query, err = p.parseQuery(prefix, source, params, task)
 		if err != nil {
 			failures = append(failures, validationErr{
 				Field: fieldQuery,
@@ -1199,7 +1505,7 @@ func (p *Template) graphTasks() *parseErr {
 				case prefix + ".task.every":
 					every, ok := ref.defaultVal.(time.Duration)
 					if ok {
-						t.every = every
+						t.every, t.everyLit = every, ""
 					} else {
 						failures = append(failures, validationErr{
 							Field: fieldTask,
@@ -1209,7 +1515,7 @@ func (p *Template) graphTasks() *parseErr {
 				case prefix + ".task.offset":
 					offset, ok := ref.defaultVal.(time.Duration)
 					if ok {
-						t.offset = offset
+						t.offset, t.offsetLit = offset, ""
 					} else {
 						failures = append(failures, validationErr{
 							Field: fieldTask,
@@ -1220,6 +1526,27 @@ func (p *Template) graphTasks() *parseErr {
 			}
 		}
 
+		secrets := make(map[string]bool)
+		for i, r := range o.Spec.slcResource(fieldTaskSecrets) {
+			ref := ifaceToReference(r)
+			if ref.Secret == "" {
+				failures = append(failures, validationErr{
+					Field: fieldTaskSecrets,
+					Index: intPtr(i),
+					Msg:   "must be a secretRef",
+				})
+				continue
+			}
+			secrets[ref.Secret] = true
+		}
+		for _, key := range querySecretKeys(source) {
+			secrets[key] = true
+		}
+		for key := range secrets {
+			t.secrets = append(t.secrets, &references{Secret: key})
+		}
+		sort.Slice(t.secrets, func(i, j int) bool { return t.secrets[i].Secret < t.secrets[j].Secret })
+
 		failures = append(failures, p.parseNestedLabels(o.Spec, func(l *label) error {
 			t.labels = append(t.labels, l)
 			p.mLabels[l.MetaName()].setMapping(t, false)
@@ -1688,6 +2015,8 @@ func (p *Template) parseChart(dashMetaName string, chartIdx int, r Resource) (*c
 		}
 	}
 
+	c.TimeRange = parseTimeRange(r, fieldChartTimeRange)
+
 	for _, fieldOptRes := range r.slcResource(fieldChartFieldOptions) {
 		c.FieldOptions = append(c.FieldOptions, fieldOption{
 			FieldName:   fieldOptRes.stringShort(fieldChartFieldOptionFieldName),
@@ -1696,6 +2025,7 @@ func (p *Template) parseChart(dashMetaName string, chartIdx int, r Resource) (*c
 		})
 	}
 
+	failures = append(failures, validTimeRange(fieldChartTimeRange, c.TimeRange)...)
 	if failures = append(failures, c.validProperties()...); len(failures) > 0 {
 		return nil, failures
 	}
@@ -1703,6 +2033,18 @@ func (p *Template) parseChart(dashMetaName string, chartIdx int, r Resource) (*c
 	return &c, nil
 }
 
+func parseTimeRange(r Resource, field string) *influxdb.TimeRangeOverride {
+	tr, ok := ifaceToResource(r[field])
+	if !ok {
+		return nil
+	}
+	return &influxdb.TimeRangeOverride{
+		Lower:   tr.stringShort(fieldTimeRangeLower),
+		Upper:   tr.stringShort(fieldTimeRangeUpper),
+		Refresh: tr.stringShort(fieldTimeRangeRefresh),
+	}
+}
+
 func (p *Template) parseChartQueries(dashMetaName string, chartIdx int, resources []Resource) (queries, []validationErr) {
 	var (
 		q     queries
@@ -1855,6 +2197,45 @@ func (p *Template) parseQuery(prefix, source string, params, task []Resource) (q
 	return q, nil
 }
 
+// querySecretKeys returns the sorted keys of the secrets the query reads
+// with secrets.get.
+func querySecretKeys(source string) []string {
+	if source == "" {
+		return nil
+	}
+
+	keys := make(map[string]bool)
+	ast.Visit(parser.ParseSource(source), func(n ast.Node) {
+		call, ok := n.(*ast.CallExpression)
+		if !ok || len(call.Arguments) != 1 {
+			return
+		}
+		callee, ok := call.Callee.(*ast.MemberExpression)
+		if !ok || callee.Property.Key() != "get" {
+			return
+		}
+		if obj, ok := callee.Object.(*ast.Identifier); !ok || obj.Name != "secrets" {
+			return
+		}
+		args, ok := call.Arguments[0].(*ast.ObjectExpression)
+		if !ok {
+			return
+		}
+		for _, prop := range args.Properties {
+			if lit, ok := prop.Value.(*ast.StringLiteral); ok && prop.Key.Key() == "key" {
+				keys[lit.Value] = true
+			}
+		}
+	})
+
+	out := make([]string, 0, len(keys))
+	for k := range keys {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
 func valFromExpr(p ast.Expression) interface{} {
 	switch literal := p.(type) {
 	case *ast.CallExpression:
@@ -1984,6 +2365,15 @@ func (r Resource) durationShort(key string) time.Duration {
 	return dur
 }
 
+// durationLit returns the duration of key as written, or empty when it is
+// not a valid duration.
+func (r Resource) durationLit(key string) string {
+	if dur, ok := r.duration(key); !ok || dur == 0 {
+		return ""
+	}
+	return strings.TrimSpace(r.stringShort(key))
+}
+
 func (r Resource) float64(key string) (float64, bool) {
 	f, ok := r[key].(float64)
 	if ok {
@@ -2190,6 +2580,21 @@ func ifaceToStr(v interface{}) (string, bool) {
 	return "", false
 }
 
+func ifaceToFloat64(v interface{}) (float64, bool) {
+	switch f := v.(type) {
+	case float64:
+		return f, true
+	case int:
+		return float64(f), true
+	case int64:
+		return float64(f), true
+	case string:
+		fv, err := strconv.ParseFloat(f, 64)
+		return fv, err == nil
+	}
+	return 0, false
+}
+
 // ParseError is the error from parsing the given package. The ParseError
 // behavior provides a list of resources that failed and all validations
 // that failed for that resource. A resource can multiple errors, and
@@ -78,6 +78,173 @@ const (
 	fieldValues       = "values"
 )
 
+const (
+	fieldHookBody    = "body"
+	fieldHookHeaders = "headers"
+	fieldHookMethod  = "method"
+	fieldHookPhase   = "phase"
+	fieldHookURL     = "url"
+)
+
+const (
+	hookTypeFlux = "flux"
+	hookTypeHTTP = "http"
+)
+
+type hook struct {
+	identity
+
+	phase    HookPhase
+	hookType string
+	query    string
+	url      string
+	method   string
+	headers  map[string]string
+	body     string
+}
+
+func (h *hook) result(status HookStatus) HookResult {
+	return HookResult{
+		MetaName: h.MetaName(),
+		Phase:    h.phase,
+		Type:     h.hookType,
+		Status:   status,
+	}
+}
+
+func (h *hook) valid() []validationErr {
+	var vErrs []validationErr
+	if h.phase != HookPhasePreApply && h.phase != HookPhasePostApply {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldHookPhase,
+			Msg:   fmt.Sprintf("must be 1 of [%s, %s]", HookPhasePreApply, HookPhasePostApply),
+		})
+	}
+
+	switch h.hookType {
+	case hookTypeFlux:
+		if h.query == "" {
+			vErrs = append(vErrs, validationErr{
+				Field: fieldQuery,
+				Msg:   "must provide a non zero value",
+			})
+		}
+	case hookTypeHTTP:
+		if u, err := url.Parse(h.url); err != nil || h.url == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			vErrs = append(vErrs, validationErr{
+				Field: fieldHookURL,
+				Msg:   "must be valid http(s) url",
+			})
+		}
+		if !validEndpointHTTPMethods[h.method] {
+			vErrs = append(vErrs, validationErr{
+				Field: fieldHookMethod,
+				Msg:   "http method must be a valid HTTP verb",
+			})
+		}
+	default:
+		vErrs = append(vErrs, validationErr{
+			Field: fieldType,
+			Msg:   fmt.Sprintf("invalid type provided %q; valid type is 1 in [%s, %s]", h.hookType, hookTypeFlux, hookTypeHTTP),
+		})
+	}
+
+	if len(vErrs) > 0 {
+		return []validationErr{
+			objectValidationErr(fieldSpec, vErrs...),
+		}
+	}
+	return nil
+}
+
+const (
+	fieldAuthPermissions = "permissions"
+	fieldAuthAction      = "action"
+)
+
+// authorization is a token scoped to resources of the template. The
+// resources are referenced by their metadata names and resolved to the
+// IDs of the applied resources, and every apply rotates the token.
+type authorization struct {
+	identity
+
+	description string
+	permissions []authPermission
+}
+
+type authPermission struct {
+	action   influxdb.Action
+	kind     Kind
+	metaName string
+}
+
+func (a *authorization) summarize() SummaryAuthorization {
+	perms := make([]influxdb.Permission, 0, len(a.permissions))
+	for _, p := range a.permissions {
+		perms = append(perms, influxdb.Permission{
+			Action:   p.action,
+			Resource: influxdb.Resource{Type: p.kind.ResourceType()},
+		})
+	}
+	return SummaryAuthorization{
+		SummaryIdentifier: SummaryIdentifier{
+			Kind:          KindAuthorization,
+			MetaName:      a.MetaName(),
+			EnvReferences: a.summarizeReferences(),
+		},
+		Description: a.description,
+		Permissions: perms,
+	}
+}
+
+func (a *authorization) valid() []validationErr {
+	if len(a.permissions) == 0 {
+		return []validationErr{
+			objectValidationErr(fieldSpec, validationErr{
+				Field: fieldAuthPermissions,
+				Msg:   "must provide at least 1 permission",
+			}),
+		}
+	}
+
+	var permErrs []validationErr
+	for i, p := range a.permissions {
+		var vErrs []validationErr
+		if p.action != influxdb.ReadAction && p.action != influxdb.WriteAction {
+			vErrs = append(vErrs, validationErr{
+				Field: fieldAuthAction,
+				Msg:   fmt.Sprintf("must be 1 of [%s, %s]", influxdb.ReadAction, influxdb.WriteAction),
+			})
+		}
+		if p.kind.ResourceType() == "" || p.kind.is(KindAuthorization) {
+			vErrs = append(vErrs, validationErr{
+				Field: fieldKind,
+				Msg:   fmt.Sprintf("unsupported kind provided %q", p.kind),
+			})
+		}
+		if p.metaName == "" {
+			vErrs = append(vErrs, validationErr{
+				Field: fieldName,
+				Msg:   "must provide the metadata name of a resource of the template",
+			})
+		}
+		if len(vErrs) > 0 {
+			permErrs = append(permErrs, validationErr{
+				Field:  fieldAuthPermissions,
+				Index:  intPtr(i),
+				Nested: vErrs,
+			})
+		}
+	}
+
+	if len(permErrs) > 0 {
+		return []validationErr{
+			objectValidationErr(fieldSpec, permErrs...),
+		}
+	}
+	return nil
+}
+
 const (
 	fieldBucketRetentionRules = "retentionRules"
 )
@@ -229,6 +396,7 @@ type check struct {
 	tags          []struct{ k, v string }
 	timeSince     time.Duration
 	thresholds    []threshold
+	specRefs      fieldRefs
 
 	labels sortedLabels
 }
@@ -265,7 +433,7 @@ func (c *check) summarize() SummaryCheck {
 	sum := SummaryCheck{
 		SummaryIdentifier: SummaryIdentifier{
 			MetaName:      c.MetaName(),
-			EnvReferences: summarizeCommonReferences(c.identity, c.labels),
+			EnvReferences: append(summarizeCommonReferences(c.identity, c.labels), c.specRefs.summarize()...),
 		},
 		Status:            c.Status(),
 		LabelAssociations: toSummaryLabels(c.labels...),
@@ -462,7 +630,14 @@ func (c chartKind) title() string {
 }
 
 const (
-	fieldDashCharts = "charts"
+	fieldDashCharts    = "charts"
+	fieldDashTimeRange = "timeRange"
+)
+
+const (
+	fieldTimeRangeLower   = "lower"
+	fieldTimeRangeUpper   = "upper"
+	fieldTimeRangeRefresh = "refresh"
 )
 
 const dashboardNameMinLength = 2
@@ -472,6 +647,7 @@ type dashboard struct {
 
 	Description string
 	Charts      []*chart
+	TimeRange   *influxdb.TimeRangeOverride
 
 	labels sortedLabels
 }
@@ -501,6 +677,7 @@ func (d *dashboard) summarize() SummaryDashboard {
 		},
 		Name:              d.Name(),
 		Description:       d.Description,
+		TimeRange:         d.TimeRange,
 		LabelAssociations: toSummaryLabels(d.labels...),
 	}
 
@@ -511,6 +688,7 @@ func (d *dashboard) summarize() SummaryDashboard {
 			Width:      c.Width,
 			XPosition:  c.XPos,
 			YPosition:  c.YPos,
+			TimeRange:  c.TimeRange,
 		})
 		for qIdx, q := range c.Queries {
 			for _, ref := range q.params {
@@ -531,6 +709,7 @@ func (d *dashboard) valid() []validationErr {
 	if err, ok := isValidName(d.Name(), dashboardNameMinLength); !ok {
 		vErrs = append(vErrs, err)
 	}
+	vErrs = append(vErrs, validTimeRange(fieldDashTimeRange, d.TimeRange)...)
 	if len(vErrs) == 0 {
 		return nil
 	}
@@ -558,6 +737,7 @@ const (
 	fieldChartHoverDimension             = "hoverDimension"
 	fieldChartFieldOptions               = "fieldOptions"
 	fieldChartTableOptions               = "tableOptions"
+	fieldChartTimeRange                  = "timeRange"
 	fieldChartTickPrefix                 = "tickPrefix"
 	fieldChartTickSuffix                 = "tickSuffix"
 	fieldChartTimeFormat                 = "timeFormat"
@@ -642,6 +822,7 @@ type chart struct {
 	AllowPanAndZoom            bool
 	DetectCoordinateFields     bool
 	GeoLayers                  geoLayers
+	TimeRange                  *influxdb.TimeRangeOverride
 }
 
 func (c *chart) properties() influxdb.ViewProperties {
@@ -977,6 +1158,19 @@ func (c *chart) validProperties() []validationErr {
 	return fails
 }
 
+func validTimeRange(field string, tr *influxdb.TimeRangeOverride) []validationErr {
+	if tr == nil {
+		return nil
+	}
+	if err := tr.Valid(); err != nil {
+		return []validationErr{{
+			Field: field,
+			Msg:   err.Error(),
+		}}
+	}
+	return nil
+}
+
 func validPosition(pos string) []validationErr {
 	pos = strings.ToLower(pos)
 	if pos != "" && pos != "overlaid" && pos != "stacked" {
@@ -1827,6 +2021,7 @@ type notificationRule struct {
 	status      string
 	statusRules []struct{ curLvl, prevLvl string }
 	tagRules    []struct{ k, v, op string }
+	specRefs    fieldRefs
 
 	associatedEndpoint *notificationEndpoint
 	endpointName       *references
@@ -1867,6 +2062,7 @@ func (r *notificationRule) summarize() SummaryNotificationRule {
 	if r.endpointName.hasEnvRef() {
 		envRefs = append(envRefs, convertRefToRefSummary("spec.endpointName", r.endpointName))
 	}
+	envRefs = append(envRefs, r.specRefs.summarize()...)
 
 	return SummaryNotificationRule{
 		SummaryIdentifier: SummaryIdentifier{
@@ -2062,8 +2258,19 @@ func toSummaryTagRules(tagRules []struct{ k, v, op string }) []SummaryTagRule {
 }
 
 const (
-	fieldTaskCron = "cron"
-	fieldTask     = "task"
+	fieldTaskConcurrency = "concurrency"
+	fieldTaskCron        = "cron"
+	fieldTaskRetry       = "retry"
+	fieldTaskSecrets     = "secrets"
+	fieldTask            = "task"
+)
+
+// default and maximum values of the task concurrency and retry options
+const (
+	taskDefaultConcurrency int64 = 1
+	taskDefaultRetry       int64 = 1
+	taskMaxConcurrency     int64 = 100
+	taskMaxRetry           int64 = 10
 )
 
 type task struct {
@@ -2073,8 +2280,18 @@ type task struct {
 	description string
 	every       time.Duration
 	offset      time.Duration
+	// everyLit and offsetLit are every and offset as written in the
+	// template, so calendar durations such as 1mo keep their meaning.
+	everyLit    string
+	offsetLit   string
+	concurrency int64
+	retry       int64
 	query       query
 	status      string
+	specRefs    fieldRefs
+
+	// secrets are the secrets the query reads with secrets.get.
+	secrets []*references
 
 	labels sortedLabels
 }
@@ -2094,19 +2311,64 @@ func (t *task) Status() influxdb.Status {
 	return influxdb.Status(t.status)
 }
 
+// Concurrency returns the concurrency of the task, defaulting to 1 when
+// not provided.
+func (t *task) Concurrency() int64 {
+	if t.concurrency == 0 {
+		return taskDefaultConcurrency
+	}
+	return t.concurrency
+}
+
+// Retry returns the retry count of the task, defaulting to 1 when not
+// provided.
+func (t *task) Retry() int64 {
+	if t.retry == 0 {
+		return taskDefaultRetry
+	}
+	return t.retry
+}
+
+// everyStr returns every as written in the template.
+func (t *task) everyStr() string {
+	if t.everyLit != "" {
+		return t.everyLit
+	}
+	return durToStr(t.every)
+}
+
+// offsetStr returns offset as written in the template.
+func (t *task) offsetStr() string {
+	if t.offsetLit != "" {
+		return t.offsetLit
+	}
+	return durToStr(t.offset)
+}
+
 func (t *task) flux() string {
 	translator := taskFluxTranslation{
-		name:     t.Name(),
-		cron:     t.cron,
-		every:    t.every,
-		offset:   t.offset,
-		rawQuery: t.query.DashboardQuery(),
+		name:        t.Name(),
+		cron:        t.cron,
+		every:       t.everyStr(),
+		offset:      t.offsetStr(),
+		concurrency: t.concurrency,
+		retry:       t.retry,
+		rawQuery:    t.query.DashboardQuery(),
 	}
 	return translator.flux()
 }
 
 func (t *task) refs() []*references {
-	return append(t.query.params, t.name, t.displayName)
+	refs := append(append(t.query.params, t.name, t.displayName), t.specRefs.refs()...)
+	return append(refs, t.secrets...)
+}
+
+func (t *task) secretKeys() []string {
+	var keys []string
+	for _, ref := range t.secrets {
+		keys = append(keys, ref.Secret)
+	}
+	return keys
 }
 
 func (t *task) summarize() SummaryTask {
@@ -2121,6 +2383,7 @@ func (t *task) summarize() SummaryTask {
 		field := fmt.Sprintf("spec.task.%s", parts[len(parts)-1])
 		refs = append(refs, convertRefToRefSummary(field, ref))
 	}
+	refs = append(refs, t.specRefs.summarize()...)
 	sort.Slice(refs, func(i, j int) bool {
 		return refs[i].EnvRefKey < refs[j].EnvRefKey
 	})
@@ -2134,8 +2397,11 @@ func (t *task) summarize() SummaryTask {
 		Name:        t.Name(),
 		Cron:        t.cron,
 		Description: t.description,
-		Every:       durToStr(t.every),
-		Offset:      durToStr(t.offset),
+		Every:       t.everyStr(),
+		Offset:      t.offsetStr(),
+		Concurrency: t.concurrency,
+		Retry:       t.retry,
+		Secrets:     t.secretKeys(),
 		Query:       t.query.DashboardQuery(),
 		Status:      t.Status(),
 
@@ -2176,6 +2442,21 @@ func (t *task) valid() []validationErr {
 		})
 	}
 
+	// zero is not provided, an explicit zero is rejected when parsing
+	if t.concurrency < 0 || t.concurrency > taskMaxConcurrency {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldTaskConcurrency,
+			Msg:   fmt.Sprintf("must be between 1 and %d", taskMaxConcurrency),
+		})
+	}
+
+	if t.retry < 0 || t.retry > taskMaxRetry {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldTaskRetry,
+			Msg:   fmt.Sprintf("must be between 1 and %d", taskMaxRetry),
+		})
+	}
+
 	if len(vErrs) > 0 {
 		return []validationErr{
 			objectValidationErr(fieldSpec, vErrs...),
@@ -2188,10 +2469,12 @@ func (t *task) valid() []validationErr {
 var fluxRegex = regexp.MustCompile(`import\s+\".*\"`)
 
 type taskFluxTranslation struct {
-	name   string
-	cron   string
-	every  time.Duration
-	offset time.Duration
+	name        string
+	cron        string
+	every       string
+	offset      string
+	concurrency int64
+	retry       int64
 
 	rawQuery string
 }
@@ -2227,12 +2510,18 @@ func (tft taskFluxTranslation) generateTaskOption() string {
 	if tft.cron != "" {
 		taskOpts = append(taskOpts, fmt.Sprintf("cron: %q", tft.cron))
 	}
-	if tft.every > 0 {
+	if tft.every != "" {
 		taskOpts = append(taskOpts, fmt.Sprintf("every: %s", tft.every))
 	}
-	if tft.offset > 0 {
+	if tft.offset != "" {
 		taskOpts = append(taskOpts, fmt.Sprintf("offset: %s", tft.offset))
 	}
+	if tft.concurrency > 0 {
+		taskOpts = append(taskOpts, fmt.Sprintf("concurrency: %d", tft.concurrency))
+	}
+	if tft.retry > 0 {
+		taskOpts = append(taskOpts, fmt.Sprintf("retry: %d", tft.retry))
+	}
 
 	// this is required by the API, super nasty. Will be super challenging for
 	// anyone outside org to figure out how to do this within an hour of looking
@@ -2479,6 +2768,18 @@ func (r *references) expression() ast.Expression {
 	return nil
 }
 
+// valOrDefault returns the provided value of the reference, falling
+// back to the envRef default when no value was provided.
+func (r *references) valOrDefault() interface{} {
+	if r == nil {
+		return nil
+	}
+	if r.val != nil {
+		return r.val
+	}
+	return r.defaultVal
+}
+
 func (r *references) Float64() float64 {
 	if r == nil || r.val == nil {
 		return 0
@@ -2526,6 +2827,38 @@ func (r *references) SecretField() influxdb.SecretField {
 	return influxdb.SecretField{}
 }
 
+// fieldRefs tracks the env references of nested spec fields, keyed by the
+// path of the field within the resource (i.e. spec.thresholds[0].value).
+type fieldRefs map[string]*references
+
+func (f fieldRefs) add(field string, ref *references) {
+	if ref.hasEnvRef() {
+		f[field] = ref
+	}
+}
+
+func (f fieldRefs) refs() []*references {
+	refs := make([]*references, 0, len(f))
+	for _, ref := range f {
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+func (f fieldRefs) summarize() []SummaryReference {
+	fields := make([]string, 0, len(f))
+	for field := range f {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	refs := make([]SummaryReference, 0, len(fields))
+	for _, field := range fields {
+		refs = append(refs, convertRefToRefSummary(field, f[field]))
+	}
+	return refs
+}
+
 func convertRefToRefSummary(field string, ref *references) SummaryReference {
 	var valType string
 	switch strings.ToLower(ref.valType) {
@@ -24,6 +24,179 @@ import (
 )
 
 func TestParse(t *testing.T) {
+	t.Run("template with apply hooks", func(t *testing.T) {
+		t.Run("with valid hooks should be valid", func(t *testing.T) {
+			testfileRunner(t, "testdata/hooks.yml", func(t *testing.T, template *Template) {
+				hooks := template.hooks()
+				require.Len(t, hooks, 2)
+
+				assert.Equal(t, "hook-1", hooks[0].MetaName())
+				assert.Equal(t, HookPhasePreApply, hooks[0].phase)
+				assert.Equal(t, hookTypeFlux, hooks[0].hookType)
+				assert.Equal(t, "buckets() |> limit(n: 1)", hooks[0].query)
+
+				assert.Equal(t, "hook-2", hooks[1].MetaName())
+				assert.Equal(t, HookPhasePostApply, hooks[1].phase)
+				assert.Equal(t, hookTypeHTTP, hooks[1].hookType)
+				assert.Equal(t, "https://example.com/notify", hooks[1].url)
+				assert.Equal(t, "POST", hooks[1].method)
+				assert.Equal(t, map[string]string{"X-Token": "secret"}, hooks[1].headers)
+				assert.Equal(t, "installed", hooks[1].body)
+			})
+		})
+
+		t.Run("handles bad config", func(t *testing.T) {
+			tests := []testTemplateResourceError{
+				{
+					name:           "invalid phase",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldHookPhase},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: ApplyHook
+metadata:
+  name: hook-1
+spec:
+  phase: duringApply
+  type: flux
+  query: buckets()
+`,
+				},
+				{
+					name:           "flux hook missing query",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldQuery},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: ApplyHook
+metadata:
+  name: hook-1
+spec:
+  phase: preApply
+  type: flux
+`,
+				},
+				{
+					name:           "http hook with invalid url",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldHookURL},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: ApplyHook
+metadata:
+  name: hook-1
+spec:
+  phase: postApply
+  type: http
+  url: ftp://example.com
+`,
+				},
+				{
+					name:           "invalid type",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldType},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: ApplyHook
+metadata:
+  name: hook-1
+spec:
+  phase: postApply
+  type: grpc
+`,
+				},
+			}
+
+			for _, tt := range tests {
+				testTemplateErrors(t, KindApplyHook, tt)
+			}
+		})
+	})
+
+	t.Run("template with authorizations", func(t *testing.T) {
+		t.Run("with valid authorization should be valid", func(t *testing.T) {
+			testfileRunner(t, "testdata/authorizations.yml", func(t *testing.T, template *Template) {
+				auths := template.authorizations()
+				require.Len(t, auths, 1)
+
+				assert.Equal(t, "auth-1", auths[0].MetaName())
+				assert.Equal(t, "reads the template's dashboard", auths[0].description)
+				expected := []authPermission{
+					{action: influxdb.ReadAction, kind: KindDashboard, metaName: "dash-1"},
+					{action: influxdb.WriteAction, kind: KindBucket, metaName: "rucket-1"},
+				}
+				assert.Equal(t, expected, auths[0].permissions)
+			})
+		})
+
+		t.Run("handles bad config", func(t *testing.T) {
+			tests := []testTemplateResourceError{
+				{
+					name:           "no permissions",
+					validationErrs: 1,
+					valFields:      []string{"spec.permissions"},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Authorization
+metadata:
+  name: auth-1
+spec:
+  description: nothing
+`,
+				},
+				{
+					name:           "invalid action",
+					validationErrs: 1,
+					valFields:      []string{"spec.permissions[0].action"},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: rucket-1
+---
+apiVersion: influxdata.com/v2alpha1
+kind: Authorization
+metadata:
+  name: auth-1
+spec:
+  permissions:
+    - action: delete
+      kind: Bucket
+      name: rucket-1
+`,
+				},
+				{
+					name:           "unsupported kind",
+					validationErrs: 1,
+					valFields:      []string{"spec.permissions[0].kind"},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Authorization
+metadata:
+  name: auth-1
+spec:
+  permissions:
+    - action: read
+      kind: ApplyHook
+      name: hook-1
+`,
+				},
+				{
+					name:           "resource not in template",
+					validationErrs: 1,
+					valFields:      []string{"spec.permissions"},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Authorization
+metadata:
+  name: auth-1
+spec:
+  permissions:
+    - action: read
+      kind: Dashboard
+      name: dash-1
+`,
+				},
+			}
+
+			for _, tt := range tests {
+				testTemplateErrors(t, KindAuthorization, tt)
+			}
+		})
+	})
+
 	t.Run("template with a bucket", func(t *testing.T) {
 		t.Run("with valid bucket template should be valid", func(t *testing.T) {
 			testfileRunner(t, "testdata/bucket", func(t *testing.T, template *Template) {
@@ -3581,11 +3754,38 @@ spec:
 
 				task1 := tasks[1]
 				baseEqual(t, 0, influxdb.Inactive, task1)
-				assert.Equal(t, (25 * time.Hour).String(), task1.Every)
-				assert.Equal(t, (15 * time.Second).String(), task1.Offset)
+				// the schedule is kept as written
+				assert.Equal(t, "1d1h", task1.Every)
+				assert.Equal(t, "15s", task1.Offset)
 			})
 		})
 
+		t.Run("with calendar every and negative offset keeps the schedule as written", func(t *testing.T) {
+			template := newParsedTemplate(t, FromString(`
+apiVersion: influxdata.com/v2alpha1
+kind: Task
+metadata:
+  name: task-1
+spec:
+  every: 1mo
+  offset: -5m
+  status: inactive
+  query: >
+    from(bucket: "rucket_1") |> range(start: -5d)
+`), EncodingYAML)
+
+			sum := template.Summary()
+			require.Len(t, sum.Tasks, 1)
+			assert.Equal(t, "1mo", sum.Tasks[0].Every)
+			assert.Equal(t, "-5m", sum.Tasks[0].Offset)
+			assert.Equal(t, influxdb.Inactive, sum.Tasks[0].Status)
+
+			require.Len(t, template.mTasks, 1)
+			flux := template.mTasks["task-1"].flux()
+			assert.Contains(t, flux, "every: 1mo")
+			assert.Contains(t, flux, "offset: -5m")
+		})
+
 		t.Run("with params option should be parameterizable", func(t *testing.T) {
 			testfileRunner(t, "testdata/tasks_params.yml", func(t *testing.T, template *Template) {
 				sum := template.Summary()
@@ -3862,6 +4062,60 @@ metadata:
 spec:
   description: desc_0
   offset: 15s
+`,
+					},
+				},
+				{
+					kind: KindTask,
+					resErr: testTemplateResourceError{
+						name:           "explicit zero concurrency",
+						validationErrs: 1,
+						valFields:      []string{fieldSpec, fieldTaskConcurrency},
+						templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Task
+metadata:
+  name: task-0
+spec:
+  every: 10m
+  concurrency: 0
+  query:  >
+    from(bucket: "rucket_1") |> yield(name: "mean")
+`,
+					},
+				},
+				{
+					kind: KindTask,
+					resErr: testTemplateResourceError{
+						name:           "concurrency above maximum",
+						validationErrs: 1,
+						valFields:      []string{fieldSpec, fieldTaskConcurrency},
+						templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Task
+metadata:
+  name: task-0
+spec:
+  every: 10m
+  concurrency: 101
+  query:  >
+    from(bucket: "rucket_1") |> yield(name: "mean")
+`,
+					},
+				},
+				{
+					kind: KindTask,
+					resErr: testTemplateResourceError{
+						name:           "retry above maximum",
+						validationErrs: 1,
+						valFields:      []string{fieldSpec, fieldTaskRetry},
+						templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Task
+metadata:
+  name: task-0
+spec:
+  every: 10m
+  retry: 11
+  query:  >
+    from(bucket: "rucket_1") |> yield(name: "mean")
 `,
 					},
 				},
@@ -4441,6 +4695,77 @@ spec:
 		})
 	})
 
+	t.Run("referencing env in spec fields", func(t *testing.T) {
+		testfileRunner(t, "testdata/env_refs_spec.yml", func(t *testing.T, template *Template) {
+			assert.Equal(t, map[string]bool{
+				"crit-threshold": false,
+				"info-min":       false,
+				"rule-env":       false,
+				"task-query":     false,
+			}, template.mEnv)
+
+			thresholds := func(t *testing.T, sum Summary) []icheck.ThresholdConfig {
+				t.Helper()
+				require.Len(t, sum.Checks, 1)
+				th, ok := sum.Checks[0].Check.(*icheck.Threshold)
+				require.True(t, ok)
+				require.Len(t, th.Thresholds, 2)
+				return th.Thresholds
+			}
+
+			sum := template.Summary()
+
+			require.Len(t, sum.Tasks, 1)
+			assert.Equal(t, `from(bucket: "rucket_1") |> yield(name: "default")`, sum.Tasks[0].Query)
+			assert.Contains(t, sum.Tasks[0].EnvReferences, SummaryReference{
+				Field:        "spec.query",
+				EnvRefKey:    "task-query",
+				DefaultValue: "from(bucket: \"rucket_1\") |> yield(name: \"default\")\n",
+			})
+
+			ths := thresholds(t, sum)
+			assert.Equal(t, 90.0, ths[0].(icheck.Greater).Value)
+			assert.Equal(t, 0.0, ths[1].(icheck.Range).Min)
+			assert.Equal(t, []SummaryReference{
+				{
+					Field:        "spec.thresholds[0].value",
+					EnvRefKey:    "crit-threshold",
+					DefaultValue: 90.0,
+				},
+				{
+					Field:     "spec.thresholds[1].min",
+					EnvRefKey: "info-min",
+				},
+			}, sum.Checks[0].EnvReferences)
+
+			require.Len(t, sum.NotificationRules, 1)
+			assert.Equal(t, []SummaryTagRule{{Key: "env", Value: "staging", Operator: "equal"}}, sum.NotificationRules[0].TagRules)
+
+			t.Log("applying env vars should populate spec fields")
+			{
+				err := template.applyEnvRefs(map[string]interface{}{
+					"crit-threshold": 95.5,
+					"info-min":       30,
+					"rule-env":       "prod",
+					"task-query":     `from(bucket: "prod") |> yield(name: "prod")`,
+				})
+				require.NoError(t, err)
+
+				sum := template.Summary()
+
+				require.Len(t, sum.Tasks, 1)
+				assert.Equal(t, `from(bucket: "prod") |> yield(name: "prod")`, sum.Tasks[0].Query)
+
+				ths := thresholds(t, sum)
+				assert.Equal(t, 95.5, ths[0].(icheck.Greater).Value)
+				assert.Equal(t, 30.0, ths[1].(icheck.Range).Min)
+
+				require.Len(t, sum.NotificationRules, 1)
+				assert.Equal(t, []SummaryTagRule{{Key: "env", Value: "prod", Operator: "equal"}}, sum.NotificationRules[0].TagRules)
+			}
+		})
+	})
+
 	t.Run("jsonnet support disabled by default", func(t *testing.T) {
 		template := validParsedTemplateFromFile(t, "testdata/bucket_associates_labels.jsonnet", EncodingJsonnet)
 		require.Equal(t, &Template{}, template)
@@ -4598,6 +4923,77 @@ spec:
 		assert.Equal(t, "rucket-3", sum.Buckets[2].Name)
 		associationsEqual(t, sum.Buckets[2].LabelAssociations, "label-1", "label-2")
 	})
+
+	t.Run("duplicate resources across templates", func(t *testing.T) {
+		newBucketTemplate := func(t *testing.T, source, name, desc string) *Template {
+			t.Helper()
+			return newParsedTemplate(t, FromReader(strings.NewReader(fmt.Sprintf(`
+apiVersion: %[1]s
+kind: Bucket
+metadata:
+  name: %[2]s
+spec:
+  description: %[3]s
+`, APIVersion, name, desc)), source), EncodingYAML)
+		}
+		combineConflictErr := func(t *testing.T, err error) *CombineConflictError {
+			t.Helper()
+			iErr, ok := err.(*errors2.Error)
+			require.True(t, ok)
+			conflictErr, ok := iErr.Err.(*CombineConflictError)
+			require.True(t, ok)
+			return conflictErr
+		}
+		newTemplates := func(t *testing.T) []*Template {
+			return []*Template{
+				newBucketTemplate(t, "infra.yml", "rucket-1", "first"),
+				newBucketTemplate(t, "dashboards.yml", "rucket-2", "first"),
+				newBucketTemplate(t, "alerts.yml", "rucket-1", "last"),
+			}
+		}
+
+		t.Run("are reported with their sources", func(t *testing.T) {
+			_, err := Combine(newTemplates(t))
+			require.Error(t, err)
+			assert.Equal(t, errors2.EConflict, errors2.ErrorCode(err))
+
+			conflictErr := combineConflictErr(t, err)
+			assert.Equal(t, []ResourceConflict{{
+				Kind:     KindBucket,
+				MetaName: "rucket-1",
+				Sources:  []string{"infra.yml", "alerts.yml"},
+			}}, conflictErr.Conflicts)
+		})
+
+		t.Run("are attributed to their source through nested combines", func(t *testing.T) {
+			templates := newTemplates(t)
+			nested, err := Combine(templates[:2])
+			require.NoError(t, err)
+
+			_, err = Combine([]*Template{nested, templates[2]})
+			conflictErr := combineConflictErr(t, err)
+			assert.Equal(t, []string{"infra.yml", "alerts.yml"}, conflictErr.Conflicts[0].Sources)
+		})
+
+		tests := []struct {
+			strategy    MergeStrategy
+			description string
+		}{
+			{strategy: MergeStrategyFirstWins, description: "first"},
+			{strategy: MergeStrategyLastWins, description: "last"},
+		}
+		for _, tt := range tests {
+			t.Run(string(tt.strategy), func(t *testing.T) {
+				combined, err := Combine(newTemplates(t), WithMergeStrategy(tt.strategy))
+				require.NoError(t, err)
+
+				buckets := combined.Summary().Buckets
+				require.Len(t, buckets, 2)
+				assert.Equal(t, "rucket-1", buckets[0].Name)
+				assert.Equal(t, tt.description, buckets[0].Description)
+			})
+		}
+	})
 }
 
 func Test_normalizeGithubURLToContent(t *testing.T) {
@@ -0,0 +1,193 @@
+// Package registry resolves community template references, such as
+// influxdata/linux_system@v1.2.0, to the URL of the template through a
+// registry index, and verifies the fetched template against the checksum
+// recorded in the index.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// DefaultCacheTTL is how long a fetched index is used before it is refreshed.
+const DefaultCacheTTL = 15 * time.Minute
+
+// maxIndexSize bounds the size of the index and of a template read from the registry.
+const maxIndexSize = 32 << 20
+
+// Index lists the templates known to a registry.
+type Index struct {
+	Templates []IndexTemplate `json:"templates"`
+}
+
+// IndexTemplate is a template and its published versions.
+type IndexTemplate struct {
+	Name     string         `json:"name"`
+	Versions []IndexVersion `json:"versions"`
+}
+
+// IndexVersion locates a version of a template. SHA256 is the hex encoded
+// checksum of the template contents.
+type IndexVersion struct {
+	Version string `json:"version"`
+	URL     string `json:"url"`
+	SHA256  string `json:"sha256"`
+}
+
+// Client resolves template references against a registry index. The index
+// is cached and refreshed once the cache TTL elapses; when a refresh fails
+// the cached index continues to be used.
+type Client struct {
+	indexURL string
+	client   *http.Client
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	index     *Index
+	fetchedAt time.Time
+}
+
+// ClientOptFn is a functional option for configuring the Client.
+type ClientOptFn func(*Client)
+
+// WithCacheTTL sets how long the index is cached.
+func WithCacheTTL(ttl time.Duration) ClientOptFn {
+	return func(c *Client) {
+		c.ttl = ttl
+	}
+}
+
+// NewClient constructs a client for the registry with the index at indexURL.
+func NewClient(indexURL string, client *http.Client, opts ...ClientOptFn) *Client {
+	c := &Client{
+		indexURL: indexURL,
+		client:   client,
+		ttl:      DefaultCacheTTL,
+		now:      time.Now,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Resolve returns the index entry for the reference. A reference without a
+// version resolves to the latest version.
+func (c *Client) Resolve(ctx context.Context, ref Reference) (IndexVersion, error) {
+	idx, err := c.getIndex(ctx)
+	if err != nil {
+		return IndexVersion{}, err
+	}
+
+	for _, t := range idx.Templates {
+		if !strings.EqualFold(t.Name, ref.FullName()) {
+			continue
+		}
+		var (
+			found  IndexVersion
+			exists bool
+		)
+		for _, v := range t.Versions {
+			if ref.Version != "" {
+				if normalizeVersion(v.Version) == ref.Version {
+					return v, nil
+				}
+				continue
+			}
+			if !exists || compareVersions(v.Version, found.Version) > 0 {
+				found, exists = v, true
+			}
+		}
+		if exists {
+			return found, nil
+		}
+	}
+
+	return IndexVersion{}, &errors.Error{
+		Code: errors.ENotFound,
+		Msg:  fmt.Sprintf("template %q not found in registry", ref.String()),
+	}
+}
+
+// Fetch resolves the reference and returns the template contents after
+// verifying them against the checksum in the index.
+func (c *Client) Fetch(ctx context.Context, ref Reference) ([]byte, IndexVersion, error) {
+	v, err := c.Resolve(ctx, ref)
+	if err != nil {
+		return nil, IndexVersion{}, err
+	}
+
+	b, err := c.get(ctx, v.URL)
+	if err != nil {
+		return nil, v, err
+	}
+
+	sum := sha256.Sum256(b)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), v.SHA256) {
+		return nil, v, &errors.Error{
+			Code: errors.EUnprocessableEntity,
+			Msg:  fmt.Sprintf("checksum mismatch for template %q from %s", ref.String(), v.URL),
+		}
+	}
+	return b, v, nil
+}
+
+func (c *Client) getIndex(ctx context.Context) (*Index, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.index != nil && c.now().Sub(c.fetchedAt) < c.ttl {
+		return c.index, nil
+	}
+
+	b, err := c.get(ctx, c.indexURL)
+	if err == nil {
+		var idx Index
+		if err = json.Unmarshal(b, &idx); err == nil {
+			c.index, c.fetchedAt = &idx, c.now()
+			return c.index, nil
+		}
+	}
+	if c.index != nil {
+		// serve the stale index rather than failing while the registry is unavailable
+		return c.index, nil
+	}
+	return nil, &errors.Error{
+		Code: errors.EUnavailable,
+		Msg:  "unable to retrieve template registry index",
+		Err:  err,
+	}
+}
+
+func (c *Client) get(ctx context.Context, addr string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(resp.Body, maxIndexSize)); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("bad response: address=%s status_code=%d", addr, resp.StatusCode)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	ref, err := ParseReference("influxdata/linux_system@1.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, Reference{Namespace: "influxdata", Name: "linux_system", Version: "v1.2.0"}, ref)
+	assert.Equal(t, "influxdata/linux_system@v1.2.0", ref.String())
+
+	ref, err = ParseReference("influxdata/linux_system")
+	require.NoError(t, err)
+	assert.Empty(t, ref.Version)
+
+	for _, s := range []string{
+		"https://github.com/influxdata/community-templates/linux_system.yml",
+		"linux_system",
+		"influxdata/linux_system@",
+		"testdata/bucket.yml",
+	} {
+		assert.False(t, IsReference(s), s)
+		_, err := ParseReference(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, 1, compareVersions("v1.10.0", "v1.9.3"))
+	assert.Equal(t, -1, compareVersions("v1.2.0-rc1", "v1.2.0"))
+	assert.Equal(t, 0, compareVersions("1.2.0", "v1.2.0"))
+}
+
+func TestClient(t *testing.T) {
+	templates := map[string]string{
+		"/linux_system-v1.1.0.yml": "apiVersion: influxdata.com/v2alpha1\nkind: Bucket\nmetadata:\n  name: linux-old\n",
+		"/linux_system-v1.2.0.yml": "apiVersion: influxdata.com/v2alpha1\nkind: Bucket\nmetadata:\n  name: linux\n",
+		"/tampered.yml":            "apiVersion: influxdata.com/v2alpha1\nkind: Bucket\nmetadata:\n  name: evil\n",
+	}
+	checksum := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+
+	var indexRequests int
+	var svr *httptest.Server
+	svr = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/index.json" {
+			indexRequests++
+			_ = json.NewEncoder(w).Encode(Index{
+				Templates: []IndexTemplate{{
+					Name: "influxdata/linux_system",
+					Versions: []IndexVersion{
+						{Version: "v1.2.0", URL: svr.URL + "/linux_system-v1.2.0.yml", SHA256: checksum(templates["/linux_system-v1.2.0.yml"])},
+						{Version: "v1.1.0", URL: svr.URL + "/linux_system-v1.1.0.yml", SHA256: checksum(templates["/linux_system-v1.1.0.yml"])},
+						{Version: "v1.0.0", URL: svr.URL + "/tampered.yml", SHA256: checksum("original")},
+					},
+				}},
+			})
+			return
+		}
+		tmpl, ok := templates[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(tmpl))
+	}))
+	defer svr.Close()
+
+	now := time.Now()
+	c := NewClient(svr.URL+"/index.json", svr.Client(), WithCacheTTL(time.Minute))
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	b, v, err := c.Fetch(ctx, Reference{Namespace: "influxdata", Name: "linux_system"})
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0", v.Version, "latest version is used when unpinned")
+	assert.Equal(t, templates["/linux_system-v1.2.0.yml"], string(b))
+
+	b, v, err = c.Fetch(ctx, Reference{Namespace: "influxdata", Name: "linux_system", Version: "v1.1.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", v.Version)
+	assert.Equal(t, templates["/linux_system-v1.1.0.yml"], string(b))
+
+	_, _, err = c.Fetch(ctx, Reference{Namespace: "influxdata", Name: "linux_system", Version: "v1.0.0"})
+	require.Error(t, err)
+	assert.Equal(t, errors.EUnprocessableEntity, errors.ErrorCode(err))
+
+	_, err = c.Resolve(ctx, Reference{Namespace: "influxdata", Name: "linux_system", Version: "v9.9.9"})
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+	assert.Equal(t, 1, indexRequests, "index is cached")
+
+	now = now.Add(2 * time.Minute)
+	_, err = c.Resolve(ctx, Reference{Namespace: "influxdata", Name: "linux_system"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, indexRequests, "index is refreshed once the ttl elapses")
+
+	// a stale index is served while the registry is unavailable
+	svr.Close()
+	now = now.Add(2 * time.Minute)
+	_, err = c.Resolve(ctx, Reference{Namespace: "influxdata", Name: "linux_system"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var referenceRe = regexp.MustCompile(`^([A-Za-z0-9_-]+)/([A-Za-z0-9_-]+)(?:@(v?[0-9][0-9A-Za-z.+-]*))?$`)
+
+// Reference identifies a template in the registry, e.g.
+// influxdata/linux_system@v1.2.0. When the version is omitted the latest
+// version in the index is used.
+type Reference struct {
+	Namespace string
+	Name      string
+	Version   string
+}
+
+// IsReference reports whether s is a registry reference rather than a URL.
+func IsReference(s string) bool {
+	return referenceRe.MatchString(s)
+}
+
+// ParseReference parses a reference of the form namespace/name[@version].
+func ParseReference(s string) (Reference, error) {
+	m := referenceRe.FindStringSubmatch(s)
+	if m == nil {
+		return Reference{}, fmt.Errorf("invalid template reference %q: expected namespace/name[@version]", s)
+	}
+	return Reference{
+		Namespace: m[1],
+		Name:      m[2],
+		Version:   normalizeVersion(m[3]),
+	}, nil
+}
+
+// FullName returns the namespace qualified name of the template.
+func (r Reference) FullName() string {
+	return r.Namespace + "/" + r.Name
+}
+
+func (r Reference) String() string {
+	if r.Version == "" {
+		return r.FullName()
+	}
+	return r.FullName() + "@" + r.Version
+}
+
+func normalizeVersion(v string) string {
+	if v == "" || strings.HasPrefix(v, "v") {
+		return v
+	}
+	return "v" + v
+}
+
+// compareVersions compares two vMAJOR.MINOR.PATCH versions numerically.
+// A version with a pre-release suffix sorts before the same version without.
+func compareVersions(a, b string) int {
+	aCore, aPre := splitVersion(a)
+	bCore, bPre := splitVersion(b)
+	for i := 0; i < len(aCore) || i < len(bCore); i++ {
+		var x, y int
+		if i < len(aCore) {
+			x = aCore[i]
+		}
+		if i < len(bCore) {
+			y = bCore[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre < bPre:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func splitVersion(v string) ([]int, string) {
+	v = strings.TrimPrefix(normalizeVersion(v), "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	var pre string
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		v, pre = v[:i], v[i+1:]
+	}
+	var core []int
+	for _, p := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(p)
+		core = append(core, n)
+	}
+	return core, pre
+}
@@ -2,8 +2,11 @@ package pkger
 
 import (
 	"context"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
@@ -11,16 +14,23 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-stack/stack"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/lang"
 	"github.com/influxdata/influxdb/v2"
+	pctx "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/jsonweb"
 	ierrors "github.com/influxdata/influxdb/v2/kit/errors"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	icheck "github.com/influxdata/influxdb/v2/notification/check"
 	"github.com/influxdata/influxdb/v2/notification/rule"
 	"github.com/influxdata/influxdb/v2/pkger/internal/wordplay"
+	"github.com/influxdata/influxdb/v2/pkger/registry"
+	"github.com/influxdata/influxdb/v2/query"
 	"github.com/influxdata/influxdb/v2/snowflake"
 	"github.com/influxdata/influxdb/v2/task/options"
 	"github.com/influxdata/influxdb/v2/task/taskmodel"
@@ -54,13 +64,22 @@ func (s Stack) LatestEvent() StackEvent {
 
 type (
 	StackEvent struct {
-		EventType    StackEventType
-		Name         string
-		Description  string
-		Sources      []string
-		TemplateURLs []string
-		Resources    []StackResource
-		UpdatedAt    time.Time `json:"updatedAt"`
+		EventType      StackEventType
+		Name           string
+		Description    string
+		Sources        []string
+		SourceVersions []StackSourceVersion
+		TemplateURLs   []string
+		Resources      []StackResource
+		UpdatedAt      time.Time `json:"updatedAt"`
+	}
+
+	// StackSourceVersion pins a source of an applied template to the contents
+	// that were applied. Version is only known for registry references.
+	StackSourceVersion struct {
+		Source  string `json:"source"`
+		SHA256  string `json:"sha256"`
+		Version string `json:"version,omitempty"`
 	}
 
 	StackCreate struct {
@@ -96,6 +115,11 @@ type (
 		Description         *string
 		TemplateURLs        []string
 		AdditionalResources []StackAdditionalResource
+
+		// IfUpdatedAt, when set, is the version of the stack the update
+		// was made against. The update fails with EPreconditionFailed when
+		// the stack was updated since.
+		IfUpdatedAt *time.Time
 	}
 
 	StackAdditionalResource struct {
@@ -137,12 +161,48 @@ type SVC interface {
 	ListStacks(ctx context.Context, orgID platform.ID, filter ListFilter) ([]Stack, error)
 	ReadStack(ctx context.Context, id platform.ID) (Stack, error)
 	UpdateStack(ctx context.Context, upd StackUpdate) (Stack, error)
+	CheckStackUpdates(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackUpdateCheck, error)
+	CheckStackDrift(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackDrift, error)
+	ReadStackJournal(ctx context.Context, stackID platform.ID) ([]StackJournalEntry, error)
 
 	Export(ctx context.Context, opts ...ExportOptFn) (*Template, error)
 	DryRun(ctx context.Context, orgID, userID platform.ID, opts ...ApplyOptFn) (ImpactSummary, error)
 	Apply(ctx context.Context, orgID, userID platform.ID, opts ...ApplyOptFn) (ImpactSummary, error)
 }
 
+// StackUpdateCheck reports whether newer versions of the sources a stack was
+// last applied from are available. Diff is the dry run of applying the newer
+// versions, it is only provided when every source of the stack could be
+// refetched.
+type StackUpdateCheck struct {
+	StackID         platform.ID
+	UpdateAvailable bool
+	Sources         []StackSourceUpdate
+	Diff            *Diff
+}
+
+// StackSourceUpdate compares the applied version of a stack source with the
+// latest version of it. Checked is false for sources that cannot be
+// refetched, i.e. templates uploaded with the apply request.
+type StackSourceUpdate struct {
+	Current         StackSourceVersion
+	Latest          *StackSourceVersion
+	Checked         bool
+	UpdateAvailable bool
+}
+
+// StackDrift reports whether the resources of a stack drifted from the
+// sources it was last applied from, i.e. were changed or removed outside of
+// the stack. Checked is false when drift cannot be told, because a source of
+// the stack cannot be refetched or changed since it was applied. Diff is the
+// dry run of reapplying the sources, it is only provided when Checked.
+type StackDrift struct {
+	StackID platform.ID
+	Checked bool
+	Drifted bool
+	Diff    *Diff
+}
+
 // SVCMiddleware is a service middleware func.
 type SVCMiddleware func(SVC) SVC
 
@@ -156,12 +216,18 @@ type serviceOpt struct {
 	timeGen       influxdb.TimeGenerator
 	store         Store
 
+	idempotencyWindow time.Duration
+	registry          *registry.Client
+	trustAnchors      []ed25519.PublicKey
+
+	authSVC     influxdb.AuthorizationService
 	bucketSVC   influxdb.BucketService
 	checkSVC    influxdb.CheckService
 	dashSVC     influxdb.DashboardService
 	labelSVC    influxdb.LabelService
 	endpointSVC influxdb.NotificationEndpointService
 	orgSVC      influxdb.OrganizationService
+	querySVC    query.QueryService
 	ruleSVC     influxdb.NotificationRuleStore
 	secretSVC   influxdb.SecretService
 	taskSVC     taskmodel.TaskService
@@ -200,6 +266,30 @@ func WithTimeGenerator(timeGen influxdb.TimeGenerator) ServiceSetterFn {
 	}
 }
 
+// WithIdempotencyWindow sets how long the result of an apply is returned
+// for a repeated idempotency key.
+func WithIdempotencyWindow(window time.Duration) ServiceSetterFn {
+	return func(opt *serviceOpt) {
+		opt.idempotencyWindow = window
+	}
+}
+
+// WithRegistryClient sets the registry client used to check stacks applied
+// from registry references for newer versions.
+func WithRegistryClient(c *registry.Client) ServiceSetterFn {
+	return func(opt *serviceOpt) {
+		opt.registry = c
+	}
+}
+
+// WithTemplateTrustAnchors requires the remote templates of stacks, which the
+// service fetches itself, to be signed by one of the trust anchors.
+func WithTemplateTrustAnchors(anchors ...ed25519.PublicKey) ServiceSetterFn {
+	return func(opt *serviceOpt) {
+		opt.trustAnchors = append(opt.trustAnchors, anchors...)
+	}
+}
+
 // WithStore sets the store for the service.
 func WithStore(store Store) ServiceSetterFn {
 	return func(opt *serviceOpt) {
@@ -207,6 +297,14 @@ func WithStore(store Store) ServiceSetterFn {
 	}
 }
 
+// WithAuthorizationSVC sets the authorization service used to create the
+// authorizations of templates.
+func WithAuthorizationSVC(authSVC influxdb.AuthorizationService) ServiceSetterFn {
+	return func(opt *serviceOpt) {
+		opt.authSVC = authSVC
+	}
+}
+
 // WithBucketSVC sets the bucket service.
 func WithBucketSVC(bktSVC influxdb.BucketService) ServiceSetterFn {
 	return func(opt *serviceOpt) {
@@ -262,6 +360,13 @@ func WithOrganizationService(orgSVC influxdb.OrganizationService) ServiceSetterF
 	}
 }
 
+// WithQuerySVC sets the query service used to execute flux template hooks.
+func WithQuerySVC(querySVC query.QueryService) ServiceSetterFn {
+	return func(opt *serviceOpt) {
+		opt.querySVC = querySVC
+	}
+}
+
 // WithSecretSVC sets the secret service.
 func WithSecretSVC(secretSVC influxdb.SecretService) ServiceSetterFn {
 	return func(opt *serviceOpt) {
@@ -296,7 +401,10 @@ type Store interface {
 	ListStacks(ctx context.Context, orgID platform.ID, filter ListFilter) ([]Stack, error)
 	ReadStackByID(ctx context.Context, id platform.ID) (Stack, error)
 	UpdateStack(ctx context.Context, stack Stack) error
+	UpdateStackIfUnchanged(ctx context.Context, stack Stack, updatedAt time.Time) error
 	DeleteStack(ctx context.Context, id platform.ID) error
+	AddStackJournalEntry(ctx context.Context, entry StackJournalEntry) error
+	ListStackJournal(ctx context.Context, stackID platform.ID) ([]StackJournalEntry, error)
 }
 
 // Service provides the template business logic including all the dependencies to make
@@ -311,14 +419,19 @@ type Service struct {
 	nameGen       NameGenerator
 	store         Store
 	timeGen       influxdb.TimeGenerator
+	idempotency   *idempotencyCache
+	registry      *registry.Client
+	trustAnchors  []ed25519.PublicKey
 
 	// external service dependencies
+	authSVC     influxdb.AuthorizationService
 	bucketSVC   influxdb.BucketService
 	checkSVC    influxdb.CheckService
 	dashSVC     influxdb.DashboardService
 	labelSVC    influxdb.LabelService
 	endpointSVC influxdb.NotificationEndpointService
 	orgSVC      influxdb.OrganizationService
+	querySVC    query.QueryService
 	ruleSVC     influxdb.NotificationRuleStore
 	secretSVC   influxdb.SecretService
 	taskSVC     taskmodel.TaskService
@@ -336,6 +449,8 @@ func NewService(opts ...ServiceSetterFn) *Service {
 		idGen:         snowflake.NewDefaultIDGenerator(),
 		nameGen:       wordplay.GetRandomName,
 		timeGen:       influxdb.RealTimeGenerator{},
+
+		idempotencyWindow: DefaultIdempotencyWindow,
 	}
 	for _, o := range opts {
 		o(opt)
@@ -350,13 +465,18 @@ func NewService(opts ...ServiceSetterFn) *Service {
 		nameGen:       opt.nameGen,
 		store:         opt.store,
 		timeGen:       opt.timeGen,
+		idempotency:   newIdempotencyCache(opt.idempotencyWindow, opt.timeGen),
+		registry:      opt.registry,
+		trustAnchors:  opt.trustAnchors,
 
+		authSVC:     opt.authSVC,
 		bucketSVC:   opt.bucketSVC,
 		checkSVC:    opt.checkSVC,
 		labelSVC:    opt.labelSVC,
 		dashSVC:     opt.dashSVC,
 		endpointSVC: opt.endpointSVC,
 		orgSVC:      opt.orgSVC,
+		querySVC:    opt.querySVC,
 		ruleSVC:     opt.ruleSVC,
 		secretSVC:   opt.secretSVC,
 		taskSVC:     opt.taskSVC,
@@ -406,6 +526,7 @@ func (s *Service) InitStack(ctx context.Context, userID platform.ID, stCreate St
 				EventType:    StackEventCreate,
 				Name:         stCreate.Name,
 				Description:  stCreate.Description,
+				Sources:      stCreate.Sources,
 				Resources:    stCreate.Resources,
 				TemplateURLs: stCreate.TemplateURLs,
 				UpdatedAt:    now,
@@ -423,18 +544,29 @@ func (s *Service) InitStack(ctx context.Context, userID platform.ID, stCreate St
 func (s *Service) UninstallStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (Stack, error) {
 	uninstalledStack, err := s.uninstallStack(ctx, identifiers)
 	if err != nil {
+		s.recordStackJournal(ctx, newStackJournalEntry(StackJournalActionUninstall, identifiers.StackID, identifiers.UserID, ImpactSummary{}, err, s.timeGen.Now()))
 		return Stack{}, err
 	}
 
 	ev := uninstalledStack.LatestEvent()
+	removed, sources := len(ev.Resources), ev.Sources
+
 	ev.EventType = StackEventUninstalled
 	ev.Resources = nil
 	ev.UpdatedAt = s.timeGen.Now()
 
 	uninstalledStack.Events = append(uninstalledStack.Events, ev)
-	if err := s.store.UpdateStack(ctx, uninstalledStack); err != nil {
-		s.log.Error("unable to update stack after uninstalling resources", zap.Error(err))
+	persistErr := s.store.UpdateStack(ctx, uninstalledStack)
+	if persistErr != nil {
+		s.log.Error("unable to update stack after uninstalling resources", zap.Error(persistErr))
 	}
+
+	// the uninstall is recorded once the stack is persisted, as failed if
+	// the stack still lists the uninstalled resources
+	entry := newStackJournalEntry(StackJournalActionUninstall, identifiers.StackID, identifiers.UserID, ImpactSummary{Sources: sources}, persistErr, s.timeGen.Now())
+	entry.Impact.Removed = removed
+	s.recordStackJournal(ctx, entry)
+
 	return uninstalledStack, nil
 }
 
@@ -476,6 +608,16 @@ func (s *Service) uninstallStack(ctx context.Context, identifiers struct{ OrgID,
 	if err != nil {
 		return Stack{}, err
 	}
+
+	for _, r := range stack.LatestEvent().Resources {
+		if !r.Kind.is(KindAuthorization) || s.authSVC == nil {
+			continue
+		}
+		err := s.authSVC.DeleteAuthorization(ctx, r.ID)
+		if err != nil && errors2.ErrorCode(err) != errors2.ENotFound {
+			s.log.Error("failed to delete stack authorization", zap.Stringer("id", r.ID), zap.Error(err))
+		}
+	}
 	return stack, nil
 }
 
@@ -495,6 +637,34 @@ func (s *Service) ReadStack(ctx context.Context, id platform.ID) (Stack, error)
 	return s.store.ReadStackByID(ctx, id)
 }
 
+// ReadStackJournal returns the applies, dry runs and uninstalls performed
+// against the stack, oldest first.
+func (s *Service) ReadStackJournal(ctx context.Context, stackID platform.ID) ([]StackJournalEntry, error) {
+	if _, err := s.store.ReadStackByID(ctx, stackID); err != nil {
+		return nil, err
+	}
+	return s.store.ListStackJournal(ctx, stackID)
+}
+
+// recordStackJournal adds the entry to the journal of its stack. Failing to
+// record an entry does not fail the operation it records.
+func (s *Service) recordStackJournal(ctx context.Context, entry StackJournalEntry) {
+	if entry.StackID == 0 {
+		return
+	}
+	if _, err := s.store.ReadStackByID(ctx, entry.StackID); err != nil {
+		// operations against stacks that do not exist have no journal
+		return
+	}
+	if err := s.store.AddStackJournalEntry(ctx, entry); err != nil {
+		s.log.Error("unable to record stack journal entry",
+			zap.Stringer("stackID", entry.StackID),
+			zap.String("action", string(entry.Action)),
+			zap.Error(err),
+		)
+	}
+}
+
 // UpdateStack updates the stack by the given parameters.
 func (s *Service) UpdateStack(ctx context.Context, upd StackUpdate) (Stack, error) {
 	existing, err := s.ReadStack(ctx, upd.ID)
@@ -518,13 +688,181 @@ func (s *Service) UpdateStack(ctx context.Context, upd StackUpdate) (Stack, erro
 	}
 
 	updatedStack := s.applyStackUpdate(existing, upd)
-	if err := s.store.UpdateStack(ctx, updatedStack); err != nil {
+	if upd.IfUpdatedAt != nil {
+		err = s.store.UpdateStackIfUnchanged(ctx, updatedStack, *upd.IfUpdatedAt)
+	} else {
+		err = s.store.UpdateStack(ctx, updatedStack)
+	}
+	if err != nil {
 		return Stack{}, err
 	}
 
 	return updatedStack, nil
 }
 
+// CheckStackUpdates refetches the sources the stack was last applied from and
+// reports which of them changed since. Registry references are checked
+// against the latest version in the registry rather than the pinned one.
+func (s *Service) CheckStackUpdates(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackUpdateCheck, error) {
+	stack, err := s.store.ReadStackByID(ctx, identifiers.StackID)
+	if err != nil {
+		return StackUpdateCheck{}, err
+	}
+	if stack.OrgID != identifiers.OrgID {
+		return StackUpdateCheck{}, &errors2.Error{
+			Code: errors2.EConflict,
+			Msg:  "you do not have access to given stack ID",
+		}
+	}
+
+	ev := stack.LatestEvent()
+	stackURLs := make(map[string]bool, len(ev.TemplateURLs))
+	for _, u := range ev.TemplateURLs {
+		stackURLs[u] = true
+	}
+
+	check := StackUpdateCheck{StackID: stack.ID}
+	allChecked := true
+	var latestTemplates []*Template
+	for _, current := range ev.SourceVersions {
+		update := StackSourceUpdate{Current: current}
+		template, err := s.fetchLatestSource(ctx, current.Source)
+		if err != nil {
+			return StackUpdateCheck{}, err
+		}
+		if template != nil {
+			latest := template.sourceVersions[0]
+			update.Checked = true
+			update.Latest = &latest
+			update.UpdateAvailable = latest.SHA256 != current.SHA256
+			check.UpdateAvailable = check.UpdateAvailable || update.UpdateAvailable
+
+			// the stack's template urls are refetched by the dry run itself
+			if !stackURLs[current.Source] {
+				latestTemplates = append(latestTemplates, template)
+			}
+		}
+		allChecked = allChecked && update.Checked
+		check.Sources = append(check.Sources, update)
+	}
+
+	if !check.UpdateAvailable || !allChecked {
+		return check, nil
+	}
+
+	opts := []ApplyOptFn{ApplyWithStackID(stack.ID)}
+	for _, t := range latestTemplates {
+		opts = append(opts, ApplyWithTemplate(t))
+	}
+	impact, err := s.DryRun(ctx, identifiers.OrgID, identifiers.UserID, opts...)
+	if err != nil {
+		return StackUpdateCheck{}, err
+	}
+	check.Diff = &impact.Diff
+	return check, nil
+}
+
+// CheckStackDrift reapplies the sources the stack was last applied from in a
+// dry run and reports whether its resources differ from them. The applied
+// versions of the sources are not stored with the stack, so drift is only
+// checked when every source is refetched unchanged.
+func (s *Service) CheckStackDrift(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackDrift, error) {
+	stack, err := s.store.ReadStackByID(ctx, identifiers.StackID)
+	if err != nil {
+		return StackDrift{}, err
+	}
+	if stack.OrgID != identifiers.OrgID {
+		return StackDrift{}, &errors2.Error{
+			Code: errors2.EConflict,
+			Msg:  "you do not have access to given stack ID",
+		}
+	}
+
+	ev := stack.LatestEvent()
+	drift := StackDrift{StackID: stack.ID}
+	if len(ev.SourceVersions) == 0 {
+		return drift, nil
+	}
+
+	stackURLs := make(map[string]bool, len(ev.TemplateURLs))
+	for _, u := range ev.TemplateURLs {
+		stackURLs[u] = true
+	}
+
+	var appliedTemplates []*Template
+	for _, applied := range ev.SourceVersions {
+		template, err := s.fetchLatestSource(ctx, applied.Source)
+		if err != nil {
+			return StackDrift{}, err
+		}
+		if template == nil || template.sourceVersions[0].SHA256 != applied.SHA256 {
+			return drift, nil
+		}
+		// the stack's template urls are refetched by the dry run itself
+		if !stackURLs[applied.Source] {
+			appliedTemplates = append(appliedTemplates, template)
+		}
+	}
+
+	opts := []ApplyOptFn{ApplyWithStackID(stack.ID)}
+	for _, t := range appliedTemplates {
+		opts = append(opts, ApplyWithTemplate(t))
+	}
+	impact, err := s.DryRun(ctx, identifiers.OrgID, identifiers.UserID, opts...)
+	if err != nil {
+		return StackDrift{}, err
+	}
+	drift.Checked = true
+	drift.Drifted = !impact.Diff.IsNoOp()
+	drift.Diff = &impact.Diff
+	return drift, nil
+}
+
+// fetchLatestSource returns the latest template for the source, or nil when
+// the source is not a url or registry reference that can be refetched.
+func (s *Service) fetchLatestSource(ctx context.Context, source string) (*Template, error) {
+	if registry.IsReference(source) {
+		if s.registry == nil {
+			return nil, nil
+		}
+		ref, err := registry.ParseReference(source)
+		if err != nil {
+			return nil, influxErr(errors2.EInternal, err)
+		}
+		ref.Version = ""
+		v, err := s.registry.Resolve(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		readerFn := FromRegistry(ctx, s.registry, ref)
+		if len(s.trustAnchors) > 0 {
+			readerFn = verifiedReaderFn(readerFn, v.URL, "", "", s.client, s.trustAnchors)
+		}
+		return Parse(convertEncoding("", v.URL), readerFn, ValidSkipParseError())
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, nil
+	}
+	switch u.Scheme {
+	case "http", "https", "file":
+	default:
+		return nil, nil
+	}
+	readerFn := s.remoteReaderFn(u)
+
+	template, err := Parse(convertEncoding("", u.Path), readerFn, ValidSkipParseError())
+	if err != nil {
+		return nil, &errors2.Error{
+			Code: errors2.EUnprocessableEntity,
+			Msg:  fmt.Sprintf("failed to refetch stack source %q", source),
+			Err:  err,
+		}
+	}
+	return template, nil
+}
+
 func (s *Service) applyStackUpdate(existing Stack, upd StackUpdate) Stack {
 	ev := existing.LatestEvent()
 	ev.EventType = StackEventUpdate
@@ -978,6 +1316,90 @@ type ImpactSummary struct {
 	StackID platform.ID
 	Diff    Diff
 	Summary Summary
+	Hooks   []HookResult
+	// Authorizations are the authorizations of the template. Their tokens
+	// are only set for an applied template.
+	Authorizations []SummaryAuthorization
+	// Timing is only set for an applied template, it is empty for a dry run.
+	Timing ApplyTiming
+	// Resources is the change applying the template makes to each of its
+	// resources, and NoOp is set when it changes none of them, such as
+	// when an unchanged template is applied again.
+	Resources []ResourceImpact
+	NoOp      bool
+}
+
+// ApplyTiming records how long an apply took and, per kind, the number of
+// resources changed and the time spent applying them.
+type ApplyTiming struct {
+	Duration time.Duration `json:"duration" yaml:"duration"`
+	Kinds    []KindTiming  `json:"kinds" yaml:"kinds"`
+}
+
+// KindTiming is the number of resources of a kind created, updated,
+// removed and left unchanged by an apply, and the time elapsed applying
+// them.
+type KindTiming struct {
+	Kind      Kind          `json:"kind" yaml:"kind"`
+	Created   int           `json:"created" yaml:"created"`
+	Updated   int           `json:"updated" yaml:"updated"`
+	Removed   int           `json:"removed" yaml:"removed"`
+	Unchanged int           `json:"unchanged" yaml:"unchanged"`
+	Elapsed   time.Duration `json:"elapsed" yaml:"elapsed"`
+}
+
+// newApplyTiming builds the timing of an apply from the applied diff and the
+// time each kind took to apply. Checks and notification endpoints are
+// reported under their base kind, as that is how they are applied.
+func newApplyTiming(duration time.Duration, diff Diff, elapsed map[Kind]time.Duration) ApplyTiming {
+	byKind := make(map[Kind]*KindTiming)
+	get := func(k Kind) *KindTiming {
+		kt, ok := byKind[k]
+		if !ok {
+			kt = &KindTiming{Kind: k, Elapsed: elapsed[k]}
+			byKind[k] = kt
+		}
+		return kt
+	}
+
+	for _, r := range diff.Impacts() {
+		kt := get(baseKind(r.Kind))
+		switch r.Change {
+		case ResourceChangeCreated:
+			kt.Created++
+		case ResourceChangeRemoved:
+			kt.Removed++
+		case ResourceChangeUnchanged:
+			kt.Unchanged++
+		default:
+			kt.Updated++
+		}
+	}
+	for k := range elapsed {
+		get(k)
+	}
+
+	timing := ApplyTiming{
+		Duration: duration,
+		Kinds:    make([]KindTiming, 0, len(byKind)),
+	}
+	for _, kt := range byKind {
+		timing.Kinds = append(timing.Kinds, *kt)
+	}
+	sort.Slice(timing.Kinds, func(i, j int) bool {
+		return timing.Kinds[i].Kind < timing.Kinds[j].Kind
+	})
+	return timing
+}
+
+func baseKind(k Kind) Kind {
+	switch {
+	case k.is(KindCheckDeadman, KindCheckThreshold):
+		return KindCheck
+	case k.is(KindNotificationEndpointHTTP, KindNotificationEndpointPagerDuty, KindNotificationEndpointSlack):
+		return KindNotificationEndpoint
+	}
+	return k
 }
 
 var reCommunityTemplatesValidAddr = regexp.MustCompile(`(?:https://raw\.githubusercontent\.com/influxdata/community-templates/master/)(?P<name>\w+)(?:/.*)`)
@@ -1003,6 +1425,12 @@ func (i *ImpactSummary) communityName() string {
 // already.
 func (s *Service) DryRun(ctx context.Context, orgID, userID platform.ID, opts ...ApplyOptFn) (ImpactSummary, error) {
 	opt := applyOptFromOptFns(opts...)
+	impact, err := s.dryRunImpact(ctx, orgID, opt)
+	s.recordStackJournal(ctx, newStackJournalEntry(StackJournalActionDryRun, opt.StackID, userID, impact, err, s.timeGen.Now()))
+	return impact, err
+}
+
+func (s *Service) dryRunImpact(ctx context.Context, orgID platform.ID, opt ApplyOpt) (ImpactSummary, error) {
 	template, err := s.templateFromApplyOpts(ctx, opt)
 	if err != nil {
 		return ImpactSummary{}, err
@@ -1013,11 +1441,26 @@ func (s *Service) DryRun(ctx context.Context, orgID, userID platform.ID, opts ..
 		return ImpactSummary{}, err
 	}
 
+	var hooks []HookResult
+	for _, h := range opt.resourceActions().filterHooks(template.hooks()) {
+		hooks = append(hooks, h.result(HookStatusPending))
+	}
+
+	var auths []SummaryAuthorization
+	for _, a := range opt.resourceActions().filterAuthorizations(template.authorizations()) {
+		auths = append(auths, a.summarize())
+	}
+
+	diff := state.diff()
 	return ImpactSummary{
-		Sources: template.sources,
-		StackID: opt.StackID,
-		Diff:    state.diff(),
-		Summary: newSummaryFromStateTemplate(state, template),
+		Sources:        template.sources,
+		StackID:        opt.StackID,
+		Diff:           diff,
+		Summary:        newSummaryFromStateTemplate(state, template),
+		Hooks:          hooks,
+		Authorizations: auths,
+		Resources:      diff.Impacts(),
+		NoOp:           diff.IsNoOp(),
 	}, nil
 }
 
@@ -1041,10 +1484,7 @@ func (s *Service) dryRun(ctx context.Context, orgID platform.ID, template *Templ
 		parseErr = err
 	}
 
-	state := newStateCoordinator(template, resourceActions{
-		skipKinds:     opt.KindsToSkip,
-		skipResources: opt.ResourcesToSkip,
-	})
+	state := newStateCoordinator(template, opt.resourceActions())
 
 	if opt.StackID > 0 {
 		if err := s.addStackState(ctx, opt.StackID, state); err != nil {
@@ -1058,10 +1498,10 @@ func (s *Service) dryRun(ctx context.Context, orgID platform.ID, template *Templ
 
 	s.dryRunBuckets(ctx, orgID, state.mBuckets)
 	s.dryRunChecks(ctx, orgID, state.mChecks)
-	s.dryRunDashboards(ctx, orgID, state.mDashboards)
+	s.dryRunDashboards(ctx, orgID, state.mDashboards, state.actions.matchByName(KindDashboard))
 	s.dryRunLabels(ctx, orgID, state.mLabels)
-	s.dryRunTasks(ctx, orgID, state.mTasks)
-	s.dryRunTelegrafConfigs(ctx, orgID, state.mTelegrafs)
+	s.dryRunTasks(ctx, orgID, state.mTasks, state.actions.matchByName(KindTask))
+	s.dryRunTelegrafConfigs(ctx, orgID, state.mTelegrafs, state.actions.matchByName(KindTelegraf))
 	s.dryRunVariables(ctx, orgID, state.mVariables)
 
 	err = s.dryRunNotificationEndpoints(ctx, orgID, state.mEndpoints)
@@ -1074,6 +1514,12 @@ func (s *Service) dryRun(ctx context.Context, orgID platform.ID, template *Templ
 		return nil, err
 	}
 
+	// conflicts are resolved once rules are associated with their endpoints,
+	// a rule keeps referencing an endpoint that is skipped.
+	if conflicts := state.resolveConflicts(); len(conflicts) > 0 {
+		return nil, conflictErr(conflicts)
+	}
+
 	stateLabelMappings, err := s.dryRunLabelMappings(ctx, state)
 	if err != nil {
 		return nil, err
@@ -1120,12 +1566,25 @@ func (s *Service) dryRunChecks(ctx context.Context, orgID platform.ID, checks ma
 	}
 }
 
-func (s *Service) dryRunDashboards(ctx context.Context, orgID platform.ID, dashs map[string]*stateDashboard) {
+func (s *Service) dryRunDashboards(ctx context.Context, orgID platform.ID, dashs map[string]*stateDashboard, matchName bool) {
+	var mExistingByName map[string]*influxdb.Dashboard
+	if matchName {
+		existingDashs, _, _ := s.dashSVC.FindDashboards(ctx, influxdb.DashboardFilter{
+			OrganizationID: &orgID,
+		}, influxdb.FindOptions{})
+		mExistingByName = make(map[string]*influxdb.Dashboard, len(existingDashs))
+		for _, d := range existingDashs {
+			mExistingByName[d.Name] = d
+		}
+	}
+
 	for _, stateDash := range dashs {
 		stateDash.orgID = orgID
 		var existing *influxdb.Dashboard
 		if stateDash.ID() != 0 {
 			existing, _ = s.dashSVC.FindDashboardByID(ctx, stateDash.ID())
+		} else {
+			existing = mExistingByName[stateDash.parserDash.Name()]
 		}
 		if IsNew(stateDash.stateStatus) && existing != nil {
 			stateDash.stateStatus = StateStatusExists
@@ -1229,12 +1688,22 @@ func (s *Service) dryRunSecrets(ctx context.Context, orgID platform.ID, template
 	return nil
 }
 
-func (s *Service) dryRunTasks(ctx context.Context, orgID platform.ID, tasks map[string]*stateTask) {
+func (s *Service) dryRunTasks(ctx context.Context, orgID platform.ID, tasks map[string]*stateTask, matchName bool) {
 	for _, stateTask := range tasks {
 		stateTask.orgID = orgID
 		var existing *taskmodel.Task
 		if stateTask.ID() != 0 {
 			existing, _ = s.taskSVC.FindTaskByID(ctx, stateTask.ID())
+		} else if matchName {
+			name := stateTask.parserTask.Name()
+			existingTasks, _, _ := s.taskSVC.FindTasks(ctx, taskmodel.TaskFilter{
+				Name:           &name,
+				OrganizationID: &orgID,
+				Limit:          1,
+			})
+			if len(existingTasks) > 0 {
+				existing = existingTasks[0]
+			}
 		}
 		if IsNew(stateTask.stateStatus) && existing != nil {
 			stateTask.stateStatus = StateStatusExists
@@ -1243,12 +1712,23 @@ func (s *Service) dryRunTasks(ctx context.Context, orgID platform.ID, tasks map[
 	}
 }
 
-func (s *Service) dryRunTelegrafConfigs(ctx context.Context, orgID platform.ID, teleConfigs map[string]*stateTelegraf) {
+func (s *Service) dryRunTelegrafConfigs(ctx context.Context, orgID platform.ID, teleConfigs map[string]*stateTelegraf, matchName bool) {
+	var mExistingByName map[string]*influxdb.TelegrafConfig
+	if matchName {
+		existingTeles, _, _ := s.teleSVC.FindTelegrafConfigs(ctx, influxdb.TelegrafConfigFilter{OrgID: &orgID})
+		mExistingByName = make(map[string]*influxdb.TelegrafConfig, len(existingTeles))
+		for _, t := range existingTeles {
+			mExistingByName[t.Name] = t
+		}
+	}
+
 	for _, stateTele := range teleConfigs {
 		stateTele.orgID = orgID
 		var existing *influxdb.TelegrafConfig
 		if stateTele.ID() != 0 {
 			existing, _ = s.teleSVC.FindTelegrafConfigByID(ctx, stateTele.ID())
+		} else {
+			existing = mExistingByName[stateTele.parserTelegraf.Name()]
 		}
 		if IsNew(stateTele.stateStatus) && existing != nil {
 			stateTele.stateStatus = StateStatusExists
@@ -1458,6 +1938,22 @@ type (
 		StackID         platform.ID
 		ResourcesToSkip map[ActionSkipResource]bool
 		KindsToSkip     map[Kind]bool
+		KindsToApply    map[Kind]bool
+
+		ResourcesToDetach map[ActionDetachResource]bool
+		ResourcesToRemove map[ActionRemoveResource]bool
+
+		// IdempotencyKey identifies an apply so that a retried request
+		// returns the impact of the original apply.
+		IdempotencyKey string
+
+		// MergeStrategy resolves resources defined by more than one of the
+		// templates.
+		MergeStrategy MergeStrategy
+
+		// ConflictPolicies resolve template resources, by kind, that are not
+		// part of the stack and share their name with an existing resource.
+		ConflictPolicies map[Kind]ConflictPolicy
 	}
 
 	// ActionSkipResource provides an action from the consumer to use the template with
@@ -1473,6 +1969,21 @@ type (
 		Kind Kind `json:"kind"`
 	}
 
+	// ActionDetachResource provides an action to stop managing a stack resource
+	// with the stack. The resource is left as is on the platform and is no longer
+	// applied, updated or removed by the stack.
+	ActionDetachResource struct {
+		Kind     Kind   `json:"kind"`
+		MetaName string `json:"resourceTemplateName"`
+	}
+
+	// ActionRemoveResource provides an action to remove a stack resource from the
+	// platform and the stack, even when the resource is still in the template.
+	ActionRemoveResource struct {
+		Kind     Kind   `json:"kind"`
+		MetaName string `json:"resourceTemplateName"`
+	}
+
 	// ApplyOptFn updates the ApplyOpt per the functional option.
 	ApplyOptFn func(opt *ApplyOpt)
 )
@@ -1497,33 +2008,82 @@ func ApplyWithResourceSkip(action ActionSkipResource) ApplyOptFn {
 		if opt.ResourcesToSkip == nil {
 			opt.ResourcesToSkip = make(map[ActionSkipResource]bool)
 		}
-		switch action.Kind {
-		case KindCheckDeadman, KindCheckThreshold:
-			action.Kind = KindCheck
-		case KindNotificationEndpointHTTP,
-			KindNotificationEndpointPagerDuty,
-			KindNotificationEndpointSlack:
-			action.Kind = KindNotificationEndpoint
-		}
+		action.Kind = normalizeActionKind(action.Kind)
 		opt.ResourcesToSkip[action] = true
 	}
 }
 
+// ApplyWithResourceDetach detaches a resource from the stack being applied. Unlike
+// resources dropped from the template, a detached resource is not removed from the
+// platform.
+func ApplyWithResourceDetach(action ActionDetachResource) ApplyOptFn {
+	return func(opt *ApplyOpt) {
+		if opt.ResourcesToDetach == nil {
+			opt.ResourcesToDetach = make(map[ActionDetachResource]bool)
+		}
+		action.Kind = normalizeActionKind(action.Kind)
+		opt.ResourcesToDetach[action] = true
+	}
+}
+
+// ApplyWithResourceRemove removes a resource of the stack being applied from the
+// platform and the stack.
+func ApplyWithResourceRemove(action ActionRemoveResource) ApplyOptFn {
+	return func(opt *ApplyOpt) {
+		if opt.ResourcesToRemove == nil {
+			opt.ResourcesToRemove = make(map[ActionRemoveResource]bool)
+		}
+		action.Kind = normalizeActionKind(action.Kind)
+		opt.ResourcesToRemove[action] = true
+	}
+}
+
+// ApplyWithKindFilter restricts the application of a template to the provided kinds.
+// Resources of any other kind in the template are skipped. Providing the option
+// multiple times widens the filter.
+func ApplyWithKindFilter(kinds ...Kind) ApplyOptFn {
+	return func(opt *ApplyOpt) {
+		if opt.KindsToApply == nil {
+			opt.KindsToApply = make(map[Kind]bool)
+		}
+		for _, k := range kinds {
+			opt.KindsToApply[normalizeActionKind(k)] = true
+		}
+	}
+}
+
+func (opt ApplyOpt) resourceActions() resourceActions {
+	return resourceActions{
+		skipKinds:       opt.KindsToSkip,
+		skipResources:   opt.ResourcesToSkip,
+		applyKinds:      opt.KindsToApply,
+		detachResources: opt.ResourcesToDetach,
+		removeResources: opt.ResourcesToRemove,
+		conflicts:       opt.ConflictPolicies,
+	}
+}
+
+// normalizeActionKind maps the specific check and notification endpoint
+// kinds to the kind used when tracking resource actions.
+func normalizeActionKind(k Kind) Kind {
+	switch k {
+	case KindCheckDeadman, KindCheckThreshold:
+		return KindCheck
+	case KindNotificationEndpointHTTP,
+		KindNotificationEndpointPagerDuty,
+		KindNotificationEndpointSlack:
+		return KindNotificationEndpoint
+	}
+	return k
+}
+
 // ApplyWithKindSkip provides an action skip a kidn in the application of a template.
 func ApplyWithKindSkip(action ActionSkipKind) ApplyOptFn {
 	return func(opt *ApplyOpt) {
 		if opt.KindsToSkip == nil {
 			opt.KindsToSkip = make(map[Kind]bool)
 		}
-		switch action.Kind {
-		case KindCheckDeadman, KindCheckThreshold:
-			action.Kind = KindCheck
-		case KindNotificationEndpointHTTP,
-			KindNotificationEndpointPagerDuty,
-			KindNotificationEndpointSlack:
-			action.Kind = KindNotificationEndpoint
-		}
-		opt.KindsToSkip[action.Kind] = true
+		opt.KindsToSkip[normalizeActionKind(action.Kind)] = true
 	}
 }
 
@@ -1541,6 +2101,63 @@ func ApplyWithStackID(stackID platform.ID) ApplyOptFn {
 	}
 }
 
+// ApplyWithIdempotencyKey identifies the apply with a key. An apply repeating
+// the key of a successful apply within the idempotency window returns the
+// original impact instead of applying the template again.
+func ApplyWithIdempotencyKey(key string) ApplyOptFn {
+	return func(o *ApplyOpt) {
+		o.IdempotencyKey = key
+	}
+}
+
+// ApplyWithMergeStrategy sets how resources defined by more than one of the
+// templates are resolved. Defaults to MergeStrategyError.
+func ApplyWithMergeStrategy(m MergeStrategy) ApplyOptFn {
+	return func(o *ApplyOpt) {
+		o.MergeStrategy = m
+	}
+}
+
+// ConflictPolicy decides how a template resource is applied when it is not
+// part of the stack and its name collides with an existing resource.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyFail fails the apply.
+	ConflictPolicyFail ConflictPolicy = "fail"
+	// ConflictPolicySkip leaves the existing resource untouched.
+	ConflictPolicySkip ConflictPolicy = "skip"
+	// ConflictPolicyOverwrite updates the existing resource with the
+	// template resource.
+	ConflictPolicyOverwrite ConflictPolicy = "overwrite"
+)
+
+// OK validates the conflict policy.
+func (p ConflictPolicy) OK() error {
+	switch p {
+	case ConflictPolicyFail, ConflictPolicySkip, ConflictPolicyOverwrite:
+		return nil
+	default:
+		return fmt.Errorf("invalid conflict policy %q; must be one of %q, %q or %q",
+			p, ConflictPolicyFail, ConflictPolicySkip, ConflictPolicyOverwrite)
+	}
+}
+
+// ApplyWithConflictPolicy sets how template resources of the kind that
+// collide with existing resources are applied. Without a policy, buckets,
+// checks, labels, notification endpoints and variables overwrite the
+// existing resource and resources of the other kinds are created alongside
+// it. Dashboards, tasks and telegraf configs are only matched to existing
+// resources by name when a policy is set for their kind.
+func ApplyWithConflictPolicy(k Kind, p ConflictPolicy) ApplyOptFn {
+	return func(o *ApplyOpt) {
+		if o.ConflictPolicies == nil {
+			o.ConflictPolicies = make(map[Kind]ConflictPolicy)
+		}
+		o.ConflictPolicies[normalizeActionKind(k)] = p
+	}
+}
+
 func applyOptFromOptFns(opts ...ApplyOptFn) ApplyOpt {
 	var opt ApplyOpt
 	for _, o := range opts {
@@ -1552,8 +2169,30 @@ func applyOptFromOptFns(opts ...ApplyOptFn) ApplyOpt {
 // Apply will apply all the resources identified in the provided template. The entire template will be applied
 // in its entirety. If a failure happens midway then the entire template will be rolled back to the state
 // from before the template were applied.
-func (s *Service) Apply(ctx context.Context, orgID, userID platform.ID, opts ...ApplyOptFn) (impact ImpactSummary, e error) {
+func (s *Service) Apply(ctx context.Context, orgID, userID platform.ID, opts ...ApplyOptFn) (ImpactSummary, error) {
 	opt := applyOptFromOptFns(opts...)
+	apply := func() (ImpactSummary, error) {
+		impact, err := s.apply(ctx, orgID, userID, opt)
+		stackID := opt.StackID
+		if impact.StackID != 0 {
+			stackID = impact.StackID
+		}
+		s.recordStackJournal(ctx, newStackJournalEntry(StackJournalActionApply, stackID, userID, impact, err, s.timeGen.Now()))
+		return impact, err
+	}
+	if opt.IdempotencyKey == "" {
+		return apply()
+	}
+	fingerprint, err := opt.fingerprint()
+	if err != nil {
+		return ImpactSummary{}, internalErr(err)
+	}
+	// replayed applies are not recorded again
+	return s.idempotency.do(ctx, orgID, userID, opt.IdempotencyKey, fingerprint, apply)
+}
+
+func (s *Service) apply(ctx context.Context, orgID, userID platform.ID, opt ApplyOpt) (impact ImpactSummary, e error) {
+	start := s.timeGen.Now()
 
 	template, err := s.templateFromApplyOpts(ctx, opt)
 	if err != nil {
@@ -1573,6 +2212,12 @@ func (s *Service) Apply(ctx context.Context, orgID, userID platform.ID, opts ...
 		return ImpactSummary{}, err
 	}
 
+	templateHooks := opt.resourceActions().filterHooks(template.hooks())
+	hooks, err := s.runHooks(ctx, orgID, templateHooks, HookPhasePreApply)
+	if err != nil {
+		return ImpactSummary{}, err
+	}
+
 	stackID := opt.StackID
 	// if stackID is not provided, a stack will be provided for the application.
 	if stackID == 0 {
@@ -1594,7 +2239,7 @@ func (s *Service) Apply(ctx context.Context, orgID, userID platform.ID, opts ...
 			}
 		}
 
-		err := updateStackFn(ctx, stackID, state, template.Sources())
+		err := updateStackFn(ctx, stackID, state, template.Sources(), template.sourceVersions)
 		if err != nil {
 			s.log.Error("failed to update stack", zap.Error(err))
 		}
@@ -1610,14 +2255,265 @@ func (s *Service) Apply(ctx context.Context, orgID, userID platform.ID, opts ...
 
 	template.applySecrets(opt.MissingSecrets)
 
+	templateAuths := opt.resourceActions().filterAuthorizations(template.authorizations())
+	auths, err := s.applyAuthorizations(ctx, orgID, userID, stackID, state, templateAuths)
+	if err != nil {
+		return ImpactSummary{}, err
+	}
+
+	// the resources have been applied at this point, a failed post apply hook
+	// is recorded in the impact summary instead of rolling back the template.
+	postHooks, err := s.runHooks(ctx, orgID, templateHooks, HookPhasePostApply)
+	if err != nil {
+		s.log.Error("failed to run post apply hooks", zap.Error(err))
+	}
+	hooks = append(hooks, postHooks...)
+
+	diff := state.diff()
 	return ImpactSummary{
-		Sources: template.sources,
-		StackID: stackID,
-		Diff:    state.diff(),
-		Summary: newSummaryFromStateTemplate(state, template),
+		Sources:        template.sources,
+		StackID:        stackID,
+		Diff:           diff,
+		Summary:        newSummaryFromStateTemplate(state, template),
+		Hooks:          hooks,
+		Authorizations: auths,
+		Timing:         newApplyTiming(s.timeGen.Now().Sub(start), diff, coordinator.kindElapsed()),
+		Resources:      diff.Impacts(),
+		NoOp:           diff.IsNoOp(),
 	}, nil
 }
 
+// applyAuthorizations creates the authorizations of the template, scoped to
+// the IDs of the applied resources, and deletes the authorizations created
+// by the previous apply of the stack, rotating their tokens. Authorizations
+// skipped by the apply are kept as they are.
+func (s *Service) applyAuthorizations(ctx context.Context, orgID, userID, stackID platform.ID, state *stateCoordinator, auths []*authorization) ([]SummaryAuthorization, error) {
+	var previous []StackResource
+	if stack, err := s.store.ReadStackByID(ctx, stackID); err == nil {
+		for _, r := range stack.LatestEvent().Resources {
+			if r.Kind.is(KindAuthorization) {
+				previous = append(previous, r)
+			}
+		}
+	}
+	if len(auths) == 0 && len(previous) == 0 {
+		return nil, nil
+	}
+	if s.authSVC == nil {
+		return nil, &errors2.Error{
+			Code: errors2.EUnprocessableEntity,
+			Msg:  "template authorizations are not supported by this server",
+		}
+	}
+
+	state.authorizations = make(map[string]platform.ID)
+	var created []SummaryAuthorization
+	for _, a := range auths {
+		auth, err := newTemplateAuthorization(orgID, userID, state, a)
+		if err == nil {
+			err = s.authSVC.CreateAuthorization(ctx, auth)
+		}
+		if err != nil {
+			for _, c := range created {
+				if err := s.authSVC.DeleteAuthorization(ctx, platform.ID(c.ID)); err != nil {
+					s.log.Error("failed to delete created authorization", zap.Stringer("id", platform.ID(c.ID)), zap.Error(err))
+				}
+			}
+			return nil, err
+		}
+
+		state.authorizations[a.MetaName()] = auth.ID
+		sum := a.summarize()
+		sum.ID = SafeID(auth.ID)
+		sum.Permissions = auth.Permissions
+		sum.Token = auth.Token
+		created = append(created, sum)
+	}
+
+	for _, r := range previous {
+		if state.actions.skipResource(KindAuthorization, r.MetaName) {
+			state.authorizations[r.MetaName] = r.ID
+			continue
+		}
+		err := s.authSVC.DeleteAuthorization(ctx, r.ID)
+		if err != nil && errors2.ErrorCode(err) != errors2.ENotFound {
+			s.log.Error("failed to delete rotated authorization", zap.Stringer("id", r.ID), zap.Error(err))
+		}
+	}
+
+	return created, nil
+}
+
+// newTemplateAuthorization resolves the resources the permissions of the
+// template authorization reference to the IDs they were applied with.
+func newTemplateAuthorization(orgID, userID platform.ID, state *stateCoordinator, a *authorization) (*influxdb.Authorization, error) {
+	perms := make([]influxdb.Permission, 0, len(a.permissions))
+	for _, p := range a.permissions {
+		var id platform.ID
+		if v, ok := state.get(p.kind, p.metaName); ok {
+			if res, ok := v.(interface{ ID() platform.ID }); ok {
+				id = res.ID()
+			}
+		}
+		if id == 0 {
+			return nil, &errors2.Error{
+				Code: errors2.EConflict,
+				Msg:  fmt.Sprintf("authorization %q references %s %q which was not applied", a.MetaName(), p.kind, p.metaName),
+			}
+		}
+
+		perm, err := influxdb.NewPermissionAtID(id, p.action, p.kind.ResourceType(), orgID)
+		if err != nil {
+			return nil, failedValidationErr(err)
+		}
+		perms = append(perms, *perm)
+	}
+
+	description := a.description
+	if description == "" {
+		description = a.Name()
+	}
+	return &influxdb.Authorization{
+		OrgID:       orgID,
+		UserID:      userID,
+		Status:      influxdb.Active,
+		Description: description,
+		Permissions: perms,
+	}, nil
+}
+
+// hookOutputLimit caps the amount of a hook's response recorded in its result.
+const hookOutputLimit = 1 << 10
+
+func (s *Service) runHooks(ctx context.Context, orgID platform.ID, hooks []*hook, phase HookPhase) ([]HookResult, error) {
+	var (
+		results []HookResult
+		errs    []string
+	)
+	for _, h := range hooks {
+		if h.phase != phase {
+			continue
+		}
+
+		res := h.result(HookStatusSuccess)
+		output, err := s.runHook(ctx, orgID, h)
+		res.Output = output
+		if err != nil {
+			res.Status = HookStatusFailed
+			res.Error = err.Error()
+			errs = append(errs, fmt.Sprintf("hook[%q]: %s", h.MetaName(), err))
+		}
+		results = append(results, res)
+	}
+
+	if len(errs) > 0 {
+		msg := fmt.Sprintf("failed to run %s hooks: %s", phase, strings.Join(errs, "; "))
+		return results, influxErr(errors2.EUnprocessableEntity, msg)
+	}
+	return results, nil
+}
+
+func (s *Service) runHook(ctx context.Context, orgID platform.ID, h *hook) (string, error) {
+	switch h.hookType {
+	case hookTypeFlux:
+		return s.runFluxHook(ctx, orgID, h)
+	case hookTypeHTTP:
+		return s.runHTTPHook(ctx, h)
+	default:
+		return "", fmt.Errorf("unsupported hook type %q", h.hookType)
+	}
+}
+
+func (s *Service) runFluxHook(ctx context.Context, orgID platform.ID, h *hook) (string, error) {
+	if s.querySVC == nil {
+		return "", errors.New("flux hooks are not supported")
+	}
+
+	auth, err := hookAuthorization(ctx, orgID)
+	if err != nil {
+		return "", err
+	}
+
+	itr, err := s.querySVC.Query(ctx, &query.Request{
+		Authorization:  auth,
+		OrganizationID: orgID,
+		Compiler:       lang.FluxCompiler{Query: h.query},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer itr.Release()
+
+	var rows int
+	for itr.More() {
+		err := itr.Next().Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(cr flux.ColReader) error {
+				rows += cr.Len()
+				return nil
+			})
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := itr.Err(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d rows returned", rows), nil
+}
+
+func (s *Service) runHTTPHook(ctx context.Context, h *hook) (string, error) {
+	if s.client == nil {
+		return "", errors.New("http hooks are not supported")
+	}
+
+	var body io.Reader
+	if h.body != "" {
+		body = strings.NewReader(h.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, h.method, h.url, body)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, hookOutputLimit))
+	output := strings.TrimSpace(string(b))
+	if resp.StatusCode/100 != 2 {
+		return output, fmt.Errorf("bad response: address=%s status_code=%d", h.url, resp.StatusCode)
+	}
+	return output, nil
+}
+
+func hookAuthorization(ctx context.Context, orgID platform.ID) (*influxdb.Authorization, error) {
+	a, err := pctx.GetAuthorizer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch a := a.(type) {
+	case *influxdb.Authorization:
+		return a, nil
+	case *influxdb.Session:
+		return a.EphemeralAuth(orgID), nil
+	case *jsonweb.Token:
+		return a.EphemeralAuth(orgID), nil
+	case *influxdb.Impersonation:
+		return a.EphemeralAuth(orgID), nil
+	default:
+		return nil, influxdb.ErrAuthorizerNotSupported
+	}
+}
+
 func (s *Service) applyState(ctx context.Context, coordinator *rollbackCoordinator, orgID, userID platform.ID, state *stateCoordinator, missingSecrets map[string]string) (e error) {
 	endpointApp, ruleApp, err := s.applyNotificationGenerator(ctx, userID, state.rules(), state.endpoints())
 	if err != nil {
@@ -1641,17 +2537,17 @@ func (s *Service) applyState(ctx context.Context, coordinator *rollbackCoordinat
 		},
 		{
 			// deps for primary resources
-			s.applyLabels(ctx, state.labels()),
+			s.applyLabels(ctx, state.labels()).withKind(KindLabel),
 		},
 		{
 			// primary resources, can have relationships to labels
-			s.applyVariables(ctx, state.variables()),
-			s.applyBuckets(ctx, state.buckets()),
-			s.applyChecks(ctx, state.checks()),
-			s.applyDashboards(ctx, state.dashboards()),
-			endpointApp,
-			s.applyTasks(ctx, state.tasks()),
-			s.applyTelegrafs(ctx, userID, state.telegrafConfigs()),
+			s.applyVariables(ctx, state.variables()).withKind(KindVariable),
+			s.applyBuckets(ctx, state.buckets()).withKind(KindBucket),
+			s.applyChecks(ctx, state.checks()).withKind(KindCheck),
+			s.applyDashboards(ctx, state.dashboards()).withKind(KindDashboard),
+			endpointApp.withKind(KindNotificationEndpoint),
+			s.applyTasks(ctx, state.tasks()).withKind(KindTask),
+			s.applyTelegrafs(ctx, userID, state.telegrafConfigs()).withKind(KindTelegraf),
 		},
 	}
 
@@ -1663,7 +2559,7 @@ func (s *Service) applyState(ctx context.Context, coordinator *rollbackCoordinat
 
 	// this has to be run after the above primary resources, because it relies on
 	// notification endpoints already being applied.
-	if err := coordinator.runTilEnd(ctx, orgID, userID, ruleApp); err != nil {
+	if err := coordinator.runTilEnd(ctx, orgID, userID, ruleApp.withKind(KindNotificationRule)); err != nil {
 		return err
 	}
 
@@ -1976,6 +2872,7 @@ func (s *Service) applyDashboard(ctx context.Context, d *stateDashboard) (influx
 			Name:        &name,
 			Description: &d.parserDash.Description,
 			Cells:       &cells,
+			TimeRange:   timeRangeUpdate(d.parserDash.TimeRange),
 		})
 		if err != nil {
 			return influxdb.Dashboard{}, applyFailErr("update", d.stateIdentity(), err)
@@ -1988,6 +2885,7 @@ func (s *Service) applyDashboard(ctx context.Context, d *stateDashboard) (influx
 			Description:    d.parserDash.Description,
 			Name:           d.parserDash.Name(),
 			Cells:          cells,
+			TimeRange:      d.parserDash.TimeRange,
 		}
 		err := s.dashSVC.CreateDashboard(ctx, &influxDashboard)
 		if err != nil {
@@ -2012,6 +2910,7 @@ func (s *Service) rollbackDashboards(ctx context.Context, dashs []*stateDashboar
 				Name:        &d.existing.Name,
 				Description: &d.existing.Description,
 				Cells:       &d.existing.Cells,
+				TimeRange:   timeRangeUpdate(d.existing.TimeRange),
 			})
 			return ierrors.Wrap(err, "failed to update dashboard")
 		default:
@@ -2044,6 +2943,8 @@ func convertChartsToCells(ch []*chart) []*influxdb.Cell {
 				Y: int32(c.YPos),
 				H: int32(c.Height),
 				W: int32(c.Width),
+
+				TimeRange: c.TimeRange,
 			},
 			View: &influxdb.View{
 				ViewContents: influxdb.ViewContents{Name: c.Name},
@@ -2055,6 +2956,15 @@ func convertChartsToCells(ch []*chart) []*influxdb.Cell {
 	return icells
 }
 
+// timeRangeUpdate returns the update replacing a time range with tr, which
+// removes the time range when tr is nil.
+func timeRangeUpdate(tr *influxdb.TimeRangeOverride) *influxdb.TimeRangeOverride {
+	if tr == nil {
+		return new(influxdb.TimeRangeOverride)
+	}
+	return tr
+}
+
 func (s *Service) applyLabels(ctx context.Context, labels []*stateLabel) applier {
 	const resource = "label"
 
@@ -2308,6 +3218,10 @@ func (s *Service) applyNotificationGenerator(ctx context.Context, userID platfor
 			continue
 		}
 		v, ok := mEndpoints[r.endpointTemplateName()]
+		if !ok && r.associatedEndpoint != nil && IsExisting(r.associatedEndpoint.stateStatus) {
+			// the endpoint is left untouched by its conflict policy
+			continue
+		}
 		if !ok {
 			errs = append(errs, &applyErrBody{
 				name: r.parserRule.MetaName(),
@@ -2591,13 +3505,13 @@ func (s *Service) applyTask(ctx context.Context, userID platform.ID, t *stateTas
 			Name: t.parserTask.Name(),
 			Cron: t.parserTask.cron,
 		}
-		if every := t.parserTask.every; every > 0 {
-			opt.Every.Parse(every.String())
+		if every := t.parserTask.everyStr(); every != "" {
+			opt.Every.Parse(every)
 		}
-		if offset := t.parserTask.offset; offset > 0 {
-			var off options.Duration
-			if err := off.Parse(offset.String()); err == nil {
-				opt.Offset = &off
+		opt.Offset = &options.Duration{}
+		if offset := t.parserTask.offsetStr(); offset != "" {
+			if err := opt.Offset.Parse(offset); err != nil {
+				opt.Offset = nil
 			}
 		}
 
@@ -2610,6 +3524,9 @@ func (s *Service) applyTask(ctx context.Context, userID platform.ID, t *stateTas
 		if err != nil {
 			return taskmodel.Task{}, applyFailErr("update", t.stateIdentity(), err)
 		}
+		if err := verifyTaskSchedule(t.parserTask, updatedTask); err != nil {
+			return taskmodel.Task{}, applyFailErr("update", t.stateIdentity(), err)
+		}
 		return *updatedTask, nil
 	default:
 		newTask, err := s.taskSVC.CreateTask(ctx, taskmodel.TaskCreate{
@@ -2623,10 +3540,52 @@ func (s *Service) applyTask(ctx context.Context, userID platform.ID, t *stateTas
 		if err != nil {
 			return taskmodel.Task{}, applyFailErr("create", t.stateIdentity(), err)
 		}
+		if err := verifyTaskSchedule(t.parserTask, newTask); err != nil {
+			// the task is not tracked for rollback until it is returned
+			if derr := s.taskSVC.DeleteTask(ctx, newTask.ID); derr != nil {
+				err = ierrors.Wrap(err, derr.Error())
+			}
+			return taskmodel.Task{}, applyFailErr("create", t.stateIdentity(), err)
+		}
 		return *newTask, nil
 	}
 }
 
+// verifyTaskSchedule returns an error if the schedule of the applied task
+// is not the one of the template, as its runs would shift.
+func verifyTaskSchedule(t *task, applied *taskmodel.Task) error {
+	want := taskSchedule{
+		cron:   t.cron,
+		every:  formatTaskDuration(t.everyStr()),
+		offset: formatTaskDuration(t.offsetStr()),
+	}
+	got, ok := taskFluxSchedule(applied.Flux)
+	if !ok || got != want {
+		return &errors2.Error{
+			Code: errors2.EConflict,
+			Msg: fmt.Sprintf("schedule of applied task is cron=%q every=%q offset=%q, template has cron=%q every=%q offset=%q",
+				got.cron, got.every, got.offset, want.cron, want.every, want.offset),
+		}
+	}
+	if applied.Status != string(t.Status()) {
+		return &errors2.Error{
+			Code: errors2.EConflict,
+			Msg:  fmt.Sprintf("status of applied task is %q, template has %q", applied.Status, t.Status()),
+		}
+	}
+	return nil
+}
+
+// formatTaskDuration formats a duration of the template as the options of
+// a task do.
+func formatTaskDuration(s string) string {
+	var d options.Duration
+	if s == "" || d.Parse(s) != nil {
+		return s
+	}
+	return d.String()
+}
+
 func (s *Service) rollbackTasks(ctx context.Context, tasks []*stateTask) error {
 	rollbackFn := func(t *stateTask) error {
 		if !IsNew(t.stateStatus) && t.existing == nil || isRestrictedTask(t.existing) {
@@ -2657,9 +3616,9 @@ func (s *Service) rollbackTasks(ctx context.Context, tasks []*stateTask) error {
 			if every := t.existing.Every; every != "" {
 				opt.Every.Parse(every)
 			}
-			if offset := t.existing.Offset; offset > 0 {
+			if s, ok := taskFluxSchedule(t.existing.Flux); ok && s.offset != "" {
 				var off options.Duration
-				if err := off.Parse(offset.String()); err == nil {
+				if err := off.Parse(s.offset); err == nil {
 					opt.Offset = &off
 				}
 			}
@@ -3068,7 +4027,7 @@ func (s *Service) templateFromApplyOpts(ctx context.Context, opt ApplyOpt) (*Tem
 		opt.Templates = append(opt.Templates, remotes...)
 	}
 
-	return Combine(opt.Templates, ValidWithoutResources())
+	return Combine(opt.Templates, ValidWithoutResources(), WithMergeStrategy(opt.MergeStrategy))
 }
 
 func (s *Service) getStackRemoteTemplates(ctx context.Context, stackID platform.ID) ([]*Template, error) {
@@ -3099,10 +4058,7 @@ func (s *Service) getStackRemoteTemplates(ctx context.Context, stackID platform.
 			encoding = EncodingYAML
 		}
 
-		readerFn := FromHTTPRequest(u.String(), s.client)
-		if u.Scheme == "file" {
-			readerFn = FromFile(u.Path)
-		}
+		readerFn := s.remoteReaderFn(u)
 
 		template, err := Parse(encoding, readerFn)
 		if err != nil {
@@ -3113,7 +4069,20 @@ func (s *Service) getStackRemoteTemplates(ctx context.Context, stackID platform.
 	return remotes, nil
 }
 
-func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.ID, state *stateCoordinator, sources []string) error {
+// remoteReaderFn returns the reader of a remote template of a stack. When the
+// service has trust anchors the template must be signed by one of them.
+func (s *Service) remoteReaderFn(u *url.URL) ReaderFn {
+	readerFn := FromHTTPRequest(u.String(), s.client)
+	if u.Scheme == "file" {
+		readerFn = FromFile(u.Path)
+	}
+	if len(s.trustAnchors) > 0 {
+		readerFn = verifiedReaderFn(readerFn, u.String(), "", "", s.client, s.trustAnchors)
+	}
+	return readerFn
+}
+
+func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.ID, state *stateCoordinator, sources []string, sourceVersions []StackSourceVersion) error {
 	stack, err := s.store.ReadStackByID(ctx, stackID)
 	if err != nil {
 		return err
@@ -3230,16 +4199,25 @@ func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.
 			Associations: stateLabelsToStackAssociations(v.labels()),
 		})
 	}
+	for metaName, id := range state.authorizations {
+		stackResources = append(stackResources, StackResource{
+			APIVersion: APIVersion,
+			ID:         id,
+			Kind:       KindAuthorization,
+			MetaName:   metaName,
+		})
+	}
 	ev := stack.LatestEvent()
 	ev.EventType = StackEventUpdate
 	ev.Resources = stackResources
 	ev.Sources = sources
+	ev.SourceVersions = sourceVersions
 	ev.UpdatedAt = s.timeGen.Now()
 	stack.Events = append(stack.Events, ev)
 	return s.store.UpdateStack(ctx, stack)
 }
 
-func (s *Service) updateStackAfterRollback(ctx context.Context, stackID platform.ID, state *stateCoordinator, sources []string) error {
+func (s *Service) updateStackAfterRollback(ctx context.Context, stackID platform.ID, state *stateCoordinator, sources []string, sourceVersions []StackSourceVersion) error {
 	stack, err := s.store.ReadStackByID(ctx, stackID)
 	if err != nil {
 		return err
@@ -3355,6 +4333,7 @@ func (s *Service) updateStackAfterRollback(ctx context.Context, stackID platform
 
 	latestEvent.EventType = StackEventUpdate
 	latestEvent.Sources = sources
+	latestEvent.SourceVersions = sourceVersions
 	latestEvent.UpdatedAt = s.timeGen.Now()
 	stack.Events = append(stack.Events, latestEvent)
 	return s.store.UpdateStack(ctx, stack)
@@ -3547,6 +4526,9 @@ func (m *doMutex) Do(fn func()) {
 
 type (
 	applier struct {
+		// kind is the kind of resource applied, it is used to report the
+		// time spent applying the kind and may be empty.
+		kind       Kind
 		creater    creater
 		rollbacker rollbacker
 	}
@@ -3562,18 +4544,48 @@ type (
 	}
 )
 
+func (a applier) withKind(k Kind) applier {
+	a.kind = k
+	return a
+}
+
 type rollbackCoordinator struct {
 	logger    *zap.Logger
 	rollbacks []rollbacker
 
 	sem chan struct{}
+
+	mu      sync.Mutex
+	elapsed map[Kind]time.Duration
 }
 
 func newRollbackCoordinator(logger *zap.Logger, reqLimit int) *rollbackCoordinator {
 	return &rollbackCoordinator{
-		logger: logger,
-		sem:    make(chan struct{}, reqLimit),
+		logger:  logger,
+		sem:     make(chan struct{}, reqLimit),
+		elapsed: make(map[Kind]time.Duration),
+	}
+}
+
+func (r *rollbackCoordinator) observeElapsed(kind Kind, d time.Duration) {
+	if kind == KindUnknown {
+		return
+	}
+	r.mu.Lock()
+	r.elapsed[kind] += d
+	r.mu.Unlock()
+}
+
+// kindElapsed returns the time spent applying each kind.
+func (r *rollbackCoordinator) kindElapsed() map[Kind]time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make(map[Kind]time.Duration, len(r.elapsed))
+	for k, d := range r.elapsed {
+		out[k] = d
 	}
+	return out
 }
 
 func (r *rollbackCoordinator) runTilEnd(ctx context.Context, orgID, userID platform.ID, appliers ...applier) error {
@@ -3585,12 +4597,20 @@ func (r *rollbackCoordinator) runTilEnd(ctx context.Context, orgID, userID platf
 		// that temp var gets recycled between iterations
 		app := appliers[i]
 		r.rollbacks = append(r.rollbacks, app.rollbacker)
+
+		// the entries of a kind are applied concurrently, the elapsed time of
+		// the kind spans from starting the first entry until the last finishes.
+		start, remaining := time.Now(), int64(app.creater.entries)
+
 		for idx := range make([]struct{}, app.creater.entries) {
 			r.sem <- struct{}{}
 			wg.Add(1)
 
 			go func(i int, resource string) {
 				defer func() {
+					if atomic.AddInt64(&remaining, -1) == 0 {
+						r.observeElapsed(app.kind, time.Since(start))
+					}
 					wg.Done()
 					<-r.sem
 				}()
@@ -3759,6 +4779,16 @@ func internalErr(err error) error {
 	return influxErr(errors2.EInternal, err)
 }
 
+func conflictErr(conflicts []resourceConflict) error {
+	descs := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		descs = append(descs, fmt.Sprintf("%s[%q] name=%q", c.kind, c.metaName, c.name))
+	}
+	return influxErr(errors2.EConflict, fmt.Sprintf(
+		"template resources conflict with existing resources: %s", strings.Join(descs, ", "),
+	))
+}
+
 func influxErr(code string, errArg interface{}, rest ...interface{}) *errors2.Error {
 	err := &errors2.Error{
 		Code: code,
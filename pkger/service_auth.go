@@ -87,6 +87,29 @@ func (s *authMW) UpdateStack(ctx context.Context, upd StackUpdate) (Stack, error
 	return s.next.UpdateStack(ctx, upd)
 }
 
+func (s *authMW) CheckStackUpdates(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackUpdateCheck, error) {
+	err := s.authAgent.OrgPermissions(ctx, identifiers.OrgID, influxdb.ReadAction)
+	if err != nil {
+		return StackUpdateCheck{}, err
+	}
+	return s.next.CheckStackUpdates(ctx, identifiers)
+}
+
+func (s *authMW) CheckStackDrift(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackDrift, error) {
+	err := s.authAgent.OrgPermissions(ctx, identifiers.OrgID, influxdb.ReadAction)
+	if err != nil {
+		return StackDrift{}, err
+	}
+	return s.next.CheckStackDrift(ctx, identifiers)
+}
+
+func (s *authMW) ReadStackJournal(ctx context.Context, stackID platform.ID) ([]StackJournalEntry, error) {
+	if _, err := s.ReadStack(ctx, stackID); err != nil {
+		return nil, err
+	}
+	return s.next.ReadStackJournal(ctx, stackID)
+}
+
 func (s *authMW) Export(ctx context.Context, opts ...ExportOptFn) (*Template, error) {
 	opt, err := exportOptFromOptFns(opts)
 	if err != nil {
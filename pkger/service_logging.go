@@ -141,6 +141,58 @@ func (s *loggingMW) UpdateStack(ctx context.Context, upd StackUpdate) (_ Stack,
 	return s.next.UpdateStack(ctx, upd)
 }
 
+func (s *loggingMW) CheckStackUpdates(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (_ StackUpdateCheck, err error) {
+	defer func(start time.Time) {
+		if err == nil {
+			return
+		}
+
+		s.logger.Error(
+			"failed to check stack updates",
+			zap.Error(err),
+			zap.Stringer("orgID", identifiers.OrgID),
+			zap.Stringer("userID", identifiers.UserID),
+			zap.Stringer("stackID", identifiers.StackID),
+			zap.Duration("took", time.Since(start)),
+		)
+	}(time.Now())
+	return s.next.CheckStackUpdates(ctx, identifiers)
+}
+
+func (s *loggingMW) CheckStackDrift(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (_ StackDrift, err error) {
+	defer func(start time.Time) {
+		if err == nil {
+			return
+		}
+
+		s.logger.Error(
+			"failed to check stack drift",
+			zap.Error(err),
+			zap.Stringer("orgID", identifiers.OrgID),
+			zap.Stringer("userID", identifiers.UserID),
+			zap.Stringer("stackID", identifiers.StackID),
+			zap.Duration("took", time.Since(start)),
+		)
+	}(time.Now())
+	return s.next.CheckStackDrift(ctx, identifiers)
+}
+
+func (s *loggingMW) ReadStackJournal(ctx context.Context, stackID platform.ID) (_ []StackJournalEntry, err error) {
+	defer func(start time.Time) {
+		if err == nil {
+			return
+		}
+
+		s.logger.Error(
+			"failed to read stack journal",
+			zap.Error(err),
+			zap.Stringer("stackID", stackID),
+			zap.Duration("took", time.Since(start)),
+		)
+	}(time.Now())
+	return s.next.ReadStackJournal(ctx, stackID)
+}
+
 func (s *loggingMW) Export(ctx context.Context, opts ...ExportOptFn) (template *Template, err error) {
 	defer func(start time.Time) {
 		dur := zap.Duration("took", time.Since(start))
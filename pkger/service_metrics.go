@@ -77,6 +77,24 @@ func (s *mwMetrics) UpdateStack(ctx context.Context, upd StackUpdate) (Stack, er
 	return stack, rec(err)
 }
 
+func (s *mwMetrics) CheckStackUpdates(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackUpdateCheck, error) {
+	rec := s.rec.Record("check_stack_updates")
+	check, err := s.next.CheckStackUpdates(ctx, identifiers)
+	return check, rec(err)
+}
+
+func (s *mwMetrics) CheckStackDrift(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackDrift, error) {
+	rec := s.rec.Record("check_stack_drift")
+	drift, err := s.next.CheckStackDrift(ctx, identifiers)
+	return drift, rec(err)
+}
+
+func (s *mwMetrics) ReadStackJournal(ctx context.Context, stackID platform.ID) ([]StackJournalEntry, error) {
+	rec := s.rec.Record("read_stack_journal")
+	entries, err := s.next.ReadStackJournal(ctx, stackID)
+	return entries, rec(err)
+}
+
 func (s *mwMetrics) Export(ctx context.Context, opts ...ExportOptFn) (*Template, error) {
 	rec := s.rec.Record("export")
 	opt, err := exportOptFromOptFns(opts)
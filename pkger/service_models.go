@@ -1,6 +1,8 @@
 package pkger
 
 import (
+	"encoding/json"
+	"fmt"
 	"reflect"
 	"sort"
 
@@ -23,6 +25,12 @@ type stateCoordinator struct {
 
 	labelMappings         []stateLabelMapping
 	labelMappingsToRemove []stateLabelMappingForRemoval
+
+	// authorizations are the IDs of the authorizations an apply created, by
+	// the metadata names of their template authorizations.
+	authorizations map[string]platform.ID
+
+	actions resourceActions
 }
 
 func newStateCoordinator(template *Template, acts resourceActions) *stateCoordinator {
@@ -36,6 +44,7 @@ func newStateCoordinator(template *Template, acts resourceActions) *stateCoordin
 		mTasks:      make(map[string]*stateTask),
 		mTelegrafs:  make(map[string]*stateTelegraf),
 		mVariables:  make(map[string]*stateVariable),
+		actions:     acts,
 	}
 
 	// labels are done first to validate dependencies are accounted for.
@@ -438,6 +447,10 @@ func (s *stateCoordinator) addStackState(stack Stack) {
 
 func (s *stateCoordinator) reconcileStackResources(stackResources []StackResource) {
 	for _, r := range stackResources {
+		if s.actions.detachResource(r.Kind, r.MetaName) {
+			// detached resources are left untouched and forgotten by the stack
+			continue
+		}
 		if !s.Contains(r.Kind, r.MetaName) {
 			s.addObjectForRemoval(r.Kind, r.MetaName, r.ID)
 			continue
@@ -456,7 +469,7 @@ func (s *stateCoordinator) reconcileLabelMappings(stackResources []StackResource
 
 	for _, r := range stackResources {
 		labels := s.labelAssociations(r.Kind, r.MetaName)
-		if len(r.Associations) == 0 {
+		if len(r.Associations) == 0 || s.actions.detachResource(r.Kind, r.MetaName) {
 			continue
 		}
 
@@ -465,7 +478,7 @@ func (s *stateCoordinator) reconcileLabelMappings(stackResources []StackResource
 		// if associations are not in state and in stack => add them for removal
 		mStackAss := make(map[StackResourceAssociation]struct{})
 		for _, ass := range r.Associations {
-			if ass.Kind.is(KindLabel) {
+			if ass.Kind.is(KindLabel) && !s.actions.detachResource(KindLabel, ass.MetaName) {
 				mStackAss[ass] = struct{}{}
 			}
 		}
@@ -494,7 +507,7 @@ func (s *stateCoordinator) reconcileLabelMappings(stackResources []StackResource
 
 func (s *stateCoordinator) reconcileNotificationDependencies(stackResources []StackResource) {
 	for _, r := range stackResources {
-		if r.Kind.is(KindNotificationRule) {
+		if r.Kind.is(KindNotificationRule) && !s.actions.detachResource(r.Kind, r.MetaName) {
 			for _, ass := range r.Associations {
 				if ass.Kind.is(KindNotificationEndpoint) {
 					s.mRules[r.MetaName].associatedEndpoint = s.mEndpoints[ass.MetaName]
@@ -702,6 +715,90 @@ func (s *stateCoordinator) getObjectIDSetter(k Kind, metaName string) (func(plat
 	}
 }
 
+// resourceConflict is a template resource that is not part of the stack
+// and shares its name with an existing resource.
+type resourceConflict struct {
+	kind     Kind
+	metaName string
+	name     string
+}
+
+// resolveConflicts applies the conflict policies to the resources that
+// collide with existing resources. Resources with the skip policy are dropped
+// from the state, leaving the existing resource untouched. The conflicts with
+// the fail policy are returned.
+func (s *stateCoordinator) resolveConflicts() []resourceConflict {
+	if len(s.actions.conflicts) == 0 {
+		return nil
+	}
+
+	var failed []resourceConflict
+	// skip reports whether the resource is dropped from the state. A resource
+	// conflicts when it was matched to an existing resource by name rather
+	// than by the ID tracked in the stack.
+	skip := func(k Kind, status StateStatus, id platform.ID, metaName, name string) bool {
+		if !IsExisting(status) || id != 0 {
+			return false
+		}
+		switch s.actions.conflicts[k] {
+		case ConflictPolicyFail:
+			failed = append(failed, resourceConflict{kind: k, metaName: metaName, name: name})
+		case ConflictPolicySkip:
+			return true
+		}
+		return false
+	}
+
+	for k, b := range s.mBuckets {
+		if skip(KindBucket, b.stateStatus, b.id, k, b.parserBkt.Name()) {
+			delete(s.mBuckets, k)
+		}
+	}
+	for k, c := range s.mChecks {
+		if skip(KindCheck, c.stateStatus, c.id, k, c.parserCheck.Name()) {
+			delete(s.mChecks, k)
+		}
+	}
+	for k, d := range s.mDashboards {
+		if skip(KindDashboard, d.stateStatus, d.id, k, d.parserDash.Name()) {
+			delete(s.mDashboards, k)
+		}
+	}
+	for k, e := range s.mEndpoints {
+		if skip(KindNotificationEndpoint, e.stateStatus, e.id, k, e.parserEndpoint.Name()) {
+			delete(s.mEndpoints, k)
+		}
+	}
+	for k, l := range s.mLabels {
+		if skip(KindLabel, l.stateStatus, l.id, k, l.parserLabel.Name()) {
+			delete(s.mLabels, k)
+		}
+	}
+	for k, t := range s.mTasks {
+		if skip(KindTask, t.stateStatus, t.id, k, t.parserTask.Name()) {
+			delete(s.mTasks, k)
+		}
+	}
+	for k, t := range s.mTelegrafs {
+		if skip(KindTelegraf, t.stateStatus, t.id, k, t.parserTelegraf.Name()) {
+			delete(s.mTelegrafs, k)
+		}
+	}
+	for k, v := range s.mVariables {
+		if skip(KindVariable, v.stateStatus, v.id, k, v.parserVar.Name()) {
+			delete(s.mVariables, k)
+		}
+	}
+
+	sort.Slice(failed, func(i, j int) bool {
+		if failed[i].kind != failed[j].kind {
+			return failed[i].kind < failed[j].kind
+		}
+		return failed[i].metaName < failed[j].metaName
+	})
+	return failed
+}
+
 type stateIdentity struct {
 	id           platform.ID
 	name         string
@@ -907,9 +1004,10 @@ func (d *stateDashboard) diffDashboard() DiffDashboard {
 			MetaName:    d.parserDash.MetaName(),
 		},
 		New: DiffDashboardValues{
-			Name:   d.parserDash.Name(),
-			Desc:   d.parserDash.Description,
-			Charts: make([]DiffChart, 0, len(d.parserDash.Charts)),
+			Name:      d.parserDash.Name(),
+			Desc:      d.parserDash.Description,
+			Charts:    make([]DiffChart, 0, len(d.parserDash.Charts)),
+			TimeRange: d.parserDash.TimeRange,
 		},
 	}
 
@@ -918,6 +1016,7 @@ func (d *stateDashboard) diffDashboard() DiffDashboard {
 			Properties: c.properties(),
 			Height:     c.Height,
 			Width:      c.Width,
+			TimeRange:  c.TimeRange,
 		})
 	}
 
@@ -926,9 +1025,10 @@ func (d *stateDashboard) diffDashboard() DiffDashboard {
 	}
 
 	oldDiff := DiffDashboardValues{
-		Name:   d.existing.Name,
-		Desc:   d.existing.Description,
-		Charts: make([]DiffChart, 0, len(d.existing.Cells)),
+		Name:      d.existing.Name,
+		Desc:      d.existing.Description,
+		Charts:    make([]DiffChart, 0, len(d.existing.Cells)),
+		TimeRange: d.existing.TimeRange,
 	}
 
 	for _, c := range d.existing.Cells {
@@ -943,11 +1043,23 @@ func (d *stateDashboard) diffDashboard() DiffDashboard {
 			YPosition:  int(c.Y),
 			Height:     int(c.H),
 			Width:      int(c.W),
+			TimeRange:  c.TimeRange,
 		})
 	}
 
 	diff.Old = &oldDiff
 
+	// The new charts of the diff carry no positions, so the changes are
+	// compared against a copy holding them.
+	newDiff := diff.New
+	newDiff.Charts = make([]DiffChart, 0, len(d.parserDash.Charts))
+	for i, c := range d.parserDash.Charts {
+		chart := diff.New.Charts[i]
+		chart.XPosition, chart.YPosition = c.XPos, c.YPos
+		newDiff.Charts = append(newDiff.Charts, chart)
+	}
+	diff.Changes = diffFields(oldDiff, newDiff)
+
 	return diff
 }
 
@@ -1369,8 +1481,10 @@ func (t *stateTask) diffTask() DiffTask {
 			Name:        t.parserTask.Name(),
 			Cron:        t.parserTask.cron,
 			Description: t.parserTask.description,
-			Every:       durToStr(t.parserTask.every),
-			Offset:      durToStr(t.parserTask.offset),
+			Every:       t.parserTask.everyStr(),
+			Offset:      t.parserTask.offsetStr(),
+			Concurrency: t.parserTask.Concurrency(),
+			Retry:       t.parserTask.Retry(),
 			Query:       t.parserTask.query.DashboardQuery(),
 			Status:      t.parserTask.Status(),
 		},
@@ -1380,16 +1494,28 @@ func (t *stateTask) diffTask() DiffTask {
 		return diff
 	}
 
+	concurrency, retry := taskFluxOptions(t.existing.Flux)
 	diff.Old = &DiffTaskValues{
 		Name:        t.existing.Name,
 		Cron:        t.existing.Cron,
 		Description: t.existing.Description,
 		Every:       t.existing.Every,
 		Offset:      t.existing.Offset.String(),
+		Concurrency: concurrency,
+		Retry:       retry,
 		Query:       t.existing.Flux,
 		Status:      influxdb.Status(t.existing.Status),
 	}
 
+	// The old schedule is compared as written in the task's options, like
+	// the template's, so an unchanged schedule is not reported as a change.
+	oldDiff := *diff.Old
+	oldDiff.Offset = durToStr(t.existing.Offset)
+	if s, ok := taskFluxSchedule(t.existing.Flux); ok {
+		oldDiff.Cron, oldDiff.Every, oldDiff.Offset = s.cron, s.every, s.offset
+	}
+	diff.Changes = diffFields(oldDiff, diff.New)
+
 	return diff
 }
 
@@ -1566,9 +1692,94 @@ func IsRemoval(status StateStatus) bool {
 	return status == StateStatusRemove
 }
 
+// diffFields compares the JSON representations of the old and new values of a
+// resource and returns every leaf field that differs between them.
+func diffFields(old, new interface{}) []DiffField {
+	return diffJSONFields("", jsonValue(old), jsonValue(new))
+}
+
+func diffJSONFields(path string, old, new interface{}) []DiffField {
+	switch o := old.(type) {
+	case map[string]interface{}:
+		n, ok := new.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(o)+len(n))
+		for k := range o {
+			keys = append(keys, k)
+		}
+		for k := range n {
+			if _, ok := o[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		var out []DiffField
+		for _, k := range keys {
+			fieldPath := k
+			if path != "" {
+				fieldPath = path + "." + k
+			}
+			out = append(out, diffJSONFields(fieldPath, o[k], n[k])...)
+		}
+		return out
+	case []interface{}:
+		n, ok := new.([]interface{})
+		if !ok {
+			break
+		}
+
+		var out []DiffField
+		for i := 0; i < len(o) || i < len(n); i++ {
+			var oldVal, newVal interface{}
+			if i < len(o) {
+				oldVal = o[i]
+			}
+			if i < len(n) {
+				newVal = n[i]
+			}
+			out = append(out, diffJSONFields(fmt.Sprintf("%s[%d]", path, i), oldVal, newVal)...)
+		}
+		return out
+	}
+
+	if reflect.DeepEqual(old, new) {
+		return nil
+	}
+	return []DiffField{{Path: path, Old: old, New: new}}
+}
+
+// jsonValue converts v into its generic JSON representation.
+func jsonValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
 type resourceActions struct {
-	skipKinds     map[Kind]bool
-	skipResources map[ActionSkipResource]bool
+	skipKinds       map[Kind]bool
+	skipResources   map[ActionSkipResource]bool
+	applyKinds      map[Kind]bool
+	detachResources map[ActionDetachResource]bool
+	removeResources map[ActionRemoveResource]bool
+	conflicts       map[Kind]ConflictPolicy
+}
+
+// matchByName identifies whether template resources of the kind are matched
+// to existing resources by name. Kinds matched by ID only are matched by
+// name once a conflict policy is set for them.
+func (r resourceActions) matchByName(k Kind) bool {
+	_, ok := r.conflicts[k]
+	return ok
 }
 
 func (r resourceActions) skipResource(k Kind, metaName string) bool {
@@ -1576,5 +1787,46 @@ func (r resourceActions) skipResource(k Kind, metaName string) bool {
 		Kind:     k,
 		MetaName: metaName,
 	}
-	return r.skipResources[key] || r.skipKinds[k]
+	if len(r.applyKinds) > 0 && !r.applyKinds[k] {
+		return true
+	}
+	// resources being detached or removed are no longer applied from the template
+	return r.skipResources[key] || r.skipKinds[k] ||
+		r.detachResource(k, metaName) || r.removeResource(k, metaName)
+}
+
+func (r resourceActions) detachResource(k Kind, metaName string) bool {
+	return r.detachResources[ActionDetachResource{
+		Kind:     normalizeActionKind(k),
+		MetaName: metaName,
+	}]
+}
+
+func (r resourceActions) removeResource(k Kind, metaName string) bool {
+	return r.removeResources[ActionRemoveResource{
+		Kind:     normalizeActionKind(k),
+		MetaName: metaName,
+	}]
+}
+
+func (r resourceActions) filterAuthorizations(auths []*authorization) []*authorization {
+	var out []*authorization
+	for _, a := range auths {
+		if r.skipResource(KindAuthorization, a.MetaName()) {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+func (r resourceActions) filterHooks(hooks []*hook) []*hook {
+	var out []*hook
+	for _, h := range hooks {
+		if r.skipResource(KindApplyHook, h.MetaName()) {
+			continue
+		}
+		out = append(out, h)
+	}
+	return out
 }
@@ -3,13 +3,24 @@ package pkger
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -24,6 +35,7 @@ import (
 	"github.com/influxdata/influxdb/v2/task/taskmodel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -82,6 +94,12 @@ func TestService(t *testing.T) {
 		if opt.nameGen != nil {
 			applyOpts = append(applyOpts, withNameGen(opt.nameGen))
 		}
+		if opt.client != nil {
+			applyOpts = append(applyOpts, WithHTTPClient(opt.client))
+		}
+		if opt.authSVC != nil {
+			applyOpts = append(applyOpts, WithAuthorizationSVC(opt.authSVC))
+		}
 
 		return NewService(applyOpts...)
 	}
@@ -129,6 +147,18 @@ func TestService(t *testing.T) {
 					})
 				}
 
+				filterKind := KindBucket
+				if len(fields.kinds) > 0 && fields.kinds[0] == KindBucket {
+					filterKind = KindVariable
+				}
+				tests = append(tests, struct {
+					name      string
+					applyOpts []ApplyOptFn
+				}{
+					name:      "filter kind " + filterKind.String(),
+					applyOpts: []ApplyOptFn{ApplyWithKindFilter(filterKind)},
+				})
+
 				for _, tt := range tests {
 					fn := func(t *testing.T) {
 						t.Helper()
@@ -150,6 +180,115 @@ func TestService(t *testing.T) {
 			})
 		}
 
+		t.Run("kind filter applies only the filtered kinds", func(t *testing.T) {
+			testfileRunner(t, "testdata/bucket_associates_label.yml", func(t *testing.T, template *Template) {
+				metaNames := func(impact ImpactSummary) (buckets, labels []string) {
+					for _, b := range impact.Diff.Buckets {
+						buckets = append(buckets, b.MetaName)
+					}
+					for _, l := range impact.Diff.Labels {
+						labels = append(labels, l.MetaName)
+					}
+					return buckets, labels
+				}
+
+				tests := []struct {
+					name            string
+					kinds           []Kind
+					expectedBuckets []string
+					expectedLabels  []string
+				}{
+					{
+						name:            "buckets",
+						kinds:           []Kind{KindBucket},
+						expectedBuckets: []string{"rucket-1", "rucket-2", "rucket-3"},
+					},
+					{
+						name:           "labels",
+						kinds:          []Kind{KindLabel},
+						expectedLabels: []string{"label-1", "label-2"},
+					},
+					{
+						name:            "buckets and labels",
+						kinds:           []Kind{KindBucket, KindLabel},
+						expectedBuckets: []string{"rucket-1", "rucket-2", "rucket-3"},
+						expectedLabels:  []string{"label-1", "label-2"},
+					},
+					{
+						name:  "kind not in template",
+						kinds: []Kind{KindTask},
+					},
+				}
+
+				for _, tt := range tests {
+					fn := func(t *testing.T) {
+						svc := newTestService()
+
+						impact, err := svc.DryRun(
+							context.TODO(),
+							platform.ID(100),
+							0,
+							ApplyWithTemplate(template),
+							ApplyWithKindFilter(tt.kinds...),
+						)
+						require.NoError(t, err)
+
+						buckets, labels := metaNames(impact)
+						assert.ElementsMatch(t, tt.expectedBuckets, buckets)
+						assert.ElementsMatch(t, tt.expectedLabels, labels)
+						assert.Empty(t, impact.Diff.Tasks)
+						assert.Empty(t, impact.Diff.Dashboards)
+						assert.Empty(t, impact.Diff.Variables)
+					}
+					t.Run(tt.name, fn)
+				}
+			})
+		})
+
+		t.Run("stack resource detach and remove actions", func(t *testing.T) {
+			testfileRunner(t, "testdata/bucket.yml", func(t *testing.T, template *Template) {
+				stackID := platform.ID(3)
+				store := &fakeStore{
+					readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+						return Stack{
+							ID: stackID,
+							Events: []StackEvent{{
+								Resources: []StackResource{
+									{APIVersion: APIVersion, ID: 1, Kind: KindBucket, MetaName: "rucket-11"},
+									{APIVersion: APIVersion, ID: 2, Kind: KindBucket, MetaName: "rucket-22"},
+									{APIVersion: APIVersion, ID: 3, Kind: KindBucket, MetaName: "dropped"},
+									{APIVersion: APIVersion, ID: 4, Kind: KindBucket, MetaName: "handed-off"},
+								},
+							}},
+						}, nil
+					},
+				}
+				fakeBktSVC := mock.NewBucketService()
+				fakeBktSVC.FindBucketByIDFn = func(_ context.Context, id platform.ID) (*influxdb.Bucket, error) {
+					return &influxdb.Bucket{ID: id, OrgID: 100, Name: id.String()}, nil
+				}
+				svc := newTestService(WithStore(store), WithBucketSVC(fakeBktSVC))
+
+				impact, err := svc.DryRun(context.TODO(), platform.ID(100), 0,
+					ApplyWithTemplate(template),
+					ApplyWithStackID(stackID),
+					ApplyWithResourceDetach(ActionDetachResource{Kind: KindBucket, MetaName: "rucket-11"}),
+					ApplyWithResourceDetach(ActionDetachResource{Kind: KindBucket, MetaName: "handed-off"}),
+					ApplyWithResourceRemove(ActionRemoveResource{Kind: KindBucket, MetaName: "rucket-22"}),
+				)
+				require.NoError(t, err)
+
+				statuses := make(map[string]StateStatus)
+				for _, b := range impact.Diff.Buckets {
+					statuses[b.MetaName] = b.StateStatus
+				}
+				assert.Equal(t, map[string]StateStatus{
+					"rucket-22": StateStatusRemove,
+					"dropped":   StateStatusRemove,
+				}, statuses)
+			})
+		})
+
 		t.Run("buckets", func(t *testing.T) {
 			t.Run("single bucket updated", func(t *testing.T) {
 				testfileRunner(t, "testdata/bucket.yml", func(t *testing.T, template *Template) {
@@ -225,6 +364,64 @@ func TestService(t *testing.T) {
 				})
 			})
 
+			t.Run("with conflict policies", func(t *testing.T) {
+				newConflictSVC := func() *Service {
+					fakeBktSVC := mock.NewBucketService()
+					fakeBktSVC.FindBucketByNameFn = func(_ context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error) {
+						if name != "rucket-11" {
+							return nil, errors.New("not found")
+						}
+						return &influxdb.Bucket{ID: platform.ID(1), OrgID: orgID, Name: name}, nil
+					}
+					return newTestService(WithBucketSVC(fakeBktSVC))
+				}
+
+				t.Run("fail", func(t *testing.T) {
+					testfileRunner(t, "testdata/bucket.yml", func(t *testing.T, template *Template) {
+						_, err := newConflictSVC().DryRun(context.TODO(), platform.ID(100), 0,
+							ApplyWithTemplate(template),
+							ApplyWithConflictPolicy(KindBucket, ConflictPolicyFail),
+						)
+						require.Error(t, err)
+						assert.Equal(t, errors2.EConflict, errors2.ErrorCode(err))
+						assert.Contains(t, err.Error(), "rucket-11")
+					})
+				})
+
+				t.Run("skip", func(t *testing.T) {
+					testfileRunner(t, "testdata/bucket.yml", func(t *testing.T, template *Template) {
+						impact, err := newConflictSVC().DryRun(context.TODO(), platform.ID(100), 0,
+							ApplyWithTemplate(template),
+							ApplyWithConflictPolicy(KindBucket, ConflictPolicySkip),
+						)
+						require.NoError(t, err)
+
+						require.Len(t, impact.Diff.Buckets, 1)
+						assert.Equal(t, "rucket-22", impact.Diff.Buckets[0].MetaName)
+						assert.Equal(t, StateStatusNew, impact.Diff.Buckets[0].StateStatus)
+					})
+				})
+
+				t.Run("overwrite", func(t *testing.T) {
+					testfileRunner(t, "testdata/bucket.yml", func(t *testing.T, template *Template) {
+						impact, err := newConflictSVC().DryRun(context.TODO(), platform.ID(100), 0,
+							ApplyWithTemplate(template),
+							ApplyWithConflictPolicy(KindBucket, ConflictPolicyOverwrite),
+						)
+						require.NoError(t, err)
+
+						statuses := make(map[string]StateStatus)
+						for _, b := range impact.Diff.Buckets {
+							statuses[b.MetaName] = b.StateStatus
+						}
+						assert.Equal(t, map[string]StateStatus{
+							"rucket-11": StateStatusExists,
+							"rucket-22": StateStatusNew,
+						}, statuses)
+					})
+				})
+			})
+
 			t.Run("with actions applied", func(t *testing.T) {
 				testDryRunActions(t, dryRunTestFields{
 					path:  "testdata/bucket.yml",
@@ -771,6 +968,351 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("Apply", func(t *testing.T) {
+		t.Run("posts apply events to the webhook", func(t *testing.T) {
+			events := make(chan ApplyEvent, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				mac := hmac.New(sha256.New, []byte("secret"))
+				mac.Write(b)
+				assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), r.Header.Get(WebhookSignatureHeader))
+
+				var event ApplyEvent
+				assert.NoError(t, json.Unmarshal(b, &event))
+				events <- event
+			}))
+			defer srv.Close()
+
+			testfileRunner(t, "testdata/bucket.yml", func(t *testing.T, template *Template) {
+				fakeBktSVC := mock.NewBucketService()
+				fakeBktSVC.CreateBucketFn = func(_ context.Context, b *influxdb.Bucket) error {
+					b.ID = platform.ID(b.RetentionPeriod)
+					return nil
+				}
+				fakeBktSVC.FindBucketByNameFn = func(_ context.Context, id platform.ID, s string) (*influxdb.Bucket, error) {
+					return nil, errors.New("not found")
+				}
+
+				svc := MWWebhook(zap.NewNop(), srv.URL, "secret", srv.Client())(newTestService(WithBucketSVC(fakeBktSVC)))
+
+				orgID, userID := platform.ID(9000), platform.ID(1)
+				_, err := svc.Apply(context.TODO(), orgID, userID, ApplyWithTemplate(template))
+				require.NoError(t, err)
+
+				select {
+				case event := <-events:
+					assert.Equal(t, ApplyEventTypeApply, event.Type)
+					assert.Equal(t, ApplyEventStatusSuccess, event.Status)
+					assert.Equal(t, SafeID(orgID), event.OrgID)
+					assert.Equal(t, SafeID(userID), event.UserID)
+					assert.NotZero(t, event.StackID)
+					require.Len(t, event.Resources, 2)
+					assert.Equal(t, KindBucket, event.Resources[0].Kind)
+				case <-time.After(5 * time.Second):
+					t.Fatal("timed out waiting for apply event")
+				}
+			})
+		})
+
+		t.Run("records applies in the stack journal", func(t *testing.T) {
+			testfileRunner(t, "testdata/bucket.yml", func(t *testing.T, template *Template) {
+				fakeBktSVC := mock.NewBucketService()
+				fakeBktSVC.CreateBucketFn = func(_ context.Context, b *influxdb.Bucket) error {
+					b.ID = platform.ID(b.RetentionPeriod)
+					return nil
+				}
+				fakeBktSVC.FindBucketByNameFn = func(_ context.Context, id platform.ID, s string) (*influxdb.Bucket, error) {
+					return nil, errors.New("not found")
+				}
+
+				var entries []StackJournalEntry
+				store := &fakeStore{
+					createFn: func(ctx context.Context, stack Stack) error { return nil },
+					readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+						return Stack{ID: id}, nil
+					},
+					updateFn: func(ctx context.Context, stack Stack) error { return nil },
+					addJournalFn: func(ctx context.Context, entry StackJournalEntry) error {
+						entries = append(entries, entry)
+						return nil
+					},
+				}
+				svc := newTestService(WithBucketSVC(fakeBktSVC), WithStore(store))
+
+				orgID, userID := platform.ID(9000), platform.ID(1)
+				impact, err := svc.Apply(context.TODO(), orgID, userID, ApplyWithTemplate(template))
+				require.NoError(t, err)
+
+				require.Len(t, entries, 1)
+				assert.Equal(t, impact.StackID, entries[0].StackID)
+				assert.Equal(t, StackJournalActionApply, entries[0].Action)
+				assert.Equal(t, userID, entries[0].UserID)
+				assert.Empty(t, entries[0].Error)
+				assert.Equal(t, StackJournalImpact{Created: 2}, entries[0].Impact)
+
+				_, err = svc.DryRun(context.TODO(), orgID, userID, ApplyWithTemplate(template))
+				require.NoError(t, err)
+				assert.Len(t, entries, 1, "dry runs without a stack are not recorded")
+			})
+		})
+
+		t.Run("records uninstalls once the stack is persisted", func(t *testing.T) {
+			orgID, userID, stackID := platform.ID(9000), platform.ID(1), platform.ID(3)
+
+			var (
+				ops       []string
+				entries   []StackJournalEntry
+				updateErr error
+			)
+			store := &fakeStore{
+				readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+					return Stack{ID: id, OrgID: orgID}, nil
+				},
+				updateFn: func(ctx context.Context, stack Stack) error {
+					ops = append(ops, "update")
+					return updateErr
+				},
+				addJournalFn: func(ctx context.Context, entry StackJournalEntry) error {
+					ops = append(ops, "journal")
+					entries = append(entries, entry)
+					return nil
+				},
+			}
+			svc := newTestService(WithStore(store))
+
+			identifiers := struct{ OrgID, UserID, StackID platform.ID }{orgID, userID, stackID}
+			_, err := svc.UninstallStack(context.TODO(), identifiers)
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"update", "journal"}, ops)
+			require.Len(t, entries, 1)
+			assert.Equal(t, StackJournalActionUninstall, entries[0].Action)
+			assert.Empty(t, entries[0].Error)
+
+			updateErr = errors.New("update failed")
+			_, err = svc.UninstallStack(context.TODO(), identifiers)
+			require.NoError(t, err)
+
+			require.Len(t, entries, 2)
+			assert.Equal(t, "update failed", entries[1].Error, "the uninstall is recorded as failed when the stack is not persisted")
+		})
+
+		t.Run("hooks", func(t *testing.T) {
+			newHookTemplate := func(t *testing.T, preURL, postURL string) *Template {
+				t.Helper()
+
+				template, err := Parse(EncodingYAML, FromString(fmt.Sprintf(`apiVersion: influxdata.com/v2alpha1
+kind: ApplyHook
+metadata:
+  name: hook-post
+spec:
+  phase: postApply
+  type: http
+  url: %s
+---
+apiVersion: influxdata.com/v2alpha1
+kind: ApplyHook
+metadata:
+  name: hook-pre
+spec:
+  phase: preApply
+  type: http
+  method: PUT
+  url: %s
+  body: seed
+`, postURL, preURL)))
+				require.NoError(t, err)
+				return template
+			}
+
+			t.Run("runs pre and post apply hooks and records results", func(t *testing.T) {
+				var calls []string
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls = append(calls, r.Method+" "+r.URL.Path)
+					w.Write([]byte("ok"))
+				}))
+				defer srv.Close()
+
+				svc := newTestService(WithHTTPClient(srv.Client()))
+
+				template := newHookTemplate(t, srv.URL+"/pre", srv.URL+"/post")
+				impact, err := svc.Apply(context.TODO(), platform.ID(9000), 0, ApplyWithTemplate(template))
+				require.NoError(t, err)
+
+				assert.Equal(t, []string{"PUT /pre", "POST /post"}, calls)
+				expected := []HookResult{
+					{MetaName: "hook-pre", Phase: HookPhasePreApply, Type: hookTypeHTTP, Status: HookStatusSuccess, Output: "ok"},
+					{MetaName: "hook-post", Phase: HookPhasePostApply, Type: hookTypeHTTP, Status: HookStatusSuccess, Output: "ok"},
+				}
+				assert.Equal(t, expected, impact.Hooks)
+			})
+
+			t.Run("failed pre apply hook aborts the apply", func(t *testing.T) {
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path == "/pre" {
+						w.WriteHeader(http.StatusInternalServerError)
+						return
+					}
+					t.Errorf("unexpected hook call: %s", r.URL.Path)
+				}))
+				defer srv.Close()
+
+				svc := newTestService(WithHTTPClient(srv.Client()))
+
+				template := newHookTemplate(t, srv.URL+"/pre", srv.URL+"/post")
+				_, err := svc.Apply(context.TODO(), platform.ID(9000), 0, ApplyWithTemplate(template))
+				require.Error(t, err)
+				assert.Equal(t, errors2.EUnprocessableEntity, errors2.ErrorCode(err))
+			})
+
+			t.Run("dry run reports hooks as pending", func(t *testing.T) {
+				svc := newTestService()
+
+				template := newHookTemplate(t, "http://localhost/pre", "http://localhost/post")
+				impact, err := svc.DryRun(context.TODO(), platform.ID(9000), 0, ApplyWithTemplate(template))
+				require.NoError(t, err)
+
+				require.Len(t, impact.Hooks, 2)
+				for _, h := range impact.Hooks {
+					assert.Equal(t, HookStatusPending, h.Status)
+				}
+			})
+		})
+
+		t.Run("authorizations", func(t *testing.T) {
+			newResourceSVCs := func() []ServiceSetterFn {
+				fakeBktSVC := mock.NewBucketService()
+				fakeBktSVC.CreateBucketFn = func(_ context.Context, b *influxdb.Bucket) error {
+					b.ID = 1
+					return nil
+				}
+				fakeBktSVC.FindBucketByNameFn = func(_ context.Context, id platform.ID, s string) (*influxdb.Bucket, error) {
+					// forces the bucket to be created a new
+					return nil, errors.New("an error")
+				}
+				fakeDashSVC := mock.NewDashboardService()
+				fakeDashSVC.CreateDashboardF = func(_ context.Context, d *influxdb.Dashboard) error {
+					d.ID = 2
+					return nil
+				}
+				return []ServiceSetterFn{WithBucketSVC(fakeBktSVC), WithDashboardSVC(fakeDashSVC)}
+			}
+
+			t.Run("creates authorizations scoped to the applied resources and rotates them", func(t *testing.T) {
+				testfileRunner(t, "testdata/authorizations.yml", func(t *testing.T, template *Template) {
+					orgID, userID, stackID := platform.ID(9000), platform.ID(1), platform.ID(3)
+
+					fakeAuthSVC := mock.NewAuthorizationService()
+					var created []*influxdb.Authorization
+					fakeAuthSVC.CreateAuthorizationFn = func(_ context.Context, a *influxdb.Authorization) error {
+						a.ID, a.Token = 100, "token"
+						created = append(created, a)
+						return nil
+					}
+					var deleted []platform.ID
+					fakeAuthSVC.DeleteAuthorizationFn = func(_ context.Context, id platform.ID) error {
+						deleted = append(deleted, id)
+						return nil
+					}
+
+					var updated Stack
+					fakeStore := &fakeStore{
+						readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+							return Stack{
+								ID:    id,
+								OrgID: orgID,
+								Events: []StackEvent{{
+									Resources: []StackResource{
+										{APIVersion: APIVersion, ID: 99, Kind: KindAuthorization, MetaName: "auth-1"},
+									},
+								}},
+							}, nil
+						},
+						updateFn: func(ctx context.Context, stack Stack) error {
+							updated = stack
+							return nil
+						},
+					}
+
+					svc := newTestService(append(newResourceSVCs(),
+						WithAuthorizationSVC(fakeAuthSVC),
+						WithStore(fakeStore),
+					)...)
+
+					impact, err := svc.Apply(context.TODO(), orgID, userID, ApplyWithTemplate(template), ApplyWithStackID(stackID))
+					require.NoError(t, err)
+
+					require.Len(t, created, 1)
+					assert.Equal(t, orgID, created[0].OrgID)
+					assert.Equal(t, userID, created[0].UserID)
+					assert.Equal(t, "reads the template's dashboard", created[0].Description)
+					dashPerm, err := influxdb.NewPermissionAtID(2, influxdb.ReadAction, influxdb.DashboardsResourceType, orgID)
+					require.NoError(t, err)
+					bktPerm, err := influxdb.NewPermissionAtID(1, influxdb.WriteAction, influxdb.BucketsResourceType, orgID)
+					require.NoError(t, err)
+					assert.Equal(t, []influxdb.Permission{*dashPerm, *bktPerm}, created[0].Permissions)
+
+					require.Len(t, impact.Authorizations, 1)
+					assert.Equal(t, SafeID(100), impact.Authorizations[0].ID)
+					assert.Equal(t, "token", impact.Authorizations[0].Token)
+
+					// the authorization of the previous apply is deleted
+					assert.Equal(t, []platform.ID{99}, deleted)
+					assert.Contains(t, updated.LatestEvent().Resources, StackResource{
+						APIVersion: APIVersion,
+						ID:         100,
+						Kind:       KindAuthorization,
+						MetaName:   "auth-1",
+					})
+				})
+			})
+
+			t.Run("rolls back the resources when an authorization fails", func(t *testing.T) {
+				testfileRunner(t, "testdata/authorizations.yml", func(t *testing.T, template *Template) {
+					fakeAuthSVC := mock.NewAuthorizationService()
+					fakeAuthSVC.CreateAuthorizationFn = func(context.Context, *influxdb.Authorization) error {
+						return errors.New("failed to create")
+					}
+
+					var deletedBuckets []platform.ID
+					fakeBktSVC := mock.NewBucketService()
+					fakeBktSVC.CreateBucketFn = func(_ context.Context, b *influxdb.Bucket) error {
+						b.ID = 1
+						return nil
+					}
+					fakeBktSVC.FindBucketByNameFn = func(_ context.Context, id platform.ID, s string) (*influxdb.Bucket, error) {
+						return nil, errors.New("an error")
+					}
+					fakeBktSVC.DeleteBucketFn = func(_ context.Context, id platform.ID) error {
+						deletedBuckets = append(deletedBuckets, id)
+						return nil
+					}
+
+					svc := newTestService(append(newResourceSVCs(),
+						WithAuthorizationSVC(fakeAuthSVC),
+						WithBucketSVC(fakeBktSVC),
+					)...)
+
+					_, err := svc.Apply(context.TODO(), platform.ID(9000), 0, ApplyWithTemplate(template))
+					require.Error(t, err)
+					assert.Equal(t, []platform.ID{1}, deletedBuckets)
+				})
+			})
+
+			t.Run("dry run lists authorizations without tokens", func(t *testing.T) {
+				testfileRunner(t, "testdata/authorizations.yml", func(t *testing.T, template *Template) {
+					svc := newTestService(newResourceSVCs()...)
+
+					impact, err := svc.DryRun(context.TODO(), platform.ID(9000), 0, ApplyWithTemplate(template))
+					require.NoError(t, err)
+
+					require.Len(t, impact.Authorizations, 1)
+					assert.Equal(t, "auth-1", impact.Authorizations[0].MetaName)
+					assert.Empty(t, impact.Authorizations[0].Token)
+					assert.Len(t, impact.Authorizations[0].Permissions, 2)
+				})
+			})
+		})
+
 		t.Run("buckets", func(t *testing.T) {
 			t.Run("successfully creates template of buckets", func(t *testing.T) {
 				testfileRunner(t, "testdata/bucket.yml", func(t *testing.T, template *Template) {
@@ -1605,6 +2147,29 @@ func TestService(t *testing.T) {
 					assert.Equal(t, 1, fakeTaskSVC.DeleteTaskCalls.Count())
 				})
 			})
+
+			t.Run("fails and deletes a created task whose schedule differs", func(t *testing.T) {
+				testfileRunner(t, "testdata/tasks.yml", func(t *testing.T, template *Template) {
+					fakeTaskSVC := mock.NewTaskService()
+					fakeTaskSVC.CreateTaskFn = func(ctx context.Context, tc taskmodel.TaskCreate) (*taskmodel.Task, error) {
+						return &taskmodel.Task{
+							ID:     platform.ID(fakeTaskSVC.CreateTaskCalls.Count() + 1),
+							Status: tc.Status,
+							// a normalized schedule, which would shift the runs
+							Flux: strings.Replace(tc.Flux, "every: 1d1h", "every: 25h", 1),
+						}, nil
+					}
+
+					svc := newTestService(WithTaskSVC(fakeTaskSVC))
+
+					_, err := svc.Apply(context.TODO(), platform.ID(9000), 0, ApplyWithTemplate(template))
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), `every="25h"`)
+
+					// the task with the wrong schedule and the other one are deleted
+					assert.Equal(t, 2, fakeTaskSVC.DeleteTaskCalls.Count())
+				})
+			})
 		})
 
 		t.Run("telegrafs", func(t *testing.T) {
@@ -3956,9 +4521,13 @@ func TestService(t *testing.T) {
 			t.Run("tasks", func(t *testing.T) {
 				t.Run("single task exports", func(t *testing.T) {
 					tests := []struct {
-						name    string
-						newName string
-						task    taskmodel.Task
+						name        string
+						newName     string
+						task        taskmodel.Task
+						concurrency int64
+						retry       int64
+						every       string
+						offset      string
 					}{
 						{
 							name:    "every offset is set",
@@ -3982,6 +4551,33 @@ func TestService(t *testing.T) {
 								Flux: `option task = { name: "larry" } from(bucket: "rucket") |> yield()`,
 							},
 						},
+						{
+							name: "scheduling options and status are set",
+							task: taskmodel.Task{
+								ID:     1,
+								Name:   "name_1",
+								Every:  "1m",
+								Offset: 10 * time.Second,
+								Status: taskmodel.TaskStatusInactive,
+								Type:   taskmodel.TaskSystemType,
+								Flux:   `option task = { name: "larry", every: 1m, offset: 10s, concurrency: 2, retry: 3 } from(bucket: "rucket") |> yield()`,
+							},
+							concurrency: 2,
+							retry:       3,
+						},
+						{
+							name: "calendar every and negative offset are kept as written",
+							task: taskmodel.Task{
+								ID:     1,
+								Name:   "name_2",
+								Every:  "1mo",
+								Offset: -5 * time.Minute,
+								Type:   taskmodel.TaskSystemType,
+								Flux:   `option task = { name: "larry", every: 1mo, offset: -5m } from(bucket: "rucket") |> yield()`,
+							},
+							every:  "1mo",
+							offset: "-5m",
+						},
 					}
 
 					for _, tt := range tests {
@@ -4022,16 +4618,70 @@ func TestService(t *testing.T) {
 							assert.Equal(t, expectedName, actual.Name)
 							assert.Equal(t, tt.task.Cron, actual.Cron)
 							assert.Equal(t, tt.task.Description, actual.Description)
-							assert.Equal(t, tt.task.Every, actual.Every)
-							assert.Equal(t, durToStr(tt.task.Offset), actual.Offset)
+							expectedEvery, expectedOffset := tt.task.Every, durToStr(tt.task.Offset)
+							if tt.every != "" {
+								expectedEvery, expectedOffset = tt.every, tt.offset
+							}
+							assert.Equal(t, expectedEvery, actual.Every)
+							assert.Equal(t, expectedOffset, actual.Offset)
+							assert.Equal(t, tt.concurrency, actual.Concurrency)
+							assert.Equal(t, tt.retry, actual.Retry)
+
+							expectedStatus := influxdb.Active
+							if tt.task.Status != "" {
+								expectedStatus = influxdb.Status(tt.task.Status)
+							}
+							assert.Equal(t, expectedStatus, actual.Status)
 
 							expectedQuery := `from(bucket: "rucket") |> yield()`
 							assert.Equal(t, expectedQuery, actual.Query)
+
+							if tt.concurrency > 0 {
+								flux := newTemplate.tasks()[0].flux()
+								assert.Contains(t, flux, fmt.Sprintf("concurrency: %d", tt.concurrency))
+								assert.Contains(t, flux, fmt.Sprintf("retry: %d", tt.retry))
+							}
 						}
 						t.Run(tt.name, fn)
 					}
 				})
 
+				t.Run("task secrets are exported as placeholders", func(t *testing.T) {
+					task := taskmodel.Task{
+						ID:    1,
+						Name:  "name_2",
+						Every: time.Minute.String(),
+						Type:  taskmodel.TaskSystemType,
+						Flux: `import "influxdata/influxdb/secrets"
+option task = { name: "larry" }
+token = secrets.get(key: "api-token")
+from(bucket: "rucket") |> yield()`,
+					}
+
+					taskSVC := mock.NewTaskService()
+					taskSVC.FindTaskByIDFn = func(ctx context.Context, id platform.ID) (*taskmodel.Task, error) {
+						return &task, nil
+					}
+
+					svc := newTestService(WithTaskSVC(taskSVC))
+
+					template, err := svc.Export(context.TODO(), ExportWithExistingResources(ResourceToClone{
+						Kind: KindTask,
+						ID:   task.ID,
+					}))
+					require.NoError(t, err)
+
+					require.Len(t, template.Objects, 1)
+					assert.Equal(t, []Resource{{
+						fieldReferencesSecret: Resource{fieldKey: "api-token"},
+					}}, template.Objects[0].Spec[fieldTaskSecrets])
+
+					sum := encodeAndDecode(t, template).Summary()
+					require.Len(t, sum.Tasks, 1)
+					assert.Equal(t, []string{"api-token"}, sum.Tasks[0].Secrets)
+					assert.Equal(t, []string{"api-token"}, sum.MissingSecrets)
+				})
+
 				t.Run("handles multiple tasks of same name", func(t *testing.T) {
 					taskSVC := mock.NewTaskService()
 					taskSVC.FindTaskByIDFn = func(ctx context.Context, id platform.ID) (*taskmodel.Task, error) {
@@ -5475,6 +6125,190 @@ func Test_normalizeRemoteSources(t *testing.T) {
 	}
 }
 
+func TestService_CheckStackUpdates(t *testing.T) {
+	const tmpl = `
+apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: rucket-1
+`
+	var contents, signature atomic.Value
+	contents.Store(tmpl)
+	signature.Store("")
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, SignatureExt) {
+			w.Write([]byte(signature.Load().(string)))
+			return
+		}
+		w.Write([]byte(contents.Load().(string)))
+	}))
+	defer svr.Close()
+
+	remote := svr.URL + "/bucket.yml"
+	applied, err := Parse(EncodingYAML, FromHTTPRequest(remote, svr.Client()))
+	require.NoError(t, err)
+	require.Len(t, applied.sourceVersions, 1)
+	sum := sha256.Sum256([]byte(tmpl))
+	assert.Equal(t, hex.EncodeToString(sum[:]), applied.sourceVersions[0].SHA256)
+
+	stackID, orgID := platform.ID(3), platform.ID(1)
+	newSVCWithOpts := func(opts []ServiceSetterFn, versions ...StackSourceVersion) *Service {
+		return NewService(append([]ServiceSetterFn{
+			WithHTTPClient(svr.Client()),
+			WithStore(&fakeStore{
+				readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+					return Stack{
+						ID:    id,
+						OrgID: orgID,
+						Events: []StackEvent{{
+							EventType:      StackEventUpdate,
+							SourceVersions: versions,
+						}},
+					}, nil
+				},
+			}),
+		}, opts...)...)
+	}
+	newSVC := func(versions ...StackSourceVersion) *Service {
+		return newSVCWithOpts(nil, versions...)
+	}
+	identifiers := struct{ OrgID, UserID, StackID platform.ID }{OrgID: orgID, UserID: 2, StackID: stackID}
+
+	t.Run("reports no update when the source is unchanged", func(t *testing.T) {
+		check, err := newSVC(applied.sourceVersions...).CheckStackUpdates(context.Background(), identifiers)
+		require.NoError(t, err)
+
+		assert.False(t, check.UpdateAvailable)
+		require.Len(t, check.Sources, 1)
+		assert.True(t, check.Sources[0].Checked)
+		assert.Equal(t, applied.sourceVersions[0], *check.Sources[0].Latest)
+		assert.Nil(t, check.Diff)
+	})
+
+	t.Run("reports an update when the source changed", func(t *testing.T) {
+		contents.Store(tmpl + "spec:\n  retentionRules:\n    - type: expire\n      everySeconds: 3600\n")
+		defer contents.Store(tmpl)
+
+		uploaded := StackSourceVersion{Source: "byte stream", SHA256: "abc"}
+		check, err := newSVC(applied.sourceVersions[0], uploaded).CheckStackUpdates(context.Background(), identifiers)
+		require.NoError(t, err)
+
+		assert.True(t, check.UpdateAvailable)
+		require.Len(t, check.Sources, 2)
+		assert.True(t, check.Sources[0].UpdateAvailable)
+		assert.NotEqual(t, applied.sourceVersions[0].SHA256, check.Sources[0].Latest.SHA256)
+		assert.False(t, check.Sources[1].Checked)
+		assert.Nil(t, check.Diff, "no diff is provided when a source cannot be refetched")
+	})
+
+	t.Run("rejects a stack from another org", func(t *testing.T) {
+		ids := identifiers
+		ids.OrgID = 9000
+		_, err := newSVC(applied.sourceVersions...).CheckStackUpdates(context.Background(), ids)
+		assert.Equal(t, errors2.EConflict, errors2.ErrorCode(err))
+	})
+
+	t.Run("verifies the source signature with trust anchors", func(t *testing.T) {
+		pub, priv, err := ed25519.GenerateKey(cryptorand.Reader)
+		require.NoError(t, err)
+		svc := newSVCWithOpts([]ServiceSetterFn{WithTemplateTrustAnchors(pub)}, applied.sourceVersions...)
+
+		_, err = svc.CheckStackUpdates(context.Background(), identifiers)
+		assert.Equal(t, errors2.EUnprocessableEntity, errors2.ErrorCode(err), "unsigned source is rejected")
+
+		signature.Store(SignTemplate(priv, []byte(tmpl)))
+		defer signature.Store("")
+		check, err := svc.CheckStackUpdates(context.Background(), identifiers)
+		require.NoError(t, err)
+		assert.True(t, check.Sources[0].Checked)
+	})
+}
+
+func TestService_CheckStackDrift(t *testing.T) {
+	const tmpl = `
+apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: rucket-1
+`
+	var contents atomic.Value
+	contents.Store(tmpl)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(contents.Load().(string)))
+	}))
+	defer svr.Close()
+
+	applied, err := Parse(EncodingYAML, FromHTTPRequest(svr.URL+"/bucket.yml", svr.Client()))
+	require.NoError(t, err)
+
+	stackID, orgID := platform.ID(3), platform.ID(1)
+	bktSVC := mock.NewBucketService()
+	bktSVC.FindBucketByNameFn = func(ctx context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error) {
+		return nil, &errors2.Error{Code: errors2.ENotFound}
+	}
+	newSVC := func(versions ...StackSourceVersion) *Service {
+		return NewService(
+			WithHTTPClient(svr.Client()),
+			WithBucketSVC(bktSVC),
+			WithCheckSVC(mock.NewCheckService()),
+			WithLabelSVC(mock.NewLabelService()),
+			WithNotificationEndpointSVC(mock.NewNotificationEndpointService()),
+			WithNotificationRuleSVC(mock.NewNotificationRuleStore()),
+			WithVariableSVC(mock.NewVariableService()),
+			WithStore(&fakeStore{
+				readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+					return Stack{
+						ID:    id,
+						OrgID: orgID,
+						Events: []StackEvent{{
+							EventType:      StackEventUpdate,
+							SourceVersions: versions,
+						}},
+					}, nil
+				},
+			}),
+		)
+	}
+	identifiers := struct{ OrgID, UserID, StackID platform.ID }{OrgID: orgID, UserID: 2, StackID: stackID}
+
+	t.Run("reports a removed resource as drift", func(t *testing.T) {
+		drift, err := newSVC(applied.sourceVersions...).CheckStackDrift(context.Background(), identifiers)
+		require.NoError(t, err)
+
+		assert.True(t, drift.Checked)
+		assert.True(t, drift.Drifted)
+		require.NotNil(t, drift.Diff)
+		require.Len(t, drift.Diff.Buckets, 1)
+		assert.True(t, IsNew(drift.Diff.Buckets[0].StateStatus))
+	})
+
+	t.Run("does not check a source that changed since it was applied", func(t *testing.T) {
+		contents.Store(tmpl + "spec:\n  retentionRules:\n    - type: expire\n      everySeconds: 3600\n")
+		defer contents.Store(tmpl)
+
+		drift, err := newSVC(applied.sourceVersions...).CheckStackDrift(context.Background(), identifiers)
+		require.NoError(t, err)
+		assert.False(t, drift.Checked)
+		assert.False(t, drift.Drifted)
+		assert.Nil(t, drift.Diff)
+	})
+
+	t.Run("does not check a source that cannot be refetched", func(t *testing.T) {
+		uploaded := StackSourceVersion{Source: "byte stream", SHA256: "abc"}
+		drift, err := newSVC(applied.sourceVersions[0], uploaded).CheckStackDrift(context.Background(), identifiers)
+		require.NoError(t, err)
+		assert.False(t, drift.Checked)
+		assert.Nil(t, drift.Diff)
+	})
+
+	t.Run("rejects a stack from another org", func(t *testing.T) {
+		ids := identifiers
+		ids.OrgID = 9000
+		_, err := newSVC(applied.sourceVersions...).CheckStackDrift(context.Background(), ids)
+		assert.Equal(t, errors2.EConflict, errors2.ErrorCode(err))
+	})
+}
+
 func newTestIDPtr(i int) *platform.ID {
 	id := platform.ID(i)
 	return &id
@@ -5489,6 +6323,10 @@ type fakeStore struct {
 	deleteFn func(ctx context.Context, id platform.ID) error
 	readFn   func(ctx context.Context, id platform.ID) (Stack, error)
 	updateFn func(ctx context.Context, stack Stack) error
+
+	updateIfUnchangedFn func(ctx context.Context, stack Stack, updatedAt time.Time) error
+
+	addJournalFn func(ctx context.Context, entry StackJournalEntry) error
 }
 
 var _ Store = (*fakeStore)(nil)
@@ -5518,6 +6356,13 @@ func (s *fakeStore) UpdateStack(ctx context.Context, stack Stack) error {
 	panic("not implemented")
 }
 
+func (s *fakeStore) UpdateStackIfUnchanged(ctx context.Context, stack Stack, updatedAt time.Time) error {
+	if s.updateIfUnchangedFn != nil {
+		return s.updateIfUnchangedFn(ctx, stack, updatedAt)
+	}
+	panic("not implemented")
+}
+
 func (s *fakeStore) DeleteStack(ctx context.Context, id platform.ID) error {
 	if s.deleteFn != nil {
 		return s.deleteFn(ctx, id)
@@ -5525,6 +6370,72 @@ func (s *fakeStore) DeleteStack(ctx context.Context, id platform.ID) error {
 	panic("not implemented")
 }
 
+func (s *fakeStore) AddStackJournalEntry(ctx context.Context, entry StackJournalEntry) error {
+	if s.addJournalFn != nil {
+		return s.addJournalFn(ctx, entry)
+	}
+	return nil
+}
+
+func (s *fakeStore) ListStackJournal(ctx context.Context, stackID platform.ID) ([]StackJournalEntry, error) {
+	panic("not implemented")
+}
+
+func Test_newApplyTiming(t *testing.T) {
+	diff := Diff{
+		Buckets: []DiffBucket{
+			{DiffIdentifier: DiffIdentifier{Kind: KindBucket, StateStatus: StateStatusNew}},
+			{DiffIdentifier: DiffIdentifier{Kind: KindBucket, StateStatus: StateStatusExists}},
+			{
+				DiffIdentifier: DiffIdentifier{Kind: KindBucket, StateStatus: StateStatusExists},
+				Old:            &DiffBucketValues{Name: "rucket"},
+				New:            DiffBucketValues{Name: "rucket"},
+			},
+		},
+		Checks: []DiffCheck{
+			{DiffIdentifier: DiffIdentifier{Kind: KindCheckDeadman, StateStatus: StateStatusNew}},
+			{DiffIdentifier: DiffIdentifier{Kind: KindCheckThreshold, StateStatus: StateStatusRemove}},
+		},
+	}
+	elapsed := map[Kind]time.Duration{
+		KindBucket: 2 * time.Second,
+		KindCheck:  time.Second,
+		KindLabel:  time.Millisecond,
+	}
+
+	timing := newApplyTiming(5*time.Second, diff, elapsed)
+
+	assert.Equal(t, ApplyTiming{
+		Duration: 5 * time.Second,
+		Kinds: []KindTiming{
+			{Kind: KindBucket, Created: 1, Updated: 1, Unchanged: 1, Elapsed: 2 * time.Second},
+			{Kind: KindCheck, Created: 1, Removed: 1, Elapsed: time.Second},
+			{Kind: KindLabel, Elapsed: time.Millisecond},
+		},
+	}, timing)
+}
+
+func Test_rollbackCoordinator_kindElapsed(t *testing.T) {
+	coord := newRollbackCoordinator(zap.NewNop(), 2)
+
+	sleep := creater{
+		entries: 3,
+		fn: func(ctx context.Context, i int, orgID, userID platform.ID) *applyErrBody {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		},
+	}
+	err := coord.runTilEnd(context.Background(), 1, 1,
+		applier{kind: KindBucket, creater: sleep},
+		applier{creater: sleep},
+	)
+	require.NoError(t, err)
+
+	elapsed := coord.kindElapsed()
+	require.Len(t, elapsed, 1)
+	assert.GreaterOrEqual(t, int64(elapsed[KindBucket]), int64(20*time.Millisecond))
+}
+
 type fakeIDGen func() platform.ID
 
 func newFakeIDGen(id platform.ID) fakeIDGen {
@@ -63,6 +63,24 @@ func (s *traceMW) UpdateStack(ctx context.Context, upd StackUpdate) (Stack, erro
 	return s.next.UpdateStack(ctx, upd)
 }
 
+func (s *traceMW) CheckStackUpdates(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackUpdateCheck, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+	return s.next.CheckStackUpdates(ctx, identifiers)
+}
+
+func (s *traceMW) CheckStackDrift(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackDrift, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+	return s.next.CheckStackDrift(ctx, identifiers)
+}
+
+func (s *traceMW) ReadStackJournal(ctx context.Context, stackID platform.ID) ([]StackJournalEntry, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+	return s.next.ReadStackJournal(ctx, stackID)
+}
+
 func (s *traceMW) Export(ctx context.Context, opts ...ExportOptFn) (template *Template, err error) {
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
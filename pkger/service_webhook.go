@@ -0,0 +1,216 @@
+package pkger
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"go.uber.org/zap"
+)
+
+// WebhookSignatureHeader carries the hex encoded HMAC-SHA256 of the event body
+// when the webhook is configured with a secret.
+const WebhookSignatureHeader = "X-Influxdb-Signature"
+
+// webhookTimeout bounds the time spent delivering a single event.
+const webhookTimeout = 10 * time.Second
+
+// ApplyEventType identifies the operation an apply event describes.
+type ApplyEventType string
+
+// ApplyEventType types.
+const (
+	ApplyEventTypeApply  ApplyEventType = "apply"
+	ApplyEventTypeDryRun ApplyEventType = "dryRun"
+)
+
+// ApplyEventStatus indicates the outcome of the operation.
+type ApplyEventStatus string
+
+// ApplyEventStatus statuses.
+const (
+	ApplyEventStatusSuccess ApplyEventStatus = "success"
+	ApplyEventStatusFailed  ApplyEventStatus = "failed"
+)
+
+// ApplyEvent is posted to the webhook for every template apply and dry run.
+// The UserID identifies the actor and Resources lists the resources affected
+// by the template.
+type ApplyEvent struct {
+	Type      ApplyEventType   `json:"type"`
+	Status    ApplyEventStatus `json:"status"`
+	Error     string           `json:"error,omitempty"`
+	OrgID     SafeID           `json:"orgID"`
+	StackID   SafeID           `json:"stackID,omitempty"`
+	UserID    SafeID           `json:"userID"`
+	Sources   []string         `json:"sources"`
+	Resources []DiffIdentifier `json:"resources"`
+	// NoOp is set when the template changes none of its resources, so
+	// receivers may skip notifying about syncs that did nothing.
+	NoOp bool      `json:"noop"`
+	Time time.Time `json:"time"`
+}
+
+type webhookMW struct {
+	log    *zap.Logger
+	url    string
+	secret string
+	client *http.Client
+	next   SVC
+}
+
+// MWWebhook posts an ApplyEvent to the url for every successful or failed
+// apply and dry run. Events are delivered asynchronously; a delivery failure
+// is logged and does not affect the result of the operation. When a secret
+// is provided, each event is signed with it.
+func MWWebhook(log *zap.Logger, url, secret string, client *http.Client) SVCMiddleware {
+	if client == nil {
+		client = &http.Client{Timeout: webhookTimeout}
+	}
+	return func(svc SVC) SVC {
+		return &webhookMW{
+			log:    log,
+			url:    url,
+			secret: secret,
+			client: client,
+			next:   svc,
+		}
+	}
+}
+
+var _ SVC = (*webhookMW)(nil)
+
+func (s *webhookMW) InitStack(ctx context.Context, userID platform.ID, newStack StackCreate) (Stack, error) {
+	return s.next.InitStack(ctx, userID, newStack)
+}
+
+func (s *webhookMW) UninstallStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (Stack, error) {
+	return s.next.UninstallStack(ctx, identifiers)
+}
+
+func (s *webhookMW) DeleteStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) error {
+	return s.next.DeleteStack(ctx, identifiers)
+}
+
+func (s *webhookMW) ListStacks(ctx context.Context, orgID platform.ID, f ListFilter) ([]Stack, error) {
+	return s.next.ListStacks(ctx, orgID, f)
+}
+
+func (s *webhookMW) ReadStack(ctx context.Context, id platform.ID) (Stack, error) {
+	return s.next.ReadStack(ctx, id)
+}
+
+func (s *webhookMW) UpdateStack(ctx context.Context, upd StackUpdate) (Stack, error) {
+	return s.next.UpdateStack(ctx, upd)
+}
+
+func (s *webhookMW) CheckStackUpdates(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackUpdateCheck, error) {
+	return s.next.CheckStackUpdates(ctx, identifiers)
+}
+
+func (s *webhookMW) CheckStackDrift(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (StackDrift, error) {
+	return s.next.CheckStackDrift(ctx, identifiers)
+}
+
+func (s *webhookMW) ReadStackJournal(ctx context.Context, stackID platform.ID) ([]StackJournalEntry, error) {
+	return s.next.ReadStackJournal(ctx, stackID)
+}
+
+func (s *webhookMW) Export(ctx context.Context, opts ...ExportOptFn) (*Template, error) {
+	return s.next.Export(ctx, opts...)
+}
+
+func (s *webhookMW) DryRun(ctx context.Context, orgID, userID platform.ID, opts ...ApplyOptFn) (ImpactSummary, error) {
+	impact, err := s.next.DryRun(ctx, orgID, userID, opts...)
+	s.notify(newApplyEvent(ApplyEventTypeDryRun, orgID, userID, impact, err, opts))
+	return impact, err
+}
+
+func (s *webhookMW) Apply(ctx context.Context, orgID, userID platform.ID, opts ...ApplyOptFn) (ImpactSummary, error) {
+	impact, err := s.next.Apply(ctx, orgID, userID, opts...)
+	s.notify(newApplyEvent(ApplyEventTypeApply, orgID, userID, impact, err, opts))
+	return impact, err
+}
+
+func (s *webhookMW) notify(event ApplyEvent) {
+	b, err := json.Marshal(event)
+	if err != nil {
+		s.log.Error("failed to encode template apply event", zap.Error(err))
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+		defer cancel()
+
+		if err := s.post(ctx, b); err != nil {
+			s.log.Error("failed to deliver template apply event",
+				zap.String("url", s.url),
+				zap.String("type", string(event.Type)),
+				zap.Stringer("orgID", platform.ID(event.OrgID)),
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
+func (s *webhookMW) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.secret))
+		mac.Write(body)
+		req.Header.Set(WebhookSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("bad response: status_code=%d", resp.StatusCode)
+	}
+	return nil
+}
+
+func newApplyEvent(typ ApplyEventType, orgID, userID platform.ID, impact ImpactSummary, err error, opts []ApplyOptFn) ApplyEvent {
+	opt := applyOptFromOptFns(opts...)
+
+	event := ApplyEvent{
+		Type:      typ,
+		Status:    ApplyEventStatusSuccess,
+		OrgID:     SafeID(orgID),
+		StackID:   SafeID(opt.StackID),
+		UserID:    SafeID(userID),
+		Sources:   impact.Sources,
+		Resources: impact.Diff.resources(),
+		NoOp:      impact.NoOp,
+		Time:      time.Now().UTC(),
+	}
+	if impact.StackID != 0 {
+		event.StackID = SafeID(impact.StackID)
+	}
+	if err != nil {
+		event.Status = ApplyEventStatusFailed
+		event.Error = err.Error()
+		for _, t := range opt.Templates {
+			event.Sources = append(event.Sources, t.Sources()...)
+		}
+	}
+	if event.Sources == nil {
+		event.Sources = []string{}
+	}
+	return event
+}
@@ -0,0 +1,114 @@
+package pkger
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// SignatureExt is the extension appended to a template URL to locate its
+// detached signature when one is not provided explicitly.
+const SignatureExt = ".sig"
+
+// ErrTemplateSignature is returned when a template's signature is missing or
+// does not verify against any of the configured trust anchors.
+var ErrTemplateSignature = &errors.Error{
+	Code: errors.EUnprocessableEntity,
+	Msg:  "template signature could not be verified",
+}
+
+// ParseTrustAnchors decodes base64 encoded ed25519 public keys into trust
+// anchors used to verify template signatures.
+func ParseTrustAnchors(keys ...string) ([]ed25519.PublicKey, error) {
+	anchors := make([]ed25519.PublicKey, 0, len(keys))
+	for i, k := range keys {
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(k))
+		if err != nil {
+			return nil, fmt.Errorf("trust anchor[%d] is not valid base64: %w", i, err)
+		}
+		if len(b) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("trust anchor[%d] has invalid size %d; must be %d bytes", i, len(b), ed25519.PublicKeySize)
+		}
+		anchors = append(anchors, ed25519.PublicKey(b))
+	}
+	return anchors, nil
+}
+
+// SignTemplate produces a base64 encoded detached signature for the raw template
+// contents. It is the counterpart of the verification performed by the server
+// when trust anchors are configured.
+func SignTemplate(key ed25519.PrivateKey, contents []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, contents))
+}
+
+// FromVerifiedHTTPRequest retrieves the template at addr and verifies its detached
+// signature against the trust anchors before handing the contents to the parser.
+// The signature is taken from sig when provided, otherwise it is fetched from
+// sigAddr, defaulting to addr with the SignatureExt appended.
+func FromVerifiedHTTPRequest(addr, sig, sigAddr string, client *http.Client, anchors []ed25519.PublicKey) ReaderFn {
+	return verifiedReaderFn(FromHTTPRequest(addr, client), addr, sig, sigAddr, client, anchors)
+}
+
+// verifiedReaderFn reads the template from fetch and verifies it against the
+// signature of the template at addr, as FromVerifiedHTTPRequest does.
+func verifiedReaderFn(fetch ReaderFn, addr, sig, sigAddr string, client *http.Client, anchors []ed25519.PublicKey) ReaderFn {
+	return func() (io.Reader, string, error) {
+		r, source, err := fetch()
+		if err != nil {
+			return nil, source, err
+		}
+		contents, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, source, err
+		}
+
+		if err := verifyRemoteTemplate(contents, addr, sig, sigAddr, client, anchors); err != nil {
+			return nil, source, err
+		}
+		return bytes.NewReader(contents), source, nil
+	}
+}
+
+// verifyRemoteTemplate verifies the contents of the template at addr against
+// the signature, which is retrieved from sigAddr when not provided.
+func verifyRemoteTemplate(contents []byte, addr, sig, sigAddr string, client *http.Client, anchors []ed25519.PublicKey) error {
+	if sig == "" {
+		if sigAddr == "" {
+			sigAddr = addr + SignatureExt
+		}
+		sr, _, err := FromHTTPRequest(sigAddr, client)()
+		if err != nil {
+			return &errors.Error{
+				Code: errors.EUnprocessableEntity,
+				Msg:  fmt.Sprintf("unable to retrieve template signature from %s", sigAddr),
+				Err:  err,
+			}
+		}
+		b, err := ioutil.ReadAll(sr)
+		if err != nil {
+			return err
+		}
+		sig = string(b)
+	}
+	return verifyTemplateSignature(contents, sig, anchors)
+}
+
+func verifyTemplateSignature(contents []byte, sig string, anchors []ed25519.PublicKey) error {
+	rawSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sig))
+	if err != nil || len(rawSig) != ed25519.SignatureSize {
+		return ErrTemplateSignature
+	}
+	for _, anchor := range anchors {
+		if ed25519.Verify(anchor, contents, rawSig) {
+			return nil
+		}
+	}
+	return ErrTemplateSignature
+}
@@ -0,0 +1,73 @@
+package pkger
+
+import (
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// StackJournalAction identifies the operation a stack journal entry records.
+type StackJournalAction string
+
+// StackJournalAction actions.
+const (
+	StackJournalActionApply     StackJournalAction = "apply"
+	StackJournalActionDryRun    StackJournalAction = "dryRun"
+	StackJournalActionUninstall StackJournalAction = "uninstall"
+)
+
+// StackJournalEntry records an apply, dry run or uninstall performed against
+// a stack, successful or failed, so the history of a stack can be audited.
+type StackJournalEntry struct {
+	StackID platform.ID
+	Action  StackJournalAction
+	// UserID is the actor performing the operation.
+	UserID  platform.ID
+	Sources []string
+	Impact  StackJournalImpact
+	// Error is the error the operation failed with, empty if it succeeded.
+	Error string
+	Time  time.Time
+}
+
+// StackJournalImpact summarizes the resources an operation affected.
+type StackJournalImpact struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Removed   int `json:"removed"`
+	Unchanged int `json:"unchanged"`
+}
+
+func newStackJournalImpact(d Diff) StackJournalImpact {
+	var impact StackJournalImpact
+	for _, g := range d.renderGroups() {
+		for _, e := range g.entries {
+			switch {
+			case e.id.StateStatus == StateStatusNew:
+				impact.Created++
+			case e.id.StateStatus == StateStatusRemove:
+				impact.Removed++
+			case len(e.changes) > 0:
+				impact.Updated++
+			default:
+				impact.Unchanged++
+			}
+		}
+	}
+	return impact
+}
+
+func newStackJournalEntry(action StackJournalAction, stackID, userID platform.ID, impact ImpactSummary, err error, now time.Time) StackJournalEntry {
+	entry := StackJournalEntry{
+		StackID: stackID,
+		Action:  action,
+		UserID:  userID,
+		Sources: impact.Sources,
+		Impact:  newStackJournalImpact(impact.Diff),
+		Time:    now.UTC(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return entry
+}
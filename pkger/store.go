@@ -3,6 +3,7 @@ package pkger
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"sort"
 	"time"
@@ -27,13 +28,20 @@ type (
 	}
 
 	entStackEvent struct {
-		EventType   StackEventType     `json:"eventType"`
-		Name        string             `json:"name"`
-		Description string             `json:"description"`
-		Sources     []string           `json:"sources,omitempty"`
-		URLs        []string           `json:"urls,omitempty"`
-		Resources   []entStackResource `json:"resources,omitempty"`
-		UpdatedAt   time.Time          `json:"updatedAt"`
+		EventType      StackEventType          `json:"eventType"`
+		Name           string                  `json:"name"`
+		Description    string                  `json:"description"`
+		Sources        []string                `json:"sources,omitempty"`
+		SourceVersions []entStackSourceVersion `json:"sourceVersions,omitempty"`
+		URLs           []string                `json:"urls,omitempty"`
+		Resources      []entStackResource      `json:"resources,omitempty"`
+		UpdatedAt      time.Time               `json:"updatedAt"`
+	}
+
+	entStackSourceVersion struct {
+		Source  string `json:"source"`
+		SHA256  string `json:"sha256"`
+		Version string `json:"version,omitempty"`
 	}
 
 	entStackResource struct {
@@ -48,8 +56,23 @@ type (
 		Kind string `json:"kind"`
 		Name string `json:"name"`
 	}
+
+	entStackJournalEntry struct {
+		Action  StackJournalAction `json:"action"`
+		UserID  string             `json:"userID"`
+		Sources []string           `json:"sources,omitempty"`
+		Impact  StackJournalImpact `json:"impact"`
+		Error   string             `json:"error,omitempty"`
+		Time    time.Time          `json:"time"`
+	}
 )
 
+var stackJournalBucket = []byte("v1_pkger_stack_journal")
+
+// stackJournalMaxEntries is the number of entries kept in the journal of a
+// stack, older entries are removed as new ones are added.
+const stackJournalMaxEntries = 500
+
 // StoreKV is a store implementation that uses a kv store backing.
 type StoreKV struct {
 	kvStore   kv.Store
@@ -193,26 +216,205 @@ func (s *StoreKV) ReadStackByID(ctx context.Context, id platform.ID) (Stack, err
 
 // UpdateStack updates a stack.
 func (s *StoreKV) UpdateStack(ctx context.Context, stack Stack) error {
-	existing, err := s.ReadStackByID(ctx, stack.ID)
+	return s.updateStack(ctx, stack, nil)
+}
+
+// UpdateStackIfUnchanged updates a stack when its latest event was last
+// updated at updatedAt. It fails with EPreconditionFailed when the stack
+// was updated since.
+func (s *StoreKV) UpdateStackIfUnchanged(ctx context.Context, stack Stack, updatedAt time.Time) error {
+	return s.updateStack(ctx, stack, &updatedAt)
+}
+
+func (s *StoreKV) updateStack(ctx context.Context, stack Stack, ifUpdatedAt *time.Time) error {
+	return s.kvStore.Update(ctx, func(tx kv.Tx) error {
+		decodedEnt, err := s.indexBase.FindEnt(ctx, tx, kv.Entity{PK: kv.EncID(stack.ID)})
+		if err != nil {
+			return err
+		}
+		existing, err := convertStackEntToStack(decodedEnt.(*entStack))
+		if err != nil {
+			return err
+		}
+
+		if stack.OrgID != existing.OrgID {
+			return &errors.Error{
+				Code: errors.EUnprocessableEntity,
+				Msg:  "org id does not match",
+			}
+		}
+		if ifUpdatedAt != nil && !existing.LatestEvent().UpdatedAt.Equal(*ifUpdatedAt) {
+			return &errors.Error{
+				Code: errors.EPreconditionFailed,
+				Msg:  "stack was updated since the version the update was made against",
+			}
+		}
+
+		ent, err := convertStackToEnt(stack)
+		if err != nil {
+			return influxErr(errors.EInvalid, err)
+		}
+		return s.indexBase.Put(ctx, tx, ent, kv.PutUpdate())
+	})
+}
+
+// DeleteStack deletes a stack by id along with its journal.
+func (s *StoreKV) DeleteStack(ctx context.Context, id platform.ID) error {
+	return s.kvStore.Update(ctx, func(tx kv.Tx) error {
+		if err := s.indexBase.DeleteEnt(ctx, tx, kv.Entity{PK: kv.EncID(id)}); err != nil {
+			return err
+		}
+		return s.deleteStackJournal(ctx, tx, id)
+	})
+}
+
+// AddStackJournalEntry appends the entry to the journal of its stack,
+// removing the oldest entries beyond stackJournalMaxEntries.
+func (s *StoreKV) AddStackJournalEntry(ctx context.Context, entry StackJournalEntry) error {
+	prefix, err := entry.StackID.Encode()
+	if err != nil {
+		return influxErr(errors.EInvalid, err)
+	}
+
+	b, err := json.Marshal(entStackJournalEntry{
+		Action:  entry.Action,
+		UserID:  entry.UserID.String(),
+		Sources: entry.Sources,
+		Impact:  entry.Impact,
+		Error:   entry.Error,
+		Time:    entry.Time,
+	})
+	if err != nil {
+		return influxErr(errors.EInternal, err)
+	}
+
+	return s.kvStore.Update(ctx, func(tx kv.Tx) error {
+		bkt, err := tx.Bucket(stackJournalBucket)
+		if err != nil {
+			return err
+		}
+
+		// entries are keyed by stack and time, entries recorded within the
+		// same nanosecond are ordered by insertion
+		ts := entry.Time.UnixNano()
+		for {
+			key := make([]byte, len(prefix)+8)
+			copy(key, prefix)
+			binary.BigEndian.PutUint64(key[len(prefix):], uint64(ts))
+
+			_, err := bkt.Get(key)
+			if kv.IsNotFound(err) {
+				if err := bkt.Put(key, b); err != nil {
+					return err
+				}
+				return s.trimStackJournal(tx, prefix, stackJournalMaxEntries)
+			}
+			if err != nil {
+				return err
+			}
+			ts++
+		}
+	})
+}
+
+// trimStackJournal removes the oldest entries of the journal of the stack
+// with the encoded ID prefix, keeping max entries.
+func (s *StoreKV) trimStackJournal(tx kv.Tx, prefix []byte, max int) error {
+	keys, err := stackJournalKeys(tx, prefix)
 	if err != nil {
 		return err
 	}
+	if len(keys) <= max {
+		return nil
+	}
 
-	if stack.OrgID != existing.OrgID {
-		return &errors.Error{
-			Code: errors.EUnprocessableEntity,
-			Msg:  "org id does not match",
+	bkt, err := tx.Bucket(stackJournalBucket)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys[:len(keys)-max] {
+		if err := bkt.Delete(k); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return s.put(ctx, stack, kv.PutUpdate())
+// stackJournalKeys returns the keys of the journal of the stack with the
+// encoded ID prefix, oldest first.
+func stackJournalKeys(tx kv.Tx, prefix []byte) ([][]byte, error) {
+	bkt, err := tx.Bucket(stackJournalBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := bkt.ForwardCursor(prefix, kv.WithCursorPrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+
+	var keys [][]byte
+	for k, _ := cur.Next(); k != nil; k, _ = cur.Next() {
+		keys = append(keys, k)
+	}
+	return keys, cur.Err()
 }
 
-// DeleteStack deletes a stack by id.
-func (s *StoreKV) DeleteStack(ctx context.Context, id platform.ID) error {
-	return s.kvStore.Update(ctx, func(tx kv.Tx) error {
-		return s.indexBase.DeleteEnt(ctx, tx, kv.Entity{PK: kv.EncID(id)})
+// ListStackJournal returns the journal of the stack, oldest entry first.
+func (s *StoreKV) ListStackJournal(ctx context.Context, stackID platform.ID) ([]StackJournalEntry, error) {
+	prefix, err := stackID.Encode()
+	if err != nil {
+		return nil, influxErr(errors.EInvalid, err)
+	}
+
+	entries := []StackJournalEntry{}
+	err = s.view(ctx, func(tx kv.Tx) error {
+		bkt, err := tx.Bucket(stackJournalBucket)
+		if err != nil {
+			return err
+		}
+
+		cur, err := bkt.ForwardCursor(prefix, kv.WithCursorPrefix(prefix))
+		if err != nil {
+			return err
+		}
+		defer cur.Close()
+
+		for k, v := cur.Next(); k != nil; k, v = cur.Next() {
+			var ent entStackJournalEntry
+			if err := json.Unmarshal(v, &ent); err != nil {
+				return err
+			}
+			entry := StackJournalEntry{
+				StackID: stackID,
+				Action:  ent.Action,
+				Sources: ent.Sources,
+				Impact:  ent.Impact,
+				Error:   ent.Error,
+				Time:    ent.Time,
+			}
+			if ent.UserID != "" {
+				if err := entry.UserID.DecodeFromString(ent.UserID); err != nil {
+					return err
+				}
+			}
+			entries = append(entries, entry)
+		}
+		return cur.Err()
 	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *StoreKV) deleteStackJournal(ctx context.Context, tx kv.Tx, stackID platform.ID) error {
+	prefix, err := stackID.Encode()
+	if err != nil {
+		return err
+	}
+	return s.trimStackJournal(tx, prefix, 0)
 }
 
 func (s *StoreKV) put(ctx context.Context, stack Stack, opts ...kv.PutOptionFn) error {
@@ -306,14 +508,19 @@ func convertStackToEnt(stack Stack) (kv.Entity, error) {
 				Associations: associations,
 			})
 		}
+		var sourceVersions []entStackSourceVersion
+		for _, v := range ev.SourceVersions {
+			sourceVersions = append(sourceVersions, entStackSourceVersion(v))
+		}
 		stEnt.Events = append(stEnt.Events, entStackEvent{
-			EventType:   ev.EventType,
-			Name:        ev.Name,
-			Description: ev.Description,
-			Sources:     ev.Sources,
-			URLs:        ev.TemplateURLs,
-			Resources:   resources,
-			UpdatedAt:   ev.UpdatedAt,
+			EventType:      ev.EventType,
+			Name:           ev.Name,
+			Description:    ev.Description,
+			Sources:        ev.Sources,
+			SourceVersions: sourceVersions,
+			URLs:           ev.TemplateURLs,
+			Resources:      resources,
+			UpdatedAt:      ev.UpdatedAt,
 		})
 	}
 
@@ -365,6 +572,9 @@ func convertEntStackEvent(ent entStackEvent) (StackEvent, error) {
 		TemplateURLs: ent.URLs,
 		UpdatedAt:    ent.UpdatedAt,
 	}
+	for _, v := range ent.SourceVersions {
+		ev.SourceVersions = append(ev.SourceVersions, StackSourceVersion(v))
+	}
 	out, err := convertStackEntResources(ent.Resources)
 	if err != nil {
 		return StackEvent{}, err
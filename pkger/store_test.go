@@ -331,6 +331,25 @@ func TestStoreKV(t *testing.T) {
 			require.Error(t, err)
 			assert.Equalf(t, errors.EUnprocessableEntity, errors.ErrorCode(err), "err: %s", err)
 		})
+
+		t.Run("if unchanged updates only the version it was made against", func(t *testing.T) {
+			current, err := storeKV.ReadStackByID(context.Background(), id)
+			require.NoError(t, err)
+			version := current.LatestEvent().UpdatedAt
+
+			event := current.LatestEvent()
+			event.UpdatedAt = version.Add(time.Hour)
+			current.Events = append(current.Events, event)
+
+			err = storeKV.UpdateStackIfUnchanged(context.Background(), current, version)
+			require.NoError(t, err)
+			readStackEqual(t, storeKV, current)
+
+			// the stack is no longer at version
+			err = storeKV.UpdateStackIfUnchanged(context.Background(), current, version)
+			require.Error(t, err)
+			assert.Equalf(t, errors.EPreconditionFailed, errors.ErrorCode(err), "err: %s", err)
+		})
 	})
 
 	t.Run("delete a stack", func(t *testing.T) {
@@ -359,6 +378,91 @@ func TestStoreKV(t *testing.T) {
 			errCodeEqual(t, errors.ENotFound, err)
 		})
 	})
+
+	t.Run("stack journal", func(t *testing.T) {
+		defer inMemStore.Flush(context.Background())
+
+		storeKV := pkger.NewStoreKV(inMemStore)
+
+		const orgID = 3
+		stack, other := stackStub(1, orgID), stackStub(2, orgID)
+		seedEntities(t, storeKV, stack, other)
+
+		now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+		entries := []pkger.StackJournalEntry{
+			{
+				StackID: stack.ID,
+				Action:  pkger.StackJournalActionDryRun,
+				UserID:  9,
+				Sources: []string{"http://example.com/t.yml"},
+				Impact:  pkger.StackJournalImpact{Created: 2},
+				Time:    now,
+			},
+			{
+				StackID: stack.ID,
+				Action:  pkger.StackJournalActionApply,
+				UserID:  9,
+				Sources: []string{"http://example.com/t.yml"},
+				Impact:  pkger.StackJournalImpact{Created: 1},
+				Error:   "failed to create bucket",
+				Time:    now,
+			},
+			{
+				StackID: stack.ID,
+				Action:  pkger.StackJournalActionUninstall,
+				UserID:  10,
+				Impact:  pkger.StackJournalImpact{Removed: 1},
+				Time:    now.Add(time.Minute),
+			},
+		}
+		for _, e := range entries {
+			require.NoError(t, storeKV.AddStackJournalEntry(context.Background(), e))
+		}
+		require.NoError(t, storeKV.AddStackJournalEntry(context.Background(), pkger.StackJournalEntry{
+			StackID: other.ID,
+			Action:  pkger.StackJournalActionApply,
+			Time:    now,
+		}))
+
+		journal, err := storeKV.ListStackJournal(context.Background(), stack.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entries, journal, "entries recorded at the same time are kept in order")
+
+		require.NoError(t, storeKV.DeleteStack(context.Background(), stack.ID))
+		journal, err = storeKV.ListStackJournal(context.Background(), stack.ID)
+		require.NoError(t, err)
+		assert.Empty(t, journal)
+
+		journal, err = storeKV.ListStackJournal(context.Background(), other.ID)
+		require.NoError(t, err)
+		require.Len(t, journal, 1)
+		assert.Zero(t, journal[0].UserID, "entries recorded without a user are read")
+	})
+
+	t.Run("stack journal is capped", func(t *testing.T) {
+		defer inMemStore.Flush(context.Background())
+
+		storeKV := pkger.NewStoreKV(inMemStore)
+
+		stack := stackStub(1, 3)
+		seedEntities(t, storeKV, stack)
+
+		// the journal keeps the last 500 entries
+		now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 510; i++ {
+			require.NoError(t, storeKV.AddStackJournalEntry(context.Background(), pkger.StackJournalEntry{
+				StackID: stack.ID,
+				Action:  pkger.StackJournalActionApply,
+				Time:    now.Add(time.Duration(i) * time.Second),
+			}))
+		}
+
+		journal, err := storeKV.ListStackJournal(context.Background(), stack.ID)
+		require.NoError(t, err)
+		require.Len(t, journal, 500)
+		assert.Equal(t, now.Add(10*time.Second), journal[0].Time)
+		assert.Equal(t, now.Add(509*time.Second), journal[499].Time)
+	})
 }
 
 func readStackEqual(t *testing.T, store pkger.Store, expected pkger.Stack) {
@@ -1,6 +1,7 @@
 package pprof
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2/kit/memstat"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	ihttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 )
@@ -17,7 +19,9 @@ type Handler struct {
 	chi.Router
 }
 
-func NewHTTPHandler(profilingEnabled bool) *Handler {
+// NewHTTPHandler constructs the debug handler. The memory reporters attribute
+// process memory to components on the /memory route.
+func NewHTTPHandler(profilingEnabled bool, memReporters ...memstat.Reporter) *Handler {
 	r := chi.NewRouter()
 	r.Route("/pprof", func(r chi.Router) {
 		if !profilingEnabled {
@@ -31,6 +35,13 @@ func NewHTTPHandler(profilingEnabled bool) *Handler {
 		r.Get("/all", archiveProfilesHandler)
 		r.Mount("/", http.HandlerFunc(httppprof.Index))
 	})
+	r.Route("/memory", func(r chi.Router) {
+		if !profilingEnabled {
+			r.NotFound(profilingDisabledHandler)
+			return
+		}
+		r.Get("/", memoryAttributionHandler(memReporters))
+	})
 
 	return &Handler{r}
 }
@@ -39,6 +50,18 @@ func profilingDisabledHandler(w http.ResponseWriter, r *http.Request) {
 	ihttp.WriteErrorResponse(r.Context(), w, errors.EForbidden, "profiling disabled")
 }
 
+func memoryAttributionHandler(reporters []memstat.Reporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := memstat.Collect(reporters...)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			ihttp.WriteErrorResponse(r.Context(), w, errors.EInternal, err.Error())
+		}
+	}
+}
+
 func archiveProfilesHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -2,7 +2,9 @@ package predicate
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"unicode"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
@@ -25,6 +27,33 @@ type parser struct {
 	n         int // buffer size
 	openParen int
 	buf       buffer
+
+	// lines of the statement, used to look ahead of the scanner
+	lines [][]rune
+}
+
+func newParser(sts string) *parser {
+	p := &parser{sc: influxql.NewScanner(strings.NewReader(sts))}
+	for _, line := range strings.Split(strings.ReplaceAll(sts, "\r\n", "\n"), "\n") {
+		p.lines = append(p.lines, []rune(line))
+	}
+	return p
+}
+
+// runeAt returns the rune of the statement at pos, a newline at the end
+// of a line and 0 past the end of the statement.
+func (p *parser) runeAt(pos influxql.Pos) rune {
+	if pos.Line >= len(p.lines) {
+		return 0
+	}
+	line := p.lines[pos.Line]
+	if pos.Char < len(line) {
+		return line[pos.Char]
+	}
+	if pos.Line < len(p.lines)-1 {
+		return '\n'
+	}
+	return 0
 }
 
 // scan returns the next token from the underlying scanner.
@@ -68,9 +97,7 @@ func Parse(sts string) (n Node, err error) {
 	if sts == "" {
 		return nil, nil
 	}
-	p := new(parser)
-	p.sc = influxql.NewScanner(strings.NewReader(sts))
-	return p.parseLogicalNode()
+	return newParser(sts).parseLogicalNode()
 }
 
 func (p *parser) parseLogicalNode() (Node, error) {
@@ -179,12 +206,11 @@ func (p *parser) parseTagRuleNode() (TagRuleNode, error) {
 		n.Operator = influxdb.NotEqual
 		goto scanRegularTagValue
 	case influxql.EQREGEX:
-		fallthrough
+		n.Operator = influxdb.RegexEqual
+		return p.parseRegexTagValue(*n, pos)
 	case influxql.NEQREGEX:
-		return *n, &errors.Error{
-			Code: errors.EInvalid,
-			Msg:  fmt.Sprintf("operator: %q at position: %d is not supported yet", tok.String(), pos.Char),
-		}
+		n.Operator = influxdb.NotRegexEqual
+		return p.parseRegexTagValue(*n, pos)
 	default:
 		return *n, &errors.Error{
 			Code: errors.EInvalid,
@@ -221,6 +247,32 @@ scanRegularTagValue:
 	}
 }
 
+// parseRegexTagValue scans the regular expression, such as /^web-\d+$/,
+// following the regex operator at pos.
+func (p *parser) parseRegexTagValue(n TagRuleNode, pos influxql.Pos) (TagRuleNode, error) {
+	// The scanner scans a / outside of a regex as a division, so the
+	// whitespace before the regex is skipped by looking ahead of it.
+	if unicode.IsSpace(p.runeAt(influxql.Pos{Line: pos.Line, Char: pos.Char + 2})) {
+		p.scan()
+	}
+	tok, _, lit := p.sc.ScanRegex()
+	if tok != influxql.REGEX {
+		return n, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("bad regex tag value, after position %d", pos.Char),
+		}
+	}
+	if _, err := regexp.Compile(lit); err != nil {
+		return n, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("bad regex tag value %q, after position %d", lit, pos.Char),
+			Err:  err,
+		}
+	}
+	n.Value = lit
+	return n, nil
+}
+
 // peekRune returns the next rune that would be read by the scanner.
 func (p *parser) peekTok() influxql.Token {
 	tok, _, _ := p.scanIgnoreWhitespace()
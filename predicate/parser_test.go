@@ -1,14 +1,12 @@
 package predicate
 
 import (
-	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	influxtesting "github.com/influxdata/influxdb/v2/testing"
-	"github.com/influxdata/influxql"
 )
 
 func TestParseNode(t *testing.T) {
@@ -64,6 +62,16 @@ func TestParseNode(t *testing.T) {
 				}},
 			}},
 		},
+		{
+			str: `_measurement="cpu" and host=~/^web-\d+$/ and region!~ /west/`,
+			node: LogicalNode{Operator: LogicalAnd, Children: [2]Node{
+				LogicalNode{Operator: LogicalAnd, Children: [2]Node{
+					TagRuleNode{Tag: influxdb.Tag{Key: "_measurement", Value: "cpu"}},
+					TagRuleNode{Tag: influxdb.Tag{Key: "host", Value: `^web-\d+$`}, Operator: influxdb.RegexEqual},
+				}},
+				TagRuleNode{Tag: influxdb.Tag{Key: "region", Value: "west"}, Operator: influxdb.NotRegexEqual},
+			}},
+		},
 		{
 			str: ` (t1="v1" and t2="v2") and (`,
 			err: &errors.Error{
@@ -133,17 +141,33 @@ func TestParseTagRule(t *testing.T) {
 			node: TagRuleNode{Tag: influxdb.Tag{Key: "abc", Value: "false"}, Operator: influxdb.Equal},
 		},
 		{
-			str: `abc!~/^payments\./`,
+			str:  `abc!~/^payments\./`,
+			node: TagRuleNode{Tag: influxdb.Tag{Key: "abc", Value: `^payments\.`}, Operator: influxdb.NotRegexEqual},
+		},
+		{
+			str:  `abc=~/^payments\./`,
+			node: TagRuleNode{Tag: influxdb.Tag{Key: "abc", Value: `^payments\.`}, Operator: influxdb.RegexEqual},
+		},
+		{
+			str:  `host =~ /^web-\d+$/`,
+			node: TagRuleNode{Tag: influxdb.Tag{Key: "host", Value: `^web-\d+$`}, Operator: influxdb.RegexEqual},
+		},
+		{
+			str:  `path=~/^\/var\/log/`,
+			node: TagRuleNode{Tag: influxdb.Tag{Key: "path", Value: `^/var/log`}, Operator: influxdb.RegexEqual},
+		},
+		{
+			str: `abc=~"web"`,
 			err: &errors.Error{
 				Code: errors.EInvalid,
-				Msg:  `operator: "!~" at position: 3 is not supported yet`,
+				Msg:  `bad regex tag value, after position 3`,
 			},
 		},
 		{
-			str: `abc=~/^payments\./`,
+			str: `abc=~/web(/`,
 			err: &errors.Error{
 				Code: errors.EInvalid,
-				Msg:  `operator: "=~" at position: 3 is not supported yet`,
+				Msg:  `bad regex tag value "web(", after position 3`,
 			},
 		},
 		{
@@ -155,9 +179,7 @@ func TestParseTagRule(t *testing.T) {
 		},
 	}
 	for _, c := range cases {
-		p := new(parser)
-		p.sc = influxql.NewScanner(strings.NewReader(c.str))
-		tr, err := p.parseTagRuleNode()
+		tr, err := newParser(c.str).parseTagRuleNode()
 		influxtesting.ErrorsEqual(t, err, c.err)
 		if c.err == nil {
 			if diff := cmp.Diff(tr, c.node); diff != "" {
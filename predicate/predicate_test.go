@@ -177,6 +177,58 @@ func TestDataTypeConversion(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "regex tag rule",
+			node: &TagRuleNode{
+				Operator: influxdb.RegexEqual,
+				Tag: influxdb.Tag{
+					Key:   "k1",
+					Value: "^v\\d+$",
+				},
+			},
+			dataType: &datatypes.Node{
+				NodeType: datatypes.Node_TypeComparisonExpression,
+				Value:    &datatypes.Node_Comparison_{Comparison: datatypes.Node_ComparisonRegex},
+				Children: []*datatypes.Node{
+					{
+						NodeType: datatypes.Node_TypeTagRef,
+						Value:    &datatypes.Node_TagRefValue{TagRefValue: "k1"},
+					},
+					{
+						NodeType: datatypes.Node_TypeLiteral,
+						Value: &datatypes.Node_RegexValue{
+							RegexValue: "^v\\d+$",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "not regex tag rule",
+			node: &TagRuleNode{
+				Operator: influxdb.NotRegexEqual,
+				Tag: influxdb.Tag{
+					Key:   "k1",
+					Value: "v1",
+				},
+			},
+			dataType: &datatypes.Node{
+				NodeType: datatypes.Node_TypeComparisonExpression,
+				Value:    &datatypes.Node_Comparison_{Comparison: datatypes.Node_ComparisonNotRegex},
+				Children: []*datatypes.Node{
+					{
+						NodeType: datatypes.Node_TypeTagRef,
+						Value:    &datatypes.Node_TagRefValue{TagRefValue: "k1"},
+					},
+					{
+						NodeType: datatypes.Node_TypeLiteral,
+						Value: &datatypes.Node_RegexValue{
+							RegexValue: "v1",
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "logical",
 			node: &LogicalNode{
@@ -355,3 +407,32 @@ func TestDataTypeConversion(t *testing.T) {
 		}
 	}
 }
+
+func TestRegexPredicate(t *testing.T) {
+	node, err := Parse(`_measurement="cpu" and host=~/^web-\d+$/`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pred, err := New(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]bool{
+		"cpu,host=web-1":  true,
+		"cpu,host=web-12": true,
+		"cpu,host=web-a":  false,
+		"cpu,host=db-1":   false,
+		"mem,host=web-1":  false,
+		"cpu,region=west": false,
+	} {
+		// series keys are matched with the measurement as a tag, like the
+		// delete of the storage engine matches them
+		name := models.ParseName([]byte(key))
+		tags := append(models.Tags{{Key: models.MeasurementTagKeyBytes, Value: name}}, models.ParseTags([]byte(key))...)
+		series := models.MakeKey(name, tags)
+		if got := pred.Matches(series); got != want {
+			t.Errorf("%s: got %v, want %v", key, got, want)
+		}
+	}
+}
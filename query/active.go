@@ -0,0 +1,61 @@
+package query
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// ActiveQuery describes a query that is compiling, queued or executing.
+type ActiveQuery struct {
+	// ID is the ephemeral ID the query controller gave the query. IDs are
+	// not reused until the server restarts.
+	ID             uint64      `json:"id"`
+	OrganizationID platform.ID `json:"orgID"`
+	// Source is the client that sent the query, such as its user agent.
+	Source    string    `json:"source,omitempty"`
+	State     string    `json:"state"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// ActiveQueryService lists and cancels the queries in flight.
+type ActiveQueryService interface {
+	// ActiveQueries returns the queries that have not finished.
+	ActiveQueries() []ActiveQuery
+
+	// CancelQuery stops the execution of a query. It reports false when
+	// no query with the ID is in flight.
+	CancelQuery(id uint64) bool
+}
+
+// QueryIDRecorder records the ID of the query executed with a context, so
+// that it can be reported to the client before the query finishes. It is
+// safe for concurrent use.
+type QueryIDRecorder struct {
+	id uint64
+}
+
+var queryIDRecorderContextKey = struct{ name string }{"query id recorder"}
+
+// ContextWithQueryIDRecorder returns a new context recording the ID of the
+// query executed with it.
+func ContextWithQueryIDRecorder(ctx context.Context) (context.Context, *QueryIDRecorder) {
+	r := &QueryIDRecorder{}
+	return context.WithValue(ctx, queryIDRecorderContextKey, r), r
+}
+
+// RecordQueryID records the ID of a query started with the context. It does
+// nothing if the context does not record query IDs.
+func RecordQueryID(ctx context.Context, id uint64) {
+	if r, ok := ctx.Value(queryIDRecorderContextKey).(*QueryIDRecorder); ok {
+		atomic.StoreUint64(&r.id, id)
+	}
+}
+
+// ID returns the ID recorded, if any.
+func (r *QueryIDRecorder) ID() (uint64, bool) {
+	id := atomic.LoadUint64(&r.id)
+	return id, id != 0
+}
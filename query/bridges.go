@@ -7,6 +7,7 @@ import (
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/metadata"
 	platform "github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/check"
 	platform2 "github.com/influxdata/influxdb/v2/kit/platform"
@@ -142,11 +143,16 @@ func (b ProxyQueryServiceAsyncBridge) Query(ctx context.Context, w io.Writer, re
 	results := flux.NewResultIteratorFromQuery(q)
 	defer results.Release()
 
+	var rows int64
 	encoder := req.Dialect.Encoder()
-	_, err = encoder.Encode(w, results)
+	_, err = encoder.Encode(w, rowCountingResultIterator{ResultIterator: results, rows: &rows})
 	// Release the results and collect the statistics regardless of the error.
 	results.Release()
 	stats := results.Statistics()
+	if stats.Metadata == nil {
+		stats.Metadata = make(metadata.Metadata)
+	}
+	stats.Metadata.Add(RowsMetadataKey, rows)
 	if err != nil {
 		return stats, tracing.LogError(span, err)
 	}
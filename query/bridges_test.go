@@ -66,3 +66,52 @@ func TestProxyQueryServiceAsyncBridge_StatsOnClientDisconnect(t *testing.T) {
 		t.Fatalf("stats were missing or had wrong metadata: exp metadata[foo]=[bar], got %v", md)
 	}
 }
+
+func TestProxyQueryServiceAsyncBridge_Rows(t *testing.T) {
+	q := mock.NewQuery()
+	r := executetest.NewResult([]*executetest.Table{
+		{
+			KeyCols: []string{"t"},
+			ColMeta: []flux.ColMeta{
+				{Label: "t", Type: flux.TString},
+				{Label: "_value", Type: flux.TInt},
+			},
+			Data: [][]interface{}{
+				{"a", int64(1)},
+				{"a", int64(2)},
+			},
+		},
+		{
+			KeyCols: []string{"t"},
+			ColMeta: []flux.ColMeta{
+				{Label: "t", Type: flux.TString},
+				{Label: "_value", Type: flux.TInt},
+			},
+			Data: [][]interface{}{
+				{"b", int64(3)},
+			},
+		},
+	})
+	r.Nm = "_result"
+	q.SetResults(r)
+
+	bridge := query.ProxyQueryServiceAsyncBridge{
+		AsyncQueryService: &mock.AsyncQueryService{
+			QueryF: func(ctx context.Context, req *query.Request) (flux.Query, error) {
+				return q, nil
+			},
+		},
+	}
+	var w strings.Builder
+	stats, err := bridge.Query(context.Background(), &w, &query.ProxyRequest{
+		Request: query.Request{OrganizationID: 0x1234},
+		Dialect: csv.DefaultDialect(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := stats.Metadata[query.RowsMetadataKey]; len(got) != 1 || got[0] != int64(3) {
+		t.Fatalf("unexpected rows metadata: exp [3], got %v", got)
+	}
+}
@@ -0,0 +1,228 @@
+// Package calendar provides server configured business calendars to Flux.
+//
+// A calendar describes the working days, working hours and holidays of an
+// organization. Every Flux query run for the organization has access to a
+// calendar record, so that checks and tasks can gate on business time:
+//
+//	from(bucket: "alerts")
+//	    |> range(start: -5m)
+//	    |> filter(fn: (r) => calendar.isBusinessHour(t: r._time))
+package calendar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	clockLayout = "15:04"
+	dateLayout  = "2006-01-02"
+)
+
+// Calendar describes the business time of an organization.
+type Calendar struct {
+	// Location is the IANA time zone the calendar is expressed in.
+	// Defaults to UTC.
+	Location string `json:"location" yaml:"location"`
+	// WorkingDays are the lower case names of the working week days.
+	// Defaults to monday through friday.
+	WorkingDays []string `json:"workingDays" yaml:"workingDays"`
+	// Start and End bound the working hours of a working day, formatted as 15:04.
+	// Defaults to 09:00 and 17:00.
+	Start string `json:"start" yaml:"start"`
+	End   string `json:"end" yaml:"end"`
+	// Holidays are the non working dates, formatted as 2006-01-02.
+	Holidays []string `json:"holidays" yaml:"holidays"`
+}
+
+// Config holds the calendars of the server. The default calendar applies
+// to every organization without a calendar of its own.
+type Config struct {
+	Default *Calendar           `json:"default" yaml:"default"`
+	Orgs    map[string]Calendar `json:"orgs" yaml:"orgs"`
+
+	orgs map[platform.ID]*compiled
+	def  *compiled
+}
+
+// Load reads the calendar configuration from a JSON or YAML file.
+func Load(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(b, &c)
+	default:
+		err = json.Unmarshal(b, &c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode calendar config %s: %w", path, err)
+	}
+
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// New constructs a Config from calendars keyed by organization.
+func New(def *Calendar, orgs map[platform.ID]Calendar) (*Config, error) {
+	c := Config{
+		Default: def,
+		Orgs:    make(map[string]Calendar, len(orgs)),
+	}
+	for id, cal := range orgs {
+		c.Orgs[id.String()] = cal
+	}
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (c *Config) init() error {
+	if c.Default != nil {
+		def, err := c.Default.compile()
+		if err != nil {
+			return fmt.Errorf("invalid default calendar: %w", err)
+		}
+		c.def = def
+	}
+
+	c.orgs = make(map[platform.ID]*compiled, len(c.Orgs))
+	for k, cal := range c.Orgs {
+		id, err := platform.IDFromString(k)
+		if err != nil {
+			return fmt.Errorf("invalid organization id %q for calendar: %w", k, err)
+		}
+		compiled, err := cal.compile()
+		if err != nil {
+			return fmt.Errorf("invalid calendar for organization %s: %w", k, err)
+		}
+		c.orgs[*id] = compiled
+	}
+	return nil
+}
+
+// IsBusinessHour reports whether t falls within the business hours of the
+// calendar of the organization. It returns false when no calendar applies.
+func (c *Config) IsBusinessHour(orgID platform.ID, t time.Time) bool {
+	cal := c.lookup(orgID)
+	return cal != nil && cal.isBusinessHour(t)
+}
+
+func (c *Config) lookup(orgID platform.ID) *compiled {
+	if c == nil {
+		return nil
+	}
+	if cal, ok := c.orgs[orgID]; ok {
+		return cal
+	}
+	return c.def
+}
+
+// compiled is a validated Calendar.
+type compiled struct {
+	loc      *time.Location
+	days     [7]bool
+	start    int // minutes into the day
+	end      int
+	holidays []time.Time // local midnight of each holiday
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func (c Calendar) compile() (*compiled, error) {
+	out := &compiled{loc: time.UTC}
+
+	if c.Location != "" {
+		loc, err := time.LoadLocation(c.Location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid location: %w", err)
+		}
+		out.loc = loc
+	}
+
+	days := c.WorkingDays
+	if len(days) == 0 {
+		days = []string{"monday", "tuesday", "wednesday", "thursday", "friday"}
+	}
+	for _, d := range days {
+		wd, ok := weekdays[strings.ToLower(d)]
+		if !ok {
+			return nil, fmt.Errorf("invalid working day %q", d)
+		}
+		out.days[wd] = true
+	}
+
+	var err error
+	if out.start, err = parseClock(c.Start, "09:00"); err != nil {
+		return nil, fmt.Errorf("invalid start: %w", err)
+	}
+	if out.end, err = parseClock(c.End, "17:00"); err != nil {
+		return nil, fmt.Errorf("invalid end: %w", err)
+	}
+	if out.start >= out.end {
+		return nil, fmt.Errorf("start %s must be before end %s", c.Start, c.End)
+	}
+
+	for _, h := range c.Holidays {
+		d, err := time.ParseInLocation(dateLayout, h, out.loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday %q: must be formatted as %s", h, dateLayout)
+		}
+		out.holidays = append(out.holidays, d)
+	}
+	return out, nil
+}
+
+func parseClock(s, def string) (int, error) {
+	if s == "" {
+		s = def
+	}
+	t, err := time.Parse(clockLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("%q must be formatted as %s", s, clockLayout)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (c *compiled) isHoliday(t time.Time) bool {
+	t = t.In(c.loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+	for _, h := range c.holidays {
+		if h.Equal(day) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compiled) isBusinessDay(t time.Time) bool {
+	return c.days[t.In(c.loc).Weekday()] && !c.isHoliday(t)
+}
+
+func (c *compiled) isBusinessHour(t time.Time) bool {
+	local := t.In(c.loc)
+	minute := local.Hour()*60 + local.Minute()
+	return c.isBusinessDay(t) && minute >= c.start && minute < c.end
+}
@@ -0,0 +1,166 @@
+package calendar_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/runtime"
+	_ "github.com/influxdata/influxdb/v2/fluxinit/static"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/query/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_IsBusinessHour(t *testing.T) {
+	orgID := platform.ID(1)
+	otherOrgID := platform.ID(2)
+
+	cfg, err := calendar.New(&calendar.Calendar{}, map[platform.ID]calendar.Calendar{
+		orgID: {
+			Location:    "America/New_York",
+			WorkingDays: []string{"monday", "tuesday", "wednesday", "thursday"},
+			Start:       "08:30",
+			End:         "16:00",
+			Holidays:    []string{"2021-11-25"},
+		},
+	})
+	require.NoError(t, err)
+
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		orgID    platform.ID
+		t        time.Time
+		expected bool
+	}{
+		{
+			name:     "within working hours in local time",
+			orgID:    orgID,
+			t:        time.Date(2021, 11, 23, 8, 30, 0, 0, ny),
+			expected: true,
+		},
+		{
+			name:     "before start",
+			orgID:    orgID,
+			t:        time.Date(2021, 11, 23, 8, 29, 0, 0, ny),
+			expected: false,
+		},
+		{
+			name:     "end is exclusive",
+			orgID:    orgID,
+			t:        time.Date(2021, 11, 23, 16, 0, 0, 0, ny),
+			expected: false,
+		},
+		{
+			name:     "holiday",
+			orgID:    orgID,
+			t:        time.Date(2021, 11, 25, 10, 0, 0, 0, ny),
+			expected: false,
+		},
+		{
+			name:     "non working day",
+			orgID:    orgID,
+			t:        time.Date(2021, 11, 26, 10, 0, 0, 0, ny),
+			expected: false,
+		},
+		{
+			name:     "default calendar applies to other orgs",
+			orgID:    otherOrgID,
+			t:        time.Date(2021, 11, 26, 10, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		fn := func(t *testing.T) {
+			assert.Equal(t, tt.expected, cfg.IsBusinessHour(tt.orgID, tt.t))
+		}
+		t.Run(tt.name, fn)
+	}
+}
+
+func TestConfig_Extern(t *testing.T) {
+	cfg, err := calendar.New(nil, map[platform.ID]calendar.Calendar{
+		1: {Location: "Europe/Berlin", Holidays: []string{"2021-12-24"}},
+	})
+	require.NoError(t, err)
+
+	file, err := cfg.Extern(context.Background(), 1)
+	require.NoError(t, err)
+	require.NotNil(t, file)
+
+	// Evaluate the calendar record against times given in UTC, whose local
+	// date or time differs.
+	src := ast.Format(file) + `
+holiday = calendar.isHoliday(t: 2021-12-23T23:30:00Z)
+dayBefore = calendar.isHoliday(t: 2021-12-23T22:30:00Z)
+businessHour = calendar.isBusinessHour(t: 2021-12-22T08:30:00Z)
+afterHours = calendar.isBusinessHour(t: 2021-12-22T16:30:00Z)
+businessDay = calendar.isBusinessDay(t: 2021-12-24T10:00:00Z)
+`
+	_, scope, err := runtime.Eval(context.Background(), src)
+	require.NoError(t, err)
+
+	for name, expected := range map[string]bool{
+		"holiday":      true,
+		"dayBefore":    false,
+		"businessHour": true,
+		"afterHours":   false,
+		"businessDay":  false,
+	} {
+		v, ok := scope.Lookup(name)
+		require.True(t, ok, name)
+		assert.Equal(t, expected, v.Bool(), name)
+	}
+
+	file, err = cfg.Extern(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Nil(t, file, "org without calendar and no default should not get an extern")
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(t *testing.T, name, contents string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+		return path
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		path := write(t, "calendars.yml", strings.Join([]string{
+			"default:",
+			"  start: \"10:00\"",
+			"orgs:",
+			"  \"0000000000000001\":",
+			"    workingDays: [saturday, sunday]",
+		}, "\n"))
+
+		cfg, err := calendar.Load(path)
+		require.NoError(t, err)
+		assert.True(t, cfg.IsBusinessHour(1, time.Date(2021, 11, 27, 12, 0, 0, 0, time.UTC)))
+		assert.False(t, cfg.IsBusinessHour(2, time.Date(2021, 11, 26, 9, 30, 0, 0, time.UTC)))
+	})
+
+	t.Run("invalid calendars", func(t *testing.T) {
+		for name, contents := range map[string]string{
+			"day.json":      `{"default": {"workingDays": ["someday"]}}`,
+			"hours.json":    `{"default": {"start": "17:00", "end": "09:00"}}`,
+			"holiday.json":  `{"default": {"holidays": ["12/25/2021"]}}`,
+			"location.json": `{"default": {"location": "Nowhere/Atlantis"}}`,
+			"org.json":      `{"orgs": {"not-an-id": {}}}`,
+		} {
+			_, err := calendar.Load(write(t, name, contents))
+			assert.Error(t, err, name)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// Name is the identifier the calendar record is bound to in Flux.
+const Name = "calendar"
+
+// Extern returns the Flux statements that bind the calendar of the organization
+// to Name, or nil when no calendar applies to it. The record provides:
+//
+//	calendar.isHoliday(t=now())
+//	calendar.isBusinessDay(t=now())
+//	calendar.isBusinessHour(t=now())
+func (c *Config) Extern(ctx context.Context, orgID platform.ID) (*ast.File, error) {
+	cal := c.lookup(orgID)
+	if cal == nil {
+		return nil, nil
+	}
+
+	pkg := parser.ParseSource(cal.source())
+	if ast.Check(pkg) > 0 {
+		return nil, ast.GetError(pkg)
+	}
+	return pkg.Files[0], nil
+}
+
+// source renders the calendar as Flux. All date functions are evaluated in
+// the location of the calendar so that working hours and holidays follow
+// local time, including daylight saving transitions.
+func (c *compiled) source() string {
+	loc := fmt.Sprintf("timezone.location(name: %s)", strconv.Quote(c.loc.String()))
+
+	var days []string
+	for wd, ok := range c.days {
+		if ok {
+			days = append(days, strconv.Itoa(wd))
+		}
+	}
+
+	// Holidays are compared as the local date encoded as yyyymmdd, since
+	// date.truncate only truncates in UTC.
+	isHoliday := "false"
+	if len(c.holidays) > 0 {
+		holidays := make([]string, 0, len(c.holidays))
+		for _, h := range c.holidays {
+			holidays = append(holidays, h.In(c.loc).Format("20060102"))
+		}
+		isHoliday = fmt.Sprintf("contains(value: date.year(t: t, location: %s) * 10000 + date.month(t: t, location: %s) * 100 + date.monthDay(t: t, location: %s), set: [%s])",
+			loc, loc, loc, strings.Join(holidays, ", "))
+	}
+	isBusinessDay := fmt.Sprintf("contains(value: date.weekDay(t: t, location: %s), set: [%s]) and not %s",
+		loc, strings.Join(days, ", "), isHoliday)
+
+	var b strings.Builder
+	b.WriteString("import \"date\"\n")
+	b.WriteString("import \"timezone\"\n\n")
+	fmt.Fprintf(&b, "%s = {\n", Name)
+	fmt.Fprintf(&b, "    isHoliday: (t=now()) => %s,\n", isHoliday)
+	fmt.Fprintf(&b, "    isBusinessDay: (t=now()) => %s,\n", isBusinessDay)
+	fmt.Fprintf(&b, "    isBusinessHour: (t=now()) => {\n")
+	fmt.Fprintf(&b, "        minute = date.hour(t: t, location: %s) * 60 + date.minute(t: t, location: %s)\n", loc, loc)
+	fmt.Fprintf(&b, "        return %s and minute >= %d and minute < %d\n", isBusinessDay, c.start, c.end)
+	b.WriteString("    },\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -22,8 +22,10 @@ import (
 	"fmt"
 	"math"
 	"runtime/debug"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/codes"
@@ -32,7 +34,10 @@ import (
 	"github.com/influxdata/flux/lang"
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/influxdb/v2/kit/check"
 	"github.com/influxdata/influxdb/v2/kit/errors"
+	"github.com/influxdata/influxdb/v2/kit/memstat"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/prom"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
@@ -60,13 +65,15 @@ type Controller struct {
 	abortOnce  sync.Once
 	abort      chan struct{}
 	memory     *memoryManager
+	orgLimits  *orgLimiter
 
 	metrics   *controllerMetrics
 	labelKeys []string
 
 	log *zap.Logger
 
-	dependencies []flux.Dependency
+	dependencies   []flux.Dependency
+	externProvider ExternProvider
 
 	fluxLogEnabled bool
 }
@@ -111,6 +118,21 @@ type Config struct {
 	// this to follow suit.
 	QueueSize int32
 
+	// OrgConcurrencyQuota is the number of queries of an organization that
+	// are allowed to be queued or executing at once. Zero leaves the queries
+	// of organizations unlimited, bounded only by ConcurrencyQuota.
+	OrgConcurrencyQuota int32
+
+	// OrgQueueSize is the number of queries of an organization that are
+	// allowed to wait for one of its OrgConcurrencyQuota slots before new
+	// queries of the organization are rejected.
+	OrgQueueSize int32
+
+	// OrgQueueTimeout is how long a query waits for a slot of its
+	// organization before it is rejected. Zero waits until the query is
+	// canceled.
+	OrgQueueTimeout time.Duration
+
 	// MetricLabelKeys is a list of labels to add to the metrics produced by the controller.
 	// The value for a given key will be read off the context.
 	// The context value must be a string or an implementation of the Stringer interface.
@@ -118,6 +140,10 @@ type Config struct {
 
 	ExecutorDependencies []flux.Dependency
 
+	// ExternProvider supplies Flux statements, such as business calendars, that are
+	// made available to every Flux query of an organization. It may be nil.
+	ExternProvider ExternProvider
+
 	// FluxLogEnabled logs any in-progress queries that get cancelled due to the server being shut down.
 	FluxLogEnabled bool
 }
@@ -170,6 +196,15 @@ func (c *Config) validate() error {
 	if c.MaxMemoryBytes < 0 {
 		return errors.New("MaxMemoryBytes must be positive")
 	}
+	if c.OrgConcurrencyQuota < 0 {
+		return errors.New("OrgConcurrencyQuota must not be negative")
+	}
+	if c.OrgQueueSize < 0 {
+		return errors.New("OrgQueueSize must not be negative")
+	}
+	if c.OrgQueueTimeout < 0 {
+		return errors.New("OrgQueueTimeout must not be negative")
+	}
 	if c.MaxMemoryBytes != 0 {
 		if minMemory := int64(c.ConcurrencyQuota) * c.InitialMemoryBytesQuotaPerQuery; c.MaxMemoryBytes < minMemory {
 			return fmt.Errorf("MaxMemoryBytes must be greater than or equal to the ConcurrencyQuota * InitialMemoryBytesQuotaPerQuery: %d < %d (%d * %d)", c.MaxMemoryBytes, minMemory, c.ConcurrencyQuota, c.InitialMemoryBytesQuotaPerQuery)
@@ -194,7 +229,10 @@ func New(config Config, logger *zap.Logger) (*Controller, error) {
 		zap.Int64("initial_memory_bytes_quota_per_query", c.InitialMemoryBytesQuotaPerQuery),
 		zap.Int64("memory_bytes_quota_per_query", c.MemoryBytesQuotaPerQuery),
 		zap.Int64("max_memory_bytes", c.MaxMemoryBytes),
-		zap.Int32("queue_size", c.QueueSize))
+		zap.Int32("queue_size", c.QueueSize),
+		zap.Int32("org_concurrency_quota", c.OrgConcurrencyQuota),
+		zap.Int32("org_queue_size", c.OrgQueueSize),
+		zap.Duration("org_queue_timeout", c.OrgQueueTimeout))
 
 	mm := &memoryManager{
 		initialBytesQuotaPerQuery: c.InitialMemoryBytesQuotaPerQuery,
@@ -209,6 +247,7 @@ func New(config Config, logger *zap.Logger) (*Controller, error) {
 	if c.ConcurrencyQuota == 0 {
 		queryQueue = nil
 	}
+	metrics := newControllerMetrics(metricLabelKeys)
 	ctrl := &Controller{
 		config:         c,
 		queries:        make(map[QueryID]*Query),
@@ -217,9 +256,11 @@ func New(config Config, logger *zap.Logger) (*Controller, error) {
 		abort:          make(chan struct{}),
 		memory:         mm,
 		log:            logger,
-		metrics:        newControllerMetrics(metricLabelKeys),
+		metrics:        metrics,
+		orgLimits:      newOrgLimiter(c, metrics),
 		labelKeys:      metricLabelKeys,
 		dependencies:   c.ExecutorDependencies,
+		externProvider: c.ExternProvider,
 		fluxLogEnabled: config.FluxLogEnabled,
 	}
 	if c.ConcurrencyQuota != 0 {
@@ -244,9 +285,13 @@ func (c *Controller) Query(ctx context.Context, req *query.Request) (flux.Query,
 	ctx = query.ContextWithRequest(ctx, req)
 	// Set the org label value for controller metrics
 	ctx = context.WithValue(ctx, orgLabel, req.OrganizationID.String()) //lint:ignore SA1029 this is a temporary ignore until we have time to create an appropriate type
+	compiler, err := c.withExtern(ctx, req.OrganizationID, req.Compiler)
+	if err != nil {
+		return nil, handleFluxError(err)
+	}
 	// The controller injects the dependencies for each incoming request.
 	ctx, deps := dependency.Inject(ctx, c.dependencies...)
-	q, err := c.query(ctx, req.Compiler, deps)
+	q, err := c.query(ctx, compiler, deps)
 	if err != nil {
 		deps.Finish()
 		return q, err
@@ -303,7 +348,31 @@ func (c *Controller) createQuery(ctx context.Context, compiler flux.Compiler, de
 	}
 	compileLabelValues[len(compileLabelValues)-1] = string(compiler.CompilerType())
 
-	cctx, cancel := context.WithCancel(ctx)
+	var (
+		source, orgID  string
+		organizationID platform.ID
+	)
+	if req := query.RequestFromContext(ctx); req != nil {
+		source, orgID, organizationID = req.Source, req.OrganizationID.String(), req.OrganizationID
+	}
+
+	// Limits set on the request context bound the query's wall clock time
+	// and memory quota.
+	limits, _ := query.LimitsFromContext(ctx)
+	memoryQuota := c.memory.memoryBytesQuotaPerQuery
+	if limits.MaxMemoryBytes > 0 && limits.MaxMemoryBytes < memoryQuota {
+		memoryQuota = limits.MaxMemoryBytes
+	}
+
+	var (
+		cctx   context.Context
+		cancel context.CancelFunc
+	)
+	if limits.MaxWallTime > 0 {
+		cctx, cancel = context.WithTimeout(ctx, limits.MaxWallTime)
+	} else {
+		cctx, cancel = context.WithCancel(ctx)
+	}
 	parentSpan, parentCtx := tracing.StartSpanFromContextWithPromMetrics(
 		cctx,
 		"all",
@@ -323,6 +392,12 @@ func (c *Controller) createQuery(ctx context.Context, compiler flux.Compiler, de
 		doneCh:             make(chan struct{}),
 		deps:               deps,
 		compiler:           compiler,
+		source:             source,
+		orgID:              orgID,
+		organizationID:     organizationID,
+		createdAt:          time.Now(),
+		limits:             limits,
+		memoryQuota:        memoryQuota,
 	}
 
 	// Lock the queries mutex for the rest of this method.
@@ -340,6 +415,7 @@ func (c *Controller) createQuery(ctx context.Context, compiler flux.Compiler, de
 		return nil, err
 	}
 	c.queries[id] = q
+	query.RecordQueryID(ctx, uint64(id))
 	return q, nil
 }
 
@@ -405,6 +481,12 @@ func (c *Controller) enqueueQuery(q *Query) error {
 		}
 	}
 
+	// The query waits for a slot of its organization before it takes
+	// a place in the queue of the controller.
+	if err := c.orgLimits.acquire(q); err != nil {
+		return err
+	}
+
 	if c.queryQueue == nil {
 		// unlimited queries case
 		c.queriesMu.RLock()
@@ -503,6 +585,8 @@ func (c *Controller) waitForQuery(q *Query) {
 }
 
 func (c *Controller) finish(q *Query) {
+	c.orgLimits.release(q)
+
 	c.queriesMu.Lock()
 	delete(c.queries, q.id)
 	if len(c.queries) == 0 && c.shutdown {
@@ -522,6 +606,57 @@ func (c *Controller) Queries() []*Query {
 	return queries
 }
 
+// ActiveQueries reports the queries that have not finished, ordered by ID.
+func (c *Controller) ActiveQueries() []query.ActiveQuery {
+	queries := c.Queries()
+	active := make([]query.ActiveQuery, 0, len(queries))
+	for _, q := range queries {
+		active = append(active, query.ActiveQuery{
+			ID:             uint64(q.id),
+			OrganizationID: q.organizationID,
+			Source:         q.source,
+			State:          q.State().String(),
+			StartedAt:      q.createdAt,
+		})
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].ID < active[j].ID })
+	return active
+}
+
+// CancelQuery stops the execution of the query with the ID. The client
+// reading the results of the query receives a canceled error.
+func (c *Controller) CancelQuery(id uint64) bool {
+	c.queriesMu.RLock()
+	q, ok := c.queries[QueryID(id)]
+	c.queriesMu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	c.log.Info("Canceling query", zap.Uint64("query_id", id), zap.String("org_id", q.orgID))
+	q.Cancel()
+	return true
+}
+
+// Check reports whether the controller accepts queries, with the number of
+// queries active and waiting in the queue.
+func (c *Controller) Check(ctx context.Context) check.Response {
+	c.queriesMu.RLock()
+	defer c.queriesMu.RUnlock()
+	if c.shutdown {
+		return check.Response{
+			Name:    "query-controller",
+			Status:  check.StatusFail,
+			Message: "query controller shutdown",
+		}
+	}
+	return check.Response{
+		Name:    "query-controller",
+		Status:  check.StatusPass,
+		Message: fmt.Sprintf("%d active queries, %d queued", len(c.queries), len(c.queryQueue)),
+	}
+}
+
 // Shutdown will signal to the Controller that it should not accept any
 // new queries and that it should finish executing any existing queries.
 // This will return once the Controller's run loop has been exited and all
@@ -591,6 +726,34 @@ func (c *Controller) PrometheusCollectors() []prometheus.Collector {
 	return collectors
 }
 
+// MemoryUsage attributes the memory allocated by executing queries to the
+// organization they run for. Queries issued by the task executor are
+// reported separately.
+func (c *Controller) MemoryUsage() []memstat.Usage {
+	c.queriesMu.RLock()
+	defer c.queriesMu.RUnlock()
+
+	type key struct{ component, name string }
+	byKey := make(map[key]int64)
+	for _, q := range c.queries {
+		alloc := q.allocator()
+		if alloc == nil {
+			continue
+		}
+		k := key{component: memstat.ComponentQueryController, name: q.orgID}
+		if q.source == query.SourceTaskExecutor {
+			k.component = memstat.ComponentTaskExecutor
+		}
+		byKey[k] += alloc.Allocated()
+	}
+
+	usage := make([]memstat.Usage, 0, len(byKey))
+	for k, bytes := range byKey {
+		usage = append(usage, memstat.Usage{Component: k.component, Name: k.name, Bytes: bytes})
+	}
+	return usage
+}
+
 func (c *Controller) GetUnusedMemoryBytes() int64 {
 	return c.memory.getUnusedMemoryBytes()
 }
@@ -630,6 +793,19 @@ type Query struct {
 	memoryManager *queryMemoryManager
 	alloc         *memory.ResourceAllocator
 	deps          *dependency.Span
+
+	// source and orgID identify the origin of the query for memory attribution.
+	source         string
+	orgID          string
+	organizationID platform.ID
+	createdAt      time.Time
+	// orgSlot is whether the query holds a slot of its organization.
+	orgSlot bool
+
+	// limits are the limits set on the request context, memoryQuota is
+	// the memory the query may allocate once they are applied.
+	limits      query.Limits
+	memoryQuota int64
 }
 
 func (q *Query) ProfilerResults() (flux.ResultIterator, error) {
@@ -899,6 +1075,7 @@ func (q *Query) setErr(err error) {
 	case <-q.parentCtx.Done():
 		q.transitionTo(Canceled)
 		err = q.parentCtx.Err()
+		q.checkDeadline()
 	default:
 		q.transitionTo(Errored)
 	}
@@ -909,6 +1086,14 @@ func (q *Query) setErr(err error) {
 	close(q.results)
 }
 
+// checkDeadline records that the query exceeded its maximum wall clock time
+// when it was canceled by the deadline set from its limits.
+func (q *Query) checkDeadline() {
+	if q.limits.MaxWallTime > 0 && q.parentCtx.Err() == context.DeadlineExceeded {
+		query.ExceedLimit(q.parentCtx, fmt.Sprintf("query exceeded its maximum wall clock time of %s", q.limits.MaxWallTime))
+	}
+}
+
 func (q *Query) addRuntimeError(e error) {
 	q.stateMu.Lock()
 	defer q.stateMu.Unlock()
@@ -956,6 +1141,7 @@ func (q *Query) pump(exec flux.Query, done <-chan struct{}) {
 			// has been canceled. Usually, the signal on the context
 			// is likely enough, but this explicitly signals just in case.
 			exec.Cancel()
+			q.checkDeadline()
 
 			// Set the done channel to nil so we don't do this again
 			// and we continue to drain the results.
@@ -23,6 +23,8 @@ import (
 	"github.com/influxdata/flux/plan/plantest"
 	"github.com/influxdata/flux/stdlib/universe"
 	_ "github.com/influxdata/influxdb/v2/fluxinit/static"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/query"
 	"github.com/influxdata/influxdb/v2/query/control"
 	"github.com/prometheus/client_golang/prometheus"
@@ -527,6 +529,66 @@ func TestController_LimitExceededError(t *testing.T) {
 	}
 }
 
+func TestController_ContextLimits(t *testing.T) {
+	const maxMemoryBytes = 64
+	ctrl, err := control.New(config, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+
+	compiler := &mock.Compiler{
+		CompileFn: func(ctx context.Context) (flux.Program, error) {
+			// Return a program that will allocate one more byte than the limit allows.
+			pts := plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("allocating-from-test", &executetest.AllocatingFromProcedureSpec{
+						ByteCount: maxMemoryBytes + 1,
+					}),
+					plan.CreatePhysicalNode("yield", &universe.YieldProcedureSpec{Name: "_result"}),
+				},
+				Edges: [][2]int{
+					{0, 1},
+				},
+				Resources: flux.ResourceManagement{
+					ConcurrencyQuota: 1,
+				},
+			}
+
+			return &lang.Program{
+				Logger:   zaptest.NewLogger(t),
+				PlanSpec: plantest.CreatePlanSpec(&pts),
+			}, nil
+		},
+	}
+
+	ctx := query.ContextWithLimits(context.Background(), query.Limits{MaxMemoryBytes: maxMemoryBytes})
+	q, err := ctrl.Query(ctx, makeRequest(compiler))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ri := flux.NewResultIteratorFromQuery(q)
+	for ri.More() {
+		res := ri.Next()
+		if err = res.Tables().Do(func(t flux.Table) error { return nil }); err != nil {
+			break
+		}
+	}
+	ri.Release()
+
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	limit, ok := query.ExceededLimit(ctx)
+	if !ok {
+		t.Fatal("expected the memory limit to be recorded as exceeded")
+	}
+	if want := "query exceeded its memory limit of 64 bytes"; limit != want {
+		t.Fatalf("unexpected exceeded limit -want/+got\n\t- %q\n\t+ %q", want, limit)
+	}
+}
+
 func TestController_CompilePanic(t *testing.T) {
 	for name, config := range bothConfigs {
 		t.Run(name, func(t *testing.T) {
@@ -1501,6 +1563,137 @@ func TestController_OnFinish(t *testing.T) {
 	}
 }
 
+func TestController_CancelQuery(t *testing.T) {
+	ctrl, err := control.New(config, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+
+	executing := make(chan struct{})
+	compiler := &mock.Compiler{
+		CompileFn: func(ctx context.Context) (flux.Program, error) {
+			return &mock.Program{
+				ExecuteFn: func(ctx context.Context, q *mock.Query, alloc memory.Allocator) {
+					close(executing)
+					<-ctx.Done()
+					q.SetErr(ctx.Err())
+				},
+			}, nil
+		},
+	}
+
+	ctx, recorder := query.ContextWithQueryIDRecorder(context.Background())
+	req := makeRequest(compiler)
+	req.OrganizationID = platform.ID(5)
+	q, err := ctrl.Query(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	<-executing
+
+	id, ok := recorder.ID()
+	if !ok {
+		t.Fatal("expected the query ID to be recorded")
+	}
+	active := ctrl.ActiveQueries()
+	if len(active) != 1 || active[0].ID != id || active[0].OrganizationID != req.OrganizationID {
+		t.Fatalf("unexpected active queries %+v", active)
+	}
+
+	if !ctrl.CancelQuery(id) {
+		t.Fatal("expected the query to be canceled")
+	}
+	for range q.Results() {
+	}
+	q.Done()
+
+	if active := ctrl.ActiveQueries(); len(active) != 0 {
+		t.Errorf("unexpected active queries %+v", active)
+	}
+	if ctrl.CancelQuery(id) {
+		t.Error("expected a finished query not to be canceled")
+	}
+}
+
+func TestController_OrgConcurrencyQuota(t *testing.T) {
+	config := config
+	config.OrgConcurrencyQuota = 1
+	config.OrgQueueSize = 1
+	config.OrgQueueTimeout = 100 * time.Millisecond
+	ctrl, err := control.New(config, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+
+	orgRequest := func(c flux.Compiler, orgID platform.ID) *query.Request {
+		req := makeRequest(c)
+		req.OrganizationID = orgID
+		return req
+	}
+
+	executing := make(chan struct{})
+	blocking := &mock.Compiler{
+		CompileFn: func(ctx context.Context) (flux.Program, error) {
+			return &mock.Program{
+				ExecuteFn: func(ctx context.Context, q *mock.Query, alloc memory.Allocator) {
+					close(executing)
+					<-ctx.Done()
+					q.SetErr(ctx.Err())
+				},
+			}, nil
+		},
+	}
+	ctx, recorder := query.ContextWithQueryIDRecorder(context.Background())
+	q, err := ctrl.Query(ctx, orgRequest(blocking, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	<-executing
+
+	// Other organizations are not limited by the query.
+	other, err := ctrl.Query(context.Background(), orgRequest(mockCompiler, 2))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	consumeResults(t, other)
+
+	// The next query of the organization waits in its queue until it
+	// times out, and the one after it is rejected.
+	queued := make(chan error, 1)
+	go func() {
+		_, err := ctrl.Query(context.Background(), orgRequest(mockCompiler, 1))
+		queued <- err
+	}()
+	for waiting := false; !waiting; {
+		for _, aq := range ctrl.ActiveQueries() {
+			waiting = waiting || aq.State == control.Queueing.String()
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := ctrl.Query(context.Background(), orgRequest(mockCompiler, 1)); errors2.ErrorCode(err) != errors2.ETooManyRequests {
+		t.Errorf("expected the query to be rejected with too many requests, got %v", err)
+	}
+	if err := <-queued; errors2.ErrorCode(err) != errors2.EUnavailable {
+		t.Errorf("expected the queued query to time out, got %v", err)
+	}
+
+	// Queries of the organization run again once its query finishes.
+	id, _ := recorder.ID()
+	ctrl.CancelQuery(id)
+	for range q.Results() {
+	}
+	q.Done()
+
+	next, err := ctrl.Query(context.Background(), orgRequest(mockCompiler, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	consumeResults(t, next)
+}
+
 func consumeResults(tb testing.TB, q flux.Query) {
 	tb.Helper()
 	for res := range q.Results() {
@@ -0,0 +1,121 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// ExternProvider supplies Flux statements that are made available to every
+// query of an organization, i.e. server configured business calendars.
+type ExternProvider interface {
+	Extern(ctx context.Context, orgID platform.ID) (*ast.File, error)
+}
+
+// ExternProviders combines extern providers. The statements of the providers
+// are made available in order.
+type ExternProviders []ExternProvider
+
+// Extern returns the statements of all providers, or nil when none of the
+// providers has statements for the organization.
+func (ps ExternProviders) Extern(ctx context.Context, orgID platform.ID) (*ast.File, error) {
+	var merged *ast.File
+	for _, p := range ps {
+		file, err := p.Extern(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		if file == nil {
+			continue
+		}
+		if merged == nil {
+			merged = &ast.File{}
+		}
+		merged.Imports = appendImports(merged.Imports, file.Imports...)
+		merged.Body = append(merged.Body, file.Body...)
+	}
+	return merged, nil
+}
+
+// withExtern prepends the statements of the configured extern provider to the
+// extern of the compiler. Compilers that do not support externs are returned as is.
+func (c *Controller) withExtern(ctx context.Context, orgID platform.ID, compiler flux.Compiler) (flux.Compiler, error) {
+	if c.externProvider == nil {
+		return compiler, nil
+	}
+
+	switch comp := compiler.(type) {
+	case lang.FluxCompiler:
+		extern, err := c.mergeExtern(ctx, orgID, comp.Extern)
+		if err != nil {
+			return nil, err
+		}
+		comp.Extern = extern
+		return comp, nil
+	case lang.ASTCompiler:
+		extern, err := c.mergeExtern(ctx, orgID, comp.Extern)
+		if err != nil {
+			return nil, err
+		}
+		comp.Extern = extern
+		return comp, nil
+	default:
+		return compiler, nil
+	}
+}
+
+func (c *Controller) mergeExtern(ctx context.Context, orgID platform.ID, extern json.RawMessage) (json.RawMessage, error) {
+	file, err := c.externProvider.Extern(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build extern for organization %s: %w", orgID, err)
+	}
+	if file == nil {
+		return extern, nil
+	}
+
+	merged := &ast.File{
+		Imports: append([]*ast.ImportDeclaration{}, file.Imports...),
+		Body:    append([]ast.Statement{}, file.Body...),
+	}
+	if len(extern) > 0 {
+		node, err := ast.UnmarshalNode(extern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode extern: %w", err)
+		}
+
+		var files []*ast.File
+		switch n := node.(type) {
+		case *ast.File:
+			files = []*ast.File{n}
+		case *ast.Package:
+			files = n.Files
+		}
+		for _, f := range files {
+			merged.Imports = appendImports(merged.Imports, f.Imports...)
+			merged.Body = append(merged.Body, f.Body...)
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// appendImports appends the imports whose path is not yet imported.
+func appendImports(imports []*ast.ImportDeclaration, add ...*ast.ImportDeclaration) []*ast.ImportDeclaration {
+	for _, imp := range add {
+		dup := false
+		for _, existing := range imports {
+			if existing.Path.Value == imp.Path.Value && existing.As == nil && imp.As == nil {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			imports = append(imports, imp)
+		}
+	}
+	return imports
+}
@@ -2,10 +2,12 @@ package control
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"sync/atomic"
 
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2/query"
 )
 
 type memoryManager struct {
@@ -43,15 +45,37 @@ func (m *memoryManager) addUnusedMemoryBytes(amount int64) int64 {
 // createAllocator will construct an allocator and memory manager
 // for the given query.
 func (c *Controller) createAllocator(q *Query) {
+	limit := c.memory.initialBytesQuotaPerQuery
+	if limit > q.memoryQuota {
+		limit = q.memoryQuota
+	}
 	q.memoryManager = &queryMemoryManager{
 		m:     c.memory,
-		limit: c.memory.initialBytesQuotaPerQuery,
+		limit: limit,
+		quota: q.memoryQuota,
+	}
+	if q.limits.MaxMemoryBytes > 0 {
+		q.memoryManager.onQuotaExceeded = func() {
+			query.ExceedLimit(q.parentCtx, fmt.Sprintf("query exceeded its memory limit of %d bytes", q.limits.MaxMemoryBytes))
+		}
 	}
-	q.alloc = &memory.ResourceAllocator{
+	alloc := &memory.ResourceAllocator{
 		// Use an anonymous function to ensure the value is copied.
 		Limit:   func(v int64) *int64 { return &v }(q.memoryManager.limit),
 		Manager: q.memoryManager,
 	}
+
+	q.stateMu.Lock()
+	q.alloc = alloc
+	q.stateMu.Unlock()
+}
+
+// allocator returns the allocator of the query once it has
+// started executing. It is safe to call concurrently.
+func (q *Query) allocator() *memory.ResourceAllocator {
+	q.stateMu.RLock()
+	defer q.stateMu.RUnlock()
+	return q.alloc
 }
 
 // queryMemoryManager is a memory manager for a specific query.
@@ -59,6 +83,13 @@ type queryMemoryManager struct {
 	m     *memoryManager
 	limit int64
 	given int64
+
+	// quota is the maximum amount of memory that may be allocated
+	// to the query, it is at most memoryBytesQuotaPerQuery.
+	quota int64
+	// onQuotaExceeded is invoked when the query requests memory
+	// beyond its quota.
+	onQuotaExceeded func()
 }
 
 // RequestMemory will determine if the query can be given more memory
@@ -73,7 +104,10 @@ type queryMemoryManager struct {
 func (q *queryMemoryManager) RequestMemory(want int64) (got int64, err error) {
 	// It can be determined statically if we are going to violate
 	// the memoryBytesQuotaPerQuery.
-	if q.limit+want > q.m.memoryBytesQuotaPerQuery {
+	if q.limit+want > q.quota {
+		if q.onQuotaExceeded != nil {
+			q.onQuotaExceeded()
+		}
 		return 0, errors.New("query hit hard limit")
 	}
 
@@ -118,12 +152,12 @@ func (q *queryMemoryManager) RequestMemory(want int64) (got int64, err error) {
 func (q *queryMemoryManager) giveMemory(want, unused int64) int64 {
 	// If we can safely double the limit, then just do that.
 	if q.limit > want && q.limit < unused {
-		if q.limit*2 <= q.m.memoryBytesQuotaPerQuery {
+		if q.limit*2 <= q.quota {
 			return q.limit
 		}
 		// Doubling the limit sends us over the quota.
 		// Determine what would be our maximum amount.
-		max := q.m.memoryBytesQuotaPerQuery - q.limit
+		max := q.quota - q.limit
 		if max > want {
 			return max
 		}
@@ -12,6 +12,8 @@ type controllerMetrics struct {
 	queueing     *prometheus.GaugeVec
 	executing    *prometheus.GaugeVec
 	memoryUnused *prometheus.GaugeVec
+	orgQueueing  *prometheus.GaugeVec
+	orgRejected  *prometheus.CounterVec
 
 	allDur       *prometheus.HistogramVec
 	compilingDur *prometheus.HistogramVec
@@ -65,6 +67,16 @@ func newControllerMetrics(labels []string) *controllerMetrics {
 			Help: "The free memory as seen by the internal memory manager",
 		}, labels),
 
+		orgQueueing: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "qc_org_queueing_active",
+			Help: "Number of queries waiting for the other queries of their organization to finish",
+		}, []string{orgLabel}),
+
+		orgRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "qc_org_limit_rejected_total",
+			Help: "Count of the queries rejected by the limits of their organization",
+		}, []string{orgLabel, "reason"}),
+
 		allDur: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Name:    "qc_all_duration_seconds",
 			Help:    "Histogram of total times spent in all query states",
@@ -102,6 +114,8 @@ func (cm *controllerMetrics) PrometheusCollectors() []prometheus.Collector {
 		cm.queueing,
 		cm.executing,
 		cm.memoryUnused,
+		cm.orgQueueing,
+		cm.orgRejected,
 
 		cm.allDur,
 		cm.compilingDur,
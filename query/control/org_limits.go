@@ -0,0 +1,140 @@
+package control
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// orgLimiter bounds the queries of each organization that are queued or
+// executing at once, so that the queries of one organization cannot take
+// all of the concurrency of the controller. Queries beyond the limit of
+// their organization wait in a bounded queue of the organization before
+// they enter the queue of the controller.
+type orgLimiter struct {
+	concurrency int
+	queueSize   int
+	timeout     time.Duration
+	metrics     *controllerMetrics
+
+	mu   sync.Mutex
+	orgs map[platform.ID]*orgSlots
+}
+
+// orgSlots are the slots of an organization. They are dropped once no
+// query holds or waits for one.
+type orgSlots struct {
+	sem     chan struct{}
+	waiting int
+	refs    int
+}
+
+// newOrgLimiter returns nil when the queries of organizations are not
+// limited.
+func newOrgLimiter(c Config, metrics *controllerMetrics) *orgLimiter {
+	if c.OrgConcurrencyQuota == 0 {
+		return nil
+	}
+	return &orgLimiter{
+		concurrency: int(c.OrgConcurrencyQuota),
+		queueSize:   int(c.OrgQueueSize),
+		timeout:     c.OrgQueueTimeout,
+		metrics:     metrics,
+		orgs:        make(map[platform.ID]*orgSlots),
+	}
+}
+
+// acquire takes a slot of the organization of the query. When all of them
+// are taken, the query waits for one unless the queue of the organization
+// is full, until the queue timeout or until the query is canceled.
+func (l *orgLimiter) acquire(q *Query) error {
+	if l == nil || !q.organizationID.Valid() {
+		return nil
+	}
+	org := q.organizationID
+
+	l.mu.Lock()
+	s, ok := l.orgs[org]
+	if !ok {
+		s = &orgSlots{sem: make(chan struct{}, l.concurrency)}
+		l.orgs[org] = s
+	}
+	select {
+	case s.sem <- struct{}{}:
+		s.refs++
+		q.orgSlot = true
+		l.mu.Unlock()
+		return nil
+	default:
+	}
+	if s.waiting >= l.queueSize {
+		l.mu.Unlock()
+		l.metrics.orgRejected.WithLabelValues(org.String(), "queue_full").Inc()
+		return &errors2.Error{
+			Code: errors2.ETooManyRequests,
+			Msg:  fmt.Sprintf("too many queries of organization %s: %d running and %d queued", org, l.concurrency, l.queueSize),
+		}
+	}
+	s.waiting++
+	s.refs++
+	l.mu.Unlock()
+
+	queueing := l.metrics.orgQueueing.WithLabelValues(org.String())
+	queueing.Inc()
+	var timeout <-chan time.Time
+	if l.timeout > 0 {
+		t := time.NewTimer(l.timeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	var err error
+	select {
+	case s.sem <- struct{}{}:
+	case <-timeout:
+		l.metrics.orgRejected.WithLabelValues(org.String(), "timeout").Inc()
+		err = &errors2.Error{
+			Code: errors2.EUnavailable,
+			Msg:  fmt.Sprintf("query waited longer than %s for other queries of organization %s to finish", l.timeout, org),
+		}
+	case <-q.parentCtx.Done():
+		err = q.parentCtx.Err()
+	}
+	queueing.Dec()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s.waiting--
+	if err != nil {
+		l.unref(org, s)
+		return err
+	}
+	q.orgSlot = true
+	return nil
+}
+
+// release frees the slot the query holds, if any.
+func (l *orgLimiter) release(q *Query) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !q.orgSlot {
+		return
+	}
+	q.orgSlot = false
+	s := l.orgs[q.organizationID]
+	<-s.sem
+	l.unref(q.organizationID, s)
+}
+
+func (l *orgLimiter) unref(org platform.ID, s *orgSlots) {
+	s.refs--
+	if s.refs == 0 {
+		delete(l.orgs, org)
+	}
+}
@@ -34,6 +34,32 @@ func (b *BucketLookup) Lookup(ctx context.Context, orgID platform.ID, name strin
 	return bucket.ID, true
 }
 
+// SharedBucketFinder finds the buckets other organizations shared with an
+// organization.
+type SharedBucketFinder interface {
+	FindSharedBucket(ctx context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error)
+}
+
+// SharedBucketLookup resolves bucket names to the buckets of the organization
+// and, failing that, to the buckets shared with the organization. Shares only
+// grant read access, so it must not be used to look up buckets written to.
+type SharedBucketLookup struct {
+	*BucketLookup
+	Shares SharedBucketFinder
+}
+
+// Lookup returns the bucket id and its existence given an org id and bucket name.
+func (b *SharedBucketLookup) Lookup(ctx context.Context, orgID platform.ID, name string) (platform.ID, bool) {
+	if id, ok := b.BucketLookup.Lookup(ctx, orgID, name); ok {
+		return id, true
+	}
+	bucket, err := b.Shares.FindSharedBucket(ctx, orgID, name)
+	if err != nil {
+		return platform.InvalidID(), false
+	}
+	return bucket.ID, true
+}
+
 // LookupName returns an bucket name given its organization ID and its bucket ID.
 func (b *BucketLookup) LookupName(ctx context.Context, orgID platform.ID, id platform.ID) string {
 	filter := influxdb.BucketFilter{
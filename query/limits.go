@@ -0,0 +1,114 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+)
+
+// Limits bounds the resources used to execute a single query.
+// A zero value for any of the limits leaves it unbounded.
+type Limits struct {
+	// MaxMemoryBytes caps the table memory the query may allocate. It can only
+	// lower the controller's per query memory quota.
+	MaxMemoryBytes int64
+	// MaxWallTime caps the wall clock time the query may run for. The CPU
+	// time of a query is not bounded: its work runs on goroutines shared
+	// with other queries, so it cannot be attributed to the query.
+	MaxWallTime time.Duration
+	// MaxBytesWritten caps the size of the points the query may write.
+	MaxBytesWritten int64
+}
+
+// IsZero reports whether no limit is set.
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+func (l Limits) String() string {
+	return fmt.Sprintf("max_memory_bytes=%d max_wall_time=%s max_bytes_written=%d", l.MaxMemoryBytes, l.MaxWallTime, l.MaxBytesWritten)
+}
+
+// limitsState tracks the usage of a query against its limits. It is shared
+// by every context derived from the one the limits were set on.
+type limitsState struct {
+	Limits
+	written int64
+
+	mu       sync.Mutex
+	exceeded string
+}
+
+func (s *limitsState) exceed(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exceeded == "" {
+		s.exceeded = msg
+	}
+}
+
+type limitsContextKey struct{}
+
+// ContextWithLimits returns a new context carrying the limits. The query
+// controller enforces them on queries started with the context.
+func ContextWithLimits(ctx context.Context, l Limits) context.Context {
+	return context.WithValue(ctx, limitsContextKey{}, &limitsState{Limits: l})
+}
+
+func limitsStateFromContext(ctx context.Context) *limitsState {
+	s, _ := ctx.Value(limitsContextKey{}).(*limitsState)
+	return s
+}
+
+// LimitsFromContext returns the limits set on the context.
+func LimitsFromContext(ctx context.Context) (Limits, bool) {
+	s := limitsStateFromContext(ctx)
+	if s == nil {
+		return Limits{}, false
+	}
+	return s.Limits, true
+}
+
+// ExceedLimit records that a query started with the context exceeded one of
+// its limits. Only the first limit exceeded is recorded.
+func ExceedLimit(ctx context.Context, msg string) {
+	if s := limitsStateFromContext(ctx); s != nil {
+		s.exceed(msg)
+	}
+}
+
+// ExceededLimit returns a description of the limit a query started with the
+// context exceeded, if any.
+func ExceededLimit(ctx context.Context) (string, bool) {
+	s := limitsStateFromContext(ctx)
+	if s == nil {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.exceeded, s.exceeded != ""
+}
+
+// AddBytesWritten counts n bytes written by the query against its
+// MaxBytesWritten limit and returns an error once the limit is exceeded.
+func AddBytesWritten(ctx context.Context, n int64) error {
+	s := limitsStateFromContext(ctx)
+	if s == nil || s.MaxBytesWritten <= 0 {
+		return nil
+	}
+	if atomic.AddInt64(&s.written, n) <= s.MaxBytesWritten {
+		return nil
+	}
+
+	msg := fmt.Sprintf("query exceeded its limit of %d bytes written", s.MaxBytesWritten)
+	s.exceed(msg)
+	return &flux.Error{
+		Code: codes.ResourceExhausted,
+		Msg:  msg,
+	}
+}
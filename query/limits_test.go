@@ -0,0 +1,43 @@
+package query_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/query"
+)
+
+func TestAddBytesWritten(t *testing.T) {
+	if err := query.AddBytesWritten(context.Background(), 1<<20); err != nil {
+		t.Fatalf("unexpected error without limits: %v", err)
+	}
+
+	ctx := query.ContextWithLimits(context.Background(), query.Limits{
+		MaxWallTime:     time.Minute,
+		MaxBytesWritten: 100,
+	})
+	if l, ok := query.LimitsFromContext(ctx); !ok || l.MaxBytesWritten != 100 {
+		t.Fatalf("unexpected limits: %v", l)
+	}
+
+	if err := query.AddBytesWritten(ctx, 60); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := query.ExceededLimit(ctx); ok {
+		t.Fatal("limit reported as exceeded before it was")
+	}
+	if err := query.AddBytesWritten(ctx, 60); err == nil {
+		t.Fatal("expected an error once the limit is exceeded")
+	}
+
+	// only the first limit exceeded is recorded
+	query.ExceedLimit(ctx, "another limit")
+	limit, ok := query.ExceededLimit(ctx)
+	if !ok {
+		t.Fatal("expected the exceeded limit to be recorded")
+	}
+	if want := "query exceeded its limit of 100 bytes written"; limit != want {
+		t.Fatalf("unexpected exceeded limit -want/+got\n\t- %q\n\t+ %q", want, limit)
+	}
+}
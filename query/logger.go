@@ -17,6 +17,8 @@ type Logger interface {
 type Log struct {
 	// Time is the time the query was completed
 	Time time.Time
+	// Duration is the time taken to run the query and write its response
+	Duration time.Duration
 	// OrganizationID is the ID of the organization that requested the query
 	OrganizationID platform2.ID
 	// TraceID is the ID of the trace related to this query
@@ -78,6 +78,7 @@ func (s *LoggingProxyQueryService) Query(ctx context.Context, w io.Writer, req *
 	defer span.Finish()
 
 	var n int64
+	start := s.nowFunction()
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("panic: %v", r)
@@ -94,6 +95,7 @@ func (s *LoggingProxyQueryService) Query(ctx context.Context, w io.Writer, req *
 			}
 		}
 
+		end := s.nowFunction()
 		traceID, sampled, _ := tracing.InfoFromContext(ctx)
 		log := Log{
 			OrganizationID: req.Request.OrganizationID,
@@ -101,7 +103,8 @@ func (s *LoggingProxyQueryService) Query(ctx context.Context, w io.Writer, req *
 			Sampled:        sampled,
 			ProxyRequest:   req,
 			ResponseSize:   n,
-			Time:           s.nowFunction(),
+			Time:           end,
+			Duration:       end.Sub(start),
 			Statistics:     stats,
 			Error:          err,
 		}
@@ -40,6 +40,9 @@ type Request struct {
 	options []RequestHeaderOption
 }
 
+// SourceTaskExecutor is the Source of requests made by the task executor.
+const SourceTaskExecutor = "task-executor"
+
 // SetReturnNoContent sets the header for a Request to return no content.
 func SetReturnNoContent(header http.Header, withError bool) {
 	if withError {
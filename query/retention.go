@@ -0,0 +1,67 @@
+package query
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// RetentionWarning notes that the time range read from a bucket starts
+// before the oldest data the bucket retains, so the start of the range
+// cannot have any data.
+type RetentionWarning struct {
+	BucketID platform.ID
+	// RequestedStart is the start of the time range read.
+	RequestedStart time.Time
+	// AvailableStart is the start of the retained data when the range was read.
+	AvailableStart time.Time
+}
+
+// RetentionWarnings collects the retention warnings of a query. It is safe
+// for concurrent use.
+type RetentionWarnings struct {
+	mu       sync.Mutex
+	warnings map[platform.ID]RetentionWarning
+}
+
+var retentionWarningsContextKey = struct{ name string }{"retention warnings"}
+
+// ContextWithRetentionWarnings returns a new context collecting the
+// retention warnings of the queries executed with it.
+func ContextWithRetentionWarnings(ctx context.Context) (context.Context, *RetentionWarnings) {
+	w := &RetentionWarnings{warnings: make(map[platform.ID]RetentionWarning)}
+	return context.WithValue(ctx, retentionWarningsContextKey, w), w
+}
+
+// RetentionWarningsFromContext retrieves the retention warnings collected
+// with the context. If retention warnings are not collected nil is returned.
+func RetentionWarningsFromContext(ctx context.Context) *RetentionWarnings {
+	w, _ := ctx.Value(retentionWarningsContextKey).(*RetentionWarnings)
+	return w
+}
+
+// Add records a warning. Of the warnings of a bucket, the one with the
+// earliest requested start is kept.
+func (w *RetentionWarnings) Add(warning RetentionWarning) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if prev, ok := w.warnings[warning.BucketID]; ok && !warning.RequestedStart.Before(prev.RequestedStart) {
+		return
+	}
+	w.warnings[warning.BucketID] = warning
+}
+
+// Warnings returns the warnings recorded, ordered by bucket ID.
+func (w *RetentionWarnings) Warnings() []RetentionWarning {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := make([]RetentionWarning, 0, len(w.warnings))
+	for _, warning := range w.warnings {
+		out = append(out, warning)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].BucketID < out[j].BucketID })
+	return out
+}
@@ -0,0 +1,52 @@
+package query
+
+import (
+	"github.com/influxdata/flux"
+)
+
+// RowsMetadataKey is the key of the statistics metadata holding the number of
+// rows of the results encoded for a query.
+const RowsMetadataKey = "influxdb/rows"
+
+// rowCountingResultIterator counts the rows of the tables of its results as
+// they are read.
+type rowCountingResultIterator struct {
+	flux.ResultIterator
+	rows *int64
+}
+
+func (i rowCountingResultIterator) Next() flux.Result {
+	return rowCountingResult{Result: i.ResultIterator.Next(), rows: i.rows}
+}
+
+type rowCountingResult struct {
+	flux.Result
+	rows *int64
+}
+
+func (r rowCountingResult) Tables() flux.TableIterator {
+	return rowCountingTables{TableIterator: r.Result.Tables(), rows: r.rows}
+}
+
+type rowCountingTables struct {
+	flux.TableIterator
+	rows *int64
+}
+
+func (t rowCountingTables) Do(f func(flux.Table) error) error {
+	return t.TableIterator.Do(func(tbl flux.Table) error {
+		return f(rowCountingTable{Table: tbl, rows: t.rows})
+	})
+}
+
+type rowCountingTable struct {
+	flux.Table
+	rows *int64
+}
+
+func (t rowCountingTable) Do(f func(flux.ColReader) error) error {
+	return t.Table.Do(func(cr flux.ColReader) error {
+		*t.rows += int64(cr.Len())
+		return f(cr)
+	})
+}
@@ -2,6 +2,8 @@ package influxdb
 
 import (
 	"context"
+	"fmt"
+	nethttp "net/http"
 
 	"github.com/influxdata/flux"
 	fluxfeature "github.com/influxdata/flux/dependencies/feature"
@@ -10,6 +12,7 @@ import (
 	"github.com/influxdata/flux/dependencies/url"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/prom"
 	"github.com/influxdata/influxdb/v2/query"
 	"github.com/influxdata/influxdb/v2/storage"
@@ -62,17 +65,28 @@ func (d StorageDependencies) PrometheusCollectors() []prometheus.Collector {
 type Dependencies struct {
 	StorageDeps StorageDependencies
 	FluxDeps    flux.Dependencies
+
+	// orgFluxDeps replace FluxDeps for queries of the given organizations.
+	orgFluxDeps map[platform.ID]flux.Dependencies
 }
 
 func (d Dependencies) Inject(ctx context.Context) context.Context {
-	ctx = d.FluxDeps.Inject(ctx)
+	fluxDeps := d.FluxDeps
+	if req := query.RequestFromContext(ctx); req != nil {
+		if orgDeps, ok := d.orgFluxDeps[req.OrganizationID]; ok {
+			fluxDeps = orgDeps
+		}
+	}
+	ctx = fluxDeps.Inject(ctx)
 	ctx = d.StorageDeps.Inject(ctx)
+	ctx = withExperimentalRuleResults(ctx)
 	return InjectFlagsFromContext(ctx)
 }
 
 // PrometheusCollectors satisfies the prom.PrometheusCollector interface.
 func (d Dependencies) PrometheusCollectors() []prometheus.Collector {
 	collectors := d.StorageDeps.PrometheusCollectors()
+	collectors = append(collectors, experimentalRuleRewrites)
 	if pc, ok := d.FluxDeps.(prom.PrometheusCollector); ok {
 		collectors = append(collectors, pc.PrometheusCollectors()...)
 	}
@@ -84,8 +98,42 @@ type FluxDepOption func(*flux.Deps)
 func WithURLValidator(v url.Validator) FluxDepOption {
 	return func(d *flux.Deps) {
 		d.Deps.URLValidator = v
-		d.Deps.HTTPClient = http.NewDefaultClient(d.Deps.URLValidator)
+		d.Deps.HTTPClient = newHTTPClient(d.Deps.URLValidator)
+	}
+}
+
+// maxRedirects is the number of redirects a Flux HTTP request follows, the
+// same as the default of net/http.
+const maxRedirects = 10
+
+// newHTTPClient returns an HTTP client validating the addresses it dials and
+// the url of every redirect it follows with the validator.
+func newHTTPClient(v url.Validator) *nethttp.Client {
+	client := http.NewDefaultClient(v)
+	client.CheckRedirect = func(req *nethttp.Request, via []*nethttp.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return v.Validate(req.URL)
 	}
+	return client
+}
+
+// WithOrgURLValidators returns a copy of the dependencies in which queries
+// run on behalf of the given organizations validate outbound HTTP requests
+// with the org's validator rather than the default one.
+func (d Dependencies) WithOrgURLValidators(validators map[platform.ID]url.Validator) Dependencies {
+	fdeps, ok := d.FluxDeps.(flux.Deps)
+	if !ok || len(validators) == 0 {
+		return d
+	}
+	d.orgFluxDeps = make(map[platform.ID]flux.Dependencies, len(validators))
+	for orgID, v := range validators {
+		orgDeps := fdeps
+		WithURLValidator(v)(&orgDeps)
+		d.orgFluxDeps[orgID] = orgDeps
+	}
+	return d
 }
 
 func NewDependencies(
@@ -109,9 +157,14 @@ func NewDependencies(
 	bucketLookupSvc := query.FromBucketService(bucketSvc)
 	orgLookupSvc := query.FromOrganizationService(orgSvc)
 	metrics := NewMetrics(metricLabelKeys)
+	// buckets shared with the organization can be read, but not written to
+	var fromBucketLookup BucketLookup = bucketLookupSvc
+	if shares, ok := bucketSvc.(query.SharedBucketFinder); ok {
+		fromBucketLookup = &query.SharedBucketLookup{BucketLookup: bucketLookupSvc, Shares: shares}
+	}
 	deps.StorageDeps.FromDeps = FromDependencies{
 		Reader:             reader,
-		BucketLookup:       bucketLookupSvc,
+		BucketLookup:       fromBucketLookup,
 		OrganizationLookup: orgLookupSvc,
 		Metrics:            metrics,
 	}
@@ -0,0 +1,88 @@
+package influxdb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	ruleLabel   = "rule"
+	resultLabel = "result"
+)
+
+// experimentalRuleRewrites counts the queries experimental rules matched.
+var experimentalRuleRewrites = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "query",
+	Subsystem: "planner",
+	Name:      "experimental_rule_rewrites_total",
+	Help:      "Number of queries with plan nodes matched by experimental planner rules, by rule and result: disabled when the flag of the rule is off for the query, rewritten, unchanged or error",
+}, []string{ruleLabel, resultLabel})
+
+type experimentalRuleResultsKey struct{}
+
+// experimentalRuleResults are the results experimental rules had on the
+// nodes of a query. The planner applies rules until the plan no longer
+// changes, so a rule may match the same node many times.
+type experimentalRuleResults struct {
+	mu   sync.Mutex
+	seen map[[2]string]bool
+}
+
+// withExperimentalRuleResults returns a context counting the results of
+// experimental rules once for the query planned with it.
+func withExperimentalRuleResults(ctx context.Context) context.Context {
+	return context.WithValue(ctx, experimentalRuleResultsKey{}, &experimentalRuleResults{
+		seen: make(map[[2]string]bool),
+	})
+}
+
+// countExperimentalRule counts the result of a rule, unless it was already
+// counted for the query of ctx.
+func countExperimentalRule(ctx context.Context, rule, result string) {
+	if res, _ := ctx.Value(experimentalRuleResultsKey{}).(*experimentalRuleResults); res != nil {
+		key := [2]string{rule, result}
+		res.mu.Lock()
+		seen := res.seen[key]
+		res.seen[key] = true
+		res.mu.Unlock()
+		if seen {
+			return
+		}
+	}
+	experimentalRuleRewrites.WithLabelValues(rule, result).Inc()
+}
+
+// ExperimentalRule is a planner rule that only rewrites the queries its
+// feature flag is enabled for. Flags are computed per query by the Flagger
+// of the server, which may enable them for some organizations only, so a
+// risky optimization can be rolled out gradually.
+//
+// The queries the rule matches nodes of are counted once per result,
+// including those the rule is disabled for, to size the rollout before
+// enabling it.
+type ExperimentalRule struct {
+	plan.Rule
+	Flag feature.BoolFlag
+}
+
+func (r ExperimentalRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	if !r.Flag.Enabled(ctx) {
+		countExperimentalRule(ctx, r.Name(), "disabled")
+		return node, false, nil
+	}
+
+	node, changed, err := r.Rule.Rewrite(ctx, node)
+	result := "unchanged"
+	switch {
+	case err != nil:
+		result = "error"
+	case changed:
+		result = "rewritten"
+	}
+	countExperimentalRule(ctx, r.Name(), result)
+	return node, changed, err
+}
@@ -0,0 +1,48 @@
+package influxdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingTestRule struct {
+	changed bool
+}
+
+func (countingTestRule) Name() string          { return "countingTestRule" }
+func (countingTestRule) Pattern() plan.Pattern { return plan.Any() }
+func (r countingTestRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	return node, r.changed, nil
+}
+
+func TestExperimentalRule_CountsQueries(t *testing.T) {
+	flag := feature.MakeBoolFlag("Counting Test Rule", "countingTestRule", "", false, feature.Temporary, false)
+	count := func(result string) float64 {
+		return testutil.ToFloat64(experimentalRuleRewrites.WithLabelValues("countingTestRule", result))
+	}
+
+	enabled, err := feature.Annotate(context.Background(), mock.NewFlagger(map[feature.Flag]interface{}{flag: true}))
+	require.NoError(t, err)
+
+	// every pass of the planner over the nodes of a query counts once
+	for _, q := range []context.Context{enabled, enabled, context.Background()} {
+		ctx := withExperimentalRuleResults(q)
+		for i := 0; i < 3; i++ {
+			_, _, err := ExperimentalRule{Rule: countingTestRule{}, Flag: flag}.Rewrite(ctx, nil)
+			require.NoError(t, err)
+			_, _, err = ExperimentalRule{Rule: countingTestRule{changed: true}, Flag: flag}.Rewrite(ctx, nil)
+			require.NoError(t, err)
+		}
+	}
+
+	assert.Equal(t, 2.0, count("unchanged"))
+	assert.Equal(t, 2.0, count("rewritten"))
+	assert.Equal(t, 1.0, count("disabled"))
+}
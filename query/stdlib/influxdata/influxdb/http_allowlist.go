@@ -0,0 +1,193 @@
+package influxdb
+
+import (
+	"fmt"
+	"net"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/flux/dependencies/url"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// lookupIP is replaced in tests.
+var lookupIP = net.LookupIP
+
+// resolvedTTL is how long an address an allowed domain resolved to may be
+// dialed after the url was validated.
+const resolvedTTL = time.Minute
+
+// HTTPAllowlist is a url.Validator that only permits HTTP requests made from
+// Flux, e.g. by http.post or the requests package, to the allowed domains and
+// networks. Every request must additionally pass the wrapped validator.
+type HTTPAllowlist struct {
+	domains  []string
+	networks []*net.IPNet
+	next     url.Validator
+
+	mu sync.Mutex
+	// resolved holds the addresses allowed domains resolved to when their
+	// urls were validated, and when they expire.
+	resolved map[string]time.Time
+}
+
+// NewHTTPAllowlist parses the entries into an allowlist. An entry is either a
+// CIDR, an IP address, a domain or a wildcard domain such as *.example.com
+// which matches any subdomain of example.com.
+func NewHTTPAllowlist(next url.Validator, entries ...string) (*HTTPAllowlist, error) {
+	if next == nil {
+		next = url.PassValidator{}
+	}
+	a := &HTTPAllowlist{next: next, resolved: make(map[string]time.Time)}
+	for _, e := range entries {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" {
+			continue
+		}
+		if _, n, err := net.ParseCIDR(e); err == nil {
+			a.networks = append(a.networks, n)
+			continue
+		}
+		if ip := net.ParseIP(e); ip != nil {
+			a.networks = append(a.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		if strings.ContainsAny(strings.TrimPrefix(e, "*."), "*/:") {
+			return nil, fmt.Errorf("invalid http allowlist entry %q", e)
+		}
+		a.domains = append(a.domains, e)
+	}
+	return a, nil
+}
+
+// Validate permits the url when its host matches an allowed domain, or when
+// every address the host resolves to is in an allowed network.
+func (a *HTTPAllowlist) Validate(u *neturl.URL) error {
+	if err := a.next.Validate(u); err != nil {
+		return err
+	}
+
+	host := strings.ToLower(u.Hostname())
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		var err error
+		if ips, err = lookupIP(host); err != nil {
+			return err
+		}
+	}
+
+	if a.allowedDomain(host) {
+		a.addResolved(ips)
+		return nil
+	}
+
+	if len(ips) == 0 {
+		return errNotAllowed(u)
+	}
+	for _, ip := range ips {
+		if !a.allowedIP(ip) {
+			return errNotAllowed(u)
+		}
+	}
+	return nil
+}
+
+func errNotAllowed(u *neturl.URL) error {
+	return &errors.Error{
+		Code: errors.EForbidden,
+		Msg:  fmt.Sprintf("url %q is not in the http allowlist", u.Redacted()),
+	}
+}
+
+// ValidateIP permits dialing the address when it is in an allowed network,
+// or when an allowed domain recently resolved to it in Validate. The address
+// is checked again when dialing since the host may resolve differently by
+// then, e.g. after a redirect or a DNS rebinding.
+func (a *HTTPAllowlist) ValidateIP(ip net.IP) error {
+	if err := a.next.ValidateIP(ip); err != nil {
+		return err
+	}
+	if a.allowedIP(ip) || a.isResolved(ip) {
+		return nil
+	}
+	return &errors.Error{
+		Code: errors.EForbidden,
+		Msg:  fmt.Sprintf("address %s is not in the http allowlist", ip),
+	}
+}
+
+func (a *HTTPAllowlist) addResolved(ips []net.IP) {
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for k, expires := range a.resolved {
+		if now.After(expires) {
+			delete(a.resolved, k)
+		}
+	}
+	for _, ip := range ips {
+		a.resolved[ip.String()] = now.Add(resolvedTTL)
+	}
+}
+
+func (a *HTTPAllowlist) isResolved(ip net.IP) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	expires, ok := a.resolved[ip.String()]
+	return ok && time.Now().Before(expires)
+}
+
+func (a *HTTPAllowlist) allowedDomain(host string) bool {
+	for _, d := range a.domains {
+		if wildcard := strings.TrimPrefix(d, "*"); wildcard != d {
+			if strings.HasSuffix(host, wildcard) {
+				return true
+			}
+			continue
+		}
+		if host == d {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *HTTPAllowlist) allowedIP(ip net.IP) bool {
+	for _, n := range a.networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseOrgHTTPAllowlists parses per-org allowlist entries of the form
+// <org-id>:<entry> into an allowlist for each org. An org's allowlist
+// replaces the default allowlist for queries run on behalf of that org.
+func ParseOrgHTTPAllowlists(next url.Validator, entries ...string) (map[platform.ID]url.Validator, error) {
+	byOrg := make(map[platform.ID][]string)
+	for _, e := range entries {
+		parts := strings.SplitN(e, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid org http allowlist entry %q: expected <org-id>:<entry>", e)
+		}
+		orgID, err := platform.IDFromString(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid org http allowlist entry %q: %v", e, err)
+		}
+		byOrg[*orgID] = append(byOrg[*orgID], parts[1])
+	}
+
+	validators := make(map[platform.ID]url.Validator, len(byOrg))
+	for orgID, orgEntries := range byOrg {
+		a, err := NewHTTPAllowlist(next, orgEntries...)
+		if err != nil {
+			return nil, err
+		}
+		validators[orgID] = a
+	}
+	return validators, nil
+}
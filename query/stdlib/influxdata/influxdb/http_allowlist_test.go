@@ -0,0 +1,180 @@
+package influxdb
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"testing"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/dependencies/url"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPAllowlist(t *testing.T) {
+	lookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "internal.corp":
+			return []net.IP{net.ParseIP("10.1.2.3")}, nil
+		case "mixed.corp":
+			return []net.IP{net.ParseIP("10.1.2.3"), net.ParseIP("8.8.8.8")}, nil
+		default:
+			return []net.IP{net.ParseIP("203.0.113.10")}, nil
+		}
+	}
+	defer func() { lookupIP = net.LookupIP }()
+
+	a, err := NewHTTPAllowlist(nil, "hooks.slack.com", "*.example.com", "10.0.0.0/8", "192.0.2.1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{url: "https://hooks.slack.com/services/x", allowed: true},
+		{url: "https://HOOKS.slack.com/services/x", allowed: true},
+		{url: "https://api.example.com/v1", allowed: true},
+		{url: "https://example.com/v1", allowed: false},
+		{url: "https://evil.com/?q=hooks.slack.com", allowed: false},
+		{url: "http://10.20.30.40:8086/api", allowed: true},
+		{url: "http://192.0.2.1/", allowed: true},
+		{url: "http://192.0.2.2/", allowed: false},
+		{url: "http://internal.corp/", allowed: true},
+		{url: "http://mixed.corp/", allowed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := neturl.Parse(tt.url)
+			require.NoError(t, err)
+
+			err = a.Validate(u)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	t.Run("wrapped validator is enforced", func(t *testing.T) {
+		a, err := NewHTTPAllowlist(url.PrivateIPValidator{}, "10.0.0.0/8")
+		require.NoError(t, err)
+
+		u, _ := neturl.Parse("http://10.0.0.1/")
+		assert.Error(t, a.Validate(u))
+		assert.Error(t, a.ValidateIP(net.ParseIP("10.0.0.1")))
+	})
+
+	t.Run("invalid entry", func(t *testing.T) {
+		_, err := NewHTTPAllowlist(nil, "http://example.com/path")
+		assert.Error(t, err)
+	})
+}
+
+func TestHTTPAllowlist_Dial(t *testing.T) {
+	lookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "allowed.test":
+			return []net.IP{net.ParseIP("127.0.0.1")}, nil
+		default:
+			// Every other host validates against a public address, while
+			// the client actually dials the local test server.
+			return []net.IP{net.ParseIP("203.0.113.10")}, nil
+		}
+	}
+	defer func() { lookupIP = net.LookupIP }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+
+	// newClient returns the client Flux uses, with every host resolving to
+	// the test server when dialed.
+	newClient := func(t *testing.T, a *HTTPAllowlist) *http.Client {
+		client := newHTTPClient(a)
+		tr := client.Transport.(*http.Transport)
+		tr.Proxy = nil
+		dial := tr.DialContext
+		tr.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dial(ctx, network, net.JoinHostPort("127.0.0.1", port))
+		}
+		return client
+	}
+	get := func(client *http.Client, a *HTTPAllowlist, rawURL string) error {
+		u, err := neturl.Parse(rawURL)
+		require.NoError(t, err)
+		if err := a.Validate(u); err != nil {
+			return err
+		}
+		resp, err := client.Get(rawURL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	t.Run("allowed domain", func(t *testing.T) {
+		a, err := NewHTTPAllowlist(nil, "allowed.test")
+		require.NoError(t, err)
+		assert.NoError(t, get(newClient(t, a), a, "http://allowed.test:"+port+"/ok"))
+	})
+
+	t.Run("domain rebinding to a denied address", func(t *testing.T) {
+		a, err := NewHTTPAllowlist(nil, "rebind.test")
+		require.NoError(t, err)
+		assert.Error(t, get(newClient(t, a), a, "http://rebind.test:"+port+"/ok"))
+	})
+
+	t.Run("redirect to a denied host", func(t *testing.T) {
+		a, err := NewHTTPAllowlist(nil, "allowed.test")
+		require.NoError(t, err)
+		to := neturl.QueryEscape("http://denied.test:" + port + "/ok")
+		assert.Error(t, get(newClient(t, a), a, "http://allowed.test:"+port+"/redirect?to="+to))
+	})
+
+	t.Run("redirect to an allowed host", func(t *testing.T) {
+		a, err := NewHTTPAllowlist(nil, "allowed.test", "127.0.0.1")
+		require.NoError(t, err)
+		to := neturl.QueryEscape("http://127.0.0.1:" + port + "/ok")
+		assert.NoError(t, get(newClient(t, a), a, "http://allowed.test:"+port+"/redirect?to="+to))
+	})
+}
+
+func TestDependencies_WithOrgURLValidators(t *testing.T) {
+	orgID := platform.ID(1)
+	validators, err := ParseOrgHTTPAllowlists(nil, orgID.String()+":hooks.slack.com", orgID.String()+":10.0.0.0/8")
+	require.NoError(t, err)
+	require.Len(t, validators, 1)
+
+	_, err = ParseOrgHTTPAllowlists(nil, "hooks.slack.com")
+	assert.Error(t, err)
+
+	deps := Dependencies{FluxDeps: flux.NewDefaultDependencies()}.WithOrgURLValidators(validators)
+
+	validatorFor := func(ctx context.Context) url.Validator {
+		v, err := flux.GetDependencies(deps.Inject(ctx)).URLValidator()
+		require.NoError(t, err)
+		return v
+	}
+
+	u, _ := neturl.Parse("http://10.0.0.1/")
+	denied, _ := neturl.Parse("http://203.0.113.10/")
+
+	orgCtx := query.ContextWithRequest(context.Background(), &query.Request{OrganizationID: orgID})
+	assert.NoError(t, validatorFor(orgCtx).Validate(u))
+	assert.Error(t, validatorFor(orgCtx).Validate(denied))
+
+	otherCtx := query.ContextWithRequest(context.Background(), &query.Request{OrganizationID: 2})
+	assert.NoError(t, validatorFor(otherCtx).Validate(denied))
+}
@@ -300,6 +300,18 @@ func (w *localPointsWriter) flush() error {
 		return nil
 	}
 
+	// count the line protocol size of the points against the limit
+	// on bytes written set for the query, if any.
+	if limits, ok := query.LimitsFromContext(w.ctx); ok && limits.MaxBytesWritten > 0 {
+		var size int64
+		for _, p := range w.buf[:w.n] {
+			size += int64(p.StringSize())
+		}
+		if w.err = query.AddBytesWritten(w.ctx, size); w.err != nil {
+			return w.err
+		}
+	}
+
 	w.err = w.wr.WritePoints(w.ctx, w.orgID, w.bucketID, w.buf[:w.n])
 	if w.err != nil {
 		return w.err
@@ -31,14 +31,14 @@ func init() {
 		PushDownWindowForceAggregateRule{},
 		PushDownWindowAggregateByTimeRule{},
 		PushDownBareAggregateRule{},
-		GroupWindowAggregateTransposeRule{},
+		ExperimentalRule{Rule: GroupWindowAggregateTransposeRule{}, Flag: feature.GroupWindowAggregateTranspose()},
 		PushDownGroupAggregateRule{},
 	)
-	// TODO(lesam): re-enable MergeFilterRule once it works with complex use cases
+	// TODO(lesam): enable MergeFilterRule by default once it works with complex use cases
 	// such as filter() |> geo.strictFilter(). See geo_merge_filter flux test.
-	//plan.RegisterLogicalRules(
-	//	MergeFiltersRule{},
-	//)
+	plan.RegisterLogicalRules(
+		ExperimentalRule{Rule: MergeFiltersRule{}, Flag: feature.MergeFiltersRule()},
+	)
 }
 
 type FromStorageRule struct{}
@@ -896,6 +896,9 @@ func (p PushDownBareAggregateRule) Rewrite(ctx context.Context, pn plan.Node) (p
 // ReadWindowAggregatePhys |> group(columns: ["_start", "_stop", ...]) |> { min, max, sum }
 //
 // The count aggregate uses sum to merge the results.
+//
+// It is registered as an ExperimentalRule enabled by the
+// groupWindowAggregateTranspose feature flag.
 type GroupWindowAggregateTransposeRule struct{}
 
 func (p GroupWindowAggregateTransposeRule) Name() string {
@@ -915,10 +918,6 @@ func (p GroupWindowAggregateTransposeRule) Pattern() plan.Pattern {
 }
 
 func (p GroupWindowAggregateTransposeRule) Rewrite(ctx context.Context, pn plan.Node) (plan.Node, bool, error) {
-	if !feature.GroupWindowAggregateTranspose().Enabled(ctx) {
-		return pn, false, nil
-	}
-
 	fnNode := pn
 	if !canPushWindowedAggregate(ctx, fnNode) {
 		return pn, false, nil
@@ -2093,7 +2093,10 @@ func TestTransposeGroupToWindowAggregateRule(t *testing.T) {
 		influxdb.PushDownGroupRule{},
 		influxdb.PushDownWindowAggregateRule{},
 		influxdb.PushDownWindowAggregateByTimeRule{},
-		influxdb.GroupWindowAggregateTransposeRule{},
+		influxdb.ExperimentalRule{
+			Rule: influxdb.GroupWindowAggregateTransposeRule{},
+			Flag: feature.GroupWindowAggregateTranspose(),
+		},
 	}
 
 	withFlagger, _ := feature.Annotate(context.Background(), flagger)
@@ -0,0 +1,137 @@
+// Package utils provides the server side Flux library of common dashboard
+// query patterns.
+//
+// The library is bound to the utils record in every Flux query, so cells
+// call the maintained implementation instead of copies of the same snippet:
+//
+//	from(bucket: "telegraf")
+//	    |> range(start: v.timeRangeStart, stop: v.timeRangeStop)
+//	    |> filter(fn: (r) => r._measurement == "cpu")
+//	    |> utils.topNByTag(tag: "host", n: 5)
+//
+// The library is versioned, utils.version is Version. Functions are only
+// added or extended with optional parameters within a major version.
+package utils
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+const (
+	// Name is the identifier the library record is bound to in Flux.
+	Name = "utils"
+	// Version is the version of the library.
+	Version = "1.0.0"
+)
+
+//go:embed utils.flux
+var source string
+
+// Source returns the Flux source of the library.
+func Source() string {
+	return source
+}
+
+// Function describes a function of the library for query builders.
+type Function struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Params maps the parameters to their type, the optional ones are
+	// listed in Defaults.
+	Params   map[string]string `json:"params"`
+	Defaults map[string]string `json:"defaults"`
+	Example  string            `json:"example"`
+}
+
+var functions = []Function{
+	{
+		Name:        "rateLimitSafeAggregate",
+		Description: "Aggregates into windows of at least every, widening the windows so no more than maxPoints windows fall between start and stop.",
+		Params: map[string]string{
+			"every":       "duration",
+			"start":       "time|duration",
+			"stop":        "time|duration",
+			"fn":          "function",
+			"maxPoints":   "int",
+			"createEmpty": "bool",
+		},
+		Defaults: map[string]string{
+			"stop":        "now()",
+			"fn":          "mean",
+			"maxPoints":   "1000",
+			"createEmpty": "false",
+		},
+		Example: `utils.rateLimitSafeAggregate(every: v.windowPeriod, start: v.timeRangeStart, stop: v.timeRangeStop)`,
+	},
+	{
+		Name:        "topNByTag",
+		Description: "Returns the n values of the tag with the highest aggregate.",
+		Params: map[string]string{
+			"tag":    "string",
+			"n":      "int",
+			"column": "string",
+			"fn":     "function",
+		},
+		Defaults: map[string]string{
+			"n":      "10",
+			"column": `"_value"`,
+			"fn":     "sum",
+		},
+		Example: `utils.topNByTag(tag: "host", n: 5)`,
+	},
+	{
+		Name:        "lastByTag",
+		Description: "Returns the last value of every value of the tag in a single table.",
+		Params: map[string]string{
+			"tag":    "string",
+			"column": "string",
+		},
+		Defaults: map[string]string{
+			"column": `"_value"`,
+		},
+		Example: `utils.lastByTag(tag: "host")`,
+	},
+	{
+		Name:        "counterRate",
+		Description: "Returns the per unit rate of a monotonic counter. Counter resets do not produce negative rates.",
+		Params: map[string]string{
+			"unit": "duration",
+		},
+		Defaults: map[string]string{
+			"unit": "1s",
+		},
+		Example: `utils.counterRate(unit: 1m)`,
+	},
+}
+
+// Functions returns the functions of the library.
+func Functions() []Function {
+	out := make([]Function, len(functions))
+	copy(out, functions)
+	return out
+}
+
+// Provider binds the library to Name in every query. It satisfies the
+// control.ExternProvider interface.
+type Provider struct {
+	file *ast.File
+}
+
+// NewProvider parses the library.
+func NewProvider() (*Provider, error) {
+	pkg := parser.ParseSource(source)
+	if ast.Check(pkg) > 0 {
+		return nil, ast.GetError(pkg)
+	}
+	return &Provider{file: pkg.Files[0]}, nil
+}
+
+// Extern returns the Flux statements binding the library to Name.
+func (p *Provider) Extern(ctx context.Context, orgID platform.ID) (*ast.File, error) {
+	return p.file, nil
+}
@@ -0,0 +1,54 @@
+package utils_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/influxdb/v2/query/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvider_Extern(t *testing.T) {
+	p, err := utils.NewProvider()
+	require.NoError(t, err)
+
+	file, err := p.Extern(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, file.Body, 1)
+
+	assign, ok := file.Body[0].(*ast.VariableAssignment)
+	require.True(t, ok, "library must be a single variable assignment")
+	assert.Equal(t, utils.Name, assign.ID.Name)
+
+	record, ok := assign.Init.(*ast.ObjectExpression)
+	require.True(t, ok, "library must be a record")
+
+	var (
+		version string
+		names   []string
+	)
+	for _, prop := range record.Properties {
+		key := prop.Key.Key()
+		if key == "version" {
+			lit, ok := prop.Value.(*ast.StringLiteral)
+			require.True(t, ok)
+			version = lit.Value
+			continue
+		}
+		names = append(names, key)
+	}
+	assert.Equal(t, utils.Version, version)
+
+	// the functions described to query builders must match the library
+	var described []string
+	for _, fn := range utils.Functions() {
+		described = append(described, fn.Name)
+
+		for param := range fn.Defaults {
+			assert.Contains(t, fn.Params, param, "default of unknown parameter %s.%s", fn.Name, param)
+		}
+	}
+	assert.ElementsMatch(t, names, described)
+}
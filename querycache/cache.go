@@ -0,0 +1,225 @@
+// Package querycache caches the results of Flux queries, so the identical
+// queries of the viewers of a dashboard are computed once.
+//
+// Results are kept for a short TTL and dropped as soon as data is written
+// to or deleted from one of the buckets they read. Only queries whose
+// buckets are known before they run and which have no side effects are
+// cached, the others run as usual.
+package querycache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultTTL is the default time a result is served from the cache.
+	DefaultTTL = 10 * time.Second
+	// DefaultMaxBytes is the default size of all of the results cached.
+	DefaultMaxBytes = 64 << 20
+	// DefaultMaxResultBytes is the default size of the largest result
+	// cached.
+	DefaultMaxResultBytes = 1 << 20
+)
+
+// Cache is the results of queries by key. When the results exceed the
+// size of the cache, the result used least recently is evicted.
+type Cache struct {
+	ttl            time.Duration
+	maxBytes       int64
+	maxResultBytes int64
+	now            func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	size    int64
+	// byBucket indexes the keys of the results by the buckets they read.
+	byBucket map[platform.ID]map[string]struct{}
+	// seq orders the writes to buckets, written is the seq of the last
+	// write to each bucket. A result is only stored when none of its
+	// buckets was written to while the query ran.
+	seq     uint64
+	written map[platform.ID]uint64
+
+	requests      *prometheus.CounterVec
+	invalidations prometheus.Counter
+	bytes         prometheus.Gauge
+}
+
+type entry struct {
+	key       string
+	result    []byte
+	stats     flux.Statistics
+	buckets   []platform.ID
+	expiresAt time.Time
+}
+
+// CacheOptFn is a functional option for configuring a Cache.
+type CacheOptFn func(*Cache)
+
+// WithTTL sets the time a result is served from the cache.
+func WithTTL(ttl time.Duration) CacheOptFn {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// WithMaxBytes bounds the size of all of the results cached.
+func WithMaxBytes(n int64) CacheOptFn {
+	return func(c *Cache) {
+		c.maxBytes = n
+	}
+}
+
+// WithMaxResultBytes bounds the size of a result cached, larger results
+// are not cached.
+func WithMaxResultBytes(n int64) CacheOptFn {
+	return func(c *Cache) {
+		c.maxResultBytes = n
+	}
+}
+
+// NewCache constructs an empty Cache.
+func NewCache(opts ...CacheOptFn) *Cache {
+	c := &Cache{
+		ttl:            DefaultTTL,
+		maxBytes:       DefaultMaxBytes,
+		maxResultBytes: DefaultMaxResultBytes,
+		now:            time.Now,
+		entries:        make(map[string]*list.Element),
+		lru:            list.New(),
+		byBucket:       make(map[platform.ID]map[string]struct{}),
+		written:        make(map[platform.ID]uint64),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "query",
+			Subsystem: "cache",
+			Name:      "requests_total",
+			Help:      "Number of queries looked up in the query result cache by result: hit, miss or uncacheable",
+		}, []string{"result"}),
+		invalidations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "query",
+			Subsystem: "cache",
+			Name:      "invalidations_total",
+			Help:      "Number of cached query results dropped because their buckets changed",
+		}),
+		bytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "query",
+			Subsystem: "cache",
+			Name:      "bytes",
+			Help:      "Size of the query results cached",
+		}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// PrometheusCollectors returns the metrics of the cache.
+func (c *Cache) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{c.requests, c.invalidations, c.bytes}
+}
+
+// get returns the result of key unless it expired.
+func (c *Cache) get(key string) (*entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expiresAt) {
+		c.remove(el)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return e, true
+}
+
+// begin returns the position of a query about to run among the writes to
+// buckets, which is passed to put once it finished.
+func (c *Cache) begin() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seq
+}
+
+// put stores the result of the query started at seq, unless one of its
+// buckets was written to since.
+func (c *Cache) put(key string, seq uint64, buckets []platform.ID, result []byte, stats flux.Statistics) {
+	size := int64(len(result))
+	if size > c.maxResultBytes || size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range buckets {
+		if c.written[id] > seq {
+			return
+		}
+	}
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	for c.size+size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+
+	e := &entry{
+		key:       key,
+		result:    result,
+		stats:     stats,
+		buckets:   buckets,
+		expiresAt: c.now().Add(c.ttl),
+	}
+	c.entries[key] = c.lru.PushFront(e)
+	c.size += size
+	for _, id := range buckets {
+		keys, ok := c.byBucket[id]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.byBucket[id] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	c.bytes.Set(float64(c.size))
+}
+
+// InvalidateBucket drops the results of the queries reading the bucket. It
+// is called when data of the bucket is written or deleted.
+func (c *Cache) InvalidateBucket(bucketID platform.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	c.written[bucketID] = c.seq
+	for key := range c.byBucket[bucketID] {
+		c.remove(c.entries[key])
+		c.invalidations.Inc()
+	}
+}
+
+// remove drops a result from the cache, c.mu must be held.
+func (c *Cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*entry)
+	delete(c.entries, e.key)
+	c.size -= int64(len(e.result))
+	for _, id := range e.buckets {
+		keys := c.byBucket[id]
+		delete(keys, e.key)
+		if len(keys) == 0 {
+			delete(c.byBucket, id)
+		}
+	}
+	c.bytes.Set(float64(c.size))
+}
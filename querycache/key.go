@@ -0,0 +1,208 @@
+package querycache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/ast/astutil"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2/query"
+)
+
+// pureImports are the packages a cached query may import. The others can
+// read data from outside of the buckets of the query or have side effects.
+var pureImports = map[string]bool{
+	"array":                      true,
+	"date":                       true,
+	"dict":                       true,
+	"experimental/aggregate":     true,
+	"influxdata/influxdb":        true,
+	"influxdata/influxdb/schema": true,
+	"interpolate":                true,
+	"join":                       true,
+	"math":                       true,
+	"regexp":                     true,
+	"strings":                    true,
+	"timezone":                   true,
+	"types":                      true,
+	"universe":                   true,
+}
+
+// uncachedCalls are the functions that prevent a query from being cached:
+// writing to a bucket, or listing the buckets the token can read.
+var uncachedCalls = map[string]bool{
+	"to":      true,
+	"wideTo":  true,
+	"buckets": true,
+}
+
+// remoteParams read a bucket of another organization or server. Their
+// buckets cannot be invalidated.
+var remoteParams = map[string]bool{
+	"org":   true,
+	"orgID": true,
+	"host":  true,
+	"token": true,
+}
+
+// source is a bucket read by a query, by name or by ID.
+type source struct {
+	name string
+	id   string
+}
+
+// analyze returns the cache key of the request and the buckets it reads.
+// It reports false when the request cannot be cached.
+//
+// The key is the organization, the query and extern formatted so that
+// whitespace does not matter, the dialect of the results, and the now of
+// the query. Queries run at the current time share the key of now for the
+// TTL of their result, queries at another time are keyed by that time.
+func analyze(req *query.ProxyRequest, now time.Time, ttl time.Duration) (string, []source, bool) {
+	var (
+		pkg     *ast.Package
+		extern  json.RawMessage
+		queryAt time.Time
+	)
+	switch c := req.Request.Compiler.(type) {
+	case lang.FluxCompiler:
+		pkg = parser.ParseSource(c.Query)
+		extern, queryAt = c.Extern, c.Now
+	case lang.ASTCompiler:
+		pkg = new(ast.Package)
+		if err := json.Unmarshal(c.AST, pkg); err != nil {
+			return "", nil, false
+		}
+		extern, queryAt = c.Extern, c.Now
+	default:
+		return "", nil, false
+	}
+	if ast.Check(pkg) > 0 {
+		// Let the query fail as usual.
+		return "", nil, false
+	}
+
+	v := &sourceVisitor{cacheable: true}
+	ast.Walk(v, pkg)
+	if !v.cacheable || len(v.sources) == 0 {
+		return "", nil, false
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", req.Request.OrganizationID)
+	for _, f := range pkg.Files {
+		s, err := astutil.Format(f)
+		if err != nil {
+			return "", nil, false
+		}
+		fmt.Fprintf(h, "%s\n", s)
+	}
+	if len(extern) > 0 {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, extern); err != nil {
+			return "", nil, false
+		}
+		fmt.Fprintf(h, "%s\n", buf.Bytes())
+	}
+	fmt.Fprintf(h, "%T%+v\n", req.Dialect, req.Dialect)
+	if d := now.Sub(queryAt); queryAt.IsZero() || (d > -ttl && d < ttl) {
+		fmt.Fprintln(h, "now")
+	} else {
+		fmt.Fprintln(h, queryAt.UTC().Format(time.RFC3339Nano))
+	}
+	return hex.EncodeToString(h.Sum(nil)), v.sources, true
+}
+
+// sourceVisitor collects the buckets read by a query, and whether the query
+// can be cached.
+type sourceVisitor struct {
+	sources   []source
+	cacheable bool
+}
+
+func (v *sourceVisitor) Visit(node ast.Node) ast.Visitor {
+	if !v.cacheable {
+		return nil
+	}
+
+	switch n := node.(type) {
+	case *ast.ImportDeclaration:
+		if n.Path == nil || !pureImports[n.Path.Value] {
+			v.cacheable = false
+		}
+	case *ast.CallExpression:
+		if uncachedCalls[calleeName(n.Callee)] {
+			v.cacheable = false
+			return nil
+		}
+		for _, arg := range n.Arguments {
+			obj, ok := arg.(*ast.ObjectExpression)
+			if !ok {
+				continue
+			}
+			v.params(obj)
+		}
+	}
+	return v
+}
+
+func (v *sourceVisitor) Done(ast.Node) {}
+
+// params records the bucket of a call. A bucket that is not a literal is
+// only known once the query runs.
+func (v *sourceVisitor) params(obj *ast.ObjectExpression) {
+	var (
+		src    source
+		bucket bool
+		remote bool
+	)
+	for _, p := range obj.Properties {
+		if p.Key == nil {
+			continue
+		}
+		key := p.Key.Key()
+		switch {
+		case key == "bucket" || key == "bucketID":
+			bucket = true
+			lit, ok := p.Value.(*ast.StringLiteral)
+			if !ok {
+				v.cacheable = false
+				return
+			}
+			if key == "bucket" {
+				src.name = lit.Value
+			} else {
+				src.id = lit.Value
+			}
+		case remoteParams[key]:
+			remote = true
+		}
+	}
+	if !bucket {
+		return
+	}
+	if remote {
+		v.cacheable = false
+		return
+	}
+	v.sources = append(v.sources, src)
+}
+
+// calleeName is the name of a function called by its identifier or as a
+// member of a package, such as experimental.to.
+func calleeName(e ast.Expression) string {
+	switch c := e.(type) {
+	case *ast.Identifier:
+		return c.Name
+	case *ast.MemberExpression:
+		return strings.Trim(c.Property.Key(), `"`)
+	}
+	return ""
+}
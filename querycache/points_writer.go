@@ -0,0 +1,45 @@
+package querycache
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// PointsWriter writes points to a bucket.
+type PointsWriter interface {
+	WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, p []models.Point) error
+}
+
+// InvalidatingPointsWriter wraps an underlying points writer and drops the
+// cached results of the buckets written to. It must wrap the storage engine
+// below any write buffer, so that writes the buffer replays later drop the
+// results cached while they were queued.
+type InvalidatingPointsWriter struct {
+	Underlying PointsWriter
+	Cache      *Cache
+}
+
+// WritePoints writes points to the underlying PointsWriter. The results of
+// the bucket are dropped even when the write fails, since some of the
+// points may have been written.
+func (w *InvalidatingPointsWriter) WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, p []models.Point) error {
+	defer w.Cache.InvalidateBucket(bucketID)
+	return w.Underlying.WritePoints(ctx, orgID, bucketID, p)
+}
+
+// InvalidatingDeleteService wraps a delete service and drops the cached
+// results of the buckets data is deleted from.
+type InvalidatingDeleteService struct {
+	Underlying influxdb.DeleteService
+	Cache      *Cache
+}
+
+// DeleteBucketRangePredicate deletes data of the bucket and drops its
+// results.
+func (s *InvalidatingDeleteService) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID platform.ID, min, max int64, pred influxdb.Predicate) error {
+	defer s.Cache.InvalidateBucket(bucketID)
+	return s.Underlying.DeleteBucketRangePredicate(ctx, orgID, bucketID, min, max, pred)
+}
@@ -0,0 +1,65 @@
+package querycache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/influxdata/influxdb/v2/writebuffer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// failingEngine fails writes with err while it is set.
+type failingEngine struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (e *failingEngine) WritePoints(_ context.Context, _, _ platform.ID, _ []models.Point) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+func (e *failingEngine) setErr(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.err = err
+}
+
+func TestInvalidatingPointsWriter_WriteBuffer(t *testing.T) {
+	const (
+		orgID    = platform.ID(1)
+		bucketID = platform.ID(2)
+	)
+	ctx := context.Background()
+	c := NewCache(WithTTL(time.Minute))
+	engine := &failingEngine{err: tsm1.ErrCacheMemorySizeLimitExceeded(2, 1)}
+
+	w, err := writebuffer.Open(zaptest.NewLogger(t), &InvalidatingPointsWriter{Underlying: engine, Cache: c}, t.TempDir(), 32<<20)
+	require.NoError(t, err)
+	defer w.Close()
+
+	points, err := models.ParsePointsString("m,t=a f=1 1")
+	require.NoError(t, err)
+	require.NoError(t, w.WritePoints(ctx, orgID, bucketID, points))
+	require.False(t, w.Status().Empty)
+
+	// a query run while the write is queued does not see its points
+	c.put("q", c.begin(), []platform.ID{bucketID}, []byte("result"), flux.Statistics{})
+
+	engine.setErr(nil)
+	drainCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	require.NoError(t, w.Drain(drainCtx))
+
+	// replaying the write drops the result
+	_, ok := c.get("q")
+	require.False(t, ok)
+}
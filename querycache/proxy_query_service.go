@@ -0,0 +1,149 @@
+package querycache
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/metadata"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/check"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/query"
+)
+
+// MetadataKey is the key of the statistics metadata of a query served from
+// the cache.
+const MetadataKey = "query-cache"
+
+// BucketFinder resolves the names of the buckets read by queries.
+type BucketFinder interface {
+	FindBucketByName(ctx context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error)
+}
+
+// ProxyQueryService serves the results of queries from the cache, and
+// caches the results of the queries it runs with the underlying service.
+type ProxyQueryService struct {
+	Underlying query.ProxyQueryService
+	Cache      *Cache
+	Buckets    BucketFinder
+}
+
+var _ query.ProxyQueryService = (*ProxyQueryService)(nil)
+
+// NewProxyQueryService constructs a ProxyQueryService caching the results
+// of the underlying service.
+func NewProxyQueryService(underlying query.ProxyQueryService, cache *Cache, buckets BucketFinder) *ProxyQueryService {
+	return &ProxyQueryService{
+		Underlying: underlying,
+		Cache:      cache,
+		Buckets:    buckets,
+	}
+}
+
+// Query writes the cached result of the request, or runs it and caches its
+// result. A cached result is only served to a caller allowed to read all of
+// the buckets of the query.
+func (s *ProxyQueryService) Query(ctx context.Context, w io.Writer, req *query.ProxyRequest) (flux.Statistics, error) {
+	key, sources, ok := analyze(req, s.Cache.now(), s.Cache.ttl)
+	if !ok {
+		s.Cache.requests.WithLabelValues("uncacheable").Inc()
+		return s.Underlying.Query(ctx, w, req)
+	}
+	orgID := req.Request.OrganizationID
+	buckets, ok := s.resolve(ctx, orgID, sources)
+	if !ok {
+		s.Cache.requests.WithLabelValues("uncacheable").Inc()
+		return s.Underlying.Query(ctx, w, req)
+	}
+
+	if e, ok := s.Cache.get(key); ok && authorized(ctx, orgID, buckets) {
+		s.Cache.requests.WithLabelValues("hit").Inc()
+		if _, err := w.Write(e.result); err != nil {
+			return flux.Statistics{}, err
+		}
+		stats := flux.Statistics{Metadata: make(metadata.Metadata)}
+		stats.Metadata.Add(MetadataKey, "hit")
+		if rows, ok := e.stats.Metadata[query.RowsMetadataKey]; ok {
+			stats.Metadata[query.RowsMetadataKey] = rows
+		}
+		return stats, nil
+	}
+
+	s.Cache.requests.WithLabelValues("miss").Inc()
+	seq := s.Cache.begin()
+	cw := &capturingWriter{w: w, max: s.Cache.maxResultBytes}
+	stats, err := s.Underlying.Query(ctx, cw, req)
+	if err != nil || len(stats.RuntimeErrors) > 0 || cw.overflow {
+		return stats, err
+	}
+	s.Cache.put(key, seq, buckets, cw.buf.Bytes(), stats)
+	return stats, nil
+}
+
+// Check checks the underlying service.
+func (s *ProxyQueryService) Check(ctx context.Context) check.Response {
+	return s.Underlying.Check(ctx)
+}
+
+// resolve returns the IDs of the buckets of the query. It reports false
+// when one is not found, the query then fails as usual.
+func (s *ProxyQueryService) resolve(ctx context.Context, orgID platform.ID, sources []source) ([]platform.ID, bool) {
+	seen := make(map[platform.ID]bool, len(sources))
+	ids := make([]platform.ID, 0, len(sources))
+	for _, src := range sources {
+		var id platform.ID
+		if src.id != "" {
+			parsed, err := platform.IDFromString(src.id)
+			if err != nil {
+				return nil, false
+			}
+			id = *parsed
+		} else {
+			b, err := s.Buckets.FindBucketByName(ctx, orgID, src.name)
+			if err != nil {
+				return nil, false
+			}
+			id = b.ID
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, true
+}
+
+// authorized reports whether the caller may read all of the buckets.
+func authorized(ctx context.Context, orgID platform.ID, buckets []platform.ID) bool {
+	for _, id := range buckets {
+		if _, _, err := authorizer.AuthorizeRead(ctx, influxdb.BucketsResourceType, id, orgID); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// capturingWriter writes through to w and keeps a copy of what was written
+// until it exceeds max bytes.
+type capturingWriter struct {
+	w        io.Writer
+	buf      bytes.Buffer
+	max      int64
+	overflow bool
+}
+
+func (w *capturingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if !w.overflow {
+		if int64(w.buf.Len()+n) > w.max {
+			w.overflow = true
+			w.buf = bytes.Buffer{}
+		} else {
+			w.buf.Write(p[:n])
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,137 @@
+package querycache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/query"
+	querymock "github.com/influxdata/influxdb/v2/query/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyQueryService(t *testing.T) {
+	const (
+		orgID    = platform.ID(1)
+		bucketID = platform.ID(2)
+		otherID  = platform.ID(3)
+	)
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewCache(WithTTL(10 * time.Second))
+	cache.now = func() time.Time { return now }
+
+	var runs int
+	underlying := &querymock.ProxyQueryService{
+		QueryF: func(ctx context.Context, w io.Writer, req *query.ProxyRequest) (flux.Statistics, error) {
+			runs++
+			_, err := w.Write([]byte("result"))
+			return flux.Statistics{}, err
+		},
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByNameFn = func(ctx context.Context, id platform.ID, name string) (*influxdb.Bucket, error) {
+		return &influxdb.Bucket{ID: bucketID, OrgID: id, Name: name}, nil
+	}
+	s := NewProxyQueryService(underlying, cache, buckets)
+
+	perm, err := influxdb.NewPermissionAtID(bucketID, influxdb.ReadAction, influxdb.BucketsResourceType, orgID)
+	require.NoError(t, err)
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{
+		Status:      influxdb.Active,
+		OrgID:       orgID,
+		Permissions: []influxdb.Permission{*perm},
+	})
+
+	run := func(ctx context.Context, q string) string {
+		t.Helper()
+		var buf bytes.Buffer
+		_, err := s.Query(ctx, &buf, &query.ProxyRequest{
+			Request: query.Request{
+				OrganizationID: orgID,
+				Compiler:       lang.FluxCompiler{Query: q, Now: now},
+			},
+			Dialect: &csv.Dialect{},
+		})
+		require.NoError(t, err)
+		return buf.String()
+	}
+
+	const q = `from(bucket: "telegraf") |> range(start: -1h)`
+	require.Equal(t, "result", run(ctx, q))
+	require.Equal(t, "result", run(ctx, `from(bucket:"telegraf")
+	|> range(start:-1h)`))
+	require.Equal(t, 1, runs, "formatting does not change the key")
+
+	t.Run("unauthorized", func(t *testing.T) {
+		ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{Status: influxdb.Active, OrgID: orgID})
+		run(ctx, q)
+		require.Equal(t, 2, runs)
+	})
+
+	t.Run("invalidated by writes", func(t *testing.T) {
+		runs = 0
+		cache.InvalidateBucket(otherID)
+		run(ctx, q)
+		require.Equal(t, 0, runs)
+
+		cache.InvalidateBucket(bucketID)
+		run(ctx, q)
+		run(ctx, q)
+		require.Equal(t, 1, runs)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		runs = 0
+		now = now.Add(10 * time.Second)
+		run(ctx, q)
+		require.Equal(t, 1, runs)
+	})
+
+	t.Run("uncacheable", func(t *testing.T) {
+		for _, q := range []string{
+			`from(bucket: "telegraf") |> range(start: -1h) |> to(bucket: "copy")`,
+			`from(bucket: v.bucket) |> range(start: -1h)`,
+			`from(bucket: "telegraf", host: "https://example.com") |> range(start: -1h)`,
+			`import "http"
+from(bucket: "telegraf") |> range(start: -1h)`,
+			`buckets()`,
+		} {
+			runs = 0
+			run(ctx, q)
+			run(ctx, q)
+			require.Equal(t, 2, runs, q)
+		}
+	})
+}
+
+func TestCache_Evict(t *testing.T) {
+	c := NewCache(WithMaxBytes(10))
+	c.put("a", c.begin(), []platform.ID{1}, []byte("aaaaa"), flux.Statistics{})
+	c.put("b", c.begin(), []platform.ID{1}, []byte("bbbbb"), flux.Statistics{})
+	_, ok := c.get("a")
+	require.True(t, ok)
+
+	// b was used least recently.
+	c.put("c", c.begin(), []platform.ID{2}, []byte("ccccc"), flux.Statistics{})
+	_, ok = c.get("b")
+	require.False(t, ok)
+	_, ok = c.get("a")
+	require.True(t, ok)
+
+	// A result of a query run while its bucket was written is not stored.
+	seq := c.begin()
+	c.InvalidateBucket(3)
+	c.put("d", seq, []platform.ID{3}, []byte("d"), flux.Statistics{})
+	_, ok = c.get("d")
+	require.False(t, ok)
+}
@@ -0,0 +1,83 @@
+package queryhistory
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+)
+
+const measurement = "queries"
+
+// BucketSink writes entries to a bucket chosen by the operator, as points of
+// the queries measurement tagged with the organization and user running
+// them. Entries are kept for the retention period of the bucket. The bucket
+// should belong to an organization only operators are members of, as the
+// entries of every organization are written to it.
+type BucketSink struct {
+	pw       storage.PointsWriter
+	buckets  influxdb.BucketService
+	bucketID platform.ID
+}
+
+// NewBucketSink constructs a BucketSink writing entries with pw to the
+// bucket with the ID, found with buckets.
+func NewBucketSink(pw storage.PointsWriter, buckets influxdb.BucketService, bucketID platform.ID) *BucketSink {
+	return &BucketSink{pw: pw, buckets: buckets, bucketID: bucketID}
+}
+
+// WriteEntries writes the entries to the bucket.
+func (s *BucketSink) WriteEntries(ctx context.Context, entries []Entry) error {
+	bucket, err := s.buckets.FindBucketByID(ctx, s.bucketID)
+	if err != nil {
+		return err
+	}
+
+	points := make(models.Points, 0, len(entries))
+	for _, e := range entries {
+		pt, err := entryPoint(e)
+		if err != nil {
+			return err
+		}
+		points = append(points, pt)
+	}
+	return s.pw.WritePoints(ctx, bucket.OrgID, bucket.ID, points)
+}
+
+func entryPoint(e Entry) (models.Point, error) {
+	status := "success"
+	if e.Error != "" {
+		status = "error"
+	}
+	tags := map[string]string{
+		"orgID":  e.OrgID,
+		"status": status,
+	}
+	if e.UserID != "" {
+		tags["userID"] = e.UserID
+	}
+	if e.Compiler != "" {
+		tags["compiler"] = e.Compiler
+	}
+	fields := map[string]interface{}{
+		"queryHash":     e.QueryHash,
+		"durationNs":    int64(e.Duration),
+		"rows":          e.Rows,
+		"responseBytes": e.ResponseBytes,
+		"scannedBytes":  e.ScannedBytes,
+		"scannedValues": e.ScannedValues,
+		"cached":        e.Cached,
+	}
+	if e.AuthorizationID != "" {
+		fields["authorizationID"] = e.AuthorizationID
+	}
+	if e.TraceID != "" {
+		fields["traceID"] = e.TraceID
+	}
+	if e.Error != "" {
+		fields["error"] = e.Error
+	}
+	return models.NewPoint(measurement, models.NewTags(tags), fields, e.Time)
+}
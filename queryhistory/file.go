@@ -0,0 +1,133 @@
+package queryhistory
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	filePrefix = "queries-"
+	fileSuffix = ".jsonl"
+	dayLayout  = "2006-01-02"
+)
+
+// DefaultRetention is the default time the files of a FileSink are kept.
+const DefaultRetention = 7 * 24 * time.Hour
+
+// FileSink appends entries to a file per UTC day in a directory, one JSON
+// entry per line, and removes the files of the days older than its
+// retention period.
+type FileSink struct {
+	dir       string
+	retention time.Duration
+	now       func() time.Time
+
+	mu  sync.Mutex
+	f   *os.File
+	day string
+}
+
+// OpenFileSink opens a FileSink writing to dir, creating it when it does
+// not exist. Files are kept for retention, or forever when it is zero.
+func OpenFileSink(dir string, retention time.Duration) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	s := &FileSink{dir: dir, retention: retention, now: time.Now}
+	if err := s.prune(s.now()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// WriteEntries appends the entries to the file of the day.
+func (s *FileSink) WriteEntries(_ context.Context, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var b []byte
+	for _, e := range entries {
+		eb, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b = append(append(b, eb...), '\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.rotate(s.now()); err != nil {
+		return err
+	}
+	_, err := s.f.Write(b)
+	return err
+}
+
+// rotate opens the file of the day of now, and prunes the files past
+// retention when the day changes.
+func (s *FileSink) rotate(now time.Time) error {
+	day := now.UTC().Format(dayLayout)
+	if s.f != nil && day == s.day {
+		return nil
+	}
+	if s.f != nil {
+		if err := s.f.Close(); err != nil {
+			return err
+		}
+		s.f = nil
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, filePrefix+day+fileSuffix), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	s.f, s.day = f, day
+	return s.prune(now)
+}
+
+// prune removes the files of the days that ended before the retention
+// period.
+func (s *FileSink) prune(now time.Time) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	cutoff := now.Add(-s.retention)
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		day, err := time.Parse(dayLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if err != nil {
+			continue
+		}
+		if day.Add(24 * time.Hour).Before(cutoff) {
+			if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes the file of the day.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
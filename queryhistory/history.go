@@ -0,0 +1,262 @@
+// Package queryhistory records the queries made through the API, with the
+// organization and user running them, a hash of their text, how long they
+// took, the rows they returned, the bytes they scanned and their error, so
+// the expensive queries can be traced to who ran them after the fact.
+//
+// Entries are written to a directory of daily files, kept for a retention
+// period, and to a bucket, with the retention period of the bucket.
+package queryhistory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/querycache"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	// queueSize is the number of entries waiting to be written. Entries
+	// logged beyond it are dropped, so queries never wait on the sinks.
+	queueSize = 4096
+	// maxBatch is the number of entries written to the sinks at once.
+	maxBatch = 512
+	// flushInterval is the longest an entry waits before it is written.
+	flushInterval = time.Second
+	// writeTimeout bounds the time taken to write a batch to a sink.
+	writeTimeout = 10 * time.Second
+)
+
+var (
+	errQueueFull = errors.New("query history queue is full")
+	errClosed    = errors.New("query history is closed")
+)
+
+// Entry is the record of a query.
+type Entry struct {
+	// Time is the time the query completed.
+	Time  time.Time `json:"time"`
+	OrgID string    `json:"orgID"`
+	// UserID and AuthorizationID identify the token the query was run
+	// with. AuthorizationID is empty for sessions.
+	UserID          string `json:"userID,omitempty"`
+	AuthorizationID string `json:"authorizationID,omitempty"`
+	TraceID         string `json:"traceID,omitempty"`
+	// Compiler is the type of the query, such as flux or ast.
+	Compiler string `json:"compiler"`
+	// QueryHash is the SHA-256 hash of the text of the query, the same for
+	// every run of the query whatever its now and extern.
+	QueryHash string `json:"queryHash"`
+	// Duration is the time taken to run the query and write its response,
+	// in nanoseconds.
+	Duration      time.Duration `json:"duration"`
+	Rows          int64         `json:"rows"`
+	ResponseBytes int64         `json:"responseBytes"`
+	ScannedBytes  int64         `json:"scannedBytes"`
+	ScannedValues int64         `json:"scannedValues"`
+	// Cached is true when the result was served from the query cache.
+	Cached bool   `json:"cached,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// NewEntry returns the entry of a logged query.
+func NewEntry(l query.Log) Entry {
+	e := Entry{
+		Time:          l.Time.UTC(),
+		OrgID:         l.OrganizationID.String(),
+		TraceID:       l.TraceID,
+		Duration:      l.Duration,
+		ResponseBytes: l.ResponseSize,
+		Rows:          sumMetadata(l.Statistics.Metadata[query.RowsMetadataKey]),
+		ScannedBytes:  sumMetadata(l.Statistics.Metadata["influxdb/scanned-bytes"]),
+		ScannedValues: sumMetadata(l.Statistics.Metadata["influxdb/scanned-values"]),
+		Cached:        len(l.Statistics.Metadata[querycache.MetadataKey]) > 0,
+	}
+	if req := l.ProxyRequest; req != nil {
+		if a := req.Request.Authorization; a != nil {
+			e.UserID = a.UserID.String()
+			if a.ID.Valid() {
+				e.AuthorizationID = a.ID.String()
+			}
+		}
+		if c := req.Request.Compiler; c != nil {
+			e.Compiler = string(c.CompilerType())
+			e.QueryHash = queryHash(c)
+		}
+	}
+	if l.Error != nil {
+		e.Error = l.Error.Error()
+	} else if len(l.Statistics.RuntimeErrors) > 0 {
+		e.Error = strings.Join(l.Statistics.RuntimeErrors, "; ")
+	}
+	return e
+}
+
+// queryHash hashes the text of the query of the compiler.
+func queryHash(c flux.Compiler) string {
+	var text []byte
+	switch c := c.(type) {
+	case lang.FluxCompiler:
+		text = []byte(c.Query)
+	case lang.ASTCompiler:
+		text = c.AST
+	default:
+		// a compiler is sent as JSON
+		text, _ = json.Marshal(c)
+	}
+	sum := sha256.Sum256(text)
+	return hex.EncodeToString(sum[:])
+}
+
+// sumMetadata adds the integers of the statistics metadata of a key, which
+// holds a value per source of the query.
+func sumMetadata(values []interface{}) int64 {
+	var n int64
+	for _, v := range values {
+		switch v := v.(type) {
+		case int64:
+			n += v
+		case int:
+			n += int64(v)
+		}
+	}
+	return n
+}
+
+// Sink stores query history entries.
+type Sink interface {
+	WriteEntries(ctx context.Context, entries []Entry) error
+}
+
+// Logger is a query.Logger recording the entries of queries to its sinks.
+// Entries are queued and written in batches in the background, so queries
+// do not wait on the sinks; when the queue is full they are dropped.
+type Logger struct {
+	log   *zap.Logger
+	sinks []Sink
+
+	mu      sync.RWMutex
+	closed  bool
+	entries chan Entry
+	done    chan struct{}
+
+	recorded prometheus.Counter
+	dropped  prometheus.Counter
+}
+
+var _ query.Logger = (*Logger)(nil)
+
+// NewLogger constructs a Logger recording entries to sinks. It writes them
+// until it is closed.
+func NewLogger(log *zap.Logger, sinks ...Sink) *Logger {
+	l := &Logger{
+		log:     log,
+		sinks:   sinks,
+		entries: make(chan Entry, queueSize),
+		done:    make(chan struct{}),
+		recorded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "query",
+			Subsystem: "history",
+			Name:      "recorded_total",
+			Help:      "Number of query history entries written to every sink",
+		}),
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "query",
+			Subsystem: "history",
+			Name:      "dropped_total",
+			Help:      "Number of query history entries dropped as the queue was full",
+		}),
+	}
+	go l.run()
+	return l
+}
+
+// PrometheusCollectors returns the metrics of the logger.
+func (l *Logger) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{l.recorded, l.dropped}
+}
+
+// Log queues the entry of the query.
+func (l *Logger) Log(ql query.Log) error {
+	e := NewEntry(ql)
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		return errClosed
+	}
+	select {
+	case l.entries <- e:
+		return nil
+	default:
+		l.dropped.Inc()
+		return errQueueFull
+	}
+}
+
+// Close writes the queued entries and stops the logger.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.entries)
+	}
+	l.mu.Unlock()
+	<-l.done
+	return nil
+}
+
+func (l *Logger) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch []Entry
+	for {
+		select {
+		case e, ok := <-l.entries:
+			if !ok {
+				l.write(batch)
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) < maxBatch {
+				continue
+			}
+		case <-ticker.C:
+		}
+		l.write(batch)
+		batch = nil
+	}
+}
+
+// write writes the batch to every sink. A sink failing to store the batch
+// does not stop the others from storing it.
+func (l *Logger) write(batch []Entry) {
+	if len(batch) == 0 {
+		return
+	}
+	failed := false
+	for _, s := range l.sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+		if err := s.WriteEntries(ctx, batch); err != nil {
+			l.log.Error("Failed to record query history", zap.Int("entries", len(batch)), zap.Error(err))
+			failed = true
+		}
+		cancel()
+	}
+	if !failed {
+		l.recorded.Add(float64(len(batch)))
+	}
+}
@@ -0,0 +1,124 @@
+package queryhistory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/metadata"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (s *recordingSink) WriteEntries(_ context.Context, entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entries...)
+	return nil
+}
+
+func TestNewEntry(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	stats := flux.Statistics{Metadata: make(metadata.Metadata)}
+	stats.Metadata.Add(query.RowsMetadataKey, int64(12))
+	stats.Metadata.Add("influxdb/scanned-bytes", int64(100))
+	stats.Metadata.Add("influxdb/scanned-bytes", int64(50))
+	stats.Metadata.Add("influxdb/scanned-values", int64(30))
+
+	req := &query.ProxyRequest{
+		Request: query.Request{
+			OrganizationID: platform.ID(1),
+			Authorization:  &influxdb.Authorization{ID: platform.ID(2), UserID: platform.ID(3), Token: "secret"},
+			Compiler:       lang.FluxCompiler{Query: `from(bucket: "b") |> range(start: -1h)`, Now: now},
+		},
+	}
+	e := NewEntry(query.Log{
+		Time:           now,
+		Duration:       2 * time.Second,
+		OrganizationID: platform.ID(1),
+		ProxyRequest:   req,
+		ResponseSize:   1024,
+		Statistics:     stats,
+		Error:          errors.New("boom"),
+	})
+
+	assert.Equal(t, Entry{
+		Time:            now,
+		OrgID:           platform.ID(1).String(),
+		UserID:          platform.ID(3).String(),
+		AuthorizationID: platform.ID(2).String(),
+		Compiler:        string(lang.FluxCompilerType),
+		QueryHash:       e.QueryHash,
+		Duration:        2 * time.Second,
+		Rows:            12,
+		ResponseBytes:   1024,
+		ScannedBytes:    150,
+		ScannedValues:   30,
+		Error:           "boom",
+	}, e)
+
+	// The hash is the same for every run of the query.
+	req2 := *req
+	req2.Request.Compiler = lang.FluxCompiler{Query: `from(bucket: "b") |> range(start: -1h)`, Now: now.Add(time.Hour)}
+	assert.Equal(t, e.QueryHash, NewEntry(query.Log{ProxyRequest: &req2}).QueryHash)
+	req2.Request.Compiler = lang.FluxCompiler{Query: `from(bucket: "c") |> range(start: -1h)`}
+	assert.NotEqual(t, e.QueryHash, NewEntry(query.Log{ProxyRequest: &req2}).QueryHash)
+}
+
+func TestLogger_Close(t *testing.T) {
+	sink := &recordingSink{}
+	l := NewLogger(zaptest.NewLogger(t), sink)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.Log(query.Log{OrganizationID: platform.ID(1)}))
+	}
+
+	// Closing writes the queued entries.
+	require.NoError(t, l.Close())
+	assert.Len(t, sink.entries, 3)
+	assert.Error(t, l.Log(query.Log{OrganizationID: platform.ID(1)}))
+}
+
+func TestFileSink_Retention(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "queries-2021-02-01.jsonl")
+	kept := filepath.Join(dir, "queries-2021-03-01.jsonl")
+	other := filepath.Join(dir, "other.jsonl")
+	for _, path := range []string{old, kept, other} {
+		require.NoError(t, os.WriteFile(path, nil, 0600))
+	}
+
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	s := &FileSink{dir: dir, retention: 7 * 24 * time.Hour, now: func() time.Time { return now }}
+	defer s.Close()
+
+	require.NoError(t, s.WriteEntries(context.Background(), []Entry{{Time: now, OrgID: "0000000000000001"}}))
+	require.NoError(t, s.WriteEntries(context.Background(), []Entry{{Time: now, OrgID: "0000000000000002"}}))
+
+	b, err := os.ReadFile(filepath.Join(dir, "queries-2021-03-04.jsonl"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(b), "\n"))
+
+	// The day ending more than the retention period ago is removed.
+	_, err = os.Stat(old)
+	assert.True(t, os.IsNotExist(err))
+	for _, path := range []string{kept, other} {
+		_, err = os.Stat(path)
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,101 @@
+package influxdb
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+const (
+	// MaxResourceAnnotations is the maximum number of annotations a resource may have.
+	MaxResourceAnnotations = 64
+	// MaxResourceAnnotationKeyLength is the maximum length of an annotation key.
+	MaxResourceAnnotationKeyLength = 128
+	// MaxResourceAnnotationValueLength is the maximum length of an annotation value.
+	MaxResourceAnnotationValueLength = 1024
+)
+
+// ResourceAnnotations are arbitrary key/value pairs stored alongside a resource,
+// e.g. team=payments or cost-center=123. Unlike labels, they are scoped to a
+// single resource and can be used to express structured ownership metadata.
+type ResourceAnnotations map[string]string
+
+// Valid returns an error if the annotations exceed the size limits or contain an empty key.
+func (a ResourceAnnotations) Valid() error {
+	if len(a) > MaxResourceAnnotations {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("a resource may not have more than %d annotations", MaxResourceAnnotations),
+		}
+	}
+	for k, v := range a {
+		if k == "" {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "annotation key must not be empty",
+			}
+		}
+		if len(k) > MaxResourceAnnotationKeyLength {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("annotation key %q exceeds %d characters", k, MaxResourceAnnotationKeyLength),
+			}
+		}
+		if len(v) > MaxResourceAnnotationValueLength {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("value of annotation %q exceeds %d characters", k, MaxResourceAnnotationValueLength),
+			}
+		}
+	}
+	return nil
+}
+
+// Matches reports whether every key/value pair of filter is present in the annotations.
+// An empty filter matches all annotations.
+func (a ResourceAnnotations) Matches(filter ResourceAnnotations) bool {
+	for k, v := range filter {
+		if got, ok := a[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a copy of the annotations.
+func (a ResourceAnnotations) Clone() ResourceAnnotations {
+	if a == nil {
+		return nil
+	}
+	other := make(ResourceAnnotations, len(a))
+	for k, v := range a {
+		other[k] = v
+	}
+	return other
+}
+
+var annotationsParamRe = regexp.MustCompile(`^annotations\[(.+)\]$`)
+
+// DecodeResourceAnnotationsFilter returns the annotations filter encoded in the
+// query parameters as annotations[key]=value. Nil is returned when no
+// annotation parameters are present.
+func DecodeResourceAnnotationsFilter(vals map[string][]string) ResourceAnnotations {
+	var filter ResourceAnnotations
+	for k, v := range vals {
+		if ss := annotationsParamRe.FindStringSubmatch(k); len(ss) == 2 && len(v) > 0 {
+			if filter == nil {
+				filter = ResourceAnnotations{}
+			}
+			filter[ss[1]] = v[0]
+		}
+	}
+	return filter
+}
+
+// AddQueryParams adds the annotations to the query params as annotations[key]=value.
+func (a ResourceAnnotations) AddQueryParams(qp map[string][]string) {
+	for k, v := range a {
+		qp["annotations["+k+"]"] = []string{v}
+	}
+}
@@ -0,0 +1,91 @@
+package influxdb_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceAnnotations_Valid(t *testing.T) {
+	tooMany := influxdb.ResourceAnnotations{}
+	for i := 0; i <= influxdb.MaxResourceAnnotations; i++ {
+		tooMany[strings.Repeat("k", i+1)] = "v"
+	}
+
+	tests := []struct {
+		name        string
+		annotations influxdb.ResourceAnnotations
+		wantErr     bool
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name:        "valid",
+			annotations: influxdb.ResourceAnnotations{"team": "payments", "cost-center": "123"},
+		},
+		{
+			name:        "empty key",
+			annotations: influxdb.ResourceAnnotations{"": "payments"},
+			wantErr:     true,
+		},
+		{
+			name:        "key too long",
+			annotations: influxdb.ResourceAnnotations{strings.Repeat("k", influxdb.MaxResourceAnnotationKeyLength+1): "v"},
+			wantErr:     true,
+		},
+		{
+			name:        "value too long",
+			annotations: influxdb.ResourceAnnotations{"k": strings.Repeat("v", influxdb.MaxResourceAnnotationValueLength+1)},
+			wantErr:     true,
+		},
+		{
+			name:        "too many annotations",
+			annotations: tooMany,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.annotations.Valid()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestResourceAnnotations_Matches(t *testing.T) {
+	a := influxdb.ResourceAnnotations{"team": "payments", "cost-center": "123"}
+
+	assert.True(t, a.Matches(nil))
+	assert.True(t, a.Matches(influxdb.ResourceAnnotations{"team": "payments"}))
+	assert.True(t, a.Matches(a))
+	assert.False(t, a.Matches(influxdb.ResourceAnnotations{"team": "search"}))
+	assert.False(t, a.Matches(influxdb.ResourceAnnotations{"owner": "payments"}))
+	assert.False(t, influxdb.ResourceAnnotations(nil).Matches(influxdb.ResourceAnnotations{"team": "payments"}))
+}
+
+func TestDecodeResourceAnnotationsFilter(t *testing.T) {
+	qp, err := url.ParseQuery("orgID=020f755c3c082000&annotations[team]=payments&annotations[cost-center]=123&annotations[]=x")
+	require.NoError(t, err)
+
+	filter := influxdb.DecodeResourceAnnotationsFilter(qp)
+	assert.Equal(t, influxdb.ResourceAnnotations{"team": "payments", "cost-center": "123"}, filter)
+
+	got := map[string][]string{}
+	filter.AddQueryParams(got)
+	assert.Equal(t, map[string][]string{
+		"annotations[team]":        {"payments"},
+		"annotations[cost-center]": {"123"},
+	}, got)
+
+	assert.Nil(t, influxdb.DecodeResourceAnnotationsFilter(url.Values{"orgID": {"020f755c3c082000"}}))
+}
@@ -0,0 +1,34 @@
+package schemacache
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// BucketService wraps a bucket service and drops the cached schema of the
+// buckets it deletes.
+type BucketService struct {
+	influxdb.BucketService
+	Cache *Cache
+}
+
+// NewBucketService constructs a BucketService dropping deleted buckets from
+// the cache.
+func NewBucketService(s influxdb.BucketService, cache *Cache) *BucketService {
+	return &BucketService{
+		BucketService: s,
+		Cache:         cache,
+	}
+}
+
+// DeleteBucket deletes the bucket and drops its schema from the cache.
+func (s *BucketService) DeleteBucket(ctx context.Context, id platform.ID) error {
+	if err := s.BucketService.DeleteBucket(ctx, id); err != nil {
+		return err
+	}
+
+	s.Cache.DeleteBucket(id)
+	return nil
+}
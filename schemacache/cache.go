@@ -0,0 +1,388 @@
+// Package schemacache maintains the schema of the data written to each
+// bucket, so the measurements, tag keys, tag values and field keys of a
+// bucket can be listed without querying the storage engine.
+//
+// The cache is fed by the write path and kept in memory, so it only knows
+// the schema of the data written since the server started. Responses carry
+// the time the cache started observing a bucket, which clients use to fall
+// back to schema queries when they need older series.
+package schemacache
+
+import (
+	"container/list"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+const (
+	// DefaultMaxMeasurements is the default number of measurements cached
+	// per bucket.
+	DefaultMaxMeasurements = 10000
+	// DefaultMaxTagKeys is the default number of tag keys cached per
+	// measurement.
+	DefaultMaxTagKeys = 1000
+	// DefaultMaxFields is the default number of field keys cached per
+	// measurement.
+	DefaultMaxFields = 1000
+	// DefaultMaxTagValues is the default number of values cached per tag key.
+	DefaultMaxTagValues = 1000
+)
+
+// numShards is the number of shards the buckets of the cache are spread
+// over, so writes to different buckets rarely wait on the same lock.
+const numShards = 16
+
+// Cache is the schema of the data written to each bucket. Every level is
+// bounded, when a bound is reached the entry seen least recently is evicted
+// and the level is marked truncated.
+type Cache struct {
+	maxMeasurements int
+	maxTagKeys      int
+	maxFields       int
+	maxTagValues    int
+	now             func() time.Time
+
+	shards [numShards]cacheShard
+}
+
+type cacheShard struct {
+	mu      sync.RWMutex
+	buckets map[platform.ID]*bucketSchema
+}
+
+// CacheOptFn is a functional option for configuring a Cache.
+type CacheOptFn func(*Cache)
+
+// WithMaxMeasurements bounds the number of measurements cached per bucket.
+func WithMaxMeasurements(n int) CacheOptFn {
+	return func(c *Cache) {
+		c.maxMeasurements = n
+	}
+}
+
+// WithMaxTagKeys bounds the number of tag keys cached per measurement.
+func WithMaxTagKeys(n int) CacheOptFn {
+	return func(c *Cache) {
+		c.maxTagKeys = n
+	}
+}
+
+// WithMaxFields bounds the number of field keys cached per measurement.
+func WithMaxFields(n int) CacheOptFn {
+	return func(c *Cache) {
+		c.maxFields = n
+	}
+}
+
+// WithMaxTagValues bounds the number of values cached per tag key.
+func WithMaxTagValues(n int) CacheOptFn {
+	return func(c *Cache) {
+		c.maxTagValues = n
+	}
+}
+
+// NewCache constructs an empty Cache.
+func NewCache(opts ...CacheOptFn) *Cache {
+	c := &Cache{
+		maxMeasurements: DefaultMaxMeasurements,
+		maxTagKeys:      DefaultMaxTagKeys,
+		maxFields:       DefaultMaxFields,
+		maxTagValues:    DefaultMaxTagValues,
+		now:             time.Now,
+	}
+	for i := range c.shards {
+		c.shards[i].buckets = make(map[platform.ID]*bucketSchema)
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Cache) shard(bucketID platform.ID) *cacheShard {
+	return &c.shards[uint64(bucketID)%numShards]
+}
+
+// bucketSchema is the schema of a bucket. Its lock is held while a write to
+// the bucket is observed, so writes to other buckets are not blocked.
+type bucketSchema struct {
+	mu           sync.RWMutex
+	orgID        platform.ID
+	observedFrom time.Time
+	updatedAt    time.Time
+
+	// measurements holds a *measurementSchema per measurement.
+	measurements *lru
+}
+
+type measurementSchema struct {
+	// tagKeys holds a *lru of the tag values per tag key.
+	tagKeys *lru
+	fields  *lru
+}
+
+// lru is a bounded set of names ordered by the time they were last seen, so
+// the name seen least recently is found and evicted in constant time.
+type lru struct {
+	max       int
+	truncated bool
+	order     *list.List // of *lruEntry, seen most recently first
+	entries   map[string]*list.Element
+}
+
+type lruEntry struct {
+	name     string
+	lastSeen time.Time
+	value    interface{}
+}
+
+func newLRU(max int) *lru {
+	return &lru{
+		max:     max,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// touch marks the name seen at now and returns its entry. A missing name is
+// added with the value returned by newValue, evicting the name seen least
+// recently when the set is full.
+func (l *lru) touch(name string, now time.Time, newValue func() interface{}) *lruEntry {
+	if el, ok := l.entries[name]; ok {
+		l.order.MoveToFront(el)
+		e := el.Value.(*lruEntry)
+		e.lastSeen = now
+		return e
+	}
+
+	if l.order.Len() > 0 && l.order.Len() >= l.max {
+		oldest := l.order.Back()
+		delete(l.entries, oldest.Value.(*lruEntry).name)
+		l.order.Remove(oldest)
+		l.truncated = true
+	}
+
+	e := &lruEntry{name: name, lastSeen: now}
+	if newValue != nil {
+		e.value = newValue()
+	}
+	l.entries[name] = l.order.PushFront(e)
+	return e
+}
+
+func (l *lru) get(name string) (*lruEntry, bool) {
+	el, ok := l.entries[name]
+	if !ok {
+		return nil, false
+	}
+	return el.Value.(*lruEntry), true
+}
+
+// each calls fn with the entries whose names have the prefix.
+func (l *lru) each(prefix string, fn func(e *lruEntry)) {
+	for el := l.order.Front(); el != nil; el = el.Next() {
+		if e := el.Value.(*lruEntry); strings.HasPrefix(e.name, prefix) {
+			fn(e)
+		}
+	}
+}
+
+// Observe records the schema of points written to the bucket.
+func (c *Cache) Observe(orgID, bucketID platform.ID, points []models.Point) {
+	if len(points) == 0 {
+		return
+	}
+	now := c.now().UTC()
+
+	b := c.bucket(orgID, bucketID, now)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.updatedAt = now
+
+	newMeasurement := func() interface{} {
+		return &measurementSchema{
+			tagKeys: newLRU(c.maxTagKeys),
+			fields:  newLRU(c.maxFields),
+		}
+	}
+	newTagValues := func() interface{} {
+		return newLRU(c.maxTagValues)
+	}
+
+	for _, p := range points {
+		m := b.measurements.touch(string(p.Name()), now, newMeasurement).value.(*measurementSchema)
+
+		for _, tag := range p.Tags() {
+			values := m.tagKeys.touch(string(tag.Key), now, newTagValues).value.(*lru)
+			values.touch(string(tag.Value), now, nil)
+		}
+
+		fields := p.FieldIterator()
+		for fields.Next() {
+			m.fields.touch(string(fields.FieldKey()), now, nil)
+		}
+	}
+}
+
+// bucket returns the schema of the bucket, adding it when it is missing.
+func (c *Cache) bucket(orgID, bucketID platform.ID, now time.Time) *bucketSchema {
+	sh := c.shard(bucketID)
+
+	sh.mu.RLock()
+	b, ok := sh.buckets[bucketID]
+	sh.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if b, ok := sh.buckets[bucketID]; ok {
+		return b
+	}
+	b = &bucketSchema{
+		orgID:        orgID,
+		observedFrom: now,
+		measurements: newLRU(c.maxMeasurements),
+	}
+	sh.buckets[bucketID] = b
+	return b
+}
+
+// DeleteBucket drops the schema of a deleted bucket.
+func (c *Cache) DeleteBucket(bucketID platform.ID) {
+	sh := c.shard(bucketID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	delete(sh.buckets, bucketID)
+}
+
+// Filter selects the part of the schema of a bucket to return.
+type Filter struct {
+	BucketID platform.ID
+	// Measurement limits the schema to a single measurement.
+	Measurement string
+	// TagKey selects a tag key of the measurement to return the values of.
+	TagKey string
+	// Prefix limits the names of the deepest level returned to the ones
+	// with the prefix.
+	Prefix string
+}
+
+// Schema is the cached schema of a bucket.
+type Schema struct {
+	BucketID platform.ID `json:"bucketID"`
+	OrgID    platform.ID `json:"orgID"`
+	// ObservedFrom is the time the cache started observing writes to the
+	// bucket, series only written before are missing. It is unset when no
+	// write to the bucket was observed.
+	ObservedFrom *time.Time `json:"observedFrom,omitempty"`
+	// UpdatedAt is the time of the last write observed.
+	UpdatedAt    *time.Time    `json:"updatedAt,omitempty"`
+	Truncated    bool          `json:"truncated"`
+	Measurements []Measurement `json:"measurements"`
+}
+
+// Measurement is the cached schema of a measurement.
+type Measurement struct {
+	Name     string    `json:"name"`
+	LastSeen time.Time `json:"lastSeen"`
+	TagKeys  []TagKey  `json:"tagKeys,omitempty"`
+	Fields   []Entry   `json:"fields,omitempty"`
+	// Truncated is set when tag keys or fields of the measurement were
+	// evicted.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// TagKey is a cached tag key and, when requested, its values.
+type TagKey struct {
+	Key       string    `json:"key"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Values    []Entry   `json:"values,omitempty"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+// Entry is a cached name and the last time it was written.
+type Entry struct {
+	Name     string    `json:"name"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// Schema returns the cached schema of a bucket, sorted by name. Without a
+// measurement in the filter only the measurements are listed, with one the
+// tag keys and fields of the measurement, and with a tag key its values.
+// The bool is false when nothing was written to the bucket since the cache
+// started.
+func (c *Cache) Schema(f Filter) (*Schema, bool) {
+	sh := c.shard(f.BucketID)
+	sh.mu.RLock()
+	b, ok := sh.buckets[f.BucketID]
+	sh.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	observedFrom, updatedAt := b.observedFrom, b.updatedAt
+	s := &Schema{
+		BucketID:     f.BucketID,
+		OrgID:        b.orgID,
+		ObservedFrom: &observedFrom,
+		UpdatedAt:    &updatedAt,
+		Truncated:    b.measurements.truncated,
+		Measurements: []Measurement{},
+	}
+
+	if f.Measurement == "" {
+		b.measurements.each(f.Prefix, func(e *lruEntry) {
+			s.Measurements = append(s.Measurements, Measurement{Name: e.name, LastSeen: e.lastSeen})
+		})
+		sort.Slice(s.Measurements, func(i, j int) bool { return s.Measurements[i].Name < s.Measurements[j].Name })
+		return s, true
+	}
+
+	me, ok := b.measurements.get(f.Measurement)
+	if !ok {
+		return s, true
+	}
+	m := me.value.(*measurementSchema)
+
+	res := Measurement{
+		Name:      f.Measurement,
+		LastSeen:  me.lastSeen,
+		Truncated: m.tagKeys.truncated || m.fields.truncated,
+	}
+	if f.TagKey == "" {
+		m.tagKeys.each(f.Prefix, func(e *lruEntry) {
+			res.TagKeys = append(res.TagKeys, TagKey{Key: e.name, LastSeen: e.lastSeen, Truncated: e.value.(*lru).truncated})
+		})
+		sort.Slice(res.TagKeys, func(i, j int) bool { return res.TagKeys[i].Key < res.TagKeys[j].Key })
+		res.Fields = entries(m.fields, f.Prefix)
+	} else if ke, ok := m.tagKeys.get(f.TagKey); ok {
+		values := ke.value.(*lru)
+		res.TagKeys = []TagKey{{
+			Key:       f.TagKey,
+			LastSeen:  ke.lastSeen,
+			Values:    entries(values, f.Prefix),
+			Truncated: values.truncated,
+		}}
+	}
+	s.Measurements = append(s.Measurements, res)
+	return s, true
+}
+
+func entries(l *lru, prefix string) []Entry {
+	es := make([]Entry, 0, l.order.Len())
+	l.each(prefix, func(e *lruEntry) {
+		es = append(es, Entry{Name: e.name, LastSeen: e.lastSeen})
+	})
+	sort.Slice(es, func(i, j int) bool { return es[i].Name < es[j].Name })
+	return es
+}
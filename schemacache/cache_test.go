@@ -0,0 +1,122 @@
+package schemacache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	const (
+		orgID    = platform.ID(1)
+		bucketID = platform.ID(2)
+	)
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCache(WithMaxTagValues(2))
+	c.now = func() time.Time { return now }
+
+	write := func(lp string) {
+		t.Helper()
+		points, err := models.ParsePointsString(lp)
+		require.NoError(t, err)
+		c.Observe(orgID, bucketID, points)
+		now = now.Add(time.Minute)
+	}
+
+	_, ok := c.Schema(Filter{BucketID: bucketID})
+	assert.False(t, ok)
+
+	write("cpu,host=a,region=west usage=1\nmem,host=a free=2i")
+	write("cpu,host=b usage=3,idle=4")
+	write("cpu,host=c usage=5")
+
+	s, ok := c.Schema(Filter{BucketID: bucketID})
+	require.True(t, ok)
+	assert.Equal(t, orgID, s.OrgID)
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), *s.ObservedFrom)
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 2, 0, 0, time.UTC), *s.UpdatedAt)
+	require.Len(t, s.Measurements, 2)
+	assert.Equal(t, "cpu", s.Measurements[0].Name)
+	assert.Equal(t, "mem", s.Measurements[1].Name)
+
+	s, _ = c.Schema(Filter{BucketID: bucketID, Measurement: "cpu"})
+	require.Len(t, s.Measurements, 1)
+	cpu := s.Measurements[0]
+	require.Len(t, cpu.TagKeys, 2)
+	assert.Equal(t, "host", cpu.TagKeys[0].Key)
+	assert.True(t, cpu.TagKeys[0].Truncated)
+	assert.Equal(t, "region", cpu.TagKeys[1].Key)
+	require.Len(t, cpu.Fields, 2)
+	assert.Equal(t, "idle", cpu.Fields[0].Name)
+	assert.Equal(t, "usage", cpu.Fields[1].Name)
+
+	// host=a was seen least recently and evicted
+	s, _ = c.Schema(Filter{BucketID: bucketID, Measurement: "cpu", TagKey: "host"})
+	values := s.Measurements[0].TagKeys[0].Values
+	require.Len(t, values, 2)
+	assert.Equal(t, "b", values[0].Name)
+	assert.Equal(t, "c", values[1].Name)
+
+	s, _ = c.Schema(Filter{BucketID: bucketID, Measurement: "cpu", TagKey: "host", Prefix: "c"})
+	values = s.Measurements[0].TagKeys[0].Values
+	require.Len(t, values, 1)
+	assert.Equal(t, "c", values[0].Name)
+}
+
+func TestCache_Bounds(t *testing.T) {
+	const bucketID = platform.ID(2)
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCache(WithMaxMeasurements(2), WithMaxTagKeys(2), WithMaxFields(2))
+	c.now = func() time.Time { return now }
+
+	write := func(lp string) {
+		t.Helper()
+		points, err := models.ParsePointsString(lp)
+		require.NoError(t, err)
+		c.Observe(1, bucketID, points)
+		now = now.Add(time.Minute)
+	}
+
+	write("cpu,a=1,b=1 x=1,y=1")
+	write("mem v=1")
+	// cpu is seen again, so mem is the measurement evicted for disk
+	write("cpu,c=1 z=1")
+	write("disk v=1")
+
+	s, _ := c.Schema(Filter{BucketID: bucketID})
+	assert.True(t, s.Truncated)
+	require.Len(t, s.Measurements, 2)
+	assert.Equal(t, "cpu", s.Measurements[0].Name)
+	assert.Equal(t, "disk", s.Measurements[1].Name)
+
+	s, _ = c.Schema(Filter{BucketID: bucketID, Measurement: "cpu"})
+	cpu := s.Measurements[0]
+	assert.True(t, cpu.Truncated)
+	require.Len(t, cpu.TagKeys, 2)
+	assert.Equal(t, "b", cpu.TagKeys[0].Key)
+	assert.Equal(t, "c", cpu.TagKeys[1].Key)
+	require.Len(t, cpu.Fields, 2)
+	assert.Equal(t, "y", cpu.Fields[0].Name)
+	assert.Equal(t, "z", cpu.Fields[1].Name)
+}
+
+func TestCache_DeleteBucket(t *testing.T) {
+	c := NewCache()
+	points, err := models.ParsePointsString("cpu usage=1")
+	require.NoError(t, err)
+
+	c.Observe(1, 2, points)
+	c.Observe(1, 3, points)
+	c.DeleteBucket(2)
+
+	_, ok := c.Schema(Filter{BucketID: 2})
+	assert.False(t, ok)
+	_, ok = c.Schema(Filter{BucketID: 3})
+	assert.True(t, ok)
+}
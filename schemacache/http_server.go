@@ -0,0 +1,105 @@
+package schemacache
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixSchemaCache = "/api/v2/schema/cache"
+
+// Handler serves the cached schema of buckets.
+type Handler struct {
+	chi.Router
+
+	log       *zap.Logger
+	api       *kithttp.API
+	cache     *Cache
+	bucketSvc influxdb.BucketService
+}
+
+// NewHTTPHandler constructs a handler serving the schema in the cache. The
+// bucket service authorizes reading the schema of a bucket, so it must be
+// one that checks the permissions of the request.
+func NewHTTPHandler(log *zap.Logger, cache *Cache, bucketSvc influxdb.BucketService) *Handler {
+	h := &Handler{
+		log:       log,
+		api:       kithttp.NewAPI(kithttp.WithLog(log)),
+		cache:     cache,
+		bucketSvc: bucketSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Get("/", h.handleGetSchema)
+	h.Router = r
+	return h
+}
+
+// Prefix is the route the handler is mounted at.
+func (h *Handler) Prefix() string {
+	return prefixSchemaCache
+}
+
+// handleGetSchema is the HTTP handler for the GET /api/v2/schema/cache route.
+func (h *Handler) handleGetSchema(w http.ResponseWriter, r *http.Request) {
+	f, err := decodeFilter(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	b, err := h.bucketSvc.FindBucketByID(r.Context(), f.BucketID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	s, ok := h.cache.Schema(f)
+	if !ok {
+		s = &Schema{
+			BucketID:     b.ID,
+			OrgID:        b.OrgID,
+			Measurements: []Measurement{},
+		}
+	}
+	h.api.Respond(w, r, http.StatusOK, s)
+}
+
+func decodeFilter(r *http.Request) (Filter, error) {
+	qp := r.URL.Query()
+
+	bucketID, err := platform.IDFromString(qp.Get("bucketID"))
+	if err != nil {
+		return Filter{}, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "bucketID is invalid",
+			Err:  err,
+		}
+	}
+
+	f := Filter{
+		BucketID:    *bucketID,
+		Measurement: qp.Get("measurement"),
+		TagKey:      qp.Get("tagKey"),
+		Prefix:      qp.Get("prefix"),
+	}
+	if f.TagKey != "" && f.Measurement == "" {
+		return Filter{}, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "tagKey requires a measurement",
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,31 @@
+package schemacache
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// PointsWriter writes points to a bucket.
+type PointsWriter interface {
+	WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, p []models.Point) error
+}
+
+// ObservingPointsWriter wraps an underlying points writer and records the
+// schema of successful writes in the cache.
+type ObservingPointsWriter struct {
+	Underlying PointsWriter
+	Cache      *Cache
+}
+
+// WritePoints writes points to the underlying PointsWriter and records their
+// schema when the write succeeds.
+func (w *ObservingPointsWriter) WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, p []models.Point) error {
+	if err := w.Underlying.WritePoints(ctx, orgID, bucketID, p); err != nil {
+		return err
+	}
+
+	w.Cache.Observe(orgID, bucketID, p)
+	return nil
+}
@@ -0,0 +1,145 @@
+package shardmove
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixShards = "/api/v2/shards"
+
+// Handler serves the shard move API to operators.
+type Handler struct {
+	chi.Router
+
+	log *zap.Logger
+	api *kithttp.API
+	svc *Service
+}
+
+// NewHTTPHandler constructs a handler starting and reporting shard moves.
+func NewHTTPHandler(log *zap.Logger, svc *Service) *Handler {
+	h := &Handler{
+		log: log,
+		api: kithttp.NewAPI(kithttp.WithLog(log)),
+		svc: svc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+		h.mwAuthorize,
+	)
+
+	r.Get("/moves", h.handleGetMoves)
+	r.Route("/{id}/move", func(r chi.Router) {
+		r.Get("/", h.handleGetMove)
+		r.Post("/", h.handlePostMove)
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix is the route the handler is mounted at.
+func (h *Handler) Prefix() string {
+	return prefixShards
+}
+
+type movesResponse struct {
+	DataDirs []string `json:"dataDirs"`
+	Moves    []Move   `json:"moves"`
+}
+
+// handleGetMoves is the HTTP handler for the GET /api/v2/shards/moves route.
+func (h *Handler) handleGetMoves(w http.ResponseWriter, r *http.Request) {
+	h.api.Respond(w, r, http.StatusOK, movesResponse{
+		DataDirs: h.svc.DataDirs(),
+		Moves:    h.svc.List(),
+	})
+}
+
+// handleGetMove is the HTTP handler for the GET /api/v2/shards/:id/move route.
+func (h *Handler) handleGetMove(w http.ResponseWriter, r *http.Request) {
+	id, err := decodeShardID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	m, err := h.svc.Find(id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, m)
+}
+
+type postMoveRequest struct {
+	DataDir string `json:"dataDir"`
+}
+
+// handlePostMove is the HTTP handler for the POST /api/v2/shards/:id/move route.
+func (h *Handler) handlePostMove(w http.ResponseWriter, r *http.Request) {
+	id, err := decodeShardID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req postMoveRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if req.DataDir == "" {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "dataDir is required",
+		})
+		return
+	}
+
+	m, err := h.svc.Start(id, req.DataDir)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Info("Started shard move", zap.Uint64("shard_id", id), zap.String("to", m.To))
+	h.api.Respond(w, r, http.StatusAccepted, m)
+}
+
+func decodeShardID(r *http.Request) (uint64, error) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return 0, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "shard id is invalid",
+			Err:  err,
+		}
+	}
+	return id, nil
+}
+
+func (h *Handler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("access to %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,257 @@
+// Package shardmove moves shards between the data directories of the
+// storage engine while they stay online, such as to a volume added to a
+// server that runs out of disk space, and tracks the progress of each move.
+package shardmove
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/maintenance"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"go.uber.org/zap"
+)
+
+// Store is the part of the TSDB store moving shards.
+type Store interface {
+	DataDirs() []string
+	Shards(ids []uint64) []*tsdb.Shard
+	MoveShard(ctx context.Context, id uint64, dir string, progress *tsdb.ShardMoveProgress) error
+}
+
+// Status is the status of a shard move.
+type Status string
+
+const (
+	// StatusRunning is the status of a move in progress.
+	StatusRunning Status = "running"
+	// StatusDone is the status of a move that completed.
+	StatusDone Status = "done"
+	// StatusFailed is the status of a move that failed. The shard stays in
+	// the data directory it was moved from.
+	StatusFailed Status = "failed"
+)
+
+// Move is the state of the move of a shard.
+type Move struct {
+	ShardID     uint64              `json:"shardID"`
+	From        string              `json:"from"`
+	To          string              `json:"to"`
+	Status      Status              `json:"status"`
+	Phase       tsdb.ShardMovePhase `json:"phase,omitempty"`
+	BytesCopied int64               `json:"bytesCopied"`
+	BytesTotal  int64               `json:"bytesTotal"`
+	StartedAt   time.Time           `json:"startedAt"`
+	FinishedAt  *time.Time          `json:"finishedAt,omitempty"`
+	Error       string              `json:"error,omitempty"`
+}
+
+type move struct {
+	Move
+	progress *tsdb.ShardMoveProgress
+}
+
+// snapshot returns the state of the move. It must be called under the lock
+// of the service.
+func (m *move) snapshot() Move {
+	mv := m.Move
+	if mv.Status == StatusRunning {
+		mv.Phase = m.progress.Phase()
+	}
+	mv.BytesCopied, mv.BytesTotal = m.progress.Bytes()
+	return mv
+}
+
+// Service starts shard moves and keeps the state of the last move of each
+// shard until the server restarts.
+type Service struct {
+	log         *zap.Logger
+	store       Store
+	coordinator *maintenance.Coordinator
+	now         func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu    sync.Mutex
+	moves map[uint64]*move
+}
+
+// ServiceOptFn is a functional option for configuring a Service.
+type ServiceOptFn func(*Service)
+
+// WithCoordinator admits moves through the maintenance coordinator, which
+// bounds their concurrency and throughput and lets operators pause or
+// abort them.
+func WithCoordinator(c *maintenance.Coordinator) ServiceOptFn {
+	return func(s *Service) {
+		s.coordinator = c
+	}
+}
+
+// NewService constructs a service moving the shards of store.
+func NewService(log *zap.Logger, store Store, opts ...ServiceOptFn) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Service{
+		log:    log,
+		store:  store,
+		now:    time.Now,
+		ctx:    ctx,
+		cancel: cancel,
+		moves:  make(map[uint64]*move),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// DataDirs returns the data directories shards can be moved to.
+func (s *Service) DataDirs() []string {
+	return s.store.DataDirs()
+}
+
+// Start starts moving a shard to the data directory dir. The move runs in
+// the background, its progress is returned by Find.
+func (s *Service) Start(id uint64, dir string) (*Move, error) {
+	shards := s.store.Shards([]uint64{id})
+	if len(shards) == 0 {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("shard %d not found", id),
+		}
+	}
+
+	dir = filepath.Clean(dir)
+	if !s.isDataDir(dir) {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("%q is not a data directory", dir),
+		}
+	}
+	from := filepath.Dir(filepath.Dir(filepath.Dir(shards[0].Path())))
+	if from == dir {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("shard %d is already stored in %q", id, dir),
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m, ok := s.moves[id]; ok && m.Status == StatusRunning {
+		return nil, &errors.Error{
+			Code: errors.EConflict,
+			Msg:  fmt.Sprintf("shard %d is already being moved", id),
+		}
+	}
+
+	m := &move{
+		Move: Move{
+			ShardID:   id,
+			From:      from,
+			To:        dir,
+			Status:    StatusRunning,
+			StartedAt: s.now().UTC(),
+		},
+		progress: new(tsdb.ShardMoveProgress),
+	}
+	s.moves[id] = m
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := s.moveShard(id, dir, m.progress)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		finishedAt := s.now().UTC()
+		m.FinishedAt = &finishedAt
+		if err != nil {
+			s.log.Error("Failed to move shard", zap.Uint64("shard_id", id), zap.String("to", dir), zap.Error(err))
+			m.Status = StatusFailed
+			m.Phase = m.progress.Phase()
+			m.Error = err.Error()
+			return
+		}
+		m.Status = StatusDone
+	}()
+
+	mv := m.snapshot()
+	return &mv, nil
+}
+
+func (s *Service) moveShard(id uint64, dir string, progress *tsdb.ShardMoveProgress) error {
+	if s.coordinator == nil {
+		return s.store.MoveShard(s.ctx, id, dir, progress)
+	}
+
+	op, err := s.coordinator.Begin(s.ctx, maintenance.KindShardMove, fmt.Sprintf("move of shard %d to %s", id, dir))
+	if err != nil {
+		return err
+	}
+	defer op.End()
+
+	progress.SetThrottle(op.Throttle)
+	return s.store.MoveShard(op.Context(), id, dir, progress)
+}
+
+// Find returns the state of the last move of a shard.
+func (s *Service) Find(id uint64) (*Move, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.moves[id]
+	if !ok {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("shard %d was not moved", id),
+		}
+	}
+	mv := m.snapshot()
+	return &mv, nil
+}
+
+// List returns the state of the last move of every shard moved, ordered
+// by the time they started.
+func (s *Service) List() []Move {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	moves := make([]Move, 0, len(s.moves))
+	for _, m := range s.moves {
+		moves = append(moves, m.snapshot())
+	}
+	sort.Slice(moves, func(i, j int) bool {
+		if !moves[i].StartedAt.Equal(moves[j].StartedAt) {
+			return moves[i].StartedAt.Before(moves[j].StartedAt)
+		}
+		return moves[i].ShardID < moves[j].ShardID
+	})
+	return moves
+}
+
+// Close cancels the moves in progress and waits for them to stop. The
+// shards of canceled moves stay in the data directories they were moved
+// from.
+func (s *Service) Close() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Service) isDataDir(dir string) bool {
+	for _, d := range s.store.DataDirs() {
+		if filepath.Clean(d) == dir {
+			return true
+		}
+	}
+	return false
+}
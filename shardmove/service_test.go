@@ -0,0 +1,95 @@
+package shardmove
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type store struct {
+	moved chan struct{}
+	err   error
+}
+
+func (s *store) DataDirs() []string {
+	return []string{"/data", "/extra"}
+}
+
+func (s *store) Shards(ids []uint64) []*tsdb.Shard {
+	if ids[0] != 1 {
+		return nil
+	}
+	return []*tsdb.Shard{tsdb.NewShard(1, "/data/db/rp/1", "/wal/db/rp/1", nil, tsdb.NewEngineOptions())}
+}
+
+func (s *store) MoveShard(ctx context.Context, id uint64, dir string, progress *tsdb.ShardMoveProgress) error {
+	select {
+	case <-s.moved:
+		return s.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestService(t *testing.T) {
+	st := &store{moved: make(chan struct{})}
+	svc := NewService(zaptest.NewLogger(t), st)
+	defer svc.Close()
+
+	for _, tt := range []struct {
+		name string
+		id   uint64
+		dir  string
+		code string
+	}{
+		{name: "shard not found", id: 2, dir: "/extra", code: errors.ENotFound},
+		{name: "not a data dir", id: 1, dir: "/other", code: errors.EInvalid},
+		{name: "same data dir", id: 1, dir: "/data/", code: errors.EInvalid},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Start(tt.id, tt.dir)
+			assert.Equal(t, tt.code, errors.ErrorCode(err))
+		})
+	}
+
+	m, err := svc.Start(1, "/extra")
+	require.NoError(t, err)
+	assert.Equal(t, "/data", m.From)
+	assert.Equal(t, "/extra", m.To)
+	assert.Equal(t, StatusRunning, m.Status)
+
+	_, err = svc.Start(1, "/extra")
+	assert.Equal(t, errors.EConflict, errors.ErrorCode(err))
+
+	close(st.moved)
+	require.Eventually(t, func() bool {
+		m, err := svc.Find(1)
+		return err == nil && m.Status == StatusDone
+	}, time.Second, 10*time.Millisecond)
+
+	moves := svc.List()
+	require.Len(t, moves, 1)
+	assert.NotNil(t, moves[0].FinishedAt)
+
+	_, err = svc.Find(2)
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
+
+func TestService_Close(t *testing.T) {
+	svc := NewService(zaptest.NewLogger(t), &store{moved: make(chan struct{})})
+
+	_, err := svc.Start(1, "/extra")
+	require.NoError(t, err)
+	require.NoError(t, svc.Close())
+
+	m, err := svc.Find(1)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, m.Status)
+	assert.Equal(t, context.Canceled.Error(), m.Error)
+}
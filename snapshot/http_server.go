@@ -0,0 +1,183 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixSnapshots = "/api/v2/snapshots"
+
+// Handler serves the snapshot API to operators.
+type Handler struct {
+	chi.Router
+
+	log *zap.Logger
+	api *kithttp.API
+	svc *Service
+}
+
+// NewHTTPHandler constructs a handler taking snapshots and serving their
+// pieces.
+func NewHTTPHandler(log *zap.Logger, svc *Service) *Handler {
+	h := &Handler{
+		log: log,
+		api: kithttp.NewAPI(kithttp.WithLog(log)),
+		svc: svc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+		h.mwAuthorize,
+	)
+
+	r.Post("/", h.handlePostSnapshot)
+	r.Get("/", h.handleGetSnapshots)
+	r.Route("/{id}", func(r chi.Router) {
+		r.Get("/", h.handleGetSnapshot)
+		r.Delete("/", h.handleDeleteSnapshot)
+		r.Get("/shards/{shardID}", h.handleGetShard)
+		r.Get("/{file}", h.handleGetFile)
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix is the route the handler is mounted at.
+func (h *Handler) Prefix() string {
+	return prefixSnapshots
+}
+
+// handlePostSnapshot is the HTTP handler for the POST /api/v2/snapshots route.
+func (h *Handler) handlePostSnapshot(w http.ResponseWriter, r *http.Request) {
+	m, err := h.svc.Create(r.Context())
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusCreated, m)
+}
+
+type snapshotsResponse struct {
+	Snapshots []*Manifest `json:"snapshots"`
+}
+
+// handleGetSnapshots is the HTTP handler for the GET /api/v2/snapshots route.
+func (h *Handler) handleGetSnapshots(w http.ResponseWriter, r *http.Request) {
+	ms, err := h.svc.List()
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, snapshotsResponse{Snapshots: ms})
+}
+
+// handleGetSnapshot is the HTTP handler for the GET /api/v2/snapshots/:id route.
+func (h *Handler) handleGetSnapshot(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	m, err := h.svc.Find(*id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, m)
+}
+
+// handleDeleteSnapshot is the HTTP handler for the DELETE /api/v2/snapshots/:id route.
+func (h *Handler) handleDeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.svc.Delete(*id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Info("Deleted snapshot", zap.Stringer("id", id))
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
+
+// handleGetFile is the HTTP handler for the GET /api/v2/snapshots/:id/:file route,
+// serving the kv, sql and buckets.json files of a snapshot.
+func (h *Handler) handleGetFile(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	name := chi.URLParam(r, "file")
+	f, err := h.svc.Open(*id, name)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	defer f.Close()
+
+	contentType := "application/octet-stream"
+	if name == FileBuckets {
+		contentType = "application/json; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, f); err != nil {
+		h.log.Info("Failed to write snapshot file", zap.Stringer("id", id), zap.String("file", name), zap.Error(err))
+	}
+}
+
+// handleGetShard is the HTTP handler for the GET /api/v2/snapshots/:id/shards/:shardID route.
+func (h *Handler) handleGetShard(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	shardID, err := strconv.ParseUint(chi.URLParam(r, "shardID"), 10, 64)
+	if err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "shard id is invalid",
+			Err:  err,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	if err := h.svc.WriteShard(r.Context(), w, *id, shardID); err != nil {
+		h.api.Err(w, r, err)
+	}
+}
+
+func (h *Handler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("access to %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
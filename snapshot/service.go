@@ -0,0 +1,404 @@
+// Package snapshot takes point-in-time snapshots of a server: its KV store,
+// its sqlite database and the shards of its storage engine, taken while
+// metadata writes are held, so the data of the snapshot is never older
+// than its metadata. Each snapshot is kept on disk with a manifest tying
+// its pieces together until it is deleted.
+package snapshot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/maintenance"
+	intar "github.com/influxdata/influxdb/v2/pkg/tar"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"go.uber.org/zap"
+)
+
+const (
+	// FileKV is the name of the backup of the KV store in a snapshot.
+	FileKV = "kv"
+	// FileSQL is the name of the backup of the sqlite database in a snapshot.
+	FileSQL = "sql"
+	// FileBuckets is the name of the manifest of the buckets and their
+	// shards in a snapshot.
+	FileBuckets = "buckets.json"
+
+	manifestFile = "manifest.json"
+	shardsDir    = "shards"
+)
+
+// Store is the part of the TSDB store snapshotting shards.
+type Store interface {
+	ShardIDs() []uint64
+	CreateShardSnapshot(id uint64, skipCacheOk bool) (string, error)
+}
+
+// Manifest describes the pieces of a snapshot.
+type Manifest struct {
+	ID        platform.ID `json:"id"`
+	CreatedAt time.Time   `json:"createdAt"`
+	KV        File        `json:"kv"`
+	SQL       File        `json:"sql"`
+	// Buckets is the manifest of the buckets, which maps the shards of
+	// the snapshot to their buckets.
+	Buckets File    `json:"buckets"`
+	Shards  []Shard `json:"shards"`
+}
+
+// File is a file of a snapshot.
+type File struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Shard is the snapshot of a shard.
+type Shard struct {
+	ID    uint64 `json:"id"`
+	Files []File `json:"files"`
+}
+
+// Service takes snapshots and serves their pieces.
+type Service struct {
+	log         *zap.Logger
+	dir         string
+	kv          influxdb.BackupService
+	sql         influxdb.SqlBackupRestoreService
+	buckets     influxdb.BucketManifestWriter
+	store       Store
+	coordinator *maintenance.Coordinator
+	idGen       platform.IDGenerator
+	now         func() time.Time
+
+	// mu serializes taking and deleting snapshots.
+	mu sync.Mutex
+}
+
+// ServiceOptFn is a functional option for configuring a Service.
+type ServiceOptFn func(*Service)
+
+// WithCoordinator tracks taking snapshots with the maintenance coordinator
+// and admits the downloads of their shards through it, which bounds their
+// concurrency and throughput and lets operators pause or abort them.
+func WithCoordinator(c *maintenance.Coordinator) ServiceOptFn {
+	return func(s *Service) {
+		s.coordinator = c
+	}
+}
+
+// NewService constructs a service keeping snapshots in dir.
+func NewService(log *zap.Logger, dir string, kv influxdb.BackupService, sql influxdb.SqlBackupRestoreService, buckets influxdb.BucketManifestWriter, store Store, opts ...ServiceOptFn) *Service {
+	s := &Service{
+		log:     log,
+		dir:     dir,
+		kv:      kv,
+		sql:     sql,
+		buckets: buckets,
+		store:   store,
+		idGen:   snowflake.NewIDGenerator(),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Create takes a snapshot. Metadata writes wait while the KV store and
+// sqlite database are backed up and the caches of the shards are written
+// and their files linked into the snapshot, which does not copy their data.
+func (s *Service) Create(ctx context.Context) (*Manifest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.coordinator != nil {
+		op := s.coordinator.Track(ctx, maintenance.KindBackup, "snapshot")
+		defer op.End()
+		ctx = op.Context()
+	}
+
+	m := &Manifest{ID: s.idGen.ID()}
+	dir := filepath.Join(s.dir, m.ID.String())
+	if err := os.MkdirAll(filepath.Join(dir, shardsDir), 0700); err != nil {
+		return nil, err
+	}
+	if err := s.create(ctx, dir, m); err != nil {
+		if rerr := os.RemoveAll(dir); rerr != nil {
+			s.log.Warn("Failed to remove incomplete snapshot", zap.String("path", dir), zap.Error(rerr))
+		}
+		return nil, err
+	}
+	s.log.Info("Created snapshot", zap.Stringer("id", m.ID), zap.Int("shards", len(m.Shards)))
+	return m, nil
+}
+
+func (s *Service) create(ctx context.Context, dir string, m *Manifest) error {
+	if err := s.snapshot(ctx, dir, m); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	// the manifest is written last, so a snapshot without one is incomplete
+	tmp := filepath.Join(dir, manifestFile+".tmp")
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, manifestFile))
+}
+
+// snapshot writes the pieces of the snapshot while metadata writes are held.
+func (s *Service) snapshot(ctx context.Context, dir string, m *Manifest) (err error) {
+	s.kv.RLockKVStore()
+	defer s.kv.RUnlockKVStore()
+	s.sql.RLockSqlStore()
+	defer s.sql.RUnlockSqlStore()
+
+	m.CreatedAt = s.now().UTC()
+	if m.KV, err = writeFile(dir, FileKV, func(w io.Writer) error {
+		return s.kv.BackupKVStore(ctx, w)
+	}); err != nil {
+		return err
+	}
+	if m.SQL, err = writeFile(dir, FileSQL, func(w io.Writer) error {
+		return s.sql.BackupSqlStore(ctx, w)
+	}); err != nil {
+		return err
+	}
+	if m.Buckets, err = writeFile(dir, FileBuckets, func(w io.Writer) error {
+		return s.buckets.WriteManifest(ctx, w)
+	}); err != nil {
+		return err
+	}
+
+	// shards are not created while metadata writes are held, so the shards
+	// are those of the bucket manifest
+	ids := s.store.ShardIDs()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		shard, err := s.snapshotShard(dir, id)
+		if err == tsdb.ErrShardNotFound {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("snapshot of shard %d: %w", id, err)
+		}
+		m.Shards = append(m.Shards, *shard)
+	}
+	return nil
+}
+
+// snapshotShard writes the cache of a shard and links its files into the
+// snapshot.
+func (s *Service) snapshotShard(dir string, id uint64) (*Shard, error) {
+	src, err := s.store.CreateShardSnapshot(id, false)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(src)
+
+	dst := filepath.Join(dir, shardsDir, strconv.FormatUint(id, 10))
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return nil, err
+	}
+	shard := &Shard{ID: id, Files: make([]File, 0, len(entries))}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		if err := linkOrCopy(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+			return nil, err
+		}
+		shard.Files = append(shard.Files, File{Name: e.Name(), Size: info.Size()})
+	}
+	return shard, nil
+}
+
+// List returns the manifests of the snapshots, the oldest first.
+func (s *Service) List() ([]*Manifest, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return []*Manifest{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	ms := make([]*Manifest, 0, len(entries))
+	for _, e := range entries {
+		id, err := platform.IDFromString(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		m, err := s.Find(*id)
+		if errors.ErrorCode(err) == errors.ENotFound {
+			// the snapshot is being taken
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].CreatedAt.Before(ms[j].CreatedAt) })
+	return ms, nil
+}
+
+// Find returns the manifest of a snapshot.
+func (s *Service) Find(id platform.ID) (*Manifest, error) {
+	b, err := os.ReadFile(filepath.Join(s.dir, id.String(), manifestFile))
+	if os.IsNotExist(err) {
+		return nil, errSnapshotNotFound(id)
+	} else if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Open opens the KV, SQL or buckets file of a snapshot.
+func (s *Service) Open(id platform.ID, name string) (io.ReadCloser, error) {
+	if name != FileKV && name != FileSQL && name != FileBuckets {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("snapshot file %q not found", name),
+		}
+	}
+	if _, err := s.Find(id); err != nil {
+		return nil, err
+	}
+	return os.Open(filepath.Join(s.dir, id.String(), name))
+}
+
+// WriteShard writes the files of a shard of a snapshot to w as a tar
+// archive, in the format of shard backups.
+func (s *Service) WriteShard(ctx context.Context, w io.Writer, id platform.ID, shardID uint64) error {
+	m, err := s.Find(id)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, sh := range m.Shards {
+		found = found || sh.ID == shardID
+	}
+	if !found {
+		return &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("shard %d not found in snapshot %s", shardID, id),
+		}
+	}
+
+	if s.coordinator != nil {
+		op, err := s.coordinator.Begin(ctx, maintenance.KindBackup, fmt.Sprintf("download of shard %d of snapshot %s", shardID, id))
+		if err != nil {
+			return err
+		}
+		defer op.End()
+		w = op.Writer(w)
+	}
+
+	dir := filepath.Join(s.dir, id.String(), shardsDir, strconv.FormatUint(shardID, 10))
+	return intar.Stream(w, dir, strconv.FormatUint(shardID, 10), nil)
+}
+
+// Delete deletes a snapshot.
+func (s *Service) Delete(id platform.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.Find(id); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(s.dir, id.String()))
+}
+
+func errSnapshotNotFound(id platform.ID) error {
+	return &errors.Error{
+		Code: errors.ENotFound,
+		Msg:  fmt.Sprintf("snapshot %s not found", id),
+	}
+}
+
+// writeFile writes a file of the snapshot with fn and returns its size and
+// checksum.
+func writeFile(dir, name string, fn func(w io.Writer) error) (File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	cw := &countingWriter{w: io.MultiWriter(f, h)}
+	if err := fn(cw); err != nil {
+		return File{}, err
+	}
+	if err := f.Sync(); err != nil {
+		return File{}, err
+	}
+	return File{Name: name, Size: cw.n, SHA256: hex.EncodeToString(h.Sum(nil))}, f.Close()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// linkOrCopy hard links src to dst, or copies it when they are on
+// different file systems.
+func linkOrCopy(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	return out.Close()
+}
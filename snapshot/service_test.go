@@ -0,0 +1,155 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// metadata is a KV store and sqlite database that fail backups taken
+// while they are not locked.
+type metadata struct {
+	kvLocked, sqlLocked int
+}
+
+func (m *metadata) BackupKVStore(_ context.Context, w io.Writer) error {
+	if m.kvLocked == 0 {
+		return fmt.Errorf("kv store is not locked")
+	}
+	_, err := w.Write([]byte("kv"))
+	return err
+}
+
+func (m *metadata) BackupShard(context.Context, io.Writer, uint64, time.Time) error {
+	return fmt.Errorf("unexpected shard backup")
+}
+
+func (m *metadata) RLockKVStore()   { m.kvLocked++ }
+func (m *metadata) RUnlockKVStore() { m.kvLocked-- }
+
+func (m *metadata) BackupSqlStore(_ context.Context, w io.Writer) error {
+	if m.sqlLocked == 0 {
+		return fmt.Errorf("sqlite database is not locked")
+	}
+	_, err := w.Write([]byte("sql"))
+	return err
+}
+
+func (m *metadata) RestoreSqlStore(context.Context, io.Reader) error {
+	return fmt.Errorf("unexpected restore")
+}
+
+func (m *metadata) RLockSqlStore()   { m.sqlLocked++ }
+func (m *metadata) RUnlockSqlStore() { m.sqlLocked-- }
+
+func (m *metadata) WriteManifest(_ context.Context, w io.Writer) error {
+	_, err := w.Write([]byte(`[]`))
+	return err
+}
+
+type store struct {
+	t    *testing.T
+	meta *metadata
+}
+
+func (s *store) ShardIDs() []uint64 {
+	return []uint64{2, 1, 3}
+}
+
+func (s *store) CreateShardSnapshot(id uint64, skipCacheOk bool) (string, error) {
+	if s.meta.kvLocked == 0 || s.meta.sqlLocked == 0 {
+		return "", fmt.Errorf("shard snapshot taken while metadata can change")
+	}
+	if skipCacheOk {
+		return "", fmt.Errorf("snapshot would skip the cache")
+	}
+	if id == 3 {
+		// deleted since its ID was listed
+		return "", tsdb.ErrShardNotFound
+	}
+	dir := filepath.Join(s.t.TempDir(), "tmp")
+	require.NoError(s.t, os.Mkdir(dir, 0700))
+	require.NoError(s.t, os.WriteFile(filepath.Join(dir, "000000001-000000001.tsm"), []byte(fmt.Sprintf("shard %d", id)), 0600))
+	return dir, nil
+}
+
+func TestService(t *testing.T) {
+	meta := &metadata{}
+	svc := NewService(zaptest.NewLogger(t), t.TempDir(), meta, meta, meta, &store{t: t, meta: meta})
+
+	m, err := svc.Create(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, meta.kvLocked)
+	assert.Zero(t, meta.sqlLocked)
+
+	kvSum := sha256.Sum256([]byte("kv"))
+	assert.Equal(t, File{Name: FileKV, Size: 2, SHA256: hex.EncodeToString(kvSum[:])}, m.KV)
+	assert.Equal(t, int64(3), m.SQL.Size)
+	assert.Equal(t, int64(2), m.Buckets.Size)
+	require.Len(t, m.Shards, 2)
+	assert.Equal(t, uint64(1), m.Shards[0].ID)
+	assert.Equal(t, uint64(2), m.Shards[1].ID)
+	assert.Equal(t, []File{{Name: "000000001-000000001.tsm", Size: 7}}, m.Shards[0].Files)
+
+	listed, err := svc.List()
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, m.ID, listed[0].ID)
+
+	f, err := svc.Open(m.ID, FileSQL)
+	require.NoError(t, err)
+	b, err := io.ReadAll(f)
+	require.NoError(t, f.Close())
+	require.NoError(t, err)
+	assert.Equal(t, "sql", string(b))
+
+	_, err = svc.Open(m.ID, "../manifest.json")
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.WriteShard(context.Background(), &buf, m.ID, 2))
+	tr := tar.NewReader(&buf)
+	h, err := tr.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "2/000000001-000000001.tsm", h.Name)
+	b, err = io.ReadAll(tr)
+	require.NoError(t, err)
+	assert.Equal(t, "shard 2", string(b))
+
+	err = svc.WriteShard(context.Background(), &buf, m.ID, 3)
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+	require.NoError(t, svc.Delete(m.ID))
+	_, err = svc.Find(m.ID)
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(svc.Delete(m.ID)))
+}
+
+func TestService_CreateFailure(t *testing.T) {
+	meta := &metadata{}
+	dir := t.TempDir()
+	svc := NewService(zaptest.NewLogger(t), dir, meta, meta, meta, &store{t: t, meta: &metadata{}})
+
+	// the shards cannot be snapshotted, so nothing is kept
+	_, err := svc.Create(context.Background())
+	require.Error(t, err)
+	assert.Zero(t, meta.kvLocked)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,11 @@
+CREATE TABLE datasources (
+    id VARCHAR(16) NOT NULL PRIMARY KEY,
+    org_id VARCHAR(16) NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT,
+    driver TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+
+    CONSTRAINT datasources_uniq_orgid_name UNIQUE (org_id, name)
+);
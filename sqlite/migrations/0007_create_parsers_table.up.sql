@@ -0,0 +1,13 @@
+CREATE TABLE parsers (
+    id VARCHAR(16) NOT NULL PRIMARY KEY,
+    org_id VARCHAR(16) NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT,
+    type TEXT NOT NULL,
+    pattern TEXT NOT NULL,
+    paths TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+
+    CONSTRAINT parsers_uniq_orgid_name UNIQUE (org_id, name)
+);
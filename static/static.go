@@ -72,6 +72,12 @@ func NewSwaggerHandler() http.Handler {
 	return mwSetCacheControl(swaggerHandler(fileOpener))
 }
 
+// Swagger returns the swagger JSON embedded in the binary. It returns an
+// error when the binary was built without assets.
+func Swagger() ([]byte, error) {
+	return Asset(path.Join(embedBaseDir, swaggerFile))
+}
+
 // mwSetCacheControl sets a default cache control header.
 func mwSetCacheControl(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
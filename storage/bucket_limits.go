@@ -0,0 +1,278 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// bucketLimitsRefreshInterval is how long the limits of a bucket are
+	// used before they are read again. Updates of the bucket made through
+	// the engine apply at once.
+	bucketLimitsRefreshInterval = 30 * time.Second
+
+	limitTagKeys   = "max_tag_keys"
+	limitFields    = "max_fields_per_measurement"
+	limitNewSeries = "max_new_series_per_hour"
+)
+
+// BucketSchemaStore reads the schema of the buckets the limits of a
+// BucketLimiter are enforced against.
+type BucketSchemaStore interface {
+	DatabaseSchema(database string) (*tsdb.DatabaseSchema, error)
+	HasSeries(database string, name []byte, tags models.Tags) bool
+}
+
+// BucketLimiter enforces the limits of buckets on the points written to
+// them. The tag keys and fields of a bucket are read from its indexes the
+// first time a bucket with limits is written to, and then tracked from the
+// points it admits. Deleted data is not subtracted until the limits of the
+// bucket change or the server restarts.
+type BucketLimiter struct {
+	store  BucketSchemaStore
+	finder BucketFinder
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[platform.ID]*bucketLimitState
+
+	rejected *prometheus.CounterVec
+}
+
+type bucketLimitState struct {
+	mu       sync.Mutex
+	limits   influxdb.BucketLimits
+	loadedAt time.Time
+
+	// tagKeys and fields are nil until they are read from the store.
+	tagKeys map[string]struct{}
+	fields  map[string]map[string]struct{}
+	// newSeries counts the series created in each minute of the last hour.
+	newSeries [60]struct {
+		minute int64
+		n      int
+	}
+}
+
+// NewBucketLimiter constructs a BucketLimiter reading the limits of the
+// buckets with finder.
+func NewBucketLimiter(store BucketSchemaStore, finder BucketFinder) *BucketLimiter {
+	return &BucketLimiter{
+		store:   store,
+		finder:  finder,
+		now:     time.Now,
+		buckets: make(map[platform.ID]*bucketLimitState),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storage",
+			Subsystem: "bucket_limits",
+			Name:      "rejected_points_total",
+			Help:      "Number of points rejected because they exceeded a limit of their bucket",
+		}, []string{"bucket", "limit"}),
+	}
+}
+
+// PrometheusCollectors returns the metrics of the rejected points.
+func (l *BucketLimiter) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{l.rejected}
+}
+
+// SetLimits applies new limits of a bucket at once, rather than when they
+// are next read.
+func (l *BucketLimiter) SetLimits(bucketID platform.ID, limits influxdb.BucketLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buckets[bucketID] = &bucketLimitState{limits: limits, loadedAt: l.now()}
+}
+
+// Forget drops the limits and the tracked schema of a bucket, such as a
+// deleted one.
+func (l *BucketLimiter) Forget(bucketID platform.ID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.buckets, bucketID)
+}
+
+// Admit returns the points that do not exceed the limits of the bucket. The
+// others are recorded as dropped points of ctx and counted by the returned
+// PartialWriteError.
+func (l *BucketLimiter) Admit(ctx context.Context, bucketID platform.ID, points []models.Point) ([]models.Point, error) {
+	s, err := l.state(ctx, bucketID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.limits.IsZero() {
+		return points, nil
+	}
+	if (s.limits.MaxTagKeys > 0 || s.limits.MaxFieldsPerMeasurement > 0) && s.tagKeys == nil {
+		schema, err := l.store.DatabaseSchema(bucketID.String())
+		if err != nil {
+			return nil, err
+		}
+		s.tagKeys, s.fields = schema.TagKeys, schema.Fields
+	}
+
+	var (
+		database      = bucketID.String()
+		droppedPoints = tsdb.DroppedPointsFromContext(ctx)
+		now           = l.now()
+		seen          = make(map[string]struct{})
+		newSeriesN    = s.newSeriesN(now)
+		dropped       int
+		reason        string
+		admitted      = points[:0:0]
+	)
+	for _, p := range points {
+		r, limit := s.check(p, database, l.store, seen, newSeriesN)
+		if r != "" {
+			l.rejected.WithLabelValues(database, limit).Inc()
+			droppedPoints.Add(p, r)
+			if reason == "" {
+				reason = r
+			}
+			dropped++
+			continue
+		}
+		if s.admit(p, database, l.store, seen) {
+			s.addNewSeries(now)
+			newSeriesN++
+		}
+		admitted = append(admitted, p)
+	}
+
+	if dropped > 0 {
+		return admitted, tsdb.PartialWriteError{Reason: reason, Dropped: dropped}
+	}
+	return admitted, nil
+}
+
+// state returns the state of a bucket, reading its limits when they were
+// not read recently.
+func (l *BucketLimiter) state(ctx context.Context, bucketID platform.ID) (*bucketLimitState, error) {
+	l.mu.Lock()
+	s, ok := l.buckets[bucketID]
+	if !ok {
+		s = &bucketLimitState{}
+		l.buckets[bucketID] = s
+	}
+	l.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loadedAt.IsZero() && l.now().Sub(s.loadedAt) < bucketLimitsRefreshInterval {
+		return s, nil
+	}
+
+	buckets, _, err := l.finder.FindBuckets(ctx, influxdb.BucketFilter{ID: &bucketID})
+	if err != nil {
+		return nil, err
+	}
+	var limits influxdb.BucketLimits
+	if len(buckets) > 0 && buckets[0].Limits != nil {
+		limits = *buckets[0].Limits
+	}
+	if limits != s.limits {
+		// the schema tracked for other limits may be stale
+		s.tagKeys, s.fields = nil, nil
+	}
+	s.limits, s.loadedAt = limits, l.now()
+	return s, nil
+}
+
+// check returns the reason p exceeds a limit, and the limit, or empty
+// strings when it does not.
+func (s *bucketLimitState) check(p models.Point, database string, store BucketSchemaStore, seen map[string]struct{}, newSeriesN int) (string, string) {
+	if max := s.limits.MaxTagKeys; max > 0 {
+		n := len(s.tagKeys)
+		for _, t := range p.Tags() {
+			if _, ok := s.tagKeys[string(t.Key)]; !ok {
+				n++
+			}
+		}
+		if n > max {
+			return fmt.Sprintf("bucket limit exceeded: more than %d tag keys", max), limitTagKeys
+		}
+	}
+
+	if max := s.limits.MaxFieldsPerMeasurement; max > 0 {
+		fields := s.fields[string(p.Name())]
+		n := len(fields)
+		iter := p.FieldIterator()
+		for iter.Next() {
+			if _, ok := fields[string(iter.FieldKey())]; !ok {
+				n++
+			}
+		}
+		if n > max {
+			return fmt.Sprintf("bucket limit exceeded: more than %d fields in measurement %q", max, p.Name()), limitFields
+		}
+	}
+
+	if max := s.limits.MaxNewSeriesPerHour; max > 0 && newSeriesN >= max {
+		if _, ok := seen[string(p.Key())]; !ok && !store.HasSeries(database, p.Name(), p.Tags()) {
+			return fmt.Sprintf("bucket limit exceeded: more than %d new series in the last hour", max), limitNewSeries
+		}
+	}
+	return "", ""
+}
+
+// admit adds the tag keys and fields of p to the schema of the bucket and
+// reports whether it creates a series.
+func (s *bucketLimitState) admit(p models.Point, database string, store BucketSchemaStore, seen map[string]struct{}) bool {
+	if s.tagKeys != nil {
+		for _, t := range p.Tags() {
+			s.tagKeys[string(t.Key)] = struct{}{}
+		}
+		fields := s.fields[string(p.Name())]
+		if fields == nil {
+			fields = make(map[string]struct{})
+			s.fields[string(p.Name())] = fields
+		}
+		iter := p.FieldIterator()
+		for iter.Next() {
+			fields[string(iter.FieldKey())] = struct{}{}
+		}
+	}
+
+	if s.limits.MaxNewSeriesPerHour == 0 {
+		return false
+	}
+	key := string(p.Key())
+	if _, ok := seen[key]; ok {
+		return false
+	}
+	seen[key] = struct{}{}
+	return !store.HasSeries(database, p.Name(), p.Tags())
+}
+
+// newSeriesN returns the number of series created in the hour before now.
+func (s *bucketLimitState) newSeriesN(now time.Time) int {
+	minute := now.Unix() / 60
+	var n int
+	for _, m := range s.newSeries {
+		if minute-m.minute < int64(len(s.newSeries)) {
+			n += m.n
+		}
+	}
+	return n
+}
+
+func (s *bucketLimitState) addNewSeries(now time.Time) {
+	minute := now.Unix() / 60
+	m := &s.newSeries[minute%int64(len(s.newSeries))]
+	if m.minute != minute {
+		m.minute, m.n = minute, 0
+	}
+	m.n++
+}
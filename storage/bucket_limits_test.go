@@ -0,0 +1,88 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaStore struct {
+	schema *tsdb.DatabaseSchema
+	series map[string]bool
+}
+
+func (s *schemaStore) DatabaseSchema(string) (*tsdb.DatabaseSchema, error) {
+	return s.schema, nil
+}
+
+func (s *schemaStore) HasSeries(_ string, name []byte, tags models.Tags) bool {
+	return s.series[string(models.MakeKey(name, tags))]
+}
+
+type bucketFinder []*influxdb.Bucket
+
+func (f bucketFinder) FindBuckets(context.Context, influxdb.BucketFilter, ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+	return f, len(f), nil
+}
+
+func TestBucketLimiter(t *testing.T) {
+	bucketID := platform.ID(1)
+	store := &schemaStore{
+		schema: &tsdb.DatabaseSchema{
+			TagKeys: map[string]struct{}{"host": {}},
+			Fields:  map[string]map[string]struct{}{"cpu": {"usage": {}}},
+		},
+		series: map[string]bool{"cpu,host=a": true},
+	}
+	bucket := &influxdb.Bucket{
+		ID: bucketID,
+		Limits: &influxdb.BucketLimits{
+			MaxTagKeys:              2,
+			MaxFieldsPerMeasurement: 2,
+			MaxNewSeriesPerHour:     2,
+		},
+	}
+	l := storage.NewBucketLimiter(store, bucketFinder{bucket})
+
+	points, err := models.ParsePointsString(`cpu,host=a usage=1,idle=2
+cpu,host=a,region=west usage=1
+cpu,host=a,dc=1 usage=1
+cpu,host=a usage=1,idle=2,system=3
+cpu,host=b usage=1
+cpu,host=c usage=1
+cpu,host=a usage=3`)
+	require.NoError(t, err)
+
+	ctx, dropped := tsdb.ContextWithDroppedPoints(context.Background())
+	admitted, err := l.Admit(ctx, bucketID, points)
+	partialErr, ok := err.(tsdb.PartialWriteError)
+	require.True(t, ok, "expected a partial write error, got %v", err)
+	assert.Equal(t, 3, partialErr.Dropped)
+
+	// region=west is the first new series of the hour and host=b the second
+	require.Len(t, admitted, 4)
+	for i, j := range []int{0, 1, 4, 6} {
+		assert.Equal(t, points[j], admitted[i])
+	}
+
+	reasons := make(map[models.Point]string)
+	for _, d := range dropped.Points() {
+		reasons[d.Point] = d.Reason
+	}
+	assert.Equal(t, "bucket limit exceeded: more than 2 tag keys", reasons[points[2]])
+	assert.Equal(t, `bucket limit exceeded: more than 2 fields in measurement "cpu"`, reasons[points[3]])
+	assert.Equal(t, "bucket limit exceeded: more than 2 new series in the last hour", reasons[points[5]])
+
+	// removing the limits admits every point
+	l.SetLimits(bucketID, influxdb.BucketLimits{})
+	admitted, err = l.Admit(context.Background(), bucketID, points)
+	require.NoError(t, err)
+	assert.Len(t, admitted, len(points))
+}
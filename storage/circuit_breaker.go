@@ -0,0 +1,284 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// ErrStorageUnavailable is wrapped by the errors of the calls a
+// CircuitBreaker fails without calling the engine.
+var ErrStorageUnavailable = errors.New("storage unavailable")
+
+// IsUnavailable reports whether err is the error of a call a CircuitBreaker
+// failed without calling the engine.
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrStorageUnavailable)
+}
+
+// CircuitBreakerConfig configures a CircuitBreaker.
+type CircuitBreakerConfig struct {
+	// StallTimeout is the duration after which a call still in progress
+	// opens the breaker.
+	StallTimeout time.Duration
+	// MaxFailures is the number of consecutive failed calls that opens the
+	// breaker. 0 only opens it on stalls.
+	MaxFailures int
+	// Cooldown is how long the breaker stays open before a call is let
+	// through to probe the engine.
+	Cooldown time.Duration
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+// CircuitBreaker stops calling the storage engine once it stalls, such as
+// on a hung fsync or a full disk, so callers fail right away with an
+// EUnavailable error instead of queuing behind the stalled calls. It opens
+// when a call takes longer than the stall timeout or after consecutive
+// failures, and lets a single call through after the cooldown to probe
+// whether the engine recovered.
+type CircuitBreaker struct {
+	log    *zap.Logger
+	config CircuitBreakerConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	reason   error
+	probing  bool
+
+	stateGauge prometheus.Gauge
+	trips      prometheus.Counter
+	rejected   *prometheus.CounterVec
+}
+
+// NewCircuitBreaker constructs a closed CircuitBreaker.
+func NewCircuitBreaker(log *zap.Logger, config CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		log:    log,
+		config: config,
+		now:    time.Now,
+		stateGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storage",
+			Subsystem: "circuit_breaker",
+			Name:      "state",
+			Help:      "State of the storage circuit breaker, 0 closed, 1 half open, 2 open",
+		}),
+		trips: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storage",
+			Subsystem: "circuit_breaker",
+			Name:      "trips_total",
+			Help:      "Number of times the storage circuit breaker opened",
+		}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storage",
+			Subsystem: "circuit_breaker",
+			Name:      "rejected_total",
+			Help:      "Number of storage calls failed without calling the engine, by operation",
+		}, []string{"op"}),
+	}
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (b *CircuitBreaker) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{b.stateGauge, b.trips, b.rejected}
+}
+
+// Do calls fn unless the breaker is open, and records how it went.
+func (b *CircuitBreaker) Do(op string, fn func() error) error {
+	probe, err := b.allow(op)
+	if err != nil {
+		return err
+	}
+
+	var stall *time.Timer
+	if b.config.StallTimeout > 0 {
+		stall = time.AfterFunc(b.config.StallTimeout, func() {
+			b.trip(fmt.Errorf("%s has not completed for %s", op, b.config.StallTimeout))
+		})
+	}
+	err = fn()
+	if stall != nil {
+		stall.Stop()
+	}
+	b.record(probe, err)
+	return err
+}
+
+// allow reports whether a call is let through, and whether it probes the
+// engine for the breaker to close.
+func (b *CircuitBreaker) allow(op string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerClosed:
+		return false, nil
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) >= b.config.Cooldown {
+			b.setState(breakerHalfOpen)
+		}
+	}
+	if b.state == breakerHalfOpen && !b.probing {
+		b.probing = true
+		return true, nil
+	}
+
+	b.rejected.WithLabelValues(op).Inc()
+	return false, &errors2.Error{
+		Code: errors2.EUnavailable,
+		Msg:  fmt.Sprintf("%s: %v", ErrStorageUnavailable, b.reason),
+		Err:  ErrStorageUnavailable,
+	}
+}
+
+// record closes the breaker on a successful probe, and counts a failure
+// towards opening it. The outcome of a call admitted before the breaker
+// opened, such as a stalled call that finally completes, says nothing about
+// whether the engine recovered and is ignored while it is not closed.
+func (b *CircuitBreaker) record(probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if !probe && b.state != breakerClosed {
+		return
+	}
+
+	if !countsAsFailure(err) {
+		b.failures = 0
+		if probe && b.state == breakerHalfOpen {
+			b.log.Info("Storage engine recovered, closing circuit breaker")
+			b.setState(breakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if probe || (b.config.MaxFailures > 0 && b.failures >= b.config.MaxFailures) {
+		b.open(err)
+	}
+}
+
+func (b *CircuitBreaker) trip(reason error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.open(reason)
+}
+
+// open opens the breaker for reason, under the lock.
+func (b *CircuitBreaker) open(reason error) {
+	if b.state != breakerOpen {
+		b.log.Warn("Storage engine unavailable, opening circuit breaker",
+			zap.Duration("cooldown", b.config.Cooldown),
+			zap.Error(reason))
+		b.trips.Inc()
+	}
+	b.reason = reason
+	b.openedAt = b.now()
+	b.setState(breakerOpen)
+}
+
+func (b *CircuitBreaker) setState(s breakerState) {
+	b.state = s
+	b.stateGauge.Set(float64(s))
+}
+
+// countsAsFailure reports whether err tells the engine is failing, rather
+// than rejecting the call itself, such as points conflicting with the
+// schema of their bucket.
+func countsAsFailure(err error) bool {
+	if err == nil ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrEngineClosed) ||
+		errors.Is(err, ErrEngineReadOnly) {
+		return false
+	}
+	if _, partial := err.(tsdb.PartialWriteError); partial {
+		return false
+	}
+	if errors.As(err, new(*errors2.Error)) {
+		code := errors2.ErrorCode(err)
+		return code == errors2.EInternal || code == errors2.EUnavailable
+	}
+	return true
+}
+
+// circuitBreakingEngine is the part of the engine the API changes data and
+// buckets through.
+type circuitBreakingEngine interface {
+	PointsWriter
+	EngineSchema
+	DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID platform.ID, min, max int64, pred influxdb.Predicate) error
+}
+
+// CircuitBreakingEngine calls the engine through a CircuitBreaker.
+type CircuitBreakingEngine struct {
+	engine  circuitBreakingEngine
+	breaker *CircuitBreaker
+}
+
+// NewCircuitBreakingEngine constructs a CircuitBreakingEngine calling engine
+// through breaker.
+func NewCircuitBreakingEngine(engine circuitBreakingEngine, breaker *CircuitBreaker) *CircuitBreakingEngine {
+	return &CircuitBreakingEngine{
+		engine:  engine,
+		breaker: breaker,
+	}
+}
+
+// WritePoints writes points to the engine.
+func (e *CircuitBreakingEngine) WritePoints(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	return e.breaker.Do("write", func() error {
+		return e.engine.WritePoints(ctx, orgID, bucketID, points)
+	})
+}
+
+// CreateBucket creates the storage of a bucket.
+func (e *CircuitBreakingEngine) CreateBucket(ctx context.Context, b *influxdb.Bucket) error {
+	return e.breaker.Do("create_bucket", func() error {
+		return e.engine.CreateBucket(ctx, b)
+	})
+}
+
+// UpdateBucketRetentionPolicy updates the retention of the storage of a bucket.
+func (e *CircuitBreakingEngine) UpdateBucketRetentionPolicy(ctx context.Context, bucketID platform.ID, upd *influxdb.BucketUpdate) error {
+	return e.breaker.Do("update_bucket", func() error {
+		return e.engine.UpdateBucketRetentionPolicy(ctx, bucketID, upd)
+	})
+}
+
+// DeleteBucket deletes the storage of a bucket.
+func (e *CircuitBreakingEngine) DeleteBucket(ctx context.Context, orgID, bucketID platform.ID) error {
+	return e.breaker.Do("delete_bucket", func() error {
+		return e.engine.DeleteBucket(ctx, orgID, bucketID)
+	})
+}
+
+// DeleteBucketRangePredicate deletes the points of a bucket matching pred
+// within a time range.
+func (e *CircuitBreakingEngine) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID platform.ID, min, max int64, pred influxdb.Predicate) error {
+	return e.breaker.Do("delete", func() error {
+		return e.engine.DeleteBucketRangePredicate(ctx, orgID, bucketID, min, max, pred)
+	})
+}
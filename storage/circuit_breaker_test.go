@@ -0,0 +1,106 @@
+package storage_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := storage.NewCircuitBreaker(zaptest.NewLogger(t), storage.CircuitBreakerConfig{
+		MaxFailures: 2,
+		Cooldown:    20 * time.Millisecond,
+	})
+	failing := errors.New("input/output error")
+	calls := 0
+	fail := func() error { calls++; return failing }
+	succeed := func() error { calls++; return nil }
+
+	// errors rejecting the call itself do not open the breaker
+	for i := 0; i < 3; i++ {
+		assert.Error(t, b.Do("write", func() error { return tsdb.PartialWriteError{Reason: "field type conflict"} }))
+		assert.Error(t, b.Do("write", func() error {
+			return &errors2.Error{Code: errors2.ENotFound, Msg: "bucket not found"}
+		}))
+	}
+
+	// consecutive failures open the breaker
+	assert.Equal(t, failing, b.Do("write", fail))
+	assert.Equal(t, failing, b.Do("write", fail))
+	err := b.Do("write", succeed)
+	require.Error(t, err)
+	assert.True(t, storage.IsUnavailable(err))
+	assert.Equal(t, errors2.EUnavailable, errors2.ErrorCode(err))
+	assert.Contains(t, err.Error(), failing.Error())
+	assert.Equal(t, 2, calls)
+
+	// a call probes the engine after the cooldown, a failed probe reopens it
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, failing, b.Do("write", fail))
+	assert.True(t, storage.IsUnavailable(b.Do("write", succeed)))
+	assert.Equal(t, 3, calls)
+
+	// a successful probe closes it
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, b.Do("write", succeed))
+	assert.NoError(t, b.Do("write", succeed))
+	assert.Equal(t, 5, calls)
+}
+
+func TestCircuitBreaker_Stall(t *testing.T) {
+	b := storage.NewCircuitBreaker(zaptest.NewLogger(t), storage.CircuitBreakerConfig{
+		StallTimeout: 10 * time.Millisecond,
+		Cooldown:     time.Hour,
+	})
+
+	stalled := make(chan struct{})
+	released := make(chan error)
+	go func() {
+		released <- b.Do("write", func() error {
+			<-stalled
+			return nil
+		})
+	}()
+
+	// calls fail right away while the engine is stalled
+	require.Eventually(t, func() bool {
+		return storage.IsUnavailable(b.Do("delete", func() error { return nil }))
+	}, time.Second, 5*time.Millisecond)
+
+	close(stalled)
+	assert.NoError(t, <-released)
+}
+
+func TestCircuitBreaker_LateSuccess(t *testing.T) {
+	b := storage.NewCircuitBreaker(zaptest.NewLogger(t), storage.CircuitBreakerConfig{
+		MaxFailures: 1,
+		Cooldown:    time.Hour,
+	})
+
+	admitted := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- b.Do("write", func() error {
+			close(admitted)
+			<-release
+			return nil
+		})
+	}()
+	<-admitted
+
+	// a failure opens the breaker while the first call is in progress
+	assert.Error(t, b.Do("write", func() error { return errors.New("input/output error") }))
+
+	// the call admitted before the breaker opened completing does not close it
+	close(release)
+	assert.NoError(t, <-done)
+	assert.True(t, storage.IsUnavailable(b.Do("write", func() error { return nil })))
+}
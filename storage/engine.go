@@ -5,14 +5,18 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/influxql/query"
+	"github.com/influxdata/influxdb/v2/kit/check"
+	"github.com/influxdata/influxdb/v2/kit/memstat"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/maintenance"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/tsdb"
 	_ "github.com/influxdata/influxdb/v2/tsdb/engine"
@@ -34,6 +38,13 @@ var (
 	// it's closed.
 	ErrEngineClosed = errors.New("engine is closed")
 
+	// ErrEngineReadOnly is returned when data is modified in a read-only
+	// engine.
+	ErrEngineReadOnly = &errors2.Error{
+		Code: errors2.EForbidden,
+		Msg:  "storage engine is read-only",
+	}
+
 	// ErrNotImplemented is returned for APIs that are temporarily not implemented.
 	ErrNotImplemented = errors.New("not implemented")
 )
@@ -55,6 +66,12 @@ type Engine struct {
 	precreatorService *precreator.Service
 
 	writePointsValidationEnabled bool
+	readOnly                     bool
+
+	maintenance        *maintenance.Coordinator
+	compactionSchedule *tsdb.CompactionSchedule
+	bucketFinder       BucketFinder
+	bucketLimiter      *BucketLimiter
 
 	logger          *zap.Logger
 	metricsDisabled bool
@@ -75,6 +92,56 @@ func WithMetricsDisabled(m bool) Option {
 	}
 }
 
+// WithReadOnly opens the engine read-only. A read-only engine does not
+// compact, enforce retention or create shards and rejects writes and
+// deletes, so that it can serve queries from a copy of the data of another
+// engine, which Refresh picks up.
+func WithReadOnly(readOnly bool) Option {
+	return func(e *Engine) {
+		e.readOnly = readOnly
+	}
+}
+
+// WithMaintenanceCoordinator admits backups and full compactions through
+// the coordinator, which bounds their concurrency and throughput and lets
+// operators pause or abort them.
+func WithMaintenanceCoordinator(c *maintenance.Coordinator) Option {
+	return func(e *Engine) {
+		e.maintenance = c
+	}
+}
+
+// WithCompactionSchedule only starts full compactions in the windows of
+// the schedule, which Config.Data.CompactionSchedule parses.
+func WithCompactionSchedule(s *tsdb.CompactionSchedule) Option {
+	return func(e *Engine) {
+		e.compactionSchedule = s
+	}
+}
+
+// WithBucketFinder expires the series of the buckets found with f that
+// have a series TTL, enforces the limits of the buckets on writes and
+// stores the shards of system buckets in the system bucket data directory.
+func WithBucketFinder(f BucketFinder) Option {
+	return func(e *Engine) {
+		e.bucketFinder = f
+	}
+}
+
+// compactionAdmitter admits the compactions of the shards through the
+// maintenance coordinator.
+type compactionAdmitter struct {
+	c *maintenance.Coordinator
+}
+
+func (a compactionAdmitter) AdmitCompaction(description string) (tsdb.MaintenanceOp, bool) {
+	op, ok := a.c.TryBegin(context.Background(), maintenance.KindCompaction, description)
+	if !ok {
+		return nil, false
+	}
+	return op, true
+}
+
 type MetaClient interface {
 	CreateDatabaseWithRetentionPolicy(name string, spec *meta.RetentionPolicySpec) (*meta.DatabaseInfo, error)
 	DropDatabase(name string) error
@@ -96,16 +163,24 @@ type MetaClient interface {
 }
 
 type TSDBStore interface {
+	CompactionLimits() (tsdb.CompactionLimits, error)
+	CreateShardSnapshot(id uint64, skipCacheOk bool) (string, error)
+	Databases() []string
+	DataDirs() []string
 	DeleteMeasurement(ctx context.Context, database, name string) error
 	DeleteSeries(ctx context.Context, database string, sources []influxql.Source, condition influxql.Expr) error
 	MeasurementNames(ctx context.Context, auth query.Authorizer, database string, cond influxql.Expr) ([][]byte, error)
+	MoveShard(ctx context.Context, id uint64, dir string, progress *tsdb.ShardMoveProgress) error
 	ShardGroup(ids []uint64) tsdb.ShardGroup
+	ShardIDs() []uint64
 	Shards(ids []uint64) []*tsdb.Shard
 	TagKeys(ctx context.Context, auth query.Authorizer, shardIDs []uint64, cond influxql.Expr) ([]tsdb.TagKeys, error)
 	TagValues(ctx context.Context, auth query.Authorizer, shardIDs []uint64, cond influxql.Expr) ([]tsdb.TagValues, error)
+	MeasurementCardinalities(ctx context.Context, database string, exact bool, topTagKeys int) ([]influxdb.MeasurementCardinality, error)
 	SeriesCardinality(ctx context.Context, database string) (int64, error)
 	SeriesCardinalityFromShards(ctx context.Context, shards []*tsdb.Shard) (*tsdb.SeriesIDSet, error)
 	SeriesFile(database string) *tsdb.SeriesFile
+	SetCompactionLimits(l tsdb.CompactionLimits) (tsdb.CompactionLimits, error)
 }
 
 // NewEngine initialises a new storage engine, including a series file, index and
@@ -133,6 +208,19 @@ func NewEngine(path string, c Config, options ...Option) *Engine {
 	e.tsdbStore.EngineOptions.EngineVersion = c.Data.Engine
 	e.tsdbStore.EngineOptions.IndexVersion = c.Data.Index
 	e.tsdbStore.EngineOptions.MetricsDisabled = e.metricsDisabled
+	if e.bucketFinder != nil {
+		e.tsdbStore.EngineOptions.SystemDatabase = systemDatabase(e.bucketFinder)
+		e.bucketLimiter = NewBucketLimiter(e.tsdbStore, e.bucketFinder)
+	}
+	if e.readOnly {
+		e.tsdbStore.EngineOptions.CompactionDisabled = true
+		e.tsdbStore.EngineOptions.MonitorDisabled = true
+		e.tsdbStore.EngineOptions.ReadOnly = true
+	}
+	if e.maintenance != nil {
+		e.tsdbStore.EngineOptions.MaintenanceAdmitter = compactionAdmitter{c: e.maintenance}
+	}
+	e.tsdbStore.EngineOptions.CompactionSchedule = e.compactionSchedule
 
 	pw := coordinator.NewPointsWriter(c.WriteTimeout, path)
 	pw.TSDBStore = e.tsdbStore
@@ -142,6 +230,9 @@ func NewEngine(path string, c Config, options ...Option) *Engine {
 	e.retentionService = retention.NewService(c.RetentionService)
 	e.retentionService.TSDBStore = e.tsdbStore
 	e.retentionService.MetaClient = e.metaClient
+	if e.bucketFinder != nil {
+		e.retentionService.BucketFinder = e.bucketFinder
+	}
 
 	e.precreatorService = precreator.NewService(c.PrecreatorConfig)
 	e.precreatorService.MetaClient = e.metaClient
@@ -175,7 +266,11 @@ func (e *Engine) PrometheusCollectors() []prometheus.Collector {
 	metrics = append(metrics, coordinator.PrometheusCollectors()...)
 	metrics = append(metrics, tsdb.ShardCollectors()...)
 	metrics = append(metrics, tsdb.BucketCollectors()...)
+	metrics = append(metrics, tsdb.BlockCacheCollectors()...)
 	metrics = append(metrics, retention.PrometheusCollectors()...)
+	if e.bucketLimiter != nil {
+		metrics = append(metrics, e.bucketLimiter.PrometheusCollectors()...)
+	}
 	return metrics
 }
 
@@ -196,12 +291,14 @@ func (e *Engine) Open(ctx context.Context) (err error) {
 		return err
 	}
 
-	if err := e.retentionService.Open(ctx); err != nil {
-		return err
-	}
+	if !e.readOnly {
+		if err := e.retentionService.Open(ctx); err != nil {
+			return err
+		}
 
-	if err := e.precreatorService.Open(ctx); err != nil {
-		return err
+		if err := e.precreatorService.Open(ctx); err != nil {
+			return err
+		}
 	}
 
 	e.closing = make(chan struct{})
@@ -209,6 +306,50 @@ func (e *Engine) Open(ctx context.Context) (err error) {
 	return nil
 }
 
+// Check reports whether the engine is open.
+func (e *Engine) Check(ctx context.Context) check.Response {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return check.Response{
+			Name:    "storage-engine",
+			Status:  check.StatusFail,
+			Message: ErrEngineClosed.Error(),
+		}
+	}
+	resp := check.Response{Name: "storage-engine", Status: check.StatusPass}
+	if e.readOnly {
+		resp.Message = "open read-only"
+	}
+	return resp
+}
+
+// ReadOnly returns true if the engine was opened read-only.
+func (e *Engine) ReadOnly() bool {
+	return e.readOnly
+}
+
+// Refresh reopens the shards of a read-only engine to serve the data on
+// disk, which is replaced by another process.
+func (e *Engine) Refresh(ctx context.Context) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if !e.readOnly {
+		return &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "only a read-only storage engine can be refreshed",
+		}
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return ErrEngineClosed
+	}
+	return e.tsdbStore.Reload(ctx)
+}
+
 // EnableCompactions allows the series file, index, & underlying engine to compact.
 func (e *Engine) EnableCompactions() {
 }
@@ -274,8 +415,34 @@ func (e *Engine) WritePoints(ctx context.Context, orgID platform.ID, bucketID pl
 	if e.closing == nil {
 		return ErrEngineClosed
 	}
+	if e.readOnly {
+		return ErrEngineReadOnly
+	}
 
-	return e.pointsWriter.WritePoints(ctx, bucketID.String(), meta.DefaultRetentionPolicyName, models.ConsistencyLevelAll, &meta.UserInfo{}, points)
+	var limitErr error
+	if e.bucketLimiter != nil {
+		admitted, err := e.bucketLimiter.Admit(ctx, bucketID, points)
+		if _, partial := err.(tsdb.PartialWriteError); err != nil && !partial {
+			return err
+		}
+		points, limitErr = admitted, err
+		if len(points) == 0 {
+			return limitErr
+		}
+	}
+
+	err := e.pointsWriter.WritePoints(ctx, bucketID.String(), meta.DefaultRetentionPolicyName, models.ConsistencyLevelAll, &meta.UserInfo{}, points)
+	if limitErr == nil {
+		return err
+	}
+	if err == nil {
+		return limitErr
+	}
+	if partialErr, ok := err.(tsdb.PartialWriteError); ok {
+		partialErr.Dropped += limitErr.(tsdb.PartialWriteError).Dropped
+		return partialErr
+	}
+	return err
 }
 
 func (e *Engine) CreateBucket(ctx context.Context, b *influxdb.Bucket) (err error) {
@@ -311,17 +478,58 @@ func (e *Engine) UpdateBucketRetentionPolicy(ctx context.Context, bucketID platf
 			Msg:  "shard-group duration must also be updated to be smaller than new retention duration",
 		}
 	}
+	if err == nil && upd.Limits != nil && e.bucketLimiter != nil {
+		e.bucketLimiter.SetLimits(bucketID, *upd.Limits)
+	}
 	return err
 }
 
+// EnforceBucketRetention removes the data of the bucket older than the
+// retention period immediately, rather than on the next retention check.
+func (e *Engine) EnforceBucketRetention(ctx context.Context, bucketID platform.ID, retentionPeriod *time.Duration, dryRun bool) (*influxdb.RetentionEnforcement, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+	if e.readOnly {
+		return nil, ErrEngineReadOnly
+	}
+
+	if retentionPeriod == nil {
+		rpi, err := e.metaClient.RetentionPolicy(bucketID.String(), meta.DefaultRetentionPolicyName)
+		if err != nil {
+			return nil, err
+		}
+		if rpi == nil {
+			return nil, &errors2.Error{
+				Code: errors2.ENotFound,
+				Msg:  "bucket not found in storage engine",
+			}
+		}
+		retentionPeriod = &rpi.Duration
+	}
+
+	return e.retentionService.EnforceRetentionPolicy(ctx, bucketID.String(), meta.DefaultRetentionPolicyName, *retentionPeriod, dryRun)
+}
+
 // DeleteBucket deletes an entire bucket from the storage engine.
 func (e *Engine) DeleteBucket(ctx context.Context, orgID, bucketID platform.ID) error {
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
+	if e.readOnly {
+		return ErrEngineReadOnly
+	}
 	err := e.tsdbStore.DeleteDatabase(bucketID.String())
 	if err != nil {
 		return err
 	}
+	if e.bucketLimiter != nil {
+		e.bucketLimiter.Forget(bucketID)
+	}
 	return e.metaClient.DropDatabase(bucketID.String())
 }
 
@@ -336,9 +544,31 @@ func (e *Engine) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID
 	if e.closing == nil {
 		return ErrEngineClosed
 	}
+	if e.readOnly {
+		return ErrEngineReadOnly
+	}
 	return e.tsdbStore.DeleteSeriesWithPredicate(ctx, bucketID.String(), min, max, pred)
 }
 
+// MemoryUsage attributes the memory held by the TSM caches and TSI indexes to
+// the buckets they belong to.
+func (e *Engine) MemoryUsage() []memstat.Usage {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return nil
+	}
+
+	var usage []memstat.Usage
+	for _, u := range e.tsdbStore.MemoryUsage() {
+		usage = append(usage,
+			memstat.Usage{Component: memstat.ComponentTSMCache, Name: u.Database, Bytes: u.CacheBytes},
+			memstat.Usage{Component: memstat.ComponentTSIIndex, Name: u.Database, Bytes: u.IndexBytes},
+		)
+	}
+	return usage
+}
+
 // RLockKVStore locks the KV store as well as the engine in preparation for doing a backup.
 func (e *Engine) RLockKVStore() {
 	e.mu.RLock()
@@ -359,6 +589,14 @@ func (e *Engine) BackupKVStore(ctx context.Context, w io.Writer) error {
 		return ErrEngineClosed
 	}
 
+	// The metadata is backed up while the KV store is locked, so it is
+	// tracked without waiting for other operations or being throttled.
+	if e.maintenance != nil {
+		op := e.maintenance.Track(ctx, maintenance.KindBackup, "backup of metadata")
+		defer op.End()
+		ctx, w = op.Context(), op.Writer(w)
+	}
+
 	return e.metaClient.Backup(ctx, w)
 }
 
@@ -366,10 +604,21 @@ func (e *Engine) BackupShard(ctx context.Context, w io.Writer, shardID uint64, s
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+	if e.maintenance != nil {
+		op, err := e.maintenance.Begin(ctx, maintenance.KindBackup, fmt.Sprintf("backup of shard %d", shardID))
+		if err != nil {
+			return err
+		}
+		defer op.End()
+		w = op.Writer(w)
+	}
 
-	if e.closing == nil {
+	// The engine is not locked while the backup is streamed, as the
+	// operation may be throttled or paused for a long time.
+	e.mu.RLock()
+	closed := e.closing == nil
+	e.mu.RUnlock()
+	if closed {
 		return ErrEngineClosed
 	}
 
@@ -386,6 +635,9 @@ func (e *Engine) RestoreKVStore(ctx context.Context, r io.Reader) error {
 	if e.closing == nil {
 		return ErrEngineClosed
 	}
+	if e.readOnly {
+		return ErrEngineReadOnly
+	}
 
 	// Replace KV store data and remove all existing shard data.
 	if err := e.metaClient.Restore(ctx, r); err != nil {
@@ -425,6 +677,9 @@ func (e *Engine) RestoreBucket(ctx context.Context, id platform.ID, buf []byte)
 	if e.closing == nil {
 		return nil, ErrEngineClosed
 	}
+	if e.readOnly {
+		return nil, ErrEngineReadOnly
+	}
 
 	var newDBI meta.DatabaseInfo
 	if err := newDBI.UnmarshalBinary(buf); err != nil {
@@ -488,6 +743,9 @@ func (e *Engine) RestoreShard(ctx context.Context, shardID uint64, r io.Reader)
 	if e.closing == nil {
 		return ErrEngineClosed
 	}
+	if e.readOnly {
+		return ErrEngineReadOnly
+	}
 
 	return e.tsdbStore.RestoreShard(ctx, shardID, r)
 }
@@ -507,6 +765,106 @@ func (e *Engine) SeriesCardinality(ctx context.Context, bucketID platform.ID) in
 	return n
 }
 
+// FindBucketCardinality counts the series of a bucket from the indexes of
+// its shards.
+func (e *Engine) FindBucketCardinality(ctx context.Context, bucketID platform.ID, exact bool, topTagKeys int) (*influxdb.BucketCardinality, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	ms, err := e.tsdbStore.MeasurementCardinalities(ctx, bucketID.String(), exact, topTagKeys)
+	if err != nil {
+		return nil, err
+	}
+	c := &influxdb.BucketCardinality{
+		BucketID:     bucketID,
+		Exact:        exact,
+		Measurements: ms,
+	}
+	// a series belongs to a single measurement
+	for _, m := range ms {
+		c.Series += m.Series
+	}
+	return c, nil
+}
+
+// FindBucketShards returns the shard groups of a bucket, with the size, TSM
+// files and tombstones of their shards.
+func (e *Engine) FindBucketShards(ctx context.Context, bucketID platform.ID) ([]influxdb.ShardGroupStatus, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	rpi, err := e.metaClient.RetentionPolicy(bucketID.String(), meta.DefaultRetentionPolicyName)
+	if err != nil {
+		return nil, err
+	}
+	if rpi == nil {
+		return nil, &errors2.Error{
+			Code: errors2.ENotFound,
+			Msg:  "bucket not found in storage engine",
+		}
+	}
+
+	groups := make([]influxdb.ShardGroupStatus, 0, len(rpi.ShardGroups))
+	for _, sgi := range rpi.ShardGroups {
+		if sgi.Deleted() {
+			continue
+		}
+		g := influxdb.ShardGroupStatus{
+			ID:     sgi.ID,
+			Start:  sgi.StartTime,
+			End:    sgi.EndTime,
+			Shards: make([]influxdb.ShardStatus, 0, len(sgi.Shards)),
+		}
+		for _, si := range sgi.Shards {
+			g.Shards = append(g.Shards, e.shardStatus(si.ID))
+		}
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Start.Before(groups[j].Start)
+	})
+	return groups, nil
+}
+
+// shardStatus returns the state on disk of the shard, or only its ID if it
+// is not open.
+func (e *Engine) shardStatus(id uint64) influxdb.ShardStatus {
+	st := influxdb.ShardStatus{ID: id}
+	shards := e.tsdbStore.Shards([]uint64{id})
+	if len(shards) == 0 {
+		return st
+	}
+	sh := shards[0]
+
+	size, err := sh.DiskSize()
+	if err != nil {
+		return st
+	}
+	fs, err := sh.FileStats()
+	if err != nil {
+		return st
+	}
+	st.DiskSize = size
+	st.TSMFiles = fs.TSMFiles
+	st.CompactionLevel = fs.CompactionLevel
+	st.FullyCompacted = fs.FullyCompacted
+	st.TombstoneFiles = fs.TombstoneFiles
+	st.TombstoneSize = fs.TombstoneSize
+	return st
+}
+
 // Path returns the path of the engine's base directory.
 func (e *Engine) Path() string {
 	return e.path
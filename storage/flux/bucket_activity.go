@@ -0,0 +1,60 @@
+package storageflux
+
+import (
+	"context"
+
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/prom"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewBucketActivityReader wraps the reader and records every read as a query
+// of the bucket read from.
+func NewBucketActivityReader(r query.StorageReader, recorder influxdb.BucketActivityRecorder) query.StorageReader {
+	return &bucketActivityReader{StorageReader: r, recorder: recorder}
+}
+
+type bucketActivityReader struct {
+	query.StorageReader
+	recorder influxdb.BucketActivityRecorder
+}
+
+func (r *bucketActivityReader) ReadFilter(ctx context.Context, spec query.ReadFilterSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	r.recorder.RecordBucketQuery(spec.BucketID)
+	return r.StorageReader.ReadFilter(ctx, spec, alloc)
+}
+
+func (r *bucketActivityReader) ReadGroup(ctx context.Context, spec query.ReadGroupSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	r.recorder.RecordBucketQuery(spec.BucketID)
+	return r.StorageReader.ReadGroup(ctx, spec, alloc)
+}
+
+func (r *bucketActivityReader) ReadWindowAggregate(ctx context.Context, spec query.ReadWindowAggregateSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	r.recorder.RecordBucketQuery(spec.BucketID)
+	return r.StorageReader.ReadWindowAggregate(ctx, spec, alloc)
+}
+
+func (r *bucketActivityReader) ReadTagKeys(ctx context.Context, spec query.ReadTagKeysSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	r.recorder.RecordBucketQuery(spec.BucketID)
+	return r.StorageReader.ReadTagKeys(ctx, spec, alloc)
+}
+
+func (r *bucketActivityReader) ReadTagValues(ctx context.Context, spec query.ReadTagValuesSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	r.recorder.RecordBucketQuery(spec.BucketID)
+	return r.StorageReader.ReadTagValues(ctx, spec, alloc)
+}
+
+func (r *bucketActivityReader) ReadSeriesCardinality(ctx context.Context, spec query.ReadSeriesCardinalitySpec, alloc memory.Allocator) (query.TableIterator, error) {
+	r.recorder.RecordBucketQuery(spec.BucketID)
+	return r.StorageReader.ReadSeriesCardinality(ctx, spec, alloc)
+}
+
+// PrometheusCollectors returns the collectors of the wrapped reader.
+func (r *bucketActivityReader) PrometheusCollectors() []prometheus.Collector {
+	if pc, ok := r.StorageReader.(prom.PrometheusCollector); ok {
+		return pc.PrometheusCollectors()
+	}
+	return nil
+}
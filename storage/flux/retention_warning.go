@@ -0,0 +1,91 @@
+package storageflux
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/prom"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewRetentionWarningReader wraps the reader and records a retention warning
+// for every read starting before the retention period of the bucket read
+// from. Warnings are only recorded for queries collecting them, see
+// query.ContextWithRetentionWarnings.
+func NewRetentionWarningReader(r query.StorageReader, buckets influxdb.BucketService) query.StorageReader {
+	return &retentionWarningReader{
+		StorageReader: r,
+		buckets:       buckets,
+		now:           time.Now,
+	}
+}
+
+type retentionWarningReader struct {
+	query.StorageReader
+	buckets influxdb.BucketService
+	now     func() time.Time
+}
+
+func (r *retentionWarningReader) check(ctx context.Context, spec query.ReadFilterSpec) {
+	warnings := query.RetentionWarningsFromContext(ctx)
+	if warnings == nil {
+		return
+	}
+
+	b, err := r.buckets.FindBucketByID(ctx, spec.BucketID)
+	if err != nil || b.RetentionPeriod <= 0 {
+		// the read itself reports a missing bucket
+		return
+	}
+
+	start := spec.Bounds.Start.Time()
+	available := r.now().Add(-b.RetentionPeriod)
+	if start.Before(available) {
+		warnings.Add(query.RetentionWarning{
+			BucketID:       spec.BucketID,
+			RequestedStart: start,
+			AvailableStart: available,
+		})
+	}
+}
+
+func (r *retentionWarningReader) ReadFilter(ctx context.Context, spec query.ReadFilterSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	r.check(ctx, spec)
+	return r.StorageReader.ReadFilter(ctx, spec, alloc)
+}
+
+func (r *retentionWarningReader) ReadGroup(ctx context.Context, spec query.ReadGroupSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	r.check(ctx, spec.ReadFilterSpec)
+	return r.StorageReader.ReadGroup(ctx, spec, alloc)
+}
+
+func (r *retentionWarningReader) ReadWindowAggregate(ctx context.Context, spec query.ReadWindowAggregateSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	r.check(ctx, spec.ReadFilterSpec)
+	return r.StorageReader.ReadWindowAggregate(ctx, spec, alloc)
+}
+
+func (r *retentionWarningReader) ReadTagKeys(ctx context.Context, spec query.ReadTagKeysSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	r.check(ctx, spec.ReadFilterSpec)
+	return r.StorageReader.ReadTagKeys(ctx, spec, alloc)
+}
+
+func (r *retentionWarningReader) ReadTagValues(ctx context.Context, spec query.ReadTagValuesSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	r.check(ctx, spec.ReadFilterSpec)
+	return r.StorageReader.ReadTagValues(ctx, spec, alloc)
+}
+
+func (r *retentionWarningReader) ReadSeriesCardinality(ctx context.Context, spec query.ReadSeriesCardinalitySpec, alloc memory.Allocator) (query.TableIterator, error) {
+	r.check(ctx, spec.ReadFilterSpec)
+	return r.StorageReader.ReadSeriesCardinality(ctx, spec, alloc)
+}
+
+// PrometheusCollectors returns the collectors of the wrapped reader.
+func (r *retentionWarningReader) PrometheusCollectors() []prometheus.Collector {
+	if pc, ok := r.StorageReader.(prom.PrometheusCollector); ok {
+		return pc.PrometheusCollectors()
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package storageflux
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/values"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/stretchr/testify/assert"
+)
+
+type readFilterReader struct {
+	query.StorageReader
+}
+
+func (readFilterReader) ReadFilter(ctx context.Context, spec query.ReadFilterSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	return nil, nil
+}
+
+func TestRetentionWarningReader(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(ctx context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		switch id {
+		case 1:
+			return &influxdb.Bucket{ID: id, RetentionPeriod: 24 * time.Hour}, nil
+		default:
+			return &influxdb.Bucket{ID: id}, nil
+		}
+	}
+
+	r := NewRetentionWarningReader(readFilterReader{}, buckets).(*retentionWarningReader)
+	r.now = func() time.Time { return now }
+
+	read := func(ctx context.Context, bucketID platform.ID, start time.Time) {
+		_, _ = r.ReadFilter(ctx, query.ReadFilterSpec{
+			BucketID: bucketID,
+			Bounds: execute.Bounds{
+				Start: values.ConvertTime(start),
+				Stop:  values.ConvertTime(now),
+			},
+		}, nil)
+	}
+
+	ctx, warnings := query.ContextWithRetentionWarnings(context.Background())
+	read(ctx, 1, now.Add(-time.Hour))
+	read(ctx, 1, now.Add(-48*time.Hour))
+	read(ctx, 1, now.Add(-36*time.Hour))
+	read(ctx, 2, now.Add(-48*time.Hour))
+
+	assert.Equal(t, []query.RetentionWarning{
+		{
+			BucketID:       1,
+			RequestedStart: now.Add(-48 * time.Hour),
+			AvailableStart: now.Add(-24 * time.Hour),
+		},
+	}, warnings.Warnings(), "only the earliest read starting before the retention of bucket 1 is recorded")
+
+	// reads without a collector are not checked
+	buckets.FindBucketByIDFn = func(ctx context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		t.Fatal("bucket should not be looked up")
+		return nil, nil
+	}
+	read(context.Background(), 1, now.Add(-48*time.Hour))
+}
@@ -67,3 +67,23 @@ func (w *LoggingPointsWriter) WritePoints(ctx context.Context, orgID platform.ID
 
 	return err
 }
+
+// BucketActivityPointsWriter wraps an underlying points writer and records
+// successful writes as activity of the bucket.
+type BucketActivityPointsWriter struct {
+	Underlying PointsWriter
+	Recorder   influxdb.BucketActivityRecorder
+}
+
+// WritePoints writes points to the underlying PointsWriter and records the
+// write when it succeeds.
+func (w *BucketActivityPointsWriter) WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, p []models.Point) error {
+	if err := w.Underlying.WritePoints(ctx, orgID, bucketID, p); err != nil {
+		return err
+	}
+
+	if len(p) > 0 {
+		w.Recorder.RecordBucketWrite(bucketID)
+	}
+	return nil
+}
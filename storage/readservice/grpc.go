@@ -0,0 +1,290 @@
+package readservice
+
+import (
+	"context"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+	"github.com/influxdata/influxdb/v2/v1/services/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ServiceName is the full name of the Storage service defined in
+// storage/reads/datatypes/storage.proto.
+const ServiceName = "influxdata.platform.storage.Storage"
+
+// maxStringValues is the number of tag keys or values sent per message.
+const maxStringValues = 1000
+
+// storageServer is the Storage service. Its descriptor is written by hand,
+// as the datatypes package only generates messages.
+type storageServer interface {
+	ReadFilter(*datatypes.ReadFilterRequest, grpc.ServerStream) error
+	ReadGroup(*datatypes.ReadGroupRequest, grpc.ServerStream) error
+	TagKeys(*datatypes.TagKeysRequest, grpc.ServerStream) error
+	TagValues(*datatypes.TagValuesRequest, grpc.ServerStream) error
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*storageServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReadFilter",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(datatypes.ReadFilterRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(storageServer).ReadFilter(req, stream)
+			},
+		},
+		{
+			StreamName:    "ReadGroup",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(datatypes.ReadGroupRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(storageServer).ReadGroup(req, stream)
+			},
+		},
+		{
+			StreamName:    "TagKeys",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(datatypes.TagKeysRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(storageServer).TagKeys(req, stream)
+			},
+		},
+		{
+			StreamName:    "TagValues",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(datatypes.TagValuesRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(storageServer).TagValues(req, stream)
+			},
+		},
+	},
+	Metadata: "storage.proto",
+}
+
+// BucketFinder finds the bucket read by a call.
+type BucketFinder interface {
+	FindBucketByID(ctx context.Context, id platform.ID) (*influxdb.Bucket, error)
+}
+
+// Server serves the reads of the storage engine over gRPC to external query
+// engines. Each call is authenticated by the token in its authorization
+// metadata and requires read permission on the bucket it reads.
+type Server struct {
+	log     *zap.Logger
+	store   reads.Store
+	auths   influxdb.AuthorizationService
+	users   influxdb.UserService
+	buckets BucketFinder
+}
+
+var _ storageServer = (*Server)(nil)
+
+// NewServer constructs a Server reading from store.
+func NewServer(log *zap.Logger, store reads.Store, auths influxdb.AuthorizationService, users influxdb.UserService, buckets BucketFinder) *Server {
+	return &Server{
+		log:     log,
+		store:   store,
+		auths:   auths,
+		users:   users,
+		buckets: buckets,
+	}
+}
+
+// Register registers the Storage service with the gRPC server.
+func (s *Server) Register(gs *grpc.Server) {
+	gs.RegisterService(&serviceDesc, s)
+}
+
+// ReadFilter streams the series matching the request.
+func (s *Server) ReadFilter(req *datatypes.ReadFilterRequest, stream grpc.ServerStream) error {
+	ctx, err := s.authorize(stream.Context(), req.ReadSource)
+	if err != nil {
+		return err
+	}
+
+	rs, err := s.store.ReadFilter(ctx, req)
+	if err != nil {
+		return s.internal("ReadFilter", err)
+	}
+	if rs == nil {
+		return nil
+	}
+	defer rs.Close()
+
+	w := &responseWriter{send: func(r *datatypes.ReadResponse) error { return stream.SendMsg(r) }}
+	if err := w.writeResultSet(rs); err != nil {
+		return s.internal("ReadFilter", err)
+	}
+	return w.flush()
+}
+
+// ReadGroup streams the groups of series matching the request.
+func (s *Server) ReadGroup(req *datatypes.ReadGroupRequest, stream grpc.ServerStream) error {
+	ctx, err := s.authorize(stream.Context(), req.ReadSource)
+	if err != nil {
+		return err
+	}
+
+	rs, err := s.store.ReadGroup(ctx, req)
+	if err != nil {
+		return s.internal("ReadGroup", err)
+	}
+	if rs == nil {
+		return nil
+	}
+	defer rs.Close()
+
+	w := &responseWriter{send: func(r *datatypes.ReadResponse) error { return stream.SendMsg(r) }}
+	if err := w.writeGroupResultSet(rs); err != nil {
+		return s.internal("ReadGroup", err)
+	}
+	return w.flush()
+}
+
+// TagKeys streams the tag keys of the series matching the request.
+func (s *Server) TagKeys(req *datatypes.TagKeysRequest, stream grpc.ServerStream) error {
+	ctx, err := s.authorize(stream.Context(), req.TagsSource)
+	if err != nil {
+		return err
+	}
+
+	it, err := s.store.TagKeys(ctx, req)
+	if err != nil {
+		return s.internal("TagKeys", err)
+	}
+	return sendStrings(stream, it)
+}
+
+// TagValues streams the values of the tag key of the series matching the
+// request.
+func (s *Server) TagValues(req *datatypes.TagValuesRequest, stream grpc.ServerStream) error {
+	ctx, err := s.authorize(stream.Context(), req.TagsSource)
+	if err != nil {
+		return err
+	}
+
+	it, err := s.store.TagValues(ctx, req)
+	if err != nil {
+		return s.internal("TagValues", err)
+	}
+	return sendStrings(stream, it)
+}
+
+// authorize authenticates the token of the call and checks that it may
+// read the bucket of source. The store only reads the bucket by its ID, so
+// the organization of source must be the organization of the bucket, and
+// the permission is checked against the organization of the bucket. It
+// returns the context of the call with the authorization of the token.
+func (s *Server) authorize(ctx context.Context, source *anypb.Any) (context.Context, error) {
+	src, err := storage.GetReadSource(source)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid read source")
+	}
+
+	token, ok := tokenFromMetadata(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "token required")
+	}
+	auth, err := s.auths.FindAuthorizationByToken(ctx, token)
+	if err != nil || !auth.IsActive() {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	if u, err := s.users.FindUserByID(ctx, auth.GetUserID()); err != nil || u.Status == influxdb.Inactive {
+		return nil, status.Error(codes.PermissionDenied, "user is inactive")
+	}
+
+	// A bucket that is not found is denied like a bucket of another
+	// organization, so its existence is not disclosed.
+	bucket, err := s.buckets.FindBucketByID(ctx, platform.ID(src.GetBucketID()))
+	if err != nil {
+		if errors.ErrorCode(err) == errors.ENotFound {
+			return nil, status.Error(codes.PermissionDenied, "bucket not found")
+		}
+		return nil, s.internal("FindBucketByID", err)
+	}
+	if bucket.OrgID != platform.ID(src.GetOrgID()) {
+		return nil, status.Error(codes.PermissionDenied, "bucket not found")
+	}
+
+	ctx = icontext.SetAuthorizer(ctx, auth)
+	if _, _, err := authorizer.AuthorizeRead(ctx, influxdb.BucketsResourceType, bucket.ID, bucket.OrgID); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	return ctx, nil
+}
+
+// internal logs the error of the store and returns it to the client.
+func (s *Server) internal(method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	s.log.Info("Storage read failed", zap.String("method", method), zap.Error(err))
+	return status.Error(codes.Internal, err.Error())
+}
+
+// tokenFromMetadata returns the token of the authorization metadata of the
+// call, given as "Token <token>" or "Bearer <token>" as in HTTP requests.
+func tokenFromMetadata(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, v := range md.Get("authorization") {
+		for _, scheme := range []string{"Token ", "Bearer "} {
+			if len(v) > len(scheme) && strings.EqualFold(v[:len(scheme)], scheme) {
+				return v[len(scheme):], true
+			}
+		}
+	}
+	return "", false
+}
+
+// sendStrings sends the values of the iterator in batches.
+func sendStrings(stream grpc.ServerStream, it cursors.StringIterator) error {
+	if it == nil {
+		return nil
+	}
+	var values [][]byte
+	for it.Next() {
+		values = append(values, []byte(it.Value()))
+		if len(values) == maxStringValues {
+			if err := stream.SendMsg(&datatypes.StringValuesResponse{Values: values}); err != nil {
+				return err
+			}
+			values = nil
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return stream.SendMsg(&datatypes.StringValuesResponse{Values: values})
+}
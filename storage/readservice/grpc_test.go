@@ -0,0 +1,98 @@
+package readservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/v1/services/storage"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestServer_authorize(t *testing.T) {
+	orgID, bucketID, userID := platform.ID(1), platform.ID(2), platform.ID(3)
+	otherOrgID, otherOrgBucketID := platform.ID(5), platform.ID(6)
+
+	read, err := influxdb.NewPermissionAtID(bucketID, influxdb.ReadAction, influxdb.BucketsResourceType, orgID)
+	require.NoError(t, err)
+	other, err := influxdb.NewPermissionAtID(platform.ID(4), influxdb.ReadAction, influxdb.BucketsResourceType, orgID)
+	require.NoError(t, err)
+	orgRead, err := influxdb.NewPermission(influxdb.ReadAction, influxdb.BucketsResourceType, orgID)
+	require.NoError(t, err)
+
+	auths := mock.NewAuthorizationService()
+	auths.FindAuthorizationByTokenFn = func(_ context.Context, token string) (*influxdb.Authorization, error) {
+		switch token {
+		case "read":
+			return &influxdb.Authorization{Status: influxdb.Active, UserID: userID, Permissions: []influxdb.Permission{*read}}, nil
+		case "other":
+			return &influxdb.Authorization{Status: influxdb.Active, UserID: userID, Permissions: []influxdb.Permission{*other}}, nil
+		case "org":
+			return &influxdb.Authorization{Status: influxdb.Active, UserID: userID, Permissions: []influxdb.Permission{*orgRead}}, nil
+		case "inactive":
+			return &influxdb.Authorization{Status: influxdb.Inactive, UserID: userID, Permissions: []influxdb.Permission{*read}}, nil
+		}
+		return nil, &errors.Error{Code: errors.ENotFound}
+	}
+	users := mock.NewUserService()
+	users.FindUserByIDFn = func(context.Context, platform.ID) (*influxdb.User, error) {
+		return &influxdb.User{ID: userID, Status: influxdb.Active}, nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(_ context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		switch id {
+		case bucketID:
+			return &influxdb.Bucket{ID: bucketID, OrgID: orgID}, nil
+		case otherOrgBucketID:
+			return &influxdb.Bucket{ID: otherOrgBucketID, OrgID: otherOrgID}, nil
+		}
+		return nil, &errors.Error{Code: errors.ENotFound}
+	}
+	s := NewServer(zaptest.NewLogger(t), nil, auths, users, buckets)
+
+	newSource := func(orgID, bucketID platform.ID) *anypb.Any {
+		source, err := anypb.New(&storage.ReadSource{OrgID: uint64(orgID), BucketID: uint64(bucketID)})
+		require.NoError(t, err)
+		return source
+	}
+	source := newSource(orgID, bucketID)
+
+	tests := []struct {
+		name   string
+		header string
+		source *anypb.Any
+		code   codes.Code
+	}{
+		{name: "token", header: "Token read", source: source, code: codes.OK},
+		{name: "bearer", header: "Bearer read", source: source, code: codes.OK},
+		{name: "no source", header: "Token read", code: codes.InvalidArgument},
+		{name: "no token", source: source, code: codes.Unauthenticated},
+		{name: "unknown token", header: "Token unknown", source: source, code: codes.Unauthenticated},
+		{name: "inactive token", header: "Token inactive", source: source, code: codes.Unauthenticated},
+		{name: "other bucket", header: "Token other", source: source, code: codes.PermissionDenied},
+		{name: "org token", header: "Token org", source: source, code: codes.OK},
+		{name: "unknown bucket", header: "Token org", source: newSource(orgID, platform.ID(7)), code: codes.PermissionDenied},
+		// The token may read every bucket of its organization, the bucket
+		// of another organization is sent with the ID of its own.
+		{name: "spoofed org", header: "Token org", source: newSource(orgID, otherOrgBucketID), code: codes.PermissionDenied},
+		{name: "other org", header: "Token org", source: newSource(otherOrgID, otherOrgBucketID), code: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.header != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.header))
+			}
+			_, err := s.authorize(ctx, tt.source)
+			require.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package readservice
+
+import (
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// maxResponseBytes is the approximate size after which the frames written
+// are sent. It is well below the default 4MB message limit of gRPC clients.
+const maxResponseBytes = 1 << 20
+
+// responseWriter writes the series of result sets as the frames of
+// ReadResponse messages.
+type responseWriter struct {
+	send   func(*datatypes.ReadResponse) error
+	frames []*datatypes.ReadResponse_Frame
+	size   int
+	err    error
+}
+
+// writeResultSet writes the series of rs.
+func (w *responseWriter) writeResultSet(rs reads.ResultSet) error {
+	for rs.Next() {
+		w.writeSeries(rs.Tags(), rs.Cursor())
+		if w.err != nil {
+			return w.err
+		}
+	}
+	return rs.Err()
+}
+
+// writeGroupResultSet writes each group of rs followed by its series.
+func (w *responseWriter) writeGroupResultSet(rs reads.GroupResultSet) error {
+	for gc := rs.Next(); gc != nil; gc = rs.Next() {
+		w.writeGroup(gc)
+		gc.Close()
+		if w.err != nil {
+			return w.err
+		}
+	}
+	return rs.Err()
+}
+
+func (w *responseWriter) writeGroup(gc reads.GroupCursor) {
+	keys, vals := gc.Keys(), gc.PartitionKeyVals()
+	w.add(&datatypes.ReadResponse_Frame{Data: &datatypes.ReadResponse_Frame_Group{
+		Group: &datatypes.ReadResponse_GroupFrame{
+			TagKeys:          copyBytes(keys),
+			PartitionKeyVals: copyBytes(vals),
+		},
+	}}, bytesLen(keys)+bytesLen(vals))
+
+	for gc.Next() {
+		w.writeSeries(gc.Tags(), gc.Cursor())
+		if w.err != nil {
+			return
+		}
+	}
+	if err := gc.Err(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// writeSeries writes the series frame of the cursor followed by its points.
+// The arrays of a cursor are reused by its next call, so they are copied.
+func (w *responseWriter) writeSeries(tags models.Tags, cur cursors.Cursor) {
+	if cur == nil {
+		return
+	}
+	defer cur.Close()
+
+	var dataType datatypes.ReadResponse_DataType
+	switch cur.(type) {
+	case cursors.FloatArrayCursor:
+		dataType = datatypes.ReadResponse_DataTypeFloat
+	case cursors.IntegerArrayCursor:
+		dataType = datatypes.ReadResponse_DataTypeInteger
+	case cursors.UnsignedArrayCursor:
+		dataType = datatypes.ReadResponse_DataTypeUnsigned
+	case cursors.BooleanArrayCursor:
+		dataType = datatypes.ReadResponse_DataTypeBoolean
+	case cursors.StringArrayCursor:
+		dataType = datatypes.ReadResponse_DataTypeString
+	default:
+		// Unknown cursor types are skipped, as the other engines do.
+		return
+	}
+
+	series := &datatypes.ReadResponse_SeriesFrame{
+		Tags:     make([]*datatypes.Tag, 0, len(tags)),
+		DataType: dataType,
+	}
+	size := 0
+	for _, t := range tags {
+		series.Tags = append(series.Tags, &datatypes.Tag{
+			Key:   append([]byte(nil), t.Key...),
+			Value: append([]byte(nil), t.Value...),
+		})
+		size += len(t.Key) + len(t.Value)
+	}
+	w.add(&datatypes.ReadResponse_Frame{Data: &datatypes.ReadResponse_Frame_Series{Series: series}}, size)
+
+	switch c := cur.(type) {
+	case cursors.FloatArrayCursor:
+		for a := c.Next(); a.Len() > 0 && w.err == nil; a = c.Next() {
+			w.add(&datatypes.ReadResponse_Frame{Data: &datatypes.ReadResponse_Frame_FloatPoints{
+				FloatPoints: &datatypes.ReadResponse_FloatPointsFrame{
+					Timestamps: append([]int64(nil), a.Timestamps...),
+					Values:     append([]float64(nil), a.Values...),
+				},
+			}}, a.Len()*16)
+		}
+	case cursors.IntegerArrayCursor:
+		for a := c.Next(); a.Len() > 0 && w.err == nil; a = c.Next() {
+			w.add(&datatypes.ReadResponse_Frame{Data: &datatypes.ReadResponse_Frame_IntegerPoints{
+				IntegerPoints: &datatypes.ReadResponse_IntegerPointsFrame{
+					Timestamps: append([]int64(nil), a.Timestamps...),
+					Values:     append([]int64(nil), a.Values...),
+				},
+			}}, a.Len()*16)
+		}
+	case cursors.UnsignedArrayCursor:
+		for a := c.Next(); a.Len() > 0 && w.err == nil; a = c.Next() {
+			w.add(&datatypes.ReadResponse_Frame{Data: &datatypes.ReadResponse_Frame_UnsignedPoints{
+				UnsignedPoints: &datatypes.ReadResponse_UnsignedPointsFrame{
+					Timestamps: append([]int64(nil), a.Timestamps...),
+					Values:     append([]uint64(nil), a.Values...),
+				},
+			}}, a.Len()*16)
+		}
+	case cursors.BooleanArrayCursor:
+		for a := c.Next(); a.Len() > 0 && w.err == nil; a = c.Next() {
+			w.add(&datatypes.ReadResponse_Frame{Data: &datatypes.ReadResponse_Frame_BooleanPoints{
+				BooleanPoints: &datatypes.ReadResponse_BooleanPointsFrame{
+					Timestamps: append([]int64(nil), a.Timestamps...),
+					Values:     append([]bool(nil), a.Values...),
+				},
+			}}, a.Len()*9)
+		}
+	case cursors.StringArrayCursor:
+		for a := c.Next(); a.Len() > 0 && w.err == nil; a = c.Next() {
+			size := a.Len() * 8
+			for _, v := range a.Values {
+				size += len(v)
+			}
+			w.add(&datatypes.ReadResponse_Frame{Data: &datatypes.ReadResponse_Frame_StringPoints{
+				StringPoints: &datatypes.ReadResponse_StringPointsFrame{
+					Timestamps: append([]int64(nil), a.Timestamps...),
+					Values:     append([]string(nil), a.Values...),
+				},
+			}}, size)
+		}
+	}
+	if err := cur.Err(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// add buffers the frame and sends the buffered frames once they reach
+// maxResponseBytes.
+func (w *responseWriter) add(frame *datatypes.ReadResponse_Frame, size int) {
+	if w.err != nil {
+		return
+	}
+	w.frames = append(w.frames, frame)
+	w.size += size
+	if w.size >= maxResponseBytes {
+		w.flush()
+	}
+}
+
+// flush sends the buffered frames.
+func (w *responseWriter) flush() error {
+	if w.err != nil || len(w.frames) == 0 {
+		return w.err
+	}
+	w.err = w.send(&datatypes.ReadResponse{Frames: w.frames})
+	w.frames, w.size = nil, 0
+	return w.err
+}
+
+func copyBytes(bs [][]byte) [][]byte {
+	if bs == nil {
+		return nil
+	}
+	c := make([][]byte, len(bs))
+	for i, b := range bs {
+		c[i] = append([]byte(nil), b...)
+	}
+	return c
+}
+
+func bytesLen(bs [][]byte) int {
+	n := 0
+	for _, b := range bs {
+		n += len(b)
+	}
+	return n
+}
@@ -4,9 +4,26 @@ import (
 	"context"
 
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 )
 
 // A BucketFinder is responsible for providing access to buckets via a filter.
 type BucketFinder interface {
 	FindBuckets(context.Context, influxdb.BucketFilter, ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error)
 }
+
+// systemDatabase returns a predicate reporting whether the database stores
+// a system bucket found with f.
+func systemDatabase(f BucketFinder) func(database string) bool {
+	return func(database string) bool {
+		id, err := platform.IDFromString(database)
+		if err != nil {
+			return false
+		}
+		buckets, _, err := f.FindBuckets(context.Background(), influxdb.BucketFilter{ID: id})
+		if err != nil || len(buckets) == 0 {
+			return false
+		}
+		return buckets[0].Type == influxdb.BucketTypeSystem
+	}
+}
@@ -59,6 +59,16 @@ type AnalyticalStorage struct {
 	log *zap.Logger
 }
 
+// UpdateTasksStatus passes the status update of many tasks through to the
+// task service.
+func (as *AnalyticalStorage) UpdateTasksStatus(ctx context.Context, filter taskmodel.TaskFilter, status taskmodel.TaskStatus, dryRun bool) ([]*taskmodel.Task, error) {
+	ss, ok := as.TaskService.(taskmodel.TaskStatusService)
+	if !ok {
+		return nil, taskmodel.ErrTaskStatusUpdateNotSupported
+	}
+	return ss.UpdateTasksStatus(ctx, filter, status, dryRun)
+}
+
 func (as *AnalyticalStorage) FinishRun(ctx context.Context, taskID, runID platform.ID) (*taskmodel.Run, error) {
 	run, err := as.TaskControlService.FinishRun(ctx, taskID, runID)
 	if run != nil && run.ID.String() != "" {
@@ -63,6 +63,7 @@ type executorConfig struct {
 	systemBuildCompiler    CompilerBuilderFunc
 	nonSystemBuildCompiler CompilerBuilderFunc
 	flagger                feature.Flagger
+	runLimits              query.Limits
 }
 
 type executorOption func(*executorConfig)
@@ -126,6 +127,15 @@ func WithFlagger(flagger feature.Flagger) executorOption {
 	}
 }
 
+// WithRunLimits is an Executor option that bounds the memory, wall clock
+// time and bytes written of each run. The query controller enforces the
+// limits, a run exceeding one fails and the limit is recorded in its run log.
+func WithRunLimits(limits query.Limits) executorOption {
+	return func(o *executorConfig) {
+		o.runLimits = limits
+	}
+}
+
 // NewExecutor creates a new task executor
 func NewExecutor(log *zap.Logger, qs query.QueryService, us PermissionService, ts taskmodel.TaskService, tcs backend.TaskControlService, opts ...executorOption) (*Executor, *ExecutorMetrics) {
 	cfg := &executorConfig{
@@ -151,6 +161,7 @@ func NewExecutor(log *zap.Logger, qs query.QueryService, us PermissionService, t
 		systemBuildCompiler:    cfg.systemBuildCompiler,
 		nonSystemBuildCompiler: cfg.nonSystemBuildCompiler,
 		flagger:                cfg.flagger,
+		runLimits:              cfg.runLimits,
 	}
 
 	e.metrics = NewExecutorMetrics(e)
@@ -189,6 +200,7 @@ type Executor struct {
 	nonSystemBuildCompiler CompilerBuilderFunc
 	systemBuildCompiler    CompilerBuilderFunc
 	flagger                feature.Flagger
+	runLimits              query.Limits
 }
 
 // SetLimitFunc sets the limit func for this task executor
@@ -503,6 +515,13 @@ func (w *worker) executeQuery(p *promise) {
 	w.start(p)
 
 	ctx = icontext.SetAuthorizer(ctx, p.auth)
+	if w.e.flagger != nil {
+		// Compute the flags for the organization of the task, which may
+		// enable experimental planner rules for it.
+		if fctx, err := feature.Annotate(ctx, w.e.flagger); err == nil {
+			ctx = fctx
+		}
+	}
 
 	buildCompiler := w.systemBuildCompiler
 	if p.task.Type != taskmodel.TaskSystemType {
@@ -521,10 +540,21 @@ func (w *worker) executeQuery(p *promise) {
 		Authorization:  p.auth,
 		OrganizationID: p.task.OrganizationID,
 		Compiler:       compiler,
+		Source:         query.SourceTaskExecutor,
 	}
 	req.WithReturnNoContent(true)
+
+	if !w.e.runLimits.IsZero() {
+		ctx = query.ContextWithLimits(ctx, w.e.runLimits)
+		w.e.tcs.AddRunLog(p.ctx, p.task.ID, p.run.ID, time.Now().UTC(), fmt.Sprintf("Run limits: %s", w.e.runLimits))
+	}
+
 	it, err := w.e.qs.Query(ctx, req)
 	if err != nil {
+		if limit, ok := query.ExceededLimit(ctx); ok {
+			w.finish(p, taskmodel.RunFail, taskmodel.ErrRunLimitExceeded(limit, err))
+			return
+		}
 		// Assume the error should not be part of the runResult.
 		w.finish(p, taskmodel.RunFail, taskmodel.ErrQueryError(err))
 		return
@@ -548,6 +578,17 @@ func (w *worker) executeQuery(p *promise) {
 		w.e.tcs.AddRunLog(p.ctx, p.task.ID, p.run.ID, time.Now().UTC(), msg)
 	}
 
+	// a run stopped by one of its limits fails with the limit it exceeded,
+	// rather than the error the query was interrupted with.
+	if limit, ok := query.ExceededLimit(ctx); ok {
+		err := runErr
+		if err == nil {
+			err = it.Err()
+		}
+		w.finish(p, taskmodel.RunFail, taskmodel.ErrRunLimitExceeded(limit, err))
+		return
+	}
+
 	if runErr != nil {
 		w.finish(p, taskmodel.RunFail, taskmodel.ErrRunExecutionError(runErr))
 		return
@@ -78,6 +78,34 @@ func (s *CoordinatingTaskService) UpdateTask(ctx context.Context, id platform.ID
 	return to, s.coordinator.TaskUpdated(ctx, from, to)
 }
 
+// UpdateTasksStatus updates the status of many tasks and publishes the
+// changes so the task owner can act on them.
+func (s *CoordinatingTaskService) UpdateTasksStatus(ctx context.Context, filter taskmodel.TaskFilter, status taskmodel.TaskStatus, dryRun bool) ([]*taskmodel.Task, error) {
+	ss, ok := s.TaskService.(taskmodel.TaskStatusService)
+	if !ok {
+		return nil, taskmodel.ErrTaskStatusUpdateNotSupported
+	}
+
+	tasks, err := ss.UpdateTasksStatus(ctx, filter, status, dryRun)
+	if err != nil || dryRun {
+		return tasks, err
+	}
+
+	// only the tasks whose status changed are returned
+	prev := taskmodel.TaskActive
+	if status == taskmodel.TaskActive {
+		prev = taskmodel.TaskInactive
+	}
+	for _, to := range tasks {
+		from := *to
+		from.Status = string(prev)
+		if err := s.coordinator.TaskUpdated(ctx, &from, to); err != nil {
+			return tasks, err
+		}
+	}
+	return tasks, nil
+}
+
 // DeleteTask delete the task and publishes the change, to allow the task owner to find out about this change faster.
 func (s *CoordinatingTaskService) DeleteTask(ctx context.Context, id platform.ID) error {
 	if err := s.coordinator.TaskDeleted(ctx, id); err != nil {
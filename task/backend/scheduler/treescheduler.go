@@ -209,6 +209,23 @@ func (s *TreeScheduler) Stop() {
 	s.wg.Wait()
 }
 
+// Stopped returns true once the scheduler was stopped.
+func (s *TreeScheduler) Stopped() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Len returns the number of tasks scheduled.
+func (s *TreeScheduler) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.nextTime)
+}
+
 // itemList is a list of items for deleting and inserting.  We have to do them separately instead of just a re-add,
 // because usually the items key must be changed between the delete and insert
 type itemList struct {
@@ -5,7 +5,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/influxdata/cron"
@@ -324,23 +323,24 @@ func extractRetryOption(opts *Options, objExpr *ast.ObjectExpression) error {
 	return nil
 }
 
-// Validate returns an error if the options aren't valid.
+// Validate returns an error if the options aren't valid. The error is a
+// *ValidationError listing the problem with each option.
 func (o *Options) Validate() error {
 	now := time.Now()
-	var errs []string
+	var errs ValidationError
 	if o.Name == "" {
-		errs = append(errs, "name required")
+		errs.add(optName, "required")
 	}
 
 	cronPresent := o.Cron != ""
 	everyPresent := !o.Every.IsZero()
 	if cronPresent == everyPresent {
 		// They're both present or both missing.
-		errs = append(errs, "must specify exactly one of either cron or every")
+		errs.add(optEvery, "exactly one of either cron or every must be specified")
 	} else if cronPresent {
 		_, err := cron.ParseUTC(o.Cron)
 		if err != nil {
-			errs = append(errs, "cron invalid: "+err.Error())
+			errs.add(optCron, "invalid: "+err.Error())
 		}
 	} else if everyPresent {
 		every, err := o.Every.DurationFrom(now)
@@ -348,9 +348,9 @@ func (o *Options) Validate() error {
 			return err
 		}
 		if every < time.Second {
-			errs = append(errs, "every option must be at least 1 second")
+			errs.add(optEvery, "must be at least 1 second")
 		} else if every.Truncate(time.Second) != every {
-			errs = append(errs, "every option must be expressible as whole seconds")
+			errs.add(optEvery, "must be expressible as whole seconds")
 		}
 	}
 	if o.Offset != nil {
@@ -360,29 +360,67 @@ func (o *Options) Validate() error {
 		}
 		if offset.Truncate(time.Second) != offset {
 			// For now, allowing negative offset delays. Maybe they're useful for forecasting?
-			errs = append(errs, "offset option must be expressible as whole seconds")
+			errs.add(optOffset, "must be expressible as whole seconds")
 		}
 	}
 	if o.Concurrency != nil {
 		if *o.Concurrency < 1 {
-			errs = append(errs, "concurrency must be at least 1")
+			errs.add(optConcurrency, "must be at least 1")
 		} else if *o.Concurrency > maxConcurrency {
-			errs = append(errs, fmt.Sprintf("concurrency exceeded max of %d", maxConcurrency))
+			errs.add(optConcurrency, fmt.Sprintf("exceeded max of %d", maxConcurrency))
 		}
 	}
 	if o.Retry != nil {
 		if *o.Retry < 1 {
-			errs = append(errs, "retry must be at least 1")
+			errs.add(optRetry, "must be at least 1")
 		} else if *o.Retry > maxRetry {
-			errs = append(errs, fmt.Sprintf("retry exceeded max of %d", maxRetry))
+			errs.add(optRetry, fmt.Sprintf("exceeded max of %d", maxRetry))
 		}
 	}
 
-	if len(errs) == 0 {
+	if len(errs.Fields) == 0 {
 		return nil
 	}
 
-	return fmt.Errorf("invalid options: %s", strings.Join(errs, ", "))
+	return &errs
+}
+
+// ValidateSchedule returns an error if the scheduler would never run a task
+// with the valid options as written: a cron schedule without a next run
+// time, or an offset longer than every, which delays every run past the
+// runs scheduled after it. The error is a *ValidationError.
+//
+// It is only checked when a task is saved. Stored tasks saved before the
+// check existed are still parsed and run as they were.
+func (o *Options) ValidateSchedule() error {
+	now := time.Now()
+	var errs ValidationError
+	if o.Cron != "" {
+		// An invalid cron is reported by Validate.
+		if c, err := cron.ParseUTC(o.Cron); err == nil {
+			if _, err := c.Next(now); err != nil {
+				// Schedules such as the 30th of February parse, but never run.
+				errs.add(optCron, "schedule never runs: "+err.Error())
+			}
+		}
+	} else if !o.Every.IsZero() && o.Offset != nil {
+		every, err := o.Every.DurationFrom(now)
+		if err != nil {
+			return err
+		}
+		offset, err := o.Offset.DurationFrom(now)
+		if err != nil {
+			return err
+		}
+		if offset > every || -offset > every {
+			errs.add(optOffset, fmt.Sprintf("must not be longer than every (%s)", o.Every.String()))
+		}
+	}
+
+	if len(errs.Fields) == 0 {
+		return nil
+	}
+	return &errs
 }
 
 // EffectiveCronString returns the effective cron string of the options.
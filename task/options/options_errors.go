@@ -3,6 +3,7 @@ package options
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // errParseTaskOptionField is returned when we fail to parse a single field in
@@ -28,6 +29,34 @@ func errTaskOptionNotObjectExpression(actualType string) error {
 	return fmt.Errorf("task option expected to be object literal, but found %q", actualType)
 }
 
+// FieldError is a problem with the value of a single task option.
+type FieldError struct {
+	Field string `json:"field"`
+	Msg   string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Msg
+}
+
+// ValidationError is returned when task options are invalid. It lists the
+// problem with each invalid option, so that they can all be fixed at once.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) add(field, msg string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Msg: msg})
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Error())
+	}
+	return fmt.Sprintf("invalid options: %s", strings.Join(msgs, ", "))
+}
+
 var (
 	ErrDuplicateIntervalField     = errors.New("cannot use both cron and every in task options")
 	ErrNoTaskOptionsDefined       = errors.New("no task options defined")
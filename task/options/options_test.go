@@ -200,6 +200,58 @@ func TestValidate(t *testing.T) {
 		t.Error("expected no error for days every")
 	}
 
+	*bad = good
+	bad.Name = ""
+	bad.Retry = pointer.Int64(0)
+	err := bad.Validate()
+	verr, ok := err.(*options.ValidationError)
+	if !ok {
+		t.Fatalf("expected a validation error, got %T", err)
+	}
+	exp := []options.FieldError{
+		{Field: "name", Msg: "required"},
+		{Field: "retry", Msg: "must be at least 1"},
+	}
+	if !cmp.Equal(verr.Fields, exp) {
+		t.Errorf("unexpected field errors -got/+exp\n%s", cmp.Diff(verr.Fields, exp))
+	}
+
+}
+
+func TestValidateSchedule(t *testing.T) {
+	good := options.Options{Name: "x", Cron: "* * * * *"}
+	if err := good.ValidateSchedule(); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := good
+	bad.Cron = "0 0 0 1 1 * 2000"
+	if err := bad.ValidateSchedule(); err == nil {
+		t.Error("expected error for cron that never runs")
+	}
+	// Stored tasks are still parsed, only saving checks the schedule.
+	if err := bad.Validate(); err != nil {
+		t.Errorf("expected no validation error for cron that never runs, got %v", err)
+	}
+
+	bad = good
+	bad.Cron = ""
+	bad.Every = *options.MustParseDuration("1m")
+	bad.Offset = options.MustParseDuration("2m")
+	if err := bad.ValidateSchedule(); err == nil {
+		t.Error("expected error for offset longer than every")
+	}
+	if err := bad.Validate(); err != nil {
+		t.Errorf("expected no validation error for offset longer than every, got %v", err)
+	}
+	bad.Offset = options.MustParseDuration("-2m")
+	if err := bad.ValidateSchedule(); err == nil {
+		t.Error("expected error for negative offset longer than every")
+	}
+	bad.Offset = options.MustParseDuration("1m")
+	if err := bad.ValidateSchedule(); err != nil {
+		t.Errorf("expected no error for offset as long as every, got %v", err)
+	}
 }
 
 func TestEffectiveCronString(t *testing.T) {
@@ -11,6 +11,7 @@ import (
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/ast/astutil"
 	"github.com/influxdata/flux/ast/edit"
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/query/fluxlang"
@@ -36,27 +37,28 @@ var (
 
 // Task is a task. 🎊
 type Task struct {
-	ID              platform.ID            `json:"id"`
-	Type            string                 `json:"type,omitempty"`
-	OrganizationID  platform.ID            `json:"orgID"`
-	Organization    string                 `json:"org"`
-	OwnerID         platform.ID            `json:"ownerID"`
-	Name            string                 `json:"name"`
-	Description     string                 `json:"description,omitempty"`
-	Status          string                 `json:"status"`
-	Flux            string                 `json:"flux"`
-	Every           string                 `json:"every,omitempty"`
-	Cron            string                 `json:"cron,omitempty"`
-	Offset          time.Duration          `json:"offset,omitempty"`
-	LatestCompleted time.Time              `json:"latestCompleted,omitempty"`
-	LatestScheduled time.Time              `json:"latestScheduled,omitempty"`
-	LatestSuccess   time.Time              `json:"latestSuccess,omitempty"`
-	LatestFailure   time.Time              `json:"latestFailure,omitempty"`
-	LastRunStatus   string                 `json:"lastRunStatus,omitempty"`
-	LastRunError    string                 `json:"lastRunError,omitempty"`
-	CreatedAt       time.Time              `json:"createdAt,omitempty"`
-	UpdatedAt       time.Time              `json:"updatedAt,omitempty"`
-	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	ID              platform.ID                  `json:"id"`
+	Type            string                       `json:"type,omitempty"`
+	OrganizationID  platform.ID                  `json:"orgID"`
+	Organization    string                       `json:"org"`
+	OwnerID         platform.ID                  `json:"ownerID"`
+	Name            string                       `json:"name"`
+	Description     string                       `json:"description,omitempty"`
+	Status          string                       `json:"status"`
+	Flux            string                       `json:"flux"`
+	Every           string                       `json:"every,omitempty"`
+	Cron            string                       `json:"cron,omitempty"`
+	Offset          time.Duration                `json:"offset,omitempty"`
+	LatestCompleted time.Time                    `json:"latestCompleted,omitempty"`
+	LatestScheduled time.Time                    `json:"latestScheduled,omitempty"`
+	LatestSuccess   time.Time                    `json:"latestSuccess,omitempty"`
+	LatestFailure   time.Time                    `json:"latestFailure,omitempty"`
+	LastRunStatus   string                       `json:"lastRunStatus,omitempty"`
+	LastRunError    string                       `json:"lastRunError,omitempty"`
+	CreatedAt       time.Time                    `json:"createdAt,omitempty"`
+	UpdatedAt       time.Time                    `json:"updatedAt,omitempty"`
+	Metadata        map[string]interface{}       `json:"metadata,omitempty"`
+	Annotations     influxdb.ResourceAnnotations `json:"annotations,omitempty"`
 }
 
 // EffectiveCron returns the effective cron string of the options.
@@ -137,16 +139,26 @@ type TaskService interface {
 	ForceRun(ctx context.Context, taskID platform.ID, scheduledFor int64) (*Run, error)
 }
 
+// TaskStatusService activates or deactivates many tasks at once.
+type TaskStatusService interface {
+	// UpdateTasksStatus sets the status of every task of the filter's
+	// organization matching the filter in a single transaction, and returns
+	// the tasks whose status changed. The filter's limit is ignored. A dry
+	// run returns the tasks without changing them.
+	UpdateTasksStatus(ctx context.Context, filter TaskFilter, status TaskStatus, dryRun bool) ([]*Task, error)
+}
+
 // TaskCreate is the set of values to create a task.
 type TaskCreate struct {
-	Type           string                 `json:"type,omitempty"`
-	Flux           string                 `json:"flux"`
-	Description    string                 `json:"description,omitempty"`
-	Status         string                 `json:"status,omitempty"`
-	OrganizationID platform.ID            `json:"orgID,omitempty"`
-	Organization   string                 `json:"org,omitempty"`
-	OwnerID        platform.ID            `json:"-"`
-	Metadata       map[string]interface{} `json:"-"` // not to be set through a web request but rather used by a http service using tasks backend.
+	Type           string                       `json:"type,omitempty"`
+	Flux           string                       `json:"flux"`
+	Description    string                       `json:"description,omitempty"`
+	Status         string                       `json:"status,omitempty"`
+	OrganizationID platform.ID                  `json:"orgID,omitempty"`
+	Organization   string                       `json:"org,omitempty"`
+	OwnerID        platform.ID                  `json:"-"`
+	Metadata       map[string]interface{}       `json:"-"` // not to be set through a web request but rather used by a http service using tasks backend.
+	Annotations    influxdb.ResourceAnnotations `json:"annotations,omitempty"`
 }
 
 func (t TaskCreate) Validate() error {
@@ -158,7 +170,7 @@ func (t TaskCreate) Validate() error {
 	case t.Status != "" && t.Status != TaskStatusActive && t.Status != TaskStatusInactive:
 		return fmt.Errorf("invalid task status: %q", t.Status)
 	}
-	return nil
+	return t.Annotations.Valid()
 }
 
 // TaskUpdate represents updates to a task. Options updates override any options set in the Flux field.
@@ -166,6 +178,8 @@ type TaskUpdate struct {
 	Flux        *string `json:"flux,omitempty"`
 	Status      *string `json:"status,omitempty"`
 	Description *string `json:"description,omitempty"`
+	// Annotations replaces all annotations of the task when set.
+	Annotations *influxdb.ResourceAnnotations `json:"annotations,omitempty"`
 
 	// LatestCompleted us to set latest completed on startup to skip task catchup
 	LatestCompleted *time.Time             `json:"-"`
@@ -188,6 +202,8 @@ func (t *TaskUpdate) UnmarshalJSON(data []byte) error {
 		Name        string  `json:"name,omitempty"`
 		Description *string `json:"description,omitempty"`
 
+		Annotations *influxdb.ResourceAnnotations `json:"annotations,omitempty"`
+
 		// Cron is a cron style time schedule that can be used in place of Every.
 		Cron string `json:"cron,omitempty"`
 
@@ -209,6 +225,7 @@ func (t *TaskUpdate) UnmarshalJSON(data []byte) error {
 	}
 	t.Options.Name = jo.Name
 	t.Description = jo.Description
+	t.Annotations = jo.Annotations
 	t.Options.Cron = jo.Cron
 	t.Options.Every = jo.Every
 	if jo.Offset != nil {
@@ -229,6 +246,8 @@ func (t *TaskUpdate) MarshalJSON() ([]byte, error) {
 		Name        string  `json:"name,omitempty"`
 		Description *string `json:"description,omitempty"`
 
+		Annotations *influxdb.ResourceAnnotations `json:"annotations,omitempty"`
+
 		// Cron is a cron style time schedule that can be used in place of Every.
 		Cron string `json:"cron,omitempty"`
 
@@ -246,6 +265,7 @@ func (t *TaskUpdate) MarshalJSON() ([]byte, error) {
 	jo.Cron = t.Options.Cron
 	jo.Every = t.Options.Every
 	jo.Description = t.Description
+	jo.Annotations = t.Annotations
 	if t.Options.Offset != nil {
 		offset := *t.Options.Offset
 		jo.Offset = &offset
@@ -269,10 +289,12 @@ func (t *TaskUpdate) Validate() error {
 		if _, err := time.ParseDuration(t.Options.Offset.String()); err != nil {
 			return fmt.Errorf("offset: %s, %s is invalid, the largest unit supported is h", t.Options.Offset.String(), err)
 		}
-	case t.Flux == nil && t.Status == nil && t.Options.IsZero():
+	case t.Flux == nil && t.Status == nil && t.Annotations == nil && t.Options.IsZero():
 		return errors.New("cannot update task without content")
 	case t.Status != nil && *t.Status != TaskStatusActive && *t.Status != TaskStatusInactive:
 		return fmt.Errorf("invalid task status: %q", *t.Status)
+	case t.Annotations != nil:
+		return t.Annotations.Valid()
 	}
 	return nil
 }
@@ -423,6 +445,53 @@ type TaskFilter struct {
 	User           *platform.ID
 	Limit          int
 	Status         *string
+	Annotations    influxdb.ResourceAnnotations
+
+	// Labels restricts the results to tasks that have all of the labels.
+	Labels []platform.ID
+	// LastRunStatus restricts the results to tasks whose most recent run
+	// finished with the status, one of failed, canceled or success.
+	LastRunStatus *string
+	// ScheduleType restricts the results to tasks scheduled with every
+	// or with cron.
+	ScheduleType *string
+	// NamePrefix restricts the results to tasks whose name starts with
+	// the prefix.
+	NamePrefix *string
+}
+
+// Task schedule types.
+const (
+	TaskScheduleEvery = "every"
+	TaskScheduleCron  = "cron"
+)
+
+// Validate returns an error if the filter's last run status or schedule
+// type is not supported.
+func (f TaskFilter) Validate() error {
+	if f.LastRunStatus != nil {
+		switch *f.LastRunStatus {
+		case RunFail.String(), RunCanceled.String(), RunSuccess.String():
+		default:
+			return &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  fmt.Sprintf("%q is not a valid last run status", *f.LastRunStatus),
+			}
+		}
+	}
+
+	if f.ScheduleType != nil {
+		switch *f.ScheduleType {
+		case TaskScheduleEvery, TaskScheduleCron:
+		default:
+			return &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  fmt.Sprintf("%q is not a valid schedule type", *f.ScheduleType),
+			}
+		}
+	}
+
+	return nil
 }
 
 // QueryParams Converts TaskFilter fields to url query params.
@@ -448,6 +517,24 @@ func (f TaskFilter) QueryParams() map[string][]string {
 		qp["limit"] = []string{strconv.Itoa(f.Limit)}
 	}
 
+	for _, id := range f.Labels {
+		qp["label"] = append(qp["label"], id.String())
+	}
+
+	if f.LastRunStatus != nil {
+		qp["lastRunStatus"] = []string{*f.LastRunStatus}
+	}
+
+	if f.ScheduleType != nil {
+		qp["scheduleType"] = []string{*f.ScheduleType}
+	}
+
+	if f.NamePrefix != nil {
+		qp["namePrefix"] = []string{*f.NamePrefix}
+	}
+
+	f.Annotations.AddQueryParams(qp)
+
 	return qp
 }
 
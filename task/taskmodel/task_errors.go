@@ -1,9 +1,11 @@
 package taskmodel
 
 import (
+	stderrors "errors"
 	"fmt"
 
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/task/options"
 )
 
 var (
@@ -69,6 +71,13 @@ var (
 		Code: errors.EInvalid,
 	}
 
+	// ErrTaskStatusUpdateNotSupported is returned when the task service cannot
+	// update the status of the tasks matching a filter.
+	ErrTaskStatusUpdateNotSupported = &errors.Error{
+		Code: errors.ENotImplemented,
+		Msg:  "updating the status of tasks by filter is not supported",
+	}
+
 	ErrOrgNotFound = &errors.Error{
 		Msg:  "organization not found",
 		Code: errors.ENotFound,
@@ -151,7 +160,18 @@ func ErrTaskTimeParse(err error) *errors.Error {
 	}
 }
 
+// ErrTaskOptionParse is returned when the options of a task are invalid.
+// The problem with each invalid option is listed in the message, as the
+// HTTP API only returns the message of errors.
 func ErrTaskOptionParse(err error) *errors.Error {
+	var verr *options.ValidationError
+	if stderrors.As(err, &verr) {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  verr.Error(),
+			Op:   "taskOptions",
+		}
+	}
 	return &errors.Error{
 		Code: errors.EInvalid,
 		Msg:  "invalid options",
@@ -169,6 +189,17 @@ func ErrRunExecutionError(err error) *errors.Error {
 	}
 }
 
+// ErrRunLimitExceeded is returned when a run is stopped for exceeding one of
+// the resource limits set on task runs.
+func ErrRunLimitExceeded(limit string, err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.ETooLarge,
+		Msg:  fmt.Sprintf("task run stopped, %s", limit),
+		Op:   "taskExecutor",
+		Err:  err,
+	}
+}
+
 func ErrTaskConcurrencyLimitReached(runsInFront int) *errors.Error {
 	return &errors.Error{
 		Code: errors.ETooManyRequests,
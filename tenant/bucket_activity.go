@@ -0,0 +1,173 @@
+package tenant
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kv"
+	"go.uber.org/zap"
+)
+
+// DefaultBucketActivityFlushInterval is how often recorded bucket activity
+// is persisted.
+const DefaultBucketActivityFlushInterval = time.Minute
+
+var (
+	_ influxdb.BucketActivityRecorder = (*BucketActivityTracker)(nil)
+	_ influxdb.BucketActivityFinder   = (*BucketActivityTracker)(nil)
+)
+
+// BucketActivityTracker records approximately when buckets were last written
+// to and queried. Activity is recorded in memory, to the second, and persisted
+// on Flush, so activity since the last flush is lost on a crash.
+type BucketActivityTracker struct {
+	log   *zap.Logger
+	store *Store
+	now   func() time.Time
+
+	mu      sync.Mutex
+	pending map[platform.ID]influxdb.BucketActivity
+}
+
+// NewBucketActivityTracker constructs a tracker persisting activity to the store.
+func NewBucketActivityTracker(log *zap.Logger, store *Store) *BucketActivityTracker {
+	return &BucketActivityTracker{
+		log:   log,
+		store: store,
+		now: func() time.Time {
+			return time.Now().UTC()
+		},
+		pending: make(map[platform.ID]influxdb.BucketActivity),
+	}
+}
+
+// RecordBucketWrite records a write to the bucket.
+func (t *BucketActivityTracker) RecordBucketWrite(bucketID platform.ID) {
+	now := t.now().Truncate(time.Second)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.pending[bucketID]
+	a.LastWriteAt = now
+	t.pending[bucketID] = a
+}
+
+// RecordBucketQuery records a query of the bucket.
+func (t *BucketActivityTracker) RecordBucketQuery(bucketID platform.ID) {
+	now := t.now().Truncate(time.Second)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.pending[bucketID]
+	a.LastQueryAt = now
+	t.pending[bucketID] = a
+}
+
+// FindBucketActivity returns the persisted activity of the buckets, updated
+// with the activity recorded since the last flush.
+func (t *BucketActivityTracker) FindBucketActivity(ctx context.Context, bucketIDs ...platform.ID) (map[platform.ID]influxdb.BucketActivity, error) {
+	out := make(map[platform.ID]influxdb.BucketActivity, len(bucketIDs))
+	err := t.store.View(ctx, func(tx kv.Tx) error {
+		for _, id := range bucketIDs {
+			a, err := t.store.GetBucketActivity(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if a != nil {
+				out[id] = *a
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, id := range bucketIDs {
+		if pending, ok := t.pending[id]; ok {
+			out[id] = mergeBucketActivity(out[id], pending)
+		}
+	}
+	return out, nil
+}
+
+// Flush persists the activity recorded since the last flush. Activity of
+// buckets that no longer exist is discarded.
+func (t *BucketActivityTracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[platform.ID]influxdb.BucketActivity)
+	t.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := t.store.Update(ctx, func(tx kv.Tx) error {
+		for id, a := range pending {
+			if _, err := t.store.GetBucket(ctx, tx, id); err == ErrBucketNotFound {
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			stored, err := t.store.GetBucketActivity(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if stored != nil {
+				a = mergeBucketActivity(*stored, a)
+			}
+
+			if err := t.store.PutBucketActivity(ctx, tx, id, a); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// keep the activity around for the next flush
+		t.mu.Lock()
+		for id, a := range pending {
+			t.pending[id] = mergeBucketActivity(a, t.pending[id])
+		}
+		t.mu.Unlock()
+	}
+	return err
+}
+
+// Run flushes the recorded activity every interval until ctx is done, and
+// once more before returning.
+func (t *BucketActivityTracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := t.Flush(context.Background()); err != nil {
+				t.log.Error("Failed to flush bucket activity", zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+			if err := t.Flush(ctx); err != nil {
+				t.log.Error("Failed to flush bucket activity", zap.Error(err))
+			}
+		}
+	}
+}
+
+func mergeBucketActivity(a, b influxdb.BucketActivity) influxdb.BucketActivity {
+	if b.LastWriteAt.After(a.LastWriteAt) {
+		a.LastWriteAt = b.LastWriteAt
+	}
+	if b.LastQueryAt.After(a.LastQueryAt) {
+		a.LastQueryAt = b.LastQueryAt
+	}
+	return a
+}
@@ -0,0 +1,78 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestBucketActivityTracker(t *testing.T) {
+	ctx := context.Background()
+
+	store := NewStore(itesting.NewTestInmemStore(t))
+	bucket := &influxdb.Bucket{OrgID: 1, Name: "rucket"}
+	require.NoError(t, store.Update(ctx, func(tx kv.Tx) error {
+		return store.CreateBucket(ctx, tx, bucket)
+	}))
+
+	now := time.Date(2021, 6, 1, 10, 0, 0, 500, time.UTC)
+	tracker := NewBucketActivityTracker(zaptest.NewLogger(t), store)
+	tracker.now = func() time.Time { return now }
+
+	tracker.RecordBucketWrite(bucket.ID)
+	tracker.RecordBucketQuery(bucket.ID)
+	require.NoError(t, tracker.Flush(ctx))
+
+	now = now.Add(time.Minute)
+	tracker.RecordBucketWrite(bucket.ID)
+
+	activity, err := tracker.FindBucketActivity(ctx, bucket.ID, platform.ID(9000))
+	require.NoError(t, err)
+	assert.Equal(t, map[platform.ID]influxdb.BucketActivity{
+		bucket.ID: {
+			LastWriteAt: time.Date(2021, 6, 1, 10, 1, 0, 0, time.UTC),
+			LastQueryAt: time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}, activity, "unflushed activity is merged with the persisted activity")
+
+	require.NoError(t, tracker.Flush(ctx))
+	require.NoError(t, store.View(ctx, func(tx kv.Tx) error {
+		persisted, err := store.GetBucketActivity(ctx, tx, bucket.ID)
+		require.NoError(t, err)
+		assert.Equal(t, activity[bucket.ID], *persisted)
+		return nil
+	}))
+
+	t.Run("discards activity of deleted buckets", func(t *testing.T) {
+		require.NoError(t, store.Update(ctx, func(tx kv.Tx) error {
+			return store.DeleteBucket(ctx, tx, bucket.ID)
+		}))
+
+		tracker.RecordBucketQuery(bucket.ID)
+		require.NoError(t, tracker.Flush(ctx))
+
+		activity, err := tracker.FindBucketActivity(ctx, bucket.ID)
+		require.NoError(t, err)
+		assert.Empty(t, activity)
+	})
+}
+
+func TestStore_BucketActivityInvalidID(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(itesting.NewTestInmemStore(t))
+
+	require.NoError(t, store.View(ctx, func(tx kv.Tx) error {
+		_, err := store.GetBucketActivity(ctx, tx, platform.InvalidID())
+		assert.Equal(t, "bucket id provided is invalid", errors.ErrorMessage(err))
+		return nil
+	}))
+}
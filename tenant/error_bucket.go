@@ -71,3 +71,12 @@ func ErrUnprocessableBucket(err error) *errors.Error {
 		Op:   "kv/MarshalBucket",
 	}
 }
+
+// InvalidBucketIDError is used when a service was provided an invalid bucket ID.
+func InvalidBucketIDError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "bucket id provided is invalid",
+		Err:  err,
+	}
+}
@@ -0,0 +1,40 @@
+package tenant
+
+import (
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var (
+	// ErrBucketShareNotFound is used when the bucket share is not found.
+	ErrBucketShareNotFound = &errors.Error{
+		Msg:  "bucket share not found",
+		Code: errors.ENotFound,
+	}
+
+	// ErrBucketShareExists is returned when a bucket is already shared with
+	// the target organization.
+	ErrBucketShareExists = &errors.Error{
+		Code: errors.EConflict,
+		Msg:  "bucket is already shared with the organization",
+	}
+)
+
+// ErrCorruptBucketShare is used when the bucket share cannot be
+// unmarshalled from the bytes stored in the kv.
+func ErrCorruptBucketShare(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.EInternal,
+		Msg:  "bucket share could not be unmarshalled",
+		Err:  err,
+		Op:   "kv/UnmarshalBucketShare",
+	}
+}
+
+// InvalidBucketShareIDError is used when the bucket share id cannot be encoded.
+func InvalidBucketShareIDError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "bucket share id provided is invalid",
+		Err:  err,
+	}
+}
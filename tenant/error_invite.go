@@ -0,0 +1,57 @@
+package tenant
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var (
+	// ErrInviteNotFound is used when the invite is not found.
+	ErrInviteNotFound = &errors.Error{
+		Msg:  "invite not found",
+		Code: errors.ENotFound,
+	}
+
+	// ErrInvalidInviteToken is returned when an invite token is unknown or
+	// expired. Both cases are reported alike to not leak which invites exist.
+	ErrInvalidInviteToken = &errors.Error{
+		Code: errors.EForbidden,
+		Msg:  "invite token is invalid or expired",
+	}
+
+	// ErrInviteEmailRequired is returned when an invite has no email address.
+	ErrInviteEmailRequired = &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "invite email is required",
+	}
+)
+
+// ErrCorruptInvite is used when the invite cannot be unmarshalled from the
+// bytes stored in the kv.
+func ErrCorruptInvite(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.EInternal,
+		Msg:  "user invite could not be unmarshalled",
+		Err:  err,
+		Op:   "kv/UnmarshalInvite",
+	}
+}
+
+// ErrSendInvite is used when the invitation email cannot be sent.
+func ErrSendInvite(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.EUnavailable,
+		Msg:  fmt.Sprintf("unable to send invitation email: %v", err),
+		Err:  err,
+	}
+}
+
+// InvalidInviteIDError is used when the invite id cannot be encoded.
+func InvalidInviteIDError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "invite id provided is invalid",
+		Err:  err,
+	}
+}
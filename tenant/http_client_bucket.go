@@ -114,6 +114,9 @@ func (s *BucketClientService) FindBuckets(ctx context.Context, filter influxdb.B
 	if filter.Name != nil {
 		params = append(params, [2]string{"name", (*filter.Name)})
 	}
+	for k, v := range filter.Annotations {
+		params = append(params, [2]string{"annotations[" + k + "]", v})
+	}
 
 	var bs bucketsResponse
 	err := s.Client.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -22,20 +23,67 @@ type BucketHandler struct {
 	log       *zap.Logger
 	bucketSvc influxdb.BucketService
 	labelSvc  influxdb.LabelService // we may need this for now but we dont want it permanently
+
+	retentionEnforcer influxdb.RetentionEnforcer
+	activityFinder    influxdb.BucketActivityFinder
+	cardinalityFinder influxdb.BucketCardinalityFinder
+	shardFinder       influxdb.BucketShardFinder
+}
+
+// BucketHandlerOption configures the BucketHandler.
+type BucketHandlerOption func(*BucketHandler)
+
+// WithRetentionEnforcer allows a bucket update to enforce the retention
+// period immediately with the enforceRetention query parameter.
+func WithRetentionEnforcer(e influxdb.RetentionEnforcer) BucketHandlerOption {
+	return func(h *BucketHandler) {
+		h.retentionEnforcer = e
+	}
+}
+
+// WithBucketActivity includes when data was last written to and queried
+// from a bucket in the bucket responses.
+func WithBucketActivity(f influxdb.BucketActivityFinder) BucketHandlerOption {
+	return func(h *BucketHandler) {
+		h.activityFinder = f
+	}
+}
+
+// WithBucketCardinality serves the series cardinality of buckets at the
+// /api/v2/buckets/:id/cardinality route.
+func WithBucketCardinality(f influxdb.BucketCardinalityFinder) BucketHandlerOption {
+	return func(h *BucketHandler) {
+		h.cardinalityFinder = f
+	}
+}
+
+// WithBucketShards serves the shard groups of buckets and the state of
+// their shards on disk at the /api/v2/buckets/:id/shards route.
+func WithBucketShards(f influxdb.BucketShardFinder) BucketHandlerOption {
+	return func(h *BucketHandler) {
+		h.shardFinder = f
+	}
 }
 
 const (
 	prefixBuckets = "/api/v2/buckets"
+
+	// defaultTopTagKeys is the number of tag keys of each measurement
+	// reported by the cardinality route, unless requested otherwise.
+	defaultTopTagKeys = 10
 )
 
 // NewHTTPBucketHandler constructs a new http server.
-func NewHTTPBucketHandler(log *zap.Logger, bucketSvc influxdb.BucketService, labelSvc influxdb.LabelService, urmHandler, labelHandler http.Handler) *BucketHandler {
+func NewHTTPBucketHandler(log *zap.Logger, bucketSvc influxdb.BucketService, labelSvc influxdb.LabelService, urmHandler, labelHandler http.Handler, opts ...BucketHandlerOption) *BucketHandler {
 	svr := &BucketHandler{
 		api:       kithttp.NewAPI(kithttp.WithLog(log)),
 		log:       log,
 		bucketSvc: bucketSvc,
 		labelSvc:  labelSvc,
 	}
+	for _, o := range opts {
+		o(svr)
+	}
 
 	r := chi.NewRouter()
 	r.Use(
@@ -53,6 +101,9 @@ func NewHTTPBucketHandler(log *zap.Logger, bucketSvc influxdb.BucketService, lab
 			r.Get("/", svr.handleGetBucket)
 			r.Patch("/", svr.handlePatchBucket)
 			r.Delete("/", svr.handleDeleteBucket)
+			r.Get("/cardinality", svr.handleGetBucketCardinality)
+			r.Get("/shards", svr.handleGetBucketShards)
+			r.Post("/retention/enforce", svr.handlePostBucketRetentionEnforce)
 
 			// mount embedded resources
 			mountableRouter := r.With(kithttp.ValidResource(svr.api, svr.lookupOrgByBucketID))
@@ -72,13 +123,16 @@ func (h *BucketHandler) Prefix() string {
 
 // bucket is used for serialization/deserialization with duration string syntax.
 type bucket struct {
-	ID                  platform.ID     `json:"id,omitempty"`
-	OrgID               platform.ID     `json:"orgID,omitempty"`
-	Type                string          `json:"type"`
-	Description         string          `json:"description,omitempty"`
-	Name                string          `json:"name"`
-	RetentionPolicyName string          `json:"rp,omitempty"` // This to support v1 sources
-	RetentionRules      []retentionRule `json:"retentionRules"`
+	ID                  platform.ID                  `json:"id,omitempty"`
+	OrgID               platform.ID                  `json:"orgID,omitempty"`
+	Type                string                       `json:"type"`
+	Description         string                       `json:"description,omitempty"`
+	Name                string                       `json:"name"`
+	RetentionPolicyName string                       `json:"rp,omitempty"` // This to support v1 sources
+	RetentionRules      []retentionRule              `json:"retentionRules"`
+	SeriesTTLSeconds    int64                        `json:"seriesTTLSeconds,omitempty"`
+	Limits              *influxdb.BucketLimits       `json:"limits,omitempty"`
+	Annotations         influxdb.ResourceAnnotations `json:"annotations,omitempty"`
 	influxdb.CRUDLog
 }
 
@@ -112,6 +166,9 @@ func (b *bucket) toInfluxDB() *influxdb.Bucket {
 		RetentionPolicyName: b.RetentionPolicyName,
 		RetentionPeriod:     rpDuration,
 		ShardGroupDuration:  sgDuration,
+		SeriesTTL:           time.Duration(b.SeriesTTLSeconds) * time.Second,
+		Limits:              b.Limits,
+		Annotations:         b.Annotations,
 		CRUDLog:             b.CRUDLog,
 	}
 }
@@ -129,6 +186,9 @@ func newBucket(pb *influxdb.Bucket) *bucket {
 		Description:         pb.Description,
 		RetentionPolicyName: pb.RetentionPolicyName,
 		RetentionRules:      []retentionRule{},
+		SeriesTTLSeconds:    int64(pb.SeriesTTL.Round(time.Second) / time.Second),
+		Limits:              pb.Limits,
+		Annotations:         pb.Annotations,
 		CRUDLog:             pb.CRUDLog,
 	}
 
@@ -157,9 +217,12 @@ type retentionRuleUpdate struct {
 
 // bucketUpdate is used for serialization/deserialization with retention rules.
 type bucketUpdate struct {
-	Name           *string               `json:"name,omitempty"`
-	Description    *string               `json:"description,omitempty"`
-	RetentionRules []retentionRuleUpdate `json:"retentionRules,omitempty"`
+	Name             *string                       `json:"name,omitempty"`
+	Description      *string                       `json:"description,omitempty"`
+	RetentionRules   []retentionRuleUpdate         `json:"retentionRules,omitempty"`
+	SeriesTTLSeconds *int64                        `json:"seriesTTLSeconds,omitempty"`
+	Limits           *influxdb.BucketLimits        `json:"limits,omitempty"`
+	Annotations      *influxdb.ResourceAnnotations `json:"annotations,omitempty"`
 }
 
 func (b *bucketUpdate) OK() error {
@@ -186,6 +249,25 @@ func (b *bucketUpdate) OK() error {
 		}
 	}
 
+	if b.SeriesTTLSeconds != nil && *b.SeriesTTLSeconds < 0 {
+		return &errors.Error{
+			Code: errors.EUnprocessableEntity,
+			Msg:  "series TTL seconds cannot be negative",
+		}
+	}
+
+	if b.Limits != nil {
+		if err := b.Limits.Valid(); err != nil {
+			return err
+		}
+	}
+
+	if b.Annotations != nil {
+		if err := b.Annotations.Valid(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -197,6 +279,12 @@ func (b *bucketUpdate) toInfluxDB() *influxdb.BucketUpdate {
 	upd := influxdb.BucketUpdate{
 		Name:        b.Name,
 		Description: b.Description,
+		Limits:      b.Limits,
+		Annotations: b.Annotations,
+	}
+	if b.SeriesTTLSeconds != nil {
+		ttl := time.Duration(*b.SeriesTTLSeconds) * time.Second
+		upd.SeriesTTL = &ttl
 	}
 
 	// For now, only use a single retention rule.
@@ -224,6 +312,12 @@ func newBucketUpdate(pb *influxdb.BucketUpdate) *bucketUpdate {
 		Name:           pb.Name,
 		Description:    pb.Description,
 		RetentionRules: []retentionRuleUpdate{},
+		Limits:         pb.Limits,
+		Annotations:    pb.Annotations,
+	}
+	if pb.SeriesTTL != nil {
+		ttl := int64((*pb.SeriesTTL).Round(time.Second) / time.Second)
+		up.SeriesTTLSeconds = &ttl
 	}
 
 	if pb.RetentionPeriod == nil && pb.ShardGroupDuration == nil {
@@ -247,8 +341,21 @@ func newBucketUpdate(pb *influxdb.BucketUpdate) *bucketUpdate {
 
 type bucketResponse struct {
 	bucket
-	Links  map[string]string `json:"links"`
-	Labels []influxdb.Label  `json:"labels"`
+	LastWriteAt *time.Time        `json:"lastWriteAt,omitempty"`
+	LastQueryAt *time.Time        `json:"lastQueryAt,omitempty"`
+	Links       map[string]string `json:"links"`
+	Labels      []influxdb.Label  `json:"labels"`
+}
+
+func (r *bucketResponse) setActivity(a influxdb.BucketActivity) {
+	if !a.LastWriteAt.IsZero() {
+		lastWriteAt := a.LastWriteAt
+		r.LastWriteAt = &lastWriteAt
+	}
+	if !a.LastQueryAt.IsZero() {
+		lastQueryAt := a.LastQueryAt
+		r.LastQueryAt = &lastQueryAt
+	}
 }
 
 func NewBucketResponse(b *influxdb.Bucket, labels ...*influxdb.Label) *bucketResponse {
@@ -276,21 +383,36 @@ type bucketsResponse struct {
 	Buckets []*bucketResponse     `json:"buckets"`
 }
 
-func newBucketsResponse(ctx context.Context, opts influxdb.FindOptions, f influxdb.BucketFilter, bs []*influxdb.Bucket, labelSvc influxdb.LabelService) *bucketsResponse {
+func newBucketsResponse(ctx context.Context, opts influxdb.FindOptions, f influxdb.BucketFilter, bs []*influxdb.Bucket, labelSvc influxdb.LabelService, activity map[platform.ID]influxdb.BucketActivity) *bucketsResponse {
 	rs := make([]*bucketResponse, 0, len(bs))
 	for _, b := range bs {
-		var labels []*influxdb.Label
-		if labelSvc != nil { // allow for no label svc
-			labels, _ = labelSvc.FindResourceLabels(ctx, influxdb.LabelMappingFilter{ResourceID: b.ID, ResourceType: influxdb.BucketsResourceType})
+		rs = append(rs, newBucketListResponse(ctx, b, labelSvc, activity))
+	}
+	links := influxdb.NewPagingLinks(prefixBuckets, opts, f, len(bs))
+	if opts.Cursor {
+		var last platform.ID
+		if len(bs) > 0 {
+			last = bs[len(bs)-1].ID
 		}
-		rs = append(rs, NewBucketResponse(b, labels...))
+		links = influxdb.NewCursorPagingLinks(prefixBuckets, opts, f, len(bs), last)
 	}
 	return &bucketsResponse{
-		Links:   influxdb.NewPagingLinks(prefixBuckets, opts, f, len(bs)),
+		Links:   links,
 		Buckets: rs,
 	}
 }
 
+// newBucketListResponse is the response of a bucket in a list of buckets.
+func newBucketListResponse(ctx context.Context, b *influxdb.Bucket, labelSvc influxdb.LabelService, activity map[platform.ID]influxdb.BucketActivity) *bucketResponse {
+	var labels []*influxdb.Label
+	if labelSvc != nil { // allow for no label svc
+		labels, _ = labelSvc.FindResourceLabels(ctx, influxdb.LabelMappingFilter{ResourceID: b.ID, ResourceType: influxdb.BucketsResourceType})
+	}
+	res := NewBucketResponse(b, labels...)
+	res.setActivity(activity[b.ID])
+	return res
+}
+
 // handlePostBucket is the HTTP handler for the POST /api/v2/buckets route.
 func (h *BucketHandler) handlePostBucket(w http.ResponseWriter, r *http.Request) {
 	var b postBucketRequest
@@ -315,11 +437,14 @@ func (h *BucketHandler) handlePostBucket(w http.ResponseWriter, r *http.Request)
 }
 
 type postBucketRequest struct {
-	OrgID               platform.ID     `json:"orgID,omitempty"`
-	Name                string          `json:"name"`
-	Description         string          `json:"description"`
-	RetentionPolicyName string          `json:"rp,omitempty"` // This to support v1 sources
-	RetentionRules      []retentionRule `json:"retentionRules"`
+	OrgID               platform.ID                  `json:"orgID,omitempty"`
+	Name                string                       `json:"name"`
+	Description         string                       `json:"description"`
+	RetentionPolicyName string                       `json:"rp,omitempty"` // This to support v1 sources
+	RetentionRules      []retentionRule              `json:"retentionRules"`
+	SeriesTTLSeconds    int64                        `json:"seriesTTLSeconds,omitempty"`
+	Limits              *influxdb.BucketLimits       `json:"limits,omitempty"`
+	Annotations         influxdb.ResourceAnnotations `json:"annotations,omitempty"`
 }
 
 func (b *postBucketRequest) OK() error {
@@ -353,8 +478,19 @@ func (b *postBucketRequest) OK() error {
 			}
 		}
 	}
+	if b.SeriesTTLSeconds < 0 {
+		return &errors.Error{
+			Code: errors.EUnprocessableEntity,
+			Msg:  "series TTL seconds cannot be negative",
+		}
+	}
+	if b.Limits != nil {
+		if err := b.Limits.Valid(); err != nil {
+			return err
+		}
+	}
 
-	return nil
+	return b.Annotations.Valid()
 }
 
 func (b postBucketRequest) toInfluxDB() *influxdb.Bucket {
@@ -375,6 +511,9 @@ func (b postBucketRequest) toInfluxDB() *influxdb.Bucket {
 		RetentionPolicyName: b.RetentionPolicyName,
 		RetentionPeriod:     rpDur,
 		ShardGroupDuration:  sgDur,
+		SeriesTTL:           time.Duration(b.SeriesTTLSeconds) * time.Second,
+		Limits:              b.Limits,
+		Annotations:         b.Annotations,
 	}
 }
 
@@ -400,7 +539,146 @@ func (h *BucketHandler) handleGetBucket(w http.ResponseWriter, r *http.Request)
 		labels, _ = h.labelSvc.FindResourceLabels(ctx, influxdb.LabelMappingFilter{ResourceID: b.ID, ResourceType: influxdb.BucketsResourceType})
 	}
 
-	h.api.Respond(w, r, http.StatusOK, NewBucketResponse(b, labels...))
+	res := NewBucketResponse(b, labels...)
+	res.setActivity(h.findActivity(ctx, b)[b.ID])
+
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+// handleGetBucketCardinality is the HTTP handler for the GET /api/v2/buckets/:id/cardinality route.
+func (h *BucketHandler) handleGetBucketCardinality(w http.ResponseWriter, r *http.Request) {
+	if h.cardinalityFinder == nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotImplemented,
+			Msg:  "bucket cardinality is not supported",
+		})
+		return
+	}
+
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	qp := r.URL.Query()
+	exact, err := decodeBoolParam(qp.Get("exact"), "exact")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	topTagKeys := defaultTopTagKeys
+	if raw := qp.Get("topTagKeys"); raw != "" {
+		if topTagKeys, err = strconv.Atoi(raw); err != nil || topTagKeys < 0 {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid topTagKeys parameter %q", raw),
+			})
+			return
+		}
+	}
+
+	c, err := h.cardinalityFinder.FindBucketCardinality(r.Context(), *id, exact, topTagKeys)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, c)
+}
+
+type bucketShardsResponse struct {
+	BucketID    platform.ID                 `json:"bucketID"`
+	ShardGroups []influxdb.ShardGroupStatus `json:"shardGroups"`
+}
+
+// handleGetBucketShards is the HTTP handler for the GET /api/v2/buckets/:id/shards route.
+func (h *BucketHandler) handleGetBucketShards(w http.ResponseWriter, r *http.Request) {
+	if h.shardFinder == nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotImplemented,
+			Msg:  "bucket shards are not supported",
+		})
+		return
+	}
+
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	groups, err := h.shardFinder.FindBucketShards(r.Context(), *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, bucketShardsResponse{
+		BucketID:    *id,
+		ShardGroups: groups,
+	})
+}
+
+type bucketRetentionEnforcementResponse struct {
+	BucketID platform.ID `json:"bucketID"`
+	retentionEnforcementResponse
+}
+
+// handlePostBucketRetentionEnforce is the HTTP handler for the POST
+// /api/v2/buckets/:id/retention/enforce route. It removes the data older
+// than the bucket's retention period now, instead of on the next pass of the
+// retention service; with dryRun=true it only reports the data it would remove.
+func (h *BucketHandler) handlePostBucketRetentionEnforce(w http.ResponseWriter, r *http.Request) {
+	if h.retentionEnforcer == nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotImplemented,
+			Msg:  "immediate retention enforcement is not supported",
+		})
+		return
+	}
+
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	dryRun, err := decodeBoolParam(r.URL.Query().Get("dryRun"), "dryRun")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	enf, err := h.retentionEnforcer.EnforceBucketRetention(r.Context(), *id, nil, dryRun)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Bucket retention enforced", zap.Stringer("bucket_id", id), zap.Bool("dry_run", dryRun), zap.Int("ranges", len(enf.Ranges)))
+
+	h.api.Respond(w, r, http.StatusOK, bucketRetentionEnforcementResponse{
+		BucketID:                     *id,
+		retentionEnforcementResponse: newRetentionEnforcementResponse(enf),
+	})
+}
+
+// findActivity returns the activity of the buckets, if activity is tracked.
+// Activity is informational, so failing to find it does not fail the request.
+func (h *BucketHandler) findActivity(ctx context.Context, bs ...*influxdb.Bucket) map[platform.ID]influxdb.BucketActivity {
+	if h.activityFinder == nil || len(bs) == 0 {
+		return nil
+	}
+
+	ids := make([]platform.ID, 0, len(bs))
+	for _, b := range bs {
+		ids = append(ids, b.ID)
+	}
+
+	activity, err := h.activityFinder.FindBucketActivity(ctx, ids...)
+	if err != nil {
+		h.log.Info("Failed to find bucket activity", zap.Error(err))
+		return nil
+	}
+	return activity
 }
 
 // handleDeleteBucket is the HTTP handler for the DELETE /api/v2/buckets/:id route.
@@ -429,6 +707,11 @@ func (h *BucketHandler) handleGetBuckets(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if kithttp.AcceptsNDJSON(r) {
+		h.streamBuckets(w, r, bucketsRequest)
+		return
+	}
+
 	bs, _, err := h.bucketSvc.FindBuckets(r.Context(), bucketsRequest.filter, bucketsRequest.opts)
 	if err != nil {
 		h.api.Err(w, r, err)
@@ -436,7 +719,54 @@ func (h *BucketHandler) handleGetBuckets(w http.ResponseWriter, r *http.Request)
 	}
 	h.log.Debug("Buckets retrieved", zap.String("buckets", fmt.Sprint(bs)))
 
-	h.api.Respond(w, r, http.StatusOK, newBucketsResponse(r.Context(), bucketsRequest.opts, bucketsRequest.filter, bs, h.labelSvc))
+	h.api.Respond(w, r, http.StatusOK, newBucketsResponse(r.Context(), bucketsRequest.opts, bucketsRequest.filter, bs, h.labelSvc, h.findActivity(r.Context(), bs...)))
+}
+
+// streamBuckets streams all buckets of the request after its cursor as
+// NDJSON. The buckets are read from the store a page at a time, the paging
+// links of the list are in the Link header.
+func (h *BucketHandler) streamBuckets(w http.ResponseWriter, r *http.Request, req *getBucketsRequest) {
+	ctx := r.Context()
+	opts, err := influxdb.CursorFindOptions(req.opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	links := influxdb.NewCursorPagingLinks(prefixBuckets, opts, req.filter, 0, 0)
+	opts.Limit = influxdb.MaxPageSize
+
+	done := false
+	next := func() ([]interface{}, error) {
+		if done {
+			return nil, nil
+		}
+		bs, _, err := h.bucketSvc.FindBuckets(ctx, req.filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		// filters by ID or name find the same bucket on every page
+		if len(bs) == 0 || (opts.After != nil && bs[len(bs)-1].ID <= *opts.After) {
+			return nil, nil
+		}
+		done = len(bs) < opts.Limit
+		last := bs[len(bs)-1].ID
+		opts.After = &last
+
+		activity := h.findActivity(ctx, bs...)
+		page := make([]interface{}, 0, len(bs))
+		for _, b := range bs {
+			page = append(page, newBucketListResponse(ctx, b, h.labelSvc, activity))
+		}
+		return page, nil
+	}
+
+	page, err := next()
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	kithttp.AddLinkHeader(w, "self", links.Self)
+	h.api.RespondNDJSON(w, r, http.StatusOK, page, next)
 }
 
 type getBucketsRequest struct {
@@ -479,10 +809,15 @@ func decodeGetBucketsRequest(r *http.Request) (*getBucketsRequest, error) {
 		req.filter.ID = id
 	}
 
+	req.filter.Annotations = influxdb.DecodeResourceAnnotationsFilter(qp)
+
 	return req, nil
 }
 
 // handlePatchBucket is the HTTP handler for the PATCH /api/v2/buckets route.
+// With enforceRetention=true the updated retention period is enforced
+// immediately; with dryRun=true the bucket is left unchanged and the response
+// reports the data the update would remove.
 func (h *BucketHandler) handlePatchBucket(w http.ResponseWriter, r *http.Request) {
 	id, err := platform.IDFromString(chi.URLParam(r, "id"))
 	if err != nil {
@@ -490,11 +825,40 @@ func (h *BucketHandler) handlePatchBucket(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	enforce, dryRun, err := decodeEnforceRetentionParams(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if enforce && h.retentionEnforcer == nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotImplemented,
+			Msg:  "immediate retention enforcement is not supported",
+		})
+		return
+	}
+
 	var reqBody bucketUpdate
 	if err := h.api.DecodeJSON(r.Body, &reqBody); err != nil {
 		h.api.Err(w, r, err)
 		return
 	}
+	upd := reqBody.toInfluxDB()
+
+	if dryRun {
+		b, err := h.bucketSvc.FindBucketByID(r.Context(), *id)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		enf, err := h.retentionEnforcer.EnforceBucketRetention(r.Context(), *id, upd.RetentionPeriod, true)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		h.api.Respond(w, r, http.StatusOK, newPatchBucketResponse(b, enf))
+		return
+	}
 
 	if reqBody.Name != nil {
 		b, err := h.bucketSvc.FindBucketByID(r.Context(), *id)
@@ -505,7 +869,7 @@ func (h *BucketHandler) handlePatchBucket(w http.ResponseWriter, r *http.Request
 		b.Name = *reqBody.Name
 	}
 
-	b, err := h.bucketSvc.UpdateBucket(r.Context(), *id, *reqBody.toInfluxDB())
+	b, err := h.bucketSvc.UpdateBucket(r.Context(), *id, *upd)
 	if err != nil {
 		h.api.Err(w, r, err)
 		return
@@ -513,7 +877,90 @@ func (h *BucketHandler) handlePatchBucket(w http.ResponseWriter, r *http.Request
 
 	h.log.Debug("Bucket updated", zap.String("bucket", fmt.Sprint(b)))
 
-	h.api.Respond(w, r, http.StatusOK, NewBucketResponse(b))
+	if !enforce {
+		h.api.Respond(w, r, http.StatusOK, NewBucketResponse(b))
+		return
+	}
+
+	enf, err := h.retentionEnforcer.EnforceBucketRetention(r.Context(), *id, nil, false)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, newPatchBucketResponse(b, enf))
+}
+
+// decodeEnforceRetentionParams decodes the enforceRetention and dryRun query
+// parameters. A dry run implies enforcement.
+func decodeEnforceRetentionParams(r *http.Request) (enforce bool, dryRun bool, err error) {
+	qp := r.URL.Query()
+	if enforce, err = decodeBoolParam(qp.Get("enforceRetention"), "enforceRetention"); err != nil {
+		return false, false, err
+	}
+	if dryRun, err = decodeBoolParam(qp.Get("dryRun"), "dryRun"); err != nil {
+		return false, false, err
+	}
+	return enforce || dryRun, dryRun, nil
+}
+
+func decodeBoolParam(raw, param string) (bool, error) {
+	if raw == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid %s parameter %q", param, raw),
+		}
+	}
+	return v, nil
+}
+
+type retentionRangeResponse struct {
+	ShardGroupID uint64    `json:"shardGroupID"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	Dropped      bool      `json:"dropped"`
+}
+
+type retentionEnforcementResponse struct {
+	DryRun                 bool                     `json:"dryRun"`
+	RetentionPeriodSeconds int64                    `json:"retentionPeriodSeconds"`
+	Cutoff                 *time.Time               `json:"cutoff,omitempty"`
+	Removed                []retentionRangeResponse `json:"removed"`
+}
+
+type patchBucketResponse struct {
+	*bucketResponse
+	RetentionEnforcement retentionEnforcementResponse `json:"retentionEnforcement"`
+}
+
+func newPatchBucketResponse(b *influxdb.Bucket, enf *influxdb.RetentionEnforcement) *patchBucketResponse {
+	return &patchBucketResponse{
+		bucketResponse:       NewBucketResponse(b),
+		RetentionEnforcement: newRetentionEnforcementResponse(enf),
+	}
+}
+
+func newRetentionEnforcementResponse(enf *influxdb.RetentionEnforcement) retentionEnforcementResponse {
+	res := retentionEnforcementResponse{
+		DryRun:                 enf.DryRun,
+		RetentionPeriodSeconds: int64(enf.RetentionPeriod.Round(time.Second) / time.Second),
+		Removed:                []retentionRangeResponse{},
+	}
+	if !enf.Cutoff.IsZero() {
+		res.Cutoff = &enf.Cutoff
+	}
+	for _, rr := range enf.Ranges {
+		res.Removed = append(res.Removed, retentionRangeResponse{
+			ShardGroupID: rr.ShardGroupID,
+			Start:        rr.Start,
+			End:          rr.End,
+			Dropped:      rr.Dropped,
+		})
+	}
+	return res
 }
 
 func (h *BucketHandler) lookupOrgByBucketID(ctx context.Context, id platform.ID) (platform.ID, error) {
@@ -0,0 +1,192 @@
+package tenant
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+// BucketShareHandler represents an HTTP API handler for sharing buckets with
+// other organizations.
+type BucketShareHandler struct {
+	chi.Router
+	api      *kithttp.API
+	log      *zap.Logger
+	shareSvc influxdb.BucketShareService
+}
+
+const prefixBucketShares = "/api/v2/shares"
+
+func (h *BucketShareHandler) Prefix() string {
+	return prefixBucketShares
+}
+
+// NewHTTPBucketShareHandler constructs a new http server.
+func NewHTTPBucketShareHandler(log *zap.Logger, shareSvc influxdb.BucketShareService) *BucketShareHandler {
+	svr := &BucketShareHandler{
+		api:      kithttp.NewAPI(kithttp.WithLog(log)),
+		log:      log,
+		shareSvc: shareSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Route("/", func(r chi.Router) {
+		r.Post("/", svr.handlePostBucketShare)
+		r.Get("/", svr.handleGetBucketShares)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", svr.handleGetBucketShare)
+			r.Delete("/", svr.handleDeleteBucketShare)
+		})
+	})
+	svr.Router = r
+	return svr
+}
+
+type bucketShareResponse struct {
+	Links map[string]string `json:"links"`
+	influxdb.BucketShare
+}
+
+func newBucketShareResponse(sh influxdb.BucketShare) bucketShareResponse {
+	return bucketShareResponse{
+		Links: map[string]string{
+			"self":      fmt.Sprintf("/api/v2/shares/%s", sh.ID),
+			"bucket":    fmt.Sprintf("/api/v2/buckets/%s", sh.BucketID),
+			"org":       fmt.Sprintf("/api/v2/orgs/%s", sh.OrgID),
+			"targetOrg": fmt.Sprintf("/api/v2/orgs/%s", sh.TargetOrgID),
+		},
+		BucketShare: sh,
+	}
+}
+
+type bucketSharesResponse struct {
+	Links  map[string]string     `json:"links"`
+	Shares []bucketShareResponse `json:"shares"`
+}
+
+type postBucketShareRequest struct {
+	BucketID    platform.ID `json:"bucketID"`
+	TargetOrgID platform.ID `json:"targetOrgID"`
+	Description string      `json:"description"`
+	ExpiresAt   *time.Time  `json:"expiresAt"`
+}
+
+// handlePostBucketShare is the HTTP handler for the POST /api/v2/shares route.
+func (h *BucketShareHandler) handlePostBucketShare(w http.ResponseWriter, r *http.Request) {
+	var req postBucketShareRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if !req.BucketID.Valid() || !req.TargetOrgID.Valid() {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "bucketID and targetOrgID are required",
+		})
+		return
+	}
+
+	share := influxdb.BucketShare{
+		BucketID:    req.BucketID,
+		TargetOrgID: req.TargetOrgID,
+		Description: req.Description,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if err := h.shareSvc.CreateBucketShare(r.Context(), &share); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Bucket share created", zap.String("share", share.ID.String()))
+
+	h.api.Respond(w, r, http.StatusCreated, newBucketShareResponse(share))
+}
+
+// handleGetBucketShares is the HTTP handler for the GET /api/v2/shares route.
+// The orgID parameter lists both the shares of the organization's buckets and
+// the buckets shared with the organization.
+func (h *BucketShareHandler) handleGetBucketShares(w http.ResponseWriter, r *http.Request) {
+	var filter influxdb.BucketShareFilter
+	q := r.URL.Query()
+	for param, dst := range map[string]**platform.ID{
+		"orgID":       &filter.OrgID,
+		"targetOrgID": &filter.TargetOrgID,
+		"bucketID":    &filter.BucketID,
+	} {
+		if v := q.Get(param); v != "" {
+			id, err := platform.IDFromString(v)
+			if err != nil {
+				h.api.Err(w, r, err)
+				return
+			}
+			*dst = id
+		}
+	}
+
+	shares, err := h.shareSvc.FindBucketShares(r.Context(), filter)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Bucket shares retrieved", zap.Int("count", len(shares)))
+
+	res := bucketSharesResponse{
+		Links: map[string]string{
+			"self": prefixBucketShares,
+		},
+		Shares: []bucketShareResponse{},
+	}
+	for _, sh := range shares {
+		res.Shares = append(res.Shares, newBucketShareResponse(*sh))
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+// handleGetBucketShare is the HTTP handler for the GET /api/v2/shares/:id route.
+func (h *BucketShareHandler) handleGetBucketShare(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	share, err := h.shareSvc.FindBucketShareByID(r.Context(), *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Bucket share retrieved", zap.String("share", share.ID.String()))
+
+	h.api.Respond(w, r, http.StatusOK, newBucketShareResponse(*share))
+}
+
+// handleDeleteBucketShare is the HTTP handler for the DELETE /api/v2/shares/:id route.
+func (h *BucketShareHandler) handleDeleteBucketShare(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.shareSvc.DeleteBucketShare(r.Context(), *id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Bucket share deleted", zap.String("shareID", fmt.Sprint(id)))
+
+	w.WriteHeader(http.StatusNoContent)
+}
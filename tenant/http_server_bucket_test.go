@@ -1,7 +1,10 @@
 package tenant_test
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -13,10 +16,13 @@ import (
 	ihttp "github.com/influxdata/influxdb/v2/http"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/kv"
 	"github.com/influxdata/influxdb/v2/mock"
 	"github.com/influxdata/influxdb/v2/tenant"
 	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -208,3 +214,234 @@ func TestHTTPBucketService_InvalidRetention(t *testing.T) {
 		})
 	}
 }
+
+func TestBucketHandler_GetBucketsNDJSON(t *testing.T) {
+	const count = 250
+
+	var pages []influxdb.FindOptions
+	svc := mock.NewBucketService()
+	svc.FindBucketsFn = func(_ context.Context, _ influxdb.BucketFilter, opts ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+		o := opts[0]
+		pages = append(pages, o)
+
+		start := platform.ID(1)
+		if o.After != nil {
+			start = *o.After + 1
+		}
+		var bs []*influxdb.Bucket
+		for id := start; id <= count && len(bs) < o.Limit; id++ {
+			bs = append(bs, &influxdb.Bucket{ID: id, OrgID: idOne, Name: id.String()})
+		}
+		return bs, len(bs), nil
+	}
+
+	handler := tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), svc, nil, nil, nil)
+	r := chi.NewRouter()
+	r.Mount(handler.Prefix(), handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/buckets?orgID="+idOne.String(), nil)
+	req.Header.Set("Accept", kithttp.NDJSONContentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, kithttp.NDJSONContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, `</api/v2/buckets?cursor=&descending=false&limit=20&orgID=`+idOne.String()+`>; rel="self"`, w.Header().Get("Link"))
+
+	var ids []platform.ID
+	sc := bufio.NewScanner(w.Body)
+	for sc.Scan() {
+		var b struct {
+			ID platform.ID `json:"id"`
+		}
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &b))
+		ids = append(ids, b.ID)
+	}
+	require.NoError(t, sc.Err())
+	require.Len(t, ids, count)
+	for i, id := range ids {
+		assert.Equal(t, platform.ID(i+1), id)
+	}
+
+	// the buckets are read a page at a time by cursor
+	require.Len(t, pages, 3)
+	for _, o := range pages {
+		assert.True(t, o.Cursor)
+		assert.Equal(t, influxdb.MaxPageSize, o.Limit)
+	}
+}
+
+type bucketCardinalityFinder func(ctx context.Context, bucketID platform.ID, exact bool, topTagKeys int) (*influxdb.BucketCardinality, error)
+
+func (f bucketCardinalityFinder) FindBucketCardinality(ctx context.Context, bucketID platform.ID, exact bool, topTagKeys int) (*influxdb.BucketCardinality, error) {
+	return f(ctx, bucketID, exact, topTagKeys)
+}
+
+func TestBucketHandler_GetBucketCardinality(t *testing.T) {
+	var gotExact bool
+	var gotTopTagKeys int
+	finder := bucketCardinalityFinder(func(_ context.Context, bucketID platform.ID, exact bool, topTagKeys int) (*influxdb.BucketCardinality, error) {
+		gotExact, gotTopTagKeys = exact, topTagKeys
+		return &influxdb.BucketCardinality{
+			BucketID: bucketID,
+			Exact:    exact,
+			Series:   2,
+			Measurements: []influxdb.MeasurementCardinality{
+				{Name: "cpu", Series: 2, TagKeys: []influxdb.TagKeyCardinality{{Key: "host", Values: 2}}},
+			},
+		}, nil
+	})
+
+	serve := func(handler *tenant.BucketHandler, target string) *httptest.ResponseRecorder {
+		r := chi.NewRouter()
+		r.Mount(handler.Prefix(), handler)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+	handler := tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), mock.NewBucketService(), nil, nil, nil, tenant.WithBucketCardinality(finder))
+	target := "/api/v2/buckets/" + idOne.String() + "/cardinality"
+
+	w := serve(handler, target)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, gotExact)
+	assert.Equal(t, 10, gotTopTagKeys)
+	assert.JSONEq(t, `{
+		"bucketID": "`+idOne.String()+`",
+		"exact": false,
+		"series": 2,
+		"measurements": [{"name": "cpu", "series": 2, "tagKeys": [{"key": "host", "values": 2}]}]
+	}`, w.Body.String())
+
+	w = serve(handler, target+"?exact=true&topTagKeys=3")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, gotExact)
+	assert.Equal(t, 3, gotTopTagKeys)
+
+	w = serve(handler, target+"?topTagKeys=-1")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// servers without a storage engine do not count series
+	w = serve(tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), mock.NewBucketService(), nil, nil, nil), target)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
+
+type bucketShardFinder func(ctx context.Context, bucketID platform.ID) ([]influxdb.ShardGroupStatus, error)
+
+func (f bucketShardFinder) FindBucketShards(ctx context.Context, bucketID platform.ID) ([]influxdb.ShardGroupStatus, error) {
+	return f(ctx, bucketID)
+}
+
+func TestBucketHandler_GetBucketShards(t *testing.T) {
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	finder := bucketShardFinder(func(_ context.Context, bucketID platform.ID) ([]influxdb.ShardGroupStatus, error) {
+		if bucketID != idOne {
+			return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+		}
+		return []influxdb.ShardGroupStatus{{
+			ID:    1,
+			Start: start,
+			End:   start.Add(24 * time.Hour),
+			Shards: []influxdb.ShardStatus{
+				{ID: 2, DiskSize: 1024, TSMFiles: 3, CompactionLevel: 2, TombstoneFiles: 1, TombstoneSize: 16},
+			},
+		}}, nil
+	})
+
+	serve := func(handler *tenant.BucketHandler, id platform.ID) *httptest.ResponseRecorder {
+		r := chi.NewRouter()
+		r.Mount(handler.Prefix(), handler)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/buckets/"+id.String()+"/shards", nil))
+		return w
+	}
+	handler := tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), mock.NewBucketService(), nil, nil, nil, tenant.WithBucketShards(finder))
+
+	w := serve(handler, idOne)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"bucketID": "`+idOne.String()+`",
+		"shardGroups": [{
+			"id": 1,
+			"start": "2021-06-01T00:00:00Z",
+			"end": "2021-06-02T00:00:00Z",
+			"shards": [{
+				"id": 2,
+				"diskSize": 1024,
+				"tsmFiles": 3,
+				"compactionLevel": 2,
+				"fullyCompacted": false,
+				"tombstoneFiles": 1,
+				"tombstoneSize": 16
+			}]
+		}]
+	}`, w.Body.String())
+
+	w = serve(handler, idOne+1)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// servers without a storage engine do not report shards
+	w = serve(tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), mock.NewBucketService(), nil, nil, nil), idOne)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
+
+type retentionEnforcer func(ctx context.Context, bucketID platform.ID, retentionPeriod *time.Duration, dryRun bool) (*influxdb.RetentionEnforcement, error)
+
+func (f retentionEnforcer) EnforceBucketRetention(ctx context.Context, bucketID platform.ID, retentionPeriod *time.Duration, dryRun bool) (*influxdb.RetentionEnforcement, error) {
+	return f(ctx, bucketID, retentionPeriod, dryRun)
+}
+
+func TestBucketHandler_PostBucketRetentionEnforce(t *testing.T) {
+	cutoff := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	var gotDryRun bool
+	enforcer := retentionEnforcer(func(_ context.Context, bucketID platform.ID, retentionPeriod *time.Duration, dryRun bool) (*influxdb.RetentionEnforcement, error) {
+		if bucketID != idOne {
+			return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+		}
+		assert.Nil(t, retentionPeriod, "the bucket's retention period is enforced")
+		gotDryRun = dryRun
+		return &influxdb.RetentionEnforcement{
+			DryRun:          dryRun,
+			RetentionPeriod: 24 * time.Hour,
+			Cutoff:          cutoff,
+			Ranges: []influxdb.RetentionRange{
+				{ShardGroupID: 1, Start: cutoff.Add(-24 * time.Hour), End: cutoff, Dropped: true},
+			},
+		}, nil
+	})
+
+	serve := func(handler *tenant.BucketHandler, target string) *httptest.ResponseRecorder {
+		r := chi.NewRouter()
+		r.Mount(handler.Prefix(), handler)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+		return w
+	}
+	handler := tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), mock.NewBucketService(), nil, nil, nil, tenant.WithRetentionEnforcer(enforcer))
+	target := "/api/v2/buckets/" + idOne.String() + "/retention/enforce"
+
+	w := serve(handler, target)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, gotDryRun)
+	assert.JSONEq(t, `{
+		"bucketID": "`+idOne.String()+`",
+		"dryRun": false,
+		"retentionPeriodSeconds": 86400,
+		"cutoff": "2021-06-01T00:00:00Z",
+		"removed": [{"shardGroupID": 1, "start": "2021-05-31T00:00:00Z", "end": "2021-06-01T00:00:00Z", "dropped": true}]
+	}`, w.Body.String())
+
+	w = serve(handler, target+"?dryRun=true")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, gotDryRun)
+
+	w = serve(handler, target+"?dryRun=maybe")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(handler, "/api/v2/buckets/"+(idOne+1).String()+"/retention/enforce")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// servers without a storage engine do not enforce retention
+	w = serve(tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), mock.NewBucketService(), nil, nil, nil), target)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
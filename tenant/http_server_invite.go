@@ -0,0 +1,214 @@
+package tenant
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+// InviteHandler represents an HTTP API handler for user invites.
+type InviteHandler struct {
+	chi.Router
+	api       *kithttp.API
+	log       *zap.Logger
+	inviteSvc influxdb.InviteService
+}
+
+const (
+	prefixInvites = "/api/v2/invites"
+
+	// InviteAcceptPath is the path invited users sign up with. It is
+	// authorized by the invite token and must not require authentication.
+	InviteAcceptPath = prefixInvites + "/accept"
+)
+
+func (h *InviteHandler) Prefix() string {
+	return prefixInvites
+}
+
+// NewHTTPInviteHandler constructs a new http server.
+func NewHTTPInviteHandler(log *zap.Logger, inviteSvc influxdb.InviteService) *InviteHandler {
+	svr := &InviteHandler{
+		api:       kithttp.NewAPI(kithttp.WithLog(log)),
+		log:       log,
+		inviteSvc: inviteSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Route("/", func(r chi.Router) {
+		r.Post("/", svr.handlePostInvite)
+		r.Get("/", svr.handleGetInvites)
+		r.Post("/accept", svr.handleAcceptInvite)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", svr.handleGetInvite)
+			r.Delete("/", svr.handleDeleteInvite)
+		})
+	})
+	svr.Router = r
+	return svr
+}
+
+type inviteResponse struct {
+	Links map[string]string `json:"links"`
+	influxdb.Invite
+}
+
+func newInviteResponse(i influxdb.Invite) inviteResponse {
+	return inviteResponse{
+		Links: map[string]string{
+			"self": fmt.Sprintf("/api/v2/invites/%s", i.ID),
+			"org":  fmt.Sprintf("/api/v2/orgs/%s", i.OrgID),
+		},
+		Invite: i,
+	}
+}
+
+type invitesResponse struct {
+	Links   map[string]string `json:"links"`
+	Invites []inviteResponse  `json:"invites"`
+}
+
+type postInviteRequest struct {
+	OrgID  platform.ID       `json:"orgID"`
+	Email  string            `json:"email"`
+	Role   influxdb.UserType `json:"role"`
+	Locale string            `json:"locale"`
+}
+
+type postInviteResponse struct {
+	inviteResponse
+	// SignupLink is the link the invited person signs up with.
+	SignupLink string `json:"signupLink"`
+}
+
+// handlePostInvite is the HTTP handler for the POST /api/v2/invites route.
+func (h *InviteHandler) handlePostInvite(w http.ResponseWriter, r *http.Request) {
+	var req postInviteRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if !req.OrgID.Valid() {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "orgID is required",
+		})
+		return
+	}
+
+	invite := influxdb.Invite{
+		OrgID:  req.OrgID,
+		Email:  req.Email,
+		Role:   req.Role,
+		Locale: req.Locale,
+	}
+	link, err := h.inviteSvc.CreateInvite(r.Context(), &invite)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Invite created", zap.String("invite", invite.ID.String()))
+
+	h.api.Respond(w, r, http.StatusCreated, postInviteResponse{
+		inviteResponse: newInviteResponse(invite),
+		SignupLink:     link,
+	})
+}
+
+// handleGetInvites is the HTTP handler for the GET /api/v2/invites route.
+func (h *InviteHandler) handleGetInvites(w http.ResponseWriter, r *http.Request) {
+	var filter influxdb.InviteFilter
+	if orgID := r.URL.Query().Get("orgID"); orgID != "" {
+		id, err := platform.IDFromString(orgID)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		filter.OrgID = *id
+	}
+
+	invites, err := h.inviteSvc.FindInvites(r.Context(), filter)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Invites retrieved", zap.Int("count", len(invites)))
+
+	res := invitesResponse{
+		Links: map[string]string{
+			"self": prefixInvites,
+		},
+		Invites: []inviteResponse{},
+	}
+	for _, i := range invites {
+		res.Invites = append(res.Invites, newInviteResponse(*i))
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+// handleGetInvite is the HTTP handler for the GET /api/v2/invites/:id route.
+func (h *InviteHandler) handleGetInvite(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	invite, err := h.inviteSvc.FindInviteByID(r.Context(), *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Invite retrieved", zap.String("invite", invite.ID.String()))
+
+	h.api.Respond(w, r, http.StatusOK, newInviteResponse(*invite))
+}
+
+// handleDeleteInvite is the HTTP handler for the DELETE /api/v2/invites/:id route.
+func (h *InviteHandler) handleDeleteInvite(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.inviteSvc.DeleteInvite(r.Context(), *id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Invite deleted", zap.String("inviteID", fmt.Sprint(id)))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAcceptInvite is the HTTP handler for the POST /api/v2/invites/accept route.
+func (h *InviteHandler) handleAcceptInvite(w http.ResponseWriter, r *http.Request) {
+	var req influxdb.AcceptInviteRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	user, err := h.inviteSvc.AcceptInvite(r.Context(), req)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Invite accepted", zap.String("user", user.ID.String()))
+
+	h.api.Respond(w, r, http.StatusCreated, newUserResponse(user))
+}
@@ -409,7 +409,15 @@ func (h *UserHandler) handleGetUsers(w http.ResponseWriter, r *http.Request) {
 	}
 	h.log.Debug("Users retrieved", zap.String("users", fmt.Sprint(users)))
 
-	h.api.Respond(w, r, http.StatusOK, newUsersResponse(users))
+	res := newUsersResponse(users)
+	if req.opts.Cursor && len(users) > 0 {
+		links := influxdb.NewCursorPagingLinks(prefixUsers, req.opts, nil, len(users), users[len(users)-1].ID)
+		res.Links["self"] = links.Self
+		if links.Next != "" {
+			res.Links["next"] = links.Next
+		}
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
 }
 
 type getUsersRequest struct {
@@ -22,3 +22,19 @@ var URMByUserIndexMapping = kv.NewIndexMapping(
 		return id, nil
 	},
 )
+
+// BucketByOrgIndexMapping is the mapping description of an index
+// between an organization and its buckets, ordered by bucket ID
+var BucketByOrgIndexMapping = kv.NewIndexMapping(
+	[]byte("bucketsv1"),
+	[]byte("bucketsbyorgidindexv1"),
+	func(v []byte) ([]byte, error) {
+		var b influxdb.Bucket
+		if err := json.Unmarshal(v, &b); err != nil {
+			return nil, err
+		}
+
+		id, _ := b.OrgID.Encode()
+		return id, nil
+	},
+)
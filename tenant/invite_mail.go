@@ -0,0 +1,186 @@
+package tenant
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// DefaultInviteLocale is the locale of invitation emails when the invite
+// has no locale or no template exists for it.
+const DefaultInviteLocale = "en"
+
+const defaultInviteTemplate = `{{define "subject"}}You are invited to join {{.OrgName}} on InfluxDB{{end}}
+{{- define "body"}}Hello,
+
+you have been invited to join the organization {{.OrgName}} on InfluxDB as {{.Role}}.
+
+Choose a username and password to sign up:
+
+{{.Link}}
+
+This invitation expires on {{.ExpiresAt.Format "2006-01-02 15:04 MST"}}.
+If you were not expecting this invitation you can ignore this email.
+{{end}}`
+
+// InviteEmail is the data invitation email templates are executed with.
+type InviteEmail struct {
+	Email     string
+	OrgName   string
+	Role      influxdb.UserType
+	Link      string
+	ExpiresAt time.Time
+}
+
+// InviteTemplates renders invitation emails in the locale of the invite.
+// Each template defines a "subject" and a "body" template.
+type InviteTemplates struct {
+	byLocale map[string]*template.Template
+}
+
+// NewInviteTemplates returns the built-in English template.
+func NewInviteTemplates() *InviteTemplates {
+	return &InviteTemplates{
+		byLocale: map[string]*template.Template{
+			DefaultInviteLocale: template.Must(template.New(DefaultInviteLocale).Parse(defaultInviteTemplate)),
+		},
+	}
+}
+
+// LoadInviteTemplates returns the built-in template, added to and overridden
+// by the files in dir named invite.<locale>.tmpl, e.g. invite.de.tmpl.
+func LoadInviteTemplates(dir string) (*InviteTemplates, error) {
+	t := NewInviteTemplates()
+	files, err := filepath.Glob(filepath.Join(dir, "invite.*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		locale := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(f), "invite."), ".tmpl")
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.Add(locale, string(b)); err != nil {
+			return nil, fmt.Errorf("invite template %s: %w", f, err)
+		}
+	}
+	return t, nil
+}
+
+// Add parses the template text for the locale.
+func (t *InviteTemplates) Add(locale, text string) error {
+	tmpl, err := template.New(locale).Parse(text)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{"subject", "body"} {
+		if tmpl.Lookup(name) == nil {
+			return fmt.Errorf("template %q is not defined", name)
+		}
+	}
+	t.byLocale[strings.ToLower(locale)] = tmpl
+	return nil
+}
+
+// lookup finds the template of the locale, falling back from a regional
+// locale such as "pt-br" to its language and then to the default locale.
+func (t *InviteTemplates) lookup(locale string) *template.Template {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	for locale != "" {
+		if tmpl, ok := t.byLocale[locale]; ok {
+			return tmpl
+		}
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return t.byLocale[DefaultInviteLocale]
+}
+
+// Render returns the subject and body of the invitation email.
+func (t *InviteTemplates) Render(locale string, data InviteEmail) (subject, body string, err error) {
+	tmpl := t.lookup(locale)
+	var sb, bb bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&sb, "subject", data); err != nil {
+		return "", "", err
+	}
+	if err := tmpl.ExecuteTemplate(&bb, "body", data); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(sb.String()), bb.String(), nil
+}
+
+// SMTPConfig configures the SMTP server invitation emails are sent with.
+type SMTPConfig struct {
+	// Addr is the host:port of the server.
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+var _ influxdb.InviteMailer = (*SMTPInviteMailer)(nil)
+
+// SMTPInviteMailer sends templated invitation emails over SMTP.
+type SMTPInviteMailer struct {
+	config    SMTPConfig
+	templates *InviteTemplates
+
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPInviteMailer constructs a mailer sending the invitations rendered
+// with the templates.
+func NewSMTPInviteMailer(config SMTPConfig, templates *InviteTemplates) *SMTPInviteMailer {
+	return &SMTPInviteMailer{
+		config:    config,
+		templates: templates,
+		sendMail:  smtp.SendMail,
+	}
+}
+
+// SendInvite renders the invitation in the locale of the invite and sends it.
+func (m *SMTPInviteMailer) SendInvite(ctx context.Context, i *influxdb.Invite, org *influxdb.Organization, link string) error {
+	subject, body, err := m.templates.Render(i.Locale, InviteEmail{
+		Email:     i.Email,
+		OrgName:   org.Name,
+		Role:      i.Role,
+		Link:      link,
+		ExpiresAt: i.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", i.Email)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		host, _, err := net.SplitHostPort(m.config.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, host)
+	}
+	return m.sendMail(m.config.Addr, auth, m.config.From, []string{i.Email}, msg.Bytes())
+}
@@ -2,6 +2,7 @@ package tenant
 
 import (
 	"context"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/authorizer"
@@ -119,3 +120,99 @@ func (s *AuthedBucketService) DeleteBucket(ctx context.Context, id platform.ID)
 	}
 	return s.s.DeleteBucket(ctx, id)
 }
+
+var _ influxdb.RetentionEnforcer = (*AuthedRetentionEnforcer)(nil)
+
+// AuthedRetentionEnforcer wraps a influxdb.RetentionEnforcer and authorizes
+// enforcement against the bucket it removes data from.
+type AuthedRetentionEnforcer struct {
+	bucketSvc influxdb.BucketService
+	s         influxdb.RetentionEnforcer
+}
+
+// NewAuthedRetentionEnforcer constructs an instance of an authorizing retention enforcer.
+func NewAuthedRetentionEnforcer(bucketSvc influxdb.BucketService, s influxdb.RetentionEnforcer) *AuthedRetentionEnforcer {
+	return &AuthedRetentionEnforcer{
+		bucketSvc: bucketSvc,
+		s:         s,
+	}
+}
+
+// EnforceBucketRetention checks to see if the authorizer on context has write access to the bucket provided.
+func (s *AuthedRetentionEnforcer) EnforceBucketRetention(ctx context.Context, id platform.ID, retentionPeriod *time.Duration, dryRun bool) (*influxdb.RetentionEnforcement, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	b, err := s.bucketSvc.FindBucketByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.BucketsResourceType, id, b.OrgID); err != nil {
+		return nil, err
+	}
+	return s.s.EnforceBucketRetention(ctx, id, retentionPeriod, dryRun)
+}
+
+var _ influxdb.BucketCardinalityFinder = (*AuthedBucketCardinalityFinder)(nil)
+
+// AuthedBucketCardinalityFinder wraps a influxdb.BucketCardinalityFinder and
+// authorizes reading the bucket it counts the series of.
+type AuthedBucketCardinalityFinder struct {
+	bucketSvc influxdb.BucketService
+	s         influxdb.BucketCardinalityFinder
+}
+
+// NewAuthedBucketCardinalityFinder constructs an instance of an authorizing bucket cardinality finder.
+func NewAuthedBucketCardinalityFinder(bucketSvc influxdb.BucketService, s influxdb.BucketCardinalityFinder) *AuthedBucketCardinalityFinder {
+	return &AuthedBucketCardinalityFinder{
+		bucketSvc: bucketSvc,
+		s:         s,
+	}
+}
+
+// FindBucketCardinality checks to see if the authorizer on context has read access to the bucket provided.
+func (s *AuthedBucketCardinalityFinder) FindBucketCardinality(ctx context.Context, id platform.ID, exact bool, topTagKeys int) (*influxdb.BucketCardinality, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	b, err := s.bucketSvc.FindBucketByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeRead(ctx, influxdb.BucketsResourceType, id, b.OrgID); err != nil {
+		return nil, err
+	}
+	return s.s.FindBucketCardinality(ctx, id, exact, topTagKeys)
+}
+
+var _ influxdb.BucketShardFinder = (*AuthedBucketShardFinder)(nil)
+
+// AuthedBucketShardFinder wraps a influxdb.BucketShardFinder and authorizes
+// reading the bucket it finds the shards of.
+type AuthedBucketShardFinder struct {
+	bucketSvc influxdb.BucketService
+	s         influxdb.BucketShardFinder
+}
+
+// NewAuthedBucketShardFinder constructs an instance of an authorizing bucket shard finder.
+func NewAuthedBucketShardFinder(bucketSvc influxdb.BucketService, s influxdb.BucketShardFinder) *AuthedBucketShardFinder {
+	return &AuthedBucketShardFinder{
+		bucketSvc: bucketSvc,
+		s:         s,
+	}
+}
+
+// FindBucketShards checks to see if the authorizer on context has read access to the bucket provided.
+func (s *AuthedBucketShardFinder) FindBucketShards(ctx context.Context, id platform.ID) ([]influxdb.ShardGroupStatus, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	b, err := s.bucketSvc.FindBucketByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeRead(ctx, influxdb.BucketsResourceType, id, b.OrgID); err != nil {
+		return nil, err
+	}
+	return s.s.FindBucketShards(ctx, id)
+}
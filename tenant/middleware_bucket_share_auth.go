@@ -0,0 +1,88 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+var _ influxdb.BucketShareService = (*AuthedBucketShareService)(nil)
+
+// AuthedBucketShareService wraps a influxdb.BucketShareService and authorizes
+// actions against it appropriately.
+type AuthedBucketShareService struct {
+	bucketSvc influxdb.BucketService
+	s         influxdb.BucketShareService
+}
+
+// NewAuthedBucketShareService constructs an instance of an authorizing bucket share service.
+func NewAuthedBucketShareService(bucketSvc influxdb.BucketService, s influxdb.BucketShareService) *AuthedBucketShareService {
+	return &AuthedBucketShareService{
+		bucketSvc: bucketSvc,
+		s:         s,
+	}
+}
+
+// CreateBucketShare checks to see if the authorizer on context has write access to the bucket shared.
+func (s *AuthedBucketShareService) CreateBucketShare(ctx context.Context, sh *influxdb.BucketShare) error {
+	b, err := s.bucketSvc.FindBucketByID(ctx, sh.BucketID)
+	if err != nil {
+		return err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.BucketsResourceType, b.ID, b.OrgID); err != nil {
+		return err
+	}
+	return s.s.CreateBucketShare(ctx, sh)
+}
+
+// FindBucketShareByID checks to see if the authorizer on context has read access to the organization owning the bucket or the organization it is shared with.
+func (s *AuthedBucketShareService) FindBucketShareByID(ctx context.Context, id platform.ID) (*influxdb.BucketShare, error) {
+	sh, err := s.s.FindBucketShareByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeReadBucketShare(ctx, sh); err != nil {
+		return nil, err
+	}
+	return sh, nil
+}
+
+// FindBucketShares retrieves all bucket shares that match the provided filter and then filters the list down to only the shares visible to the authorizer.
+func (s *AuthedBucketShareService) FindBucketShares(ctx context.Context, filter influxdb.BucketShareFilter) ([]*influxdb.BucketShare, error) {
+	ss, err := s.s.FindBucketShares(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := ss[:0]
+	for _, sh := range ss {
+		if err := authorizeReadBucketShare(ctx, sh); err != nil {
+			continue
+		}
+		shares = append(shares, sh)
+	}
+	return shares, nil
+}
+
+// DeleteBucketShare checks to see if the authorizer on context has write access to the bucket shared.
+func (s *AuthedBucketShareService) DeleteBucketShare(ctx context.Context, id platform.ID) error {
+	sh, err := s.s.FindBucketShareByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.BucketsResourceType, sh.BucketID, sh.OrgID); err != nil {
+		return err
+	}
+	return s.s.DeleteBucketShare(ctx, id)
+}
+
+// authorizeReadBucketShare makes shares visible to both organizations.
+func authorizeReadBucketShare(ctx context.Context, sh *influxdb.BucketShare) error {
+	if _, _, err := authorizer.AuthorizeReadOrg(ctx, sh.OrgID); err == nil {
+		return nil
+	}
+	_, _, err := authorizer.AuthorizeReadOrg(ctx, sh.TargetOrgID)
+	return err
+}
@@ -0,0 +1,78 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+var _ influxdb.InviteService = (*AuthedInviteService)(nil)
+
+// AuthedInviteService wraps a influxdb.InviteService and authorizes actions
+// against it appropriately.
+type AuthedInviteService struct {
+	s influxdb.InviteService
+}
+
+// NewAuthedInviteService constructs an instance of an authorizing invite service.
+func NewAuthedInviteService(s influxdb.InviteService) *AuthedInviteService {
+	return &AuthedInviteService{
+		s: s,
+	}
+}
+
+// CreateInvite checks to see if the authorizer on context has write access to the organization invited to.
+func (s *AuthedInviteService) CreateInvite(ctx context.Context, i *influxdb.Invite) (string, error) {
+	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, i.OrgID); err != nil {
+		return "", err
+	}
+	return s.s.CreateInvite(ctx, i)
+}
+
+// FindInviteByID checks to see if the authorizer on context has read access to the organization of the invite.
+func (s *AuthedInviteService) FindInviteByID(ctx context.Context, id platform.ID) (*influxdb.Invite, error) {
+	i, err := s.s.FindInviteByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeReadOrg(ctx, i.OrgID); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// FindInvites retrieves all invites that match the provided filter and then filters the list down to only the invites of organizations that are authorized.
+func (s *AuthedInviteService) FindInvites(ctx context.Context, filter influxdb.InviteFilter) ([]*influxdb.Invite, error) {
+	is, err := s.s.FindInvites(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	invites := is[:0]
+	for _, i := range is {
+		if _, _, err := authorizer.AuthorizeReadOrg(ctx, i.OrgID); err != nil {
+			continue
+		}
+		invites = append(invites, i)
+	}
+	return invites, nil
+}
+
+// DeleteInvite checks to see if the authorizer on context has write access to the organization of the invite.
+func (s *AuthedInviteService) DeleteInvite(ctx context.Context, id platform.ID) error {
+	i, err := s.s.FindInviteByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, i.OrgID); err != nil {
+		return err
+	}
+	return s.s.DeleteInvite(ctx, id)
+}
+
+// AcceptInvite pass through. The invite token authorizes the request.
+func (s *AuthedInviteService) AcceptInvite(ctx context.Context, req influxdb.AcceptInviteRequest) (*influxdb.User, error) {
+	return s.s.AcceptInvite(ctx, req)
+}
@@ -0,0 +1,90 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"go.uber.org/zap"
+)
+
+type InviteLogger struct {
+	logger        *zap.Logger
+	inviteService influxdb.InviteService
+}
+
+// NewInviteLogger returns a logging service middleware for the Invite Service.
+func NewInviteLogger(log *zap.Logger, s influxdb.InviteService) *InviteLogger {
+	return &InviteLogger{
+		logger:        log,
+		inviteService: s,
+	}
+}
+
+var _ influxdb.InviteService = (*InviteLogger)(nil)
+
+func (l *InviteLogger) CreateInvite(ctx context.Context, i *influxdb.Invite) (link string, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			msg := fmt.Sprintf("failed to create invite to org %v", i.OrgID)
+			l.logger.Error(msg, zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("invite create", dur)
+	}(time.Now())
+	return l.inviteService.CreateInvite(ctx, i)
+}
+
+func (l *InviteLogger) FindInviteByID(ctx context.Context, id platform.ID) (i *influxdb.Invite, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			msg := fmt.Sprintf("failed to find invite with ID %v", id)
+			l.logger.Debug(msg, zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("invite find by ID", dur)
+	}(time.Now())
+	return l.inviteService.FindInviteByID(ctx, id)
+}
+
+func (l *InviteLogger) FindInvites(ctx context.Context, filter influxdb.InviteFilter) (is []*influxdb.Invite, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to find invites", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("invites find", dur)
+	}(time.Now())
+	return l.inviteService.FindInvites(ctx, filter)
+}
+
+func (l *InviteLogger) DeleteInvite(ctx context.Context, id platform.ID) (err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			msg := fmt.Sprintf("failed to delete invite with ID %v", id)
+			l.logger.Error(msg, zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("invite delete", dur)
+	}(time.Now())
+	return l.inviteService.DeleteInvite(ctx, id)
+}
+
+func (l *InviteLogger) AcceptInvite(ctx context.Context, req influxdb.AcceptInviteRequest) (u *influxdb.User, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			msg := fmt.Sprintf("failed to accept invite for user %s", req.Username)
+			l.logger.Error(msg, zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("invite accept", dur)
+	}(time.Now())
+	return l.inviteService.AcceptInvite(ctx, req)
+}
@@ -0,0 +1,57 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/metric"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ influxdb.InviteService = (*InviteMetrics)(nil)
+
+type InviteMetrics struct {
+	// RED metrics
+	rec *metric.REDClient
+
+	inviteService influxdb.InviteService
+}
+
+// NewInviteMetrics returns a metrics service middleware for the Invite Service.
+func NewInviteMetrics(reg prometheus.Registerer, s influxdb.InviteService, opts ...metric.ClientOptFn) *InviteMetrics {
+	o := metric.ApplyMetricOpts(opts...)
+	return &InviteMetrics{
+		rec:           metric.New(reg, o.ApplySuffix("invite")),
+		inviteService: s,
+	}
+}
+
+func (m *InviteMetrics) CreateInvite(ctx context.Context, i *influxdb.Invite) (string, error) {
+	rec := m.rec.Record("create_invite")
+	link, err := m.inviteService.CreateInvite(ctx, i)
+	return link, rec(err)
+}
+
+func (m *InviteMetrics) FindInviteByID(ctx context.Context, id platform.ID) (*influxdb.Invite, error) {
+	rec := m.rec.Record("find_invite_by_id")
+	i, err := m.inviteService.FindInviteByID(ctx, id)
+	return i, rec(err)
+}
+
+func (m *InviteMetrics) FindInvites(ctx context.Context, filter influxdb.InviteFilter) ([]*influxdb.Invite, error) {
+	rec := m.rec.Record("find_invites")
+	is, err := m.inviteService.FindInvites(ctx, filter)
+	return is, rec(err)
+}
+
+func (m *InviteMetrics) DeleteInvite(ctx context.Context, id platform.ID) error {
+	rec := m.rec.Record("delete_invite")
+	return rec(m.inviteService.DeleteInvite(ctx, id))
+}
+
+func (m *InviteMetrics) AcceptInvite(ctx context.Context, req influxdb.AcceptInviteRequest) (*influxdb.User, error) {
+	rec := m.rec.Record("accept_invite")
+	u, err := m.inviteService.AcceptInvite(ctx, req)
+	return u, rec(err)
+}
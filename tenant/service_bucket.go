@@ -94,6 +94,9 @@ func (s *BucketSvc) FindBuckets(ctx context.Context, filter influxdb.BucketFilte
 		if err != nil {
 			return nil, 0, err
 		}
+		if !b.Annotations.Matches(filter.Annotations) {
+			return []*influxdb.Bucket{}, 0, nil
+		}
 		return []*influxdb.Bucket{b}, 1, nil
 	}
 	if filter.OrganizationID == nil && filter.Org != nil {
@@ -111,13 +114,17 @@ func (s *BucketSvc) FindBuckets(ctx context.Context, filter influxdb.BucketFilte
 			if err != nil {
 				return err
 			}
-			buckets = []*influxdb.Bucket{b}
+			buckets = []*influxdb.Bucket{}
+			if b.Annotations.Matches(filter.Annotations) {
+				buckets = append(buckets, b)
+			}
 			return nil
 		}
 
 		bs, err := s.store.ListBuckets(ctx, tx, BucketFilter{
 			Name:           filter.Name,
 			OrganizationID: filter.OrganizationID,
+			Annotations:    filter.Annotations,
 		}, opt...)
 		if err != nil {
 			return err
@@ -144,6 +151,10 @@ func (s *BucketSvc) CreateBucket(ctx context.Context, b *influxdb.Bucket) error
 		return err
 	}
 
+	if err := b.Annotations.Valid(); err != nil {
+		return err
+	}
+
 	// make sure the org exists
 	if _, err := s.svc.FindOrganizationByID(ctx, b.OrgID); err != nil {
 		return err
@@ -157,6 +168,12 @@ func (s *BucketSvc) CreateBucket(ctx context.Context, b *influxdb.Bucket) error
 // UpdateBucket updates a single bucket with changeset.
 // Returns the new bucket state after update.
 func (s *BucketSvc) UpdateBucket(ctx context.Context, id platform.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+	if upd.Annotations != nil {
+		if err := upd.Annotations.Valid(); err != nil {
+			return nil, err
+		}
+	}
+
 	var bucket *influxdb.Bucket
 	err := s.store.Update(ctx, func(tx kv.Tx) error {
 		b, err := s.store.UpdateBucket(ctx, tx, id, upd)
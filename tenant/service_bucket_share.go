@@ -0,0 +1,122 @@
+package tenant
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var _ influxdb.BucketShareService = (*BucketShareSvc)(nil)
+
+// BucketShareSvc shares buckets read-only with other organizations.
+type BucketShareSvc struct {
+	store *Store
+	svc   *Service
+	now   func() time.Time
+}
+
+func NewBucketShareSvc(st *Store, svc *Service) *BucketShareSvc {
+	return &BucketShareSvc{
+		store: st,
+		svc:   svc,
+		now:   time.Now,
+	}
+}
+
+// CreateBucketShare shares the bucket with the target organization. The
+// organization owning the bucket is set on the share.
+func (s *BucketShareSvc) CreateBucketShare(ctx context.Context, sh *influxdb.BucketShare) error {
+	b, err := s.svc.FindBucketByID(ctx, sh.BucketID)
+	if err != nil {
+		return err
+	}
+	if b.Type == influxdb.BucketTypeSystem {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "system buckets cannot be shared",
+		}
+	}
+	sh.OrgID = b.OrgID
+
+	if sh.TargetOrgID == sh.OrgID {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "bucket cannot be shared with the organization owning it",
+		}
+	}
+	if _, err := s.svc.FindOrganizationByID(ctx, sh.TargetOrgID); err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	if sh.ExpiresAt != nil && !now.Before(*sh.ExpiresAt) {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "bucket share expiration must be in the future",
+		}
+	}
+
+	if userID, err := icontext.GetUserID(ctx); err == nil {
+		sh.SharedBy = userID
+	}
+
+	sh.ID = 0
+	sh.SetCreatedAt(now)
+	sh.SetUpdatedAt(now)
+
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		existing, err := s.store.ListBucketShares(ctx, tx, influxdb.BucketShareFilter{
+			TargetOrgID: &sh.TargetOrgID,
+			BucketID:    &sh.BucketID,
+		})
+		if err != nil {
+			return err
+		}
+		for _, e := range existing {
+			if e.Active(now) {
+				return ErrBucketShareExists
+			}
+		}
+		return s.store.CreateBucketShare(ctx, tx, sh)
+	})
+}
+
+// FindBucketShareByID returns the bucket share with the id.
+func (s *BucketShareSvc) FindBucketShareByID(ctx context.Context, id platform.ID) (*influxdb.BucketShare, error) {
+	var share *influxdb.BucketShare
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		sh, err := s.store.GetBucketShare(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		share = sh
+		return nil
+	})
+	return share, err
+}
+
+// FindBucketShares returns the bucket shares matching the filter.
+func (s *BucketShareSvc) FindBucketShares(ctx context.Context, filter influxdb.BucketShareFilter) ([]*influxdb.BucketShare, error) {
+	var shares []*influxdb.BucketShare
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		ss, err := s.store.ListBucketShares(ctx, tx, filter)
+		if err != nil {
+			return err
+		}
+		shares = ss
+		return nil
+	})
+	return shares, err
+}
+
+// DeleteBucketShare revokes the bucket share.
+func (s *BucketShareSvc) DeleteBucketShare(ctx context.Context, id platform.ID) error {
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		return s.store.DeleteBucketShare(ctx, tx, id)
+	})
+}
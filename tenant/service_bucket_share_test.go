@@ -0,0 +1,55 @@
+package tenant_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/tenant"
+	influxdbtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketShareService(t *testing.T) {
+	ctx := context.Background()
+	storage := tenant.NewStore(influxdbtesting.NewTestInmemStore(t))
+	ten := tenant.NewService(storage)
+	svc := tenant.NewBucketShareSvc(storage, ten)
+
+	owner := &influxdb.Organization{Name: "owner"}
+	require.NoError(t, ten.CreateOrganization(ctx, owner))
+	target := &influxdb.Organization{Name: "target"}
+	require.NoError(t, ten.CreateOrganization(ctx, target))
+	bucket := &influxdb.Bucket{OrgID: owner.ID, Name: "shared"}
+	require.NoError(t, ten.CreateBucket(ctx, bucket))
+
+	share := &influxdb.BucketShare{BucketID: bucket.ID, TargetOrgID: target.ID}
+	require.NoError(t, svc.CreateBucketShare(ctx, share))
+	assert.Equal(t, owner.ID, share.OrgID)
+	assert.True(t, share.ID.Valid())
+
+	// the share is visible to both organizations
+	for _, org := range []*influxdb.Organization{owner, target} {
+		shares, err := svc.FindBucketShares(ctx, influxdb.BucketShareFilter{OrgID: &org.ID})
+		require.NoError(t, err)
+		require.Len(t, shares, 1)
+		assert.Equal(t, share.ID, shares[0].ID)
+	}
+
+	err := svc.CreateBucketShare(ctx, &influxdb.BucketShare{BucketID: bucket.ID, TargetOrgID: target.ID})
+	assert.Equal(t, errors.EConflict, errors.ErrorCode(err))
+
+	err = svc.CreateBucketShare(ctx, &influxdb.BucketShare{BucketID: bucket.ID, TargetOrgID: owner.ID})
+	assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	past := time.Now().Add(-time.Hour)
+	err = svc.CreateBucketShare(ctx, &influxdb.BucketShare{BucketID: bucket.ID, TargetOrgID: target.ID, ExpiresAt: &past})
+	assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	require.NoError(t, svc.DeleteBucketShare(ctx, share.ID))
+	_, err = svc.FindBucketShareByID(ctx, share.ID)
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
@@ -0,0 +1,271 @@
+package tenant
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/mail"
+	"net/url"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"go.uber.org/zap"
+)
+
+var _ influxdb.InviteService = (*InviteSvc)(nil)
+
+// InviteSvc invites users to organizations by email. Invited users sign up
+// with a tokenized link and are granted the invite's role in the organization,
+// so no admin has to set their initial password.
+type InviteSvc struct {
+	store *Store
+	svc   *Service
+
+	mailer     influxdb.InviteMailer
+	signupURL  string
+	expiration time.Duration
+	now        func() time.Time
+	log        *zap.Logger
+}
+
+type InviteServiceOptionFn func(*InviteSvc)
+
+// WithInviteMailer sets the mailer sending invitation emails. Without a
+// mailer invites are only created, and the returned link has to be passed
+// on to the invited person.
+func WithInviteMailer(m influxdb.InviteMailer) InviteServiceOptionFn {
+	return func(s *InviteSvc) {
+		s.mailer = m
+	}
+}
+
+// WithInviteSignupURL sets the URL of the signup page invitation links point
+// to. The invite token is added as the token query parameter.
+func WithInviteSignupURL(u string) InviteServiceOptionFn {
+	return func(s *InviteSvc) {
+		s.signupURL = u
+	}
+}
+
+// WithInviteExpiration sets how long invites can be accepted.
+func WithInviteExpiration(d time.Duration) InviteServiceOptionFn {
+	return func(s *InviteSvc) {
+		if d > 0 {
+			s.expiration = d
+		}
+	}
+}
+
+func WithInviteLogger(logger *zap.Logger) InviteServiceOptionFn {
+	return func(s *InviteSvc) {
+		s.log = logger
+	}
+}
+
+func NewInviteSvc(st *Store, svc *Service, opts ...InviteServiceOptionFn) *InviteSvc {
+	s := &InviteSvc{
+		store:      st,
+		svc:        svc,
+		signupURL:  "/signup",
+		expiration: influxdb.DefaultInviteExpiration,
+		now:        time.Now,
+		log:        zap.NewNop(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func newInviteToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (s *InviteSvc) link(token string) (string, error) {
+	u, err := url.Parse(s.signupURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// CreateInvite creates the invite and emails the invitation link.
+func (s *InviteSvc) CreateInvite(ctx context.Context, i *influxdb.Invite) (string, error) {
+	if i.Email == "" {
+		return "", ErrInviteEmailRequired
+	}
+	addr, err := mail.ParseAddress(i.Email)
+	if err != nil {
+		return "", &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invite email is invalid",
+			Err:  err,
+		}
+	}
+	i.Email = addr.Address
+
+	if i.Role == "" {
+		i.Role = influxdb.Member
+	}
+	if err := i.Role.Valid(); err != nil {
+		return "", &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		}
+	}
+
+	org, err := s.svc.FindOrganizationByID(ctx, i.OrgID)
+	if err != nil {
+		return "", err
+	}
+
+	if userID, err := icontext.GetUserID(ctx); err == nil {
+		i.InvitedBy = userID
+	}
+
+	token, err := newInviteToken()
+	if err != nil {
+		return "", ErrInternalServiceError(err)
+	}
+	link, err := s.link(token)
+	if err != nil {
+		return "", ErrInternalServiceError(err)
+	}
+
+	now := s.now().UTC()
+	i.ID = 0
+	i.ExpiresAt = now.Add(s.expiration)
+	i.SetCreatedAt(now)
+	i.SetUpdatedAt(now)
+
+	if err := s.store.Update(ctx, func(tx kv.Tx) error {
+		return s.store.CreateInvite(ctx, tx, i, token)
+	}); err != nil {
+		return "", err
+	}
+
+	if s.mailer == nil {
+		return link, nil
+	}
+
+	if err := s.mailer.SendInvite(ctx, i, org, link); err != nil {
+		// an invite nobody received cannot be accepted, remove it
+		if cleanupErr := s.DeleteInvite(ctx, i.ID); cleanupErr != nil {
+			s.log.Error(
+				"couldn't clean up invite after failing to send it",
+				zap.String("invite_id", i.ID.String()),
+				zap.Error(cleanupErr),
+			)
+		}
+		return "", ErrSendInvite(err)
+	}
+
+	return link, nil
+}
+
+// FindInviteByID returns the invite with the id.
+func (s *InviteSvc) FindInviteByID(ctx context.Context, id platform.ID) (*influxdb.Invite, error) {
+	var invite *influxdb.Invite
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		i, err := s.store.GetInvite(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		invite = i
+		return nil
+	})
+	return invite, err
+}
+
+// FindInvites returns the invites matching the filter.
+func (s *InviteSvc) FindInvites(ctx context.Context, filter influxdb.InviteFilter) ([]*influxdb.Invite, error) {
+	var invites []*influxdb.Invite
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		is, err := s.store.ListInvites(ctx, tx, filter)
+		if err != nil {
+			return err
+		}
+		invites = is
+		return nil
+	})
+	return invites, err
+}
+
+// DeleteInvite revokes the invite.
+func (s *InviteSvc) DeleteInvite(ctx context.Context, id platform.ID) error {
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		return s.store.DeleteInvite(ctx, tx, id)
+	})
+}
+
+// AcceptInvite signs up the invited user and adds them to the organization.
+func (s *InviteSvc) AcceptInvite(ctx context.Context, req influxdb.AcceptInviteRequest) (*influxdb.User, error) {
+	if req.Username == "" {
+		return nil, &errors.Error{
+			Code: errors.EEmptyValue,
+			Msg:  "username is empty",
+		}
+	}
+	if len(req.Password) < MinPasswordLen {
+		return nil, EShortPassword
+	}
+
+	passHash, err := encryptPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	// The invite is consumed in the transaction creating the user, so that
+	// it is accepted at most once.
+	user := &influxdb.User{
+		Name:   req.Username,
+		Status: influxdb.Active,
+	}
+	err = s.store.Update(ctx, func(tx kv.Tx) error {
+		invite, err := s.store.GetInviteByToken(ctx, tx, req.Token)
+		if errors.ErrorCode(err) == errors.ENotFound {
+			return ErrInvalidInviteToken
+		}
+		if err != nil {
+			return err
+		}
+		if !s.now().Before(invite.ExpiresAt) {
+			return ErrInvalidInviteToken
+		}
+		if err := s.store.DeleteInvite(ctx, tx, invite.ID); err != nil {
+			return err
+		}
+
+		if err := s.store.CreateUser(ctx, tx, user); err != nil {
+			return err
+		}
+		if err := s.store.SetPassword(ctx, tx, user.ID, passHash); err != nil {
+			return err
+		}
+		return s.store.CreateURM(ctx, tx, &influxdb.UserResourceMapping{
+			ResourceType: influxdb.OrgsResourceType,
+			ResourceID:   invite.OrgID,
+			UserID:       user.ID,
+			UserType:     invite.Role,
+			MappingType:  influxdb.UserMappingType,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,163 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/tenant"
+	influxdbtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeInviteMailer struct {
+	links []string
+	err   error
+}
+
+func (m *fakeInviteMailer) SendInvite(ctx context.Context, i *influxdb.Invite, org *influxdb.Organization, link string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.links = append(m.links, link)
+	return nil
+}
+
+func TestInviteService(t *testing.T) {
+	setup := func(t *testing.T, opts ...tenant.InviteServiceOptionFn) (*tenant.Service, *tenant.InviteSvc, *influxdb.Organization) {
+		t.Helper()
+
+		storage := tenant.NewStore(influxdbtesting.NewTestInmemStore(t))
+		ten := tenant.NewService(storage)
+
+		org := &influxdb.Organization{Name: "org1"}
+		require.NoError(t, ten.CreateOrganization(context.Background(), org))
+
+		return ten, tenant.NewInviteSvc(storage, ten, opts...), org
+	}
+
+	tokenOf := func(t *testing.T, link string) string {
+		t.Helper()
+		u, err := url.Parse(link)
+		require.NoError(t, err)
+		return u.Query().Get("token")
+	}
+
+	t.Run("invited users sign up with the invite's role", func(t *testing.T) {
+		mailer := &fakeInviteMailer{}
+		ten, svc, org := setup(t,
+			tenant.WithInviteMailer(mailer),
+			tenant.WithInviteSignupURL("https://influx.example.com/signup"),
+		)
+		ctx := context.Background()
+
+		invite := &influxdb.Invite{OrgID: org.ID, Email: "Jo <jo@example.com>", Role: influxdb.Owner}
+		link, err := svc.CreateInvite(ctx, invite)
+		require.NoError(t, err)
+		assert.Equal(t, []string{link}, mailer.links)
+		assert.True(t, strings.HasPrefix(link, "https://influx.example.com/signup?token="))
+		assert.Equal(t, "jo@example.com", invite.Email)
+
+		invites, err := svc.FindInvites(ctx, influxdb.InviteFilter{OrgID: org.ID})
+		require.NoError(t, err)
+		require.Len(t, invites, 1)
+		assert.Equal(t, invite.ID, invites[0].ID)
+
+		user, err := svc.AcceptInvite(ctx, influxdb.AcceptInviteRequest{
+			Token:    tokenOf(t, link),
+			Username: "jo",
+			Password: "password1",
+		})
+		require.NoError(t, err)
+		require.NoError(t, ten.ComparePassword(ctx, user.ID, "password1"))
+
+		urms, _, err := ten.FindUserResourceMappings(ctx, influxdb.UserResourceMappingFilter{UserID: user.ID})
+		require.NoError(t, err)
+		require.Len(t, urms, 1)
+		assert.Equal(t, org.ID, urms[0].ResourceID)
+		assert.Equal(t, influxdb.Owner, urms[0].UserType)
+
+		// invites are consumed
+		_, err = svc.AcceptInvite(ctx, influxdb.AcceptInviteRequest{
+			Token:    tokenOf(t, link),
+			Username: "jo2",
+			Password: "password1",
+		})
+		assert.Equal(t, tenant.ErrInvalidInviteToken, err)
+	})
+
+	t.Run("expired invites cannot be accepted", func(t *testing.T) {
+		_, svc, org := setup(t, tenant.WithInviteExpiration(time.Nanosecond))
+		ctx := context.Background()
+
+		link, err := svc.CreateInvite(ctx, &influxdb.Invite{OrgID: org.ID, Email: "jo@example.com"})
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+
+		_, err = svc.AcceptInvite(ctx, influxdb.AcceptInviteRequest{
+			Token:    tokenOf(t, link),
+			Username: "jo",
+			Password: "password1",
+		})
+		assert.Equal(t, tenant.ErrInvalidInviteToken, err)
+	})
+
+	t.Run("failed signups leave the invite unused", func(t *testing.T) {
+		ten, svc, org := setup(t)
+		ctx := context.Background()
+
+		require.NoError(t, ten.CreateUser(ctx, &influxdb.User{Name: "taken"}))
+		link, err := svc.CreateInvite(ctx, &influxdb.Invite{OrgID: org.ID, Email: "jo@example.com"})
+		require.NoError(t, err)
+
+		_, err = svc.AcceptInvite(ctx, influxdb.AcceptInviteRequest{
+			Token:    tokenOf(t, link),
+			Username: "taken",
+			Password: "password1",
+		})
+		require.Error(t, err)
+
+		_, err = svc.AcceptInvite(ctx, influxdb.AcceptInviteRequest{
+			Token:    tokenOf(t, link),
+			Username: "jo",
+			Password: "password1",
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("invites that cannot be sent are removed", func(t *testing.T) {
+		_, svc, org := setup(t, tenant.WithInviteMailer(&fakeInviteMailer{err: errors.New("connection refused")}))
+		ctx := context.Background()
+
+		_, err := svc.CreateInvite(ctx, &influxdb.Invite{OrgID: org.ID, Email: "jo@example.com"})
+		require.Error(t, err)
+
+		invites, err := svc.FindInvites(ctx, influxdb.InviteFilter{OrgID: org.ID})
+		require.NoError(t, err)
+		assert.Empty(t, invites)
+	})
+}
+
+func TestInviteTemplates(t *testing.T) {
+	templates := tenant.NewInviteTemplates()
+	require.NoError(t, templates.Add("de", `{{define "subject"}}Einladung zu {{.OrgName}}{{end}}{{define "body"}}{{.Link}}{{end}}`))
+
+	data := tenant.InviteEmail{OrgName: "org1", Link: "https://influx.example.com/signup?token=abc"}
+
+	subject, body, err := templates.Render("de-AT", data)
+	require.NoError(t, err)
+	assert.Equal(t, "Einladung zu org1", subject)
+	assert.Equal(t, data.Link, body)
+
+	subject, body, err = templates.Render("fr", data)
+	require.NoError(t, err)
+	assert.Equal(t, "You are invited to join org1 on InfluxDB", subject)
+	assert.Contains(t, body, data.Link)
+
+	assert.Error(t, templates.Add("es", `{{define "subject"}}Invitación{{end}}`), "templates must define a body")
+}
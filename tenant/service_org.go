@@ -120,6 +120,10 @@ func (s *OrgSvc) FindOrganizations(ctx context.Context, filter influxdb.Organiza
 
 // Creates a new organization and sets b.ID with the new identifier.
 func (s *OrgSvc) CreateOrganization(ctx context.Context, o *influxdb.Organization) error {
+	if err := influxdb.ValidTimezone(o.DefaultTimezone); err != nil {
+		return err
+	}
+
 	err := s.store.Update(ctx, func(tx kv.Tx) error {
 		return s.store.CreateOrg(ctx, tx, o)
 	})
@@ -128,11 +132,12 @@ func (s *OrgSvc) CreateOrganization(ctx context.Context, o *influxdb.Organizatio
 	}
 
 	tb := &influxdb.Bucket{
-		OrgID:           o.ID,
-		Type:            influxdb.BucketTypeSystem,
-		Name:            influxdb.TasksSystemBucketName,
-		RetentionPeriod: influxdb.TasksSystemBucketRetention,
-		Description:     "System bucket for task logs",
+		OrgID:              o.ID,
+		Type:               influxdb.BucketTypeSystem,
+		Name:               influxdb.TasksSystemBucketName,
+		RetentionPeriod:    s.store.tasksBucket.RetentionPeriod,
+		ShardGroupDuration: s.store.tasksBucket.ShardGroupDuration,
+		Description:        "System bucket for task logs",
 	}
 
 	if err := s.svc.CreateBucket(ctx, tb); err != nil {
@@ -140,11 +145,12 @@ func (s *OrgSvc) CreateOrganization(ctx context.Context, o *influxdb.Organizatio
 	}
 
 	mb := &influxdb.Bucket{
-		OrgID:           o.ID,
-		Type:            influxdb.BucketTypeSystem,
-		Name:            influxdb.MonitoringSystemBucketName,
-		RetentionPeriod: influxdb.MonitoringSystemBucketRetention,
-		Description:     "System bucket for monitoring logs",
+		OrgID:              o.ID,
+		Type:               influxdb.BucketTypeSystem,
+		Name:               influxdb.MonitoringSystemBucketName,
+		RetentionPeriod:    s.store.monitoringBucket.RetentionPeriod,
+		ShardGroupDuration: s.store.monitoringBucket.ShardGroupDuration,
+		Description:        "System bucket for monitoring logs",
 	}
 
 	if err := s.svc.CreateBucket(ctx, mb); err != nil {
@@ -172,6 +178,12 @@ func (s *OrgSvc) CreateOrganization(ctx context.Context, o *influxdb.Organizatio
 // Updates a single organization with changeset.
 // Returns the new organization state after update.
 func (s *OrgSvc) UpdateOrganization(ctx context.Context, id platform.ID, upd influxdb.OrganizationUpdate) (*influxdb.Organization, error) {
+	if upd.DefaultTimezone != nil {
+		if err := influxdb.ValidTimezone(*upd.DefaultTimezone); err != nil {
+			return nil, err
+		}
+	}
+
 	var org *influxdb.Organization
 	err := s.store.Update(ctx, func(tx kv.Tx) error {
 		o, err := s.store.UpdateOrg(ctx, tx, id, upd)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
@@ -23,11 +24,37 @@ type Store struct {
 
 	now func() time.Time
 
-	urmByUserIndex *kv.Index
+	urmByUserIndex   *kv.Index
+	bucketByOrgIndex *kv.Index
+
+	tasksBucket      SystemBucketConfig
+	monitoringBucket SystemBucketConfig
+}
+
+// SystemBucketConfig configures a system bucket created with every
+// organization. A zero ShardGroupDuration leaves it to the storage engine.
+type SystemBucketConfig struct {
+	RetentionPeriod    time.Duration
+	ShardGroupDuration time.Duration
 }
 
 type StoreOption func(*Store)
 
+// WithTasksBucketConfig configures the _tasks bucket of new organizations.
+func WithTasksBucketConfig(c SystemBucketConfig) StoreOption {
+	return func(s *Store) {
+		s.tasksBucket = c
+	}
+}
+
+// WithMonitoringBucketConfig configures the _monitoring bucket of new
+// organizations.
+func WithMonitoringBucketConfig(c SystemBucketConfig) StoreOption {
+	return func(s *Store) {
+		s.monitoringBucket = c
+	}
+}
+
 func NewStore(kvStore kv.Store, opts ...StoreOption) *Store {
 	store := &Store{
 		kvStore:     kvStore,
@@ -37,7 +64,14 @@ func NewStore(kvStore kv.Store, opts ...StoreOption) *Store {
 		now: func() time.Time {
 			return time.Now().UTC()
 		},
-		urmByUserIndex: kv.NewIndex(index.URMByUserIndexMapping, kv.WithIndexReadPathEnabled),
+		urmByUserIndex:   kv.NewIndex(index.URMByUserIndexMapping, kv.WithIndexReadPathEnabled),
+		bucketByOrgIndex: kv.NewIndex(index.BucketByOrgIndexMapping, kv.WithIndexReadPathEnabled),
+		tasksBucket: SystemBucketConfig{
+			RetentionPeriod: influxdb.TasksSystemBucketRetention,
+		},
+		monitoringBucket: SystemBucketConfig{
+			RetentionPeriod: influxdb.MonitoringSystemBucketRetention,
+		},
 	}
 
 	for _, opt := range opts {
@@ -8,6 +8,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/tenant/index"
 )
 
 var (
@@ -138,6 +139,7 @@ func (s *Store) GetBucketByName(ctx context.Context, tx kv.Tx, orgID platform.ID
 type BucketFilter struct {
 	Name           *string
 	OrganizationID *platform.ID
+	Annotations    influxdb.ResourceAnnotations
 }
 
 func (s *Store) ListBuckets(ctx context.Context, tx kv.Tx, filter BucketFilter, opt ...influxdb.FindOptions) ([]*influxdb.Bucket, error) {
@@ -159,7 +161,7 @@ func (s *Store) ListBuckets(ctx context.Context, tx kv.Tx, filter BucketFilter,
 
 	// if an organization is passed we need to use the index
 	if filter.OrganizationID != nil {
-		return s.listBucketsByOrg(ctx, tx, *filter.OrganizationID, o)
+		return s.listBucketsByOrg(ctx, tx, *filter.OrganizationID, filter.Annotations, o)
 	}
 
 	b, err := tx.Bucket(bucketBucket)
@@ -200,7 +202,7 @@ func (s *Store) ListBuckets(ctx context.Context, tx kv.Tx, filter BucketFilter,
 		}
 
 		// check to see if it matches the filter
-		if filter.Name == nil || (*filter.Name == b.Name) {
+		if (filter.Name == nil || *filter.Name == b.Name) && b.Annotations.Matches(filter.Annotations) {
 			bs = append(bs, b)
 		}
 
@@ -212,7 +214,7 @@ func (s *Store) ListBuckets(ctx context.Context, tx kv.Tx, filter BucketFilter,
 	return bs, cursor.Err()
 }
 
-func (s *Store) listBucketsByOrg(ctx context.Context, tx kv.Tx, orgID platform.ID, o influxdb.FindOptions) ([]*influxdb.Bucket, error) {
+func (s *Store) listBucketsByOrg(ctx context.Context, tx kv.Tx, orgID platform.ID, annotations influxdb.ResourceAnnotations, o influxdb.FindOptions) ([]*influxdb.Bucket, error) {
 	// get the prefix key (org id with an empty name)
 	key, err := bucketIndexKey(orgID, "")
 	if err != nil {
@@ -227,6 +229,10 @@ func (s *Store) listBucketsByOrg(ctx context.Context, tx kv.Tx, orgID platform.I
 		return nil, err
 	}
 
+	if o.Cursor {
+		return s.listBucketsByOrgID(ctx, tx, orgID, annotations, o)
+	}
+
 	start := key
 	opts := []kv.CursorOption{kv.WithCursorPrefix(key)}
 	if o.Descending {
@@ -287,6 +293,9 @@ func (s *Store) listBucketsByOrg(ctx context.Context, tx kv.Tx, orgID platform.I
 			return nil, err
 		}
 
+		if !b.Annotations.Matches(annotations) {
+			continue
+		}
 		bs = append(bs, b)
 
 		if len(bs) >= o.Limit {
@@ -297,6 +306,68 @@ func (s *Store) listBucketsByOrg(ctx context.Context, tx kv.Tx, orgID platform.I
 	return bs, cursor.Err()
 }
 
+// listBucketsByOrgID lists the buckets of the org ordered by ID for cursor
+// pagination, seeking to the bucket after the cursor in the index of
+// buckets by org ID.
+func (s *Store) listBucketsByOrgID(ctx context.Context, tx kv.Tx, orgID platform.ID, annotations influxdb.ResourceAnnotations, o influxdb.FindOptions) ([]*influxdb.Bucket, error) {
+	orgKey, err := orgID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		}
+	}
+
+	idx, err := tx.Bucket(index.BucketByOrgIndexMapping.IndexBucket())
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := kv.IndexKey(orgKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	seek := prefix
+	if o.After != nil {
+		after, err := (*o.After + 1).Encode()
+		if err != nil {
+			return nil, err
+		}
+		if seek, err = kv.IndexKey(orgKey, after); err != nil {
+			return nil, err
+		}
+	}
+
+	cursor, err := idx.ForwardCursor(seek, kv.WithCursorPrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	bs := []*influxdb.Bucket{}
+	for k, v := cursor.Next(); k != nil; k, v = cursor.Next() {
+		var id platform.ID
+		if err := id.Decode(v); err != nil {
+			return nil, &errors.Error{
+				Err: err,
+			}
+		}
+		b, err := s.GetBucket(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !b.Annotations.Matches(annotations) {
+			continue
+		}
+		bs = append(bs, b)
+
+		if len(bs) >= o.Limit {
+			break
+		}
+	}
+	return bs, cursor.Err()
+}
+
 func (s *Store) CreateBucket(ctx context.Context, tx kv.Tx, bucket *influxdb.Bucket) (err error) {
 	// generate new bucket ID
 	bucket.ID, err = s.generateSafeID(ctx, tx, bucketBucket, s.BucketIDGen)
@@ -339,6 +410,14 @@ func (s *Store) CreateBucket(ctx context.Context, tx kv.Tx, bucket *influxdb.Buc
 		return ErrInternalServiceError(err)
 	}
 
+	orgKey, err := bucket.OrgID.Encode()
+	if err != nil {
+		return InvalidOrgIDError(err)
+	}
+	if err := s.bucketByOrgIndex.Insert(tx, orgKey, encodedID); err != nil {
+		return ErrInternalServiceError(err)
+	}
+
 	if err := b.Put(encodedID, v); err != nil {
 		return ErrInternalServiceError(err)
 	}
@@ -407,6 +486,19 @@ func (s *Store) UpdateBucket(ctx context.Context, tx kv.Tx, id platform.ID, upd
 	if upd.ShardGroupDuration != nil {
 		bucket.ShardGroupDuration = *upd.ShardGroupDuration
 	}
+	if upd.SeriesTTL != nil {
+		bucket.SeriesTTL = *upd.SeriesTTL
+	}
+	if upd.Limits != nil {
+		bucket.Limits = nil
+		if !upd.Limits.IsZero() {
+			limits := *upd.Limits
+			bucket.Limits = &limits
+		}
+	}
+	if upd.Annotations != nil {
+		bucket.Annotations = upd.Annotations.Clone()
+	}
 
 	v, err := marshalBucket(bucket)
 	if err != nil {
@@ -448,6 +540,14 @@ func (s *Store) DeleteBucket(ctx context.Context, tx kv.Tx, id platform.ID) erro
 		return ErrInternalServiceError(err)
 	}
 
+	orgKey, err := bucket.OrgID.Encode()
+	if err != nil {
+		return InvalidOrgIDError(err)
+	}
+	if err := s.bucketByOrgIndex.Delete(tx, orgKey, encodedID); err != nil {
+		return ErrInternalServiceError(err)
+	}
+
 	b, err := tx.Bucket(bucketBucket)
 	if err != nil {
 		return err
@@ -457,5 +557,5 @@ func (s *Store) DeleteBucket(ctx context.Context, tx kv.Tx, id platform.ID) erro
 		return ErrInternalServiceError(err)
 	}
 
-	return nil
+	return s.DeleteBucketActivity(ctx, tx, id)
 }
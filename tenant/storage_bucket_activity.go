@@ -0,0 +1,94 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var bucketActivityBucket = []byte("bucketactivityv1")
+
+type bucketActivity struct {
+	LastWriteAt time.Time `json:"lastWriteAt"`
+	LastQueryAt time.Time `json:"lastQueryAt"`
+}
+
+// GetBucketActivity returns the persisted activity of the bucket, or nil if
+// none was persisted.
+func (s *Store) GetBucketActivity(ctx context.Context, tx kv.Tx, id platform.ID) (*influxdb.BucketActivity, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, InvalidBucketIDError(err)
+	}
+
+	b, err := tx.Bucket(bucketActivityBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, ErrInternalServiceError(err)
+	}
+
+	var a bucketActivity
+	if err := json.Unmarshal(v, &a); err != nil {
+		return nil, ErrInternalServiceError(err)
+	}
+
+	return &influxdb.BucketActivity{
+		LastWriteAt: a.LastWriteAt,
+		LastQueryAt: a.LastQueryAt,
+	}, nil
+}
+
+// PutBucketActivity persists the activity of the bucket.
+func (s *Store) PutBucketActivity(ctx context.Context, tx kv.Tx, id platform.ID, activity influxdb.BucketActivity) error {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return InvalidBucketIDError(err)
+	}
+
+	v, err := json.Marshal(bucketActivity{
+		LastWriteAt: activity.LastWriteAt,
+		LastQueryAt: activity.LastQueryAt,
+	})
+	if err != nil {
+		return ErrInternalServiceError(err)
+	}
+
+	b, err := tx.Bucket(bucketActivityBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return ErrInternalServiceError(err)
+	}
+	return nil
+}
+
+// DeleteBucketActivity removes the persisted activity of the bucket.
+func (s *Store) DeleteBucketActivity(ctx context.Context, tx kv.Tx, id platform.ID) error {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return InvalidBucketIDError(err)
+	}
+
+	b, err := tx.Bucket(bucketActivityBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(encodedID); err != nil {
+		return ErrInternalServiceError(err)
+	}
+	return nil
+}
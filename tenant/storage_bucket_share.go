@@ -0,0 +1,126 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var bucketShareBucket = []byte("bucketsharesv1")
+
+func unmarshalBucketShare(v []byte) (*influxdb.BucketShare, error) {
+	s := &influxdb.BucketShare{}
+	if err := json.Unmarshal(v, s); err != nil {
+		return nil, ErrCorruptBucketShare(err)
+	}
+	return s, nil
+}
+
+// GetBucketShare returns the bucket share with the id.
+func (s *Store) GetBucketShare(ctx context.Context, tx kv.Tx, id platform.ID) (*influxdb.BucketShare, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, InvalidBucketShareIDError(err)
+	}
+
+	b, err := tx.Bucket(bucketShareBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return nil, ErrBucketShareNotFound
+	}
+	if err != nil {
+		return nil, ErrInternalServiceError(err)
+	}
+
+	return unmarshalBucketShare(v)
+}
+
+// ListBucketShares returns the bucket shares matching the filter.
+func (s *Store) ListBucketShares(ctx context.Context, tx kv.Tx, filter influxdb.BucketShareFilter) ([]*influxdb.BucketShare, error) {
+	b, err := tx.Bucket(bucketShareBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := b.ForwardCursor(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	shares := []*influxdb.BucketShare{}
+	for k, v := cursor.Next(); k != nil; k, v = cursor.Next() {
+		sh, err := unmarshalBucketShare(v)
+		if err != nil {
+			continue
+		}
+		if filter.OrgID != nil && sh.OrgID != *filter.OrgID && sh.TargetOrgID != *filter.OrgID {
+			continue
+		}
+		if filter.TargetOrgID != nil && sh.TargetOrgID != *filter.TargetOrgID {
+			continue
+		}
+		if filter.BucketID != nil && sh.BucketID != *filter.BucketID {
+			continue
+		}
+		shares = append(shares, sh)
+	}
+
+	return shares, cursor.Err()
+}
+
+// CreateBucketShare stores the bucket share.
+func (s *Store) CreateBucketShare(ctx context.Context, tx kv.Tx, sh *influxdb.BucketShare) error {
+	if !sh.ID.Valid() {
+		sh.ID = s.IDGen.ID()
+	}
+
+	encodedID, err := sh.ID.Encode()
+	if err != nil {
+		return InvalidBucketShareIDError(err)
+	}
+
+	v, err := json.Marshal(sh)
+	if err != nil {
+		return ErrInternalServiceError(err)
+	}
+
+	b, err := tx.Bucket(bucketShareBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return ErrInternalServiceError(err)
+	}
+	return nil
+}
+
+// DeleteBucketShare removes the bucket share.
+func (s *Store) DeleteBucketShare(ctx context.Context, tx kv.Tx, id platform.ID) error {
+	if _, err := s.GetBucketShare(ctx, tx, id); err != nil {
+		return err
+	}
+
+	encodedID, err := id.Encode()
+	if err != nil {
+		return InvalidBucketShareIDError(err)
+	}
+
+	b, err := tx.Bucket(bucketShareBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(encodedID); err != nil {
+		return ErrInternalServiceError(err)
+	}
+	return nil
+}
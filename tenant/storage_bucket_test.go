@@ -298,6 +298,40 @@ func TestBucket(t *testing.T) {
 				assert.Equal(t, allInOrg[3:4], offsetBuckets)
 			},
 		},
+		{
+			name:  "list in org by cursor",
+			setup: simpleSetup,
+			update: func(t *testing.T, store *tenant.Store, tx kv.Tx) {
+				require.NoError(t, store.DeleteBucket(context.Background(), tx, fourthBucketID))
+			},
+			results: func(t *testing.T, store *tenant.Store, tx kv.Tx) {
+				allBuckets := testBuckets(10, withCrudLog)
+				orgID := secondOrgID
+				// ordered by ID, bucket 4 is deleted
+				allInOrg := []*influxdb.Bucket{
+					allBuckets[1],
+					allBuckets[5],
+					allBuckets[7],
+					allBuckets[9],
+				}
+
+				buckets, err := store.ListBuckets(
+					context.Background(), tx,
+					tenant.BucketFilter{OrganizationID: &orgID},
+					influxdb.FindOptions{Limit: 3, Cursor: true},
+				)
+				require.NoError(t, err)
+				assert.Equal(t, allInOrg[:3], buckets)
+
+				buckets, err = store.ListBuckets(
+					context.Background(), tx,
+					tenant.BucketFilter{OrganizationID: &orgID},
+					influxdb.FindOptions{After: &allInOrg[2].ID, Limit: 3, Cursor: true},
+				)
+				require.NoError(t, err)
+				assert.Equal(t, allInOrg[3:], buckets)
+			},
+		},
 		{
 			name:  "update",
 			setup: simpleSetup,
@@ -328,6 +362,43 @@ func TestBucket(t *testing.T) {
 				assert.Equal(t, expected, buckets)
 			},
 		},
+		{
+			name:  "list by annotations",
+			setup: simpleSetup,
+			update: func(t *testing.T, store *tenant.Store, tx kv.Tx) {
+				payments := influxdb.ResourceAnnotations{"team": "payments", "cost-center": "123"}
+				_, err := store.UpdateBucket(context.Background(), tx, thirdBucketID, influxdb.BucketUpdate{Annotations: &payments})
+				require.NoError(t, err)
+
+				search := influxdb.ResourceAnnotations{"team": "search"}
+				_, err = store.UpdateBucket(context.Background(), tx, fourthBucketID, influxdb.BucketUpdate{Annotations: &search})
+				require.NoError(t, err)
+			},
+			results: func(t *testing.T, store *tenant.Store, tx kv.Tx) {
+				expected := testBuckets(10, withCrudLog)
+				expected[2].Annotations = influxdb.ResourceAnnotations{"team": "payments", "cost-center": "123"}
+
+				buckets, err := store.ListBuckets(context.Background(), tx, tenant.BucketFilter{
+					Annotations: influxdb.ResourceAnnotations{"team": "payments"},
+				})
+				require.NoError(t, err)
+				assert.Equal(t, expected[2:3], buckets)
+
+				orgID := firstOrgID
+				buckets, err = store.ListBuckets(context.Background(), tx, tenant.BucketFilter{
+					OrganizationID: &orgID,
+					Annotations:    influxdb.ResourceAnnotations{"cost-center": "123"},
+				})
+				require.NoError(t, err)
+				assert.Equal(t, expected[2:3], buckets)
+
+				buckets, err = store.ListBuckets(context.Background(), tx, tenant.BucketFilter{
+					Annotations: influxdb.ResourceAnnotations{"team": "payments", "cost-center": "456"},
+				})
+				require.NoError(t, err)
+				assert.Empty(t, buckets)
+			},
+		},
 		{
 			name:  "delete",
 			setup: simpleSetup,
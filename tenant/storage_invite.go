@@ -0,0 +1,187 @@
+package tenant
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var (
+	inviteBucket     = []byte("invitesv1")
+	inviteTokenIndex = []byte("invitetokenindexv1")
+)
+
+// storedInvite is the persisted invite. Only the hash of the token is
+// stored, the token itself is only part of the invitation link.
+type storedInvite struct {
+	influxdb.Invite
+	TokenHash string `json:"tokenHash"`
+}
+
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func unmarshalInvite(v []byte) (*storedInvite, error) {
+	i := &storedInvite{}
+	if err := json.Unmarshal(v, i); err != nil {
+		return nil, ErrCorruptInvite(err)
+	}
+	return i, nil
+}
+
+func (s *Store) getInvite(ctx context.Context, tx kv.Tx, id platform.ID) (*storedInvite, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, InvalidInviteIDError(err)
+	}
+
+	b, err := tx.Bucket(inviteBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return nil, ErrInviteNotFound
+	}
+	if err != nil {
+		return nil, ErrInternalServiceError(err)
+	}
+
+	return unmarshalInvite(v)
+}
+
+// GetInvite returns the invite with the id.
+func (s *Store) GetInvite(ctx context.Context, tx kv.Tx, id platform.ID) (*influxdb.Invite, error) {
+	i, err := s.getInvite(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &i.Invite, nil
+}
+
+// GetInviteByToken returns the invite the token was issued for.
+func (s *Store) GetInviteByToken(ctx context.Context, tx kv.Tx, token string) (*influxdb.Invite, error) {
+	idx, err := tx.Bucket(inviteTokenIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := idx.Get([]byte(hashInviteToken(token)))
+	if kv.IsNotFound(err) {
+		return nil, ErrInviteNotFound
+	}
+	if err != nil {
+		return nil, ErrInternalServiceError(err)
+	}
+
+	var id platform.ID
+	if err := id.Decode(v); err != nil {
+		return nil, platform.ErrCorruptID(err)
+	}
+	return s.GetInvite(ctx, tx, id)
+}
+
+// ListInvites returns the invites matching the filter.
+func (s *Store) ListInvites(ctx context.Context, tx kv.Tx, filter influxdb.InviteFilter) ([]*influxdb.Invite, error) {
+	b, err := tx.Bucket(inviteBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := b.ForwardCursor(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	invites := []*influxdb.Invite{}
+	for k, v := cursor.Next(); k != nil; k, v = cursor.Next() {
+		i, err := unmarshalInvite(v)
+		if err != nil {
+			continue
+		}
+		if filter.OrgID.Valid() && i.OrgID != filter.OrgID {
+			continue
+		}
+		invites = append(invites, &i.Invite)
+	}
+
+	return invites, cursor.Err()
+}
+
+// CreateInvite stores the invite, indexed by the hash of the token.
+func (s *Store) CreateInvite(ctx context.Context, tx kv.Tx, i *influxdb.Invite, token string) error {
+	if !i.ID.Valid() {
+		i.ID = s.IDGen.ID()
+	}
+
+	encodedID, err := i.ID.Encode()
+	if err != nil {
+		return InvalidInviteIDError(err)
+	}
+
+	stored := storedInvite{Invite: *i, TokenHash: hashInviteToken(token)}
+	v, err := json.Marshal(stored)
+	if err != nil {
+		return ErrInternalServiceError(err)
+	}
+
+	idx, err := tx.Bucket(inviteTokenIndex)
+	if err != nil {
+		return err
+	}
+
+	b, err := tx.Bucket(inviteBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Put([]byte(stored.TokenHash), encodedID); err != nil {
+		return ErrInternalServiceError(err)
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return ErrInternalServiceError(err)
+	}
+	return nil
+}
+
+// DeleteInvite removes the invite and its token.
+func (s *Store) DeleteInvite(ctx context.Context, tx kv.Tx, id platform.ID) error {
+	i, err := s.getInvite(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	encodedID, err := id.Encode()
+	if err != nil {
+		return InvalidInviteIDError(err)
+	}
+
+	idx, err := tx.Bucket(inviteTokenIndex)
+	if err != nil {
+		return err
+	}
+
+	b, err := tx.Bucket(inviteBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Delete([]byte(i.TokenHash)); err != nil {
+		return ErrInternalServiceError(err)
+	}
+
+	if err := b.Delete(encodedID); err != nil {
+		return ErrInternalServiceError(err)
+	}
+	return nil
+}
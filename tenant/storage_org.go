@@ -236,6 +236,10 @@ func (s *Store) UpdateOrg(ctx context.Context, tx kv.Tx, id platform.ID, upd inf
 		u.Description = *upd.Description
 	}
 
+	if upd.DefaultTimezone != nil {
+		u.DefaultTimezone = *upd.DefaultTimezone
+	}
+
 	v, err := marshalOrg(u)
 	if err != nil {
 		return nil, err
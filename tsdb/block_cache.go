@@ -0,0 +1,194 @@
+package tsdb
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// BlockCacheKey identifies a block of a series in a TSM file by the file and
+// the offset of the block in it.
+type BlockCacheKey struct {
+	File   uint64
+	Offset int64
+}
+
+// BlockCache keeps recently decoded TSM blocks in memory, so that repeated
+// reads of the same series, such as those of dashboards refreshing every few
+// seconds, do not decode the same blocks again. The cache is shared by the
+// shards of a store and evicts the least recently used blocks once it holds
+// more than its maximum size. A nil cache caches nothing.
+type BlockCache struct {
+	mu      sync.Mutex
+	maxSize uint64
+	size    uint64
+	lru     *list.List
+	entries map[BlockCacheKey]*list.Element
+}
+
+type blockCacheEntry struct {
+	key   BlockCacheKey
+	value interface{}
+	size  uint64
+}
+
+// NewBlockCache returns a cache holding decoded blocks of at most maxSize
+// bytes.
+func NewBlockCache(maxSize uint64) *BlockCache {
+	return &BlockCache{
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: make(map[BlockCacheKey]*list.Element),
+	}
+}
+
+// Get returns the decoded block cached for the key. The block must not be
+// modified.
+func (c *BlockCache) Get(key BlockCacheKey) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		globalBlockCacheMetrics.misses.Inc()
+		return nil, false
+	}
+	globalBlockCacheMetrics.hits.Inc()
+	c.lru.MoveToFront(e)
+	return e.Value.(*blockCacheEntry).value, true
+}
+
+// Put caches the decoded block for the key. The size is the number of bytes
+// the block takes in memory. Blocks larger than the cache are not cached.
+func (c *BlockCache) Put(key BlockCacheKey, value interface{}, size int) {
+	if c == nil || size <= 0 || uint64(size) > c.maxSize {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+	c.entries[key] = c.lru.PushFront(&blockCacheEntry{key: key, value: value, size: uint64(size)})
+	c.size += uint64(size)
+
+	for c.size > c.maxSize {
+		c.remove(c.lru.Back())
+		globalBlockCacheMetrics.evictions.Inc()
+	}
+	c.updateMetrics()
+}
+
+// RemoveFile drops the blocks of the file, such as when the file is closed.
+func (c *BlockCache) RemoveFile(file uint64) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		if e.Value.(*blockCacheEntry).key.File == file {
+			c.remove(e)
+		}
+		e = next
+	}
+	c.updateMetrics()
+}
+
+// Size returns the number of bytes the cached blocks take.
+func (c *BlockCache) Size() uint64 {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Len returns the number of cached blocks.
+func (c *BlockCache) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *BlockCache) remove(e *list.Element) {
+	entry := c.lru.Remove(e).(*blockCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+func (c *BlockCache) updateMetrics() {
+	globalBlockCacheMetrics.size.Set(float64(c.size))
+	globalBlockCacheMetrics.blocks.Set(float64(c.lru.Len()))
+}
+
+const blockCacheSubsystem = "block_cache"
+
+var globalBlockCacheMetrics = newBlockCacheMetrics()
+
+type blockCacheMetrics struct {
+	hits      prometheus.Counter
+	misses    prometheus.Counter
+	evictions prometheus.Counter
+	size      prometheus.Gauge
+	blocks    prometheus.Gauge
+}
+
+func newBlockCacheMetrics() *blockCacheMetrics {
+	return &blockCacheMetrics{
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: storageNamespace,
+			Subsystem: blockCacheSubsystem,
+			Name:      "hits_total",
+			Help:      "Counter of reads of TSM blocks served from the block cache",
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: storageNamespace,
+			Subsystem: blockCacheSubsystem,
+			Name:      "misses_total",
+			Help:      "Counter of reads of TSM blocks not in the block cache",
+		}),
+		evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: storageNamespace,
+			Subsystem: blockCacheSubsystem,
+			Name:      "evictions_total",
+			Help:      "Counter of blocks evicted from the block cache to stay within its size",
+		}),
+		size: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: storageNamespace,
+			Subsystem: blockCacheSubsystem,
+			Name:      "size_bytes",
+			Help:      "Gauge of the bytes taken by the blocks in the block cache",
+		}),
+		blocks: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: storageNamespace,
+			Subsystem: blockCacheSubsystem,
+			Name:      "blocks",
+			Help:      "Gauge of the number of blocks in the block cache",
+		}),
+	}
+}
+
+// BlockCacheCollectors returns the metrics of the block cache.
+func BlockCacheCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		globalBlockCacheMetrics.hits,
+		globalBlockCacheMetrics.misses,
+		globalBlockCacheMetrics.evictions,
+		globalBlockCacheMetrics.size,
+		globalBlockCacheMetrics.blocks,
+	}
+}
@@ -0,0 +1,47 @@
+package tsdb_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockCache(t *testing.T) {
+	c := tsdb.NewBlockCache(100)
+
+	a, b := tsdb.BlockCacheKey{File: 1, Offset: 5}, tsdb.BlockCacheKey{File: 2, Offset: 5}
+	c.Put(a, "a", 40)
+	c.Put(b, "b", 40)
+	require.Equal(t, uint64(80), c.Size())
+
+	v, ok := c.Get(a)
+	require.True(t, ok)
+	assert.Equal(t, "a", v)
+
+	// b is the least recently used block and is evicted
+	d := tsdb.BlockCacheKey{File: 1, Offset: 10}
+	c.Put(d, "d", 40)
+	_, ok = c.Get(b)
+	assert.False(t, ok)
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, uint64(80), c.Size())
+
+	// blocks larger than the cache are not cached
+	c.Put(tsdb.BlockCacheKey{File: 3}, "large", 101)
+	assert.Equal(t, 2, c.Len())
+
+	c.RemoveFile(1)
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, uint64(0), c.Size())
+}
+
+func TestBlockCache_Nil(t *testing.T) {
+	var c *tsdb.BlockCache
+	c.Put(tsdb.BlockCacheKey{File: 1}, "a", 1)
+	_, ok := c.Get(tsdb.BlockCacheKey{File: 1})
+	assert.False(t, ok)
+	c.RemoveFile(1)
+	assert.Equal(t, 0, c.Len())
+}
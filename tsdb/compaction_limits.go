@@ -0,0 +1,104 @@
+package tsdb
+
+import (
+	"fmt"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// CompactionLimits are the limits shared by the level and full compactions
+// of all shards of a store.
+type CompactionLimits struct {
+	// MaxConcurrent is the maximum number of concurrent compactions. Zero
+	// uses half of runtime.GOMAXPROCS(0).
+	MaxConcurrent int `json:"maxConcurrentCompactions"`
+	// Throughput is the rate limit in bytes per second of the writes of
+	// compactions. Zero disables the limit.
+	Throughput int `json:"compactThroughput"`
+	// ThroughputBurst is the rate limit in bytes per second compactions
+	// may write at in bursts.
+	ThroughputBurst int `json:"compactThroughputBurst"`
+}
+
+// Valid returns an error if a limit is negative.
+func (l CompactionLimits) Valid() error {
+	if l.MaxConcurrent < 0 {
+		return fmt.Errorf("max concurrent compactions must not be negative")
+	}
+	if l.Throughput < 0 || l.ThroughputBurst < 0 {
+		return fmt.Errorf("compaction throughput must not be negative")
+	}
+	return nil
+}
+
+// normalize applies the defaults and the bounds of the limits.
+func (l CompactionLimits) normalize() CompactionLimits {
+	if l.MaxConcurrent == 0 {
+		l.MaxConcurrent = runtime.GOMAXPROCS(0) / 2 // Default to 50% of cores for compactions
+		if l.MaxConcurrent < 1 {
+			l.MaxConcurrent = 1
+		}
+	}
+
+	// Don't allow more compactions to run than cores.
+	if l.MaxConcurrent > runtime.GOMAXPROCS(0) {
+		l.MaxConcurrent = runtime.GOMAXPROCS(0)
+	}
+
+	if l.Throughput > 0 && l.ThroughputBurst < l.Throughput {
+		l.ThroughputBurst = l.Throughput
+	}
+	return l
+}
+
+func (l CompactionLimits) logFields() []zapcore.Field {
+	fields := []zapcore.Field{zap.Int("max_concurrent_compactions", l.MaxConcurrent)}
+	if l.Throughput > 0 {
+		return append(fields,
+			zap.Int("throughput_bytes_per_second", l.Throughput),
+			zap.Int("throughput_bytes_per_second_burst", l.ThroughputBurst),
+		)
+	}
+	return append(fields,
+		zap.String("throughput_bytes_per_second", "unlimited"),
+		zap.String("throughput_bytes_per_second_burst", "unlimited"),
+	)
+}
+
+// CompactionLimits returns the limits compactions currently run with.
+func (s *Store) CompactionLimits() (CompactionLimits, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.opened {
+		return CompactionLimits{}, ErrStoreClosed
+	}
+
+	var l CompactionLimits
+	l.MaxConcurrent = s.compactionLimiter.Capacity()
+	l.Throughput, l.ThroughputBurst = s.compactionRate.Rate()
+	return l, nil
+}
+
+// SetCompactionLimits changes the limits of compactions without a restart
+// and returns the limits applied. Running compactions are not stopped when
+// the concurrency is lowered, but no compaction starts until fewer than the
+// new maximum run.
+func (s *Store) SetCompactionLimits(l CompactionLimits) (CompactionLimits, error) {
+	if err := l.Valid(); err != nil {
+		return CompactionLimits{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.opened {
+		return CompactionLimits{}, ErrStoreClosed
+	}
+
+	l = l.normalize()
+	s.compactionLimiter.SetCapacity(l.MaxConcurrent)
+	s.compactionRate.SetRate(l.Throughput, l.ThroughputBurst)
+	s.Logger.Info("Changed compaction settings", l.logFields()...)
+	return l, nil
+}
@@ -0,0 +1,32 @@
+package tsdb_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_SetCompactionLimits(t *testing.T) {
+	s := MustOpenStore(t, tsdb.DefaultIndex)
+	defer s.Close()
+
+	l, err := s.SetCompactionLimits(tsdb.CompactionLimits{MaxConcurrent: 1, Throughput: 1024})
+	require.NoError(t, err)
+	assert.Equal(t, tsdb.CompactionLimits{MaxConcurrent: 1, Throughput: 1024, ThroughputBurst: 1024}, l)
+
+	got, err := s.CompactionLimits()
+	require.NoError(t, err)
+	assert.Equal(t, l, got)
+
+	// zero uses the defaults, which disable the throughput limit
+	l, err = s.SetCompactionLimits(tsdb.CompactionLimits{MaxConcurrent: runtime.GOMAXPROCS(0) + 1})
+	require.NoError(t, err)
+	assert.Equal(t, runtime.GOMAXPROCS(0), l.MaxConcurrent)
+	assert.Zero(t, l.Throughput)
+
+	_, err = s.SetCompactionLimits(tsdb.CompactionLimits{MaxConcurrent: -1})
+	assert.Error(t, err)
+}
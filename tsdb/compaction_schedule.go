@@ -0,0 +1,157 @@
+package tsdb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+// TimeWindow is a daily window of time, from Start up to End minutes after
+// midnight. A window ending before it starts spans midnight.
+type TimeWindow struct {
+	Start int
+	End   int
+}
+
+// ParseTimeWindow parses a window formatted as HH:MM-HH:MM, such as
+// 02:00-06:00 or 22:00-02:00.
+func ParseTimeWindow(s string) (TimeWindow, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return TimeWindow{}, fmt.Errorf("time window %q must be formatted as HH:MM-HH:MM", s)
+	}
+	var minutes [2]int
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return TimeWindow{}, fmt.Errorf("time window %q must be formatted as HH:MM-HH:MM", s)
+		}
+		minutes[i] = t.Hour()*60 + t.Minute()
+	}
+	if minutes[0] == minutes[1] {
+		return TimeWindow{}, fmt.Errorf("time window %q is empty", s)
+	}
+	return TimeWindow{Start: minutes[0], End: minutes[1]}, nil
+}
+
+// Contains reports whether the time of day of t is in the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.Start < w.End {
+		return w.Start <= m && m < w.End
+	}
+	return m >= w.Start || m < w.End
+}
+
+func (w TimeWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+// MarshalText encodes the window as HH:MM-HH:MM.
+func (w TimeWindow) MarshalText() ([]byte, error) {
+	return []byte(w.String()), nil
+}
+
+// UnmarshalText decodes a window formatted as HH:MM-HH:MM.
+func (w *TimeWindow) UnmarshalText(text []byte) error {
+	v, err := ParseTimeWindow(string(text))
+	if err != nil {
+		return err
+	}
+	*w = v
+	return nil
+}
+
+// CompactionSchedule restricts when full compactions may start. Full
+// compactions start only in one of the Allow windows, when there are any,
+// and never in a Blackout window. Level and snapshot compactions are not
+// restricted, so that writes are not held up.
+//
+// A nil schedule allows full compactions at any time.
+type CompactionSchedule struct {
+	Allow    []TimeWindow
+	Blackout []TimeWindow
+
+	// Location is the time zone the windows are in.
+	Location *time.Location
+}
+
+// NewCompactionSchedule parses the allow and blackout windows of a schedule
+// in the named time zone, or the local time zone of the server when
+// timezone is empty.
+func NewCompactionSchedule(allow, blackout []string, timezone string) (*CompactionSchedule, error) {
+	s := &CompactionSchedule{Location: time.Local}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid compaction windows time zone %q: %w", timezone, err)
+		}
+		s.Location = loc
+	}
+	for _, v := range allow {
+		w, err := ParseTimeWindow(v)
+		if err != nil {
+			return nil, err
+		}
+		s.Allow = append(s.Allow, w)
+	}
+	for _, v := range blackout {
+		w, err := ParseTimeWindow(v)
+		if err != nil {
+			return nil, err
+		}
+		s.Blackout = append(s.Blackout, w)
+	}
+	return s, nil
+}
+
+// Timezone is the name of the time zone the windows are in.
+func (s *CompactionSchedule) Timezone() string {
+	if s == nil || s.Location == nil {
+		return time.Local.String()
+	}
+	return s.Location.String()
+}
+
+// Allowed reports whether full compactions may start at t.
+func (s *CompactionSchedule) Allowed(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	if s.Location != nil {
+		t = t.In(s.Location)
+	}
+	for _, w := range s.Blackout {
+		if w.Contains(t) {
+			return false
+		}
+	}
+	if len(s.Allow) == 0 {
+		return true
+	}
+	for _, w := range s.Allow {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextChange returns the time after t at which full compactions are next
+// allowed, when they are not allowed at t, or disallowed otherwise. It
+// returns false when that never happens.
+func (s *CompactionSchedule) NextChange(t time.Time) (time.Time, bool) {
+	allowed := s.Allowed(t)
+	next := t.Truncate(time.Minute)
+	// Windows repeat daily, so a change happens within two days, which
+	// covers days lengthened by daylight saving time.
+	for i := 0; i < 2*minutesPerDay; i++ {
+		next = next.Add(time.Minute)
+		if s.Allowed(next) != allowed {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
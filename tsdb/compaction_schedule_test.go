@@ -0,0 +1,53 @@
+package tsdb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeWindow(t *testing.T) {
+	w, err := tsdb.ParseTimeWindow("22:30-02:00")
+	require.NoError(t, err)
+	assert.Equal(t, tsdb.TimeWindow{Start: 22*60 + 30, End: 2 * 60}, w)
+	assert.Equal(t, "22:30-02:00", w.String())
+
+	for _, s := range []string{"", "02:00", "02:00-06:00-07:00", "2am-6am", "25:00-06:00", "02:00-02:00"} {
+		_, err := tsdb.ParseTimeWindow(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestCompactionSchedule(t *testing.T) {
+	s, err := tsdb.NewCompactionSchedule([]string{"22:00-06:00"}, []string{"01:00-02:00"}, "UTC")
+	require.NoError(t, err)
+
+	at := func(hour, min int) time.Time {
+		return time.Date(2021, 6, 1, hour, min, 0, 0, time.UTC)
+	}
+	assert.True(t, s.Allowed(at(23, 0)))
+	assert.True(t, s.Allowed(at(0, 30)))
+	assert.False(t, s.Allowed(at(1, 30)), "blackout windows take precedence")
+	assert.True(t, s.Allowed(at(2, 0)))
+	assert.False(t, s.Allowed(at(6, 0)))
+	assert.False(t, s.Allowed(at(12, 0)))
+
+	next, ok := s.NextChange(at(12, 0))
+	require.True(t, ok)
+	assert.Equal(t, at(22, 0), next)
+	next, ok = s.NextChange(at(0, 30))
+	require.True(t, ok)
+	assert.Equal(t, at(1, 0), next)
+
+	// no windows allows full compactions at any time
+	var none *tsdb.CompactionSchedule
+	assert.True(t, none.Allowed(at(12, 0)))
+	_, ok = none.NextChange(at(12, 0))
+	assert.False(t, ok)
+
+	_, err = tsdb.NewCompactionSchedule(nil, nil, "Not/AZone")
+	assert.Error(t, err)
+}
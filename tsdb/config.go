@@ -77,6 +77,16 @@ type Config struct {
 	Engine string `toml:"-"`
 	Index  string `toml:"index-version"`
 
+	// ExtraDirs are additional data directories shards can be moved to,
+	// such as volumes added after the server was set up. Series files and
+	// new shards are always stored in Dir.
+	ExtraDirs []string `toml:"extra-dirs"`
+
+	// SystemBucketDir is the data directory new shards of system buckets,
+	// such as _monitoring and _tasks, are created in instead of Dir. It is
+	// one of the data directories shards can be moved between.
+	SystemBucketDir string `toml:"system-bucket-dir"`
+
 	// General WAL configuration options
 	WALDir string `toml:"wal-dir"`
 
@@ -112,6 +122,21 @@ type Config struct {
 	CompactThroughput              toml.Size     `toml:"compact-throughput"`
 	CompactThroughputBurst         toml.Size     `toml:"compact-throughput-burst"`
 
+	// CompactFullAllowWindows are the daily windows, formatted as
+	// HH:MM-HH:MM, full compactions may start in. Full compactions may start
+	// at any time when no window is set.
+	CompactFullAllowWindows []string `toml:"compact-full-allow-windows"`
+
+	// CompactFullBlackoutWindows are the daily windows, formatted as
+	// HH:MM-HH:MM, full compactions may not start in, even within an allow
+	// window.
+	CompactFullBlackoutWindows []string `toml:"compact-full-blackout-windows"`
+
+	// CompactFullWindowsTimezone is the time zone of the compaction windows,
+	// such as UTC or Europe/Berlin. The local time zone of the server is used
+	// when it is empty.
+	CompactFullWindowsTimezone string `toml:"compact-full-windows-timezone"`
+
 	// Limits
 
 	// MaxConcurrentCompactions is the maximum number of concurrent level and full compactions
@@ -145,6 +170,11 @@ type Config struct {
 	// been found to be problematic in some cases. It may help users who have
 	// slow disks.
 	TSMWillNeed bool `toml:"tsm-use-madv-willneed"`
+
+	// BlockCacheMaxMemorySize is the maximum size of the decoded TSM blocks
+	// kept in memory for repeated reads, shared by all shards. Setting
+	// block-cache-max-memory-size to 0 disables the cache.
+	BlockCacheMaxMemorySize toml.Size `toml:"block-cache-max-memory-size"`
 }
 
 // NewConfig returns the default configuration for tsdb.
@@ -196,6 +226,10 @@ func (c *Config) Validate() error {
 		return errors.New("series-file-max-concurrent-compactions must be non-negative")
 	}
 
+	if _, err := c.CompactionSchedule(); err != nil {
+		return err
+	}
+
 	valid := false
 	for _, e := range RegisteredEngines() {
 		if e == c.Engine {
@@ -220,3 +254,8 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+// CompactionSchedule parses the windows full compactions may start in.
+func (c *Config) CompactionSchedule() (*CompactionSchedule, error) {
+	return NewCompactionSchedule(c.CompactFullAllowWindows, c.CompactFullBlackoutWindows, c.CompactFullWindowsTimezone)
+}
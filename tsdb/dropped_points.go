@@ -0,0 +1,54 @@
+package tsdb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+var droppedPointsContextKey = struct{ name string }{"dropped points"}
+
+// DroppedPoint is a point a write dropped, with the reason it was dropped for.
+type DroppedPoint struct {
+	Point  models.Point
+	Reason string
+}
+
+// DroppedPoints collects the points dropped by the writes made with a
+// context, which a PartialWriteError only counts.
+type DroppedPoints struct {
+	mu     sync.Mutex
+	points []DroppedPoint
+}
+
+// ContextWithDroppedPoints returns a new context collecting the points
+// dropped by the writes made with it.
+func ContextWithDroppedPoints(ctx context.Context) (context.Context, *DroppedPoints) {
+	d := &DroppedPoints{}
+	return context.WithValue(ctx, droppedPointsContextKey, d), d
+}
+
+// DroppedPointsFromContext retrieves the dropped points collected with the
+// context. If dropped points are not collected nil is returned.
+func DroppedPointsFromContext(ctx context.Context) *DroppedPoints {
+	d, _ := ctx.Value(droppedPointsContextKey).(*DroppedPoints)
+	return d
+}
+
+// Add records a dropped point. A nil *DroppedPoints records nothing.
+func (d *DroppedPoints) Add(p models.Point, reason string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.points = append(d.points, DroppedPoint{Point: p, Reason: reason})
+}
+
+// Points returns the dropped points recorded, in the order they were dropped.
+func (d *DroppedPoints) Points() []DroppedPoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]DroppedPoint(nil), d.points...)
+}
@@ -32,6 +32,7 @@ type Engine interface {
 	Close() error
 	SetEnabled(enabled bool)
 	SetCompactionsEnabled(enabled bool)
+	SetLevelCompactionsEnabled(enabled bool)
 	ScheduleFullCompaction() error
 
 	WithLogger(*zap.Logger)
@@ -73,6 +74,9 @@ type Engine interface {
 
 	LastModified() time.Time
 	DiskSize() int64
+	FileStats() ShardFileStats
+	TombstoneStats() ShardTombstoneStats
+	CacheSize() int64
 	IsIdle() (bool, string)
 	Free() error
 
@@ -161,8 +165,9 @@ type EngineOptions struct {
 	// This option is intended for offline tooling.
 	CompactionDisabled          bool
 	CompactionPlannerCreator    CompactionPlannerCreator
-	CompactionLimiter           limiter.Fixed
+	CompactionLimiter           limiter.Concurrency
 	CompactionThroughputLimiter limiter.Rate
+	BlockCache                  *BlockCache
 	WALEnabled                  bool
 	MonitorDisabled             bool
 
@@ -170,6 +175,10 @@ type EngineOptions struct {
 	// If no function is set, all databases will be opened.
 	DatabaseFilter func(database string) bool
 
+	// SystemDatabase reports whether the database stores a system bucket,
+	// whose new shards are created in Config.SystemBucketDir when set.
+	SystemDatabase func(database string) bool
+
 	// RetentionPolicyFilter is a predicate controlling which combination of database and retention policy may be opened.
 	// nil will allow all combinations to pass.
 	RetentionPolicyFilter func(database, rp string) bool
@@ -185,6 +194,36 @@ type EngineOptions struct {
 
 	FileStoreObserver FileStoreObserver
 	MetricsDisabled   bool
+
+	// MaintenanceAdmitter admits full compactions as maintenance operations
+	// when set, bounding them with the other maintenance of the server.
+	MaintenanceAdmitter MaintenanceAdmitter
+
+	// CompactionSchedule restricts when full compactions may start. Full
+	// compactions may start at any time when it is nil.
+	CompactionSchedule *CompactionSchedule
+
+	// ReadOnly opens engines without modifying their files, which another
+	// process writes, such as the server a read replica serves the data of.
+	ReadOnly bool
+}
+
+// MaintenanceAdmitter admits work of an engine as maintenance operations,
+// which share the concurrency and IO budget of the maintenance of the server.
+type MaintenanceAdmitter interface {
+	// AdmitCompaction admits a compaction without waiting. It returns false
+	// when the compaction may not run now and must be retried later.
+	AdmitCompaction(description string) (MaintenanceOp, bool)
+}
+
+// MaintenanceOp is a maintenance operation admitted by a MaintenanceAdmitter.
+type MaintenanceOp interface {
+	// Throttle accounts for n bytes written by the operation. It blocks
+	// while the operation is paused or over the IO budget, and fails once
+	// the operation is aborted.
+	Throttle(n int) error
+	// End releases the operation.
+	End()
 }
 
 // NewEngineOptions constructs an EngineOptions object with safe default values.
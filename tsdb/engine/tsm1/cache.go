@@ -681,6 +681,11 @@ func (c *Cache) ApplyEntryFn(f func(key []byte, entry *entry) error) error {
 type CacheLoader struct {
 	files []string
 
+	// ReadOnly loads the segment files without modifying them, as they are
+	// written by another process. A corrupt entry, such as one still being
+	// appended, ends the loading of its file instead of truncating it.
+	ReadOnly bool
+
 	Logger *zap.Logger
 }
 
@@ -694,14 +699,18 @@ func NewCacheLoader(files []string) *CacheLoader {
 
 // Load returns a cache loaded with the data contained within the segment files.
 // If, during reading of a segment file, corruption is encountered, that segment
-// file is truncated up to and including the last valid byte, and processing
-// continues with the next segment file.
+// file is truncated up to and including the last valid byte, unless the loader
+// is read-only, and processing continues with the next segment file.
 func (cl *CacheLoader) Load(cache *Cache) error {
 
 	var r *WALSegmentReader
 	for _, fn := range cl.files {
 		if err := func() error {
-			f, err := os.OpenFile(fn, os.O_CREATE|os.O_RDWR, 0666)
+			flag := os.O_CREATE | os.O_RDWR
+			if cl.ReadOnly {
+				flag = os.O_RDONLY
+			}
+			f, err := os.OpenFile(fn, flag, 0666)
 			if err != nil {
 				return err
 			}
@@ -731,8 +740,10 @@ func (cl *CacheLoader) Load(cache *Cache) error {
 				if err != nil {
 					n := r.Count()
 					cl.Logger.Info("File corrupt", zap.Error(err), zap.String("path", f.Name()), zap.Int64("pos", n))
-					if err := f.Truncate(n); err != nil {
-						return err
+					if !cl.ReadOnly {
+						if err := f.Truncate(n); err != nil {
+							return err
+						}
 					}
 					break
 				}
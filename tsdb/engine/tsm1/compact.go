@@ -14,6 +14,7 @@ package tsm1
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"math"
@@ -869,7 +870,7 @@ func (c *Compactor) WriteSnapshot(cache *Cache, logger *zap.Logger) ([]string, e
 	for i := 0; i < concurrency; i++ {
 		go func(sp *Cache) {
 			iter := NewCacheKeyIterator(sp, tsdb.DefaultMaxPointsPerBlock, intC)
-			files, err := c.writeNewFiles(c.FileStore.NextGeneration(), 0, nil, iter, throttle, logger)
+			files, err := c.writeNewFiles(c.FileStore.NextGeneration(), 0, nil, iter, throttle, nil, logger)
 			resC <- res{files: files, err: err}
 
 		}(splits[i])
@@ -903,7 +904,7 @@ func (c *Compactor) WriteSnapshot(cache *Cache, logger *zap.Logger) ([]string, e
 }
 
 // compact writes multiple smaller TSM files into 1 or more larger files.
-func (c *Compactor) compact(fast bool, tsmFiles []string, logger *zap.Logger) ([]string, error) {
+func (c *Compactor) compact(fast bool, tsmFiles []string, opThrottle writeThrottle, logger *zap.Logger) ([]string, error) {
 	size := c.Size
 	if size <= 0 {
 		size = tsdb.DefaultMaxPointsPerBlock
@@ -963,11 +964,17 @@ func (c *Compactor) compact(fast bool, tsmFiles []string, logger *zap.Logger) ([
 		return nil, err
 	}
 
-	return c.writeNewFiles(maxGeneration, maxSequence, tsmFiles, tsm, true, logger)
+	return c.writeNewFiles(maxGeneration, maxSequence, tsmFiles, tsm, true, opThrottle, logger)
 }
 
 // CompactFull writes multiple smaller TSM files into 1 or more larger files.
 func (c *Compactor) CompactFull(tsmFiles []string, logger *zap.Logger) ([]string, error) {
+	return c.compactFull(tsmFiles, nil, logger)
+}
+
+// compactFull is CompactFull with the bytes written accounted for by
+// opThrottle, when not nil.
+func (c *Compactor) compactFull(tsmFiles []string, opThrottle writeThrottle, logger *zap.Logger) ([]string, error) {
 	c.mu.RLock()
 	enabled := c.compactionsEnabled
 	c.mu.RUnlock()
@@ -981,7 +988,7 @@ func (c *Compactor) CompactFull(tsmFiles []string, logger *zap.Logger) ([]string
 	}
 	defer c.remove(tsmFiles)
 
-	files, err := c.compact(false, tsmFiles, logger)
+	files, err := c.compact(false, tsmFiles, opThrottle, logger)
 
 	// See if we were disabled while writing a snapshot
 	c.mu.RLock()
@@ -1000,6 +1007,12 @@ func (c *Compactor) CompactFull(tsmFiles []string, logger *zap.Logger) ([]string
 
 // CompactFast writes multiple smaller TSM files into 1 or more larger files.
 func (c *Compactor) CompactFast(tsmFiles []string, logger *zap.Logger) ([]string, error) {
+	return c.compactFast(tsmFiles, nil, logger)
+}
+
+// compactFast is CompactFast with the bytes written accounted for by
+// opThrottle, when not nil.
+func (c *Compactor) compactFast(tsmFiles []string, opThrottle writeThrottle, logger *zap.Logger) ([]string, error) {
 	c.mu.RLock()
 	enabled := c.compactionsEnabled
 	c.mu.RUnlock()
@@ -1013,7 +1026,7 @@ func (c *Compactor) CompactFast(tsmFiles []string, logger *zap.Logger) ([]string
 	}
 	defer c.remove(tsmFiles)
 
-	files, err := c.compact(true, tsmFiles, logger)
+	files, err := c.compact(true, tsmFiles, opThrottle, logger)
 
 	// See if we were disabled while writing a snapshot
 	c.mu.RLock()
@@ -1044,7 +1057,7 @@ func (c *Compactor) removeTmpFiles(files []string) error {
 
 // writeNewFiles writes from the iterator into new TSM files, rotating
 // to a new file once it has reached the max TSM file size.
-func (c *Compactor) writeNewFiles(generation, sequence int, src []string, iter KeyIterator, throttle bool, logger *zap.Logger) ([]string, error) {
+func (c *Compactor) writeNewFiles(generation, sequence int, src []string, iter KeyIterator, throttle bool, opThrottle writeThrottle, logger *zap.Logger) ([]string, error) {
 	// These are the new TSM files written
 	var files []string
 
@@ -1056,7 +1069,7 @@ func (c *Compactor) writeNewFiles(generation, sequence int, src []string, iter K
 		logger.Debug("Compacting files", zap.Int("file_count", len(src)), zap.String("output_file", fileName))
 
 		// Write as much as possible to this file
-		err := c.write(fileName, iter, throttle, logger)
+		err := c.write(fileName, iter, throttle, opThrottle, logger)
 
 		// We've hit the max file limit and there is more to write.  Create a new file
 		// and continue.
@@ -1096,7 +1109,7 @@ func (c *Compactor) writeNewFiles(generation, sequence int, src []string, iter K
 	return files, nil
 }
 
-func (c *Compactor) write(path string, iter KeyIterator, throttle bool, logger *zap.Logger) (err error) {
+func (c *Compactor) write(path string, iter KeyIterator, throttle bool, opThrottle writeThrottle, logger *zap.Logger) (err error) {
 	fd, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_EXCL, 0666)
 	if err != nil {
 		return errCompactionInProgress{err: err}
@@ -1116,8 +1129,15 @@ func (c *Compactor) write(path string, iter KeyIterator, throttle bool, logger *
 		limitWriter syncingWriter = fd
 	)
 
-	if c.RateLimit != nil && throttle {
-		limitWriter = limiter.NewWriterWithRate(fd, c.RateLimit)
+	var rate limiter.Rate
+	if throttle {
+		rate = c.RateLimit
+	}
+	if opThrottle != nil {
+		rate = &opRate{Rate: rate, throttle: opThrottle}
+	}
+	if rate != nil {
+		limitWriter = limiter.NewWriterWithRate(fd, rate)
 	}
 
 	// Use a disk based TSM buffer if it looks like we might create a big index
@@ -1211,6 +1231,35 @@ func (c *Compactor) write(path string, iter KeyIterator, throttle bool, logger *
 	return nil
 }
 
+// writeThrottle accounts for the bytes written by a compaction admitted as a
+// maintenance operation. It blocks while the operation is paused or over its
+// IO budget, and fails the compaction once the operation is aborted.
+type writeThrottle func(n int) error
+
+// opRate limits the writes of a compaction admitted as a maintenance
+// operation by the compaction throughput limit, if any, and then by the
+// operation.
+type opRate struct {
+	limiter.Rate
+	throttle writeThrottle
+}
+
+func (r *opRate) WaitN(ctx context.Context, n int) error {
+	if r.Rate != nil {
+		if err := r.Rate.WaitN(ctx, n); err != nil {
+			return err
+		}
+	}
+	return r.throttle(n)
+}
+
+func (r *opRate) Burst() int {
+	if r.Rate != nil {
+		return r.Rate.Burst()
+	}
+	return math.MaxInt32
+}
+
 func (c *Compactor) add(files []string) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -142,7 +142,17 @@ type Engine struct {
 	activeCompactions *compactionCounter
 
 	// Limiter for concurrent compactions.
-	compactionLimiter limiter.Fixed
+	compactionLimiter limiter.Concurrency
+
+	// maintenance admits full compactions as maintenance operations.
+	maintenance tsdb.MaintenanceAdmitter
+
+	// compactionSchedule restricts when full compactions may start.
+	compactionSchedule *tsdb.CompactionSchedule
+
+	// readOnly opens the engine without modifying its files, which are
+	// written by another process.
+	readOnly bool
 
 	scheduler *scheduler
 
@@ -170,14 +180,17 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 	if opt.WALEnabled {
 		wal = NewWAL(walPath, opt.Config.WALMaxConcurrentWrites, opt.Config.WALMaxWriteDelay, etags)
 		wal.syncDelay = time.Duration(opt.Config.WALFsyncDelay)
+		wal.readOnly = opt.ReadOnly
 	}
 
 	fs := NewFileStore(path, etags)
 	fs.openLimiter = opt.OpenLimiter
+	fs.readOnly = opt.ReadOnly
 	if opt.FileStoreObserver != nil {
 		fs.WithObserver(opt.FileStoreObserver)
 	}
 	fs.tsmMMAPWillNeed = opt.Config.TSMWillNeed
+	fs.blockCache = opt.BlockCache
 
 	cache := NewCache(uint64(opt.Config.CacheMaxMemorySize), etags)
 
@@ -192,6 +205,11 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 		planner.SetFileStore(fs)
 	}
 
+	compactionLimiter := opt.CompactionLimiter
+	if compactionLimiter == nil {
+		compactionLimiter = limiter.NewFixed(0)
+	}
+
 	stats := newEngineMetrics(etags)
 	activeCompactions := &compactionCounter{}
 	e := &Engine{
@@ -211,7 +229,7 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 		CompactionPlan: planner,
 
 		activeCompactions: activeCompactions,
-		scheduler:         newScheduler(activeCompactions, opt.CompactionLimiter.Capacity()),
+		scheduler:         newScheduler(activeCompactions, compactionLimiter.Capacity()),
 
 		CacheFlushMemorySizeThreshold: uint64(opt.Config.CacheSnapshotMemorySize),
 		CacheFlushWriteColdDuration:   time.Duration(opt.Config.CacheSnapshotWriteColdDuration),
@@ -219,7 +237,10 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 		WALEnabled:                    opt.WALEnabled,
 		formatFileName:                DefaultFormatFileName,
 		stats:                         stats,
-		compactionLimiter:             opt.CompactionLimiter,
+		compactionLimiter:             compactionLimiter,
+		maintenance:                   opt.MaintenanceAdmitter,
+		compactionSchedule:            opt.CompactionSchedule,
+		readOnly:                      opt.ReadOnly,
 		seriesIDSets:                  opt.SeriesIDSets,
 	}
 
@@ -346,6 +367,10 @@ func (e *Engine) SetEnabled(enabled bool) {
 // SetCompactionsEnabled enables compactions on the engine.  When disabled
 // all running compactions are aborted and new compactions stop running.
 func (e *Engine) SetCompactionsEnabled(enabled bool) {
+	if enabled && e.readOnly {
+		// the files of a read-only engine are compacted by their writer
+		return
+	}
 	if enabled {
 		e.enableSnapshotCompactions()
 		e.enableLevelCompactions(false)
@@ -355,6 +380,20 @@ func (e *Engine) SetCompactionsEnabled(enabled bool) {
 	}
 }
 
+// SetLevelCompactionsEnabled enables the level and full compactions of the
+// engine, leaving snapshots of the cache running.  When disabled all running
+// level compactions are aborted.
+func (e *Engine) SetLevelCompactionsEnabled(enabled bool) {
+	if enabled && e.readOnly {
+		return
+	}
+	if enabled {
+		e.enableLevelCompactions(false)
+	} else {
+		e.disableLevelCompactions(false)
+	}
+}
+
 // enableLevelCompactions will request that level compactions start back up again
 //
 // 'wait' signifies that a corresponding call to disableLevelCompactions(true) was made at some
@@ -709,14 +748,36 @@ func (e *Engine) DiskSize() int64 {
 	return e.FileStore.DiskSizeBytes() + walDiskSizeBytes
 }
 
+// FileStats summarizes the TSM files of the engine.
+func (e *Engine) FileStats() tsdb.ShardFileStats {
+	s := e.FileStore.fileStats()
+	s.FullyCompacted, _ = e.CompactionPlan.FullyCompacted()
+	return s
+}
+
+// TombstoneStats reports the deleted data of the engine pending removal by a
+// compaction.
+func (e *Engine) TombstoneStats() tsdb.ShardTombstoneStats {
+	return e.FileStore.tombstoneStats()
+}
+
+// CacheSize returns the number of bytes held in the in-memory cache.
+func (e *Engine) CacheSize() int64 {
+	return int64(e.Cache.Size())
+}
+
 // Open opens and initializes the engine.
 func (e *Engine) Open(ctx context.Context) error {
-	if err := os.MkdirAll(e.path, 0777); err != nil {
-		return err
-	}
+	// The temporary files of a read-only engine belong to the process
+	// writing its files, which may still be writing them.
+	if !e.readOnly {
+		if err := os.MkdirAll(e.path, 0777); err != nil {
+			return err
+		}
 
-	if err := e.cleanup(); err != nil {
-		return err
+		if err := e.cleanup(); err != nil {
+			return err
+		}
 	}
 
 	fields, err := tsdb.NewMeasurementFieldSet(filepath.Join(e.path, "fields.idx"))
@@ -2016,6 +2077,13 @@ func (e *Engine) compact(wg *sync.WaitGroup) {
 				e.stats.Queued.With(prometheus.Labels{levelKey: levelOpt}).Set(float64(len4))
 			}
 
+			// Full and optimize compactions only start in the windows of the
+			// compaction schedule. They stay queued until then.
+			if len(level4Groups) > 0 && !e.compactionSchedule.Allowed(time.Now()) {
+				e.CompactionPlan.Release(level4Groups)
+				level4Groups = nil
+			}
+
 			// Update the level plan queue stats
 			// For stats, use the length needed, even if the lock was
 			// not acquired
@@ -2026,6 +2094,8 @@ func (e *Engine) compact(wg *sync.WaitGroup) {
 			// Set the queue depths on the scheduler
 			// Use the real queue depth, dependent on acquiring
 			// the file locks.
+			// The limit is set too, since it can be changed at runtime.
+			e.scheduler.setMaxConcurrency(e.compactionLimiter.Capacity())
 			e.scheduler.setDepth(1, len(level1Groups))
 			e.scheduler.setDepth(2, len(level2Groups))
 			e.scheduler.setDepth(3, len(level3Groups))
@@ -2106,6 +2176,17 @@ func (e *Engine) compactFull(grp CompactionGroup, wg *sync.WaitGroup) bool {
 
 	// Try the lo priority limiter, otherwise steal a little from the high priority if we can.
 	if e.compactionLimiter.TryTake() {
+		// Full compactions rewrite whole shards, so they share the
+		// concurrency and IO budget of the other maintenance of the server.
+		if e.maintenance != nil {
+			op, ok := e.maintenance.AdmitCompaction(fmt.Sprintf("full compaction of shard %d", e.id))
+			if !ok {
+				e.compactionLimiter.Release()
+				return false
+			}
+			s.op = op
+		}
+
 		{
 			val := atomic.AddInt64(&e.activeCompactions.full, 1)
 			e.stats.Active.With(prometheus.Labels{levelKey: levelFull}).Set(float64(val))
@@ -2118,6 +2199,9 @@ func (e *Engine) compactFull(grp CompactionGroup, wg *sync.WaitGroup) bool {
 				e.stats.Active.With(prometheus.Labels{levelKey: levelFull}).Set(float64(val))
 			}()
 			defer e.compactionLimiter.Release()
+			if s.op != nil {
+				defer s.op.End()
+			}
 			s.Apply()
 			// Release the files in the compaction plan
 			e.CompactionPlan.Release([]CompactionGroup{s.group})
@@ -2142,6 +2226,9 @@ type compactionStrategy struct {
 	fileStore *FileStore
 
 	engine *Engine
+
+	// op is the maintenance operation the compaction was admitted as, if any.
+	op tsdb.MaintenanceOp
 }
 
 // Apply concurrently compacts all the groups in a compaction strategy.
@@ -2166,10 +2253,14 @@ func (s *compactionStrategy) compactGroup() {
 		err   error
 		files []string
 	)
+	var opThrottle writeThrottle
+	if s.op != nil {
+		opThrottle = s.op.Throttle
+	}
 	if s.fast {
-		files, err = s.compactor.CompactFast(group, log)
+		files, err = s.compactor.compactFast(group, opThrottle, log)
 	} else {
-		files, err = s.compactor.CompactFull(group, log)
+		files, err = s.compactor.compactFull(group, opThrottle, log)
 	}
 
 	if err != nil {
@@ -2279,6 +2370,7 @@ func (e *Engine) reloadCache() error {
 	e.Cache.SetMaxSize(0)
 
 	loader := NewCacheLoader(files)
+	loader.ReadOnly = e.readOnly
 	loader.WithLogger(e.logger)
 	if err := loader.Load(e.Cache); err != nil {
 		return err
@@ -2,12 +2,15 @@ package tsm1
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/limiter"
 	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
@@ -78,6 +81,113 @@ func TestEngine_ConcurrentShardSnapshots(t *testing.T) {
 	realEngineStruct.Cache.snapshotting = false
 }
 
+func TestEngine_FullCompactionAdmittedAsMaintenance(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "shard_test")
+	require.NoError(t, err, "error creating temporary directory")
+	defer os.RemoveAll(tmpDir)
+
+	sfile := NewSeriesFile(t, tmpDir)
+	defer sfile.Close()
+
+	admitter := &testMaintenanceAdmitter{}
+	opts := tsdb.NewEngineOptions()
+	opts.Config.WALDir = filepath.Join(tmpDir, "wal")
+	opts.SeriesIDSets = seriesIDSets([]*tsdb.SeriesIDSet{})
+	opts.CompactionLimiter = limiter.NewFixed(1)
+	opts.MaintenanceAdmitter = admitter
+
+	sh := tsdb.NewShard(1, filepath.Join(tmpDir, "shard"), filepath.Join(tmpDir, "wal"), sfile, opts)
+	require.NoError(t, sh.Open(context.Background()), "error opening shard")
+	defer sh.Close()
+
+	engine, err := sh.Engine()
+	require.NoError(t, err, "error retrieving shard engine")
+	e := engine.(*Engine)
+
+	// compactions are started by the test only
+	e.SetLevelCompactionsEnabled(false)
+	e.Compactor.EnableCompactions()
+
+	snapshot := func(ts int64) {
+		require.NoError(t, sh.WritePoints(context.Background(), []models.Point{models.MustNewPoint(
+			"cpu",
+			models.NewTags(map[string]string{"host": "server"}),
+			map[string]interface{}{"value": 1.0},
+			time.Unix(ts, 0),
+		)}))
+		require.NoError(t, e.WriteSnapshot())
+	}
+	group := func() CompactionGroup {
+		var grp CompactionGroup
+		for _, f := range e.FileStore.Files() {
+			grp = append(grp, f.Path())
+		}
+		return grp
+	}
+	snapshot(1)
+	snapshot(2)
+	require.Len(t, group(), 2)
+
+	var wg sync.WaitGroup
+	require.False(t, e.compactFull(group(), &wg), "compaction started without being admitted")
+	require.Equal(t, 1, e.compactionLimiter.Available())
+	require.Empty(t, admitter.ops)
+
+	// an aborted operation fails the compaction, leaving the files as they were
+	admitter.admit, admitter.err = true, errors.New("aborted")
+	require.True(t, e.compactFull(group(), &wg))
+	wg.Wait()
+	require.Len(t, group(), 2)
+	tmp, err := filepath.Glob(filepath.Join(e.path, "*."+TmpTSMFileExtension))
+	require.NoError(t, err)
+	require.Empty(t, tmp)
+
+	admitter.err = nil
+	require.True(t, e.compactFull(group(), &wg))
+	wg.Wait()
+	require.Len(t, group(), 1)
+	require.Equal(t, 1, e.compactionLimiter.Available())
+
+	require.Len(t, admitter.ops, 2)
+	for _, op := range admitter.ops {
+		require.True(t, op.ended, "maintenance operation not ended")
+		require.Greater(t, op.bytes, 0)
+	}
+	require.Equal(t, []string{"full compaction of shard 1", "full compaction of shard 1"}, admitter.descriptions)
+}
+
+type testMaintenanceAdmitter struct {
+	admit        bool
+	err          error
+	descriptions []string
+	ops          []*testMaintenanceOp
+}
+
+func (a *testMaintenanceAdmitter) AdmitCompaction(description string) (tsdb.MaintenanceOp, bool) {
+	if !a.admit {
+		return nil, false
+	}
+	op := &testMaintenanceOp{err: a.err}
+	a.descriptions = append(a.descriptions, description)
+	a.ops = append(a.ops, op)
+	return op, true
+}
+
+type testMaintenanceOp struct {
+	err   error
+	bytes int
+	ended bool
+}
+
+func (o *testMaintenanceOp) Throttle(n int) error {
+	o.bytes += n
+	return o.err
+}
+
+func (o *testMaintenanceOp) End() {
+	o.ended = true
+}
+
 // NewSeriesFile returns a new instance of SeriesFile with a temporary file path.
 func NewSeriesFile(tb testing.TB, tmpDir string) *tsdb.SeriesFile {
 	tb.Helper()
@@ -67,6 +67,55 @@ func TestEngine_DeleteWALLoadMetadata(t *testing.T) {
 	}
 }
 
+// Ensure that a read-only engine opened on the files of another engine
+// leaves them as they are.
+func TestEngine_OpenReadOnly(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			e := MustOpenEngine(t, index)
+			defer e.Close()
+
+			require.NoError(t, e.WritePointsString(`cpu,host=A value=1.1 1000000000`))
+			e.MustWriteSnapshot()
+			require.NoError(t, e.WritePointsString(`cpu,host=B value=1.2 2000000000`))
+
+			// Leave the files of the writer in the middle of its work: a
+			// compaction writing a temporary file, an entry partially
+			// appended to a WAL segment and a new, empty segment.
+			dataDir, walDir := filepath.Join(e.root, "data"), filepath.Join(e.root, "wal")
+			require.NoError(t, os.WriteFile(filepath.Join(dataDir, "000000002-000000002.tsm.tmp"), []byte("partial"), 0666))
+			segments, err := filepath.Glob(filepath.Join(walDir, "_*.wal"))
+			require.NoError(t, err)
+			require.NotEmpty(t, segments)
+			f, err := os.OpenFile(segments[len(segments)-1], os.O_APPEND|os.O_WRONLY, 0666)
+			require.NoError(t, err)
+			_, err = f.Write([]byte{byte(tsm1.WriteWALEntryType), 0, 0, 1, 0, 1, 2})
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+			require.NoError(t, os.WriteFile(filepath.Join(walDir, "_99999.wal"), nil, 0666))
+
+			before := mustReadFiles(t, dataDir, walDir)
+
+			opt := tsdb.NewEngineOptions()
+			opt.IndexVersion = index
+			opt.ReadOnly = true
+			seriesIDs := tsdb.NewSeriesIDSet()
+			opt.SeriesIDSets = seriesIDSets([]*tsdb.SeriesIDSet{seriesIDs})
+			idx := tsdb.MustOpenIndex(2, "db0", t.TempDir(), seriesIDs, e.sfile, opt)
+			defer idx.Close()
+
+			replica := tsm1.NewEngine(2, idx, dataDir, walDir, e.sfile, opt).(*tsm1.Engine)
+			require.NoError(t, replica.Open(context.Background()))
+			require.Equal(t, 1, replica.FileStore.Count())
+			require.Len(t, replica.Cache.Values(tsm1.SeriesFieldKeyBytes("cpu,host=B", "value")), 1)
+			require.Equal(t, tsm1.ErrWALReadOnly, replica.WAL.CloseSegment())
+			require.NoError(t, replica.Close())
+
+			require.Equal(t, before, mustReadFiles(t, dataDir, walDir))
+		})
+	}
+}
+
 // See https://github.com/influxdata/influxdb/v2/issues/14229
 func TestEngine_DeleteSeriesAfterCacheSnapshot(t *testing.T) {
 	for _, index := range tsdb.RegisteredIndexes() {
@@ -1825,6 +1874,97 @@ func TestEngine_LastModified(t *testing.T) {
 	}
 }
 
+func TestEngine_FileStats(t *testing.T) {
+	e, err := NewEngine(t, tsdb.TSI1IndexName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// mock the planner so compactions don't run during the test
+	e.CompactionPlan = &mockPlanner{}
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	if got, exp := e.FileStats(), (tsdb.ShardFileStats{}); got != exp {
+		t.Fatalf("unexpected stats of an empty engine: got %+v, exp %+v", got, exp)
+	}
+
+	p1 := MustParsePointString("cpu,host=A value=1.1 1000000000")
+	p2 := MustParsePointString("cpu,host=A value=1.2 2000000000")
+	for _, p := range []models.Point{p1, p2} {
+		if err := e.CreateSeriesIfNotExists(p.Key(), p.Name(), p.Tags()); err != nil {
+			t.Fatalf("create series index error: %v", err)
+		}
+	}
+	if err := e.WritePoints(context.Background(), []models.Point{p1, p2}); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+	if err := e.WriteSnapshot(); err != nil {
+		t.Fatalf("failed to snapshot: %s", err.Error())
+	}
+
+	if got, exp := e.FileStats(), (tsdb.ShardFileStats{TSMFiles: 1, CompactionLevel: 1}); got != exp {
+		t.Fatalf("unexpected stats after a snapshot: got %+v, exp %+v", got, exp)
+	}
+
+	// Deleting part of the series tombstones the file.
+	itr := &seriesIterator{keys: [][]byte{[]byte("cpu,host=A")}}
+	if err := e.DeleteSeriesRange(context.Background(), itr, 0, 1000000000); err != nil {
+		t.Fatalf("failed to delete series: %v", err)
+	}
+	stats := e.FileStats()
+	if stats.TombstoneFiles != 1 || stats.TombstoneSize == 0 {
+		t.Fatalf("expected a tombstone file, got %+v", stats)
+	}
+}
+
+func TestEngine_TombstoneStats(t *testing.T) {
+	e, err := NewEngine(t, tsdb.TSI1IndexName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// mock the planner so compactions don't run during the test
+	e.CompactionPlan = &mockPlanner{}
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	p1 := MustParsePointString("cpu,host=A value=1.1 1000000000")
+	p2 := MustParsePointString("cpu,host=B value=1.2 2000000000")
+	for _, p := range []models.Point{p1, p2} {
+		if err := e.CreateSeriesIfNotExists(p.Key(), p.Name(), p.Tags()); err != nil {
+			t.Fatalf("create series index error: %v", err)
+		}
+	}
+	if err := e.WritePoints(context.Background(), []models.Point{p1, p2}); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+	if err := e.WriteSnapshot(); err != nil {
+		t.Fatalf("failed to snapshot: %s", err.Error())
+	}
+
+	if got, exp := e.TombstoneStats(), (tsdb.ShardTombstoneStats{}); got != exp {
+		t.Fatalf("unexpected stats without deletes: got %+v, exp %+v", got, exp)
+	}
+
+	// Deleting a series leaves its block in the file until it is compacted.
+	itr := &seriesIterator{keys: [][]byte{[]byte("cpu,host=B")}}
+	if err := e.DeleteSeriesRange(context.Background(), itr, math.MinInt64, math.MaxInt64); err != nil {
+		t.Fatalf("failed to delete series: %v", err)
+	}
+	stats := e.TombstoneStats()
+	if stats.TombstoneFiles != 1 || stats.TombstoneSize == 0 || stats.TSMSize == 0 {
+		t.Fatalf("expected a tombstone file, got %+v", stats)
+	}
+	if stats.WastedSize <= 0 || stats.WastedSize >= stats.TSMSize {
+		t.Fatalf("expected part of the file to be wasted, got %+v", stats)
+	}
+}
+
 func TestEngine_SnapshotsDisabled(t *testing.T) {
 	sfile := MustOpenSeriesFile()
 	defer sfile.Close()
@@ -2791,6 +2931,28 @@ func (f *SeriesFile) Close() {
 	}
 }
 
+// mustReadFiles returns the contents of the files in the directories by
+// their path.
+func mustReadFiles(tb testing.TB, dirs ...string) map[string][]byte {
+	tb.Helper()
+
+	files := make(map[string][]byte)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		require.NoError(tb, err)
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			b, err := os.ReadFile(path)
+			require.NoError(tb, err)
+			files[path] = b
+		}
+	}
+	return files
+}
+
 // MustParsePointsString parses points from a string. Panic on error.
 func MustParsePointsString(buf string) []models.Point {
 	a, err := models.ParsePointsString(buf)
@@ -173,8 +173,10 @@ type FileStore struct {
 	dir               string
 
 	files           []TSMFile
-	tsmMMAPWillNeed bool          // If true then the kernel will be advised MMAP_WILLNEED for TSM files.
-	openLimiter     limiter.Fixed // limit the number of concurrent opening TSM files.
+	tsmMMAPWillNeed bool             // If true then the kernel will be advised MMAP_WILLNEED for TSM files.
+	openLimiter     limiter.Fixed    // limit the number of concurrent opening TSM files.
+	blockCache      *tsdb.BlockCache // decoded blocks shared with the other shards, nil when disabled.
+	readOnly        bool             // If true then corrupt TSM files are not renamed, as another process owns them.
 
 	logger       *zap.Logger // Logger to be used for important messages
 	traceLogger  *zap.Logger // Logger to be used when trace-logging is on.
@@ -347,6 +349,92 @@ func (f *FileStore) Count() int {
 	return len(f.files)
 }
 
+// fileStats counts the TSM files and their tombstones, and finds their highest
+// compaction level.
+func (f *FileStore) fileStats() tsdb.ShardFileStats {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	s := tsdb.ShardFileStats{TSMFiles: len(f.files)}
+	for _, file := range f.files {
+		// Files of a sequence over 4 were compacted by full or optimize
+		// compactions, which the planner considers level 4.
+		if _, seq, err := f.parseFileName(file.Path()); err == nil {
+			if seq > 4 {
+				seq = 4
+			}
+			if seq > s.CompactionLevel {
+				s.CompactionLevel = seq
+			}
+		}
+		if ts := file.TombstoneStats(); ts.TombstoneExists {
+			s.TombstoneFiles++
+			s.TombstoneSize += int64(ts.Size)
+		}
+	}
+	return s
+}
+
+// tombstoneStats reports the TSM files with tombstones and estimates the bytes
+// of them a compaction would reclaim. It reads the index of every file with
+// tombstones, so it is more expensive than fileStats.
+func (f *FileStore) tombstoneStats() tsdb.ShardTombstoneStats {
+	f.mu.RLock()
+	var files []TSMFile
+	for _, file := range f.files {
+		if file.TombstoneStats().TombstoneExists {
+			file.Ref()
+			files = append(files, file)
+		}
+	}
+	f.mu.RUnlock()
+
+	var s tsdb.ShardTombstoneStats
+	for _, file := range files {
+		s.TombstoneFiles++
+		s.TombstoneSize += int64(file.TombstoneStats().Size)
+		s.TSMSize += int64(file.Size())
+		if wasted := int64(file.Size()) - liveSize(file); wasted > 0 {
+			s.WastedSize += wasted
+		}
+		file.Unref()
+	}
+	return s
+}
+
+// liveSize estimates the bytes of the TSM file a compaction would keep: the
+// header and footer, and the blocks and index entries of the keys not
+// deleted, except the blocks entirely within a deleted time range.
+func liveSize(f TSMFile) int64 {
+	const headerSize, footerSize = 5, 8
+
+	size := int64(headerSize + footerSize)
+	var entries []IndexEntry
+	for i, n := 0, f.KeyCount(); i < n; i++ {
+		key, _ := f.KeyAt(i)
+		tombstones := f.TombstoneRange(key)
+		entries = f.ReadEntries(key, &entries)
+
+		size += int64(2 + len(key) + indexTypeSize + indexCountSize)
+		for _, e := range entries {
+			if !deletedBlock(tombstones, e) {
+				size += int64(e.Size) + indexEntrySize
+			}
+		}
+	}
+	return size
+}
+
+// deletedBlock reports whether a single deleted time range covers the block.
+func deletedBlock(tombstones []TimeRange, e IndexEntry) bool {
+	for _, t := range tombstones {
+		if t.Min <= e.MinTime && t.Max >= e.MaxTime {
+			return true
+		}
+	}
+	return false
+}
+
 // Files returns the slice of TSM files currently loaded. This is only used for
 // tests, and the files aren't guaranteed to stay valid in the presence of compactions.
 func (f *FileStore) Files() []TSMFile {
@@ -599,7 +687,7 @@ func (f *FileStore) Open(ctx context.Context) error {
 			defer f.openLimiter.Release()
 
 			start := time.Now()
-			df, err := NewTSMReader(file, WithMadviseWillNeed(f.tsmMMAPWillNeed))
+			df, err := NewTSMReader(file, WithMadviseWillNeed(f.tsmMMAPWillNeed), WithBlockCache(f.blockCache))
 			f.logger.Info("Opened file",
 				zap.String("path", file.Name()),
 				zap.Int("id", idx),
@@ -607,7 +695,12 @@ func (f *FileStore) Open(ctx context.Context) error {
 
 			// If we are unable to read a TSM file then log the error, rename
 			// the file, and continue loading the shard without it.
-			if err != nil {
+			if err != nil && f.readOnly {
+				f.logger.Error("Cannot read corrupt tsm file", zap.String("path", file.Name()), zap.Int("id", idx), zap.Error(err))
+				file.Close()
+				readerC <- &res{r: df, err: fmt.Errorf("cannot read corrupt file %s: %v", file.Name(), err)}
+				return
+			} else if err != nil {
 				f.logger.Error("Cannot read corrupt tsm file, renaming", zap.String("path", file.Name()), zap.Int("id", idx), zap.Error(err))
 				file.Close()
 				if e := os.Rename(file.Name(), file.Name()+"."+BadTSMFileExtension); e != nil {
@@ -839,7 +932,7 @@ func (f *FileStore) replace(oldFiles, newFiles []string, updatedFn func(r []TSMF
 			}
 		}
 
-		tsm, err := NewTSMReader(fd, WithMadviseWillNeed(f.tsmMMAPWillNeed))
+		tsm, err := NewTSMReader(fd, WithMadviseWillNeed(f.tsmMMAPWillNeed), WithBlockCache(f.blockCache))
 		if err != nil {
 			if newName != oldName {
 				if err1 := os.Rename(newName, oldName); err1 != nil {
@@ -19,10 +19,26 @@ func (t *TSMReader) ReadFloatBlockAt(entry *IndexEntry, vals *[]FloatValue) ([]F
 }
 
 // ReadFloatArrayBlockAt fills vals with the float values corresponding to the given index entry.
+// The values are read from the block cache when the reader has one.
 func (t *TSMReader) ReadFloatArrayBlockAt(entry *IndexEntry, vals *tsdb.FloatArray) error {
+	key := t.blockCacheKey(entry)
+	if v, ok := t.blockCache.Get(key); ok {
+		cached := v.(*tsdb.FloatArray)
+		vals.Timestamps = append(vals.Timestamps[:0], cached.Timestamps...)
+		vals.Values = append(vals.Values[:0], cached.Values...)
+		return nil
+	}
+
 	t.mu.RLock()
 	err := t.accessor.readFloatArrayBlock(entry, vals)
 	t.mu.RUnlock()
+	if err == nil && t.blockCache != nil {
+		cached := &tsdb.FloatArray{
+			Timestamps: append(vals.Timestamps[:0:0], vals.Timestamps...),
+			Values:     append(vals.Values[:0:0], vals.Values...),
+		}
+		t.blockCache.Put(key, cached, arrayBlockSize(cached))
+	}
 	return err
 }
 
@@ -35,10 +51,26 @@ func (t *TSMReader) ReadIntegerBlockAt(entry *IndexEntry, vals *[]IntegerValue)
 }
 
 // ReadIntegerArrayBlockAt fills vals with the integer values corresponding to the given index entry.
+// The values are read from the block cache when the reader has one.
 func (t *TSMReader) ReadIntegerArrayBlockAt(entry *IndexEntry, vals *tsdb.IntegerArray) error {
+	key := t.blockCacheKey(entry)
+	if v, ok := t.blockCache.Get(key); ok {
+		cached := v.(*tsdb.IntegerArray)
+		vals.Timestamps = append(vals.Timestamps[:0], cached.Timestamps...)
+		vals.Values = append(vals.Values[:0], cached.Values...)
+		return nil
+	}
+
 	t.mu.RLock()
 	err := t.accessor.readIntegerArrayBlock(entry, vals)
 	t.mu.RUnlock()
+	if err == nil && t.blockCache != nil {
+		cached := &tsdb.IntegerArray{
+			Timestamps: append(vals.Timestamps[:0:0], vals.Timestamps...),
+			Values:     append(vals.Values[:0:0], vals.Values...),
+		}
+		t.blockCache.Put(key, cached, arrayBlockSize(cached))
+	}
 	return err
 }
 
@@ -51,10 +83,26 @@ func (t *TSMReader) ReadUnsignedBlockAt(entry *IndexEntry, vals *[]UnsignedValue
 }
 
 // ReadUnsignedArrayBlockAt fills vals with the unsigned values corresponding to the given index entry.
+// The values are read from the block cache when the reader has one.
 func (t *TSMReader) ReadUnsignedArrayBlockAt(entry *IndexEntry, vals *tsdb.UnsignedArray) error {
+	key := t.blockCacheKey(entry)
+	if v, ok := t.blockCache.Get(key); ok {
+		cached := v.(*tsdb.UnsignedArray)
+		vals.Timestamps = append(vals.Timestamps[:0], cached.Timestamps...)
+		vals.Values = append(vals.Values[:0], cached.Values...)
+		return nil
+	}
+
 	t.mu.RLock()
 	err := t.accessor.readUnsignedArrayBlock(entry, vals)
 	t.mu.RUnlock()
+	if err == nil && t.blockCache != nil {
+		cached := &tsdb.UnsignedArray{
+			Timestamps: append(vals.Timestamps[:0:0], vals.Timestamps...),
+			Values:     append(vals.Values[:0:0], vals.Values...),
+		}
+		t.blockCache.Put(key, cached, arrayBlockSize(cached))
+	}
 	return err
 }
 
@@ -67,10 +115,26 @@ func (t *TSMReader) ReadStringBlockAt(entry *IndexEntry, vals *[]StringValue) ([
 }
 
 // ReadStringArrayBlockAt fills vals with the string values corresponding to the given index entry.
+// The values are read from the block cache when the reader has one.
 func (t *TSMReader) ReadStringArrayBlockAt(entry *IndexEntry, vals *tsdb.StringArray) error {
+	key := t.blockCacheKey(entry)
+	if v, ok := t.blockCache.Get(key); ok {
+		cached := v.(*tsdb.StringArray)
+		vals.Timestamps = append(vals.Timestamps[:0], cached.Timestamps...)
+		vals.Values = append(vals.Values[:0], cached.Values...)
+		return nil
+	}
+
 	t.mu.RLock()
 	err := t.accessor.readStringArrayBlock(entry, vals)
 	t.mu.RUnlock()
+	if err == nil && t.blockCache != nil {
+		cached := &tsdb.StringArray{
+			Timestamps: append(vals.Timestamps[:0:0], vals.Timestamps...),
+			Values:     append(vals.Values[:0:0], vals.Values...),
+		}
+		t.blockCache.Put(key, cached, arrayBlockSize(cached))
+	}
 	return err
 }
 
@@ -83,10 +147,26 @@ func (t *TSMReader) ReadBooleanBlockAt(entry *IndexEntry, vals *[]BooleanValue)
 }
 
 // ReadBooleanArrayBlockAt fills vals with the boolean values corresponding to the given index entry.
+// The values are read from the block cache when the reader has one.
 func (t *TSMReader) ReadBooleanArrayBlockAt(entry *IndexEntry, vals *tsdb.BooleanArray) error {
+	key := t.blockCacheKey(entry)
+	if v, ok := t.blockCache.Get(key); ok {
+		cached := v.(*tsdb.BooleanArray)
+		vals.Timestamps = append(vals.Timestamps[:0], cached.Timestamps...)
+		vals.Values = append(vals.Values[:0], cached.Values...)
+		return nil
+	}
+
 	t.mu.RLock()
 	err := t.accessor.readBooleanArrayBlock(entry, vals)
 	t.mu.RUnlock()
+	if err == nil && t.blockCache != nil {
+		cached := &tsdb.BooleanArray{
+			Timestamps: append(vals.Timestamps[:0:0], vals.Timestamps...),
+			Values:     append(vals.Values[:0:0], vals.Values...),
+		}
+		t.blockCache.Put(key, cached, arrayBlockSize(cached))
+	}
 	return err
 }
 
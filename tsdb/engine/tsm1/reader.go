@@ -33,6 +33,11 @@ type TSMReader struct {
 	madviseWillNeed bool // Hint to the kernel with MADV_WILLNEED.
 	mu              sync.RWMutex
 
+	// id identifies the file in the block cache, which keeps decoded blocks
+	// of the file when set.
+	id         uint64
+	blockCache *tsdb.BlockCache
+
 	// accessor provides access and decoding of blocks for the reader.
 	accessor blockAccessor
 
@@ -218,9 +223,19 @@ var WithMadviseWillNeed = func(willNeed bool) tsmReaderOption {
 	}
 }
 
+// WithBlockCache is an option for caching the decoded blocks of the reader.
+var WithBlockCache = func(c *tsdb.BlockCache) tsmReaderOption {
+	return func(r *TSMReader) {
+		r.blockCache = c
+	}
+}
+
+// tsmReaderIDs numbers the readers to key their blocks in the block cache.
+var tsmReaderIDs uint64
+
 // NewTSMReader returns a new TSMReader from the given file.
 func NewTSMReader(f *os.File, options ...tsmReaderOption) (*TSMReader, error) {
-	t := &TSMReader{}
+	t := &TSMReader{id: atomic.AddUint64(&tsmReaderIDs, 1)}
 	for _, option := range options {
 		option(t)
 	}
@@ -370,10 +385,37 @@ func (t *TSMReader) Close() error {
 	if err := t.accessor.close(); err != nil {
 		return err
 	}
+	t.blockCache.RemoveFile(t.id)
 
 	return t.index.Close()
 }
 
+func (t *TSMReader) blockCacheKey(entry *IndexEntry) tsdb.BlockCacheKey {
+	return tsdb.BlockCacheKey{File: t.id, Offset: entry.Offset}
+}
+
+// arrayBlockSize approximates the bytes a decoded block takes in memory.
+func arrayBlockSize(block interface{}) int {
+	switch a := block.(type) {
+	case *tsdb.FloatArray:
+		return 16 * len(a.Timestamps)
+	case *tsdb.IntegerArray:
+		return 16 * len(a.Timestamps)
+	case *tsdb.UnsignedArray:
+		return 16 * len(a.Timestamps)
+	case *tsdb.BooleanArray:
+		return 9 * len(a.Timestamps)
+	case *tsdb.StringArray:
+		n := 24 * len(a.Timestamps)
+		for _, v := range a.Values {
+			n += len(v)
+		}
+		return n
+	default:
+		return 0
+	}
+}
+
 // Ref records a usage of this TSMReader.  If there are active references
 // when the reader is closed or removed, the reader will remain open until
 // there are no more references.
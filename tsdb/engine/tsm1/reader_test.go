@@ -5,10 +5,12 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
 
+	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/stretchr/testify/require"
 )
 
@@ -211,6 +213,66 @@ func TestTSMReader_MMAP_Read(t *testing.T) {
 	}
 }
 
+func TestTSMReader_BlockCache(t *testing.T) {
+	dir := mustTempDir()
+	defer os.RemoveAll(dir)
+	f := mustTempFile(dir)
+	defer f.Close()
+
+	w, err := NewTSMWriter(f)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	if err := w.Write([]byte("cpu"), []Value{NewValue(1, 1.0), NewValue(2, 2.0)}); err != nil {
+		t.Fatalf("unexpected error writing: %v", err)
+	}
+	if err := w.WriteIndex(); err != nil {
+		t.Fatalf("unexpected error writing index: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error closing: %v", err)
+	}
+
+	f, err = os.Open(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error open file: %v", err)
+	}
+
+	cache := tsdb.NewBlockCache(1 << 20)
+	r, err := NewTSMReader(f, WithBlockCache(cache))
+	if err != nil {
+		t.Fatalf("unexpected error created reader: %v", err)
+	}
+
+	entries := r.Entries([]byte("cpu"))
+	if got, exp := len(entries), 1; got != exp {
+		t.Fatalf("entries length mismatch: got %v, exp %v", got, exp)
+	}
+
+	exp := &tsdb.FloatArray{Timestamps: []int64{1, 2}, Values: []float64{1.0, 2.0}}
+	for i := 0; i < 2; i++ {
+		var vals tsdb.FloatArray
+		if err := r.ReadFloatArrayBlockAt(&entries[0], &vals); err != nil {
+			t.Fatalf("unexpected error reading block: %v", err)
+		}
+		if !reflect.DeepEqual(&vals, exp) {
+			t.Fatalf("read values mismatch: got %v, exp %v", vals, exp)
+		}
+		// callers modify the values they read, which must not change the cache
+		vals.Values[0] = 10
+	}
+	if got, exp := cache.Len(), 1; got != exp {
+		t.Fatalf("cached blocks mismatch: got %v, exp %v", got, exp)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("unexpected error closing reader: %v", err)
+	}
+	if got, exp := cache.Len(), 0; got != exp {
+		t.Fatalf("cached blocks after close mismatch: got %v, exp %v", got, exp)
+	}
+}
+
 func TestTSMReader_MMAP_Keys(t *testing.T) {
 	dir := mustTempDir()
 	defer os.RemoveAll(dir)
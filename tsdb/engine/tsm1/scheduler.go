@@ -23,6 +23,10 @@ func newScheduler(activeCompactions *compactionCounter, maxConcurrency int) *sch
 	}
 }
 
+func (s *scheduler) setMaxConcurrency(maxConcurrency int) {
+	s.maxConcurrency = maxConcurrency
+}
+
 func (s *scheduler) setDepth(level, depth int) {
 	level = level - 1
 	if level < 0 || level > len(s.queues) {
@@ -67,6 +67,9 @@ var (
 	// ErrWALCorrupt is returned when reading a corrupt WAL entry.
 	ErrWALCorrupt = fmt.Errorf("corrupted WAL entry")
 
+	// ErrWALReadOnly is returned when attempting to write to a read-only WAL.
+	ErrWALReadOnly = fmt.Errorf("WAL is read-only")
+
 	defaultWaitingWALWrites = runtime.GOMAXPROCS(0) * 2
 
 	// bytePool is a shared bytes pool buffer re-cycle []byte slices to reduce allocations.
@@ -97,6 +100,10 @@ type WAL struct {
 	// is opened if a non-default value is required.
 	syncDelay time.Duration
 
+	// readOnly opens the WAL without modifying its segment files, which are
+	// written by another process. It must be set before the WAL is opened.
+	readOnly bool
+
 	// WALOutput is the writer used by the logger.
 	logger       *zap.Logger // Logger to be used for important messages
 	traceLogger  *zap.Logger // Logger to be used when trace-logging is on.
@@ -240,8 +247,10 @@ func (l *WAL) Open() error {
 	l.traceLogger.Info("tsm1 WAL starting", zap.Int("segment_size", l.SegmentSize))
 	l.traceLogger.Info("tsm1 WAL writing", zap.String("path", l.path))
 
-	if err := os.MkdirAll(l.path, 0777); err != nil {
-		return err
+	if !l.readOnly {
+		if err := os.MkdirAll(l.path, 0777); err != nil {
+			return err
+		}
 	}
 
 	segments, err := segmentFileNames(l.path)
@@ -249,7 +258,9 @@ func (l *WAL) Open() error {
 		return err
 	}
 
-	if len(segments) > 0 {
+	// The last segment of a read-only WAL may still be appended to by the
+	// process writing it, so it is neither removed nor opened for writing.
+	if len(segments) > 0 && !l.readOnly {
 		lastSegment := segments[len(segments)-1]
 		id, err := idFromFileName(lastSegment)
 		if err != nil {
@@ -464,6 +475,10 @@ func (l *WAL) writeToLog(ctx context.Context, entry WALEntry) (int, error) {
 	defer l.limiter.Release()
 	cancel()
 
+	if l.readOnly {
+		return -1, ErrWALReadOnly
+	}
+
 	bytes := bytesPool.Get(entry.MarshalSize())
 
 	b, err := entry.Encode(bytes)
@@ -547,6 +562,9 @@ func (l *WAL) rollSegment() error {
 func (l *WAL) CloseSegment() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if l.readOnly {
+		return ErrWALReadOnly
+	}
 	if l.currentSegmentWriter == nil || l.currentSegmentWriter.size > 0 {
 		if err := l.newSegmentFile(); err != nil {
 			// A drop database or RP call could trigger this error if writes were in-flight
@@ -356,8 +356,13 @@ func (t *ticker) Stop() {
 	t.wg.Wait()
 }
 
-// Path returns the path set on the shard when it was created.
-func (s *Shard) Path() string { return s.path }
+// Path returns the path of the shard. It changes when the shard is moved to
+// another data directory.
+func (s *Shard) Path() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.path
+}
 
 // Open initializes and opens the shard's store.
 func (s *Shard) Open(ctx context.Context) error {
@@ -618,6 +623,20 @@ func (s *Shard) SetCompactionsEnabled(enabled bool) {
 	engine.SetCompactionsEnabled(enabled)
 }
 
+// SetLevelCompactionsEnabled enables or disables the level compactions of the
+// shard, leaving snapshots of its cache running. Level compactions are not
+// enabled on a disabled shard.
+func (s *Shard) SetLevelCompactionsEnabled(enabled bool) {
+	s.mu.RLock()
+	engine := s._engine
+	skip := enabled && (!s.enabled || s.CompactionDisabled)
+	s.mu.RUnlock()
+	if engine == nil || skip {
+		return
+	}
+	engine.SetLevelCompactionsEnabled(enabled)
+}
+
 // DiskSize returns the size on disk of this shard.
 func (s *Shard) DiskSize() (int64, error) {
 	s.mu.RLock()
@@ -631,6 +650,63 @@ func (s *Shard) DiskSize() (int64, error) {
 	return size, nil
 }
 
+// ShardFileStats summarizes the TSM files of a shard.
+type ShardFileStats struct {
+	TSMFiles int
+	// CompactionLevel is the highest compaction level of the TSM files, from
+	// 1 for files written from the cache to 4 for fully compacted files.
+	CompactionLevel int
+	FullyCompacted  bool
+	// TombstoneFiles is the number of TSM files with deleted data pending
+	// removal by a compaction, recorded in TombstoneSize bytes.
+	TombstoneFiles int
+	TombstoneSize  int64
+}
+
+// FileStats summarizes the TSM files of the shard.
+func (s *Shard) FileStats() (ShardFileStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// Like DiskSize, report the files of disabled shards too.
+	if s._engine == nil {
+		return ShardFileStats{}, ErrEngineClosed
+	}
+	return s._engine.FileStats(), nil
+}
+
+// ShardTombstoneStats reports the deleted data of a shard that is still on
+// disk. Compactions remove deleted data from the TSM files, so it only
+// takes space until the files with tombstones are compacted.
+type ShardTombstoneStats struct {
+	TombstoneFiles int
+	TombstoneSize  int64
+	// TSMSize is the size of the TSM files with tombstones.
+	TSMSize int64
+	// WastedSize estimates the bytes of the TSM files taken by deleted data.
+	WastedSize int64
+}
+
+// TombstoneStats reports the deleted data of the shard pending removal by a
+// compaction.
+func (s *Shard) TombstoneStats() (ShardTombstoneStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s._engine == nil {
+		return ShardTombstoneStats{}, ErrEngineClosed
+	}
+	return s._engine.TombstoneStats(), nil
+}
+
+// MemoryUsage returns the in-memory size of the shard's cache and index.
+func (s *Shard) MemoryUsage() (cacheBytes, indexBytes int64, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s._engine == nil || s.index == nil {
+		return 0, 0, ErrEngineClosed
+	}
+	return s._engine.CacheSize(), int64(s.index.Bytes()), nil
+}
+
 // FieldCreate holds information for a field to create on a measurement.
 type FieldCreate struct {
 	Measurement []byte
@@ -655,7 +731,7 @@ func (s *Shard) WritePoints(ctx context.Context, points []models.Point) (rErr er
 		}
 	}()
 
-	points, fieldsToCreate, err := s.validateSeriesAndFields(points)
+	points, fieldsToCreate, err := s.validateSeriesAndFields(points, DroppedPointsFromContext(ctx))
 	if err != nil {
 		if _, ok := err.(PartialWriteError); !ok {
 			return err
@@ -680,7 +756,8 @@ func (s *Shard) WritePoints(ctx context.Context, points []models.Point) (rErr er
 }
 
 // validateSeriesAndFields checks which series and fields are new and whose metadata should be saved and indexed.
-func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point, []*FieldCreate, error) {
+// The points it drops are recorded in droppedPoints, which may be nil.
+func (s *Shard) validateSeriesAndFields(points []models.Point, droppedPoints *DroppedPoints) ([]models.Point, []*FieldCreate, error) {
 	var (
 		fieldsToCreate []*FieldCreate
 		err            error
@@ -703,20 +780,24 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 		// Drop any series w/ a "time" tag, these are illegal
 		if v := tags.Get(timeBytes); v != nil {
 			dropped++
+			r := fmt.Sprintf(
+				"invalid tag key: input tag \"%s\" on measurement \"%s\" is invalid",
+				"time", string(p.Name()))
 			if reason == "" {
-				reason = fmt.Sprintf(
-					"invalid tag key: input tag \"%s\" on measurement \"%s\" is invalid",
-					"time", string(p.Name()))
+				reason = r
 			}
+			droppedPoints.Add(p, r)
 			continue
 		}
 
 		// Drop any series with invalid unicode characters in the key.
 		if validateKeys && !models.ValidKeyTokens(string(p.Name()), tags) {
 			dropped++
+			r := fmt.Sprintf("key contains invalid unicode: \"%s\"", string(p.Key()))
 			if reason == "" {
-				reason = fmt.Sprintf("key contains invalid unicode: \"%s\"", string(p.Key()))
+				reason = r
 			}
+			droppedPoints.Add(p, r)
 			continue
 		}
 
@@ -734,17 +815,22 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 	}
 
 	// Add new series. Check for partial writes.
-	var droppedKeys [][]byte
+	var (
+		droppedKeys  [][]byte
+		seriesReason string
+	)
 	if err := engine.CreateSeriesListIfNotExists(keys, names, tagsSlice); err != nil {
 		switch err := err.(type) {
 		// (DSB) This was previously *PartialWriteError. Now catch pointer and value types.
 		case *PartialWriteError:
 			reason = err.Reason
+			seriesReason = err.Reason
 			dropped += err.Dropped
 			droppedKeys = err.DroppedKeys
 			s.stats.writesDropped.Add(float64(err.Dropped))
 		case PartialWriteError:
 			reason = err.Reason
+			seriesReason = err.Reason
 			dropped += err.Dropped
 			droppedKeys = err.DroppedKeys
 			s.stats.writesDropped.Add(float64(err.Dropped))
@@ -766,17 +852,20 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 			break
 		}
 		if !validField {
+			r := fmt.Sprintf(
+				"invalid field name: input field \"%s\" on measurement \"%s\" is invalid",
+				"time", string(p.Name()))
 			if reason == "" {
-				reason = fmt.Sprintf(
-					"invalid field name: input field \"%s\" on measurement \"%s\" is invalid",
-					"time", string(p.Name()))
+				reason = r
 			}
+			droppedPoints.Add(p, r)
 			dropped++
 			continue
 		}
 
 		// Skip any points whos keys have been dropped. Dropped has already been incremented for them.
 		if len(droppedKeys) > 0 && bytesutil.Contains(droppedKeys, keys[i]) {
+			droppedPoints.Add(p, seriesReason)
 			continue
 		}
 
@@ -790,6 +879,7 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 				if reason == "" {
 					reason = err.Reason
 				}
+				droppedPoints.Add(p, err.Reason)
 				dropped += err.Dropped
 				s.stats.writesDropped.Add(float64(err.Dropped))
 			default:
@@ -313,6 +313,70 @@ func TestWriteTimeField(t *testing.T) {
 	}
 }
 
+func TestShard_WritePoints_DroppedPoints(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := filepath.Join(tmpDir, "shard")
+	tmpWal := filepath.Join(tmpDir, "wal")
+
+	sfile := MustOpenSeriesFile(t)
+	defer sfile.Close()
+
+	opts := tsdb.NewEngineOptions()
+	opts.Config.WALDir = filepath.Join(tmpDir, "wal")
+
+	sh := tsdb.NewShard(1, tmpShard, tmpWal, sfile.SeriesFile, opts)
+	if err := sh.Open(context.Background()); err != nil {
+		t.Fatalf("error opening shard: %s", err.Error())
+	}
+	defer sh.Close()
+
+	if err := sh.WritePoints(context.Background(), []models.Point{models.MustNewPoint(
+		"cpu",
+		models.NewTags(map[string]string{}),
+		map[string]interface{}{"value": 1.0},
+		time.Unix(1, 2),
+	)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	conflict := models.MustNewPoint(
+		"cpu",
+		models.NewTags(map[string]string{}),
+		map[string]interface{}{"value": "one"},
+		time.Unix(2, 2),
+	)
+	timeTag := models.MustNewPoint(
+		"cpu",
+		models.NewTags(map[string]string{"time": "now"}),
+		map[string]interface{}{"value": 1.0},
+		time.Unix(2, 2),
+	)
+	valid := models.MustNewPoint(
+		"cpu",
+		models.NewTags(map[string]string{}),
+		map[string]interface{}{"value": 2.0},
+		time.Unix(2, 2),
+	)
+
+	ctx, droppedPoints := tsdb.ContextWithDroppedPoints(context.Background())
+	err := sh.WritePoints(ctx, []models.Point{conflict, timeTag, valid})
+	if perr, ok := err.(tsdb.PartialWriteError); !ok || perr.Dropped != 2 {
+		t.Fatalf("expected partial write error dropping 2 points, got %v", err)
+	}
+
+	dropped := droppedPoints.Points()
+	if len(dropped) != 2 {
+		t.Fatalf("got %d dropped points, exp 2", len(dropped))
+	}
+	if dropped[0].Point != timeTag || !strings.Contains(dropped[0].Reason, "invalid tag key") {
+		t.Errorf("unexpected dropped point %v: %s", dropped[0].Point, dropped[0].Reason)
+	}
+	if dropped[1].Point != conflict || !strings.Contains(dropped[1].Reason, tsdb.ErrFieldTypeConflict.Error()) {
+		t.Errorf("unexpected dropped point %v: %s", dropped[1].Point, dropped[1].Reason)
+	}
+}
+
 func TestShardWriteAddNewField(t *testing.T) {
 	tmpDir, _ := ioutil.TempDir("", "shard_test")
 	defer os.RemoveAll(tmpDir)
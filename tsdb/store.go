@@ -83,6 +83,9 @@ type Store struct {
 	// This prevents new shards from being created while old ones are being deleted.
 	pendingShardDeletes map[uint64]struct{}
 
+	// Maintains a set of shards that are being moved between data dirs.
+	movingShards map[uint64]struct{}
+
 	// Epoch tracker helps serialize writes and deletes that may conflict. It
 	// is stored by shard.
 	epochs map[uint64]*epochTracker
@@ -95,6 +98,16 @@ type Store struct {
 	closing chan struct{}
 	wg      sync.WaitGroup
 	opened  bool
+
+	// reloadMu serializes reloads, and reloaded holds the fingerprints of
+	// the shard and series file directories as of the last reload.
+	reloadMu sync.Mutex
+	reloaded map[string]dirFingerprint
+
+	// compactionLimiter and compactionRate are shared by the compactions
+	// of all shards. Their limits can be changed at runtime.
+	compactionLimiter *limiter.Resizable
+	compactionRate    *limiter.AdjustableRate
 }
 
 // NewStore returns a new store with the given path and a default configuration.
@@ -105,6 +118,7 @@ func NewStore(path string) *Store {
 		path:                path,
 		sfiles:              make(map[string]*SeriesFile),
 		pendingShardDeletes: make(map[uint64]struct{}),
+		movingShards:        make(map[uint64]struct{}),
 		epochs:              make(map[uint64]*epochTracker),
 		EngineOptions:       NewEngineOptions(),
 		Logger:              zap.NewNop(),
@@ -268,47 +282,25 @@ func (s *Store) loadShards(ctx context.Context) error {
 	// Limit the number of concurrent TSM files to be opened to the number of cores.
 	s.EngineOptions.OpenLimiter = limiter.NewFixed(runtime.GOMAXPROCS(0))
 
-	// Setup a shared limiter for compactions
-	lim := s.EngineOptions.Config.MaxConcurrentCompactions
-	if lim == 0 {
-		lim = runtime.GOMAXPROCS(0) / 2 // Default to 50% of cores for compactions
+	// Setup shared limiters for compactions
+	limits := CompactionLimits{
+		MaxConcurrent:   s.EngineOptions.Config.MaxConcurrentCompactions,
+		Throughput:      int(s.EngineOptions.Config.CompactThroughput),
+		ThroughputBurst: int(s.EngineOptions.Config.CompactThroughputBurst),
+	}.normalize()
+	s.compactionLimiter = limiter.NewResizable(limits.MaxConcurrent)
+	s.compactionRate = limiter.NewAdjustableRate(limits.Throughput, limits.ThroughputBurst)
+	s.EngineOptions.CompactionLimiter = s.compactionLimiter
+	s.EngineOptions.CompactionThroughputLimiter = s.compactionRate
 
-		if lim < 1 {
-			lim = 1
-		}
-	}
+	s.Logger.Info("Compaction settings", limits.logFields()...)
 
-	// Don't allow more compactions to run than cores.
-	if lim > runtime.GOMAXPROCS(0) {
-		lim = runtime.GOMAXPROCS(0)
-	}
-
-	s.EngineOptions.CompactionLimiter = limiter.NewFixed(lim)
-
-	compactionSettings := []zapcore.Field{zap.Int("max_concurrent_compactions", lim)}
-	throughput := int(s.EngineOptions.Config.CompactThroughput)
-	throughputBurst := int(s.EngineOptions.Config.CompactThroughputBurst)
-	if throughput > 0 {
-		if throughputBurst < throughput {
-			throughputBurst = throughput
-		}
-
-		compactionSettings = append(
-			compactionSettings,
-			zap.Int("throughput_bytes_per_second", throughput),
-			zap.Int("throughput_bytes_per_second_burst", throughputBurst),
-		)
-		s.EngineOptions.CompactionThroughputLimiter = limiter.NewRate(throughput, throughputBurst)
-	} else {
-		compactionSettings = append(
-			compactionSettings,
-			zap.String("throughput_bytes_per_second", "unlimited"),
-			zap.String("throughput_bytes_per_second_burst", "unlimited"),
-		)
+	// Setup the block cache shared by the shards
+	if size := s.EngineOptions.Config.BlockCacheMaxMemorySize; size > 0 {
+		s.EngineOptions.BlockCache = NewBlockCache(uint64(size))
+		s.Logger.Info("Block cache enabled", zap.Uint64("max_memory_size", uint64(size)))
 	}
 
-	s.Logger.Info("Compaction settings", compactionSettings...)
-
 	log, logEnd := logger.NewOperation(context.TODO(), s.Logger, "Open store", "tsdb_open")
 	defer logEnd()
 
@@ -347,7 +339,6 @@ func (s *Store) loadShards(ctx context.Context) error {
 		}
 
 		for _, rp := range rpDirs {
-			rpPath := filepath.Join(s.path, db.Name(), rp.Name())
 			if !rp.IsDir() {
 				log.Info("Skipping retention policy dir", zap.String("name", rp.Name()), zap.String("reason", "not a directory"))
 				continue
@@ -363,21 +354,14 @@ func (s *Store) loadShards(ctx context.Context) error {
 				continue
 			}
 
-			shardDirs, err := ioutil.ReadDir(rpPath)
+			shardDirs, err := s.shardDirs(log, db.Name(), rp.Name())
 			if err != nil {
 				return err
 			}
 
-			for _, sh := range shardDirs {
-				// Series file should not be in a retention policy but skip just in case.
-				if sh.Name() == SeriesFileDirectory {
-					log.Warn("Skipping series file in retention policy dir", zap.String("path", filepath.Join(s.path, db.Name(), rp.Name())))
-					continue
-				}
-
+			for sh, path := range shardDirs {
 				n++
-				go func(db, rp, sh string) {
-					path := filepath.Join(s.path, db, rp, sh)
+				go func(db, rp, sh, path string) {
 					walPath := filepath.Join(s.EngineOptions.Config.WALDir, db, rp, sh)
 
 					if err := t.Take(ctx); err != nil {
@@ -403,20 +387,8 @@ func (s *Store) loadShards(ctx context.Context) error {
 						return
 					}
 
-					// Copy options and assign shared index.
-					opt := s.EngineOptions
-
-					// Provide an implementation of the ShardIDSets
-					opt.SeriesIDSets = shardSet{store: s, db: db}
-
 					// Open engine.
-					shard := NewShard(shardID, path, walPath, sfile, opt)
-
-					// Disable compactions, writes and queries until all shards are loaded
-					shard.EnableOnOpen = false
-					shard.CompactionDisabled = s.EngineOptions.CompactionDisabled
-					shard.WithLogger(s.baseLogger)
-
+					shard := s.newLoadedShard(db, shardID, path, walPath, sfile)
 					err = shard.Open(ctx)
 					if err != nil {
 						log.Error("Failed to open shard", logger.Shard(shardID), zap.Error(err))
@@ -426,7 +398,7 @@ func (s *Store) loadShards(ctx context.Context) error {
 
 					resC <- &res{s: shard}
 					log.Info("Opened shard", zap.String("index_version", shard.IndexType()), zap.String("path", path), zap.Duration("duration", time.Since(start)))
-				}(db.Name(), rp.Name(), sh.Name())
+				}(db.Name(), rp.Name(), sh, path)
 			}
 		}
 	}
@@ -471,6 +443,67 @@ func (s *Store) loadShards(ctx context.Context) error {
 	return nil
 }
 
+// dataDirs returns the directories shards are stored in, starting with the
+// store's path.
+func (s *Store) dataDirs() []string {
+	dirs := append([]string{s.path}, s.EngineOptions.Config.ExtraDirs...)
+	if dir := s.EngineOptions.Config.SystemBucketDir; dir != "" {
+		for _, d := range dirs {
+			if filepath.Clean(d) == filepath.Clean(dir) {
+				return dirs
+			}
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// newShardDir returns the data directory new shards of the database are
+// created in.
+func (s *Store) newShardDir(database string) string {
+	dir := s.EngineOptions.Config.SystemBucketDir
+	if dir == "" || s.EngineOptions.SystemDatabase == nil || !s.EngineOptions.SystemDatabase(database) {
+		return s.path
+	}
+	return dir
+}
+
+// shardDirs returns the paths of the shards of a retention policy in all
+// data directories, keyed by shard directory name. Shards being moved
+// between data directories are skipped.
+func (s *Store) shardDirs(log *zap.Logger, db, rp string) (map[string]string, error) {
+	paths := make(map[string]string)
+	for i, dir := range s.dataDirs() {
+		rpPath := filepath.Join(dir, db, rp)
+		shardDirs, err := ioutil.ReadDir(rpPath)
+		if os.IsNotExist(err) && i > 0 {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, sh := range shardDirs {
+			// Series file should not be in a retention policy but skip just in case.
+			if sh.Name() == SeriesFileDirectory {
+				log.Warn("Skipping series file in retention policy dir", zap.String("path", rpPath))
+				continue
+			}
+
+			if isShardMoveDir(sh.Name()) {
+				log.Warn("Skipping shard left over by an interrupted move", zap.String("path", filepath.Join(rpPath, sh.Name())))
+				continue
+			}
+
+			if prev, ok := paths[sh.Name()]; ok {
+				log.Warn("Skipping shard found in multiple data dirs", zap.String("path", filepath.Join(rpPath, sh.Name())), zap.String("using", prev))
+				continue
+			}
+			paths[sh.Name()] = filepath.Join(rpPath, sh.Name())
+		}
+	}
+	return paths, nil
+}
+
 // Close closes the store and all associated shards. After calling Close accessing
 // shards through the Store will result in ErrStoreClosed being returned.
 func (s *Store) Close() error {
@@ -508,6 +541,24 @@ func (s *Store) Close() error {
 	return nil
 }
 
+// newLoadedShard returns a shard found on disk, which is opened disabled so
+// that it is not written or queried until it is added to the store.
+func (s *Store) newLoadedShard(db string, id uint64, path, walPath string, sfile *SeriesFile) *Shard {
+	// Copy options and assign shared index.
+	opt := s.EngineOptions
+
+	// Provide an implementation of the ShardIDSets
+	opt.SeriesIDSets = shardSet{store: s, db: db}
+
+	shard := NewShard(id, path, walPath, sfile, opt)
+
+	// Disable compactions, writes and queries until all shards are loaded
+	shard.EnableOnOpen = false
+	shard.CompactionDisabled = s.EngineOptions.CompactionDisabled
+	shard.WithLogger(s.baseLogger)
+	return shard
+}
+
 // epochsForShards returns a copy of the epoch trackers only including what is necessary
 // for the provided shards. Must be called under the lock.
 func (s *Store) epochsForShards(shards []*Shard) map[uint64]*epochTracker {
@@ -617,8 +668,13 @@ func (s *Store) CreateShard(ctx context.Context, database, retentionPolicy strin
 	}
 
 	// Create the db and retention policy directories if they don't exist.
-	if err := os.MkdirAll(filepath.Join(s.path, database, retentionPolicy), 0700); err != nil {
-		return err
+	// They always exist in the store's path, which holds the series file
+	// and is scanned for the databases to load.
+	dataDir := s.newShardDir(database)
+	for _, dir := range []string{s.path, dataDir} {
+		if err := os.MkdirAll(filepath.Join(dir, database, retentionPolicy), 0700); err != nil {
+			return err
+		}
 	}
 
 	// Create the WAL directory.
@@ -637,7 +693,7 @@ func (s *Store) CreateShard(ctx context.Context, database, retentionPolicy strin
 	opt := s.EngineOptions
 	opt.SeriesIDSets = shardSet{store: s, db: database}
 
-	path := filepath.Join(s.path, database, retentionPolicy, strconv.FormatUint(shardID, 10))
+	path := filepath.Join(dataDir, database, retentionPolicy, strconv.FormatUint(shardID, 10))
 	shard := NewShard(shardID, path, walPath, sfile, opt)
 	shard.WithLogger(s.baseLogger)
 	shard.EnableOnOpen = enabled
@@ -768,7 +824,7 @@ func (s *Store) DeleteShard(shardID uint64) error {
 	}
 
 	// Remove the on-disk shard data.
-	if err := os.RemoveAll(sh.path); err != nil {
+	if err := os.RemoveAll(sh.Path()); err != nil {
 		return err
 	}
 
@@ -824,6 +880,11 @@ func (s *Store) DeleteDatabase(name string) error {
 	if err := os.RemoveAll(dbPath); err != nil {
 		return err
 	}
+	for _, dir := range s.dataDirs()[1:] {
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
 	if err := os.RemoveAll(filepath.Join(s.EngineOptions.Config.WALDir, name)); err != nil {
 		return err
 	}
@@ -874,9 +935,11 @@ func (s *Store) DeleteRetentionPolicy(database, name string) error {
 		return fmt.Errorf("invalid path for database '%s', retention policy '%s': %s", database, name, rpPath)
 	}
 
-	// Remove the retention policy folder.
-	if err := os.RemoveAll(filepath.Join(s.path, database, name)); err != nil {
-		return err
+	// Remove the retention policy folder from all data dirs.
+	for _, dir := range s.dataDirs() {
+		if err := os.RemoveAll(filepath.Join(dir, database, name)); err != nil {
+			return err
+		}
 	}
 
 	// Remove the retention policy folder from the the WAL.
@@ -1045,6 +1108,46 @@ func (s *Store) DiskSize() (int64, error) {
 	return size, nil
 }
 
+// DatabaseMemoryUsage is the in-memory size of the caches and
+// indexes of all shards belonging to a database.
+type DatabaseMemoryUsage struct {
+	Database   string
+	CacheBytes int64
+	IndexBytes int64
+}
+
+// MemoryUsage returns the in-memory size of the shard caches and indexes,
+// summed per database. Shards that are closed are skipped.
+func (s *Store) MemoryUsage() []DatabaseMemoryUsage {
+	s.mu.RLock()
+	allShards := s.filterShards(nil)
+	s.mu.RUnlock()
+
+	byDB := make(map[string]*DatabaseMemoryUsage)
+	var dbs []string
+	for _, sh := range allShards {
+		cacheBytes, indexBytes, err := sh.MemoryUsage()
+		if err != nil {
+			continue
+		}
+		u, ok := byDB[sh.Database()]
+		if !ok {
+			u = &DatabaseMemoryUsage{Database: sh.Database()}
+			byDB[sh.Database()] = u
+			dbs = append(dbs, sh.Database())
+		}
+		u.CacheBytes += cacheBytes
+		u.IndexBytes += indexBytes
+	}
+
+	sort.Strings(dbs)
+	usage := make([]DatabaseMemoryUsage, 0, len(dbs))
+	for _, db := range dbs {
+		usage = append(usage, *byDB[db])
+	}
+	return usage
+}
+
 // sketchesForDatabase returns merged sketches for the provided database, by
 // walking each shard in the database and merging the sketches found there.
 func (s *Store) sketchesForDatabase(dbName string, getSketches func(*Shard) (estimator.Sketch, estimator.Sketch, error)) (estimator.Sketch, estimator.Sketch, error) {
@@ -1200,7 +1303,7 @@ func (s *Store) BackupShard(id uint64, since time.Time, w io.Writer) error {
 		}
 	}
 
-	path, err := relativePath(s.path, shard.path)
+	path, err := relativePath(shardDataDir(shard.Path()), shard.Path())
 	if err != nil {
 		return err
 	}
@@ -1217,7 +1320,7 @@ func (s *Store) ExportShard(id uint64, start time.Time, end time.Time, w io.Writ
 		}
 	}
 
-	path, err := relativePath(s.path, shard.path)
+	path, err := relativePath(shardDataDir(shard.Path()), shard.Path())
 	if err != nil {
 		return err
 	}
@@ -1233,7 +1336,7 @@ func (s *Store) RestoreShard(ctx context.Context, id uint64, r io.Reader) error
 		return fmt.Errorf("shard %d doesn't exist on this server", id)
 	}
 
-	path, err := relativePath(s.path, shard.path)
+	path, err := relativePath(shardDataDir(shard.Path()), shard.Path())
 	if err != nil {
 		return err
 	}
@@ -1251,7 +1354,7 @@ func (s *Store) ImportShard(id uint64, r io.Reader) error {
 		return fmt.Errorf("shard %d doesn't exist on this server", id)
 	}
 
-	path, err := relativePath(s.path, shard.path)
+	path, err := relativePath(shardDataDir(shard.Path()), shard.Path())
 	if err != nil {
 		return err
 	}
@@ -1266,12 +1369,29 @@ func (s *Store) ShardRelativePath(id uint64) (string, error) {
 	if shard == nil {
 		return "", fmt.Errorf("shard %d doesn't exist on this server", id)
 	}
-	return relativePath(s.path, shard.path)
+	return relativePath(shardDataDir(shard.Path()), shard.Path())
 }
 
 // DeleteSeries loops through the local shards and deletes the series data for
 // the passed in series keys.
 func (s *Store) DeleteSeriesWithPredicate(ctx context.Context, database string, min, max int64, pred influxdb.Predicate) error {
+	return s.deleteSeriesWithPredicate(ctx, database, byDatabase(database), min, max, pred)
+}
+
+// DeleteShardsRange deletes the data between min and max from the shards of
+// the database with the given IDs, leaving its other shards untouched.
+func (s *Store) DeleteShardsRange(ctx context.Context, database string, shardIDs []uint64, min, max int64) error {
+	ids := make(map[uint64]struct{}, len(shardIDs))
+	for _, id := range shardIDs {
+		ids[id] = struct{}{}
+	}
+	return s.deleteSeriesWithPredicate(ctx, database, func(sh *Shard) bool {
+		_, ok := ids[sh.id]
+		return ok && sh.database == database
+	}, min, max, nil)
+}
+
+func (s *Store) deleteSeriesWithPredicate(ctx context.Context, database string, filter func(sh *Shard) bool, min, max int64, pred influxdb.Predicate) error {
 	s.mu.RLock()
 	if s.databases[database].hasMultipleIndexTypes() {
 		s.mu.RUnlock()
@@ -1283,7 +1403,7 @@ func (s *Store) DeleteSeriesWithPredicate(ctx context.Context, database string,
 		// No series file means nothing has been written to this DB and thus nothing to delete.
 		return nil
 	}
-	shards := s.filterShards(byDatabase(database))
+	shards := s.filterShards(filter)
 	epochs := s.epochsForShards(shards)
 	s.mu.RUnlock()
 
@@ -1474,6 +1594,7 @@ func (s *Store) WriteToShard(ctx context.Context, shardID uint64, points []model
 	}
 
 	epoch := s.epochs[shardID]
+	_, moving := s.movingShards[shardID]
 
 	s.mu.RUnlock()
 
@@ -1489,8 +1610,9 @@ func (s *Store) WriteToShard(ctx context.Context, shardID uint64, points []model
 	}
 
 	// Ensure snapshot compactions are enabled since the shard might have been cold
-	// and disabled by the monitor.
-	if isIdle, _ := sh.IsIdle(); isIdle {
+	// and disabled by the monitor. A shard being moved keeps its level
+	// compactions disabled until the move completes.
+	if isIdle, _ := sh.IsIdle(); isIdle && !moving {
 		sh.SetCompactionsEnabled(true)
 	}
 
@@ -1950,6 +2072,9 @@ func (s *Store) monitorShards() {
 		case <-t.C:
 			s.mu.RLock()
 			for _, sh := range s.shards {
+				if _, ok := s.movingShards[sh.ID()]; ok {
+					continue
+				}
 				if isIdle, _ := sh.IsIdle(); isIdle {
 					if err := sh.Free(); err != nil {
 						s.Logger.Warn("Error while freeing cold shard resources",
@@ -2036,6 +2161,12 @@ func relativePath(storePath, shardPath string) (string, error) {
 	return name, nil
 }
 
+// shardDataDir returns the data directory a shard is stored in, the
+// grandparent of its retention policy directory.
+func shardDataDir(shardPath string) string {
+	return filepath.Dir(filepath.Dir(filepath.Dir(shardPath)))
+}
+
 type shardSet struct {
 	store *Store
 	db    string
@@ -0,0 +1,226 @@
+package tsdb
+
+import (
+	"context"
+	"encoding/binary"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/pkg/estimator"
+	"github.com/influxdata/influxdb/v2/pkg/estimator/hll"
+)
+
+// MeasurementCardinalities counts the series of each measurement of a
+// database, and the values of the topTagKeys tag keys of each measurement
+// with the most values, from the indexes of its shards. The counts are exact,
+// or estimated with sketches, which bound the memory used to count them.
+//
+// Measurements are sorted by their number of series, the most first.
+func (s *Store) MeasurementCardinalities(ctx context.Context, database string, exact bool, topTagKeys int) ([]influxdb.MeasurementCardinality, error) {
+	s.mu.RLock()
+	shards := s.filterShards(byDatabase(database))
+	s.mu.RUnlock()
+
+	counters := make(map[string]*measurementCounter)
+	for _, sh := range shards {
+		index, err := sh.Index()
+		if err == ErrEngineClosed || err == ErrShardDisabled {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if err := countMeasurements(ctx, index, counters, exact); err != nil {
+			return nil, err
+		}
+	}
+
+	ms := make([]influxdb.MeasurementCardinality, 0, len(counters))
+	for name, c := range counters {
+		m := influxdb.MeasurementCardinality{
+			Name:    name,
+			Series:  c.series.count(),
+			TagKeys: make([]influxdb.TagKeyCardinality, 0, len(c.tagKeys)),
+		}
+		for key, values := range c.tagKeys {
+			m.TagKeys = append(m.TagKeys, influxdb.TagKeyCardinality{Key: key, Values: values.count()})
+		}
+		sort.Slice(m.TagKeys, func(i, j int) bool {
+			a, b := m.TagKeys[i], m.TagKeys[j]
+			return a.Values > b.Values || a.Values == b.Values && a.Key < b.Key
+		})
+		if len(m.TagKeys) > topTagKeys {
+			m.TagKeys = m.TagKeys[:topTagKeys]
+		}
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].Series > ms[j].Series || ms[i].Series == ms[j].Series && ms[i].Name < ms[j].Name
+	})
+	return ms, nil
+}
+
+// countMeasurements adds the series and tag values of the measurements of
+// a shard index to counters. Series IDs are shared by the shards of a
+// database, so a series in several shards is counted once.
+func countMeasurements(ctx context.Context, index Index, counters map[string]*measurementCounter, exact bool) error {
+	mitr, err := index.MeasurementIterator()
+	if err != nil {
+		return err
+	} else if mitr == nil {
+		return nil
+	}
+	defer mitr.Close()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name, err := mitr.Next()
+		if err != nil {
+			return err
+		} else if name == nil {
+			return nil
+		}
+
+		c, ok := counters[string(name)]
+		if !ok {
+			c = &measurementCounter{
+				exact:   exact,
+				series:  newSeriesCounter(exact),
+				tagKeys: make(map[string]*cardinalityCounter),
+			}
+			counters[string(name)] = c
+		}
+		if err := c.addSeries(index, name); err != nil {
+			return err
+		}
+		if err := c.addTagValues(index, name); err != nil {
+			return err
+		}
+	}
+}
+
+type measurementCounter struct {
+	exact   bool
+	series  *cardinalityCounter
+	tagKeys map[string]*cardinalityCounter
+}
+
+func (c *measurementCounter) addSeries(index Index, name []byte) error {
+	sitr, err := index.MeasurementSeriesIDIterator(name)
+	if err != nil {
+		return err
+	} else if sitr == nil {
+		return nil
+	}
+	defer sitr.Close()
+
+	for {
+		e, err := sitr.Next()
+		if err != nil {
+			return err
+		} else if e.SeriesID == 0 {
+			return nil
+		}
+		c.series.addID(e.SeriesID)
+	}
+}
+
+func (c *measurementCounter) addTagValues(index Index, name []byte) error {
+	kitr, err := index.TagKeyIterator(name)
+	if err != nil {
+		return err
+	} else if kitr == nil {
+		return nil
+	}
+	defer kitr.Close()
+
+	for {
+		key, err := kitr.Next()
+		if err != nil {
+			return err
+		} else if key == nil {
+			return nil
+		}
+
+		values, ok := c.tagKeys[string(key)]
+		if !ok {
+			values = newValueCounter(c.exact)
+			c.tagKeys[string(key)] = values
+		}
+		if err := addTagKeyValues(index, name, key, values); err != nil {
+			return err
+		}
+	}
+}
+
+func addTagKeyValues(index Index, name, key []byte, values *cardinalityCounter) error {
+	vitr, err := index.TagValueIterator(name, key)
+	if err != nil {
+		return err
+	} else if vitr == nil {
+		return nil
+	}
+	defer vitr.Close()
+
+	for {
+		value, err := vitr.Next()
+		if err != nil {
+			return err
+		} else if value == nil {
+			return nil
+		}
+		values.add(value)
+	}
+}
+
+// cardinalityCounter counts distinct series IDs or values exactly, or
+// estimates their number with a sketch.
+type cardinalityCounter struct {
+	ids    *SeriesIDSet
+	values map[string]struct{}
+	sketch estimator.Sketch
+}
+
+func newSeriesCounter(exact bool) *cardinalityCounter {
+	if exact {
+		return &cardinalityCounter{ids: NewSeriesIDSet()}
+	}
+	return &cardinalityCounter{sketch: hll.NewDefaultPlus()}
+}
+
+func newValueCounter(exact bool) *cardinalityCounter {
+	if exact {
+		return &cardinalityCounter{values: make(map[string]struct{})}
+	}
+	return &cardinalityCounter{sketch: hll.NewDefaultPlus()}
+}
+
+func (c *cardinalityCounter) addID(id uint64) {
+	if c.ids != nil {
+		c.ids.AddNoLock(id)
+		return
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], id)
+	c.sketch.Add(buf[:])
+}
+
+func (c *cardinalityCounter) add(value []byte) {
+	if c.values != nil {
+		c.values[string(value)] = struct{}{}
+		return
+	}
+	c.sketch.Add(value)
+}
+
+func (c *cardinalityCounter) count() int64 {
+	switch {
+	case c.ids != nil:
+		return int64(c.ids.Cardinality())
+	case c.values != nil:
+		return int64(len(c.values))
+	default:
+		return int64(c.sketch.Count())
+	}
+}
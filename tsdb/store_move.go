@@ -0,0 +1,507 @@
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// shardMovingSuffix marks the copy of a shard in the data directory it
+	// is moved to until the move switches to it.
+	shardMovingSuffix = ".moving"
+	// shardMovedSuffix marks the shard in the data directory it was moved
+	// from until it is deleted.
+	shardMovedSuffix = ".moved"
+)
+
+// isShardMoveDir returns true if name is the directory of an incomplete
+// shard move.
+func isShardMoveDir(name string) bool {
+	return strings.HasSuffix(name, shardMovingSuffix) || strings.HasSuffix(name, shardMovedSuffix)
+}
+
+// ShardMovePhase is the phase a shard move is in.
+type ShardMovePhase string
+
+const (
+	// ShardMoveCopying copies the files of the shard while it is online.
+	ShardMoveCopying ShardMovePhase = "copying"
+	// ShardMoveVerifying compares the checksums of the copied files.
+	ShardMoveVerifying ShardMovePhase = "verifying"
+	// ShardMoveSwitching closes the shard, copies the files changed since
+	// they were copied and opens the shard from the new directory.
+	ShardMoveSwitching ShardMovePhase = "switching"
+	// ShardMoveDeleting deletes the shard from the old directory.
+	ShardMoveDeleting ShardMovePhase = "deleting"
+)
+
+// ShardMoveProgress tracks the progress of a shard move. It is safe for
+// concurrent use.
+type ShardMoveProgress struct {
+	mu          sync.Mutex
+	phase       ShardMovePhase
+	bytesTotal  int64
+	bytesCopied int64
+
+	throttle func(n int) error
+}
+
+// SetThrottle sets a function called before the move writes n bytes, which
+// blocks to limit the throughput of the move. The move fails with the error
+// the function returns. It must be set before the move starts. The files
+// copied while the shard is closed to switch it are not throttled, so the
+// shard is not kept closed by the throttle.
+func (p *ShardMoveProgress) SetThrottle(fn func(n int) error) {
+	p.throttle = fn
+}
+
+// writer returns w, throttled when a throttle is set and throttled is true.
+func (p *ShardMoveProgress) writer(w io.Writer, throttled bool) io.Writer {
+	if p.throttle == nil || !throttled {
+		return w
+	}
+	return throttledWriter{w: w, throttle: p.throttle}
+}
+
+type throttledWriter struct {
+	w        io.Writer
+	throttle func(n int) error
+}
+
+func (w throttledWriter) Write(b []byte) (int, error) {
+	if err := w.throttle(len(b)); err != nil {
+		return 0, err
+	}
+	return w.w.Write(b)
+}
+
+// Phase returns the phase the move is in.
+func (p *ShardMoveProgress) Phase() ShardMovePhase {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.phase
+}
+
+// Bytes returns the number of bytes copied and the number of bytes to copy
+// known so far. Files changed while the shard is copied add to the total.
+func (p *ShardMoveProgress) Bytes() (copied, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.bytesCopied, p.bytesTotal
+}
+
+func (p *ShardMoveProgress) setPhase(phase ShardMovePhase) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phase = phase
+}
+
+func (p *ShardMoveProgress) addTotal(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bytesTotal += n
+}
+
+func (p *ShardMoveProgress) addCopied(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bytesCopied += n
+}
+
+// DataDirs returns the directories shards can be stored in.
+func (s *Store) DataDirs() []string {
+	return s.dataDirs()
+}
+
+// MoveShard moves a shard to another data directory while it stays online.
+// The shard is copied and verified while it serves writes and queries.
+// It is then closed to copy the files changed since and opened from the new
+// directory. Only operations on the shard wait while it is closed; the store
+// stays available. The shard in the old directory is deleted last.
+//
+// A move interrupted by a restart leaves the shard in the old directory.
+// The directories of the incomplete move are skipped when loading shards.
+func (s *Store) MoveShard(ctx context.Context, id uint64, dir string, progress *ShardMoveProgress) error {
+	if progress == nil {
+		progress = new(ShardMoveProgress)
+	}
+
+	sh := s.Shard(id)
+	if sh == nil {
+		return &errors2.Error{
+			Code: errors2.ENotFound,
+			Msg:  fmt.Sprintf("shard %d not found", id),
+		}
+	}
+
+	dir = filepath.Clean(dir)
+	if !s.isDataDir(dir) {
+		return &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  fmt.Sprintf("%q is not a data directory", dir),
+		}
+	}
+	src := sh.Path()
+	from := shardDataDir(src)
+	if from == dir {
+		return &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  fmt.Sprintf("shard %d is already stored in %q", id, dir),
+		}
+	}
+
+	s.mu.Lock()
+	if _, ok := s.movingShards[id]; ok {
+		s.mu.Unlock()
+		return &errors2.Error{
+			Code: errors2.EConflict,
+			Msg:  fmt.Sprintf("shard %d is already being moved", id),
+		}
+	}
+	s.movingShards[id] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.movingShards, id)
+		s.mu.Unlock()
+	}()
+
+	rel, err := relativePath(from, src)
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(dir, rel)
+	if _, err := os.Stat(dst); err == nil {
+		return &errors2.Error{
+			Code: errors2.EConflict,
+			Msg:  fmt.Sprintf("%q already exists", dst),
+		}
+	}
+	tmp := dst + shardMovingSuffix
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+
+	log, logEnd := logger.NewOperation(ctx, s.Logger, "Move shard", "tsdb_shard_move",
+		logger.Shard(id), zap.String("from", src), zap.String("to", dst))
+	defer logEnd()
+
+	if err := s.moveShard(ctx, sh, src, tmp, dst, progress); err != nil {
+		log.Error("Failed to move shard", zap.Error(err))
+		if rerr := os.RemoveAll(tmp); rerr != nil {
+			log.Warn("Failed to remove incomplete shard copy", zap.String("path", tmp), zap.Error(rerr))
+		}
+		return err
+	}
+
+	progress.setPhase(ShardMoveDeleting)
+	return os.RemoveAll(src + shardMovedSuffix)
+}
+
+func (s *Store) moveShard(ctx context.Context, sh *Shard, src, tmp, dst string, progress *ShardMoveProgress) error {
+	// Level compactions rewrite the files of the shard, which would then be
+	// copied again. Snapshots of the cache keep running, as they only add
+	// small files.
+	sh.SetLevelCompactionsEnabled(false)
+	defer sh.SetLevelCompactionsEnabled(true)
+
+	progress.setPhase(ShardMoveCopying)
+	copied, err := syncDir(ctx, src, tmp, progress, true)
+	if err != nil {
+		return err
+	}
+
+	progress.setPhase(ShardMoveVerifying)
+	if err := verifyFiles(ctx, src, tmp, copied, true); err != nil {
+		return err
+	}
+
+	progress.setPhase(ShardMoveSwitching)
+	return s.switchShard(ctx, sh, src, tmp, dst, progress)
+}
+
+// switchShard completes the copy of the shard and reopens the shard from it.
+// The files changed since the copy was verified are synced while the shard
+// is online, so that the final pass, made while the shard is closed, only
+// copies what changed since. Only the shard is locked while it is closed.
+func (s *Store) switchShard(ctx context.Context, sh *Shard, src, tmp, dst string, progress *ShardMoveProgress) error {
+	if _, err := syncDir(ctx, src, tmp, progress, true); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	current := s.shards[sh.id] == sh
+	s.mu.RUnlock()
+	if !current {
+		return &errors2.Error{
+			Code: errors2.EConflict,
+			Msg:  fmt.Sprintf("shard %d was deleted during the move", sh.id),
+		}
+	}
+
+	move := func() error {
+		// The shard is closed, so its files no longer change. The copy is
+		// not throttled, to keep the shard closed as briefly as possible.
+		copied, err := syncDir(ctx, src, tmp, progress, false)
+		if err != nil {
+			return err
+		}
+		if err := verifyFiles(ctx, src, tmp, copied, false); err != nil {
+			return err
+		}
+
+		if err := os.Rename(tmp, dst); err != nil {
+			return err
+		}
+		if err := os.Rename(src, src+shardMovedSuffix); err != nil {
+			s.undoRename(dst, tmp)
+			return err
+		}
+		return nil
+	}
+	undo := func() {
+		s.undoRename(src+shardMovedSuffix, src)
+		s.undoRename(dst, tmp)
+	}
+	return sh.relocate(ctx, dst, move, undo)
+}
+
+// relocate closes the shard, calls move to move its files to path and opens
+// the shard from path. Operations on the shard wait until it is reopened.
+// When move fails the shard is reopened from its current path. When the
+// shard fails to open from path, undo is called to move its files back
+// before it is reopened from its current path.
+func (s *Shard) relocate(ctx context.Context, path string, move func() error, undo func()) error {
+	s.mu.Lock()
+	if s._engine == nil {
+		s.mu.Unlock()
+		return ErrEngineClosed
+	}
+
+	enabled := s.enabled
+	closed := s.metricUpdater
+	err := s.closeNoLock()
+	if err == nil {
+		err = move()
+	}
+	if err == nil {
+		from := s.path
+		s.path = path
+		if _, err = s.openNoLock(ctx); err != nil {
+			s.closeIndexNoLock()
+			undo()
+			s.path = from
+		}
+	}
+	if s._engine == nil {
+		if _, oerr := s.openNoLock(ctx); oerr != nil {
+			s.closeIndexNoLock()
+			s.logger.Error("Failed to reopen shard after failed move", zap.Error(oerr))
+		}
+	}
+	s.setEnabledNoLock(enabled)
+	s.mu.Unlock()
+
+	// The metrics goroutine of the closed engine takes the shard lock, so it
+	// is waited for once the lock is released.
+	if closed != nil {
+		closed.wg.Wait()
+	}
+	return err
+}
+
+// closeIndexNoLock closes the index left open by a failed open. Must hold
+// s.mu before calling.
+func (s *Shard) closeIndexNoLock() {
+	if s.index == nil {
+		return
+	}
+	if err := s.index.Close(); err == nil {
+		s.index = nil
+	}
+}
+
+// isDataDir returns true if dir is one of the data directories of the store.
+func (s *Store) isDataDir(dir string) bool {
+	for _, d := range s.dataDirs() {
+		if filepath.Clean(d) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// fileStamp is the size and modification time of a copied file.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func stampOf(fi os.FileInfo) fileStamp {
+	return fileStamp{size: fi.Size(), modTime: fi.ModTime()}
+}
+
+func (f fileStamp) equal(other fileStamp) bool {
+	return f.size == other.size && f.modTime.Equal(other.modTime)
+}
+
+// syncDir copies the files of src that differ in size or modification time
+// from their copy in dst, and removes the files of dst missing in src. It
+// returns the stamps of the copied files, keyed by their path relative to
+// src. Files removed from src while it is copied are skipped. The copy is
+// throttled by the throttle of progress when throttled is true.
+func syncDir(ctx context.Context, src, dst string, progress *ShardMoveProgress, throttled bool) (map[string]fileStamp, error) {
+	files := make(map[string]fileStamp)
+	if err := filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		files[rel] = stampOf(fi)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// Remove the files of a previous pass that were removed since.
+	if err := filepath.Walk(dst, func(path string, fi os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil || !fi.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dst, path)
+		if err != nil {
+			return err
+		}
+		if _, ok := files[rel]; !ok {
+			return os.Remove(path)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for rel, stamp := range files {
+		if fi, err := os.Stat(filepath.Join(dst, rel)); err == nil && stampOf(fi).equal(stamp) {
+			delete(files, rel)
+			continue
+		}
+		progress.addTotal(stamp.size)
+	}
+
+	for rel, stamp := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := copyFile(filepath.Join(src, rel), filepath.Join(dst, rel), progress, throttled); os.IsNotExist(err) {
+			delete(files, rel)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if err := os.Chtimes(filepath.Join(dst, rel), stamp.modTime, stamp.modTime); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// copyFile copies the file at src to dst, creating the parent directories
+// of dst.
+func copyFile(src, dst string, progress *ShardMoveProgress, throttled bool) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	n, err := io.Copy(progress.writer(out, throttled), in)
+	progress.addCopied(n)
+	if err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// verifyFiles compares the checksums of the copied files with the ones of
+// the originals. When skipChanged is set, files changed since they were
+// copied are skipped, as they are copied again.
+func verifyFiles(ctx context.Context, src, dst string, files map[string]fileStamp, skipChanged bool) error {
+	for rel, stamp := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if skipChanged {
+			if fi, err := os.Stat(filepath.Join(src, rel)); err != nil || !stampOf(fi).equal(stamp) {
+				continue
+			}
+		}
+
+		want, err := fileChecksum(filepath.Join(src, rel))
+		if err != nil {
+			return err
+		}
+		got, err := fileChecksum(filepath.Join(dst, rel))
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return fmt.Errorf("verifying copy of %s: checksum mismatch", filepath.Join(src, rel))
+		}
+	}
+	return nil
+}
+
+func fileChecksum(path string) (uint32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, f); err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
+
+// undoRename renames a directory back after a failed move.
+func (s *Store) undoRename(from, to string) {
+	if err := os.Rename(from, to); err != nil {
+		s.Logger.Error("Failed to undo rename of shard directory", zap.String("from", from), zap.String("to", to), zap.Error(err))
+	}
+}
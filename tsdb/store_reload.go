@@ -0,0 +1,319 @@
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// retiredCloseDelay is how long the shards and series files replaced by a
+// reload stay open, so that the queries which looked them up before the
+// reload can finish. Closing a shard additionally waits for the readers of
+// its TSM files.
+const retiredCloseDelay = time.Minute
+
+// dirFingerprint summarizes the files of a directory, to tell whether they
+// changed between reloads.
+type dirFingerprint struct {
+	files   int
+	size    int64
+	modTime int64
+}
+
+// fingerprintDirs returns the fingerprint of the files in the directories.
+// Directories that do not exist are skipped.
+func fingerprintDirs(dirs ...string) (dirFingerprint, error) {
+	var fp dirFingerprint
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			fp.files++
+			fp.size += info.Size()
+			if t := info.ModTime().UnixNano(); t > fp.modTime {
+				fp.modTime = t
+			}
+			return nil
+		})
+		if err != nil {
+			return dirFingerprint{}, err
+		}
+	}
+	return fp, nil
+}
+
+// reloadShard is a shard found on disk by a reload.
+type reloadShard struct {
+	db            string
+	id            uint64
+	path, walPath string
+}
+
+// Reload opens the shards and series files that were added or changed on
+// disk since the store was opened or last reloaded, and drops the shards
+// removed from disk. It lets a read-only store serve data written to its
+// path by another process, such as a snapshot synced from a write node.
+//
+// The new shards and series files are opened before they replace the old
+// ones in a single swap, so queries keep being served throughout and the
+// store is left unchanged when opening fails. The replaced shards and series
+// files are closed once the queries using them had time to finish.
+func (s *Store) Reload(ctx context.Context) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	s.mu.RLock()
+	if !s.opened {
+		s.mu.RUnlock()
+		return ErrStoreClosed
+	}
+	current := make(map[uint64]*Shard, len(s.shards))
+	for id, sh := range s.shards {
+		current[id] = sh
+	}
+	currentSfiles := make(map[string]*SeriesFile, len(s.sfiles))
+	for db, sfile := range s.sfiles {
+		currentSfiles[db] = sfile
+	}
+	s.mu.RUnlock()
+
+	found, err := s.reloadShardDirs()
+	if err != nil {
+		return err
+	}
+
+	fingerprints := make(map[string]dirFingerprint)
+	changed := func(key string, dirs ...string) (bool, error) {
+		fp, err := fingerprintDirs(dirs...)
+		if err != nil {
+			return false, err
+		}
+		fingerprints[key] = fp
+		prev, ok := s.reloaded[key]
+		return !ok || prev != fp, nil
+	}
+
+	// Open what changed, closing it all again when anything fails to open.
+	var (
+		openedSfiles = make(map[string]*SeriesFile)
+		openedShards = make(map[uint64]*Shard)
+	)
+	closeOpened := func() {
+		for _, sh := range openedShards {
+			sh.Close()
+		}
+		for _, sfile := range openedSfiles {
+			sfile.Close()
+		}
+	}
+
+	checkedSfiles := make(map[string]bool)
+	for _, f := range found {
+		if checkedSfiles[f.db] {
+			continue
+		}
+		checkedSfiles[f.db] = true
+		path := filepath.Join(s.path, f.db, SeriesFileDirectory)
+		if ok, err := changed(path, path); err != nil {
+			closeOpened()
+			return err
+		} else if !ok && currentSfiles[f.db] != nil {
+			continue
+		}
+
+		sfile := NewSeriesFile(path)
+		sfile.WithMaxCompactionConcurrency(s.EngineOptions.Config.SeriesFileMaxConcurrentSnapshotCompactions)
+		sfile.Logger = s.baseLogger
+		if err := sfile.Open(); err != nil {
+			closeOpened()
+			return fmt.Errorf("failed to open series file of database %s: %w", f.db, err)
+		}
+		openedSfiles[f.db] = sfile
+	}
+
+	for _, f := range found {
+		ok, err := changed(f.path, f.path, f.walPath)
+		if err != nil {
+			closeOpened()
+			return err
+		}
+		sfile, sfileChanged := openedSfiles[f.db]
+		if !ok && !sfileChanged && current[f.id] != nil {
+			continue
+		}
+		if !sfileChanged {
+			sfile = currentSfiles[f.db]
+		}
+
+		sh := s.newLoadedShard(f.db, f.id, f.path, f.walPath, sfile)
+		if err := sh.Open(ctx); err != nil {
+			closeOpened()
+			return fmt.Errorf("failed to open shard: %d: %w", f.id, err)
+		}
+		openedShards[f.id] = sh
+	}
+
+	// Swap in what was opened and drop what is no longer on disk.
+	var (
+		retiredShards []*Shard
+		retiredSfiles []*SeriesFile
+	)
+	onDisk := make(map[uint64]bool, len(found))
+	dbs := make(map[string]bool)
+	for _, f := range found {
+		onDisk[f.id] = true
+		dbs[f.db] = true
+	}
+
+	s.mu.Lock()
+	if !s.opened {
+		s.mu.Unlock()
+		closeOpened()
+		return ErrStoreClosed
+	}
+	for id, sh := range s.shards {
+		if !onDisk[id] {
+			retiredShards = append(retiredShards, sh)
+			delete(s.shards, id)
+			delete(s.epochs, id)
+		}
+	}
+	for db, sfile := range s.sfiles {
+		if _, ok := openedSfiles[db]; ok || !dbs[db] {
+			retiredSfiles = append(retiredSfiles, sfile)
+			delete(s.sfiles, db)
+			delete(s.databases, db)
+		}
+	}
+	for db, sfile := range openedSfiles {
+		s.sfiles[db] = sfile
+	}
+	for id, sh := range openedShards {
+		if old := s.shards[id]; old != nil {
+			retiredShards = append(retiredShards, old)
+		}
+		s.shards[id] = sh
+		if _, ok := s.epochs[id]; !ok {
+			s.epochs[id] = newEpochTracker()
+		}
+	}
+	s.databases = make(map[string]*databaseState)
+	for _, sh := range s.shards {
+		if _, ok := s.databases[sh.database]; !ok {
+			s.databases[sh.database] = new(databaseState)
+		}
+		s.databases[sh.database].addIndexType(sh.IndexType())
+	}
+	s.reloaded = fingerprints
+	s.mu.Unlock()
+
+	for _, sh := range openedShards {
+		sh.SetEnabled(true)
+	}
+
+	if len(openedShards) > 0 || len(retiredShards) > 0 {
+		s.Logger.Info("Reloaded store",
+			zap.Int("opened_shards", len(openedShards)),
+			zap.Int("retired_shards", len(retiredShards)),
+			zap.Int("opened_series_files", len(openedSfiles)))
+	}
+	s.closeRetired(retiredShards, retiredSfiles)
+	return nil
+}
+
+// reloadShardDirs returns the shards on disk which pass the filters of the
+// engine options.
+func (s *Store) reloadShardDirs() ([]reloadShard, error) {
+	dbDirs, err := ioutil.ReadDir(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []reloadShard
+	for _, db := range dbDirs {
+		if !db.IsDir() {
+			continue
+		}
+		if s.EngineOptions.DatabaseFilter != nil && !s.EngineOptions.DatabaseFilter(db.Name()) {
+			continue
+		}
+
+		rpDirs, err := ioutil.ReadDir(filepath.Join(s.path, db.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, rp := range rpDirs {
+			if !rp.IsDir() || rp.Name() == SeriesFileDirectory {
+				continue
+			}
+			if s.EngineOptions.RetentionPolicyFilter != nil && !s.EngineOptions.RetentionPolicyFilter(db.Name(), rp.Name()) {
+				continue
+			}
+
+			shardDirs, err := s.shardDirs(s.Logger, db.Name(), rp.Name())
+			if err != nil {
+				return nil, err
+			}
+			for sh, path := range shardDirs {
+				id, err := strconv.ParseUint(sh, 10, 64)
+				if err != nil {
+					s.Logger.Warn("Skipping invalid shard ID found at path", zap.String("path", path))
+					continue
+				}
+				if s.EngineOptions.ShardFilter != nil && !s.EngineOptions.ShardFilter(db.Name(), rp.Name(), id) {
+					continue
+				}
+				found = append(found, reloadShard{
+					db:      db.Name(),
+					id:      id,
+					path:    path,
+					walPath: filepath.Join(s.EngineOptions.Config.WALDir, db.Name(), rp.Name(), sh),
+				})
+			}
+		}
+	}
+	return found, nil
+}
+
+// closeRetired closes the shards and series files replaced by a reload after
+// retiredCloseDelay, or as soon as the store closes.
+func (s *Store) closeRetired(shards []*Shard, sfiles []*SeriesFile) {
+	if len(shards) == 0 && len(sfiles) == 0 {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		timer := time.NewTimer(retiredCloseDelay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-s.closing:
+		}
+
+		if err := s.walkShards(shards, func(sh *Shard) error {
+			return sh.Close()
+		}); err != nil {
+			s.Logger.Error("Failed to close shards replaced by reload", zap.Error(err))
+		}
+		for _, sfile := range sfiles {
+			if err := sfile.Close(); err != nil {
+				s.Logger.Error("Failed to close series file replaced by reload", zap.String("path", sfile.Path()), zap.Error(err))
+			}
+		}
+	}()
+}
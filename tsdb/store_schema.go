@@ -0,0 +1,67 @@
+package tsdb
+
+import "github.com/influxdata/influxdb/v2/models"
+
+// DatabaseSchema is the tag keys and the field keys of a database.
+type DatabaseSchema struct {
+	// TagKeys are the tag keys of all measurements.
+	TagKeys map[string]struct{}
+	// Fields are the field keys of every measurement.
+	Fields map[string]map[string]struct{}
+}
+
+// DatabaseSchema reads the tag keys and the field keys of a database from
+// the indexes of its shards.
+func (s *Store) DatabaseSchema(database string) (*DatabaseSchema, error) {
+	s.mu.RLock()
+	shards := s.filterShards(byDatabase(database))
+	s.mu.RUnlock()
+
+	schema := &DatabaseSchema{
+		TagKeys: make(map[string]struct{}),
+		Fields:  make(map[string]map[string]struct{}),
+	}
+	for _, sh := range shards {
+		index, err := sh.Index()
+		if err == ErrEngineClosed || err == ErrShardDisabled {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		err = index.ForEachMeasurementName(func(name []byte) error {
+			keys, err := index.MeasurementTagKeysByExpr(name, nil)
+			if err != nil {
+				return err
+			}
+			for k := range keys {
+				schema.TagKeys[k] = struct{}{}
+			}
+
+			fields := schema.Fields[string(name)]
+			if fields == nil {
+				fields = make(map[string]struct{})
+				schema.Fields[string(name)] = fields
+			}
+			if mf := sh.MeasurementFields(name); mf != nil {
+				for _, f := range mf.FieldKeys() {
+					fields[f] = struct{}{}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return schema, nil
+}
+
+// HasSeries reports whether the series file of a database holds the series.
+func (s *Store) HasSeries(database string, name []byte, tags models.Tags) bool {
+	sfile := s.seriesFile(database)
+	if sfile == nil {
+		return false
+	}
+	return sfile.HasSeries(name, tags, nil)
+}
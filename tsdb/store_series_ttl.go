@@ -0,0 +1,94 @@
+package tsdb
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2/pkg/limiter"
+	"github.com/influxdata/influxql"
+)
+
+// DeleteStaleSeries removes the series of a database that are only found in
+// the stale shards, the shards holding no data newer than the series TTL of
+// the database. Their data is deleted from the stale shards, which drops
+// them from the shard indexes. A series found in any other shard of the
+// database received points since and is kept whole.
+//
+// It returns the number of series removed.
+func (s *Store) DeleteStaleSeries(ctx context.Context, database string, stale []uint64) (int, error) {
+	s.mu.RLock()
+	if s.databases[database].hasMultipleIndexTypes() {
+		s.mu.RUnlock()
+		return 0, ErrMultipleIndexTypes
+	}
+	sfile := s.sfiles[database]
+	if sfile == nil {
+		s.mu.RUnlock()
+		// No series file means nothing has been written to this DB and thus nothing to delete.
+		return 0, nil
+	}
+	staleIDs := make(map[uint64]struct{}, len(stale))
+	for _, id := range stale {
+		staleIDs[id] = struct{}{}
+	}
+	var staleShards, liveShards []*Shard
+	for _, sh := range s.filterShards(byDatabase(database)) {
+		if _, ok := staleIDs[sh.id]; ok {
+			staleShards = append(staleShards, sh)
+		} else {
+			liveShards = append(liveShards, sh)
+		}
+	}
+	epochs := s.epochsForShards(staleShards)
+	s.mu.RUnlock()
+
+	if len(staleShards) == 0 {
+		return 0, nil
+	}
+
+	// Series IDs are shared by the shards of a database, so the series of the
+	// stale shards that are not in a live shard are the expired ones.
+	staleSeries, err := s.SeriesCardinalityFromShards(ctx, staleShards)
+	if err != nil {
+		return 0, err
+	}
+	liveSeries, err := s.SeriesCardinalityFromShards(ctx, liveShards)
+	if err != nil {
+		return 0, err
+	}
+	expired := staleSeries.AndNot(liveSeries)
+	if expired.Cardinality() == 0 {
+		return 0, nil
+	}
+
+	// Limit to 1 delete at a time, like the other deletes of the store.
+	limit := limiter.NewFixed(1)
+
+	err = s.walkShards(staleShards, func(sh *Shard) error {
+		if err := limit.Take(ctx); err != nil {
+			return err
+		}
+		defer limit.Release()
+
+		// install our guard and wait for any prior deletes to finish. the
+		// guard ensures future deletes that could conflict wait for us.
+		waiter := epochs[sh.id].WaitDelete(newGuard(influxql.MinTime, influxql.MaxTime, nil, nil))
+		waiter.Wait()
+		defer waiter.Done()
+
+		index, err := sh.Index()
+		if err != nil {
+			return err
+		}
+
+		ids := index.SeriesIDSet().And(expired)
+		if ids.Cardinality() == 0 {
+			return nil
+		}
+		itr := NewSeriesIteratorAdapter(sfile, NewSeriesIDSetIterator(ids))
+		return sh.DeleteSeriesRange(ctx, itr, influxql.MinTime, influxql.MaxTime)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(expired.Cardinality()), nil
+}
@@ -20,8 +20,10 @@ import (
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxdb/v2/internal"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/pkg/deep"
 	"github.com/influxdata/influxdb/v2/pkg/slices"
@@ -143,6 +145,177 @@ func TestStore_CreateShard(t *testing.T) {
 	}
 }
 
+func TestStore_Reload(t *testing.T) {
+
+	test := func(t *testing.T, index string) {
+		s := MustOpenStore(t, index)
+		defer s.Close()
+
+		s.MustCreateShardWithData("db0", "rp0", 1, "cpu,host=serverA value=1 0")
+
+		// Shards created on disk by another process are opened on reload.
+		if err := os.MkdirAll(filepath.Join(s.Path(), "db0", "rp0", "2"), 0777); err != nil {
+			t.Fatal(err)
+		} else if sh := s.Shard(2); sh != nil {
+			t.Fatalf("unexpected shard(2) before reload")
+		}
+
+		if err := s.Reload(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if sh := s.Shard(1); sh == nil {
+			t.Fatalf("expected shard(1)")
+		} else if sh = s.Shard(2); sh == nil {
+			t.Fatalf("expected shard(2)")
+		}
+
+		if n, err := s.SeriesCardinality(context.Background(), "db0"); err != nil {
+			t.Fatal(err)
+		} else if n != 1 {
+			t.Fatalf("got %d series, expected 1", n)
+		}
+
+		// Shards unchanged on disk are kept open as they are.
+		sh1 := s.Shard(1)
+		if err := s.Reload(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if sh := s.Shard(1); sh != sh1 {
+			t.Fatalf("expected shard(1) to be kept on reload")
+		}
+
+		// Shards removed from disk are dropped, while a reader still holding
+		// a dropped shard can use it.
+		if err := os.RemoveAll(filepath.Join(s.Path(), "db0", "rp0", "2")); err != nil {
+			t.Fatal(err)
+		}
+		sh2 := s.Shard(2)
+		if err := s.Reload(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if sh := s.Shard(2); sh != nil {
+			t.Fatalf("unexpected shard(2) after reload")
+		} else if _, err := sh2.DiskSize(); err != nil {
+			t.Fatalf("unexpected error using dropped shard: %v", err)
+		}
+
+		if err := s.Store.Close(); err != nil {
+			t.Fatal(err)
+		} else if err := s.Reload(context.Background()); err != tsdb.ErrStoreClosed {
+			t.Fatalf("got error %v, expected %v", err, tsdb.ErrStoreClosed)
+		}
+	}
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) { test(t, index) })
+	}
+}
+
+func TestStore_MoveShard(t *testing.T) {
+
+	test := func(t *testing.T, index string) {
+		extraDir, err := ioutil.TempDir("", "influxdb-tsdb-extra-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(extraDir)
+
+		s := NewStore(t, index)
+		s.EngineOptions.Config.ExtraDirs = []string{extraDir}
+		if err := s.Open(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+
+		s.MustCreateShardWithData("db0", "rp0", 1, "cpu,host=serverA value=1 0")
+
+		if err := s.MoveShard(context.Background(), 1, filepath.Join(s.Path(), "db0"), nil); errors2.ErrorCode(err) != errors2.EInvalid {
+			t.Fatalf("got error %v, expected a data directory to be required", err)
+		}
+
+		sh := s.Shard(1)
+		var progress tsdb.ShardMoveProgress
+		if err := s.MoveShard(context.Background(), 1, extraDir, &progress); err != nil {
+			t.Fatal(err)
+		}
+		if s.Shard(1) != sh {
+			t.Fatal("expected the moved shard to be reopened in place")
+		}
+		if copied, total := progress.Bytes(); copied == 0 || copied != total {
+			t.Fatalf("got %d of %d bytes copied", copied, total)
+		}
+
+		exp := filepath.Join(extraDir, "db0", "rp0", "1")
+		if got := s.Shard(1).Path(); got != exp {
+			t.Fatalf("got shard path %q, expected %q", got, exp)
+		} else if _, err := os.Stat(filepath.Join(s.Path(), "db0", "rp0", "1")); !os.IsNotExist(err) {
+			t.Fatalf("expected shard to be removed from the old data directory: %v", err)
+		}
+
+		s.MustWriteToShardString(1, "cpu,host=serverB value=1 0")
+
+		// Moved shards are opened from the data directory they were moved to.
+		if err := s.Reload(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if sh := s.Shard(1); sh == nil || sh.Path() != exp {
+			t.Fatalf("expected shard(1) in %q", exp)
+		}
+
+		if n, err := s.SeriesCardinality(context.Background(), "db0"); err != nil {
+			t.Fatal(err)
+		} else if n != 2 {
+			t.Fatalf("got %d series, expected 2", n)
+		}
+	}
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) { test(t, index) })
+	}
+}
+
+func TestStore_CreateShard_SystemBucketDir(t *testing.T) {
+
+	test := func(t *testing.T, index string) {
+		systemDir, err := ioutil.TempDir("", "influxdb-tsdb-system-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(systemDir)
+
+		s := NewStore(t, index)
+		s.EngineOptions.Config.SystemBucketDir = systemDir
+		s.EngineOptions.SystemDatabase = func(database string) bool { return database == "system" }
+		if err := s.Open(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+
+		s.MustCreateShardWithData("system", "rp0", 1, "cpu,host=serverA value=1 0")
+		s.MustCreateShardWithData("db0", "rp0", 2, "cpu,host=serverA value=1 0")
+
+		if got, exp := s.Shard(1).Path(), filepath.Join(systemDir, "system", "rp0", "1"); got != exp {
+			t.Fatalf("got system shard path %q, expected %q", got, exp)
+		}
+		if got, exp := s.Shard(2).Path(), filepath.Join(s.Path(), "db0", "rp0", "2"); got != exp {
+			t.Fatalf("got shard path %q, expected %q", got, exp)
+		}
+
+		// The system bucket dir is a data dir, its shards are loaded on open.
+		if err := s.Reload(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if sh := s.Shard(1); sh == nil || sh.Path() != filepath.Join(systemDir, "system", "rp0", "1") {
+			t.Fatal("expected system shard to be reloaded from the system bucket dir")
+		}
+
+		if err := s.DeleteDatabase("system"); err != nil {
+			t.Fatal(err)
+		} else if _, err := os.Stat(filepath.Join(systemDir, "system")); !os.IsNotExist(err) {
+			t.Fatalf("expected system database to be removed from the system bucket dir: %v", err)
+		}
+	}
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) { test(t, index) })
+	}
+}
+
 func TestStore_DropConcurrentWriteMultipleShards(t *testing.T) {
 
 	test := func(t *testing.T, index string) {
@@ -294,6 +467,106 @@ func TestStore_DeleteSeries_NonExistentDB(t *testing.T) {
 	}
 }
 
+// Ensure the store removes the series only found in stale shards.
+func TestStore_DeleteStaleSeries(t *testing.T) {
+
+	test := func(t *testing.T, index string) {
+		s := MustOpenStore(t, index)
+		defer s.Close()
+
+		// Shard 1 is stale, shard 2 received points since.
+		s.MustCreateShardWithData("db0", "rp0", 1, "cpu,pod=a v=1 0", "cpu,pod=b v=1 0", "mem,pod=a v=1 0")
+		s.MustCreateShardWithData("db0", "rp0", 2, "cpu,pod=a v=1 100")
+		s.MustCreateShardWithData("db1", "rp0", 3, "cpu,pod=b v=1 0")
+
+		n, err := s.DeleteStaleSeries(context.Background(), "db0", []uint64{1})
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+
+		// cpu,pod=a is still written to and is kept in the stale shard too.
+		require.Equal(t, int64(1), s.Shard(1).SeriesN())
+		require.Equal(t, int64(1), s.Shard(2).SeriesN())
+
+		// Other databases are untouched.
+		require.Equal(t, int64(1), s.Shard(3).SeriesN())
+
+		// Nothing is left to expire.
+		n, err = s.DeleteStaleSeries(context.Background(), "db0", []uint64{1})
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+	}
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) { test(t, index) })
+	}
+}
+
+func TestStore_MeasurementCardinalities(t *testing.T) {
+
+	test := func(t *testing.T, index string) {
+		s := MustOpenStore(t, index)
+		defer s.Close()
+
+		// cpu,host=a,region=west is in both shards and is counted once.
+		s.MustCreateShardWithData("db0", "rp0", 1,
+			"cpu,host=a,region=west v=1 0",
+			"cpu,host=b,region=west v=1 0",
+			"mem,host=a v=1 0",
+		)
+		s.MustCreateShardWithData("db0", "rp0", 2,
+			"cpu,host=a,region=west v=1 100",
+			"cpu,host=c,region=east v=1 100",
+		)
+		s.MustCreateShardWithData("db1", "rp0", 3, "disk,host=a v=1 0")
+
+		for _, exact := range []bool{true, false} {
+			ms, err := s.MeasurementCardinalities(context.Background(), "db0", exact, 1)
+			require.NoError(t, err)
+			require.Equal(t, []influxdb.MeasurementCardinality{
+				{
+					Name:    "cpu",
+					Series:  3,
+					TagKeys: []influxdb.TagKeyCardinality{{Key: "host", Values: 3}},
+				},
+				{
+					Name:    "mem",
+					Series:  1,
+					TagKeys: []influxdb.TagKeyCardinality{{Key: "host", Values: 1}},
+				},
+			}, ms)
+		}
+	}
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) { test(t, index) })
+	}
+}
+
+// Ensure the store only deletes the range from the given shards.
+func TestStore_DeleteShardsRange(t *testing.T) {
+
+	test := func(t *testing.T, index string) {
+		s := MustOpenStore(t, index)
+		defer s.Close()
+
+		s.MustCreateShardWithData("db0", "rp0", 1, "cpu,pod=a v=1 0")
+		s.MustCreateShardWithData("db0", "rp0", 2, "cpu,pod=a v=1 0")
+		s.MustCreateShardWithData("db1", "rp0", 3, "cpu,pod=a v=1 0")
+
+		require.NoError(t, s.DeleteShardsRange(context.Background(), "db0", []uint64{1, 3}, 0, 0))
+
+		require.Equal(t, int64(0), s.Shard(1).SeriesN())
+
+		// Shards not listed, or of other databases, are untouched.
+		require.Equal(t, int64(1), s.Shard(2).SeriesN())
+		require.Equal(t, int64(1), s.Shard(3).SeriesN())
+	}
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) { test(t, index) })
+	}
+}
+
 // Ensure the store can delete an existing shard.
 func TestStore_DeleteShard(t *testing.T) {
 
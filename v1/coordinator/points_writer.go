@@ -386,6 +386,8 @@ func (w *PointsWriter) WritePointsPrivileged(
 		return err
 	}
 
+	droppedPoints := tsdb.DroppedPointsFromContext(ctx)
+
 	// Write each shard in it's own goroutine and return as soon as one fails.
 	ch := make(chan error, len(shardMappings.Points))
 	for shardID, points := range shardMappings.Points {
@@ -397,7 +399,11 @@ func (w *PointsWriter) WritePointsPrivileged(
 				w.stats.pointsWriteErr.Observe(float64(len(points)))
 			}
 			if err == tsdb.ErrShardDeletion {
-				err = tsdb.PartialWriteError{Reason: fmt.Sprintf("shard %d is pending deletion", shard.ID), Dropped: len(points)}
+				reason := fmt.Sprintf("shard %d is pending deletion", shard.ID)
+				for _, p := range points {
+					droppedPoints.Add(p, reason)
+				}
+				err = tsdb.PartialWriteError{Reason: reason, Dropped: len(points)}
 			}
 			ch <- err
 		}(shardMappings.Shards[shardID], database, retentionPolicy, points)
@@ -406,6 +412,9 @@ func (w *PointsWriter) WritePointsPrivileged(
 	if len(shardMappings.Dropped) > 0 {
 		w.stats.pointsWriteDropped.Observe(float64(len(shardMappings.Dropped)))
 		err = tsdb.PartialWriteError{Reason: "points beyond retention policy", Dropped: len(shardMappings.Dropped)}
+		for _, p := range shardMappings.Dropped {
+			droppedPoints.Add(p, "point beyond retention policy")
+		}
 	}
 	timeout := time.NewTimer(w.WriteTimeout)
 	defer timeout.Stop()
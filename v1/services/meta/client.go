@@ -1020,6 +1020,43 @@ func (c *Client) Load() error {
 	})
 }
 
+// Reload replaces the cached meta data with the meta data in the store,
+// which may have been replaced by another process, such as when a replica
+// restores a snapshot of the store of a write node.
+func (c *Client) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := &Data{}
+	if err := c.store.View(context.TODO(), func(tx kv.Tx) error {
+		b, err := tx.Bucket(BucketName)
+		if err != nil {
+			return err
+		}
+
+		buf, err := b.Get(metadataKey)
+		if err != nil {
+			return err
+		}
+		return data.UnmarshalBinary(buf)
+	}); errors.Is(err, kv.ErrKeyNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if data.Index == c.cacheData.Index {
+		return nil
+	}
+	c.cacheData = data
+
+	// close channels to signal changes
+	close(c.changed)
+	c.changed = make(chan struct{})
+
+	return nil
+}
+
 func (c *Client) RLock() {
 	c.store.RLock()
 }
@@ -11,11 +11,22 @@ import (
 type Config struct {
 	Enabled       bool          `toml:"enabled"`
 	CheckInterval toml.Duration `toml:"check-interval"`
+
+	// ProgressiveDelete enables continuous deletion of expired data in small
+	// time slices, ahead of whole shard groups being dropped.
+	ProgressiveDelete         bool          `toml:"progressive-delete"`
+	ProgressiveDeleteInterval toml.Duration `toml:"progressive-delete-interval"`
+	ProgressiveDeleteSlice    toml.Duration `toml:"progressive-delete-slice"`
 }
 
 // NewConfig returns an instance of Config with defaults.
 func NewConfig() Config {
-	return Config{Enabled: true, CheckInterval: toml.Duration(30 * time.Minute)}
+	return Config{
+		Enabled:                   true,
+		CheckInterval:             toml.Duration(30 * time.Minute),
+		ProgressiveDeleteInterval: toml.Duration(time.Minute),
+		ProgressiveDeleteSlice:    toml.Duration(10 * time.Minute),
+	}
 }
 
 // Validate returns an error if the Config is invalid.
@@ -28,5 +39,14 @@ func (c Config) Validate() error {
 		return errors.New("check-interval must be positive")
 	}
 
+	if c.ProgressiveDelete {
+		if c.ProgressiveDeleteInterval <= 0 {
+			return errors.New("progressive-delete-interval must be positive")
+		}
+		if c.ProgressiveDeleteSlice <= 0 {
+			return errors.New("progressive-delete-slice must be positive")
+		}
+	}
+
 	return nil
 }
@@ -14,6 +14,9 @@ func TestConfig_Parse(t *testing.T) {
 	if _, err := toml.Decode(`
 enabled = true
 check-interval = "1s"
+progressive-delete = true
+progressive-delete-interval = "2s"
+progressive-delete-slice = "3s"
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -23,6 +26,12 @@ check-interval = "1s"
 		t.Fatalf("unexpected enabled state: %v", c.Enabled)
 	} else if time.Duration(c.CheckInterval) != time.Second {
 		t.Fatalf("unexpected check interval: %v", c.CheckInterval)
+	} else if !c.ProgressiveDelete {
+		t.Fatalf("unexpected progressive delete state: %v", c.ProgressiveDelete)
+	} else if time.Duration(c.ProgressiveDeleteInterval) != 2*time.Second {
+		t.Fatalf("unexpected progressive delete interval: %v", c.ProgressiveDeleteInterval)
+	} else if time.Duration(c.ProgressiveDeleteSlice) != 3*time.Second {
+		t.Fatalf("unexpected progressive delete slice: %v", c.ProgressiveDeleteSlice)
 	}
 }
 
@@ -44,6 +53,20 @@ func TestConfig_Validate(t *testing.T) {
 		t.Fatal("expected error for negative check-interval, got nil")
 	}
 
+	c = retention.NewConfig()
+	c.ProgressiveDelete = true
+	c.ProgressiveDeleteSlice = 0
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for progressive-delete-slice = 0, got nil")
+	}
+
+	c = retention.NewConfig()
+	c.ProgressiveDelete = true
+	c.ProgressiveDeleteInterval = 0
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for progressive-delete-interval = 0, got nil")
+	}
+
 	c.Enabled = false
 	if err := c.Validate(); err != nil {
 		t.Fatalf("unexpected validation fail from disabled config: %s", err)
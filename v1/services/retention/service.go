@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/logger"
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
 	"github.com/prometheus/client_golang/prometheus"
@@ -22,6 +23,13 @@ type Service struct {
 	TSDBStore interface {
 		ShardIDs() []uint64
 		DeleteShard(shardID uint64) error
+		DeleteShardsRange(ctx context.Context, database string, shardIDs []uint64, min, max int64) error
+		DeleteStaleSeries(ctx context.Context, database string, stale []uint64) (int, error)
+	}
+	// BucketFinder finds the buckets whose series expire. Series do not
+	// expire when it is nil.
+	BucketFinder interface {
+		FindBuckets(context.Context, influxdb.BucketFilter, ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error)
 	}
 
 	config Config
@@ -55,6 +63,18 @@ func (s *Service) Open(ctx context.Context) error {
 		defer s.wg.Done()
 		s.run(ctx)
 	}()
+
+	if s.config.ProgressiveDelete {
+		s.logger.Info("Starting progressive retention deletes",
+			logger.DurationLiteral("progressive_delete_interval", time.Duration(s.config.ProgressiveDeleteInterval)),
+			logger.DurationLiteral("progressive_delete_slice", time.Duration(s.config.ProgressiveDeleteSlice)))
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.runProgressive(ctx)
+		}()
+	}
 	return nil
 }
 
@@ -85,7 +105,8 @@ const storageNamespace = "storage"
 const retentionSubsystem = "retention"
 
 type retentionMetrics struct {
-	checkDuration prometheus.Histogram
+	checkDuration             prometheus.Histogram
+	progressiveDeleteDuration prometheus.Histogram
 }
 
 func newRetentionMetrics() *retentionMetrics {
@@ -96,12 +117,19 @@ func newRetentionMetrics() *retentionMetrics {
 			Name:      "check_duration",
 			Help:      "Histogram of duration of retention check (in seconds)",
 		}),
+		progressiveDeleteDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: storageNamespace,
+			Subsystem: retentionSubsystem,
+			Name:      "progressive_delete_duration",
+			Help:      "Histogram of duration of progressive retention deletes (in seconds)",
+		}),
 	}
 }
 
 func PrometheusCollectors() []prometheus.Collector {
 	return []prometheus.Collector{
 		globalRetentionMetrics.checkDuration,
+		globalRetentionMetrics.progressiveDeleteDuration,
 	}
 }
 
@@ -187,6 +215,10 @@ func (s *Service) run(ctx context.Context) {
 				retryNeeded = true
 			}
 
+			if !s.expireSeries(ctx, log, time.Now().UTC()) {
+				retryNeeded = true
+			}
+
 			if retryNeeded {
 				log.Info("One or more errors occurred during shard deletion and will be retried on the next check", logger.DurationLiteral("check_interval", time.Duration(s.config.CheckInterval)))
 			}
@@ -197,3 +229,289 @@ func (s *Service) run(ctx context.Context) {
 		}
 	}
 }
+
+// runProgressive deletes expired data from shard groups that are still live,
+// one slice at a time, so that data ages out continuously rather than in
+// shard group sized steps when the group is eventually dropped.
+func (s *Service) runProgressive(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(s.config.ProgressiveDeleteInterval))
+	defer ticker.Stop()
+
+	// deletedThrough records, per retention policy, the time up to which
+	// expired data has already been deleted.
+	deletedThrough := make(map[string]int64)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			startTime := time.Now()
+			deletedThrough = s.deleteExpiredSlices(ctx, startTime.UTC(), deletedThrough)
+			globalRetentionMetrics.progressiveDeleteDuration.Observe(time.Since(startTime).Seconds())
+		}
+	}
+}
+
+// deleteExpiredSlices deletes at most one slice of expired data from each
+// retention policy and returns the updated deletion watermarks.
+func (s *Service) deleteExpiredSlices(ctx context.Context, now time.Time, deletedThrough map[string]int64) map[string]int64 {
+	slice := int64(s.config.ProgressiveDeleteSlice)
+	next := make(map[string]int64, len(deletedThrough))
+
+	for _, d := range s.MetaClient.Databases() {
+		for _, r := range d.RetentionPolicies {
+			if r.Duration == 0 {
+				continue
+			}
+
+			key := d.Name + "." + r.Name
+			lower, ok := oldestShardGroupStart(r)
+			if !ok {
+				continue
+			}
+			if through, ok := deletedThrough[key]; ok && through > lower {
+				lower = through
+			}
+			next[key] = lower
+
+			cutoff := now.Add(-r.Duration).UnixNano()
+			if lower >= cutoff {
+				continue
+			}
+
+			upper := lower + slice
+			if upper > cutoff {
+				upper = cutoff
+			}
+
+			// Only the shards holding the slice are touched, rather than
+			// every shard of the database.
+			ids := shardIDsInRange(r, lower, upper)
+			if len(ids) == 0 {
+				next[key] = upper
+				continue
+			}
+			if err := s.TSDBStore.DeleteShardsRange(ctx, d.Name, ids, lower, upper-1); err != nil {
+				s.logger.Info("Failed to delete expired data",
+					logger.Database(d.Name),
+					logger.RetentionPolicy(r.Name),
+					zap.Error(err))
+				continue
+			}
+
+			s.logger.Debug("Deleted expired data",
+				logger.Database(d.Name),
+				logger.RetentionPolicy(r.Name),
+				zap.Time("min", time.Unix(0, lower).UTC()),
+				zap.Time("max", time.Unix(0, upper).UTC()))
+			next[key] = upper
+		}
+	}
+	return next
+}
+
+// expireSeries removes the series of the buckets with a series TTL that
+// received no points for longer than the TTL. Series are tracked per shard
+// group: a series expires once every shard group it has data in ended
+// before the TTL, so it can outlive the TTL by up to a shard group duration.
+// It returns false if the series of a bucket could not be expired.
+func (s *Service) expireSeries(ctx context.Context, log *zap.Logger, now time.Time) bool {
+	if s.BucketFinder == nil {
+		return true
+	}
+
+	var buckets []*influxdb.Bucket
+	for offset := 0; ; offset += influxdb.MaxPageSize {
+		bs, _, err := s.BucketFinder.FindBuckets(ctx, influxdb.BucketFilter{}, influxdb.FindOptions{Limit: influxdb.MaxPageSize, Offset: offset})
+		if err != nil {
+			log.Info("Failed to find the buckets whose series expire", zap.Error(err))
+			return false
+		}
+		buckets = append(buckets, bs...)
+		if len(bs) < influxdb.MaxPageSize {
+			break
+		}
+	}
+
+	ok := true
+	for _, b := range buckets {
+		if b.SeriesTTL <= 0 {
+			continue
+		}
+		cutoff := now.Add(-b.SeriesTTL)
+		database := b.ID.String()
+
+		var stale []uint64
+		if di := s.findDatabase(database); di != nil {
+			for _, r := range di.RetentionPolicies {
+				for _, g := range r.ShardGroups {
+					if g.Deleted() || g.EndTime.After(cutoff) {
+						continue
+					}
+					for _, sh := range g.Shards {
+						stale = append(stale, sh.ID)
+					}
+				}
+			}
+		}
+		if len(stale) == 0 {
+			continue
+		}
+
+		n, err := s.TSDBStore.DeleteStaleSeries(ctx, database, stale)
+		if err != nil {
+			log.Info("Failed to expire series", logger.Database(database), zap.Error(err))
+			ok = false
+			continue
+		}
+		if n > 0 {
+			log.Info("Expired series",
+				logger.Database(database),
+				zap.Int("series", n),
+				zap.Time("inactive_since", cutoff))
+		}
+	}
+	return ok
+}
+
+func (s *Service) findDatabase(name string) *meta.DatabaseInfo {
+	for _, d := range s.MetaClient.Databases() {
+		if d.Name == name {
+			return &d
+		}
+	}
+	return nil
+}
+
+// oldestShardGroupStart returns the start time of the oldest shard group of
+// rpi that has not been deleted.
+func oldestShardGroupStart(rpi meta.RetentionPolicyInfo) (int64, bool) {
+	var (
+		oldest int64
+		found  bool
+	)
+	for _, g := range rpi.ShardGroups {
+		if g.Deleted() {
+			continue
+		}
+		if start := g.StartTime.UnixNano(); !found || start < oldest {
+			oldest, found = start, true
+		}
+	}
+	return oldest, found
+}
+
+// shardIDsInRange returns the shards of the shard groups of rpi that hold
+// data between min and max, excluding max.
+func shardIDsInRange(rpi meta.RetentionPolicyInfo, min, max int64) []uint64 {
+	var ids []uint64
+	for _, g := range rpi.ShardGroups {
+		if g.Deleted() || g.EndTime.UnixNano() <= min || g.StartTime.UnixNano() >= max {
+			continue
+		}
+		ids = append(ids, shardIDs(g)...)
+	}
+	return ids
+}
+
+// shardIDs returns the IDs of the shards of g.
+func shardIDs(g meta.ShardGroupInfo) []uint64 {
+	ids := make([]uint64, 0, len(g.Shards))
+	for _, sh := range g.Shards {
+		ids = append(ids, sh.ID)
+	}
+	return ids
+}
+
+// EnforceRetentionPolicy immediately removes the data of the retention policy
+// that is older than duration: shard groups that expired entirely are dropped
+// and the expired part of the remaining shard groups is deleted. A dry run
+// only reports what would be removed.
+func (s *Service) EnforceRetentionPolicy(ctx context.Context, database, policy string, duration time.Duration, dryRun bool) (*influxdb.RetentionEnforcement, error) {
+	now := time.Now().UTC()
+	enf := &influxdb.RetentionEnforcement{
+		DryRun:          dryRun,
+		RetentionPeriod: duration,
+	}
+	if duration == 0 {
+		return enf, nil
+	}
+	enf.Cutoff = now.Add(-duration)
+
+	rpi, err := s.findRetentionPolicy(database, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	log := s.logger.With(logger.Database(database), logger.RetentionPolicy(policy))
+
+	dropped := make(map[uint64]struct{})
+	for _, g := range rpi.ShardGroups {
+		if g.Deleted() || !g.StartTime.Before(enf.Cutoff) {
+			continue
+		}
+
+		if g.EndTime.Before(enf.Cutoff) {
+			enf.Ranges = append(enf.Ranges, influxdb.RetentionRange{
+				ShardGroupID: g.ID,
+				Start:        g.StartTime,
+				End:          g.EndTime,
+				Dropped:      true,
+			})
+			if dryRun {
+				continue
+			}
+			if err := s.MetaClient.DeleteShardGroup(database, policy, g.ID); err != nil {
+				return nil, err
+			}
+			log.Info("Deleted shard group", logger.ShardGroup(g.ID))
+			for _, sh := range g.Shards {
+				dropped[sh.ID] = struct{}{}
+			}
+			continue
+		}
+
+		enf.Ranges = append(enf.Ranges, influxdb.RetentionRange{
+			ShardGroupID: g.ID,
+			Start:        g.StartTime,
+			End:          enf.Cutoff,
+		})
+		if dryRun {
+			continue
+		}
+		if err := s.TSDBStore.DeleteShardsRange(ctx, database, shardIDs(g), g.StartTime.UnixNano(), enf.Cutoff.UnixNano()-1); err != nil {
+			return nil, err
+		}
+		log.Info("Deleted expired data",
+			logger.ShardGroup(g.ID),
+			zap.Time("min", g.StartTime),
+			zap.Time("max", enf.Cutoff))
+	}
+
+	for _, id := range s.TSDBStore.ShardIDs() {
+		if _, ok := dropped[id]; !ok {
+			continue
+		}
+		if err := s.TSDBStore.DeleteShard(id); err != nil {
+			return nil, err
+		}
+		log.Info("Deleted shard", logger.Shard(id))
+	}
+
+	return enf, nil
+}
+
+func (s *Service) findRetentionPolicy(database, policy string) (meta.RetentionPolicyInfo, error) {
+	for _, d := range s.MetaClient.Databases() {
+		if d.Name != database {
+			continue
+		}
+		for _, r := range d.RetentionPolicies {
+			if r.Name == policy {
+				return r, nil
+			}
+		}
+	}
+	return meta.RetentionPolicyInfo{}, meta.ErrRetentionPolicyNotFound
+}
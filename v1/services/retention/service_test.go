@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/internal"
 	"github.com/influxdata/influxdb/v2/toml"
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
@@ -376,6 +377,306 @@ func testService_8819_repro(t *testing.T) (*Service, chan error, chan struct{})
 	return s, errC, done
 }
 
+func TestService_ProgressiveDelete(t *testing.T) {
+	start := time.Now().UTC().Truncate(time.Hour).Add(-3 * time.Hour)
+	data := []meta.DatabaseInfo{
+		{
+			Name:                   "db0",
+			DefaultRetentionPolicy: "rp0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{
+				{
+					Name:               "rp0",
+					ReplicaN:           1,
+					Duration:           time.Hour,
+					ShardGroupDuration: 24 * time.Hour,
+					ShardGroups: []meta.ShardGroupInfo{
+						{
+							ID:        1,
+							StartTime: start,
+							EndTime:   start.Add(24 * time.Hour),
+							Shards:    []meta.ShardInfo{{ID: 2}},
+						},
+						{
+							// Shard groups past the slice are left untouched.
+							ID:        5,
+							StartTime: start.Add(24 * time.Hour),
+							EndTime:   start.Add(48 * time.Hour),
+							Shards:    []meta.ShardInfo{{ID: 6}},
+						},
+					},
+				},
+				{
+					// Infinite retention is never deleted progressively.
+					Name:               "rp1",
+					ReplicaN:           1,
+					ShardGroupDuration: 24 * time.Hour,
+					ShardGroups: []meta.ShardGroupInfo{
+						{
+							ID:        3,
+							StartTime: start,
+							EndTime:   start.Add(24 * time.Hour),
+							Shards:    []meta.ShardInfo{{ID: 4}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	config := retention.NewConfig()
+	config.ProgressiveDelete = true
+	config.ProgressiveDeleteInterval = toml.Duration(10 * time.Millisecond)
+	config.ProgressiveDeleteSlice = toml.Duration(10 * time.Minute)
+	s := NewService(t, config)
+	s.MetaClient.DatabasesFn = func() []meta.DatabaseInfo {
+		return data
+	}
+
+	type deletion struct {
+		db       string
+		shardIDs []uint64
+		min, max int64
+	}
+	var mu sync.Mutex
+	var deletions []deletion
+	done := make(chan struct{})
+	s.TSDBStore.DeleteShardsRangeFn = func(_ context.Context, database string, shardIDs []uint64, min, max int64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deletions = append(deletions, deletion{db: database, shardIDs: shardIDs, min: min, max: max})
+		if len(deletions) == 2 {
+			close(done)
+		}
+		return nil
+	}
+
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatalf("unexpected open error: %s", err)
+	}
+	defer func() {
+		if err := s.Close(); err != nil {
+			t.Fatalf("unexpected close error: %s", err)
+		}
+	}()
+
+	timer := time.NewTimer(time.Second)
+	select {
+	case <-done:
+		timer.Stop()
+	case <-timer.C:
+		t.Fatal("timeout waiting for progressive deletes")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	slice := int64(10 * time.Minute)
+	exp := []deletion{
+		{db: "db0", shardIDs: []uint64{2}, min: start.UnixNano(), max: start.UnixNano() + slice - 1},
+		{db: "db0", shardIDs: []uint64{2}, min: start.UnixNano() + slice, max: start.UnixNano() + 2*slice - 1},
+	}
+	if got := deletions[:2]; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected deletions: got %v, exp %v", got, exp)
+	}
+}
+
+func TestService_EnforceRetentionPolicy(t *testing.T) {
+	now := time.Now().UTC()
+	data := []meta.DatabaseInfo{
+		{
+			Name:                   "db0",
+			DefaultRetentionPolicy: "rp0",
+			RetentionPolicies: []meta.RetentionPolicyInfo{
+				{
+					Name:               "rp0",
+					ReplicaN:           1,
+					Duration:           72 * time.Hour,
+					ShardGroupDuration: 24 * time.Hour,
+					ShardGroups: []meta.ShardGroupInfo{
+						{
+							ID:        1,
+							StartTime: now.Add(-72 * time.Hour),
+							EndTime:   now.Add(-48 * time.Hour),
+							Shards:    []meta.ShardInfo{{ID: 10}},
+						},
+						{
+							ID:        2,
+							StartTime: now.Add(-36 * time.Hour),
+							EndTime:   now.Add(-12 * time.Hour),
+							Shards:    []meta.ShardInfo{{ID: 20}},
+						},
+						{
+							ID:        3,
+							StartTime: now.Add(-12 * time.Hour),
+							EndTime:   now.Add(12 * time.Hour),
+							Shards:    []meta.ShardInfo{{ID: 30}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	newService := func(t *testing.T) (*Service, *[]string) {
+		s := NewService(t, retention.NewConfig())
+		var calls []string
+		s.MetaClient.DatabasesFn = func() []meta.DatabaseInfo {
+			return data
+		}
+		s.MetaClient.DeleteShardGroupFn = func(database, policy string, id uint64) error {
+			calls = append(calls, fmt.Sprintf("DeleteShardGroup(%s, %s, %d)", database, policy, id))
+			return nil
+		}
+		s.TSDBStore.ShardIDsFn = func() []uint64 {
+			return []uint64{10, 20, 30}
+		}
+		s.TSDBStore.DeleteShardFn = func(id uint64) error {
+			calls = append(calls, fmt.Sprintf("DeleteShard(%d)", id))
+			return nil
+		}
+		s.TSDBStore.DeleteShardsRangeFn = func(_ context.Context, database string, shardIDs []uint64, min, max int64) error {
+			calls = append(calls, fmt.Sprintf("DeleteShardsRange(%s, %v, %d, %d)", database, shardIDs, min, max))
+			return nil
+		}
+		return s, &calls
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		s, calls := newService(t)
+		enf, err := s.EnforceRetentionPolicy(context.Background(), "db0", "rp0", 24*time.Hour, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*calls) != 0 {
+			t.Fatalf("dry run removed data: %v", *calls)
+		}
+		if !enf.DryRun || len(enf.Ranges) != 2 {
+			t.Fatalf("unexpected enforcement: %+v", enf)
+		}
+		if r := enf.Ranges[0]; r.ShardGroupID != 1 || !r.Dropped {
+			t.Fatalf("expected shard group 1 to be dropped: %+v", r)
+		}
+		if r := enf.Ranges[1]; r.ShardGroupID != 2 || r.Dropped || !r.End.Equal(enf.Cutoff) {
+			t.Fatalf("expected shard group 2 to be deleted up to the cutoff: %+v", r)
+		}
+	})
+
+	t.Run("enforce", func(t *testing.T) {
+		s, calls := newService(t)
+		enf, err := s.EnforceRetentionPolicy(context.Background(), "db0", "rp0", 24*time.Hour, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := []string{
+			"DeleteShardGroup(db0, rp0, 1)",
+			fmt.Sprintf("DeleteShardsRange(db0, [20], %d, %d)", data[0].RetentionPolicies[0].ShardGroups[1].StartTime.UnixNano(), enf.Cutoff.UnixNano()-1),
+			"DeleteShard(10)",
+		}
+		if !reflect.DeepEqual(*calls, exp) {
+			t.Fatalf("unexpected calls: got %v, exp %v", *calls, exp)
+		}
+	})
+
+	t.Run("infinite retention", func(t *testing.T) {
+		s, calls := newService(t)
+		enf, err := s.EnforceRetentionPolicy(context.Background(), "db0", "rp0", 0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*calls) != 0 || len(enf.Ranges) != 0 {
+			t.Fatalf("infinite retention removed data: %v", *calls)
+		}
+	})
+
+	t.Run("unknown policy", func(t *testing.T) {
+		s, _ := newService(t)
+		if _, err := s.EnforceRetentionPolicy(context.Background(), "db1", "rp0", time.Hour, false); err != meta.ErrRetentionPolicyNotFound {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+type bucketFinder []*influxdb.Bucket
+
+func (f bucketFinder) FindBuckets(context.Context, influxdb.BucketFilter, ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+	return f, len(f), nil
+}
+
+func TestService_ExpireSeries(t *testing.T) {
+	now := time.Now().UTC()
+	shardGroups := []meta.ShardGroupInfo{
+		{
+			ID:        1,
+			StartTime: now.Add(-72 * time.Hour),
+			EndTime:   now.Add(-48 * time.Hour),
+			Shards:    []meta.ShardInfo{{ID: 10}},
+		},
+		{
+			ID:        2,
+			StartTime: now.Add(-36 * time.Hour),
+			EndTime:   now.Add(-12 * time.Hour),
+			Shards:    []meta.ShardInfo{{ID: 20}},
+		},
+		{
+			ID:        3,
+			StartTime: now.Add(-12 * time.Hour),
+			EndTime:   now.Add(12 * time.Hour),
+			Shards:    []meta.ShardInfo{{ID: 30}},
+		},
+	}
+	data := []meta.DatabaseInfo{
+		{Name: "0000000000000001", RetentionPolicies: []meta.RetentionPolicyInfo{{Name: "autogen", ShardGroups: shardGroups}}},
+		{Name: "0000000000000002", RetentionPolicies: []meta.RetentionPolicyInfo{{Name: "autogen", ShardGroups: shardGroups}}},
+	}
+
+	config := retention.NewConfig()
+	config.CheckInterval = toml.Duration(10 * time.Millisecond)
+	s := NewService(t, config)
+	s.Service.BucketFinder = bucketFinder{
+		{ID: 1, SeriesTTL: 24 * time.Hour},
+		{ID: 2},
+	}
+	s.MetaClient.DatabasesFn = func() []meta.DatabaseInfo {
+		return data
+	}
+	s.MetaClient.PruneShardGroupsFn = func() error { return nil }
+	s.TSDBStore.ShardIDsFn = func() []uint64 { return nil }
+
+	type expiry struct {
+		database string
+		stale    []uint64
+	}
+	expired := make(chan expiry, 1)
+	s.TSDBStore.DeleteStaleSeriesFn = func(_ context.Context, database string, stale []uint64) (int, error) {
+		select {
+		case expired <- expiry{database: database, stale: stale}:
+		default:
+		}
+		return 1, nil
+	}
+
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatalf("unexpected open error: %s", err)
+	}
+	defer func() {
+		if err := s.Close(); err != nil {
+			t.Fatalf("unexpected close error: %s", err)
+		}
+	}()
+
+	timer := time.NewTimer(100 * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case got := <-expired:
+		// Only the shard group that ended before the TTL is stale, and only
+		// the bucket with a TTL expires series.
+		if want := (expiry{database: "0000000000000001", stale: []uint64{10}}); !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected expiry: got=%#v want=%#v", got, want)
+		}
+	case <-timer.C:
+		t.Errorf("timeout waiting for series to expire")
+	}
+}
+
 type Service struct {
 	MetaClient *internal.MetaClientMock
 	TSDBStore  *internal.TSDBStoreMock
@@ -0,0 +1,498 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	// queueSize is the number of deliveries an endpoint queues before
+	// events are dropped.
+	queueSize = 1024
+	// deliveryLogSize is the number of deliveries kept in the delivery log.
+	deliveryLogSize = 1000
+	// maxBackoff bounds the wait between two attempts of a delivery.
+	maxBackoff = time.Minute
+)
+
+// DeliveryStatus is the status of a delivery.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is the status of a delivery queued or being retried.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered is the status of a delivery the endpoint accepted.
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed is the status of a delivery given up on.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// Delivery is the record of an event delivered to an endpoint.
+type Delivery struct {
+	ID        string         `json:"id"`
+	EventID   string         `json:"eventID"`
+	EventType EventType      `json:"eventType"`
+	Endpoint  string         `json:"endpoint"`
+	Status    DeliveryStatus `json:"status"`
+	Attempts  int            `json:"attempts"`
+	// StatusCode is the status of the last response of the endpoint.
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	body []byte
+}
+
+// DeliveryFilter selects the deliveries of the delivery log.
+type DeliveryFilter struct {
+	Endpoint string
+	Status   DeliveryStatus
+	// Limit is the maximum number of deliveries returned, 0 is unlimited.
+	Limit int
+}
+
+// DispatcherOption configures a Dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithHTTPClient sets the client deliveries are made with.
+func WithHTTPClient(c *http.Client) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.client = c
+	}
+}
+
+// WithRetries sets the number of attempts of a delivery and the wait after
+// its first failed attempt, doubling with every attempt.
+func WithRetries(maxAttempts int, backoff time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.maxAttempts = maxAttempts
+		d.backoff = backoff
+	}
+}
+
+// WithConfigPath sets the file the endpoints created and deleted with
+// CreateEndpoint and DeleteEndpoint are saved to, so they are kept across
+// restarts. Without it they are only kept in memory.
+func WithConfigPath(path string) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.configPath = path
+	}
+}
+
+// Dispatcher delivers events to the endpoints whose filter matches them.
+// Every endpoint has a queue of its own delivered in order, so a slow or
+// failing endpoint does not delay the others. The queues are kept in
+// memory, the deliveries pending when the server stops are lost.
+type Dispatcher struct {
+	log         *zap.Logger
+	client      *http.Client
+	idGen       platform.IDGenerator
+	now         func() time.Time
+	maxAttempts int
+	backoff     time.Duration
+	configPath  string
+
+	endpointsMu sync.RWMutex
+	endpoints   []*endpointQueue
+
+	mu         sync.Mutex
+	deliveries []*Delivery
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	delivered *prometheus.CounterVec
+	dropped   *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+}
+
+type endpointQueue struct {
+	Endpoint
+	queue chan *Delivery
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewDispatcher constructs a Dispatcher delivering events to endpoints and
+// starts delivering. Close stops it.
+func NewDispatcher(log *zap.Logger, endpoints []Endpoint, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		log:         log,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		idGen:       snowflake.NewDefaultIDGenerator(),
+		now:         time.Now,
+		maxAttempts: 5,
+		backoff:     time.Second,
+		delivered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "webhook",
+			Name:      "deliveries_total",
+			Help:      "Number of finished deliveries by endpoint and status",
+		}, []string{"endpoint", "status"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "webhook",
+			Name:      "dropped_events_total",
+			Help:      "Number of events not delivered because the queue of the endpoint was full",
+		}, []string{"endpoint"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "webhook",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the requests made to endpoints",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 4, 6),
+		}, []string{"endpoint"}),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.maxAttempts < 1 {
+		d.maxAttempts = 1
+	}
+
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	for _, e := range endpoints {
+		d.endpoints = append(d.endpoints, d.start(e))
+	}
+	return d
+}
+
+// start starts delivering the queue of an endpoint.
+func (d *Dispatcher) start(e Endpoint) *endpointQueue {
+	q := &endpointQueue{
+		Endpoint: e,
+		queue:    make(chan *Delivery, queueSize),
+		done:     make(chan struct{}),
+	}
+	q.ctx, q.cancel = context.WithCancel(d.ctx)
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer close(q.done)
+		d.run(q)
+	}()
+	return q
+}
+
+// PrometheusCollectors returns the metrics of the deliveries.
+func (d *Dispatcher) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{d.delivered, d.dropped, d.latency}
+}
+
+// Endpoints returns the endpoints events are delivered to.
+func (d *Dispatcher) Endpoints() []Endpoint {
+	d.endpointsMu.RLock()
+	defer d.endpointsMu.RUnlock()
+
+	endpoints := make([]Endpoint, len(d.endpoints))
+	for i, q := range d.endpoints {
+		endpoints[i] = q.Endpoint
+	}
+	return endpoints
+}
+
+// CreateEndpoint starts delivering the events matching its filter to e. The
+// name of e must not be used by another endpoint.
+func (d *Dispatcher) CreateEndpoint(e Endpoint) error {
+	if err := e.validate(); err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  err.Error(),
+		}
+	}
+
+	d.endpointsMu.Lock()
+	defer d.endpointsMu.Unlock()
+
+	for _, q := range d.endpoints {
+		if q.Name == e.Name {
+			return &errors.Error{
+				Code: errors.EConflict,
+				Msg:  fmt.Sprintf("webhook endpoint %s already exists", e.Name),
+			}
+		}
+	}
+
+	endpoints := make([]Endpoint, 0, len(d.endpoints)+1)
+	for _, q := range d.endpoints {
+		endpoints = append(endpoints, q.Endpoint)
+	}
+	if err := d.save(append(endpoints, e)); err != nil {
+		return err
+	}
+
+	d.endpoints = append(d.endpoints, d.start(e))
+	return nil
+}
+
+// DeleteEndpoint stops delivering events to the endpoint named name. Its
+// deliveries still queued fail.
+func (d *Dispatcher) DeleteEndpoint(name string) error {
+	d.endpointsMu.Lock()
+	defer d.endpointsMu.Unlock()
+
+	i := -1
+	endpoints := make([]Endpoint, 0, len(d.endpoints))
+	for j, q := range d.endpoints {
+		if q.Name == name {
+			i = j
+			continue
+		}
+		endpoints = append(endpoints, q.Endpoint)
+	}
+	if i < 0 {
+		return &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("webhook endpoint %s not found", name),
+		}
+	}
+	if err := d.save(endpoints); err != nil {
+		return err
+	}
+
+	q := d.endpoints[i]
+	d.endpoints = append(d.endpoints[:i:i], d.endpoints[i+1:]...)
+
+	// Publish holds the lock while queueing, so nothing is queued once the
+	// goroutine delivering the queue is done.
+	q.cancel()
+	<-q.done
+	for {
+		select {
+		case delivery := <-q.queue:
+			d.finish(delivery, DeliveryFailed, 0, fmt.Errorf("endpoint was deleted"))
+		default:
+			return nil
+		}
+	}
+}
+
+// save writes the endpoints to the config file of the dispatcher, if any.
+func (d *Dispatcher) save(endpoints []Endpoint) error {
+	if d.configPath == "" {
+		return nil
+	}
+	c := Config{Endpoints: endpoints}
+	if err := c.Save(d.configPath); err != nil {
+		return &errors.Error{
+			Code: errors.EInternal,
+			Msg:  "failed to save webhook endpoints",
+			Err:  err,
+		}
+	}
+	return nil
+}
+
+// Publish queues the delivery of e to the endpoints matching its type. It
+// does not wait for the deliveries, an event is dropped for the endpoints
+// whose queue is full.
+func (d *Dispatcher) Publish(e Event) {
+	if e.ID == "" {
+		e.ID = d.idGen.ID().String()
+	}
+	if e.Time.IsZero() {
+		e.Time = d.now()
+	}
+	e.Time = e.Time.UTC()
+
+	d.endpointsMu.RLock()
+	defer d.endpointsMu.RUnlock()
+
+	var body []byte
+	for _, q := range d.endpoints {
+		if !q.Matches(e.Type) {
+			continue
+		}
+		if body == nil {
+			var err error
+			if body, err = json.Marshal(e); err != nil {
+				d.log.Error("Failed to encode webhook event", zap.String("type", string(e.Type)), zap.Error(err))
+				return
+			}
+		}
+
+		now := d.now().UTC()
+		delivery := &Delivery{
+			ID:        d.idGen.ID().String(),
+			EventID:   e.ID,
+			EventType: e.Type,
+			Endpoint:  q.Name,
+			Status:    DeliveryPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+			body:      body,
+		}
+		d.record(delivery)
+
+		select {
+		case q.queue <- delivery:
+		default:
+			d.dropped.WithLabelValues(q.Name).Inc()
+			d.finish(delivery, DeliveryFailed, 0, fmt.Errorf("queue of endpoint is full"))
+		}
+	}
+}
+
+// Deliveries returns the deliveries of the delivery log matching filter,
+// the latest first.
+func (d *Dispatcher) Deliveries(filter DeliveryFilter) []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var deliveries []Delivery
+	for i := len(d.deliveries) - 1; i >= 0; i-- {
+		delivery := d.deliveries[i]
+		if filter.Endpoint != "" && delivery.Endpoint != filter.Endpoint {
+			continue
+		}
+		if filter.Status != "" && delivery.Status != filter.Status {
+			continue
+		}
+		deliveries = append(deliveries, *delivery)
+		if filter.Limit > 0 && len(deliveries) == filter.Limit {
+			break
+		}
+	}
+	return deliveries
+}
+
+// Close stops delivering events and waits for the attempts in progress.
+func (d *Dispatcher) Close() error {
+	d.cancel()
+	d.wg.Wait()
+	return nil
+}
+
+func (d *Dispatcher) record(delivery *Delivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.deliveries = append(d.deliveries, delivery)
+	if n := len(d.deliveries) - deliveryLogSize; n > 0 {
+		copy(d.deliveries, d.deliveries[n:])
+		for i := len(d.deliveries) - n; i < len(d.deliveries); i++ {
+			d.deliveries[i] = nil
+		}
+		d.deliveries = d.deliveries[:len(d.deliveries)-n]
+	}
+}
+
+// update applies fn to a delivery under the lock of the delivery log.
+func (d *Dispatcher) update(delivery *Delivery, fn func(*Delivery)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	fn(delivery)
+	delivery.UpdatedAt = d.now().UTC()
+}
+
+func (d *Dispatcher) finish(delivery *Delivery, status DeliveryStatus, code int, err error) {
+	d.update(delivery, func(delivery *Delivery) {
+		delivery.Status = status
+		delivery.StatusCode = code
+		delivery.Error = ""
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		delivery.body = nil
+	})
+	d.delivered.WithLabelValues(delivery.Endpoint, string(status)).Inc()
+}
+
+func (d *Dispatcher) run(q *endpointQueue) {
+	log := d.log.With(zap.String("endpoint", q.Name))
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case delivery := <-q.queue:
+			d.deliver(log, q, delivery)
+		}
+	}
+}
+
+// deliver attempts a delivery until the endpoint accepts it, rejects it
+// with a status that is not worth retrying or the attempts run out.
+func (d *Dispatcher) deliver(log *zap.Logger, q *endpointQueue, delivery *Delivery) {
+	backoff := d.backoff
+	for attempt := 1; ; attempt++ {
+		code, retry, err := d.attempt(q, delivery)
+		d.update(delivery, func(delivery *Delivery) {
+			delivery.Attempts = attempt
+			delivery.StatusCode = code
+		})
+		if err == nil {
+			d.finish(delivery, DeliveryDelivered, code, nil)
+			return
+		}
+		if !retry || attempt >= d.maxAttempts {
+			log.Warn("Failed to deliver webhook event",
+				zap.String("event_id", delivery.EventID),
+				zap.Int("attempts", attempt),
+				zap.Error(err))
+			d.finish(delivery, DeliveryFailed, code, err)
+			return
+		}
+		d.update(delivery, func(delivery *Delivery) {
+			delivery.Error = err.Error()
+		})
+
+		select {
+		case <-q.ctx.Done():
+			d.finish(delivery, DeliveryFailed, code, fmt.Errorf("delivery stopped before it succeeded: %w", err))
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// attempt posts a delivery to its endpoint. It returns the status of the
+// response and whether a failed attempt is worth retrying.
+func (d *Dispatcher) attempt(q *endpointQueue, delivery *Delivery) (int, bool, error) {
+	req, err := http.NewRequestWithContext(q.ctx, http.MethodPost, q.URL, bytes.NewReader(delivery.body))
+	if err != nil {
+		return 0, false, err
+	}
+	now := d.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "influxdb-webhook")
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderTimestamp, fmt.Sprint(now.Unix()))
+	if q.Secret != "" {
+		req.Header.Set(HeaderSignature, "sha256="+Sign(q.Secret, now, delivery.body))
+	}
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	d.latency.WithLabelValues(q.Name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return 0, true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return resp.StatusCode, false, nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return resp.StatusCode, true, fmt.Errorf("endpoint responded %s", resp.Status)
+	default:
+		return resp.StatusCode, false, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+}
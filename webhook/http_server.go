@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixWebhooks = "/api/v2/webhooks"
+
+// Handler serves the endpoints of the dispatcher and its delivery log to
+// operators.
+type Handler struct {
+	chi.Router
+
+	log        *zap.Logger
+	api        *kithttp.API
+	dispatcher *Dispatcher
+}
+
+// NewHTTPHandler constructs a handler creating, listing and deleting the
+// endpoints of the dispatcher and listing its deliveries.
+func NewHTTPHandler(log *zap.Logger, dispatcher *Dispatcher) *Handler {
+	h := &Handler{
+		log:        log,
+		api:        kithttp.NewAPI(kithttp.WithLog(log)),
+		dispatcher: dispatcher,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+		h.mwAuthorize,
+	)
+
+	r.Get("/", h.handleGetEndpoints)
+	r.Post("/", h.handlePostEndpoint)
+	r.Get("/deliveries", h.handleGetDeliveries)
+	r.Delete("/{name}", h.handleDeleteEndpoint)
+	h.Router = r
+	return h
+}
+
+// Prefix is the route the handler is mounted at.
+func (h *Handler) Prefix() string {
+	return prefixWebhooks
+}
+
+type endpointResponse struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Signed bool     `json:"signed"`
+	Events []string `json:"events"`
+}
+
+func newEndpointResponse(e Endpoint) endpointResponse {
+	events := e.Events
+	if events == nil {
+		events = []string{}
+	}
+	return endpointResponse{
+		Name:   e.Name,
+		URL:    e.URL,
+		Signed: e.Secret != "",
+		Events: events,
+	}
+}
+
+type endpointsResponse struct {
+	Endpoints []endpointResponse `json:"endpoints"`
+}
+
+type deliveriesResponse struct {
+	Deliveries []Delivery `json:"deliveries"`
+}
+
+// handleGetEndpoints is the HTTP handler for the GET /api/v2/webhooks route.
+// The secrets of the endpoints are never returned.
+func (h *Handler) handleGetEndpoints(w http.ResponseWriter, r *http.Request) {
+	endpoints := h.dispatcher.Endpoints()
+	resp := endpointsResponse{Endpoints: make([]endpointResponse, 0, len(endpoints))}
+	for _, e := range endpoints {
+		resp.Endpoints = append(resp.Endpoints, newEndpointResponse(e))
+	}
+	h.api.Respond(w, r, http.StatusOK, resp)
+}
+
+// handlePostEndpoint is the HTTP handler for the POST /api/v2/webhooks route.
+// The secret of the endpoint is not returned.
+func (h *Handler) handlePostEndpoint(w http.ResponseWriter, r *http.Request) {
+	var e Endpoint
+	if err := h.api.DecodeJSON(r.Body, &e); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if err := h.dispatcher.CreateEndpoint(e); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusCreated, newEndpointResponse(e))
+}
+
+// handleDeleteEndpoint is the HTTP handler for the DELETE /api/v2/webhooks/:name route.
+func (h *Handler) handleDeleteEndpoint(w http.ResponseWriter, r *http.Request) {
+	if err := h.dispatcher.DeleteEndpoint(chi.URLParam(r, "name")); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetDeliveries is the HTTP handler for the GET /api/v2/webhooks/deliveries route.
+func (h *Handler) handleGetDeliveries(w http.ResponseWriter, r *http.Request) {
+	qp := r.URL.Query()
+	filter := DeliveryFilter{
+		Endpoint: qp.Get("endpoint"),
+		Status:   DeliveryStatus(qp.Get("status")),
+	}
+	switch filter.Status {
+	case "", DeliveryPending, DeliveryDelivered, DeliveryFailed:
+	default:
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid delivery status %q", filter.Status),
+		})
+		return
+	}
+	if v := qp.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "limit must be a positive integer",
+			})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	deliveries := h.dispatcher.Deliveries(filter)
+	if deliveries == nil {
+		deliveries = []Delivery{}
+	}
+	h.api.Respond(w, r, http.StatusOK, deliveriesResponse{Deliveries: deliveries})
+}
+
+func (h *Handler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("access to %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
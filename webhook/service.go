@@ -0,0 +1,173 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/task/backend"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+)
+
+// BucketService wraps a bucket service and publishes the events of the
+// buckets it creates and deletes.
+type BucketService struct {
+	influxdb.BucketService
+	Dispatcher *Dispatcher
+}
+
+// NewBucketService constructs a BucketService publishing to d.
+func NewBucketService(s influxdb.BucketService, d *Dispatcher) *BucketService {
+	return &BucketService{
+		BucketService: s,
+		Dispatcher:    d,
+	}
+}
+
+// CreateBucket creates the bucket and publishes EventBucketCreated.
+func (s *BucketService) CreateBucket(ctx context.Context, b *influxdb.Bucket) error {
+	if err := s.BucketService.CreateBucket(ctx, b); err != nil {
+		return err
+	}
+
+	s.Dispatcher.Publish(bucketEvent(EventBucketCreated, b))
+	return nil
+}
+
+// DeleteBucket deletes the bucket and publishes EventBucketDeleted.
+func (s *BucketService) DeleteBucket(ctx context.Context, id platform.ID) error {
+	b, err := s.BucketService.FindBucketByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.BucketService.DeleteBucket(ctx, id); err != nil {
+		return err
+	}
+
+	s.Dispatcher.Publish(bucketEvent(EventBucketDeleted, b))
+	return nil
+}
+
+func bucketEvent(t EventType, b *influxdb.Bucket) Event {
+	return Event{
+		Type:       t,
+		OrgID:      b.OrgID.String(),
+		ResourceID: b.ID.String(),
+		Data: map[string]interface{}{
+			"name":            b.Name,
+			"type":            b.Type.String(),
+			"retentionPeriod": b.RetentionPeriod.String(),
+		},
+	}
+}
+
+// AuthorizationService wraps an authorization service and publishes the
+// events of the tokens it deletes or makes inactive.
+type AuthorizationService struct {
+	influxdb.AuthorizationService
+	Dispatcher *Dispatcher
+}
+
+// NewAuthorizationService constructs an AuthorizationService publishing to d.
+func NewAuthorizationService(s influxdb.AuthorizationService, d *Dispatcher) *AuthorizationService {
+	return &AuthorizationService{
+		AuthorizationService: s,
+		Dispatcher:           d,
+	}
+}
+
+// UpdateAuthorization updates the authorization and publishes
+// EventAuthorizationRevoked when it makes an active authorization inactive.
+func (s *AuthorizationService) UpdateAuthorization(ctx context.Context, id platform.ID, upd *influxdb.AuthorizationUpdate) (*influxdb.Authorization, error) {
+	prev, err := s.AuthorizationService.FindAuthorizationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	a, err := s.AuthorizationService.UpdateAuthorization(ctx, id, upd)
+	if err != nil {
+		return nil, err
+	}
+
+	if prev.IsActive() && !a.IsActive() {
+		s.Dispatcher.Publish(authorizationRevokedEvent(a, "inactive"))
+	}
+	return a, nil
+}
+
+// DeleteAuthorization deletes the authorization and publishes
+// EventAuthorizationRevoked.
+func (s *AuthorizationService) DeleteAuthorization(ctx context.Context, id platform.ID) error {
+	a, err := s.AuthorizationService.FindAuthorizationByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.AuthorizationService.DeleteAuthorization(ctx, id); err != nil {
+		return err
+	}
+
+	s.Dispatcher.Publish(authorizationRevokedEvent(a, "deleted"))
+	return nil
+}
+
+// authorizationRevokedEvent returns the event of a revoked authorization,
+// which never carries its token.
+func authorizationRevokedEvent(a *influxdb.Authorization, reason string) Event {
+	return Event{
+		Type:       EventAuthorizationRevoked,
+		OrgID:      a.OrgID.String(),
+		ResourceID: a.ID.String(),
+		Data: map[string]interface{}{
+			"userID":      a.UserID.String(),
+			"description": a.Description,
+			"reason":      reason,
+		},
+	}
+}
+
+// TaskControlService wraps the task control service of the executor and
+// publishes the events of the task runs finishing as failed.
+type TaskControlService struct {
+	backend.TaskControlService
+	Tasks      taskmodel.TaskService
+	Dispatcher *Dispatcher
+}
+
+// NewTaskControlService constructs a TaskControlService publishing to d.
+// The tasks of failed runs are found with ts.
+func NewTaskControlService(tcs backend.TaskControlService, ts taskmodel.TaskService, d *Dispatcher) *TaskControlService {
+	return &TaskControlService{
+		TaskControlService: tcs,
+		Tasks:              ts,
+		Dispatcher:         d,
+	}
+}
+
+// FinishRun finishes the run and publishes EventTaskRunFailed when it failed.
+func (s *TaskControlService) FinishRun(ctx context.Context, taskID, runID platform.ID) (*taskmodel.Run, error) {
+	run, err := s.TaskControlService.FinishRun(ctx, taskID, runID)
+	if err != nil || run == nil || run.Status != taskmodel.RunFail.String() {
+		return run, err
+	}
+
+	data := map[string]interface{}{
+		"taskID":       taskID.String(),
+		"scheduledFor": run.ScheduledFor,
+		"startedAt":    run.StartedAt,
+		"finishedAt":   run.FinishedAt,
+	}
+	if n := len(run.Log); n > 0 {
+		data["lastLog"] = run.Log[n-1].Message
+	}
+	e := Event{
+		Type:       EventTaskRunFailed,
+		ResourceID: runID.String(),
+		Data:       data,
+	}
+	// the run is finished whether or not its task is still found
+	if task, err := s.Tasks.FindTaskByID(ctx, taskID); err == nil {
+		e.OrgID = task.OrganizationID.String()
+		data["taskName"] = task.Name
+	}
+	s.Dispatcher.Publish(e)
+	return run, nil
+}
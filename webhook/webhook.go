@@ -0,0 +1,224 @@
+// Package webhook delivers the lifecycle events of resources, such as a
+// bucket being created or a task run failing, to the HTTP endpoints
+// operators configure, so external automation can react to them.
+//
+// An event is posted as JSON to every endpoint whose filter matches its
+// type. With a secret, the request carries the HMAC-SHA256 of its timestamp
+// and body, see Sign, so an endpoint can tell the server sent it. Failed
+// deliveries are retried with a backoff, and the outcome of every delivery
+// is kept in a delivery log.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EventType is the type of an event.
+type EventType string
+
+const (
+	// EventBucketCreated is the type of the event of a bucket being created.
+	EventBucketCreated EventType = "bucket.created"
+	// EventBucketDeleted is the type of the event of a bucket being deleted.
+	EventBucketDeleted EventType = "bucket.deleted"
+	// EventAuthorizationRevoked is the type of the event of a token being
+	// deleted or made inactive.
+	EventAuthorizationRevoked EventType = "authorization.revoked"
+	// EventTaskRunFailed is the type of the event of a task run failing.
+	EventTaskRunFailed EventType = "task.run.failed"
+)
+
+// EventTypes are the types of the events delivered.
+var EventTypes = []EventType{
+	EventBucketCreated,
+	EventBucketDeleted,
+	EventAuthorizationRevoked,
+	EventTaskRunFailed,
+}
+
+// Event is the JSON body posted to an endpoint.
+type Event struct {
+	ID         string                 `json:"id"`
+	Type       EventType              `json:"type"`
+	Time       time.Time              `json:"time"`
+	OrgID      string                 `json:"orgID,omitempty"`
+	ResourceID string                 `json:"resourceID"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+const (
+	// HeaderEventID is the header holding the ID of the delivered event.
+	HeaderEventID = "X-Influxdb-Webhook-Id"
+	// HeaderTimestamp is the header holding the unix time, in seconds, a
+	// delivery was attempted at.
+	HeaderTimestamp = "X-Influxdb-Webhook-Timestamp"
+	// HeaderSignature is the header holding the signature of a delivery,
+	// prefixed with sha256=.
+	HeaderSignature = "X-Influxdb-Webhook-Signature"
+)
+
+// Sign returns the hex encoded HMAC-SHA256, keyed with secret, of the
+// timestamp and the body of a delivery joined by a dot. Endpoints check it
+// against HeaderSignature, and should reject timestamps too far in the past
+// to guard against replays.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Endpoint is an HTTP endpoint events are delivered to.
+type Endpoint struct {
+	// Name identifies the endpoint in the delivery log and the metrics.
+	Name string `json:"name" yaml:"name"`
+	URL  string `json:"url" yaml:"url"`
+	// Secret signs the deliveries to the endpoint. Empty does not sign them.
+	Secret string `json:"secret" yaml:"secret"`
+	// Events are the types of the events delivered to the endpoint. A type
+	// ending in .* matches every type of its prefix, bucket.* matches the
+	// events of buckets. Empty delivers every event.
+	Events []string `json:"events" yaml:"events"`
+}
+
+// Matches reports whether events of type t are delivered to the endpoint.
+func (e Endpoint) Matches(t EventType) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, f := range e.Events {
+		if f == string(t) || f == "*" {
+			return true
+		}
+		if strings.HasSuffix(f, ".*") && strings.HasPrefix(string(t), strings.TrimSuffix(f, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e Endpoint) validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("webhook endpoint %s has no name", e.URL)
+	}
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url of webhook endpoint %s: %w", e.Name, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url of webhook endpoint %s must be http or https", e.Name)
+	}
+	for _, f := range e.Events {
+		if f == "*" || strings.HasSuffix(f, ".*") {
+			continue
+		}
+		if !knownEventType(EventType(f)) {
+			return fmt.Errorf("unknown event type %q of webhook endpoint %s", f, e.Name)
+		}
+	}
+	return nil
+}
+
+func knownEventType(t EventType) bool {
+	for _, et := range EventTypes {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Config holds the endpoints of the server.
+type Config struct {
+	Endpoints []Endpoint `json:"endpoints" yaml:"endpoints"`
+}
+
+// Load reads the webhook configuration from a JSON or YAML file. A file
+// that does not exist holds no endpoints.
+func Load(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(b, &c)
+	default:
+		err = json.Unmarshal(b, &c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode webhook config %s: %w", path, err)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Save writes the webhook configuration to a JSON or YAML file, replacing
+// it at once so a server stopping midway does not leave it truncated. The
+// file holds the secrets of the endpoints and is only readable by its owner.
+func (c *Config) Save(path string) error {
+	var (
+		b   []byte
+		err error
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		b, err = yaml.Marshal(c)
+	default:
+		b, err = json.MarshalIndent(c, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook config %s: %w", path, err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Validate checks the endpoints are well formed and named uniquely.
+func (c *Config) Validate() error {
+	names := make(map[string]bool, len(c.Endpoints))
+	for _, e := range c.Endpoints {
+		if err := e.validate(); err != nil {
+			return err
+		}
+		if names[e.Name] {
+			return fmt.Errorf("webhook endpoint %s is configured more than once", e.Name)
+		}
+		names[e.Name] = true
+	}
+	return nil
+}
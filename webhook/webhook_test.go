@@ -0,0 +1,284 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type received struct {
+	event     Event
+	signature string
+	timestamp string
+	body      []byte
+}
+
+// testEndpoint records the requests it receives and answers them with the
+// statuses it is given, then with 204.
+type testEndpoint struct {
+	mu       sync.Mutex
+	statuses []int
+	received []received
+	got      chan struct{}
+}
+
+func newTestEndpoint(t *testing.T, statuses ...int) (*testEndpoint, *httptest.Server) {
+	e := &testEndpoint{statuses: statuses, got: make(chan struct{}, 16)}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var event Event
+		assert.NoError(t, json.Unmarshal(body, &event))
+
+		e.mu.Lock()
+		e.received = append(e.received, received{
+			event:     event,
+			signature: r.Header.Get(HeaderSignature),
+			timestamp: r.Header.Get(HeaderTimestamp),
+			body:      body,
+		})
+		status := http.StatusNoContent
+		if len(e.statuses) > 0 {
+			status, e.statuses = e.statuses[0], e.statuses[1:]
+		}
+		e.mu.Unlock()
+
+		w.WriteHeader(status)
+		e.got <- struct{}{}
+	}))
+	t.Cleanup(s.Close)
+	return e, s
+}
+
+func (e *testEndpoint) wait(t *testing.T, n int) []received {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-e.got:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("endpoint received %d requests, want %d", i, n)
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]received(nil), e.received...)
+}
+
+// waitDelivery waits for the delivery of an event to an endpoint to finish.
+func waitDelivery(t *testing.T, d *Dispatcher, endpoint string) Delivery {
+	t.Helper()
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		deliveries := d.Deliveries(DeliveryFilter{Endpoint: endpoint, Limit: 1})
+		if len(deliveries) == 1 && deliveries[0].Status != DeliveryPending {
+			return deliveries[0]
+		}
+	}
+	t.Fatalf("delivery to %s did not finish", endpoint)
+	return Delivery{}
+}
+
+func TestEndpoint_Matches(t *testing.T) {
+	assert.True(t, Endpoint{}.Matches(EventBucketCreated))
+	assert.True(t, Endpoint{Events: []string{"bucket.*"}}.Matches(EventBucketDeleted))
+	assert.False(t, Endpoint{Events: []string{"bucket.*"}}.Matches(EventTaskRunFailed))
+	assert.True(t, Endpoint{Events: []string{"task.run.failed"}}.Matches(EventTaskRunFailed))
+	assert.False(t, Endpoint{Events: []string{"task.run.failed"}}.Matches(EventAuthorizationRevoked))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for _, c := range []Config{
+		{Endpoints: []Endpoint{{URL: "http://example.com"}}},
+		{Endpoints: []Endpoint{{Name: "a", URL: "ftp://example.com"}}},
+		{Endpoints: []Endpoint{{Name: "a", URL: "http://example.com", Events: []string{"bucket.renamed"}}}},
+		{Endpoints: []Endpoint{{Name: "a", URL: "http://example.com"}, {Name: "a", URL: "http://example.org"}}},
+	} {
+		assert.Error(t, c.Validate())
+	}
+	c := Config{Endpoints: []Endpoint{{Name: "a", URL: "https://example.com", Events: []string{"bucket.*", "task.run.failed"}}}}
+	assert.NoError(t, c.Validate())
+}
+
+func TestDispatcher_Publish(t *testing.T) {
+	buckets, bucketsSrv := newTestEndpoint(t)
+	tasks, tasksSrv := newTestEndpoint(t)
+
+	d := NewDispatcher(zaptest.NewLogger(t), []Endpoint{
+		{Name: "buckets", URL: bucketsSrv.URL, Secret: "s3cr3t", Events: []string{"bucket.*"}},
+		{Name: "tasks", URL: tasksSrv.URL, Events: []string{string(EventTaskRunFailed)}},
+	})
+	defer d.Close()
+
+	d.Publish(Event{Type: EventBucketCreated, OrgID: "020f755c3c082000", ResourceID: "020f755c3c082001"})
+	d.Publish(Event{Type: EventTaskRunFailed, ResourceID: "020f755c3c082002"})
+
+	got := buckets.wait(t, 1)
+	require.Len(t, got, 1)
+	assert.Equal(t, EventBucketCreated, got[0].event.Type)
+	assert.Equal(t, "020f755c3c082001", got[0].event.ResourceID)
+	assert.NotEmpty(t, got[0].event.ID)
+
+	ts, err := strconv.ParseInt(got[0].timestamp, 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256="+Sign("s3cr3t", time.Unix(ts, 0), got[0].body), got[0].signature)
+
+	got = tasks.wait(t, 1)
+	require.Len(t, got, 1)
+	assert.Equal(t, EventTaskRunFailed, got[0].event.Type)
+	assert.Empty(t, got[0].signature, "deliveries without a secret are not signed")
+
+	delivery := waitDelivery(t, d, "buckets")
+	assert.Equal(t, DeliveryDelivered, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, delivery.StatusCode)
+}
+
+func TestDispatcher_Retries(t *testing.T) {
+	retried, retriedSrv := newTestEndpoint(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	rejected, rejectedSrv := newTestEndpoint(t, http.StatusBadRequest)
+
+	d := NewDispatcher(zaptest.NewLogger(t), []Endpoint{
+		{Name: "retried", URL: retriedSrv.URL},
+		{Name: "rejected", URL: rejectedSrv.URL},
+	}, WithRetries(3, time.Millisecond))
+	defer d.Close()
+
+	d.Publish(Event{Type: EventBucketDeleted, ResourceID: "020f755c3c082001"})
+
+	got := retried.wait(t, 3)
+	for _, r := range got {
+		assert.Equal(t, got[0].event.ID, r.event.ID, "retries deliver the same event")
+	}
+	delivery := waitDelivery(t, d, "retried")
+	assert.Equal(t, DeliveryDelivered, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+
+	// a client error is not retried
+	rejected.wait(t, 1)
+	delivery = waitDelivery(t, d, "rejected")
+	assert.Equal(t, DeliveryFailed, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusBadRequest, delivery.StatusCode)
+	assert.NotEmpty(t, delivery.Error)
+
+	assert.Len(t, d.Deliveries(DeliveryFilter{Status: DeliveryFailed}), 1)
+}
+
+func TestBucketService(t *testing.T) {
+	endpoint, srv := newTestEndpoint(t)
+	d := NewDispatcher(zaptest.NewLogger(t), []Endpoint{{Name: "buckets", URL: srv.URL}})
+	defer d.Close()
+
+	bucket := &influxdb.Bucket{ID: platform.ID(2), OrgID: platform.ID(1), Name: "telegraf"}
+	bs := mock.NewBucketService()
+	bs.FindBucketByIDFn = func(context.Context, platform.ID) (*influxdb.Bucket, error) {
+		return bucket, nil
+	}
+	s := NewBucketService(bs, d)
+
+	require.NoError(t, s.CreateBucket(context.Background(), bucket))
+	require.NoError(t, s.DeleteBucket(context.Background(), bucket.ID))
+
+	got := endpoint.wait(t, 2)
+	require.Len(t, got, 2)
+	assert.Equal(t, EventBucketCreated, got[0].event.Type)
+	assert.Equal(t, EventBucketDeleted, got[1].event.Type)
+	for _, r := range got {
+		assert.Equal(t, bucket.OrgID.String(), r.event.OrgID)
+		assert.Equal(t, bucket.ID.String(), r.event.ResourceID)
+		assert.Equal(t, "telegraf", r.event.Data["name"])
+	}
+}
+
+func TestDispatcher_CreateDeleteEndpoint(t *testing.T) {
+	endpoint, srv := newTestEndpoint(t)
+	path := filepath.Join(t.TempDir(), "webhooks.yml")
+
+	d := NewDispatcher(zaptest.NewLogger(t), nil, WithConfigPath(path))
+	defer d.Close()
+
+	e := Endpoint{Name: "buckets", URL: srv.URL, Secret: "s3cr3t", Events: []string{"bucket.*"}}
+	require.NoError(t, d.CreateEndpoint(e))
+	assert.Equal(t, errors.EConflict, errors.ErrorCode(d.CreateEndpoint(e)))
+	assert.Equal(t, errors.EInvalid, errors.ErrorCode(d.CreateEndpoint(Endpoint{Name: "ftp", URL: "ftp://example.com"})))
+
+	d.Publish(Event{Type: EventBucketCreated, ResourceID: "020f755c3c082001"})
+	endpoint.wait(t, 1)
+
+	// the endpoints are kept across restarts
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Endpoint{e}, c.Endpoints)
+
+	require.NoError(t, d.DeleteEndpoint("buckets"))
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(d.DeleteEndpoint("buckets")))
+	assert.Empty(t, d.Endpoints())
+
+	c, err = Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, c.Endpoints)
+}
+
+func TestHandler(t *testing.T) {
+	_, srv := newTestEndpoint(t)
+	d := NewDispatcher(zaptest.NewLogger(t), nil)
+	defer d.Close()
+	h := NewHTTPHandler(zaptest.NewLogger(t), d)
+
+	do := func(auth influxdb.Authorizer, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if auth != nil {
+			r = r.WithContext(icontext.SetAuthorizer(context.Background(), auth))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	operator := &influxdb.Authorization{Status: influxdb.Active, Permissions: influxdb.OperPermissions()}
+	member := &influxdb.Authorization{OrgID: 1, Status: influxdb.Active}
+	body := `{"name": "buckets", "url": "` + srv.URL + `", "secret": "s3cr3t", "events": ["bucket.*"]}`
+
+	for _, auth := range []influxdb.Authorizer{nil, member} {
+		assert.Equal(t, http.StatusUnauthorized, do(auth, http.MethodPost, "/", body).Code)
+		assert.Equal(t, http.StatusUnauthorized, do(auth, http.MethodGet, "/", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, do(auth, http.MethodDelete, "/buckets", "").Code)
+	}
+	assert.Empty(t, d.Endpoints())
+
+	w := do(operator, http.MethodPost, "/", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "s3cr3t")
+	var created endpointResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.Equal(t, endpointResponse{Name: "buckets", URL: srv.URL, Signed: true, Events: []string{"bucket.*"}}, created)
+
+	assert.Equal(t, http.StatusConflict, do(operator, http.MethodPost, "/", body).Code)
+	assert.Equal(t, http.StatusBadRequest, do(operator, http.MethodPost, "/", `{"name": "ftp", "url": "ftp://example.com"}`).Code)
+
+	w = do(operator, http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "s3cr3t")
+	var listed endpointsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&listed))
+	assert.Equal(t, []endpointResponse{created}, listed.Endpoints)
+
+	assert.Equal(t, http.StatusNoContent, do(operator, http.MethodDelete, "/buckets", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(operator, http.MethodDelete, "/buckets", "").Code)
+	assert.Empty(t, d.Endpoints())
+}
@@ -0,0 +1,107 @@
+package writebuffer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixWriteBuffer = "/api/v2/write-buffer"
+
+// Handler serves the state of the write buffer to operators and lets them
+// drain it.
+type Handler struct {
+	chi.Router
+
+	log    *zap.Logger
+	api    *kithttp.API
+	writer *PointsWriter
+}
+
+// NewHTTPHandler constructs a handler reporting and draining the queue of
+// writer.
+func NewHTTPHandler(log *zap.Logger, writer *PointsWriter) *Handler {
+	h := &Handler{
+		log:    log,
+		api:    kithttp.NewAPI(kithttp.WithLog(log)),
+		writer: writer,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+		h.mwAuthorize,
+	)
+
+	r.Get("/", h.handleGetStatus)
+	r.Post("/drain", h.handlePostDrain)
+	h.Router = r
+	return h
+}
+
+// Prefix is the route the handler is mounted at.
+func (h *Handler) Prefix() string {
+	return prefixWriteBuffer
+}
+
+// handleGetStatus is the HTTP handler for the GET /api/v2/write-buffer route.
+func (h *Handler) handleGetStatus(w http.ResponseWriter, r *http.Request) {
+	h.api.Respond(w, r, http.StatusOK, h.writer.Status())
+}
+
+// handlePostDrain is the HTTP handler for the POST /api/v2/write-buffer/drain route.
+// It replays the queued writes and waits for the queue to be empty, for at
+// most the duration of the timeout parameter when given.
+func (h *Handler) handlePostDrain(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if v := r.URL.Query().Get("timeout"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout <= 0 {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid timeout %q", v),
+			})
+			return
+		}
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	h.log.Info("Draining write buffer", zap.Int64("bytes", h.writer.Status().Bytes))
+	if err := h.writer.Drain(ctx); err != nil {
+		status := h.writer.Status()
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EUnavailable,
+			Msg:  fmt.Sprintf("write buffer still holds %d bytes: %s", status.Bytes, status.LastError),
+			Err:  err,
+		})
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, h.writer.Status())
+}
+
+func (h *Handler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("access to %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
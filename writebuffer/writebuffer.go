@@ -0,0 +1,438 @@
+// Package writebuffer absorbs the writes the storage engine fails for a
+// short while, such as when its cache is full behind a stalled compaction,
+// into a queue on disk and replays them once the engine accepts writes
+// again, instead of failing the writes of every client.
+package writebuffer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/durablequeue"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	// headerSize is the size of the organization and bucket IDs a block
+	// starts with.
+	headerSize = 2 * 8
+	// maxBlockSize bounds the line protocol of a block, a write is split
+	// over several blocks so a block always fits in a segment.
+	maxBlockSize = 1 << 20
+	// blockOverhead is the size of the length a block is stored with.
+	blockOverhead = 8
+
+	minRetryInterval = time.Second
+	maxRetryInterval = 30 * time.Second
+)
+
+// IsTransient reports whether the storage engine failed a write for a
+// condition expected to clear by itself, so the write is worth buffering.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, partial := err.(tsdb.PartialWriteError); partial {
+		return false
+	}
+	return storage.IsUnavailable(err) ||
+		errors.Is(err, tsm1.ErrSnapshotInProgress) ||
+		errors.Is(err, tsdb.ErrShardDisabled) ||
+		errors.Is(err, syscall.ENOSPC) ||
+		// the cache error has no sentinel, it is formatted with the sizes
+		strings.Contains(err.Error(), "cache-max-memory-size exceeded")
+}
+
+// Status is the state of the buffer.
+type Status struct {
+	// Bytes is the size of the writes waiting to be replayed.
+	Bytes int64 `json:"bytes"`
+	// Empty reports whether no writes are waiting to be replayed.
+	Empty bool `json:"empty"`
+	// LastError is the error of the last replay that failed, empty once a
+	// replay succeeds.
+	LastError string `json:"lastError,omitempty"`
+}
+
+// PointsWriter writes points to the storage engine, and queues the writes
+// the engine fails with a transient error, see IsTransient, to replay them
+// in order in the background. A write that is queued succeeds for its
+// client. Until the queue is empty again, the following writes are queued
+// behind it rather than written to the engine, so replayed points never
+// overwrite newer points with the same series and time.
+type PointsWriter struct {
+	log        *zap.Logger
+	underlying storage.PointsWriter
+	queue      *durablequeue.Queue
+	queueSize  *durablequeue.SharedCount
+	maxSize    int64
+	notify     chan struct{}
+
+	// enqueueMu makes the blocks of a write fit in the queue together.
+	enqueueMu sync.Mutex
+
+	mu      sync.Mutex
+	lastErr error
+	drained chan struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	bytes    prometheus.GaugeFunc
+	queued   prometheus.Counter
+	replayed prometheus.Counter
+	dropped  prometheus.Counter
+}
+
+// Open opens the queue kept in dir, holding up to maxSize bytes, and starts
+// replaying the writes it holds to underlying.
+func Open(log *zap.Logger, underlying storage.PointsWriter, dir string, maxSize int64) (*PointsWriter, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	queueSize := &durablequeue.SharedCount{}
+	queue, err := durablequeue.NewQueue(
+		dir,
+		maxSize,
+		durablequeue.DefaultSegmentSize,
+		queueSize,
+		durablequeue.MaxWritesPending,
+		func(b []byte) error {
+			if len(b) < headerSize {
+				return fmt.Errorf("write buffer block of %d bytes is too short", len(b))
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	queue.WithLogger(log)
+	if err := queue.Open(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &PointsWriter{
+		log:        log,
+		underlying: underlying,
+		queue:      queue,
+		queueSize:  queueSize,
+		maxSize:    maxSize,
+		notify:     make(chan struct{}, 1),
+		drained:    make(chan struct{}),
+		cancel:     cancel,
+		queued: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storage",
+			Subsystem: "write_buffer",
+			Name:      "queued_points_total",
+			Help:      "Number of points queued because the storage engine was unavailable",
+		}),
+		replayed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storage",
+			Subsystem: "write_buffer",
+			Name:      "replayed_points_total",
+			Help:      "Number of queued points written to the storage engine",
+		}),
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storage",
+			Subsystem: "write_buffer",
+			Name:      "dropped_points_total",
+			Help:      "Number of queued points the storage engine rejected when replayed",
+		}),
+	}
+	w.bytes = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "storage",
+		Subsystem: "write_buffer",
+		Name:      "queue_bytes",
+		Help:      "Size of the writes waiting to be replayed",
+	}, func() float64 {
+		return float64(w.queue.TotalBytes())
+	})
+	if !queue.Empty() {
+		w.notify <- struct{}{}
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.run(ctx)
+	}()
+	return w, nil
+}
+
+// PrometheusCollectors returns the metrics of the buffer.
+func (w *PointsWriter) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{w.bytes, w.queued, w.replayed, w.dropped}
+}
+
+// WritePoints writes points to the storage engine, or queues them when the
+// engine fails the write with a transient error or earlier writes are still
+// queued. The error of the engine is returned when the write does not fit
+// in the queue.
+func (w *PointsWriter) WritePoints(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	if !w.queue.Empty() {
+		if err := w.enqueue(orgID, bucketID, points); err != nil {
+			if _, partial := err.(tsdb.PartialWriteError); partial {
+				return err
+			}
+			return &errors2.Error{
+				Code: errors2.EUnavailable,
+				Msg:  "storage engine is replaying queued writes and the write buffer cannot hold this write",
+				Err:  err,
+			}
+		}
+		return nil
+	}
+
+	err := w.underlying.WritePoints(ctx, orgID, bucketID, points)
+	if !IsTransient(err) {
+		return err
+	}
+
+	if qerr := w.enqueue(orgID, bucketID, points); qerr != nil {
+		w.log.Warn("Failed to queue write the storage engine failed",
+			zap.Stringer("org_id", orgID),
+			zap.Stringer("bucket_id", bucketID),
+			zap.NamedError("write_error", err),
+			zap.Error(qerr))
+		if _, partial := qerr.(tsdb.PartialWriteError); partial {
+			return qerr
+		}
+		return err
+	}
+	w.log.Debug("Queued write the storage engine failed",
+		zap.Stringer("org_id", orgID),
+		zap.Stringer("bucket_id", bucketID),
+		zap.Int("points", len(points)),
+		zap.Error(err))
+	return nil
+}
+
+// enqueue queues the blocks of a write. A write that does not fit in the
+// queue is not queued at all. A write the queue fails to store the rest of
+// once its first blocks are queued fails with a PartialWriteError counting
+// the points that were not queued: the queued blocks are replayed, so the
+// write must not be retried whole.
+func (w *PointsWriter) enqueue(orgID, bucketID platform.ID, points []models.Point) error {
+	blocks := encodeBlocks(orgID, bucketID, points)
+	var size int64
+	for _, b := range blocks {
+		size += int64(len(b)) + blockOverhead
+	}
+
+	w.enqueueMu.Lock()
+	defer w.enqueueMu.Unlock()
+	if w.queueSize.Value()+size > w.maxSize {
+		return durablequeue.ErrQueueFull
+	}
+	for i, b := range blocks {
+		if err := w.queue.Append(b); err != nil {
+			if i == 0 {
+				return err
+			}
+			var dropped int
+			for _, b := range blocks[i:] {
+				dropped += bytes.Count(b[headerSize:], []byte{'\n'})
+			}
+			w.queued.Add(float64(len(points) - dropped))
+			w.notifyReplay()
+			return tsdb.PartialWriteError{
+				Reason:  fmt.Sprintf("queued %d of %d blocks of the write: %v", i, len(blocks), err),
+				Dropped: dropped,
+			}
+		}
+	}
+	w.queued.Add(float64(len(points)))
+	w.notifyReplay()
+	return nil
+}
+
+func (w *PointsWriter) notifyReplay() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// encodeBlocks encodes points as blocks of line protocol of at most
+// maxBlockSize bytes, headed by the organization and bucket IDs.
+func encodeBlocks(orgID, bucketID platform.ID, points []models.Point) [][]byte {
+	var blocks [][]byte
+	var b []byte
+	for _, p := range points {
+		if b != nil && len(b) > headerSize && len(b)+p.StringSize() >= headerSize+maxBlockSize {
+			blocks = append(blocks, b)
+			b = nil
+		}
+		if b == nil {
+			b = make([]byte, headerSize)
+			binary.BigEndian.PutUint64(b, uint64(orgID))
+			binary.BigEndian.PutUint64(b[headerSize/2:], uint64(bucketID))
+		}
+		b = p.AppendString(b)
+		b = append(b, '\n')
+	}
+	if b != nil {
+		blocks = append(blocks, b)
+	}
+	return blocks
+}
+
+func decodeBlock(b []byte) (orgID, bucketID platform.ID, points []models.Point, err error) {
+	if len(b) < headerSize {
+		return 0, 0, nil, fmt.Errorf("write buffer block of %d bytes is too short", len(b))
+	}
+	orgID = platform.ID(binary.BigEndian.Uint64(b))
+	bucketID = platform.ID(binary.BigEndian.Uint64(b[headerSize/2:]))
+	points, err = models.ParsePoints(b[headerSize:])
+	return orgID, bucketID, points, err
+}
+
+// Status returns the state of the buffer.
+func (w *PointsWriter) Status() Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	s := Status{
+		Bytes: w.queue.TotalBytes(),
+		Empty: w.queue.Empty(),
+	}
+	if w.lastErr != nil {
+		s.LastError = w.lastErr.Error()
+	}
+	return s
+}
+
+// Drain replays the queued writes right away, without waiting for the
+// interval between failed replays, and waits for the queue to be empty.
+func (w *PointsWriter) Drain(ctx context.Context) error {
+	w.mu.Lock()
+	drained := w.drained
+	w.mu.Unlock()
+	if w.queue.Empty() {
+		return nil
+	}
+
+	w.notifyReplay()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops replaying writes and closes the queue. The writes left in the
+// queue are replayed once it is opened again.
+func (w *PointsWriter) Close() error {
+	w.cancel()
+	w.wg.Wait()
+	return w.queue.Close()
+}
+
+func (w *PointsWriter) run(ctx context.Context) {
+	interval := minRetryInterval
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.notify:
+		case <-timer.C:
+		}
+
+		err := w.replay(ctx)
+		if err != nil && ctx.Err() == nil {
+			w.log.Info("Storage engine is still unavailable, retrying queued writes later",
+				zap.Duration("interval", interval),
+				zap.Error(err))
+		}
+		if err == nil {
+			interval = minRetryInterval
+		} else if interval *= 2; interval > maxRetryInterval {
+			interval = maxRetryInterval
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(interval)
+	}
+}
+
+// replay writes the queued blocks in order until the queue is empty or the
+// storage engine fails a write with a transient error. Blocks the engine
+// rejects otherwise, such as the writes of a deleted bucket, are dropped.
+func (w *PointsWriter) replay(ctx context.Context) error {
+	for ctx.Err() == nil {
+		b, err := w.queue.Current()
+		if err == io.EOF {
+			w.setDrained(nil)
+			return nil
+		} else if err != nil {
+			w.setErr(err)
+			return err
+		}
+
+		orgID, bucketID, points, err := decodeBlock(b)
+		if err == nil {
+			err = w.underlying.WritePoints(ctx, orgID, bucketID, points)
+		}
+		if IsTransient(err) {
+			w.setErr(err)
+			return err
+		}
+		if err != nil {
+			w.dropped.Add(float64(len(points)))
+			w.log.Warn("Dropped queued write the storage engine rejected",
+				zap.Stringer("org_id", orgID),
+				zap.Stringer("bucket_id", bucketID),
+				zap.Int("points", len(points)),
+				zap.Error(err))
+		} else {
+			w.replayed.Add(float64(len(points)))
+		}
+
+		if err := w.queue.Advance(); err != nil {
+			w.setErr(err)
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (w *PointsWriter) setErr(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastErr = err
+}
+
+// setDrained wakes up the callers of Drain waiting for the queue to empty.
+func (w *PointsWriter) setDrained(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastErr = err
+	close(w.drained)
+	w.drained = make(chan struct{})
+}
@@ -0,0 +1,190 @@
+package writebuffer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/durablequeue"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type write struct {
+	orgID, bucketID platform.ID
+	points          []string
+}
+
+// testEngine fails writes with err while it is set.
+type testEngine struct {
+	mu     sync.Mutex
+	err    error
+	writes []write
+}
+
+func (e *testEngine) WritePoints(_ context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return e.err
+	}
+	w := write{orgID: orgID, bucketID: bucketID}
+	for _, p := range points {
+		w.points = append(w.points, p.String())
+	}
+	e.writes = append(e.writes, w)
+	return nil
+}
+
+func (e *testEngine) setErr(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.err = err
+}
+
+func (e *testEngine) Writes() []write {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]write(nil), e.writes...)
+}
+
+func mustParse(t *testing.T, lp string) []models.Point {
+	t.Helper()
+	points, err := models.ParsePointsString(lp)
+	require.NoError(t, err)
+	return points
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(tsm1.ErrSnapshotInProgress))
+	assert.True(t, IsTransient(tsm1.ErrCacheMemorySizeLimitExceeded(2, 1)))
+	assert.True(t, IsTransient(tsdb.ErrShardDisabled))
+	assert.True(t, IsTransient(&errors2.Error{Code: errors2.EUnavailable, Err: storage.ErrStorageUnavailable}))
+	assert.False(t, IsTransient(nil))
+	assert.False(t, IsTransient(errors.New("bucket not found")))
+	assert.False(t, IsTransient(tsdb.PartialWriteError{Reason: "field type conflict", Dropped: 1}))
+}
+
+func TestPointsWriter(t *testing.T) {
+	dir := t.TempDir()
+	engine := &testEngine{}
+	ctx := context.Background()
+
+	w, err := Open(zaptest.NewLogger(t), engine, dir, 32<<20)
+	require.NoError(t, err)
+
+	require.NoError(t, w.WritePoints(ctx, 1, 2, mustParse(t, "m,t=a f=1 1")))
+	require.Len(t, engine.Writes(), 1)
+
+	// other errors are returned
+	notFound := errors.New("bucket not found")
+	engine.setErr(notFound)
+	assert.Equal(t, notFound, w.WritePoints(ctx, 1, 2, mustParse(t, "m,t=e f=5 5")))
+
+	// writes failed by the engine for a while are queued
+	engine.setErr(tsm1.ErrCacheMemorySizeLimitExceeded(2, 1))
+	require.NoError(t, w.WritePoints(ctx, 1, 2, mustParse(t, "m,t=b f=2 2\nm,t=c f=3 3")))
+	require.NoError(t, w.WritePoints(ctx, 1, 3, mustParse(t, "m,t=d f=4 4")))
+	assert.False(t, w.Status().Empty)
+
+	// the queue is kept when the buffer is closed
+	require.NoError(t, w.Close())
+	engine.setErr(nil)
+	w, err = Open(zaptest.NewLogger(t), engine, dir, 32<<20)
+	require.NoError(t, err)
+	defer w.Close()
+
+	drainCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	require.NoError(t, w.Drain(drainCtx))
+	assert.True(t, w.Status().Empty)
+	assert.Empty(t, w.Status().LastError)
+
+	assert.Equal(t, []write{
+		{orgID: 1, bucketID: 2, points: []string{"m,t=a f=1 1"}},
+		{orgID: 1, bucketID: 2, points: []string{"m,t=b f=2 2", "m,t=c f=3 3"}},
+		{orgID: 1, bucketID: 3, points: []string{"m,t=d f=4 4"}},
+	}, engine.Writes())
+}
+
+func TestEncodeBlocks(t *testing.T) {
+	var points []models.Point
+	for i := 0; i < 3; i++ {
+		p, err := models.NewPoint("m", nil, models.Fields{"f": strings.Repeat("x", maxBlockSize/2)}, time.Unix(0, int64(i)))
+		require.NoError(t, err)
+		points = append(points, p)
+	}
+
+	// a block never holds more than maxBlockSize of line protocol
+	blocks := encodeBlocks(1, 2, points)
+	require.Len(t, blocks, 3)
+	var n int
+	for _, b := range blocks {
+		orgID, bucketID, decoded, err := decodeBlock(b)
+		require.NoError(t, err)
+		assert.Equal(t, platform.ID(1), orgID)
+		assert.Equal(t, platform.ID(2), bucketID)
+		n += len(decoded)
+	}
+	assert.Equal(t, 3, n)
+}
+
+func TestPointsWriter_QueuesBehindReplay(t *testing.T) {
+	engine := &testEngine{}
+	ctx := context.Background()
+
+	w, err := Open(zaptest.NewLogger(t), engine, t.TempDir(), 32<<20)
+	require.NoError(t, err)
+	defer w.Close()
+
+	engine.setErr(tsm1.ErrSnapshotInProgress)
+	require.NoError(t, w.WritePoints(ctx, 1, 2, mustParse(t, "m,t=a f=1 1")))
+
+	// the engine recovered, but the newer value is queued behind the old one
+	engine.setErr(nil)
+	require.NoError(t, w.WritePoints(ctx, 1, 2, mustParse(t, "m,t=a f=2 1")))
+
+	drainCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	require.NoError(t, w.Drain(drainCtx))
+
+	assert.Equal(t, []write{
+		{orgID: 1, bucketID: 2, points: []string{"m,t=a f=1 1"}},
+		{orgID: 1, bucketID: 2, points: []string{"m,t=a f=2 1"}},
+	}, engine.Writes())
+
+	// once the queue is empty, writes go to the engine again
+	require.NoError(t, w.WritePoints(ctx, 1, 2, mustParse(t, "m,t=a f=3 1")))
+	assert.Len(t, engine.Writes(), 3)
+}
+
+func TestPointsWriter_QueueFull(t *testing.T) {
+	engine := &testEngine{}
+	engine.setErr(tsm1.ErrSnapshotInProgress)
+
+	maxSize := 2 * int64(durablequeue.DefaultSegmentSize)
+	w, err := Open(zaptest.NewLogger(t), engine, t.TempDir(), maxSize)
+	require.NoError(t, err)
+	defer w.Close()
+
+	// a write larger than the queue is not queued at all, rather than
+	// queued in part and then retried whole by its client
+	var points []models.Point
+	for i := int64(0); i <= maxSize/maxBlockSize; i++ {
+		p, err := models.NewPoint("m", nil, models.Fields{"f": strings.Repeat("x", maxBlockSize-100)}, time.Unix(0, i))
+		require.NoError(t, err)
+		points = append(points, p)
+	}
+	assert.Equal(t, tsm1.ErrSnapshotInProgress, w.WritePoints(context.Background(), 1, 2, points))
+	assert.True(t, w.Status().Empty)
+}